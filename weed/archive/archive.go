@@ -0,0 +1,79 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// An archive is one sequential tar stream, suitable for the tape libraries and the archival blob stores,
+// which only append and read from the beginning. The volume files are archived as "volumes/<base name><ext>",
+// and the filer files as "files/<full path>". The catalog of all members is the last member "CATALOG.json",
+// and is usually also saved separately, to find what is in the archive without reading it through.
+// The stream is written in fixed size blocks, padded with zeros at the end, and can be split into segments,
+// e.g., one segment for each tape cartridge.
+
+const (
+	CatalogName  = "CATALOG.json"
+	VolumePrefix = "volumes/"
+	FilePrefix   = "files"
+
+	KindVolume = "volume"
+	KindFile   = "file"
+
+	DefaultBlockSize = 256 * 1024
+)
+
+// CatalogEntry describes one member of the archive
+type CatalogEntry struct {
+	Name       string    `json:"name"`
+	Kind       string    `json:"kind"`
+	Offset     int64     `json:"offset"`
+	Size       int64     `json:"size"`
+	Md5        string    `json:"md5"`
+	Mode       int64     `json:"mode,omitempty"`
+	ModTime    time.Time `json:"modTime"`
+	Mime       string    `json:"mime,omitempty"`
+	VolumeId   uint32    `json:"volumeId,omitempty"`
+	Collection string    `json:"collection,omitempty"`
+	Path       string    `json:"path,omitempty"`
+}
+
+// Catalog lists all members of the archive, in the order of the stream
+type Catalog struct {
+	Source    string          `json:"source"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Entries   []*CatalogEntry `json:"entries"`
+}
+
+func (c *Catalog) TotalSize() (total int64) {
+	for _, e := range c.Entries {
+		total += e.Size
+	}
+	return
+}
+
+func (c *Catalog) Marshal() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+func UnmarshalCatalog(data []byte) (*Catalog, error) {
+	c := &Catalog{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parse catalog: %v", err)
+	}
+	return c, nil
+}
+
+// VolumeMemberName is the member name of a volume file, with the ext as ".dat" or ".idx"
+func VolumeMemberName(collection string, volumeId uint32, ext string) string {
+	if collection == "" {
+		return fmt.Sprintf("%s%d%s", VolumePrefix, volumeId, ext)
+	}
+	return fmt.Sprintf("%s%s_%d%s", VolumePrefix, collection, volumeId, ext)
+}
+
+// FileMemberName is the member name of a filer file
+func FileMemberName(fullPath string) string {
+	return FilePrefix + fullPath
+}
@@ -0,0 +1,101 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestArchive(t *testing.T, w io.Writer, blockSize int) *Catalog {
+	aw := NewWriter(w, blockSize, "test")
+	members := map[string]string{
+		VolumeMemberName("photos", 3, ".dat"): strings.Repeat("d", 3000),
+		FileMemberName("/buckets/b1/a.txt"):   "hello",
+	}
+	for _, name := range []string{VolumeMemberName("photos", 3, ".dat"), FileMemberName("/buckets/b1/a.txt")} {
+		content := members[name]
+		err := aw.Add(&CatalogEntry{Name: name, Size: int64(len(content)), ModTime: time.Unix(1700000000, 0)}, strings.NewReader(content))
+		assert.Nil(t, err)
+	}
+	catalog, err := aw.Close()
+	assert.Nil(t, err)
+	return catalog
+}
+
+func TestWriteAndVerify(t *testing.T) {
+	var buf bytes.Buffer
+	catalog := writeTestArchive(t, &buf, 1024)
+
+	assert.Equal(t, 0, buf.Len()%1024, "written in full blocks")
+	assert.Equal(t, 2, len(catalog.Entries))
+	assert.Equal(t, "volumes/photos_3.dat", catalog.Entries[0].Name)
+	assert.Equal(t, int64(0), catalog.Entries[0].Offset)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", catalog.Entries[1].Md5)
+
+	// the member content starts right after its header
+	second := catalog.Entries[1]
+	assert.Equal(t, 0, int(second.Offset%512))
+	assert.Equal(t, "hello", string(buf.Bytes()[second.Offset+512:second.Offset+512+5]))
+
+	problems, err := Verify(bytes.NewReader(buf.Bytes()), nil)
+	assert.Nil(t, err)
+	assert.Empty(t, problems)
+
+	// a corrupted member is reported
+	corrupted := bytes.Clone(buf.Bytes())
+	corrupted[second.Offset+512] = 'j'
+	problems, err = Verify(bytes.NewReader(corrupted), catalog)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(problems))
+	assert.True(t, strings.HasPrefix(problems[0], "files/buckets/b1/a.txt: md5 "))
+	assert.True(t, strings.HasSuffix(problems[0], "expected 5d41402abc4b2a76b9719d911017c592"))
+
+	// a truncated archive has no catalog
+	problems, err = Verify(bytes.NewReader(buf.Bytes()[:second.Offset]), catalog)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"no catalog at the end, the archive is incomplete", "files/buckets/b1/a.txt: missing"}, problems)
+}
+
+func TestWalk(t *testing.T) {
+	var buf bytes.Buffer
+	writeTestArchive(t, &buf, 0)
+
+	var names []string
+	entries, catalog, err := Walk(&buf, func(entry *CatalogEntry, content io.Reader) error {
+		names = append(names, entry.Name)
+		if strings.HasPrefix(entry.Name, FilePrefix) {
+			data, readErr := io.ReadAll(content)
+			assert.Equal(t, "hello", string(data))
+			return readErr
+		}
+		// the volume is skipped without reading
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"volumes/photos_3.dat", "files/buckets/b1/a.txt"}, names)
+	assert.Equal(t, 2, len(entries))
+	assert.Empty(t, Compare(catalog, entries))
+}
+
+func TestSegments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.tar")
+	w, err := Create(path, 2048)
+	assert.Nil(t, err)
+	catalog := writeTestArchive(t, w, 1024)
+	assert.Nil(t, w.Close())
+
+	matches, _ := filepath.Glob(path + ".*")
+	assert.Equal(t, 4, len(matches))
+
+	r, err := Open(path)
+	assert.Nil(t, err)
+	defer r.Close()
+	problems, err := Verify(r, catalog)
+	assert.Nil(t, err)
+	assert.Empty(t, problems)
+}
@@ -0,0 +1,103 @@
+package archive
+
+import (
+	"archive/tar"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Walk reads the archive sequentially, and calls fn, if not nil, with each member except the catalog.
+// Whatever fn does not read of the member is skipped, so the md5 of every member read is always computed.
+// It returns the members read, and the catalog at the end of the archive, nil if the archive is incomplete.
+func Walk(r io.Reader, fn func(entry *CatalogEntry, content io.Reader) error) (entries []*CatalogEntry, catalog *Catalog, err error) {
+	tr := tar.NewReader(r)
+	for {
+		hdr, nextErr := tr.Next()
+		if nextErr == io.EOF {
+			return entries, catalog, nil
+		}
+		if nextErr != nil {
+			return entries, catalog, nextErr
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Name == CatalogName {
+			data, readErr := io.ReadAll(tr)
+			if readErr != nil {
+				return entries, nil, fmt.Errorf("read catalog: %v", readErr)
+			}
+			if catalog, err = UnmarshalCatalog(data); err != nil {
+				return entries, nil, err
+			}
+			continue
+		}
+
+		entry := &CatalogEntry{
+			Name:    hdr.Name,
+			Size:    hdr.Size,
+			Mode:    hdr.Mode,
+			ModTime: hdr.ModTime,
+		}
+		hash := md5.New()
+		content := io.TeeReader(tr, hash)
+		if fn != nil {
+			if err = fn(entry, content); err != nil {
+				return entries, catalog, err
+			}
+		}
+		if _, err = io.Copy(io.Discard, content); err != nil {
+			return entries, catalog, fmt.Errorf("read %s: %v", hdr.Name, err)
+		}
+		entry.Md5 = hex.EncodeToString(hash.Sum(nil))
+		entries = append(entries, entry)
+	}
+}
+
+// Verify reads through the archive, and reports the members missing, changed or unexpected, compared to the catalog.
+// The catalog at the end of the archive is used if catalog is nil.
+func Verify(r io.Reader, catalog *Catalog) (problems []string, err error) {
+	entries, streamCatalog, err := Walk(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	if streamCatalog == nil {
+		problems = append(problems, "no catalog at the end, the archive is incomplete")
+	}
+	if catalog == nil {
+		catalog = streamCatalog
+	}
+	if catalog == nil {
+		return problems, nil
+	}
+	return append(problems, Compare(catalog, entries)...), nil
+}
+
+// Compare reports the differences between the catalog and the members read
+func Compare(catalog *Catalog, entries []*CatalogEntry) (problems []string) {
+	read := make(map[string]*CatalogEntry, len(entries))
+	for _, entry := range entries {
+		read[entry.Name] = entry
+	}
+	for _, expected := range catalog.Entries {
+		entry, found := read[expected.Name]
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: missing", expected.Name))
+			continue
+		}
+		delete(read, expected.Name)
+		if entry.Size != expected.Size {
+			problems = append(problems, fmt.Sprintf("%s: size %d, expected %d", expected.Name, entry.Size, expected.Size))
+		} else if entry.Md5 != expected.Md5 {
+			problems = append(problems, fmt.Sprintf("%s: md5 %s, expected %s", expected.Name, entry.Md5, expected.Md5))
+		}
+	}
+	for _, entry := range entries {
+		if _, found := read[entry.Name]; found {
+			problems = append(problems, fmt.Sprintf("%s: not in the catalog", entry.Name))
+		}
+	}
+	return
+}
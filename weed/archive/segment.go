@@ -0,0 +1,137 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// The archive can be written to a file, a tape device, or "-" for stdout, to be piped to an archival blob store.
+// With a segment size, the archive is split into the segments "<path>.000", "<path>.001", ...,
+// which are read back in order as one stream.
+
+func segmentPath(path string, index int) string {
+	return fmt.Sprintf("%s.%03d", path, index)
+}
+
+// Create opens the target to write the archive
+func Create(path string, segmentSize int64) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	if segmentSize <= 0 {
+		return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	}
+	return &segmentWriter{path: path, segmentSize: segmentSize}, nil
+}
+
+// Open opens the archive to read, either the file or the device, or its segments
+func Open(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	if _, err := os.Stat(path); err == nil || !os.IsNotExist(err) {
+		return os.Open(path)
+	}
+	if _, err := os.Stat(segmentPath(path, 0)); err != nil {
+		return nil, fmt.Errorf("open archive %s: %v", path, err)
+	}
+	return &segmentReader{path: path}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+type segmentWriter struct {
+	path        string
+	segmentSize int64
+	index       int
+	file        *os.File
+	written     int64
+}
+
+func (s *segmentWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if s.file == nil || s.written >= s.segmentSize {
+			if err = s.next(); err != nil {
+				return n, err
+			}
+		}
+		chunk := p
+		if remaining := s.segmentSize - s.written; int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+		written, writeErr := s.file.Write(chunk)
+		n += written
+		s.written += int64(written)
+		if writeErr != nil {
+			return n, writeErr
+		}
+		p = p[written:]
+	}
+	return n, nil
+}
+
+func (s *segmentWriter) next() error {
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return err
+		}
+		s.index++
+	}
+	file, err := os.OpenFile(segmentPath(s.path, s.index), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	s.file, s.written = file, 0
+	return nil
+}
+
+func (s *segmentWriter) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// segmentReader opens the segments one by one, till the next segment does not exist
+type segmentReader struct {
+	path  string
+	index int
+	file  *os.File
+}
+
+func (s *segmentReader) Read(p []byte) (n int, err error) {
+	for {
+		if s.file == nil {
+			file, openErr := os.Open(segmentPath(s.path, s.index))
+			if os.IsNotExist(openErr) && s.index > 0 {
+				return 0, io.EOF
+			}
+			if openErr != nil {
+				return 0, openErr
+			}
+			s.file = file
+		}
+		n, err = s.file.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		s.file.Close()
+		s.file = nil
+		s.index++
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (s *segmentReader) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
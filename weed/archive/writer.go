@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"archive/tar"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Writer writes the archive members sequentially, and the catalog at the end
+type Writer struct {
+	blocks  *blockWriter
+	counter *countingWriter
+	tw      *tar.Writer
+	catalog *Catalog
+}
+
+func NewWriter(w io.Writer, blockSize int, source string) *Writer {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	blocks := &blockWriter{w: w, buf: make([]byte, blockSize)}
+	counter := &countingWriter{w: blocks}
+	return &Writer{
+		blocks:  blocks,
+		counter: counter,
+		tw:      tar.NewWriter(counter),
+		catalog: &Catalog{
+			Source:    source,
+			CreatedAt: time.Now(),
+		},
+	}
+}
+
+// Add writes one member with entry.Size bytes from the reader, and fills its offset and md5
+func (w *Writer) Add(entry *CatalogEntry, r io.Reader) error {
+	// write the padding of the previous member, to start at the header
+	if err := w.tw.Flush(); err != nil {
+		return err
+	}
+	entry.Offset = w.counter.written
+	if err := w.tw.WriteHeader(toTarHeader(entry)); err != nil {
+		return fmt.Errorf("write header of %s: %v", entry.Name, err)
+	}
+	hash := md5.New()
+	n, err := io.Copy(io.MultiWriter(w.tw, hash), r)
+	if err != nil {
+		return fmt.Errorf("write %s: %v", entry.Name, err)
+	}
+	if n != entry.Size {
+		return fmt.Errorf("write %s: read %d bytes, expected %d", entry.Name, n, entry.Size)
+	}
+	entry.Md5 = hex.EncodeToString(hash.Sum(nil))
+	w.catalog.Entries = append(w.catalog.Entries, entry)
+	return nil
+}
+
+// Close writes the catalog and the end of the archive, and pads the last block.
+// The underlying writer is not closed.
+func (w *Writer) Close() (*Catalog, error) {
+	data, err := w.catalog.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if err = w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     CatalogName,
+		Size:     int64(len(data)),
+		Mode:     0644,
+		ModTime:  w.catalog.CreatedAt,
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return nil, fmt.Errorf("write catalog header: %v", err)
+	}
+	if _, err = w.tw.Write(data); err != nil {
+		return nil, fmt.Errorf("write catalog: %v", err)
+	}
+	if err = w.tw.Close(); err != nil {
+		return nil, err
+	}
+	if err = w.blocks.Close(); err != nil {
+		return nil, err
+	}
+	return w.catalog, nil
+}
+
+func toTarHeader(entry *CatalogEntry) *tar.Header {
+	mode := entry.Mode & 0777
+	if mode == 0 {
+		mode = 0644
+	}
+	return &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry.Name,
+		Size:     entry.Size,
+		Mode:     mode,
+		ModTime:  entry.ModTime,
+		Format:   tar.FormatPAX,
+	}
+}
+
+type countingWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (c *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = c.w.Write(p)
+	c.written += int64(n)
+	return
+}
+
+// blockWriter writes to the underlying writer in fixed size blocks, as the tape drives expect
+type blockWriter struct {
+	w   io.Writer
+	buf []byte
+	n   int
+}
+
+func (b *blockWriter) Write(p []byte) (written int, err error) {
+	for len(p) > 0 {
+		copied := copy(b.buf[b.n:], p)
+		b.n += copied
+		p = p[copied:]
+		written += copied
+		if b.n == len(b.buf) {
+			if err = b.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (b *blockWriter) flush() error {
+	if _, err := b.w.Write(b.buf); err != nil {
+		return err
+	}
+	b.n = 0
+	return nil
+}
+
+// Close pads the last block with zeros, which the tar readers skip after the end of the archive
+func (b *blockWriter) Close() error {
+	if b.n == 0 {
+		return nil
+	}
+	clear(b.buf[b.n:])
+	return b.flush()
+}
@@ -0,0 +1,205 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	mathrand "math/rand/v2"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	ServiceFiler = "filer"
+	ServiceS3    = "s3"
+	ServiceMq    = "mq"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// the fields which can be redacted
+const (
+	FieldIdentity   = "identity"
+	FieldRemoteAddr = "remote_addr"
+	FieldPath       = "path"
+)
+
+// Record is one audited operation, written as one JSON line
+type Record struct {
+	Time       time.Time `json:"time"`
+	Service    string    `json:"service"`
+	Identity   string    `json:"identity,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	Operation  string    `json:"operation"`
+	Path       string    `json:"path,omitempty"` // the file path, the bucket and the object key, or the topic
+	Result     string    `json:"result"`
+	Status     int       `json:"status,omitempty"` // the http status code
+	Error      string    `json:"error,omitempty"`
+	LatencyMs  float64   `json:"latency_ms"`
+	// the successful read only operations are sampled, while the others are always recorded
+	ReadOnly bool `json:"-"`
+}
+
+type Sink interface {
+	// GetName gets the name to locate the configuration in audit.toml file
+	GetName() string
+	// Initialize initializes the sink
+	Initialize(configuration util.Configuration, prefix string) error
+	// Write writes the JSON encoded record, without the trailing new line
+	Write(record *Record, line []byte) error
+}
+
+var (
+	Sinks []Sink
+
+	logger atomic.Pointer[Logger]
+)
+
+type Logger struct {
+	sinks      []Sink
+	sampleRate float64
+	redacted   map[string]bool
+	redactKey  []byte
+}
+
+// NewLogger writes the records to all the sinks. The successful read only operations are kept at the sample rate,
+// and the redacted fields are replaced by the keyed hashes, which still correlate the records of the same value.
+func NewLogger(sinks []Sink, sampleRate float64, redactedFields []string, redactKey []byte) *Logger {
+	l := &Logger{
+		sinks:      sinks,
+		sampleRate: sampleRate,
+		redacted:   make(map[string]bool),
+		redactKey:  redactKey,
+	}
+	for _, field := range redactedFields {
+		l.redacted[field] = true
+	}
+	if len(l.redactKey) == 0 {
+		l.redactKey = make([]byte, 32)
+		rand.Read(l.redactKey)
+	}
+	return l
+}
+
+// LoadConfiguration enables the sinks configured in audit.toml, all of which receive every record
+func LoadConfiguration(config *util.ViperProxy, prefix string) {
+	if config == nil {
+		return
+	}
+	var sinks []Sink
+	for _, sink := range Sinks {
+		if config.GetBool(prefix + sink.GetName() + ".enabled") {
+			if err := sink.Initialize(config, prefix+sink.GetName()+"."); err != nil {
+				glog.Fatalf("Failed to initialize audit log for %s: %+v", sink.GetName(), err)
+			}
+			sinks = append(sinks, sink)
+			glog.V(0).Infof("Configure audit log for %s", sink.GetName())
+		}
+	}
+	if len(sinks) == 0 {
+		return
+	}
+	config.SetDefault(prefix+"sample_rate", 1.0)
+	sampleRate := config.GetFloat64(prefix + "sample_rate")
+	if sampleRate < 0 || sampleRate > 1 {
+		glog.Fatalf("audit log sample_rate %v should be between 0 and 1", sampleRate)
+	}
+	SetLogger(NewLogger(sinks, sampleRate, config.GetStringSlice(prefix+"redact"), []byte(config.GetString(prefix+"redact_key"))))
+}
+
+// SetLogger replaces the audit logger, nil to disable the audit log
+func SetLogger(l *Logger) {
+	logger.Store(l)
+}
+
+func IsEnabled() bool {
+	return logger.Load() != nil
+}
+
+// Log writes the record to the audit logger, if enabled
+func Log(record *Record) {
+	if l := logger.Load(); l != nil {
+		l.Log(record)
+	}
+}
+
+func (l *Logger) Log(record *Record) {
+	if record.ReadOnly && record.Result == ResultSuccess && l.sampleRate < 1 && mathrand.Float64() >= l.sampleRate {
+		return
+	}
+	if l.redacted[FieldIdentity] {
+		record.Identity = l.redact(record.Identity)
+	}
+	if l.redacted[FieldRemoteAddr] {
+		record.RemoteAddr = l.redact(record.RemoteAddr)
+	}
+	if l.redacted[FieldPath] {
+		record.Path = l.redact(record.Path)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		glog.Errorf("encode audit record %+v: %v", record, err)
+		return
+	}
+	for _, sink := range l.sinks {
+		if err := sink.Write(record, line); err != nil {
+			glog.Errorf("write audit record to %s: %v", sink.GetName(), err)
+		}
+	}
+}
+
+func (l *Logger) redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	h := hmac.New(sha256.New, l.redactKey)
+	h.Write([]byte(value))
+	return "redacted:" + hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// LogHttpRequest audits the http request started at the time, which is read only for GET and HEAD
+func LogHttpRequest(service string, r *http.Request, identity, operation, path string, status int, start time.Time) {
+	l := logger.Load()
+	if l == nil {
+		return
+	}
+	record := &Record{
+		Time:       start,
+		Service:    service,
+		Identity:   identity,
+		RemoteAddr: httpRemoteAddr(r),
+		Operation:  operation,
+		Path:       path,
+		Result:     ResultSuccess,
+		Status:     status,
+		LatencyMs:  sinceMs(start),
+		ReadOnly:   r.Method == http.MethodGet || r.Method == http.MethodHead,
+	}
+	if status >= http.StatusBadRequest {
+		record.Result = ResultFailure
+		record.Error = http.StatusText(status)
+	}
+	l.Log(record)
+}
+
+func httpRemoteAddr(r *http.Request) string {
+	if realIp := r.Header.Get("X-Real-IP"); realIp != "" {
+		return realIp
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func sinceMs(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+type memorySink struct {
+	records []*Record
+}
+
+func (s *memorySink) GetName() string {
+	return "memory"
+}
+
+func (s *memorySink) Initialize(configuration util.Configuration, prefix string) error {
+	return nil
+}
+
+func (s *memorySink) Write(record *Record, line []byte) error {
+	decoded := &Record{}
+	if err := json.Unmarshal(line, decoded); err != nil {
+		return err
+	}
+	s.records = append(s.records, decoded)
+	return nil
+}
+
+func TestSampling(t *testing.T) {
+	sink := &memorySink{}
+	l := NewLogger([]Sink{sink}, 0, nil, nil)
+
+	l.Log(&Record{Operation: "GET", Result: ResultSuccess, ReadOnly: true})
+	l.Log(&Record{Operation: "GET", Result: ResultFailure, ReadOnly: true})
+	l.Log(&Record{Operation: "PUT", Result: ResultSuccess})
+	if len(sink.records) != 2 || sink.records[0].Result != ResultFailure || sink.records[1].Operation != "PUT" {
+		t.Fatalf("only the failures and the writes should be kept, got %+v", sink.records)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	sink := &memorySink{}
+	l := NewLogger([]Sink{sink}, 1, []string{FieldIdentity, FieldPath}, []byte("key"))
+
+	for i := 0; i < 2; i++ {
+		l.Log(&Record{Identity: "alice", RemoteAddr: "10.0.0.1", Operation: "PUT", Path: "/buckets/b/k", Result: ResultSuccess})
+	}
+	first, second := sink.records[0], sink.records[1]
+	if !strings.HasPrefix(first.Identity, "redacted:") || strings.Contains(first.Identity, "alice") {
+		t.Errorf("the identity should be redacted, got %s", first.Identity)
+	}
+	if first.Identity != second.Identity || first.Path != second.Path {
+		t.Errorf("the same values should be redacted the same")
+	}
+	if first.Identity == first.Path {
+		t.Errorf("different values should be redacted differently")
+	}
+	if first.RemoteAddr != "10.0.0.1" {
+		t.Errorf("the remote address should be kept, got %s", first.RemoteAddr)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+	sink := &FileSink{}
+	config := util.GetViper()
+	config.Set("audit_test.file.path", path)
+	if err := sink.Initialize(config, "audit_test.file."); err != nil {
+		t.Fatal(err)
+	}
+	SetLogger(NewLogger([]Sink{sink}, 1, nil, nil))
+	defer SetLogger(nil)
+
+	r := httptest.NewRequest("DELETE", "/dir/file.txt", nil)
+	r.RemoteAddr = "10.0.0.2:1234"
+	LogHttpRequest(ServiceFiler, r, "", "DELETE", r.URL.Path, 404, time.Now())
+	Log(&Record{Service: ServiceMq, Operation: "PublishMessage", Path: "ns.topic", Result: ResultSuccess})
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []*Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			t.Fatalf("decode %s: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expect 2 records, got %d", len(records))
+	}
+	if r := records[0]; r.Service != ServiceFiler || r.RemoteAddr != "10.0.0.2" || r.Path != "/dir/file.txt" || r.Result != ResultFailure || r.Status != 404 {
+		t.Errorf("unexpected http record %+v", r)
+	}
+	if r := records[1]; r.Service != ServiceMq || r.Path != "ns.topic" || r.Result != ResultSuccess {
+		t.Errorf("unexpected record %+v", r)
+	}
+}
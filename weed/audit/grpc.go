@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type grpcAuditor struct {
+	service    string
+	operations map[string]bool
+	pathOf     func(req interface{}) string
+}
+
+// GrpcServerOptions audits the gRPC methods in the operations, which map the method names to whether read only.
+// The path is from the request, or the first received message of the streams.
+// The audit log should be configured before, and nothing is audited if not enabled.
+func GrpcServerOptions(service string, operations map[string]bool, pathOf func(req interface{}) string) []grpc.ServerOption {
+	if !IsEnabled() {
+		return nil
+	}
+	a := &grpcAuditor{
+		service:    service,
+		operations: operations,
+		pathOf:     pathOf,
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.unaryInterceptor),
+		grpc.ChainStreamInterceptor(a.streamInterceptor),
+	}
+}
+
+func (a *grpcAuditor) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	operation := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+	readOnly, found := a.operations[operation]
+	if !found {
+		return handler(ctx, req)
+	}
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	record := a.newRecord(ctx, operation, a.pathOf(req), readOnly, start)
+	if err != nil {
+		record.Result, record.Error = ResultFailure, status.Convert(err).Message()
+	} else if errorResponse, ok := resp.(interface{ GetError() string }); ok && errorResponse.GetError() != "" {
+		// the filer responds some errors in the response
+		record.Result, record.Error = ResultFailure, errorResponse.GetError()
+	}
+	Log(record)
+	return resp, err
+}
+
+func (a *grpcAuditor) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	operation := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+	readOnly, found := a.operations[operation]
+	if !found {
+		return handler(srv, ss)
+	}
+	start := time.Now()
+	stream := &auditServerStream{ServerStream: ss, pathOf: a.pathOf}
+	err := handler(srv, stream)
+	var path string
+	if p := stream.path.Load(); p != nil {
+		path = *p
+	}
+	record := a.newRecord(ss.Context(), operation, path, readOnly, start)
+	// the streams are usually ended by the clients
+	if err != nil && err != io.EOF && status.Code(err) != codes.Canceled {
+		record.Result, record.Error = ResultFailure, status.Convert(err).Message()
+	}
+	Log(record)
+	return err
+}
+
+func (a *grpcAuditor) newRecord(ctx context.Context, operation, path string, readOnly bool, start time.Time) *Record {
+	identity, remoteAddr := grpcPeer(ctx)
+	return &Record{
+		Time:       start,
+		Service:    a.service,
+		Identity:   identity,
+		RemoteAddr: remoteAddr,
+		Operation:  operation,
+		Path:       path,
+		Result:     ResultSuccess,
+		LatencyMs:  sinceMs(start),
+		ReadOnly:   readOnly,
+	}
+}
+
+// grpcPeer identifies the clients by the common names of the TLS client certificates
+func grpcPeer(ctx context.Context) (identity, remoteAddr string) {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", ""
+	}
+	if pr.Addr != nil {
+		remoteAddr = pr.Addr.String()
+		if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+			remoteAddr = host
+		}
+	}
+	if tlsInfo, isTls := pr.AuthInfo.(credentials.TLSInfo); isTls && len(tlsInfo.State.PeerCertificates) > 0 {
+		identity = tlsInfo.State.PeerCertificates[0].Subject.CommonName
+	}
+	return
+}
+
+// auditServerStream takes the path from the first received message
+type auditServerStream struct {
+	grpc.ServerStream
+	pathOf func(req interface{}) string
+	path   atomic.Pointer[string]
+}
+
+func (s *auditServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.path.Load() == nil {
+		path := s.pathOf(m)
+		s.path.CompareAndSwap(nil, &path)
+	}
+	return nil
+}
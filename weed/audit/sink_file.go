@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/grace"
+)
+
+func init() {
+	Sinks = append(Sinks, &FileSink{})
+}
+
+// FileSink appends the records as JSON lines to a local file, which is reopened on SIGHUP after being rotated
+type FileSink struct {
+	sync.Mutex
+	path string
+	file *os.File
+}
+
+func (s *FileSink) GetName() string {
+	return "file"
+}
+
+func (s *FileSink) Initialize(configuration util.Configuration, prefix string) error {
+	s.path = configuration.GetString(prefix + "path")
+	glog.V(0).Infof("audit.file.path: %s", s.path)
+	if s.path == "" {
+		return fmt.Errorf("missing path")
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	grace.OnReload(func() {
+		if err := s.open(); err != nil {
+			glog.Errorf("reopen audit log %s: %v", s.path, err)
+		}
+	})
+	return nil
+}
+
+func (s *FileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	previous := s.file
+	s.file = file
+	s.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+	return nil
+}
+
+func (s *FileSink) Write(record *Record, line []byte) error {
+	data := make([]byte, 0, len(line)+1)
+	data = append(append(data, line...), '\n')
+	s.Lock()
+	defer s.Unlock()
+	_, err := s.file.Write(data)
+	return err
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Sinks = append(Sinks, &SeaweedMqSink{})
+}
+
+// SeaweedMqSink publishes the records to a SeaweedFS MQ topic, keyed by the path.
+// The records are dropped with errors if the buffer is full, e.g., when the brokers are not reachable,
+// so the requests are not blocked by the audit log.
+type SeaweedMqSink struct {
+	topic   topic.Topic
+	records chan *seaweedMqRecord
+}
+
+type seaweedMqRecord struct {
+	key   []byte
+	value []byte
+}
+
+func (s *SeaweedMqSink) GetName() string {
+	return "seaweed_mq"
+}
+
+func (s *SeaweedMqSink) Initialize(configuration util.Configuration, prefix string) error {
+	brokers := configuration.GetStringSlice(prefix + "brokers")
+	namespace, topicName := configuration.GetString(prefix+"namespace"), configuration.GetString(prefix+"topic")
+	glog.V(0).Infof("audit.seaweed_mq.brokers: %v", brokers)
+	glog.V(0).Infof("audit.seaweed_mq.topic: %s.%s", namespace, topicName)
+	if len(brokers) == 0 {
+		return fmt.Errorf("missing brokers")
+	}
+	if namespace == "" || topicName == "" {
+		return fmt.Errorf("missing namespace or topic")
+	}
+	partitionCount := configuration.GetInt(prefix + "partition_count")
+	if partitionCount <= 0 {
+		partitionCount = 4
+	}
+	bufferSize := configuration.GetInt(prefix + "buffer_size")
+	if bufferSize <= 0 {
+		bufferSize = 10000
+	}
+	s.topic = topic.NewTopic(namespace, topicName)
+	s.records = make(chan *seaweedMqRecord, bufferSize)
+
+	// the brokers may not be started yet, e.g., in "weed server", so connect to them in the background
+	go s.loopPublish(&pub_client.PublisherConfiguration{
+		Topic:               s.topic,
+		PartitionCount:      int32(partitionCount),
+		Brokers:             brokers,
+		PublisherName:       "audit",
+		GrpcDialOption:      security.LoadClientTLS(util.GetViper(), "grpc.client"),
+		MaxInFlightMessages: bufferSize,
+	})
+	return nil
+}
+
+func (s *SeaweedMqSink) Write(record *Record, line []byte) error {
+	select {
+	case s.records <- &seaweedMqRecord{key: []byte(record.Path), value: line}:
+		return nil
+	default:
+		return fmt.Errorf("drop the record of %s %s, the buffer of %s is full", record.Operation, record.Path, s.topic)
+	}
+}
+
+func (s *SeaweedMqSink) loopPublish(config *pub_client.PublisherConfiguration) {
+	publisher := pub_client.NewTopicPublisher(config)
+	glog.V(0).Infof("publishing audit records to %s", s.topic)
+	for record := range s.records {
+		if err := publisher.Publish(record.key, record.value); err != nil {
+			glog.Errorf("publish audit record %s to %s: %v", record.key, s.topic, err)
+		}
+	}
+}
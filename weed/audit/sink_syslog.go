@@ -0,0 +1,38 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package audit
+
+import (
+	"log/syslog"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Sinks = append(Sinks, &SyslogSink{})
+}
+
+// SyslogSink sends the records to the local or a remote syslog daemon, with the auth facility
+type SyslogSink struct {
+	writer *syslog.Writer
+}
+
+func (s *SyslogSink) GetName() string {
+	return "syslog"
+}
+
+func (s *SyslogSink) Initialize(configuration util.Configuration, prefix string) (err error) {
+	// the local syslog daemon if the network is empty
+	network, address := configuration.GetString(prefix+"network"), configuration.GetString(prefix+"address")
+	configuration.SetDefault(prefix+"tag", "seaweedfs")
+	tag := configuration.GetString(prefix + "tag")
+	glog.V(0).Infof("audit.syslog: %s %s %s", network, address, tag)
+	s.writer, err = syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	return err
+}
+
+func (s *SyslogSink) Write(record *Record, line []byte) error {
+	return s.writer.Info(string(line))
+}
@@ -0,0 +1,179 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+)
+
+// consulRegistry registers the servers as consul services with TTL health checks, via the consul agent http api.
+// The ACL token is read from the CONSUL_HTTP_TOKEN environment variable, and https is used if CONSUL_HTTP_SSL is true.
+type consulRegistry struct {
+	baseUrl    string
+	namespace  string
+	token      string
+	client     *http.Client
+	stopChans  map[string]chan struct{}
+	stopChansL sync.Mutex
+}
+
+type consulService struct {
+	ID      string            `json:"ID"`
+	Name    string            `json:"Name"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Meta    map[string]string `json:"Meta,omitempty"`
+	Check   *consulCheck      `json:"Check,omitempty"`
+}
+
+type consulCheck struct {
+	TTL                            string `json:"TTL"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+func newConsulRegistry(hosts, namespace string) (Registry, error) {
+	scheme := "http"
+	if ssl, _ := strconv.ParseBool(os.Getenv("CONSUL_HTTP_SSL")); ssl {
+		scheme = "https"
+	}
+	return &consulRegistry{
+		baseUrl:   fmt.Sprintf("%s://%s", scheme, hosts),
+		namespace: namespace,
+		token:     os.Getenv("CONSUL_HTTP_TOKEN"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+		stopChans: make(map[string]chan struct{}),
+	}, nil
+}
+
+func (r *consulRegistry) serviceId(serverType string, address pb.ServerAddress) string {
+	return serviceName(r.namespace, serverType) + "-" + string(address)
+}
+
+func (r *consulRegistry) Register(serverType string, address pb.ServerAddress) error {
+	host, portString, err := net.SplitHostPort(address.ToHttpAddress())
+	if err != nil {
+		return err
+	}
+	port, _ := strconv.Atoi(portString)
+	service := &consulService{
+		ID:      r.serviceId(serverType, address),
+		Name:    serviceName(r.namespace, serverType),
+		Address: host,
+		Port:    port,
+		Meta:    map[string]string{"address": string(address)},
+		Check: &consulCheck{
+			TTL:                            registrationTTL.String(),
+			DeregisterCriticalServiceAfter: (10 * registrationTTL).String(),
+		},
+	}
+	if err = r.register(service); err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	r.stopChansL.Lock()
+	if previous, found := r.stopChans[service.ID]; found {
+		close(previous)
+	}
+	r.stopChans[service.ID] = stopCh
+	r.stopChansL.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(registrationTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+			if err := r.do(http.MethodPut, "/v1/agent/check/pass/service:"+service.ID, nil, nil); err != nil {
+				// the agent may have restarted and lost the registration
+				glog.V(0).Infof("consul check %s: %v, registering again", service.ID, err)
+				if err = r.register(service); err != nil {
+					glog.Warningf("register %s in consul: %v", service.ID, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *consulRegistry) register(service *consulService) error {
+	if err := r.do(http.MethodPut, "/v1/agent/service/register", service, nil); err != nil {
+		return err
+	}
+	return r.do(http.MethodPut, "/v1/agent/check/pass/service:"+service.ID, nil, nil)
+}
+
+func (r *consulRegistry) Deregister(serverType string, address pb.ServerAddress) error {
+	id := r.serviceId(serverType, address)
+	r.stopChansL.Lock()
+	if stopCh, found := r.stopChans[id]; found {
+		close(stopCh)
+		delete(r.stopChans, id)
+	}
+	r.stopChansL.Unlock()
+	return r.do(http.MethodPut, "/v1/agent/service/deregister/"+id, nil, nil)
+}
+
+func (r *consulRegistry) Lookup(serverType string) (addresses []pb.ServerAddress, err error) {
+	var entries []struct {
+		Service consulService `json:"Service"`
+	}
+	if err = r.do(http.MethodGet, "/v1/health/service/"+serviceName(r.namespace, serverType)+"?passing=true", nil, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if address := entry.Service.Meta["address"]; address != "" {
+			addresses = append(addresses, pb.ServerAddress(address))
+		} else {
+			addresses = append(addresses, pb.ServerAddress(net.JoinHostPort(entry.Service.Address, strconv.Itoa(entry.Service.Port))))
+		}
+	}
+	return addresses, nil
+}
+
+func (r *consulRegistry) do(method, path string, input, output any) error {
+	var body io.Reader
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	request, err := http.NewRequest(method, r.baseUrl+path, body)
+	if err != nil {
+		return err
+	}
+	if r.token != "" {
+		request.Header.Set("X-Consul-Token", r.token)
+	}
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s %s", method, path, response.Status, bytes.TrimSpace(data))
+	}
+	if output == nil {
+		return nil
+	}
+	return json.Unmarshal(data, output)
+}
@@ -0,0 +1,129 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/util/grace"
+)
+
+const (
+	defaultNamespace = "seaweedfs"
+	registrationTTL  = 30 * time.Second
+)
+
+// Registry registers the servers in a service registry, and looks up the registered servers of a type,
+// so the servers find each other without static lists in dynamic environments.
+type Registry interface {
+	// Register keeps the server registered until Deregister. If the process dies, the registration expires.
+	Register(serverType string, address pb.ServerAddress) error
+	Deregister(serverType string, address pb.ServerAddress) error
+	Lookup(serverType string) ([]pb.ServerAddress, error)
+}
+
+type newRegistryFunc func(hosts, namespace string) (Registry, error)
+
+var (
+	registryFactories = map[string]newRegistryFunc{
+		"consul": newConsulRegistry,
+		"etcd":   newEtcdRegistry,
+		"nomad":  newNomadRegistry,
+	}
+	registries     = make(map[string]Registry)
+	registriesLock sync.Mutex
+)
+
+func init() {
+	for scheme := range registryFactories {
+		scheme := scheme
+		pb.RegisterServerResolver(scheme, func(registry string, serverType string) ([]pb.ServerAddress, error) {
+			r, err := GetRegistry(scheme + "+" + registry)
+			if err != nil {
+				return nil, err
+			}
+			return r.Lookup(serverType)
+		})
+	}
+}
+
+// IsRegistry checks whether the server list is a service registry, in the form of "<consul|etcd|nomad>+<hosts>[/<namespace>]"
+func IsRegistry(servers string) bool {
+	scheme, _, found := strings.Cut(servers, "+")
+	if !found {
+		return false
+	}
+	_, found = registryFactories[scheme]
+	return found
+}
+
+// GetRegistry returns the registry of the uri, e.g., "consul+localhost:8500", "etcd+10.0.0.1:2379,10.0.0.2:2379/prod",
+// or "nomad+localhost:4646". The servers are registered under the namespace, "seaweedfs" by default.
+// The registries are shared by the uri.
+func GetRegistry(uri string) (Registry, error) {
+	registriesLock.Lock()
+	defer registriesLock.Unlock()
+	if r, found := registries[uri]; found {
+		return r, nil
+	}
+	scheme, target, _ := strings.Cut(uri, "+")
+	newRegistry, found := registryFactories[scheme]
+	if !found {
+		return nil, fmt.Errorf("unknown service registry %s", uri)
+	}
+	hosts, namespace, _ := strings.Cut(target, "/")
+	if hosts == "" {
+		return nil, fmt.Errorf("service registry %s without hosts", uri)
+	}
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	r, err := newRegistry(hosts, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %v", uri, err)
+	}
+	registries[uri] = r
+	return r, nil
+}
+
+// RegisterServer registers the server in the registry, and deregisters it when the process is interrupted
+func RegisterServer(uri string, serverType string, address pb.ServerAddress) error {
+	r, err := GetRegistry(uri)
+	if err != nil {
+		return err
+	}
+	if err = r.Register(serverType, address); err != nil {
+		return fmt.Errorf("register %s %s in %s: %v", serverType, address, uri, err)
+	}
+	glog.V(0).Infof("registered %s %s in %s", serverType, address, uri)
+	grace.OnInterrupt(func() {
+		if err := r.Deregister(serverType, address); err != nil {
+			glog.Warningf("deregister %s %s from %s: %v", serverType, address, uri, err)
+		}
+	})
+	return nil
+}
+
+// WaitForServers looks up the servers of the type until at least count of them are registered
+func WaitForServers(uri string, serverType string, count int) ([]pb.ServerAddress, error) {
+	r, err := GetRegistry(uri)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		addresses, err := r.Lookup(serverType)
+		if err == nil && len(addresses) >= count {
+			return addresses, nil
+		}
+		glog.V(0).Infof("waiting for %d %s servers in %s, found %v: %v", count, serverType, uri, addresses, err)
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// serviceName is the name of the servers of the type, e.g., "seaweedfs-volumeserver"
+func serviceName(namespace, serverType string) string {
+	return namespace + "-" + strings.ToLower(serverType)
+}
@@ -0,0 +1,46 @@
+package discovery
+
+import (
+	"testing"
+)
+
+func TestIsRegistry(t *testing.T) {
+	for servers, expected := range map[string]bool{
+		"consul+localhost:8500":            true,
+		"etcd+10.0.0.1:2379,10.0.0.2:2379": true,
+		"nomad+localhost:4646/prod":        true,
+		"dnssrv+_grpc._tcp.master.consul":  false,
+		"localhost:9333,localhost:9334":    false,
+		"unknown+localhost:8500":           false,
+	} {
+		if IsRegistry(servers) != expected {
+			t.Errorf("IsRegistry(%s) should be %v", servers, expected)
+		}
+	}
+}
+
+func TestGetRegistry(t *testing.T) {
+	r, err := GetRegistry("nomad+localhost:4646/prod")
+	if err != nil {
+		t.Fatalf("get registry: %v", err)
+	}
+	if namespace := r.(*nomadRegistry).namespace; namespace != "prod" {
+		t.Errorf("namespace %s, expected prod", namespace)
+	}
+	if same, _ := GetRegistry("nomad+localhost:4646/prod"); same != r {
+		t.Errorf("registries of the same uri should be shared")
+	}
+
+	r, _ = GetRegistry("nomad+localhost:4646")
+	if namespace := r.(*nomadRegistry).namespace; namespace != defaultNamespace {
+		t.Errorf("namespace %s, expected %s", namespace, defaultNamespace)
+	}
+
+	if _, err = GetRegistry("nomad+/prod"); err == nil {
+		t.Errorf("registry without hosts should fail")
+	}
+
+	if name := serviceName(defaultNamespace, "volumeServer"); name != "seaweedfs-volumeserver" {
+		t.Errorf("service name %s", name)
+	}
+}
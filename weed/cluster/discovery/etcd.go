@@ -0,0 +1,127 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+)
+
+const etcdTimeout = 5 * time.Second
+
+// etcdRegistry registers each server as the key /<namespace>/<server type>/<address>, attached to a lease kept alive.
+// The credentials are read from the ETCD_USERNAME and ETCD_PASSWORD environment variables.
+type etcdRegistry struct {
+	client      *clientv3.Client
+	namespace   string
+	cancels     map[string]context.CancelFunc
+	cancelsLock sync.Mutex
+}
+
+func newEtcdRegistry(hosts, namespace string) (Registry, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(hosts, ","),
+		Username:    os.Getenv("ETCD_USERNAME"),
+		Password:    os.Getenv("ETCD_PASSWORD"),
+		DialTimeout: etcdTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &etcdRegistry{
+		client:    client,
+		namespace: namespace,
+		cancels:   make(map[string]context.CancelFunc),
+	}, nil
+}
+
+func (r *etcdRegistry) keyPrefix(serverType string) string {
+	return "/" + r.namespace + "/" + serverType + "/"
+}
+
+func (r *etcdRegistry) Register(serverType string, address pb.ServerAddress) error {
+	key := r.keyPrefix(serverType) + string(address)
+	ctx, cancel := context.WithCancel(context.Background())
+	keepAlive, err := r.register(ctx, key, address)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	r.cancelsLock.Lock()
+	if previousCancel, found := r.cancels[key]; found {
+		previousCancel()
+	}
+	r.cancels[key] = cancel
+	r.cancelsLock.Unlock()
+
+	go func() {
+		for {
+			// drain the keep alive responses, until the lease is lost, e.g., the etcd cluster is unreachable for too long
+			for range keepAlive {
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			glog.V(0).Infof("etcd lease of %s is lost, registering again", key)
+			for {
+				if keepAlive, err = r.register(ctx, key, address); err == nil {
+					break
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				glog.Warningf("register %s in etcd: %v", key, err)
+				time.Sleep(registrationTTL / 3)
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *etcdRegistry) register(ctx context.Context, key string, address pb.ServerAddress) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+	lease, err := r.client.Grant(timeoutCtx, int64(registrationTTL/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = r.client.Put(timeoutCtx, key, string(address), clientv3.WithLease(lease.ID)); err != nil {
+		return nil, err
+	}
+	return r.client.KeepAlive(ctx, lease.ID)
+}
+
+func (r *etcdRegistry) Deregister(serverType string, address pb.ServerAddress) error {
+	key := r.keyPrefix(serverType) + string(address)
+	r.cancelsLock.Lock()
+	if cancel, found := r.cancels[key]; found {
+		cancel()
+		delete(r.cancels, key)
+	}
+	r.cancelsLock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	_, err := r.client.Delete(ctx, key)
+	return err
+}
+
+func (r *etcdRegistry) Lookup(serverType string) (addresses []pb.ServerAddress, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	resp, err := r.client.Get(ctx, r.keyPrefix(serverType), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range resp.Kvs {
+		addresses = append(addresses, pb.ServerAddress(kv.Value))
+	}
+	return addresses, nil
+}
@@ -0,0 +1,84 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+)
+
+// nomadRegistry looks up the servers in the nomad native service discovery. The services are registered by
+// the nomad job specs, named like "seaweedfs-master", with the optional tag "grpc=<port>" for a non default grpc port.
+// The ACL token is read from the NOMAD_TOKEN environment variable.
+type nomadRegistry struct {
+	baseUrl   string
+	namespace string
+	token     string
+	client    *http.Client
+}
+
+func newNomadRegistry(hosts, namespace string) (Registry, error) {
+	return &nomadRegistry{
+		baseUrl:   "http://" + hosts,
+		namespace: namespace,
+		token:     os.Getenv("NOMAD_TOKEN"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Register does nothing, since the nomad services are registered by the job specs
+func (r *nomadRegistry) Register(serverType string, address pb.ServerAddress) error {
+	return nil
+}
+
+func (r *nomadRegistry) Deregister(serverType string, address pb.ServerAddress) error {
+	return nil
+}
+
+func (r *nomadRegistry) Lookup(serverType string) (addresses []pb.ServerAddress, err error) {
+	request, err := http.NewRequest(http.MethodGet, r.baseUrl+"/v1/service/"+serviceName(r.namespace, serverType), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.token != "" {
+		request.Header.Set("X-Nomad-Token", r.token)
+	}
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lookup %s: %s %s", serviceName(r.namespace, serverType), response.Status, strings.TrimSpace(string(data)))
+	}
+	var registrations []struct {
+		Address string   `json:"Address"`
+		Port    int      `json:"Port"`
+		Tags    []string `json:"Tags"`
+	}
+	if err = json.Unmarshal(data, &registrations); err != nil {
+		return nil, err
+	}
+	for _, registration := range registrations {
+		grpcPort := 0
+		for _, tag := range registration.Tags {
+			if value, found := strings.CutPrefix(tag, "grpc="); found {
+				grpcPort, _ = strconv.Atoi(value)
+			}
+		}
+		address := net.JoinHostPort(registration.Address, strconv.Itoa(registration.Port))
+		addresses = append(addresses, pb.NewServerAddressWithGrpcPort(address, grpcPort))
+	}
+	return addresses, nil
+}
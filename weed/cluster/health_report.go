@@ -0,0 +1,105 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// the health status, from the best to the worst
+const (
+	HealthOk       = "ok"
+	HealthWarning  = "warning"
+	HealthCritical = "critical"
+)
+
+// the kinds of the health issues
+const (
+	IssueNotReady            = "not_ready"
+	IssueDiskPressure        = "disk_pressure"
+	IssueNoFreeVolumeSlot    = "no_free_volume_slot"
+	IssueUnderReplicated     = "under_replicated"
+	IssueMissingEcShards     = "missing_ec_shards"
+	IssueUnassignedPartition = "unassigned_partition"
+	IssuePartitionGap        = "partition_gap"
+)
+
+// HealthReport aggregates the health of the volume servers, the filers, and the MQ brokers, reported by the master
+type HealthReport struct {
+	Status string         `json:"status"` // the worst status of the issues
+	Time   time.Time      `json:"time"`
+	Nodes  []*NodeHealth  `json:"nodes"`
+	Issues []*HealthIssue `json:"issues"`
+}
+
+type NodeHealth struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+	Ready   bool   `json:"ready"`
+	Error   string `json:"error,omitempty"`
+}
+
+type HealthIssue struct {
+	Status  string `json:"status"`
+	Kind    string `json:"kind"`
+	Node    string `json:"node,omitempty"`
+	Message string `json:"message"`
+}
+
+func NewHealthReport() *HealthReport {
+	return &HealthReport{
+		Status: HealthOk,
+		Time:   time.Now(),
+	}
+}
+
+// AddNode adds the readiness of the node, and a critical issue if not ready
+func (r *HealthReport) AddNode(nodeType, address string, err error) {
+	node := &NodeHealth{Type: nodeType, Address: address, Ready: err == nil}
+	if err != nil {
+		node.Error = err.Error()
+		r.AddIssue(HealthCritical, IssueNotReady, address, "%s %s is not ready: %v", nodeType, address, err)
+	}
+	r.Nodes = append(r.Nodes, node)
+}
+
+// AddIssue adds the issue, and lowers the status of the report accordingly
+func (r *HealthReport) AddIssue(status, kind, node, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, &HealthIssue{
+		Status:  status,
+		Kind:    kind,
+		Node:    node,
+		Message: fmt.Sprintf(format, args...),
+	})
+	if healthRank(status) > healthRank(r.Status) {
+		r.Status = status
+	}
+}
+
+// Sort orders the nodes by the types and the addresses, and the issues from the worst
+func (r *HealthReport) Sort() {
+	sort.SliceStable(r.Nodes, func(i, j int) bool {
+		if r.Nodes[i].Type != r.Nodes[j].Type {
+			return r.Nodes[i].Type < r.Nodes[j].Type
+		}
+		return r.Nodes[i].Address < r.Nodes[j].Address
+	})
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		return healthRank(r.Issues[i].Status) > healthRank(r.Issues[j].Status)
+	})
+}
+
+// ExitCode follows the monitoring plugins: 0 for ok, 1 for warning, and 2 for critical
+func (r *HealthReport) ExitCode() int {
+	return healthRank(r.Status)
+}
+
+func healthRank(status string) int {
+	switch status {
+	case HealthOk:
+		return 0
+	case HealthWarning:
+		return 1
+	}
+	return 2
+}
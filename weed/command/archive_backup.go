@@ -0,0 +1,400 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/archive"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/wdclient"
+)
+
+var (
+	archiveBackup ArchiveBackupOptions
+)
+
+type ArchiveBackupOptions struct {
+	master        *string
+	filer         *string
+	volumeIds     *string
+	collection    *string
+	bucket        *string
+	filerPath     *string
+	output        *string
+	catalog       *string
+	blockSize     *int
+	segmentSizeMB *int64
+
+	grpcDialOption grpc.DialOption
+	filerAddress   pb.ServerAddress
+}
+
+func init() {
+	cmdArchiveBackup.Run = runArchiveBackup // break init cycle
+	archiveBackup.master = cmdArchiveBackup.Flag.String("master", "localhost:9333", "SeaweedFS master location, to archive the volumes")
+	archiveBackup.filer = cmdArchiveBackup.Flag.String("filer", "localhost:8888", "filer location, to archive the files")
+	archiveBackup.volumeIds = cmdArchiveBackup.Flag.String("volumeId", "", "comma separated volume ids to archive")
+	archiveBackup.collection = cmdArchiveBackup.Flag.String("collection", "", "archive all volumes of the collection")
+	archiveBackup.bucket = cmdArchiveBackup.Flag.String("bucket", "", "archive all files of the bucket")
+	archiveBackup.filerPath = cmdArchiveBackup.Flag.String("filerPath", "", "archive all files under the filer path")
+	archiveBackup.output = cmdArchiveBackup.Flag.String("o", "", "the archive file, tape device, or \"-\" for stdout")
+	archiveBackup.catalog = cmdArchiveBackup.Flag.String("catalog", "", "also save the catalog to this file, default to <archive>.catalog.json for an archive file")
+	archiveBackup.blockSize = cmdArchiveBackup.Flag.Int("blockSize", archive.DefaultBlockSize, "write the archive in blocks of this size")
+	archiveBackup.segmentSizeMB = cmdArchiveBackup.Flag.Int64("segmentSizeMB", 0, "split the archive into segments of this size, as <archive>.000, <archive>.001, ...")
+}
+
+var cmdArchiveBackup = &Command{
+	UsageLine: "archive.backup -o=/dev/nst0 -collection=photos -bucket=docs",
+	Short:     "archive volumes or buckets as one sequential stream, for tapes and archival blob stores",
+	Long: `Archive volumes or buckets as one sequential tar stream with a catalog.
+
+	The archive only appends, and is written in fixed size blocks, so it can be written to a tape device,
+	a file on an LTFS mounted tape, or piped to an archival blob store, e.g.:
+
+		weed archive.backup -collection=photos -o=/dev/nst0
+		weed archive.backup -bucket=docs -o=- | aws s3 cp - s3://archive/docs.tar --storage-class DEEP_ARCHIVE
+
+	The volumes are archived as their .dat and .idx files, and the files with their content.
+	The catalog lists every member with its offset, size and md5. It is the last member of the archive,
+	and is also saved separately, to find what is in the archive without reading it through.
+	The erasure coded volumes and the files only in the remote storage are not archived.
+
+	Use "weed archive.verify" to check the archive, and "weed archive.restore" to restore it.
+
+  `,
+}
+
+func runArchiveBackup(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	archiveBackup.grpcDialOption = security.LoadClientTLS(util.GetViper(), "grpc.client")
+	archiveBackup.filerAddress = pb.ServerAddress(*archiveBackup.filer)
+
+	if *archiveBackup.output == "" {
+		return false
+	}
+	if *archiveBackup.volumeIds == "" && *archiveBackup.collection == "" && *archiveBackup.bucket == "" && *archiveBackup.filerPath == "" {
+		fmt.Fprintf(os.Stderr, "nothing to archive, specify -volumeId, -collection, -bucket or -filerPath\n")
+		return false
+	}
+
+	catalog, err := archiveBackup.run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "archive: %v\n", err)
+		return true
+	}
+
+	catalogFile := *archiveBackup.catalog
+	if catalogFile == "" && *archiveBackup.output != "-" && isRegularFileOrMissing(*archiveBackup.output) {
+		catalogFile = *archiveBackup.output + ".catalog.json"
+	}
+	if catalogFile != "" {
+		data, _ := catalog.Marshal()
+		if err = util.WriteFile(catalogFile, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "save catalog %s: %v\n", catalogFile, err)
+			return true
+		}
+	}
+	fmt.Fprintf(os.Stderr, "archived %d members, %d bytes\n", len(catalog.Entries), catalog.TotalSize())
+
+	return true
+}
+
+func isRegularFileOrMissing(path string) bool {
+	stat, err := os.Stat(path)
+	return os.IsNotExist(err) || err == nil && stat.Mode().IsRegular()
+}
+
+func (option *ArchiveBackupOptions) run() (*archive.Catalog, error) {
+	target, err := archive.Create(*option.output, *option.segmentSizeMB*1024*1024)
+	if err != nil {
+		return nil, err
+	}
+	defer target.Close()
+
+	w := archive.NewWriter(target, *option.blockSize, option.source())
+
+	volumes, err := option.collectVolumes()
+	if err != nil {
+		return nil, err
+	}
+	for _, vid := range volumes {
+		if err = option.archiveVolume(w, vid); err != nil {
+			return nil, err
+		}
+	}
+
+	dirs, err := option.collectDirs()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if err = option.archiveDir(w, util.FullPath(dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	catalog, err := w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return catalog, target.Close()
+}
+
+func (option *ArchiveBackupOptions) source() string {
+	var sources []string
+	for _, source := range []struct{ name, value string }{
+		{"volumeId", *option.volumeIds},
+		{"collection", *option.collection},
+		{"bucket", *option.bucket},
+		{"filerPath", *option.filerPath},
+	} {
+		if source.value != "" {
+			sources = append(sources, source.name+"="+source.value)
+		}
+	}
+	return strings.Join(sources, " ")
+}
+
+func (option *ArchiveBackupOptions) collectVolumes() (vids []uint32, err error) {
+	for _, vidString := range strings.Split(*option.volumeIds, ",") {
+		if vidString = strings.TrimSpace(vidString); vidString == "" {
+			continue
+		}
+		vid, parseErr := strconv.ParseUint(vidString, 10, 32)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid volume id %s", vidString)
+		}
+		vids = append(vids, uint32(vid))
+	}
+	if *option.collection == "" {
+		return vids, nil
+	}
+
+	err = pb.WithMasterClient(false, pb.ServerAddress(*option.master), option.grpcDialOption, false, func(client master_pb.SeaweedClient) error {
+		resp, err := client.VolumeList(context.Background(), &master_pb.VolumeListRequest{})
+		if err != nil {
+			return err
+		}
+		found := make(map[uint32]bool)
+		for _, dc := range resp.TopologyInfo.DataCenterInfos {
+			for _, rack := range dc.RackInfos {
+				for _, dn := range rack.DataNodeInfos {
+					for _, disk := range dn.DiskInfos {
+						for _, v := range disk.VolumeInfos {
+							if v.Collection == *option.collection && !found[v.Id] {
+								found[v.Id] = true
+								vids = append(vids, v.Id)
+							}
+						}
+					}
+				}
+			}
+		}
+		return nil
+	})
+	slices.Sort(vids)
+	return vids, err
+}
+
+func (option *ArchiveBackupOptions) archiveVolume(w *archive.Writer, vid uint32) error {
+	lookup, err := operation.LookupVolumeId(func(_ context.Context) pb.ServerAddress { return pb.ServerAddress(*option.master) }, option.grpcDialOption, strconv.FormatUint(uint64(vid), 10))
+	if err != nil {
+		return fmt.Errorf("lookup volume %d: %v", vid, err)
+	}
+	if len(lookup.Locations) == 0 {
+		return fmt.Errorf("volume %d not found", vid)
+	}
+	volumeServer := lookup.Locations[0].ServerAddress()
+
+	return operation.WithVolumeServerClient(true, volumeServer, option.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+		status, err := client.ReadVolumeFileStatus(context.Background(), &volume_server_pb.ReadVolumeFileStatusRequest{
+			VolumeId: vid,
+		})
+		if err != nil {
+			return fmt.Errorf("read volume %d file status: %v", vid, err)
+		}
+		if status.VolumeInfo != nil && len(status.VolumeInfo.Files) > 0 {
+			fmt.Fprintf(os.Stderr, "skip volume %d, its data is in the remote tier\n", vid)
+			return nil
+		}
+		// the index is archived after the data, so all archived index entries point to the archived data
+		for _, file := range []struct {
+			ext     string
+			size    uint64
+			modTime uint64
+		}{
+			{".dat", status.DatFileSize, status.DatFileTimestampSeconds},
+			{".idx", status.IdxFileSize, status.IdxFileTimestampSeconds},
+		} {
+			copyFileClient, err := client.CopyFile(context.Background(), &volume_server_pb.CopyFileRequest{
+				VolumeId:           vid,
+				Ext:                file.ext,
+				CompactionRevision: status.CompactionRevision,
+				StopOffset:         file.size,
+				Collection:         status.Collection,
+			})
+			if err != nil {
+				return fmt.Errorf("copy volume %d%s: %v", vid, file.ext, err)
+			}
+			fmt.Fprintf(os.Stderr, "archive volume %d%s, %d bytes from %s\n", vid, file.ext, file.size, volumeServer)
+			if err = w.Add(&archive.CatalogEntry{
+				Name:       archive.VolumeMemberName(status.Collection, vid, file.ext),
+				Kind:       archive.KindVolume,
+				Size:       int64(file.size),
+				ModTime:    time.Unix(int64(file.modTime), 0),
+				VolumeId:   vid,
+				Collection: status.Collection,
+			}, &copyFileReader{stream: copyFileClient}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// copyFileReader reads the content streamed by CopyFile
+type copyFileReader struct {
+	stream volume_server_pb.VolumeServer_CopyFileClient
+	buf    bytes.Buffer
+}
+
+func (r *copyFileReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		resp, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf.Write(resp.FileContent)
+	}
+	return r.buf.Read(p)
+}
+
+func (option *ArchiveBackupOptions) collectDirs() (dirs []string, err error) {
+	if *option.filerPath != "" {
+		dirs = append(dirs, *option.filerPath)
+	}
+	if *option.bucket == "" {
+		return dirs, nil
+	}
+	err = option.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, string(util.NewFullPath(resp.DirBuckets, *option.bucket)))
+		return nil
+	})
+	return dirs, err
+}
+
+// archiveDir archives the files in the directory, the subdirectories after the files
+func (option *ArchiveBackupOptions) archiveDir(w *archive.Writer, dir util.FullPath) error {
+	var files, subDirs []*filer_pb.Entry
+	if err := filer_pb.ReadDirAllEntries(option, dir, "", func(entry *filer_pb.Entry, isLast bool) error {
+		if entry.IsDirectory {
+			subDirs = append(subDirs, entry)
+		} else {
+			files = append(files, entry)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("list %s: %v", dir, err)
+	}
+
+	for _, entry := range files {
+		if err := option.archiveFile(w, dir.Child(entry.Name), entry); err != nil {
+			return err
+		}
+	}
+	for _, entry := range subDirs {
+		if err := option.archiveDir(w, dir.Child(entry.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (option *ArchiveBackupOptions) archiveFile(w *archive.Writer, p util.FullPath, entry *filer_pb.Entry) error {
+	if entry.IsInRemoteOnly() {
+		fmt.Fprintf(os.Stderr, "skip %s, only in the remote storage\n", p)
+		return nil
+	}
+	size := int64(filer.FileSize(entry))
+	catalogEntry := &archive.CatalogEntry{
+		Name:    archive.FileMemberName(string(p)),
+		Kind:    archive.KindFile,
+		Size:    size,
+		Mode:    int64(entry.Attributes.GetFileMode()),
+		ModTime: time.Unix(entry.Attributes.GetMtime(), 0),
+		Mime:    entry.Attributes.GetMime(),
+		Path:    string(p),
+	}
+	if len(entry.Content) > 0 {
+		return w.Add(catalogEntry, bytes.NewReader(entry.Content))
+	}
+
+	streamFn, err := filer.PrepareStreamContent(option, func(fileId string) string { return "" }, entry.GetChunks(), 0, size)
+	if err != nil {
+		return fmt.Errorf("read %s: %v", p, err)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(streamFn(pw))
+	}()
+	defer pr.Close()
+	return w.Add(catalogEntry, pr)
+}
+
+var _ = filer_pb.FilerClient(&ArchiveBackupOptions{})
+
+func (option *ArchiveBackupOptions) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithFilerClient(streamingMode, 0, option.filerAddress, option.grpcDialOption, fn)
+}
+
+func (option *ArchiveBackupOptions) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (option *ArchiveBackupOptions) GetDataCenter() string {
+	return ""
+}
+
+func (option *ArchiveBackupOptions) GetLookupFileIdFunction() wdclient.LookupFileIdFunctionType {
+	return func(fileId string) (targetUrls []string, err error) {
+		vid := filer.VolumeId(fileId)
+		err = option.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			resp, err := client.LookupVolume(context.Background(), &filer_pb.LookupVolumeRequest{
+				VolumeIds: []string{vid},
+			})
+			if err != nil {
+				return err
+			}
+			locations, found := resp.LocationsMap[vid]
+			if !found {
+				return fmt.Errorf("volume %s not found", vid)
+			}
+			for _, loc := range locations.Locations {
+				targetUrls = append(targetUrls, fmt.Sprintf("http://%s/%s", loc.Url, fileId))
+			}
+			return nil
+		})
+		return
+	}
+}
@@ -0,0 +1,202 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/archive"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+var (
+	archiveRestore ArchiveRestoreOptions
+)
+
+type ArchiveRestoreOptions struct {
+	input     *string
+	catalog   *string
+	volumeDir *string
+	filer     *string
+	filerPath *string
+	toPath    *string
+
+	catalogEntries map[string]*archive.CatalogEntry
+	signingKey     security.SigningKey
+}
+
+func init() {
+	cmdArchiveRestore.Run = runArchiveRestore // break init cycle
+	archiveRestore.input = cmdArchiveRestore.Flag.String("i", "", "the archive file, tape device, or \"-\" for stdin")
+	archiveRestore.catalog = cmdArchiveRestore.Flag.String("catalog", "", "the catalog saved with the archive, to restore the files with their mime types")
+	archiveRestore.volumeDir = cmdArchiveRestore.Flag.String("volumeDir", "", "restore the volume files to this directory")
+	archiveRestore.filer = cmdArchiveRestore.Flag.String("filer", "", "restore the files to this filer")
+	archiveRestore.filerPath = cmdArchiveRestore.Flag.String("filerPath", "/", "only restore the files under this filer path")
+	archiveRestore.toPath = cmdArchiveRestore.Flag.String("to", "", "restore the files under -filerPath to this path, default to their original paths")
+}
+
+var cmdArchiveRestore = &Command{
+	UsageLine: "archive.restore -i=/dev/nst0 -volumeDir=/data/restored -filer=localhost:8888",
+	Short:     "restore volumes or files from an archive",
+	Long: `Restore the volumes or files from an archive written by "weed archive.backup".
+
+	The archive is read sequentially. The volume files are restored to -volumeDir,
+	to be copied to a volume server, and the files are uploaded to -filer.
+	The existing volume files are not overwritten.
+
+	The restored members are checked against the catalog, which is the last member of the archive,
+	or the separately saved catalog if specified.
+
+  `,
+}
+
+func runArchiveRestore(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	archiveRestore.signingKey = security.SigningKey(util.GetViper().GetString("jwt.filer_signing.key"))
+
+	if *archiveRestore.input == "" {
+		return false
+	}
+	if *archiveRestore.volumeDir == "" && *archiveRestore.filer == "" {
+		fmt.Fprintf(os.Stderr, "nothing to restore, specify -volumeDir or -filer\n")
+		return false
+	}
+
+	catalog, err := loadArchiveCatalog(*archiveRestore.catalog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return true
+	}
+	archiveRestore.catalogEntries = make(map[string]*archive.CatalogEntry)
+	if catalog != nil {
+		for _, entry := range catalog.Entries {
+			archiveRestore.catalogEntries[entry.Name] = entry
+		}
+	}
+
+	source, err := archive.Open(*archiveRestore.input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return true
+	}
+	defer source.Close()
+
+	var restored int
+	entries, streamCatalog, err := archive.Walk(source, func(entry *archive.CatalogEntry, content io.Reader) error {
+		isRestored, restoreErr := archiveRestore.restore(entry, content)
+		if isRestored {
+			restored++
+		}
+		return restoreErr
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restore: %v\n", err)
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "restored %d members\n", restored)
+
+	if catalog == nil {
+		catalog = streamCatalog
+	}
+	if catalog == nil {
+		fmt.Fprintf(os.Stderr, "no catalog at the end, the archive is incomplete\n")
+		return true
+	}
+	for _, problem := range archive.Compare(catalog, entries) {
+		fmt.Fprintf(os.Stderr, "%s\n", problem)
+	}
+
+	return true
+}
+
+func loadArchiveCatalog(catalogFile string) (*archive.Catalog, error) {
+	if catalogFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(catalogFile)
+	if err != nil {
+		return nil, fmt.Errorf("read catalog %s: %v", catalogFile, err)
+	}
+	return archive.UnmarshalCatalog(data)
+}
+
+func (option *ArchiveRestoreOptions) restore(entry *archive.CatalogEntry, content io.Reader) (isRestored bool, err error) {
+	switch {
+	case strings.HasPrefix(entry.Name, archive.VolumePrefix):
+		if *option.volumeDir == "" {
+			return false, nil
+		}
+		return true, option.restoreVolumeFile(entry, content)
+	case strings.HasPrefix(entry.Name, archive.FilePrefix+"/"):
+		if *option.filer == "" {
+			return false, nil
+		}
+		p := util.FullPath(strings.TrimPrefix(entry.Name, archive.FilePrefix))
+		filerPath := util.FullPath(*option.filerPath)
+		if filerPath != "/" && p != filerPath && !p.IsUnder(filerPath) {
+			return false, nil
+		}
+		if *option.toPath != "" {
+			p = util.FullPath(path.Join(*option.toPath, strings.TrimPrefix(string(p), string(filerPath))))
+		}
+		return true, option.restoreFile(entry, p, content)
+	}
+	return false, nil
+}
+
+func (option *ArchiveRestoreOptions) restoreVolumeFile(entry *archive.CatalogEntry, content io.Reader) error {
+	fileName := filepath.Join(*option.volumeDir, strings.TrimPrefix(entry.Name, archive.VolumePrefix))
+	dst, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("restore %s: %v", entry.Name, err)
+	}
+	defer dst.Close()
+	if _, err = io.Copy(dst, content); err != nil {
+		return fmt.Errorf("restore %s: %v", entry.Name, err)
+	}
+	fmt.Fprintf(os.Stderr, "restored %s\n", fileName)
+	return dst.Close()
+}
+
+func (option *ArchiveRestoreOptions) restoreFile(entry *archive.CatalogEntry, p util.FullPath, content io.Reader) error {
+	query := url.Values{}
+	query.Set("mode", strconv.FormatInt(entry.Mode&0777, 8))
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("http://%s%s?%s", *option.filer, urlPathEscape(string(p)), query.Encode()), content)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = entry.Size
+	if catalogEntry, found := option.catalogEntries[entry.Name]; found && catalogEntry.Mime != "" {
+		req.Header.Set("Content-Type", catalogEntry.Mime)
+	}
+	if jwt := security.GenJwtForFilerServer(option.signingKey, 60); jwt != "" {
+		req.Header.Set("Authorization", "BEARER "+string(jwt))
+	}
+	resp, err := util_http.Do(req)
+	if err != nil {
+		return fmt.Errorf("restore %s: %v", p, err)
+	}
+	defer util_http.CloseResponse(resp)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("restore %s: %s", p, resp.Status)
+	}
+	fmt.Fprintf(os.Stderr, "restored %s\n", p)
+	return nil
+}
+
+func urlPathEscape(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/seaweedfs/seaweedfs/weed/archive"
+)
+
+var (
+	archiveVerify ArchiveVerifyOptions
+)
+
+type ArchiveVerifyOptions struct {
+	input   *string
+	catalog *string
+}
+
+func init() {
+	cmdArchiveVerify.Run = runArchiveVerify // break init cycle
+	archiveVerify.input = cmdArchiveVerify.Flag.String("i", "", "the archive file, tape device, or \"-\" for stdin")
+	archiveVerify.catalog = cmdArchiveVerify.Flag.String("catalog", "", "verify against the catalog saved with the archive, default to the catalog at the end of the archive")
+}
+
+var cmdArchiveVerify = &Command{
+	UsageLine: "archive.verify -i=/dev/nst0",
+	Short:     "verify an archive against its catalog",
+	Long: `Read through an archive written by "weed archive.backup", and check the size and md5 of every member
+	against the catalog.
+
+		weed archive.verify -i=/dev/nst0 -catalog=photos.catalog.json
+		aws s3 cp s3://archive/docs.tar - | weed archive.verify -i=-
+
+  `,
+}
+
+func runArchiveVerify(cmd *Command, args []string) bool {
+
+	if *archiveVerify.input == "" {
+		return false
+	}
+
+	catalog, err := loadArchiveCatalog(*archiveVerify.catalog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return true
+	}
+
+	source, err := archive.Open(*archiveVerify.input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return true
+	}
+	defer source.Close()
+
+	problems, err := archive.Verify(source, catalog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: %v\n", err)
+		return true
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "%s\n", problem)
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(os.Stderr, "verified\n")
+	}
+
+	return true
+}
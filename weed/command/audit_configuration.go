@@ -0,0 +1,20 @@
+package command
+
+import (
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/audit"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var loadAuditConfigOnce sync.Once
+
+// loadAuditConfiguration enables the audit log in audit.toml, if any.
+// The filer, the S3 gateway, and the broker in one process, e.g., "weed server", share one audit log.
+func loadAuditConfiguration() {
+	loadAuditConfigOnce.Do(func() {
+		if util.LoadConfiguration("audit", false) {
+			audit.LoadConfiguration(util.GetViper(), "audit.")
+		}
+	})
+}
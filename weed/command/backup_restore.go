@@ -0,0 +1,177 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/replication/snapshot"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var (
+	backupRestore BackupRestoreOptions
+)
+
+type BackupRestoreOptions struct {
+	filer       *string
+	remote      *string
+	time        *string
+	filerPath   *string
+	toPath      *string
+	collection  *string
+	replication *string
+	dryRun      *bool
+
+	grpcDialOption grpc.DialOption
+	filerAddress   pb.ServerAddress
+}
+
+func init() {
+	cmdBackupRestore.Run = runBackupRestore // break init cycle
+	backupRestore.filer = cmdBackupRestore.Flag.String("filer", "localhost:8888", "restore the files to this filer")
+	backupRestore.remote = cmdBackupRestore.Flag.String("remote", "", "the remote storage location backed up by \"weed backup.snapshot\", e.g., cloud1/bucket/backup")
+	backupRestore.time = cmdBackupRestore.Flag.String("time", "", "restore the files as of this time, in RFC3339 format, e.g., 2024-01-02T15:04:05Z, default to the latest")
+	backupRestore.filerPath = cmdBackupRestore.Flag.String("filerPath", "/", "only restore the files under this filer path")
+	backupRestore.toPath = cmdBackupRestore.Flag.String("to", "", "restore the files under -filerPath to this path, default to their original paths")
+	backupRestore.collection = cmdBackupRestore.Flag.String("collection", "", "the collection to store the restored chunks")
+	backupRestore.replication = cmdBackupRestore.Flag.String("replication", "", "the replication of the restored chunks, default to the filer setting")
+	backupRestore.dryRun = cmdBackupRestore.Flag.Bool("dryRun", false, "only list the files to restore")
+}
+
+var cmdBackupRestore = &Command{
+	UsageLine: "backup.restore -remote=cloud1/bucket/backup -time=2024-01-02T15:04:05Z -filer=localhost:8888 -to=/restored",
+	Short:     "restore the files backed up by backup.snapshot as of a time",
+	Long: `Restore the files backed up by "weed backup.snapshot" as of a time.
+
+	The namespace as of the time is materialized from the latest snapshot finished before the time,
+	and the metadata events after the snapshot started. The files are created in the filer,
+	with their chunks uploaded from the backup. The existing files are overwritten.
+	The hard links are restored as separate files.
+
+	weed backup.restore -remote=cloud1/bucket/backup -time=2024-01-02T15:04:05Z -dryRun
+	weed backup.restore -remote=cloud1/bucket/backup -time=2024-01-02T15:04:05Z -filerPath=/buckets/docs -to=/buckets/docs_restored
+
+  `,
+}
+
+func runBackupRestore(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	backupRestore.grpcDialOption = security.LoadClientTLS(util.GetViper(), "grpc.client")
+	backupRestore.filerAddress = pb.ServerAddress(*backupRestore.filer)
+
+	if *backupRestore.remote == "" {
+		return false
+	}
+	restoreTime := time.Now()
+	if *backupRestore.time != "" {
+		var err error
+		if restoreTime, err = time.Parse(time.RFC3339, *backupRestore.time); err != nil {
+			fmt.Fprintf(os.Stderr, "parse time %s: %v\n", *backupRestore.time, err)
+			return false
+		}
+	}
+
+	store, err := openBackupStore(backupRestore.grpcDialOption, backupRestore.filerAddress, *backupRestore.remote)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return true
+	}
+	ns, err := snapshot.LoadNamespace(store, util.FullPath(*backupRestore.filerPath), restoreTime.UnixNano())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load %s as of %v: %v\n", *backupRestore.remote, restoreTime, err)
+		return true
+	}
+
+	var restored int
+	for _, fullPath := range ns.Paths() {
+		if err = backupRestore.restore(store, fullPath, ns.Entries[fullPath]); err != nil {
+			fmt.Fprintf(os.Stderr, "restore %s: %v\n", fullPath, err)
+			return true
+		}
+		restored++
+	}
+	fmt.Fprintf(os.Stderr, "restored %d entries as of %v\n", restored, restoreTime)
+	return true
+}
+
+func (option *BackupRestoreOptions) restore(store snapshot.Store, fullPath util.FullPath, entry *filer_pb.Entry) error {
+	if *option.toPath != "" {
+		fullPath = util.FullPath(path.Join(*option.toPath, strings.TrimPrefix(string(fullPath), *option.filerPath)))
+	}
+	if *option.dryRun {
+		fmt.Fprintf(os.Stderr, "%s\n", fullPath)
+		return nil
+	}
+
+	entry, err := snapshot.RestoreEntry(store, entry, option.saveDataAsChunk)
+	if err != nil {
+		return err
+	}
+	dir, name := fullPath.DirAndName()
+	entry.Name = name
+	if err = option.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+			Directory: dir,
+			Entry:     entry,
+		})
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "restored %s\n", fullPath)
+	return nil
+}
+
+func (option *BackupRestoreOptions) saveDataAsChunk(reader io.Reader, name string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+	uploader, err := operation.NewUploader()
+	if err != nil {
+		return nil, fmt.Errorf("upload data: %v", err)
+	}
+	fileId, uploadResult, err, _ := uploader.UploadWithRetry(
+		option,
+		&filer_pb.AssignVolumeRequest{
+			Count:       1,
+			Replication: *option.replication,
+			Collection:  *option.collection,
+			Path:        name,
+		},
+		&operation.UploadOption{
+			Filename: name,
+		},
+		func(host, fileId string) string {
+			return fmt.Sprintf("http://%s/%s", host, fileId)
+		},
+		reader,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("upload data: %v", err)
+	}
+	if uploadResult.Error != "" {
+		return nil, fmt.Errorf("upload result: %v", uploadResult.Error)
+	}
+	return uploadResult.ToPbFileChunk(fileId, offset, tsNs), nil
+}
+
+var _ = filer_pb.FilerClient(&BackupRestoreOptions{})
+
+func (option *BackupRestoreOptions) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithFilerClient(streamingMode, 0, option.filerAddress, option.grpcDialOption, fn)
+}
+
+func (option *BackupRestoreOptions) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (option *BackupRestoreOptions) GetDataCenter() string {
+	return ""
+}
@@ -0,0 +1,224 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/remote_storage"
+	"github.com/seaweedfs/seaweedfs/weed/replication/snapshot"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var (
+	backupSnapshot BackupSnapshotOptions
+)
+
+type BackupSnapshotOptions struct {
+	filer            *string
+	filerPath        *string
+	remote           *string
+	snapshotInterval *time.Duration
+	flushInterval    *time.Duration
+
+	grpcDialOption grpc.DialOption
+	filerAddress   pb.ServerAddress
+	clientId       int32
+	clientEpoch    int32
+}
+
+func init() {
+	cmdBackupSnapshot.Run = runBackupSnapshot // break init cycle
+	backupSnapshot.filer = cmdBackupSnapshot.Flag.String("filer", "localhost:8888", "filer location")
+	backupSnapshot.filerPath = cmdBackupSnapshot.Flag.String("filerPath", "/", "back up the files under this filer path")
+	backupSnapshot.remote = cmdBackupSnapshot.Flag.String("remote", "", "the remote storage location to back up to, e.g., cloud1/bucket/backup, configured by \"remote.configure\" in weed shell")
+	backupSnapshot.snapshotInterval = cmdBackupSnapshot.Flag.Duration("snapshotInterval", 24*time.Hour, "take a new metadata snapshot after this interval, to speed up restoring")
+	backupSnapshot.flushInterval = cmdBackupSnapshot.Flag.Duration("flushInterval", time.Minute, "upload the metadata events after this interval, the restore points are up to the last upload")
+	backupSnapshot.clientId = util.RandomInt32()
+}
+
+var cmdBackupSnapshot = &Command{
+	UsageLine: "backup.snapshot -filer=localhost:8888 -filerPath=/buckets -remote=cloud1/bucket/backup",
+	Short:     "continuously back up the files to a cloud storage, for point in time restore",
+	Long: `Continuously back up the files under a filer path to a cloud storage, for point in time restore.
+
+	The cloud storage is configured by "remote.configure" in weed shell, e.g., s3, gcs or azure.
+	The backup keeps metadata snapshots, the metadata events after the snapshots, and the data chunks:
+
+		<remote>/snapshots/<start>-<stop>.snapshot
+		<remote>/logs/<start>-<stop>.log
+		<remote>/chunks/<file id>
+
+	Since the chunks are never changed, each chunk is uploaded only once, so the backup is incremental.
+	The first run takes a snapshot, and later a new snapshot is taken every -snapshotInterval.
+	The backup resumes from the last uploaded events after restarting.
+
+	Use "weed backup.restore" to restore the files as of any time after the first snapshot.
+	The old snapshots, logs and chunks are not deleted.
+
+  `,
+}
+
+func runBackupSnapshot(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	backupSnapshot.grpcDialOption = security.LoadClientTLS(util.GetViper(), "grpc.client")
+	backupSnapshot.filerAddress = pb.ServerAddress(*backupSnapshot.filer)
+
+	if *backupSnapshot.remote == "" {
+		return false
+	}
+	store, err := openBackupStore(backupSnapshot.grpcDialOption, backupSnapshot.filerAddress, *backupSnapshot.remote)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return true
+	}
+
+	b := snapshot.NewBackup(store, filer.LookupFn(&backupSnapshot))
+	lastTsNs, lastSnapshotTsNs, err := b.Resume()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resume backup from %s: %v\n", *backupSnapshot.remote, err)
+		return true
+	}
+	if lastSnapshotTsNs == 0 {
+		startTsNs := time.Now().UnixNano()
+		if lastSnapshotTsNs, err = backupSnapshot.writeSnapshot(b, startTsNs); err != nil {
+			fmt.Fprintf(os.Stderr, "snapshot: %v\n", err)
+			return true
+		}
+		// the events during the snapshot are also backed up
+		lastTsNs = startTsNs
+	}
+
+	go backupSnapshot.loopSnapshot(b, lastSnapshotTsNs)
+	go backupSnapshot.loopFlushLog(b)
+
+	util.RetryUntil("backup.snapshot", func() error {
+		return backupSnapshot.followMetadata(b, lastTsNs)
+	}, func(err error) bool {
+		glog.Errorf("backup.snapshot from %s: %v", backupSnapshot.filerAddress, err)
+		if flushedTsNs, flushErr := b.FlushLog(); flushErr == nil && flushedTsNs > lastTsNs {
+			lastTsNs = flushedTsNs
+		}
+		return true
+	})
+
+	return true
+}
+
+func openBackupStore(grpcDialOption grpc.DialOption, filerAddress pb.ServerAddress, remote string) (snapshot.Store, error) {
+	remoteConf, err := filer.ReadRemoteStorageConf(grpcDialOption, filerAddress, remote_storage.ParseLocationName(remote))
+	if err != nil {
+		return nil, fmt.Errorf("find configuration for %s: %v", remote, err)
+	}
+	loc, err := remote_storage.ParseRemoteLocation(remoteConf.Type, remote)
+	if err != nil {
+		return nil, err
+	}
+	client, err := remote_storage.GetRemoteStorage(remoteConf)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.NewRemoteStore(client, loc), nil
+}
+
+func (option *BackupSnapshotOptions) writeSnapshot(b *snapshot.Backup, startTsNs int64) (int64, error) {
+	glog.V(0).Infof("snapshot %s since %v", *option.filerPath, time.Unix(0, startTsNs))
+	return b.WriteSnapshot(startTsNs, func(eachEntryFn func(dir string, entry *filer_pb.Entry) error) error {
+		return option.traverse(util.FullPath(*option.filerPath), eachEntryFn)
+	})
+}
+
+func (option *BackupSnapshotOptions) traverse(dir util.FullPath, eachEntryFn func(dir string, entry *filer_pb.Entry) error) error {
+	var subDirs []util.FullPath
+	err := filer_pb.ReadDirAllEntries(option, dir, "", func(entry *filer_pb.Entry, isLast bool) error {
+		if entry.IsDirectory {
+			if dir.Child(entry.Name) == util.FullPath(filer.SystemLogDir) {
+				return nil
+			}
+			subDirs = append(subDirs, dir.Child(entry.Name))
+		}
+		return eachEntryFn(string(dir), entry)
+	})
+	if err != nil {
+		return fmt.Errorf("list %s: %v", dir, err)
+	}
+	for _, subDir := range subDirs {
+		if err = option.traverse(subDir, eachEntryFn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (option *BackupSnapshotOptions) loopSnapshot(b *snapshot.Backup, lastSnapshotTsNs int64) {
+	for {
+		nextSnapshotTime := time.Unix(0, lastSnapshotTsNs).Add(*option.snapshotInterval)
+		time.Sleep(time.Until(nextSnapshotTime))
+		stopTsNs, err := option.writeSnapshot(b, time.Now().UnixNano())
+		if err != nil {
+			glog.Errorf("snapshot: %v", err)
+			time.Sleep(time.Minute)
+			continue
+		}
+		lastSnapshotTsNs = stopTsNs
+	}
+}
+
+func (option *BackupSnapshotOptions) loopFlushLog(b *snapshot.Backup) {
+	for range time.Tick(*option.flushInterval) {
+		if lastTsNs, err := b.FlushLog(); err != nil {
+			glog.Errorf("upload metadata events: %v", err)
+		} else if lastTsNs > 0 {
+			glog.V(1).Infof("backup.snapshot progressed to %v", time.Unix(0, lastTsNs))
+		}
+	}
+}
+
+func (option *BackupSnapshotOptions) followMetadata(b *snapshot.Backup, startTsNs int64) error {
+	glog.V(0).Infof("backup.snapshot %s from %v", *option.filerPath, time.Unix(0, startTsNs))
+
+	prefix := *option.filerPath
+	if !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+	processEventFn := func(resp *filer_pb.SubscribeMetadataResponse) error {
+		if strings.HasPrefix(resp.Directory, filer.SystemLogDir) {
+			return nil
+		}
+		return b.AddEvent(resp)
+	}
+
+	option.clientEpoch++
+	metadataFollowOption := &pb.MetadataFollowOption{
+		ClientName:     "backup.snapshot",
+		ClientId:       option.clientId,
+		ClientEpoch:    option.clientEpoch,
+		PathPrefix:     prefix,
+		StartTsNs:      startTsNs,
+		EventErrorType: pb.RetryForeverOnError,
+	}
+	return pb.FollowMetadata(option.filerAddress, option.grpcDialOption, metadataFollowOption, processEventFn)
+}
+
+var _ = filer_pb.FilerClient(&BackupSnapshotOptions{})
+
+func (option *BackupSnapshotOptions) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithFilerClient(streamingMode, option.clientId, option.filerAddress, option.grpcDialOption, fn)
+}
+
+func (option *BackupSnapshotOptions) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (option *BackupSnapshotOptions) GetDataCenter() string {
+	return ""
+}
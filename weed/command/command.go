@@ -11,16 +11,24 @@ import (
 var Commands = []*Command{
 	cmdAutocomplete,
 	cmdUnautocomplete,
+	cmdArchiveBackup,
+	cmdArchiveRestore,
+	cmdArchiveVerify,
 	cmdBackup,
+	cmdBackupRestore,
+	cmdBackupSnapshot,
 	cmdBenchmark,
 	cmdCompact,
+	cmdDoctor,
 	cmdDownload,
 	cmdExport,
 	cmdFiler,
 	cmdFilerBackup,
 	cmdFilerCat,
 	cmdFilerCopy,
+	cmdFilerDerive,
 	cmdFilerMetaBackup,
+	cmdFilerMetaImport,
 	cmdFilerMetaTail,
 	cmdFilerRemoteGateway,
 	cmdFilerRemoteSynchronize,
@@ -32,11 +40,18 @@ var Commands = []*Command{
 	cmdMaster,
 	cmdMasterFollower,
 	cmdMount,
+	cmdMqAmqp,
 	cmdMqBroker,
+	cmdMqConnect,
+	cmdMqMirror,
+	cmdMqRecover,
+	cmdNfs,
 	cmdS3,
+	cmdS3Replicate,
 	cmdScaffold,
 	cmdServer,
 	cmdShell,
+	cmdSim,
 	cmdUpdate,
 	cmdUpload,
 	cmdVersion,
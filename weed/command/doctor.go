@@ -0,0 +1,711 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	doctorOptions DoctorOptions
+)
+
+type DoctorOptions struct {
+	masters      *string
+	filerGroup   *string
+	maxClockSkew *time.Duration
+	timeout      *time.Duration
+	jsonOutput   *bool
+}
+
+func init() {
+	cmdDoctor.Run = runDoctor // break init cycle
+	doctorOptions.masters = cmdDoctor.Flag.String("master", "localhost:9333", "comma-separated master servers")
+	doctorOptions.filerGroup = cmdDoctor.Flag.String("filerGroup", "", "the filer group of the filers and the brokers")
+	doctorOptions.maxClockSkew = cmdDoctor.Flag.Duration("maxClockSkew", time.Second, "report the servers whose clocks are off by more than this")
+	doctorOptions.timeout = cmdDoctor.Flag.Duration("timeout", 5*time.Second, "the timeout of each request to the servers")
+	doctorOptions.jsonOutput = cmdDoctor.Flag.Bool("json", false, "print the findings in json")
+}
+
+var cmdDoctor = &Command{
+	UsageLine: "doctor -master=<ip:port>",
+	Short:     "diagnose the common problems of a cluster",
+	Long: `diagnose the common problems of a cluster
+
+	This command finds the masters, volume servers, filers and brokers from the masters, connects to each of them,
+	and checks:
+	1. unreachable servers, from this machine, from the master leader, and from a filer to the volume servers.
+	2. gRPC ports that are not reachable while the HTTP ports are, i.e., not the HTTP port + 10000 as expected.
+	3. clocks off from the other servers by more than -maxClockSkew.
+	4. replication settings that the topology can not satisfy, from the master default, the existing volumes, and filer.conf.
+	5. orphaned erasure coding shards, left by an interrupted ec.encode or lost with the failed disks.
+	6. topic partitions assigned to the brokers that are gone.
+
+	Each finding comes with the suggested fix. The exit code is 1 if any error is found.
+
+	weed doctor -master=master1:9333,master2:9333,master3:9333
+
+`,
+}
+
+const (
+	doctorError   = "error"
+	doctorWarning = "warning"
+)
+
+// the kinds of the findings
+const (
+	findingUnreachable        = "unreachable"
+	findingGrpcPort           = "grpc_port"
+	findingClockSkew          = "clock_skew"
+	findingReplication        = "replication"
+	findingOrphanedEcShards   = "orphaned_ec_shards"
+	findingDeadBrokerAssigned = "dead_broker_assigned"
+)
+
+type doctorFinding struct {
+	Severity string `json:"severity"`
+	Kind     string `json:"kind"`
+	Node     string `json:"node,omitempty"`
+	Message  string `json:"message"`
+	Fix      string `json:"fix,omitempty"`
+}
+
+type doctor struct {
+	option         *DoctorOptions
+	grpcDialOption grpc.DialOption
+
+	lock         sync.Mutex
+	findings     []*doctorFinding
+	clockOffsets map[string]time.Duration // the server => its clock minus the local clock
+}
+
+func runDoctor(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	d := &doctor{
+		option:         &doctorOptions,
+		grpcDialOption: security.LoadClientTLS(util.GetViper(), "grpc.client"),
+		clockOffsets:   make(map[string]time.Duration),
+	}
+	d.diagnose()
+	d.sortFindings()
+
+	hasError := false
+	for _, f := range d.findings {
+		hasError = hasError || f.Severity == doctorError
+	}
+	if *d.option.jsonOutput {
+		if d.findings == nil {
+			d.findings = []*doctorFinding{}
+		}
+		output, _ := json.MarshalIndent(d.findings, "", "  ")
+		fmt.Println(string(output))
+	} else {
+		d.printFindings()
+	}
+	if hasError {
+		os.Exit(1)
+	}
+	return true
+}
+
+func (d *doctor) addFinding(severity, kind, node, fix, format string, args ...interface{}) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.findings = append(d.findings, &doctorFinding{
+		Severity: severity,
+		Kind:     kind,
+		Node:     node,
+		Message:  fmt.Sprintf(format, args...),
+		Fix:      fix,
+	})
+}
+
+func (d *doctor) sortFindings() {
+	sort.SliceStable(d.findings, func(i, j int) bool {
+		if d.findings[i].Severity != d.findings[j].Severity {
+			return d.findings[i].Severity == doctorError
+		}
+		return d.findings[i].Kind < d.findings[j].Kind
+	})
+}
+
+func (d *doctor) printFindings() {
+	errors, warnings := 0, 0
+	for _, f := range d.findings {
+		if f.Severity == doctorError {
+			errors++
+		} else {
+			warnings++
+		}
+		fmt.Printf("[%s] %s: %s\n", f.Severity, f.Kind, f.Message)
+		if f.Fix != "" {
+			fmt.Printf("    fix: %s\n", f.Fix)
+		}
+	}
+	if len(d.findings) == 0 {
+		fmt.Printf("no problems found\n")
+		return
+	}
+	fmt.Printf("\n%d errors, %d warnings\n", errors, warnings)
+}
+
+func (d *doctor) diagnose() {
+	leader, masters := d.findMasters()
+	if leader == "" {
+		return
+	}
+
+	var topologyInfo *master_pb.TopologyInfo
+	var defaultReplication string
+	var filers, brokers []pb.ServerAddress
+	err := d.withMasterClient(leader, func(ctx context.Context, client master_pb.SeaweedClient) error {
+		volumes, err := client.VolumeList(ctx, &master_pb.VolumeListRequest{})
+		if err != nil {
+			return fmt.Errorf("list volumes: %v", err)
+		}
+		topologyInfo = volumes.TopologyInfo
+		configuration, err := client.GetMasterConfiguration(ctx, &master_pb.GetMasterConfigurationRequest{})
+		if err != nil {
+			return fmt.Errorf("get master configuration: %v", err)
+		}
+		defaultReplication = configuration.DefaultReplication
+		if filers, err = d.listClusterNodes(ctx, client, cluster.FilerType); err != nil {
+			return err
+		}
+		brokers, err = d.listClusterNodes(ctx, client, cluster.BrokerType)
+		return err
+	})
+	if err != nil {
+		d.addFinding(doctorError, findingUnreachable, string(leader), "", "read the cluster from the master leader %s: %v", leader, err)
+		return
+	}
+
+	var volumeServers []pb.ServerAddress
+	eachDoctorDataNode(topologyInfo, func(dn *master_pb.DataNodeInfo) {
+		volumeServers = append(volumeServers, pb.NewServerAddressFromDataNode(dn))
+	})
+
+	d.checkServers(leader, masters, volumeServers, filers, brokers)
+	d.checkClockSkew()
+	d.checkReplication(topologyInfo, defaultReplication, filers)
+	d.checkEcShards(topologyInfo)
+	d.checkTopics(brokers)
+}
+
+// findMasters pings the masters, and returns the raft leader and all the masters in the raft cluster
+func (d *doctor) findMasters() (leader pb.ServerAddress, masters []pb.ServerAddress) {
+	masters = pb.ServerAddresses(*d.option.masters).ToAddresses()
+	for _, master := range masters {
+		err := d.withMasterClient(master, func(ctx context.Context, client master_pb.SeaweedClient) error {
+			configuration, err := client.GetMasterConfiguration(ctx, &master_pb.GetMasterConfigurationRequest{})
+			if err != nil {
+				return err
+			}
+			leader = pb.ServerAddress(configuration.Leader)
+			return nil
+		})
+		if err == nil && leader != "" {
+			break
+		}
+	}
+	if leader == "" {
+		d.addFinding(doctorError, findingUnreachable, "", "check the -master addresses, and that the masters are running and have elected a leader",
+			"no master leader found from %s", *d.option.masters)
+		return "", nil
+	}
+
+	// the masters of the raft cluster, which may be more than listed in -master
+	_ = d.withMasterClient(leader, func(ctx context.Context, client master_pb.SeaweedClient) error {
+		resp, err := client.RaftListClusterServers(ctx, &master_pb.RaftListClusterServersRequest{})
+		if err != nil {
+			return err
+		}
+		for _, server := range resp.ClusterServers {
+			master := pb.ServerAddress(server.Id)
+			if !containsServerAddress(masters, master) {
+				masters = append(masters, master)
+			}
+		}
+		return nil
+	})
+	return leader, masters
+}
+
+func (d *doctor) listClusterNodes(ctx context.Context, client master_pb.SeaweedClient, clientType string) (nodes []pb.ServerAddress, err error) {
+	resp, err := client.ListClusterNodes(ctx, &master_pb.ListClusterNodesRequest{
+		ClientType: clientType,
+		FilerGroup: *d.option.filerGroup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %v", clientType, err)
+	}
+	for _, node := range resp.ClusterNodes {
+		nodes = append(nodes, pb.ServerAddress(node.Address))
+	}
+	return nodes, nil
+}
+
+// checkServers connects to each server from this machine, and from the master leader and a filer, as the servers do
+func (d *doctor) checkServers(leader pb.ServerAddress, masters, volumeServers, filers, brokers []pb.ServerAddress) {
+	var wg sync.WaitGroup
+	executor := util.NewLimitedConcurrentExecutor(16)
+	check := func(fn func()) {
+		wg.Add(1)
+		executor.Execute(func() {
+			defer wg.Done()
+			fn()
+		})
+	}
+
+	for _, master := range masters {
+		master := master
+		check(func() {
+			d.pingServer(cluster.MasterType, master, "")
+		})
+	}
+	for _, volumeServer := range volumeServers {
+		volumeServer := volumeServer
+		check(func() {
+			d.pingServer(cluster.VolumeServerType, volumeServer, leader)
+		})
+	}
+	for _, filerAddress := range filers {
+		filerAddress := filerAddress
+		check(func() {
+			d.pingServer(cluster.FilerType, filerAddress, leader)
+		})
+	}
+	for _, broker := range brokers {
+		broker := broker
+		check(func() {
+			d.checkBroker(broker)
+		})
+	}
+	wg.Wait()
+
+	// the filers read and write the volume servers directly
+	if len(filers) > 0 {
+		for _, volumeServer := range volumeServers {
+			volumeServer := volumeServer
+			check(func() {
+				d.pingFromServer(cluster.FilerType, filers[0], cluster.VolumeServerType, volumeServer)
+			})
+		}
+		wg.Wait()
+	}
+}
+
+// pingServer pings the server from this machine for the reachability and the clock, and then from the master leader
+func (d *doctor) pingServer(serverType string, address, leader pb.ServerAddress) {
+	var resp pingResponse
+	start := time.Now()
+	err := d.ping(serverType, address, "", "", &resp)
+	if err != nil {
+		d.reportUnreachable(serverType, address, err)
+		return
+	}
+	stop := time.Now()
+	if resp.StartTimeNs != 0 {
+		d.lock.Lock()
+		d.clockOffsets[fmt.Sprintf("%s %s", serverType, address)] = time.Unix(0, resp.StartTimeNs).Sub(start.Add(stop.Sub(start) / 2))
+		d.lock.Unlock()
+	}
+	if leader != "" {
+		d.pingFromServer(cluster.MasterType, leader, serverType, address)
+	}
+}
+
+// pingFromServer asks the server to ping the target, to find the network problems between the servers
+func (d *doctor) pingFromServer(serverType string, address pb.ServerAddress, targetType string, target pb.ServerAddress) {
+	var resp pingResponse
+	if err := d.ping(serverType, address, targetType, target, &resp); err != nil {
+		d.addFinding(doctorError, findingUnreachable, string(target),
+			"check the firewall and the address "+string(target)+" the "+targetType+" registered, e.g., set -ip to the address reachable from the other servers",
+			"%s %s can not reach %s %s: %v", serverType, address, targetType, target, err)
+	}
+}
+
+type pingResponse struct {
+	StartTimeNs int64
+}
+
+func (d *doctor) ping(serverType string, address pb.ServerAddress, targetType string, target pb.ServerAddress, resp *pingResponse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), *d.option.timeout)
+	defer cancel()
+	switch serverType {
+	case cluster.MasterType:
+		return pb.WithMasterClient(false, address, d.grpcDialOption, false, func(client master_pb.SeaweedClient) error {
+			pingResp, err := client.Ping(ctx, &master_pb.PingRequest{Target: string(target), TargetType: targetType})
+			if err == nil {
+				resp.StartTimeNs = pingResp.StartTimeNs
+			}
+			return err
+		})
+	case cluster.VolumeServerType:
+		return pb.WithVolumeServerClient(false, address, d.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			pingResp, err := client.Ping(ctx, &volume_server_pb.PingRequest{Target: string(target), TargetType: targetType})
+			if err == nil {
+				resp.StartTimeNs = pingResp.StartTimeNs
+			}
+			return err
+		})
+	case cluster.FilerType:
+		return pb.WithFilerClient(false, 0, address, d.grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+			pingResp, err := client.Ping(ctx, &filer_pb.PingRequest{Target: string(target), TargetType: targetType})
+			if err == nil {
+				resp.StartTimeNs = pingResp.StartTimeNs
+			}
+			return err
+		})
+	}
+	return fmt.Errorf("unknown server type %s", serverType)
+}
+
+// reportUnreachable tells the gRPC port mismatch from the server down, by connecting to the HTTP port
+func (d *doctor) reportUnreachable(serverType string, address pb.ServerAddress, err error) {
+	httpAddress := address.ToHttpAddress()
+	if conn, dialErr := net.DialTimeout("tcp", httpAddress, *d.option.timeout); dialErr == nil {
+		conn.Close()
+		d.addFinding(doctorError, findingGrpcPort, string(address),
+			"start the "+serverType+" with the gRPC port of its HTTP port + 10000, or register the address as <host>:<port>.<grpcPort>, e.g., with -port.grpc, "+
+				"and check that the firewall allows the gRPC port",
+			"%s %s answers at the HTTP port, but not at the gRPC address %s: %v", serverType, address, address.ToGrpcAddress(), err)
+		return
+	}
+	d.addFinding(doctorError, findingUnreachable, string(address),
+		"check that the "+serverType+" is running, and the firewall allows its HTTP and gRPC ports",
+		"%s %s is unreachable: %v", serverType, address, err)
+}
+
+// checkBroker checks the broker by the standard gRPC health checking, since the brokers serve only gRPC
+func (d *doctor) checkBroker(broker pb.ServerAddress) {
+	ctx, cancel := context.WithTimeout(context.Background(), *d.option.timeout)
+	defer cancel()
+	err := pb.WithGrpcClient(false, 0, func(conn *grpc.ClientConn) error {
+		resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		if status.Code(err) == codes.Unimplemented {
+			return nil // the older brokers
+		}
+		if err != nil {
+			return err
+		}
+		if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			d.addFinding(doctorWarning, findingUnreachable, string(broker),
+				"see /readyz?verbose on the broker for the failed readiness checks",
+				"broker %s is %v", broker, resp.Status)
+		}
+		return nil
+	}, string(broker), false, d.grpcDialOption)
+	if err != nil {
+		d.addFinding(doctorError, findingUnreachable, string(broker),
+			"check that the broker is running, and the firewall allows its port",
+			"broker %s is unreachable: %v", broker, err)
+	}
+}
+
+// checkClockSkew compares the clocks with the median of all servers, so the clock of this machine does not matter
+func (d *doctor) checkClockSkew() {
+	for _, skew := range findClockSkews(d.clockOffsets, *d.option.maxClockSkew) {
+		d.addFinding(doctorWarning, findingClockSkew, skew.server,
+			"synchronize the clock with NTP, e.g., chrony; the skewed clocks break the TTL, the JWT expiration and the metadata subscriptions",
+			"the clock of %s is off by %v from the other servers", skew.server, skew.offset.Round(time.Millisecond))
+	}
+}
+
+type clockSkew struct {
+	server string
+	offset time.Duration
+}
+
+func findClockSkews(offsets map[string]time.Duration, maxClockSkew time.Duration) (skews []clockSkew) {
+	if len(offsets) < 2 {
+		return nil
+	}
+	var sorted []time.Duration
+	for _, offset := range offsets {
+		sorted = append(sorted, offset)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+	for server, offset := range offsets {
+		if skew := offset - median; skew > maxClockSkew || skew < -maxClockSkew {
+			skews = append(skews, clockSkew{server: server, offset: skew})
+		}
+	}
+	sort.Slice(skews, func(i, j int) bool { return skews[i].server < skews[j].server })
+	return
+}
+
+// placementRequirement is a replication setting to satisfy, with where it comes from
+type placementRequirement struct {
+	source      string
+	replication string
+	diskType    string
+	dataCenter  string
+	rack        string
+}
+
+// checkReplication checks that the topology has enough data centers, racks and servers for each replication setting
+func (d *doctor) checkReplication(topologyInfo *master_pb.TopologyInfo, defaultReplication string, filers []pb.ServerAddress) {
+	requirements := []placementRequirement{{source: "the master default replication", replication: defaultReplication}}
+	seen := make(map[string]bool)
+	eachDoctorDataNode(topologyInfo, func(dn *master_pb.DataNodeInfo) {
+		for _, diskInfo := range dn.DiskInfos {
+			for _, v := range diskInfo.VolumeInfos {
+				rp, err := super_block.NewReplicaPlacementFromByte(byte(v.ReplicaPlacement))
+				if err != nil || seen[v.Collection+"/"+rp.String()+"/"+v.DiskType] {
+					continue
+				}
+				seen[v.Collection+"/"+rp.String()+"/"+v.DiskType] = true
+				requirements = append(requirements, placementRequirement{
+					source:      fmt.Sprintf("the volumes of collection %q", v.Collection),
+					replication: rp.String(),
+					diskType:    v.DiskType,
+				})
+			}
+		}
+	})
+	for _, filerAddress := range filers {
+		fc, err := filer.ReadFilerConf(filerAddress, d.grpcDialOption, nil)
+		if err != nil {
+			continue
+		}
+		for _, location := range fc.ToProto().Locations {
+			if location.Replication == "" && location.DiskType == "" && location.DataCenter == "" && location.Rack == "" {
+				continue
+			}
+			replication := location.Replication
+			if replication == "" {
+				replication = defaultReplication
+			}
+			requirements = append(requirements, placementRequirement{
+				source:      fmt.Sprintf("the filer.conf rule of %s", location.LocationPrefix),
+				replication: replication,
+				diskType:    location.DiskType,
+				dataCenter:  location.DataCenter,
+				rack:        location.Rack,
+			})
+		}
+		break
+	}
+
+	for _, requirement := range requirements {
+		if requirement.replication == "" {
+			continue
+		}
+		rp, err := super_block.NewReplicaPlacementFromString(requirement.replication)
+		if err != nil {
+			d.addFinding(doctorError, findingReplication, "", "set a valid replication, e.g., 001", "%s has invalid replication %q: %v", requirement.source, requirement.replication, err)
+			continue
+		}
+		if err := checkPlacementFeasible(topologyInfo, rp, requirement.diskType, requirement.dataCenter, requirement.rack); err != nil {
+			d.addFinding(doctorError, findingReplication, "",
+				"add the servers, set -dataCenter and -rack of the volume servers as deployed, or lower the replication, e.g., with volume.configure.replication or fs.configure",
+				"the replication %s of %s can not be satisfied: %v", requirement.replication, requirement.source, err)
+		}
+	}
+}
+
+// checkPlacementFeasible checks that there is a rack with enough servers, in a data center with enough racks,
+// and enough other data centers, counting the servers with the disk type only
+func checkPlacementFeasible(topologyInfo *master_pb.TopologyInfo, rp *super_block.ReplicaPlacement, diskType, dataCenter, rack string) error {
+	diskTypeKey := string(types.ToDiskType(diskType))
+	dataCenterCount, maxRackCount, maxServerCount := 0, 0, 0
+	mainDataCenterFound := false
+	for _, dc := range topologyInfo.GetDataCenterInfos() {
+		rackCount := 0
+		for _, r := range dc.RackInfos {
+			serverCount := 0
+			for _, dn := range r.DataNodeInfos {
+				if diskInfo, found := dn.DiskInfos[diskTypeKey]; found && diskInfo.MaxVolumeCount > 0 {
+					serverCount++
+				}
+			}
+			if serverCount == 0 {
+				continue
+			}
+			rackCount++
+			if (dataCenter == "" || dc.Id == dataCenter) && (rack == "" || r.Id == rack) && serverCount > maxServerCount {
+				maxServerCount = serverCount
+			}
+		}
+		if rackCount == 0 {
+			continue
+		}
+		dataCenterCount++
+		if dataCenter == "" || dc.Id == dataCenter {
+			mainDataCenterFound = true
+			if rackCount > maxRackCount {
+				maxRackCount = rackCount
+			}
+		}
+	}
+
+	diskTypeName := types.ToDiskType(diskType).ReadableString()
+	switch {
+	case !mainDataCenterFound:
+		return fmt.Errorf("no %s volume servers in data center %q", diskTypeName, dataCenter)
+	case dataCenterCount < rp.DiffDataCenterCount+1:
+		return fmt.Errorf("needs %d data centers with %s volume servers, found %d", rp.DiffDataCenterCount+1, diskTypeName, dataCenterCount)
+	case maxRackCount < rp.DiffRackCount+1:
+		return fmt.Errorf("needs %d racks with %s volume servers in a data center, found at most %d", rp.DiffRackCount+1, diskTypeName, maxRackCount)
+	case maxServerCount < rp.SameRackCount+1:
+		return fmt.Errorf("needs %d %s volume servers in a rack, found at most %d", rp.SameRackCount+1, diskTypeName, maxServerCount)
+	}
+	return nil
+}
+
+// checkEcShards reports the erasure coding shards that are not usable as they are
+func (d *doctor) checkEcShards(topologyInfo *master_pb.TopologyInfo) {
+	for _, orphan := range findOrphanedEcShards(topologyInfo) {
+		switch {
+		case orphan.hasVolume && orphan.shardCount >= orphan.scheme.TotalShards():
+			d.addFinding(doctorWarning, findingOrphanedEcShards, "",
+				fmt.Sprintf("if no ec.encode is running, verify the shards and delete the original with volume.delete -volumeId=%d", orphan.vid),
+				"volume %d has all %d erasure coding shards, but the original volume is not deleted", orphan.vid, orphan.shardCount)
+		case orphan.hasVolume:
+			d.addFinding(doctorWarning, findingOrphanedEcShards, "",
+				fmt.Sprintf("if no ec.encode or ec.decode is running, encode the volume again with ec.encode -volumeId=%d, which replaces the partial shards", orphan.vid),
+				"volume %d has %d of %d erasure coding shards, left by an interrupted conversion", orphan.vid, orphan.shardCount, orphan.scheme.TotalShards())
+		case orphan.shardCount < orphan.scheme.DataShards:
+			d.addFinding(doctorError, findingOrphanedEcShards, "",
+				"recover the missing shards from the failed disks, or delete the remaining shards after confirming the data loss",
+				"volume %d has only %d erasure coding shards, fewer than the %d data shards to decode", orphan.vid, orphan.shardCount, orphan.scheme.DataShards)
+		default:
+			d.addFinding(doctorWarning, findingOrphanedEcShards, "",
+				"rebuild the missing shards with ec.rebuild -force",
+				"volume %d has %d of %d erasure coding shards", orphan.vid, orphan.shardCount, orphan.scheme.TotalShards())
+		}
+	}
+}
+
+type orphanedEcShards struct {
+	vid        uint32
+	shardCount int
+	scheme     erasure_coding.Scheme
+	hasVolume  bool // the original volume still exists
+}
+
+func findOrphanedEcShards(topologyInfo *master_pb.TopologyInfo) (orphans []orphanedEcShards) {
+	shards := make(map[uint32]erasure_coding.ShardBits)
+	schemes := make(map[uint32]erasure_coding.Scheme)
+	volumes := make(map[uint32]bool)
+	eachDoctorDataNode(topologyInfo, func(dn *master_pb.DataNodeInfo) {
+		for _, diskInfo := range dn.DiskInfos {
+			for _, v := range diskInfo.VolumeInfos {
+				volumes[v.Id] = true
+			}
+			for _, ecShardInfo := range diskInfo.EcShardInfos {
+				shards[ecShardInfo.Id] = shards[ecShardInfo.Id].Plus(erasure_coding.ShardBits(ecShardInfo.EcIndexBits))
+				schemes[ecShardInfo.Id] = erasure_coding.NewScheme(ecShardInfo.DataShards, ecShardInfo.ParityShards)
+			}
+		}
+	})
+	for vid, shardBits := range shards {
+		orphan := orphanedEcShards{vid: vid, shardCount: shardBits.ShardIdCount(), scheme: schemes[vid], hasVolume: volumes[vid]}
+		if orphan.hasVolume || orphan.shardCount < orphan.scheme.TotalShards() {
+			orphans = append(orphans, orphan)
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].vid < orphans[j].vid })
+	return
+}
+
+// checkTopics looks up the topic partitions from a broker, and reports those on the brokers not registered on the master
+func (d *doctor) checkTopics(brokers []pb.ServerAddress) {
+	liveBrokers := make(map[string]bool)
+	for _, broker := range brokers {
+		liveBrokers[string(broker)] = true
+	}
+	for _, broker := range brokers {
+		ctx, cancel := context.WithTimeout(context.Background(), *d.option.timeout*2)
+		err := pb.WithBrokerGrpcClient(false, string(broker), d.grpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
+			topics, err := client.ListTopics(ctx, &mq_pb.ListTopicsRequest{})
+			if err != nil {
+				return err
+			}
+			for _, t := range topics.Topics {
+				resp, err := client.LookupTopicBrokers(ctx, &mq_pb.LookupTopicBrokersRequest{Topic: t})
+				if err != nil {
+					return err
+				}
+				d.checkTopicAssignments(topic.FromPbTopic(t).String(), resp.BrokerPartitionAssignments, liveBrokers)
+			}
+			return nil
+		})
+		cancel()
+		if err == nil {
+			return
+		}
+	}
+}
+
+func (d *doctor) checkTopicAssignments(topicName string, assignments []*mq_pb.BrokerPartitionAssignment, liveBrokers map[string]bool) {
+	fix := fmt.Sprintf("restart the broker, or reassign the partitions to the live brokers with weed mq.recover -master=%s -apply", *d.option.masters)
+	for _, assignment := range assignments {
+		partition := assignment.Partition
+		if assignment.LeaderBroker != "" && !liveBrokers[assignment.LeaderBroker] {
+			d.addFinding(doctorError, findingDeadBrokerAssigned, assignment.LeaderBroker, fix,
+				"topic %s partition [%d,%d) is led by broker %s, which is not registered on the master",
+				topicName, partition.GetRangeStart(), partition.GetRangeStop(), assignment.LeaderBroker)
+		}
+		if assignment.FollowerBroker != "" && !liveBrokers[assignment.FollowerBroker] {
+			d.addFinding(doctorWarning, findingDeadBrokerAssigned, assignment.FollowerBroker, fix,
+				"topic %s partition [%d,%d) is followed by broker %s, which is not registered on the master",
+				topicName, partition.GetRangeStart(), partition.GetRangeStop(), assignment.FollowerBroker)
+		}
+	}
+}
+
+func (d *doctor) withMasterClient(master pb.ServerAddress, fn func(ctx context.Context, client master_pb.SeaweedClient) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), *d.option.timeout)
+	defer cancel()
+	return pb.WithMasterClient(false, master, d.grpcDialOption, false, func(client master_pb.SeaweedClient) error {
+		return fn(ctx, client)
+	})
+}
+
+func eachDoctorDataNode(topologyInfo *master_pb.TopologyInfo, fn func(dn *master_pb.DataNodeInfo)) {
+	for _, dc := range topologyInfo.GetDataCenterInfos() {
+		for _, r := range dc.RackInfos {
+			for _, dn := range r.DataNodeInfos {
+				fn(dn)
+			}
+		}
+	}
+}
+
+func containsServerAddress(addresses []pb.ServerAddress, address pb.ServerAddress) bool {
+	for _, a := range addresses {
+		if strings.EqualFold(a.ToHttpAddress(), address.ToHttpAddress()) {
+			return true
+		}
+	}
+	return false
+}
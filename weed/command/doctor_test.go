@@ -0,0 +1,129 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+)
+
+// newDoctorTopology creates the data centers of the racks, each with the servers of the disk types
+func newDoctorTopology(dataCenters map[string]map[string][]string) *master_pb.TopologyInfo {
+	topologyInfo := &master_pb.TopologyInfo{}
+	for dcId, racks := range dataCenters {
+		dc := &master_pb.DataCenterInfo{Id: dcId}
+		for rackId, diskTypes := range racks {
+			rack := &master_pb.RackInfo{Id: rackId}
+			for i, diskType := range diskTypes {
+				rack.DataNodeInfos = append(rack.DataNodeInfos, &master_pb.DataNodeInfo{
+					Id:        dcId + rackId + string(rune('a'+i)) + ":8080",
+					DiskInfos: map[string]*master_pb.DiskInfo{diskType: {Type: diskType, MaxVolumeCount: 8}},
+				})
+			}
+			dc.RackInfos = append(dc.RackInfos, rack)
+		}
+		topologyInfo.DataCenterInfos = append(topologyInfo.DataCenterInfos, dc)
+	}
+	return topologyInfo
+}
+
+func TestCheckPlacementFeasible(t *testing.T) {
+	topologyInfo := newDoctorTopology(map[string]map[string][]string{
+		"dc1": {"rack1": {"", ""}, "rack2": {"", "ssd"}},
+		"dc2": {"rack1": {"ssd"}},
+	})
+	tests := []struct {
+		replication string
+		diskType    string
+		dataCenter  string
+		rack        string
+		err         string
+	}{
+		{replication: "000"},
+		{replication: "001"},
+		{replication: "011"},
+		{replication: "100", err: "needs 2 data centers with hdd volume servers, found 1"},
+		{replication: "002", err: "needs 3 hdd volume servers in a rack, found at most 2"},
+		{replication: "020", err: "needs 3 racks"},
+		{replication: "200", err: "needs 3 data centers"},
+		{replication: "100", diskType: "ssd"},
+		{replication: "001", diskType: "ssd", err: "needs 2 ssd volume servers in a rack, found at most 1"},
+		{replication: "001", dataCenter: "dc2", err: "no hdd volume servers in data center"},
+		{replication: "001", dataCenter: "dc1", rack: "rack2", err: "needs 2 hdd volume servers in a rack, found at most 1"},
+	}
+	for _, tt := range tests {
+		rp, _ := super_block.NewReplicaPlacementFromString(tt.replication)
+		err := checkPlacementFeasible(topologyInfo, rp, tt.diskType, tt.dataCenter, tt.rack)
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("replication %s disk %q dc %q rack %q: expected %q, got %v", tt.replication, tt.diskType, tt.dataCenter, tt.rack, tt.err, err)
+		}
+	}
+}
+
+func TestFindClockSkews(t *testing.T) {
+	// the local clock is 10 seconds behind all the servers, except the skewed one
+	skews := findClockSkews(map[string]time.Duration{
+		"master a": 10 * time.Second,
+		"master b": 10*time.Second + 100*time.Millisecond,
+		"filer c":  10 * time.Second,
+		"volume d": 13 * time.Second,
+	}, time.Second)
+	if len(skews) != 1 || skews[0].server != "volume d" || skews[0].offset != 2900*time.Millisecond {
+		t.Errorf("unexpected skews %+v", skews)
+	}
+	if skews := findClockSkews(map[string]time.Duration{"master a": time.Hour}, time.Second); len(skews) != 0 {
+		t.Errorf("one server can not be skewed, got %+v", skews)
+	}
+}
+
+func TestFindOrphanedEcShards(t *testing.T) {
+	topologyInfo := newDoctorTopology(map[string]map[string][]string{"dc1": {"rack1": {"", ""}}})
+	disks := []*master_pb.DiskInfo{
+		topologyInfo.DataCenterInfos[0].RackInfos[0].DataNodeInfos[0].DiskInfos[""],
+		topologyInfo.DataCenterInfos[0].RackInfos[0].DataNodeInfos[1].DiskInfos[""],
+	}
+	disks[0].VolumeInfos = []*master_pb.VolumeInformationMessage{{Id: 1}, {Id: 2}}
+	disks[0].EcShardInfos = []*master_pb.VolumeEcShardInformationMessage{
+		{Id: 1, EcIndexBits: 0x7f},   // the interrupted ec.encode
+		{Id: 2, EcIndexBits: 0x3fff}, // the original not deleted
+		{Id: 3, EcIndexBits: 0x3ff},  // with the data shards only
+		{Id: 5, EcIndexBits: 0xff},   // with 8 of 10 data shards
+	}
+	disks[1].EcShardInfos = []*master_pb.VolumeEcShardInformationMessage{
+		{Id: 4, EcIndexBits: 0x7f},
+		{Id: 4, EcIndexBits: 0x3f80}, // complete on two servers
+	}
+
+	orphans := findOrphanedEcShards(topologyInfo)
+	expected := []orphanedEcShards{
+		{vid: 1, shardCount: 7, hasVolume: true},
+		{vid: 2, shardCount: 14, hasVolume: true},
+		{vid: 3, shardCount: 10},
+		{vid: 5, shardCount: 8},
+	}
+	if len(orphans) != len(expected) {
+		t.Fatalf("expected %d orphans, got %+v", len(expected), orphans)
+	}
+	for i, orphan := range orphans {
+		if orphan.vid != expected[i].vid || orphan.shardCount != expected[i].shardCount || orphan.hasVolume != expected[i].hasVolume {
+			t.Errorf("expected %+v, got %+v", expected[i], orphan)
+		}
+	}
+}
+
+func TestCheckTopicAssignments(t *testing.T) {
+	masters := "localhost:9333"
+	d := &doctor{option: &DoctorOptions{masters: &masters}}
+	d.checkTopicAssignments("test.topic", []*mq_pb.BrokerPartitionAssignment{
+		{Partition: &schema_pb.Partition{RangeStart: 0, RangeStop: 512}, LeaderBroker: "broker1:17777", FollowerBroker: "broker2:17777"},
+		{Partition: &schema_pb.Partition{RangeStart: 512, RangeStop: 1024}, LeaderBroker: "broker3:17777"},
+	}, map[string]bool{"broker1:17777": true, "broker2:17777": true})
+
+	if len(d.findings) != 1 || d.findings[0].Severity != doctorError || d.findings[0].Node != "broker3:17777" {
+		t.Errorf("expected the partition led by the dead broker, got %+v", d.findings)
+	}
+}
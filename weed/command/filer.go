@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
@@ -69,12 +70,29 @@ type FilerOptions struct {
 	diskType                *string
 	allowedOrigins          *string
 	exposeDirectoryData     *bool
+	metaLogRetention        *time.Duration
+	metaLogSegment          *time.Duration
+	metaLogCompactAfter     *time.Duration
+	dirStatsDepth           *int
+	dirStatsReconcile       *time.Duration
+	readTransformWorkers    *int
+	listConcurrency         *int
+	readConcurrency         *int
+	writeConcurrency        *int
+	deleteConcurrency       *int
+	maxQueuedRequests       *int
+	requestQueueTimeout     *time.Duration
+	clientLimitKeyBy        *string
+	clientRequestsPerSecond *float64
+	clientRequestBurst      *int
+	clientUploads           *int
+	clientUploadMBps        *int
 	certProvider            certprovider.Provider
 }
 
 func init() {
 	cmdFiler.Run = runFiler // break init cycle
-	f.mastersString = cmdFiler.Flag.String("master", "localhost:9333", "comma-separated master servers or a single DNS SRV record of at least 1 master server, prepended with dnssrv+")
+	f.mastersString = cmdFiler.Flag.String("master", "localhost:9333", "comma-separated master servers, a single DNS SRV record of at least 1 master server prepended with dnssrv+, or a service registry to find the masters from and register in, e.g., consul+localhost:8500")
 	f.filerGroup = cmdFiler.Flag.String("filerGroup", "", "share metadata with other filers in the same filerGroup")
 	f.collection = cmdFiler.Flag.String("collection", "", "all data will be stored in this default collection")
 	f.ip = cmdFiler.Flag.String("ip", util.DetectedHostAddress(), "filer server http listen ip address")
@@ -103,6 +121,23 @@ func init() {
 	f.diskType = cmdFiler.Flag.String("disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
 	f.allowedOrigins = cmdFiler.Flag.String("allowedOrigins", "*", "comma separated list of allowed origins")
 	f.exposeDirectoryData = cmdFiler.Flag.Bool("exposeDirectoryData", true, "whether to return directory metadata and content in Filer UI")
+	f.metaLogRetention = cmdFiler.Flag.Duration("metaLog.retention", 0, "delete the metadata change logs older than this, 0 to keep them forever")
+	f.metaLogSegment = cmdFiler.Flag.Duration("metaLog.segment", time.Minute, "each metadata log file collects the changes of this duration, whole minutes dividing an hour")
+	f.metaLogCompactAfter = cmdFiler.Flag.Duration("metaLog.compactAfter", 0, "merge the chunks of the metadata log files older than this, 0 to disable")
+	f.dirStatsDepth = cmdFiler.Flag.Int("dirStats.depth", 0, "cache the recursive size and file counts of the directories up to this depth, e.g., 2 for /buckets/<bucket>, 0 to disable")
+	f.dirStatsReconcile = cmdFiler.Flag.Duration("dirStats.reconcileInterval", 24*time.Hour, "recount the cached directory statistics this often, 0 to disable")
+	f.readTransformWorkers = cmdFiler.Flag.Int("readTransformWorkers", 0, "number of reads decrypting, decompressing or resizing images at the same time, 0 for the number of CPUs")
+	f.listConcurrency = cmdFiler.Flag.Int("concurrency.list", 0, "max concurrent directory listings over http and gRPC, the others are queued, 0 for unlimited")
+	f.readConcurrency = cmdFiler.Flag.Int("concurrency.read", 0, "max concurrent lookups and file reads over http and gRPC, the others are queued, 0 for unlimited")
+	f.writeConcurrency = cmdFiler.Flag.Int("concurrency.write", 0, "max concurrent creates, updates, renames and uploads over http and gRPC, the others are queued, 0 for unlimited")
+	f.deleteConcurrency = cmdFiler.Flag.Int("concurrency.delete", 0, "max concurrent deletes over http and gRPC, the others are queued, 0 for unlimited")
+	f.maxQueuedRequests = cmdFiler.Flag.Int("concurrency.maxQueued", 1000, "max queued requests of each limited type, the others are rejected with 429 or RESOURCE_EXHAUSTED")
+	f.requestQueueTimeout = cmdFiler.Flag.Duration("concurrency.queueTimeout", 10*time.Second, "reject the queued requests waiting longer than this with 429 or RESOURCE_EXHAUSTED, 0 to wait until admitted")
+	f.clientLimitKeyBy = cmdFiler.Flag.String("clientLimit.keyBy", "ip", "tell the clients apart by [ip|identity], where identity is the subject of the verified jwt, or else the ip")
+	f.clientRequestsPerSecond = cmdFiler.Flag.Float64("clientLimit.requestsPerSecond", 0, "max http requests per second of each client, the others are rejected with 429, 0 for unlimited")
+	f.clientRequestBurst = cmdFiler.Flag.Int("clientLimit.burst", 0, "max http requests of each client in a burst over the requests per second, 0 for the requests per second")
+	f.clientUploads = cmdFiler.Flag.Int("clientLimit.concurrentUploads", 0, "max concurrent http uploads of each client, the others are rejected with 429, 0 for unlimited")
+	f.clientUploadMBps = cmdFiler.Flag.Int("clientLimit.uploadMBps", 0, "max http upload bandwidth of each client in MB/s, 0 for unlimited")
 
 	// start s3 on filer
 	filerStartS3 = cmdFiler.Flag.Bool("s3", false, "whether to start S3 gateway")
@@ -119,6 +154,7 @@ func init() {
 	filerS3Options.allowEmptyFolder = cmdFiler.Flag.Bool("s3.allowEmptyFolder", true, "allow empty folders")
 	filerS3Options.allowDeleteBucketNotEmpty = cmdFiler.Flag.Bool("s3.allowDeleteBucketNotEmpty", true, "allow recursive deleting all entries along with bucket")
 	filerS3Options.localSocket = cmdFiler.Flag.String("s3.localSocket", "", "default to /tmp/seaweedfs-s3-<port>.sock")
+	filerS3Options.metricsMaxLabelValues = cmdFiler.Flag.Int("s3.metricsMaxLabelValues", 1000, "max number of active buckets and identities having their own metrics, the rest are counted as \"_other\". 0 means no limit.")
 
 	// start webdav on filer
 	filerStartWebDav = cmdFiler.Flag.Bool("webdav", false, "whether to start webdav gateway")
@@ -156,6 +192,10 @@ func filerLongDesc() string {
 
 	The example filer.toml configuration file can be generated by "weed scaffold -config=filer"
 
+	Without the store transactions, a directory is renamed by moving the entries one by one. The filer running
+	the rename makes it look atomic to its own clients, and resumes it if interrupted. The other filers sharing
+	the same filer store may see the partially moved directory until the rename finishes.
+
 Supported Filer Stores:
 `
 
@@ -248,6 +288,8 @@ func (fo *FilerOptions) GetCertificateWithUpdate(*tls.ClientHelloInfo) (*tls.Cer
 
 func (fo *FilerOptions) startFiler() {
 
+	loadAuditConfiguration()
+
 	defaultMux := http.NewServeMux()
 	publicVolumeMux := defaultMux
 
@@ -263,10 +305,14 @@ func (fo *FilerOptions) startFiler() {
 	if *fo.allowedOrigins == "" {
 		*fo.allowedOrigins = "*"
 	}
+	if *fo.clientLimitKeyBy != "ip" && *fo.clientLimitKeyBy != "identity" {
+		glog.Fatalf("unknown clientLimit.keyBy %s, expected ip or identity", *fo.clientLimitKeyBy)
+	}
 
 	defaultLevelDbDirectory := util.ResolvePath(*fo.defaultLevelDbDirectory + "/filerldb2")
 
 	filerAddress := pb.NewServerAddress(*fo.ip, *fo.port, *fo.portGrpc)
+	registerServer(*fo.mastersString, cluster.FilerType, filerAddress)
 
 	fs, nfs_err := weed_server.NewFilerServer(defaultMux, publicVolumeMux, &weed_server.FilerOption{
 		Masters:               fo.masters,
@@ -286,8 +332,33 @@ func (fo *FilerOptions) startFiler() {
 		ConcurrentUploadLimit: int64(*fo.concurrentUploadLimitMB) * 1024 * 1024,
 		ShowUIDirectoryDelete: *fo.showUIDirectoryDelete,
 		DownloadMaxBytesPs:    int64(*fo.downloadMaxMBps) * 1024 * 1024,
+		ReadTransformWorkers:  *fo.readTransformWorkers,
 		DiskType:              *fo.diskType,
 		AllowedOrigins:        strings.Split(*fo.allowedOrigins, ","),
+		MetaLog: filer.MetaLogOption{
+			Retention:       *fo.metaLogRetention,
+			SegmentDuration: *fo.metaLogSegment,
+			CompactAfter:    *fo.metaLogCompactAfter,
+		},
+		DirStats: filer.DirStatsOption{
+			Depth:             *fo.dirStatsDepth,
+			ReconcileInterval: *fo.dirStatsReconcile,
+		},
+		Admission: weed_server.FilerAdmissionOption{
+			ListConcurrency:   *fo.listConcurrency,
+			ReadConcurrency:   *fo.readConcurrency,
+			WriteConcurrency:  *fo.writeConcurrency,
+			DeleteConcurrency: *fo.deleteConcurrency,
+			MaxQueued:         *fo.maxQueuedRequests,
+			QueueTimeout:      *fo.requestQueueTimeout,
+		},
+		ClientLimits: weed_server.FilerClientLimitOption{
+			KeyBy:                *fo.clientLimitKeyBy,
+			RequestsPerSecond:    *fo.clientRequestsPerSecond,
+			Burst:                *fo.clientRequestBurst,
+			ConcurrentUploads:    *fo.clientUploads,
+			UploadBytesPerSecond: int64(*fo.clientUploadMBps) * 1024 * 1024,
+		},
 	})
 	if nfs_err != nil {
 		glog.Fatalf("Filer startup error: %v", nfs_err)
@@ -329,9 +400,11 @@ func (fo *FilerOptions) startFiler() {
 	if err != nil {
 		glog.Fatalf("failed to listen on grpc port %d: %v", grpcPort, err)
 	}
-	grpcS := pb.NewGrpcServer(security.LoadServerTLS(util.GetViper(), "grpc.filer"))
+	serverTlsOption, _ := security.LoadServerTLS(util.GetViper(), "grpc.filer")
+	grpcS := pb.NewGrpcServer(append(append(fs.AuditServerOptions(), fs.AdmissionServerOptions()...), serverTlsOption)...)
 	filer_pb.RegisterSeaweedFilerServer(grpcS, fs)
 	reflection.Register(grpcS)
+	fs.RegisterGrpcHealth(grpcS)
 	if grpcLocalL != nil {
 		go grpcS.Serve(grpcLocalL)
 	}
@@ -0,0 +1,141 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/derive"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+	"google.golang.org/grpc"
+)
+
+var (
+	filerDeriveOptions FilerDeriveOptions
+)
+
+type FilerDeriveOptions struct {
+	name            *string
+	filer           *string
+	concurrency     *int
+	maxRetries      *int
+	retryInterval   *time.Duration
+	chunkSizeMB     *int
+	startFromLatest *bool
+	grpcDialOption  grpc.DialOption
+	clientId        int32
+}
+
+var _ = filer_pb.FilerClient(&FilerDeriveOptions{})
+
+func init() {
+	cmdFilerDerive.Run = runFilerDerive // break init cycle
+	filerDeriveOptions.name = cmdFilerDerive.Flag.String("name", "derive", "name of the worker, to track the progress")
+	filerDeriveOptions.filer = cmdFilerDerive.Flag.String("filer", "localhost:8888", "filer of one SeaweedFS cluster")
+	filerDeriveOptions.concurrency = cmdFilerDerive.Flag.Int("concurrency", 4, "number of files to derive at the same time")
+	filerDeriveOptions.maxRetries = cmdFilerDerive.Flag.Int("maxRetries", 3, "give up a file after this many retries")
+	filerDeriveOptions.retryInterval = cmdFilerDerive.Flag.Duration("retryInterval", 10*time.Second, "wait this long before the first retry, doubled for each next retry")
+	filerDeriveOptions.chunkSizeMB = cmdFilerDerive.Flag.Int("chunkSizeMB", 8, "split the derived files into chunks of this size")
+	filerDeriveOptions.startFromLatest = cmdFilerDerive.Flag.Bool("startFromLatest", false, "without a checkpoint, start from now on instead of the earliest change in the filer metadata log")
+	filerDeriveOptions.clientId = util.RandomInt32()
+}
+
+var cmdFilerDerive = &Command{
+	UsageLine: "filer.derive -name=thumbnails -filer=<filerHost>:<filerPort>",
+	Short:     "generate thumbnails, transcodes, or checksums of the files as they are written to the filer",
+	Long: `generate thumbnails, transcodes, or checksums of the files as they are written to the filer
+
+	filer.derive follows the filer metadata changes. For each file created or changed under the path prefixes
+	of a rule, it generates the derived file by the rule handler, and writes it next to the source file,
+	e.g., /photos/.thumbnails/a.jpg for /photos/a.jpg. When the source file is deleted or renamed, the derived
+	file is deleted too.
+
+	The derived files are marked with the extended attributes, so they are not derived again, and are only
+	regenerated when the content of the source file changes. The failed files are retried a few times, and then
+	skipped with an error logged.
+
+	The progress is saved in the filer under /etc/seaweedfs/derive/<name>, so the worker resumes from there after
+	a restart.
+
+	Run "weed scaffold -config=derive" to generate a derive.toml file, and enable the rules.
+
+`,
+}
+
+func runFilerDerive(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	util.LoadConfiguration("derive", true)
+	util_http.InitGlobalHttpClient()
+	config := util.GetViper()
+
+	filerDeriveOptions.grpcDialOption = security.LoadClientTLS(util.GetViper(), "grpc.client")
+
+	var ruleNames []string
+	for ruleName := range config.GetStringMap("rule") {
+		ruleNames = append(ruleNames, ruleName)
+	}
+	sort.Strings(ruleNames)
+	var rules []*derive.Rule
+	for _, ruleName := range ruleNames {
+		prefix := "rule." + ruleName + "."
+		if !config.GetBool(prefix + "enabled") {
+			continue
+		}
+		rule, err := derive.NewRule(ruleName, config, prefix)
+		if err != nil {
+			glog.Errorf("derive.toml: %v", err)
+			return false
+		}
+		glog.V(0).Infof("rule %s: %s of %v %v to %s", rule.Name, rule.HandlerName, rule.PathPrefixes, rule.NamePattern, rule.Target)
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		var handlerNames []string
+		for handlerName := range derive.Handlers {
+			handlerNames = append(handlerNames, handlerName)
+		}
+		sort.Strings(handlerNames)
+		fmt.Printf("no rule enabled in derive.toml, with the handlers: %s\n", strings.Join(handlerNames, ", "))
+		return false
+	}
+
+	w := derive.NewWorker(&derive.WorkerOption{
+		Name:            *filerDeriveOptions.name,
+		FilerAddress:    pb.ServerAddress(*filerDeriveOptions.filer),
+		GrpcDialOption:  filerDeriveOptions.grpcDialOption,
+		FilerClient:     &filerDeriveOptions,
+		ClientId:        filerDeriveOptions.clientId,
+		Rules:           rules,
+		Concurrency:     *filerDeriveOptions.concurrency,
+		MaxRetries:      *filerDeriveOptions.maxRetries,
+		RetryInterval:   *filerDeriveOptions.retryInterval,
+		ChunkSizeMB:     *filerDeriveOptions.chunkSizeMB,
+		StartFromLatest: *filerDeriveOptions.startFromLatest,
+	})
+	if err := w.Run(); err != nil {
+		glog.Errorf("filer.derive: %v", err)
+		return false
+	}
+	return true
+}
+
+func (option *FilerDeriveOptions) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithFilerClient(streamingMode, option.clientId, pb.ServerAddress(*option.filer), option.grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		return fn(client)
+	})
+}
+
+func (option *FilerDeriveOptions) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (option *FilerDeriveOptions) GetDataCenter() string {
+	return ""
+}
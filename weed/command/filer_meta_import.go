@@ -0,0 +1,164 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	metaImport FilerMetaImportOptions
+)
+
+type FilerMetaImportOptions struct {
+	config    *string
+	batchSize *int
+	dirPrefix *string
+}
+
+func init() {
+	cmdFilerMetaImport.Run = runFilerMetaImport // break init cycle
+	metaImport.config = cmdFilerMetaImport.Flag.String("config", "", "path to filer.toml specifying the filer store to import into")
+	metaImport.batchSize = cmdFilerMetaImport.Flag.Int("batchSize", 10000, "number of entries written in one transaction")
+	metaImport.dirPrefix = cmdFilerMetaImport.Flag.String("dirPrefix", "", "import entries only with directories matching prefix")
+}
+
+var cmdFilerMetaImport = &Command{
+	UsageLine: "filer.meta.import -config=/path/to/filer.toml [-batchSize=10000] <metadata file>",
+	Short:     "bulk import the metadata saved by fs.meta.save directly into a filer store",
+	Long: `bulk import the metadata saved by fs.meta.save directly into a filer store.
+
+	This is for initial migrations of many entries into an empty filer store, with the filer stopped.
+	It is much faster than fs.meta.load, which creates the entries one by one through the filer.
+
+	The mysql and postgres stores load the entries with LOAD DATA LOCAL INFILE and COPY.
+	LOAD DATA LOCAL needs local_infile enabled on the mysql server, otherwise batched inserts are used.
+	Other stores insert the entries one by one.
+
+	weed filer.meta.import -config=/path/to/filer.toml /path/to/xxx.meta
+
+  `,
+}
+
+func runFilerMetaImport(cmd *Command, args []string) bool {
+
+	if len(args) != 1 {
+		return false
+	}
+
+	v := viper.New()
+	v.SetConfigFile(*metaImport.config)
+	if err := v.ReadInConfig(); err != nil {
+		glog.Fatalf("Failed to load %s file: %v\nPlease use this command to generate the a %s.toml file\n"+
+			"    weed scaffold -config=%s -output=.\n\n\n",
+			*metaImport.config, err, "filer", "filer")
+	}
+
+	store, err := initMetaImportStore(v)
+	if err != nil {
+		glog.Errorf("init filer store: %v", err)
+		return false
+	}
+	defer store.Shutdown()
+
+	if err := metaImport.importFile(store, args[0]); err != nil {
+		glog.Errorf("import %s: %v", args[0], err)
+		return false
+	}
+	return true
+}
+
+func initMetaImportStore(v *viper.Viper) (filer.FilerStore, error) {
+	for _, store := range filer.Stores {
+		if v.GetBool(store.GetName() + ".enabled") {
+			store = reflect.New(reflect.ValueOf(store).Elem().Type()).Interface().(filer.FilerStore)
+			if err := store.Initialize(v, store.GetName()+"."); err != nil {
+				return nil, fmt.Errorf("initialize store %s: %v", store.GetName(), err)
+			}
+			glog.V(0).Infof("configured filer store to %s", store.GetName())
+			return store, nil
+		}
+	}
+	return nil, fmt.Errorf("no filer store enabled in %s", v.ConfigFileUsed())
+}
+
+func (metaImport *FilerMetaImportOptions) importFile(store filer.FilerStore, fileName string) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	reader := bufio.NewReaderSize(f, 1024*1024)
+
+	bulkStore, isBulkInsertable := store.(filer.BulkInsertable)
+	ctx := context.Background()
+	var batch []*filer.Entry
+	var count int64
+	startTime := time.Now()
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if isBulkInsertable {
+			if err := bulkStore.BulkInsertEntries(ctx, batch); err != nil {
+				return err
+			}
+		} else {
+			for _, entry := range batch {
+				if err := store.InsertEntry(ctx, entry); err != nil {
+					return fmt.Errorf("insert %s: %v", entry.FullPath, err)
+				}
+			}
+		}
+		count += int64(len(batch))
+		batch = batch[:0]
+		glog.V(0).Infof("imported %d entries, %.0f entries/second", count, float64(count)/time.Since(startTime).Seconds())
+		return nil
+	}
+
+	sizeBuf := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(reader, sizeBuf); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		data := make([]byte, util.BytesToUint32(sizeBuf))
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return err
+		}
+		fullEntry := &filer_pb.FullEntry{}
+		if err := proto.Unmarshal(data, fullEntry); err != nil {
+			return err
+		}
+		if *metaImport.dirPrefix != "" && !strings.HasPrefix(fullEntry.Dir, *metaImport.dirPrefix) {
+			continue
+		}
+		batch = append(batch, filer.FromPbEntry(fullEntry.Dir, fullEntry.Entry))
+		if len(batch) >= *metaImport.batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d entries from %s in %v\n", count, fileName, time.Since(startTime))
+	return nil
+}
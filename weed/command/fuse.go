@@ -183,6 +183,26 @@ func runFuse(cmd *Command, args []string) bool {
 			} else {
 				panic(fmt.Errorf("readOnly: %s", err))
 			}
+		case "writebackCache":
+			if parsed, err := strconv.ParseBool(parameter.value); err == nil {
+				mountOptions.writebackCache = &parsed
+			} else {
+				panic(fmt.Errorf("writebackCache: %s", err))
+			}
+		case "writebackCache.dirtyMB":
+			if parsed, err := strconv.ParseInt(parameter.value, 0, 32); err == nil {
+				intValue := int(parsed)
+				mountOptions.writebackDirtyMB = &intValue
+			} else {
+				panic(fmt.Errorf("writebackCache.dirtyMB: %s", err))
+			}
+		case "writebackCache.flushers":
+			if parsed, err := strconv.ParseInt(parameter.value, 0, 32); err == nil {
+				intValue := int(parsed)
+				mountOptions.writebackFlushers = &intValue
+			} else {
+				panic(fmt.Errorf("writebackCache.flushers: %s", err))
+			}
 		case "cpuprofile":
 			mountCpuProfile = &parameter.value
 		case "memprofile":
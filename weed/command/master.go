@@ -20,6 +20,8 @@ import (
 
 	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/cluster/discovery"
 	"github.com/seaweedfs/seaweedfs/weed/util/grace"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
@@ -42,23 +44,26 @@ type MasterOptions struct {
 	ipBind                     *string
 	metaFolder                 *string
 	peers                      *string
+	peersCount                 *int
 	volumeSizeLimitMB          *uint
 	volumePreallocate          *bool
 	maxParallelVacuumPerServer *int
 	// pulseSeconds       *int
-	defaultReplication *string
-	garbageThreshold   *float64
-	whiteList          *string
-	disableHttp        *bool
-	metricsAddress     *string
-	metricsIntervalSec *int
-	raftResumeState    *bool
-	metricsHttpPort    *int
-	metricsHttpIp      *string
-	heartbeatInterval  *time.Duration
-	electionTimeout    *time.Duration
-	raftHashicorp      *bool
-	raftBootstrap      *bool
+	defaultReplication    *string
+	garbageThreshold      *float64
+	whiteList             *string
+	disableHttp           *bool
+	metricsAddress        *string
+	metricsIntervalSec    *int
+	raftResumeState       *bool
+	metricsHttpPort       *int
+	metricsHttpIp         *string
+	heartbeatInterval     *time.Duration
+	electionTimeout       *time.Duration
+	raftHashicorp         *bool
+	raftBootstrap         *bool
+	raftSnapshotInterval  *time.Duration
+	raftSnapshotThreshold *uint64
 }
 
 func init() {
@@ -68,7 +73,8 @@ func init() {
 	m.ip = cmdMaster.Flag.String("ip", util.DetectedHostAddress(), "master <ip>|<server> address, also used as identifier")
 	m.ipBind = cmdMaster.Flag.String("ip.bind", "", "ip address to bind to. If empty, default to same as -ip option.")
 	m.metaFolder = cmdMaster.Flag.String("mdir", os.TempDir(), "data directory to store meta data")
-	m.peers = cmdMaster.Flag.String("peers", "", "all master nodes in comma separated ip:port list, example: 127.0.0.1:9093,127.0.0.1:9094,127.0.0.1:9095")
+	m.peers = cmdMaster.Flag.String("peers", "", "all master nodes in comma separated ip:port list, example: 127.0.0.1:9093,127.0.0.1:9094,127.0.0.1:9095, or a service registry to register in and find the peers from, example: consul+localhost:8500, etcd+localhost:2379, nomad+localhost:4646")
+	m.peersCount = cmdMaster.Flag.Int("peers.count", 1, "with the peers from a service registry, the number of master nodes to wait for before starting")
 	m.volumeSizeLimitMB = cmdMaster.Flag.Uint("volumeSizeLimitMB", 30*1000, "Master stops directing writes to oversized volumes.")
 	m.volumePreallocate = cmdMaster.Flag.Bool("volumePreallocate", false, "Preallocate disk space for volumes.")
 	m.maxParallelVacuumPerServer = cmdMaster.Flag.Int("maxParallelVacuumPerServer", 1, "maximum number of volumes to vacuum in parallel per volume server")
@@ -86,6 +92,8 @@ func init() {
 	m.electionTimeout = cmdMaster.Flag.Duration("electionTimeout", 10*time.Second, "election timeout of master servers")
 	m.raftHashicorp = cmdMaster.Flag.Bool("raftHashicorp", false, "use hashicorp raft")
 	m.raftBootstrap = cmdMaster.Flag.Bool("raftBootstrap", false, "Whether to bootstrap the Raft cluster")
+	m.raftSnapshotInterval = cmdMaster.Flag.Duration("raftSnapshotInterval", 2*time.Minute, "check whether to snapshot and compact the raft log this often, 0 to disable")
+	m.raftSnapshotThreshold = cmdMaster.Flag.Uint64("raftSnapshotThreshold", 8192, "snapshot the raft log when there are this many new entries since the last snapshot")
 }
 
 var cmdMaster = &Command{
@@ -150,7 +158,17 @@ func startMaster(masterOption MasterOptions, masterWhiteList []string) {
 		*masterOption.ipBind = *masterOption.ip
 	}
 
-	myMasterAddress, peers := checkPeers(*masterOption.ip, *masterOption.port, *masterOption.portGrpc, *masterOption.peers)
+	peersString := *masterOption.peers
+	if discovery.IsRegistry(peersString) {
+		registerServer(peersString, cluster.MasterType, pb.NewServerAddress(*masterOption.ip, *masterOption.port, *masterOption.portGrpc))
+		peerList, err := discovery.WaitForServers(peersString, cluster.MasterType, *masterOption.peersCount)
+		if err != nil {
+			glog.Fatalf("find master peers in %s: %v", peersString, err)
+		}
+		peersString = strings.Join(pb.ToAddressStrings(peerList), ",")
+	}
+
+	myMasterAddress, peers := checkPeers(*masterOption.ip, *masterOption.port, *masterOption.portGrpc, peersString)
 
 	masterPeers := make(map[string]pb.ServerAddress)
 	for _, peer := range peers {
@@ -178,6 +196,8 @@ func startMaster(masterOption MasterOptions, masterWhiteList []string) {
 		HeartbeatInterval: *masterOption.heartbeatInterval,
 		ElectionTimeout:   *masterOption.electionTimeout,
 		RaftBootstrap:     *masterOption.raftBootstrap,
+		SnapshotInterval:  *masterOption.raftSnapshotInterval,
+		SnapshotThreshold: *masterOption.raftSnapshotThreshold,
 	}
 	var raftServer *weed_server.RaftServer
 	var err error
@@ -211,6 +231,7 @@ func startMaster(masterOption MasterOptions, masterWhiteList []string) {
 		protobuf.RegisterRaftServer(grpcS, raftServer)
 	}
 	reflection.Register(grpcS)
+	ms.RegisterGrpcHealth(grpcS)
 	glog.V(0).Infof("Start Seaweed Master %s grpc server at %s:%d", util.Version(), *masterOption.ipBind, grpcPort)
 	if grpcLocalL != nil {
 		go grpcS.Serve(grpcLocalL)
@@ -300,6 +321,28 @@ func checkPeers(masterIp string, masterPort int, masterGrpcPort int, peers strin
 	return
 }
 
+// resolveMasters finds the masters in the service registry, or parses the comma separated masters
+func resolveMasters(masters string) []pb.ServerAddress {
+	if !discovery.IsRegistry(masters) {
+		return pb.ServerAddresses(masters).ToAddresses()
+	}
+	masterList, err := discovery.WaitForServers(masters, cluster.MasterType, 1)
+	if err != nil {
+		glog.Fatalf("find masters in %s: %v", masters, err)
+	}
+	return masterList
+}
+
+// registerServer registers the server in the service registry, if the masters are found from one
+func registerServer(masters string, serverType string, address pb.ServerAddress) {
+	if !discovery.IsRegistry(masters) {
+		return
+	}
+	if err := discovery.RegisterServer(masters, serverType, address); err != nil {
+		glog.Fatalf("%v", err)
+	}
+}
+
 func isTheFirstOne(self pb.ServerAddress, peers []pb.ServerAddress) bool {
 	slices.SortFunc(peers, func(a, b pb.ServerAddress) int {
 		return strings.Compare(string(a), string(b))
@@ -33,6 +33,12 @@ type MountOptions struct {
 	debugPort          *int
 	localSocket        *string
 	disableXAttr       *bool
+	writebackCache     *bool
+	writebackDirtyMB   *int
+	writebackFlushers  *int
+	readAheadChunks    *int
+	readAheadWorkers   *int
+	readAheadMemoryMB  *int
 	extraOptions       []string
 }
 
@@ -72,6 +78,12 @@ func init() {
 	mountOptions.debugPort = cmdMount.Flag.Int("debug.port", 6061, "http port for debugging")
 	mountOptions.localSocket = cmdMount.Flag.String("localSocket", "", "default to /tmp/seaweedfs-mount-<mount_dir_hash>.sock")
 	mountOptions.disableXAttr = cmdMount.Flag.Bool("disableXAttr", false, "disable xattr")
+	mountOptions.writebackCache = cmdMount.Flag.Bool("writebackCache", false, "let close() return before the written files are flushed to the filer. fsync() still waits for the flush.")
+	mountOptions.writebackDirtyMB = cmdMount.Flag.Int("writebackCache.dirtyMB", 256, "limit of the written but not flushed data in the writeback cache mode, writes wait for the flushers beyond it")
+	mountOptions.writebackFlushers = cmdMount.Flag.Int("writebackCache.flushers", 4, "number of background flushers in the writeback cache mode")
+	mountOptions.readAheadChunks = cmdMount.Flag.Int("readAhead.chunks", 8, "maximum number of chunks to prefetch ahead of the sequential reads of a file, 0 to disable")
+	mountOptions.readAheadWorkers = cmdMount.Flag.Int("readAhead.concurrency", 8, "maximum number of chunks prefetched in parallel")
+	mountOptions.readAheadMemoryMB = cmdMount.Flag.Int("readAhead.memoryMB", 256, "limit of the prefetched but not yet read data")
 
 	mountCpuProfile = cmdMount.Flag.String("cpuprofile", "", "cpu profile output file")
 	mountMemProfile = cmdMount.Flag.String("memprofile", "", "memory profile output file")
@@ -93,5 +105,19 @@ var cmdMount = &Command{
 
   On OS X, it requires OSXFUSE (https://osxfuse.github.io/).
 
+  On Windows, it requires WinFsp (https://winfsp.dev/), and weed built with "-tags winfsp"
+  after adding github.com/winfsp/cgofuse to the module. Mount to a drive letter with "-dir=S:".
+  The names are case-insensitive, and the hidden, system and archive attributes are kept.
+
+  To keep files or directories in the local cache for offline or low latency access,
+  pin them with an xattr, and unpin them by removing it:
+    setfattr -n user.seaweedfs.pin -v 1 /mnt/weed/dataset
+    setfattr -x user.seaweedfs.pin /mnt/weed/dataset
+  The chunks of the pinned files are downloaded in the background and never evicted.
+
+  The files opened with O_DIRECT bypass the kernel page cache. The shared writable mmap
+  pages are saved to the filer by fsync() or msync(MS_SYNC), and otherwise by the close()
+  or the munmap() after the kernel writes them back. msync(MS_ASYNC) does not save them.
+
   `,
 }
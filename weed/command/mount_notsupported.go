@@ -1,5 +1,7 @@
-//go:build !linux && !darwin
-// +build !linux,!darwin
+//go:build !linux && !darwin && !(windows && winfsp)
+// +build !linux
+// +build !darwin
+// +build !windows !winfsp
 
 package command
 
@@ -250,6 +250,12 @@ func RunMount(option *MountOptions, umask os.FileMode) bool {
 		UidGidMapper:       uidGidMapper,
 		DisableXAttr:       *option.disableXAttr,
 		IsMacOs:            runtime.GOOS == "darwin",
+		WritebackCache:     *option.writebackCache,
+		WritebackMaxDirty:  int64(*option.writebackDirtyMB) * 1024 * 1024,
+		WritebackFlushers:  *option.writebackFlushers,
+		ReadAheadChunks:    *option.readAheadChunks,
+		ReadAheadWorkers:   *option.readAheadWorkers,
+		ReadAheadMemory:    int64(*option.readAheadMemoryMB) * 1024 * 1024,
 	})
 
 	// create mount root
@@ -283,6 +289,7 @@ func RunMount(option *MountOptions, umask os.FileMode) bool {
 	glog.V(0).Infof("This is SeaweedFS version %s %s %s", util.Version(), runtime.GOOS, runtime.GOARCH)
 
 	server.Serve()
+	seaweedFileSystem.FlushWriteback()
 
 	return true
 }
@@ -0,0 +1,118 @@
+//go:build windows && winfsp
+// +build windows,winfsp
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mount/winfsp"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/grace"
+)
+
+var mountCaseInsensitive *bool
+
+func init() {
+	mountCaseInsensitive = cmdMount.Flag.Bool("caseInsensitive", true, "match the file names case-insensitively, like NTFS")
+}
+
+// runMount mounts the filer to a drive letter, e.g., -dir=S:, with WinFsp installed
+func runMount(cmd *Command, args []string) bool {
+
+	if *mountOptions.debug {
+		go http.ListenAndServe(fmt.Sprintf(":%d", *mountOptions.debugPort), nil)
+	}
+
+	grace.SetupProfiling(*mountCpuProfile, *mountMemProfile)
+	if *mountReadRetryTime < time.Second {
+		*mountReadRetryTime = time.Second
+	}
+	util.RetryWaitTime = *mountReadRetryTime
+
+	umask, umaskErr := strconv.ParseUint(*mountOptions.umaskString, 8, 64)
+	if umaskErr != nil {
+		fmt.Printf("can not parse umask %s", *mountOptions.umaskString)
+		return false
+	}
+
+	if len(args) > 0 {
+		return false
+	}
+
+	chunkSizeLimitMB := *mountOptions.chunkSizeLimitMB
+	if chunkSizeLimitMB <= 0 {
+		fmt.Printf("Please specify a reasonable buffer size.\n")
+		return false
+	}
+	if *mountOptions.dir == "" || *mountOptions.dir == "." {
+		fmt.Printf("Please specify the drive letter or the directory to mount via \"-dir\", e.g., -dir=S:\n")
+		return false
+	}
+
+	filerAddress := pb.ServerAddresses(*mountOptions.filer).ToAddresses()[0]
+	util.LoadSecurityConfiguration()
+	grpcDialOption := security.LoadClientTLS(util.GetViper(), "grpc.client")
+	var cipher bool
+	err := pb.WithGrpcFilerClient(false, 0, filerAddress, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
+		if err != nil {
+			return fmt.Errorf("get filer %s configuration: %v", filerAddress, err)
+		}
+		cipher = resp.Cipher
+		return nil
+	})
+	if err != nil {
+		glog.Errorf("failed to talk to filer %s: %v", filerAddress, err)
+		return true
+	}
+
+	fs, err := winfsp.NewFileSystem(&winfsp.Option{
+		Filer:              filerAddress,
+		FilerMountRootPath: path.Clean("/" + *mountOptions.filerMountRootPath),
+		GrpcDialOption:     grpcDialOption,
+		Collection:         *mountOptions.collection,
+		Replication:        *mountOptions.replication,
+		DiskType:           *mountOptions.diskType,
+		TtlSec:             int32(*mountOptions.ttlSec),
+		ChunkSizeLimit:     int64(chunkSizeLimitMB) * 1024 * 1024,
+		Cipher:             cipher,
+		CacheDir:           *mountOptions.cacheDirForRead,
+		CacheSizeMB:        *mountOptions.cacheSizeMBForRead,
+		Umask:              os.FileMode(umask),
+		ReadOnly:           *mountOptions.readOnly,
+		CaseInsensitive:    *mountCaseInsensitive,
+	})
+	if err != nil {
+		glog.Errorf("mount %s%s: %v", filerAddress, *mountOptions.filerMountRootPath, err)
+		return false
+	}
+
+	// the current Windows user owns the files, and the volume is named after the filer
+	options := []string{
+		"-o", "uid=-1,gid=-1",
+		"-o", "volname=seaweedfs",
+		"-o", "FileSystemName=SeaweedFS",
+	}
+	if *mountOptions.readOnly {
+		options = append(options, "-o", "ro")
+	}
+	for _, option := range mountOptions.extraOptions {
+		options = append(options, "-o", option)
+	}
+
+	glog.V(0).Infof("mounting %s%s to %v", filerAddress, *mountOptions.filerMountRootPath, *mountOptions.dir)
+	glog.V(0).Infof("This is SeaweedFS version %s %s %s", util.Version(), runtime.GOOS, runtime.GOARCH)
+	return winfsp.Mount(fs, *mountOptions.dir, options)
+}
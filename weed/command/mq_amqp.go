@@ -0,0 +1,107 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/amqp"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/grace"
+)
+
+var (
+	mqAmqpOptions MqAmqpOptions
+)
+
+type MqAmqpOptions struct {
+	brokers           *string
+	ip                *string
+	port              *int
+	namespace         *string
+	partitionCount    *int
+	maxPartitionCount *int
+	prefetch          *int
+	maxMessageSizeKB  *int
+	username          *string
+	password          *string
+}
+
+func init() {
+	cmdMqAmqp.Run = runMqAmqp // break init cycle
+	mqAmqpOptions.brokers = cmdMqAmqp.Flag.String("broker", "localhost:17777", "comma-separated message queue brokers")
+	mqAmqpOptions.ip = cmdMqAmqp.Flag.String("ip", util.DetectedHostAddress(), "AMQP gateway host address")
+	mqAmqpOptions.port = cmdMqAmqp.Flag.Int("port", 5672, "AMQP gateway port")
+	mqAmqpOptions.namespace = cmdMqAmqp.Flag.String("namespace", "amqp", "namespace of the topics mapped from the exchanges and queues")
+	mqAmqpOptions.partitionCount = cmdMqAmqp.Flag.Int("partitionCount", 4, "partition count of the topics created by publishing")
+	mqAmqpOptions.maxPartitionCount = cmdMqAmqp.Flag.Int("maxPartitionCount", 16, "max partitions each consumer processes concurrently")
+	mqAmqpOptions.prefetch = cmdMqAmqp.Flag.Int("prefetch", 64, "default unacknowledged messages per consumer partition, if the client does not set basic.qos")
+	mqAmqpOptions.maxMessageSizeKB = cmdMqAmqp.Flag.Int("maxMessageSizeKB", amqp.DefaultMaxMessageSize/1024, "the larger published messages are rejected, closing the channel")
+	mqAmqpOptions.username = cmdMqAmqp.Flag.String("username", "", "if set, clients should log in with this username")
+	mqAmqpOptions.password = cmdMqAmqp.Flag.String("password", "", "password of the username")
+}
+
+var cmdMqAmqp = &Command{
+	UsageLine: "mq.amqp -broker=<ip:port> [-port=5672] [-namespace=amqp]",
+	Short:     "<WIP> start an AMQP 0.9.1 gateway to the message queue",
+	Long: `start an AMQP 0.9.1 gateway to the message queue
+
+	Existing RabbitMQ applications can connect to the gateway, and move onto the message queue incrementally.
+
+	The AMQP model is mapped to the message queue in the namespace:
+	  * an exchange is a topic with the same name
+	  * publishing to the default exchange goes to the topic named by the routing key, i.e., the queue name
+	  * a queue is a consumer group, consuming the topics of its bound exchanges,
+	    or the topic with the queue name if not bound to any exchange
+	  * the routing key is the message key, and the bindings filter messages with direct, fanout, or topic rules
+
+	Supported: exchange and queue declare/delete, queue bind/unbind, basic publish/consume/cancel/qos,
+	ack/nack/reject, and publisher confirms, sent once the broker acks the published messages.
+
+	Not supported: headers exchanges, basic.get, queue purge, transactions, and message properties,
+	which are not kept. Exchanges, queues and bindings live in the gateway memory,
+	and are expected to be declared by the clients on start.
+
+`,
+}
+
+func runMqAmqp(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+
+	hostname, _ := os.Hostname()
+	gateway := amqp.NewGateway(&amqp.GatewayOption{
+		Brokers:           util.StringSplit(*mqAmqpOptions.brokers, ","),
+		Namespace:         *mqAmqpOptions.namespace,
+		PartitionCount:    int32(*mqAmqpOptions.partitionCount),
+		MaxPartitionCount: int32(*mqAmqpOptions.maxPartitionCount),
+		DefaultPrefetch:   int32(*mqAmqpOptions.prefetch),
+		MaxMessageSize:    int64(*mqAmqpOptions.maxMessageSizeKB) * 1024,
+		Username:          *mqAmqpOptions.username,
+		Password:          *mqAmqpOptions.password,
+		InstanceId:        fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		GrpcDialOption:    security.LoadClientTLS(util.GetViper(), "grpc.client"),
+	})
+
+	listener, localListener, err := util.NewIpAndLocalListeners(*mqAmqpOptions.ip, *mqAmqpOptions.port, 0)
+	if err != nil {
+		glog.Fatalf("failed to listen on amqp port %d: %v", *mqAmqpOptions.port, err)
+	}
+	if localListener != nil {
+		go func() {
+			if err := gateway.Serve(localListener); err != nil {
+				glog.Errorf("amqp gateway serve on local listener: %v", err)
+			}
+		}()
+	}
+
+	grace.OnInterrupt(gateway.Shutdown)
+
+	glog.V(0).Infof("start AMQP gateway at %s:%d", *mqAmqpOptions.ip, *mqAmqpOptions.port)
+	if err := gateway.Serve(listener); err != nil {
+		glog.Errorf("amqp gateway serve: %v", err)
+		return false
+	}
+	return true
+}
@@ -1,16 +1,25 @@
 package command
 
 import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
 	"github.com/seaweedfs/seaweedfs/weed/util/grace"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/mq/broker"
+	"github.com/seaweedfs/seaweedfs/weed/mq/logstore"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/security"
+	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
+	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/tracing"
 )
 
 var (
@@ -23,26 +32,52 @@ type MessageQueueBrokerOptions struct {
 	filerGroup    *string
 	ip            *string
 	port          *int
+	portHttp      *int
 	dataCenter    *string
 	rack          *string
 	cpuprofile    *string
 	memprofile    *string
+
+	clientPublishMessagesPerSecond *int64
+	clientPublishBytesPerSecond    *int64
+	topicPublishMessagesPerSecond  *int64
+	topicPublishBytesPerSecond     *int64
+
+	dedupWindow  *time.Duration
+	dedupMaxKeys *int
+
+	logReadPrefetch  *int
+	maxMessageSizeKB *int
+
+	traceEndpoint    *string
+	traceSampleRatio *float64
 }
 
 func init() {
 	cmdMqBroker.Run = runMqBroker // break init cycle
-	mqBrokerStandaloneOptions.mastersString = cmdMqBroker.Flag.String("master", "localhost:9333", "comma-separated master servers")
+	mqBrokerStandaloneOptions.mastersString = cmdMqBroker.Flag.String("master", "localhost:9333", "comma-separated master servers, or a service registry to find the masters from and register in, e.g., consul+localhost:8500")
 	mqBrokerStandaloneOptions.filerGroup = cmdMqBroker.Flag.String("filerGroup", "", "share metadata with other filers in the same filerGroup")
 	mqBrokerStandaloneOptions.ip = cmdMqBroker.Flag.String("ip", util.DetectedHostAddress(), "broker host address")
 	mqBrokerStandaloneOptions.port = cmdMqBroker.Flag.Int("port", 17777, "broker gRPC listen port")
+	mqBrokerStandaloneOptions.portHttp = cmdMqBroker.Flag.Int("port.http", 0, "broker http listen port for the dashboard UI, the metrics, and the /healthz and /readyz probes, 0 to disable")
 	mqBrokerStandaloneOptions.dataCenter = cmdMqBroker.Flag.String("dataCenter", "", "prefer to read and write to volumes in this data center")
 	mqBrokerStandaloneOptions.rack = cmdMqBroker.Flag.String("rack", "", "prefer to write to volumes in this rack")
 	mqBrokerStandaloneOptions.cpuprofile = cmdMqBroker.Flag.String("cpuprofile", "", "cpu profile output file")
 	mqBrokerStandaloneOptions.memprofile = cmdMqBroker.Flag.String("memprofile", "", "memory profile output file")
+	mqBrokerStandaloneOptions.clientPublishMessagesPerSecond = cmdMqBroker.Flag.Int64("quota.client.messagesPerSecond", 0, "limit published messages per second for each client, 0 means unlimited")
+	mqBrokerStandaloneOptions.clientPublishBytesPerSecond = cmdMqBroker.Flag.Int64("quota.client.bytesPerSecond", 0, "limit published bytes per second for each client, 0 means unlimited")
+	mqBrokerStandaloneOptions.topicPublishMessagesPerSecond = cmdMqBroker.Flag.Int64("quota.topic.messagesPerSecond", 0, "limit published messages per second for each topic, 0 means unlimited")
+	mqBrokerStandaloneOptions.topicPublishBytesPerSecond = cmdMqBroker.Flag.Int64("quota.topic.bytesPerSecond", 0, "limit published bytes per second for each topic, 0 means unlimited")
+	mqBrokerStandaloneOptions.dedupWindow = cmdMqBroker.Flag.Duration("dedup.window", 5*time.Minute, "drop the messages with the idempotency keys published within this window to the same partition, 0 to disable")
+	mqBrokerStandaloneOptions.dedupMaxKeys = cmdMqBroker.Flag.Int("dedup.maxKeys", 100000, "max idempotency keys remembered for each partition")
+	mqBrokerStandaloneOptions.logReadPrefetch = cmdMqBroker.Flag.Int("logRead.prefetch", 4, "log file and parquet file chunks fetched ahead from the volume servers when the subscribers read the persisted messages, 0 to read one by one")
+	mqBrokerStandaloneOptions.maxMessageSizeKB = cmdMqBroker.Flag.Int("maxMessageSizeKB", 4*1024, "reject the larger published messages, unless the topic sets its own limit, 0 means unlimited")
+	mqBrokerStandaloneOptions.traceEndpoint = cmdMqBroker.Flag.String("trace.endpoint", "", "export OpenTelemetry traces with OTLP over HTTP, e.g., http://localhost:4318 of Jaeger or Tempo")
+	mqBrokerStandaloneOptions.traceSampleRatio = cmdMqBroker.Flag.Float64("trace.sampleRatio", 0.01, "sample ratio of the traces started on the broker, messages traced by the publishers follow the publishers' sampling")
 }
 
 var cmdMqBroker = &Command{
-	UsageLine: "mq.broker [-port=17777] [-master=<ip:port>]",
+	UsageLine: "mq.broker [-port=17777] [-port.http=0] [-master=<ip:port>]",
 	Short:     "<WIP> start a message queue broker",
 	Long: `start a message queue broker
 
@@ -56,8 +91,6 @@ func runMqBroker(cmd *Command, args []string) bool {
 
 	util.LoadSecurityConfiguration()
 
-	mqBrokerStandaloneOptions.masters = pb.ServerAddresses(*mqBrokerStandaloneOptions.mastersString).ToAddressMap()
-
 	return mqBrokerStandaloneOptions.startQueueServer()
 
 }
@@ -68,6 +101,23 @@ func (mqBrokerOpt *MessageQueueBrokerOptions) startQueueServer() bool {
 
 	grpcDialOption := security.LoadClientTLS(util.GetViper(), "grpc.msg_broker")
 
+	loadAuditConfiguration()
+
+	mqBrokerOpt.masters = make(map[string]pb.ServerAddress)
+	for _, master := range resolveMasters(*mqBrokerOpt.mastersString) {
+		mqBrokerOpt.masters[string(master)] = master
+	}
+	registerServer(*mqBrokerOpt.mastersString, cluster.BrokerType, pb.NewServerAddress(*mqBrokerOpt.ip, *mqBrokerOpt.port, 0))
+
+	// encrypt the partition log files written through the filer
+	segmentEncryption, err := logstore.LoadLogSegmentEncryption()
+	if err != nil {
+		glog.Fatalf("mq log encryption: %v", err)
+	}
+	logstore.SegmentEncryption = segmentEncryption
+
+	shutdownTracing := tracing.Init("seaweedfs-mq-broker", *mqBrokerOpt.traceEndpoint, *mqBrokerOpt.traceSampleRatio)
+
 	qs, err := broker.NewMessageBroker(&broker.MessageQueueBrokerOption{
 		Masters:            mqBrokerOpt.masters,
 		FilerGroup:         *mqBrokerOpt.filerGroup,
@@ -77,21 +127,81 @@ func (mqBrokerOpt *MessageQueueBrokerOptions) startQueueServer() bool {
 		MaxMB:              0,
 		Ip:                 *mqBrokerOpt.ip,
 		Port:               *mqBrokerOpt.port,
+		ClientPublishQuota: broker.PublishQuota{
+			MessagesPerSecond: *mqBrokerOpt.clientPublishMessagesPerSecond,
+			BytesPerSecond:    *mqBrokerOpt.clientPublishBytesPerSecond,
+		},
+		TopicPublishQuota: broker.PublishQuota{
+			MessagesPerSecond: *mqBrokerOpt.topicPublishMessagesPerSecond,
+			BytesPerSecond:    *mqBrokerOpt.topicPublishBytesPerSecond,
+		},
+		DedupWindow:     *mqBrokerOpt.dedupWindow,
+		DedupMaxKeys:    *mqBrokerOpt.dedupMaxKeys,
+		LogReadPrefetch: *mqBrokerOpt.logReadPrefetch,
+		MaxMessageSize:  *mqBrokerOpt.maxMessageSizeKB * 1024,
+		Tenants:         loadMqTenants(),
 	}, grpcDialOption)
 	if err != nil {
 		glog.Fatalf("failed to create new message broker for queue server: %v", err)
 	}
 
+	// hand off the partitions before exiting
+	grace.OnInterrupt(qs.Shutdown)
+	grace.OnInterrupt(shutdownTracing)
+
+	// start the dashboard UI
+	if *mqBrokerOpt.portHttp > 0 {
+		httpMux := http.NewServeMux()
+		httpMux.HandleFunc("/", qs.UiStatusHandler)
+		qs.HealthChecks.Register(httpMux)
+		httpMux.Handle("/metrics", promhttp.HandlerFor(stats_collect.Gather, promhttp.HandlerOpts{}))
+		httpMux.Handle("/favicon.ico", http.FileServer(http.FS(weed_server.StaticFS)))
+		httpMux.Handle("/seaweedfsstatic/", http.StripPrefix("/seaweedfsstatic", http.FileServer(http.FS(weed_server.StaticFS))))
+		httpL, _, err := util.NewIpAndLocalListeners("", *mqBrokerOpt.portHttp, 0)
+		if err != nil {
+			glog.Fatalf("failed to listen on http port %d: %v", *mqBrokerOpt.portHttp, err)
+		}
+		glog.V(0).Infof("broker dashboard UI at http port %d", *mqBrokerOpt.portHttp)
+		go http.Serve(httpL, httpMux)
+	}
+
 	// start grpc listener
 	grpcL, _, err := util.NewIpAndLocalListeners("", *mqBrokerOpt.port, 0)
 	if err != nil {
 		glog.Fatalf("failed to listen on grpc port %d: %v", *mqBrokerOpt.port, err)
 	}
-	grpcS := pb.NewGrpcServer(security.LoadServerTLS(util.GetViper(), "grpc.msg_broker"))
+	serverTlsOption, _ := security.LoadServerTLS(util.GetViper(), "grpc.msg_broker")
+	grpcS := pb.NewGrpcServer(append(append(qs.AuditServerOptions(), qs.TenantServerOptions()...), serverTlsOption)...)
 	mq_pb.RegisterSeaweedMessagingServer(grpcS, qs)
 	reflection.Register(grpcS)
+	qs.HealthChecks.RegisterGrpc(grpcS)
 	grpcS.Serve(grpcL)
 
 	return true
 
 }
+
+// loadMqTenants reads the enabled tenants in mq_broker.toml, if any
+func loadMqTenants() (tenants []*broker.Tenant) {
+	if !util.LoadConfiguration("mq_broker", false) {
+		return nil
+	}
+	v := util.GetViper()
+	for name := range v.GetStringMap("tenant") {
+		prefix := "tenant." + name + "."
+		if !v.GetBool(prefix + "enabled") {
+			continue
+		}
+		tenants = append(tenants, &broker.Tenant{
+			Name:      name,
+			Hostnames: v.GetStringSlice(prefix + "hostnames"),
+			Namespace: v.GetString(prefix + "namespace"),
+			PublishQuota: broker.PublishQuota{
+				MessagesPerSecond: v.GetInt64(prefix + "publish_messages_per_second"),
+				BytesPerSecond:    v.GetInt64(prefix + "publish_bytes_per_second"),
+			},
+			AllowedCommonNames: v.GetStringSlice(prefix + "allowed_common_names"),
+		})
+	}
+	return
+}
@@ -0,0 +1,178 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/connect"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/grace"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+	"google.golang.org/grpc"
+)
+
+var (
+	mqConnectOptions MqConnectOptions
+)
+
+type MqConnectOptions struct {
+	name              *string
+	topic             *string
+	brokers           *string
+	filer             *string
+	maxPartitionCount *int
+	batchSize         *int
+	batchSizeMB       *int
+	flushInterval     *time.Duration
+	startFromLatest   *bool
+	grpcDialOption    grpc.DialOption
+	clientId          int32
+}
+
+var _ = filer_pb.FilerClient(&MqConnectOptions{})
+
+func init() {
+	cmdMqConnect.Run = runMqConnect // break init cycle
+	mqConnectOptions.name = cmdMqConnect.Flag.String("name", "", "name of the connector, to track the progress and share the partitions among the instances")
+	mqConnectOptions.topic = cmdMqConnect.Flag.String("topic", "", "the topic to consume, or to publish the filer changes to, in the form of <namespace>.<name>")
+	mqConnectOptions.brokers = cmdMqConnect.Flag.String("brokers", "localhost:17777", "comma-separated message queue brokers")
+	mqConnectOptions.filer = cmdMqConnect.Flag.String("filer", "localhost:8888", "filer to save the checkpoints, and to write the files to for the filer sink")
+	mqConnectOptions.maxPartitionCount = cmdMqConnect.Flag.Int("maxPartitionCount", 64, "max partitions to consume concurrently")
+	mqConnectOptions.batchSize = cmdMqConnect.Flag.Int("batchSize", 10000, "write out a batch with this many messages")
+	mqConnectOptions.batchSizeMB = cmdMqConnect.Flag.Int("batchSizeMB", 16, "write out a batch with this many MB of keys and values")
+	mqConnectOptions.flushInterval = cmdMqConnect.Flag.Duration("flushInterval", time.Minute, "write out a batch when it is this old")
+	mqConnectOptions.startFromLatest = cmdMqConnect.Flag.Bool("startFromLatest", false, "for partitions or the filer source without checkpoints, start from now on instead of the earliest message")
+	mqConnectOptions.clientId = util.RandomInt32()
+}
+
+var cmdMqConnect = &Command{
+	UsageLine: "mq.connect -name=archive -topic=ns.t1 -brokers=<ip:port> -filer=<ip:port>",
+	Short:     "<WIP> write message queue topics out to a filer path, S3 bucket, or webhook, or publish filer changes to a topic",
+	Long: `write message queue topics out to a filer path, S3 bucket, or webhook, or publish filer changes to a topic
+
+	The connector consumes the topic, and writes the messages out to the sink in batches of each partition,
+	as json lines. The files and S3 objects are partitioned by the hour of the first message, as
+	dt=yyyy-mm-dd/hour=hh/.
+
+	After each batch is written, the progress is saved in the filer under /etc/seaweedfs/mq_connect/<name>/,
+	so the connector resumes from there after a restart. The messages are written at least once, and a
+	batch written again has the same name.
+
+	Run multiple instances with the same name to share the partitions.
+
+	With the filer source enabled instead, the connector publishes the metadata changes of the filer under
+	the path prefixes to the topic, one json event per created, updated, deleted, or renamed entry, keyed by
+	the path. The progress is also saved in the filer, and the changes of the last minute are published again
+	after a restart, which the brokers drop as duplicates.
+
+	Run "weed scaffold -config=mq_connect" to generate a mq_connect.toml file, and enable one sink or the filer source.
+
+`,
+}
+
+func runMqConnect(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	util.LoadConfiguration("mq_connect", true)
+	util_http.InitGlobalHttpClient()
+	config := util.GetViper()
+
+	namespace, topicName, found := strings.Cut(*mqConnectOptions.topic, ".")
+	if !found {
+		fmt.Printf("topic %s should be in the form of <namespace>.<name>\n", *mqConnectOptions.topic)
+		return false
+	}
+
+	mqConnectOptions.grpcDialOption = security.LoadClientTLS(util.GetViper(), "grpc.client")
+
+	if config.GetBool("source.filer.enabled") {
+		return runMqConnectFilerSource(topic.NewTopic(namespace, topicName), config)
+	}
+
+	var dataSink connect.Sink
+	for _, sk := range connect.Sinks {
+		if config.GetBool("sink." + sk.GetName() + ".enabled") {
+			if err := sk.Initialize(config, "sink."+sk.GetName()+".", &mqConnectOptions); err != nil {
+				glog.Errorf("initialize sink %s: %v", sk.GetName(), err)
+				return false
+			}
+			dataSink = sk
+			break
+		}
+	}
+	if dataSink == nil {
+		println("no sink enabled in mq_connect.toml:")
+		for _, sk := range connect.Sinks {
+			println("    " + sk.GetName())
+		}
+		return false
+	}
+
+	hostname, _ := os.Hostname()
+	c := connect.NewConnector(&connect.ConnectorOption{
+		Name:              *mqConnectOptions.name,
+		Topic:             topic.NewTopic(namespace, topicName),
+		Brokers:           util.StringSplit(*mqConnectOptions.brokers, ","),
+		InstanceId:        fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		MaxPartitionCount: int32(*mqConnectOptions.maxPartitionCount),
+		BatchSize:         *mqConnectOptions.batchSize,
+		BatchBytes:        *mqConnectOptions.batchSizeMB * 1024 * 1024,
+		FlushInterval:     *mqConnectOptions.flushInterval,
+		StartFromLatest:   *mqConnectOptions.startFromLatest,
+		GrpcDialOption:    mqConnectOptions.grpcDialOption,
+		FilerClient:       &mqConnectOptions,
+	}, dataSink)
+
+	grace.OnInterrupt(c.Shutdown)
+
+	if err := c.Run(); err != nil {
+		glog.Errorf("mq.connect: %v", err)
+		return false
+	}
+	return true
+}
+
+func runMqConnectFilerSource(t topic.Topic, config util.Configuration) bool {
+	config.SetDefault("source.filer.partitionCount", 4)
+	s := connect.NewFilerSource(&connect.FilerSourceOption{
+		Name:            *mqConnectOptions.name,
+		Topic:           t,
+		Brokers:         util.StringSplit(*mqConnectOptions.brokers, ","),
+		PartitionCount:  int32(config.GetInt("source.filer.partitionCount")),
+		FilerAddress:    pb.ServerAddress(*mqConnectOptions.filer),
+		PathPrefixes:    config.GetStringSlice("source.filer.pathPrefixes"),
+		StartFromLatest: *mqConnectOptions.startFromLatest,
+		GrpcDialOption:  mqConnectOptions.grpcDialOption,
+		FilerClient:     &mqConnectOptions,
+		ClientId:        mqConnectOptions.clientId,
+	})
+
+	grace.OnInterrupt(s.Shutdown)
+
+	if err := s.Run(); err != nil {
+		glog.Errorf("mq.connect: %v", err)
+		return false
+	}
+	return true
+}
+
+func (option *MqConnectOptions) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithFilerClient(streamingMode, option.clientId, pb.ServerAddress(*option.filer), option.grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		return fn(client)
+	})
+}
+
+func (option *MqConnectOptions) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (option *MqConnectOptions) GetDataCenter() string {
+	return ""
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/mirror"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/grace"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+var (
+	mqMirrorOptions MqMirrorOptions
+)
+
+type MqMirrorOptions struct {
+	sourceCluster     *string
+	targetCluster     *string
+	sourceBrokers     *string
+	targetBrokers     *string
+	namespace         *string
+	topics            *string
+	consumerGroup     *string
+	maxPartitionCount *int
+}
+
+func init() {
+	cmdMqMirror.Run = runMqMirror // break init cycle
+	mqMirrorOptions.sourceCluster = cmdMqMirror.Flag.String("source.cluster", "", "name of the source cluster, recorded as the origin of the mirrored messages")
+	mqMirrorOptions.targetCluster = cmdMqMirror.Flag.String("target.cluster", "", "name of the target cluster, messages originated from it are not mirrored back")
+	mqMirrorOptions.sourceBrokers = cmdMqMirror.Flag.String("source.brokers", "localhost:17777", "comma-separated brokers of the source cluster")
+	mqMirrorOptions.targetBrokers = cmdMqMirror.Flag.String("target.brokers", "", "comma-separated brokers of the target cluster")
+	mqMirrorOptions.namespace = cmdMqMirror.Flag.String("namespace", "", "mirror all topics in this namespace, if -topics is not set")
+	mqMirrorOptions.topics = cmdMqMirror.Flag.String("topics", "", "comma-separated topics to mirror, in the form of <namespace>.<name>")
+	mqMirrorOptions.consumerGroup = cmdMqMirror.Flag.String("consumerGroup", "", "consumer group on the source cluster to track the progress, default to mirror-<target.cluster>")
+	mqMirrorOptions.maxPartitionCount = cmdMqMirror.Flag.Int("maxPartitionCount", 64, "max partitions to mirror concurrently for each topic")
+}
+
+var cmdMqMirror = &Command{
+	UsageLine: "mq.mirror -source.cluster=dc1 -source.brokers=<ip:port> -target.cluster=dc2 -target.brokers=<ip:port> [-topics=ns.t1,ns.t2]",
+	Short:     "<WIP> mirror message queue topics to another cluster",
+	Long: `mirror message queue topics to another cluster
+
+	The mirror subscribes to the topics on the source cluster, and republishes the messages to the target cluster,
+	for disaster recovery or fan-out to multiple data centers.
+
+	The mirrored messages are published with their timestamps, which are the offsets. The target broker moves
+	a timestamp forward if its partition already has later messages, e.g., from local publishers, so consumers
+	switching to the target cluster should resume from a slightly earlier offset, and skip the duplicates.
+
+	Each mirrored message records the cluster it is originated from. Messages originated from the target cluster
+	are skipped, so two mirrors in opposite directions do not loop forever.
+
+	The progress is tracked by the consumer group on the source cluster, and a message is acked to the source
+	cluster only after the target cluster acks it. Run multiple mirrors with the same consumer group to share
+	the partitions.
+
+`,
+}
+
+func runMqMirror(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	util_http.InitGlobalHttpClient()
+
+	var topics []topic.Topic
+	for _, name := range util.StringSplit(*mqMirrorOptions.topics, ",") {
+		namespace, topicName, found := strings.Cut(name, ".")
+		if !found {
+			fmt.Printf("topic %s should be in the form of <namespace>.<name>\n", name)
+			return false
+		}
+		topics = append(topics, topic.NewTopic(namespace, topicName))
+	}
+
+	consumerGroup := *mqMirrorOptions.consumerGroup
+	if consumerGroup == "" {
+		consumerGroup = "mirror-" + *mqMirrorOptions.targetCluster
+	}
+	hostname, _ := os.Hostname()
+
+	m := mirror.NewMirror(&mirror.MirrorOption{
+		SourceCluster:     *mqMirrorOptions.sourceCluster,
+		TargetCluster:     *mqMirrorOptions.targetCluster,
+		SourceBrokers:     util.StringSplit(*mqMirrorOptions.sourceBrokers, ","),
+		TargetBrokers:     util.StringSplit(*mqMirrorOptions.targetBrokers, ","),
+		Namespace:         *mqMirrorOptions.namespace,
+		Topics:            topics,
+		ConsumerGroup:     consumerGroup,
+		InstanceId:        fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		MaxPartitionCount: int32(*mqMirrorOptions.maxPartitionCount),
+		GrpcDialOption:    security.LoadClientTLS(util.GetViper(), "grpc.client"),
+	})
+
+	grace.OnInterrupt(m.Shutdown)
+
+	if err := m.Run(); err != nil {
+		glog.Errorf("mq.mirror: %v", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,324 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/filer_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/pub_balancer"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc"
+)
+
+var (
+	mqRecover MqRecoverOptions
+)
+
+type MqRecoverOptions struct {
+	filer          *string
+	master         *string
+	filerGroup     *string
+	brokers        *string
+	apply          *bool
+	grpcDialOption grpc.DialOption
+	volumeStatus   map[string]error // volume id => lookup error
+}
+
+func init() {
+	cmdMqRecover.Run = runMqRecover // break init cycle
+	mqRecover.filer = cmdMqRecover.Flag.String("filer", "localhost:8888", "filer server address")
+	mqRecover.master = cmdMqRecover.Flag.String("master", "localhost:9333", "master server address, to find the live brokers")
+	mqRecover.filerGroup = cmdMqRecover.Flag.String("filerGroup", "", "the filer group of the brokers")
+	mqRecover.brokers = cmdMqRecover.Flag.String("brokers", "", "comma-separated brokers to assign the partitions to, instead of the live brokers registered on the master")
+	mqRecover.apply = cmdMqRecover.Flag.Bool("apply", false, "write the recovered topic.conf files to the filer")
+}
+
+var cmdMqRecover = &Command{
+	UsageLine: "mq.recover -filer=<ip:port> -master=<ip:port> [-apply]",
+	Short:     "<WIP> rebuild message queue broker state from the filer",
+	Long: `rebuild message queue broker state from the filer, e.g., after losing all brokers
+
+	The brokers are stateless, and all topics are stored in the filer under /topics.
+	This command scans each topic in the filer, and
+	1. reads its topic.conf, or rebuilds it from the latest generation of partition directories if missing.
+	2. validates the log segments of each partition, and reports the broken ones.
+	3. assigns the partitions whose leader or follower is gone to the current brokers.
+
+	Start the new brokers first, so they are registered on the master, and run with -apply to write the changes.
+
+`,
+}
+
+func runMqRecover(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	mqRecover.grpcDialOption = security.LoadClientTLS(util.GetViper(), "grpc.client")
+	mqRecover.volumeStatus = make(map[string]error)
+
+	if err := mqRecover.doRecover(); err != nil {
+		fmt.Printf("mq.recover: %v\n", err)
+		return false
+	}
+	return true
+}
+
+func (opt *MqRecoverOptions) doRecover() error {
+
+	brokers := opt.listBrokers()
+	if len(brokers) == 0 {
+		return fmt.Errorf("no brokers found, start the brokers or specify -brokers")
+	}
+	fmt.Printf("brokers: %v\n", brokers)
+	activeBrokers := cmap.New[*pub_balancer.BrokerStats]()
+	for _, broker := range brokers {
+		activeBrokers.Set(broker, pub_balancer.NewBrokerStats())
+	}
+
+	filerAddress := pb.ServerAddress(*opt.filer)
+	fca := &filer_client.FilerClientAccessor{
+		GetFiler: func() pb.ServerAddress {
+			return filerAddress
+		},
+		GetGrpcDialOption: func() grpc.DialOption {
+			return opt.grpcDialOption
+		},
+	}
+
+	topics, err := opt.listTopics(fca)
+	if err != nil {
+		return fmt.Errorf("list topics: %v", err)
+	}
+
+	var changedCount, brokenSegmentCount int
+	for _, t := range topics {
+		isChanged, brokenCount, recoverErr := opt.recoverTopic(fca, t, activeBrokers)
+		if recoverErr != nil {
+			fmt.Printf("topic %v: %v\n", t, recoverErr)
+			continue
+		}
+		if isChanged {
+			changedCount++
+		}
+		brokenSegmentCount += brokenCount
+	}
+
+	fmt.Printf("scanned %d topics, %d topic.conf changed, %d broken log segments\n", len(topics), changedCount, brokenSegmentCount)
+	if changedCount > 0 && !*opt.apply {
+		fmt.Printf("this is a dry run, use -apply to write the changes\n")
+	}
+	return nil
+}
+
+func (opt *MqRecoverOptions) listBrokers() (brokers []string) {
+	if *opt.brokers != "" {
+		for _, broker := range pb.ServerAddresses(*opt.brokers).ToAddresses() {
+			brokers = append(brokers, string(broker))
+		}
+		return
+	}
+	for _, node := range cluster.ListExistingPeerUpdates(pb.ServerAddress(*opt.master), opt.grpcDialOption, *opt.filerGroup, cluster.BrokerType) {
+		brokers = append(brokers, node.Address)
+	}
+	sort.Strings(brokers)
+	return
+}
+
+func (opt *MqRecoverOptions) listTopics(fca *filer_client.FilerClientAccessor) (topics []topic.Topic, err error) {
+	err = fca.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		var namespaces []string
+		if listErr := filer_pb.SeaweedList(client, filer.TopicsDir, "", func(entry *filer_pb.Entry, isLast bool) error {
+			// skip system directories, e.g., .system
+			if entry.IsDirectory && !strings.HasPrefix(entry.Name, ".") {
+				namespaces = append(namespaces, entry.Name)
+			}
+			return nil
+		}, "", false, 0); listErr != nil {
+			return listErr
+		}
+		for _, namespace := range namespaces {
+			if listErr := filer_pb.SeaweedList(client, filer.TopicsDir+"/"+namespace, "", func(entry *filer_pb.Entry, isLast bool) error {
+				if entry.IsDirectory {
+					topics = append(topics, topic.NewTopic(namespace, entry.Name))
+				}
+				return nil
+			}, "", false, 0); listErr != nil {
+				return listErr
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (opt *MqRecoverOptions) recoverTopic(fca *filer_client.FilerClientAccessor, t topic.Topic, activeBrokers cmap.ConcurrentMap[string, *pub_balancer.BrokerStats]) (isChanged bool, brokenCount int, err error) {
+
+	conf, err := fca.ReadTopicConfFromFiler(t)
+	if err != nil && !errors.Is(err, filer_pb.ErrNotFound) {
+		return false, 0, err
+	}
+	if conf == nil || len(conf.BrokerPartitionAssignments) == 0 {
+		partitions, rebuildErr := opt.findLatestPartitions(fca, t)
+		if rebuildErr != nil {
+			return false, 0, fmt.Errorf("rebuild topic.conf: %v", rebuildErr)
+		}
+		if len(partitions) == 0 {
+			return false, 0, fmt.Errorf("no topic.conf and no partitions found")
+		}
+		var recordType *schema_pb.RecordType
+		if conf != nil {
+			recordType = conf.RecordType
+		}
+		conf = &mq_pb.ConfigureTopicResponse{
+			RecordType: recordType,
+		}
+		for _, partition := range partitions {
+			conf.BrokerPartitionAssignments = append(conf.BrokerPartitionAssignments, &mq_pb.BrokerPartitionAssignment{
+				Partition: partition.ToPbPartition(),
+			})
+		}
+		fmt.Printf("topic %v: rebuilt topic.conf with %d partitions\n", t, len(partitions))
+		isChanged = true
+	}
+
+	// validate the log segments of each partition
+	for _, assignment := range conf.BrokerPartitionAssignments {
+		partition := topic.FromPbPartition(assignment.Partition)
+		segmentCount, brokenSegments, validateErr := opt.validatePartitionSegments(fca, t, partition)
+		if validateErr != nil {
+			return false, brokenCount, fmt.Errorf("validate partition %v: %v", partition, validateErr)
+		}
+		for _, brokenSegment := range brokenSegments {
+			fmt.Printf("topic %v partition %v: %s\n", t, partition, brokenSegment)
+		}
+		brokenCount += len(brokenSegments)
+		fmt.Printf("topic %v partition %v: %d log segments, %d broken\n", t, partition, segmentCount, len(brokenSegments))
+	}
+
+	// assign the partitions to the current brokers
+	if pub_balancer.EnsureAssignmentsToActiveBrokers(activeBrokers, pub_balancer.FollowerCount(conf.Replication), conf.BrokerPartitionAssignments) {
+		isChanged = true
+	}
+	for _, assignment := range conf.BrokerPartitionAssignments {
+		fmt.Printf("topic %v partition %v: leader %s follower %s\n", t, topic.FromPbPartition(assignment.Partition), assignment.LeaderBroker, assignment.FollowerBroker)
+	}
+
+	if isChanged && *opt.apply {
+		if err = fca.SaveTopicConfToFiler(t, conf); err != nil {
+			return false, brokenCount, err
+		}
+		fmt.Printf("topic %v: saved topic.conf\n", t)
+	}
+
+	return isChanged, brokenCount, nil
+}
+
+// findLatestPartitions reads the partitions from the latest generation directory, e.g., /topics/<ns>/<name>/v2024-01-01-00-00-00/0000-0630
+func (opt *MqRecoverOptions) findLatestPartitions(fca *filer_client.FilerClientAccessor, t topic.Topic) (partitions []topic.Partition, err error) {
+	err = fca.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		var latestVersion string
+		var latestTime time.Time
+		if listErr := filer_pb.SeaweedList(client, t.Dir(), "", func(entry *filer_pb.Entry, isLast bool) error {
+			if !entry.IsDirectory {
+				return nil
+			}
+			if versionTime, parseErr := topic.ParseTopicVersion(entry.Name); parseErr == nil && versionTime.After(latestTime) {
+				latestVersion, latestTime = entry.Name, versionTime
+			}
+			return nil
+		}, "", false, 0); listErr != nil {
+			return listErr
+		}
+		if latestVersion == "" {
+			return nil
+		}
+		return filer_pb.SeaweedList(client, t.Dir()+"/"+latestVersion, "", func(entry *filer_pb.Entry, isLast bool) error {
+			if !entry.IsDirectory {
+				return nil
+			}
+			start, stop := topic.ParsePartitionBoundary(entry.Name)
+			if start >= stop {
+				return nil
+			}
+			partitions = append(partitions, topic.Partition{
+				RangeStart: start,
+				RangeStop:  stop,
+				RingSize:   pub_balancer.MaxPartitionCount,
+				UnixTimeNs: latestTime.UnixNano(),
+			})
+			return nil
+		}, "", false, 0)
+	})
+	return
+}
+
+// validatePartitionSegments checks each log segment is named by its start time, and all its chunks are on live volumes
+func (opt *MqRecoverOptions) validatePartitionSegments(fca *filer_client.FilerClientAccessor, t topic.Topic, partition topic.Partition) (segmentCount int, brokenSegments []string, err error) {
+	partitionDir := topic.PartitionDir(t, partition)
+	err = fca.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.SeaweedList(client, partitionDir, "", func(entry *filer_pb.Entry, isLast bool) error {
+			if entry.IsDirectory || len(entry.Content) > 0 {
+				// skip the small files, e.g., .offset files
+				return nil
+			}
+			segmentCount++
+			if segmentErr := opt.validateSegment(client, entry); segmentErr != nil {
+				brokenSegments = append(brokenSegments, fmt.Sprintf("%s: %v", entry.Name, segmentErr))
+			}
+			return nil
+		}, "", false, 0)
+	})
+	if errors.Is(err, filer_pb.ErrNotFound) {
+		// no data has been written to the partition yet
+		return 0, nil, nil
+	}
+	return
+}
+
+func (opt *MqRecoverOptions) validateSegment(client filer_pb.SeaweedFilerClient, entry *filer_pb.Entry) error {
+	if !strings.HasSuffix(entry.Name, ".parquet") {
+		if _, err := time.Parse(topic.TIME_FORMAT, entry.Name); err != nil {
+			return fmt.Errorf("unexpected segment name")
+		}
+	}
+	if len(entry.GetChunks()) == 0 {
+		return fmt.Errorf("no data")
+	}
+	for _, chunk := range entry.GetChunks() {
+		vid := filer.VolumeId(chunk.GetFileIdString())
+		lookupErr, found := opt.volumeStatus[vid]
+		if !found {
+			lookupErr = lookupVolume(client, vid)
+			opt.volumeStatus[vid] = lookupErr
+		}
+		if lookupErr != nil {
+			return fmt.Errorf("chunk %s: %v", chunk.GetFileIdString(), lookupErr)
+		}
+	}
+	return nil
+}
+
+func lookupVolume(client filer_pb.SeaweedFilerClient, vid string) error {
+	resp, err := client.LookupVolume(context.Background(), &filer_pb.LookupVolumeRequest{
+		VolumeIds: []string{vid},
+	})
+	if err != nil {
+		return err
+	}
+	if locations, found := resp.LocationsMap[vid]; !found || len(locations.Locations) == 0 {
+		return fmt.Errorf("volume %s not found", vid)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/nfs"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var (
+	nfsStandaloneOptions NfsOption
+)
+
+type NfsOption struct {
+	filer         *string
+	filerRootPath *string
+	bindIp        *string
+	port          *int
+	collection    *string
+	replication   *string
+	disk          *string
+	cacheDir      *string
+	cacheSizeMB   *int64
+}
+
+func init() {
+	cmdNfs.Run = runNfs // break init cycle
+	nfsStandaloneOptions.filer = cmdNfs.Flag.String("filer", "localhost:8888", "filer server address")
+	nfsStandaloneOptions.filerRootPath = cmdNfs.Flag.String("filer.path", "/", "export this remote path from filer server")
+	nfsStandaloneOptions.bindIp = cmdNfs.Flag.String("ip.bind", "", "ip address to bind to. Default listen to all.")
+	nfsStandaloneOptions.port = cmdNfs.Flag.Int("port", 2049, "nfs server listen port")
+	nfsStandaloneOptions.collection = cmdNfs.Flag.String("collection", "", "collection to create the files")
+	nfsStandaloneOptions.replication = cmdNfs.Flag.String("replication", "", "replication to create the files")
+	nfsStandaloneOptions.disk = cmdNfs.Flag.String("disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
+	nfsStandaloneOptions.cacheDir = cmdNfs.Flag.String("cacheDir", os.TempDir(), "local cache directory for file chunks")
+	nfsStandaloneOptions.cacheSizeMB = cmdNfs.Flag.Int64("cacheCapacityMB", 0, "local cache capacity in MB")
+}
+
+var cmdNfs = &Command{
+	UsageLine: "nfs -port=2049 -filer=<ip:port> -filer.path=/",
+	Short:     "start an NFSv4 server that is backed by a filer",
+	Long: `start an NFSv4 server that exports a filer directory, for the clients which can not use FUSE.
+
+	The NFSv4.0 and NFSv4.1 clients can mount the exported directory as the NFS root, e.g.,
+
+		mount -t nfs4 -o vers=4.1 <nfs_server>:/ /mnt/seaweedfs
+
+	The file handles are the inodes of the filer entries followed by the entry paths, so they are still
+	valid after the nfs server restarts. The entries created without inodes by the other filer clients get
+	the file ids from their paths, and their file handles become stale if they are renamed by other clients.
+
+	The clients are identified by AUTH_SYS. The owner and the group are the numeric ids, and the
+	permissions are reported to the clients but not enforced by the server, same as the other gateways.
+	The byte range locks and the delegations are not supported.
+
+`,
+}
+
+func runNfs(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+
+	glog.V(0).Infof("Starting Seaweed NFS Server %s at port %d", util.Version(), *nfsStandaloneOptions.port)
+
+	return nfsStandaloneOptions.startNfsServer()
+
+}
+
+func (no *NfsOption) startNfsServer() bool {
+
+	filerAddress := pb.ServerAddress(*no.filer)
+
+	grpcDialOption := security.LoadClientTLS(util.GetViper(), "grpc.client")
+
+	var cipher bool
+	// connect to filer
+	for {
+		err := pb.WithGrpcFilerClient(false, 0, filerAddress, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+			resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
+			if err != nil {
+				return fmt.Errorf("get filer %s configuration: %v", filerAddress, err)
+			}
+			cipher = resp.Cipher
+			return nil
+		})
+		if err != nil {
+			glog.V(0).Infof("wait to connect to filer %s grpc address %s", *no.filer, filerAddress.ToGrpcAddress())
+			time.Sleep(time.Second)
+		} else {
+			glog.V(0).Infof("connected to filer %s grpc address %s", *no.filer, filerAddress.ToGrpcAddress())
+			break
+		}
+	}
+
+	nfsServer, err := nfs.NewNfsServer(&nfs.NfsServerOption{
+		Filer:          filerAddress,
+		FilerRootPath:  *no.filerRootPath,
+		GrpcDialOption: grpcDialOption,
+		Collection:     *no.collection,
+		Replication:    *no.replication,
+		DiskType:       *no.disk,
+		Cipher:         cipher,
+		CacheDir:       util.ResolvePath(*no.cacheDir),
+		CacheSizeMB:    *no.cacheSizeMB,
+	})
+	if err != nil {
+		glog.Fatalf("NFS Server startup error: %v", err)
+	}
+
+	listenAddress := util.JoinHostPort(*no.bindIp, *no.port)
+	nfsListener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		glog.Fatalf("NFS Server listener on %s error: %v", listenAddress, err)
+	}
+
+	glog.V(0).Infof("Start Seaweed NFS Server %s at %s exporting %s", util.Version(), listenAddress, *no.filerRootPath)
+	if err = nfsServer.Serve(nfsListener); err != nil {
+		glog.Fatalf("NFS Server Fail to serve: %v", err)
+	}
+
+	return true
+
+}
@@ -37,6 +37,7 @@ var (
 
 type S3Options struct {
 	filer                     *string
+	balanceFilerReads         *bool
 	bindIp                    *string
 	port                      *int
 	portHttps                 *int
@@ -50,6 +51,7 @@ type S3Options struct {
 	tlsVerifyClientCert       *bool
 	metricsHttpPort           *int
 	metricsHttpIp             *string
+	metricsMaxLabelValues     *int
 	allowEmptyFolder          *bool
 	allowDeleteBucketNotEmpty *bool
 	auditLogConfig            *string
@@ -61,7 +63,8 @@ type S3Options struct {
 
 func init() {
 	cmdS3.Run = runS3 // break init cycle
-	s3StandaloneOptions.filer = cmdS3.Flag.String("filer", "localhost:8888", "filer server address")
+	s3StandaloneOptions.filer = cmdS3.Flag.String("filer", "localhost:8888", "comma-separated filer server addresses, failing over to the next one if the filer is unreachable")
+	s3StandaloneOptions.balanceFilerReads = cmdS3.Flag.Bool("filer.balanceReads", false, "spread the object reads over all the healthy filers, only if the filers share the same filer store")
 	s3StandaloneOptions.bindIp = cmdS3.Flag.String("ip.bind", "", "ip address to bind to. Default to localhost.")
 	s3StandaloneOptions.port = cmdS3.Flag.Int("port", 8333, "s3 server http listen port")
 	s3StandaloneOptions.portHttps = cmdS3.Flag.Int("port.https", 0, "s3 server https listen port")
@@ -77,6 +80,7 @@ func init() {
 	s3StandaloneOptions.tlsVerifyClientCert = cmdS3.Flag.Bool("tlsVerifyClientCert", false, "whether to verify the client's certificate")
 	s3StandaloneOptions.metricsHttpPort = cmdS3.Flag.Int("metricsPort", 0, "Prometheus metrics listen port")
 	s3StandaloneOptions.metricsHttpIp = cmdS3.Flag.String("metricsIp", "", "metrics listen ip. If empty, default to same as -ip.bind option.")
+	s3StandaloneOptions.metricsMaxLabelValues = cmdS3.Flag.Int("metricsMaxLabelValues", 1000, "max number of active buckets and identities having their own metrics, the rest are counted as \"_other\". 0 means no limit.")
 	s3StandaloneOptions.allowEmptyFolder = cmdS3.Flag.Bool("allowEmptyFolder", true, "allow empty folders")
 	s3StandaloneOptions.allowDeleteBucketNotEmpty = cmdS3.Flag.Bool("allowDeleteBucketNotEmpty", true, "allow recursive deleting all entries along with bucket")
 	s3StandaloneOptions.localFilerSocket = cmdS3.Flag.String("localFilerSocket", "", "local filer socket path")
@@ -84,7 +88,7 @@ func init() {
 }
 
 var cmdS3 = &Command{
-	UsageLine: "s3 [-port=8333] [-filer=<ip:port>] [-config=</path/to/config.json>]",
+	UsageLine: "s3 [-port=8333] [-filer=<ip:port>[,<ip:port>]...] [-config=</path/to/config.json>]",
 	Short:     "start a s3 API compatible server that is backed by a filer",
 	Long: `start a s3 API compatible server that is backed by a filer.
 
@@ -190,34 +194,42 @@ func (s3opt *S3Options) GetCertificateWithUpdate(*tls.ClientHelloInfo) (*tls.Cer
 
 func (s3opt *S3Options) startS3Server() bool {
 
-	filerAddress := pb.ServerAddress(*s3opt.filer)
+	loadAuditConfiguration()
+
+	filerAddresses := pb.ServerAddresses(*s3opt.filer).ToAddresses()
 
 	filerBucketsPath := "/buckets"
 	filerGroup := ""
+	var masters []pb.ServerAddress
 
 	grpcDialOption := security.LoadClientTLS(util.GetViper(), "grpc.client")
 
+	if s3opt.metricsMaxLabelValues != nil {
+		stats_collect.S3MaxLabelValues = *s3opt.metricsMaxLabelValues
+	}
+
 	// metrics read from the filer
 	var metricsAddress string
 	var metricsIntervalSec int
 
 	for {
-		err := pb.WithGrpcFilerClient(false, 0, filerAddress, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		err := pb.WithOneOfGrpcFilerClients(false, filerAddresses, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
 			resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
 			if err != nil {
-				return fmt.Errorf("get filer %s configuration: %v", filerAddress, err)
+				return fmt.Errorf("get filer %v configuration: %v", filerAddresses, err)
 			}
 			filerBucketsPath = resp.DirBuckets
 			filerGroup = resp.FilerGroup
+			masters = pb.ServerAddresses(strings.Join(resp.Masters, ",")).ToAddresses()
 			metricsAddress, metricsIntervalSec = resp.MetricsAddress, int(resp.MetricsIntervalSec)
 			glog.V(0).Infof("S3 read filer buckets dir: %s", filerBucketsPath)
 			return nil
 		})
 		if err != nil {
-			glog.V(0).Infof("wait to connect to filer %s grpc address %s", *s3opt.filer, filerAddress.ToGrpcAddress())
+			glog.V(0).Infof("wait to connect to filer %s: %v", *s3opt.filer, err)
 			time.Sleep(time.Second)
 		} else {
-			glog.V(0).Infof("connected to filer %s grpc address %s", *s3opt.filer, filerAddress.ToGrpcAddress())
+			glog.V(0).Infof("connected to filer %s", *s3opt.filer)
 			break
 		}
 	}
@@ -230,7 +242,8 @@ func (s3opt *S3Options) startS3Server() bool {
 		localFilerSocket = *s3opt.localFilerSocket
 	}
 	s3ApiServer, s3ApiServer_err := s3api.NewS3ApiServer(router, &s3api.S3ApiServerOption{
-		Filer:                     filerAddress,
+		Filers:                    filerAddresses,
+		BalanceFilerReads:         s3opt.balanceFilerReads != nil && *s3opt.balanceFilerReads,
 		Port:                      *s3opt.port,
 		Config:                    *s3opt.config,
 		DomainName:                *s3opt.domainName,
@@ -242,6 +255,7 @@ func (s3opt *S3Options) startS3Server() bool {
 		LocalFilerSocket:          localFilerSocket,
 		DataCenter:                *s3opt.dataCenter,
 		FilerGroup:                filerGroup,
+		Masters:                   masters,
 	})
 	if s3ApiServer_err != nil {
 		glog.Fatalf("S3 API Server startup error: %v", s3ApiServer_err)
@@ -296,6 +310,7 @@ func (s3opt *S3Options) startS3Server() bool {
 	grpcS := pb.NewGrpcServer(security.LoadServerTLS(util.GetViper(), "grpc.s3"))
 	s3_pb.RegisterSeaweedS3Server(grpcS, s3ApiServer)
 	reflection.Register(grpcS)
+	s3ApiServer.RegisterGrpcHealth(grpcS)
 	if grpcLocalL != nil {
 		go grpcS.Serve(grpcLocalL)
 	}
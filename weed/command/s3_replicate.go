@@ -0,0 +1,407 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/remote_pb"
+	"github.com/seaweedfs/seaweedfs/weed/remote_storage"
+	"github.com/seaweedfs/seaweedfs/weed/replication/source"
+	"github.com/seaweedfs/seaweedfs/weed/s3api"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+type S3ReplicateOptions struct {
+	filerAddress       *string
+	grpcDialOption     grpc.DialOption
+	readChunkFromFiler *bool
+	timeAgo            *time.Duration
+	concurrency        *int
+
+	bucketsDir  string
+	clientId    int32
+	clientEpoch int32
+
+	// the replication configurations of the buckets, and the configured remote storages
+	configLock   sync.RWMutex
+	replications map[string]*s3.ReplicationConfiguration
+	remoteConfs  map[string]*remote_pb.RemoteConf
+}
+
+var _ = filer_pb.FilerClient(&S3ReplicateOptions{})
+
+func (option *S3ReplicateOptions) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithFilerClient(streamingMode, option.clientId, pb.ServerAddress(*option.filerAddress), option.grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		return fn(client)
+	})
+}
+func (option *S3ReplicateOptions) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (option *S3ReplicateOptions) GetDataCenter() string {
+	return ""
+}
+
+var (
+	s3ReplicateOptions S3ReplicateOptions
+)
+
+func init() {
+	cmdS3Replicate.Run = runS3Replicate // break init cycle
+	s3ReplicateOptions.filerAddress = cmdS3Replicate.Flag.String("filer", "localhost:8888", "filer of the SeaweedFS cluster")
+	s3ReplicateOptions.readChunkFromFiler = cmdS3Replicate.Flag.Bool("filerProxy", false, "read file chunks from filer instead of volume servers")
+	s3ReplicateOptions.timeAgo = cmdS3Replicate.Flag.Duration("timeAgo", 0, "start time before now, instead of the last replicated offset. \"300ms\", \"1.5h\" or \"2h45m\". Valid time units are \"ns\", \"us\" (or \"µs\"), \"ms\", \"s\", \"m\", \"h\"")
+	s3ReplicateOptions.concurrency = cmdS3Replicate.Flag.Int("concurrency", 32, "number of objects replicated concurrently")
+	s3ReplicateOptions.clientId = util.RandomInt32()
+}
+
+var cmdS3Replicate = &Command{
+	UsageLine: "s3.replicate",
+	Short:     "resumable continuously replicate the s3 objects to other clusters, as configured by PutBucketReplication",
+	Long: `resumable continuously replicate the s3 objects to other clusters, as configured by PutBucketReplication
+
+	s3.replicate listens on filer local buckets update events.
+	The new or updated objects in the buckets with a replication configuration are written to the destination,
+	a bucket in a remote storage configured by "remote.configure", e.g., another SeaweedFS cluster or AWS S3.
+	The deletes are replicated if the DeleteMarkerReplication of the rule is enabled.
+
+	The rule destination is the bucket arn, with the account as the remote storage name:
+
+		<Destination><Bucket>arn:aws:s3:::backup</Bucket><Account>cloud1</Account></Destination>
+
+	The objects written through the s3 gateway are PENDING, and then COMPLETED or FAILED,
+	shown as the x-amz-replication-status of HeadObject.
+
+		weed s3.replicate -filer=localhost:8888
+
+`,
+}
+
+func runS3Replicate(cmd *Command, args []string) bool {
+
+	util.LoadSecurityConfiguration()
+	grpcDialOption := security.LoadClientTLS(util.GetViper(), "grpc.client")
+	s3ReplicateOptions.grpcDialOption = grpcDialOption
+
+	filerAddress := pb.ServerAddress(*s3ReplicateOptions.filerAddress)
+
+	filerSource := &source.FilerSource{}
+	filerSource.DoInitialize(
+		filerAddress.ToHttpAddress(),
+		filerAddress.ToGrpcAddress(),
+		"/", // does not matter
+		*s3ReplicateOptions.readChunkFromFiler,
+	)
+
+	s3ReplicateOptions.bucketsDir = "/buckets"
+	s3ReplicateOptions.WithFilerClient(false, func(filerClient filer_pb.SeaweedFilerClient) error {
+		resp, err := filerClient.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
+		if err != nil {
+			return err
+		}
+		s3ReplicateOptions.bucketsDir = resp.DirBuckets
+		return nil
+	})
+
+	fmt.Printf("replicate buckets in %s ...\n", s3ReplicateOptions.bucketsDir)
+	util.RetryUntil("s3.replicate", func() error {
+		return s3ReplicateOptions.followBucketUpdatesAndReplicate(filerSource)
+	}, func(err error) bool {
+		if err != nil {
+			glog.Errorf("replicate %s: %v", s3ReplicateOptions.bucketsDir, err)
+		}
+		return true
+	})
+	return true
+
+}
+
+func (option *S3ReplicateOptions) followBucketUpdatesAndReplicate(filerSource *source.FilerSource) error {
+
+	if err := option.collectReplicationConfigurations(); err != nil {
+		return fmt.Errorf("read replication configurations: %v", err)
+	}
+
+	// the offset is tracked separately from filer.remote.gateway, which may follow the same buckets
+	offsetKey := "s3.replicate:" + option.bucketsDir
+	lastOffsetTs := time.Now()
+	if *option.timeAgo != 0 {
+		lastOffsetTs = time.Now().Add(-*option.timeAgo)
+	} else if lastOffsetTsNs, err := remote_storage.GetSyncOffset(option.grpcDialOption, pb.ServerAddress(*option.filerAddress), offsetKey); err == nil && lastOffsetTsNs > 0 {
+		lastOffsetTs = time.Unix(0, lastOffsetTsNs)
+		glog.V(0).Infof("resume from %v", lastOffsetTs)
+	}
+
+	processor := NewMetadataProcessor(option.makeReplicationEventProcessor(filerSource), *option.concurrency, lastOffsetTs.UnixNano())
+
+	var lastLogTsNs = time.Now().UnixNano()
+	processEventFnWithOffset := pb.AddOffsetFunc(func(resp *filer_pb.SubscribeMetadataResponse) error {
+		processor.AddSyncJob(resp)
+		return nil
+	}, 3*time.Second, func(counter int64, lastTsNs int64) error {
+		offsetTsNs := processor.processedTsWatermark.Load()
+		if offsetTsNs == 0 {
+			return nil
+		}
+		now := time.Now().UnixNano()
+		glog.V(0).Infof("s3 replicate %s progressed to %v %0.2f/sec", *option.filerAddress, time.Unix(0, offsetTsNs), float64(counter)/(float64(now-lastLogTsNs)/1e9))
+		lastLogTsNs = now
+		return remote_storage.SetSyncOffset(option.grpcDialOption, pb.ServerAddress(*option.filerAddress), offsetKey, offsetTsNs)
+	})
+
+	option.clientEpoch++
+
+	metadataFollowOption := &pb.MetadataFollowOption{
+		ClientName:             "s3.replicate",
+		ClientId:               option.clientId,
+		ClientEpoch:            option.clientEpoch,
+		SelfSignature:          0,
+		PathPrefix:             option.bucketsDir + "/",
+		AdditionalPathPrefixes: []string{filer.DirectoryEtcRemote},
+		DirectoriesToWatch:     nil,
+		StartTsNs:              lastOffsetTs.UnixNano(),
+		StopTsNs:               0,
+		EventErrorType:         pb.RetryForeverOnError,
+	}
+
+	return pb.FollowMetadata(pb.ServerAddress(*option.filerAddress), option.grpcDialOption, metadataFollowOption, processEventFnWithOffset)
+}
+
+func (option *S3ReplicateOptions) collectReplicationConfigurations() error {
+	replications := make(map[string]*s3.ReplicationConfiguration)
+	err := filer_pb.List(option, option.bucketsDir, "", func(entry *filer_pb.Entry, isLast bool) error {
+		if config := replicationConfigurationOf(entry); config != nil {
+			replications[entry.Name] = config
+		}
+		return nil
+	}, "", false, math.MaxUint32)
+	if err != nil {
+		return err
+	}
+
+	remoteConfs := make(map[string]*remote_pb.RemoteConf)
+	err = filer_pb.List(option, filer.DirectoryEtcRemote, "", func(entry *filer_pb.Entry, isLast bool) error {
+		if !strings.HasSuffix(entry.Name, filer.REMOTE_STORAGE_CONF_SUFFIX) {
+			return nil
+		}
+		conf := &remote_pb.RemoteConf{}
+		if err := proto.Unmarshal(entry.Content, conf); err != nil {
+			return fmt.Errorf("unmarshal %s/%s: %v", filer.DirectoryEtcRemote, entry.Name, err)
+		}
+		remoteConfs[conf.Name] = conf
+		return nil
+	}, "", false, math.MaxUint32)
+	if err != nil && err != filer_pb.ErrNotFound {
+		return err
+	}
+
+	option.configLock.Lock()
+	defer option.configLock.Unlock()
+	option.replications = replications
+	option.remoteConfs = remoteConfs
+	return nil
+}
+
+func replicationConfigurationOf(bucketEntry *filer_pb.Entry) *s3.ReplicationConfiguration {
+	data, found := bucketEntry.Extended[s3_constants.ExtReplicationKey]
+	if !bucketEntry.IsDirectory || !found {
+		return nil
+	}
+	config, err := s3api.ParseReplicationConfiguration(data)
+	if err != nil {
+		glog.Warningf("replication configuration of bucket %s: %v", bucketEntry.Name, err)
+		return nil
+	}
+	return config
+}
+
+func (option *S3ReplicateOptions) makeReplicationEventProcessor(filerSource *source.FilerSource) pb.ProcessMetadataFunc {
+
+	handleEtcRemoteChanges := func(resp *filer_pb.SubscribeMetadataResponse) error {
+		message := resp.EventNotification
+		option.configLock.Lock()
+		defer option.configLock.Unlock()
+		if message.OldEntry != nil && strings.HasSuffix(message.OldEntry.Name, filer.REMOTE_STORAGE_CONF_SUFFIX) {
+			delete(option.remoteConfs, strings.TrimSuffix(message.OldEntry.Name, filer.REMOTE_STORAGE_CONF_SUFFIX))
+		}
+		if message.NewEntry != nil && strings.HasSuffix(message.NewEntry.Name, filer.REMOTE_STORAGE_CONF_SUFFIX) {
+			conf := &remote_pb.RemoteConf{}
+			if err := proto.Unmarshal(message.NewEntry.Content, conf); err != nil {
+				return fmt.Errorf("unmarshal %s/%s: %v", filer.DirectoryEtcRemote, message.NewEntry.Name, err)
+			}
+			option.remoteConfs[conf.Name] = conf
+		}
+		return nil
+	}
+
+	handleBucketChanges := func(resp *filer_pb.SubscribeMetadataResponse) {
+		message := resp.EventNotification
+		option.configLock.Lock()
+		defer option.configLock.Unlock()
+		if message.OldEntry != nil && resp.Directory == option.bucketsDir {
+			delete(option.replications, message.OldEntry.Name)
+		}
+		if message.NewEntry != nil && message.NewParentPath == option.bucketsDir {
+			if config := replicationConfigurationOf(message.NewEntry); config != nil {
+				option.replications[message.NewEntry.Name] = config
+			} else {
+				delete(option.replications, message.NewEntry.Name)
+			}
+		}
+	}
+
+	return func(resp *filer_pb.SubscribeMetadataResponse) error {
+		message := resp.EventNotification
+		if strings.HasPrefix(resp.Directory, filer.DirectoryEtcRemote) {
+			return handleEtcRemoteChanges(resp)
+		}
+		if filer_pb.IsEmpty(resp) {
+			return nil
+		}
+		if resp.Directory == option.bucketsDir || message.NewParentPath == option.bucketsDir {
+			handleBucketChanges(resp)
+			return nil
+		}
+
+		var oldPath, newPath util.FullPath
+		if message.OldEntry != nil {
+			oldPath = util.NewFullPath(resp.Directory, message.OldEntry.Name)
+		}
+		if message.NewEntry != nil {
+			newPath = util.NewFullPath(message.NewParentPath, message.NewEntry.Name)
+		}
+		isRenamed := message.OldEntry != nil && message.NewEntry != nil && oldPath != newPath
+
+		// the object deleted, or renamed from
+		if message.OldEntry != nil && (message.NewEntry == nil || isRenamed) {
+			if err := option.replicateDelete(resp.Directory, message.OldEntry); err != nil {
+				return err
+			}
+		}
+
+		// the object written through the s3 gateway is pending, and a renamed object is written as new
+		if message.NewEntry != nil {
+			status := string(message.NewEntry.Extended[s3_constants.ExtReplicationStatusKey])
+			if status == s3.ReplicationStatusPending || isRenamed {
+				return option.replicateWrite(filerSource, message.NewParentPath, message.NewEntry)
+			}
+		}
+		return nil
+	}
+}
+
+// replicationRuleOf finds the rule replicating the object, and the remote storage to replicate to
+func (option *S3ReplicateOptions) replicationRuleOf(dir string, entry *filer_pb.Entry) (rule *s3.ReplicationRule, dest *remote_pb.RemoteStorageLocation, remoteConf *remote_pb.RemoteConf, ok bool) {
+	if entry.IsDirectory || isMultipartUploadDir(dir+"/") {
+		return nil, nil, nil, false
+	}
+	bucketPath, ok := extractBucketPath(option.bucketsDir, dir)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	key := strings.TrimPrefix(string(util.NewFullPath(dir, entry.Name)), string(bucketPath))
+
+	option.configLock.RLock()
+	defer option.configLock.RUnlock()
+	rule = s3api.ReplicationRuleOf(option.replications[bucketPath.Name()], key, s3api.ObjectTagsOf(entry.Extended))
+	if rule == nil {
+		return nil, nil, nil, false
+	}
+	remoteName, destBucket := s3api.ReplicationDestination(rule)
+	if remoteConf, ok = option.remoteConfs[remoteName]; !ok {
+		glog.Warningf("replicate %s/%s to un-configured remote storage %s", dir, entry.Name, remoteName)
+		return rule, nil, nil, false
+	}
+	dest = &remote_pb.RemoteStorageLocation{
+		Name:   remoteName,
+		Bucket: destBucket,
+		Path:   key,
+	}
+	return rule, dest, remoteConf, true
+}
+
+func (option *S3ReplicateOptions) replicateWrite(filerSource *source.FilerSource, dir string, entry *filer_pb.Entry) error {
+	_, dest, remoteConf, ok := option.replicationRuleOf(dir, entry)
+	if !ok {
+		// the rule is removed since, or its remote storage is not configured
+		if string(entry.Extended[s3_constants.ExtReplicationStatusKey]) == s3.ReplicationStatusPending {
+			return option.updateReplicationStatus(dir, entry, s3.ReplicationStatusFailed)
+		}
+		return nil
+	}
+	// the data encrypted by the s3 gateway can not be read by the destination
+	if _, isEncrypted := entry.Extended[s3_constants.ExtSseNonceKey]; isEncrypted {
+		glog.Warningf("skip replicating encrypted %s/%s", dir, entry.Name)
+		return option.updateReplicationStatus(dir, entry, s3.ReplicationStatusFailed)
+	}
+
+	client, err := remote_storage.GetRemoteStorage(remoteConf)
+	if err != nil {
+		return err
+	}
+
+	// the remote storage saves the extended attributes as the object tags
+	replica := proto.Clone(entry).(*filer_pb.Entry)
+	replica.Extended = make(map[string][]byte)
+	for k, v := range s3api.ObjectTagsOf(entry.Extended) {
+		replica.Extended[k] = []byte(v)
+	}
+
+	status := s3.ReplicationStatusCompleted
+	if _, writeErr := retriedWriteFile(client, filerSource, replica, dest); writeErr != nil {
+		status = s3.ReplicationStatusFailed
+	}
+	return option.updateReplicationStatus(dir, entry, status)
+}
+
+func (option *S3ReplicateOptions) replicateDelete(dir string, entry *filer_pb.Entry) error {
+	rule, dest, remoteConf, ok := option.replicationRuleOf(dir, entry)
+	if !ok || !s3api.IsDeleteReplicated(rule) {
+		return nil
+	}
+	client, err := remote_storage.GetRemoteStorage(remoteConf)
+	if err != nil {
+		return err
+	}
+	glog.V(0).Infof("delete %s", remote_storage.FormatLocation(dest))
+	return client.DeleteFile(dest)
+}
+
+// updateReplicationStatus sets the replication status of the object, unless it is overwritten since
+func (option *S3ReplicateOptions) updateReplicationStatus(dir string, entry *filer_pb.Entry, status string) error {
+	current, err := filer_pb.GetEntry(option, util.NewFullPath(dir, entry.Name))
+	if err != nil {
+		if err == filer_pb.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	if current == nil || !filer.IsSameData(current, entry) {
+		return nil
+	}
+	if current.Extended == nil {
+		current.Extended = make(map[string][]byte)
+	}
+	current.Extended[s3_constants.ExtReplicationStatusKey] = []byte(status)
+	return option.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.UpdateEntry(context.Background(), &filer_pb.UpdateEntryRequest{
+			Directory: dir,
+			Entry:     current,
+		})
+		return err
+	})
+}
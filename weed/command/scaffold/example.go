@@ -19,3 +19,15 @@ var Master string
 
 //go:embed shell.toml
 var Shell string
+
+//go:embed mq_connect.toml
+var MqConnect string
+
+//go:embed mq_broker.toml
+var MqBroker string
+
+//go:embed derive.toml
+var Derive string
+
+//go:embed audit.toml
+var Audit string
@@ -9,6 +9,7 @@ import (
 
 	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 
+	"github.com/seaweedfs/seaweedfs/weed/cluster/discovery"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -89,7 +90,8 @@ func init() {
 	masterOptions.port = cmdServer.Flag.Int("master.port", 9333, "master server http listen port")
 	masterOptions.portGrpc = cmdServer.Flag.Int("master.port.grpc", 0, "master server grpc listen port")
 	masterOptions.metaFolder = cmdServer.Flag.String("master.dir", "", "data directory to store meta data, default to same as -dir specified")
-	masterOptions.peers = cmdServer.Flag.String("master.peers", "", "all master nodes in comma separated ip:masterPort list")
+	masterOptions.peers = cmdServer.Flag.String("master.peers", "", "all master nodes in comma separated ip:masterPort list, or a service registry, e.g., consul+localhost:8500")
+	masterOptions.peersCount = cmdServer.Flag.Int("master.peers.count", 1, "with the master peers from a service registry, the number of master nodes to wait for before starting")
 	masterOptions.volumeSizeLimitMB = cmdServer.Flag.Uint("master.volumeSizeLimitMB", 30*1000, "Master stops directing writes to oversized volumes.")
 	masterOptions.volumePreallocate = cmdServer.Flag.Bool("master.volumePreallocate", false, "Preallocate disk space for volumes.")
 	masterOptions.maxParallelVacuumPerServer = cmdServer.Flag.Int("master.maxParallelVacuumPerServer", 1, "maximum number of volumes to vacuum in parallel on one volume server")
@@ -100,6 +102,8 @@ func init() {
 	masterOptions.raftResumeState = cmdServer.Flag.Bool("master.resumeState", false, "resume previous state on start master server")
 	masterOptions.raftHashicorp = cmdServer.Flag.Bool("master.raftHashicorp", false, "use hashicorp raft")
 	masterOptions.raftBootstrap = cmdServer.Flag.Bool("master.raftBootstrap", false, "Whether to bootstrap the Raft cluster")
+	masterOptions.raftSnapshotInterval = cmdServer.Flag.Duration("master.raftSnapshotInterval", 2*time.Minute, "check whether to snapshot and compact the raft log this often, 0 to disable")
+	masterOptions.raftSnapshotThreshold = cmdServer.Flag.Uint64("master.raftSnapshotThreshold", 8192, "snapshot the raft log when there are this many new entries since the last snapshot")
 	masterOptions.heartbeatInterval = cmdServer.Flag.Duration("master.heartbeatInterval", 300*time.Millisecond, "heartbeat interval of master servers, and will be randomly multiplied by [1, 1.25)")
 	masterOptions.electionTimeout = cmdServer.Flag.Duration("master.electionTimeout", 10*time.Second, "election timeout of master servers")
 
@@ -121,6 +125,23 @@ func init() {
 	filerOptions.downloadMaxMBps = cmdServer.Flag.Int("filer.downloadMaxMBps", 0, "download max speed for each download request, in MB per second")
 	filerOptions.diskType = cmdServer.Flag.String("filer.disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
 	filerOptions.exposeDirectoryData = cmdServer.Flag.Bool("filer.exposeDirectoryData", true, "expose directory data via filer. If false, filer UI will be innaccessible.")
+	filerOptions.metaLogRetention = cmdServer.Flag.Duration("filer.metaLog.retention", 0, "delete the metadata change logs older than this, 0 to keep them forever")
+	filerOptions.metaLogSegment = cmdServer.Flag.Duration("filer.metaLog.segment", time.Minute, "each metadata log file collects the changes of this duration, whole minutes dividing an hour")
+	filerOptions.metaLogCompactAfter = cmdServer.Flag.Duration("filer.metaLog.compactAfter", 0, "merge the chunks of the metadata log files older than this, 0 to disable")
+	filerOptions.dirStatsDepth = cmdServer.Flag.Int("filer.dirStats.depth", 0, "cache the recursive size and file counts of the directories up to this depth, e.g., 2 for /buckets/<bucket>, 0 to disable")
+	filerOptions.dirStatsReconcile = cmdServer.Flag.Duration("filer.dirStats.reconcileInterval", 24*time.Hour, "recount the cached directory statistics this often, 0 to disable")
+	filerOptions.readTransformWorkers = cmdServer.Flag.Int("filer.readTransformWorkers", 0, "number of reads decrypting, decompressing or resizing images at the same time, 0 for the number of CPUs")
+	filerOptions.listConcurrency = cmdServer.Flag.Int("filer.concurrency.list", 0, "max concurrent directory listings over http and gRPC, the others are queued, 0 for unlimited")
+	filerOptions.readConcurrency = cmdServer.Flag.Int("filer.concurrency.read", 0, "max concurrent lookups and file reads over http and gRPC, the others are queued, 0 for unlimited")
+	filerOptions.writeConcurrency = cmdServer.Flag.Int("filer.concurrency.write", 0, "max concurrent creates, updates, renames and uploads over http and gRPC, the others are queued, 0 for unlimited")
+	filerOptions.deleteConcurrency = cmdServer.Flag.Int("filer.concurrency.delete", 0, "max concurrent deletes over http and gRPC, the others are queued, 0 for unlimited")
+	filerOptions.maxQueuedRequests = cmdServer.Flag.Int("filer.concurrency.maxQueued", 1000, "max queued requests of each limited type, the others are rejected with 429 or RESOURCE_EXHAUSTED")
+	filerOptions.requestQueueTimeout = cmdServer.Flag.Duration("filer.concurrency.queueTimeout", 10*time.Second, "reject the queued requests waiting longer than this with 429 or RESOURCE_EXHAUSTED, 0 to wait until admitted")
+	filerOptions.clientLimitKeyBy = cmdServer.Flag.String("filer.clientLimit.keyBy", "ip", "tell the clients apart by [ip|identity], where identity is the subject of the verified jwt, or else the ip")
+	filerOptions.clientRequestsPerSecond = cmdServer.Flag.Float64("filer.clientLimit.requestsPerSecond", 0, "max http requests per second of each client, the others are rejected with 429, 0 for unlimited")
+	filerOptions.clientRequestBurst = cmdServer.Flag.Int("filer.clientLimit.burst", 0, "max http requests of each client in a burst over the requests per second, 0 for the requests per second")
+	filerOptions.clientUploads = cmdServer.Flag.Int("filer.clientLimit.concurrentUploads", 0, "max concurrent http uploads of each client, the others are rejected with 429, 0 for unlimited")
+	filerOptions.clientUploadMBps = cmdServer.Flag.Int("filer.clientLimit.uploadMBps", 0, "max http upload bandwidth of each client in MB/s, 0 for unlimited")
 
 	serverOptions.v.port = cmdServer.Flag.Int("volume.port", 8080, "volume server http listen port")
 	serverOptions.v.portGrpc = cmdServer.Flag.Int("volume.port.grpc", 0, "volume server grpc listen port")
@@ -130,6 +151,10 @@ func init() {
 	serverOptions.v.fixJpgOrientation = cmdServer.Flag.Bool("volume.images.fix.orientation", false, "Adjust jpg orientation when uploading.")
 	serverOptions.v.readMode = cmdServer.Flag.String("volume.readMode", "proxy", "[local|proxy|redirect] how to deal with non-local volume: 'not found|read in remote node|redirect volume location'.")
 	serverOptions.v.compactionMBPerSecond = cmdServer.Flag.Int("volume.compactionMBps", 0, "limit compaction speed in mega bytes per second")
+	serverOptions.v.compactInPlace = cmdServer.Flag.Bool("volume.compactInPlace", false, "vacuum by relocating the live needles inside the .dat file instead of writing a compacted copy, for disks without room for the copy")
+	serverOptions.v.copyCompression = cmdServer.Flag.String("volume.copyCompression", "zstd", "[zstd|none] compression accepted when copying or tailing volumes from other volume servers")
+	serverOptions.v.scrubMBPerSecond = cmdServer.Flag.Int("volume.scrubMBps", 0, "if positive, continuously verify the needle checksums and repair the corrupted ones, limited to the mega bytes per second")
+	serverOptions.v.scrubInterval = cmdServer.Flag.Duration("volume.scrubInterval", 24*time.Hour, "interval between the passes of scrubbing all needles")
 	serverOptions.v.fileSizeLimitMB = cmdServer.Flag.Int("volume.fileSizeLimitMB", 256, "limit file size to avoid out of memory")
 	serverOptions.v.ldbTimeout = cmdServer.Flag.Int64("volume.index.leveldbTimeout", 0, "alive time for leveldb (default to 0). If leveldb of volume is not accessed in ldbTimeout hours, it will be off loaded to reduce opened files and memory consumption.")
 	serverOptions.v.concurrentUploadLimitMB = cmdServer.Flag.Int("volume.concurrentUploadLimitMB", 64, "limit total concurrent upload size")
@@ -140,7 +165,11 @@ func init() {
 	serverOptions.v.idxFolder = cmdServer.Flag.String("volume.dir.idx", "", "directory to store .idx files")
 	serverOptions.v.inflightUploadDataTimeout = cmdServer.Flag.Duration("volume.inflightUploadDataTimeout", 60*time.Second, "inflight upload data wait timeout of volume servers")
 	serverOptions.v.hasSlowRead = cmdServer.Flag.Bool("volume.hasSlowRead", true, "<experimental> if true, this prevents slow reads from blocking other requests, but large file read P99 latency will increase.")
+	serverOptions.v.zeroCopyRead = cmdServer.Flag.Bool("volume.zeroCopyRead", false, "<experimental> send the needles already verified by a previous read with sendfile, skipping the checksum recompute")
 	serverOptions.v.readBufferSizeMB = cmdServer.Flag.Int("volume.readBufferSizeMB", 4, "<experimental> larger values can optimize query performance but will increase some memory usage,Use with hasSlowRead normally")
+	serverOptions.v.readCacheDir = cmdServer.Flag.String("volume.readCache.dir", "", "directory on a fast device, e.g., NVMe or SSD, to cache the needles read from the hdd or remote tier volumes. Empty to disable.")
+	serverOptions.v.readCacheSizeMB = cmdServer.Flag.Int("volume.readCache.sizeMB", 1024, "needle read cache size limit in MB")
+	serverOptions.v.readCacheEviction = cmdServer.Flag.String("volume.readCache.eviction", "lru", "needle read cache eviction, lru or lfu")
 
 	s3Options.port = cmdServer.Flag.Int("s3.port", 8333, "s3 server http listen port")
 	s3Options.portHttps = cmdServer.Flag.Int("s3.port.https", 0, "s3 server https listen port")
@@ -156,6 +185,7 @@ func init() {
 	s3Options.allowEmptyFolder = cmdServer.Flag.Bool("s3.allowEmptyFolder", true, "allow empty folders")
 	s3Options.allowDeleteBucketNotEmpty = cmdServer.Flag.Bool("s3.allowDeleteBucketNotEmpty", true, "allow recursive deleting all entries along with bucket")
 	s3Options.localSocket = cmdServer.Flag.String("s3.localSocket", "", "default to /tmp/seaweedfs-s3-<port>.sock")
+	s3Options.metricsMaxLabelValues = cmdServer.Flag.Int("s3.metricsMaxLabelValues", 1000, "max number of active buckets and identities having their own metrics, the rest are counted as \"_other\". 0 means no limit.")
 
 	iamOptions.port = cmdServer.Flag.Int("iam.port", 8111, "iam server http listen port")
 
@@ -171,6 +201,17 @@ func init() {
 	webdavOptions.filerRootPath = cmdServer.Flag.String("webdav.filer.path", "/", "use this remote path from filer server")
 
 	mqBrokerOptions.port = cmdServer.Flag.Int("mq.broker.port", 17777, "message queue broker gRPC listen port")
+	mqBrokerOptions.portHttp = cmdServer.Flag.Int("mq.broker.port.http", 0, "message queue broker http listen port for the dashboard UI, the metrics, and the /healthz and /readyz probes, 0 to disable")
+	mqBrokerOptions.clientPublishMessagesPerSecond = cmdServer.Flag.Int64("mq.broker.quota.client.messagesPerSecond", 0, "limit published messages per second for each client, 0 means unlimited")
+	mqBrokerOptions.clientPublishBytesPerSecond = cmdServer.Flag.Int64("mq.broker.quota.client.bytesPerSecond", 0, "limit published bytes per second for each client, 0 means unlimited")
+	mqBrokerOptions.topicPublishMessagesPerSecond = cmdServer.Flag.Int64("mq.broker.quota.topic.messagesPerSecond", 0, "limit published messages per second for each topic, 0 means unlimited")
+	mqBrokerOptions.topicPublishBytesPerSecond = cmdServer.Flag.Int64("mq.broker.quota.topic.bytesPerSecond", 0, "limit published bytes per second for each topic, 0 means unlimited")
+	mqBrokerOptions.dedupWindow = cmdServer.Flag.Duration("mq.broker.dedup.window", 5*time.Minute, "drop the messages with the idempotency keys published within this window to the same partition, 0 to disable")
+	mqBrokerOptions.dedupMaxKeys = cmdServer.Flag.Int("mq.broker.dedup.maxKeys", 100000, "max idempotency keys remembered for each partition")
+	mqBrokerOptions.logReadPrefetch = cmdServer.Flag.Int("mq.broker.logRead.prefetch", 4, "log file and parquet file chunks fetched ahead from the volume servers when the subscribers read the persisted messages, 0 to read one by one")
+	mqBrokerOptions.maxMessageSizeKB = cmdServer.Flag.Int("mq.broker.maxMessageSizeKB", 4*1024, "reject the larger published messages, unless the topic sets its own limit, 0 means unlimited")
+	mqBrokerOptions.traceEndpoint = cmdServer.Flag.String("mq.broker.trace.endpoint", "", "export OpenTelemetry traces with OTLP over HTTP, e.g., http://localhost:4318 of Jaeger or Tempo")
+	mqBrokerOptions.traceSampleRatio = cmdServer.Flag.Float64("mq.broker.trace.sampleRatio", 0.01, "sample ratio of the traces started on the broker")
 
 }
 
@@ -198,7 +239,7 @@ func runServer(cmd *Command, args []string) bool {
 		*isStartingFiler = true
 	}
 
-	if *isStartingMasterServer {
+	if *isStartingMasterServer && !discovery.IsRegistry(*masterOptions.peers) {
 		_, peerList := checkPeers(*serverIp, *masterOptions.port, *masterOptions.portGrpc, *masterOptions.peers)
 		peers := strings.Join(pb.ToAddressStrings(peerList), ",")
 		masterOptions.peers = &peers
@@ -216,6 +257,7 @@ func runServer(cmd *Command, args []string) bool {
 	masterOptions.ip = serverIp
 	masterOptions.ipBind = serverBindIp
 	filerOptions.masters = pb.ServerAddresses(*masterOptions.peers).ToServiceDiscovery()
+	filerOptions.mastersString = masterOptions.peers
 	filerOptions.ip = serverIp
 	filerOptions.bindIp = serverBindIp
 	s3Options.bindIp = serverBindIp
@@ -223,12 +265,12 @@ func runServer(cmd *Command, args []string) bool {
 	iamOptions.masters = masterOptions.peers
 	serverOptions.v.ip = serverIp
 	serverOptions.v.bindIp = serverBindIp
-	serverOptions.v.masters = pb.ServerAddresses(*masterOptions.peers).ToAddresses()
+	serverOptions.v.mastersString = masterOptions.peers
 	serverOptions.v.idleConnectionTimeout = serverTimeout
 	serverOptions.v.dataCenter = serverDataCenter
 	serverOptions.v.rack = serverRack
 	mqBrokerOptions.ip = serverIp
-	mqBrokerOptions.masters = filerOptions.masters.GetInstancesAsMap()
+	mqBrokerOptions.mastersString = masterOptions.peers
 	mqBrokerOptions.filerGroup = filerOptions.filerGroup
 
 	// serverOptions.v.pulseSeconds = pulseSeconds
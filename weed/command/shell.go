@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"os"
 
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/shell"
@@ -62,6 +63,9 @@ func runShell(command *Command, args []string) bool {
 	fmt.Printf("master: %s filer: %s\n", *shellOptions.Masters, shellOptions.FilerAddress)
 
 	shell.RunShell(shellOptions)
+	if exitCode := shell.ExitCode(); exitCode != 0 {
+		os.Exit(exitCode)
+	}
 
 	return true
 
@@ -0,0 +1,313 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/shell"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+var (
+	sim SimOptions
+)
+
+type SimOptions struct {
+	topology          *string
+	volumeSizeLimit   *uint64
+	volumes           *int
+	replication       *string
+	collection        *string
+	diskType          *string
+	dataCenter        *string
+	rack              *string
+	ecVolumes         *int
+	ecReplication     *string
+	showVolumes       *bool
+	showEcBalancePlan *bool
+}
+
+func init() {
+	cmdSim.Run = runSim // break init cycle
+	sim.topology = cmdSim.Flag.String("topology", "", "the topology layout json file")
+	sim.volumeSizeLimit = cmdSim.Flag.Uint64("volumeSizeLimitMB", 30*1000, "the volume size limit of the master")
+	sim.volumes = cmdSim.Flag.Int("volumes", 100, "the number of volumes to grow")
+	sim.replication = cmdSim.Flag.String("replication", "000", "the replication of the grown volumes")
+	sim.collection = cmdSim.Flag.String("collection", "", "the collection of the grown volumes")
+	sim.diskType = cmdSim.Flag.String("disk", "", "the disk type of the grown volumes, [hdd|ssd|<tag>]")
+	sim.dataCenter = cmdSim.Flag.String("dataCenter", "", "grow the volumes in this data center")
+	sim.rack = cmdSim.Flag.String("rack", "", "grow the volumes in this rack")
+	sim.ecVolumes = cmdSim.Flag.Int("ec.volumes", 0, "the number of volumes to erasure code after growing, placed on the hdd disks")
+	sim.ecReplication = cmdSim.Flag.String("ec.replication", "", "the replica placement limiting the ec shards per rack and server, as ec.balance -shardReplicaPlacement")
+	sim.showVolumes = cmdSim.Flag.Bool("showVolumes", false, "list the placement of each volume")
+	sim.showEcBalancePlan = cmdSim.Flag.Bool("showEcBalancePlan", false, "print the ec shard moves planned by ec.balance")
+}
+
+var cmdSim = &Command{
+	UsageLine: "sim -topology=layout.json -volumes=100 -replication=010",
+	Short:     "simulate the volume placement on a topology",
+	Long: `Simulate how the master places the volumes on a topology, and how ec.balance spreads the ec shards,
+	without any master or volume server, to check the placement before applying it to production.
+
+	The topology layout is a json file of the servers by the data center and the rack:
+
+	{
+	  "dc1": {
+	    "rack1": {
+	      "server1":   {"limit": 100, "used": 20},
+	      "rack1-hdd": {"limit": 100, "count": 8},
+	      "rack1-ssd": {"limit": 20, "disk": "ssd", "count": 2}
+	    }
+	  }
+	}
+
+	where "limit" is the max volume count, "used" is the count of the volume slots already taken, and "count" makes
+	that many servers named as "<name>-1", "<name>-2", and so on. The server names should be unique.
+
+	The volumes are grown one by one with the replication, and then the -ec.volumes are erasure coded,
+	with all the shards of a volume on one server as ec.encode leaves them, and spread as ec.balance does.
+	As in the cluster, ec.balance tells the racks apart by their names, even in different data centers.
+
+	The report lists the volumes, the ec shards and the free volume slots of each data center, rack and server,
+	and what happens if it fails: how many volumes lose all replicas, or too many ec shards to be recovered,
+	and how many are degraded, i.e., lose some of them.
+
+		weed sim -topology=layout.json -volumes=300 -replication=010
+		weed sim -topology=layout.json -volumes=0 -ec.volumes=50 -ec.replication=020
+
+  `,
+}
+
+func runSim(cmd *Command, args []string) bool {
+
+	if *sim.topology == "" {
+		return false
+	}
+	layout, err := os.ReadFile(*sim.topology)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read topology layout: %v\n", err)
+		return true
+	}
+	topo, err := topology.NewSimulatedTopology(layout, *sim.volumeSizeLimit*1024*1024)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return true
+	}
+
+	rp, err := super_block.NewReplicaPlacementFromString(*sim.replication)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replication %s: %v\n", *sim.replication, err)
+		return true
+	}
+	var ecRp *super_block.ReplicaPlacement
+	if *sim.ecReplication != "" {
+		if ecRp, err = super_block.NewReplicaPlacementFromString(*sim.ecReplication); err != nil {
+			fmt.Fprintf(os.Stderr, "ec.replication %s: %v\n", *sim.ecReplication, err)
+			return true
+		}
+	}
+
+	volumes, growErr := topology.NewDefaultVolumeGrowth().SimulateGrowth(topo, &topology.VolumeGrowOption{
+		Collection:       *sim.collection,
+		ReplicaPlacement: rp,
+		Ttl:              needle.EMPTY_TTL,
+		DiskType:         types.ToDiskType(*sim.diskType),
+		DataCenter:       *sim.dataCenter,
+		Rack:             *sim.rack,
+	}, 1, *sim.volumes)
+	fmt.Printf("grew %d of %d volumes with replication %s\n", len(volumes), *sim.volumes, rp)
+	if growErr != nil {
+		fmt.Printf("  %v\n", growErr)
+	}
+
+	report := newSimReport(topo.ToTopologyInfo())
+	for _, v := range volumes {
+		var servers []string
+		for _, server := range v.Servers {
+			servers = append(servers, string(server.Id()))
+		}
+		report.addVolume(v.Id, servers)
+	}
+
+	if *sim.ecVolumes > 0 {
+		ecPlacement, ecErr := simulateEcPlacement(report.topoInfo, needle.VolumeId(len(volumes)+1), ecRp)
+		if ecErr != nil {
+			fmt.Printf("erasure coded 0 of %d volumes\n  %v\n", *sim.ecVolumes, ecErr)
+		} else {
+			fmt.Printf("erasure coded %d volumes\n", len(ecPlacement))
+		}
+		for vid, shards := range ecPlacement {
+			report.addEcVolume(vid, shards)
+		}
+	}
+
+	fmt.Println()
+	report.print(os.Stdout)
+	return true
+}
+
+// simulateEcPlacement hides the moves ec.balance prints, unless asked
+func simulateEcPlacement(topoInfo *master_pb.TopologyInfo, vid needle.VolumeId, rp *super_block.ReplicaPlacement) (map[needle.VolumeId]map[string]erasure_coding.ShardBits, error) {
+	if !*sim.showEcBalancePlan {
+		if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			stdout := os.Stdout
+			os.Stdout = devNull
+			defer func() {
+				os.Stdout = stdout
+				devNull.Close()
+			}()
+		}
+	}
+	return shell.SimulateEcPlacement(topoInfo, *sim.collection, vid, *sim.ecVolumes, rp)
+}
+
+// simDomain is a data center, a rack or a server, which may fail as a whole
+type simDomain struct {
+	name       string
+	servers    map[string]bool
+	volumes    int
+	ecShards   int
+	free       int64
+	lost       int
+	degraded   int
+	ecLost     int
+	ecDegraded int
+}
+
+type simReport struct {
+	topoInfo *master_pb.TopologyInfo
+	domains  []*simDomain
+	// the domains of each server
+	serverDomains map[string][]*simDomain
+	placements    []string
+}
+
+func newSimReport(topoInfo *master_pb.TopologyInfo) *simReport {
+	r := &simReport{
+		topoInfo:      topoInfo,
+		serverDomains: make(map[string][]*simDomain),
+	}
+	for _, dc := range topoInfo.DataCenterInfos {
+		dcDomain := r.addDomain(dc.Id)
+		for _, rack := range dc.RackInfos {
+			rackDomain := r.addDomain(dc.Id + "/" + rack.Id)
+			for _, dn := range rack.DataNodeInfos {
+				dnDomain := r.addDomain(dc.Id + "/" + rack.Id + "/" + dn.Id)
+				for _, domain := range []*simDomain{dcDomain, rackDomain, dnDomain} {
+					domain.servers[dn.Id] = true
+					r.serverDomains[dn.Id] = append(r.serverDomains[dn.Id], domain)
+				}
+			}
+		}
+	}
+	return r
+}
+
+func (r *simReport) addDomain(name string) *simDomain {
+	domain := &simDomain{name: name, servers: make(map[string]bool)}
+	r.domains = append(r.domains, domain)
+	return domain
+}
+
+func (r *simReport) addVolume(vid needle.VolumeId, servers []string) {
+	if *sim.showVolumes {
+		r.placements = append(r.placements, fmt.Sprintf("volume %d: %s", vid, strings.Join(servers, " ")))
+	}
+	for _, server := range servers {
+		for _, domain := range r.serverDomains[server] {
+			domain.volumes++
+		}
+	}
+	for _, domain := range r.domains {
+		inside := 0
+		for _, server := range servers {
+			if domain.servers[server] {
+				inside++
+			}
+		}
+		if inside == len(servers) {
+			domain.lost++
+		} else if inside > 0 {
+			domain.degraded++
+		}
+	}
+}
+
+func (r *simReport) addEcVolume(vid needle.VolumeId, shards map[string]erasure_coding.ShardBits) {
+	if *sim.showVolumes {
+		var placements []string
+		for server, shardBits := range shards {
+			placements = append(placements, fmt.Sprintf("%s%v", server, shardBits.ShardIds()))
+		}
+		sort.Strings(placements)
+		r.placements = append(r.placements, fmt.Sprintf("ec volume %d: %s", vid, strings.Join(placements, " ")))
+	}
+	for server, shardBits := range shards {
+		for _, domain := range r.serverDomains[server] {
+			domain.ecShards += shardBits.ShardIdCount()
+		}
+	}
+	for _, domain := range r.domains {
+		inside := 0
+		for server, shardBits := range shards {
+			if domain.servers[server] {
+				inside += shardBits.ShardIdCount()
+			}
+		}
+		if erasure_coding.TotalShardsCount-inside < erasure_coding.DataShardsCount {
+			domain.ecLost++
+		} else if inside > 0 {
+			domain.ecDegraded++
+		}
+	}
+}
+
+func (r *simReport) print(out *os.File) {
+	sort.Strings(r.placements)
+	for _, placement := range r.placements {
+		fmt.Fprintln(out, placement)
+	}
+	if len(r.placements) > 0 {
+		fmt.Fprintln(out)
+	}
+
+	// the free slots are counted after the ec shards are placed
+	free := make(map[string]int64)
+	for _, dc := range r.topoInfo.DataCenterInfos {
+		for _, rack := range dc.RackInfos {
+			for _, dn := range rack.DataNodeInfos {
+				for _, disk := range dn.DiskInfos {
+					shards := 0
+					for _, ecShardInfo := range disk.EcShardInfos {
+						shards += erasure_coding.ShardBits(ecShardInfo.EcIndexBits).ShardIdCount()
+					}
+					free[dn.Id] += disk.MaxVolumeCount - disk.VolumeCount - int64((shards+erasure_coding.DataShardsCount-1)/erasure_coding.DataShardsCount)
+				}
+			}
+		}
+	}
+
+	sort.Slice(r.domains, func(i, j int) bool {
+		return slices.Compare(strings.Split(r.domains[i].name, "/"), strings.Split(r.domains[j].name, "/")) < 0
+	})
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\t\t\t\tif down:\t\t\t")
+	fmt.Fprintln(w, "DOMAIN\tVOLUMES\tEC SHARDS\tFREE\tLOST\tDEGRADED\tEC LOST\tEC DEGRADED")
+	for _, domain := range r.domains {
+		for server := range domain.servers {
+			domain.free += free[server]
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n", domain.name, domain.volumes, domain.ecShards, domain.free,
+			domain.lost, domain.degraded, domain.ecLost, domain.ecDegraded)
+	}
+	w.Flush()
+}
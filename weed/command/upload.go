@@ -3,12 +3,19 @@ package command
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 	"google.golang.org/grpc"
 
 	"github.com/seaweedfs/seaweedfs/weed/operation"
@@ -31,6 +38,10 @@ type UploadOptions struct {
 	diskType     *string
 	maxMB        *int
 	usePublicUrl *bool
+	concurrency  *int
+	retries      *int
+	preserveMode *bool
+	manifest     *string
 }
 
 func init() {
@@ -46,10 +57,14 @@ func init() {
 	upload.ttl = cmdUpload.Flag.String("ttl", "", "time to live, e.g.: 1m, 1h, 1d, 1M, 1y")
 	upload.maxMB = cmdUpload.Flag.Int("maxMB", 4, "split files larger than the limit")
 	upload.usePublicUrl = cmdUpload.Flag.Bool("usePublicUrl", false, "upload to public url from volume server")
+	upload.concurrency = cmdUpload.Flag.Int("c", 1, "concurrent file uploads, works together with -dir")
+	upload.retries = cmdUpload.Flag.Int("retry", 2, "retry the failed uploads this many times")
+	upload.preserveMode = cmdUpload.Flag.Bool("preserveMode", false, "save the file permissions, e.g., 0644, as the \"Mode\" attribute")
+	upload.manifest = cmdUpload.Flag.String("manifest", "", "write the paths and file ids of the uploaded files as json to this file")
 }
 
 var cmdUpload = &Command{
-	UsageLine: "upload -master=localhost:9333 file1 [file2 file3]\n         weed upload -master=localhost:9333 -dir=one_directory -include=*.pdf",
+	UsageLine: "upload -master=localhost:9333 file1 [file2 file3]\n         weed upload -master=localhost:9333 -dir=one_directory -include=*.pdf -c=8 -manifest=uploaded.json",
 	Short:     "upload one or a list of files",
 	Long: `upload one or a list of files, or batch upload one whole folder recursively.
 
@@ -64,6 +79,14 @@ var cmdUpload = &Command{
   If "maxMB" is set to a positive number, files larger than it would be split into chunks and uploaded separately.
   The list of file ids of those chunks would be stored in an additional chunk, and this additional chunk's file id would be returned.
 
+  The files are uploaded with their modification times, and with "-preserveMode" also their permissions as the
+  "Mode" attribute, returned as the "Mode" header when read. A failed upload is retried "-retry" times.
+  The files of one folder can be uploaded concurrently with "-c". The files failing all the retries are reported,
+  and the other files are still uploaded.
+
+  With "-manifest", the uploaded files are written to the file as a json array, one object per file with the
+  local path, the file id, the url, the size, the modification time, the permissions, the attempts, and the error if failed.
+
   `,
 }
 
@@ -81,67 +104,208 @@ func runUpload(cmd *Command, args []string) bool {
 		*upload.replication = defaultReplication
 	}
 
+	uploader := &fileUploader{
+		grpcDialOption: grpcDialOption,
+		pref: operation.StoragePreference{
+			Replication: *upload.replication,
+			Collection:  *upload.collection,
+			DataCenter:  *upload.dataCenter,
+			Ttl:         *upload.ttl,
+			DiskType:    *upload.diskType,
+			MaxMB:       *upload.maxMB,
+		},
+	}
+
 	if len(args) == 0 {
 		if *upload.dir == "" {
 			return false
 		}
-		err = filepath.Walk(util.ResolvePath(*upload.dir), func(path string, info os.FileInfo, err error) error {
-			if err == nil {
-				if !info.IsDir() {
-					if *upload.include != "" {
-						if ok, _ := filepath.Match(*upload.include, filepath.Base(path)); !ok {
-							return nil
-						}
-					}
-					parts, e := operation.NewFileParts([]string{path})
-					if e != nil {
-						return e
-					}
-					results, e := operation.SubmitFiles(func(_ context.Context) pb.ServerAddress { return pb.ServerAddress(*upload.master) }, grpcDialOption, parts, operation.StoragePreference{
-						Replication: *upload.replication,
-						Collection:  *upload.collection,
-						DataCenter:  *upload.dataCenter,
-						Ttl:         *upload.ttl,
-						DiskType:    *upload.diskType,
-						MaxMB:       *upload.maxMB,
-					}, *upload.usePublicUrl)
-					bytes, _ := json.Marshal(results)
-					fmt.Println(string(bytes))
-					if e != nil {
-						return e
-					}
-				}
-			} else {
-				fmt.Println(err)
-			}
-			return err
-		})
-		if err != nil {
-			fmt.Println(err.Error())
-			return false
-		}
+		err = uploader.uploadDirectory(util.ResolvePath(*upload.dir), max(*upload.concurrency, 1))
 	} else {
-		parts, e := operation.NewFileParts(args)
-		if e != nil {
-			fmt.Println(e.Error())
+		uploader.uploadFiles(args)
+	}
+
+	// the manifest has the files uploaded so far, even if the walk failed
+	if *upload.manifest != "" {
+		if writeErr := uploader.writeManifest(*upload.manifest); writeErr != nil {
+			fmt.Printf("write manifest %s: %v\n", *upload.manifest, writeErr)
 			return false
 		}
-		results, err := operation.SubmitFiles(func(_ context.Context) pb.ServerAddress { return pb.ServerAddress(*upload.master) }, grpcDialOption, parts, operation.StoragePreference{
-			Replication: *upload.replication,
-			Collection:  *upload.collection,
-			DataCenter:  *upload.dataCenter,
-			Ttl:         *upload.ttl,
-			DiskType:    *upload.diskType,
-			MaxMB:       *upload.maxMB,
-		}, *upload.usePublicUrl)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		return false
+	}
+	if uploader.failed > 0 {
+		fmt.Printf("upload: %d of %d files failed\n", uploader.failed, len(uploader.manifest))
+		return false
+	}
+	return true
+}
+
+// uploadManifestEntry is one uploaded file in the manifest
+type uploadManifestEntry struct {
+	Path     string `json:"path"`
+	Fid      string `json:"fid,omitempty"`
+	Url      string `json:"url,omitempty"`
+	Size     uint32 `json:"size"`
+	Mtime    int64  `json:"mtime"`
+	Mode     string `json:"mode"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+type fileUploader struct {
+	grpcDialOption grpc.DialOption
+	pref           operation.StoragePreference
+
+	sync.Mutex
+	manifest []*uploadManifestEntry
+	failed   int
+}
+
+// uploadDirectory walks the directory, and uploads the files by the concurrent workers, each file with its own file key
+func (u *fileUploader) uploadDirectory(dir string, concurrency int) error {
+	paths := make(chan string, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				u.uploadFiles([]string{path})
+			}
+		}()
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			fmt.Println(err.Error())
-			return false
+			fmt.Println(err)
+			return err
 		}
+		if info.IsDir() {
+			return nil
+		}
+		if *upload.include != "" {
+			if ok, _ := filepath.Match(*upload.include, filepath.Base(path)); !ok {
+				return nil
+			}
+		}
+		paths <- path
+		return nil
+	})
+	close(paths)
+	wg.Wait()
+	return err
+}
+
+// uploadFiles uploads the files with consecutive file keys, and retries all of them if any fails,
+// since the files share the assigned file key
+func (u *fileUploader) uploadFiles(paths []string) {
+	var results []operation.SubmitResult
+	var parts []*operation.FilePart
+	var err error
+	attempts := 0
+	for attempts <= max(*upload.retries, 0) {
+		if attempts > 0 {
+			time.Sleep(time.Duration(attempts) * time.Second)
+		}
+		attempts++
+		results = nil
+		if parts, err = u.newFileParts(paths); err == nil {
+			results, err = operation.SubmitFiles(func(_ context.Context) pb.ServerAddress { return pb.ServerAddress(*upload.master) }, u.grpcDialOption, parts, u.pref, *upload.usePublicUrl)
+			closeFileParts(parts)
+		}
+		if err == nil {
+			for _, result := range results {
+				if result.Error != "" {
+					err = errors.New(result.Error)
+				}
+			}
+		}
+		if err == nil {
+			break
+		}
+		glog.V(0).Infof("upload %v, attempt %d: %v", paths, attempts, err)
+	}
+
+	u.Lock()
+	defer u.Unlock()
+	if results != nil {
 		bytes, _ := json.Marshal(results)
 		fmt.Println(string(bytes))
+	} else {
+		fmt.Println(err.Error())
 	}
-	return true
+	for i, path := range paths {
+		entry := &uploadManifestEntry{
+			Path:     path,
+			Attempts: attempts,
+		}
+		if i < len(parts) && parts[i] != nil {
+			entry.Mtime = parts[i].ModTime
+		}
+		if info, statErr := os.Stat(path); statErr == nil {
+			entry.Mode = fmt.Sprintf("%04o", info.Mode().Perm())
+		}
+		if i < len(results) {
+			entry.Fid, entry.Url, entry.Size, entry.Error = results[i].Fid, results[i].FileUrl, results[i].Size, results[i].Error
+		}
+		if err != nil {
+			entry.Fid, entry.Url, entry.Size = "", "", 0
+			if entry.Error == "" {
+				entry.Error = err.Error()
+			}
+			u.failed++
+		}
+		u.manifest = append(u.manifest, entry)
+	}
+}
+
+// newFileParts opens the files, with the permissions saved as an attribute if "-preserveMode"
+func (u *fileUploader) newFileParts(paths []string) ([]*operation.FilePart, error) {
+	parts, err := operation.NewFileParts(paths)
+	if err != nil {
+		closeFileParts(parts)
+		return nil, err
+	}
+	if *upload.preserveMode {
+		for i, part := range parts {
+			info, statErr := os.Stat(paths[i])
+			if statErr != nil {
+				closeFileParts(parts)
+				return nil, statErr
+			}
+			part.Pairs = map[string]string{
+				needle.PairNamePrefix + "Mode": fmt.Sprintf("%04o", info.Mode().Perm()),
+			}
+		}
+	}
+	return parts, nil
+}
+
+// closeFileParts closes the files not closed by the upload, e.g., after failing to assign the file keys
+func closeFileParts(parts []*operation.FilePart) {
+	for _, part := range parts {
+		if part == nil {
+			continue
+		}
+		if closer, ok := part.Reader.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// writeManifest writes the uploaded files as a json array, sorted by the paths
+func (u *fileUploader) writeManifest(manifestFile string) error {
+	sort.Slice(u.manifest, func(i, j int) bool {
+		return u.manifest[i].Path < u.manifest[j].Path
+	})
+	data, err := json.MarshalIndent(u.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestFile, data, 0644)
 }
 
 func readMasterConfiguration(grpcDialOption grpc.DialOption, masterAddress pb.ServerAddress) (replication string, err error) {
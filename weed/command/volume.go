@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 
 	"github.com/spf13/viper"
@@ -59,6 +60,10 @@ type VolumeServerOptions struct {
 	cpuProfile                *string
 	memProfile                *string
 	compactionMBPerSecond     *int
+	compactInPlace            *bool
+	copyCompression           *string
+	scrubMBPerSecond          *int
+	scrubInterval             *time.Duration
 	fileSizeLimitMB           *int
 	concurrentUploadLimitMB   *int
 	concurrentDownloadLimitMB *int
@@ -69,7 +74,11 @@ type VolumeServerOptions struct {
 	// pulseSeconds          *int
 	inflightUploadDataTimeout *time.Duration
 	hasSlowRead               *bool
+	zeroCopyRead              *bool
 	readBufferSizeMB          *int
+	readCacheDir              *string
+	readCacheSizeMB           *int
+	readCacheEviction         *string
 	ldbTimeout                *int64
 }
 
@@ -81,7 +90,7 @@ func init() {
 	v.ip = cmdVolume.Flag.String("ip", util.DetectedHostAddress(), "ip or server name, also used as identifier")
 	v.publicUrl = cmdVolume.Flag.String("publicUrl", "", "Publicly accessible address")
 	v.bindIp = cmdVolume.Flag.String("ip.bind", "", "ip address to bind to. If empty, default to same as -ip option.")
-	v.mastersString = cmdVolume.Flag.String("mserver", "localhost:9333", "comma-separated master servers")
+	v.mastersString = cmdVolume.Flag.String("mserver", "localhost:9333", "comma-separated master servers, or a service registry to find the masters from and register in, e.g., consul+localhost:8500")
 	v.preStopSeconds = cmdVolume.Flag.Int("preStopSeconds", 10, "number of seconds between stop send heartbeats and stop volume server")
 	// v.pulseSeconds = cmdVolume.Flag.Int("pulseSeconds", 5, "number of seconds between heartbeats, must be smaller than or equal to the master's setting")
 	v.idleConnectionTimeout = cmdVolume.Flag.Int("idleTimeout", 30, "connection idle seconds")
@@ -94,6 +103,10 @@ func init() {
 	v.cpuProfile = cmdVolume.Flag.String("cpuprofile", "", "cpu profile output file")
 	v.memProfile = cmdVolume.Flag.String("memprofile", "", "memory profile output file")
 	v.compactionMBPerSecond = cmdVolume.Flag.Int("compactionMBps", 0, "limit background compaction or copying speed in mega bytes per second")
+	v.compactInPlace = cmdVolume.Flag.Bool("compactInPlace", false, "vacuum by relocating the live needles inside the .dat file instead of writing a compacted copy, for disks without room for the copy")
+	v.copyCompression = cmdVolume.Flag.String("copyCompression", "zstd", "[zstd|none] compression accepted when copying or tailing volumes from other volume servers")
+	v.scrubMBPerSecond = cmdVolume.Flag.Int("scrubMBps", 0, "if positive, continuously verify the needle checksums and repair the corrupted ones, limited to the mega bytes per second")
+	v.scrubInterval = cmdVolume.Flag.Duration("scrubInterval", 24*time.Hour, "interval between the passes of scrubbing all needles")
 	v.fileSizeLimitMB = cmdVolume.Flag.Int("fileSizeLimitMB", 256, "limit file size to avoid out of memory")
 	v.ldbTimeout = cmdVolume.Flag.Int64("index.leveldbTimeout", 0, "alive time for leveldb (default to 0). If leveldb of volume is not accessed in ldbTimeout hours, it will be off loaded to reduce opened files and memory consumption.")
 	v.concurrentUploadLimitMB = cmdVolume.Flag.Int("concurrentUploadLimitMB", 256, "limit total concurrent upload size")
@@ -104,7 +117,11 @@ func init() {
 	v.idxFolder = cmdVolume.Flag.String("dir.idx", "", "directory to store .idx files")
 	v.inflightUploadDataTimeout = cmdVolume.Flag.Duration("inflightUploadDataTimeout", 60*time.Second, "inflight upload data wait timeout of volume servers")
 	v.hasSlowRead = cmdVolume.Flag.Bool("hasSlowRead", true, "<experimental> if true, this prevents slow reads from blocking other requests, but large file read P99 latency will increase.")
+	v.zeroCopyRead = cmdVolume.Flag.Bool("zeroCopyRead", false, "<experimental> send the needles already verified by a previous read with sendfile, skipping the checksum recompute")
 	v.readBufferSizeMB = cmdVolume.Flag.Int("readBufferSizeMB", 4, "<experimental> larger values can optimize query performance but will increase some memory usage,Use with hasSlowRead normally.")
+	v.readCacheDir = cmdVolume.Flag.String("readCache.dir", "", "directory on a fast device, e.g., NVMe or SSD, to cache the needles read from the hdd or remote tier volumes. Empty to disable.")
+	v.readCacheSizeMB = cmdVolume.Flag.Int("readCache.sizeMB", 1024, "needle read cache size limit in MB")
+	v.readCacheEviction = cmdVolume.Flag.String("readCache.eviction", "lru", "needle read cache eviction, lru or lfu")
 }
 
 var cmdVolume = &Command{
@@ -144,7 +161,6 @@ func runVolume(cmd *Command, args []string) bool {
 	go stats_collect.StartMetricsServer(*v.metricsHttpIp, *v.metricsHttpPort)
 
 	minFreeSpaces := util.MustParseMinFreeSpace(*minFreeSpace, *minFreeSpacePercent)
-	v.masters = pb.ServerAddresses(*v.mastersString).ToAddresses()
 	v.startVolumeServer(*volumeFolders, *maxVolumeCounts, *volumeWhiteListOption, minFreeSpaces)
 
 	return true
@@ -223,6 +239,9 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 		*v.publicUrl = util.JoinHostPort(*v.ip, *v.publicPort)
 	}
 
+	v.masters = resolveMasters(*v.mastersString)
+	registerServer(*v.mastersString, cluster.VolumeServerType, pb.NewServerAddress(*v.ip, *v.port, *v.portGrpc))
+
 	volumeMux := http.NewServeMux()
 	publicVolumeMux := volumeMux
 	if v.isSeparatedPublicPort() {
@@ -247,6 +266,14 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 		volumeNeedleMapKind = storage.NeedleMapLevelDbLarge
 	}
 
+	var readCache *storage.NeedleReadCache
+	if *v.readCacheDir != "" {
+		var err error
+		if readCache, err = storage.NewNeedleReadCache(util.ResolvePath(*v.readCacheDir), int64(*v.readCacheSizeMB)*1024*1024, *v.readCacheEviction); err != nil {
+			glog.Fatalf("needle read cache: %v", err)
+		}
+	}
+
 	volumeServer := weed_server.NewVolumeServer(volumeMux, publicVolumeMux,
 		*v.ip, *v.port, *v.portGrpc, *v.publicUrl,
 		v.folders, v.folderMaxLimits, minFreeSpaces, diskTypes,
@@ -256,12 +283,18 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 		v.whiteList,
 		*v.fixJpgOrientation, *v.readMode,
 		*v.compactionMBPerSecond,
+		*v.scrubMBPerSecond,
+		*v.scrubInterval,
 		*v.fileSizeLimitMB,
 		int64(*v.concurrentUploadLimitMB)*1024*1024,
 		int64(*v.concurrentDownloadLimitMB)*1024*1024,
 		*v.inflightUploadDataTimeout,
 		*v.hasSlowRead,
 		*v.readBufferSizeMB,
+		*v.zeroCopyRead,
+		*v.compactInPlace,
+		readCache,
+		*v.copyCompression,
 		*v.ldbTimeout,
 	)
 	// starting grpc server
@@ -332,7 +365,7 @@ func (v VolumeServerOptions) isSeparatedPublicPort() bool {
 	return *v.publicPort != *v.port
 }
 
-func (v VolumeServerOptions) startGrpcService(vs volume_server_pb.VolumeServerServer) *grpc.Server {
+func (v VolumeServerOptions) startGrpcService(vs *weed_server.VolumeServer) *grpc.Server {
 	grpcPort := *v.portGrpc
 	grpcL, err := util.NewListener(util.JoinHostPort(*v.bindIp, grpcPort), 0)
 	if err != nil {
@@ -341,6 +374,7 @@ func (v VolumeServerOptions) startGrpcService(vs volume_server_pb.VolumeServerSe
 	grpcS := pb.NewGrpcServer(security.LoadServerTLS(util.GetViper(), "grpc.volume"))
 	volume_server_pb.RegisterVolumeServerServer(grpcS, vs)
 	reflection.Register(grpcS)
+	vs.RegisterGrpcHealth(grpcS)
 	go func() {
 		if err := grpcS.Serve(grpcL); err != nil {
 			glog.Fatalf("start gRPC service failed, %s", err)
@@ -0,0 +1,34 @@
+package derive
+
+import (
+	"context"
+	"errors"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// Handler generates one derived file from a source file, e.g., a thumbnail, a transcoded video, or a checksum
+type Handler interface {
+	Initialize(configuration util.Configuration, prefix string) error
+	// DefaultTarget is the template of the derived file path, if the rule does not set one
+	DefaultTarget() string
+	// Derive reads the source file and writes the derived file of the job. It is retried on errors, except ErrNotDerivable.
+	Derive(ctx context.Context, job *Job) error
+}
+
+// Job is one derived file to generate
+type Job struct {
+	SourcePath util.FullPath
+	TargetPath util.FullPath
+	// the local temporary files, with the same extensions as the source and the derived file
+	SourceFile string
+	TargetFile string
+}
+
+// ErrNotDerivable means the source file can not be derived, e.g., a corrupted image, so it is not retried
+var ErrNotDerivable = errors.New("not derivable")
+
+var (
+	// Handlers creates the handlers by name, one for each rule
+	Handlers = make(map[string]func() Handler)
+)
@@ -0,0 +1,62 @@
+package derive
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Handlers["checksum"] = func() Handler {
+		return &ChecksumHandler{}
+	}
+}
+
+// ChecksumHandler writes the checksum of the file in the format of "sha256sum", i.e., "<hex digest>  <file name>"
+type ChecksumHandler struct {
+	algorithm string
+	newHash   func() hash.Hash
+}
+
+func (h *ChecksumHandler) Initialize(configuration util.Configuration, prefix string) error {
+	configuration.SetDefault(prefix+"algorithm", "sha256")
+	h.algorithm = configuration.GetString(prefix + "algorithm")
+	switch h.algorithm {
+	case "md5":
+		h.newHash = md5.New
+	case "sha1":
+		h.newHash = sha1.New
+	case "sha256":
+		h.newHash = sha256.New
+	case "sha512":
+		h.newHash = sha512.New
+	default:
+		return fmt.Errorf("unknown %salgorithm %s", prefix, h.algorithm)
+	}
+	return nil
+}
+
+func (h *ChecksumHandler) DefaultTarget() string {
+	return "{dir}/{name}." + h.algorithm
+}
+
+func (h *ChecksumHandler) Derive(ctx context.Context, job *Job) error {
+	in, err := os.Open(job.SourceFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	digest := h.newHash()
+	if _, err = io.Copy(digest, in); err != nil {
+		return err
+	}
+	return os.WriteFile(job.TargetFile, []byte(fmt.Sprintf("%x  %s\n", digest.Sum(nil), job.SourcePath.Name())), 0644)
+}
@@ -0,0 +1,66 @@
+package derive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Handlers["exec"] = func() Handler {
+		return &ExecHandler{}
+	}
+}
+
+// ExecHandler runs an external command to derive the file, e.g., ffmpeg to transcode a video.
+// The "{input}" and "{output}" in the command arguments are replaced with the source and the derived file.
+type ExecHandler struct {
+	command []string
+	timeout time.Duration
+}
+
+func (h *ExecHandler) Initialize(configuration util.Configuration, prefix string) error {
+	configuration.SetDefault(prefix+"timeoutSeconds", 3600)
+	h.command = configuration.GetStringSlice(prefix + "command")
+	h.timeout = time.Duration(configuration.GetInt(prefix+"timeoutSeconds")) * time.Second
+	if len(h.command) == 0 {
+		return fmt.Errorf("%scommand is required", prefix)
+	}
+	if _, err := exec.LookPath(h.command[0]); err != nil {
+		return fmt.Errorf("%scommand: %v", prefix, err)
+	}
+	return nil
+}
+
+func (h *ExecHandler) DefaultTarget() string {
+	return "{dir}/.derived/{name}"
+}
+
+func (h *ExecHandler) Derive(ctx context.Context, job *Job) error {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	replacer := strings.NewReplacer("{input}", job.SourceFile, "{output}", job.TargetFile)
+	var args []string
+	for _, arg := range h.command[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+	cmd := exec.CommandContext(ctx, h.command[0], args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := stderr.String()
+		if len(message) > 1024 {
+			message = message[len(message)-1024:]
+		}
+		return fmt.Errorf("%s: %v: %s", h.command[0], err, strings.TrimSpace(message))
+	}
+	return nil
+}
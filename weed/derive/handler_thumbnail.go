@@ -0,0 +1,69 @@
+package derive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/images"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Handlers["thumbnail"] = func() Handler {
+		return &ThumbnailHandler{}
+	}
+}
+
+// ThumbnailHandler resizes the images, in the same format as the derived file extension
+type ThumbnailHandler struct {
+	width  int
+	height int
+	mode   string
+}
+
+func (h *ThumbnailHandler) Initialize(configuration util.Configuration, prefix string) error {
+	configuration.SetDefault(prefix+"width", 200)
+	configuration.SetDefault(prefix+"height", 200)
+	h.width = configuration.GetInt(prefix + "width")
+	h.height = configuration.GetInt(prefix + "height")
+	h.mode = configuration.GetString(prefix + "mode")
+	if h.width <= 0 && h.height <= 0 {
+		return fmt.Errorf("%swidth or %sheight is required", prefix, prefix)
+	}
+	return nil
+}
+
+func (h *ThumbnailHandler) DefaultTarget() string {
+	return "{dir}/.thumbnails/{name}"
+}
+
+func (h *ThumbnailHandler) Derive(ctx context.Context, job *Job) error {
+	data, err := os.ReadFile(job.SourceFile)
+	if err != nil {
+		return err
+	}
+	ext := strings.ToLower(filepath.Ext(job.TargetFile))
+	switch ext {
+	case ".png", ".jpg", ".jpeg", ".gif":
+	default:
+		return fmt.Errorf("%w: unsupported thumbnail format %s", ErrNotDerivable, ext)
+	}
+	resized, width, height := images.Resized(ext, bytes.NewReader(data), h.width, h.height, h.mode)
+	if width == 0 && height == 0 {
+		return fmt.Errorf("%w: not a supported image", ErrNotDerivable)
+	}
+	out, err := os.Create(job.TargetFile)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, resized); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,89 @@
+package derive
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// Rule derives one file from each source file under the path prefixes with the name matching the pattern
+type Rule struct {
+	Name             string
+	HandlerName      string
+	Handler          Handler
+	PathPrefixes     []string
+	NamePattern      *regexp.Regexp
+	Target           string // the template of the derived file path, see TargetPath
+	DeleteWithSource bool   // delete the derived file when the source file is deleted or renamed
+}
+
+// NewRule reads the rule from the configuration under the prefix, e.g., "rule.thumbnail."
+func NewRule(name string, configuration util.Configuration, prefix string) (*Rule, error) {
+	configuration.SetDefault(prefix+"deleteWithSource", true)
+	r := &Rule{
+		Name:             name,
+		HandlerName:      configuration.GetString(prefix + "handler"),
+		PathPrefixes:     configuration.GetStringSlice(prefix + "pathPrefixes"),
+		Target:           configuration.GetString(prefix + "target"),
+		DeleteWithSource: configuration.GetBool(prefix + "deleteWithSource"),
+	}
+	newHandler, found := Handlers[r.HandlerName]
+	if !found {
+		return nil, fmt.Errorf("rule %s: unknown handler %q", name, r.HandlerName)
+	}
+	r.Handler = newHandler()
+	if err := r.Handler.Initialize(configuration, prefix); err != nil {
+		return nil, fmt.Errorf("rule %s: %v", name, err)
+	}
+	if pattern := configuration.GetString(prefix + "namePattern"); pattern != "" {
+		var err error
+		if r.NamePattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("rule %s: namePattern %s: %v", name, pattern, err)
+		}
+	}
+	if r.Target == "" {
+		r.Target = r.Handler.DefaultTarget()
+	}
+	if !strings.HasPrefix(r.Target, "{dir}") && !strings.HasPrefix(r.Target, "/") {
+		return nil, fmt.Errorf("rule %s: target %s should be under {dir} or an absolute path", name, r.Target)
+	}
+	if !strings.Contains(r.Target, "{name}") && !strings.Contains(r.Target, "{base}") {
+		return nil, fmt.Errorf("rule %s: target %s should contain {name} or {base}", name, r.Target)
+	}
+	return r, nil
+}
+
+// Matches checks whether the file is a source file of the rule
+func (r *Rule) Matches(source util.FullPath) bool {
+	if len(r.PathPrefixes) > 0 {
+		found := false
+		for _, pathPrefix := range r.PathPrefixes {
+			if strings.HasPrefix(string(source), pathPrefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return r.NamePattern == nil || r.NamePattern.MatchString(source.Name())
+}
+
+// TargetPath fills the target template with the source file path, where "{dir}" is the directory,
+// "{name}" is the file name, "{base}" is the file name without the extension, and "{ext}" is the extension with the dot.
+// For example, "{dir}/.thumbnails/{base}_200{ext}" derives "/photos/.thumbnails/a_200.jpg" from "/photos/a.jpg".
+func (r *Rule) TargetPath(source util.FullPath) util.FullPath {
+	dir, name := source.DirAndName()
+	ext := path.Ext(name)
+	target := strings.NewReplacer(
+		"{dir}", strings.TrimSuffix(dir, "/"),
+		"{name}", name,
+		"{base}", strings.TrimSuffix(name, ext),
+		"{ext}", ext,
+	).Replace(r.Target)
+	return util.FullPath(path.Clean(target))
+}
@@ -0,0 +1,117 @@
+package derive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/spf13/viper"
+)
+
+func newTestRule(t *testing.T, settings map[string]interface{}) (*Rule, error) {
+	t.Helper()
+	v := viper.New()
+	for key, value := range settings {
+		v.Set("rule.test."+key, value)
+	}
+	return NewRule("test", &util.ViperProxy{Viper: v}, "rule.test.")
+}
+
+func TestRuleTargetPath(t *testing.T) {
+	rule, err := newTestRule(t, map[string]interface{}{
+		"handler":      "thumbnail",
+		"pathPrefixes": []string{"/photos/"},
+		"namePattern":  `(?i)\.(jpg|png)$`,
+		"target":       "{dir}/.thumbnails/{base}_200{ext}",
+	})
+	if err != nil {
+		t.Fatalf("new rule: %v", err)
+	}
+	if !rule.DeleteWithSource {
+		t.Errorf("derived files should be deleted with the source files by default")
+	}
+
+	tests := []struct {
+		source  util.FullPath
+		matches bool
+		target  util.FullPath
+	}{
+		{"/photos/a.jpg", true, "/photos/.thumbnails/a_200.jpg"},
+		{"/photos/x/B.PNG", true, "/photos/x/.thumbnails/B_200.PNG"},
+		{"/photos/a.txt", false, "/photos/.thumbnails/a_200.txt"},
+		{"/videos/a.jpg", false, "/videos/.thumbnails/a_200.jpg"},
+	}
+	for _, tt := range tests {
+		if matches := rule.Matches(tt.source); matches != tt.matches {
+			t.Errorf("%s matches %v, expected %v", tt.source, matches, tt.matches)
+		}
+		if target := rule.TargetPath(tt.source); target != tt.target {
+			t.Errorf("%s target %s, expected %s", tt.source, target, tt.target)
+		}
+	}
+}
+
+func TestNewRuleErrors(t *testing.T) {
+	if _, err := newTestRule(t, map[string]interface{}{"handler": "unknown"}); err == nil {
+		t.Errorf("expected an error for an unknown handler")
+	}
+	if _, err := newTestRule(t, map[string]interface{}{"handler": "checksum", "target": "{dir}/sum.txt"}); err == nil {
+		t.Errorf("expected an error for a target without the source name")
+	}
+	if _, err := newTestRule(t, map[string]interface{}{"handler": "checksum", "namePattern": "("}); err == nil {
+		t.Errorf("expected an error for an invalid name pattern")
+	}
+	rule, err := newTestRule(t, map[string]interface{}{"handler": "checksum", "algorithm": "md5"})
+	if err != nil {
+		t.Fatalf("new rule: %v", err)
+	}
+	if target := rule.TargetPath("/a/b.iso"); target != "/a/b.iso.md5" {
+		t.Errorf("default target %s", target)
+	}
+}
+
+func TestChecksumDerive(t *testing.T) {
+	rule, err := newTestRule(t, map[string]interface{}{"handler": "checksum"})
+	if err != nil {
+		t.Fatalf("new rule: %v", err)
+	}
+	dir := t.TempDir()
+	job := &Job{
+		SourcePath: "/data/hello.txt",
+		TargetPath: rule.TargetPath("/data/hello.txt"),
+		SourceFile: filepath.Join(dir, "source.txt"),
+		TargetFile: filepath.Join(dir, "target.sha256"),
+	}
+	if err = os.WriteFile(job.SourceFile, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = rule.Handler.Derive(context.Background(), job); err != nil {
+		t.Fatalf("derive: %v", err)
+	}
+	data, _ := os.ReadFile(job.TargetFile)
+	expected := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  hello.txt\n"
+	if string(data) != expected {
+		t.Errorf("checksum %q, expected %q", data, expected)
+	}
+}
+
+func TestProgressWatermark(t *testing.T) {
+	p := progress{pending: make(map[int64]int), lastTsNs: 100}
+	p.start(200)
+	p.start(300)
+	p.start(300)
+	if tsNs := p.watermark(); tsNs != 199 {
+		t.Errorf("watermark %d, expected 199", tsNs)
+	}
+	p.done(300)
+	p.done(200)
+	if tsNs := p.watermark(); tsNs != 299 {
+		t.Errorf("watermark %d, expected 299", tsNs)
+	}
+	p.done(300)
+	if tsNs := p.watermark(); tsNs != 300 {
+		t.Errorf("watermark %d, expected 300", tsNs)
+	}
+}
@@ -0,0 +1,472 @@
+package derive
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc"
+)
+
+const (
+	checkpointsDir     = filer.DirectoryEtcSeaweedFS + "/derive"
+	checkpointInterval = 3 * time.Second
+
+	// the extended attributes of the derived files, so they are not derived again,
+	// and are only regenerated when the source file is changed
+	ExtDerivedRule          = "derive.rule"
+	ExtDerivedSource        = "derive.source"
+	ExtDerivedSourceVersion = "derive.source_version"
+)
+
+type WorkerOption struct {
+	Name            string // names the checkpoint
+	FilerAddress    pb.ServerAddress
+	GrpcDialOption  grpc.DialOption
+	FilerClient     filer_pb.FilerClient
+	ClientId        int32
+	Rules           []*Rule
+	Concurrency     int
+	MaxRetries      int           // the failed files are given up after this many retries
+	RetryInterval   time.Duration // doubled after each retry
+	ChunkSizeMB     int
+	StartFromLatest bool // without the checkpoint, start from now on instead of the earliest change in the filer metadata log
+}
+
+// Worker follows the filer metadata changes, and generates the derived files of the changed source files,
+// and deletes those of the deleted or renamed source files. The derived files are written next to the source files,
+// and marked with the extended attributes, so they are not generated again for the same source file content.
+//
+// The changes are processed concurrently, but the changes of the same file are processed in order.
+// The progress is saved in the filer under /etc/seaweedfs/derive/<name>, and the changes since then are
+// processed again after a restart.
+type Worker struct {
+	option      *WorkerOption
+	queues      []chan *filer_pb.SubscribeMetadataResponse
+	progress    progress
+	clientEpoch int32
+}
+
+func NewWorker(option *WorkerOption) *Worker {
+	if option.Concurrency <= 0 {
+		option.Concurrency = 1
+	}
+	if option.ChunkSizeMB <= 0 {
+		option.ChunkSizeMB = 8
+	}
+	return &Worker{
+		option: option,
+		progress: progress{
+			pending: make(map[int64]int),
+		},
+	}
+}
+
+func (w *Worker) Run() error {
+	if w.option.Name == "" {
+		return fmt.Errorf("worker name is required")
+	}
+	if len(w.option.Rules) == 0 {
+		return fmt.Errorf("no rules enabled")
+	}
+	startTsNs, err := w.readCheckpoint()
+	if err != nil {
+		return fmt.Errorf("read checkpoint: %v", err)
+	}
+	if startTsNs == 0 && w.option.StartFromLatest {
+		startTsNs = time.Now().UnixNano()
+	}
+	w.progress.lastTsNs = startTsNs
+
+	for i := 0; i < w.option.Concurrency; i++ {
+		queue := make(chan *filer_pb.SubscribeMetadataResponse, 16)
+		w.queues = append(w.queues, queue)
+		go func() {
+			for resp := range queue {
+				w.processEvent(resp)
+				w.progress.done(resp.TsNs)
+			}
+		}()
+	}
+
+	processEventFn := pb.AddOffsetFunc(w.dispatch, checkpointInterval, func(counter int64, lastTsNs int64) error {
+		processedTsNs := w.progress.watermark()
+		glog.V(1).Infof("derive %s processed %d filer changes up to %v", w.option.Name, counter, time.Unix(0, processedTsNs))
+		return w.saveCheckpoint(processedTsNs)
+	})
+
+	pathPrefixes := w.pathPrefixes()
+	for {
+		glog.V(0).Infof("derive %s follows filer %s changes of %v since %v", w.option.Name, w.option.FilerAddress, pathPrefixes, time.Unix(0, startTsNs))
+		w.clientEpoch++
+		err = pb.FollowMetadata(w.option.FilerAddress, w.option.GrpcDialOption, &pb.MetadataFollowOption{
+			ClientName:             "derive-" + w.option.Name,
+			ClientId:               w.option.ClientId,
+			ClientEpoch:            w.clientEpoch,
+			PathPrefix:             pathPrefixes[0],
+			AdditionalPathPrefixes: pathPrefixes[1:],
+			StartTsNs:              startTsNs,
+			EventErrorType:         pb.RetryForeverOnError,
+		}, processEventFn)
+		glog.Errorf("derive %s follow filer %s: %v", w.option.Name, w.option.FilerAddress, err)
+		time.Sleep(1747 * time.Millisecond)
+		startTsNs = w.progress.watermark()
+	}
+}
+
+// pathPrefixes follows the whole filer if any rule has no path prefixes
+func (w *Worker) pathPrefixes() (pathPrefixes []string) {
+	for _, rule := range w.option.Rules {
+		if len(rule.PathPrefixes) == 0 {
+			return []string{"/"}
+		}
+		pathPrefixes = append(pathPrefixes, rule.PathPrefixes...)
+	}
+	return
+}
+
+// dispatch queues the change by the file path, so the changes of one file are processed in order
+func (w *Worker) dispatch(resp *filer_pb.SubscribeMetadataResponse) error {
+	if filer_pb.IsEmpty(resp) {
+		return nil
+	}
+	message := resp.EventNotification
+	key := resp.Directory
+	if message.NewEntry != nil {
+		key = string(util.NewFullPath(resp.Directory, message.NewEntry.Name))
+	} else if message.OldEntry != nil {
+		key = string(util.NewFullPath(resp.Directory, message.OldEntry.Name))
+	}
+	w.progress.start(resp.TsNs)
+	hash := util.HashStringToLong(key)
+	if hash < 0 {
+		hash = -hash
+	}
+	w.queues[hash%int64(len(w.queues))] <- resp
+	return nil
+}
+
+func (w *Worker) processEvent(resp *filer_pb.SubscribeMetadataResponse) {
+	message := resp.EventNotification
+	var oldPath, newPath util.FullPath
+	if message.OldEntry != nil && isSourceFile(message.OldEntry) {
+		oldPath = util.NewFullPath(resp.Directory, message.OldEntry.Name)
+	}
+	if message.NewEntry != nil && isSourceFile(message.NewEntry) {
+		dir := resp.Directory
+		if message.NewParentPath != "" {
+			dir = message.NewParentPath
+		}
+		newPath = util.NewFullPath(dir, message.NewEntry.Name)
+	}
+	if isSkipped(oldPath) {
+		oldPath = ""
+	}
+	if isSkipped(newPath) {
+		newPath = ""
+	}
+
+	for _, rule := range w.option.Rules {
+		if oldPath != "" && oldPath != newPath && rule.DeleteWithSource && rule.Matches(oldPath) {
+			w.retry(rule, oldPath, func() error {
+				return w.deleteDerived(rule, oldPath)
+			})
+		}
+		if newPath != "" && rule.Matches(newPath) && filer.FileSize(message.NewEntry) > 0 {
+			w.retry(rule, newPath, func() error {
+				return w.derive(rule, newPath, message.NewEntry)
+			})
+		}
+	}
+}
+
+// isSourceFile checks the entry is a file, and not derived by any rule
+func isSourceFile(entry *filer_pb.Entry) bool {
+	return !entry.IsDirectory && entry.Extended[ExtDerivedRule] == nil
+}
+
+// isSkipped skips the filer configurations including the checkpoints, and the topic data
+func isSkipped(p util.FullPath) bool {
+	return p != "" && (strings.HasPrefix(string(p), filer.DirectoryEtcSeaweedFS+"/") || strings.HasPrefix(string(p), filer.TopicsDir+"/"))
+}
+
+func (w *Worker) retry(rule *Rule, source util.FullPath, fn func() error) {
+	interval := w.option.RetryInterval
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil {
+			return
+		}
+		if errors.Is(err, ErrNotDerivable) || i >= w.option.MaxRetries {
+			glog.Errorf("derive %s of %s: %v", rule.Name, source, err)
+			return
+		}
+		glog.Warningf("derive %s of %s, retry in %v: %v", rule.Name, source, interval, err)
+		time.Sleep(interval)
+		interval *= 2
+	}
+}
+
+// sourceVersion changes with the file content
+func sourceVersion(entry *filer_pb.Entry) string {
+	if len(entry.Content) > 0 {
+		return fmt.Sprintf("%x", md5.Sum(entry.Content))
+	}
+	return fmt.Sprintf("%s-%d", filer.ETag(entry), filer.FileSize(entry))
+}
+
+func (w *Worker) derive(rule *Rule, source util.FullPath, entry *filer_pb.Entry) error {
+	target := rule.TargetPath(source)
+	if target == source {
+		return fmt.Errorf("%w: the derived file %s is the source file", ErrNotDerivable, target)
+	}
+	version := sourceVersion(entry)
+	existing, err := w.lookup(target)
+	if err != nil {
+		return fmt.Errorf("lookup %s: %v", target, err)
+	}
+	if existing != nil {
+		if string(existing.Extended[ExtDerivedSource]) != string(source) || string(existing.Extended[ExtDerivedRule]) != rule.Name {
+			glog.Warningf("derive %s of %s: skip the existing %s not derived from it", rule.Name, source, target)
+			return nil
+		}
+		if string(existing.Extended[ExtDerivedSourceVersion]) == version {
+			return nil
+		}
+	}
+
+	job := &Job{
+		SourcePath: source,
+		TargetPath: target,
+	}
+	if job.SourceFile, err = w.download(source, entry); err != nil {
+		return fmt.Errorf("download %s: %v", source, err)
+	}
+	defer os.Remove(job.SourceFile)
+	if job.TargetFile, err = createTempFile(target, nil); err != nil {
+		return err
+	}
+	defer os.Remove(job.TargetFile)
+
+	if err = rule.Handler.Derive(context.Background(), job); err != nil {
+		return err
+	}
+	if err = w.upload(rule, job, version); err != nil {
+		return fmt.Errorf("upload %s: %v", target, err)
+	}
+	glog.V(1).Infof("derive %s: %s => %s", rule.Name, source, target)
+	return nil
+}
+
+// deleteDerived deletes the derived file, if it is derived from the source file by the rule
+func (w *Worker) deleteDerived(rule *Rule, source util.FullPath) error {
+	target := rule.TargetPath(source)
+	existing, err := w.lookup(target)
+	if err != nil {
+		return fmt.Errorf("lookup %s: %v", target, err)
+	}
+	if existing == nil || string(existing.Extended[ExtDerivedSource]) != string(source) || string(existing.Extended[ExtDerivedRule]) != rule.Name {
+		return nil
+	}
+	dir, name := target.DirAndName()
+	if err = filer_pb.Remove(w.option.FilerClient, dir, name, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("delete %s: %v", target, err)
+	}
+	glog.V(1).Infof("derive %s: delete %s of %s", rule.Name, target, source)
+	return nil
+}
+
+func (w *Worker) lookup(p util.FullPath) (entry *filer_pb.Entry, err error) {
+	dir, name := p.DirAndName()
+	err = w.option.FilerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, lookupErr := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+			Directory: dir,
+			Name:      name,
+		})
+		if lookupErr == filer_pb.ErrNotFound {
+			return nil
+		}
+		if lookupErr != nil {
+			return lookupErr
+		}
+		entry = resp.Entry
+		return nil
+	})
+	return
+}
+
+// createTempFile creates a local temporary file with the extension of the file path
+func createTempFile(p util.FullPath, content io.Reader) (string, error) {
+	f, err := os.CreateTemp("", "derive-*"+path.Ext(p.Name()))
+	if err != nil {
+		return "", err
+	}
+	if content != nil {
+		if _, err = io.Copy(f, content); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return "", err
+		}
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (w *Worker) download(source util.FullPath, entry *filer_pb.Entry) (string, error) {
+	return createTempFile(source, filer.NewFileReader(w.option.FilerClient, entry))
+}
+
+func (w *Worker) upload(rule *Rule, job *Job, version string) error {
+	f, err := os.Open(job.TargetFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	uploader, err := operation.NewUploader()
+	if err != nil {
+		return err
+	}
+	saveFn := func(reader io.Reader, name string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+		fileId, uploadResult, uploadErr, _ := uploader.UploadWithRetry(
+			w.option.FilerClient,
+			&filer_pb.AssignVolumeRequest{
+				Count: 1,
+				Path:  string(job.TargetPath),
+			},
+			&operation.UploadOption{
+				Filename: name,
+			},
+			func(host, fileId string) string {
+				return fmt.Sprintf("http://%s/%s", host, fileId)
+			},
+			reader,
+		)
+		if uploadErr != nil {
+			return nil, uploadErr
+		}
+		return uploadResult.ToPbFileChunk(fileId, offset, tsNs), nil
+	}
+
+	var chunks []*filer_pb.FileChunk
+	chunkSize := int64(w.option.ChunkSizeMB) * 1024 * 1024
+	for offset := int64(0); offset < stat.Size(); offset += chunkSize {
+		chunk, chunkErr := saveFn(io.LimitReader(f, chunkSize), job.TargetPath.Name(), offset, time.Now().UnixNano())
+		if chunkErr != nil {
+			return chunkErr
+		}
+		chunks = append(chunks, chunk)
+	}
+	if chunks, err = filer.MaybeManifestize(saveFn, chunks); err != nil {
+		return err
+	}
+
+	dir, name := job.TargetPath.DirAndName()
+	now := time.Now()
+	return w.option.FilerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+			Directory: dir,
+			Entry: &filer_pb.Entry{
+				Name: name,
+				Attributes: &filer_pb.FuseAttributes{
+					Crtime:   now.Unix(),
+					Mtime:    now.Unix(),
+					FileMode: uint32(0644),
+					FileSize: uint64(stat.Size()),
+					Mime:     mime.TypeByExtension(path.Ext(name)),
+				},
+				Chunks: chunks,
+				Extended: map[string][]byte{
+					ExtDerivedRule:          []byte(rule.Name),
+					ExtDerivedSource:        []byte(job.SourcePath),
+					ExtDerivedSourceVersion: []byte(version),
+				},
+			},
+		})
+	})
+}
+
+func (w *Worker) readCheckpoint() (tsNs int64, err error) {
+	err = w.option.FilerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		data, readErr := filer.ReadInsideFiler(client, util.Join(checkpointsDir, w.option.Name), "checkpoint")
+		if readErr == filer_pb.ErrNotFound {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+		if len(data) != 8 {
+			return fmt.Errorf("invalid checkpoint of %d bytes", len(data))
+		}
+		tsNs = int64(util.BytesToUint64(data))
+		return nil
+	})
+	return
+}
+
+func (w *Worker) saveCheckpoint(tsNs int64) error {
+	if tsNs == 0 {
+		return nil
+	}
+	data := make([]byte, 8)
+	util.Uint64toBytes(data, uint64(tsNs))
+	return w.option.FilerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer.SaveInsideFiler(client, util.Join(checkpointsDir, w.option.Name), "checkpoint", data)
+	})
+}
+
+// progress tracks the changes being processed, so the checkpoint does not pass any unfinished change
+type progress struct {
+	sync.Mutex
+	pending  map[int64]int
+	lastTsNs int64
+}
+
+func (p *progress) start(tsNs int64) {
+	p.Lock()
+	defer p.Unlock()
+	p.pending[tsNs]++
+	p.lastTsNs = max(p.lastTsNs, tsNs)
+}
+
+func (p *progress) done(tsNs int64) {
+	p.Lock()
+	defer p.Unlock()
+	if p.pending[tsNs] <= 1 {
+		delete(p.pending, tsNs)
+	} else {
+		p.pending[tsNs]--
+	}
+}
+
+// watermark is the time up to which all changes are processed
+func (p *progress) watermark() int64 {
+	p.Lock()
+	defer p.Unlock()
+	tsNs := p.lastTsNs
+	for pendingTsNs := range p.pending {
+		tsNs = min(tsNs, pendingTsNs-1)
+	}
+	return tsNs
+}
@@ -25,12 +25,22 @@ type SqlGenerator interface {
 	GetSqlDropTable(tableName string) string
 }
 
+// SqlCursorGenerator is implemented by the databases which list large directories with server-side cursors,
+// instead of loading the whole result set at once
+type SqlCursorGenerator interface {
+	GetSqlDeclareCursor(cursorName, query string) string
+	GetSqlFetchCursor(cursorName string, count int) string
+}
+
 type AbstractSqlStore struct {
 	SqlGenerator
-	DB                 *sql.DB
-	SupportBucketTable bool
-	dbs                map[string]bool
-	dbsLock            sync.Mutex
+	DB                    *sql.DB
+	SupportBucketTable    bool
+	UsePreparedStatements bool // prepare each statement once and reuse it, instead of sending the sql text every time
+	dbs                   map[string]bool
+	dbsLock               sync.Mutex
+	stmts                 map[string]*sql.Stmt
+	stmtsLock             sync.Mutex
 }
 
 var _ filer.BucketAware = (*AbstractSqlStore)(nil)
@@ -63,6 +73,10 @@ func (store *AbstractSqlStore) OnBucketDeletion(bucket string) {
 
 const (
 	DEFAULT_TABLE = "filemeta"
+
+	listCursorName = "list_cursor"
+	// the listings larger than one batch, e.g., the pages of filer.PaginationSize entries, are fetched with a cursor
+	listCursorBatchSize = 1000
 )
 
 type TxOrDB interface {
@@ -165,12 +179,12 @@ func (store *AbstractSqlStore) InsertEntry(ctx context.Context, entry *filer.Ent
 		meta = util.MaybeGzipData(meta)
 	}
 	sqlInsert := "insert"
-	res, err := db.ExecContext(ctx, store.GetSqlInsert(bucket), util.HashStringToLong(dir), name, dir, meta)
+	res, err := store.execContext(ctx, db, store.GetSqlInsert(bucket), util.HashStringToLong(dir), name, dir, meta)
 	if err != nil && strings.Contains(strings.ToLower(err.Error()), "duplicate entry") {
 		// now the insert failed possibly due to duplication constraints
 		sqlInsert = "falls back to update"
 		glog.V(1).Infof("insert %s %s: %v", entry.FullPath, sqlInsert, err)
-		res, err = db.ExecContext(ctx, store.GetSqlUpdate(bucket), meta, util.HashStringToLong(dir), name, dir)
+		res, err = store.execContext(ctx, db, store.GetSqlUpdate(bucket), meta, util.HashStringToLong(dir), name, dir)
 	}
 	if err != nil {
 		return fmt.Errorf("%s %s: %s", sqlInsert, entry.FullPath, err)
@@ -197,7 +211,7 @@ func (store *AbstractSqlStore) UpdateEntry(ctx context.Context, entry *filer.Ent
 		return fmt.Errorf("encode %s: %s", entry.FullPath, err)
 	}
 
-	res, err := db.ExecContext(ctx, store.GetSqlUpdate(bucket), meta, util.HashStringToLong(dir), name, dir)
+	res, err := store.execContext(ctx, db, store.GetSqlUpdate(bucket), meta, util.HashStringToLong(dir), name, dir)
 	if err != nil {
 		return fmt.Errorf("update %s: %s", entry.FullPath, err)
 	}
@@ -217,7 +231,7 @@ func (store *AbstractSqlStore) FindEntry(ctx context.Context, fullpath util.Full
 	}
 
 	dir, name := shortPath.DirAndName()
-	row := db.QueryRowContext(ctx, store.GetSqlFind(bucket), util.HashStringToLong(dir), name, dir)
+	row := store.queryRowContext(ctx, db, store.GetSqlFind(bucket), util.HashStringToLong(dir), name, dir)
 
 	var data []byte
 	if err := row.Scan(&data); err != nil {
@@ -246,7 +260,7 @@ func (store *AbstractSqlStore) DeleteEntry(ctx context.Context, fullpath util.Fu
 
 	dir, name := shortPath.DirAndName()
 
-	res, err := db.ExecContext(ctx, store.GetSqlDelete(bucket), util.HashStringToLong(dir), name, dir)
+	res, err := store.execContext(ctx, db, store.GetSqlDelete(bucket), util.HashStringToLong(dir), name, dir)
 	if err != nil {
 		return fmt.Errorf("delete %s: %s", fullpath, err)
 	}
@@ -302,10 +316,57 @@ func (store *AbstractSqlStore) ListDirectoryPrefixedEntries(ctx context.Context,
 		sqlText = store.GetSqlListInclusive(bucket)
 	}
 
-	rows, err := db.QueryContext(ctx, sqlText, util.HashStringToLong(string(shortPath)), startFileName, string(shortPath), prefix+"%", limit+1)
+	args := []interface{}{util.HashStringToLong(string(shortPath)), startFileName, string(shortPath), prefix + "%", limit + 1}
+
+	_, inTransaction := ctx.Value("tx").(*sql.Tx)
+	if cursorGenerator, ok := store.SqlGenerator.(SqlCursorGenerator); ok && limit > listCursorBatchSize && !inTransaction {
+		lastFileName, isCursorDeclared, err := store.listWithCursor(ctx, cursorGenerator, sqlText, args, dirPath, eachEntryFunc)
+		if isCursorDeclared {
+			return lastFileName, err
+		}
+		// e.g., a postgres compatible database without cursors
+		glog.V(1).Infof("list %s without cursor: %v", dirPath, err)
+	}
+
+	rows, err := db.QueryContext(ctx, sqlText, args...)
 	if err != nil {
 		return lastFileName, fmt.Errorf("list %s : %v", dirPath, err)
 	}
+	_, lastFileName, _, err = scanEntries(rows, dirPath, eachEntryFunc)
+
+	return lastFileName, err
+}
+
+// listWithCursor fetches the entries in batches, so a large directory is not loaded into memory at once
+func (store *AbstractSqlStore) listWithCursor(ctx context.Context, cursorGenerator SqlCursorGenerator, sqlText string, args []interface{}, dirPath util.FullPath, eachEntryFunc filer.ListEachEntryFunc) (lastFileName string, isCursorDeclared bool, err error) {
+	// cursors only live within a transaction
+	tx, err := store.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return lastFileName, false, fmt.Errorf("list %s begin: %v", dirPath, err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, cursorGenerator.GetSqlDeclareCursor(listCursorName, sqlText), args...); err != nil {
+		return lastFileName, false, fmt.Errorf("list %s declare cursor: %v", dirPath, err)
+	}
+
+	for {
+		rows, err := tx.QueryContext(ctx, cursorGenerator.GetSqlFetchCursor(listCursorName, listCursorBatchSize))
+		if err != nil {
+			return lastFileName, true, fmt.Errorf("list %s fetch: %v", dirPath, err)
+		}
+		count, batchLastFileName, isDone, err := scanEntries(rows, dirPath, eachEntryFunc)
+		if batchLastFileName != "" {
+			lastFileName = batchLastFileName
+		}
+		if err != nil || isDone || count < listCursorBatchSize {
+			return lastFileName, true, err
+		}
+	}
+}
+
+// scanEntries reads the name and meta of each row, until eachEntryFunc returns false
+func scanEntries(rows *sql.Rows, dirPath util.FullPath, eachEntryFunc filer.ListEachEntryFunc) (count int, lastFileName string, isDone bool, err error) {
 	defer rows.Close()
 
 	for rows.Next() {
@@ -313,8 +374,9 @@ func (store *AbstractSqlStore) ListDirectoryPrefixedEntries(ctx context.Context,
 		var data []byte
 		if err = rows.Scan(&name, &data); err != nil {
 			glog.V(0).Infof("scan %s : %v", dirPath, err)
-			return lastFileName, fmt.Errorf("scan %s: %v", dirPath, err)
+			return count, lastFileName, true, fmt.Errorf("scan %s: %v", dirPath, err)
 		}
+		count++
 		lastFileName = name
 
 		entry := &filer.Entry{
@@ -322,26 +384,80 @@ func (store *AbstractSqlStore) ListDirectoryPrefixedEntries(ctx context.Context,
 		}
 		if err = entry.DecodeAttributesAndChunks(util.MaybeDecompressData(data)); err != nil {
 			glog.V(0).Infof("scan decode %s : %v", entry.FullPath, err)
-			return lastFileName, fmt.Errorf("scan decode %s : %v", entry.FullPath, err)
+			return count, lastFileName, true, fmt.Errorf("scan decode %s : %v", entry.FullPath, err)
 		}
 
 		if !eachEntryFunc(entry) {
-			break
+			return count, lastFileName, true, nil
 		}
 
 	}
 
-	return lastFileName, nil
+	return count, lastFileName, false, rows.Err()
 }
 
 func (store *AbstractSqlStore) ListDirectoryEntries(ctx context.Context, dirPath util.FullPath, startFileName string, includeStartFile bool, limit int64, eachEntryFunc filer.ListEachEntryFunc) (lastFileName string, err error) {
-	return store.ListDirectoryPrefixedEntries(ctx, dirPath, startFileName, includeStartFile, limit, "", nil)
+	return store.ListDirectoryPrefixedEntries(ctx, dirPath, startFileName, includeStartFile, limit, "", eachEntryFunc)
 }
 
 func (store *AbstractSqlStore) Shutdown() {
+	store.closeStatements("")
 	store.DB.Close()
 }
 
+// preparedStatement returns the statement prepared for the query, or nil to run the query text directly
+func (store *AbstractSqlStore) preparedStatement(ctx context.Context, db TxOrDB, query string) *sql.Stmt {
+	if !store.UsePreparedStatements {
+		return nil
+	}
+	store.stmtsLock.Lock()
+	stmt, found := store.stmts[query]
+	if !found {
+		var err error
+		if stmt, err = store.DB.PrepareContext(ctx, query); err != nil {
+			store.stmtsLock.Unlock()
+			glog.V(1).Infof("prepare %s: %v", query, err)
+			return nil
+		}
+		if store.stmts == nil {
+			store.stmts = make(map[string]*sql.Stmt)
+		}
+		store.stmts[query] = stmt
+	}
+	store.stmtsLock.Unlock()
+
+	if tx, ok := db.(*sql.Tx); ok {
+		return tx.StmtContext(ctx, stmt)
+	}
+	return stmt
+}
+
+func (store *AbstractSqlStore) execContext(ctx context.Context, db TxOrDB, query string, args ...interface{}) (sql.Result, error) {
+	if stmt := store.preparedStatement(ctx, db, query); stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
+	return db.ExecContext(ctx, query, args...)
+}
+
+func (store *AbstractSqlStore) queryRowContext(ctx context.Context, db TxOrDB, query string, args ...interface{}) *sql.Row {
+	if stmt := store.preparedStatement(ctx, db, query); stmt != nil {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+	return db.QueryRowContext(ctx, query, args...)
+}
+
+// closeStatements closes the statements on the table, or all statements if the table is empty
+func (store *AbstractSqlStore) closeStatements(tableName string) {
+	store.stmtsLock.Lock()
+	defer store.stmtsLock.Unlock()
+	for query, stmt := range store.stmts {
+		if tableName == "" || strings.Contains(query, tableName) {
+			stmt.Close()
+			delete(store.stmts, query)
+		}
+	}
+}
+
 func isValidBucket(bucket string) bool {
 	if s3bucket.VerifyS3BucketName(bucket) != nil {
 		return false
@@ -361,6 +477,8 @@ func (store *AbstractSqlStore) deleteTable(ctx context.Context, bucket string) e
 	if !store.SupportBucketTable {
 		return nil
 	}
+	// the statements prepared for the table are no longer valid
+	store.closeStatements(bucket)
 	_, err := store.DB.ExecContext(ctx, store.SqlGenerator.GetSqlDropTable(bucket))
 	return err
 }
@@ -0,0 +1,113 @@
+package abstract_sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// SqlRow is one row of the filemeta table
+type SqlRow struct {
+	DirHash   int64
+	Name      string
+	Directory string
+	Meta      []byte
+}
+
+// SqlBulkLoader is implemented by the databases with a faster way to load many rows than inserting them,
+// e.g., COPY for postgres, or LOAD DATA for mysql
+type SqlBulkLoader interface {
+	BulkLoad(ctx context.Context, tx *sql.Tx, tableName string, rows []*SqlRow) error
+}
+
+var _ filer.BulkInsertable = (*AbstractSqlStore)(nil)
+
+// BulkInsertEntries writes the entries in one transaction, with the bulk loader if the database supports it,
+// or with one prepared insert statement for each table.
+func (store *AbstractSqlStore) BulkInsertEntries(ctx context.Context, entries []*filer.Entry) error {
+	tableRows, err := store.toSqlRows(ctx, entries)
+	if err != nil {
+		return err
+	}
+
+	if loader, ok := store.SqlGenerator.(SqlBulkLoader); ok {
+		err = store.inTransaction(ctx, func(tx *sql.Tx) error {
+			for tableName, rows := range tableRows {
+				if err := loader.BulkLoad(ctx, tx, tableName, rows); err != nil {
+					return fmt.Errorf("bulk load %d rows into %s: %v", len(rows), tableName, err)
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			return nil
+		}
+		// e.g., LOAD DATA LOCAL is disabled on the mysql server
+		glog.Warningf("%v, falls back to batched inserts", err)
+	}
+
+	return store.inTransaction(ctx, func(tx *sql.Tx) error {
+		for tableName, rows := range tableRows {
+			if err := store.batchInsert(ctx, tx, tableName, rows); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (store *AbstractSqlStore) toSqlRows(ctx context.Context, entries []*filer.Entry) (tableRows map[string][]*SqlRow, err error) {
+	tableRows = make(map[string][]*SqlRow)
+	for _, entry := range entries {
+		_, bucket, shortPath, err := store.getTxOrDB(ctx, entry.FullPath, false)
+		if err != nil {
+			return nil, fmt.Errorf("findDB %s : %v", entry.FullPath, err)
+		}
+		dir, name := shortPath.DirAndName()
+		meta, err := entry.EncodeAttributesAndChunks()
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %s", entry.FullPath, err)
+		}
+		if len(entry.GetChunks()) > filer.CountEntryChunksForGzip {
+			meta = util.MaybeGzipData(meta)
+		}
+		tableRows[bucket] = append(tableRows[bucket], &SqlRow{
+			DirHash:   util.HashStringToLong(dir),
+			Name:      name,
+			Directory: dir,
+			Meta:      meta,
+		})
+	}
+	return tableRows, nil
+}
+
+func (store *AbstractSqlStore) inTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := store.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %v", err)
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// batchInsert prepares the insert statement once for all the rows
+func (store *AbstractSqlStore) batchInsert(ctx context.Context, tx *sql.Tx, tableName string, rows []*SqlRow) error {
+	stmt, err := tx.PrepareContext(ctx, store.GetSqlInsert(tableName))
+	if err != nil {
+		return fmt.Errorf("prepare insert into %s: %v", tableName, err)
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row.DirHash, row.Name, row.Directory, row.Meta); err != nil {
+			return fmt.Errorf("insert %s/%s: %v", row.Directory, row.Name, err)
+		}
+	}
+	return nil
+}
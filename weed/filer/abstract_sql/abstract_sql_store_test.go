@@ -0,0 +1,315 @@
+package abstract_sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver records the statements, and answers the queries with the rows given by the test
+type fakeDriver struct {
+	sync.Mutex
+	statements []string
+	execArgs   [][]driver.Value
+	commits    int
+	rollbacks  int
+	execErr    func(query string) error
+	queryRows  func(query string) [][]driver.Value // the rows of name and meta
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error)            { return &fakeConn{d}, nil }
+func (d *fakeDriver) Connect(ctx context.Context) (driver.Conn, error) { return &fakeConn{d}, nil }
+func (d *fakeDriver) Driver() driver.Driver                            { return d }
+
+func (d *fakeDriver) record(query string, args []driver.Value) {
+	d.Lock()
+	defer d.Unlock()
+	d.statements = append(d.statements, query)
+	d.execArgs = append(d.execArgs, args)
+}
+
+func (d *fakeDriver) countStatements(prefix string) (count int) {
+	d.Lock()
+	defer d.Unlock()
+	for _, statement := range d.statements {
+		if strings.HasPrefix(statement, prefix) {
+			count++
+		}
+	}
+	return
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c.d, query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return &fakeTx{c.d}, nil }
+
+// BeginTx supports the read only transactions of the cursors
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return &fakeTx{c.d}, nil
+}
+
+type fakeTx struct {
+	d *fakeDriver
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.d.Lock()
+	defer tx.d.Unlock()
+	tx.d.commits++
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.d.Lock()
+	defer tx.d.Unlock()
+	tx.d.rollbacks++
+	return nil
+}
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.record(s.query, args)
+	if s.d.execErr != nil {
+		if err := s.d.execErr(s.query); err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.record(s.query, args)
+	var rows [][]driver.Value
+	if s.d.queryRows != nil {
+		rows = s.d.queryRows(s.query)
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"name", "meta"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type fakeSqlGenerator struct{}
+
+func (gen *fakeSqlGenerator) GetSqlInsert(tableName string) string { return "INSERT " + tableName }
+func (gen *fakeSqlGenerator) GetSqlUpdate(tableName string) string { return "UPDATE " + tableName }
+func (gen *fakeSqlGenerator) GetSqlFind(tableName string) string   { return "FIND " + tableName }
+func (gen *fakeSqlGenerator) GetSqlDelete(tableName string) string { return "DELETE " + tableName }
+func (gen *fakeSqlGenerator) GetSqlDeleteFolderChildren(tableName string) string {
+	return "DELETE CHILDREN " + tableName
+}
+func (gen *fakeSqlGenerator) GetSqlListExclusive(tableName string) string { return "LIST " + tableName }
+func (gen *fakeSqlGenerator) GetSqlListInclusive(tableName string) string {
+	return "LIST INCLUSIVE " + tableName
+}
+func (gen *fakeSqlGenerator) GetSqlCreateTable(tableName string) string { return "CREATE " + tableName }
+func (gen *fakeSqlGenerator) GetSqlDropTable(tableName string) string   { return "DROP " + tableName }
+
+type fakeCursorSqlGenerator struct {
+	fakeSqlGenerator
+}
+
+func (gen *fakeCursorSqlGenerator) GetSqlDeclareCursor(cursorName, query string) string {
+	return "DECLARE " + cursorName + " FOR " + query
+}
+func (gen *fakeCursorSqlGenerator) GetSqlFetchCursor(cursorName string, count int) string {
+	return fmt.Sprintf("FETCH %d FROM %s", count, cursorName)
+}
+
+// fakeBulkSqlGenerator loads the rows with a bulk load statement, or fails if loadErr is set
+type fakeBulkSqlGenerator struct {
+	fakeSqlGenerator
+	loadErr error
+	loaded  map[string][]*SqlRow
+}
+
+func (gen *fakeBulkSqlGenerator) BulkLoad(ctx context.Context, tx *sql.Tx, tableName string, rows []*SqlRow) error {
+	if _, err := tx.ExecContext(ctx, "LOAD "+tableName); err != nil {
+		return err
+	}
+	if gen.loadErr != nil {
+		return gen.loadErr
+	}
+	gen.loaded[tableName] = append(gen.loaded[tableName], rows...)
+	return nil
+}
+
+func newFakeSqlStore(gen SqlGenerator) (*AbstractSqlStore, *fakeDriver) {
+	d := &fakeDriver{}
+	return &AbstractSqlStore{SqlGenerator: gen, DB: sql.OpenDB(d)}, d
+}
+
+func newTestEntries(dir string, count int) (entries []*filer.Entry) {
+	for i := 0; i < count; i++ {
+		entries = append(entries, &filer.Entry{
+			FullPath: util.NewFullPath(dir, fmt.Sprintf("f%05d", i)),
+			Attr:     filer.Attr{Mode: 0644, FileSize: uint64(i)},
+		})
+	}
+	return
+}
+
+func TestBulkInsertEntries(t *testing.T) {
+	gen := &fakeBulkSqlGenerator{loaded: make(map[string][]*SqlRow)}
+	store, d := newFakeSqlStore(gen)
+	entries := newTestEntries("/a/b", 3)
+
+	require.NoError(t, store.BulkInsertEntries(context.Background(), entries))
+	rows := gen.loaded[DEFAULT_TABLE]
+	require.Equal(t, 3, len(rows))
+	for i, row := range rows {
+		assert.Equal(t, util.HashStringToLong("/a/b"), row.DirHash)
+		assert.Equal(t, "/a/b", row.Directory)
+		assert.Equal(t, entries[i].Name(), row.Name)
+		entry := &filer.Entry{}
+		require.NoError(t, entry.DecodeAttributesAndChunks(row.Meta))
+		assert.Equal(t, entries[i].FileSize, entry.FileSize)
+	}
+	assert.Equal(t, 0, d.countStatements("INSERT"))
+	assert.Equal(t, 1, d.commits)
+}
+
+func TestBulkInsertEntriesFallback(t *testing.T) {
+	// e.g., LOAD DATA LOCAL is disabled on the mysql server
+	gen := &fakeBulkSqlGenerator{loaded: make(map[string][]*SqlRow), loadErr: fmt.Errorf("disabled")}
+	store, d := newFakeSqlStore(gen)
+
+	require.NoError(t, store.BulkInsertEntries(context.Background(), newTestEntries("/a/b", 3)))
+	assert.Empty(t, gen.loaded)
+	assert.Equal(t, 1, d.rollbacks)
+	assert.Equal(t, 3, d.countStatements("INSERT "+DEFAULT_TABLE))
+	assert.Equal(t, 1, d.commits)
+
+	// the failed inserts roll back the whole batch
+	d.execErr = func(query string) error {
+		if strings.HasPrefix(query, "INSERT") {
+			return fmt.Errorf("duplicated")
+		}
+		return nil
+	}
+	assert.Error(t, store.BulkInsertEntries(context.Background(), newTestEntries("/a/b", 3)))
+	assert.Equal(t, 3, d.rollbacks)
+	assert.Equal(t, 1, d.commits)
+}
+
+// listRows returns the rows of the entries, in batches of the FETCH statements if listed with the cursor
+func listRows(t *testing.T, entries []*filer.Entry) func(query string) [][]driver.Value {
+	var rows [][]driver.Value
+	for _, entry := range entries {
+		meta, err := entry.EncodeAttributesAndChunks()
+		require.NoError(t, err)
+		rows = append(rows, []driver.Value{entry.Name(), meta})
+	}
+	return func(query string) (batch [][]driver.Value) {
+		if !strings.HasPrefix(query, "FETCH") {
+			return rows
+		}
+		var count int
+		fmt.Sscanf(query, "FETCH %d", &count)
+		count = min(count, len(rows))
+		batch, rows = rows[:count], rows[count:]
+		return batch
+	}
+}
+
+func TestListDirectoryEntriesWithCursor(t *testing.T) {
+	store, d := newFakeSqlStore(&fakeCursorSqlGenerator{})
+	entries := newTestEntries("/a/b", 2*listCursorBatchSize+500)
+	d.queryRows = listRows(t, entries)
+
+	var listed []string
+	lastFileName, err := store.ListDirectoryEntries(context.Background(), "/a/b", "", false, filer.PaginationSize, func(entry *filer.Entry) bool {
+		listed = append(listed, entry.Name())
+		return true
+	})
+	require.NoError(t, err)
+	require.Equal(t, len(entries), len(listed))
+	assert.Equal(t, entries[len(entries)-1].Name(), lastFileName)
+	assert.Equal(t, 1, d.countStatements("DECLARE "+listCursorName+" FOR LIST "+DEFAULT_TABLE))
+	assert.Equal(t, 3, d.countStatements("FETCH"))
+	assert.Equal(t, 0, d.countStatements("LIST"))
+
+	// stops fetching once the caller stops
+	store, d = newFakeSqlStore(&fakeCursorSqlGenerator{})
+	d.queryRows = listRows(t, entries)
+	listed = nil
+	lastFileName, err = store.ListDirectoryEntries(context.Background(), "/a/b", "", false, filer.PaginationSize, func(entry *filer.Entry) bool {
+		listed = append(listed, entry.Name())
+		return len(listed) < listCursorBatchSize+1
+	})
+	require.NoError(t, err)
+	assert.Equal(t, listCursorBatchSize+1, len(listed))
+	assert.Equal(t, listed[len(listed)-1], lastFileName)
+	assert.Equal(t, 2, d.countStatements("FETCH"))
+}
+
+func TestListDirectoryEntriesWithoutCursor(t *testing.T) {
+	entries := newTestEntries("/a/b", 10)
+
+	// the listings fitting in one batch, and the databases without cursors, query the rows at once
+	for _, gen := range []SqlGenerator{&fakeSqlGenerator{}, &fakeCursorSqlGenerator{}} {
+		store, d := newFakeSqlStore(gen)
+		d.queryRows = listRows(t, entries)
+		var listed int
+		_, err := store.ListDirectoryEntries(context.Background(), "/a/b", "", false, 100, func(entry *filer.Entry) bool {
+			listed++
+			return true
+		})
+		require.NoError(t, err)
+		assert.Equal(t, len(entries), listed)
+		assert.Equal(t, 0, d.countStatements("DECLARE"))
+		assert.Equal(t, 1, d.countStatements("LIST "+DEFAULT_TABLE))
+	}
+
+	// falls back when the cursor can not be declared, e.g., a postgres compatible database without cursors
+	store, d := newFakeSqlStore(&fakeCursorSqlGenerator{})
+	d.queryRows = listRows(t, entries)
+	d.execErr = func(query string) error {
+		if strings.HasPrefix(query, "DECLARE") {
+			return fmt.Errorf("not supported")
+		}
+		return nil
+	}
+	var listed int
+	_, err := store.ListDirectoryEntries(context.Background(), "/a/b", "", true, filer.PaginationSize, func(entry *filer.Entry) bool {
+		listed++
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, len(entries), listed)
+	assert.Equal(t, 1, d.countStatements("LIST INCLUSIVE "+DEFAULT_TABLE))
+}
@@ -0,0 +1,371 @@
+//go:build badger
+// +build badger
+
+package badger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	weed_util "github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	DIR_FILE_SEPARATOR = byte(0x00)
+
+	defaultBatchSize      = 256
+	valueLogGcInterval    = 10 * time.Minute
+	valueLogGcDiscardRate = 0.5
+)
+
+func init() {
+	filer.Stores = append(filer.Stores, &BadgerStore{})
+}
+
+// BadgerStore keeps the entries as "<dir>\x00<name>" in BadgerDB, the same layout as leveldb,
+// so a directory is listed by a prefix scan.
+//
+// The concurrent writes are committed together in one write batch, so a busy filer writes faster than
+// with one commit per write. Each write still returns after its batch is committed.
+type BadgerStore struct {
+	db        *badger.DB
+	batchSize int
+	writes    chan *writeOp
+	writeDone sync.WaitGroup
+	stopGc    chan struct{}
+}
+
+type writeOp struct {
+	key      []byte
+	value    []byte
+	isDelete bool
+	done     chan error
+}
+
+func (store *BadgerStore) GetName() string {
+	return "badger"
+}
+
+func (store *BadgerStore) Initialize(configuration weed_util.Configuration, prefix string) (err error) {
+	configuration.SetDefault(prefix+"batch_size", defaultBatchSize)
+	dir := configuration.GetString(prefix + "dir")
+	syncWrites := configuration.GetBool(prefix + "sync_writes")
+	batchSize := configuration.GetInt(prefix + "batch_size")
+	return store.initialize(dir, syncWrites, batchSize)
+}
+
+func (store *BadgerStore) initialize(dir string, syncWrites bool, batchSize int) (err error) {
+	glog.V(0).Infof("filer store badger dir: %s", dir)
+	os.MkdirAll(dir, 0755)
+	if err := weed_util.TestFolderWritable(dir); err != nil {
+		return fmt.Errorf("Check Badger Folder %s Writable: %s", dir, err)
+	}
+
+	opts := badger.DefaultOptions(dir).
+		WithSyncWrites(syncWrites).
+		WithLogger(badgerLogger{})
+	if store.db, err = badger.Open(opts); err != nil {
+		return fmt.Errorf("open badger %s: %v", dir, err)
+	}
+
+	store.batchSize = batchSize
+	if store.batchSize <= 0 {
+		store.batchSize = defaultBatchSize
+	}
+	store.writes = make(chan *writeOp, store.batchSize)
+	store.stopGc = make(chan struct{})
+	store.writeDone.Add(1)
+	go store.loopWrite()
+	go store.loopValueLogGc()
+	return nil
+}
+
+// loopWrite commits the queued writes together, without waiting for more writes to come
+func (store *BadgerStore) loopWrite() {
+	defer store.writeDone.Done()
+	for op := range store.writes {
+		ops := []*writeOp{op}
+	collect:
+		for len(ops) < store.batchSize {
+			select {
+			case next, ok := <-store.writes:
+				if !ok {
+					break collect
+				}
+				ops = append(ops, next)
+			default:
+				break collect
+			}
+		}
+		err := store.writeBatch(ops)
+		for _, op := range ops {
+			op.done <- err
+		}
+	}
+}
+
+func (store *BadgerStore) writeBatch(ops []*writeOp) error {
+	wb := store.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, op := range ops {
+		var err error
+		if op.isDelete {
+			err = wb.Delete(op.key)
+		} else {
+			err = wb.Set(op.key, op.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+func (store *BadgerStore) write(key, value []byte, isDelete bool) error {
+	op := &writeOp{
+		key:      key,
+		value:    value,
+		isDelete: isDelete,
+		done:     make(chan error, 1),
+	}
+	store.writes <- op
+	return <-op.done
+}
+
+// loopValueLogGc rewrites the value log files with mostly deleted or overwritten values
+func (store *BadgerStore) loopValueLogGc() {
+	ticker := time.NewTicker(valueLogGcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-store.stopGc:
+			return
+		case <-ticker.C:
+			for store.db.RunValueLogGC(valueLogGcDiscardRate) == nil {
+			}
+		}
+	}
+}
+
+func (store *BadgerStore) BeginTransaction(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+func (store *BadgerStore) CommitTransaction(ctx context.Context) error {
+	return nil
+}
+func (store *BadgerStore) RollbackTransaction(ctx context.Context) error {
+	return nil
+}
+
+func (store *BadgerStore) InsertEntry(ctx context.Context, entry *filer.Entry) (err error) {
+	key := genKey(entry.DirAndName())
+
+	value, err := entry.EncodeAttributesAndChunks()
+	if err != nil {
+		return fmt.Errorf("encoding %s %+v: %v", entry.FullPath, entry.Attr, err)
+	}
+
+	if len(entry.GetChunks()) > filer.CountEntryChunksForGzip {
+		value = weed_util.MaybeGzipData(value)
+	}
+
+	if err = store.write(key, value, false); err != nil {
+		return fmt.Errorf("persisting %s : %v", entry.FullPath, err)
+	}
+
+	return nil
+}
+
+func (store *BadgerStore) UpdateEntry(ctx context.Context, entry *filer.Entry) (err error) {
+
+	return store.InsertEntry(ctx, entry)
+}
+
+func (store *BadgerStore) FindEntry(ctx context.Context, fullpath weed_util.FullPath) (entry *filer.Entry, err error) {
+	key := genKey(fullpath.DirAndName())
+
+	var data []byte
+	err = store.db.View(func(txn *badger.Txn) error {
+		item, getErr := txn.Get(key)
+		if getErr != nil {
+			return getErr
+		}
+		data, getErr = item.ValueCopy(nil)
+		return getErr
+	})
+
+	if err == badger.ErrKeyNotFound {
+		return nil, filer_pb.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get %s : %v", fullpath, err)
+	}
+
+	entry = &filer.Entry{
+		FullPath: fullpath,
+	}
+	err = entry.DecodeAttributesAndChunks(weed_util.MaybeDecompressData(data))
+	if err != nil {
+		return entry, fmt.Errorf("decode %s : %v", entry.FullPath, err)
+	}
+
+	return entry, nil
+}
+
+func (store *BadgerStore) DeleteEntry(ctx context.Context, fullpath weed_util.FullPath) (err error) {
+	key := genKey(fullpath.DirAndName())
+
+	if err = store.write(key, nil, true); err != nil {
+		return fmt.Errorf("delete %s : %v", fullpath, err)
+	}
+
+	return nil
+}
+
+func (store *BadgerStore) DeleteFolderChildren(ctx context.Context, fullpath weed_util.FullPath) (err error) {
+
+	directoryPrefix := genDirectoryKeyPrefix(fullpath, "")
+
+	var keys [][]byte
+	err = store.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = directoryPrefix
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list %s : %v", fullpath, err)
+	}
+
+	wb := store.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, key := range keys {
+		if err = wb.Delete(key); err != nil {
+			return fmt.Errorf("delete %s : %v", fullpath, err)
+		}
+	}
+	if err = wb.Flush(); err != nil {
+		return fmt.Errorf("delete %s : %v", fullpath, err)
+	}
+
+	return nil
+}
+
+func (store *BadgerStore) ListDirectoryEntries(ctx context.Context, dirPath weed_util.FullPath, startFileName string, includeStartFile bool, limit int64, eachEntryFunc filer.ListEachEntryFunc) (lastFileName string, err error) {
+	return store.ListDirectoryPrefixedEntries(ctx, dirPath, startFileName, includeStartFile, limit, "", eachEntryFunc)
+}
+
+func (store *BadgerStore) ListDirectoryPrefixedEntries(ctx context.Context, dirPath weed_util.FullPath, startFileName string, includeStartFile bool, limit int64, prefix string, eachEntryFunc filer.ListEachEntryFunc) (lastFileName string, err error) {
+
+	directoryPrefix := genDirectoryKeyPrefix(dirPath, prefix)
+	lastFileStart := directoryPrefix
+	if startFileName != "" {
+		lastFileStart = genDirectoryKeyPrefix(dirPath, startFileName)
+	}
+	if bytes.Compare(lastFileStart, directoryPrefix) < 0 {
+		// starting before the prefix would stop at the first key without the prefix
+		lastFileStart = directoryPrefix
+	}
+
+	viewErr := store.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = directoryPrefix
+		if limit > 0 && limit < int64(opts.PrefetchSize) {
+			opts.PrefetchSize = int(limit) + 1
+		}
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(lastFileStart); it.Valid(); it.Next() {
+			item := it.Item()
+			fileName := getNameFromKey(item.Key())
+			if fileName == "" {
+				continue
+			}
+			if fileName == startFileName && !includeStartFile {
+				continue
+			}
+			limit--
+			if limit < 0 {
+				break
+			}
+			lastFileName = fileName
+			entry := &filer.Entry{
+				FullPath: weed_util.NewFullPath(string(dirPath), fileName),
+			}
+			if valueErr := item.Value(func(value []byte) error {
+				return entry.DecodeAttributesAndChunks(weed_util.MaybeDecompressData(value))
+			}); valueErr != nil {
+				err = valueErr
+				glog.V(0).Infof("list %s : %v", entry.FullPath, err)
+				break
+			}
+			if !eachEntryFunc(entry) {
+				break
+			}
+		}
+		return nil
+	})
+	if viewErr != nil {
+		return lastFileName, fmt.Errorf("prefix list %s : %v", dirPath, viewErr)
+	}
+
+	return lastFileName, err
+}
+
+func genKey(dirPath, fileName string) (key []byte) {
+	key = []byte(dirPath)
+	key = append(key, DIR_FILE_SEPARATOR)
+	key = append(key, []byte(fileName)...)
+	return key
+}
+
+func genDirectoryKeyPrefix(fullpath weed_util.FullPath, startFileName string) (keyPrefix []byte) {
+	keyPrefix = []byte(string(fullpath))
+	keyPrefix = append(keyPrefix, DIR_FILE_SEPARATOR)
+	if len(startFileName) > 0 {
+		keyPrefix = append(keyPrefix, []byte(startFileName)...)
+	}
+	return keyPrefix
+}
+
+func getNameFromKey(key []byte) string {
+	sepIndex := bytes.LastIndexByte(key, DIR_FILE_SEPARATOR)
+	return string(key[sepIndex+1:])
+}
+
+func (store *BadgerStore) Shutdown() {
+	close(store.writes)
+	store.writeDone.Wait()
+	close(store.stopGc)
+	store.db.Close()
+}
+
+// badgerLogger sends the badger logs to glog, with the informational ones only in the verbose mode
+type badgerLogger struct{}
+
+func (badgerLogger) Errorf(format string, args ...interface{}) {
+	glog.Errorf("badger: "+format, args...)
+}
+func (badgerLogger) Warningf(format string, args ...interface{}) {
+	glog.Warningf("badger: "+format, args...)
+}
+func (badgerLogger) Infof(format string, args ...interface{}) {
+	glog.V(1).Infof("badger: "+format, args...)
+}
+func (badgerLogger) Debugf(format string, args ...interface{}) {
+	glog.V(4).Infof("badger: "+format, args...)
+}
@@ -0,0 +1,53 @@
+//go:build badger
+// +build badger
+
+package badger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+)
+
+func (store *BadgerStore) KvPut(ctx context.Context, key []byte, value []byte) (err error) {
+
+	if err = store.write(key, value, false); err != nil {
+		return fmt.Errorf("kv put: %v", err)
+	}
+
+	return nil
+}
+
+func (store *BadgerStore) KvGet(ctx context.Context, key []byte) (value []byte, err error) {
+
+	err = store.db.View(func(txn *badger.Txn) error {
+		item, getErr := txn.Get(key)
+		if getErr != nil {
+			return getErr
+		}
+		value, getErr = item.ValueCopy(nil)
+		return getErr
+	})
+
+	if err == badger.ErrKeyNotFound {
+		return nil, filer.ErrKvNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("kv get: %v", err)
+	}
+
+	return
+}
+
+func (store *BadgerStore) KvDelete(ctx context.Context, key []byte) (err error) {
+
+	if err = store.write(key, nil, true); err != nil {
+		return fmt.Errorf("kv delete: %v", err)
+	}
+
+	return nil
+}
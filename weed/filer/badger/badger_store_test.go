@@ -0,0 +1,159 @@
+//go:build badger
+// +build badger
+
+package badger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestCreateAndFind(t *testing.T) {
+	testFiler := filer.NewFiler(pb.ServerDiscovery{}, nil, "", "", "", "", "", 255, nil)
+	dir := t.TempDir()
+	store := &BadgerStore{}
+	store.initialize(dir, false, 0)
+	testFiler.SetStore(store)
+	defer store.Shutdown()
+
+	fullpath := util.FullPath("/home/chris/this/is/one/file1.jpg")
+
+	ctx := context.Background()
+
+	entry1 := &filer.Entry{
+		FullPath: fullpath,
+		Attr: filer.Attr{
+			Mode: 0440,
+			Uid:  1234,
+			Gid:  5678,
+		},
+	}
+
+	if err := testFiler.CreateEntry(ctx, entry1, false, false, nil, false, testFiler.MaxFilenameLength); err != nil {
+		t.Errorf("create entry %v: %v", entry1.FullPath, err)
+		return
+	}
+
+	entry, err := testFiler.FindEntry(ctx, fullpath)
+
+	if err != nil {
+		t.Errorf("find entry: %v", err)
+		return
+	}
+
+	if entry.FullPath != entry1.FullPath {
+		t.Errorf("find wrong entry: %v", entry.FullPath)
+		return
+	}
+
+	// checking one upper directory
+	entries, _, _ := testFiler.ListDirectoryEntries(ctx, util.FullPath("/home/chris/this/is/one"), "", false, 100, "", "", "")
+	if len(entries) != 1 {
+		t.Errorf("list entries count: %v", len(entries))
+		return
+	}
+
+	// checking one upper directory
+	entries, _, _ = testFiler.ListDirectoryEntries(ctx, util.FullPath("/"), "", false, 100, "", "", "")
+	if len(entries) != 1 {
+		t.Errorf("list entries count: %v", len(entries))
+		return
+	}
+
+}
+
+func TestConcurrentWritesAndList(t *testing.T) {
+	dir := t.TempDir()
+	store := &BadgerStore{}
+	if err := store.initialize(dir, false, 16); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	defer store.Shutdown()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry := &filer.Entry{
+				FullPath: util.FullPath(fmt.Sprintf("/dir/file%03d.txt", i)),
+				Attr: filer.Attr{
+					Mtime: time.Now(),
+					Mode:  os.FileMode(0644),
+				},
+			}
+			if err := store.InsertEntry(ctx, entry); err != nil {
+				t.Errorf("insert %s: %v", entry.FullPath, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var names []string
+	lastFileName, err := store.ListDirectoryPrefixedEntries(ctx, "/dir", "file010.txt", false, 5, "file0", func(entry *filer.Entry) bool {
+		names = append(names, entry.Name())
+		return true
+	})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(names) != 5 || names[0] != "file011.txt" || lastFileName != "file015.txt" {
+		t.Errorf("listed %v, last %s", names, lastFileName)
+	}
+
+	if err = store.DeleteFolderChildren(ctx, "/dir"); err != nil {
+		t.Fatalf("delete children: %v", err)
+	}
+	if _, err = store.FindEntry(ctx, "/dir/file001.txt"); err == nil {
+		t.Errorf("the children should be deleted")
+	}
+
+	if err = store.KvPut(ctx, []byte("k"), []byte("v")); err != nil {
+		t.Fatalf("kv put: %v", err)
+	}
+	if value, err := store.KvGet(ctx, []byte("k")); err != nil || string(value) != "v" {
+		t.Errorf("kv get %q: %v", value, err)
+	}
+	if err = store.KvDelete(ctx, []byte("k")); err != nil {
+		t.Fatalf("kv delete: %v", err)
+	}
+	if _, err = store.KvGet(ctx, []byte("k")); err != filer.ErrKvNotFound {
+		t.Errorf("kv get after delete: %v", err)
+	}
+}
+
+func BenchmarkInsertEntry(b *testing.B) {
+	dir := b.TempDir()
+	store := &BadgerStore{}
+	store.initialize(dir, false, 0)
+	defer store.Shutdown()
+
+	ctx := context.Background()
+
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			entry := &filer.Entry{
+				FullPath: util.FullPath(fmt.Sprintf("/file%d-%d.txt", time.Now().UnixNano(), i)),
+				Attr: filer.Attr{
+					Crtime: time.Now(),
+					Mtime:  time.Now(),
+					Mode:   os.FileMode(0644),
+				},
+			}
+			store.InsertEntry(ctx, entry)
+		}
+	})
+}
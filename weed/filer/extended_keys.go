@@ -0,0 +1,24 @@
+package filer
+
+// The extended attributes of the entries, shared with the s3 gateway.
+// The attributes of the objects are named as the s3 headers, so the filer returns them with the objects.
+const (
+	// ExtVersionIdKey is the version id of an object in a versioned bucket, absent for the "null" version
+	ExtVersionIdKey = "X-Amz-Version-Id"
+
+	// ExtObjectLockKey is the object lock configuration of a bucket, in json
+	ExtObjectLockKey = "Seaweed-X-Amz-Object-Lock"
+	// ExtObjectLockModeKey is the retention mode of an object, GOVERNANCE or COMPLIANCE
+	ExtObjectLockModeKey = "X-Amz-Object-Lock-Mode"
+	// ExtObjectLockRetainUntilDateKey is when the retention of an object expires, in RFC3339
+	ExtObjectLockRetainUntilDateKey = "X-Amz-Object-Lock-Retain-Until-Date"
+	// ExtObjectLockLegalHoldKey is the legal hold of an object, ON or OFF
+	ExtObjectLockLegalHoldKey = "X-Amz-Object-Lock-Legal-Hold"
+	// the values of the object lock
+	ObjectLockModeGovernance = "GOVERNANCE"
+	ObjectLockModeCompliance = "COMPLIANCE"
+	ObjectLockLegalHoldOn    = "ON"
+
+	// ExtTaggingPrefix prefixes the tags of an object, one attribute per tag
+	ExtTaggingPrefix = "X-Amz-Tagging-"
+)
@@ -10,10 +10,12 @@ import (
 )
 
 type ChunkGroup struct {
-	lookupFn     wdclient.LookupFileIdFunctionType
-	sections     map[SectionIndex]*FileChunkSection
-	sectionsLock sync.RWMutex
-	readerCache  *ReaderCache
+	lookupFn        wdclient.LookupFileIdFunctionType
+	sections        map[SectionIndex]*FileChunkSection
+	sectionsLock    sync.RWMutex
+	readerCache     *ReaderCache
+	readAhead       *ReadAhead
+	readAheadWindow readAheadWindow
 }
 
 func NewChunkGroup(lookupFn wdclient.LookupFileIdFunctionType, chunkCache chunk_cache.ChunkCache, chunks []*filer_pb.FileChunk) (*ChunkGroup, error) {
@@ -52,6 +54,8 @@ func (group *ChunkGroup) ReadDataAt(fileSize int64, buff []byte, offset int64) (
 	group.sectionsLock.RLock()
 	defer group.sectionsLock.RUnlock()
 
+	group.maybeReadAhead(fileSize, offset, len(buff))
+
 	sectionIndexStart, sectionIndexStop := SectionIndex(offset/SectionSize), SectionIndex((offset+int64(len(buff)))/SectionSize)
 	for si := sectionIndexStart; si < sectionIndexStop+1; si++ {
 		section, found := group.sections[si]
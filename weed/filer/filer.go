@@ -7,6 +7,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/cluster/lock_manager"
@@ -18,6 +19,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/metadata_index"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
@@ -36,23 +38,38 @@ var (
 )
 
 type Filer struct {
-	UniqueFilerId       int32
-	UniqueFilerEpoch    int32
-	Store               VirtualFilerStore
-	MasterClient        *wdclient.MasterClient
-	fileIdDeletionQueue *util.UnboundedQueue
-	GrpcDialOption      grpc.DialOption
-	DirBucketsPath      string
-	Cipher              bool
-	LocalMetaLogBuffer  *log_buffer.LogBuffer
-	metaLogCollection   string
-	metaLogReplication  string
-	MetaAggregator      *MetaAggregator
-	Signature           int32
-	FilerConf           *FilerConf
-	RemoteStorage       *FilerRemoteStorage
-	Dlm                 *lock_manager.DistributedLockManager
-	MaxFilenameLength   uint32
+	UniqueFilerId        int32
+	UniqueFilerEpoch     int32
+	Store                VirtualFilerStore
+	MasterClient         *wdclient.MasterClient
+	fileIdDeletionQueue  *util.UnboundedQueue
+	GrpcDialOption       grpc.DialOption
+	DirBucketsPath       string
+	Cipher               bool
+	LocalMetaLogBuffer   *log_buffer.LogBuffer
+	metaLogCollection    string
+	metaLogReplication   string
+	MetaAggregator       *MetaAggregator
+	Signature            int32
+	FilerConf            *FilerConf
+	RemoteStorage        *FilerRemoteStorage
+	Dlm                  *lock_manager.DistributedLockManager
+	MaxFilenameLength    uint32
+	MetaLog              MetaLogOption
+	DirStats             DirStatsOption
+	MetadataIndex        metadata_index.MetadataIndex
+	chunkReferenceLock   sync.Mutex
+	dirQuotas            map[util.FullPath]DirQuota
+	dirQuotasLock        sync.RWMutex
+	dirQuotaUsageLock    sync.Mutex
+	dirStatsLock         sync.Mutex
+	dirTtls              map[util.FullPath]DirTtl
+	dirTtlsLock          sync.RWMutex
+	hardLinkLock         sync.Mutex
+	metadataIndexLock    sync.Mutex
+	metadataIndexChanges []metadataIndexChange
+	metadataIndexNotify  chan struct{}
+	renames              renamesInProgress
 }
 
 func NewFiler(masters pb.ServerDiscovery, grpcDialOption grpc.DialOption, filerHost pb.ServerAddress, filerGroup string, collection string, replication string, dataCenter string, maxFilenameLength uint32, notifyFn func()) *Filer {
@@ -208,6 +225,12 @@ func (f *Filer) CreateEntry(ctx context.Context, entry *Entry, o_excl bool, isFr
 
 	if oldEntry == nil {
 
+		f.maybeInheritDirTtl(entry)
+		if err := f.checkDirQuota(ctx, entry.FullPath, dirQuotaUsageOf(entry)); err != nil {
+			return err
+		}
+		f.maybeCommitWorm(nil, entry)
+
 		if !skipCreateParentDir {
 			dirParts := strings.Split(string(entry.FullPath), "/")
 			if err := f.ensureParentDirectoryEntry(ctx, entry, dirParts, len(dirParts)-1, isFromOtherCluster); err != nil {
@@ -228,13 +251,17 @@ func (f *Filer) CreateEntry(ctx context.Context, entry *Entry, o_excl bool, isFr
 		glog.V(4).Infof("UpdateEntry %s: old entry: %v", entry.FullPath, oldEntry.Name())
 		if err := f.UpdateEntry(ctx, oldEntry, entry); err != nil {
 			glog.Errorf("update entry %s: %v", entry.FullPath, err)
-			return fmt.Errorf("update entry %s: %v", entry.FullPath, err)
+			return fmt.Errorf("update entry %s: %w", entry.FullPath, err)
 		}
 	}
 
+	isVersionSaved := f.MaybeSaveVersion(ctx, oldEntry, entry, isFromOtherCluster)
+
 	f.NotifyUpdateEvent(ctx, oldEntry, entry, true, isFromOtherCluster, signatures)
 
-	f.deleteChunksIfNotNew(oldEntry, entry)
+	if !isVersionSaved {
+		f.deleteChunksIfNotNew(oldEntry, entry)
+	}
 
 	glog.V(4).Infof("CreateEntry %s: created", entry.FullPath)
 
@@ -310,6 +337,7 @@ func (f *Filer) ensureParentDirectoryEntry(ctx context.Context, entry *Entry, di
 func (f *Filer) UpdateEntry(ctx context.Context, oldEntry, entry *Entry) (err error) {
 	if oldEntry != nil {
 		entry.Attr.Crtime = oldEntry.Attr.Crtime
+		f.keepInheritedDirTtl(oldEntry, entry)
 		if oldEntry.IsDirectory() && !entry.IsDirectory() {
 			glog.Errorf("existing %s is a directory", oldEntry.FullPath)
 			return fmt.Errorf("existing %s is a directory", oldEntry.FullPath)
@@ -318,7 +346,18 @@ func (f *Filer) UpdateEntry(ctx context.Context, oldEntry, entry *Entry) (err er
 			glog.Errorf("existing %s is a file", oldEntry.FullPath)
 			return fmt.Errorf("existing %s is a file", oldEntry.FullPath)
 		}
+		if f.IsWormEnforced(oldEntry) {
+			return ErrWormEnforced
+		}
+		if err = f.checkObjectLockUpdate(oldEntry, entry); err != nil {
+			return err
+		}
+		oldUsage, newUsage := dirQuotaUsageOf(oldEntry), dirQuotaUsageOf(entry)
+		if err = f.checkDirQuota(ctx, entry.FullPath, DirQuotaUsage{Bytes: newUsage.Bytes - oldUsage.Bytes}); err != nil {
+			return err
+		}
 	}
+	f.maybeCommitWorm(oldEntry, entry)
 	return f.Store.UpdateEntry(ctx, entry)
 }
 
@@ -340,6 +379,9 @@ func (f *Filer) FindEntry(ctx context.Context, p util.FullPath) (entry *Entry, e
 	if string(p) == "/" {
 		return Root, nil
 	}
+	if err = f.waitForRename(ctx, p); err != nil {
+		return nil, err
+	}
 	entry, err = f.Store.FindEntry(ctx, p)
 	if entry != nil && entry.TtlSec > 0 {
 		if entry.Crtime.Add(time.Duration(entry.TtlSec) * time.Second).Before(time.Now()) {
@@ -375,5 +417,8 @@ func (f *Filer) doListDirectoryEntries(ctx context.Context, p util.FullPath, sta
 
 func (f *Filer) Shutdown() {
 	f.LocalMetaLogBuffer.ShutdownLogBuffer()
+	if f.MetadataIndex != nil {
+		f.MetadataIndex.Shutdown()
+	}
 	f.Store.Shutdown()
 }
@@ -1,7 +1,13 @@
 package filer
 
 import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 func (f *Filer) isBucket(entry *Entry) bool {
@@ -19,3 +25,110 @@ func (f *Filer) isBucket(entry *Entry) bool {
 	return true
 
 }
+
+// BucketOriginsKey is the extended attribute of a bucket, listing the other buckets whose collections may still
+// hold the chunks of its objects, after the bucket is renamed, or the objects are moved from the other buckets.
+// A bucket collection is only dropped when no bucket uses it, or else the objects are deleted one by one.
+const BucketOriginsKey = "Seaweed-Bucket-Origins"
+
+// bucketOriginsOf lists the buckets whose collections the objects in the bucket may use, including itself
+func bucketOriginsOf(entry *Entry) (origins []string) {
+	origins = append(origins, entry.Name())
+	if value := string(entry.Extended[BucketOriginsKey]); value != "" {
+		for _, origin := range strings.Split(value, ",") {
+			if !slices.Contains(origins, origin) {
+				origins = append(origins, origin)
+			}
+		}
+	}
+	return origins
+}
+
+// bucketCollectionNames are the collections of the bucket, named by the filer, and by the s3 gateway in a filer group
+func (f *Filer) bucketCollectionNames(bucket string) []string {
+	if f.MasterClient.FilerGroup != "" {
+		return []string{bucket, f.MasterClient.FilerGroup + "_" + bucket}
+	}
+	return []string{bucket}
+}
+
+// RecordBucketOrigins records the collections of the source bucket on the bucket the entry is moved into,
+// before moving the entry without copying its chunks. Renaming a bucket records its own collections on itself.
+func (f *Filer) RecordBucketOrigins(ctx context.Context, entry *Entry, newPath util.FullPath) error {
+	sourceBucket, targetBucket := f.DetectBucket(entry.FullPath), f.DetectBucket(newPath)
+	if sourceBucket == "" || targetBucket == "" || sourceBucket == targetBucket {
+		return nil
+	}
+	sourceBucketEntry := entry
+	if !f.isBucket(entry) {
+		var err error
+		if sourceBucketEntry, err = f.FindEntry(ctx, util.FullPath(f.DirBucketsPath).Child(sourceBucket)); err != nil {
+			return fmt.Errorf("find bucket %s: %v", sourceBucket, err)
+		}
+	}
+	// the renamed bucket entry is moved with its extended attributes
+	targetBucketEntry := entry
+	if !f.isBucket(entry) {
+		var err error
+		if targetBucketEntry, err = f.FindEntry(ctx, util.FullPath(f.DirBucketsPath).Child(targetBucket)); err != nil {
+			return fmt.Errorf("find bucket %s: %v", targetBucket, err)
+		}
+	}
+
+	origins := bucketOriginsOf(targetBucketEntry)[1:]
+	for _, origin := range bucketOriginsOf(sourceBucketEntry) {
+		if origin != targetBucket && !slices.Contains(origins, origin) {
+			origins = append(origins, origin)
+		}
+	}
+	if string(targetBucketEntry.Extended[BucketOriginsKey]) == strings.Join(origins, ",") {
+		return nil
+	}
+	oldTargetBucketEntry := targetBucketEntry.ShallowClone()
+	if targetBucketEntry.Extended == nil {
+		targetBucketEntry.Extended = make(map[string][]byte)
+	} else {
+		targetBucketEntry.Extended = maps.Clone(targetBucketEntry.Extended)
+	}
+	targetBucketEntry.Extended[BucketOriginsKey] = []byte(strings.Join(origins, ","))
+	if err := f.Store.UpdateEntry(ctx, targetBucketEntry); err != nil {
+		return fmt.Errorf("record origins of bucket %s: %v", targetBucketEntry.Name(), err)
+	}
+	f.NotifyUpdateEvent(ctx, oldTargetBucketEntry, targetBucketEntry, false, false, nil)
+	return nil
+}
+
+// bucketCollectionsToDrop lists the collections used only by the bucket, and tells whether the bucket shares
+// any collection with the other buckets, when its chunks must be deleted one by one instead
+func (f *Filer) bucketCollectionsToDrop(ctx context.Context, bucketEntry *Entry) (collections []string, isShared bool, err error) {
+	usedElsewhere := make(map[string]bool)
+	lastFileName := ""
+	for {
+		entries, hasMore, listErr := f.ListDirectoryEntries(ctx, util.FullPath(f.DirBucketsPath), lastFileName, false, PaginationSize, "", "", "")
+		if listErr != nil {
+			return nil, false, listErr
+		}
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			if entry.Name() == bucketEntry.Name() || !f.isBucket(entry) {
+				continue
+			}
+			for _, origin := range bucketOriginsOf(entry) {
+				usedElsewhere[origin] = true
+			}
+		}
+		if !hasMore {
+			break
+		}
+	}
+
+	origins := bucketOriginsOf(bucketEntry)
+	for _, origin := range origins {
+		if usedElsewhere[origin] {
+			isShared = true
+			continue
+		}
+		collections = append(collections, f.bucketCollectionNames(origin)...)
+	}
+	return collections, isShared, nil
+}
@@ -0,0 +1,25 @@
+package filer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketOriginsOf(t *testing.T) {
+	entry := &Entry{FullPath: "/buckets/b"}
+	assert.Equal(t, []string{"b"}, bucketOriginsOf(entry))
+
+	entry.Extended = map[string][]byte{BucketOriginsKey: []byte("a,b,c")}
+	assert.Equal(t, []string{"b", "a", "c"}, bucketOriginsOf(entry))
+}
+
+func TestCanRenameAcrossBuckets(t *testing.T) {
+	f := &Filer{DirBucketsPath: "/buckets", FilerConf: NewFilerConf()}
+
+	assert.NoError(t, f.CanRename("/buckets/a/dir", "/buckets/b/dir", "key"))
+	assert.NoError(t, f.CanRename("/buckets", "/buckets", "a"))
+	assert.Error(t, f.CanRename("/buckets/a", "/tmp", "key"))
+	assert.Error(t, f.CanRename("/tmp", "/buckets/b", "key"))
+	assert.Error(t, f.CanRename("/buckets", "/buckets/a", "a"))
+}
@@ -0,0 +1,156 @@
+package filer
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/cluster/lock_manager"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// Chunks shared by cloned entries, e.g., by copy_file_range or the s3 copy within a bucket, are marked with IsShared,
+// and counted in the filer store kv. The count is the number of entries referencing the chunk, and the hard links
+// of one file count as one entry. The kv is removed when only one entry is left, so the last entry deletes the chunk
+// as usual. The counts may leak references after failures, which only keeps the chunks, and "fs.chunk.references"
+// recounts them from the entries.
+// Each count is read and written under the cluster distributed lock of its kv key, so the filers sharing one store
+// do not lose each other's updates.
+
+const (
+	chunkReferencePrefix         = "chunk.ref."
+	chunkReferenceLockRetryDelay = 50 * time.Millisecond
+)
+
+// ChunkReferenceKey is the kv key of the reference count of the shared chunk
+func ChunkReferenceKey(fileId string) []byte {
+	return []byte(chunkReferencePrefix + fileId)
+}
+
+// DecodeChunkReferenceCount returns 0 if the kv value is missing, meaning the chunk is referenced by at most one entry
+func DecodeChunkReferenceCount(value []byte) uint32 {
+	if len(value) != 4 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(value)
+}
+
+// EncodeChunkReferenceCount is the kv value of the reference count
+func EncodeChunkReferenceCount(count uint32) []byte {
+	value := make([]byte, 4)
+	binary.BigEndian.PutUint32(value, count)
+	return value
+}
+
+// ReferenceChunks adds one reference to each data chunk, and marks them as shared.
+// The chunks should already be resolved from the chunk manifests.
+func (f *Filer) ReferenceChunks(ctx context.Context, dataChunks []*filer_pb.FileChunk) error {
+	for _, chunk := range dataChunks {
+		if err := f.addChunkReference(ctx, chunk); err != nil {
+			return err
+		}
+		chunk.IsShared = true
+	}
+	return nil
+}
+
+func (f *Filer) addChunkReference(ctx context.Context, chunk *filer_pb.FileChunk) error {
+	key := ChunkReferenceKey(chunk.GetFileIdString())
+	unlock := f.lockChunkReference(key)
+	defer unlock()
+
+	count, err := f.getChunkReferenceCount(ctx, key)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		count = 1
+	}
+	return f.setChunkReferenceCount(ctx, key, count+1)
+}
+
+// releaseChunkReference removes one reference of the chunk, and returns true if other entries still reference it
+func (f *Filer) releaseChunkReference(chunk *filer_pb.FileChunk) (isReferenced bool) {
+	if !chunk.IsShared || chunk.IsChunkManifest {
+		return false
+	}
+
+	ctx := context.Background()
+	key := ChunkReferenceKey(chunk.GetFileIdString())
+	unlock := f.lockChunkReference(key)
+	defer unlock()
+
+	count, err := f.getChunkReferenceCount(ctx, key)
+	if err != nil {
+		// keep the chunk if not sure
+		glog.Errorf("read reference count of chunk %s: %v", chunk.GetFileIdString(), err)
+		return true
+	}
+	if count <= 1 {
+		return false
+	}
+
+	count--
+	if count <= 1 {
+		err = f.Store.KvDelete(ctx, key)
+	} else {
+		err = f.setChunkReferenceCount(ctx, key, count)
+	}
+	if err != nil {
+		glog.Errorf("update reference count of chunk %s: %v", chunk.GetFileIdString(), err)
+	}
+	return true
+}
+
+func (f *Filer) getChunkReferenceCount(ctx context.Context, key []byte) (uint32, error) {
+	value, err := f.Store.KvGet(ctx, key)
+	if err == ErrKvNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return DecodeChunkReferenceCount(value), nil
+}
+
+func (f *Filer) setChunkReferenceCount(ctx context.Context, key []byte, count uint32) error {
+	return f.Store.KvPut(ctx, key, EncodeChunkReferenceCount(count))
+}
+
+// lockChunkReference locks the reference count of one chunk across the filers.
+// A filer without lock servers yet, i.e., before joining the cluster, only locks in process.
+func (f *Filer) lockChunkReference(key []byte) (unlock func()) {
+	f.chunkReferenceLock.Lock()
+	if len(f.Dlm.LockRing.GetSnapshot()) == 0 {
+		return f.chunkReferenceLock.Unlock
+	}
+
+	name, owner := string(key), string(f.Dlm.Host)
+	for {
+		expiredAtNs := time.Now().Add(lock_manager.LiveLockTTL).UnixNano()
+		_, renewToken, movedTo, err := f.Dlm.LockWithTimeout(name, expiredAtNs, "", owner)
+		if movedTo != f.Dlm.Host {
+			break
+		}
+		if err == nil {
+			return func() {
+				if _, unlockErr := f.Dlm.Unlock(name, renewToken); unlockErr != nil {
+					glog.Warningf("unlock %s: %v", name, unlockErr)
+				}
+				f.chunkReferenceLock.Unlock()
+			}
+		}
+		// locked by another filer
+		time.Sleep(chunkReferenceLockRetryDelay)
+	}
+
+	lock := cluster.NewLockClient(f.GrpcDialOption, f.Dlm.Host).NewShortLivedLock(name, owner)
+	return func() {
+		if err := lock.StopShortLivedLock(); err != nil {
+			glog.Warningf("unlock %s: %v", name, err)
+		}
+		f.chunkReferenceLock.Unlock()
+	}
+}
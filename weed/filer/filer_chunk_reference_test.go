@@ -0,0 +1,145 @@
+package filer
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster/lock_manager"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// kvOnlyStore keeps only the kv, which is all the reference counting uses
+type kvOnlyStore struct {
+	VirtualFilerStore
+	sync.Mutex
+	kv map[string][]byte
+}
+
+func (store *kvOnlyStore) KvPut(ctx context.Context, key []byte, value []byte) error {
+	store.Lock()
+	defer store.Unlock()
+	store.kv[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+func (store *kvOnlyStore) KvGet(ctx context.Context, key []byte) ([]byte, error) {
+	store.Lock()
+	defer store.Unlock()
+	value, found := store.kv[string(key)]
+	if !found {
+		return nil, ErrKvNotFound
+	}
+	return value, nil
+}
+
+func (store *kvOnlyStore) KvDelete(ctx context.Context, key []byte) error {
+	store.Lock()
+	defer store.Unlock()
+	delete(store.kv, string(key))
+	return nil
+}
+
+func newChunkReferenceTestFiler(host pb.ServerAddress) *Filer {
+	return &Filer{
+		Store:               &kvOnlyStore{kv: make(map[string][]byte)},
+		Dlm:                 lock_manager.NewDistributedLockManager(host),
+		FilerConf:           NewFilerConf(),
+		fileIdDeletionQueue: util.NewUnboundedQueue(),
+	}
+}
+
+func chunkReferenceCount(t *testing.T, f *Filer, fileId string) uint32 {
+	count, err := f.getChunkReferenceCount(context.Background(), ChunkReferenceKey(fileId))
+	assert.NoError(t, err)
+	return count
+}
+
+func deletedFileIds(f *Filer) (fileIds []string) {
+	f.fileIdDeletionQueue.Consume(func(ids []string) {
+		fileIds = append(fileIds, ids...)
+	})
+	return
+}
+
+func cloneChunks(chunks []*filer_pb.FileChunk) (clones []*filer_pb.FileChunk) {
+	for _, chunk := range chunks {
+		clones = append(clones, &filer_pb.FileChunk{FileId: chunk.FileId, Size: chunk.Size, IsShared: chunk.IsShared})
+	}
+	return
+}
+
+func TestChunkReferenceCloneOverwriteDelete(t *testing.T) {
+	f := newChunkReferenceTestFiler("localhost:8888")
+	ctx := context.Background()
+
+	source := []*filer_pb.FileChunk{{FileId: "1,01", Size: 10}, {FileId: "1,02", Offset: 10, Size: 10}}
+
+	// clone twice, the source and two targets reference the chunks
+	assert.NoError(t, f.ReferenceChunks(ctx, source))
+	assert.NoError(t, f.ReferenceChunks(ctx, source))
+	for _, chunk := range source {
+		assert.True(t, chunk.IsShared)
+		assert.Equal(t, uint32(3), chunkReferenceCount(t, f, chunk.FileId))
+	}
+	target1, target2 := cloneChunks(source), cloneChunks(source)
+
+	// overwriting one target releases its references, but keeps the chunks
+	f.deleteChunksIfNotNew(&Entry{FullPath: "/t1", Chunks: target1}, &Entry{FullPath: "/t1", Chunks: []*filer_pb.FileChunk{{FileId: "1,03", Size: 20}}})
+	assert.Empty(t, deletedFileIds(f))
+	for _, chunk := range source {
+		assert.Equal(t, uint32(2), chunkReferenceCount(t, f, chunk.FileId))
+	}
+
+	// deleting the other target leaves only the source, which drops the counts
+	f.DeleteChunks("/t2", target2)
+	assert.Empty(t, deletedFileIds(f))
+	for _, chunk := range source {
+		assert.Equal(t, uint32(0), chunkReferenceCount(t, f, chunk.FileId))
+	}
+
+	// the last entry deletes the chunks
+	f.DeleteChunks("/s", source)
+	assert.Equal(t, []string{"1,01", "1,02"}, deletedFileIds(f))
+}
+
+func TestChunkReferenceNotShared(t *testing.T) {
+	f := newChunkReferenceTestFiler("localhost:8888")
+
+	f.DeleteChunks("/s", []*filer_pb.FileChunk{{FileId: "1,01", Size: 10}})
+	assert.Equal(t, []string{"1,01"}, deletedFileIds(f))
+}
+
+func TestChunkReferenceConcurrentUpdates(t *testing.T) {
+	for _, servers := range [][]pb.ServerAddress{nil, {"localhost:8888"}} {
+		f := newChunkReferenceTestFiler("localhost:8888")
+		f.Dlm.LockRing.SetSnapshot(servers)
+		ctx := context.Background()
+
+		chunk := &filer_pb.FileChunk{FileId: "1,01", Size: 10}
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, f.ReferenceChunks(ctx, []*filer_pb.FileChunk{{FileId: chunk.FileId, Size: chunk.Size}}))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, uint32(21), chunkReferenceCount(t, f, chunk.FileId), "servers %v", servers)
+
+		chunk.IsShared = true
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.True(t, f.releaseChunkReference(chunk))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, uint32(11), chunkReferenceCount(t, f, chunk.FileId), "servers %v", servers)
+	}
+}
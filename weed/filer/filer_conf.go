@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/wdclient"
@@ -15,6 +16,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"github.com/viant/ptrie"
 	jsonpb "google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -151,6 +153,29 @@ func (fc *FilerConf) DeleteLocationConf(locationPrefix string) {
 	return
 }
 
+// MoveLocationConfs moves the rules under the old prefix to the new prefix, e.g., after a bucket is renamed,
+// and points the rules of the old collection to the new collection
+func (fc *FilerConf) MoveLocationConfs(oldPrefix, newPrefix, oldCollection, newCollection string) (moved bool) {
+	rules := ptrie.New[*filer_pb.FilerConf_PathConf]()
+	fc.rules.Walk(func(key []byte, value *filer_pb.FilerConf_PathConf) bool {
+		if strings.HasPrefix(string(key), oldPrefix) {
+			value = proto.Clone(value).(*filer_pb.FilerConf_PathConf)
+			value.LocationPrefix = newPrefix + strings.TrimPrefix(value.LocationPrefix, oldPrefix)
+			if value.Collection == oldCollection {
+				value.Collection = newCollection
+			}
+			key = []byte(value.LocationPrefix)
+			moved = true
+		} else {
+			key = bytes.Clone(key)
+		}
+		_ = rules.Put(key, value)
+		return true
+	})
+	fc.rules = rules
+	return moved
+}
+
 func (fc *FilerConf) MatchStorageRule(path string) (pathConf *filer_pb.FilerConf_PathConf) {
 	pathConf = &filer_pb.FilerConf_PathConf{}
 	fc.rules.MatchPrefix([]byte(path), func(key []byte, value *filer_pb.FilerConf_PathConf) bool {
@@ -177,6 +202,7 @@ func mergePathConf(a, b *filer_pb.FilerConf_PathConf) {
 	a.Replication = util.Nvl(b.Replication, a.Replication)
 	a.Ttl = util.Nvl(b.Ttl, a.Ttl)
 	a.DiskType = util.Nvl(b.DiskType, a.DiskType)
+	a.LatencyClass = util.Nvl(b.LatencyClass, a.LatencyClass)
 	a.Fsync = b.Fsync || a.Fsync
 	if b.VolumeGrowthCount > 0 {
 		a.VolumeGrowthCount = b.VolumeGrowthCount
@@ -196,6 +222,26 @@ func mergePathConf(a, b *filer_pb.FilerConf_PathConf) {
 	if b.WormGracePeriodSeconds > 0 {
 		a.WormGracePeriodSeconds = b.WormGracePeriodSeconds
 	}
+	a.ContentAddressed = b.ContentAddressed || a.ContentAddressed
+	if b.ContentAddressedRetentionSeconds > 0 {
+		a.ContentAddressedRetentionSeconds = b.ContentAddressedRetentionSeconds
+	}
+	if b.MaxVersions > 0 {
+		a.MaxVersions = b.MaxVersions
+	}
+	if b.VersionRetentionSeconds > 0 {
+		a.VersionRetentionSeconds = b.VersionRetentionSeconds
+	}
+	if b.TrashRetentionSeconds > 0 {
+		a.TrashRetentionSeconds = b.TrashRetentionSeconds
+	}
+	a.Compression = util.Nvl(b.Compression, a.Compression)
+	if len(b.CompressMimeTypes) > 0 {
+		a.CompressMimeTypes = b.CompressMimeTypes
+	}
+	if len(b.SkipCompressMimeTypes) > 0 {
+		a.SkipCompressMimeTypes = b.SkipCompressMimeTypes
+	}
 }
 
 func (fc *FilerConf) ToProto() *filer_pb.FilerConf {
@@ -47,3 +47,21 @@ func TestFilerConf(t *testing.T) {
 	assert.Equal(t, false, fc.MatchStorageRule("/buckets/other").ReadOnly)
 
 }
+
+func TestMoveLocationConfs(t *testing.T) {
+	fc := NewFilerConf()
+	fc.doLoadConf(&filer_pb.FilerConf{Locations: []*filer_pb.FilerConf_PathConf{
+		{LocationPrefix: "/buckets/a/", Collection: "a", Ttl: "7d"},
+		{LocationPrefix: "/buckets/a/logs/", Replication: "001"},
+		{LocationPrefix: "/buckets/ab/", Collection: "ab"},
+	}})
+
+	assert.True(t, fc.MoveLocationConfs("/buckets/a/", "/buckets/b/", "a", "b"))
+	assert.Equal(t, "b", fc.MatchStorageRule("/buckets/b/x").Collection)
+	assert.Equal(t, "7d", fc.MatchStorageRule("/buckets/b/x").Ttl)
+	assert.Equal(t, "001", fc.MatchStorageRule("/buckets/b/logs/x").Replication)
+	assert.Equal(t, "", fc.MatchStorageRule("/buckets/a/x").Collection)
+	assert.Equal(t, "ab", fc.MatchStorageRule("/buckets/ab/x").Collection)
+
+	assert.False(t, fc.MoveLocationConfs("/buckets/c/", "/buckets/d/", "c", "d"))
+}
@@ -0,0 +1,27 @@
+package filer
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// ContentAddressedName is the file name of the content in a content addressed location,
+// the hex encoded sha256 digest of the content.
+func ContentAddressedName(sha256Sum []byte) string {
+	return hex.EncodeToString(sha256Sum)
+}
+
+// IsContentAddressedDeletable checks whether the entry can be deleted under the rule.
+// Files in a content addressed location can only be deleted after the retention time,
+// and the directories can not be deleted since they may have files.
+func IsContentAddressedDeletable(rule *filer_pb.FilerConf_PathConf, entry *Entry, now time.Time) bool {
+	if !rule.ContentAddressed {
+		return true
+	}
+	if entry.IsDirectory() || rule.ContentAddressedRetentionSeconds == 0 {
+		return false
+	}
+	return now.Sub(entry.Crtime) >= time.Duration(rule.ContentAddressedRetentionSeconds)*time.Second
+}
@@ -0,0 +1,39 @@
+package filer
+
+import (
+	"crypto/sha256"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func TestContentAddressedName(t *testing.T) {
+	sum := sha256.Sum256([]byte("hello"))
+	if name := ContentAddressedName(sum[:]); name != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("unexpected name %s", name)
+	}
+}
+
+func TestIsContentAddressedDeletable(t *testing.T) {
+	now := time.Now()
+	file := &Entry{Attr: Attr{Crtime: now.Add(-time.Hour)}}
+	dir := &Entry{Attr: Attr{Crtime: now.Add(-time.Hour), Mode: os.ModeDir}}
+
+	if !IsContentAddressedDeletable(&filer_pb.FilerConf_PathConf{}, file, now) {
+		t.Errorf("files in other locations should be deletable")
+	}
+	if IsContentAddressedDeletable(&filer_pb.FilerConf_PathConf{ContentAddressed: true}, file, now) {
+		t.Errorf("files without retention should never be deletable")
+	}
+	if IsContentAddressedDeletable(&filer_pb.FilerConf_PathConf{ContentAddressed: true, ContentAddressedRetentionSeconds: 7200}, file, now) {
+		t.Errorf("files within retention should not be deletable")
+	}
+	if !IsContentAddressedDeletable(&filer_pb.FilerConf_PathConf{ContentAddressed: true, ContentAddressedRetentionSeconds: 3600}, file, now) {
+		t.Errorf("files past retention should be deletable")
+	}
+	if IsContentAddressedDeletable(&filer_pb.FilerConf_PathConf{ContentAddressed: true, ContentAddressedRetentionSeconds: 3600}, dir, now) {
+		t.Errorf("directories should not be deletable")
+	}
+}
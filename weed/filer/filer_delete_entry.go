@@ -29,7 +29,21 @@ func (f *Filer) DeleteEntryMetaAndData(ctx context.Context, p util.FullPath, isR
 	if ifNotModifiedAfter > 0 && entry.Attr.Mtime.Unix() > ifNotModifiedAfter {
 		return nil
 	}
-	isDeleteCollection := f.isBucket(entry)
+	isBucket := f.isBucket(entry)
+	isDeleteCollection := isBucket
+	var collectionsToDrop []string
+	if isBucket {
+		var isShared bool
+		if collectionsToDrop, isShared, err = f.bucketCollectionsToDrop(ctx, entry); err != nil {
+			return fmt.Errorf("check collections of bucket %s: %v", p, err)
+		}
+		// the chunks in the collections shared with the other buckets are deleted one by one
+		isDeleteCollection = !isShared
+	}
+	// the locked objects can be deleted as they are kept as the previous versions, unless the collection is dropped
+	if err = f.checkWormDeletable(ctx, entry, shouldDeleteChunks && !isDeleteCollection && !isFromOtherCluster); err != nil {
+		return err
+	}
 	if entry.IsDirectory() {
 		// delete the folder children, not including the folder itself
 		err = f.doBatchDeleteFolderMetaAndData(ctx, entry, isRecursive, ignoreRecursiveError, shouldDeleteChunks && !isDeleteCollection, isDeleteCollection, isFromOtherCluster, signatures, func(hardLinkIds []HardLinkId) error {
@@ -52,13 +66,18 @@ func (f *Filer) DeleteEntryMetaAndData(ctx context.Context, p util.FullPath, isR
 		return fmt.Errorf("delete file %s: %v", p, err)
 	}
 
-	if shouldDeleteChunks && !isDeleteCollection {
+	if shouldDeleteChunks && !isDeleteCollection && !f.MaybeSaveVersion(ctx, entry, nil, isFromOtherCluster) {
 		f.DeleteChunks(p, entry.GetChunks())
 	}
 
-	if isDeleteCollection {
-		collectionName := entry.Name()
-		f.DoDeleteCollection(collectionName)
+	if isBucket {
+		for _, collectionName := range collectionsToDrop {
+			f.DoDeleteCollection(collectionName)
+		}
+		// the chunks of the versions are gone with the collections, unless shared with the other buckets
+		if versionsErr := f.DeleteEntryMetaAndData(ctx, VersionsDirOf(p), true, true, shouldDeleteChunks && !isDeleteCollection, false, nil, 0); versionsErr != nil && versionsErr != filer_pb.ErrNotFound {
+			glog.Errorf("delete versions of %s: %v", p, versionsErr)
+		}
 	}
 
 	return nil
@@ -94,7 +113,7 @@ func (f *Filer) doBatchDeleteFolderMetaAndData(ctx context.Context, entry *Entry
 						// hard link chunk data are deleted separately
 						err = onHardLinkIdsFn([]HardLinkId{sub.HardLinkId})
 					} else {
-						if shouldDeleteChunks {
+						if shouldDeleteChunks && !f.MaybeSaveVersion(ctx, sub, nil, isFromOtherCluster) {
 							chunksToDelete = append(chunksToDelete, sub.GetChunks()...)
 						}
 					}
@@ -87,6 +87,9 @@ func (f *Filer) DeleteChunks(fullpath util.FullPath, chunks []*filer_pb.FileChun
 func (f *Filer) doDeleteChunks(chunks []*filer_pb.FileChunk) {
 	for _, chunk := range chunks {
 		if !chunk.IsChunkManifest {
+			if f.releaseChunkReference(chunk) {
+				continue
+			}
 			f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
 			continue
 		}
@@ -95,6 +98,9 @@ func (f *Filer) doDeleteChunks(chunks []*filer_pb.FileChunk) {
 			glog.V(0).Infof("failed to resolve manifest %s: %v", chunk.FileId, manifestResolveErr)
 		}
 		for _, dChunk := range dataChunks {
+			if f.releaseChunkReference(dChunk) {
+				continue
+			}
 			f.fileIdDeletionQueue.EnQueue(dChunk.GetFileIdString())
 		}
 		f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
@@ -103,6 +109,9 @@ func (f *Filer) doDeleteChunks(chunks []*filer_pb.FileChunk) {
 
 func (f *Filer) DeleteChunksNotRecursive(chunks []*filer_pb.FileChunk) {
 	for _, chunk := range chunks {
+		if f.releaseChunkReference(chunk) {
+			continue
+		}
 		f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
 	}
 }
@@ -0,0 +1,407 @@
+package filer
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// A directory quota limits the total size of the files, and the number of the files and directories, under a directory.
+// The limits are the extended attributes of the directory, set by "fs.quota". The usage of each quota directory is
+// counted in the filer store kv as the entries are changed, and the quota directories are listed in the kv, so the
+// writes do not need to look up the parent directories.
+//
+// The quotas are checked before the entries are created, updated, or moved into the quota directories. Concurrent
+// writes may go slightly over the limits. With the filer store shared by multiple filers, the usage may drift after
+// concurrent updates by the filers, or deleting a whole bucket by the store; "fs.quota -recount" fixes it.
+
+const (
+	ExtDirQuotaMaxBytesKey   = "quota.max_bytes"
+	ExtDirQuotaMaxEntriesKey = "quota.max_entries"
+
+	// DirQuotaListKey is the kv key of the quota directories, one per line
+	DirQuotaListKey     = "dir.quota.list"
+	dirQuotaUsagePrefix = "dir.quota.usage."
+)
+
+var ErrDirQuotaExceeded = errors.New("directory quota exceeded")
+
+// DirQuota is the limits of a quota directory, 0 for no limit
+type DirQuota struct {
+	MaxBytes   int64
+	MaxEntries int64
+}
+
+// DirQuotaUsage is the total size of the files, and the number of the files and directories, under a quota directory
+type DirQuotaUsage struct {
+	Bytes   int64
+	Entries int64
+}
+
+type dirQuotaSkipCheckKey struct{}
+
+// GetDirQuota reads the quota from the extended attributes of a directory
+func GetDirQuota(extended map[string][]byte) (quota DirQuota, found bool) {
+	if value, ok := extended[ExtDirQuotaMaxBytesKey]; ok {
+		quota.MaxBytes, _ = strconv.ParseInt(string(value), 10, 64)
+		found = true
+	}
+	if value, ok := extended[ExtDirQuotaMaxEntriesKey]; ok {
+		quota.MaxEntries, _ = strconv.ParseInt(string(value), 10, 64)
+		found = true
+	}
+	return
+}
+
+// SetDirQuota sets the quota in the extended attributes of a directory, and removes the limits of 0
+func SetDirQuota(extended map[string][]byte, quota DirQuota) {
+	setLimit := func(key string, limit int64) {
+		if limit > 0 {
+			extended[key] = []byte(strconv.FormatInt(limit, 10))
+		} else {
+			delete(extended, key)
+		}
+	}
+	setLimit(ExtDirQuotaMaxBytesKey, quota.MaxBytes)
+	setLimit(ExtDirQuotaMaxEntriesKey, quota.MaxEntries)
+}
+
+// DirQuotaUsageKey is the kv key of the usage of the quota directory
+func DirQuotaUsageKey(dir util.FullPath) []byte {
+	return []byte(dirQuotaUsagePrefix + string(dir))
+}
+
+func EncodeDirQuotaUsage(usage DirQuotaUsage) []byte {
+	value := make([]byte, 16)
+	binary.BigEndian.PutUint64(value[0:8], uint64(usage.Bytes))
+	binary.BigEndian.PutUint64(value[8:16], uint64(usage.Entries))
+	return value
+}
+
+// DecodeDirQuotaUsage returns zero usage if the kv value is missing
+func DecodeDirQuotaUsage(value []byte) DirQuotaUsage {
+	if len(value) != 16 {
+		return DirQuotaUsage{}
+	}
+	return DirQuotaUsage{
+		Bytes:   int64(binary.BigEndian.Uint64(value[0:8])),
+		Entries: int64(binary.BigEndian.Uint64(value[8:16])),
+	}
+}
+
+func DecodeDirQuotaList(value []byte) (dirs []util.FullPath) {
+	for _, line := range strings.Split(string(value), "\n") {
+		if line != "" {
+			dirs = append(dirs, util.FullPath(line))
+		}
+	}
+	return
+}
+
+// WithoutDirQuotaCheck skips the quota checks of the entries written with the context,
+// e.g., when moving the entries already checked as a whole. The usage is still counted.
+func WithoutDirQuotaCheck(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dirQuotaSkipCheckKey{}, true)
+}
+
+// dirQuotaUsageOf is what the entry adds to the usage of the quota directories above it
+func dirQuotaUsageOf(entry *Entry) DirQuotaUsage {
+	if entry == nil {
+		return DirQuotaUsage{}
+	}
+	if entry.IsDirectory() {
+		return DirQuotaUsage{Entries: 1}
+	}
+	return DirQuotaUsage{Bytes: int64(entry.Size()), Entries: 1}
+}
+
+// LoadDirQuotas reads the quota directories listed in the kv
+func (f *Filer) LoadDirQuotas() {
+	ctx := context.Background()
+	value, err := f.Store.KvGet(ctx, []byte(DirQuotaListKey))
+	if err != nil && err != ErrKvNotFound {
+		glog.Errorf("read directory quotas: %v", err)
+		return
+	}
+	f.dirQuotasLock.Lock()
+	defer f.dirQuotasLock.Unlock()
+	f.dirQuotas = make(map[util.FullPath]DirQuota)
+	for _, dir := range DecodeDirQuotaList(value) {
+		entry, findErr := f.FindEntry(ctx, dir)
+		if findErr != nil {
+			glog.Warningf("directory quota %s: %v", dir, findErr)
+			continue
+		}
+		if quota, found := GetDirQuota(entry.Extended); found {
+			f.dirQuotas[dir] = quota
+		}
+	}
+	if len(f.dirQuotas) > 0 {
+		glog.V(0).Infof("loaded %d directory quotas", len(f.dirQuotas))
+	}
+}
+
+// dirQuotasOf finds the quota directories above the path
+func (f *Filer) dirQuotasOf(p util.FullPath) map[util.FullPath]DirQuota {
+	f.dirQuotasLock.RLock()
+	defer f.dirQuotasLock.RUnlock()
+	if len(f.dirQuotas) == 0 {
+		return nil
+	}
+	quotas := make(map[util.FullPath]DirQuota)
+	for p != "/" && p != "" {
+		dir, _ := p.DirAndName()
+		p = util.FullPath(dir)
+		if quota, found := f.dirQuotas[p]; found {
+			quotas[p] = quota
+		}
+	}
+	return quotas
+}
+
+// checkDirQuota returns ErrDirQuotaExceeded if adding the usage to the path would go over the quota of any directory above it
+func (f *Filer) checkDirQuota(ctx context.Context, p util.FullPath, delta DirQuotaUsage) error {
+	if delta.Bytes <= 0 && delta.Entries <= 0 {
+		return nil
+	}
+	if skip, _ := ctx.Value(dirQuotaSkipCheckKey{}).(bool); skip {
+		return nil
+	}
+	return f.checkDirQuotas(ctx, f.dirQuotasOf(p), delta)
+}
+
+func (f *Filer) checkDirQuotas(ctx context.Context, quotas map[util.FullPath]DirQuota, delta DirQuotaUsage) error {
+	for dir, quota := range quotas {
+		usage, err := f.getDirQuotaUsage(ctx, dir)
+		if err != nil {
+			return fmt.Errorf("read usage of quota directory %s: %v", dir, err)
+		}
+		if quota.MaxBytes > 0 && delta.Bytes > 0 && usage.Bytes+delta.Bytes > quota.MaxBytes {
+			return fmt.Errorf("%w: %s would use %d bytes, over its limit of %d bytes", ErrDirQuotaExceeded, dir, usage.Bytes+delta.Bytes, quota.MaxBytes)
+		}
+		if quota.MaxEntries > 0 && delta.Entries > 0 && usage.Entries+delta.Entries > quota.MaxEntries {
+			return fmt.Errorf("%w: %s would have %d entries, over its limit of %d entries", ErrDirQuotaExceeded, dir, usage.Entries+delta.Entries, quota.MaxEntries)
+		}
+	}
+	return nil
+}
+
+// CheckDirQuotaMove checks the quota directories above the new path, but not above the old path,
+// for the entry and all entries under it, before the entry is moved
+func (f *Filer) CheckDirQuotaMove(ctx context.Context, entry *Entry, newPath util.FullPath) error {
+	quotas := f.dirQuotasOf(newPath)
+	for dir := range f.dirQuotasOf(entry.FullPath) {
+		delete(quotas, dir)
+	}
+	if len(quotas) == 0 {
+		return nil
+	}
+	delta := dirQuotaUsageOf(entry)
+	if entry.IsDirectory() {
+		usage, err := f.countDirQuotaUsage(ctx, entry.FullPath)
+		if err != nil {
+			return fmt.Errorf("count usage of %s: %v", entry.FullPath, err)
+		}
+		delta.Bytes += usage.Bytes
+		delta.Entries += usage.Entries
+	}
+	return f.checkDirQuotas(ctx, quotas, delta)
+}
+
+// onDirQuotaEntryChange counts the usage of the changed entry, and registers the changed quota directories
+func (f *Filer) onDirQuotaEntryChange(oldEntry, newEntry *Entry) {
+	f.updateDirQuotaUsage(oldEntry, newEntry)
+	f.maybeUpdateDirQuotas(oldEntry, newEntry)
+}
+
+// updateDirQuotaUsage moves the usage of the old entry from the quota directories above it, to those above the new entry
+func (f *Filer) updateDirQuotaUsage(oldEntry, newEntry *Entry) {
+	deltas := make(map[util.FullPath]DirQuotaUsage)
+	if oldEntry != nil {
+		usage := dirQuotaUsageOf(oldEntry)
+		for dir := range f.dirQuotasOf(oldEntry.FullPath) {
+			deltas[dir] = DirQuotaUsage{Bytes: -usage.Bytes, Entries: -usage.Entries}
+		}
+	}
+	if newEntry != nil {
+		usage := dirQuotaUsageOf(newEntry)
+		for dir := range f.dirQuotasOf(newEntry.FullPath) {
+			delta := deltas[dir]
+			deltas[dir] = DirQuotaUsage{Bytes: delta.Bytes + usage.Bytes, Entries: delta.Entries + usage.Entries}
+		}
+	}
+	if len(deltas) == 0 {
+		return
+	}
+
+	f.dirQuotaUsageLock.Lock()
+	defer f.dirQuotaUsageLock.Unlock()
+	ctx := context.Background()
+	for dir, delta := range deltas {
+		if delta.Bytes == 0 && delta.Entries == 0 {
+			continue
+		}
+		usage, err := f.getDirQuotaUsage(ctx, dir)
+		if err != nil {
+			glog.Errorf("read usage of quota directory %s: %v", dir, err)
+			continue
+		}
+		usage.Bytes = max(usage.Bytes+delta.Bytes, 0)
+		usage.Entries = max(usage.Entries+delta.Entries, 0)
+		if err = f.Store.KvPut(ctx, DirQuotaUsageKey(dir), EncodeDirQuotaUsage(usage)); err != nil {
+			glog.Errorf("update usage of quota directory %s: %v", dir, err)
+		}
+	}
+}
+
+// maybeUpdateDirQuotas registers the directories with quotas, and forgets those deleted, moved away, or without quotas
+func (f *Filer) maybeUpdateDirQuotas(oldEntry, newEntry *Entry) {
+	if oldEntry != nil && oldEntry.IsDirectory() {
+		if _, hadQuota := GetDirQuota(oldEntry.Extended); hadQuota {
+			removed := newEntry == nil || newEntry.FullPath != oldEntry.FullPath
+			if !removed {
+				_, hasQuota := GetDirQuota(newEntry.Extended)
+				removed = !hasQuota
+			}
+			if removed {
+				f.removeDirQuota(oldEntry.FullPath)
+			}
+		}
+	}
+	if newEntry != nil && newEntry.IsDirectory() {
+		if quota, hasQuota := GetDirQuota(newEntry.Extended); hasQuota {
+			f.addDirQuota(newEntry.FullPath, quota)
+		}
+	}
+}
+
+func (f *Filer) addDirQuota(dir util.FullPath, quota DirQuota) {
+	f.dirQuotasLock.Lock()
+	defer f.dirQuotasLock.Unlock()
+	if f.dirQuotas == nil {
+		f.dirQuotas = make(map[util.FullPath]DirQuota)
+	}
+	_, existing := f.dirQuotas[dir]
+	f.dirQuotas[dir] = quota
+	if existing {
+		return
+	}
+	glog.V(0).Infof("directory quota %s: %d bytes, %d entries", dir, quota.MaxBytes, quota.MaxEntries)
+	f.saveDirQuotaList()
+	// the directory may already have entries
+	go func() {
+		if err := f.recountDirQuotaUsage(context.Background(), dir); err != nil {
+			glog.Errorf("count usage of quota directory %s: %v", dir, err)
+		}
+	}()
+}
+
+func (f *Filer) removeDirQuota(dir util.FullPath) {
+	f.dirQuotasLock.Lock()
+	defer f.dirQuotasLock.Unlock()
+	if _, existing := f.dirQuotas[dir]; !existing {
+		return
+	}
+	delete(f.dirQuotas, dir)
+	glog.V(0).Infof("remove directory quota %s", dir)
+	f.saveDirQuotaList()
+	if err := f.Store.KvDelete(context.Background(), DirQuotaUsageKey(dir)); err != nil {
+		glog.Errorf("delete usage of quota directory %s: %v", dir, err)
+	}
+}
+
+// saveDirQuotaList should be called with the dirQuotasLock
+func (f *Filer) saveDirQuotaList() {
+	var dirs []string
+	for dir := range f.dirQuotas {
+		dirs = append(dirs, string(dir))
+	}
+	sort.Strings(dirs)
+	if err := f.Store.KvPut(context.Background(), []byte(DirQuotaListKey), []byte(strings.Join(dirs, "\n"))); err != nil {
+		glog.Errorf("save directory quotas: %v", err)
+	}
+}
+
+func (f *Filer) getDirQuotaUsage(ctx context.Context, dir util.FullPath) (DirQuotaUsage, error) {
+	value, err := f.Store.KvGet(ctx, DirQuotaUsageKey(dir))
+	if err == ErrKvNotFound {
+		return DirQuotaUsage{}, nil
+	}
+	if err != nil {
+		return DirQuotaUsage{}, err
+	}
+	return DecodeDirQuotaUsage(value), nil
+}
+
+func (f *Filer) recountDirQuotaUsage(ctx context.Context, dir util.FullPath) error {
+	usage, err := f.countDirQuotaUsage(ctx, dir)
+	if err != nil {
+		return err
+	}
+	f.dirQuotaUsageLock.Lock()
+	defer f.dirQuotaUsageLock.Unlock()
+	return f.Store.KvPut(ctx, DirQuotaUsageKey(dir), EncodeDirQuotaUsage(usage))
+}
+
+// countDirQuotaUsage counts all entries under the directory
+func (f *Filer) countDirQuotaUsage(ctx context.Context, dir util.FullPath) (usage DirQuotaUsage, err error) {
+	lastFileName := ""
+	for {
+		entries, hasMore, listErr := f.ListDirectoryEntries(ctx, dir, lastFileName, false, PaginationSize, "", "", "")
+		if listErr != nil {
+			return usage, listErr
+		}
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			entryUsage := dirQuotaUsageOf(entry)
+			usage.Bytes += entryUsage.Bytes
+			usage.Entries += entryUsage.Entries
+			if entry.IsDirectory() {
+				subUsage, subErr := f.countDirQuotaUsage(ctx, entry.FullPath)
+				if subErr != nil {
+					return usage, subErr
+				}
+				usage.Bytes += subUsage.Bytes
+				usage.Entries += subUsage.Entries
+			}
+		}
+		if !hasMore {
+			return usage, nil
+		}
+	}
+}
+
+// onDirQuotaMetadataChange follows the quota directories changed by the other filers
+func (f *Filer) onDirQuotaMetadataChange(event *filer_pb.SubscribeMetadataResponse) {
+	oldEntry, newEntry := entriesOfEvent(event)
+	f.maybeUpdateDirQuotas(oldEntry, newEntry)
+}
+
+// onDirQuotaReplay counts the usage of the change replayed from a filer with a different filer store
+func (f *Filer) onDirQuotaReplay(event *filer_pb.SubscribeMetadataResponse) {
+	oldEntry, newEntry := entriesOfEvent(event)
+	f.updateDirQuotaUsage(oldEntry, newEntry)
+}
+
+func entriesOfEvent(event *filer_pb.SubscribeMetadataResponse) (oldEntry, newEntry *Entry) {
+	message := event.EventNotification
+	if message.OldEntry != nil {
+		oldEntry = FromPbEntry(event.Directory, message.OldEntry)
+	}
+	if message.NewEntry != nil {
+		dir := event.Directory
+		if message.NewParentPath != "" {
+			dir = message.NewParentPath
+		}
+		newEntry = FromPbEntry(dir, message.NewEntry)
+	}
+	return
+}
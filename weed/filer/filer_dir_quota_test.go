@@ -0,0 +1,89 @@
+package filer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestDirQuotaExtended(t *testing.T) {
+	extended := make(map[string][]byte)
+	if _, found := GetDirQuota(extended); found {
+		t.Errorf("unexpected quota without the extended attributes")
+	}
+
+	SetDirQuota(extended, DirQuota{MaxBytes: 1 << 30, MaxEntries: 1000})
+	if quota, found := GetDirQuota(extended); !found || quota.MaxBytes != 1<<30 || quota.MaxEntries != 1000 {
+		t.Errorf("unexpected quota %+v, found %v", quota, found)
+	}
+
+	SetDirQuota(extended, DirQuota{MaxEntries: 10})
+	if quota, found := GetDirQuota(extended); !found || quota.MaxBytes != 0 || quota.MaxEntries != 10 {
+		t.Errorf("unexpected quota %+v, found %v", quota, found)
+	}
+
+	SetDirQuota(extended, DirQuota{})
+	if len(extended) != 0 {
+		t.Errorf("the cleared quota should remove the extended attributes: %v", extended)
+	}
+}
+
+func TestDirQuotaUsageCodec(t *testing.T) {
+	usage := DirQuotaUsage{Bytes: 123456789, Entries: 42}
+	if decoded := DecodeDirQuotaUsage(EncodeDirQuotaUsage(usage)); decoded != usage {
+		t.Errorf("decoded %+v, expected %+v", decoded, usage)
+	}
+	if decoded := DecodeDirQuotaUsage(nil); decoded != (DirQuotaUsage{}) {
+		t.Errorf("missing usage should be zero: %+v", decoded)
+	}
+
+	dirs := DecodeDirQuotaList([]byte("/a\n/a/b\n"))
+	if len(dirs) != 2 || dirs[0] != "/a" || dirs[1] != "/a/b" {
+		t.Errorf("unexpected quota directories %v", dirs)
+	}
+}
+
+func TestDirQuotasOf(t *testing.T) {
+	f := &Filer{
+		dirQuotas: map[util.FullPath]DirQuota{
+			"/":         {MaxEntries: 1},
+			"/data":     {MaxBytes: 100},
+			"/data/x/y": {MaxEntries: 10},
+		},
+	}
+
+	quotas := f.dirQuotasOf("/data/x/y/file")
+	if len(quotas) != 3 {
+		t.Errorf("expected 3 quota directories above, got %v", quotas)
+	}
+
+	// a quota directory does not count itself
+	quotas = f.dirQuotasOf("/data/x/y")
+	if _, found := quotas["/data/x/y"]; found || len(quotas) != 2 {
+		t.Errorf("unexpected quota directories %v", quotas)
+	}
+
+	quotas = f.dirQuotasOf("/database/file")
+	if _, found := quotas["/data"]; found || len(quotas) != 1 {
+		t.Errorf("unexpected quota directories %v", quotas)
+	}
+
+	if quotas = f.dirQuotasOf("/"); len(quotas) != 0 {
+		t.Errorf("the root has no quota directories above: %v", quotas)
+	}
+}
+
+func TestDirQuotaUsageOf(t *testing.T) {
+	file := &Entry{FullPath: "/data/file", Attr: Attr{FileSize: 1024}}
+	if usage := dirQuotaUsageOf(file); usage != (DirQuotaUsage{Bytes: 1024, Entries: 1}) {
+		t.Errorf("unexpected file usage %+v", usage)
+	}
+	dir := &Entry{FullPath: "/data/dir", Attr: Attr{Mode: os.ModeDir | 0755}}
+	if usage := dirQuotaUsageOf(dir); usage != (DirQuotaUsage{Entries: 1}) {
+		t.Errorf("unexpected directory usage %+v", usage)
+	}
+	if usage := dirQuotaUsageOf(nil); usage != (DirQuotaUsage{}) {
+		t.Errorf("unexpected usage of no entry %+v", usage)
+	}
+}
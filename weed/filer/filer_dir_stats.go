@@ -0,0 +1,291 @@
+package filer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The directory statistics are the total size of the files, and the numbers of the files and directories, under a
+// directory, so "du" on a huge tree does not need to traverse it. They are kept in the filer store kv for the
+// directories up to a configured depth, and updated as the entries are changed, so each change updates at most
+// depth+1 directories. The deeper directories are counted by traversing them when asked.
+//
+// Concurrent changes by multiple filers sharing one filer store, or deleting a whole bucket by the store, may make the
+// statistics drift. They are recounted periodically by one of the filers, or on demand.
+
+const (
+	dirStatsPrefix = "dir.stats."
+	// dirStatsReconciledKey is the kv key of when and with which depth the statistics were last recounted
+	dirStatsReconciledKey = "dir.stats.reconciled"
+
+	dirStatsCheckInterval = 10 * time.Minute
+)
+
+// DirStatsOption controls which directories have the cached statistics
+type DirStatsOption struct {
+	Depth             int           // the directories up to this depth have the cached statistics, "/" is 0, 0 to disable
+	ReconcileInterval time.Duration // how often the cached statistics are recounted, 0 to disable
+}
+
+// DirStats is the total size of the files, and the numbers of the files and directories, under a directory
+type DirStats struct {
+	Bytes       int64
+	Files       int64
+	Directories int64
+}
+
+func (s *DirStats) add(other DirStats, sign int64) {
+	s.Bytes += sign * other.Bytes
+	s.Files += sign * other.Files
+	s.Directories += sign * other.Directories
+}
+
+// DirStatsKey is the kv key of the statistics of the directory
+func DirStatsKey(dir util.FullPath) []byte {
+	return []byte(dirStatsPrefix + string(dir))
+}
+
+func EncodeDirStats(stats DirStats) []byte {
+	value := make([]byte, 24)
+	binary.BigEndian.PutUint64(value[0:8], uint64(stats.Bytes))
+	binary.BigEndian.PutUint64(value[8:16], uint64(stats.Files))
+	binary.BigEndian.PutUint64(value[16:24], uint64(stats.Directories))
+	return value
+}
+
+// DecodeDirStats returns zero statistics if the kv value is missing
+func DecodeDirStats(value []byte) DirStats {
+	if len(value) != 24 {
+		return DirStats{}
+	}
+	return DirStats{
+		Bytes:       int64(binary.BigEndian.Uint64(value[0:8])),
+		Files:       int64(binary.BigEndian.Uint64(value[8:16])),
+		Directories: int64(binary.BigEndian.Uint64(value[16:24])),
+	}
+}
+
+// dirStatsOf is what the entry adds to the statistics of the directories above it
+func dirStatsOf(entry *Entry) DirStats {
+	if entry == nil {
+		return DirStats{}
+	}
+	if entry.IsDirectory() {
+		return DirStats{Directories: 1}
+	}
+	return DirStats{Bytes: int64(entry.Size()), Files: 1}
+}
+
+// dirDepth is the depth of the directory, with "/" at 0
+func dirDepth(dir util.FullPath) int {
+	if dir == "/" || dir == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(string(dir), "/"), "/")
+}
+
+// IsDirStatsCached checks whether the directory has the cached statistics
+func (f *Filer) IsDirStatsCached(dir util.FullPath) bool {
+	return f.DirStats.Depth > 0 && dirDepth(dir) <= f.DirStats.Depth && !isDirStatsExcluded(dir)
+}
+
+// the metadata logs are not notified as entry changes, so they are not counted
+func isDirStatsExcluded(p util.FullPath) bool {
+	return p == SystemLogDir || strings.HasPrefix(string(p), SystemLogDir+"/")
+}
+
+// dirStatsDirsOf lists the directories with the cached statistics above the path
+func (f *Filer) dirStatsDirsOf(p util.FullPath) (dirs []util.FullPath) {
+	if f.DirStats.Depth <= 0 || isDirStatsExcluded(p) {
+		return nil
+	}
+	for p != "/" && p != "" {
+		dir, _ := p.DirAndName()
+		p = util.FullPath(dir)
+		if dirDepth(p) <= f.DirStats.Depth {
+			dirs = append(dirs, p)
+		}
+	}
+	return
+}
+
+// onDirStatsEntryChange moves the statistics of the old entry from the directories above it, to those above the new entry
+func (f *Filer) onDirStatsEntryChange(oldEntry, newEntry *Entry) {
+	if f.DirStats.Depth <= 0 {
+		return
+	}
+	deltas := make(map[util.FullPath]DirStats)
+	if oldEntry != nil {
+		stats := dirStatsOf(oldEntry)
+		for _, dir := range f.dirStatsDirsOf(oldEntry.FullPath) {
+			delta := deltas[dir]
+			delta.add(stats, -1)
+			deltas[dir] = delta
+		}
+	}
+	if newEntry != nil {
+		stats := dirStatsOf(newEntry)
+		for _, dir := range f.dirStatsDirsOf(newEntry.FullPath) {
+			delta := deltas[dir]
+			delta.add(stats, 1)
+			deltas[dir] = delta
+		}
+	}
+
+	f.dirStatsLock.Lock()
+	defer f.dirStatsLock.Unlock()
+	ctx := context.Background()
+
+	// a deleted or moved directory has no statistics. Its entries are usually already removed one by one,
+	// but when a whole bucket is dropped by the store, what is left is removed from the directories above it.
+	if oldEntry != nil && oldEntry.IsDirectory() && (newEntry == nil || newEntry.FullPath != oldEntry.FullPath) && f.IsDirStatsCached(oldEntry.FullPath) {
+		if left, err := f.getCachedDirStats(ctx, oldEntry.FullPath); err == nil && left != (DirStats{}) {
+			for _, dir := range f.dirStatsDirsOf(oldEntry.FullPath) {
+				delta := deltas[dir]
+				delta.add(left, -1)
+				deltas[dir] = delta
+			}
+		}
+		if err := f.Store.KvDelete(ctx, DirStatsKey(oldEntry.FullPath)); err != nil {
+			glog.Errorf("delete statistics of directory %s: %v", oldEntry.FullPath, err)
+		}
+	}
+	// a created directory starts from none, even if an earlier directory of the same path was left with statistics
+	if newEntry != nil && newEntry.IsDirectory() && (oldEntry == nil || newEntry.FullPath != oldEntry.FullPath) && f.IsDirStatsCached(newEntry.FullPath) {
+		if err := f.Store.KvDelete(ctx, DirStatsKey(newEntry.FullPath)); err != nil {
+			glog.Errorf("reset statistics of directory %s: %v", newEntry.FullPath, err)
+		}
+	}
+
+	for dir, delta := range deltas {
+		if delta == (DirStats{}) {
+			continue
+		}
+		stats, err := f.getCachedDirStats(ctx, dir)
+		if err != nil {
+			glog.Errorf("read statistics of directory %s: %v", dir, err)
+			continue
+		}
+		stats.add(delta, 1)
+		stats.Bytes, stats.Files, stats.Directories = max(stats.Bytes, 0), max(stats.Files, 0), max(stats.Directories, 0)
+		if err = f.Store.KvPut(ctx, DirStatsKey(dir), EncodeDirStats(stats)); err != nil {
+			glog.Errorf("update statistics of directory %s: %v", dir, err)
+		}
+	}
+}
+
+func (f *Filer) getCachedDirStats(ctx context.Context, dir util.FullPath) (DirStats, error) {
+	value, err := f.Store.KvGet(ctx, DirStatsKey(dir))
+	if err == ErrKvNotFound {
+		return DirStats{}, nil
+	}
+	if err != nil {
+		return DirStats{}, err
+	}
+	return DecodeDirStats(value), nil
+}
+
+// GetDirStats returns the statistics of the directory, from the cache if the directory has the cached statistics,
+// or else by traversing it. With recount, the directory is always traversed, and its cached statistics are recounted.
+func (f *Filer) GetDirStats(ctx context.Context, dir util.FullPath, recount bool) (stats DirStats, isCached bool, err error) {
+	if f.IsDirStatsCached(dir) && !recount {
+		stats, err = f.getCachedDirStats(ctx, dir)
+		return stats, true, err
+	}
+	stats, err = f.countDirStats(ctx, dir, f.IsDirStatsCached(dir))
+	return stats, false, err
+}
+
+// countDirStats traverses the directory, and saves the statistics of the directories with the cached statistics if asked
+func (f *Filer) countDirStats(ctx context.Context, dir util.FullPath, save bool) (stats DirStats, err error) {
+	lastFileName := ""
+	for {
+		entries, hasMore, listErr := f.ListDirectoryEntries(ctx, dir, lastFileName, false, PaginationSize, "", "", "")
+		if listErr != nil {
+			return stats, listErr
+		}
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			if isDirStatsExcluded(entry.FullPath) {
+				continue
+			}
+			stats.add(dirStatsOf(entry), 1)
+			if entry.IsDirectory() {
+				subStats, subErr := f.countDirStats(ctx, entry.FullPath, save)
+				if subErr != nil {
+					return stats, subErr
+				}
+				stats.add(subStats, 1)
+			}
+		}
+		if !hasMore {
+			break
+		}
+	}
+	if save && f.IsDirStatsCached(dir) {
+		f.dirStatsLock.Lock()
+		defer f.dirStatsLock.Unlock()
+		if err = f.Store.KvPut(ctx, DirStatsKey(dir), EncodeDirStats(stats)); err != nil {
+			return stats, fmt.Errorf("save statistics of directory %s: %v", dir, err)
+		}
+	}
+	return stats, nil
+}
+
+// LoopReconcileDirStats recounts the cached statistics when they are first enabled, when the depth is changed,
+// and every reconcile interval. The filers sharing one filer store take turns by the recorded recount time.
+func (f *Filer) LoopReconcileDirStats() {
+	if f.DirStats.Depth <= 0 {
+		return
+	}
+	for {
+		if err := f.maybeReconcileDirStats(context.Background(), time.Now()); err != nil {
+			glog.Errorf("recount directory statistics: %v", err)
+		}
+		time.Sleep(dirStatsCheckInterval)
+	}
+}
+
+func (f *Filer) maybeReconcileDirStats(ctx context.Context, now time.Time) error {
+	value, err := f.Store.KvGet(ctx, []byte(dirStatsReconciledKey))
+	if err != nil && err != ErrKvNotFound {
+		return err
+	}
+	reconciledAt, depth := decodeDirStatsReconciled(value)
+	if depth == f.DirStats.Depth && (f.DirStats.ReconcileInterval <= 0 || now.Sub(reconciledAt) < f.DirStats.ReconcileInterval) {
+		return nil
+	}
+	// record the recount first, so the other filers do not start another one
+	if err = f.Store.KvPut(ctx, []byte(dirStatsReconciledKey), encodeDirStatsReconciled(now, f.DirStats.Depth)); err != nil {
+		return err
+	}
+	glog.V(0).Infof("recount directory statistics up to depth %d", f.DirStats.Depth)
+	stats, err := f.countDirStats(ctx, "/", true)
+	if err != nil {
+		return err
+	}
+	glog.V(0).Infof("recounted directory statistics in %v: %d bytes, %d files, %d directories",
+		time.Since(now), stats.Bytes, stats.Files, stats.Directories)
+	return nil
+}
+
+func encodeDirStatsReconciled(reconciledAt time.Time, depth int) []byte {
+	return []byte(fmt.Sprintf("%d,%d", reconciledAt.UnixNano(), depth))
+}
+
+func decodeDirStatsReconciled(value []byte) (reconciledAt time.Time, depth int) {
+	tsNs, depthString, found := strings.Cut(string(value), ",")
+	if !found {
+		return
+	}
+	ts, _ := strconv.ParseInt(tsNs, 10, 64)
+	depth, _ = strconv.Atoi(depthString)
+	return time.Unix(0, ts), depth
+}
@@ -0,0 +1,50 @@
+package filer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestDirStatsCodec(t *testing.T) {
+	stats := DirStats{Bytes: 123456789, Files: 42, Directories: 7}
+	if decoded := DecodeDirStats(EncodeDirStats(stats)); decoded != stats {
+		t.Errorf("decoded %+v, expected %+v", decoded, stats)
+	}
+	if decoded := DecodeDirStats(nil); decoded != (DirStats{}) {
+		t.Errorf("missing statistics should be zero: %+v", decoded)
+	}
+
+	now := time.Unix(0, 1700000000123456789)
+	if reconciledAt, depth := decodeDirStatsReconciled(encodeDirStatsReconciled(now, 3)); !reconciledAt.Equal(now) || depth != 3 {
+		t.Errorf("decoded %v depth %d", reconciledAt, depth)
+	}
+	if reconciledAt, depth := decodeDirStatsReconciled(nil); !reconciledAt.IsZero() || depth != 0 {
+		t.Errorf("missing recount should be zero: %v depth %d", reconciledAt, depth)
+	}
+}
+
+func TestDirStatsDirsOf(t *testing.T) {
+	f := &Filer{DirStats: DirStatsOption{Depth: 2}}
+
+	dirs := f.dirStatsDirsOf("/buckets/b1/x/y/file")
+	expected := []util.FullPath{"/buckets/b1", "/buckets", "/"}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("expected %v, got %v", expected, dirs)
+	}
+	if dirs := f.dirStatsDirsOf("/file"); !reflect.DeepEqual(dirs, []util.FullPath{"/"}) {
+		t.Errorf("expected only /, got %v", dirs)
+	}
+	if dirs := f.dirStatsDirsOf(SystemLogDir + "/2024-01-01/00-00.00000000"); len(dirs) != 0 {
+		t.Errorf("metadata logs should not be counted: %v", dirs)
+	}
+
+	if !f.IsDirStatsCached("/") || !f.IsDirStatsCached("/buckets/b1") || f.IsDirStatsCached("/buckets/b1/x") {
+		t.Errorf("unexpected cached directories for depth 2")
+	}
+	if (&Filer{}).IsDirStatsCached("/") {
+		t.Errorf("directory statistics should be disabled by default")
+	}
+}
@@ -0,0 +1,272 @@
+package filer
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// A directory ttl is inherited by the files created under the directory, including those in the sub directories,
+// unless the writes set their own ttl, or filer.conf sets one for the location. The nearest directory ttl wins.
+// The ttl is an extended attribute of the directory, set by "fs.ttl", and the ttl directories are listed in the
+// filer store kv, so the writes do not need to look up the parent directories.
+//
+// The files written before the ttl is set keep living, unless the ttl is retroactive. The ttl directories are walked
+// periodically, each by one of the filers, to remove the expired files, and with a retroactive ttl, the files
+// without a ttl older than it, together with their chunks.
+
+const (
+	ExtDirTtlKey            = "dir.ttl"
+	ExtDirTtlRetroactiveKey = "dir.ttl.retroactive"
+
+	// DirTtlListKey is the kv key of the ttl directories, one per line
+	DirTtlListKey = "dir.ttl.list"
+
+	dirTtlExpireInterval = time.Hour
+)
+
+// DirTtl is the ttl inherited under a directory
+type DirTtl struct {
+	Ttl         string
+	Seconds     int32
+	Retroactive bool
+}
+
+// GetDirTtl reads the ttl from the extended attributes of a directory
+func GetDirTtl(extended map[string][]byte) (dirTtl DirTtl, found bool) {
+	value, ok := extended[ExtDirTtlKey]
+	if !ok {
+		return
+	}
+	ttl, err := needle.ReadTTL(string(value))
+	if err != nil || ttl.Minutes() == 0 {
+		return
+	}
+	_, retroactive := extended[ExtDirTtlRetroactiveKey]
+	return DirTtl{
+		Ttl:         ttl.String(),
+		Seconds:     int32(ttl.Minutes()) * 60,
+		Retroactive: retroactive,
+	}, true
+}
+
+// SetDirTtl sets the ttl in the extended attributes of a directory, or removes it if the ttl is empty
+func SetDirTtl(extended map[string][]byte, ttl string, retroactive bool) {
+	delete(extended, ExtDirTtlKey)
+	delete(extended, ExtDirTtlRetroactiveKey)
+	if ttl == "" {
+		return
+	}
+	extended[ExtDirTtlKey] = []byte(ttl)
+	if retroactive {
+		extended[ExtDirTtlRetroactiveKey] = []byte("true")
+	}
+}
+
+func DecodeDirTtlList(value []byte) (dirs []util.FullPath) {
+	return DecodeDirQuotaList(value)
+}
+
+// LoadDirTtls reads the ttl directories listed in the kv
+func (f *Filer) LoadDirTtls() {
+	ctx := context.Background()
+	value, err := f.Store.KvGet(ctx, []byte(DirTtlListKey))
+	if err != nil && err != ErrKvNotFound {
+		glog.Errorf("read directory ttls: %v", err)
+		return
+	}
+	f.dirTtlsLock.Lock()
+	defer f.dirTtlsLock.Unlock()
+	f.dirTtls = make(map[util.FullPath]DirTtl)
+	for _, dir := range DecodeDirTtlList(value) {
+		entry, findErr := f.FindEntry(ctx, dir)
+		if findErr != nil {
+			glog.Warningf("directory ttl %s: %v", dir, findErr)
+			continue
+		}
+		if dirTtl, found := GetDirTtl(entry.Extended); found {
+			f.dirTtls[dir] = dirTtl
+		}
+	}
+	if len(f.dirTtls) > 0 {
+		glog.V(0).Infof("loaded %d directory ttls", len(f.dirTtls))
+	}
+}
+
+// InheritedTtlSec is the ttl of the nearest ttl directory above the path, 0 if none
+func (f *Filer) InheritedTtlSec(p util.FullPath) int32 {
+	f.dirTtlsLock.RLock()
+	defer f.dirTtlsLock.RUnlock()
+	if len(f.dirTtls) == 0 {
+		return 0
+	}
+	for p != "/" && p != "" {
+		dir, _ := p.DirAndName()
+		p = util.FullPath(dir)
+		if dirTtl, found := f.dirTtls[p]; found {
+			return dirTtl.Seconds
+		}
+	}
+	return 0
+}
+
+// maybeInheritDirTtl sets the inherited ttl of a new file without a ttl
+func (f *Filer) maybeInheritDirTtl(entry *Entry) {
+	if entry.IsDirectory() || entry.TtlSec > 0 {
+		return
+	}
+	entry.TtlSec = f.InheritedTtlSec(entry.FullPath)
+}
+
+// keepInheritedDirTtl keeps the inherited ttl of a file updated without a ttl, e.g., by the mount
+func (f *Filer) keepInheritedDirTtl(oldEntry, entry *Entry) {
+	if entry.IsDirectory() || entry.TtlSec > 0 || oldEntry.TtlSec == 0 {
+		return
+	}
+	if oldEntry.TtlSec == f.InheritedTtlSec(entry.FullPath) {
+		entry.TtlSec = oldEntry.TtlSec
+	}
+}
+
+// maybeUpdateDirTtls registers the directories with ttls, and forgets those deleted, moved away, or without ttls
+func (f *Filer) maybeUpdateDirTtls(oldEntry, newEntry *Entry) {
+	if oldEntry != nil && oldEntry.IsDirectory() {
+		if _, hadTtl := GetDirTtl(oldEntry.Extended); hadTtl {
+			removed := newEntry == nil || newEntry.FullPath != oldEntry.FullPath
+			if !removed {
+				_, hasTtl := GetDirTtl(newEntry.Extended)
+				removed = !hasTtl
+			}
+			if removed {
+				f.removeDirTtl(oldEntry.FullPath)
+			}
+		}
+	}
+	if newEntry != nil && newEntry.IsDirectory() {
+		if dirTtl, hasTtl := GetDirTtl(newEntry.Extended); hasTtl {
+			f.addDirTtl(newEntry.FullPath, dirTtl)
+		}
+	}
+}
+
+func (f *Filer) addDirTtl(dir util.FullPath, dirTtl DirTtl) {
+	f.dirTtlsLock.Lock()
+	defer f.dirTtlsLock.Unlock()
+	if f.dirTtls == nil {
+		f.dirTtls = make(map[util.FullPath]DirTtl)
+	}
+	existing, found := f.dirTtls[dir]
+	f.dirTtls[dir] = dirTtl
+	if found && existing == dirTtl {
+		return
+	}
+	glog.V(0).Infof("directory ttl %s: %s, retroactive %v", dir, dirTtl.Ttl, dirTtl.Retroactive)
+	if !found {
+		f.saveDirTtlList()
+	}
+}
+
+func (f *Filer) removeDirTtl(dir util.FullPath) {
+	f.dirTtlsLock.Lock()
+	defer f.dirTtlsLock.Unlock()
+	if _, existing := f.dirTtls[dir]; !existing {
+		return
+	}
+	delete(f.dirTtls, dir)
+	glog.V(0).Infof("remove directory ttl %s", dir)
+	f.saveDirTtlList()
+}
+
+// saveDirTtlList should be called with the dirTtlsLock
+func (f *Filer) saveDirTtlList() {
+	var dirs []string
+	for dir := range f.dirTtls {
+		dirs = append(dirs, string(dir))
+	}
+	sort.Strings(dirs)
+	if err := f.Store.KvPut(context.Background(), []byte(DirTtlListKey), []byte(strings.Join(dirs, "\n"))); err != nil {
+		glog.Errorf("save directory ttls: %v", err)
+	}
+}
+
+// onDirTtlMetadataChange follows the ttl directories changed by the other filers
+func (f *Filer) onDirTtlMetadataChange(event *filer_pb.SubscribeMetadataResponse) {
+	oldEntry, newEntry := entriesOfEvent(event)
+	f.maybeUpdateDirTtls(oldEntry, newEntry)
+}
+
+// LoopExpireDirTtls removes the expired files under the ttl directories, each directory by one of the filers
+func (f *Filer) LoopExpireDirTtls() {
+	for {
+		time.Sleep(dirTtlExpireInterval)
+		f.dirTtlsLock.RLock()
+		dirTtls := make(map[util.FullPath]DirTtl, len(f.dirTtls))
+		for dir, dirTtl := range f.dirTtls {
+			dirTtls[dir] = dirTtl
+		}
+		f.dirTtlsLock.RUnlock()
+
+		for dir, dirTtl := range dirTtls {
+			if !f.Dlm.IsLocal(DirTtlListKey + string(dir)) {
+				continue
+			}
+			count, err := f.expireDirTtl(context.Background(), dir, dirTtl, time.Now())
+			if err != nil {
+				glog.Errorf("expire files under ttl directory %s: %v", dir, err)
+			}
+			if count > 0 {
+				glog.V(0).Infof("expired %d files under ttl directory %s", count, dir)
+			}
+		}
+	}
+}
+
+// expireDirTtl walks the directory, where listing removes the expired entries with ttls, and removes the files
+// without ttls older than a retroactive ttl. The nested ttl directories are left to their own ttls.
+func (f *Filer) expireDirTtl(ctx context.Context, dir util.FullPath, dirTtl DirTtl, now time.Time) (count int64, err error) {
+	lastFileName := ""
+	for {
+		entries, hasMore, listErr := f.ListDirectoryEntries(ctx, dir, lastFileName, false, PaginationSize, "", "", "")
+		if listErr != nil {
+			return count, listErr
+		}
+		var subDirs []util.FullPath
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			if entry.IsDirectory() {
+				if _, hasTtl := GetDirTtl(entry.Extended); !hasTtl {
+					subDirs = append(subDirs, entry.FullPath)
+				}
+				continue
+			}
+			if !dirTtl.Retroactive || entry.TtlSec > 0 {
+				continue
+			}
+			if entry.Crtime.Add(time.Duration(dirTtl.Seconds) * time.Second).After(now) {
+				continue
+			}
+			deleteErr := f.DeleteEntryMetaAndData(ctx, entry.FullPath, false, false, true, false, nil, 0)
+			if deleteErr != nil && deleteErr != filer_pb.ErrNotFound {
+				glog.Warningf("expire %s: %v", entry.FullPath, deleteErr)
+				continue
+			}
+			count++
+		}
+		for _, subDir := range subDirs {
+			subCount, subErr := f.expireDirTtl(ctx, subDir, dirTtl, now)
+			count += subCount
+			if subErr != nil {
+				return count, subErr
+			}
+		}
+		if !hasMore {
+			return count, nil
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package filer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestDirTtlExtended(t *testing.T) {
+	extended := make(map[string][]byte)
+	if _, found := GetDirTtl(extended); found {
+		t.Errorf("unexpected ttl without the extended attributes")
+	}
+
+	SetDirTtl(extended, "7d", true)
+	if dirTtl, found := GetDirTtl(extended); !found || dirTtl.Seconds != 7*24*3600 || !dirTtl.Retroactive || dirTtl.Ttl != "7d" {
+		t.Errorf("unexpected ttl %+v, found %v", dirTtl, found)
+	}
+
+	SetDirTtl(extended, "12h", false)
+	if dirTtl, found := GetDirTtl(extended); !found || dirTtl.Seconds != 12*3600 || dirTtl.Retroactive {
+		t.Errorf("unexpected ttl %+v, found %v", dirTtl, found)
+	}
+
+	extended[ExtDirTtlKey] = []byte("bad")
+	if dirTtl, found := GetDirTtl(extended); found {
+		t.Errorf("unexpected ttl %+v of an invalid value", dirTtl)
+	}
+
+	SetDirTtl(extended, "", true)
+	if len(extended) != 0 {
+		t.Errorf("the cleared ttl should remove the extended attributes: %v", extended)
+	}
+}
+
+func TestInheritDirTtl(t *testing.T) {
+	f := &Filer{
+		dirTtls: map[util.FullPath]DirTtl{
+			"/logs":     {Ttl: "7d", Seconds: 7 * 24 * 3600},
+			"/logs/tmp": {Ttl: "1h", Seconds: 3600},
+		},
+	}
+
+	// the nearest ttl directory wins
+	if ttlSec := f.InheritedTtlSec("/logs/app/2024/a.log"); ttlSec != 7*24*3600 {
+		t.Errorf("unexpected ttl %d", ttlSec)
+	}
+	if ttlSec := f.InheritedTtlSec("/logs/tmp/x/a.log"); ttlSec != 3600 {
+		t.Errorf("unexpected ttl %d", ttlSec)
+	}
+	if ttlSec := f.InheritedTtlSec("/logsx/a.log"); ttlSec != 0 {
+		t.Errorf("unexpected ttl %d outside the ttl directories", ttlSec)
+	}
+
+	file := &Entry{FullPath: "/logs/a.log"}
+	f.maybeInheritDirTtl(file)
+	if file.TtlSec != 7*24*3600 {
+		t.Errorf("expected the file to inherit the ttl, got %d", file.TtlSec)
+	}
+
+	// the ttl set by the write is kept
+	file = &Entry{FullPath: "/logs/a.log", Attr: Attr{TtlSec: 60}}
+	f.maybeInheritDirTtl(file)
+	if file.TtlSec != 60 {
+		t.Errorf("expected the ttl of the write, got %d", file.TtlSec)
+	}
+
+	dir := &Entry{FullPath: "/logs/app", Attr: Attr{Mode: os.ModeDir | 0755}}
+	f.maybeInheritDirTtl(dir)
+	if dir.TtlSec != 0 {
+		t.Errorf("the directories should not inherit the ttl, got %d", dir.TtlSec)
+	}
+
+	// an update without a ttl keeps the inherited ttl, but not another one
+	oldEntry := &Entry{FullPath: "/logs/a.log", Attr: Attr{TtlSec: 7 * 24 * 3600}}
+	updated := &Entry{FullPath: "/logs/a.log"}
+	f.keepInheritedDirTtl(oldEntry, updated)
+	if updated.TtlSec != oldEntry.TtlSec {
+		t.Errorf("expected the inherited ttl kept, got %d", updated.TtlSec)
+	}
+	oldEntry.TtlSec = 60
+	updated.TtlSec = 0
+	f.keepInheritedDirTtl(oldEntry, updated)
+	if updated.TtlSec != 0 {
+		t.Errorf("expected the ttl of the write cleared, got %d", updated.TtlSec)
+	}
+}
@@ -1,6 +1,11 @@
 package filer
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -10,7 +15,61 @@ const (
 
 type HardLinkId []byte // 16 bytes + 1 marker byte
 
+var ErrEntryExists = errors.New("entry already exists")
+
 func NewHardLinkId() HardLinkId {
 	bytes := append(util.RandomBytes(16), HARD_LINK_MARKER)
 	return bytes
 }
+
+// CreateHardLink links the new path to the file of the old path, in one store transaction if supported.
+// On the first link, the file becomes a hard link, whose attributes and chunks are kept once in the filer store kv
+// by the hard link id, and shared by all the links. The link counter is only counted up by this filer at a time,
+// so the links to one file should be created through one filer.
+func (f *Filer) CreateHardLink(ctx context.Context, oldPath, newPath util.FullPath, signatures []int32) (linked, link *Entry, err error) {
+	f.hardLinkLock.Lock()
+	defer f.hardLinkLock.Unlock()
+
+	oldEntry, err := f.FindEntry(ctx, oldPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if oldEntry.IsDirectory() {
+		return nil, nil, fmt.Errorf("hard link to directory %s is not allowed", oldPath)
+	}
+	if f.IsWormEnforced(oldEntry) {
+		return nil, nil, ErrWormEnforced
+	}
+	if _, findErr := f.FindEntry(ctx, newPath); findErr == nil {
+		return nil, nil, ErrEntryExists
+	} else if findErr != filer_pb.ErrNotFound {
+		return nil, nil, findErr
+	}
+
+	txCtx, err := f.BeginTransaction(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	linked = oldEntry.ShallowClone()
+	if len(linked.HardLinkId) == 0 {
+		linked.HardLinkId = NewHardLinkId()
+		linked.HardLinkCounter = 1
+	}
+	linked.HardLinkCounter++
+	if err = f.UpdateEntry(txCtx, oldEntry, linked); err != nil {
+		f.RollbackTransaction(txCtx)
+		return nil, nil, fmt.Errorf("link %s: %v", oldPath, err)
+	}
+	link = linked.ShallowClone()
+	link.FullPath = newPath
+	if err = f.CreateEntry(txCtx, link, true, false, signatures, false, f.MaxFilenameLength); err != nil {
+		f.RollbackTransaction(txCtx)
+		return nil, nil, fmt.Errorf("link %s to %s: %v", newPath, oldPath, err)
+	}
+	if err = f.CommitTransaction(txCtx); err != nil {
+		f.RollbackTransaction(txCtx)
+		return nil, nil, fmt.Errorf("link %s to %s: %v", newPath, oldPath, err)
+	}
+	f.NotifyUpdateEvent(ctx, oldEntry, linked, false, false, signatures)
+	return linked, link, nil
+}
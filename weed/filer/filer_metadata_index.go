@@ -0,0 +1,287 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/metadata_index"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The entry changes are queued in memory, and sent to the metadata index in batches by one goroutine, in the order of
+// the changes, so a slow index does not slow down the writes. When the index is empty, e.g., first enabled, the
+// existing entries are indexed by traversing the filer store.
+//
+// The index may keep a document of a deleted entry, e.g., deleted during the traversal. The search results are looked
+// up in the filer store, so such a document is not returned, and is removed from the index.
+
+const (
+	metadataIndexFlushInterval = time.Second
+	metadataIndexBatchSize     = 1000
+	metadataIndexRetryInterval = 5 * time.Second
+	// indexing the existing entries waits while this many changes are queued
+	metadataIndexMaxQueued = 10 * metadataIndexBatchSize
+	// the longer values of the extended attributes are not indexed, nor the binary values
+	metadataIndexMaxExtendedSize = 1024
+)
+
+var ErrMetadataIndexDisabled = errors.New("metadata index is not enabled")
+
+type metadataIndexChange struct {
+	put         *metadata_index.Document
+	deletePath  string
+	isDirectory bool // also delete the documents under the deleted path
+}
+
+// StartMetadataIndex sends the entry changes to the index from now on, and indexes the existing entries if the index is empty
+func (f *Filer) StartMetadataIndex(index metadata_index.MetadataIndex) {
+	f.metadataIndexNotify = make(chan struct{}, 1)
+	f.MetadataIndex = index
+	go f.loopFlushMetadataIndex()
+	go func() {
+		if err := f.maybeBuildMetadataIndex(context.Background()); err != nil {
+			glog.Errorf("index existing entries: %v", err)
+		}
+	}()
+}
+
+// MetadataIndexDocumentOf is what is indexed for the entry
+func MetadataIndexDocumentOf(entry *Entry) *metadata_index.Document {
+	dir, name := entry.FullPath.DirAndName()
+	doc := &metadata_index.Document{
+		Path:        string(entry.FullPath),
+		Dir:         dir,
+		Name:        name,
+		Size:        int64(entry.Size()),
+		Mtime:       entry.Attr.Mtime.Unix(),
+		Crtime:      entry.Attr.Crtime.Unix(),
+		Mime:        entry.Attr.Mime,
+		IsDirectory: entry.IsDirectory(),
+	}
+	if !doc.IsDirectory {
+		doc.Ext = metadata_index.Ext(name)
+	}
+	for k, v := range entry.Extended {
+		if tag, found := strings.CutPrefix(k, ExtTaggingPrefix); found {
+			if doc.Tags == nil {
+				doc.Tags = make(map[string]string)
+			}
+			doc.Tags[tag] = string(v)
+			continue
+		}
+		if len(v) > metadataIndexMaxExtendedSize || !utf8.Valid(v) {
+			continue
+		}
+		if doc.Extended == nil {
+			doc.Extended = make(map[string]string)
+		}
+		doc.Extended[k] = string(v)
+	}
+	return doc
+}
+
+// the metadata logs are not notified as entry changes, so they are not indexed
+func isMetadataIndexExcluded(p util.FullPath) bool {
+	return p == SystemLogDir || strings.HasPrefix(string(p), SystemLogDir+"/")
+}
+
+// onMetadataIndexEntryChange queues the change of the entry to be sent to the index
+func (f *Filer) onMetadataIndexEntryChange(oldEntry, newEntry *Entry) {
+	if f.MetadataIndex == nil {
+		return
+	}
+	var changes []metadataIndexChange
+	if oldEntry != nil && (newEntry == nil || newEntry.FullPath != oldEntry.FullPath) && !isMetadataIndexExcluded(oldEntry.FullPath) {
+		changes = append(changes, metadataIndexChange{deletePath: string(oldEntry.FullPath), isDirectory: oldEntry.IsDirectory()})
+	}
+	if newEntry != nil && !isMetadataIndexExcluded(newEntry.FullPath) {
+		changes = append(changes, metadataIndexChange{put: MetadataIndexDocumentOf(newEntry)})
+	}
+	f.queueMetadataIndexChanges(changes...)
+}
+
+func (f *Filer) queueMetadataIndexChanges(changes ...metadataIndexChange) {
+	if len(changes) == 0 {
+		return
+	}
+	f.metadataIndexLock.Lock()
+	f.metadataIndexChanges = append(f.metadataIndexChanges, changes...)
+	isFull := len(f.metadataIndexChanges) >= metadataIndexBatchSize
+	f.metadataIndexLock.Unlock()
+	if isFull {
+		select {
+		case f.metadataIndexNotify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (f *Filer) loopFlushMetadataIndex() {
+	ticker := time.NewTicker(metadataIndexFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-f.metadataIndexNotify:
+		}
+
+		f.metadataIndexLock.Lock()
+		changes := f.metadataIndexChanges
+		f.metadataIndexChanges = nil
+		f.metadataIndexLock.Unlock()
+
+		for len(changes) > 0 {
+			if err := applyMetadataIndexChanges(context.Background(), f.MetadataIndex, changes); err != nil {
+				glog.Errorf("update metadata index %s: %v", f.MetadataIndex.GetName(), err)
+				time.Sleep(metadataIndexRetryInterval)
+				// retry before the changes queued meanwhile
+				f.metadataIndexLock.Lock()
+				changes = append(changes, f.metadataIndexChanges...)
+				f.metadataIndexChanges = nil
+				f.metadataIndexLock.Unlock()
+				continue
+			}
+			changes = nil
+		}
+	}
+}
+
+// applyMetadataIndexChanges sends the changes in order, with the consecutive puts or deletes in one request
+func applyMetadataIndexChanges(ctx context.Context, index metadata_index.MetadataIndex, changes []metadataIndexChange) error {
+	var docs []*metadata_index.Document
+	var paths, dirs []string
+	flushPuts := func() error {
+		for len(docs) > 0 {
+			batch := docs
+			if len(batch) > metadataIndexBatchSize {
+				batch = docs[:metadataIndexBatchSize]
+			}
+			if err := index.Put(ctx, batch); err != nil {
+				return err
+			}
+			docs = docs[len(batch):]
+		}
+		return nil
+	}
+	flushDeletes := func() error {
+		if len(paths) == 0 {
+			return nil
+		}
+		err := index.Delete(ctx, paths, dirs)
+		paths, dirs = nil, nil
+		return err
+	}
+	for _, change := range changes {
+		if change.put != nil {
+			if err := flushDeletes(); err != nil {
+				return err
+			}
+			docs = append(docs, change.put)
+			continue
+		}
+		if err := flushPuts(); err != nil {
+			return err
+		}
+		paths = append(paths, change.deletePath)
+		if change.isDirectory {
+			dirs = append(dirs, change.deletePath)
+		}
+	}
+	if err := flushPuts(); err != nil {
+		return err
+	}
+	return flushDeletes()
+}
+
+func (f *Filer) maybeBuildMetadataIndex(ctx context.Context) error {
+	isEmpty, err := f.MetadataIndex.IsEmpty(ctx)
+	if err != nil || !isEmpty {
+		return err
+	}
+	glog.V(0).Infof("index existing entries to metadata index %s", f.MetadataIndex.GetName())
+	startTime := time.Now()
+	count, err := f.indexMetadataUnder(ctx, "/")
+	if err != nil {
+		return err
+	}
+	glog.V(0).Infof("indexed %d existing entries in %v", count, time.Since(startTime))
+	return nil
+}
+
+func (f *Filer) indexMetadataUnder(ctx context.Context, dir util.FullPath) (count int64, err error) {
+	lastFileName := ""
+	for {
+		entries, hasMore, listErr := f.ListDirectoryEntries(ctx, dir, lastFileName, false, PaginationSize, "", "", "")
+		if listErr != nil {
+			return count, listErr
+		}
+		var changes []metadataIndexChange
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			if isMetadataIndexExcluded(entry.FullPath) {
+				continue
+			}
+			changes = append(changes, metadataIndexChange{put: MetadataIndexDocumentOf(entry)})
+		}
+		f.queueMetadataIndexChanges(changes...)
+		count += int64(len(changes))
+		f.waitMetadataIndexQueue(ctx)
+		for _, entry := range entries {
+			if entry.IsDirectory() && !isMetadataIndexExcluded(entry.FullPath) {
+				subCount, subErr := f.indexMetadataUnder(ctx, entry.FullPath)
+				count += subCount
+				if subErr != nil {
+					return count, subErr
+				}
+			}
+		}
+		if !hasMore {
+			break
+		}
+	}
+	return count, nil
+}
+
+// waitMetadataIndexQueue waits for the queued changes to be sent to the index
+func (f *Filer) waitMetadataIndexQueue(ctx context.Context) {
+	for ctx.Err() == nil {
+		f.metadataIndexLock.Lock()
+		queued := len(f.metadataIndexChanges)
+		f.metadataIndexLock.Unlock()
+		if queued < metadataIndexMaxQueued {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// SearchMetadata returns the entries matching the query, ordered by the full path, and the cursor of the next page
+func (f *Filer) SearchMetadata(ctx context.Context, query *metadata_index.Query) (entries []*Entry, nextCursor string, err error) {
+	if f.MetadataIndex == nil {
+		return nil, "", ErrMetadataIndexDisabled
+	}
+	query.Normalize()
+	docs, nextCursor, err := f.MetadataIndex.Search(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+	var stale []metadataIndexChange
+	for _, doc := range docs {
+		entry, findErr := f.FindEntry(ctx, util.FullPath(doc.Path))
+		if findErr == filer_pb.ErrNotFound {
+			stale = append(stale, metadataIndexChange{deletePath: doc.Path})
+			continue
+		}
+		if findErr != nil {
+			return nil, "", findErr
+		}
+		entries = append(entries, entry)
+	}
+	f.queueMetadataIndexChanges(stale...)
+	return entries, nextCursor, nil
+}
@@ -0,0 +1,85 @@
+package filer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/metadata_index"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// recordingMetadataIndex records the requests sent to the index
+type recordingMetadataIndex struct {
+	metadata_index.MetadataIndex
+	requests []string
+}
+
+func (index *recordingMetadataIndex) Put(ctx context.Context, docs []*metadata_index.Document) error {
+	var paths []string
+	for _, doc := range docs {
+		paths = append(paths, doc.Path)
+	}
+	index.requests = append(index.requests, fmt.Sprintf("put %v", paths))
+	return nil
+}
+
+func (index *recordingMetadataIndex) Delete(ctx context.Context, paths []string, dirs []string) error {
+	index.requests = append(index.requests, fmt.Sprintf("delete %v %v", paths, dirs))
+	return nil
+}
+
+func TestMetadataIndexDocumentOf(t *testing.T) {
+	entry := &Entry{
+		FullPath: "/buckets/b/photos/IMG.JPG",
+		Attr:     Attr{Mtime: time.Unix(1700000000, 0), Mime: "image/jpeg", FileSize: 1234},
+		Extended: map[string][]byte{
+			"X-Amz-Tagging-album": []byte("trip"),
+			"Seaweed-Owner":       []byte("alice"),
+			"binary":              {0xff, 0xfe},
+		},
+	}
+	doc := MetadataIndexDocumentOf(entry)
+	if doc.Dir != "/buckets/b/photos" || doc.Name != "IMG.JPG" || doc.Ext != "jpg" || doc.Size != 1234 || doc.Mtime != 1700000000 || doc.Mime != "image/jpeg" {
+		t.Errorf("unexpected document %+v", doc)
+	}
+	if fmt.Sprint(doc.Tags) != "map[album:trip]" || fmt.Sprint(doc.Extended) != "map[Seaweed-Owner:alice]" {
+		t.Errorf("tags %v, extended %v", doc.Tags, doc.Extended)
+	}
+}
+
+func TestMetadataIndexChangesInOrder(t *testing.T) {
+	index := &recordingMetadataIndex{}
+	f := &Filer{MetadataIndex: index, metadataIndexNotify: make(chan struct{}, 1)}
+
+	dir := &Entry{FullPath: "/a", Attr: Attr{Mode: os.ModeDir | 0755}}
+	file := &Entry{FullPath: "/a/f"}
+	f.onMetadataIndexEntryChange(nil, dir)
+	f.onMetadataIndexEntryChange(nil, file)
+	// renamed, and then the directory deleted
+	f.onMetadataIndexEntryChange(file, &Entry{FullPath: "/b"})
+	f.onMetadataIndexEntryChange(dir, nil)
+	f.onMetadataIndexEntryChange(nil, &Entry{FullPath: SystemLogDir + "/2024-01-01/00-00.00000000"})
+
+	if err := applyMetadataIndexChanges(context.Background(), index, f.metadataIndexChanges); err != nil {
+		t.Fatal(err)
+	}
+	expected := "[put [/a /a/f] delete [/a/f] [] put [/b] delete [/a] [/a]]"
+	if fmt.Sprint(index.requests) != expected {
+		t.Errorf("requests %v, expected %s", index.requests, expected)
+	}
+}
+
+func TestIsMetadataIndexExcluded(t *testing.T) {
+	for p, expected := range map[util.FullPath]bool{
+		SystemLogDir:                 true,
+		SystemLogDir + "/2024-01-01": true,
+		"/topics/.system":            false,
+	} {
+		if isMetadataIndexExcluded(p) != expected {
+			t.Errorf("%s excluded: %v", p, !expected)
+		}
+	}
+}
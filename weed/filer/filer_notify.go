@@ -32,6 +32,12 @@ func (f *Filer) NotifyUpdateEvent(ctx context.Context, oldEntry, newEntry *Entry
 	if strings.HasPrefix(fullpath, SystemLogDir) {
 		return
 	}
+
+	f.onDirQuotaEntryChange(oldEntry, newEntry)
+	f.maybeUpdateDirTtls(oldEntry, newEntry)
+	f.onDirStatsEntryChange(oldEntry, newEntry)
+	f.onMetadataIndexEntryChange(oldEntry, newEntry)
+
 	foundSelf := false
 	for _, sig := range signatures {
 		if sig == f.Signature {
@@ -93,6 +99,8 @@ func (f *Filer) logFlushFunc(logBuffer *log_buffer.LogBuffer, startTime, stopTim
 	}
 
 	startTime, stopTime = startTime.UTC(), stopTime.UTC()
+	// the flushes within the same segment are appended to the same file
+	startTime = startTime.Truncate(f.metaLogSegmentDuration())
 
 	targetFile := fmt.Sprintf("%s/%04d-%02d-%02d/%02d-%02d.%08x", SystemLogDir,
 		startTime.Year(), startTime.Month(), startTime.Day(), startTime.Hour(), startTime.Minute(), f.UniqueFilerId,
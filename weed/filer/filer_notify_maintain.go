@@ -0,0 +1,181 @@
+package filer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const metaLogMaintenanceInterval = 10 * time.Minute
+
+// MetaLogOption controls how long the metadata change log is kept, and how it is stored
+type MetaLogOption struct {
+	Retention       time.Duration // the older changes are deleted, 0 to keep them forever
+	SegmentDuration time.Duration // each log file collects the changes of this duration, which divides an hour
+	CompactAfter    time.Duration // the chunks of the log files older than this are merged, 0 to disable
+}
+
+func (o MetaLogOption) Validate() error {
+	if o.Retention < 0 || o.CompactAfter < 0 {
+		return fmt.Errorf("metadata log retention %v and compaction age %v should not be negative", o.Retention, o.CompactAfter)
+	}
+	if o.SegmentDuration < time.Minute || o.SegmentDuration > time.Hour || time.Hour%o.SegmentDuration != 0 || o.SegmentDuration%time.Minute != 0 {
+		return fmt.Errorf("metadata log segment duration %v should be whole minutes dividing an hour", o.SegmentDuration)
+	}
+	return nil
+}
+
+func (f *Filer) metaLogSegmentDuration() time.Duration {
+	if f.MetaLog.SegmentDuration < time.Minute {
+		return time.Minute
+	}
+	return f.MetaLog.SegmentDuration
+}
+
+// metaLogLookBack is how much earlier than the changes a log file can be named,
+// since the file is named by the segment of the first buffered change, and the buffer is flushed later
+func (f *Filer) metaLogLookBack() time.Duration {
+	return f.metaLogSegmentDuration() + LogFlushInterval
+}
+
+// LoopMaintainMetaLog deletes the expired metadata log files, and compacts the log files written by this filer
+func (f *Filer) LoopMaintainMetaLog() {
+	if f.MetaLog.Retention == 0 && f.MetaLog.CompactAfter == 0 {
+		return
+	}
+	for {
+		time.Sleep(metaLogMaintenanceInterval)
+		if err := f.maintainMetaLog(time.Now()); err != nil {
+			glog.Errorf("maintain metadata log: %v", err)
+		}
+	}
+}
+
+func (f *Filer) maintainMetaLog(now time.Time) error {
+	ctx := context.Background()
+	dayEntries, _, err := f.ListDirectoryEntries(ctx, SystemLogDir, "", false, math.MaxInt32, "", "", "")
+	if err != nil {
+		return fmt.Errorf("list %s: %v", SystemLogDir, err)
+	}
+
+	// all changes in a file are older than its name by at most the look back
+	var retentionCutoff string
+	if f.MetaLog.Retention > 0 {
+		retentionCutoff = now.Add(-f.MetaLog.Retention - f.metaLogLookBack()).UTC().Format("2006-01-02-15-04")
+	}
+	// the idle files are no longer appended, even if written with a longer segment duration before
+	var compactionCutoff string
+	if f.MetaLog.CompactAfter > 0 {
+		idleAfter := time.Hour + LogFlushInterval
+		if f.MetaLog.CompactAfter > idleAfter {
+			idleAfter = f.MetaLog.CompactAfter
+		}
+		compactionCutoff = now.Add(-idleAfter).UTC().Format("2006-01-02-15-04")
+	}
+	selfFilerId := fmt.Sprintf("%08x", f.UniqueFilerId)
+
+	for _, dayEntry := range dayEntries {
+		day := dayEntry.Name()
+		if retentionCutoff != "" && day+"-23-59" < retentionCutoff {
+			if err := f.DeleteEntryMetaAndData(ctx, dayEntry.FullPath, true, true, true, false, nil, 0); err != nil && err != filer_pb.ErrNotFound {
+				return fmt.Errorf("delete %s: %v", dayEntry.FullPath, err)
+			}
+			glog.V(0).Infof("deleted expired metadata log %s", dayEntry.FullPath)
+			continue
+		}
+		if day+"-00-00" >= retentionCutoff && day+"-00-00" >= compactionCutoff {
+			break
+		}
+
+		logEntries, _, err := f.ListDirectoryEntries(ctx, dayEntry.FullPath, "", false, math.MaxInt32, "", "", "")
+		if err != nil {
+			return fmt.Errorf("list %s: %v", dayEntry.FullPath, err)
+		}
+		for _, logEntry := range logEntries {
+			name := day + "-" + util.FileNameBase(logEntry.Name())
+			if name < retentionCutoff {
+				if err := f.DeleteEntryMetaAndData(ctx, logEntry.FullPath, false, false, true, false, nil, 0); err != nil && err != filer_pb.ErrNotFound {
+					return fmt.Errorf("delete %s: %v", logEntry.FullPath, err)
+				}
+				continue
+			}
+			// the files written by other filers sharing the filer store are compacted by themselves
+			if name < compactionCutoff && len(logEntry.GetChunks()) > 1 && getFilerId(logEntry.Name()) == selfFilerId {
+				if err := f.compactMetaLogFile(ctx, logEntry); err != nil {
+					glog.Errorf("compact metadata log %s: %v", logEntry.FullPath, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// compactMetaLogFile merges the chunks appended to the log file by each flush into one chunk,
+// so reading it back looks up fewer volumes. The replaced chunks are deleted when the entry is updated.
+func (f *Filer) compactMetaLogFile(ctx context.Context, logEntry *Entry) error {
+	data := make([]byte, TotalSize(logEntry.GetChunks()))
+	if err := ReadAll(data, f.MasterClient, logEntry.GetChunks()); err != nil {
+		return fmt.Errorf("read: %v", err)
+	}
+	assignResult, uploadResult, err := f.assignAndUpload(string(logEntry.FullPath), data)
+	if err != nil {
+		return err
+	}
+	chunkCount := len(logEntry.GetChunks())
+	logEntry.Chunks = []*filer_pb.FileChunk{uploadResult.ToPbFileChunk(assignResult.Fid, 0, time.Now().UnixNano())}
+	if err = f.CreateEntry(ctx, logEntry, false, false, nil, false, f.MaxFilenameLength); err != nil {
+		return err
+	}
+	glog.V(1).Infof("compacted metadata log %s from %d chunks", logEntry.FullPath, chunkCount)
+	return nil
+}
+
+// EarliestMetaLogTsNs returns the time of the earliest change still in the metadata log,
+// so the subscribers can resume from any later time without missing changes.
+func (f *Filer) EarliestMetaLogTsNs() (tsNs int64, err error) {
+	ctx := context.Background()
+	dayEntries, _, err := f.ListDirectoryEntries(ctx, SystemLogDir, "", false, math.MaxInt32, "", "", "")
+	if err != nil {
+		return 0, fmt.Errorf("list %s: %v", SystemLogDir, err)
+	}
+	for _, dayEntry := range dayEntries {
+		logEntries, _, err := f.ListDirectoryEntries(ctx, dayEntry.FullPath, "", false, math.MaxInt32, "", "", "")
+		if err != nil {
+			return 0, fmt.Errorf("list %s: %v", dayEntry.FullPath, err)
+		}
+		// the files of all filers starting in the earliest minute with any change
+		earliestMinute := ""
+		for _, logEntry := range logEntries {
+			minute := util.FileNameBase(logEntry.Name())
+			if minute != earliestMinute && tsNs != 0 {
+				break
+			}
+			earliestMinute = minute
+			logEntryItem, err := newLogFileIterator(f.MasterClient, logEntry, 0, 0).getNext()
+			if err == io.EOF {
+				continue
+			}
+			if err != nil {
+				return 0, fmt.Errorf("read %s: %v", logEntry.FullPath, err)
+			}
+			if tsNs == 0 || logEntryItem.TsNs < tsNs {
+				tsNs = logEntryItem.TsNs
+			}
+		}
+		if tsNs != 0 {
+			return tsNs, nil
+		}
+	}
+
+	// not flushed yet
+	if earliestTime := f.LocalMetaLogBuffer.GetEarliestTime(); !earliestTime.IsZero() {
+		return earliestTime.UnixNano(), nil
+	}
+	return time.Now().UnixNano(), nil
+}
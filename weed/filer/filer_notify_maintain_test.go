@@ -0,0 +1,38 @@
+package filer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetaLogOptionValidate(t *testing.T) {
+	tests := []struct {
+		option  MetaLogOption
+		isValid bool
+	}{
+		{MetaLogOption{SegmentDuration: time.Minute}, true},
+		{MetaLogOption{SegmentDuration: 15 * time.Minute, Retention: 7 * 24 * time.Hour, CompactAfter: 2 * time.Hour}, true},
+		{MetaLogOption{SegmentDuration: time.Hour}, true},
+		{MetaLogOption{SegmentDuration: 0}, false},
+		{MetaLogOption{SegmentDuration: 90 * time.Second}, false},
+		{MetaLogOption{SegmentDuration: 7 * time.Minute}, false},
+		{MetaLogOption{SegmentDuration: 2 * time.Hour}, false},
+		{MetaLogOption{SegmentDuration: time.Minute, Retention: -time.Hour}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.option.Validate(); (err == nil) != tt.isValid {
+			t.Errorf("%+v: valid %v, got error %v", tt.option, tt.isValid, err)
+		}
+	}
+}
+
+func TestMetaLogLookBack(t *testing.T) {
+	f := &Filer{}
+	if lookBack := f.metaLogLookBack(); lookBack != time.Minute+LogFlushInterval {
+		t.Errorf("default look back %v", lookBack)
+	}
+	f.MetaLog.SegmentDuration = 10 * time.Minute
+	if lookBack := f.metaLogLookBack(); lookBack != 10*time.Minute+LogFlushInterval {
+		t.Errorf("look back %v", lookBack)
+	}
+}
@@ -29,7 +29,9 @@ func (f *Filer) collectPersistedLogBuffer(startPosition log_buffer.MessagePositi
 		return nil, io.EOF
 	}
 
-	startDate := fmt.Sprintf("%04d-%02d-%02d", startPosition.Year(), startPosition.Month(), startPosition.Day())
+	// the earlier log files may contain the changes after the start position
+	listFrom := startPosition.Add(-f.metaLogLookBack()).UTC()
+	startDate := fmt.Sprintf("%04d-%02d-%02d", listFrom.Year(), listFrom.Month(), listFrom.Day())
 
 	dayEntries, _, listDayErr := f.ListDirectoryEntries(context.Background(), SystemLogDir, startDate, true, math.MaxInt32, "", "", "")
 	if listDayErr != nil {
@@ -157,8 +159,9 @@ func NewLogFileEntryCollector(f *Filer, startPosition log_buffer.MessagePosition
 		// println("enqueue day entry", dayEntry.Name())
 	}
 
-	startDate := fmt.Sprintf("%04d-%02d-%02d", startPosition.Year(), startPosition.Month(), startPosition.Day())
-	startHourMinute := fmt.Sprintf("%02d-%02d", startPosition.Hour(), startPosition.Minute())
+	listFrom := startPosition.Add(-f.metaLogLookBack()).UTC()
+	startDate := fmt.Sprintf("%04d-%02d-%02d", listFrom.Year(), listFrom.Month(), listFrom.Day())
+	startHourMinute := fmt.Sprintf("%02d-%02d", listFrom.Hour(), listFrom.Minute())
 	var stopDate, stopHourMinute string
 	if stopTsNs != 0 {
 		stopTime := time.Unix(0, stopTsNs+24*60*60*int64(time.Nanosecond)).UTC()
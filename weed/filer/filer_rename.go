@@ -13,12 +13,19 @@ func (f *Filer) CanRename(source, target util.FullPath, oldName string) error {
 		return fmt.Errorf("mv: can not move directory to a subdirectory of itself")
 	}
 
+	// the objects moved between buckets keep their chunks in the collections of the source bucket,
+	// which are recorded on the target bucket, so only the moves in or out of the buckets are refused
 	sourceBucket := f.DetectBucket(source)
 	targetBucket := f.DetectBucket(target)
-	if sourceBucket != targetBucket {
+	if (sourceBucket == "") != (targetBucket == "") {
 		return fmt.Errorf("can not move across collection %s => %s", sourceBucket, targetBucket)
 	}
 
+	// the content addressed files are named by their content
+	if f.FilerConf.MatchStorageRule(string(sourcePath)).ContentAddressed || f.FilerConf.MatchStorageRule(string(target.Child(oldName))).ContentAddressed {
+		return fmt.Errorf("mv: can not move content addressed %s", sourcePath)
+	}
+
 	return nil
 }
 
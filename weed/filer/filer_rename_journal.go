@@ -0,0 +1,238 @@
+package filer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// A directory tree is renamed in one store transaction if the filer store supports transactions.
+// Otherwise, the entries are moved one by one, with a rename journal in the filer store kv, so a rename interrupted
+// by a filer restart or an error is resumed, with a backoff and up to maxRenameAttempts until the filer restarts.
+// While the rename is in progress, the lookups and the listings under the old and the new paths on this filer wait
+// for it, so the clients see the tree either before or after the rename. While an interrupted rename waits to be
+// resumed, they fail fast instead. The listings of the parent directories do not wait, and may show both the old
+// and the new directories during the rename.
+//
+// The journal is only known by the filer running the rename. The other filers sharing the filer store do not wait,
+// and may see the partially moved tree until the rename finishes.
+
+const (
+	renameJournalPrefix  = "rename.journal."
+	maxRenameAttempts    = 10
+	renameRetryBaseDelay = time.Minute
+	renameRetryMaxDelay  = time.Hour
+)
+
+var errRenameInterrupted = errors.New("the rename is interrupted, to be resumed")
+
+// RenameJournal is a rename of a directory tree in progress
+type RenameJournal struct {
+	OldPath     util.FullPath `json:"oldPath"`
+	NewPath     util.FullPath `json:"newPath"`
+	Signatures  []int32       `json:"signatures,omitempty"`
+	StartedAtNs int64         `json:"startedAtNs"`
+}
+
+type renameInProgress struct {
+	journal       *RenameJournal
+	isRunning     bool
+	done          chan struct{} // closed when the rename finishes or fails
+	attempts      int           // the failed attempts since the filer starts
+	nextAttemptAt time.Time
+}
+
+type renamesInProgress struct {
+	sync.Mutex
+	renames map[util.FullPath]*renameInProgress // by the old path
+	count   atomic.Int32
+}
+
+type renameCtxKey struct{}
+
+// WithRename marks the context of the rename itself, which does not wait for the renames in progress
+func WithRename(ctx context.Context) context.Context {
+	return context.WithValue(ctx, renameCtxKey{}, true)
+}
+
+func isRenameCtx(ctx context.Context) bool {
+	isRename, _ := ctx.Value(renameCtxKey{}).(bool)
+	return isRename
+}
+
+func (f *Filer) renameJournalKey() []byte {
+	return []byte(renameJournalPrefix + string(f.Dlm.Host))
+}
+
+// isAffectedByRename checks whether the path is changed by the rename
+func isAffectedByRename(journal *RenameJournal, p util.FullPath) bool {
+	for _, renamed := range []util.FullPath{journal.OldPath, journal.NewPath} {
+		if p == renamed || p.IsUnder(renamed) {
+			return true
+		}
+	}
+	return false
+}
+
+// renameRetryDelay is the backoff before resuming the rename after the failed attempts
+func renameRetryDelay(attempts int) time.Duration {
+	delay := renameRetryBaseDelay
+	for i := 1; i < attempts && delay < renameRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > renameRetryMaxDelay {
+		return renameRetryMaxDelay
+	}
+	return delay
+}
+
+// waitForRename waits for the renames in progress changing the path, or fails if the rename is interrupted
+func (f *Filer) waitForRename(ctx context.Context, p util.FullPath) error {
+	if f.renames.count.Load() == 0 || isRenameCtx(ctx) {
+		return nil
+	}
+	for {
+		var done chan struct{}
+		f.renames.Lock()
+		for _, rename := range f.renames.renames {
+			if isAffectedByRename(rename.journal, p) {
+				if !rename.isRunning {
+					f.renames.Unlock()
+					return fmt.Errorf("%s is renamed %s => %s: %w", p, rename.journal.OldPath, rename.journal.NewPath, errRenameInterrupted)
+				}
+				done = rename.done
+				break
+			}
+		}
+		f.renames.Unlock()
+		if done == nil {
+			return nil
+		}
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return fmt.Errorf("wait for renaming %s: %v", p, ctx.Err())
+		}
+	}
+}
+
+// StartRename records the rename in the journal, and fails if it overlaps with another rename in progress
+func (f *Filer) StartRename(ctx context.Context, journal *RenameJournal) error {
+	f.renames.Lock()
+	defer f.renames.Unlock()
+	for _, rename := range f.renames.renames {
+		if isAffectedByRename(rename.journal, journal.OldPath) || isAffectedByRename(rename.journal, journal.NewPath) ||
+			isAffectedByRename(journal, rename.journal.OldPath) || isAffectedByRename(journal, rename.journal.NewPath) {
+			return fmt.Errorf("renaming %s => %s is in progress", rename.journal.OldPath, rename.journal.NewPath)
+		}
+	}
+	if f.renames.renames == nil {
+		f.renames.renames = make(map[util.FullPath]*renameInProgress)
+	}
+	f.renames.renames[journal.OldPath] = &renameInProgress{journal: journal, isRunning: true, done: make(chan struct{})}
+	f.renames.count.Add(1)
+	if err := f.saveRenameJournals(ctx); err != nil {
+		delete(f.renames.renames, journal.OldPath)
+		f.renames.count.Add(-1)
+		return fmt.Errorf("save rename journal: %v", err)
+	}
+	return nil
+}
+
+// FinishRename removes the finished rename from the journal. The failed rename is kept, and resumed later
+// with a backoff, or given up after maxRenameAttempts until the filer restarts.
+func (f *Filer) FinishRename(ctx context.Context, journal *RenameJournal, renameErr error) {
+	f.renames.Lock()
+	defer f.renames.Unlock()
+	rename, found := f.renames.renames[journal.OldPath]
+	if !found {
+		return
+	}
+	if renameErr != nil {
+		rename.isRunning = false
+		rename.attempts++
+		// the waiting lookups fail fast until the rename is resumed
+		close(rename.done)
+		rename.done = make(chan struct{})
+		if rename.attempts >= maxRenameAttempts {
+			glog.Errorf("rename %s => %s failed %d times, not resumed until the filer restarts: %v", journal.OldPath, journal.NewPath, rename.attempts, renameErr)
+			stats.FilerStalledRenamesGauge.Inc()
+			return
+		}
+		delay := renameRetryDelay(rename.attempts)
+		rename.nextAttemptAt = time.Now().Add(delay)
+		glog.Errorf("rename %s => %s, to be resumed in %v: %v", journal.OldPath, journal.NewPath, delay, renameErr)
+		return
+	}
+	delete(f.renames.renames, journal.OldPath)
+	f.renames.count.Add(-1)
+	close(rename.done)
+	if err := f.saveRenameJournals(ctx); err != nil {
+		glog.Errorf("remove rename journal %s => %s: %v", journal.OldPath, journal.NewPath, err)
+	}
+}
+
+// ResumeRenames returns the interrupted renames due to resume, which are marked as running
+func (f *Filer) ResumeRenames() (journals []*RenameJournal) {
+	f.renames.Lock()
+	defer f.renames.Unlock()
+	now := time.Now()
+	for _, rename := range f.renames.renames {
+		if !rename.isRunning && rename.attempts < maxRenameAttempts && !now.Before(rename.nextAttemptAt) {
+			rename.isRunning = true
+			journals = append(journals, rename.journal)
+		}
+	}
+	return
+}
+
+// LoadRenameJournals loads the renames interrupted by the last restart, to be resumed
+func (f *Filer) LoadRenameJournals() {
+	value, err := f.Store.KvGet(context.Background(), f.renameJournalKey())
+	if err == ErrKvNotFound {
+		return
+	}
+	if err != nil {
+		glog.Errorf("load rename journal: %v", err)
+		return
+	}
+	var journals []*RenameJournal
+	if err = json.Unmarshal(value, &journals); err != nil {
+		glog.Errorf("parse rename journal: %v", err)
+		return
+	}
+
+	f.renames.Lock()
+	defer f.renames.Unlock()
+	if f.renames.renames == nil {
+		f.renames.renames = make(map[util.FullPath]*renameInProgress)
+	}
+	for _, journal := range journals {
+		glog.V(0).Infof("interrupted rename %s => %s to be resumed", journal.OldPath, journal.NewPath)
+		f.renames.renames[journal.OldPath] = &renameInProgress{journal: journal, done: make(chan struct{})}
+		f.renames.count.Add(1)
+	}
+}
+
+func (f *Filer) saveRenameJournals(ctx context.Context) error {
+	if len(f.renames.renames) == 0 {
+		return f.Store.KvDelete(ctx, f.renameJournalKey())
+	}
+	var journals []*RenameJournal
+	for _, rename := range f.renames.renames {
+		journals = append(journals, rename.journal)
+	}
+	value, err := json.Marshal(journals)
+	if err != nil {
+		return err
+	}
+	return f.Store.KvPut(ctx, f.renameJournalKey(), value)
+}
@@ -0,0 +1,122 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestIsAffectedByRename(t *testing.T) {
+	journal := &RenameJournal{OldPath: "/a/x", NewPath: "/b/y"}
+	for _, p := range []util.FullPath{"/a/x", "/a/x/file", "/b/y", "/b/y/z/file"} {
+		if !isAffectedByRename(journal, p) {
+			t.Errorf("%s should be affected", p)
+		}
+	}
+	for _, p := range []util.FullPath{"/a", "/a/xx", "/b", "/c/x"} {
+		if isAffectedByRename(journal, p) {
+			t.Errorf("%s should not be affected", p)
+		}
+	}
+}
+
+func TestWaitForRename(t *testing.T) {
+	f := &Filer{}
+	if err := f.waitForRename(context.Background(), "/a/x"); err != nil {
+		t.Fatalf("no rename in progress: %v", err)
+	}
+
+	rename := &renameInProgress{journal: &RenameJournal{OldPath: "/a/x", NewPath: "/b/y"}, isRunning: true, done: make(chan struct{})}
+	f.renames.renames = map[util.FullPath]*renameInProgress{"/a/x": rename}
+	f.renames.count.Add(1)
+
+	for _, p := range []util.FullPath{"/c", "/a", "/b"} {
+		if err := f.waitForRename(context.Background(), p); err != nil {
+			t.Errorf("unrelated path %s should not wait: %v", p, err)
+		}
+	}
+	if err := f.waitForRename(WithRename(context.Background()), "/a/x/file"); err != nil {
+		t.Errorf("the rename itself should not wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := f.waitForRename(ctx, "/b/y/file"); err == nil {
+		t.Errorf("expected to wait until the context is done")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		f.renames.Lock()
+		delete(f.renames.renames, "/a/x")
+		f.renames.count.Add(-1)
+		close(rename.done)
+		f.renames.Unlock()
+	}()
+	if err := f.waitForRename(context.Background(), "/a/x/file"); err != nil {
+		t.Errorf("expected to wait for the rename: %v", err)
+	}
+
+	if journals := f.ResumeRenames(); len(journals) != 0 {
+		t.Errorf("nothing to resume: %v", journals)
+	}
+}
+
+func TestInterruptedRename(t *testing.T) {
+	f := &Filer{}
+	journal := &RenameJournal{OldPath: "/a/x", NewPath: "/b/y"}
+	rename := &renameInProgress{journal: journal, isRunning: true, done: make(chan struct{})}
+	f.renames.renames = map[util.FullPath]*renameInProgress{"/a/x": rename}
+	f.renames.count.Add(1)
+
+	// the waiting lookups fail once the rename fails
+	waited := make(chan error)
+	go func() {
+		waited <- f.waitForRename(context.Background(), "/b/y/file")
+	}()
+	time.Sleep(10 * time.Millisecond)
+	f.FinishRename(context.Background(), journal, fmt.Errorf("injected"))
+	if err := <-waited; !errors.Is(err, errRenameInterrupted) {
+		t.Errorf("expected the interrupted rename, got %v", err)
+	}
+	if err := f.waitForRename(context.Background(), "/a/x/file"); !errors.Is(err, errRenameInterrupted) {
+		t.Errorf("expected to fail fast, got %v", err)
+	}
+
+	// resumed after the backoff
+	if journals := f.ResumeRenames(); len(journals) != 0 {
+		t.Errorf("resumed before the backoff: %v", journals)
+	}
+	rename.nextAttemptAt = time.Now()
+	if journals := f.ResumeRenames(); len(journals) != 1 {
+		t.Errorf("expected to resume, got %v", journals)
+	}
+
+	// given up after the max attempts
+	for rename.attempts < maxRenameAttempts {
+		f.FinishRename(context.Background(), journal, fmt.Errorf("injected"))
+		rename.nextAttemptAt = time.Now()
+		rename.isRunning = rename.attempts < maxRenameAttempts
+	}
+	if journals := f.ResumeRenames(); len(journals) != 0 {
+		t.Errorf("resumed after the max attempts: %v", journals)
+	}
+}
+
+func TestRenameRetryDelay(t *testing.T) {
+	for attempts, expected := range map[int]time.Duration{
+		1:   time.Minute,
+		2:   2 * time.Minute,
+		6:   32 * time.Minute,
+		7:   time.Hour,
+		100: time.Hour,
+	} {
+		if delay := renameRetryDelay(attempts); delay != expected {
+			t.Errorf("delay %v after %d attempts, expected %v", delay, attempts, expected)
+		}
+	}
+}
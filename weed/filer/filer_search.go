@@ -45,6 +45,9 @@ func (f *Filer) StreamListDirectoryEntries(ctx context.Context, p util.FullPath,
 	if strings.HasSuffix(string(p), "/") && len(p) > 1 {
 		p = p[0 : len(p)-1]
 	}
+	if err = f.waitForRename(ctx, p); err != nil {
+		return
+	}
 
 	prefixInNamePattern, restNamePattern := splitPattern(namePattern)
 	if prefixInNamePattern != "" {
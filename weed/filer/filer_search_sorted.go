@@ -0,0 +1,132 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// MaxSortedListingSize limits how many entries of a directory are sorted in memory,
+// for the stores not listing in the sort order natively.
+const MaxSortedListingSize = 100000
+
+var ErrSortedListingTooLarge = fmt.Errorf("directory has more than %d entries to sort", MaxSortedListingSize)
+
+// StreamListSortedDirectoryEntries lists a directory in the sort order, natively by the store if possible.
+// Otherwise, all the entries with the prefix are read and sorted, up to MaxSortedListingSize entries.
+// For the orders other than by name, startFileName is the last entry of the previous page,
+// and entries modified between pages may be listed again or skipped.
+func (f *Filer) StreamListSortedDirectoryEntries(ctx context.Context, p util.FullPath, startFileName string, inclusive bool, limit int64, prefix string, order filer_pb.ListEntriesSortOrder, eachEntryFunc ListEachEntryFunc) (err error) {
+	if order == filer_pb.ListEntriesSortOrder_NAME {
+		_, err = f.StreamListDirectoryEntries(ctx, p, startFileName, inclusive, limit, prefix, "", "", eachEntryFunc)
+		return err
+	}
+	if strings.HasSuffix(string(p), "/") && len(p) > 1 {
+		p = p[0 : len(p)-1]
+	}
+
+	if sortedListable, ok := f.Store.(SortedListable); ok {
+		err = f.doListStoreSortedEntries(ctx, sortedListable, p, startFileName, inclusive, limit, prefix, order, eachEntryFunc)
+		if !errors.Is(err, ErrUnsupportedListSortOrder) {
+			return err
+		}
+	}
+
+	var entries []*Entry
+	if _, err = f.StreamListDirectoryEntries(ctx, p, "", false, MaxSortedListingSize+1, prefix, "", "", func(entry *Entry) bool {
+		entries = append(entries, entry)
+		return true
+	}); err != nil {
+		return err
+	}
+	if len(entries) > MaxSortedListingSize {
+		return fmt.Errorf("list %s sorted by %v: %w", p, order, ErrSortedListingTooLarge)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return compareSortedEntries(entries[i], entries[j], order) < 0
+	})
+
+	start := 0
+	if startFileName != "" {
+		start = -1
+		for i, entry := range entries {
+			if entry.Name() == startFileName {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return fmt.Errorf("list %s from %s: %w", p, startFileName, filer_pb.ErrNotFound)
+		}
+		if !inclusive {
+			start++
+		}
+	}
+
+	for _, entry := range entries[start:] {
+		if limit <= 0 {
+			break
+		}
+		limit--
+		if !eachEntryFunc(entry) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *Filer) doListStoreSortedEntries(ctx context.Context, sortedListable SortedListable, p util.FullPath, startFileName string, inclusive bool, limit int64, prefix string, order filer_pb.ListEntriesSortOrder, eachEntryFunc ListEachEntryFunc) (err error) {
+	for limit > 0 {
+		var listedCount, expiredCount int64
+		var isStopped bool
+		startFileName, err = sortedListable.ListDirectorySortedEntries(ctx, p, startFileName, inclusive, limit, prefix, order, func(entry *Entry) bool {
+			listedCount++
+			if entry.TtlSec > 0 && entry.Crtime.Add(time.Duration(entry.TtlSec)*time.Second).Before(time.Now()) {
+				expiredCount++
+				return true
+			}
+			if !eachEntryFunc(entry) {
+				isStopped = true
+				return false
+			}
+			return true
+		})
+		if err != nil || isStopped || listedCount < limit {
+			return err
+		}
+		// list more in place of the expired entries
+		limit = expiredCount
+		inclusive = false
+	}
+	return nil
+}
+
+// compareSortedEntries orders the entries by the sort key, and then by name
+func compareSortedEntries(a, b *Entry, order filer_pb.ListEntriesSortOrder) int {
+	var c int
+	switch order {
+	case filer_pb.ListEntriesSortOrder_MTIME, filer_pb.ListEntriesSortOrder_MTIME_DESC:
+		c = a.Mtime.Compare(b.Mtime)
+	case filer_pb.ListEntriesSortOrder_SIZE, filer_pb.ListEntriesSortOrder_SIZE_DESC:
+		sizeA, sizeB := a.Size(), b.Size()
+		if sizeA < sizeB {
+			c = -1
+		} else if sizeA > sizeB {
+			c = 1
+		}
+	}
+	if c == 0 {
+		c = strings.Compare(a.Name(), b.Name())
+	}
+	switch order {
+	case filer_pb.ListEntriesSortOrder_NAME_DESC, filer_pb.ListEntriesSortOrder_MTIME_DESC, filer_pb.ListEntriesSortOrder_SIZE_DESC:
+		return -c
+	}
+	return c
+}
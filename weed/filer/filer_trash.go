@@ -0,0 +1,178 @@
+package filer
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The locations with trash_retention_seconds in filer.conf move the deleted files and directories to the trash,
+// instead of deleting them. A deleted entry is moved with everything under it as "/.trash/<trash id>-<name>",
+// where the trash id is the deletion time in nanoseconds, so the trash is listed the oldest first.
+// The original path and the purge time are kept in the extended attributes of the moved entry,
+// and the entries are purged with their chunks after the retention of their original locations.
+
+const (
+	TrashDir = "/.trash"
+
+	TrashOriginalPathKey = "Seaweed-Trash-Original-Path"
+	TrashPurgeAtKey      = "Seaweed-Trash-Purge-At"
+
+	trashIdLength      = 19
+	trashPurgeInterval = time.Hour
+)
+
+// TrashEntry is a deleted entry in the trash
+type TrashEntry struct {
+	*Entry
+	TrashName    string
+	OriginalPath util.FullPath
+	DeletedAtNs  int64
+	PurgeAtNs    int64
+}
+
+// IsTrashPath tells whether the path is in the trash, whose entries are deleted directly
+func IsTrashPath(p util.FullPath) bool {
+	return p == TrashDir || strings.HasPrefix(string(p), TrashDir+"/")
+}
+
+func trashNameOf(deletedAt time.Time, name string) string {
+	return fmt.Sprintf("%0*d-%s", trashIdLength, deletedAt.UnixNano(), name)
+}
+
+// parseTrashName returns when the entry was deleted
+func parseTrashName(trashName string) (deletedAtNs int64, err error) {
+	if len(trashName) <= trashIdLength || trashName[trashIdLength] != '-' {
+		return 0, fmt.Errorf("invalid trash name %q", trashName)
+	}
+	if deletedAtNs, err = strconv.ParseInt(trashName[:trashIdLength], 10, 64); err != nil || deletedAtNs <= 0 {
+		return 0, fmt.Errorf("invalid trash name %q", trashName)
+	}
+	return deletedAtNs, nil
+}
+
+func toTrashEntry(entry *Entry) (*TrashEntry, error) {
+	deletedAtNs, err := parseTrashName(entry.Name())
+	if err != nil {
+		return nil, err
+	}
+	originalPath := string(entry.Extended[TrashOriginalPathKey])
+	if originalPath == "" {
+		return nil, fmt.Errorf("trash entry %s has no original path", entry.FullPath)
+	}
+	purgeAtNs, _ := strconv.ParseInt(string(entry.Extended[TrashPurgeAtKey]), 10, 64)
+	return &TrashEntry{
+		Entry:        entry,
+		TrashName:    entry.Name(),
+		OriginalPath: util.FullPath(originalPath),
+		DeletedAtNs:  deletedAtNs,
+		PurgeAtNs:    purgeAtNs,
+	}, nil
+}
+
+// TrashRetentionOf tells how long the deleted entry is kept in the trash, 0 if it is deleted directly.
+// The entries are only trashed when their chunks are deleted with them, and the buckets are always deleted directly.
+func (f *Filer) TrashRetentionOf(entry *Entry, isDeleteData, isFromOtherCluster bool) time.Duration {
+	// the entries trashed on the other cluster are replicated as moved
+	if !isDeleteData || isFromOtherCluster || IsTrashPath(entry.FullPath) || IsVersionPath(entry.FullPath) || f.isBucket(entry) {
+		return 0
+	}
+	rule := f.FilerConf.MatchStorageRule(string(entry.FullPath))
+	return time.Duration(rule.TrashRetentionSeconds) * time.Second
+}
+
+// PrepareTrashEntry marks the entry with its original path and purge time, to be moved as the returned trash name
+func PrepareTrashEntry(entry *Entry, retention time.Duration, now time.Time) (trashName string) {
+	entry.Extended = maps.Clone(entry.Extended)
+	if entry.Extended == nil {
+		entry.Extended = make(map[string][]byte)
+	}
+	entry.Extended[TrashOriginalPathKey] = []byte(entry.FullPath)
+	entry.Extended[TrashPurgeAtKey] = []byte(strconv.FormatInt(now.Add(retention).UnixNano(), 10))
+	return trashNameOf(now, entry.Name())
+}
+
+// PrepareRestoredEntry removes the trash marks from the entry, to be moved back
+func PrepareRestoredEntry(entry *Entry) {
+	entry.Extended = maps.Clone(entry.Extended)
+	delete(entry.Extended, TrashOriginalPathKey)
+	delete(entry.Extended, TrashPurgeAtKey)
+}
+
+// ListTrash lists the entries in the trash deleted under the path prefix, the oldest first
+func (f *Filer) ListTrash(ctx context.Context, pathPrefix string, startFrom string, limit int) (trashEntries []*TrashEntry, err error) {
+	lastFileName := startFrom
+	for limit <= 0 || len(trashEntries) < limit {
+		entries, hasMore, listErr := f.ListDirectoryEntries(ctx, TrashDir, lastFileName, false, PaginationSize, "", "", "")
+		if listErr != nil {
+			if listErr == filer_pb.ErrNotFound {
+				return trashEntries, nil
+			}
+			return nil, listErr
+		}
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			trashEntry, parseErr := toTrashEntry(entry)
+			if parseErr != nil {
+				continue
+			}
+			if pathPrefix != "" && string(trashEntry.OriginalPath) != pathPrefix && !trashEntry.OriginalPath.IsUnder(util.FullPath(pathPrefix)) {
+				continue
+			}
+			trashEntries = append(trashEntries, trashEntry)
+			if limit > 0 && len(trashEntries) >= limit {
+				break
+			}
+		}
+		if !hasMore {
+			break
+		}
+	}
+	return trashEntries, nil
+}
+
+// FindTrash finds one entry in the trash
+func (f *Filer) FindTrash(ctx context.Context, trashName string) (*TrashEntry, error) {
+	if _, err := parseTrashName(trashName); err != nil || strings.Contains(trashName, "/") {
+		return nil, filer_pb.ErrNotFound
+	}
+	entry, err := f.FindEntry(ctx, util.FullPath(TrashDir).Child(trashName))
+	if err != nil {
+		return nil, err
+	}
+	return toTrashEntry(entry)
+}
+
+// LoopPurgeTrash purges the entries in the trash after their retention
+func (f *Filer) LoopPurgeTrash() {
+	for {
+		time.Sleep(trashPurgeInterval)
+		if err := f.purgeTrash(context.Background(), time.Now()); err != nil {
+			glog.Errorf("purge trash: %v", err)
+		}
+	}
+}
+
+func (f *Filer) purgeTrash(ctx context.Context, now time.Time) error {
+	trashEntries, err := f.ListTrash(ctx, "", "", 0)
+	if err != nil {
+		return err
+	}
+	for _, trashEntry := range trashEntries {
+		if trashEntry.PurgeAtNs > now.UnixNano() {
+			continue
+		}
+		glog.V(1).Infof("purge %s deleted from %s", trashEntry.FullPath, trashEntry.OriginalPath)
+		if err = f.DeleteEntryMetaAndData(ctx, trashEntry.FullPath, true, false, true, false, nil, 0); err != nil && err != filer_pb.ErrNotFound {
+			glog.Errorf("purge %s: %v", trashEntry.FullPath, err)
+		}
+	}
+	return nil
+}
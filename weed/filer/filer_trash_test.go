@@ -0,0 +1,55 @@
+package filer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashName(t *testing.T) {
+	deletedAt := time.Unix(1700000000, 123)
+	trashName := trashNameOf(deletedAt, "a-b.txt")
+	assert.Equal(t, "1700000000000000123-a-b.txt", trashName)
+
+	deletedAtNs, err := parseTrashName(trashName)
+	assert.NoError(t, err)
+	assert.Equal(t, deletedAt.UnixNano(), deletedAtNs)
+
+	for _, invalid := range []string{"a.txt", "1700000000000000123", "17000000000000001234-a", "000000000000000000-x"} {
+		_, err = parseTrashName(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPrepareTrashEntry(t *testing.T) {
+	entry := &Entry{FullPath: "/home/a.txt", Extended: map[string][]byte{"k": []byte("v")}}
+	original := entry.Extended
+	now := time.Unix(1700000000, 0)
+
+	trashName := PrepareTrashEntry(entry, time.Hour, now)
+	assert.Equal(t, "/home/a.txt", string(entry.Extended[TrashOriginalPathKey]))
+	assert.NotContains(t, original, TrashOriginalPathKey)
+
+	entry.FullPath = util.FullPath(TrashDir).Child(trashName)
+	trashEntry, err := toTrashEntry(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "/home/a.txt", string(trashEntry.OriginalPath))
+	assert.Equal(t, now.UnixNano(), trashEntry.DeletedAtNs)
+	assert.Equal(t, now.Add(time.Hour).UnixNano(), trashEntry.PurgeAtNs)
+
+	PrepareRestoredEntry(entry)
+	assert.Equal(t, map[string][]byte{"k": []byte("v")}, entry.Extended)
+}
+
+func TestTrashRetentionOf(t *testing.T) {
+	f := &Filer{DirBucketsPath: "/buckets", FilerConf: NewFilerConf()}
+	f.FilerConf.AddLocationConf(&filer_pb.FilerConf_PathConf{LocationPrefix: "/home/", TrashRetentionSeconds: 60})
+
+	assert.Equal(t, time.Minute, f.TrashRetentionOf(&Entry{FullPath: "/home/a.txt"}, true, false))
+	assert.Equal(t, time.Duration(0), f.TrashRetentionOf(&Entry{FullPath: "/home/a.txt"}, false, false))
+	assert.Equal(t, time.Duration(0), f.TrashRetentionOf(&Entry{FullPath: "/home/a.txt"}, true, true))
+	assert.Equal(t, time.Duration(0), f.TrashRetentionOf(&Entry{FullPath: "/tmp/a.txt"}, true, false))
+}
@@ -0,0 +1,328 @@
+package filer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The locations with max_versions in filer.conf keep the previous versions of the overwritten or deleted files.
+// A previous version is the old entry with its chunk list, saved as "/.versions/path/to/file/<version id>",
+// where the version id is the time it was replaced in nanoseconds, so the versions are listed the oldest first.
+// The versions are pruned to max_versions on each save, and those replaced longer ago than
+// version_retention_seconds are also pruned periodically.
+//
+// A version may share chunks with the current file and the other versions, e.g., after a partial overwrite
+// by the mount, so a pruned version only deletes the chunks not referenced by them. Restoring a version moves
+// it back as the current file, and the replaced current file becomes a new version.
+//
+// The s3 objects in the versioned buckets carry their own version ids, so they are kept even if empty.
+
+const (
+	VersionsDir = "/.versions"
+
+	versionIdLength      = 19
+	versionPruneInterval = time.Hour
+)
+
+// EntryVersion is a previous version of a file
+type EntryVersion struct {
+	*Entry
+	VersionId    string
+	ReplacedAtNs int64
+}
+
+// VersionsDirOf is the directory of the previous versions of the file
+func VersionsDirOf(p util.FullPath) util.FullPath {
+	return util.FullPath(VersionsDir + string(p))
+}
+
+// IsVersionPath tells whether the path is under the versions directory, whose entries are never versioned
+func IsVersionPath(p util.FullPath) bool {
+	return p == VersionsDir || strings.HasPrefix(string(p), VersionsDir+"/")
+}
+
+// VersionIdOf is the version id of a version replaced at the time
+func VersionIdOf(replacedAt time.Time) string {
+	return fmt.Sprintf("%0*d", versionIdLength, replacedAt.UnixNano())
+}
+
+// ParseVersionId returns when the version was replaced
+func ParseVersionId(versionId string) (replacedAtNs int64, err error) {
+	if len(versionId) != versionIdLength {
+		return 0, fmt.Errorf("invalid version id %q", versionId)
+	}
+	if replacedAtNs, err = strconv.ParseInt(versionId, 10, 64); err != nil || replacedAtNs <= 0 {
+		return 0, fmt.Errorf("invalid version id %q", versionId)
+	}
+	return replacedAtNs, nil
+}
+
+func isSameContent(a, b *Entry) bool {
+	if !bytes.Equal(a.Content, b.Content) || len(a.GetChunks()) != len(b.GetChunks()) {
+		return false
+	}
+	for i, chunk := range a.GetChunks() {
+		if chunk.GetFileIdString() != b.GetChunks()[i].GetFileIdString() {
+			return false
+		}
+	}
+	return true
+}
+
+// isReplacedBy tells whether the new entry replaces the content, or the s3 version, of the old entry
+func isReplacedBy(oldEntry, newEntry *Entry) bool {
+	return newEntry == nil || !isSameContent(oldEntry, newEntry) ||
+		!bytes.Equal(oldEntry.Extended[ExtVersionIdKey], newEntry.Extended[ExtVersionIdKey])
+}
+
+// MaybeSaveVersion keeps the old entry as a previous version, if its location keeps versions and its content is
+// overwritten or deleted. When saved, the chunks of the old entry are owned by the version, and must not be deleted.
+func (f *Filer) MaybeSaveVersion(ctx context.Context, oldEntry, newEntry *Entry, isFromOtherCluster bool) (isSaved bool) {
+	// the versions saved on the other cluster are replicated as new entries
+	if oldEntry == nil || isFromOtherCluster || oldEntry.IsDirectory() || IsVersionPath(oldEntry.FullPath) {
+		return false
+	}
+	// the hard links share the chunks, and the remote only entries have no chunks to keep
+	if len(oldEntry.HardLinkId) != 0 || oldEntry.IsInRemoteOnly() {
+		return false
+	}
+	if _, isS3Versioned := oldEntry.Extended[ExtVersionIdKey]; oldEntry.Size() == 0 && !isS3Versioned {
+		return false
+	}
+	if !isReplacedBy(oldEntry, newEntry) {
+		return false
+	}
+	rule := f.FilerConf.MatchStorageRule(string(oldEntry.FullPath))
+	if rule.MaxVersions == 0 {
+		return false
+	}
+
+	now := time.Now()
+	version := oldEntry.ShallowClone()
+	version.FullPath = VersionsDirOf(oldEntry.FullPath).Child(VersionIdOf(now))
+	if err := f.CreateEntry(ctx, version, true, false, nil, false, 0); err != nil {
+		glog.Errorf("save version of %s: %v", oldEntry.FullPath, err)
+		return false
+	}
+	if err := f.pruneVersions(ctx, oldEntry.FullPath, rule, now); err != nil {
+		glog.Errorf("prune versions of %s: %v", oldEntry.FullPath, err)
+	}
+	return true
+}
+
+// ListVersions lists the previous versions of the file, the oldest first
+func (f *Filer) ListVersions(ctx context.Context, p util.FullPath) (versions []*EntryVersion, err error) {
+	lastFileName := ""
+	for {
+		entries, hasMore, listErr := f.ListDirectoryEntries(ctx, VersionsDirOf(p), lastFileName, false, PaginationSize, "", "", "")
+		if listErr != nil {
+			if listErr == filer_pb.ErrNotFound {
+				return versions, nil
+			}
+			return nil, listErr
+		}
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			// the versions of the files under a directory of the same path
+			if entry.IsDirectory() {
+				continue
+			}
+			replacedAtNs, parseErr := ParseVersionId(entry.Name())
+			if parseErr != nil {
+				continue
+			}
+			versions = append(versions, &EntryVersion{Entry: entry, VersionId: entry.Name(), ReplacedAtNs: replacedAtNs})
+		}
+		if !hasMore {
+			return versions, nil
+		}
+	}
+}
+
+// FindVersion finds one previous version of the file
+func (f *Filer) FindVersion(ctx context.Context, p util.FullPath, versionId string) (*EntryVersion, error) {
+	replacedAtNs, err := ParseVersionId(versionId)
+	if err != nil {
+		return nil, filer_pb.ErrNotFound
+	}
+	entry, err := f.FindEntry(ctx, VersionsDirOf(p).Child(versionId))
+	if err != nil {
+		return nil, err
+	}
+	if entry.IsDirectory() {
+		return nil, filer_pb.ErrNotFound
+	}
+	return &EntryVersion{Entry: entry, VersionId: versionId, ReplacedAtNs: replacedAtNs}, nil
+}
+
+// RestoreVersion moves the previous version back as the current file.
+// The replaced current file is saved as a new version, if its location still keeps versions.
+func (f *Filer) RestoreVersion(ctx context.Context, p util.FullPath, versionId string) (*Entry, error) {
+	version, err := f.FindVersion(ctx, p, versionId)
+	if err != nil {
+		return nil, err
+	}
+
+	// remove the version first, so it is not pruned when the current file is saved as a new version
+	if err = f.Store.DeleteOneEntry(ctx, version.Entry); err != nil {
+		return nil, fmt.Errorf("remove version %s of %s: %v", versionId, p, err)
+	}
+	restored := version.ShallowClone()
+	restored.FullPath = p
+	restored.Mtime = time.Now()
+	if err = f.CreateEntry(ctx, restored, false, false, nil, false, f.MaxFilenameLength); err != nil {
+		if insertErr := f.Store.InsertEntry(ctx, version.Entry); insertErr != nil {
+			glog.Errorf("put back version %s of %s: %v", versionId, p, insertErr)
+		}
+		return nil, fmt.Errorf("restore version %s of %s: %v", versionId, p, err)
+	}
+	f.NotifyUpdateEvent(ctx, version.Entry, nil, false, false, nil)
+	return restored, nil
+}
+
+// pruneVersions deletes the oldest versions over the max versions, and the versions replaced before the retention.
+// The locked versions are kept until their locks are released.
+func (f *Filer) pruneVersions(ctx context.Context, p util.FullPath, rule *filer_pb.FilerConf_PathConf, now time.Time) error {
+	versions, err := f.ListVersions(ctx, p)
+	if err != nil {
+		return err
+	}
+	excess := len(versions) - int(rule.MaxVersions)
+	if rule.MaxVersions == 0 {
+		// no longer versioned, only pruned by the retention
+		excess = 0
+	}
+	retention := time.Duration(rule.VersionRetentionSeconds) * time.Second
+	var kept []*EntryVersion
+	var pruned []*EntryVersion
+	for i, version := range versions {
+		isExpired := retention > 0 && now.Sub(time.Unix(0, version.ReplacedAtNs)) > retention
+		if (i < excess || isExpired) && !IsObjectLocked(version.Extended, now) {
+			pruned = append(pruned, version)
+		} else {
+			kept = append(kept, version)
+		}
+	}
+	if len(pruned) == 0 {
+		return nil
+	}
+	return f.deleteVersions(ctx, p, pruned, kept)
+}
+
+// DeleteVersion deletes one previous version of the file
+func (f *Filer) DeleteVersion(ctx context.Context, p util.FullPath, versionId string) error {
+	if _, err := ParseVersionId(versionId); err != nil {
+		return filer_pb.ErrNotFound
+	}
+	versions, err := f.ListVersions(ctx, p)
+	if err != nil {
+		return err
+	}
+	var deleted, kept []*EntryVersion
+	for _, version := range versions {
+		if version.VersionId == versionId {
+			if IsObjectLocked(version.Extended, time.Now()) {
+				return ErrWormEnforced
+			}
+			deleted = append(deleted, version)
+		} else {
+			kept = append(kept, version)
+		}
+	}
+	if len(deleted) == 0 {
+		return filer_pb.ErrNotFound
+	}
+	return f.deleteVersions(ctx, p, deleted, kept)
+}
+
+// deleteVersions deletes the versions, and their chunks not shared with the current file and the kept versions
+func (f *Filer) deleteVersions(ctx context.Context, p util.FullPath, deleted, kept []*EntryVersion) (err error) {
+	var referencedChunks []*filer_pb.FileChunk
+	if current, findErr := f.FindEntry(ctx, p); findErr == nil {
+		referencedChunks = append(referencedChunks, current.GetChunks()...)
+	} else if findErr != filer_pb.ErrNotFound {
+		return findErr
+	}
+	for _, version := range kept {
+		referencedChunks = append(referencedChunks, version.GetChunks()...)
+	}
+	for _, version := range deleted {
+		garbage, minusErr := MinusChunks(f.MasterClient.GetLookupFileIdFunction(), version.GetChunks(), referencedChunks)
+		if minusErr != nil {
+			return fmt.Errorf("resolve chunks of version %s: %v", version.FullPath, minusErr)
+		}
+		if err = f.DeleteEntryMetaAndData(ctx, version.FullPath, false, false, false, false, nil, 0); err != nil {
+			return err
+		}
+		f.DeleteChunksNotRecursive(garbage)
+	}
+	if len(kept) == 0 {
+		// fails if there are still the versions of the files under it
+		_ = f.DeleteEntryMetaAndData(ctx, VersionsDirOf(p), false, false, false, false, nil, 0)
+	}
+	return nil
+}
+
+// LoopPruneVersions prunes the versions replaced before the retention of their locations
+func (f *Filer) LoopPruneVersions() {
+	for {
+		time.Sleep(versionPruneInterval)
+		if !f.hasVersionRetention() {
+			continue
+		}
+		if err := f.pruneVersionsUnder(context.Background(), VersionsDir, time.Now()); err != nil {
+			glog.Errorf("prune versions: %v", err)
+		}
+	}
+}
+
+func (f *Filer) hasVersionRetention() bool {
+	for _, location := range f.FilerConf.ToProto().Locations {
+		if location.VersionRetentionSeconds > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneVersionsUnder walks the versions directory, where any directory with version entries is of one file
+func (f *Filer) pruneVersionsUnder(ctx context.Context, dir util.FullPath, now time.Time) error {
+	lastFileName := ""
+	hasVersions := false
+	for {
+		entries, hasMore, err := f.ListDirectoryEntries(ctx, dir, lastFileName, false, PaginationSize, "", "", "")
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			if !entry.IsDirectory() {
+				hasVersions = true
+				continue
+			}
+			if err = f.pruneVersionsUnder(ctx, entry.FullPath, now); err != nil {
+				return err
+			}
+		}
+		if !hasMore {
+			break
+		}
+	}
+	if !hasVersions {
+		return nil
+	}
+	p := util.FullPath(strings.TrimPrefix(string(dir), VersionsDir))
+	rule := f.FilerConf.MatchStorageRule(string(p))
+	if rule.VersionRetentionSeconds == 0 {
+		return nil
+	}
+	return f.pruneVersions(ctx, p, rule, now)
+}
@@ -0,0 +1,66 @@
+package filer
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestVersionId(t *testing.T) {
+	replacedAt := time.Unix(1700000000, 123)
+	versionId := VersionIdOf(replacedAt)
+	replacedAtNs, err := ParseVersionId(versionId)
+	if err != nil || replacedAtNs != replacedAt.UnixNano() {
+		t.Fatalf("parse %s: %d %v", versionId, replacedAtNs, err)
+	}
+	for _, invalid := range []string{"", "123", "abcdefghijklmnopqrs", "0000000000000000000", "../../../etc/passwd"} {
+		if _, err := ParseVersionId(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+
+	// the versions are listed by name, the oldest first
+	ids := []string{VersionIdOf(time.Unix(2000000000, 0)), VersionIdOf(time.Unix(1000000000, 0)), VersionIdOf(time.Unix(1500000000, 0))}
+	sort.Strings(ids)
+	for i := 1; i < len(ids); i++ {
+		a, _ := ParseVersionId(ids[i-1])
+		b, _ := ParseVersionId(ids[i])
+		if a >= b {
+			t.Errorf("versions %s and %s are not ordered by time", ids[i-1], ids[i])
+		}
+	}
+}
+
+func TestVersionPaths(t *testing.T) {
+	if p := VersionsDirOf("/a/b.txt"); p != "/.versions/a/b.txt" {
+		t.Errorf("unexpected versions directory %s", p)
+	}
+	for _, p := range []util.FullPath{"/.versions", "/.versions/a/b.txt/0000000000000000001"} {
+		if !IsVersionPath(p) {
+			t.Errorf("%s should be a version path", p)
+		}
+	}
+	for _, p := range []util.FullPath{"/", "/.versionsx", "/a/.versions/b"} {
+		if IsVersionPath(p) {
+			t.Errorf("%s should not be a version path", p)
+		}
+	}
+}
+
+func TestIsSameContent(t *testing.T) {
+	a := &Entry{Chunks: []*filer_pb.FileChunk{{FileId: "1,01"}, {FileId: "1,02"}}}
+	b := &Entry{Chunks: []*filer_pb.FileChunk{{FileId: "1,01"}, {FileId: "1,02"}}, Attr: Attr{Mode: 0600}}
+	if !isSameContent(a, b) {
+		t.Errorf("only the attributes are changed")
+	}
+	b.Chunks = append(b.Chunks, &filer_pb.FileChunk{FileId: "1,03"})
+	if isSameContent(a, b) {
+		t.Errorf("the chunks are changed")
+	}
+	if isSameContent(&Entry{Content: []byte("a")}, &Entry{Content: []byte("b")}) {
+		t.Errorf("the inline content is changed")
+	}
+}
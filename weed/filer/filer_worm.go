@@ -0,0 +1,195 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// ErrWormEnforced is returned when changing, moving, or deleting a write-once file before its retention passes
+var ErrWormEnforced = errors.New("write-once file is under retention: operation not permitted")
+
+// In a worm location, a file is committed when it is first written with data, or set to readonly by the mount.
+// It can still be changed within the grace period after that, and then becomes immutable until the retention passes.
+//
+// The s3 objects under the object lock carry their retention and legal hold in the extended attributes.
+// An object under a legal hold, or retained in the compliance mode, is immutable like the committed worm files,
+// except that its metadata can still be changed without shortening the retention, and that it can be replaced or
+// deleted where the previous versions are kept, since it is then kept as a previous version.
+// The retention in the governance mode is enforced by the s3 gateway, which can bypass it.
+
+// WormCommitTsNs is when a file written now in the worm location becomes immutable
+func WormCommitTsNs(rule *filer_pb.FilerConf_PathConf, now time.Time) int64 {
+	return now.Add(time.Duration(rule.WormGracePeriodSeconds) * time.Second).UnixNano()
+}
+
+// WormRetainUntil is when the committed file can be changed or deleted again, or forever if the rule has no retention
+func WormRetainUntil(rule *filer_pb.FilerConf_PathConf, enforcedAtTsNs int64) (retainUntil time.Time, isForever bool) {
+	if rule.WormRetentionTimeSeconds == 0 || rule.WormRetentionTimeSeconds > math.MaxInt64/uint64(time.Second) {
+		return time.Time{}, true
+	}
+	return time.Unix(0, enforcedAtTsNs).Add(time.Duration(rule.WormRetentionTimeSeconds) * time.Second), false
+}
+
+// ObjectLockOf returns the retention and the legal hold of the s3 object in its extended attributes
+func ObjectLockOf(extended map[string][]byte) (mode string, retainUntil time.Time, legalHold bool) {
+	mode = string(extended[ExtObjectLockModeKey])
+	if value, found := extended[ExtObjectLockRetainUntilDateKey]; found {
+		retainUntil, _ = time.Parse(time.RFC3339, string(value))
+	}
+	legalHold = string(extended[ExtObjectLockLegalHoldKey]) == ObjectLockLegalHoldOn
+	return
+}
+
+// IsObjectLocked checks whether the s3 object is under a legal hold, or retained in the compliance mode now
+func IsObjectLocked(extended map[string][]byte, now time.Time) bool {
+	mode, retainUntil, legalHold := ObjectLockOf(extended)
+	return legalHold || mode == ObjectLockModeCompliance && now.Before(retainUntil)
+}
+
+// IsWormEnforced checks whether the file committed at enforcedAtTsNs is immutable under the rule now
+func IsWormEnforced(rule *filer_pb.FilerConf_PathConf, enforcedAtTsNs int64, now time.Time) bool {
+	if !rule.Worm || enforcedAtTsNs == 0 || now.UnixNano() < enforcedAtTsNs {
+		return false
+	}
+	retainUntil, isForever := WormRetainUntil(rule, enforcedAtTsNs)
+	return isForever || now.Before(retainUntil)
+}
+
+// HasWormLocation checks whether the directory, or any location under it, is configured as write-once
+func (fc *FilerConf) HasWormLocation(dir string) (found bool) {
+	fc.rules.Walk(func(key []byte, value *filer_pb.FilerConf_PathConf) bool {
+		if value.Worm && (strings.HasPrefix(dir, string(key)) || strings.HasPrefix(string(key), dir)) {
+			found = true
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// IsWormEnforced checks whether the entry is an immutable write-once file now
+func (f *Filer) IsWormEnforced(entry *Entry) bool {
+	if f.FilerConf == nil || entry == nil || entry.IsDirectory() || entry.WORMEnforcedAtTsNs == 0 {
+		return false
+	}
+	return IsWormEnforced(f.FilerConf.MatchStorageRule(string(entry.FullPath)), entry.WORMEnforcedAtTsNs, time.Now())
+}
+
+// maybeCommitWorm commits the file written with data in a worm location, keeping the commit time of the existing file
+func (f *Filer) maybeCommitWorm(oldEntry, entry *Entry) {
+	if entry.IsDirectory() || entry.WORMEnforcedAtTsNs != 0 {
+		return
+	}
+	if oldEntry != nil && oldEntry.WORMEnforcedAtTsNs != 0 {
+		entry.WORMEnforcedAtTsNs = oldEntry.WORMEnforcedAtTsNs
+		return
+	}
+	if f.FilerConf == nil || entry.Size() == 0 {
+		return
+	}
+	if rule := f.FilerConf.MatchStorageRule(string(entry.FullPath)); rule.Worm {
+		entry.WORMEnforcedAtTsNs = WormCommitTsNs(rule, time.Now())
+	}
+}
+
+// checkObjectLockUpdate checks that a locked object is only replaced if kept as a previous version,
+// and that its compliance retention is not shortened
+func (f *Filer) checkObjectLockUpdate(oldEntry, entry *Entry) error {
+	now := time.Now()
+	if oldEntry.IsDirectory() || !IsObjectLocked(oldEntry.Extended, now) {
+		return nil
+	}
+	if isReplacedBy(oldEntry, entry) {
+		if f.keepsVersionOf(oldEntry) {
+			return nil
+		}
+		return ErrWormEnforced
+	}
+	oldMode, oldRetainUntil, _ := ObjectLockOf(oldEntry.Extended)
+	mode, retainUntil, _ := ObjectLockOf(entry.Extended)
+	if oldMode == ObjectLockModeCompliance && now.Before(oldRetainUntil) &&
+		(mode != ObjectLockModeCompliance || retainUntil.Before(oldRetainUntil)) {
+		return ErrWormEnforced
+	}
+	return nil
+}
+
+// keepsVersionOf tells whether the file would be kept as a previous version if replaced or deleted
+func (f *Filer) keepsVersionOf(entry *Entry) bool {
+	if f.FilerConf == nil || IsVersionPath(entry.FullPath) || len(entry.HardLinkId) != 0 || entry.IsInRemoteOnly() {
+		return false
+	}
+	if _, isS3Versioned := entry.Extended[ExtVersionIdKey]; entry.Size() == 0 && !isS3Versioned {
+		return false
+	}
+	return f.FilerConf.MatchStorageRule(string(entry.FullPath)).MaxVersions != 0
+}
+
+// hasObjectLockBucket checks whether the directory is, or is in, a bucket with the object lock enabled,
+// or keeps the versions of such a bucket
+func (f *Filer) hasObjectLockBucket(ctx context.Context, dir util.FullPath) bool {
+	if IsVersionPath(dir) {
+		dir = util.FullPath(strings.TrimPrefix(string(dir), VersionsDir))
+	}
+	bucketsPath := util.FullPath(f.DirBucketsPath)
+	if !strings.HasPrefix(string(dir), string(bucketsPath)+"/") {
+		return false
+	}
+	bucket, _, _ := strings.Cut(strings.TrimPrefix(string(dir), string(bucketsPath)+"/"), "/")
+	bucketEntry, err := f.FindEntry(ctx, bucketsPath.Child(bucket))
+	if err != nil {
+		return false
+	}
+	_, found := bucketEntry.Extended[ExtObjectLockKey]
+	return found
+}
+
+// CheckWormDeletable checks that the entry, or any file under the directory, is not an immutable write-once file,
+// or a locked object
+func (f *Filer) CheckWormDeletable(ctx context.Context, entry *Entry) error {
+	return f.checkWormDeletable(ctx, entry, false)
+}
+
+// checkWormDeletable also allows deleting the locked objects kept as the previous versions, if keepingVersions
+func (f *Filer) checkWormDeletable(ctx context.Context, entry *Entry, keepingVersions bool) error {
+	if f.FilerConf == nil {
+		return nil
+	}
+	if !entry.IsDirectory() {
+		if f.IsWormEnforced(entry) {
+			return ErrWormEnforced
+		}
+		if IsObjectLocked(entry.Extended, time.Now()) && !(keepingVersions && f.keepsVersionOf(entry)) {
+			return ErrWormEnforced
+		}
+		return nil
+	}
+	if !f.FilerConf.HasWormLocation(string(entry.FullPath)) && !f.hasObjectLockBucket(ctx, entry.FullPath) {
+		return nil
+	}
+	lastFileName := ""
+	for {
+		entries, _, err := f.ListDirectoryEntries(ctx, entry.FullPath, lastFileName, false, PaginationSize, "", "", "")
+		if err != nil {
+			if errors.Is(err, filer_pb.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		for _, sub := range entries {
+			lastFileName = sub.Name()
+			if err = f.checkWormDeletable(ctx, sub, keepingVersions); err != nil {
+				return err
+			}
+		}
+		if len(entries) < PaginationSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package filer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestIsWormEnforced(t *testing.T) {
+	now := time.Now()
+	rule := &filer_pb.FilerConf_PathConf{Worm: true, WormGracePeriodSeconds: 60, WormRetentionTimeSeconds: 3600}
+	committedAt := WormCommitTsNs(rule, now)
+
+	if IsWormEnforced(rule, 0, now) {
+		t.Errorf("the uncommitted file should not be enforced")
+	}
+	if IsWormEnforced(rule, committedAt, now.Add(30*time.Second)) {
+		t.Errorf("the file should be changeable within the grace period")
+	}
+	if !IsWormEnforced(rule, committedAt, now.Add(2*time.Minute)) {
+		t.Errorf("the file should be enforced after the grace period")
+	}
+	if IsWormEnforced(rule, committedAt, now.Add(2*time.Hour)) {
+		t.Errorf("the file should be changeable after the retention")
+	}
+	if retainUntil, isForever := WormRetainUntil(rule, committedAt); isForever || !retainUntil.Equal(now.Add(time.Minute+time.Hour)) {
+		t.Errorf("unexpected retain until %v, forever %v", retainUntil, isForever)
+	}
+
+	forever := &filer_pb.FilerConf_PathConf{Worm: true}
+	if !IsWormEnforced(forever, WormCommitTsNs(forever, now), now.Add(24*365*time.Hour)) {
+		t.Errorf("the file should be enforced forever without the retention")
+	}
+	if IsWormEnforced(&filer_pb.FilerConf_PathConf{}, committedAt, now.Add(2*time.Minute)) {
+		t.Errorf("the file should not be enforced after the location is no longer worm")
+	}
+}
+
+func TestHasWormLocation(t *testing.T) {
+	fc := NewFilerConf()
+	fc.SetLocationConf(&filer_pb.FilerConf_PathConf{LocationPrefix: "/buckets/audit/", Worm: true})
+	fc.SetLocationConf(&filer_pb.FilerConf_PathConf{LocationPrefix: "/data/", Collection: "data"})
+
+	for dir, expected := range map[string]bool{
+		"/buckets/audit/2024": true,
+		"/buckets":            true,
+		"/":                   true,
+		"/data/x":             false,
+		"/buckets/other":      false,
+	} {
+		if found := fc.HasWormLocation(dir); found != expected {
+			t.Errorf("HasWormLocation(%s) = %v, expected %v", dir, found, expected)
+		}
+	}
+}
+
+func TestMaybeCommitWorm(t *testing.T) {
+	fc := NewFilerConf()
+	fc.SetLocationConf(&filer_pb.FilerConf_PathConf{LocationPrefix: "/audit/", Worm: true})
+	f := &Filer{FilerConf: fc}
+
+	empty := &Entry{FullPath: "/audit/empty"}
+	f.maybeCommitWorm(nil, empty)
+	if empty.WORMEnforcedAtTsNs != 0 {
+		t.Errorf("the empty file should not be committed")
+	}
+
+	written := &Entry{FullPath: "/audit/log", Attr: Attr{FileSize: 10}}
+	f.maybeCommitWorm(nil, written)
+	if written.WORMEnforcedAtTsNs == 0 || !f.IsWormEnforced(written) {
+		t.Errorf("the written file should be committed and enforced")
+	}
+
+	// the commit time of the existing file is kept
+	updated := &Entry{FullPath: "/audit/log", Attr: Attr{FileSize: 20}}
+	f.maybeCommitWorm(written, updated)
+	if updated.WORMEnforcedAtTsNs != written.WORMEnforcedAtTsNs {
+		t.Errorf("expected commit time %d, got %d", written.WORMEnforcedAtTsNs, updated.WORMEnforcedAtTsNs)
+	}
+
+	other := &Entry{FullPath: "/other/log", Attr: Attr{FileSize: 10}}
+	f.maybeCommitWorm(nil, other)
+	if other.WORMEnforcedAtTsNs != 0 {
+		t.Errorf("the file outside of the worm location should not be committed")
+	}
+}
+
+func TestObjectLock(t *testing.T) {
+	now := time.Now()
+	lock := func(mode string, retainUntil time.Time, legalHold string) map[string][]byte {
+		return map[string][]byte{
+			ExtObjectLockModeKey:            []byte(mode),
+			ExtObjectLockRetainUntilDateKey: []byte(retainUntil.UTC().Format(time.RFC3339)),
+			ExtObjectLockLegalHoldKey:       []byte(legalHold),
+		}
+	}
+	for _, tt := range []struct {
+		extended map[string][]byte
+		locked   bool
+	}{
+		{nil, false},
+		{lock(ObjectLockModeCompliance, now.Add(time.Hour), ""), true},
+		{lock(ObjectLockModeCompliance, now.Add(-time.Hour), ""), false},
+		{lock(ObjectLockModeGovernance, now.Add(time.Hour), ""), false},
+		{lock("", time.Time{}, ObjectLockLegalHoldOn), true},
+		{lock(ObjectLockModeGovernance, now.Add(time.Hour), "OFF"), false},
+	} {
+		if locked := IsObjectLocked(tt.extended, now); locked != tt.locked {
+			t.Errorf("IsObjectLocked(%s) = %v, expected %v", tt.extended, locked, tt.locked)
+		}
+	}
+
+	fc := NewFilerConf()
+	fc.SetLocationConf(&filer_pb.FilerConf_PathConf{LocationPrefix: "/buckets/versioned/", MaxVersions: 10})
+	f := &Filer{FilerConf: fc}
+	compliance := lock(ObjectLockModeCompliance, now.Add(time.Hour), "")
+	locked := func(p string) *Entry {
+		return &Entry{FullPath: "/buckets/" + util.FullPath(p), Attr: Attr{FileSize: 10}, Content: []byte("0123456789"), Extended: compliance}
+	}
+
+	replaced := locked("versioned/a")
+	replaced.Content = []byte("9876543210")
+	if err := f.checkObjectLockUpdate(locked("versioned/a"), replaced); err != nil {
+		t.Errorf("the locked object kept as a version should be replaced: %v", err)
+	}
+	if err := f.checkObjectLockUpdate(locked("other/a"), &Entry{FullPath: "/buckets/other/a"}); err != ErrWormEnforced {
+		t.Errorf("the locked object not versioned should not be replaced: %v", err)
+	}
+	extended := locked("other/a")
+	extended.Extended = lock(ObjectLockModeCompliance, now.Add(2*time.Hour), "")
+	if err := f.checkObjectLockUpdate(locked("other/a"), extended); err != nil {
+		t.Errorf("the compliance retention should be extended: %v", err)
+	}
+	shortened := locked("other/a")
+	shortened.Extended = lock(ObjectLockModeGovernance, now.Add(2*time.Hour), "")
+	if err := f.checkObjectLockUpdate(locked("other/a"), shortened); err != ErrWormEnforced {
+		t.Errorf("the compliance retention should not be changed to the governance mode: %v", err)
+	}
+
+	if !f.keepsVersionOf(locked("versioned/a")) || f.keepsVersionOf(locked("other/a")) {
+		t.Errorf("only the objects in the versioned location are kept as versions")
+	}
+	if f.keepsVersionOf(&Entry{FullPath: VersionsDirOf("/buckets/versioned/a").Child("1"), Attr: Attr{FileSize: 10}}) {
+		t.Errorf("the versions are not versioned again")
+	}
+}
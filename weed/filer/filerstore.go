@@ -3,6 +3,7 @@ package filer
 import (
 	"context"
 	"errors"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"io"
 )
@@ -14,6 +15,7 @@ var (
 	ErrUnsupportedSuperLargeDirectoryListing = errors.New("unsupported super large directory listing")
 	ErrKvNotImplemented                      = errors.New("kv not implemented yet")
 	ErrKvNotFound                            = errors.New("kv: not found")
+	ErrUnsupportedListSortOrder              = errors.New("unsupported directory listing sort order")
 )
 
 type ListEachEntryFunc func(entry *Entry) bool
@@ -49,6 +51,19 @@ type BucketAware interface {
 	CanDropWholeBucket() bool
 }
 
+// BulkInsertable stores insert many new entries faster than one by one, e.g., for initial migrations.
+// The entries should not exist in the store yet.
+type BulkInsertable interface {
+	BulkInsertEntries(ctx context.Context, entries []*Entry) error
+}
+
+// SortedListable stores list a directory in other orders than by name natively,
+// instead of the filer reading and sorting all the entries.
+// ErrUnsupportedListSortOrder is returned for the orders not supported natively.
+type SortedListable interface {
+	ListDirectorySortedEntries(ctx context.Context, dirPath util.FullPath, startFileName string, includeStartFile bool, limit int64, prefix string, order filer_pb.ListEntriesSortOrder, eachEntryFunc ListEachEntryFunc) (lastFileName string, err error)
+}
+
 type Debuggable interface {
 	Debug(writer io.Writer)
 }
@@ -110,3 +110,56 @@ func BenchmarkInsertEntry(b *testing.B) {
 		store.InsertEntry(ctx, entry)
 	}
 }
+
+func TestCreateHardLink(t *testing.T) {
+	testFiler := filer.NewFiler(pb.ServerDiscovery{}, nil, "", "", "", "", "", 255, nil)
+	dir := t.TempDir()
+	store := &LevelDBStore{}
+	store.initialize(dir)
+	testFiler.SetStore(store)
+
+	ctx := context.Background()
+	file := &filer.Entry{
+		FullPath: "/home/chris/file1.jpg",
+		Attr:     filer.Attr{Mode: 0644, Mtime: time.Now()},
+		Content:  []byte("hello"),
+	}
+	if err := testFiler.CreateEntry(ctx, file, false, false, nil, false, testFiler.MaxFilenameLength); err != nil {
+		t.Fatalf("create entry %v: %v", file.FullPath, err)
+	}
+
+	for i, linkPath := range []util.FullPath{"/home/chris/link1.jpg", "/backup/link2.jpg"} {
+		linked, link, err := testFiler.CreateHardLink(ctx, file.FullPath, linkPath, nil)
+		if err != nil {
+			t.Fatalf("link %s: %v", linkPath, err)
+		}
+		if linked.HardLinkCounter != int32(i+2) || link.HardLinkCounter != linked.HardLinkCounter {
+			t.Errorf("unexpected link counters %d %d", linked.HardLinkCounter, link.HardLinkCounter)
+		}
+	}
+	if _, _, err := testFiler.CreateHardLink(ctx, file.FullPath, "/backup/link2.jpg", nil); err != filer.ErrEntryExists {
+		t.Errorf("expected existing link, got %v", err)
+	}
+	if _, _, err := testFiler.CreateHardLink(ctx, "/home/chris", "/backup/chris", nil); err == nil {
+		t.Errorf("expected no hard link to a directory")
+	}
+
+	// all the links share the attributes and the content
+	for _, p := range []util.FullPath{"/home/chris/file1.jpg", "/home/chris/link1.jpg", "/backup/link2.jpg"} {
+		entry, err := testFiler.FindEntry(ctx, p)
+		if err != nil {
+			t.Fatalf("find %s: %v", p, err)
+		}
+		if entry.HardLinkCounter != 3 || string(entry.Content) != "hello" {
+			t.Errorf("%s: unexpected counter %d content %q", p, entry.HardLinkCounter, entry.Content)
+		}
+	}
+
+	if err := testFiler.DeleteEntryMetaAndData(ctx, "/home/chris/link1.jpg", false, false, false, false, nil, 0); err != nil {
+		t.Fatalf("delete link: %v", err)
+	}
+	entry, err := testFiler.FindEntry(ctx, "/backup/link2.jpg")
+	if err != nil || entry.HardLinkCounter != 2 {
+		t.Errorf("unexpected counter after unlink: %v %v", entry, err)
+	}
+}
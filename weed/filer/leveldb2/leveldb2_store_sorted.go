@@ -0,0 +1,59 @@
+package leveldb
+
+import (
+	"bytes"
+	"context"
+
+	leveldb_util "github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	weed_util "github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// ListDirectorySortedEntries lists by name in descending order, by iterating the directory keys backwards
+func (store *LevelDB2Store) ListDirectorySortedEntries(ctx context.Context, dirPath weed_util.FullPath, startFileName string, includeStartFile bool, limit int64, prefix string, order filer_pb.ListEntriesSortOrder, eachEntryFunc filer.ListEachEntryFunc) (lastFileName string, err error) {
+	if order != filer_pb.ListEntriesSortOrder_NAME_DESC {
+		return "", filer.ErrUnsupportedListSortOrder
+	}
+
+	directoryPrefix, partitionId := genDirectoryKeyPrefix(dirPath, prefix, store.dbCount)
+	keyRange := leveldb_util.BytesPrefix(directoryPrefix)
+	if startFileName != "" {
+		lastFileLimit, _ := genDirectoryKeyPrefix(dirPath, startFileName, store.dbCount)
+		if includeStartFile {
+			lastFileLimit = append(lastFileLimit, 0)
+		}
+		if bytes.Compare(lastFileLimit, keyRange.Limit) < 0 {
+			keyRange.Limit = lastFileLimit
+		}
+	}
+
+	iter := store.dbs[partitionId].NewIterator(keyRange, nil)
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		fileName := getNameFromKey(iter.Key())
+		if fileName == "" {
+			continue
+		}
+		limit--
+		if limit < 0 {
+			break
+		}
+		lastFileName = fileName
+		entry := &filer.Entry{
+			FullPath: weed_util.NewFullPath(string(dirPath), fileName),
+		}
+		if decodeErr := entry.DecodeAttributesAndChunks(weed_util.MaybeDecompressData(iter.Value())); decodeErr != nil {
+			err = decodeErr
+			glog.V(0).Infof("list %s : %v", entry.FullPath, err)
+			break
+		}
+		if !eachEntryFunc(entry) {
+			break
+		}
+	}
+	iter.Release()
+
+	return lastFileName, err
+}
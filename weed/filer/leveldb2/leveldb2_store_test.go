@@ -3,7 +3,9 @@ package leveldb
 import (
 	"context"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"testing"
+	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -83,3 +85,57 @@ func TestEmptyRoot(t *testing.T) {
 	}
 
 }
+
+func TestListSortedEntries(t *testing.T) {
+	testFiler := filer.NewFiler(pb.ServerDiscovery{}, nil, "", "", "", "", "", 255, nil)
+	dir := t.TempDir()
+	store := &LevelDB2Store{}
+	store.initialize(dir, 2)
+	testFiler.SetStore(store)
+
+	ctx := context.Background()
+	now := time.Now()
+	for i, name := range []string{"a", "b", "c", "d"} {
+		entry := &filer.Entry{
+			FullPath: util.NewFullPath("/sorted", name),
+			Attr: filer.Attr{
+				Mode:     0644,
+				Mtime:    now.Add(-time.Duration(i) * time.Minute), // "a" is the newest
+				FileSize: uint64(i % 2),
+			},
+		}
+		if err := testFiler.CreateEntry(ctx, entry, false, false, nil, false, testFiler.MaxFilenameLength); err != nil {
+			t.Fatalf("create entry %v: %v", entry.FullPath, err)
+		}
+	}
+
+	list := func(startFileName string, limit int64, order filer_pb.ListEntriesSortOrder) (names string) {
+		err := testFiler.StreamListSortedDirectoryEntries(ctx, "/sorted", startFileName, false, limit, "", order, func(entry *filer.Entry) bool {
+			names += entry.Name()
+			return true
+		})
+		if err != nil {
+			t.Fatalf("list sorted by %v: %v", order, err)
+		}
+		return
+	}
+
+	for _, tc := range []struct {
+		order         filer_pb.ListEntriesSortOrder
+		startFileName string
+		limit         int64
+		expected      string
+	}{
+		{filer_pb.ListEntriesSortOrder_NAME, "", 10, "abcd"},
+		{filer_pb.ListEntriesSortOrder_NAME_DESC, "", 10, "dcba"},
+		{filer_pb.ListEntriesSortOrder_NAME_DESC, "c", 10, "ba"},
+		{filer_pb.ListEntriesSortOrder_MTIME, "", 10, "dcba"},
+		{filer_pb.ListEntriesSortOrder_MTIME_DESC, "", 3, "abc"},
+		{filer_pb.ListEntriesSortOrder_SIZE, "", 10, "acbd"},
+		{filer_pb.ListEntriesSortOrder_SIZE_DESC, "d", 2, "bc"},
+	} {
+		if names := list(tc.startFileName, tc.limit, tc.order); names != tc.expected {
+			t.Errorf("list sorted by %v from %q: %s, expected %s", tc.order, tc.startFileName, names, tc.expected)
+		}
+	}
+}
@@ -142,6 +142,8 @@ func (ma *MetaAggregator) doSubscribeToOneFiler(f *Filer, self pb.ServerAddress,
 				glog.Errorf("failed to reply metadata change from %v: %v", peer, err)
 				return
 			}
+			f.onDirQuotaReplay(event)
+			f.onDirStatsEntryChange(entriesOfEvent(event))
 			counter++
 			if lastPersistTime.Add(time.Minute).Before(time.Now()) {
 				if err := ma.updateOffset(f, peer, peerSignature, event.TsNs); err == nil {
@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/seaweedfs/seaweedfs/weed/filer/abstract_sql"
+)
+
+var (
+	_ = abstract_sql.SqlBulkLoader(&SqlGenMysql{})
+
+	bulkLoadCounter int64
+)
+
+// BulkLoad sends the rows with LOAD DATA LOCAL INFILE, which needs local_infile enabled on the server.
+// The rows are tab separated, and the binary meta is hex encoded.
+func (gen *SqlGenMysql) BulkLoad(ctx context.Context, tx *sql.Tx, tableName string, rows []*abstract_sql.SqlRow) error {
+	var buf bytes.Buffer
+	for _, row := range rows {
+		buf.WriteString(strconv.FormatInt(row.DirHash, 10))
+		buf.WriteByte('\t')
+		writeLoadDataField(&buf, row.Name)
+		buf.WriteByte('\t')
+		writeLoadDataField(&buf, row.Directory)
+		buf.WriteByte('\t')
+		buf.WriteString(hex.EncodeToString(row.Meta))
+		buf.WriteByte('\n')
+	}
+
+	readerName := fmt.Sprintf("seaweedfs_bulk_load_%d", atomic.AddInt64(&bulkLoadCounter, 1))
+	mysql.RegisterReaderHandler(readerName, func() io.Reader {
+		return bytes.NewReader(buf.Bytes())
+	})
+	defer mysql.DeregisterReaderHandler(readerName)
+
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE `%s` CHARACTER SET utf8mb4 "+
+		"(`dirhash`,`name`,`directory`,@meta) SET `meta` = UNHEX(@meta)", readerName, tableName))
+	return err
+}
+
+// writeLoadDataField escapes the field with the default LOAD DATA escape character
+func writeLoadDataField(buf *bytes.Buffer, field string) {
+	for i := 0; i < len(field); i++ {
+		switch c := field[i]; c {
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case 0:
+			buf.WriteString(`\0`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+//go:build mysql
+// +build mysql
+
+package mysql
+
+import (
+	"os"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer/store_test"
+)
+
+// TestBulkLoad loads the entries with LOAD DATA LOCAL INFILE into a real mysql server, e.g.,
+//
+//	MYSQL_DSN="root:secret@tcp(localhost:3306)/seaweedfs?allowAllFiles=true" go test -tags mysql ./weed/filer/mysql/
+//
+// The filemeta table should be created as in the scaffold filer.toml, and local_infile enabled on the server.
+func TestBulkLoad(t *testing.T) {
+	dsn := os.Getenv("MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_DSN is not set")
+	}
+	store := &MysqlStore{}
+	if err := store.initialize(dsn, "", false, "", "", "", 0, "", 2, 2, 60, false); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	defer store.Shutdown()
+
+	store_test.TestBulkInsert(t, store, []string{"tab\tname", "new\nline", `back\slash`, `\N`, "nul\x00byte"})
+}
@@ -0,0 +1,26 @@
+package mysql
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteLoadDataField(t *testing.T) {
+	for field, expected := range map[string]string{
+		"plain.txt":     "plain.txt",
+		"tab\tname":     `tab\tname`,
+		"new\nline":     `new\nline`,
+		"carriage\rret": `carriage\rret`,
+		`back\slash`:    `back\\slash`,
+		`\N`:            `\\N`,
+		"nul\x00byte":   `nul\0byte`,
+		"文件 名":          "文件 名",
+		"":              "",
+	} {
+		var buf bytes.Buffer
+		writeLoadDataField(&buf, field)
+		if buf.String() != expected {
+			t.Errorf("field %q escaped as %q, expected %q", field, buf.String(), expected)
+		}
+	}
+}
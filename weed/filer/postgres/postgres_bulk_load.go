@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/seaweedfs/seaweedfs/weed/filer/abstract_sql"
+)
+
+var (
+	_ = abstract_sql.SqlBulkLoader(&SqlGenPostgres{})
+	_ = abstract_sql.SqlCursorGenerator(&SqlGenPostgres{})
+)
+
+// BulkLoad streams the rows with COPY, which skips the per statement overhead of inserts
+func (gen *SqlGenPostgres) BulkLoad(ctx context.Context, tx *sql.Tx, tableName string, rows []*abstract_sql.SqlRow) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(tableName, "dirhash", "name", "directory", "meta"))
+	if err != nil {
+		return fmt.Errorf("copy in: %v", err)
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err = stmt.ExecContext(ctx, row.DirHash, row.Name, row.Directory, row.Meta); err != nil {
+			return fmt.Errorf("copy %s/%s: %v", row.Directory, row.Name, err)
+		}
+	}
+	// flush the buffered rows
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("copy flush: %v", err)
+	}
+	return nil
+}
+
+func (gen *SqlGenPostgres) GetSqlDeclareCursor(cursorName, query string) string {
+	return fmt.Sprintf(`DECLARE %s NO SCROLL CURSOR FOR %s`, cursorName, query)
+}
+
+func (gen *SqlGenPostgres) GetSqlFetchCursor(cursorName string, count int) string {
+	return fmt.Sprintf(`FETCH FORWARD %d FROM %s`, count, cursorName)
+}
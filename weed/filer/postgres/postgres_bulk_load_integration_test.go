@@ -0,0 +1,35 @@
+//go:build postgres
+// +build postgres
+
+package postgres
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer/store_test"
+)
+
+// TestBulkLoad loads the entries with COPY into a real postgres server, and lists them with a cursor, e.g.,
+//
+//	POSTGRES_HOST=localhost POSTGRES_PORT=5432 POSTGRES_USER=postgres POSTGRES_PASSWORD=secret POSTGRES_DB=seaweedfs \
+//	  go test -tags postgres ./weed/filer/postgres/
+//
+// The filemeta table should be created as in the scaffold filer.toml.
+func TestBulkLoad(t *testing.T) {
+	hostname := os.Getenv("POSTGRES_HOST")
+	if hostname == "" {
+		t.Skip("POSTGRES_HOST is not set")
+	}
+	port, _ := strconv.Atoi(os.Getenv("POSTGRES_PORT"))
+	store := &PostgresStore{}
+	if err := store.initialize("", false, os.Getenv("POSTGRES_USER"), os.Getenv("POSTGRES_PASSWORD"), hostname, port,
+		os.Getenv("POSTGRES_DB"), "", "disable", 2, 2, 60); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	defer store.Shutdown()
+
+	// the postgres text can not hold the nul bytes
+	store_test.TestBulkInsert(t, store, []string{"tab\tname", "new\nline", `back\slash`, `\N`})
+}
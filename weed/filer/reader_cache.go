@@ -34,6 +34,7 @@ type SingleChunkCacher struct {
 	shouldCache    bool
 	wg             sync.WaitGroup
 	cacheStartedCh chan struct{}
+	readAhead      atomic.Pointer[ReadAhead] // set if prefetched by the read ahead, until the data is released
 }
 
 func NewReaderCache(limit int, chunkCache chunk_cache.ChunkCache, lookupFileIdFn wdclient.LookupFileIdFunctionType) *ReaderCache {
@@ -85,6 +86,47 @@ func (rc *ReaderCache) MaybeCache(chunkViews *Interval[*ChunkView]) {
 	return
 }
 
+// prefetch starts downloading the chunk for the read ahead, unless it is already downloaded or cached.
+// isLimited is true if no more chunks can be prefetched for now.
+func (rc *ReaderCache) prefetch(chunkView *ChunkView, readAhead *ReadAhead) (isLimited bool) {
+	if rc.lookupFileIdFn == nil {
+		return true
+	}
+
+	rc.Lock()
+	defer rc.Unlock()
+
+	if _, found := rc.downloaders[chunkView.FileId]; found {
+		return false
+	}
+	if rc.chunkCache.IsInCache(chunkView.FileId, true) {
+		return false
+	}
+	if len(rc.downloaders) >= rc.limit || !readAhead.reserve(int64(chunkView.ChunkSize)) {
+		return true
+	}
+
+	shouldCache := (uint64(chunkView.ViewOffset) + chunkView.ChunkSize) <= rc.chunkCache.GetMaxFilePartSizeInCache()
+	cacher := newSingleChunkCacher(rc, chunkView.FileId, chunkView.CipherKey, chunkView.IsGzipped, int(chunkView.ChunkSize), shouldCache)
+	cacher.readAhead.Store(readAhead)
+	go func() {
+		defer readAhead.downloaded()
+		cacher.startCaching()
+	}()
+	<-cacher.cacheStartedCh
+	rc.downloaders[chunkView.FileId] = cacher
+	return false
+}
+
+func (rc *ReaderCache) releaseReadAhead() {
+	rc.Lock()
+	defer rc.Unlock()
+
+	for _, downloader := range rc.downloaders {
+		downloader.releaseReadAhead()
+	}
+}
+
 func (rc *ReaderCache) ReadChunkAt(buffer []byte, fileId string, cipherKey []byte, isGzipped bool, offset int64, chunkSize int, shouldCache bool) (int, error) {
 	rc.Lock()
 
@@ -94,7 +136,8 @@ func (rc *ReaderCache) ReadChunkAt(buffer []byte, fileId string, cipherKey []byt
 			return n, err
 		}
 	}
-	if shouldCache || rc.lookupFileIdFn == nil {
+	// the chunks larger than the cacheable size may still be cached, e.g., pinned in the mount
+	if shouldCache || rc.lookupFileIdFn == nil || rc.chunkCache.IsInCache(fileId, true) {
 		n, err := rc.chunkCache.ReadChunkAt(buffer, fileId, uint64(offset))
 		if n > 0 {
 			rc.Unlock()
@@ -120,6 +163,11 @@ func (rc *ReaderCache) ReadChunkAt(buffer []byte, fileId string, cipherKey []byt
 
 	// glog.V(4).Infof("cache1 %s", fileId)
 
+	// the failed download is replaced
+	if cacher, found := rc.downloaders[fileId]; found {
+		cacher.releaseReadAhead()
+	}
+
 	cacher := newSingleChunkCacher(rc, fileId, cipherKey, isGzipped, chunkSize, shouldCache)
 	go cacher.startCaching()
 	<-cacher.cacheStartedCh
@@ -193,6 +241,8 @@ func (s *SingleChunkCacher) startCaching() {
 }
 
 func (s *SingleChunkCacher) destroy() {
+	s.releaseReadAhead()
+
 	// wait for all reads to finish before destroying the data
 	s.wg.Wait()
 	s.Lock()
@@ -205,6 +255,12 @@ func (s *SingleChunkCacher) destroy() {
 	}
 }
 
+func (s *SingleChunkCacher) releaseReadAhead() {
+	if readAhead := s.readAhead.Swap(nil); readAhead != nil {
+		readAhead.release(int64(s.chunkSize))
+	}
+}
+
 func (s *SingleChunkCacher) readChunkAt(buf []byte, offset int64) (int, error) {
 	s.wg.Add(1)
 	defer s.wg.Done()
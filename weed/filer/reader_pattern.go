@@ -39,3 +39,7 @@ func (rp *ReaderPattern) MonitorReadAt(offset int64, size int) {
 func (rp *ReaderPattern) IsRandomMode() bool {
 	return atomic.LoadInt64(&rp.isSequentialCounter) < 0
 }
+
+func (rp *ReaderPattern) IsSequentialMode() bool {
+	return atomic.LoadInt64(&rp.isSequentialCounter) > 0
+}
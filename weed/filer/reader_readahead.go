@@ -0,0 +1,132 @@
+package filer
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ReadAhead prefetches the chunks following the sequential reads in parallel.
+// The number of downloads and the prefetched bytes are limited across all the files sharing it.
+type ReadAhead struct {
+	maxChunks   int
+	concurrency int64
+	memoryLimit int64
+	inflight    atomic.Int64
+	memoryUsed  atomic.Int64
+}
+
+// NewReadAhead returns nil to disable the read ahead if any limit is not positive
+func NewReadAhead(maxChunks, concurrency int, memoryLimit int64) *ReadAhead {
+	if maxChunks <= 0 || concurrency <= 0 || memoryLimit <= 0 {
+		return nil
+	}
+	return &ReadAhead{
+		maxChunks:   maxChunks,
+		concurrency: int64(concurrency),
+		memoryLimit: memoryLimit,
+	}
+}
+
+func (ra *ReadAhead) reserve(size int64) bool {
+	if ra.inflight.Add(1) > ra.concurrency {
+		ra.inflight.Add(-1)
+		return false
+	}
+	if ra.memoryUsed.Add(size) > ra.memoryLimit {
+		ra.memoryUsed.Add(-size)
+		ra.inflight.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (ra *ReadAhead) downloaded() {
+	ra.inflight.Add(-1)
+}
+
+func (ra *ReadAhead) release(size int64) {
+	ra.memoryUsed.Add(-size)
+}
+
+// readAheadWindow adapts the number of chunks to prefetch for one file:
+// it starts with one chunk on sequential reads, doubles every time the reads reach
+// the last chunk of the window, and resets on random reads.
+type readAheadWindow struct {
+	sync.Mutex
+	pattern   *ReaderPattern
+	size      int
+	lastStart int64 // the offset of the last chunk in the window
+}
+
+func (group *ChunkGroup) SetReadAhead(readAhead *ReadAhead) {
+	group.readAhead = readAhead
+}
+
+// ReleaseReadAhead returns the prefetched bytes to the read ahead limit, when the chunk group is no longer used
+func (group *ChunkGroup) ReleaseReadAhead() {
+	if group.readAhead != nil {
+		group.readerCache.releaseReadAhead()
+	}
+}
+
+func (group *ChunkGroup) maybeReadAhead(fileSize int64, offset int64, size int) {
+	if group.readAhead == nil {
+		return
+	}
+
+	w := &group.readAheadWindow
+	w.Lock()
+	defer w.Unlock()
+
+	if w.pattern == nil {
+		w.pattern = NewReaderPattern()
+	}
+	w.pattern.MonitorReadAt(offset, size)
+	if !w.pattern.IsSequentialMode() {
+		w.size, w.lastStart = 0, 0
+		return
+	}
+
+	readStop := offset + int64(size)
+	if w.size == 0 {
+		w.size = 1
+	} else if readStop > w.lastStart {
+		// the reads have caught up with the prefetched chunks
+		if w.size *= 2; w.size > group.readAhead.maxChunks {
+			w.size = group.readAhead.maxChunks
+		}
+	}
+
+	for _, chunkView := range group.chunkViewsAfter(fileSize, readStop, w.size) {
+		w.lastStart = chunkView.ViewOffset
+		if isLimited := group.readerCache.prefetch(chunkView, group.readAhead); isLimited {
+			break
+		}
+	}
+}
+
+// chunkViewsAfter returns the views of the next chunks, starting from the one containing the offset
+func (group *ChunkGroup) chunkViewsAfter(fileSize int64, offset int64, count int) (chunkViews []*ChunkView) {
+	for si := SectionIndex(offset / SectionSize); int64(si)*SectionSize < fileSize && len(chunkViews) < count; si++ {
+		section, found := group.sections[si]
+		if !found {
+			continue
+		}
+		section.setupForRead(group, fileSize)
+		section.lock.RLock()
+		section.chunkViews.Lock.RLock()
+		for x := section.chunkViews.Front(); x != nil && len(chunkViews) < count; x = x.Next {
+			chunkView := x.Value
+			if chunkView.ViewOffset+int64(chunkView.ViewSize) <= offset {
+				continue
+			}
+			if len(chunkViews) > 0 && chunkViews[len(chunkViews)-1].FileId == chunkView.FileId {
+				continue
+			}
+			chunkViews = append(chunkViews, chunkView)
+		}
+		section.chunkViews.Lock.RUnlock()
+		section.lock.RUnlock()
+	}
+	return
+}
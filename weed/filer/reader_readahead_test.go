@@ -0,0 +1,115 @@
+package filer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+const testReadAheadChunkSize = 1024
+
+// newReadAheadTestGroup serves the chunks "1" to "<chunkCount>", each filled with its own number
+func newReadAheadTestGroup(t *testing.T, chunkCount int, readAhead *ReadAhead) (*ChunkGroup, map[string]int, *sync.Mutex) {
+	util_http.InitGlobalHttpClient()
+
+	var lock sync.Mutex
+	downloads := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fileId := strings.TrimPrefix(r.URL.Path, "/")
+		x, _ := strconv.Atoi(fileId)
+		lock.Lock()
+		downloads[fileId]++
+		lock.Unlock()
+		w.Write(bytes.Repeat([]byte{byte(x)}, testReadAheadChunkSize))
+	}))
+	t.Cleanup(server.Close)
+
+	lookupFn := func(fileId string) ([]string, error) {
+		return []string{fmt.Sprintf("%s/%s", server.URL, fileId)}, nil
+	}
+	var chunks []*filer_pb.FileChunk
+	for i := 0; i < chunkCount; i++ {
+		chunks = append(chunks, &filer_pb.FileChunk{
+			FileId:       strconv.Itoa(i + 1),
+			Offset:       int64(i * testReadAheadChunkSize),
+			Size:         testReadAheadChunkSize,
+			ModifiedTsNs: 1,
+		})
+	}
+	group, err := NewChunkGroup(lookupFn, (*chunk_cache.TieredChunkCache)(nil), chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group.SetReadAhead(readAhead)
+	return group, downloads, &lock
+}
+
+func TestReadAheadSequentialReads(t *testing.T) {
+	const chunkCount = 16
+	readAhead := NewReadAhead(4, 2, 3*testReadAheadChunkSize)
+	group, downloads, lock := newReadAheadTestGroup(t, chunkCount, readAhead)
+
+	fileSize := int64(chunkCount * testReadAheadChunkSize)
+	buff := make([]byte, 256)
+	for offset := int64(0); offset < fileSize; offset += int64(len(buff)) {
+		n, _, err := group.ReadDataAt(fileSize, buff, offset)
+		if (err != nil && err != io.EOF) || n != len(buff) {
+			t.Fatalf("read at %d: %d %v", offset, n, err)
+		}
+		if expected := byte(offset/testReadAheadChunkSize + 1); buff[0] != expected || buff[n-1] != expected {
+			t.Fatalf("read at %d: expected %d, got %d", offset, expected, buff[0])
+		}
+		if used := readAhead.memoryUsed.Load(); used > readAhead.memoryLimit {
+			t.Fatalf("prefetched %d bytes over the limit", used)
+		}
+		if inflight := readAhead.inflight.Load(); inflight > readAhead.concurrency {
+			t.Fatalf("%d prefetches in parallel", inflight)
+		}
+	}
+
+	if group.readAheadWindow.size != 4 {
+		t.Errorf("the window should grow to 4 chunks, got %d", group.readAheadWindow.size)
+	}
+	lock.Lock()
+	for fileId, count := range downloads {
+		if count != 1 {
+			t.Errorf("chunk %s downloaded %d times", fileId, count)
+		}
+	}
+	lock.Unlock()
+
+	group.ReleaseReadAhead()
+	if used := readAhead.memoryUsed.Load(); used != 0 {
+		t.Errorf("prefetched %d bytes after releasing", used)
+	}
+}
+
+func TestReadAheadRandomReads(t *testing.T) {
+	const chunkCount = 8
+	readAhead := NewReadAhead(4, 4, 1024*1024)
+	group, downloads, lock := newReadAheadTestGroup(t, chunkCount, readAhead)
+
+	fileSize := int64(chunkCount * testReadAheadChunkSize)
+	buff := make([]byte, 100)
+	for _, chunkIndex := range []int64{6, 2, 4} {
+		if _, _, err := group.ReadDataAt(fileSize, buff, chunkIndex*testReadAheadChunkSize+500); err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(downloads) != 3 || group.readAheadWindow.size != 0 {
+		t.Errorf("random reads should not prefetch, downloaded %v", downloads)
+	}
+}
@@ -1,4 +1,4 @@
-package filer
+package filer_test
 
 import (
 	"bytes"
@@ -6,6 +6,7 @@ import (
 
 	. "github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/pb/iam_pb"
 
 	"github.com/stretchr/testify/assert"
@@ -45,10 +46,10 @@ func TestS3Conf(t *testing.T) {
 		},
 	}
 	var buf bytes.Buffer
-	err := ProtoToText(&buf, s3Conf)
+	err := filer.ProtoToText(&buf, s3Conf)
 	assert.Equal(t, err, nil)
 	s3ConfSaved := &iam_pb.S3ApiConfiguration{}
-	err = ParseS3ConfigurationFromBytes(buf.Bytes(), s3ConfSaved)
+	err = filer.ParseS3ConfigurationFromBytes(buf.Bytes(), s3ConfSaved)
 	assert.Equal(t, err, nil)
 
 	assert.Equal(t, "some_name", s3ConfSaved.Identities[0].Name)
@@ -169,7 +170,7 @@ func TestCheckDuplicateAccessKey(t *testing.T) {
 		},
 	}
 	for i, test := range tests {
-		err := CheckDuplicateAccessKey(test.s3cfg)
+		err := filer.CheckDuplicateAccessKey(test.s3cfg)
 		var errString string
 		if err == nil {
 			errString = ""
@@ -0,0 +1,53 @@
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBulkInsert bulk inserts the entries with the names to escape, and more entries than listed at once
+// by the stores with cursors, then finds and lists them
+func TestBulkInsert(t *testing.T, store filer.FilerStore, names []string) {
+	bulkStore, isBulkInsertable := store.(filer.BulkInsertable)
+	if !assert.True(t, isBulkInsertable, "bulk insertable") {
+		return
+	}
+	ctx := context.Background()
+	dir := util.FullPath(fmt.Sprintf("/bulk_insert_test/%d", time.Now().UnixNano()))
+	defer store.DeleteFolderChildren(ctx, dir)
+
+	var entries []*filer.Entry
+	for _, name := range names {
+		entries = append(entries, makeEntry(dir.Child(name), false))
+	}
+	for i := 0; i < 12000; i++ {
+		entries = append(entries, makeEntry(dir.Child(fmt.Sprintf("f%05d", i)), false))
+	}
+	for start := 0; start < len(entries); start += 5000 {
+		err := bulkStore.BulkInsertEntries(ctx, entries[start:min(start+5000, len(entries))])
+		if !assert.Nil(t, err, "bulk insert") {
+			return
+		}
+	}
+
+	for _, name := range names {
+		entry, err := store.FindEntry(ctx, dir.Child(name))
+		if assert.Nil(t, err, "find %q", name) {
+			assert.Equal(t, name, entry.Name(), "found name")
+		}
+	}
+
+	var counter int
+	_, err := store.ListDirectoryEntries(ctx, dir, "", false, 20000, func(entry *filer.Entry) bool {
+		counter++
+		return true
+	})
+	assert.Nil(t, err, "list directory")
+	assert.Equal(t, len(entries), counter, "directory list counter")
+}
@@ -12,6 +12,7 @@ import (
 type FilerClientAccessor struct {
 	GetFiler          func() pb.ServerAddress
 	GetGrpcDialOption func() grpc.DialOption
+	TopicConfCache    *TopicConfCache // optional
 }
 
 func (fca *FilerClientAccessor) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
@@ -23,13 +24,34 @@ func (fca *FilerClientAccessor) SaveTopicConfToFiler(t topic.Topic, conf *mq_pb.
 	glog.V(0).Infof("save conf for topic %v to filer", t)
 
 	// save the topic configuration on filer
-	return fca.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+	if err := fca.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
 		return t.WriteConfFile(client, conf)
-	})
+	}); err != nil {
+		if fca.TopicConfCache != nil {
+			fca.TopicConfCache.Invalidate(t)
+		}
+		return err
+	}
+
+	if fca.TopicConfCache != nil {
+		fca.TopicConfCache.Put(t, conf)
+	}
+	return nil
 }
 
 func (fca *FilerClientAccessor) ReadTopicConfFromFiler(t topic.Topic) (conf *mq_pb.ConfigureTopicResponse, err error) {
 
+	if fca.TopicConfCache != nil {
+		return fca.TopicConfCache.Get(t, func() (*mq_pb.ConfigureTopicResponse, error) {
+			return fca.readTopicConfFromFiler(t)
+		})
+	}
+
+	return fca.readTopicConfFromFiler(t)
+}
+
+func (fca *FilerClientAccessor) readTopicConfFromFiler(t topic.Topic) (conf *mq_pb.ConfigureTopicResponse, err error) {
+
 	glog.V(1).Infof("load conf for topic %v from filer", t)
 
 	if err = fca.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
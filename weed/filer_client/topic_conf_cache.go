@@ -0,0 +1,113 @@
+package filer_client
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+)
+
+// TopicConfCache caches topic.conf for a short time, so that many partitions
+// loading at the same time do not all read the same file from the filer.
+// Entries are invalidated by filer metadata changes under /topics, and the ttl
+// is a safety net in case some metadata change is missed.
+type TopicConfCache struct {
+	ttl       time.Duration
+	entries   map[topic.Topic]*topicConfCacheEntry
+	lock      sync.RWMutex
+	loadGroup singleflight.Group
+
+	// optional, called after the topic.conf is changed on the filer
+	OnTopicConfChange func(t topic.Topic)
+}
+
+type topicConfCacheEntry struct {
+	conf     *mq_pb.ConfigureTopicResponse
+	expireAt time.Time
+}
+
+func NewTopicConfCache(ttl time.Duration) *TopicConfCache {
+	return &TopicConfCache{
+		ttl:     ttl,
+		entries: make(map[topic.Topic]*topicConfCacheEntry),
+	}
+}
+
+// Get returns a copy of the cached conf, or loads it once for all concurrent callers
+func (c *TopicConfCache) Get(t topic.Topic, loadFn func() (*mq_pb.ConfigureTopicResponse, error)) (*mq_pb.ConfigureTopicResponse, error) {
+	c.lock.RLock()
+	entry, found := c.entries[t]
+	c.lock.RUnlock()
+	if found && time.Now().Before(entry.expireAt) {
+		return proto.Clone(entry.conf).(*mq_pb.ConfigureTopicResponse), nil
+	}
+
+	loaded, err, _ := c.loadGroup.Do(t.String(), func() (interface{}, error) {
+		conf, err := loadFn()
+		if err != nil {
+			return nil, err
+		}
+		c.Put(t, conf)
+		return conf, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(loaded.(*mq_pb.ConfigureTopicResponse)).(*mq_pb.ConfigureTopicResponse), nil
+}
+
+func (c *TopicConfCache) Put(t topic.Topic, conf *mq_pb.ConfigureTopicResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[t] = &topicConfCacheEntry{
+		conf:     proto.Clone(conf).(*mq_pb.ConfigureTopicResponse),
+		expireAt: time.Now().Add(c.ttl),
+	}
+}
+
+func (c *TopicConfCache) Invalidate(t topic.Topic) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, t)
+}
+
+// OnMetadataChange invalidates the topic whose topic.conf is changed, renamed, or deleted
+func (c *TopicConfCache) OnMetadataChange(resp *filer_pb.SubscribeMetadataResponse) error {
+	message := resp.EventNotification
+	if message == nil {
+		return nil
+	}
+	changed := make(map[topic.Topic]struct{})
+	if message.OldEntry != nil && message.OldEntry.Name == filer.TopicConfFile {
+		c.invalidateDir(resp.Directory, changed)
+	}
+	if message.NewEntry != nil && message.NewEntry.Name == filer.TopicConfFile {
+		c.invalidateDir(message.NewParentPath, changed)
+		c.invalidateDir(resp.Directory, changed)
+	}
+	if c.OnTopicConfChange != nil {
+		for t := range changed {
+			c.OnTopicConfChange(t)
+		}
+	}
+	return nil
+}
+
+// dir is like /topics/<namespace>/<name>
+func (c *TopicConfCache) invalidateDir(dir string, changed map[topic.Topic]struct{}) {
+	parts := strings.Split(strings.TrimPrefix(dir, filer.TopicsDir+"/"), "/")
+	if len(parts) != 2 {
+		return
+	}
+	t := topic.NewTopic(parts[0], parts[1])
+	glog.V(2).Infof("invalidate cached conf for topic %v", t)
+	c.Invalidate(t)
+	changed[t] = struct{}{}
+}
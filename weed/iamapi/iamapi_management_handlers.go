@@ -34,6 +34,8 @@ const (
 	StatementActionList     = "List*"
 	StatementActionTagging  = "Tagging*"
 	StatementActionDelete   = "DeleteBucket*"
+
+	StatementActionBypassGovernanceRetention = "BypassGovernanceRetention"
 )
 
 var (
@@ -61,6 +63,8 @@ func MapToStatementAction(action string) string {
 		return s3_constants.ACTION_TAGGING
 	case StatementActionDelete:
 		return s3_constants.ACTION_DELETE_BUCKET
+	case StatementActionBypassGovernanceRetention:
+		return s3_constants.ACTION_BYPASS_GOVERNANCE_RETENTION
 	default:
 		return ""
 	}
@@ -84,6 +88,8 @@ func MapToIdentitiesAction(action string) string {
 		return StatementActionTagging
 	case s3_constants.ACTION_DELETE_BUCKET:
 		return StatementActionDelete
+	case s3_constants.ACTION_BYPASS_GOVERNANCE_RETENTION:
+		return StatementActionBypassGovernanceRetention
 	default:
 		return ""
 	}
@@ -53,7 +53,7 @@ func NewIamApiServer(router *mux.Router, option *IamServerOption) (iamApiServer
 		masterClient: wdclient.NewMasterClient(option.GrpcDialOption, "", "iam", "", "", "", *pb.NewServiceDiscoveryFromMap(option.Masters)),
 	}
 	s3Option := s3api.S3ApiServerOption{
-		Filer:          option.Filer,
+		Filers:         []pb.ServerAddress{option.Filer},
 		GrpcDialOption: option.GrpcDialOption,
 	}
 	iamApiServer = &IamApiServer{
@@ -0,0 +1,98 @@
+package aws_kms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awskms "github.com/aws/aws-sdk-go/service/kms"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/kms"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// AwsKms keeps the master keys in AWS KMS, or a service compatible with its api.
+type AwsKms struct {
+	svc          *awskms.KMS
+	defaultKeyId string
+}
+
+func init() {
+	kms.KeyManagers = append(kms.KeyManagers, &AwsKms{})
+}
+
+func (k *AwsKms) GetName() string {
+	return "aws"
+}
+
+func (k *AwsKms) Initialize(configuration util.Configuration, prefix string) error {
+	glog.V(0).Infof("kms.aws.region: %s", configuration.GetString(prefix+"region"))
+	glog.V(0).Infof("kms.aws.endpoint: %s", configuration.GetString(prefix+"endpoint"))
+	return k.initialize(
+		configuration.GetString(prefix+"aws_access_key_id"),
+		configuration.GetString(prefix+"aws_secret_access_key"),
+		configuration.GetString(prefix+"region"),
+		configuration.GetString(prefix+"endpoint"),
+		configuration.GetString(prefix+"default_key_id"),
+	)
+}
+
+func (k *AwsKms) initialize(awsAccessKeyId, awsSecretAccessKey, region, endpoint, defaultKeyId string) error {
+	if defaultKeyId == "" {
+		return fmt.Errorf("aws kms default_key_id is not set")
+	}
+	config := &aws.Config{
+		Region: aws.String(region),
+	}
+	if endpoint != "" {
+		config.Endpoint = aws.String(endpoint)
+	}
+	if awsAccessKeyId != "" && awsSecretAccessKey != "" {
+		config.Credentials = credentials.NewStaticCredentials(awsAccessKeyId, awsSecretAccessKey, "")
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return fmt.Errorf("create aws session: %v", err)
+	}
+	k.svc = awskms.New(sess)
+	k.defaultKeyId = defaultKeyId
+	return nil
+}
+
+func (k *AwsKms) DefaultKeyId() string {
+	return k.defaultKeyId
+}
+
+func (k *AwsKms) GenerateDataKey(ctx context.Context, keyId string) (dataKey, encryptedDataKey []byte, err error) {
+	resp, err := k.svc.GenerateDataKeyWithContext(ctx, &awskms.GenerateDataKeyInput{
+		KeyId:   aws.String(keyId),
+		KeySpec: aws.String(awskms.DataKeySpecAes256),
+	})
+	if err != nil {
+		return nil, nil, toKmsError(keyId, err)
+	}
+	return resp.Plaintext, resp.CiphertextBlob, nil
+}
+
+func (k *AwsKms) DecryptDataKey(ctx context.Context, keyId string, encryptedDataKey []byte) (dataKey []byte, err error) {
+	resp, err := k.svc.DecryptWithContext(ctx, &awskms.DecryptInput{
+		KeyId:          aws.String(keyId),
+		CiphertextBlob: encryptedDataKey,
+	})
+	if err != nil {
+		return nil, toKmsError(keyId, err)
+	}
+	return resp.Plaintext, nil
+}
+
+func toKmsError(keyId string, err error) error {
+	var notFound *awskms.NotFoundException
+	if errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %s", kms.ErrKeyNotFound, keyId)
+	}
+	return fmt.Errorf("aws kms: %v", err)
+}
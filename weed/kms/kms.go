@@ -0,0 +1,63 @@
+package kms
+
+import (
+	"context"
+	"errors"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The key managers keep the master keys away from the data. The objects are encrypted by their own data keys, and
+// only the data keys encrypted by a master key are stored with the objects, i.e., the envelope encryption.
+// Reading an object asks the key manager to decrypt its data key.
+
+const (
+	// DataKeySize is the size of the data keys, for AES-256
+	DataKeySize = 32
+)
+
+var (
+	ErrKeyNotFound = errors.New("kms key not found")
+)
+
+type KeyManager interface {
+	// GetName gets the name to locate the configuration in security.toml file
+	GetName() string
+	// Initialize initializes the key manager
+	Initialize(configuration util.Configuration, prefix string) error
+	// DefaultKeyId is the master key used if the requests do not specify one
+	DefaultKeyId() string
+	// GenerateDataKey generates a data key, returned both in plaintext and encrypted by the master key
+	GenerateDataKey(ctx context.Context, keyId string) (dataKey, encryptedDataKey []byte, err error)
+	// DecryptDataKey decrypts the data key encrypted by the master key
+	DecryptDataKey(ctx context.Context, keyId string, encryptedDataKey []byte) (dataKey []byte, err error)
+}
+
+var (
+	KeyManagers []KeyManager
+)
+
+// LoadConfiguration returns the enabled key manager, or nil if none is enabled
+func LoadConfiguration(config util.Configuration, prefix string) KeyManager {
+
+	if config == nil {
+		return nil
+	}
+
+	var enabled KeyManager
+	for _, keyManager := range KeyManagers {
+		if !config.GetBool(prefix + keyManager.GetName() + ".enabled") {
+			continue
+		}
+		if enabled != nil {
+			glog.Fatalf("KMS is enabled for both %s and %s", enabled.GetName(), keyManager.GetName())
+		}
+		if err := keyManager.Initialize(config, prefix+keyManager.GetName()+"."); err != nil {
+			glog.Fatalf("Failed to initialize KMS for %s: %+v", keyManager.GetName(), err)
+		}
+		glog.V(0).Infof("Configure KMS for %s", keyManager.GetName())
+		enabled = keyManager
+	}
+	return enabled
+}
@@ -0,0 +1,106 @@
+package local
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/kms"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// LocalKms keeps the master keys in a local key file, one key per line as "<key id> <base64 encoded 32 bytes>".
+// The lines starting with "#" are comments. The key file should be readable only by the s3 gateway.
+type LocalKms struct {
+	keys         map[string]util.CipherKey
+	defaultKeyId string
+}
+
+func init() {
+	kms.KeyManagers = append(kms.KeyManagers, &LocalKms{})
+}
+
+func (k *LocalKms) GetName() string {
+	return "local"
+}
+
+func (k *LocalKms) Initialize(configuration util.Configuration, prefix string) error {
+	keyFile := configuration.GetString(prefix + "key_file")
+	glog.V(0).Infof("kms.local.key_file: %s", keyFile)
+	f, err := os.Open(util.ResolvePath(keyFile))
+	if err != nil {
+		return fmt.Errorf("open kms key file: %v", err)
+	}
+	defer f.Close()
+	return k.initialize(f, configuration.GetString(prefix+"default_key_id"))
+}
+
+func (k *LocalKms) initialize(reader io.Reader, defaultKeyId string) error {
+	k.keys = make(map[string]util.CipherKey)
+	firstKeyId := ""
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid kms key line: %s", fields[0])
+		}
+		key, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(key) != kms.DataKeySize {
+			return fmt.Errorf("kms key %s should be %d bytes in base64", fields[0], kms.DataKeySize)
+		}
+		k.keys[fields[0]] = key
+		if firstKeyId == "" {
+			firstKeyId = fields[0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read kms key file: %v", err)
+	}
+	if len(k.keys) == 0 {
+		return fmt.Errorf("no kms keys")
+	}
+	k.defaultKeyId = defaultKeyId
+	if k.defaultKeyId == "" {
+		k.defaultKeyId = firstKeyId
+	}
+	if _, found := k.keys[k.defaultKeyId]; !found {
+		return fmt.Errorf("default kms key %s: %w", k.defaultKeyId, kms.ErrKeyNotFound)
+	}
+	return nil
+}
+
+func (k *LocalKms) DefaultKeyId() string {
+	return k.defaultKeyId
+}
+
+func (k *LocalKms) GenerateDataKey(ctx context.Context, keyId string) (dataKey, encryptedDataKey []byte, err error) {
+	masterKey, found := k.keys[keyId]
+	if !found {
+		return nil, nil, fmt.Errorf("%w: %s", kms.ErrKeyNotFound, keyId)
+	}
+	dataKey = util.GenCipherKey()
+	if encryptedDataKey, err = util.Encrypt(dataKey, masterKey); err != nil {
+		return nil, nil, fmt.Errorf("encrypt data key: %v", err)
+	}
+	return
+}
+
+func (k *LocalKms) DecryptDataKey(ctx context.Context, keyId string, encryptedDataKey []byte) (dataKey []byte, err error) {
+	masterKey, found := k.keys[keyId]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", kms.ErrKeyNotFound, keyId)
+	}
+	if dataKey, err = util.Decrypt(encryptedDataKey, masterKey); err != nil {
+		return nil, fmt.Errorf("decrypt data key: %v", err)
+	}
+	return
+}
@@ -0,0 +1,54 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/kms"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestLocalKms(t *testing.T) {
+	keyFile := fmt.Sprintf("# master keys\nkey1 %s\n\nkey2 %s\n",
+		base64.StdEncoding.EncodeToString(util.GenCipherKey()),
+		base64.StdEncoding.EncodeToString(util.GenCipherKey()))
+
+	k := &LocalKms{}
+	if err := k.initialize(strings.NewReader(keyFile), ""); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	if k.DefaultKeyId() != "key1" {
+		t.Errorf("expected the first key as the default, got %s", k.DefaultKeyId())
+	}
+
+	ctx := context.Background()
+	dataKey, encryptedDataKey, err := k.GenerateDataKey(ctx, "key2")
+	if err != nil {
+		t.Fatalf("generate data key: %v", err)
+	}
+	if len(dataKey) != kms.DataKeySize || bytes.Contains(encryptedDataKey, dataKey) {
+		t.Fatalf("unexpected data key %x encrypted as %x", dataKey, encryptedDataKey)
+	}
+	decrypted, err := k.DecryptDataKey(ctx, "key2", encryptedDataKey)
+	if err != nil || !bytes.Equal(decrypted, dataKey) {
+		t.Errorf("decrypt data key: %x, %v", decrypted, err)
+	}
+	if _, err = k.DecryptDataKey(ctx, "key1", encryptedDataKey); err == nil {
+		t.Errorf("expected an error decrypting with another key")
+	}
+	if _, _, err = k.GenerateDataKey(ctx, "key3"); !errors.Is(err, kms.ErrKeyNotFound) {
+		t.Errorf("expected key not found, got %v", err)
+	}
+
+	if err = k.initialize(strings.NewReader(keyFile), "key3"); !errors.Is(err, kms.ErrKeyNotFound) {
+		t.Errorf("expected the missing default key rejected, got %v", err)
+	}
+	if err = k.initialize(strings.NewReader("key1 short"), ""); err == nil {
+		t.Errorf("expected the invalid key rejected")
+	}
+}
@@ -0,0 +1,140 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/kms"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// VaultKms keeps the master keys in the transit secrets engine of HashiCorp Vault.
+// The data keys are generated and decrypted by vault, so the master keys never leave vault.
+type VaultKms struct {
+	address      string
+	token        string
+	mount        string
+	defaultKeyId string
+	client       *http.Client
+}
+
+func init() {
+	kms.KeyManagers = append(kms.KeyManagers, &VaultKms{})
+}
+
+func (k *VaultKms) GetName() string {
+	return "vault"
+}
+
+func (k *VaultKms) Initialize(configuration util.Configuration, prefix string) error {
+	glog.V(0).Infof("kms.vault.address: %s", configuration.GetString(prefix+"address"))
+	glog.V(0).Infof("kms.vault.mount: %s", configuration.GetString(prefix+"mount"))
+	return k.initialize(
+		configuration.GetString(prefix+"address"),
+		configuration.GetString(prefix+"token"),
+		configuration.GetString(prefix+"mount"),
+		configuration.GetString(prefix+"default_key_id"),
+	)
+}
+
+func (k *VaultKms) initialize(address, token, mount, defaultKeyId string) error {
+	if address == "" {
+		return fmt.Errorf("vault address is not set")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if mount == "" {
+		mount = "transit"
+	}
+	if defaultKeyId == "" {
+		return fmt.Errorf("vault default_key_id is not set")
+	}
+	k.address = strings.TrimSuffix(address, "/")
+	k.token = token
+	k.mount = strings.Trim(mount, "/")
+	k.defaultKeyId = defaultKeyId
+	k.client = &http.Client{Timeout: 30 * time.Second}
+	return nil
+}
+
+func (k *VaultKms) DefaultKeyId() string {
+	return k.defaultKeyId
+}
+
+func (k *VaultKms) GenerateDataKey(ctx context.Context, keyId string) (dataKey, encryptedDataKey []byte, err error) {
+	var resp struct {
+		Data struct {
+			Plaintext  string `json:"plaintext"`
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err = k.call(ctx, "datakey/plaintext", keyId, map[string]interface{}{"bits": kms.DataKeySize * 8}, &resp); err != nil {
+		return nil, nil, err
+	}
+	if dataKey, err = base64.StdEncoding.DecodeString(resp.Data.Plaintext); err != nil {
+		return nil, nil, fmt.Errorf("decode vault data key: %v", err)
+	}
+	return dataKey, []byte(resp.Data.Ciphertext), nil
+}
+
+func (k *VaultKms) DecryptDataKey(ctx context.Context, keyId string, encryptedDataKey []byte) (dataKey []byte, err error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err = k.call(ctx, "decrypt", keyId, map[string]interface{}{"ciphertext": string(encryptedDataKey)}, &resp); err != nil {
+		return nil, err
+	}
+	if dataKey, err = base64.StdEncoding.DecodeString(resp.Data.Plaintext); err != nil {
+		return nil, fmt.Errorf("decode vault data key: %v", err)
+	}
+	return dataKey, nil
+}
+
+// call posts to the transit api, e.g., /v1/transit/decrypt/<key id>
+func (k *VaultKms) call(ctx context.Context, operation, keyId string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	apiUrl := fmt.Sprintf("%s/v1/%s/%s/%s", k.address, k.mount, operation, url.PathEscape(keyId))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if k.token != "" {
+		req.Header.Set("X-Vault-Token", k.token)
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault %s: %v", operation, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("vault %s: %v", operation, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", kms.ErrKeyNotFound, keyId)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault %s: %s %s", operation, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if err = json.Unmarshal(respBody, response); err != nil {
+		return fmt.Errorf("vault %s response: %v", operation, err)
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+/*
+Package elastic is for elastic metadata index.
+
+The referenced "github.com/olivere/elastic/v7" library is too big when compiled.
+So this is only compiled in "make full_install".
+*/
+package elastic
@@ -0,0 +1,211 @@
+//go:build elastic
+// +build elastic
+
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	elastic "github.com/olivere/elastic/v7"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/metadata_index"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// ElasticIndex keeps the documents in an Elasticsearch index, shared by the filers, keyed by the md5 of the full path.
+// The extended attributes and the tags are mapped as flattened fields, matched by "extended.<name>" and "tags.<key>".
+// The name pattern is an Elasticsearch wildcard query, with "*" and "?", but no character classes.
+type ElasticIndex struct {
+	client    *elastic.Client
+	indexName string
+}
+
+const docMappings = `{
+	"mappings": {
+		"dynamic": false,
+		"properties": {
+			"path":         {"type": "keyword"},
+			"dir":          {"type": "keyword"},
+			"name":         {"type": "keyword"},
+			"ext":          {"type": "keyword"},
+			"size":         {"type": "long"},
+			"mtime":        {"type": "long"},
+			"crtime":       {"type": "long"},
+			"mime":         {"type": "keyword"},
+			"is_directory": {"type": "boolean"},
+			"extended":     {"type": "flattened"},
+			"tags":         {"type": "flattened"}
+		}
+	}
+}`
+
+func init() {
+	metadata_index.MetadataIndexes = append(metadata_index.MetadataIndexes, &ElasticIndex{})
+}
+
+func (index *ElasticIndex) GetName() string {
+	return "elastic7"
+}
+
+func (index *ElasticIndex) Initialize(configuration util.Configuration, prefix string) (err error) {
+	options := []elastic.ClientOptionFunc{}
+	servers := configuration.GetStringSlice(prefix + "servers")
+	options = append(options, elastic.SetURL(servers...))
+	username := configuration.GetString(prefix + "username")
+	password := configuration.GetString(prefix + "password")
+	if username != "" && password != "" {
+		options = append(options, elastic.SetBasicAuth(username, password))
+	}
+	options = append(options, elastic.SetSniff(configuration.GetBool(prefix+"sniff_enabled")))
+	options = append(options, elastic.SetHealthcheck(configuration.GetBool(prefix+"healthcheck_enabled")))
+	index.indexName = configuration.GetString(prefix + "index")
+	if index.indexName == "" {
+		index.indexName = "seaweedfs_metadata"
+	}
+	glog.Infof("metadata index elastic endpoints: %v, index %s", servers, index.indexName)
+	return index.initialize(options)
+}
+
+func (index *ElasticIndex) initialize(options []elastic.ClientOptionFunc) (err error) {
+	ctx := context.Background()
+	index.client, err = elastic.NewClient(options...)
+	if err != nil {
+		return fmt.Errorf("init elastic %v", err)
+	}
+	if ok, err := index.client.IndexExists(index.indexName).Do(ctx); err == nil && !ok {
+		_, err = index.client.CreateIndex(index.indexName).Body(docMappings).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("create index(%s) %v", index.indexName, err)
+		}
+	}
+	return nil
+}
+
+func docId(path string) string {
+	return util.Md5String([]byte(path))
+}
+
+func (index *ElasticIndex) Put(ctx context.Context, docs []*metadata_index.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	bulk := index.client.Bulk().Index(index.indexName)
+	for _, doc := range docs {
+		bulk.Add(elastic.NewBulkIndexRequest().Id(docId(doc.Path)).Doc(doc))
+	}
+	return index.doBulk(ctx, bulk)
+}
+
+func (index *ElasticIndex) Delete(ctx context.Context, paths []string, dirs []string) error {
+	if len(paths) > 0 {
+		bulk := index.client.Bulk().Index(index.indexName)
+		for _, path := range paths {
+			bulk.Add(elastic.NewBulkDeleteRequest().Id(docId(path)))
+		}
+		if err := index.doBulk(ctx, bulk); err != nil {
+			return err
+		}
+	}
+	for _, dir := range dirs {
+		_, err := index.client.DeleteByQuery(index.indexName).
+			Query(elastic.NewPrefixQuery("path", dir+"/")).
+			ProceedOnVersionConflict().
+			Do(ctx)
+		if err != nil {
+			return fmt.Errorf("delete under %s: %v", dir, err)
+		}
+	}
+	return nil
+}
+
+func (index *ElasticIndex) doBulk(ctx context.Context, bulk *elastic.BulkService) error {
+	resp, err := bulk.Do(ctx)
+	if err != nil {
+		return fmt.Errorf("bulk %s: %v", index.indexName, err)
+	}
+	// deleting a missing document is not a failure
+	for _, item := range resp.Failed() {
+		if item.Status != 404 {
+			return fmt.Errorf("bulk %s %s: %v", index.indexName, item.Id, item.Error)
+		}
+	}
+	return nil
+}
+
+func (index *ElasticIndex) Search(ctx context.Context, query *metadata_index.Query) (docs []*metadata_index.Document, nextCursor string, err error) {
+	boolQuery := elastic.NewBoolQuery()
+	if query.Directory != "/" {
+		boolQuery.Filter(elastic.NewPrefixQuery("path", query.PathPrefix()))
+	}
+	if query.NamePattern != "" {
+		boolQuery.Filter(elastic.NewWildcardQuery("name", query.NamePattern))
+	}
+	if query.Ext != "" {
+		boolQuery.Filter(elastic.NewTermQuery("ext", query.Ext))
+	}
+	if query.MimePrefix != "" {
+		boolQuery.Filter(elastic.NewPrefixQuery("mime", query.MimePrefix))
+	}
+	if query.MinSize > 0 || query.MaxSize > 0 {
+		sizeQuery := elastic.NewRangeQuery("size").Gte(query.MinSize)
+		if query.MaxSize > 0 {
+			sizeQuery.Lte(query.MaxSize)
+		}
+		boolQuery.Filter(sizeQuery)
+	}
+	if query.MtimeAfter > 0 || query.MtimeBefore > 0 {
+		mtimeQuery := elastic.NewRangeQuery("mtime").Gte(query.MtimeAfter)
+		if query.MtimeBefore > 0 {
+			mtimeQuery.Lt(query.MtimeBefore)
+		}
+		boolQuery.Filter(mtimeQuery)
+	}
+	for k, v := range query.Extended {
+		boolQuery.Filter(elastic.NewTermQuery("extended."+k, v))
+	}
+	for k, v := range query.Tags {
+		boolQuery.Filter(elastic.NewTermQuery("tags."+k, v))
+	}
+	if query.FilesOnly {
+		boolQuery.Filter(elastic.NewTermQuery("is_directory", false))
+	}
+
+	search := index.client.Search(index.indexName).
+		Query(boolQuery).
+		Sort("path", true).
+		Size(query.Limit)
+	if query.Cursor != "" {
+		search = search.SearchAfter(query.Cursor)
+	}
+	result, err := search.Do(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("search %s: %v", index.indexName, err)
+	}
+	for _, hit := range result.Hits.Hits {
+		doc := &metadata_index.Document{}
+		if err := json.Unmarshal(hit.Source, doc); err != nil {
+			glog.Warningf("metadata index: skip %s: %v", hit.Id, err)
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	if len(result.Hits.Hits) >= query.Limit && len(docs) > 0 {
+		nextCursor = docs[len(docs)-1].Path
+	}
+	return docs, nextCursor, nil
+}
+
+func (index *ElasticIndex) IsEmpty(ctx context.Context) (bool, error) {
+	count, err := index.client.Count(index.indexName).Do(ctx)
+	if err != nil {
+		return false, fmt.Errorf("count %s: %v", index.indexName, err)
+	}
+	return count == 0, nil
+}
+
+func (index *ElasticIndex) Shutdown() {
+	index.client.Stop()
+}
@@ -0,0 +1,243 @@
+package leveldb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	leveldb_errors "github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	leveldb_util "github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/metadata_index"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// LevelDbIndex is the embedded index, kept in a leveldb in the local disk of the filer.
+// The documents are keyed by the full path, so a search scans the documents under the directory in order.
+// A search by a tag, an exact name, or an extension scans only the documents with the tag, the name, or the
+// extension, by the secondary keys of the tag, the name, or the extension followed by the full path.
+// Other searches, e.g., by a name pattern, the size, or the mtime alone, read all the documents under the directory,
+// so they should be narrowed by the directory. Use elastic for such searches over a large namespace.
+//
+// It only sees the changes made through this filer. Use a shared index, e.g., elastic, with multiple filers.
+type LevelDbIndex struct {
+	db *leveldb.DB
+}
+
+const (
+	docPrefix  = "d"
+	extPrefix  = "e"
+	namePrefix = "n"
+	tagPrefix  = "t"
+)
+
+func init() {
+	metadata_index.MetadataIndexes = append(metadata_index.MetadataIndexes, &LevelDbIndex{})
+}
+
+func (index *LevelDbIndex) GetName() string {
+	return "leveldb"
+}
+
+func (index *LevelDbIndex) Initialize(configuration util.Configuration, prefix string) (err error) {
+	dir := configuration.GetString(prefix + "dir")
+	return index.initialize(dir)
+}
+
+func (index *LevelDbIndex) initialize(dir string) (err error) {
+	glog.Infof("metadata index leveldb dir: %s", dir)
+	os.MkdirAll(dir, 0755)
+	if err := util.TestFolderWritable(dir); err != nil {
+		return fmt.Errorf("check metadata index folder %s writable: %s", dir, err)
+	}
+
+	opts := &opt.Options{
+		BlockCacheCapacity: 32 * 1024 * 1024,
+		WriteBuffer:        16 * 1024 * 1024,
+	}
+	index.db, err = leveldb.OpenFile(dir, opts)
+	if leveldb_errors.IsCorrupted(err) {
+		index.db, err = leveldb.RecoverFile(dir, opts)
+	}
+	return err
+}
+
+func docKey(path string) []byte {
+	return []byte(docPrefix + path)
+}
+
+func extKey(ext, path string) []byte {
+	return []byte(extPrefix + ext + "\x00" + path)
+}
+
+func nameKey(name, path string) []byte {
+	return []byte(namePrefix + name + "\x00" + path)
+}
+
+func tagKey(k, v, path string) []byte {
+	return []byte(tagPrefix + k + "=" + v + "\x00" + path)
+}
+
+// secondaryKeys are the keys to find the document by its name, extension and tags
+func secondaryKeys(doc *metadata_index.Document) (keys [][]byte) {
+	if doc.Name != "" {
+		keys = append(keys, nameKey(doc.Name, doc.Path))
+	}
+	if doc.Ext != "" {
+		keys = append(keys, extKey(doc.Ext, doc.Path))
+	}
+	for k, v := range doc.Tags {
+		keys = append(keys, tagKey(k, v, doc.Path))
+	}
+	return
+}
+
+func (index *LevelDbIndex) getDoc(path string) (*metadata_index.Document, error) {
+	data, err := index.db.Get(docKey(path), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc := &metadata_index.Document{}
+	if err = json.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("decode %s: %v", path, err)
+	}
+	return doc, nil
+}
+
+func (index *LevelDbIndex) deleteDoc(batch *leveldb.Batch, doc *metadata_index.Document) {
+	batch.Delete(docKey(doc.Path))
+	for _, key := range secondaryKeys(doc) {
+		batch.Delete(key)
+	}
+}
+
+func (index *LevelDbIndex) Put(ctx context.Context, docs []*metadata_index.Document) error {
+	batch := new(leveldb.Batch)
+	for _, doc := range docs {
+		old, err := index.getDoc(doc.Path)
+		if err != nil {
+			return err
+		}
+		if old != nil {
+			index.deleteDoc(batch, old)
+		}
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("encode %s: %v", doc.Path, err)
+		}
+		batch.Put(docKey(doc.Path), data)
+		for _, key := range secondaryKeys(doc) {
+			batch.Put(key, nil)
+		}
+	}
+	return index.db.Write(batch, nil)
+}
+
+func (index *LevelDbIndex) Delete(ctx context.Context, paths []string, dirs []string) error {
+	batch := new(leveldb.Batch)
+	for _, path := range paths {
+		old, err := index.getDoc(path)
+		if err != nil {
+			return err
+		}
+		if old != nil {
+			index.deleteDoc(batch, old)
+		}
+	}
+	for _, dir := range dirs {
+		iter := index.db.NewIterator(leveldb_util.BytesPrefix(docKey(dir+"/")), nil)
+		for iter.Next() {
+			doc := &metadata_index.Document{}
+			if err := json.Unmarshal(iter.Value(), doc); err != nil {
+				batch.Delete(bytes.Clone(iter.Key()))
+				continue
+			}
+			index.deleteDoc(batch, doc)
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+	return index.db.Write(batch, nil)
+}
+
+func (index *LevelDbIndex) Search(ctx context.Context, query *metadata_index.Query) (docs []*metadata_index.Document, nextCursor string, err error) {
+
+	// scan the keys of one tag, or of the name, or of the extension, or else all the documents, under the directory
+	scanPrefix := docPrefix
+	if len(query.Tags) > 0 {
+		var names []string
+		for k := range query.Tags {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		scanPrefix = tagPrefix + names[0] + "=" + query.Tags[names[0]] + "\x00"
+	} else if query.NamePattern != "" && !strings.ContainsAny(query.NamePattern, "*?[\\") {
+		scanPrefix = namePrefix + query.NamePattern + "\x00"
+	} else if query.Ext != "" {
+		scanPrefix = extPrefix + query.Ext + "\x00"
+	}
+	isSecondary := scanPrefix != docPrefix
+
+	iter := index.db.NewIterator(leveldb_util.BytesPrefix([]byte(scanPrefix+query.PathPrefix())), nil)
+	defer iter.Release()
+
+	found := iter.First()
+	if query.Cursor != "" {
+		found = iter.Seek([]byte(scanPrefix + query.Cursor))
+		if found && string(iter.Key()) == scanPrefix+query.Cursor {
+			found = iter.Next()
+		}
+	}
+	for ; found; found = iter.Next() {
+		if err = ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		var doc *metadata_index.Document
+		if isSecondary {
+			path := string(iter.Key()[len(scanPrefix):])
+			if doc, err = index.getDoc(path); err != nil {
+				return nil, "", err
+			}
+			if doc == nil {
+				continue
+			}
+		} else {
+			doc = &metadata_index.Document{}
+			if err = json.Unmarshal(iter.Value(), doc); err != nil {
+				glog.Warningf("metadata index: skip %s: %v", iter.Key(), err)
+				continue
+			}
+		}
+		if !query.Matches(doc) {
+			continue
+		}
+		docs = append(docs, doc)
+		if len(docs) >= query.Limit {
+			nextCursor = doc.Path
+			break
+		}
+	}
+	return docs, nextCursor, iter.Error()
+}
+
+func (index *LevelDbIndex) IsEmpty(ctx context.Context) (bool, error) {
+	iter := index.db.NewIterator(nil, nil)
+	defer iter.Release()
+	return !iter.First(), iter.Error()
+}
+
+func (index *LevelDbIndex) Shutdown() {
+	index.db.Close()
+}
@@ -0,0 +1,112 @@
+package leveldb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	leveldb_util "github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/seaweedfs/seaweedfs/weed/metadata_index"
+)
+
+func searchPaths(t *testing.T, index *LevelDbIndex, query *metadata_index.Query) (paths []string, nextCursor string) {
+	query.Normalize()
+	docs, nextCursor, err := index.Search(context.Background(), query)
+	if err != nil {
+		t.Fatalf("search %+v: %v", query, err)
+	}
+	for _, doc := range docs {
+		paths = append(paths, doc.Path)
+	}
+	return paths, nextCursor
+}
+
+func TestSearch(t *testing.T) {
+	index := &LevelDbIndex{}
+	if err := index.initialize(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer index.Shutdown()
+	ctx := context.Background()
+
+	if isEmpty, err := index.IsEmpty(ctx); err != nil || !isEmpty {
+		t.Fatalf("expected empty, got %v %v", isEmpty, err)
+	}
+
+	docs := []*metadata_index.Document{
+		{Path: "/photos", Name: "photos", IsDirectory: true},
+		{Path: "/photos/a.jpg", Name: "a.jpg", Ext: "jpg", Size: 100, Mtime: 10, Tags: map[string]string{"album": "trip"}},
+		{Path: "/photos/b.JPG", Name: "b.JPG", Ext: "jpg", Size: 2000, Mtime: 20},
+		{Path: "/photos/2024/c.png", Name: "c.png", Ext: "png", Size: 300, Mtime: 30, Tags: map[string]string{"album": "trip"}},
+		{Path: "/docs/d.txt", Name: "d.txt", Ext: "txt", Size: 10, Mtime: 40, Extended: map[string]string{"owner": "alice"}},
+	}
+	if err := index.Put(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, c := range []struct {
+		query    metadata_index.Query
+		expected string
+	}{
+		{metadata_index.Query{}, "[/docs/d.txt /photos /photos/2024/c.png /photos/a.jpg /photos/b.JPG]"},
+		{metadata_index.Query{Directory: "/photos/", FilesOnly: true}, "[/photos/2024/c.png /photos/a.jpg /photos/b.JPG]"},
+		{metadata_index.Query{Ext: ".JPG"}, "[/photos/a.jpg /photos/b.JPG]"},
+		{metadata_index.Query{Tags: map[string]string{"album": "trip"}}, "[/photos/2024/c.png /photos/a.jpg]"},
+		{metadata_index.Query{Directory: "/photos/2024", Tags: map[string]string{"album": "trip"}}, "[/photos/2024/c.png]"},
+		{metadata_index.Query{NamePattern: "*.txt"}, "[/docs/d.txt]"},
+		{metadata_index.Query{NamePattern: "a.jpg"}, "[/photos/a.jpg]"},
+		{metadata_index.Query{Directory: "/docs", NamePattern: "a.jpg"}, "[]"},
+		{metadata_index.Query{NamePattern: "a.jp"}, "[]"},
+		{metadata_index.Query{MinSize: 100, MaxSize: 300, FilesOnly: true}, "[/photos/2024/c.png /photos/a.jpg]"},
+		{metadata_index.Query{MtimeAfter: 20, MtimeBefore: 40}, "[/photos/2024/c.png /photos/b.JPG]"},
+		{metadata_index.Query{Extended: map[string]string{"owner": "alice"}}, "[/docs/d.txt]"},
+		{metadata_index.Query{Directory: "/photo"}, "[]"},
+	} {
+		if paths, _ := searchPaths(t, index, &c.query); fmt.Sprint(paths) != c.expected {
+			t.Errorf("%d: got %v, expected %s", i, paths, c.expected)
+		}
+	}
+
+	// paged by the cursor
+	var pages []string
+	query := metadata_index.Query{Limit: 2}
+	for {
+		paths, nextCursor := searchPaths(t, index, &query)
+		pages = append(pages, fmt.Sprint(paths))
+		if nextCursor == "" {
+			break
+		}
+		query.Cursor = nextCursor
+	}
+	if fmt.Sprint(pages) != "[[/docs/d.txt /photos] [/photos/2024/c.png /photos/a.jpg] [/photos/b.JPG]]" {
+		t.Errorf("pages %v", pages)
+	}
+
+	// the changed tag is not found by the old value
+	if err := index.Put(ctx, []*metadata_index.Document{{Path: "/photos/a.jpg", Name: "a.jpg", Ext: "jpg", Tags: map[string]string{"album": "home"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if paths, _ := searchPaths(t, index, &metadata_index.Query{Tags: map[string]string{"album": "trip"}}); fmt.Sprint(paths) != "[/photos/2024/c.png]" {
+		t.Errorf("after retagging: %v", paths)
+	}
+
+	// deleting a directory deletes everything under it
+	if err := index.Delete(ctx, []string{"/photos"}, []string{"/photos"}); err != nil {
+		t.Fatal(err)
+	}
+	if paths, _ := searchPaths(t, index, &metadata_index.Query{}); fmt.Sprint(paths) != "[/docs/d.txt]" {
+		t.Errorf("after deleting: %v", paths)
+	}
+	if paths, _ := searchPaths(t, index, &metadata_index.Query{Ext: "png"}); len(paths) != 0 {
+		t.Errorf("extension keys left: %v", paths)
+	}
+	iter := index.db.NewIterator(leveldb_util.BytesPrefix([]byte(namePrefix)), nil)
+	for iter.Next() {
+		if !strings.HasSuffix(string(iter.Key()), "\x00/docs/d.txt") {
+			t.Errorf("name key left: %q", iter.Key())
+		}
+	}
+	iter.Release()
+}
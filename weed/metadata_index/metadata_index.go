@@ -0,0 +1,163 @@
+package metadata_index
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The metadata index mirrors the metadata of the filer entries, i.e., the name, extension, size, times, mime type,
+// extended attributes and tags, so the entries can be searched by them across the whole namespace, without listing
+// the directories. The filer sends the entry changes to the index asynchronously, so a search may lag a little behind.
+//
+// The results are ordered by the full path, and paged by the full path of the last result of the previous page.
+
+// Document is what is indexed for one entry
+type Document struct {
+	Path        string            `json:"path"`
+	Dir         string            `json:"dir"`
+	Name        string            `json:"name"`
+	Ext         string            `json:"ext"` // lower case, without the dot
+	Size        int64             `json:"size"`
+	Mtime       int64             `json:"mtime"` // unix seconds
+	Crtime      int64             `json:"crtime"`
+	Mime        string            `json:"mime"`
+	IsDirectory bool              `json:"is_directory"`
+	Extended    map[string]string `json:"extended,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// Query is the conditions of a search. The empty conditions are ignored.
+type Query struct {
+	Directory   string            // search under this directory, "/" for all
+	NamePattern string            // wildcard pattern of the name, with "*" and "?"
+	Ext         string            // the extension, without the dot
+	MimePrefix  string            // e.g., "image/"
+	MinSize     int64             // in bytes
+	MaxSize     int64             // in bytes, 0 for no limit
+	MtimeAfter  int64             // unix seconds, inclusive
+	MtimeBefore int64             // unix seconds, exclusive
+	Extended    map[string]string // the extended attributes with these values
+	Tags        map[string]string // the tags with these values
+	FilesOnly   bool
+	Limit       int
+	Cursor      string // the full path of the last result of the previous page
+}
+
+const (
+	DefaultSearchLimit = 100
+	MaxSearchLimit     = 10000
+)
+
+type MetadataIndex interface {
+	// GetName gets the name to locate the configuration in filer.toml file
+	GetName() string
+	// Initialize initializes the index
+	Initialize(configuration util.Configuration, prefix string) error
+	// Put adds or replaces the documents
+	Put(ctx context.Context, docs []*Document) error
+	// Delete removes the documents of the paths, and also the documents under the paths of the directories
+	Delete(ctx context.Context, paths []string, dirs []string) error
+	// Search returns the documents matching the query ordered by path, and the cursor of the next page if the page is full
+	Search(ctx context.Context, query *Query) (docs []*Document, nextCursor string, err error)
+	// IsEmpty checks whether nothing is indexed yet, to index the existing entries
+	IsEmpty(ctx context.Context) (bool, error)
+	Shutdown()
+}
+
+var (
+	MetadataIndexes []MetadataIndex
+)
+
+// LoadConfiguration returns the enabled index, or nil if none is enabled
+func LoadConfiguration(config *util.ViperProxy, prefix string) MetadataIndex {
+
+	if config == nil {
+		return nil
+	}
+
+	var enabled MetadataIndex
+	for _, index := range MetadataIndexes {
+		if !config.GetBool(prefix + index.GetName() + ".enabled") {
+			continue
+		}
+		if enabled != nil {
+			glog.Fatalf("Metadata index is enabled for both %s and %s", enabled.GetName(), index.GetName())
+		}
+		if err := index.Initialize(config, prefix+index.GetName()+"."); err != nil {
+			glog.Fatalf("Failed to initialize metadata index for %s: %+v", index.GetName(), err)
+		}
+		glog.V(0).Infof("Configure metadata index for %s", index.GetName())
+		enabled = index
+	}
+	return enabled
+}
+
+// Normalize fills in the defaults and limits of the query
+func (q *Query) Normalize() {
+	q.Directory = strings.TrimSuffix(q.Directory, "/")
+	if q.Directory == "" {
+		q.Directory = "/"
+	}
+	q.Ext = strings.ToLower(strings.TrimPrefix(q.Ext, "."))
+	if q.Limit <= 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	if q.Limit > MaxSearchLimit {
+		q.Limit = MaxSearchLimit
+	}
+}
+
+// PathPrefix is the prefix of the full paths under the query directory
+func (q *Query) PathPrefix() string {
+	if q.Directory == "/" {
+		return "/"
+	}
+	return q.Directory + "/"
+}
+
+// Matches checks the document against the query conditions, except the cursor and the limit
+func (q *Query) Matches(doc *Document) bool {
+	if !strings.HasPrefix(doc.Path, q.PathPrefix()) {
+		return false
+	}
+	if q.FilesOnly && doc.IsDirectory {
+		return false
+	}
+	if q.Ext != "" && doc.Ext != q.Ext {
+		return false
+	}
+	if q.MimePrefix != "" && !strings.HasPrefix(doc.Mime, q.MimePrefix) {
+		return false
+	}
+	if doc.Size < q.MinSize || (q.MaxSize > 0 && doc.Size > q.MaxSize) {
+		return false
+	}
+	if doc.Mtime < q.MtimeAfter || (q.MtimeBefore > 0 && doc.Mtime >= q.MtimeBefore) {
+		return false
+	}
+	for k, v := range q.Extended {
+		if value, found := doc.Extended[k]; !found || value != v {
+			return false
+		}
+	}
+	for k, v := range q.Tags {
+		if value, found := doc.Tags[k]; !found || value != v {
+			return false
+		}
+	}
+	if q.NamePattern != "" {
+		if matched, err := filepath.Match(q.NamePattern, doc.Name); err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Ext is the lower case extension of the name, without the dot
+func Ext(name string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+}
@@ -0,0 +1,276 @@
+package mount
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+const (
+	// PinXAttrName marks a file or a directory to keep in the local cache, e.g., setfattr -n user.seaweedfs.pin -v 1
+	PinXAttrName = "user.seaweedfs.pin"
+
+	pinnedPathsFileName = "pinned_paths"
+	pinSyncInterval     = 5 * time.Minute
+)
+
+// FilePinner downloads the chunks of the pinned files, and of all the files under the pinned directories,
+// into the pinned chunk cache, where they are kept until unpinned.
+// The pinned paths are saved in the cache dir, so the pinned chunks are still used after remounting.
+type FilePinner struct {
+	wfs       *WFS
+	cache     *chunk_cache.PinnedChunkCache
+	pathsFile string
+	syncCh    chan struct{}
+
+	sync.Mutex
+	paths map[util.FullPath]struct{}
+}
+
+func NewFilePinner(wfs *WFS, cache *chunk_cache.PinnedChunkCache, dir string) *FilePinner {
+	p := &FilePinner{
+		wfs:       wfs,
+		cache:     cache,
+		pathsFile: filepath.Join(dir, pinnedPathsFileName),
+		syncCh:    make(chan struct{}, 1),
+		paths:     make(map[util.FullPath]struct{}),
+	}
+	if data, err := os.ReadFile(p.pathsFile); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				p.paths[util.FullPath(line)] = struct{}{}
+			}
+		}
+	}
+	return p
+}
+
+// isPinValue checks the value of the pin xattr, where "0" and "false" unpin
+func isPinValue(data []byte) bool {
+	value := strings.ToLower(strings.TrimSpace(strings.TrimRight(string(data), "\x00")))
+	return value != "" && value != "0" && value != "false"
+}
+
+func isPinnedEntry(entry *filer_pb.Entry) bool {
+	if entry == nil || entry.Extended == nil {
+		return false
+	}
+	data, found := entry.Extended[XATTR_PREFIX+PinXAttrName]
+	return found && isPinValue(data)
+}
+
+// SetPinned pins or unpins the path, and downloads or releases the chunks in the background
+func (p *FilePinner) SetPinned(path util.FullPath, pinned bool) {
+	p.Lock()
+	_, found := p.paths[path]
+	if found == pinned {
+		p.Unlock()
+		return
+	}
+	if pinned {
+		p.paths[path] = struct{}{}
+	} else {
+		delete(p.paths, path)
+	}
+	p.savePaths()
+	p.Unlock()
+	glog.V(1).Infof("pinned %s: %v", path, pinned)
+	p.triggerSync()
+}
+
+// Renamed moves the pinned paths under the renamed file or directory
+func (p *FilePinner) Renamed(oldPath, newPath util.FullPath) {
+	p.Lock()
+	changed := false
+	for path := range p.paths {
+		if path == oldPath || path.IsUnder(oldPath) {
+			delete(p.paths, path)
+			p.paths[newPath+path[len(oldPath):]] = struct{}{}
+			changed = true
+		} else if path == newPath || path.IsUnder(newPath) {
+			// replaced by the renamed one
+			delete(p.paths, path)
+			changed = true
+		}
+	}
+	if changed {
+		p.savePaths()
+	}
+	p.Unlock()
+	if changed {
+		p.triggerSync()
+	}
+}
+
+func (p *FilePinner) isUnderPinnedPath(path util.FullPath) bool {
+	p.Lock()
+	defer p.Unlock()
+	for pinnedPath := range p.paths {
+		if path == pinnedPath || path.IsUnder(pinnedPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// savePaths writes the pinned paths to the cache dir, with the lock held
+func (p *FilePinner) savePaths() {
+	var buf bytes.Buffer
+	for path := range p.paths {
+		buf.WriteString(string(path))
+		buf.WriteByte('\n')
+	}
+	tmpFile := p.pathsFile + ".tmp"
+	if err := os.WriteFile(tmpFile, buf.Bytes(), 0644); err != nil {
+		glog.Warningf("save pinned paths: %v", err)
+		return
+	}
+	if err := os.Rename(tmpFile, p.pathsFile); err != nil {
+		glog.Warningf("save pinned paths: %v", err)
+	}
+}
+
+func (p *FilePinner) triggerSync() {
+	select {
+	case p.syncCh <- struct{}{}:
+	default:
+	}
+}
+
+// processMetadataEvent follows the pins changed by other mounts, and the changed files under the pinned paths
+func (p *FilePinner) processMetadataEvent(resp *filer_pb.SubscribeMetadataResponse) error {
+	message := resp.EventNotification
+	var oldPath util.FullPath
+	if message.OldEntry != nil {
+		oldPath = util.NewFullPath(resp.Directory, message.OldEntry.Name)
+	}
+	if message.NewEntry == nil {
+		if oldPath != "" && p.isUnderPinnedPath(oldPath) {
+			p.SetPinned(oldPath, false)
+			p.triggerSync()
+		}
+		return nil
+	}
+	dir := resp.Directory
+	if message.NewParentPath != "" {
+		dir = message.NewParentPath
+	}
+	newPath := util.NewFullPath(dir, message.NewEntry.Name)
+	if oldPath != "" && oldPath != newPath {
+		p.Renamed(oldPath, newPath)
+	}
+	p.SetPinned(newPath, isPinnedEntry(message.NewEntry))
+	if p.isUnderPinnedPath(newPath) {
+		p.triggerSync()
+	}
+	return nil
+}
+
+func (p *FilePinner) loopSync() {
+	p.triggerSync()
+	ticker := time.NewTicker(pinSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.syncCh:
+		case <-ticker.C:
+		}
+		if err := p.syncPinnedChunks(); err != nil {
+			glog.Warningf("sync pinned files: %v", err)
+		}
+	}
+}
+
+// syncPinnedChunks downloads the chunks of the pinned files not pinned yet,
+// and unpins the chunks no longer used by them once all the pinned paths are visited
+func (p *FilePinner) syncPinnedChunks() error {
+	p.Lock()
+	var paths []util.FullPath
+	for path := range p.paths {
+		paths = append(paths, path)
+	}
+	p.Unlock()
+
+	chunks := make(map[string]*filer_pb.FileChunk)
+	var collectChunks func(path util.FullPath, entry *filer_pb.Entry) error
+	collectChunks = func(path util.FullPath, entry *filer_pb.Entry) error {
+		if entry.IsDirectory {
+			return filer_pb.ReadDirAllEntries(p.wfs, path, "", func(child *filer_pb.Entry, isLast bool) error {
+				return collectChunks(path.Child(child.Name), child)
+			})
+		}
+		if len(entry.GetChunks()) == 0 {
+			return nil
+		}
+		dataChunks, _, err := filer.ResolveChunkManifest(p.wfs.LookupFn(), entry.GetChunks(), 0, math.MaxInt64)
+		if err != nil {
+			return err
+		}
+		for _, chunk := range dataChunks {
+			chunks[chunk.GetFileIdString()] = chunk
+		}
+		return nil
+	}
+
+	for _, path := range paths {
+		entry, err := filer_pb.GetEntry(p.wfs, path)
+		if err == filer_pb.ErrNotFound {
+			p.SetPinned(path, false)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+		if err = collectChunks(path, entry); err != nil {
+			return err
+		}
+	}
+
+	var downloadErr error
+	for fileId, chunk := range chunks {
+		if p.cache.IsPinned(fileId) {
+			continue
+		}
+		if err := p.downloadChunk(chunk); err != nil {
+			glog.Warningf("pin chunk %s: %v", fileId, err)
+			downloadErr = err
+		}
+	}
+	for _, fileId := range p.cache.PinnedFileIds() {
+		if _, found := chunks[fileId]; !found {
+			p.cache.Unpin(fileId)
+		}
+	}
+	glog.V(1).Infof("%d pinned paths, %d pinned chunks, %d bytes", len(paths), len(chunks), p.cache.PinnedBytes())
+	return downloadErr
+}
+
+func (p *FilePinner) downloadChunk(chunk *filer_pb.FileChunk) error {
+	fileId := chunk.GetFileIdString()
+	urlStrings, err := p.wfs.LookupFn()(fileId)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, chunk.Size)
+	n, err := util_http.RetriedFetchChunkData(data, urlStrings, chunk.CipherKey, chunk.IsCompressed, true, 0)
+	if err != nil {
+		return err
+	}
+	return p.cache.Pin(fileId, data[:n])
+}
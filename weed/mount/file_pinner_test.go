@@ -0,0 +1,33 @@
+package mount
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestFilePinnerPaths(t *testing.T) {
+	dir := t.TempDir()
+	p := NewFilePinner(nil, nil, dir)
+	p.SetPinned("/data/models", true)
+	p.SetPinned("/data/a.txt", isPinValue([]byte("1")))
+	p.SetPinned("/data/b.txt", isPinValue([]byte("0")))
+
+	// the pinned paths under the renamed directory are moved, and the replaced ones are dropped
+	p.SetPinned("/archive/models/old", true)
+	p.Renamed("/data/models", "/archive/models")
+
+	// reloaded after remounting
+	p = NewFilePinner(nil, nil, dir)
+	for _, path := range []util.FullPath{"/archive/models", "/data/a.txt"} {
+		if _, found := p.paths[path]; !found {
+			t.Errorf("%s should be pinned", path)
+		}
+	}
+	if len(p.paths) != 2 {
+		t.Errorf("unexpected pinned paths %v", p.paths)
+	}
+	if !p.isUnderPinnedPath("/archive/models/v1/weights.bin") || p.isUnderPinnedPath("/archive/modelsX") {
+		t.Errorf("unexpected paths under the pinned directory")
+	}
+}
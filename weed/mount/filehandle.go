@@ -7,6 +7,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"os"
 	"sync"
+	"sync/atomic"
 )
 
 type FileHandleId uint64
@@ -30,6 +31,9 @@ type FileHandle struct {
 
 	isDeleted bool
 
+	// the error of the background flush in the writeback cache mode
+	writebackStatus atomic.Int32
+
 	// for debugging
 	mirrorFile *os.File
 }
@@ -74,11 +78,15 @@ func (fh *FileHandle) SetEntry(entry *filer_pb.Entry) {
 	if entry != nil {
 		fileSize := filer.FileSize(entry)
 		entry.Attributes.FileSize = fileSize
+		if fh.entryChunkGroup != nil {
+			fh.entryChunkGroup.ReleaseReadAhead()
+		}
 		var resolveManifestErr error
 		fh.entryChunkGroup, resolveManifestErr = filer.NewChunkGroup(fh.wfs.LookupFn(), fh.wfs.chunkCache, entry.Chunks)
 		if resolveManifestErr != nil {
 			glog.Warningf("failed to resolve manifest chunks in %+v", entry)
 		}
+		fh.entryChunkGroup.SetReadAhead(fh.wfs.readAhead)
 	} else {
 		glog.Fatalf("setting file handle entry to nil")
 	}
@@ -99,6 +107,12 @@ func (fh *FileHandle) ReleaseHandle() {
 	defer fh.wfs.fhLockTable.ReleaseLock(fh.fh, fhActiveLock)
 
 	fh.dirtyPages.Destroy()
+	if fh.entryChunkGroup != nil {
+		fh.entryChunkGroup.ReleaseReadAhead()
+	}
+	if fh.wfs.writebackCache != nil {
+		fh.wfs.writebackCache.forgetDirtyBytes(fh, -1)
+	}
 	if IsDebugFileReadWrite {
 		fh.mirrorFile.Close()
 	}
@@ -54,6 +54,17 @@ func (i *FileHandleToInode) AcquireFileHandle(wfs *WFS, inode uint64, entry *fil
 	return fh
 }
 
+// ReferenceFileHandle keeps the file handle open, e.g., until its background flush is done
+func (i *FileHandleToInode) ReferenceFileHandle(fh *FileHandle) bool {
+	i.Lock()
+	defer i.Unlock()
+	if existing, found := i.inode2fh[fh.inode]; !found || existing != fh {
+		return false
+	}
+	fh.counter++
+	return true
+}
+
 func (i *FileHandleToInode) ReleaseByInode(inode uint64) {
 	i.Lock()
 	defer i.Unlock()
@@ -85,22 +85,6 @@ func (wfs *WFS) wormEnforcedForEntry(path util.FullPath, entry *filer_pb.Entry)
 		return false, false
 	}
 
-	// worm is not enforced
-	if entry.WormEnforcedAtTsNs == 0 {
-		return false, true
-	}
-
-	// worm will never expire
-	if rule.WormRetentionTimeSeconds == 0 {
-		return true, true
-	}
-
-	enforcedAt := time.Unix(0, entry.WormEnforcedAtTsNs)
-
-	// worm is expired
-	if time.Now().Sub(enforcedAt).Seconds() >= float64(rule.WormRetentionTimeSeconds) {
-		return false, true
-	}
-
-	return true, true
+	// the same as the filer, the file can still be changed within the grace period, and after the retention
+	return filer.IsWormEnforced(rule, entry.WormEnforcedAtTsNs, time.Now()), true
 }
@@ -14,6 +14,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/mount/meta_cache"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -49,6 +50,14 @@ type Option struct {
 	DisableXAttr       bool
 	IsMacOs            bool
 
+	WritebackCache    bool  // flush the closed files in the background
+	WritebackMaxDirty int64 // bytes written but not flushed yet
+	WritebackFlushers int
+
+	ReadAheadChunks  int   // chunks to prefetch ahead of the sequential reads, growing from one
+	ReadAheadWorkers int   // chunks prefetched in parallel
+	ReadAheadMemory  int64 // bytes prefetched but not read yet
+
 	MountUid         uint32
 	MountGid         uint32
 	MountMode        os.FileMode
@@ -62,6 +71,7 @@ type Option struct {
 
 	uniqueCacheDirForRead  string
 	uniqueCacheDirForWrite string
+	pinnedCacheDir         string
 }
 
 type WFS struct {
@@ -73,7 +83,7 @@ type WFS struct {
 	option            *Option
 	metaCache         *meta_cache.MetaCache
 	stats             statsCache
-	chunkCache        *chunk_cache.TieredChunkCache
+	chunkCache        chunk_cache.ChunkCache
 	signature         int32
 	concurrentWriters *util.LimitedConcurrentExecutor
 	inodeToPath       *InodeToPath
@@ -83,6 +93,9 @@ type WFS struct {
 	IsOverQuota       bool
 	fhLockTable       *util.LockTable[FileHandleId]
 	FilerConf         *filer.FilerConf
+	writebackCache    *WritebackCache
+	filePinner        *FilePinner
+	readAhead         *filer.ReadAhead
 }
 
 func NewSeaweedFileSystem(option *Option) *WFS {
@@ -94,12 +107,24 @@ func NewSeaweedFileSystem(option *Option) *WFS {
 		fhMap:         NewFileHandleToInode(),
 		dhMap:         NewDirectoryHandleToInode(),
 		fhLockTable:   util.NewLockTable[FileHandleId](),
+		readAhead:     filer.NewReadAhead(option.ReadAheadChunks, option.ReadAheadWorkers, option.ReadAheadMemory),
 	}
 
 	wfs.option.filerIndex = int32(rand.Intn(len(option.FilerAddresses)))
 	wfs.option.setupUniqueCacheDirectory()
+	var tieredChunkCache *chunk_cache.TieredChunkCache
 	if option.CacheSizeMBForRead > 0 {
-		wfs.chunkCache = chunk_cache.NewTieredChunkCache(256, option.getUniqueCacheDirForRead(), option.CacheSizeMBForRead, 1024*1024)
+		tieredChunkCache = chunk_cache.NewTieredChunkCache(256, option.getUniqueCacheDirForRead(), option.CacheSizeMBForRead, 1024*1024)
+	}
+	wfs.chunkCache = tieredChunkCache
+	if !option.DisableXAttr {
+		// the pinned chunks are kept after unmounting
+		if pinnedChunkCache, err := chunk_cache.NewPinnedChunkCache(tieredChunkCache, option.pinnedCacheDir); err != nil {
+			glog.Warningf("pinning files is disabled: %v", err)
+		} else {
+			wfs.chunkCache = pinnedChunkCache
+			wfs.filePinner = NewFilePinner(wfs, pinnedChunkCache, option.pinnedCacheDir)
+		}
 	}
 
 	wfs.metaCache = meta_cache.NewMetaCache(path.Join(option.getUniqueCacheDirForRead(), "meta"), option.UidGidMapper,
@@ -131,6 +156,8 @@ func NewSeaweedFileSystem(option *Option) *WFS {
 			}
 		})
 	grace.OnInterrupt(func() {
+		// the dirty pages may be in the write cache dir
+		wfs.FlushWriteback()
 		wfs.metaCache.Shutdown()
 		os.RemoveAll(option.getUniqueCacheDirForWrite())
 		os.RemoveAll(option.getUniqueCacheDirForRead())
@@ -139,17 +166,36 @@ func NewSeaweedFileSystem(option *Option) *WFS {
 	if wfs.option.ConcurrentWriters > 0 {
 		wfs.concurrentWriters = util.NewLimitedConcurrentExecutor(wfs.option.ConcurrentWriters)
 	}
+	if wfs.option.WritebackCache {
+		wfs.writebackCache = NewWritebackCache(wfs, wfs.option.WritebackMaxDirty, wfs.option.WritebackFlushers)
+	}
 	return wfs
 }
 
+// FlushWriteback flushes the files still dirty in the writeback cache, e.g., after unmounting
+func (wfs *WFS) FlushWriteback() {
+	if wfs.writebackCache != nil {
+		wfs.writebackCache.FlushAll()
+	}
+}
+
 func (wfs *WFS) StartBackgroundTasks() error {
 	follower, err := wfs.subscribeFilerConfEvents()
 	if err != nil {
 		return err
 	}
 
+	followers := []*meta_cache.MetadataFollower{follower}
+	if wfs.filePinner != nil {
+		followers = append(followers, &meta_cache.MetadataFollower{
+			PathPrefixToWatch: wfs.option.FilerMountRootPath,
+			ProcessEventFn:    wfs.filePinner.processMetadataEvent,
+		})
+		go wfs.filePinner.loopSync()
+	}
+
 	startTime := time.Now()
-	go meta_cache.SubscribeMetaEvents(wfs.metaCache, wfs.signature, wfs, wfs.option.FilerMountRootPath, startTime.UnixNano(), follower)
+	go meta_cache.SubscribeMetaEvents(wfs.metaCache, wfs.signature, wfs, wfs.option.FilerMountRootPath, startTime.UnixNano(), followers...)
 	go wfs.loopCheckQuota()
 
 	return nil
@@ -230,6 +276,9 @@ func (option *Option) setupUniqueCacheDirectory() {
 	os.MkdirAll(option.uniqueCacheDirForRead, os.FileMode(0777)&^option.Umask)
 	option.uniqueCacheDirForWrite = filepath.Join(path.Join(option.CacheDirForWrite, cacheUniqueId), "swap")
 	os.MkdirAll(option.uniqueCacheDirForWrite, os.FileMode(0777)&^option.Umask)
+	// not removed after unmounting, and kept across versions
+	pinnedCacheId := util.Md5String([]byte(option.MountDirectory + string(option.FilerAddresses[0]) + option.FilerMountRootPath))[0:8]
+	option.pinnedCacheDir = path.Join(option.CacheDirForRead, pinnedCacheId+"_pinned")
 }
 
 func (option *Option) getUniqueCacheDirForWrite() string {
@@ -0,0 +1,68 @@
+package mount
+
+import (
+	"context"
+	"math"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// cloneFile lets the target file share the chunks of the source file on the filer, without copying the data.
+// This works like a reflink when the whole source file is copied into an empty target file,
+// which is what "cp --reflink=auto" and most backup tools do with copy_file_range.
+//
+// The FICLONE ioctl is not forwarded to FUSE file systems by the kernel, so copy_file_range is the entry point.
+// Returns false to fall back to copying the data.
+func (wfs *WFS) cloneFile(fhIn, fhOut *FileHandle, in *fuse.CopyFileRangeIn) (written uint32, ok bool) {
+	if in.OffIn != 0 || in.OffOut != 0 || fhIn.inode == fhOut.inode {
+		return 0, false
+	}
+	// both files should be the same as on the filer
+	if fhIn.dirtyMetadata || fhOut.dirtyMetadata {
+		return 0, false
+	}
+
+	sourceEntry, targetEntry := fhIn.GetEntry().GetEntry(), fhOut.GetEntry().GetEntry()
+	fileSize := filer.FileSize(sourceEntry)
+	if fileSize == 0 || fileSize > in.Len || fileSize > math.MaxUint32 {
+		return 0, false
+	}
+	if filer.FileSize(targetEntry) != 0 || len(targetEntry.GetChunks()) > 0 || sourceEntry.RemoteEntry != nil {
+		return 0, false
+	}
+
+	sourceDir, sourceName := fhIn.FullPath().DirAndName()
+	targetDir, targetName := fhOut.FullPath().DirAndName()
+	request := &filer_pb.CloneEntryRequest{
+		SourceDirectory: sourceDir,
+		SourceName:      sourceName,
+		TargetDirectory: targetDir,
+		TargetName:      targetName,
+		Signatures:      []int32{wfs.signature},
+	}
+
+	var resp *filer_pb.CloneEntryResponse
+	err := wfs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) (err error) {
+		resp, err = client.CloneEntry(context.Background(), request)
+		return err
+	})
+	if err != nil {
+		glog.V(1).Infof("clone %s to %s: %v", fhIn.FullPath(), fhOut.FullPath(), err)
+		return 0, false
+	}
+
+	// the source chunks are marked as shared, and its manifest chunks may be rewritten
+	wfs.mapPbIdFromFilerToLocal(resp.SourceEntry)
+	fhIn.SetEntry(resp.SourceEntry)
+	wfs.metaCache.UpdateEntry(context.Background(), filer.FromPbEntry(sourceDir, resp.SourceEntry))
+
+	wfs.mapPbIdFromFilerToLocal(resp.Entry)
+	fhOut.SetEntry(resp.Entry)
+	wfs.metaCache.UpdateEntry(context.Background(), filer.FromPbEntry(targetDir, resp.Entry))
+
+	return uint32(fileSize), true
+}
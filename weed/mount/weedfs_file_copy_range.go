@@ -70,6 +70,11 @@ func (wfs *WFS) CopyFileRange(cancel <-chan struct{}, in *fuse.CopyFileRangeIn)
 		in.OffOut, in.OffOut+in.Len,
 	)
 
+	// share the chunks if copying a whole file
+	if written, ok := wfs.cloneFile(fhIn, fhOut, in); ok {
+		return written, fuse.OK
+	}
+
 	data := make([]byte, in.Len)
 	totalRead, err := readDataByFileHandle(data, fhIn, int64(in.OffIn))
 	if err != nil {
@@ -66,16 +66,11 @@ func (wfs *WFS) Open(cancel <-chan struct{}, in *fuse.OpenIn, out *fuse.OpenOut)
 	fileHandle, status = wfs.AcquireHandle(in.NodeId, in.Flags, in.Uid, in.Gid)
 	if status == fuse.OK {
 		out.Fh = uint64(fileHandle.fh)
-		out.OpenFlags = in.Flags
-		if wfs.option.IsMacOs {
-			// remove the direct_io flag, as it is not well-supported on macOS
-			// https://code.google.com/archive/p/macfuse/wikis/OPTIONS.wiki recommended to avoid the direct_io flag
-			if in.Flags&fuse.FOPEN_DIRECT_IO != 0 {
-				glog.V(4).Infof("macfuse direct_io mode %v => false\n", in.Flags&fuse.FOPEN_DIRECT_IO != 0)
-				out.OpenFlags &^= fuse.FOPEN_DIRECT_IO
-			}
-		}
-		// TODO https://github.com/libfuse/libfuse/blob/master/include/fuse_common.h#L64
+		// The open(2) flags in in.Flags are not FOPEN_* flags, e.g., O_WRONLY would be FOPEN_DIRECT_IO.
+		// The O_DIRECT reads and writes go through the kernel's direct io path, which bypasses the page cache
+		// and keeps the cached pages coherent. FOPEN_DIRECT_IO is not used, since the kernel refuses
+		// the shared writable mappings of the files opened with it.
+		out.OpenFlags = 0
 	}
 	return status
 }
@@ -106,5 +101,13 @@ func (wfs *WFS) Open(cancel <-chan struct{}, in *fuse.OpenIn, out *fuse.OpenOut)
  * @param fi file information
  */
 func (wfs *WFS) Release(cancel <-chan struct{}, in *fuse.ReleaseIn) {
+	// the dirty pages of the shared writable mappings are written back when unmapped, which may be after the last close()
+	if fh := wfs.GetHandle(FileHandleId(in.Fh)); fh != nil && fh.dirtyMetadata {
+		if wfs.writebackCache != nil {
+			wfs.writebackCache.Schedule(fh, in.Uid, in.Gid)
+		} else if status := wfs.doFlush(fh, in.Uid, in.Gid); status != fuse.OK {
+			glog.Errorf("release %s fh %d: %v", fh.FullPath(), fh.fh, status)
+		}
+	}
 	wfs.ReleaseHandle(FileHandleId(in.Fh))
 }
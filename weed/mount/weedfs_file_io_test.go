@@ -0,0 +1,61 @@
+package mount
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// TestReleaseFlushesWrittenBackPages follows the requests of a shared writable mapping:
+// the dirty pages are written back after the last close(), when unmapped, and before the release
+func TestReleaseFlushesWrittenBackPages(t *testing.T) {
+	wfs := &WFS{
+		option:      &Option{ChunkSizeLimit: 1024 * 1024, uniqueCacheDirForWrite: t.TempDir()},
+		inodeToPath: NewInodeToPath(util.FullPath("/"), 0),
+		fhMap:       NewFileHandleToInode(),
+		fhLockTable: util.NewLockTable[FileHandleId](),
+	}
+	var flushLock sync.Mutex
+	var flushedSizes []uint64
+	wfs.writebackCache = newWritebackCache(1024*1024, 1, func(fh *FileHandle, uid, gid uint32) fuse.Status {
+		flushLock.Lock()
+		defer flushLock.Unlock()
+		flushedSizes = append(flushedSizes, fh.GetEntry().GetEntry().Attributes.FileSize)
+		fh.dirtyMetadata = false
+		wfs.writebackCache.forgetDirtyBytes(fh, wfs.writebackCache.getDirtyBytes(fh))
+		return fuse.OK
+	}, wfs.fhMap.ReferenceFileHandle, func(fh *FileHandle) {
+		wfs.ReleaseHandle(fh.fh)
+	})
+
+	fh := wfs.fhMap.AcquireFileHandle(wfs, 2, &filer_pb.Entry{Name: "f", Attributes: &filer_pb.FuseAttributes{}})
+	write := func(offset uint64, size int) {
+		if _, status := wfs.Write(nil, &fuse.WriteIn{Fh: uint64(fh.fh), Offset: offset, Size: uint32(size)}, make([]byte, size)); status != fuse.OK {
+			t.Fatalf("write: %v", status)
+		}
+	}
+
+	// mmap() then close()
+	write(0, 4096)
+	if status := wfs.Flush(nil, &fuse.FlushIn{Fh: uint64(fh.fh)}); status != fuse.OK {
+		t.Fatalf("flush: %v", status)
+	}
+	wfs.writebackCache.WaitForHandle(fh)
+
+	// munmap() writes back the pages dirtied after close(), then releases the file
+	write(4096, 4096)
+	wfs.Release(nil, &fuse.ReleaseIn{Fh: uint64(fh.fh)})
+	wfs.writebackCache.WaitForHandle(fh)
+
+	flushLock.Lock()
+	defer flushLock.Unlock()
+	if len(flushedSizes) != 2 || flushedSizes[1] != 8192 {
+		t.Errorf("flushed file sizes %v, expected [4096 8192]", flushedSizes)
+	}
+	if wfs.GetHandle(fh.fh) != nil {
+		t.Errorf("the file handle is not released after the flush")
+	}
+}
@@ -57,6 +57,20 @@ func (wfs *WFS) Flush(cancel <-chan struct{}, in *fuse.FlushIn) fuse.Status {
 		return fuse.ENOENT
 	}
 
+	if wfs.writebackCache != nil {
+		if status := fh.takeWritebackStatus(); status != fuse.OK {
+			return status
+		}
+		if !wfs.writebackCache.IsOverLimit() {
+			if fh.dirtyMetadata {
+				wfs.writebackCache.Schedule(fh, in.Uid, in.Gid)
+			}
+			return fuse.OK
+		}
+		// flush by itself to slow down the writers
+		wfs.writebackCache.WaitForHandle(fh)
+	}
+
 	return wfs.doFlush(fh, in.Uid, in.Gid)
 }
 
@@ -81,11 +95,21 @@ func (wfs *WFS) Flush(cancel <-chan struct{}, in *fuse.FlushIn) fuse.Status {
  */
 func (wfs *WFS) Fsync(cancel <-chan struct{}, in *fuse.FsyncIn) (code fuse.Status) {
 
+	// msync(MS_SYNC) of a shared writable mapping also ends here, after the kernel writes back the dirty pages.
+	// msync(MS_ASYNC) is not sent to the mount, and its pages are saved to the filer by the next fsync, close, or the release after munmap.
 	fh := wfs.GetHandle(FileHandleId(in.Fh))
 	if fh == nil {
 		return fuse.ENOENT
 	}
 
+	if wfs.writebackCache != nil {
+		wfs.writebackCache.WaitForHandle(fh)
+		if status := wfs.doFlush(fh, in.Uid, in.Gid); status != fuse.OK {
+			return status
+		}
+		return fh.takeWritebackStatus()
+	}
+
 	return wfs.doFlush(fh, in.Uid, in.Gid)
 
 }
@@ -98,6 +122,12 @@ func (wfs *WFS) doFlush(fh *FileHandle, uid, gid uint32) fuse.Status {
 	// send the data to the OS
 	glog.V(4).Infof("doFlush %s fh %d", fileFullPath, fh.fh)
 
+	if wfs.writebackCache != nil {
+		// the later writes are flushed next time
+		dirtyBytes := wfs.writebackCache.getDirtyBytes(fh)
+		defer wfs.writebackCache.forgetDirtyBytes(fh, dirtyBytes)
+	}
+
 	if !wfs.IsOverQuota {
 		if err := fh.dirtyPages.FlushData(); err != nil {
 			glog.Errorf("%v doFlush: %v", fileFullPath, err)
@@ -45,6 +45,10 @@ func (wfs *WFS) Write(cancel <-chan struct{}, in *fuse.WriteIn, data []byte) (wr
 		return 0, fuse.ENOENT
 	}
 
+	if wfs.writebackCache != nil {
+		wfs.writebackCache.WaitForDirtyRoom()
+	}
+
 	fh.dirtyPages.writerPattern.MonitorWriteAt(int64(in.Offset), int(in.Size))
 
 	tsNs := time.Now().UnixNano()
@@ -65,6 +69,9 @@ func (wfs *WFS) Write(cancel <-chan struct{}, in *fuse.WriteIn, data []byte) (wr
 	fh.dirtyPages.AddPage(offset, data, fh.dirtyPages.writerPattern.IsSequentialMode(), tsNs)
 
 	written = uint32(len(data))
+	if wfs.writebackCache != nil {
+		wfs.writebackCache.AddDirtyBytes(fh, int64(len(data)), in.Uid, in.Gid)
+	}
 
 	if offset == 0 {
 		// detect mime type
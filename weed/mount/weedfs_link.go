@@ -3,9 +3,10 @@ package mount
 import (
 	"context"
 	"syscall"
-	"time"
 
 	"github.com/hanwen/go-fuse/v2/fuse"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
@@ -43,9 +44,9 @@ func (wfs *WFS) Link(cancel <-chan struct{}, in *fuse.LinkIn, name string, out *
 	}
 	oldParentPath, _ := oldEntryPath.DirAndName()
 
-	oldEntry, status := wfs.maybeLoadEntry(oldEntryPath)
-	if status != fuse.OK {
-		return status
+	oldEntry, loadStatus := wfs.maybeLoadEntry(oldEntryPath)
+	if loadStatus != fuse.OK {
+		return loadStatus
 	}
 
 	// hardlink is not allowed in WORM mode
@@ -53,65 +54,45 @@ func (wfs *WFS) Link(cancel <-chan struct{}, in *fuse.LinkIn, name string, out *
 		return fuse.EPERM
 	}
 
-	// update old file to hardlink mode
-	if len(oldEntry.HardLinkId) == 0 {
-		oldEntry.HardLinkId = filer.NewHardLinkId()
-		oldEntry.HardLinkCounter = 1
-	}
-	oldEntry.HardLinkCounter++
-	updateOldEntryRequest := &filer_pb.UpdateEntryRequest{
-		Directory:  oldParentPath,
-		Entry:      oldEntry,
-		Signatures: []int32{wfs.signature},
-	}
-
-	// CreateLink 1.2 : update new file to hardlink mode
-	oldEntry.Attributes.Mtime = time.Now().Unix()
-	request := &filer_pb.CreateEntryRequest{
-		Directory: string(newParentPath),
-		Entry: &filer_pb.Entry{
-			Name:            name,
-			IsDirectory:     false,
-			Attributes:      oldEntry.Attributes,
-			Chunks:          oldEntry.GetChunks(),
-			Extended:        oldEntry.Extended,
-			HardLinkId:      oldEntry.HardLinkId,
-			HardLinkCounter: oldEntry.HardLinkCounter,
-		},
-		Signatures:               []int32{wfs.signature},
-		SkipCheckParentDirectory: true,
-	}
-
-	// apply changes to the filer, and also apply to local metaCache
+	// the filer turns the old file into a hard link, and creates the new link, in one call
+	var resp *filer_pb.CreateHardLinkResponse
 	err := wfs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
-
-		wfs.mapPbIdFromLocalToFiler(request.Entry)
-		defer wfs.mapPbIdFromFilerToLocal(request.Entry)
-
-		if err := filer_pb.UpdateEntry(client, updateOldEntryRequest); err != nil {
-			return err
-		}
-		wfs.metaCache.UpdateEntry(context.Background(), filer.FromPbEntry(updateOldEntryRequest.Directory, updateOldEntryRequest.Entry))
-
-		if err := filer_pb.CreateEntry(client, request); err != nil {
-			return err
-		}
-
-		wfs.metaCache.InsertEntry(context.Background(), filer.FromPbEntry(request.Directory, request.Entry))
-
-		return nil
+		var linkErr error
+		resp, linkErr = client.CreateHardLink(context.Background(), &filer_pb.CreateHardLinkRequest{
+			OldDirectory: oldParentPath,
+			OldName:      oldEntry.Name,
+			NewDirectory: string(newParentPath),
+			NewName:      name,
+			Signatures:   []int32{wfs.signature},
+		})
+		return linkErr
 	})
 
 	newEntryPath := newParentPath.Child(name)
 
 	if err != nil {
 		glog.V(0).Infof("Link %v -> %s: %v", oldEntryPath, newEntryPath, err)
+		switch status.Code(err) {
+		case codes.AlreadyExists:
+			return fuse.Status(syscall.EEXIST)
+		case codes.NotFound:
+			return fuse.ENOENT
+		case codes.PermissionDenied:
+			return fuse.EPERM
+		case codes.ResourceExhausted:
+			return fuse.Status(syscall.ENOSPC)
+		}
 		return fuse.EIO
 	}
 
+	// apply the changes to the local metaCache
+	wfs.metaCache.UpdateEntry(context.Background(), filer.FromPbEntry(oldParentPath, resp.OldEntry))
+	wfs.metaCache.InsertEntry(context.Background(), filer.FromPbEntry(string(newParentPath), resp.Entry))
+	wfs.mapPbIdFromFilerToLocal(resp.Entry)
+
 	wfs.inodeToPath.AddPath(oldEntry.Attributes.Inode, newEntryPath)
 
-	wfs.outputPbEntry(out, oldEntry.Attributes.Inode, request.Entry)
+	wfs.outputPbEntry(out, oldEntry.Attributes.Inode, resp.Entry)
 
 	return fuse.OK
 }
@@ -172,6 +172,10 @@ func (wfs *WFS) Rename(cancel <-chan struct{}, in *fuse.RenameIn, oldName string
 
 	glog.V(4).Infof("dir Rename %s => %s", oldPath, newPath)
 
+	// the background flushes should not recreate the renamed or replaced files
+	wfs.waitForWriteback(oldPath)
+	wfs.waitForWriteback(newPath)
+
 	// update remote filer
 	err := wfs.WithFilerClient(true, func(client filer_pb.SeaweedFilerClient) error {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -220,6 +224,9 @@ func (wfs *WFS) Rename(cancel <-chan struct{}, in *fuse.RenameIn, oldName string
 		glog.V(0).Infof("Link: %v", err)
 		return
 	}
+	if wfs.filePinner != nil {
+		wfs.filePinner.Renamed(oldPath, newPath)
+	}
 
 	return fuse.OK
 
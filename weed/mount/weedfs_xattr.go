@@ -6,6 +6,7 @@ import (
 	"syscall"
 
 	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 	sys "golang.org/x/sys/unix"
 )
 
@@ -134,10 +135,15 @@ func (wfs *WFS) SetXAttr(cancel <-chan struct{}, input *fuse.SetXAttrIn, attr st
 
 	if fh != nil {
 		fh.dirtyMetadata = true
+		wfs.setPinned(path, attr, data)
 		return fuse.OK
 	}
 
-	return wfs.saveEntry(path, entry)
+	status = wfs.saveEntry(path, entry)
+	if status == fuse.OK {
+		wfs.setPinned(path, attr, data)
+	}
+	return status
 
 }
 
@@ -210,5 +216,16 @@ func (wfs *WFS) RemoveXAttr(cancel <-chan struct{}, header *fuse.InHeader, attr
 
 	delete(entry.Extended, XATTR_PREFIX+attr)
 
-	return wfs.saveEntry(path, entry)
+	status = wfs.saveEntry(path, entry)
+	if status == fuse.OK {
+		wfs.setPinned(path, attr, nil)
+	}
+	return status
+}
+
+// setPinned follows the changes of the pin xattr
+func (wfs *WFS) setPinned(path util.FullPath, attr string, data []byte) {
+	if wfs.filePinner != nil && attr == PinXAttrName {
+		wfs.filePinner.SetPinned(path, isPinValue(data))
+	}
 }
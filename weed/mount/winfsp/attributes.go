@@ -0,0 +1,142 @@
+package winfsp
+
+import (
+	"encoding/binary"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// the Windows file attributes as the BSD file flags, the same as UF_* in WinFsp FUSE
+const (
+	FlagReadonly = 0x00001000
+	FlagHidden   = 0x00008000
+	FlagSystem   = 0x00000080
+	FlagArchive  = 0x00000800
+
+	// the file attributes kept in the entry, while the read-only attribute follows the write permission bits
+	windowsFlagsKey   = "winfsp.flags"
+	storedFlagsMask   = FlagHidden | FlagSystem | FlagArchive
+	ownerWritableMode = 0200
+)
+
+// the unix file types in the mode, as expected by FUSE
+const (
+	modeTypeDirectory = 0040000
+	modeTypeRegular   = 0100000
+	modeTypeSymlink   = 0120000
+)
+
+type Attributes struct {
+	Inode  uint64
+	Mode   uint32 // the unix file type and permission bits
+	Nlink  uint32
+	Uid    uint32
+	Gid    uint32
+	Size   int64
+	Mtime  time.Time
+	Ctime  time.Time
+	Crtime time.Time
+	Flags  uint32 // the Windows file attributes
+}
+
+func (fs *FileSystem) toAttributes(fullPath util.FullPath, entry *filer_pb.Entry) *Attributes {
+	attr := entry.Attributes
+	fileMode := os.FileMode(attr.FileMode)
+	mode := uint32(fileMode.Perm())
+	switch {
+	case entry.IsDirectory || fileMode.IsDir():
+		mode |= modeTypeDirectory
+	case fileMode&os.ModeSymlink != 0:
+		mode |= modeTypeSymlink
+	default:
+		mode |= modeTypeRegular
+	}
+	inode := attr.Inode
+	if inode == 0 || fullPath == fs.root {
+		inode = fullPath.AsInode(attr.Crtime)
+	}
+	size := int64(filer.FileSize(entry))
+	if entry.IsDirectory {
+		size = 0
+	} else if fileMode&os.ModeSymlink != 0 {
+		size = int64(len(attr.SymlinkTarget))
+	}
+	mtime := time.Unix(attr.Mtime, 0)
+	nlink := uint32(1)
+	if entry.HardLinkCounter > 0 {
+		nlink = uint32(entry.HardLinkCounter)
+	}
+	return &Attributes{
+		Inode:  inode,
+		Mode:   mode,
+		Nlink:  nlink,
+		Uid:    attr.Uid,
+		Gid:    attr.Gid,
+		Size:   size,
+		Mtime:  mtime,
+		Ctime:  mtime,
+		Crtime: time.Unix(attr.Crtime, 0),
+		Flags:  windowsFlags(entry),
+	}
+}
+
+// windowsFlags maps the entry to the Windows file attributes:
+// the dot files are hidden, and the files without the owner write permission are read-only
+func windowsFlags(entry *filer_pb.Entry) uint32 {
+	var flags uint32
+	if data, found := entry.Extended[windowsFlagsKey]; found && len(data) == 4 {
+		flags = binary.BigEndian.Uint32(data) & storedFlagsMask
+	}
+	if strings.HasPrefix(entry.Name, ".") {
+		flags |= FlagHidden
+	}
+	if entry.Attributes != nil && entry.Attributes.FileMode&ownerWritableMode == 0 {
+		flags |= FlagReadonly
+	}
+	return flags
+}
+
+func setWindowsFlags(entry *filer_pb.Entry, flags uint32) {
+	if flags&FlagReadonly != 0 {
+		entry.Attributes.FileMode &^= 0222
+	} else if entry.Attributes.FileMode&ownerWritableMode == 0 {
+		entry.Attributes.FileMode |= ownerWritableMode
+	}
+	if flags&storedFlagsMask == 0 {
+		delete(entry.Extended, windowsFlagsKey)
+		return
+	}
+	if entry.Extended == nil {
+		entry.Extended = make(map[string][]byte)
+	}
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, flags&storedFlagsMask)
+	entry.Extended[windowsFlagsKey] = data
+}
+
+// truncateEntry drops the chunks beyond the size, so the data is not seen again after extending the file
+func truncateEntry(entry *filer_pb.Entry, size uint64) {
+	if size < filer.FileSize(entry) {
+		if uint64(len(entry.Content)) > size {
+			entry.Content = entry.Content[:size]
+		}
+		var chunks []*filer_pb.FileChunk
+		for _, chunk := range entry.GetChunks() {
+			if uint64(chunk.Offset) >= size {
+				continue
+			}
+			if uint64(chunk.Offset)+chunk.Size > size {
+				chunk.Size = size - uint64(chunk.Offset)
+			}
+			chunks = append(chunks, chunk)
+		}
+		entry.Chunks = chunks
+	}
+	entry.Attributes.FileSize = size
+	entry.Attributes.Mtime = time.Now().Unix()
+}
@@ -0,0 +1,318 @@
+package winfsp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// openFile is shared by all the handles of the same file, so they see the writes of each other
+type openFile struct {
+	sync.Mutex
+	path    util.FullPath
+	entry   *filer_pb.Entry
+	handles int
+	dirty   bool
+	// the sequential writes not uploaded yet
+	buffer       []byte
+	bufferOffset int64
+}
+
+func (fs *FileSystem) getHandle(fh uint64) *openFile {
+	fs.handlesLock.Lock()
+	defer fs.handlesLock.Unlock()
+	return fs.handles[fh]
+}
+
+func (fs *FileSystem) getOpenFile(fullPath util.FullPath) *openFile {
+	fs.handlesLock.Lock()
+	defer fs.handlesLock.Unlock()
+	return fs.openFiles[fullPath]
+}
+
+func (fs *FileSystem) addHandle(fullPath util.FullPath, entry *filer_pb.Entry) uint64 {
+	fs.handlesLock.Lock()
+	defer fs.handlesLock.Unlock()
+	f, found := fs.openFiles[fullPath]
+	if !found {
+		f = &openFile{path: fullPath, entry: entry}
+		fs.openFiles[fullPath] = f
+	}
+	f.handles++
+	fs.nextHandle++
+	fs.handles[fs.nextHandle] = f
+	return fs.nextHandle
+}
+
+func (fs *FileSystem) renameOpenFiles(oldPath, newPath util.FullPath) {
+	fs.handlesLock.Lock()
+	defer fs.handlesLock.Unlock()
+	for fullPath, f := range fs.openFiles {
+		if fullPath != oldPath && !fullPath.IsUnder(oldPath) {
+			continue
+		}
+		renamed := newPath + fullPath[len(oldPath):]
+		delete(fs.openFiles, fullPath)
+		fs.openFiles[renamed] = f
+		f.Lock()
+		f.path = renamed
+		f.entry.Name = renamed.Name()
+		f.Unlock()
+	}
+}
+
+// Create creates the file, and opens it
+func (fs *FileSystem) Create(name string, mode os.FileMode, uid, gid uint32) (uint64, error) {
+	dir, childName, err := fs.resolveParent(name)
+	if err != nil {
+		return 0, err
+	}
+	entry := fs.newEntry(childName, mode.Perm(), uid, gid)
+	if err := fs.createEntry(dir, entry); err != nil {
+		return 0, err
+	}
+	return fs.addHandle(dir.Child(childName), entry), nil
+}
+
+// Open opens the file, and truncates it if asked
+func (fs *FileSystem) Open(name string, truncate bool) (uint64, error) {
+	fullPath, entry, err := fs.resolve(name)
+	if err != nil {
+		return 0, err
+	}
+	if entry.IsDirectory {
+		return 0, ErrIsDirectory
+	}
+	fh := fs.addHandle(fullPath, entry)
+	if truncate {
+		if err := fs.Truncate(name, 0, fh); err != nil {
+			fs.Release(fh)
+			return 0, err
+		}
+	}
+	return fh, nil
+}
+
+func (fs *FileSystem) Read(fh uint64, buf []byte, offset int64) (int, error) {
+	f := fs.getHandle(fh)
+	if f == nil {
+		return 0, ErrBadHandle
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	fileSize := int64(filer.FileSize(f.entry))
+	if offset >= fileSize {
+		return 0, nil
+	}
+	count := min(int64(len(buf)), fileSize-offset)
+	data := buf[:count]
+	clear(data)
+	if len(f.entry.Content) > 0 {
+		copy(data, f.entry.Content[min(offset, int64(len(f.entry.Content))):])
+	} else if len(f.entry.GetChunks()) > 0 {
+		visibleIntervals, err := filer.NonOverlappingVisibleIntervals(filer.LookupFn(fs), f.entry.GetChunks(), offset, offset+count)
+		if err != nil {
+			glog.Errorf("read %s: %v", f.path, err)
+			return 0, ErrIO
+		}
+		chunkViews := filer.ViewFromVisibleIntervals(visibleIntervals, offset, count)
+		reader := filer.NewChunkReaderAtFromClient(fs.readerCache, chunkViews, fileSize)
+		if _, err := reader.ReadAt(data, offset); err != nil && err != io.EOF {
+			glog.Errorf("read %s: %v", f.path, err)
+			return 0, ErrIO
+		}
+	}
+	// the buffered writes are newer than the chunks
+	if len(f.buffer) > 0 {
+		start, stop := max(offset, f.bufferOffset), min(offset+count, f.bufferOffset+int64(len(f.buffer)))
+		if start < stop {
+			copy(data[start-offset:stop-offset], f.buffer[start-f.bufferOffset:stop-f.bufferOffset])
+		}
+	}
+	return int(count), nil
+}
+
+func (fs *FileSystem) Write(fh uint64, data []byte, offset int64) (int, error) {
+	if fs.option.ReadOnly {
+		return 0, ErrReadOnly
+	}
+	f := fs.getHandle(fh)
+	if f == nil {
+		return 0, ErrBadHandle
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	if len(f.buffer) > 0 && (offset != f.bufferOffset+int64(len(f.buffer)) || int64(len(f.buffer)+len(data)) > fs.option.ChunkSizeLimit) {
+		if err := fs.uploadBufferLocked(f); err != nil {
+			return 0, err
+		}
+	}
+	if len(f.buffer) == 0 {
+		f.bufferOffset = offset
+	}
+	f.buffer = append(f.buffer, data...)
+	f.entry.Attributes.FileSize = max(f.entry.Attributes.FileSize, uint64(offset)+uint64(len(data)))
+	f.entry.Attributes.Mtime = time.Now().Unix()
+	f.dirty = true
+	if int64(len(f.buffer)) >= fs.option.ChunkSizeLimit {
+		if err := fs.uploadBufferLocked(f); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// Truncate changes the file size, and the open file if any
+func (fs *FileSystem) Truncate(name string, size int64, fh uint64) error {
+	if size < 0 {
+		return ErrInvalid
+	}
+	f := fs.getHandle(fh)
+	if f == nil {
+		fullPath, entry, err := fs.resolve(name)
+		if err != nil {
+			return err
+		}
+		if entry.IsDirectory {
+			return ErrIsDirectory
+		}
+		if f = fs.getOpenFile(fullPath); f == nil {
+			if fs.option.ReadOnly {
+				return ErrReadOnly
+			}
+			truncateEntry(entry, uint64(size))
+			return fs.saveEntry(fullPath, entry)
+		}
+	}
+	f.Lock()
+	defer f.Unlock()
+	if err := fs.uploadBufferLocked(f); err != nil {
+		return err
+	}
+	truncateEntry(f.entry, uint64(size))
+	f.dirty = true
+	return fs.flushLocked(f)
+}
+
+// Flush saves the buffered writes and the entry to the filer
+func (fs *FileSystem) Flush(fh uint64) error {
+	f := fs.getHandle(fh)
+	if f == nil {
+		return ErrBadHandle
+	}
+	f.Lock()
+	defer f.Unlock()
+	return fs.flushLocked(f)
+}
+
+// Release flushes the file, and forgets the handle
+func (fs *FileSystem) Release(fh uint64) error {
+	f := fs.getHandle(fh)
+	if f == nil {
+		return ErrBadHandle
+	}
+	f.Lock()
+	err := fs.flushLocked(f)
+	f.Unlock()
+
+	fs.handlesLock.Lock()
+	defer fs.handlesLock.Unlock()
+	delete(fs.handles, fh)
+	f.handles--
+	if f.handles <= 0 && fs.openFiles[f.path] == f {
+		delete(fs.openFiles, f.path)
+	}
+	return err
+}
+
+func (fs *FileSystem) flushLocked(f *openFile) error {
+	if err := fs.uploadBufferLocked(f); err != nil {
+		return err
+	}
+	if !f.dirty {
+		return nil
+	}
+	entry := f.entry
+	manifestChunks, nonManifestChunks := filer.SeparateManifestChunks(entry.GetChunks())
+	chunks, _ := filer.CompactFileChunks(filer.LookupFn(fs), nonManifestChunks)
+	chunks, err := filer.MaybeManifestize(fs.saveDataAsChunk, chunks)
+	if err != nil {
+		glog.V(0).Infof("flush %s manifestize: %v", f.path, err)
+	}
+	entry.Chunks = append(chunks, manifestChunks...)
+	if err := fs.saveEntry(f.path, entry); err != nil {
+		glog.Errorf("flush %s: %v", f.path, err)
+		return err
+	}
+	f.dirty = false
+	return nil
+}
+
+// uploadBufferLocked saves the buffered writes as a chunk of the open file
+func (fs *FileSystem) uploadBufferLocked(f *openFile) error {
+	if len(f.buffer) == 0 {
+		return nil
+	}
+	entry := f.entry
+	tsNs := time.Now().UnixNano()
+	if len(entry.Content) > 0 {
+		contentChunk, err := fs.saveDataAsChunk(util.NewBytesReader(entry.Content), string(f.path), 0, tsNs-1)
+		if err != nil {
+			glog.Errorf("upload %s: %v", f.path, err)
+			return ErrIO
+		}
+		entry.Chunks = append(entry.GetChunks(), contentChunk)
+		entry.Content = nil
+	}
+	chunk, err := fs.saveDataAsChunk(util.NewBytesReader(f.buffer), string(f.path), f.bufferOffset, tsNs)
+	if err != nil {
+		glog.Errorf("upload %s: %v", f.path, err)
+		return ErrIO
+	}
+	entry.Chunks = append(entry.GetChunks(), chunk)
+	f.buffer = nil
+	return nil
+}
+
+func (fs *FileSystem) saveDataAsChunk(reader io.Reader, name string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+	uploader, err := operation.NewUploader()
+	if err != nil {
+		return nil, fmt.Errorf("upload data: %v", err)
+	}
+	fileId, uploadResult, err, _ := uploader.UploadWithRetry(
+		fs,
+		&filer_pb.AssignVolumeRequest{
+			Count:       1,
+			Replication: fs.option.Replication,
+			Collection:  fs.option.Collection,
+			DiskType:    fs.option.DiskType,
+			TtlSec:      fs.option.TtlSec,
+			Path:        name,
+		},
+		&operation.UploadOption{
+			Filename: name,
+			Cipher:   fs.option.Cipher,
+		},
+		func(host, fileId string) string {
+			return fmt.Sprintf("http://%s/%s", host, fileId)
+		},
+		reader,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("upload data: %v", err)
+	}
+	if uploadResult.Error != "" {
+		return nil, fmt.Errorf("upload result: %v", uploadResult.Error)
+	}
+	return uploadResult.ToPbFileChunk(fileId, offset, tsNs), nil
+}
@@ -0,0 +1,572 @@
+package winfsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
+)
+
+var (
+	ErrNotFound     = errors.New("no such file or directory")
+	ErrExist        = errors.New("file exists")
+	ErrNotEmpty     = errors.New("directory not empty")
+	ErrNotDirectory = errors.New("not a directory")
+	ErrIsDirectory  = errors.New("is a directory")
+	ErrInvalid      = errors.New("invalid argument")
+	ErrBadHandle    = errors.New("bad file handle")
+	ErrReadOnly     = errors.New("read-only file system")
+	ErrIO           = errors.New("input/output error")
+)
+
+const statisticsCacheTtl = 10 * time.Second
+
+type Option struct {
+	Filer              pb.ServerAddress
+	FilerMountRootPath string
+	GrpcDialOption     grpc.DialOption
+	Collection         string
+	Replication        string
+	DiskType           string
+	TtlSec             int32
+	ChunkSizeLimit     int64
+	Cipher             bool
+	CacheDir           string
+	CacheSizeMB        int64
+	Umask              os.FileMode
+	ReadOnly           bool
+	// the names are matched case-insensitively like NTFS, while the filer keeps them case-sensitive
+	CaseInsensitive bool
+}
+
+// FileSystem serves the filer directory to a Windows drive letter through WinFsp.
+// The names are relative to the mount root with "/" separators, and the writes are buffered per open file
+// until the file is flushed or a non-sequential write comes.
+type FileSystem struct {
+	option      *Option
+	root        util.FullPath
+	signature   int32
+	startTime   time.Time
+	readerCache *filer.ReaderCache
+
+	handlesLock sync.Mutex
+	handles     map[uint64]*openFile
+	openFiles   map[util.FullPath]*openFile
+	nextHandle  uint64
+
+	statsLock sync.Mutex
+	stats     *filer_pb.StatisticsResponse
+	statsTime time.Time
+}
+
+func NewFileSystem(option *Option) (*FileSystem, error) {
+	if option.ChunkSizeLimit <= 0 {
+		option.ChunkSizeLimit = 2 * 1024 * 1024
+	}
+	fs := &FileSystem{
+		option:    option,
+		root:      util.FullPath(path.Clean("/" + option.FilerMountRootPath)),
+		signature: util.RandomInt32(),
+		startTime: time.Now(),
+		handles:   make(map[uint64]*openFile),
+		openFiles: make(map[util.FullPath]*openFile),
+	}
+
+	var chunkCache chunk_cache.ChunkCache = chunk_cache.NewChunkCacheInMemory(256)
+	if option.CacheSizeMB > 0 {
+		cacheUniqueId := util.Md5String([]byte("winfsp" + string(option.Filer) + string(fs.root) + util.Version()))[0:8]
+		cacheDir := path.Join(option.CacheDir, cacheUniqueId)
+		os.MkdirAll(cacheDir, os.FileMode(0755))
+		chunkCache = chunk_cache.NewTieredChunkCache(256, cacheDir, option.CacheSizeMB, 1024*1024)
+	}
+	fs.readerCache = filer.NewReaderCache(32, chunkCache, filer.LookupFn(fs))
+
+	if _, entry, err := fs.resolve("/"); err != nil {
+		return nil, fmt.Errorf("mount %s: %v", fs.root, err)
+	} else if !entry.IsDirectory {
+		return nil, fmt.Errorf("mount %s: %v", fs.root, ErrNotDirectory)
+	}
+	return fs, nil
+}
+
+var _ = filer_pb.FilerClient(&FileSystem{})
+
+func (fs *FileSystem) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithGrpcClient(streamingMode, fs.signature, func(grpcConnection *grpc.ClientConn) error {
+		client := filer_pb.NewSeaweedFilerClient(grpcConnection)
+		return fn(client)
+	}, fs.option.Filer.ToGrpcAddress(), false, fs.option.GrpcDialOption)
+}
+
+func (fs *FileSystem) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (fs *FileSystem) GetDataCenter() string {
+	return ""
+}
+
+// fullPath maps the name under the mount to the filer path, without matching the letter cases
+func (fs *FileSystem) fullPath(name string) util.FullPath {
+	name = strings.Trim(strings.ReplaceAll(name, "\\", "/"), "/")
+	if name == "" {
+		return fs.root
+	}
+	return fs.root.Child(name)
+}
+
+func (fs *FileSystem) getEntry(fullPath util.FullPath) (*filer_pb.Entry, error) {
+	if fullPath == "/" {
+		return &filer_pb.Entry{
+			IsDirectory: true,
+			Attributes: &filer_pb.FuseAttributes{
+				FileMode: uint32(os.ModeDir | 0777),
+				Mtime:    fs.startTime.Unix(),
+				Crtime:   fs.startTime.Unix(),
+			},
+		}, nil
+	}
+	entry, err := filer_pb.GetEntry(fs, fullPath)
+	if err != nil {
+		return nil, toError(err)
+	}
+	if entry == nil {
+		return nil, ErrNotFound
+	}
+	if entry.Attributes == nil {
+		entry.Attributes = &filer_pb.FuseAttributes{}
+	}
+	return entry, nil
+}
+
+// resolve finds the entry of the name, and its filer path with the letter cases kept by the filer
+func (fs *FileSystem) resolve(name string) (util.FullPath, *filer_pb.Entry, error) {
+	fullPath := fs.fullPath(name)
+	entry, err := fs.getEntry(fullPath)
+	if err != ErrNotFound || !fs.option.CaseInsensitive || fullPath == fs.root {
+		return fullPath, entry, err
+	}
+	// match each path component case-insensitively
+	current := fs.root
+	for _, component := range strings.Split(string(fullPath)[len(fs.root):], "/") {
+		if component == "" {
+			continue
+		}
+		child, err := fs.lookupChild(current, component)
+		if err != nil {
+			return "", nil, err
+		}
+		current = current.Child(child.Name)
+		entry = child
+	}
+	return current, entry, nil
+}
+
+// resolveParent resolves the directory of the name, and returns the new name in it
+func (fs *FileSystem) resolveParent(name string) (dir util.FullPath, childName string, err error) {
+	parent, childName := fs.fullPath(name).DirAndName()
+	if childName == "" || fs.fullPath(name) == fs.root {
+		return "", "", ErrInvalid
+	}
+	parentName := strings.TrimPrefix(parent, string(fs.root))
+	dir, entry, err := fs.resolve(parentName)
+	if err != nil {
+		return "", "", err
+	}
+	if !entry.IsDirectory {
+		return "", "", ErrNotDirectory
+	}
+	return dir, childName, nil
+}
+
+// lookupChild finds the child by the exact name, or else by the case-insensitive name if enabled
+func (fs *FileSystem) lookupChild(dir util.FullPath, name string) (*filer_pb.Entry, error) {
+	entry, err := fs.getEntry(dir.Child(name))
+	if err != ErrNotFound || !fs.option.CaseInsensitive {
+		return entry, err
+	}
+	var found *filer_pb.Entry
+	err = filer_pb.ReadDirAllEntries(fs, dir, "", func(child *filer_pb.Entry, isLast bool) error {
+		if found == nil && strings.EqualFold(child.Name, name) {
+			found = child
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, toError(err)
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	if found.Attributes == nil {
+		found.Attributes = &filer_pb.FuseAttributes{}
+	}
+	return found, nil
+}
+
+func (fs *FileSystem) createEntry(dir util.FullPath, entry *filer_pb.Entry) error {
+	if fs.option.ReadOnly {
+		return ErrReadOnly
+	}
+	// the names differing only in the letter cases are the same file on Windows
+	if existing, err := fs.lookupChild(dir, entry.Name); err == nil && existing != nil {
+		return ErrExist
+	} else if err != nil && err != ErrNotFound {
+		return err
+	}
+	return toError(fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+			Directory:  string(dir),
+			Entry:      entry,
+			OExcl:      true,
+			Signatures: []int32{fs.signature},
+		})
+	}))
+}
+
+func (fs *FileSystem) saveEntry(fullPath util.FullPath, entry *filer_pb.Entry) error {
+	if fs.option.ReadOnly {
+		return ErrReadOnly
+	}
+	dir, _ := fullPath.DirAndName()
+	return toError(fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory:  dir,
+			Entry:      entry,
+			Signatures: []int32{fs.signature},
+		})
+	}))
+}
+
+func (fs *FileSystem) newEntry(name string, mode os.FileMode, uid, gid uint32) *filer_pb.Entry {
+	now := time.Now().Unix()
+	return &filer_pb.Entry{
+		Name:        name,
+		IsDirectory: mode.IsDir(),
+		Attributes: &filer_pb.FuseAttributes{
+			Mtime:    now,
+			Crtime:   now,
+			FileMode: uint32(mode &^ fs.option.Umask),
+			Uid:      uid,
+			Gid:      gid,
+			TtlSec:   fs.option.TtlSec,
+		},
+	}
+}
+
+// Getattr returns the attributes of the name, or of the open file if the handle is given
+func (fs *FileSystem) Getattr(name string, fh uint64) (*Attributes, error) {
+	if f := fs.getHandle(fh); f != nil {
+		f.Lock()
+		defer f.Unlock()
+		return fs.toAttributes(f.path, f.entry), nil
+	}
+	fullPath, entry, err := fs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if f := fs.getOpenFile(fullPath); f != nil {
+		f.Lock()
+		defer f.Unlock()
+		return fs.toAttributes(f.path, f.entry), nil
+	}
+	return fs.toAttributes(fullPath, entry), nil
+}
+
+// Readdir lists the directory, with the attributes of the children
+func (fs *FileSystem) Readdir(name string, fn func(name string, attributes *Attributes) bool) error {
+	dir, entry, err := fs.resolve(name)
+	if err != nil {
+		return err
+	}
+	if !entry.IsDirectory {
+		return ErrNotDirectory
+	}
+	if !fn(".", fs.toAttributes(dir, entry)) || !fn("..", nil) {
+		return nil
+	}
+	stopped := errors.New("stopped")
+	err = filer_pb.ReadDirAllEntries(fs, dir, "", func(child *filer_pb.Entry, isLast bool) error {
+		if child.Attributes == nil {
+			child.Attributes = &filer_pb.FuseAttributes{}
+		}
+		if !fn(child.Name, fs.toAttributes(dir.Child(child.Name), child)) {
+			return stopped
+		}
+		return nil
+	})
+	if err == stopped {
+		return nil
+	}
+	return toError(err)
+}
+
+func (fs *FileSystem) Mkdir(name string, mode os.FileMode, uid, gid uint32) error {
+	dir, childName, err := fs.resolveParent(name)
+	if err != nil {
+		return err
+	}
+	return fs.createEntry(dir, fs.newEntry(childName, os.ModeDir|mode.Perm(), uid, gid))
+}
+
+func (fs *FileSystem) Symlink(target, name string, uid, gid uint32) error {
+	dir, childName, err := fs.resolveParent(name)
+	if err != nil {
+		return err
+	}
+	entry := fs.newEntry(childName, os.ModeSymlink|0777, uid, gid)
+	entry.Attributes.SymlinkTarget = target
+	return fs.createEntry(dir, entry)
+}
+
+func (fs *FileSystem) Readlink(name string) (string, error) {
+	_, entry, err := fs.resolve(name)
+	if err != nil {
+		return "", err
+	}
+	if os.FileMode(entry.Attributes.FileMode)&os.ModeSymlink == 0 {
+		return "", ErrInvalid
+	}
+	return entry.Attributes.SymlinkTarget, nil
+}
+
+func (fs *FileSystem) Unlink(name string) error {
+	fullPath, entry, err := fs.resolve(name)
+	if err != nil {
+		return err
+	}
+	if entry.IsDirectory {
+		return ErrIsDirectory
+	}
+	return fs.removeEntry(fullPath)
+}
+
+func (fs *FileSystem) Rmdir(name string) error {
+	fullPath, entry, err := fs.resolve(name)
+	if err != nil {
+		return err
+	}
+	if !entry.IsDirectory {
+		return ErrNotDirectory
+	}
+	if fullPath == fs.root {
+		return ErrInvalid
+	}
+	isEmpty := true
+	err = filer_pb.List(fs, string(fullPath), "", func(entry *filer_pb.Entry, isLast bool) error {
+		isEmpty = false
+		return nil
+	}, "", false, 1)
+	if err != nil {
+		return toError(err)
+	}
+	if !isEmpty {
+		return ErrNotEmpty
+	}
+	return fs.removeEntry(fullPath)
+}
+
+func (fs *FileSystem) removeEntry(fullPath util.FullPath) error {
+	if fs.option.ReadOnly {
+		return ErrReadOnly
+	}
+	dir, name := fullPath.DirAndName()
+	return toError(fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.DoRemove(client, dir, name, true, false, false, false, []int32{fs.signature})
+	}))
+}
+
+// Rename moves the entry, and replaces the target if any.
+// Renaming to the same name in different letter cases only changes the letter cases.
+func (fs *FileSystem) Rename(oldName, newName string) error {
+	if fs.option.ReadOnly {
+		return ErrReadOnly
+	}
+	oldPath, oldEntry, err := fs.resolve(oldName)
+	if err != nil {
+		return err
+	}
+	if oldPath == fs.root {
+		return ErrInvalid
+	}
+	newDir, newChildName, err := fs.resolveParent(newName)
+	if err != nil {
+		return err
+	}
+	newPath := newDir.Child(newChildName)
+	if newPath == oldPath {
+		return nil
+	}
+	if newPath.IsUnder(oldPath) {
+		return ErrInvalid
+	}
+	if existing, err := fs.lookupChild(newDir, newChildName); err == nil {
+		existingPath := newDir.Child(existing.Name)
+		if existingPath != oldPath {
+			if existing.IsDirectory != oldEntry.IsDirectory {
+				if existing.IsDirectory {
+					return ErrIsDirectory
+				}
+				return ErrNotDirectory
+			}
+			if existing.IsDirectory {
+				if err := fs.Rmdir(strings.TrimPrefix(string(existingPath), string(fs.root))); err != nil {
+					return err
+				}
+			} else if err := fs.removeEntry(existingPath); err != nil {
+				return err
+			}
+		}
+	} else if err != ErrNotFound {
+		return err
+	}
+
+	// the buffered writes are saved to the old path first
+	if f := fs.getOpenFile(oldPath); f != nil {
+		f.Lock()
+		err = fs.flushLocked(f)
+		f.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	oldDir, oldChildName := oldPath.DirAndName()
+	err = toError(fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.AtomicRenameEntry(context.Background(), &filer_pb.AtomicRenameEntryRequest{
+			OldDirectory: oldDir,
+			OldName:      oldChildName,
+			NewDirectory: string(newDir),
+			NewName:      newChildName,
+			Signatures:   []int32{fs.signature},
+		})
+		return err
+	}))
+	if err == nil {
+		fs.renameOpenFiles(oldPath, newPath)
+	}
+	return err
+}
+
+// updateAttributes changes the entry, also the one of the open file
+func (fs *FileSystem) updateAttributes(name string, fn func(entry *filer_pb.Entry)) error {
+	if fs.option.ReadOnly {
+		return ErrReadOnly
+	}
+	fullPath, entry, err := fs.resolve(name)
+	if err != nil {
+		return err
+	}
+	if f := fs.getOpenFile(fullPath); f != nil {
+		f.Lock()
+		defer f.Unlock()
+		fn(f.entry)
+		f.dirty = true
+		return fs.flushLocked(f)
+	}
+	fn(entry)
+	if fullPath == "/" {
+		return nil
+	}
+	return fs.saveEntry(fullPath, entry)
+}
+
+func (fs *FileSystem) Chmod(name string, mode os.FileMode) error {
+	return fs.updateAttributes(name, func(entry *filer_pb.Entry) {
+		entry.Attributes.FileMode = uint32(os.FileMode(entry.Attributes.FileMode)&^os.ModePerm | mode.Perm())
+	})
+}
+
+func (fs *FileSystem) Chown(name string, uid, gid uint32) error {
+	return fs.updateAttributes(name, func(entry *filer_pb.Entry) {
+		if uid != ^uint32(0) {
+			entry.Attributes.Uid = uid
+		}
+		if gid != ^uint32(0) {
+			entry.Attributes.Gid = gid
+		}
+	})
+}
+
+func (fs *FileSystem) Utimens(name string, mtime time.Time) error {
+	return fs.updateAttributes(name, func(entry *filer_pb.Entry) {
+		entry.Attributes.Mtime = mtime.Unix()
+	})
+}
+
+func (fs *FileSystem) Setcrtime(name string, crtime time.Time) error {
+	return fs.updateAttributes(name, func(entry *filer_pb.Entry) {
+		entry.Attributes.Crtime = crtime.Unix()
+	})
+}
+
+// Chflags sets the Windows file attributes
+func (fs *FileSystem) Chflags(name string, flags uint32) error {
+	return fs.updateAttributes(name, func(entry *filer_pb.Entry) {
+		setWindowsFlags(entry, flags)
+	})
+}
+
+type Statistics struct {
+	TotalSize uint64
+	UsedSize  uint64
+	FileCount uint64
+}
+
+// Statfs reads the filer statistics, cached for a few seconds
+func (fs *FileSystem) Statfs() Statistics {
+	fs.statsLock.Lock()
+	defer fs.statsLock.Unlock()
+	if fs.stats == nil || time.Since(fs.statsTime) >= statisticsCacheTtl {
+		err := fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			resp, err := client.Statistics(context.Background(), &filer_pb.StatisticsRequest{
+				Collection:  fs.option.Collection,
+				Replication: fs.option.Replication,
+				DiskType:    fs.option.DiskType,
+			})
+			if err != nil {
+				return err
+			}
+			fs.stats, fs.statsTime = resp, time.Now()
+			return nil
+		})
+		if err != nil {
+			glog.V(0).Infof("read filer statistics: %v", err)
+		}
+	}
+	if fs.stats == nil {
+		return Statistics{}
+	}
+	return Statistics{
+		TotalSize: fs.stats.TotalSize,
+		UsedSize:  fs.stats.UsedSize,
+		FileCount: fs.stats.FileCount,
+	}
+}
+
+func toError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, filer_pb.ErrNotFound) || strings.Contains(err.Error(), filer_pb.ErrNotFound.Error()):
+		return ErrNotFound
+	case strings.Contains(err.Error(), "EEXIST") || strings.Contains(err.Error(), "existing"):
+		return ErrExist
+	case strings.Contains(err.Error(), "not empty") || strings.Contains(err.Error(), "non-empty"):
+		return ErrNotEmpty
+	}
+	glog.V(1).Infof("filer operation: %v", err)
+	return ErrIO
+}
@@ -0,0 +1,202 @@
+package winfsp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// fakeFiler keeps the entries in memory, for the namespace operations
+type fakeFiler struct {
+	filer_pb.UnimplementedSeaweedFilerServer
+	sync.Mutex
+	entries map[util.FullPath]*filer_pb.Entry
+}
+
+func (f *fakeFiler) LookupDirectoryEntry(ctx context.Context, req *filer_pb.LookupDirectoryEntryRequest) (*filer_pb.LookupDirectoryEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	entry, found := f.entries[util.NewFullPath(req.Directory, req.Name)]
+	if !found {
+		return nil, filer_pb.ErrNotFound
+	}
+	return &filer_pb.LookupDirectoryEntryResponse{Entry: proto.Clone(entry).(*filer_pb.Entry)}, nil
+}
+
+func (f *fakeFiler) ListEntries(req *filer_pb.ListEntriesRequest, stream grpc.ServerStreamingServer[filer_pb.ListEntriesResponse]) error {
+	f.Lock()
+	var names []string
+	children := make(map[string]*filer_pb.Entry)
+	for path, entry := range f.entries {
+		if dir, name := path.DirAndName(); dir == req.Directory && name > req.StartFromFileName {
+			names = append(names, name)
+			children[name] = proto.Clone(entry).(*filer_pb.Entry)
+		}
+	}
+	f.Unlock()
+	sort.Strings(names)
+	for i, name := range names {
+		if req.Limit > 0 && uint32(i) >= req.Limit {
+			break
+		}
+		if err := stream.Send(&filer_pb.ListEntriesResponse{Entry: children[name]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeFiler) CreateEntry(ctx context.Context, req *filer_pb.CreateEntryRequest) (*filer_pb.CreateEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	path := util.NewFullPath(req.Directory, req.Entry.Name)
+	if _, found := f.entries[path]; found {
+		return &filer_pb.CreateEntryResponse{Error: "EEXIST: entry already exists"}, nil
+	}
+	f.entries[path] = req.Entry
+	return &filer_pb.CreateEntryResponse{}, nil
+}
+
+func (f *fakeFiler) UpdateEntry(ctx context.Context, req *filer_pb.UpdateEntryRequest) (*filer_pb.UpdateEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.entries[util.NewFullPath(req.Directory, req.Entry.Name)] = req.Entry
+	return &filer_pb.UpdateEntryResponse{}, nil
+}
+
+func (f *fakeFiler) DeleteEntry(ctx context.Context, req *filer_pb.DeleteEntryRequest) (*filer_pb.DeleteEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.entries, util.NewFullPath(req.Directory, req.Name))
+	return &filer_pb.DeleteEntryResponse{}, nil
+}
+
+func (f *fakeFiler) AtomicRenameEntry(ctx context.Context, req *filer_pb.AtomicRenameEntryRequest) (*filer_pb.AtomicRenameEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	oldPath, newPath := util.NewFullPath(req.OldDirectory, req.OldName), util.NewFullPath(req.NewDirectory, req.NewName)
+	for path, entry := range f.entries {
+		if path == oldPath || path.IsUnder(oldPath) {
+			delete(f.entries, path)
+			renamed := util.FullPath(string(newPath) + strings.TrimPrefix(string(path), string(oldPath)))
+			entry.Name = renamed.Name()
+			f.entries[renamed] = entry
+		}
+	}
+	return &filer_pb.AtomicRenameEntryResponse{}, nil
+}
+
+func newTestFileSystem(t *testing.T) (*fakeFiler, *FileSystem) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filer := &fakeFiler{entries: make(map[util.FullPath]*filer_pb.Entry)}
+	grpcServer := grpc.NewServer()
+	filer_pb.RegisterSeaweedFilerServer(grpcServer, filer)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	fs, err := NewFileSystem(&Option{
+		Filer:           pb.ServerAddress(fmt.Sprintf("127.0.0.1:%d.%d", port, port)),
+		GrpcDialOption:  grpc.WithTransportCredentials(insecure.NewCredentials()),
+		CaseInsensitive: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filer, fs
+}
+
+func TestCaseInsensitiveNames(t *testing.T) {
+	filer, fs := newTestFileSystem(t)
+
+	if err := fs.Mkdir("/Docs", 0755, 1000, 1000); err != nil {
+		t.Fatal(err)
+	}
+	fh, err := fs.Create("/docs/Report.TXT", 0644, 1000, 1000)
+	if err != nil {
+		t.Fatalf("create under the directory in other letter cases: %v", err)
+	}
+	if err := fs.Release(fh); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := filer.entries["/Docs/Report.TXT"]; !found {
+		t.Fatalf("the file should be created in the existing directory")
+	}
+
+	// the same file in any letter cases
+	if _, err := fs.Getattr("/DOCS/report.txt", ^uint64(0)); err != nil {
+		t.Errorf("getattr in other letter cases: %v", err)
+	}
+	if _, err := fs.Create("/docs/REPORT.txt", 0644, 1000, 1000); err != ErrExist {
+		t.Errorf("expect ErrExist for the name in other letter cases, got %v", err)
+	}
+
+	// renaming to other letter cases keeps one file
+	if err := fs.Rename("/docs/report.txt", "/docs/report.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := filer.entries["/Docs/report.txt"]; !found || len(filer.entries) != 2 {
+		t.Errorf("unexpected entries after renaming the letter cases: %v", filer.entries)
+	}
+
+	// the renaming replaces the target in other letter cases
+	fh, err = fs.Create("/docs/other.txt", 0644, 1000, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.Release(fh)
+	if err := fs.Rename("/docs/other.txt", "/docs/REPORT.TXT"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := filer.entries["/Docs/REPORT.TXT"]; !found || len(filer.entries) != 2 {
+		t.Errorf("unexpected entries after replacing: %v", filer.entries)
+	}
+}
+
+func TestWindowsAttributes(t *testing.T) {
+	_, fs := newTestFileSystem(t)
+
+	fh, err := fs.Create("/.config", 0644, 1000, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.Release(fh)
+	attributes, err := fs.Getattr("/.config", ^uint64(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attributes.Flags != FlagHidden || attributes.Mode != modeTypeRegular|0644 {
+		t.Errorf("the dot file should be hidden, got flags %x mode %o", attributes.Flags, attributes.Mode)
+	}
+
+	if err := fs.Chflags("/.config", FlagReadonly|FlagSystem|FlagArchive); err != nil {
+		t.Fatal(err)
+	}
+	attributes, _ = fs.Getattr("/.config", ^uint64(0))
+	if attributes.Flags != FlagReadonly|FlagSystem|FlagArchive|FlagHidden || attributes.Mode&0222 != 0 {
+		t.Errorf("unexpected flags %x mode %o", attributes.Flags, attributes.Mode)
+	}
+
+	if err := fs.Chflags("/.config", 0); err != nil {
+		t.Fatal(err)
+	}
+	attributes, _ = fs.Getattr("/.config", ^uint64(0))
+	if attributes.Flags != FlagHidden || attributes.Mode&0200 == 0 {
+		t.Errorf("the read-only attribute should be cleared, got flags %x mode %o", attributes.Flags, attributes.Mode)
+	}
+}
@@ -0,0 +1,248 @@
+//go:build windows && winfsp
+// +build windows,winfsp
+
+package winfsp
+
+import (
+	"os"
+	"time"
+
+	"github.com/winfsp/cgofuse/fuse"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+// host adapts the FileSystem to the cgofuse interface of WinFsp
+type host struct {
+	fuse.FileSystemBase
+	fs *FileSystem
+}
+
+var (
+	_ fuse.FileSystemChflags   = &host{}
+	_ fuse.FileSystemSetcrtime = &host{}
+)
+
+// Mount serves the file system on the drive letter or the directory until unmounted, e.g., "S:"
+func Mount(fs *FileSystem, mountPoint string, options []string) bool {
+	h := fuse.NewFileSystemHost(&host{fs: fs})
+	h.SetCapCaseInsensitive(fs.option.CaseInsensitive)
+	h.SetCapReaddirPlus(true)
+	return h.Mount(mountPoint, options)
+}
+
+func toErrno(err error) int {
+	switch err {
+	case nil:
+		return 0
+	case ErrNotFound:
+		return -fuse.ENOENT
+	case ErrExist:
+		return -fuse.EEXIST
+	case ErrNotEmpty:
+		return -fuse.ENOTEMPTY
+	case ErrNotDirectory:
+		return -fuse.ENOTDIR
+	case ErrIsDirectory:
+		return -fuse.EISDIR
+	case ErrInvalid:
+		return -fuse.EINVAL
+	case ErrBadHandle:
+		return -fuse.EBADF
+	case ErrReadOnly:
+		return -fuse.EROFS
+	}
+	return -fuse.EIO
+}
+
+func fillStat(stat *fuse.Stat_t, attributes *Attributes) {
+	*stat = fuse.Stat_t{
+		Ino:      attributes.Inode,
+		Mode:     attributes.Mode,
+		Nlink:    attributes.Nlink,
+		Uid:      attributes.Uid,
+		Gid:      attributes.Gid,
+		Size:     attributes.Size,
+		Atim:     fuse.NewTimespec(attributes.Mtime),
+		Mtim:     fuse.NewTimespec(attributes.Mtime),
+		Ctim:     fuse.NewTimespec(attributes.Ctime),
+		Birthtim: fuse.NewTimespec(attributes.Crtime),
+		Blksize:  4096,
+		Blocks:   (attributes.Size + 511) / 512,
+		Flags:    attributes.Flags,
+	}
+}
+
+func (h *host) Statfs(path string, stat *fuse.Statfs_t) int {
+	const blockSize = 4096
+	statistics := h.fs.Statfs()
+	total, used := statistics.TotalSize/blockSize, statistics.UsedSize/blockSize
+	free := uint64(0)
+	if total > used {
+		free = total - used
+	}
+	*stat = fuse.Statfs_t{
+		Bsize:   blockSize,
+		Frsize:  blockSize,
+		Blocks:  total,
+		Bfree:   free,
+		Bavail:  free,
+		Files:   statistics.FileCount,
+		Ffree:   1 << 30,
+		Favail:  1 << 30,
+		Namemax: 255,
+	}
+	return 0
+}
+
+func (h *host) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	attributes, err := h.fs.Getattr(path, fh)
+	if err != nil {
+		return toErrno(err)
+	}
+	fillStat(stat, attributes)
+	return 0
+}
+
+func (h *host) Mkdir(path string, mode uint32) int {
+	uid, gid, _ := fuse.Getcontext()
+	return toErrno(h.fs.Mkdir(path, os.FileMode(mode).Perm(), uid, gid))
+}
+
+func (h *host) Mknod(path string, mode uint32, dev uint64) int {
+	uid, gid, _ := fuse.Getcontext()
+	fh, err := h.fs.Create(path, os.FileMode(mode).Perm(), uid, gid)
+	if err != nil {
+		return toErrno(err)
+	}
+	return toErrno(h.fs.Release(fh))
+}
+
+func (h *host) Create(path string, flags int, mode uint32) (int, uint64) {
+	uid, gid, _ := fuse.Getcontext()
+	fh, err := h.fs.Create(path, os.FileMode(mode).Perm(), uid, gid)
+	if err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	return 0, fh
+}
+
+func (h *host) Open(path string, flags int) (int, uint64) {
+	fh, err := h.fs.Open(path, flags&fuse.O_TRUNC != 0)
+	if err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	return 0, fh
+}
+
+func (h *host) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	n, err := h.fs.Read(fh, buff, ofst)
+	if err != nil {
+		return toErrno(err)
+	}
+	return n
+}
+
+func (h *host) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	n, err := h.fs.Write(fh, buff, ofst)
+	if err != nil {
+		return toErrno(err)
+	}
+	return n
+}
+
+func (h *host) Truncate(path string, size int64, fh uint64) int {
+	return toErrno(h.fs.Truncate(path, size, fh))
+}
+
+func (h *host) Flush(path string, fh uint64) int {
+	return toErrno(h.fs.Flush(fh))
+}
+
+func (h *host) Fsync(path string, datasync bool, fh uint64) int {
+	return toErrno(h.fs.Flush(fh))
+}
+
+func (h *host) Release(path string, fh uint64) int {
+	if err := h.fs.Release(fh); err != nil {
+		glog.Errorf("release %s: %v", path, err)
+		return toErrno(err)
+	}
+	return 0
+}
+
+func (h *host) Opendir(path string) (int, uint64) {
+	attributes, err := h.fs.Getattr(path, ^uint64(0))
+	if err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	if attributes.Mode&modeTypeDirectory != modeTypeDirectory {
+		return -fuse.ENOTDIR, ^uint64(0)
+	}
+	return 0, 0
+}
+
+func (h *host) Releasedir(path string, fh uint64) int {
+	return 0
+}
+
+func (h *host) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	err := h.fs.Readdir(path, func(name string, attributes *Attributes) bool {
+		if attributes == nil {
+			return fill(name, nil, 0)
+		}
+		stat := &fuse.Stat_t{}
+		fillStat(stat, attributes)
+		return fill(name, stat, 0)
+	})
+	return toErrno(err)
+}
+
+func (h *host) Unlink(path string) int {
+	return toErrno(h.fs.Unlink(path))
+}
+
+func (h *host) Rmdir(path string) int {
+	return toErrno(h.fs.Rmdir(path))
+}
+
+func (h *host) Rename(oldpath string, newpath string) int {
+	return toErrno(h.fs.Rename(oldpath, newpath))
+}
+
+func (h *host) Symlink(target string, newpath string) int {
+	uid, gid, _ := fuse.Getcontext()
+	return toErrno(h.fs.Symlink(target, newpath, uid, gid))
+}
+
+func (h *host) Readlink(path string) (int, string) {
+	target, err := h.fs.Readlink(path)
+	if err != nil {
+		return toErrno(err), ""
+	}
+	return 0, target
+}
+
+func (h *host) Chmod(path string, mode uint32) int {
+	return toErrno(h.fs.Chmod(path, os.FileMode(mode).Perm()))
+}
+
+func (h *host) Chown(path string, uid uint32, gid uint32) int {
+	return toErrno(h.fs.Chown(path, uid, gid))
+}
+
+func (h *host) Utimens(path string, tmsp []fuse.Timespec) int {
+	mtime := time.Now()
+	if len(tmsp) > 1 {
+		mtime = tmsp[1].Time()
+	}
+	return toErrno(h.fs.Utimens(path, mtime))
+}
+
+func (h *host) Chflags(path string, flags uint32) int {
+	return toErrno(h.fs.Chflags(path, flags))
+}
+
+func (h *host) Setcrtime(path string, tmsp fuse.Timespec) int {
+	return toErrno(h.fs.Setcrtime(path, tmsp.Time()))
+}
@@ -0,0 +1,218 @@
+package mount
+
+import (
+	"sync"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// WritebackCache lets close() return before the written file is flushed to the filer.
+// The closed files are flushed by the background flushers, and the file handle is kept open until then,
+// so reopening the file in this mount reads the dirty data, and fsync() still waits until the file is persisted.
+// The dirty bytes are limited, and the writes wait for the flushers when the limit is reached.
+type WritebackCache struct {
+	dirtyLimit int64
+	flush      func(fh *FileHandle, uid, gid uint32) fuse.Status
+	reference  func(fh *FileHandle) bool
+	release    func(fh *FileHandle)
+
+	sync.Mutex
+	dirtyCond    *sync.Cond
+	dirtyBytes   int64
+	dirtyHandles map[FileHandleId]*writebackRequest
+
+	scheduleLock sync.Mutex
+	pending      map[FileHandleId]*writebackRequest
+	queue        chan *writebackRequest
+}
+
+type writebackRequest struct {
+	fh         *FileHandle
+	uid, gid   uint32
+	dirtyBytes int64
+	done       chan struct{}
+}
+
+func NewWritebackCache(wfs *WFS, dirtyLimit int64, flushers int) *WritebackCache {
+	return newWritebackCache(dirtyLimit, flushers, wfs.doFlush, wfs.fhMap.ReferenceFileHandle, func(fh *FileHandle) {
+		wfs.ReleaseHandle(fh.fh)
+	})
+}
+
+func newWritebackCache(dirtyLimit int64, flushers int, flush func(fh *FileHandle, uid, gid uint32) fuse.Status, reference func(fh *FileHandle) bool, release func(fh *FileHandle)) *WritebackCache {
+	c := &WritebackCache{
+		dirtyLimit:   dirtyLimit,
+		flush:        flush,
+		reference:    reference,
+		release:      release,
+		dirtyHandles: make(map[FileHandleId]*writebackRequest),
+		pending:      make(map[FileHandleId]*writebackRequest),
+		queue:        make(chan *writebackRequest, 1024),
+	}
+	c.dirtyCond = sync.NewCond(&c.Mutex)
+	if flushers <= 0 {
+		flushers = 1
+	}
+	for i := 0; i < flushers; i++ {
+		go c.loopFlush()
+	}
+	return c
+}
+
+// WaitForDirtyRoom blocks the write until the dirty bytes are below the limit.
+// It must be called without holding the file handle lock, since the file may be flushed meanwhile.
+func (c *WritebackCache) WaitForDirtyRoom() {
+	c.Lock()
+	for c.dirtyBytes >= c.dirtyLimit {
+		var dirtyHandles []*writebackRequest
+		for _, dirty := range c.dirtyHandles {
+			dirtyHandles = append(dirtyHandles, dirty)
+		}
+		c.Unlock()
+		for _, dirty := range dirtyHandles {
+			c.Schedule(dirty.fh, dirty.uid, dirty.gid)
+		}
+		c.Lock()
+		if c.dirtyBytes >= c.dirtyLimit {
+			c.dirtyCond.Wait()
+		}
+	}
+	c.Unlock()
+}
+
+// AddDirtyBytes counts the bytes written to the file handle since its last flush
+func (c *WritebackCache) AddDirtyBytes(fh *FileHandle, size int64, uid, gid uint32) {
+	c.Lock()
+	defer c.Unlock()
+	dirty, found := c.dirtyHandles[fh.fh]
+	if !found {
+		dirty = &writebackRequest{fh: fh}
+		c.dirtyHandles[fh.fh] = dirty
+	}
+	dirty.uid, dirty.gid = uid, gid
+	dirty.dirtyBytes += size
+	c.dirtyBytes += size
+}
+
+// IsOverLimit checks whether the close() should flush the file by itself
+func (c *WritebackCache) IsOverLimit() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.dirtyBytes >= c.dirtyLimit
+}
+
+// getDirtyBytes returns the bytes written to the file handle and not flushed yet
+func (c *WritebackCache) getDirtyBytes(fh *FileHandle) int64 {
+	c.Lock()
+	defer c.Unlock()
+	if dirty, found := c.dirtyHandles[fh.fh]; found {
+		return dirty.dirtyBytes
+	}
+	return 0
+}
+
+// forgetDirtyBytes is called when the dirty bytes of the file handle are flushed, or all of them when released
+func (c *WritebackCache) forgetDirtyBytes(fh *FileHandle, size int64) {
+	c.Lock()
+	defer c.Unlock()
+	dirty, found := c.dirtyHandles[fh.fh]
+	if !found {
+		return
+	}
+	if size < 0 || size > dirty.dirtyBytes {
+		size = dirty.dirtyBytes
+	}
+	dirty.dirtyBytes -= size
+	c.dirtyBytes -= size
+	if dirty.dirtyBytes == 0 {
+		delete(c.dirtyHandles, fh.fh)
+	}
+	c.dirtyCond.Broadcast()
+}
+
+// Schedule flushes the file handle in the background, and keeps it open until flushed
+func (c *WritebackCache) Schedule(fh *FileHandle, uid, gid uint32) {
+	c.scheduleLock.Lock()
+	if _, found := c.pending[fh.fh]; found || !c.reference(fh) {
+		c.scheduleLock.Unlock()
+		return
+	}
+	request := &writebackRequest{
+		fh:   fh,
+		uid:  uid,
+		gid:  gid,
+		done: make(chan struct{}),
+	}
+	c.pending[fh.fh] = request
+	c.scheduleLock.Unlock()
+
+	c.queue <- request
+}
+
+// WaitForHandle waits until the scheduled flush of the file handle is done
+func (c *WritebackCache) WaitForHandle(fh *FileHandle) {
+	c.scheduleLock.Lock()
+	request, found := c.pending[fh.fh]
+	c.scheduleLock.Unlock()
+	if found {
+		<-request.done
+	}
+}
+
+// FlushAll flushes all the dirty file handles and waits for them, e.g., when unmounting
+func (c *WritebackCache) FlushAll() {
+	c.Lock()
+	var dirtyHandles []*writebackRequest
+	for _, dirty := range c.dirtyHandles {
+		dirtyHandles = append(dirtyHandles, dirty)
+	}
+	c.Unlock()
+	for _, dirty := range dirtyHandles {
+		c.Schedule(dirty.fh, dirty.uid, dirty.gid)
+	}
+
+	c.scheduleLock.Lock()
+	var pending []*writebackRequest
+	for _, request := range c.pending {
+		pending = append(pending, request)
+	}
+	c.scheduleLock.Unlock()
+	for _, request := range pending {
+		<-request.done
+	}
+}
+
+func (c *WritebackCache) loopFlush() {
+	for request := range c.queue {
+		fh := request.fh
+		if status := c.flush(fh, request.uid, request.gid); status != fuse.OK {
+			glog.Errorf("writeback %s fh %d: %v", fh.FullPath(), fh.fh, status)
+			fh.writebackStatus.Store(int32(status))
+		}
+
+		c.scheduleLock.Lock()
+		delete(c.pending, fh.fh)
+		close(request.done)
+		c.scheduleLock.Unlock()
+
+		c.release(fh)
+	}
+}
+
+// takeWritebackStatus returns the error of the earlier background flush, so it is reported by the next fsync() or close()
+func (fh *FileHandle) takeWritebackStatus() fuse.Status {
+	return fuse.Status(fh.writebackStatus.Swap(int32(fuse.OK)))
+}
+
+func (wfs *WFS) waitForWriteback(path util.FullPath) {
+	if wfs.writebackCache == nil {
+		return
+	}
+	if inode, found := wfs.inodeToPath.GetInode(path); found {
+		if fh, fhFound := wfs.fhMap.FindFileHandle(inode); fhFound {
+			wfs.writebackCache.WaitForHandle(fh)
+		}
+	}
+}
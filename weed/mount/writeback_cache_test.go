@@ -0,0 +1,82 @@
+package mount
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestWritebackCache(t *testing.T) {
+	wfs := &WFS{inodeToPath: NewInodeToPath(util.FullPath("/"), 0)}
+	var c *WritebackCache
+	var references, releases, flushes atomic.Int32
+	flushGate := make(chan struct{})
+	flushStatus := fuse.OK
+	c = newWritebackCache(100, 2, func(fh *FileHandle, uid, gid uint32) fuse.Status {
+		<-flushGate
+		flushes.Add(1)
+		c.forgetDirtyBytes(fh, c.getDirtyBytes(fh))
+		return flushStatus
+	}, func(fh *FileHandle) bool {
+		references.Add(1)
+		return true
+	}, func(fh *FileHandle) {
+		releases.Add(1)
+	})
+
+	fh1 := &FileHandle{fh: 1, inode: 1, wfs: wfs}
+	fh2 := &FileHandle{fh: 2, inode: 2, wfs: wfs}
+
+	// close() returns before the flush, and the handle is only scheduled once
+	c.AddDirtyBytes(fh1, 40, 0, 0)
+	c.Schedule(fh1, 0, 0)
+	c.Schedule(fh1, 0, 0)
+	if references.Load() != 1 || flushes.Load() != 0 {
+		t.Fatalf("expected one reference and no flush, got %d references and %d flushes", references.Load(), flushes.Load())
+	}
+
+	// the writes wait for the room when the dirty bytes reach the limit
+	c.AddDirtyBytes(fh2, 60, 0, 0)
+	if !c.IsOverLimit() {
+		t.Fatalf("100 dirty bytes should reach the limit")
+	}
+	var wg sync.WaitGroup
+	var waited atomic.Bool
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.WaitForDirtyRoom()
+		waited.Store(true)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if waited.Load() {
+		t.Fatalf("the write should wait for the flushers")
+	}
+	close(flushGate)
+	wg.Wait()
+
+	// fsync() waits for the scheduled flush
+	c.WaitForHandle(fh1)
+	c.WaitForHandle(fh2)
+	if c.IsOverLimit() || c.getDirtyBytes(fh1) != 0 || c.getDirtyBytes(fh2) != 0 {
+		t.Errorf("the dirty bytes should be flushed")
+	}
+
+	// the error of the background flush is reported once by the next fsync() or close()
+	flushStatus = fuse.EIO
+	c.AddDirtyBytes(fh1, 10, 0, 0)
+	c.FlushAll()
+	if status := fh1.takeWritebackStatus(); status != fuse.EIO {
+		t.Errorf("expected the background flush error, got %v", status)
+	}
+	if status := fh1.takeWritebackStatus(); status != fuse.OK {
+		t.Errorf("the error should be reported once, got %v", status)
+	}
+	if references.Load() != releases.Load() {
+		t.Errorf("%d references but %d releases", references.Load(), releases.Load())
+	}
+}
@@ -0,0 +1,567 @@
+package amqp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/sub_client"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+var errConsumerCancelled = fmt.Errorf("consumer is cancelled")
+
+type deliveryOutcome int
+
+const (
+	outcomeAck deliveryOutcome = iota
+	outcomeDrop
+	outcomeRequeue
+)
+
+type channel struct {
+	conn        *connection
+	id          uint16
+	prefetch    int32
+	confirm     bool
+	publishSeq  uint64
+	publishing  *publishing
+	closing     bool // sent channel.close, waiting for close-ok
+	deliveryTag uint64
+	pending     map[uint64]chan deliveryOutcome // unacknowledged deliveries
+	pendingLock sync.Mutex
+	consumers   map[string]*consumer
+}
+
+// publishing is a basic.publish waiting for its content header and body frames
+type publishing struct {
+	exchange   string
+	routingKey string
+	bodySize   uint64
+	body       []byte
+	hasHeader  bool
+}
+
+type consumer struct {
+	tag         string
+	queue       string
+	noAck       bool
+	subscribers []*sub_client.TopicSubscriber
+	stopCh      chan struct{}
+	stopOnce    sync.Once
+}
+
+func (c *consumer) stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+		for _, subscriber := range c.subscribers {
+			subscriber.Shutdown()
+		}
+	})
+}
+
+func newChannel(conn *connection, id uint16) *channel {
+	return &channel{
+		conn:      conn,
+		id:        id,
+		prefetch:  conn.gateway.option.DefaultPrefetch,
+		pending:   make(map[uint64]chan deliveryOutcome),
+		consumers: make(map[string]*consumer),
+	}
+}
+
+// shutdown stops the consumers, the unacknowledged messages will be delivered again
+func (ch *channel) shutdown() {
+	for _, c := range ch.consumers {
+		c.stop()
+	}
+}
+
+// handleFrame processes one frame on the channel. A channel exception closes the channel,
+// and other errors close the connection.
+func (ch *channel) handleFrame(f *frame) (closed bool, err error) {
+	if ch.closing {
+		// drop all frames until the client confirms the close
+		if f.frameType == frameMethod {
+			d := newDecoder(f.payload)
+			if d.short() == classChannel && d.short() == methodChannelCloseOk {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	switch f.frameType {
+	case frameMethod:
+		if ch.publishing != nil {
+			return false, &amqpError{code: replyUnexpectedFrame, text: "UNEXPECTED_FRAME - expect content of basic.publish"}
+		}
+		closed, err = ch.handleMethod(newDecoder(f.payload))
+	case frameHeader:
+		err = ch.handleContentHeader(f.payload)
+	case frameBody:
+		err = ch.handleContentBody(f.payload)
+	default:
+		err = &amqpError{code: replyFrameError, text: fmt.Sprintf("FRAME_ERROR - unknown frame type %d", f.frameType)}
+	}
+
+	if e, ok := err.(*amqpError); ok && e.code < replyFrameError {
+		// soft error, only close the channel
+		glog.V(1).Infof("amqp channel %d close: %v", ch.id, e)
+		ch.shutdown()
+		ch.closing = true
+		closeMethod := newMethod(classChannel, methodChannelClose)
+		closeMethod.short(e.code)
+		closeMethod.shortstr(e.text)
+		closeMethod.short(e.classId)
+		closeMethod.short(e.methodId)
+		return false, ch.conn.sendMethod(ch.id, closeMethod)
+	}
+	return closed, err
+}
+
+func (ch *channel) handleMethod(d *decoder) (closed bool, err error) {
+	classId, methodId := d.short(), d.short()
+	if d.err != nil {
+		return false, &amqpError{code: replyFrameError, text: "FRAME_ERROR - malformed method"}
+	}
+	switch uint32(classId)<<16 | uint32(methodId) {
+	case classChannel<<16 | methodChannelClose:
+		ch.shutdown()
+		return true, ch.conn.sendMethod(ch.id, newMethod(classChannel, methodChannelCloseOk))
+	case classChannel<<16 | methodChannelFlow:
+		flowOk := newMethod(classChannel, methodChannelFlowOk)
+		flowOk.bit(d.bit())
+		err = ch.conn.sendMethod(ch.id, flowOk)
+	case classExchange<<16 | methodExchangeDeclare:
+		err = ch.exchangeDeclare(d)
+	case classExchange<<16 | methodExchangeDelete:
+		err = ch.exchangeDelete(d)
+	case classQueue<<16 | methodQueueDeclare:
+		err = ch.queueDeclare(d)
+	case classQueue<<16 | methodQueueBind:
+		err = ch.queueBind(d)
+	case classQueue<<16 | methodQueueUnbind:
+		err = ch.queueUnbind(d)
+	case classQueue<<16 | methodQueueDelete:
+		err = ch.queueDelete(d)
+	case classBasic<<16 | methodBasicQos:
+		err = ch.basicQos(d)
+	case classBasic<<16 | methodBasicConsume:
+		err = ch.basicConsume(d)
+	case classBasic<<16 | methodBasicCancel:
+		err = ch.basicCancel(d)
+	case classBasic<<16 | methodBasicPublish:
+		d.short()
+		ch.publishing = &publishing{
+			exchange:   d.shortstr(),
+			routingKey: d.shortstr(),
+		}
+	case classBasic<<16 | methodBasicAck:
+		tag, multiple := d.longlong(), d.bit()
+		err = ch.settle(tag, multiple, outcomeAck)
+	case classBasic<<16 | methodBasicReject:
+		tag, requeue := d.longlong(), d.bit()
+		err = ch.settle(tag, false, rejectOutcome(requeue))
+	case classBasic<<16 | methodBasicNack:
+		tag, multiple, requeue := d.longlong(), d.bit(), d.bit()
+		err = ch.settle(tag, multiple, rejectOutcome(requeue))
+	case classConfirm<<16 | methodConfirmSelect:
+		ch.confirm = true
+		if noWait := d.bit(); !noWait {
+			err = ch.conn.sendMethod(ch.id, newMethod(classConfirm, methodConfirmSelectOk))
+		}
+	default:
+		err = &amqpError{code: replyNotImplemented, text: fmt.Sprintf("NOT_IMPLEMENTED - method %d.%d", classId, methodId)}
+	}
+	if e, ok := err.(*amqpError); ok && e.classId == 0 {
+		e.classId, e.methodId = classId, methodId
+	}
+	return false, err
+}
+
+func rejectOutcome(requeue bool) deliveryOutcome {
+	if requeue {
+		return outcomeRequeue
+	}
+	return outcomeDrop
+}
+
+func (ch *channel) exchangeDeclare(d *decoder) error {
+	d.short()
+	name, kind := d.shortstr(), d.shortstr()
+	passive, _, _, _, noWait := d.bit(), d.bit(), d.bit(), d.bit(), d.bit()
+	if code, text := ch.conn.gateway.declareExchange(name, kind, passive); code != 0 {
+		return &amqpError{code: code, text: text}
+	}
+	if noWait {
+		return nil
+	}
+	return ch.conn.sendMethod(ch.id, newMethod(classExchange, methodExchangeDeclareOk))
+}
+
+func (ch *channel) exchangeDelete(d *decoder) error {
+	d.short()
+	name := d.shortstr()
+	_, noWait := d.bit(), d.bit()
+	ch.conn.gateway.deleteExchange(name)
+	if noWait {
+		return nil
+	}
+	return ch.conn.sendMethod(ch.id, newMethod(classExchange, methodExchangeDeleteOk))
+}
+
+func (ch *channel) queueDeclare(d *decoder) error {
+	d.short()
+	name := d.shortstr()
+	passive, _, _, _, noWait := d.bit(), d.bit(), d.bit(), d.bit(), d.bit()
+	if name == "" {
+		name = "amq.gen-" + randomString()
+	}
+	if code, text := ch.conn.gateway.declareQueue(name, passive); code != 0 {
+		return &amqpError{code: code, text: text}
+	}
+	if noWait {
+		return nil
+	}
+	declareOk := newMethod(classQueue, methodQueueDeclareOk)
+	declareOk.shortstr(name)
+	declareOk.long(0) // the backlog of a consumer group is not tracked
+	declareOk.long(0)
+	return ch.conn.sendMethod(ch.id, declareOk)
+}
+
+func (ch *channel) queueBind(d *decoder) error {
+	d.short()
+	queue, exchange, bindingKey := d.shortstr(), d.shortstr(), d.shortstr()
+	noWait := d.bit()
+	if code, text := ch.conn.gateway.bindQueue(queue, exchange, bindingKey); code != 0 {
+		return &amqpError{code: code, text: text}
+	}
+	if noWait {
+		return nil
+	}
+	return ch.conn.sendMethod(ch.id, newMethod(classQueue, methodQueueBindOk))
+}
+
+func (ch *channel) queueUnbind(d *decoder) error {
+	d.short()
+	queue, exchange, bindingKey := d.shortstr(), d.shortstr(), d.shortstr()
+	ch.conn.gateway.unbindQueue(queue, exchange, bindingKey)
+	return ch.conn.sendMethod(ch.id, newMethod(classQueue, methodQueueUnbindOk))
+}
+
+func (ch *channel) queueDelete(d *decoder) error {
+	d.short()
+	queue := d.shortstr()
+	_, _, noWait := d.bit(), d.bit(), d.bit()
+	ch.conn.gateway.deleteQueue(queue)
+	if noWait {
+		return nil
+	}
+	deleteOk := newMethod(classQueue, methodQueueDeleteOk)
+	deleteOk.long(0)
+	return ch.conn.sendMethod(ch.id, deleteOk)
+}
+
+func (ch *channel) basicQos(d *decoder) error {
+	d.long()
+	if prefetchCount := d.short(); prefetchCount > 0 {
+		ch.prefetch = int32(prefetchCount)
+	}
+	return ch.conn.sendMethod(ch.id, newMethod(classBasic, methodBasicQosOk))
+}
+
+func (ch *channel) basicConsume(d *decoder) error {
+	d.short()
+	queue, tag := d.shortstr(), d.shortstr()
+	_, noAck, _, noWait := d.bit(), d.bit(), d.bit(), d.bit()
+
+	g := ch.conn.gateway
+	exchangeBindings, found := g.queueBindings(queue)
+	if !found {
+		return &amqpError{code: replyNotFound, text: fmt.Sprintf("NOT_FOUND - no queue '%s'", queue)}
+	}
+	if tag == "" {
+		tag = "amq.ctag-" + randomString()
+	}
+	if _, found := ch.consumers[tag]; found {
+		return &amqpError{code: replyNotAllowed, text: fmt.Sprintf("NOT_ALLOWED - consumer tag '%s' is in use", tag)}
+	}
+	if len(exchangeBindings) == 0 {
+		// only bound to the default exchange
+		exchangeBindings[""] = nil
+	}
+
+	if !noWait {
+		consumeOk := newMethod(classBasic, methodBasicConsumeOk)
+		consumeOk.shortstr(tag)
+		if err := ch.conn.sendMethod(ch.id, consumeOk); err != nil {
+			return err
+		}
+	}
+
+	c := &consumer{
+		tag:    tag,
+		queue:  queue,
+		noAck:  noAck,
+		stopCh: make(chan struct{}),
+	}
+	instanceId := fmt.Sprintf("%s-%s", g.option.InstanceId, randomString())
+	for exchange, bindings := range exchangeBindings {
+		topicName := exchange
+		if exchange == "" {
+			topicName = queue
+		}
+		subscriber := sub_client.NewTopicSubscriber(g.option.Brokers, &sub_client.SubscriberConfiguration{
+			ConsumerGroup:           queue,
+			ConsumerGroupInstanceId: instanceId,
+			GrpcDialOption:          g.option.GrpcDialOption,
+			MaxPartitionCount:       g.option.MaxPartitionCount,
+			SlidingWindowSize:       ch.prefetch,
+		}, &sub_client.ContentConfiguration{
+			Topic:                   g.toTopic(topicName),
+			ResumeFromConsumerGroup: true,
+		}, make(chan sub_client.KeyedOffset, 1024))
+		subscriber.SetEachDataMessageFunc(func(message *mq_pb.DataMessage) error {
+			return ch.deliver(c, exchange, bindings, message)
+		})
+		c.subscribers = append(c.subscribers, subscriber)
+	}
+	ch.consumers[tag] = c
+	for _, subscriber := range c.subscribers {
+		go func(subscriber *sub_client.TopicSubscriber) {
+			if err := subscriber.Subscribe(); err != nil {
+				glog.Errorf("amqp consumer %s on queue %s: %v", c.tag, c.queue, err)
+			}
+		}(subscriber)
+	}
+	return nil
+}
+
+func (ch *channel) basicCancel(d *decoder) error {
+	tag := d.shortstr()
+	noWait := d.bit()
+	if c, found := ch.consumers[tag]; found {
+		c.stop()
+		delete(ch.consumers, tag)
+	}
+	if noWait {
+		return nil
+	}
+	cancelOk := newMethod(classBasic, methodBasicCancelOk)
+	cancelOk.shortstr(tag)
+	return ch.conn.sendMethod(ch.id, cancelOk)
+}
+
+// deliver sends the message to the client, and waits for the acknowledgement.
+// A returned error leaves the message unacknowledged in the consumer group.
+func (ch *channel) deliver(c *consumer, exchange string, bindings []binding, message *mq_pb.DataMessage) error {
+	routingKey := toRoutingKey(message.Key)
+	if len(bindings) > 0 && !anyBindingMatches(bindings, routingKey) {
+		// not routed to this queue
+		return nil
+	}
+
+	redelivered := false
+	for {
+		select {
+		case <-c.stopCh:
+			return errConsumerCancelled
+		default:
+		}
+
+		tag := atomic.AddUint64(&ch.deliveryTag, 1)
+		var outcomeCh chan deliveryOutcome
+		if !c.noAck {
+			outcomeCh = make(chan deliveryOutcome, 1)
+			ch.pendingLock.Lock()
+			ch.pending[tag] = outcomeCh
+			ch.pendingLock.Unlock()
+		}
+
+		deliverMethod := newMethod(classBasic, methodBasicDeliver)
+		deliverMethod.shortstr(c.tag)
+		deliverMethod.longlong(tag)
+		deliverMethod.bit(redelivered)
+		deliverMethod.shortstr(exchange)
+		deliverMethod.shortstr(routingKey)
+		if err := ch.conn.sendContent(ch.id, deliverMethod, message.Value); err != nil {
+			ch.forget(tag)
+			return err
+		}
+		if c.noAck {
+			return nil
+		}
+
+		select {
+		case outcome := <-outcomeCh:
+			if outcome != outcomeRequeue {
+				return nil
+			}
+			redelivered = true
+		case <-c.stopCh:
+			ch.forget(tag)
+			return errConsumerCancelled
+		}
+	}
+}
+
+func anyBindingMatches(bindings []binding, routingKey string) bool {
+	for _, b := range bindings {
+		if b.matches(routingKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ch *channel) forget(tag uint64) {
+	ch.pendingLock.Lock()
+	delete(ch.pending, tag)
+	ch.pendingLock.Unlock()
+}
+
+// settle resolves the deliveries up to the tag if multiple is set, or only the tag
+func (ch *channel) settle(tag uint64, multiple bool, outcome deliveryOutcome) error {
+	ch.pendingLock.Lock()
+	defer ch.pendingLock.Unlock()
+	if !multiple {
+		outcomeCh, found := ch.pending[tag]
+		if !found {
+			return &amqpError{code: replyPreconditionErr, text: fmt.Sprintf("PRECONDITION_FAILED - unknown delivery tag %d", tag)}
+		}
+		outcomeCh <- outcome
+		delete(ch.pending, tag)
+		return nil
+	}
+	for t, outcomeCh := range ch.pending {
+		if tag == 0 || t <= tag {
+			outcomeCh <- outcome
+			delete(ch.pending, t)
+		}
+	}
+	return nil
+}
+
+func (ch *channel) handleContentHeader(payload []byte) error {
+	if ch.publishing == nil || ch.publishing.hasHeader {
+		return &amqpError{code: replyUnexpectedFrame, text: "UNEXPECTED_FRAME - unexpected content header"}
+	}
+	d := newDecoder(payload)
+	d.short() // class
+	d.short() // weight
+	ch.publishing.bodySize = d.longlong()
+	// the properties are not kept
+	if d.err != nil {
+		return &amqpError{code: replyFrameError, text: "FRAME_ERROR - malformed content header"}
+	}
+	if maxSize := ch.conn.gateway.option.MaxMessageSize; ch.publishing.bodySize > uint64(maxSize) {
+		return &amqpError{code: replyContentTooLarge, text: fmt.Sprintf("CONTENT_TOO_LARGE - message size %d exceeds the max %d", ch.publishing.bodySize, maxSize)}
+	}
+	ch.publishing.hasHeader = true
+	if ch.publishing.bodySize == 0 {
+		return ch.publish()
+	}
+	return nil
+}
+
+func (ch *channel) handleContentBody(payload []byte) error {
+	if ch.publishing == nil || !ch.publishing.hasHeader {
+		return &amqpError{code: replyUnexpectedFrame, text: "UNEXPECTED_FRAME - unexpected content body"}
+	}
+	// the declared size is checked against the max message size, so the body is never buffered beyond it
+	if uint64(len(ch.publishing.body)+len(payload)) > ch.publishing.bodySize {
+		return &amqpError{code: replyFrameError, text: "FRAME_ERROR - content body exceeds the declared size"}
+	}
+	ch.publishing.body = append(ch.publishing.body, payload...)
+	if uint64(len(ch.publishing.body)) == ch.publishing.bodySize {
+		return ch.publish()
+	}
+	return nil
+}
+
+func (ch *channel) publish() error {
+	p := ch.publishing
+	ch.publishing = nil
+
+	topicName := p.exchange
+	if p.exchange == "" {
+		// the default exchange routes to the queue named by the routing key
+		topicName = p.routingKey
+	}
+	// in the confirm mode, the message is confirmed once the broker acks it, possibly out of order
+	var callback pub_client.PublishCallback
+	if ch.confirm {
+		ch.publishSeq++
+		seq := ch.publishSeq
+		callback = func(message *mq_pb.DataMessage, err error) {
+			if err != nil {
+				glog.V(1).Infof("amqp publish to exchange '%s' routing key '%s': %v", p.exchange, p.routingKey, err)
+			}
+			if sendErr := ch.sendConfirm(seq, err); sendErr != nil {
+				glog.V(1).Infof("amqp confirm %d on channel %d: %v", seq, ch.id, sendErr)
+			}
+		}
+	}
+	var err error
+	if topicName == "" {
+		err = fmt.Errorf("no queue to route to")
+	} else {
+		publisher := ch.conn.gateway.getOrCreatePublisher(ch.conn.gateway.toTopic(topicName))
+		err = publisher.PublishDataMessageAsync(&mq_pb.DataMessage{
+			Key:   toMessageKey(p.routingKey),
+			Value: p.body,
+		}, callback)
+	}
+	if err == nil {
+		return nil
+	}
+	glog.V(1).Infof("amqp publish to exchange '%s' routing key '%s': %v", p.exchange, p.routingKey, err)
+	if !ch.confirm {
+		return nil
+	}
+	return ch.sendConfirm(ch.publishSeq, err)
+}
+
+// sendConfirm sends basic.ack for the published message, or basic.nack if it fails
+func (ch *channel) sendConfirm(seq uint64, err error) error {
+	confirmMethod := newMethod(classBasic, methodBasicAck)
+	if err != nil {
+		confirmMethod = newMethod(classBasic, methodBasicNack)
+	}
+	confirmMethod.longlong(seq)
+	confirmMethod.bit(false)
+	if err != nil {
+		confirmMethod.bit(false)
+	}
+	return ch.conn.sendMethod(ch.id, confirmMethod)
+}
+
+// toMessageKey uses the routing key as the message key, so messages with the same routing key stay in order.
+// The message queue requires a key, so an empty routing key is replaced by a unique key starting with 0,
+// to spread the messages across the partitions.
+func toMessageKey(routingKey string) []byte {
+	if routingKey != "" {
+		return []byte(routingKey)
+	}
+	key := make([]byte, 9)
+	rand.Read(key[1:])
+	return key
+}
+
+func toRoutingKey(key []byte) string {
+	if len(key) > 0 && key[0] == 0 {
+		return ""
+	}
+	return string(key)
+}
+
+func randomString() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
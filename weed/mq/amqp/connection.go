@@ -0,0 +1,297 @@
+package amqp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const defaultHeartbeat = 60 // seconds
+
+// amqpError is a channel or connection exception, reported to the client with channel.close or connection.close
+type amqpError struct {
+	code     uint16
+	text     string
+	classId  uint16
+	methodId uint16
+}
+
+func (e *amqpError) Error() string {
+	return fmt.Sprintf("%d %s", e.code, e.text)
+}
+
+type connection struct {
+	gateway   *Gateway
+	conn      net.Conn
+	reader    *bufio.Reader
+	writeLock sync.Mutex
+	frameMax  uint32
+	heartbeat time.Duration
+	channels  map[uint16]*channel
+	closed    chan struct{}
+}
+
+func newConnection(g *Gateway, conn net.Conn) *connection {
+	return &connection{
+		gateway:  g,
+		conn:     conn,
+		reader:   bufio.NewReader(conn),
+		frameMax: frameMaxSize,
+		channels: make(map[uint16]*channel),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (c *connection) serve() error {
+	defer func() {
+		close(c.closed)
+		for _, ch := range c.channels {
+			ch.shutdown()
+		}
+		c.conn.Close()
+	}()
+
+	if err := c.handshake(); err != nil {
+		return err
+	}
+	if c.heartbeat > 0 {
+		go c.keepSendingHeartbeats()
+	}
+
+	for {
+		if c.heartbeat > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(3 * c.heartbeat))
+		}
+		f, err := readFrame(c.reader, c.frameMax)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := c.dispatch(f); err != nil {
+			if e, ok := err.(*amqpError); ok {
+				c.sendClose(e)
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (c *connection) handshake() error {
+	header := make([]byte, len(protocolHeader))
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return err
+	}
+	if !bytes.Equal(header, protocolHeader) {
+		c.conn.Write(protocolHeader)
+		return fmt.Errorf("unsupported protocol header %q", header)
+	}
+
+	start := newMethod(classConnection, methodConnectionStart)
+	start.octet(0)
+	start.octet(9)
+	start.table([]tableField{
+		{"product", "SeaweedFS"},
+		{"version", util.Version()},
+		{"platform", "Go"},
+		{"capabilities", []tableField{
+			{"publisher_confirms", true},
+			{"basic.nack", true},
+			{"consumer_cancel_notify", false},
+			{"per_consumer_qos", true},
+		}},
+	})
+	start.longstr([]byte("PLAIN"))
+	start.longstr([]byte("en_US"))
+	if err := c.sendMethod(0, start); err != nil {
+		return err
+	}
+
+	d, err := c.expectMethod(classConnection, methodConnectionStartOk)
+	if err != nil {
+		return err
+	}
+	d.table()
+	mechanism := d.shortstr()
+	response := d.longstr()
+	if d.err != nil {
+		return d.err
+	}
+	if mechanism != "PLAIN" || !c.authenticate(response) {
+		c.sendClose(&amqpError{code: replyAccessRefused, text: "ACCESS_REFUSED - login was refused"})
+		return fmt.Errorf("login refused with mechanism %s", mechanism)
+	}
+
+	tune := newMethod(classConnection, methodConnectionTune)
+	tune.short(maxChannelNumber)
+	tune.long(frameMaxSize)
+	tune.short(defaultHeartbeat)
+	if err := c.sendMethod(0, tune); err != nil {
+		return err
+	}
+	if d, err = c.expectMethod(classConnection, methodConnectionTuneOk); err != nil {
+		return err
+	}
+	d.short()
+	if frameMax := d.long(); frameMax >= frameMinSize && frameMax < frameMaxSize {
+		c.frameMax = frameMax
+	}
+	c.heartbeat = time.Duration(d.short()) * time.Second
+	if d.err != nil {
+		return d.err
+	}
+
+	if d, err = c.expectMethod(classConnection, methodConnectionOpen); err != nil {
+		return err
+	}
+	openOk := newMethod(classConnection, methodConnectionOpenOk)
+	openOk.shortstr("")
+	return c.sendMethod(0, openOk)
+}
+
+// authenticate checks the PLAIN response, in the form of "authzid\x00username\x00password"
+func (c *connection) authenticate(response []byte) bool {
+	if c.gateway.option.Username == "" {
+		return true
+	}
+	parts := bytes.Split(response, []byte{0})
+	if len(parts) != 3 {
+		return false
+	}
+	// compare both in constant time, not to leak which one or how much of it matches
+	usernameMatches := subtle.ConstantTimeCompare(parts[1], []byte(c.gateway.option.Username))
+	passwordMatches := subtle.ConstantTimeCompare(parts[2], []byte(c.gateway.option.Password))
+	return usernameMatches&passwordMatches == 1
+}
+
+func (c *connection) expectMethod(classId, methodId uint16) (*decoder, error) {
+	f, err := readFrame(c.reader, c.frameMax)
+	if err != nil {
+		return nil, err
+	}
+	if f.frameType != frameMethod || f.channel != 0 {
+		return nil, fmt.Errorf("expect method %d.%d, got frame type %d on channel %d", classId, methodId, f.frameType, f.channel)
+	}
+	d := newDecoder(f.payload)
+	if gotClassId, gotMethodId := d.short(), d.short(); gotClassId != classId || gotMethodId != methodId {
+		return nil, fmt.Errorf("expect method %d.%d, got %d.%d", classId, methodId, gotClassId, gotMethodId)
+	}
+	return d, nil
+}
+
+func (c *connection) dispatch(f *frame) error {
+	if f.frameType == frameHeartbeat {
+		return nil
+	}
+	if f.channel == 0 {
+		if f.frameType != frameMethod {
+			return &amqpError{code: replyFrameError, text: "FRAME_ERROR - content on channel 0"}
+		}
+		d := newDecoder(f.payload)
+		classId, methodId := d.short(), d.short()
+		switch {
+		case classId == classConnection && methodId == methodConnectionClose:
+			c.sendMethod(0, newMethod(classConnection, methodConnectionCloseOk))
+			return io.EOF
+		case classId == classConnection && methodId == methodConnectionCloseOk:
+			return io.EOF
+		}
+		return &amqpError{code: replyNotImplemented, text: "NOT_IMPLEMENTED", classId: classId, methodId: methodId}
+	}
+
+	ch, found := c.channels[f.channel]
+	if !found {
+		if f.frameType == frameMethod {
+			d := newDecoder(f.payload)
+			if d.short() == classChannel && d.short() == methodChannelOpen {
+				ch = newChannel(c, f.channel)
+				c.channels[f.channel] = ch
+				openOk := newMethod(classChannel, methodChannelOpenOk)
+				openOk.longstr(nil)
+				return c.sendMethod(f.channel, openOk)
+			}
+		}
+		return &amqpError{code: replyChannelError, text: fmt.Sprintf("CHANNEL_ERROR - channel %d is not open", f.channel)}
+	}
+
+	closed, err := ch.handleFrame(f)
+	if closed {
+		ch.shutdown()
+		delete(c.channels, f.channel)
+	}
+	return err
+}
+
+// sendClose reports a connection exception to the client
+func (c *connection) sendClose(e *amqpError) {
+	glog.V(1).Infof("amqp connection %s close: %v", c.conn.RemoteAddr(), e)
+	closeMethod := newMethod(classConnection, methodConnectionClose)
+	closeMethod.short(e.code)
+	closeMethod.shortstr(e.text)
+	closeMethod.short(e.classId)
+	closeMethod.short(e.methodId)
+	c.sendMethod(0, closeMethod)
+}
+
+func (c *connection) sendMethod(channelId uint16, method *encoder) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return writeFrame(c.conn, frameMethod, channelId, method.payload())
+}
+
+// sendContent sends a method with its content header and body frames, without other frames in between
+func (c *connection) sendContent(channelId uint16, method *encoder, body []byte) error {
+	header := &encoder{}
+	header.short(classBasic)
+	header.short(0)
+	header.longlong(uint64(len(body)))
+	header.short(0) // no properties
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if err := writeFrame(c.conn, frameMethod, channelId, method.payload()); err != nil {
+		return err
+	}
+	if err := writeFrame(c.conn, frameHeader, channelId, header.payload()); err != nil {
+		return err
+	}
+	maxBodySize := int(c.frameMax) - frameOverhead
+	for len(body) > 0 {
+		size := min(len(body), maxBodySize)
+		if err := writeFrame(c.conn, frameBody, channelId, body[:size]); err != nil {
+			return err
+		}
+		body = body[size:]
+	}
+	return nil
+}
+
+func (c *connection) keepSendingHeartbeats() {
+	ticker := time.NewTicker(c.heartbeat / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			c.writeLock.Lock()
+			err := writeFrame(c.conn, frameHeartbeat, 0, nil)
+			c.writeLock.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package amqp
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPublisher keeps the callbacks, to ack the published messages when the test decides
+type testPublisher struct {
+	sync.Mutex
+	messages  []*mq_pb.DataMessage
+	callbacks []pub_client.PublishCallback
+	published chan struct{}
+}
+
+func (p *testPublisher) PublishDataMessageAsync(message *mq_pb.DataMessage, callback pub_client.PublishCallback) error {
+	p.Lock()
+	defer p.Unlock()
+	p.messages = append(p.messages, message)
+	p.callbacks = append(p.callbacks, callback)
+	p.published <- struct{}{}
+	return nil
+}
+
+func (p *testPublisher) FinishPublish() error {
+	return nil
+}
+
+func (p *testPublisher) ack(i int, err error) {
+	p.Lock()
+	message, callback := p.messages[i], p.callbacks[i]
+	p.Unlock()
+	callback(message, err)
+}
+
+type testClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func newTestGateway(option *GatewayOption) (*Gateway, *testPublisher) {
+	g := NewGateway(option)
+	publisher := &testPublisher{published: make(chan struct{}, 16)}
+	g.newPublisher = func(t topic.Topic) topicPublisher {
+		return publisher
+	}
+	return g, publisher
+}
+
+func dialTestGateway(t *testing.T, g *Gateway) *testClient {
+	clientConn, serverConn := net.Pipe()
+	go newConnection(g, serverConn).serve()
+	t.Cleanup(func() {
+		clientConn.Close()
+	})
+	clientConn.SetDeadline(time.Now().Add(10 * time.Second))
+	return &testClient{t: t, conn: clientConn, reader: bufio.NewReader(clientConn)}
+}
+
+func (c *testClient) send(frameType uint8, channel uint16, payload []byte) {
+	assert.NoError(c.t, writeFrame(c.conn, frameType, channel, payload))
+}
+
+func (c *testClient) sendMethod(channel uint16, method *encoder) {
+	c.send(frameMethod, channel, method.payload())
+}
+
+// expectMethod reads the next frame, skipping the heartbeats, and checks it is the method
+func (c *testClient) expectMethod(channel uint16, classId, methodId uint16) *decoder {
+	for {
+		f, err := readFrame(c.reader, frameMaxSize)
+		require.NoError(c.t, err)
+		if f.frameType == frameHeartbeat {
+			continue
+		}
+		require.Equal(c.t, uint8(frameMethod), f.frameType)
+		require.Equal(c.t, channel, f.channel)
+		d := newDecoder(f.payload)
+		require.Equal(c.t, fmt.Sprintf("%d.%d", classId, methodId), fmt.Sprintf("%d.%d", d.short(), d.short()))
+		return d
+	}
+}
+
+func (c *testClient) startOk(username, password string) {
+	_, err := c.conn.Write(protocolHeader)
+	require.NoError(c.t, err)
+	c.expectMethod(0, classConnection, methodConnectionStart)
+	startOk := newMethod(classConnection, methodConnectionStartOk)
+	startOk.table(nil)
+	startOk.shortstr("PLAIN")
+	startOk.longstr([]byte("\x00" + username + "\x00" + password))
+	startOk.shortstr("en_US")
+	c.sendMethod(0, startOk)
+}
+
+func (c *testClient) open(username, password string) {
+	c.startOk(username, password)
+	c.expectMethod(0, classConnection, methodConnectionTune)
+	tuneOk := newMethod(classConnection, methodConnectionTuneOk)
+	tuneOk.short(maxChannelNumber)
+	tuneOk.long(frameMinSize)
+	tuneOk.short(0)
+	c.sendMethod(0, tuneOk)
+	open := newMethod(classConnection, methodConnectionOpen)
+	open.shortstr("/")
+	open.shortstr("")
+	open.bit(false)
+	c.sendMethod(0, open)
+	c.expectMethod(0, classConnection, methodConnectionOpenOk)
+
+	channelOpen := newMethod(classChannel, methodChannelOpen)
+	channelOpen.shortstr("")
+	c.sendMethod(1, channelOpen)
+	c.expectMethod(1, classChannel, methodChannelOpenOk)
+}
+
+func (c *testClient) confirmSelect() {
+	confirmSelect := newMethod(classConfirm, methodConfirmSelect)
+	confirmSelect.bit(false)
+	c.sendMethod(1, confirmSelect)
+	c.expectMethod(1, classConfirm, methodConfirmSelectOk)
+}
+
+// publish sends basic.publish to the default exchange, with the body split into frames of bodyFrameSize
+func (c *testClient) publish(queue string, body []byte, bodyFrameSize int) {
+	publish := newMethod(classBasic, methodBasicPublish)
+	publish.short(0)
+	publish.shortstr("")
+	publish.shortstr(queue)
+	publish.bit(false)
+	publish.bit(false)
+	c.sendMethod(1, publish)
+	header := &encoder{}
+	header.short(classBasic)
+	header.short(0)
+	header.longlong(uint64(len(body)))
+	header.short(0)
+	c.send(frameHeader, 1, header.payload())
+	for len(body) > 0 {
+		size := min(len(body), bodyFrameSize)
+		c.send(frameBody, 1, body[:size])
+		body = body[size:]
+	}
+}
+
+func TestConnectionAuthentication(t *testing.T) {
+	g, _ := newTestGateway(&GatewayOption{Username: "user", Password: "secret"})
+
+	c := dialTestGateway(t, g)
+	c.startOk("user", "wrong")
+	d := c.expectMethod(0, classConnection, methodConnectionClose)
+	assert.Equal(t, uint16(replyAccessRefused), d.short())
+
+	c = dialTestGateway(t, g)
+	c.startOk("user", "secre")
+	c.expectMethod(0, classConnection, methodConnectionClose)
+
+	c = dialTestGateway(t, g)
+	c.open("user", "secret")
+}
+
+func TestPublishBodyFrames(t *testing.T) {
+	g, publisher := newTestGateway(&GatewayOption{})
+	c := dialTestGateway(t, g)
+	c.open("", "")
+
+	body := make([]byte, 3*frameMinSize)
+	for i := range body {
+		body[i] = byte(i)
+	}
+	c.publish("q", body, frameMinSize-frameOverhead)
+	<-publisher.published
+	c.publish("empty", nil, 1)
+	<-publisher.published
+
+	publisher.Lock()
+	defer publisher.Unlock()
+	require.Equal(t, 2, len(publisher.messages))
+	assert.Equal(t, body, publisher.messages[0].Value)
+	assert.Equal(t, []byte("q"), publisher.messages[0].Key)
+	assert.Empty(t, publisher.messages[1].Value)
+}
+
+func TestPublishContentTooLarge(t *testing.T) {
+	g, publisher := newTestGateway(&GatewayOption{MaxMessageSize: 1024})
+	c := dialTestGateway(t, g)
+	c.open("", "")
+
+	// the pipe is not buffered, so the channel.close is read while publishing
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		c.publish("q", make([]byte, 1025), 512)
+	}()
+	d := c.expectMethod(1, classChannel, methodChannelClose)
+	assert.Equal(t, uint16(replyContentTooLarge), d.short())
+	<-published
+	assert.Empty(t, publisher.published)
+
+	// the frames are dropped until the client confirms the close
+	c.sendMethod(1, newMethod(classChannel, methodChannelCloseOk))
+	channelOpen := newMethod(classChannel, methodChannelOpen)
+	channelOpen.shortstr("")
+	c.sendMethod(1, channelOpen)
+	c.expectMethod(1, classChannel, methodChannelOpenOk)
+	c.publish("q", make([]byte, 1024), 512)
+	<-publisher.published
+}
+
+func TestPublishConfirmAfterBrokerAck(t *testing.T) {
+	g, publisher := newTestGateway(&GatewayOption{})
+	c := dialTestGateway(t, g)
+	c.open("", "")
+	c.confirmSelect()
+
+	c.publish("q", []byte("a"), 1)
+	<-publisher.published
+	c.publish("q", []byte("b"), 1)
+	<-publisher.published
+
+	// no confirms before the broker acks
+	c.conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, err := c.reader.Peek(1)
+	assert.Error(t, err, "confirmed before the broker acks")
+	c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	// the confirms follow the broker acks, possibly out of order
+	go publisher.ack(1, fmt.Errorf("publish failed"))
+	d := c.expectMethod(1, classBasic, methodBasicNack)
+	assert.Equal(t, uint64(2), d.longlong())
+	go publisher.ack(0, nil)
+	d = c.expectMethod(1, classBasic, methodBasicAck)
+	assert.Equal(t, uint64(1), d.longlong())
+}
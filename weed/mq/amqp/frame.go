@@ -0,0 +1,328 @@
+package amqp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// the subset of AMQP 0.9.1 used by the gateway
+
+var protocolHeader = []byte{'A', 'M', 'Q', 'P', 0, 0, 9, 1}
+
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+	frameEnd       = 0xCE
+
+	frameMinSize     = 4096
+	frameMaxSize     = 128 * 1024
+	frameHeaderSize  = 7
+	frameOverhead    = frameHeaderSize + 1
+	maxChannelNumber = 2047
+)
+
+const (
+	classConnection = 10
+	classChannel    = 20
+	classExchange   = 40
+	classQueue      = 50
+	classBasic      = 60
+	classConfirm    = 85
+)
+
+const (
+	methodConnectionStart   = 10
+	methodConnectionStartOk = 11
+	methodConnectionTune    = 30
+	methodConnectionTuneOk  = 31
+	methodConnectionOpen    = 40
+	methodConnectionOpenOk  = 41
+	methodConnectionClose   = 50
+	methodConnectionCloseOk = 51
+
+	methodChannelOpen    = 10
+	methodChannelOpenOk  = 11
+	methodChannelFlow    = 20
+	methodChannelFlowOk  = 21
+	methodChannelClose   = 40
+	methodChannelCloseOk = 41
+
+	methodExchangeDeclare   = 10
+	methodExchangeDeclareOk = 11
+	methodExchangeDelete    = 20
+	methodExchangeDeleteOk  = 21
+
+	methodQueueDeclare   = 10
+	methodQueueDeclareOk = 11
+	methodQueueBind      = 20
+	methodQueueBindOk    = 21
+	methodQueuePurge     = 30
+	methodQueuePurgeOk   = 31
+	methodQueueDelete    = 40
+	methodQueueDeleteOk  = 41
+	methodQueueUnbind    = 50
+	methodQueueUnbindOk  = 51
+
+	methodBasicQos       = 10
+	methodBasicQosOk     = 11
+	methodBasicConsume   = 20
+	methodBasicConsumeOk = 21
+	methodBasicCancel    = 30
+	methodBasicCancelOk  = 31
+	methodBasicPublish   = 40
+	methodBasicDeliver   = 60
+	methodBasicAck       = 80
+	methodBasicReject    = 90
+	methodBasicNack      = 120
+
+	methodConfirmSelect   = 10
+	methodConfirmSelectOk = 11
+)
+
+// reply codes
+const (
+	replySuccess         = 200
+	replyContentTooLarge = 311
+	replyAccessRefused   = 403
+	replyNotFound        = 404
+	replyPreconditionErr = 406
+	replyFrameError      = 501
+	replyChannelError    = 504
+	replyUnexpectedFrame = 505
+	replyNotAllowed      = 530
+	replyNotImplemented  = 540
+)
+
+type frame struct {
+	frameType uint8
+	channel   uint16
+	payload   []byte
+}
+
+func readFrame(r *bufio.Reader, maxSize uint32) (*frame, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[3:7])
+	if size > maxSize {
+		return nil, fmt.Errorf("frame size %d exceeds the negotiated %d", size, maxSize)
+	}
+	payload := make([]byte, size+1)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	if payload[size] != frameEnd {
+		return nil, fmt.Errorf("invalid frame end %x", payload[size])
+	}
+	return &frame{
+		frameType: header[0],
+		channel:   binary.BigEndian.Uint16(header[1:3]),
+		payload:   payload[:size],
+	}, nil
+}
+
+func writeFrame(w io.Writer, frameType uint8, channel uint16, payload []byte) error {
+	buf := make([]byte, frameHeaderSize+len(payload)+1)
+	buf[0] = frameType
+	binary.BigEndian.PutUint16(buf[1:3], channel)
+	binary.BigEndian.PutUint32(buf[3:7], uint32(len(payload)))
+	copy(buf[frameHeaderSize:], payload)
+	buf[len(buf)-1] = frameEnd
+	_, err := w.Write(buf)
+	return err
+}
+
+// decoder reads the arguments of a method frame or a content header
+type decoder struct {
+	buf  []byte
+	bits uint8
+	nbit uint8
+	err  error
+}
+
+func newDecoder(payload []byte) *decoder {
+	return &decoder{buf: payload}
+}
+
+func (d *decoder) take(n int) []byte {
+	d.nbit = 0
+	if d.err != nil {
+		return nil
+	}
+	if len(d.buf) < n {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) octet() uint8 {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) short() uint16 {
+	if b := d.take(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) long() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) longlong() uint64 {
+	if b := d.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) shortstr() string {
+	n := d.octet()
+	return string(d.take(int(n)))
+}
+
+func (d *decoder) longstr() []byte {
+	n := d.long()
+	return d.take(int(n))
+}
+
+// table skips a field table, the gateway does not use any table arguments
+func (d *decoder) table() {
+	d.longstr()
+}
+
+// bit reads consecutive bit fields packed in one octet
+func (d *decoder) bit() bool {
+	if d.nbit == 0 || d.nbit == 8 {
+		b := d.take(1)
+		if b == nil {
+			return false
+		}
+		d.bits = b[0]
+		d.nbit = 0
+	}
+	v := d.bits&(1<<d.nbit) != 0
+	d.nbit++
+	return v
+}
+
+// encoder writes the arguments of a method frame or a content header
+type encoder struct {
+	bytes.Buffer
+	bits uint8
+	nbit uint8
+}
+
+func newMethod(classId, methodId uint16) *encoder {
+	e := &encoder{}
+	e.short(classId)
+	e.short(methodId)
+	return e
+}
+
+func (e *encoder) flushBits() {
+	if e.nbit > 0 {
+		e.WriteByte(e.bits)
+		e.bits, e.nbit = 0, 0
+	}
+}
+
+func (e *encoder) octet(v uint8) {
+	e.flushBits()
+	e.WriteByte(v)
+}
+
+func (e *encoder) short(v uint16) {
+	e.flushBits()
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	e.Write(b[:])
+}
+
+func (e *encoder) long(v uint32) {
+	e.flushBits()
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.Write(b[:])
+}
+
+func (e *encoder) longlong(v uint64) {
+	e.flushBits()
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	e.Write(b[:])
+}
+
+func (e *encoder) shortstr(s string) {
+	if len(s) > 255 {
+		s = s[:255]
+	}
+	e.octet(uint8(len(s)))
+	e.WriteString(s)
+}
+
+func (e *encoder) longstr(b []byte) {
+	e.long(uint32(len(b)))
+	e.Write(b)
+}
+
+func (e *encoder) bit(v bool) {
+	if e.nbit == 8 {
+		e.flushBits()
+	}
+	if v {
+		e.bits |= 1 << e.nbit
+	}
+	e.nbit++
+}
+
+// table writes a field table with string and boolean values, or nested tables
+func (e *encoder) table(fields []tableField) {
+	t := &encoder{}
+	for _, f := range fields {
+		t.shortstr(f.name)
+		switch v := f.value.(type) {
+		case bool:
+			t.octet('t')
+			if v {
+				t.octet(1)
+			} else {
+				t.octet(0)
+			}
+		case string:
+			t.octet('S')
+			t.longstr([]byte(v))
+		case []tableField:
+			t.octet('F')
+			t.table(v)
+		}
+	}
+	t.flushBits()
+	e.longstr(t.Bytes())
+}
+
+type tableField struct {
+	name  string
+	value any
+}
+
+func (e *encoder) payload() []byte {
+	e.flushBits()
+	return e.Bytes()
+}
@@ -0,0 +1,228 @@
+package amqp
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"google.golang.org/grpc"
+)
+
+type GatewayOption struct {
+	Brokers           []string
+	Namespace         string // the exchanges and queues are mapped to topics in this namespace
+	PartitionCount    int32  // partition count of the topics created by publishing
+	MaxPartitionCount int32  // max partitions each consumer processes concurrently
+	DefaultPrefetch   int32  // max unacknowledged messages per consumer partition, if the client does not set basic.qos
+	MaxMessageSize    int64  // the larger published messages close the channel, DefaultMaxMessageSize if 0
+	Username          string // if set, clients should authenticate with PLAIN mechanism
+	Password          string
+	InstanceId        string
+	GrpcDialOption    grpc.DialOption
+}
+
+// Gateway speaks AMQP 0.9.1 to the clients, and maps the AMQP model onto the message queue:
+//
+//   - an exchange is a topic with the same name,
+//     and a message published to the default exchange goes to the topic named by the routing key, i.e., the queue
+//   - the routing key is the message key, so messages with the same routing key keep their order
+//   - a queue is a consumer group, which consumes the topics of its bound exchanges,
+//     or the topic with the queue name if not bound to any exchange
+//   - bindings filter the messages by the routing key, following the direct, fanout, or topic exchange rules
+//
+// Exchanges, queues and bindings are kept in memory, as AMQP clients usually declare them on start.
+// Offsets of the queues are tracked by the consumer groups, so a queue resumes where it left off.
+type Gateway struct {
+	option         *GatewayOption
+	exchanges      map[string]string // exchange name => type
+	queues         map[string][]binding
+	lock           sync.RWMutex
+	publishers     map[topic.Topic]topicPublisher
+	publishersLock sync.Mutex
+	newPublisher   func(t topic.Topic) topicPublisher
+}
+
+// DefaultMaxMessageSize is the max message size of the published messages, if not configured
+const DefaultMaxMessageSize = 4 * 1024 * 1024
+
+// topicPublisher publishes the messages to a topic, and calls back once the broker acks each message
+type topicPublisher interface {
+	PublishDataMessageAsync(message *mq_pb.DataMessage, callback pub_client.PublishCallback) error
+	FinishPublish() error
+}
+
+func NewGateway(option *GatewayOption) *Gateway {
+	if option.MaxMessageSize <= 0 {
+		option.MaxMessageSize = DefaultMaxMessageSize
+	}
+	g := &Gateway{
+		option:     option,
+		exchanges:  make(map[string]string),
+		queues:     make(map[string][]binding),
+		publishers: make(map[topic.Topic]topicPublisher),
+	}
+	g.newPublisher = func(t topic.Topic) topicPublisher {
+		return pub_client.NewTopicPublisher(&pub_client.PublisherConfiguration{
+			Topic:          t,
+			PartitionCount: g.option.PartitionCount,
+			Brokers:        g.option.Brokers,
+			PublisherName:  "amqp-" + g.option.InstanceId,
+		})
+	}
+	for name, kind := range predefinedExchanges {
+		g.exchanges[name] = kind
+	}
+	return g
+}
+
+func (g *Gateway) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			c := newConnection(g, conn)
+			if err := c.serve(); err != nil {
+				glog.V(1).Infof("amqp connection %s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// Shutdown flushes the messages buffered in the publishers
+func (g *Gateway) Shutdown() {
+	g.publishersLock.Lock()
+	defer g.publishersLock.Unlock()
+	for t, publisher := range g.publishers {
+		if err := publisher.FinishPublish(); err != nil {
+			glog.Errorf("finish publishing topic %v: %v", t, err)
+		}
+	}
+}
+
+func (g *Gateway) toTopic(name string) topic.Topic {
+	return topic.NewTopic(g.option.Namespace, name)
+}
+
+func (g *Gateway) getOrCreatePublisher(t topic.Topic) topicPublisher {
+	g.publishersLock.Lock()
+	defer g.publishersLock.Unlock()
+	if publisher, found := g.publishers[t]; found {
+		return publisher
+	}
+	publisher := g.newPublisher(t)
+	g.publishers[t] = publisher
+	return publisher
+}
+
+// declareExchange returns the reply code and text if the declaration fails
+func (g *Gateway) declareExchange(name, kind string, passive bool) (uint16, string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	existing, found := g.exchanges[name]
+	if passive {
+		if !found {
+			return replyNotFound, fmt.Sprintf("NOT_FOUND - no exchange '%s'", name)
+		}
+		return 0, ""
+	}
+	if !isSupportedExchangeType(kind) {
+		return replyNotImplemented, fmt.Sprintf("NOT_IMPLEMENTED - exchange type '%s' is not supported", kind)
+	}
+	if found && existing != kind {
+		return replyPreconditionErr, fmt.Sprintf("PRECONDITION_FAILED - exchange '%s' is declared as '%s'", name, existing)
+	}
+	g.exchanges[name] = kind
+	return 0, ""
+}
+
+func (g *Gateway) deleteExchange(name string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	delete(g.exchanges, name)
+	for queue, bindings := range g.queues {
+		var kept []binding
+		for _, b := range bindings {
+			if b.exchange != name {
+				kept = append(kept, b)
+			}
+		}
+		g.queues[queue] = kept
+	}
+}
+
+func (g *Gateway) declareQueue(name string, passive bool) (uint16, string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if _, found := g.queues[name]; !found {
+		if passive {
+			return replyNotFound, fmt.Sprintf("NOT_FOUND - no queue '%s'", name)
+		}
+		g.queues[name] = nil
+	}
+	return 0, ""
+}
+
+func (g *Gateway) deleteQueue(name string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	delete(g.queues, name)
+}
+
+func (g *Gateway) bindQueue(queue, exchange, bindingKey string) (uint16, string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	bindings, found := g.queues[queue]
+	if !found {
+		return replyNotFound, fmt.Sprintf("NOT_FOUND - no queue '%s'", queue)
+	}
+	kind, found := g.exchanges[exchange]
+	if !found {
+		return replyNotFound, fmt.Sprintf("NOT_FOUND - no exchange '%s'", exchange)
+	}
+	for _, b := range bindings {
+		if b.exchange == exchange && b.bindingKey == bindingKey {
+			return 0, ""
+		}
+	}
+	g.queues[queue] = append(bindings, binding{
+		exchange:     exchange,
+		exchangeType: kind,
+		bindingKey:   bindingKey,
+	})
+	return 0, ""
+}
+
+func (g *Gateway) unbindQueue(queue, exchange, bindingKey string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	var kept []binding
+	for _, b := range g.queues[queue] {
+		if b.exchange != exchange || b.bindingKey != bindingKey {
+			kept = append(kept, b)
+		}
+	}
+	if _, found := g.queues[queue]; found {
+		g.queues[queue] = kept
+	}
+}
+
+// queueBindings returns the bindings grouped by the exchange, found is false if the queue is not declared
+func (g *Gateway) queueBindings(queue string) (exchangeBindings map[string][]binding, found bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	bindings, found := g.queues[queue]
+	if !found {
+		return nil, false
+	}
+	exchangeBindings = make(map[string][]binding)
+	for _, b := range bindings {
+		exchangeBindings[b.exchange] = append(exchangeBindings[b.exchange], b)
+	}
+	return exchangeBindings, true
+}
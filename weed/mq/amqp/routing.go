@@ -0,0 +1,66 @@
+package amqp
+
+import (
+	"strings"
+)
+
+const (
+	ExchangeDirect = "direct"
+	ExchangeFanout = "fanout"
+	ExchangeTopic  = "topic"
+)
+
+// the exchanges every AMQP broker has
+var predefinedExchanges = map[string]string{
+	"amq.direct": ExchangeDirect,
+	"amq.fanout": ExchangeFanout,
+	"amq.topic":  ExchangeTopic,
+}
+
+func isSupportedExchangeType(kind string) bool {
+	return kind == ExchangeDirect || kind == ExchangeFanout || kind == ExchangeTopic
+}
+
+type binding struct {
+	exchange     string
+	exchangeType string
+	bindingKey   string
+}
+
+// matches tells whether a message published with the routing key is routed to the queue by this binding
+func (b binding) matches(routingKey string) bool {
+	switch b.exchangeType {
+	case ExchangeFanout:
+		return true
+	case ExchangeTopic:
+		return matchTopicPattern(strings.Split(b.bindingKey, "."), strings.Split(routingKey, "."))
+	default:
+		return b.bindingKey == routingKey
+	}
+}
+
+// matchTopicPattern matches the dot separated words, where "*" matches exactly one word,
+// and "#" matches zero or more words
+func matchTopicPattern(pattern, words []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "#":
+			for i := 0; i <= len(words); i++ {
+				if matchTopicPattern(pattern[1:], words[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+			if len(words) == 0 {
+				return false
+			}
+		default:
+			if len(words) == 0 || pattern[0] != words[0] {
+				return false
+			}
+		}
+		pattern, words = pattern[1:], words[1:]
+	}
+	return len(words) == 0
+}
@@ -0,0 +1,67 @@
+package amqp
+
+import (
+	"testing"
+)
+
+func TestBindingMatches(t *testing.T) {
+	tests := []struct {
+		exchangeType string
+		bindingKey   string
+		routingKey   string
+		want         bool
+	}{
+		{ExchangeDirect, "orders", "orders", true},
+		{ExchangeDirect, "orders", "orders.eu", false},
+		{ExchangeFanout, "", "anything", true},
+		{ExchangeTopic, "orders.*", "orders.eu", true},
+		{ExchangeTopic, "orders.*", "orders", false},
+		{ExchangeTopic, "orders.*", "orders.eu.paid", false},
+		{ExchangeTopic, "orders.#", "orders", true},
+		{ExchangeTopic, "orders.#", "orders.eu.paid", true},
+		{ExchangeTopic, "#.paid", "orders.eu.paid", true},
+		{ExchangeTopic, "#.paid", "orders.eu.created", false},
+		{ExchangeTopic, "*.eu.#", "orders.eu", true},
+		{ExchangeTopic, "#", "", true},
+		{ExchangeTopic, "*", "", true},
+		{ExchangeTopic, "orders.#.paid", "orders.paid", true},
+	}
+	for _, tt := range tests {
+		b := binding{exchangeType: tt.exchangeType, bindingKey: tt.bindingKey}
+		if got := b.matches(tt.routingKey); got != tt.want {
+			t.Errorf("%s exchange binding %q routing key %q: got %v, want %v", tt.exchangeType, tt.bindingKey, tt.routingKey, got, tt.want)
+		}
+	}
+}
+
+func TestMethodArguments(t *testing.T) {
+	e := newMethod(classBasic, methodBasicDeliver)
+	e.shortstr("ctag")
+	e.longlong(7)
+	e.bit(true)
+	e.shortstr("amq.topic")
+	e.shortstr("orders.eu")
+
+	d := newDecoder(e.payload())
+	if classId, methodId := d.short(), d.short(); classId != classBasic || methodId != methodBasicDeliver {
+		t.Fatalf("unexpected method %d.%d", classId, methodId)
+	}
+	if v := d.shortstr(); v != "ctag" {
+		t.Errorf("consumer tag %q", v)
+	}
+	if v := d.longlong(); v != 7 {
+		t.Errorf("delivery tag %d", v)
+	}
+	if !d.bit() {
+		t.Errorf("redelivered should be set")
+	}
+	if v := d.shortstr(); v != "amq.topic" {
+		t.Errorf("exchange %q", v)
+	}
+	if v := d.shortstr(); v != "orders.eu" {
+		t.Errorf("routing key %q", v)
+	}
+	if d.err != nil {
+		t.Errorf("decode: %v", d.err)
+	}
+}
@@ -0,0 +1,41 @@
+package broker
+
+import (
+	"github.com/seaweedfs/seaweedfs/weed/audit"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+	"google.golang.org/grpc"
+)
+
+// auditMethods are audited with the topics, mapped to whether read only.
+// The publishing and the subscribing are audited once per stream, not per message.
+var auditMethods = map[string]bool{
+	"ConfigureTopic":   false,
+	"AlterTopic":       false,
+	"ClosePublishers":  false,
+	"CloseSubscribers": false,
+	"PublishMessage":   false,
+	"SubscribeMessage": true,
+}
+
+// AuditServerOptions audits the topic changes, the publishing and the subscribing, if the audit log is enabled
+func (b *MessageQueueBroker) AuditServerOptions() []grpc.ServerOption {
+	return audit.GrpcServerOptions(audit.ServiceMq, auditMethods, brokerAuditTopic)
+}
+
+func brokerAuditTopic(req interface{}) string {
+	var t *schema_pb.Topic
+	switch req := req.(type) {
+	case *mq_pb.PublishMessageRequest:
+		t = req.GetInit().GetTopic()
+	case *mq_pb.SubscribeMessageRequest:
+		t = req.GetInit().GetTopic()
+	case interface{ GetTopic() *schema_pb.Topic }:
+		t = req.GetTopic()
+	}
+	if t == nil {
+		return ""
+	}
+	return topic.FromPbTopic(t).String()
+}
@@ -0,0 +1,130 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/pub_balancer"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// AlterTopic Runs on any broker, but proxied to the balancer if not the balancer
+// It changes the retention, the compaction, the partition count, the replication, the flush settings, or the max message size of an existing topic.
+// The brokers follow the topic.conf changes, and the publishers reconnect to pick up the changed partitions or followers.
+func (b *MessageQueueBroker) AlterTopic(ctx context.Context, request *mq_pb.AlterTopicRequest) (resp *mq_pb.ConfigureTopicResponse, err error) {
+	if !b.isLockOwner() {
+		proxyErr := b.withBrokerClient(false, pb.ServerAddress(b.lockAsBalancer.LockOwner()), func(client mq_pb.SeaweedMessagingClient) error {
+			resp, err = client.AlterTopic(ctx, request)
+			return nil
+		})
+		if proxyErr != nil {
+			return nil, proxyErr
+		}
+		return resp, err
+	}
+
+	t := topic.FromPbTopic(request.Topic)
+	if request.Replication < 0 || request.Replication > 2 {
+		return nil, status.Errorf(codes.InvalidArgument, "topic %s: replication %d should be 1 or 2", t, request.Replication)
+	}
+	if request.PartitionCount < 0 || request.PartitionCount > pub_balancer.MaxPartitionCount {
+		return nil, status.Errorf(codes.InvalidArgument, "topic %s: partition count %d should be at most %d", t, request.PartitionCount, pub_balancer.MaxPartitionCount)
+	}
+	if request.FlushIntervalMs > 0 && request.FlushIntervalMs < minFlushIntervalMs {
+		return nil, status.Errorf(codes.InvalidArgument, "topic %s: flush interval %dms should be at least %dms", t, request.FlushIntervalMs, minFlushIntervalMs)
+	}
+	if request.BufferSizeKb > 0 && (request.BufferSizeKb < minBufferSizeKb || request.BufferSizeKb > maxBufferSizeKb) {
+		return nil, status.Errorf(codes.InvalidArgument, "topic %s: buffer size %dKB should be in [%d, %d]KB", t, request.BufferSizeKb, minBufferSizeKb, maxBufferSizeKb)
+	}
+	// each message is buffered as a whole
+	if request.MaxMessageSizeKb > maxBufferSizeKb {
+		return nil, status.Errorf(codes.InvalidArgument, "topic %s: max message size %dKB should be at most %dKB", t, request.MaxMessageSizeKb, maxBufferSizeKb)
+	}
+
+	resp, err = b.fca.ReadTopicConfFromFiler(t)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "topic %s: %v", t, err)
+	}
+	partitionCount := int32(len(resp.BrokerPartitionAssignments))
+	if request.PartitionCount != 0 && request.PartitionCount < partitionCount {
+		return nil, status.Errorf(codes.InvalidArgument, "topic %s: partition count can not be decreased from %d to %d", t, partitionCount, request.PartitionCount)
+	}
+
+	switch {
+	case request.RetentionSeconds < 0:
+		resp.RetentionSeconds = 0
+	case request.RetentionSeconds > 0:
+		resp.RetentionSeconds = request.RetentionSeconds
+	}
+	replicationChanged := request.Replication != 0 && pub_balancer.FollowerCount(request.Replication) != pub_balancer.FollowerCount(resp.Replication)
+	if request.Replication != 0 {
+		resp.Replication = request.Replication
+	}
+	resp.FlushIntervalMs = alterSetting(resp.FlushIntervalMs, request.FlushIntervalMs)
+	resp.BufferSizeKb = alterSetting(resp.BufferSizeKb, request.BufferSizeKb)
+	resp.MaxMessageSizeKb = alterSetting(resp.MaxMessageSizeKb, request.MaxMessageSizeKb)
+	switch {
+	case request.Fsync < 0:
+		resp.Fsync = false
+	case request.Fsync > 0:
+		resp.Fsync = true
+	}
+	switch {
+	case request.Compact < 0:
+		resp.Compact = false
+	case request.Compact > 0:
+		resp.Compact = true
+	}
+	switch {
+	case request.CompactionWindowSeconds < 0:
+		resp.CompactionWindowSeconds = 0
+	case request.CompactionWindowSeconds > 0:
+		resp.CompactionWindowSeconds = request.CompactionWindowSeconds
+	}
+
+	if request.PartitionCount > partitionCount {
+		// the keys are spread over more partitions, so the partitions of a new generation replace the existing ones.
+		// The messages published before are kept in the previous generation.
+		previous := proto.Clone(resp).(*mq_pb.ConfigureTopicResponse)
+		if err = b.reallocateTopicPartitions(ctx, t, previous, resp, request.PartitionCount); err != nil {
+			return nil, err
+		}
+		glog.V(0).Infof("AlterTopic: topic %s partitions %d => %d: %v", t, partitionCount, request.PartitionCount, resp.BrokerPartitionAssignments)
+		return resp, nil
+	}
+
+	if replicationChanged {
+		// the leaders pick up the added or removed followers when the publishers reconnect
+		pub_balancer.EnsureAssignmentsToActiveBrokers(b.PubBalancer.Brokers, pub_balancer.FollowerCount(resp.Replication), resp.BrokerPartitionAssignments)
+	}
+	if err = b.fca.SaveTopicConfToFiler(t, resp); err != nil {
+		return nil, fmt.Errorf("alter topic: %v", err)
+	}
+	glog.V(0).Infof("AlterTopic: topic %s retention %ds compact %v window %ds replication %d flush interval %dms buffer size %dKB fsync %v max message size %dKB",
+		t, resp.RetentionSeconds, resp.Compact, resp.CompactionWindowSeconds, resp.Replication, resp.FlushIntervalMs, resp.BufferSizeKb, resp.Fsync, resp.MaxMessageSizeKb)
+
+	return resp, nil
+}
+
+const (
+	minFlushIntervalMs = 10
+	minBufferSizeKb    = 64
+	maxBufferSizeKb    = 256 * 1024
+)
+
+// alterSetting keeps the current value if not requested, or resets it to 0 for the default if negative
+func alterSetting(current, requested int32) int32 {
+	switch {
+	case requested < 0:
+		return 0
+	case requested > 0:
+		return requested
+	}
+	return current
+}
@@ -45,31 +45,85 @@ func (b *MessageQueueBroker) ConfigureTopic(ctx context.Context, request *mq_pb.
 		// The gone assignees will die by themselves.
 	}
 
+	// the compression dictionaries are only added, since the published messages refer to them
+	var dictionaries []*mq_pb.CompressionDictionary
+	if resp != nil {
+		dictionaries = resp.CompressionDictionaries
+	}
+	dictionaries, dictionariesChanged, mergeErr := topic.MergeCompressionDictionaries(dictionaries, request.CompressionDictionaries)
+	if mergeErr != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "topic %s: %v", t, mergeErr)
+	}
+
+	// the sampling rules are kept unless explicitly updated, since the publishers do not know them
+	var samplingRules []*mq_pb.SamplingRule
+	if resp != nil {
+		samplingRules = resp.SamplingRules
+	}
+	if request.UpdateSamplingRules {
+		if validateErr := topic.ValidateSamplingRules(t, request.SamplingRules); validateErr != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "topic %s: %v", t, validateErr)
+		}
+		samplingRules = request.SamplingRules
+	}
+
 	if readErr == nil && assignErr == nil && len(resp.BrokerPartitionAssignments) == int(request.PartitionCount) {
 		glog.V(0).Infof("existing topic partitions %d: %+v", len(resp.BrokerPartitionAssignments), resp.BrokerPartitionAssignments)
+		if dictionariesChanged || request.UpdateSamplingRules {
+			resp.CompressionDictionaries = dictionaries
+			resp.SamplingRules = samplingRules
+			if err := b.fca.SaveTopicConfToFiler(t, resp); err != nil {
+				return nil, fmt.Errorf("configure topic: %v", err)
+			}
+			glog.V(0).Infof("topic %s has %d compression dictionaries and %d sampling rules", t, len(dictionaries), len(samplingRules))
+		}
 		return
 	}
 
-	if resp != nil && len(resp.BrokerPartitionAssignments) > 0 {
-		if cancelErr := b.assignTopicPartitionsToBrokers(ctx, request.Topic, resp.BrokerPartitionAssignments, false); cancelErr != nil {
-			glog.V(1).Infof("cancel old topic %s partitions assignments %v : %v", request.Topic, resp.BrokerPartitionAssignments, cancelErr)
+	// the partitions are re-allocated, keeping the other settings of the topic
+	previous := resp
+	resp = &mq_pb.ConfigureTopicResponse{
+		RecordType:              request.RecordType,
+		CompressionDictionaries: dictionaries,
+		SamplingRules:           samplingRules,
+	}
+	if previous != nil {
+		resp.RetentionSeconds = previous.RetentionSeconds
+		resp.Replication = previous.Replication
+		resp.FlushIntervalMs = previous.FlushIntervalMs
+		resp.BufferSizeKb = previous.BufferSizeKb
+		resp.Fsync = previous.Fsync
+		resp.MaxMessageSizeKb = previous.MaxMessageSizeKb
+		resp.Compact = previous.Compact
+		resp.CompactionWindowSeconds = previous.CompactionWindowSeconds
+	}
+	if err = b.reallocateTopicPartitions(ctx, t, previous, resp, request.PartitionCount); err != nil {
+		return nil, err
+	}
+
+	glog.V(0).Infof("ConfigureTopic: topic %s partition assignments: %v", request.Topic, resp.BrokerPartitionAssignments)
+
+	return resp, err
+}
+
+// reallocateTopicPartitions drains the previous partitions, and allocates the partitions to the active brokers.
+// The configuration with the new assignments is saved on filer.
+func (b *MessageQueueBroker) reallocateTopicPartitions(ctx context.Context, t topic.Topic, previous, conf *mq_pb.ConfigureTopicResponse, partitionCount int32) error {
+	if previous != nil && len(previous.BrokerPartitionAssignments) > 0 {
+		if cancelErr := b.assignTopicPartitionsToBrokers(ctx, t.ToPbTopic(), previous.BrokerPartitionAssignments, false); cancelErr != nil {
+			glog.V(1).Infof("cancel old topic %s partitions assignments %v : %v", t, previous.BrokerPartitionAssignments, cancelErr)
 		}
 	}
-	resp = &mq_pb.ConfigureTopicResponse{}
 	if b.PubBalancer.Brokers.IsEmpty() {
-		return nil, status.Errorf(codes.Unavailable, pub_balancer.ErrNoBroker.Error())
+		return status.Errorf(codes.Unavailable, pub_balancer.ErrNoBroker.Error())
 	}
-	resp.BrokerPartitionAssignments = pub_balancer.AllocateTopicPartitions(b.PubBalancer.Brokers, request.PartitionCount)
-	resp.RecordType = request.RecordType
+	conf.BrokerPartitionAssignments = pub_balancer.AllocateTopicPartitions(b.PubBalancer.Brokers, partitionCount, pub_balancer.FollowerCount(conf.Replication))
 
 	// save the topic configuration on filer
-	if err := b.fca.SaveTopicConfToFiler(t, resp); err != nil {
-		return nil, fmt.Errorf("configure topic: %v", err)
+	if err := b.fca.SaveTopicConfToFiler(t, conf); err != nil {
+		return fmt.Errorf("configure topic: %v", err)
 	}
 
-	b.PubBalancer.OnPartitionChange(request.Topic, resp.BrokerPartitionAssignments)
-
-	glog.V(0).Infof("ConfigureTopic: topic %s partition assignments: %v", request.Topic, resp.BrokerPartitionAssignments)
-
-	return resp, err
+	b.PubBalancer.OnPartitionChange(t.ToPbTopic(), conf.BrokerPartitionAssignments)
+	return nil
 }
@@ -0,0 +1,35 @@
+package broker
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+// DescribeTopic returns the partitions of the topic hosted on this broker,
+// with the connected clients and the buffer occupancy
+func (b *MessageQueueBroker) DescribeTopic(ctx context.Context, request *mq_pb.DescribeTopicRequest) (*mq_pb.DescribeTopicResponse, error) {
+	if request.Topic == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "missing topic")
+	}
+	t := topic.FromPbTopic(request.Topic)
+	partitions := b.localTopicManager.DescribeLocalTopic(t)
+	if partitions == nil {
+		return nil, status.Errorf(codes.NotFound, "topic %s is not hosted on %s", t, b.option.BrokerAddress())
+	}
+	return &mq_pb.DescribeTopicResponse{
+		Topic:      request.Topic,
+		Partitions: partitions,
+	}, nil
+}
+
+// ListConnections returns the publishers and subscribers connected to this broker
+func (b *MessageQueueBroker) ListConnections(ctx context.Context, request *mq_pb.ListConnectionsRequest) (*mq_pb.ListConnectionsResponse, error) {
+	return &mq_pb.ListConnectionsResponse{
+		Connections: b.localTopicManager.ListConnections(),
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/pub_balancer"
 	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
@@ -24,7 +25,10 @@ func (b *MessageQueueBroker) LookupTopicBrokers(ctx context.Context, request *mq
 	}
 
 	t := topic.FromPbTopic(request.Topic)
-	ret := &mq_pb.LookupTopicBrokersResponse{}
+	ret := &mq_pb.LookupTopicBrokersResponse{
+		KeyHashFunction: topic.KeyHashFunction,
+		RingSize:        pub_balancer.MaxPartitionCount,
+	}
 	conf := &mq_pb.ConfigureTopicResponse{}
 	ret.Topic = request.Topic
 	if conf, err = b.fca.ReadTopicConfFromFiler(t); err != nil {
@@ -32,12 +36,27 @@ func (b *MessageQueueBroker) LookupTopicBrokers(ctx context.Context, request *mq
 	} else {
 		err = b.ensureTopicActiveAssignments(t, conf)
 		ret.BrokerPartitionAssignments = conf.BrokerPartitionAssignments
+		ret.CompressionDictionaries = conf.CompressionDictionaries
+		for _, assignment := range conf.BrokerPartitionAssignments {
+			if assignment.Partition != nil && assignment.Partition.RingSize > 0 {
+				ret.RingSize = assignment.Partition.RingSize
+				break
+			}
+		}
 	}
 
 	return ret, err
 }
 
 func (b *MessageQueueBroker) ListTopics(ctx context.Context, request *mq_pb.ListTopicsRequest) (resp *mq_pb.ListTopicsResponse, err error) {
+	if request.LocalOnly {
+		ret := &mq_pb.ListTopicsResponse{}
+		for _, t := range b.localTopicManager.ListLocalTopics() {
+			ret.Topics = append(ret.Topics, t.ToPbTopic())
+		}
+		return ret, nil
+	}
+
 	if !b.isLockOwner() {
 		proxyErr := b.withBrokerClient(false, pb.ServerAddress(b.lockAsBalancer.LockOwner()), func(client mq_pb.SeaweedMessagingClient) error {
 			resp, err = client.ListTopics(ctx, request)
@@ -6,10 +6,17 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
 	"io"
 	"math/rand"
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -35,6 +42,12 @@ import (
 // Subscribers needs to listen for new partitions and connect to the brokers.
 // Each subscription may not get data. It can act as a backup.
 
+var publishRequestPool = sync.Pool{
+	New: func() interface{} {
+		return &mq_pb.PublishMessageRequest{}
+	},
+}
+
 func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_PublishMessageServer) error {
 
 	req, err := stream.Recv()
@@ -42,7 +55,6 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 		return err
 	}
 	response := &mq_pb.PublishMessageResponse{}
-	// TODO check whether current broker should be the leader for the topic partition
 	initMessage := req.GetInit()
 	if initMessage == nil {
 		response.Error = fmt.Sprintf("missing init message")
@@ -50,8 +62,29 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 		return stream.Send(response)
 	}
 
-	// get or generate a local partition
+	if b.isStopping() {
+		response.Error = fmt.Sprintf("broker %s is shutting down", b.option.BrokerAddress())
+		return stream.Send(response)
+	}
+
 	t, p := topic.FromPbTopic(initMessage.Topic), topic.FromPbPartition(initMessage.Partition)
+
+	// only the current leader accepts writes, otherwise redirect the publisher to the leader
+	self := string(b.option.BrokerAddress())
+	leader, findLeaderErr := b.findPartitionLeader(t, p)
+	if findLeaderErr != nil {
+		response.Error = findLeaderErr.Error()
+		glog.Errorf("publish to topic %v partition %v: %v", t, p, findLeaderErr)
+		return stream.Send(response)
+	}
+	if leader != self {
+		response.Error = fmt.Sprintf("broker %s is not the leader of topic %v partition %v", self, t, p)
+		response.LeaderBroker = leader
+		glog.V(0).Infof("redirect publisher %s of topic %v partition %v to leader %s", initMessage.PublisherName, t, p, leader)
+		return stream.Send(response)
+	}
+
+	// get or generate a local partition
 	localTopicPartition, getOrGenErr := b.GetOrGenerateLocalPartition(t, p)
 	if getOrGenErr != nil {
 		response.Error = fmt.Sprintf("topic %v not found: %v", t, getOrGenErr)
@@ -67,7 +100,9 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 	}
 
 	var receivedSequence, acknowledgedSequence int64
-	var isClosed bool
+	var isClosed, hasSentShouldClose bool
+	var throttleHintMs int32
+	publisher := topic.NewLocalPublisher()
 
 	// start sending ack to publisher
 	ackInterval := int64(1)
@@ -79,9 +114,34 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 			// println("stop sending ack to publisher", initMessage.PublisherName)
 		}()
 
-		lastAckTime := time.Now()
+		lastAckTime, lastFlushTime := time.Now(), time.Now()
 		for !isClosed {
-			receivedSequence = atomic.LoadInt64(&localTopicPartition.AckTsNs)
+			if throttleMs := atomic.SwapInt32(&throttleHintMs, 0); throttleMs > 0 {
+				if err := stream.Send(&mq_pb.PublishMessageResponse{
+					ThrottleMs: throttleMs,
+				}); err != nil {
+					glog.Errorf("Error sending throttle hint to %s: %v", initMessage.PublisherName, err)
+				}
+			}
+			receivedSequence = localTopicPartition.AckedTsNs(initMessage.AckLevel)
+			// waiting for the flush interval would hold the acks for too long
+			if initMessage.AckLevel == mq_pb.AckLevel_ACK_FLUSHED && acknowledgedSequence < atomic.LoadInt64(&localTopicPartition.BufferedTsNs) && time.Since(lastFlushTime) > 1*time.Second {
+				localTopicPartition.LogBuffer.ForceFlush()
+				lastFlushTime = time.Now()
+			}
+			if publisher.IsShuttingDown() && !hasSentShouldClose {
+				// ack what has been received, and ask the publisher to find the new partition leader
+				acknowledgedSequence = receivedSequence
+				if err := stream.Send(&mq_pb.PublishMessageResponse{
+					AckSequence: acknowledgedSequence,
+					ShouldClose: true,
+				}); err != nil {
+					glog.Errorf("Error sending should close to %s: %v", initMessage.PublisherName, err)
+				}
+				publisher.Stats.AckPending(acknowledgedSequence)
+				hasSentShouldClose = true
+				continue
+			}
 			if acknowledgedSequence < receivedSequence && (receivedSequence-acknowledgedSequence >= ackInterval || time.Since(lastAckTime) > 1*time.Second) {
 				acknowledgedSequence = receivedSequence
 				response := &mq_pb.PublishMessageResponse{
@@ -90,6 +150,7 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 				if err := stream.Send(response); err != nil {
 					glog.Errorf("Error sending response %v: %v", response, err)
 				}
+				publisher.Stats.AckPending(acknowledgedSequence)
 				// println("sent ack", acknowledgedSequence, "=>", initMessage.PublisherName)
 				lastAckTime = time.Now()
 			} else {
@@ -99,8 +160,9 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 	}()
 
 	// process each published messages
-	clientName := fmt.Sprintf("%v-%4d/%s/%v", findClientAddress(stream.Context()), rand.Intn(10000), initMessage.Topic, initMessage.Partition)
-	localTopicPartition.Publishers.AddPublisher(clientName, topic.NewLocalPublisher())
+	clientAddress := findClientAddress(stream.Context())
+	clientName := fmt.Sprintf("%v-%4d/%s/%v", clientAddress, rand.Intn(10000), initMessage.Topic, initMessage.Partition)
+	localTopicPartition.Publishers.AddPublisher(clientName, publisher)
 
 	defer func() {
 		// remove the publisher
@@ -118,10 +180,30 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 		isClosed = true
 	}()
 
+	// quotas are shared by all publish streams of the same client or topic, and of the same tenant
+	quotaClientKey := initMessage.PublisherName
+	if quotaClientKey == "" {
+		quotaClientKey, _, _ = net.SplitHostPort(clientAddress)
+	}
+	tenant := tenantFromContext(stream.Context())
+	if tenant != nil {
+		quotaClientKey = tenant.Name + "/" + quotaClientKey
+	}
+	quotaTopicKey := t.String()
+	sampler := b.getTopicSampler(t)
+	spanAttributes := tracing.MessagingAttributes(t.String(), p.String())
+
+	// the request is reused for each message, since the local partition copies the message data
+	dataReq := publishRequestPool.Get().(*mq_pb.PublishMessageRequest)
+	defer func() {
+		dataReq.Reset()
+		publishRequestPool.Put(dataReq)
+	}()
+
 	// process each published messages
 	for {
 		// receive a message
-		req, err := stream.Recv()
+		err := stream.RecvMsg(dataReq)
 		if err != nil {
 			if err == io.EOF {
 				break
@@ -131,7 +213,7 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 		}
 
 		// Process the received message
-		dataMessage := req.GetData()
+		dataMessage := dataReq.GetData()
 		if dataMessage == nil {
 			continue
 		}
@@ -139,10 +221,57 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 		// The control message should still be sent to the follower
 		// to avoid timing issue when ack messages.
 
-		// send to the local partition
-		if err = localTopicPartition.Publish(dataMessage); err != nil {
+		// reject keyed messages sent to the wrong partition
+		if len(dataMessage.Key) > 0 && dataMessage.Ctrl == nil && !p.CoversKey(dataMessage.Key) {
+			response.Error = fmt.Sprintf("key hash %d is not in partition %v of topic %v", topic.KeyHash(dataMessage.Key, p.RingSize), p, t)
+			glog.Errorf("misrouted message from %s: %s", initMessage.PublisherName, response.Error)
+			return stream.Send(response)
+		}
+
+		// reject the oversized messages, instead of piling them up in the buffers and the log files
+		if maxSize := b.maxMessageSize(localTopicPartition); maxSize > 0 && dataMessage.Ctrl == nil {
+			if size := proto.Size(dataMessage); size > maxSize {
+				stats.MqOversizedMessagesCounter.WithLabelValues(quotaTopicKey).Inc()
+				response.Error = fmt.Sprintf("message of %d bytes is larger than the max message size %d of topic %v", size, maxSize, t)
+				response.ErrorCode = mq_pb.PublishErrorCode_PUBLISH_ERROR_MESSAGE_TOO_LARGE
+				response.RejectedTsNs = dataMessage.TsNs
+				glog.V(0).Infof("reject message from %s: %s", initMessage.PublisherName, response.Error)
+				return stream.Send(response)
+			}
+		}
+
+		// over quota: delay the message, which also delays the acks, and hint the publisher to back off
+		messageSize := len(dataMessage.Key) + len(dataMessage.Value)
+		if delay := max(b.clientPubLimiter.Reserve(quotaClientKey, messageSize), b.topicPubLimiter.Reserve(quotaTopicKey, messageSize),
+			tenant.publishLimiter().Reserve(tenant.Name, messageSize)); delay > 0 {
+			atomic.StoreInt32(&throttleHintMs, int32(delay.Milliseconds())+1)
+			time.Sleep(delay)
+		}
+
+		// the duplicates are acked but not stored
+		if dataMessage.IdempotencyKey != "" && dataMessage.Ctrl == nil && localTopicPartition.IsDuplicate(dataMessage) {
+			stats.MqDeduplicatedMessagesCounter.WithLabelValues(quotaTopicKey).Inc()
+			continue
+		}
+
+		// send to the local partition, the trace context is kept in the headers for the subscribers
+		_, span := tracing.StartSpanFromHeaders(stream.Context(), dataMessage.Headers, "mq.broker.publish",
+			trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(spanAttributes...),
+			trace.WithAttributes(attribute.Int("messaging.message.body.size", len(dataMessage.Value))))
+		err = localTopicPartition.Publish(dataMessage)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
 			return fmt.Errorf("topic %v partition %v publish error: %v", initMessage.Topic, initMessage.Partition, err)
 		}
+		span.End()
+
+		if dataMessage.Ctrl == nil {
+			b.sampleMessage(sampler, dataMessage)
+			publisher.Stats.Add(messageSize)
+			publisher.Stats.AddPending(dataMessage.TsNs)
+		}
 	}
 
 	glog.V(0).Infof("topic %v partition %v publish stream from %s closed.", initMessage.Topic, initMessage.Partition, initMessage.PublisherName)
@@ -150,6 +279,14 @@ func (b *MessageQueueBroker) PublishMessage(stream mq_pb.SeaweedMessaging_Publis
 	return nil
 }
 
+// maxMessageSize is the max message size of the topic, or the broker default
+func (b *MessageQueueBroker) maxMessageSize(localPartition *topic.LocalPartition) int {
+	if maxSize := localPartition.MaxMessageSize.Load(); maxSize > 0 {
+		return int(maxSize)
+	}
+	return b.option.MaxMessageSize
+}
+
 // duplicated from master_grpc_server.go
 func findClientAddress(ctx context.Context) string {
 	// fmt.Printf("FromContext %+v\n", ctx)
@@ -3,6 +3,7 @@ package broker
 import (
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/logstore"
 	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util/buffered_queue"
@@ -93,6 +94,9 @@ func (b *MessageQueueBroker) PublishFollowMe(stream mq_pb.SeaweedMessaging_Publi
 	}
 
 	partitionDir := topic.PartitionDir(t, p)
+	conf, confErr := b.fca.ReadTopicConfFromFiler(t)
+	fsync := confErr == nil && conf.Fsync
+	totals := &topic.PartitionTotals{}
 
 	// flush the remaining messages
 	inMemoryBuffers.CloseInput()
@@ -112,8 +116,16 @@ func (b *MessageQueueBroker) PublishFollowMe(stream mq_pb.SeaweedMessaging_Publi
 
 		targetFile := fmt.Sprintf("%s/%s", partitionDir, startTime.Format(topic.TIME_FORMAT))
 
+		segment := logstore.EncodeLogSegment(mem.buf)
+
+		messages, bytes := logstore.CountLogRecords(mem.buf)
 		for {
-			if err := b.appendToFile(targetFile, mem.buf); err != nil {
+			err := b.loadPartitionTotals(t, p, totals)
+			if err == nil {
+				totalMessages, totalBytes, _ := totals.Get()
+				err = b.appendToFile(targetFile, segment, fsync, totalMessages+messages, totalBytes+bytes, stopTime.UnixNano())
+			}
+			if err != nil {
 				glog.V(0).Infof("metadata log write failed %s: %v", targetFile, err)
 				time.Sleep(737 * time.Millisecond)
 			} else {
@@ -121,6 +133,8 @@ func (b *MessageQueueBroker) PublishFollowMe(stream mq_pb.SeaweedMessaging_Publi
 			}
 		}
 
+		totals.Add(messages, bytes)
+
 		glog.V(0).Infof("flushed remaining data at %v to %s size %d", mem.stopTime.UnixNano(), targetFile, len(mem.buf))
 	}
 
@@ -1,6 +1,7 @@
 package broker
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
@@ -10,8 +11,15 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
+	"github.com/seaweedfs/seaweedfs/weed/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"io"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -39,7 +47,8 @@ func (b *MessageQueueBroker) SubscribeMessage(stream mq_pb.SeaweedMessaging_Subs
 		return getOrGenErr
 	}
 
-	localTopicPartition.Subscribers.AddSubscriber(clientName, topic.NewLocalSubscriber())
+	subscriber := topic.NewLocalSubscriber(req.GetInit().ConsumerGroup)
+	localTopicPartition.Subscribers.AddSubscriber(clientName, subscriber)
 	glog.V(0).Infof("Subscriber %s connected on %v %v", clientName, t, partition)
 	isConnected := true
 	sleepIntervalCount := 0
@@ -57,6 +66,39 @@ func (b *MessageQueueBroker) SubscribeMessage(stream mq_pb.SeaweedMessaging_Subs
 	startPosition := b.getRequestPosition(req.GetInit())
 	imt := sub_coordinator.NewInflightMessageTracker(int(req.GetInit().SlidingWindowSize))
 
+	// the data, the resent data, and the end of stream ack are sent from different goroutines
+	var sendLock sync.Mutex
+	sendResponse := func(resp *mq_pb.SubscribeMessageResponse) error {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+		return stream.Send(resp)
+	}
+	spanAttributes := append(tracing.MessagingAttributes(t.String(), partition.String()),
+		attribute.String("messaging.consumer.group.name", req.GetInit().ConsumerGroup))
+	sendLogEntry := func(logEntry *filer_pb.LogEntry) error {
+		_, span := tracing.StartSpanFromHeaders(ctx, logEntry.Headers, "mq.broker.deliver",
+			trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(spanAttributes...))
+		defer span.End()
+		err := sendResponse(&mq_pb.SubscribeMessageResponse{Message: &mq_pb.SubscribeMessageResponse_Data{
+			Data: &mq_pb.DataMessage{
+				Key:      logEntry.Key,
+				Value:    logEntry.Data,
+				TsNs:     logEntry.TsNs,
+				Origin:   logEntry.Origin,
+				PrevTsNs: logEntry.PrevTsNs,
+				Headers:  logEntry.Headers,
+			},
+		}})
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			subscriber.Stats.Add(len(logEntry.Key) + len(logEntry.Data))
+			subscriber.Stats.SetQueueDepth(imt.InflightCount())
+		}
+		return err
+	}
+
 	// connect to the follower
 	var subscribeFollowMeStream mq_pb.SeaweedMessaging_SubscribeFollowMeClient
 	glog.V(0).Infof("follower broker: %v", req.GetInit().FollowerBroker)
@@ -90,6 +132,12 @@ func (b *MessageQueueBroker) SubscribeMessage(stream mq_pb.SeaweedMessaging_Subs
 		glog.V(0).Infof("follower %s connected", follower)
 	}
 
+	if ackDeadline := time.Duration(req.GetInit().AckDeadlineSeconds) * time.Second; ackDeadline > 0 {
+		redeliveryDone := make(chan struct{})
+		defer close(redeliveryDone)
+		go redeliverExpiredMessages(t, req.GetInit().ConsumerGroup, clientName, localTopicPartition, imt, ackDeadline, redeliveryDone, sendLogEntry)
+	}
+
 	go func() {
 		var lastOffset int64
 		for {
@@ -97,7 +145,7 @@ func (b *MessageQueueBroker) SubscribeMessage(stream mq_pb.SeaweedMessaging_Subs
 			if err != nil {
 				if err == io.EOF {
 					// the client has called CloseSend(). This is to ack the close.
-					stream.Send(&mq_pb.SubscribeMessageResponse{Message: &mq_pb.SubscribeMessageResponse_Ctrl{
+					sendResponse(&mq_pb.SubscribeMessageResponse{Message: &mq_pb.SubscribeMessageResponse_Ctrl{
 						Ctrl: &mq_pb.SubscribeMessageResponse_SubscribeCtrlMessage{
 							IsEndOfStream: true,
 						},
@@ -107,12 +155,30 @@ func (b *MessageQueueBroker) SubscribeMessage(stream mq_pb.SeaweedMessaging_Subs
 				glog.V(0).Infof("topic %v partition %v subscriber %s lastOffset %d error: %v", t, partition, clientName, lastOffset, err)
 				break
 			}
-			if ack.GetAck().Key == nil {
+			if resendRange := ack.GetResendRange(); resendRange != nil {
+				// the subscriber detected a gap, and asks for the missing messages
+				glog.V(0).Infof("subscriber %s resend %v %v range (%d, %d)", clientName, t, partition, resendRange.StartTsNs, resendRange.StopTsNs)
+				if err := localTopicPartition.ReadRange(clientName+"-resend", resendRange.StartTsNs, resendRange.StopTsNs, func(logEntry *filer_pb.LogEntry) (bool, error) {
+					if err := sendLogEntry(logEntry); err != nil {
+						return true, err
+					}
+					return false, nil
+				}); err != nil {
+					glog.Errorf("subscriber %s resend %v %v range (%d, %d): %v", clientName, t, partition, resendRange.StartTsNs, resendRange.StopTsNs, err)
+				}
+				continue
+			}
+			if ack.GetAck().GetKey() == nil {
 				// skip ack for control messages
 				continue
 			}
 			imt.AcknowledgeMessage(ack.GetAck().Key, ack.GetAck().Sequence)
+			subscriber.Stats.SetLastAckSequence(ack.GetAck().Sequence)
+			subscriber.Stats.SetQueueDepth(imt.InflightCount())
 			currentLastOffset := imt.GetOldestAckedTimestamp()
+			if currentLastOffset > atomic.LoadInt64(&subscriber.AckedTsNs) {
+				atomic.StoreInt64(&subscriber.AckedTsNs, currentLastOffset)
+			}
 			// fmt.Printf("%+v recv (%s,%d), oldest %d\n", partition, string(ack.GetAck().Key), ack.GetAck().Sequence, currentLastOffset)
 			if subscribeFollowMeStream != nil && currentLastOffset > lastOffset {
 				if err := subscribeFollowMeStream.Send(&mq_pb.SubscribeFollowMeRequest{
@@ -182,13 +248,7 @@ func (b *MessageQueueBroker) SubscribeMessage(stream mq_pb.SeaweedMessaging_Subs
 			imt.EnflightMessage(logEntry.Key, logEntry.TsNs)
 		}
 
-		if err := stream.Send(&mq_pb.SubscribeMessageResponse{Message: &mq_pb.SubscribeMessageResponse_Data{
-			Data: &mq_pb.DataMessage{
-				Key:   logEntry.Key,
-				Value: logEntry.Data,
-				TsNs:  logEntry.TsNs,
-			},
-		}}); err != nil {
+		if err := sendLogEntry(logEntry); err != nil {
 			glog.Errorf("Error sending data: %v", err)
 			return false, err
 		}
@@ -198,6 +258,36 @@ func (b *MessageQueueBroker) SubscribeMessage(stream mq_pb.SeaweedMessaging_Subs
 	})
 }
 
+// redeliverExpiredMessages sends again the messages not acked within the ack deadline, until done.
+// If the subscriber goes away instead, the consumer group resumes from the oldest unacked message.
+func redeliverExpiredMessages(t topic.Topic, consumerGroup, clientName string, localTopicPartition *topic.LocalPartition, imt *sub_coordinator.InflightMessageTracker, ackDeadline time.Duration, done chan struct{}, sendLogEntry func(logEntry *filer_pb.LogEntry) error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			for _, message := range imt.RenewExpiredMessages(now, ackDeadline) {
+				glog.V(1).Infof("subscriber %s redeliver %v key %s ts %d, delivery %d", clientName, t, message.Key, message.TsNs, message.Deliveries)
+				err := localTopicPartition.ReadRange(clientName+"-redeliver", message.TsNs-1, message.TsNs+1, func(logEntry *filer_pb.LogEntry) (bool, error) {
+					if !bytes.Equal(logEntry.Key, message.Key) || !imt.IsInflight(logEntry.Key) {
+						return false, nil
+					}
+					if err := sendLogEntry(logEntry); err != nil {
+						return true, err
+					}
+					stats.MqRedeliveredMessagesCounter.WithLabelValues(t.String(), consumerGroup).Inc()
+					return true, nil
+				})
+				if err != nil {
+					glog.Errorf("subscriber %s redeliver %v ts %d: %v", clientName, t, message.TsNs, err)
+				}
+			}
+		}
+	}
+}
+
 func (b *MessageQueueBroker) getRequestPosition(initMessage *mq_pb.SubscribeMessageRequest_InitMessage) (startPosition log_buffer.MessagePosition) {
 	if initMessage == nil {
 		return
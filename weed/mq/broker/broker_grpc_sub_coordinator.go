@@ -7,6 +7,9 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"io"
+	"sync/atomic"
+	"time"
 )
 
 // SubscriberToSubCoordinator coordinates the subscribers
@@ -39,13 +42,21 @@ func (b *MessageQueueBroker) SubscriberToSubCoordinator(stream mq_pb.SeaweedMess
 
 	ctx := stream.Context()
 
+	// any message from the subscriber counts as a heartbeat
+	var lastHeardTsNs atomic.Int64
+	lastHeardTsNs.Store(time.Now().UnixNano())
+	recvErrChan := make(chan error, 1)
+
 	go func() {
 		// process ack messages
 		for {
 			req, err := stream.Recv()
 			if err != nil {
 				glog.V(0).Infof("subscriber %s/%s/%s receive: %v", initMessage.ConsumerGroup, initMessage.ConsumerGroupInstanceId, initMessage.Topic, err)
+				recvErrChan <- err
+				return
 			}
+			lastHeardTsNs.Store(time.Now().UnixNano())
 
 			if ackUnAssignment := req.GetAckUnAssignment(); ackUnAssignment != nil {
 				glog.V(0).Infof("subscriber %s/%s/%s ack close of %v", initMessage.ConsumerGroup, initMessage.ConsumerGroupInstanceId, initMessage.Topic, ackUnAssignment)
@@ -55,21 +66,18 @@ func (b *MessageQueueBroker) SubscriberToSubCoordinator(stream mq_pb.SeaweedMess
 				glog.V(0).Infof("subscriber %s/%s/%s ack assignment %v", initMessage.ConsumerGroup, initMessage.ConsumerGroupInstanceId, initMessage.Topic, ackAssignment)
 				cg.AckAssignment(cgi, ackAssignment)
 			}
-
-			select {
-			case <-ctx.Done():
-				err := ctx.Err()
-				if err == context.Canceled {
-					// Client disconnected
-					return
-				}
-				return
-			default:
-				// Continue processing the request
-			}
 		}
 	}()
 
+	// without a session timeout, the subscriber is only removed when disconnected
+	sessionTimeout := time.Duration(initMessage.SessionTimeoutSeconds) * time.Second
+	var sessionCheck <-chan time.Time
+	if sessionTimeout > 0 {
+		sessionTicker := time.NewTicker(time.Second)
+		defer sessionTicker.Stop()
+		sessionCheck = sessionTicker.C
+	}
+
 	// send commands to subscriber
 	for {
 		select {
@@ -81,6 +89,16 @@ func (b *MessageQueueBroker) SubscriberToSubCoordinator(stream mq_pb.SeaweedMess
 			}
 			glog.V(0).Infof("subscriber %s/%s/%s disconnected: %v", initMessage.ConsumerGroup, initMessage.ConsumerGroupInstanceId, initMessage.Topic, err)
 			return err
+		case err = <-recvErrChan:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case now := <-sessionCheck:
+			if lastHeard := time.Unix(0, lastHeardTsNs.Load()); now.Sub(lastHeard) > sessionTimeout {
+				err = status.Errorf(codes.DeadlineExceeded, "no heartbeat since %v", lastHeard)
+				return err
+			}
 		case message := <-cgi.ResponseChan:
 			glog.V(0).Infof("subscriber %s/%s/%s send: %v", initMessage.ConsumerGroup, initMessage.ConsumerGroupInstanceId, initMessage.Topic, message)
 			if err := stream.Send(message); err != nil {
@@ -0,0 +1,167 @@
+package broker
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	ui "github.com/seaweedfs/seaweedfs/weed/mq/broker/broker_ui"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+type uiTopic struct {
+	Topic      topic.Topic
+	Error      string
+	Partitions []*uiPartition
+}
+
+type uiPartition struct {
+	Partition               topic.Partition
+	Leader                  string
+	Follower                string
+	Error                   string
+	Publishers              []string
+	Subscribers             []*uiSubscriber
+	BufferedMessages        int64
+	BufferedBytes           uint64
+	LastTsNs                int64
+	PublishedMessages       int64
+	PublishedBytes          uint64
+	MessagesPerSecond       float64
+	RecentPublishedMessages []int64
+}
+
+type uiSubscriber struct {
+	ClientName    string
+	ConsumerGroup string
+	AckedTsNs     int64
+	Lag           time.Duration
+}
+
+// UiStatusHandler shows the topics, where the partitions are, and how the publishers and subscribers are doing,
+// collected from the brokers hosting the partitions with the admin gRPC calls
+func (b *MessageQueueBroker) UiStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var balancer string
+	if b.lockAsBalancer != nil {
+		balancer = b.lockAsBalancer.LockOwner()
+	}
+
+	var topics []*uiTopic
+	var listErr string
+	if balancer == "" {
+		listErr = "the broker balancer is not found yet"
+	} else if resp, err := b.ListTopics(ctx, &mq_pb.ListTopicsRequest{}); err != nil {
+		listErr = err.Error()
+	} else {
+		connectionsByBroker := make(map[string][]*mq_pb.ClientConnection)
+		for _, pbTopic := range resp.Topics {
+			topics = append(topics, b.collectUiTopic(ctx, topic.FromPbTopic(pbTopic), connectionsByBroker))
+		}
+	}
+
+	args := struct {
+		Version  string
+		Broker   string
+		Balancer string
+		Error    string
+		Topics   []*uiTopic
+	}{
+		util.Version(),
+		b.option.BrokerAddress().String(),
+		balancer,
+		listErr,
+		topics,
+	}
+	if err := ui.StatusTpl.Execute(w, args); err != nil {
+		glog.V(1).Infof("render broker ui: %v", err)
+	}
+}
+
+func (b *MessageQueueBroker) collectUiTopic(ctx context.Context, t topic.Topic, connectionsByBroker map[string][]*mq_pb.ClientConnection) *uiTopic {
+	ret := &uiTopic{Topic: t}
+	conf, err := b.fca.ReadTopicConfFromFiler(t)
+	if err != nil {
+		ret.Error = err.Error()
+		return ret
+	}
+
+	descriptionsByBroker := make(map[string][]*mq_pb.LocalPartitionDescription)
+	brokerErrors := make(map[string]error)
+	for _, assignment := range conf.BrokerPartitionAssignments {
+		partition := &uiPartition{
+			Partition: topic.FromPbPartition(assignment.Partition),
+			Leader:    assignment.LeaderBroker,
+			Follower:  assignment.FollowerBroker,
+		}
+		ret.Partitions = append(ret.Partitions, partition)
+		if assignment.LeaderBroker == "" {
+			partition.Error = "not assigned"
+			continue
+		}
+
+		if _, found := descriptionsByBroker[assignment.LeaderBroker]; !found && brokerErrors[assignment.LeaderBroker] == nil {
+			brokerErrors[assignment.LeaderBroker] = b.withBrokerClient(false, pb.ServerAddress(assignment.LeaderBroker), func(client mq_pb.SeaweedMessagingClient) error {
+				if _, found := connectionsByBroker[assignment.LeaderBroker]; !found {
+					resp, err := client.ListConnections(ctx, &mq_pb.ListConnectionsRequest{})
+					if err != nil {
+						return err
+					}
+					connectionsByBroker[assignment.LeaderBroker] = resp.Connections
+				}
+				resp, err := client.DescribeTopic(ctx, &mq_pb.DescribeTopicRequest{Topic: t.ToPbTopic()})
+				if err != nil {
+					return err
+				}
+				descriptionsByBroker[assignment.LeaderBroker] = resp.Partitions
+				return nil
+			})
+		}
+		if err := brokerErrors[assignment.LeaderBroker]; err != nil {
+			partition.Error = err.Error()
+			continue
+		}
+
+		for _, description := range descriptionsByBroker[assignment.LeaderBroker] {
+			if topic.FromPbPartition(description.Partition).Equals(partition.Partition) {
+				partition.fillDescription(description)
+				break
+			}
+		}
+		for _, connection := range connectionsByBroker[assignment.LeaderBroker] {
+			if connection.Type != mq_pb.ClientConnection_SUBSCRIBER || topic.FromPbTopic(connection.Topic) != t ||
+				!topic.FromPbPartition(connection.Partition).Equals(partition.Partition) {
+				continue
+			}
+			subscriber := &uiSubscriber{
+				ClientName:    connection.ClientName,
+				ConsumerGroup: connection.ConsumerGroup,
+				AckedTsNs:     connection.AckedTsNs,
+			}
+			if connection.AckedTsNs > 0 && partition.LastTsNs > connection.AckedTsNs {
+				subscriber.Lag = time.Duration(partition.LastTsNs - connection.AckedTsNs)
+			}
+			partition.Subscribers = append(partition.Subscribers, subscriber)
+		}
+	}
+	return ret
+}
+
+func (partition *uiPartition) fillDescription(description *mq_pb.LocalPartitionDescription) {
+	partition.Publishers = description.Publishers
+	partition.BufferedMessages = description.BufferedMessages
+	partition.BufferedBytes = uint64(description.BufferedBytes)
+	partition.LastTsNs = description.LastTsNs
+	partition.PublishedMessages = description.PublishedMessages
+	partition.PublishedBytes = uint64(description.PublishedBytes)
+	partition.RecentPublishedMessages = description.RecentPublishedMessages
+	if count := len(description.RecentPublishedMessages); count > 0 && description.SampleIntervalSeconds > 0 {
+		partition.MessagesPerSecond = float64(description.RecentPublishedMessages[count-1]) / float64(description.SampleIntervalSeconds)
+	}
+}
@@ -0,0 +1,39 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// checkFilerReady checks that the broker has found a reachable filer to store the messages
+func (b *MessageQueueBroker) checkFilerReady(ctx context.Context) error {
+	if b.GetFiler() == "" {
+		return fmt.Errorf("no filer found yet")
+	}
+	return b.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.Ping(ctx, &filer_pb.PingRequest{})
+		return err
+	})
+}
+
+// checkBalancerReady checks that the broker balancer is found to assign the partitions, and the broker is not handing them off
+func (b *MessageQueueBroker) checkBalancerReady(ctx context.Context) error {
+	if atomic.LoadInt32(&b.stopping) != 0 {
+		return fmt.Errorf("shutting down")
+	}
+	if b.lockAsBalancer == nil || b.lockAsBalancer.LockOwner() == "" {
+		return fmt.Errorf("the broker balancer is not found yet")
+	}
+	return nil
+}
+
+// checkPartitionsReady checks that the broker is not loading the assigned partitions
+func (b *MessageQueueBroker) checkPartitionsReady(ctx context.Context) error {
+	if assigning := atomic.LoadInt32(&b.assigning); assigning > 0 {
+		return fmt.Errorf("loading %d partition assignments", assigning)
+	}
+	return nil
+}
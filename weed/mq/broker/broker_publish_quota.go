@@ -0,0 +1,95 @@
+package broker
+
+import (
+	"sync"
+	"time"
+)
+
+// PublishQuota limits the publishing rate. Zero means unlimited.
+type PublishQuota struct {
+	MessagesPerSecond int64
+	BytesPerSecond    int64
+}
+
+func (q PublishQuota) IsUnlimited() bool {
+	return q.MessagesPerSecond <= 0 && q.BytesPerSecond <= 0
+}
+
+// PublishRateLimiter is a token bucket per key, e.g., per client or per topic.
+// The bucket can hold up to one second worth of quota.
+type PublishRateLimiter struct {
+	quota       PublishQuota
+	buckets     map[string]*publishBucket
+	bucketsLock sync.Mutex
+	lastPrune   time.Time
+}
+
+type publishBucket struct {
+	messageTokens float64
+	byteTokens    float64
+	lastRefill    time.Time
+}
+
+func NewPublishRateLimiter(quota PublishQuota) *PublishRateLimiter {
+	return &PublishRateLimiter{
+		quota:     quota,
+		buckets:   make(map[string]*publishBucket),
+		lastPrune: time.Now(),
+	}
+}
+
+// Reserve takes one message of the given size from the key's bucket,
+// and returns how long the caller should wait before the message is within quota.
+func (l *PublishRateLimiter) Reserve(key string, byteCount int) (delay time.Duration) {
+	if l == nil || l.quota.IsUnlimited() {
+		return 0
+	}
+
+	l.bucketsLock.Lock()
+	defer l.bucketsLock.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPrune) > time.Minute {
+		l.pruneIdleBuckets(now)
+	}
+
+	bucket, found := l.buckets[key]
+	if !found {
+		bucket = &publishBucket{
+			messageTokens: float64(l.quota.MessagesPerSecond),
+			byteTokens:    float64(l.quota.BytesPerSecond),
+			lastRefill:    now,
+		}
+		l.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	bucket.lastRefill = now
+
+	if l.quota.MessagesPerSecond > 0 {
+		bucket.messageTokens = min(bucket.messageTokens+elapsed*float64(l.quota.MessagesPerSecond), float64(l.quota.MessagesPerSecond))
+		bucket.messageTokens -= 1
+		if bucket.messageTokens < 0 {
+			delay = max(delay, time.Duration(-bucket.messageTokens/float64(l.quota.MessagesPerSecond)*float64(time.Second)))
+		}
+	}
+	if l.quota.BytesPerSecond > 0 {
+		bucket.byteTokens = min(bucket.byteTokens+elapsed*float64(l.quota.BytesPerSecond), float64(l.quota.BytesPerSecond))
+		bucket.byteTokens -= float64(byteCount)
+		if bucket.byteTokens < 0 {
+			delay = max(delay, time.Duration(-bucket.byteTokens/float64(l.quota.BytesPerSecond)*float64(time.Second)))
+		}
+	}
+
+	return delay
+}
+
+// a bucket idle for a while is full again, and is the same as a new one
+func (l *PublishRateLimiter) pruneIdleBuckets(now time.Time) {
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastRefill) > time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
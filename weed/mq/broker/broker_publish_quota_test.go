@@ -0,0 +1,43 @@
+package broker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublishRateLimiter(t *testing.T) {
+	limiter := NewPublishRateLimiter(PublishQuota{MessagesPerSecond: 10, BytesPerSecond: 1000})
+
+	// a full bucket allows a burst of one second worth of quota
+	for i := 0; i < 10; i++ {
+		if delay := limiter.Reserve("client1", 10); delay > 0 {
+			t.Fatalf("message %d delayed by %v", i, delay)
+		}
+	}
+	if delay := limiter.Reserve("client1", 10); delay <= 0 || delay > 200*time.Millisecond {
+		t.Errorf("expected about 100ms delay over message quota, got %v", delay)
+	}
+
+	// other keys are not affected
+	if delay := limiter.Reserve("client2", 10); delay > 0 {
+		t.Errorf("client2 delayed by %v", delay)
+	}
+
+	// a large message is over the byte quota
+	if delay := limiter.Reserve("client3", 3000); delay < 1900*time.Millisecond {
+		t.Errorf("expected about 2s delay over byte quota, got %v", delay)
+	}
+}
+
+func TestPublishRateLimiterUnlimited(t *testing.T) {
+	var nilLimiter *PublishRateLimiter
+	if delay := nilLimiter.Reserve("client", 1<<20); delay != 0 {
+		t.Errorf("nil limiter delayed by %v", delay)
+	}
+	limiter := NewPublishRateLimiter(PublishQuota{})
+	for i := 0; i < 1000; i++ {
+		if delay := limiter.Reserve("client", 1<<20); delay != 0 {
+			t.Fatalf("unlimited limiter delayed by %v", delay)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/cluster"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util/health"
 	"github.com/seaweedfs/seaweedfs/weed/wdclient"
 	"google.golang.org/grpc"
 
@@ -31,6 +32,13 @@ type MessageQueueBrokerOption struct {
 	Port               int
 	Cipher             bool
 	VolumeServerAccess string // how to access volume servers
+	ClientPublishQuota PublishQuota
+	TopicPublishQuota  PublishQuota
+	DedupWindow        time.Duration // drop the messages with the idempotency keys seen within the window, 0 to disable
+	DedupMaxKeys       int           // max idempotency keys remembered for each partition
+	LogReadPrefetch    int           // log file and parquet file chunks fetched ahead when the subscribers read the persisted messages
+	MaxMessageSize     int           // reject the larger published messages, unless the topic has its own limit. 0 means unlimited
+	Tenants            []*Tenant     // tenants told apart by the hostnames the clients connect to
 }
 
 func (option *MessageQueueBrokerOption) BrokerAddress() pb.ServerAddress {
@@ -50,10 +58,23 @@ type MessageQueueBroker struct {
 	SubCoordinator    *sub_coordinator.SubCoordinator
 	accessLock        sync.Mutex
 	fca               *filer_client.FilerClientAccessor
+	clientPubLimiter  *PublishRateLimiter
+	topicPubLimiter   *PublishRateLimiter
+	tenants           *tenantRouter
+	samplers          map[topic.Topic]*topicSampler
+	samplersLock      sync.Mutex
+	stopping          int32
+	assigning         int32 // the partition assignments being loaded
+	HealthChecks      *health.Checks
 }
 
 func NewMessageBroker(option *MessageQueueBrokerOption, grpcDialOption grpc.DialOption) (mqBroker *MessageQueueBroker, err error) {
 
+	tenants, err := newTenantRouter(option.Tenants)
+	if err != nil {
+		return nil, err
+	}
+
 	pubBalancer := pub_balancer.NewPubBalancer()
 	subCoordinator := sub_coordinator.NewSubCoordinator()
 
@@ -63,20 +84,34 @@ func NewMessageBroker(option *MessageQueueBrokerOption, grpcDialOption grpc.Dial
 		MasterClient:      wdclient.NewMasterClient(grpcDialOption, option.FilerGroup, cluster.BrokerType, option.BrokerAddress(), option.DataCenter, option.Rack, *pb.NewServiceDiscoveryFromMap(option.Masters)),
 		filers:            make(map[pb.ServerAddress]struct{}),
 		localTopicManager: topic.NewLocalTopicManager(),
+		samplers:          make(map[topic.Topic]*topicSampler),
 		PubBalancer:       pubBalancer,
 		SubCoordinator:    subCoordinator,
+		clientPubLimiter:  NewPublishRateLimiter(option.ClientPublishQuota),
+		topicPubLimiter:   NewPublishRateLimiter(option.TopicPublishQuota),
+		tenants:           tenants,
 	}
 	fca := &filer_client.FilerClientAccessor{
 		GetFiler:          mqBroker.GetFiler,
 		GetGrpcDialOption: mqBroker.GetGrpcDialOption,
+		TopicConfCache:    filer_client.NewTopicConfCache(topicConfCacheTtl),
 	}
+	fca.TopicConfCache.OnTopicConfChange = mqBroker.onTopicConfChange
 	mqBroker.fca = fca
 	subCoordinator.FilerClientAccessor = fca
 
+	mqBroker.HealthChecks = health.NewChecks()
+	mqBroker.HealthChecks.Add("filer", mqBroker.checkFilerReady)
+	mqBroker.HealthChecks.Add("balancer", mqBroker.checkBalancerReady)
+	mqBroker.HealthChecks.Add("partitions", mqBroker.checkPartitionsReady)
+
 	mqBroker.MasterClient.SetOnPeerUpdateFn(mqBroker.OnBrokerUpdate)
 	pubBalancer.OnPartitionChange = mqBroker.SubCoordinator.OnPartitionChange
 
 	go mqBroker.MasterClient.KeepConnectedToMaster(context.Background())
+	go mqBroker.subscribeTopicConfChanges()
+	go mqBroker.loopDeleteExpiredTopicFiles()
+	go mqBroker.localTopicManager.LoopSampleThroughput()
 
 	existingNodes := cluster.ListExistingPeerUpdates(mqBroker.MasterClient.GetMaster(context.Background()), grpcDialOption, option.FilerGroup, cluster.FilerType)
 	for _, newNode := range existingNodes {
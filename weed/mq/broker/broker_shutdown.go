@@ -0,0 +1,121 @@
+package broker
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+)
+
+const (
+	shutdownPublisherWaitTimeout = 15 * time.Second
+	shutdownFlushTimeout         = 30 * time.Second
+)
+
+func (b *MessageQueueBroker) isStopping() bool {
+	return atomic.LoadInt32(&b.stopping) == 1
+}
+
+// Shutdown hands off the local partitions before the broker exits, so that rolling restarts do not lose in-flight data.
+//  1. stop accepting new publishers
+//  2. move the partition leadership to other brokers, by rewriting the topic.conf assignments
+//  3. ask the connected publishers to close, so they look up the new leaders
+//  4. flush the in-memory messages to the filer
+func (b *MessageQueueBroker) Shutdown() {
+	if !atomic.CompareAndSwapInt32(&b.stopping, 0, 1) {
+		return
+	}
+	self := string(b.option.BrokerAddress())
+	glog.V(0).Infof("broker %s shutting down", self)
+
+	topicPartitions := b.localTopicManager.ListTopicPartitions()
+
+	b.handOffPartitionLeadership(self, topicPartitions)
+
+	// ask the publishers to close, and wait for them to disconnect
+	for _, tp := range topicPartitions {
+		b.localTopicManager.ClosePublishers(tp.Topic, tp.UnixTimeNs)
+	}
+	deadline := time.Now().Add(shutdownPublisherWaitTimeout)
+	for _, tp := range topicPartitions {
+		localPartition := b.localTopicManager.GetLocalPartition(tp.Topic, tp.Partition)
+		for localPartition != nil && localPartition.Publishers.Size() > 0 && time.Now().Before(deadline) {
+			time.Sleep(113 * time.Millisecond)
+		}
+	}
+	b.stopTopicSamplers()
+
+	// flush the in-memory messages
+	var wg sync.WaitGroup
+	for _, tp := range topicPartitions {
+		localPartition := b.localTopicManager.GetLocalPartition(tp.Topic, tp.Partition)
+		if localPartition == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(tp topic.TopicPartition, localPartition *topic.LocalPartition) {
+			defer wg.Done()
+			if !localPartition.FlushAndShutdown(shutdownFlushTimeout) {
+				glog.Errorf("broker %s: timed out flushing topic %v partition %v", self, tp.Topic, tp.Partition)
+			}
+		}(tp, localPartition)
+	}
+	wg.Wait()
+
+	glog.V(0).Infof("broker %s shut down with %d partitions handed off", self, len(topicPartitions))
+}
+
+// handOffPartitionLeadership assigns the partitions led by this broker to the follower, or to another broker
+func (b *MessageQueueBroker) handOffPartitionLeadership(self string, topicPartitions []topic.TopicPartition) {
+	var otherBrokers []string
+	for _, node := range cluster.ListExistingPeerUpdates(b.MasterClient.GetMaster(context.Background()), b.grpcDialOption, b.option.FilerGroup, cluster.BrokerType) {
+		if node.Address != self {
+			otherBrokers = append(otherBrokers, node.Address)
+		}
+	}
+	if len(otherBrokers) == 0 {
+		glog.Warningf("broker %s: no other brokers to take over %d partitions", self, len(topicPartitions))
+		return
+	}
+
+	handledTopics := make(map[topic.Topic]struct{})
+	for _, tp := range topicPartitions {
+		if _, found := handledTopics[tp.Topic]; found {
+			continue
+		}
+		handledTopics[tp.Topic] = struct{}{}
+
+		conf, err := b.fca.ReadTopicConfFromFiler(tp.Topic)
+		if err != nil {
+			glog.Errorf("broker %s: read topic %v conf: %v", self, tp.Topic, err)
+			continue
+		}
+		hasChanges := false
+		for _, assignment := range conf.BrokerPartitionAssignments {
+			if assignment.LeaderBroker != self {
+				continue
+			}
+			if assignment.FollowerBroker != "" && assignment.FollowerBroker != self {
+				assignment.LeaderBroker = assignment.FollowerBroker
+				assignment.FollowerBroker = ""
+			} else {
+				assignment.LeaderBroker = otherBrokers[rand.Intn(len(otherBrokers))]
+				assignment.FollowerBroker = ""
+			}
+			hasChanges = true
+		}
+		if !hasChanges {
+			continue
+		}
+		if err = b.fca.SaveTopicConfToFiler(tp.Topic, conf); err != nil {
+			glog.Errorf("broker %s: hand off topic %v: %v", self, tp.Topic, err)
+			continue
+		}
+		glog.V(0).Infof("broker %s: handed off topic %v assignments: %v", self, tp.Topic, conf.BrokerPartitionAssignments)
+	}
+}
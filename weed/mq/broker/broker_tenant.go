@@ -0,0 +1,247 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Tenant is a logical tenant sharing the brokers, told apart by the hostname the clients connect to,
+// i.e., the TLS server name (SNI), or the :authority of the plain text connections.
+// The clients connecting with other hostnames, e.g., the other brokers, are not limited.
+type Tenant struct {
+	Name      string
+	Hostnames []string
+	// the only namespace the tenant can access, also for the topics without namespaces
+	Namespace string
+	// limits the publishing of all clients of the tenant together
+	PublishQuota PublishQuota
+	// the common names of the client certificates allowed, empty to allow all clients
+	AllowedCommonNames []string
+}
+
+type tenantState struct {
+	*Tenant
+	allowedCommonNames map[string]bool
+	pubLimiter         *PublishRateLimiter
+}
+
+// tenantRouter finds the tenant of each request by its hostname
+type tenantRouter struct {
+	byHostname map[string]*tenantState
+}
+
+// the methods the tenants can call, the others are for the brokers or the administrators
+var tenantMethods = map[string]bool{
+	"FindBrokerLeader":           true,
+	"ListTopics":                 true,
+	"ConfigureTopic":             true,
+	"AlterTopic":                 true,
+	"LookupTopicBrokers":         true,
+	"DescribeTopic":              true,
+	"ListConnections":            true,
+	"ClosePublishers":            true,
+	"CloseSubscribers":           true,
+	"PublishMessage":             true,
+	"SubscribeMessage":           true,
+	"SubscriberToSubCoordinator": true,
+}
+
+func newTenantRouter(tenants []*Tenant) (*tenantRouter, error) {
+	if len(tenants) == 0 {
+		return nil, nil
+	}
+	r := &tenantRouter{
+		byHostname: make(map[string]*tenantState),
+	}
+	for _, tenant := range tenants {
+		if len(tenant.Hostnames) == 0 || tenant.Namespace == "" {
+			return nil, fmt.Errorf("tenant %s needs hostnames and a namespace", tenant.Name)
+		}
+		state := &tenantState{
+			Tenant:             tenant,
+			allowedCommonNames: make(map[string]bool),
+			pubLimiter:         NewPublishRateLimiter(tenant.PublishQuota),
+		}
+		for _, commonName := range tenant.AllowedCommonNames {
+			state.allowedCommonNames[commonName] = true
+		}
+		for _, hostname := range tenant.Hostnames {
+			hostname = strings.ToLower(hostname)
+			if existing, found := r.byHostname[hostname]; found {
+				return nil, fmt.Errorf("hostname %s of tenant %s is also used by tenant %s", hostname, tenant.Name, existing.Name)
+			}
+			r.byHostname[hostname] = state
+		}
+		glog.V(0).Infof("tenant %s on %v with namespace %s", tenant.Name, tenant.Hostnames, tenant.Namespace)
+	}
+	return r, nil
+}
+
+// tenantOf returns the tenant of the connection, or nil if the hostname is not of any tenant
+func (r *tenantRouter) tenantOf(ctx context.Context) (*tenantState, error) {
+	var hostname, commonName string
+	var isTls bool
+	if pr, ok := peer.FromContext(ctx); ok {
+		var tlsInfo credentials.TLSInfo
+		if tlsInfo, isTls = pr.AuthInfo.(credentials.TLSInfo); isTls {
+			// the server name is verified by the client, unlike the :authority
+			hostname = tlsInfo.State.ServerName
+			if len(tlsInfo.State.PeerCertificates) > 0 {
+				commonName = tlsInfo.State.PeerCertificates[0].Subject.CommonName
+			}
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); !isTls && ok && len(md.Get(":authority")) > 0 {
+		hostname = md.Get(":authority")[0]
+		if host, _, err := net.SplitHostPort(hostname); err == nil {
+			hostname = host
+		}
+	}
+	tenant, found := r.byHostname[strings.ToLower(hostname)]
+	if !found {
+		return nil, nil
+	}
+	if len(tenant.allowedCommonNames) > 0 && !tenant.allowedCommonNames[commonName] {
+		return nil, status.Errorf(codes.PermissionDenied, "client %q is not allowed for tenant %s", commonName, tenant.Name)
+	}
+	return tenant, nil
+}
+
+// checkTopic allows only the topics in the namespace of the tenant, and puts the topics without namespaces there
+func (tenant *tenantState) checkTopic(t *schema_pb.Topic) error {
+	if t == nil {
+		return nil
+	}
+	if t.Namespace == "" {
+		t.Namespace = tenant.Namespace
+		return nil
+	}
+	if t.Namespace != tenant.Namespace {
+		return status.Errorf(codes.PermissionDenied, "tenant %s can not access namespace %s", tenant.Name, t.Namespace)
+	}
+	return nil
+}
+
+func (tenant *tenantState) publishLimiter() *PublishRateLimiter {
+	if tenant == nil {
+		return nil
+	}
+	return tenant.pubLimiter
+}
+
+type tenantContextKey struct{}
+
+func tenantFromContext(ctx context.Context) *tenantState {
+	tenant, _ := ctx.Value(tenantContextKey{}).(*tenantState)
+	return tenant
+}
+
+func (r *tenantRouter) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	tenant, err := r.tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenant == nil {
+		return handler(ctx, req)
+	}
+	if !tenantMethods[info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]] {
+		return nil, status.Errorf(codes.PermissionDenied, "tenant %s can not call %s", tenant.Name, info.FullMethod)
+	}
+	if topicRequest, ok := req.(interface{ GetTopic() *schema_pb.Topic }); ok {
+		if err := tenant.checkTopic(topicRequest.GetTopic()); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := handler(context.WithValue(ctx, tenantContextKey{}, tenant), req)
+	if err != nil {
+		return resp, err
+	}
+
+	// only list the topics and the clients of the tenant
+	switch resp := resp.(type) {
+	case *mq_pb.ListTopicsResponse:
+		var topics []*schema_pb.Topic
+		for _, t := range resp.Topics {
+			if t.Namespace == tenant.Namespace {
+				topics = append(topics, t)
+			}
+		}
+		resp.Topics = topics
+	case *mq_pb.ListConnectionsResponse:
+		var connections []*mq_pb.ClientConnection
+		for _, connection := range resp.Connections {
+			if connection.GetTopic().GetNamespace() == tenant.Namespace {
+				connections = append(connections, connection)
+			}
+		}
+		resp.Connections = connections
+	}
+	return resp, nil
+}
+
+func (r *tenantRouter) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	tenant, err := r.tenantOf(stream.Context())
+	if err != nil {
+		return err
+	}
+	if tenant == nil {
+		return handler(srv, stream)
+	}
+	if !tenantMethods[info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]] {
+		return status.Errorf(codes.PermissionDenied, "tenant %s can not call %s", tenant.Name, info.FullMethod)
+	}
+	return handler(srv, &tenantServerStream{
+		ServerStream: stream,
+		ctx:          context.WithValue(stream.Context(), tenantContextKey{}, tenant),
+		tenant:       tenant,
+	})
+}
+
+// tenantServerStream checks the topics in the init messages of the streams
+type tenantServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	tenant *tenantState
+}
+
+func (s *tenantServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tenantServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	switch req := m.(type) {
+	case *mq_pb.PublishMessageRequest:
+		return s.tenant.checkTopic(req.GetInit().GetTopic())
+	case *mq_pb.SubscribeMessageRequest:
+		return s.tenant.checkTopic(req.GetInit().GetTopic())
+	case *mq_pb.SubscriberToSubCoordinatorRequest:
+		return s.tenant.checkTopic(req.GetInit().GetTopic())
+	}
+	return nil
+}
+
+// TenantServerOptions routes the requests to the tenants, if any is configured
+func (b *MessageQueueBroker) TenantServerOptions() []grpc.ServerOption {
+	if b.tenants == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(b.tenants.unaryInterceptor),
+		grpc.ChainStreamInterceptor(b.tenants.streamInterceptor),
+	}
+}
@@ -0,0 +1,61 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTenantRouter(t *testing.T) {
+	r, err := newTenantRouter([]*Tenant{
+		{Name: "a", Hostnames: []string{"a.mq.example.com"}, Namespace: "ns_a"},
+		{Name: "b", Hostnames: []string{"B.mq.example.com"}, Namespace: "ns_b"},
+	})
+	if err != nil {
+		t.Fatalf("new tenant router: %v", err)
+	}
+	if _, err := newTenantRouter([]*Tenant{{Name: "c", Hostnames: []string{"c"}}}); err == nil {
+		t.Errorf("tenant without namespace should fail")
+	}
+
+	ctxOf := func(authority string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(":authority", authority))
+	}
+	call := func(ctx context.Context, method string, req interface{}, resp interface{}) (interface{}, error) {
+		return r.unaryInterceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/messaging_pb.SeaweedMessaging/" + method},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return resp, nil
+			})
+	}
+
+	// the topic without namespace is put in the namespace of the tenant
+	req := &mq_pb.LookupTopicBrokersRequest{Topic: &schema_pb.Topic{Name: "t"}}
+	if _, err := call(ctxOf("b.mq.example.com:17777"), "LookupTopicBrokers", req, nil); err != nil || req.Topic.Namespace != "ns_b" {
+		t.Errorf("lookup without namespace: %v, namespace %s", err, req.Topic.Namespace)
+	}
+
+	req = &mq_pb.LookupTopicBrokersRequest{Topic: &schema_pb.Topic{Namespace: "ns_b", Name: "t"}}
+	if _, err := call(ctxOf("a.mq.example.com"), "LookupTopicBrokers", req, nil); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("lookup in the namespace of another tenant: %v", err)
+	}
+	if _, err := call(ctxOf("a.mq.example.com"), "BalanceTopics", &mq_pb.BalanceTopicsRequest{}, nil); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("tenant calling admin method: %v", err)
+	}
+	// not a tenant
+	if _, err := call(ctxOf("10.0.0.1:17777"), "LookupTopicBrokers", req, nil); err != nil {
+		t.Errorf("lookup without tenant: %v", err)
+	}
+
+	resp, err := call(ctxOf("a.mq.example.com"), "ListTopics", &mq_pb.ListTopicsRequest{}, &mq_pb.ListTopicsResponse{
+		Topics: []*schema_pb.Topic{{Namespace: "ns_a", Name: "t1"}, {Namespace: "ns_b", Name: "t2"}},
+	})
+	if err != nil || len(resp.(*mq_pb.ListTopicsResponse).Topics) != 1 {
+		t.Errorf("list topics: %v %v", resp, err)
+	}
+}
@@ -0,0 +1,99 @@
+package broker
+
+import (
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// topic.conf is cached for a while, and invalidated early when changed on the filer
+const topicConfCacheTtl = 30 * time.Second
+
+func (b *MessageQueueBroker) subscribeTopicConfChanges() {
+	for b.currentFiler == "" {
+		time.Sleep(time.Millisecond * 237)
+	}
+
+	metadataFollowOption := &pb.MetadataFollowOption{
+		ClientName:     "broker " + string(b.option.BrokerAddress()),
+		ClientId:       util.RandomInt32(),
+		ClientEpoch:    1,
+		PathPrefix:     filer.TopicsDir + "/",
+		NamesToWatch:   []string{filer.TopicConfFile},
+		StartTsNs:      time.Now().UnixNano(),
+		EventErrorType: pb.TrivialOnError,
+	}
+	util.RetryUntil("followTopicConfChanges", func() error {
+		metadataFollowOption.ClientEpoch++
+		return pb.WithFilerClientFollowMetadata(b, metadataFollowOption, b.fca.TopicConfCache.OnMetadataChange)
+	}, func(err error) bool {
+		glog.V(0).Infof("broker follow topic conf changes: %v", err)
+		return true
+	})
+}
+
+// onTopicConfChange reconfigures this broker after the topic.conf is changed, e.g., by AlterTopic.
+// The sampling rules and the flush settings are reloaded, and the publishers reconnect if the followers of the local partitions are changed.
+func (b *MessageQueueBroker) onTopicConfChange(t topic.Topic) {
+	b.samplersLock.Lock()
+	sampler, found := b.samplers[t]
+	b.samplersLock.Unlock()
+	if found && sampler.isLoading.CompareAndSwap(false, true) {
+		go b.loadSamplingRules(sampler)
+	}
+
+	for _, tp := range b.localTopicManager.ListTopicPartitions() {
+		if tp.Topic == t {
+			go b.configureLocalPartitions(t)
+			go b.reconnectChangedFollowers(t)
+			return
+		}
+	}
+}
+
+// configureLocalPartitions applies the flush settings and the max message size of the topic to its local partitions
+func (b *MessageQueueBroker) configureLocalPartitions(t topic.Topic) {
+	conf, err := b.fca.ReadTopicConfFromFiler(t)
+	if err != nil {
+		glog.V(1).Infof("read topic %s conf: %v", t, err)
+		return
+	}
+	flushInterval := time.Duration(conf.FlushIntervalMs) * time.Millisecond
+	bufferSize := int(conf.BufferSizeKb) * 1024
+	for _, tp := range b.localTopicManager.ListTopicPartitions() {
+		if tp.Topic != t {
+			continue
+		}
+		if localPartition := b.localTopicManager.GetLocalPartition(t, tp.Partition); localPartition != nil {
+			localPartition.ConfigureFlush(flushInterval, bufferSize, conf.Fsync)
+			localPartition.MaxMessageSize.Store(int64(conf.MaxMessageSizeKb) * 1024)
+		}
+	}
+}
+
+// reconnectChangedFollowers asks the publishers to reconnect, since the leader connects to the follower
+// that the publishers found in the assignments
+func (b *MessageQueueBroker) reconnectChangedFollowers(t topic.Topic) {
+	conf, err := b.fca.ReadTopicConfFromFiler(t)
+	if err != nil {
+		glog.V(1).Infof("read topic %s conf: %v", t, err)
+		return
+	}
+	self := string(b.option.BrokerAddress())
+	for _, assignment := range conf.BrokerPartitionAssignments {
+		if assignment.LeaderBroker != self {
+			continue
+		}
+		partition := topic.FromPbPartition(assignment.Partition)
+		localPartition := b.localTopicManager.GetLocalPartition(t, partition)
+		if localPartition == nil || localPartition.Publishers.Size() == 0 || localPartition.Follower == assignment.FollowerBroker {
+			continue
+		}
+		glog.V(0).Infof("topic %v partition %v follower %q => %q, reconnect the publishers", t, partition, localPartition.Follower, assignment.FollowerBroker)
+		b.localTopicManager.ClosePublishers(t, partition.UnixTimeNs)
+	}
+}
@@ -21,9 +21,26 @@ func (b *MessageQueueBroker) GetOrGenerateLocalPartition(t topic.Topic, partitio
 		glog.Errorf("topic %v partition %v not setup: %v", t, partition, getOrGenError)
 		return nil, fmt.Errorf("topic %v partition %v not setup: %v", t, partition, getOrGenError)
 	}
+	if localTopicPartition == nil {
+		return nil, fmt.Errorf("topic %v partition %v is not assigned to broker %s", t, partition, b.option.BrokerAddress())
+	}
 	return localTopicPartition, nil
 }
 
+// findPartitionLeader returns the leader broker of the topic partition in the current assignments
+func (b *MessageQueueBroker) findPartitionLeader(t topic.Topic, partition topic.Partition) (leader string, err error) {
+	conf, err := b.fca.ReadTopicConfFromFiler(t)
+	if err != nil {
+		return "", fmt.Errorf("topic %v not found: %v", t, err)
+	}
+	for _, assignment := range conf.BrokerPartitionAssignments {
+		if partition.Equals(topic.FromPbPartition(assignment.Partition)) {
+			return assignment.LeaderBroker, nil
+		}
+	}
+	return "", fmt.Errorf("topic %v partition %v not found", t, partition)
+}
+
 func (b *MessageQueueBroker) doGetOrGenLocalPartition(t topic.Topic, partition topic.Partition, conf *mq_pb.ConfigureTopicResponse) (localPartition *topic.LocalPartition, isGenerated bool, err error) {
 	b.accessLock.Lock()
 	defer b.accessLock.Unlock()
@@ -37,11 +54,34 @@ func (b *MessageQueueBroker) doGetOrGenLocalPartition(t topic.Topic, partition t
 	return localPartition, isGenerated, nil
 }
 
+func (b *MessageQueueBroker) newLocalPartition(t topic.Topic, partition topic.Partition) *topic.LocalPartition {
+	localPartition := topic.NewLocalPartition(partition, b.genLogFlushFunc(t, partition), logstore.GenMergedReadFunc(b, t, partition, b.option.LogReadPrefetch))
+	localPartition.Dedup = topic.NewDedupWindow(b.option.DedupWindow, b.option.DedupMaxKeys)
+	localPartition.LogBuffer.SetLastTsNsUnknown()
+	// the topic.conf, the totals, and the last timestamp are read from the filer, so not under the access lock
+	go b.configureLocalPartitions(t)
+	go func() {
+		if err := b.loadPartitionTotals(t, partition, &localPartition.Totals); err != nil {
+			glog.V(0).Infof("load totals of topic %v partition %v: %v", t, partition, err)
+		}
+	}()
+	go func() {
+		// link the new messages to the persisted ones, so the subscribers detect the messages lost before restarting
+		lastTsNs, err := logstore.ReadPartitionLastTsNs(b, topic.PartitionDir(t, partition))
+		if err != nil {
+			glog.V(0).Infof("load last timestamp of topic %v partition %v: %v", t, partition, err)
+			return
+		}
+		localPartition.LogBuffer.RestoreLastTsNs(lastTsNs)
+	}()
+	return localPartition
+}
+
 func (b *MessageQueueBroker) genLocalPartitionFromFiler(t topic.Topic, partition topic.Partition, conf *mq_pb.ConfigureTopicResponse) (localPartition *topic.LocalPartition, isGenerated bool, err error) {
 	self := b.option.BrokerAddress()
 	for _, assignment := range conf.BrokerPartitionAssignments {
 		if assignment.LeaderBroker == string(self) && partition.Equals(topic.FromPbPartition(assignment.Partition)) {
-			localPartition = topic.NewLocalPartition(partition, b.genLogFlushFunc(t, partition), logstore.GenMergedReadFunc(b, t, partition))
+			localPartition = b.newLocalPartition(t, partition)
 			b.localTopicManager.AddLocalPartition(t, localPartition)
 			isGenerated = true
 			break
@@ -53,7 +93,7 @@ func (b *MessageQueueBroker) genLocalPartitionFromFiler(t topic.Topic, partition
 
 func (b *MessageQueueBroker) ensureTopicActiveAssignments(t topic.Topic, conf *mq_pb.ConfigureTopicResponse) (err error) {
 	// also fix assignee broker if invalid
-	hasChanges := pub_balancer.EnsureAssignmentsToActiveBrokers(b.PubBalancer.Brokers, 1, conf.BrokerPartitionAssignments)
+	hasChanges := pub_balancer.EnsureAssignmentsToActiveBrokers(b.PubBalancer.Brokers, pub_balancer.FollowerCount(conf.Replication), conf.BrokerPartitionAssignments)
 	if hasChanges {
 		glog.V(0).Infof("topic %v partition updated assignments: %v", t, conf.BrokerPartitionAssignments)
 		if err = b.fca.SaveTopicConfToFiler(t, conf); err != nil {
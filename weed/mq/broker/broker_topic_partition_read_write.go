@@ -1,10 +1,15 @@
 package broker
 
 import (
+	"context"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/logstore"
 	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
+	"github.com/seaweedfs/seaweedfs/weed/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"sync/atomic"
 	"time"
 )
@@ -21,17 +26,42 @@ func (b *MessageQueueBroker) genLogFlushFunc(t topic.Topic, p topic.Partition) l
 
 		targetFile := fmt.Sprintf("%s/%s", partitionDir, startTime.Format(topic.TIME_FORMAT))
 
-		// TODO append block with more metadata
+		// the flush covers many messages, so it is traced on its own
+		_, span := tracing.Tracer().Start(context.Background(), "mq.broker.flush")
+		span.SetAttributes(tracing.MessagingAttributes(t.String(), p.String())...)
+		span.SetAttributes(attribute.String("seaweedfs.flush.file", targetFile), attribute.Int("seaweedfs.flush.size", len(buf)),
+			attribute.Int64("seaweedfs.flush.lag_ms", time.Since(startTime).Milliseconds()))
+		defer span.End()
 
+		// each flush is one checksummed segment, so the readers can detect corruption
+		segment := logstore.EncodeLogSegment(buf)
+
+		fsync := false
+		totals := &topic.PartitionTotals{}
+		if localPartition := b.localTopicManager.GetLocalPartition(t, p); localPartition != nil {
+			fsync = localPartition.Fsync.Load()
+			totals = &localPartition.Totals
+		}
+
+		// the running totals are saved with the log file
+		messages, bytes := logstore.CountLogRecords(buf)
 		for {
-			if err := b.appendToFile(targetFile, buf); err != nil {
+			err := b.loadPartitionTotals(t, p, totals)
+			if err == nil {
+				totalMessages, totalBytes, _ := totals.Get()
+				err = b.appendToFile(targetFile, segment, fsync, totalMessages+messages, totalBytes+bytes, stopTime.UnixNano())
+			}
+			if err != nil {
 				glog.V(0).Infof("metadata log write failed %s: %v", targetFile, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 				time.Sleep(737 * time.Millisecond)
 			} else {
 				break
 			}
 		}
 
+		totals.Add(messages, bytes)
 		atomic.StoreInt64(&logBuffer.LastFlushTsNs, stopTime.UnixNano())
 
 		b.accessLock.Lock()
@@ -0,0 +1,95 @@
+package broker
+
+import (
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/logstore"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	topicRetentionCheckInterval = 10 * time.Minute
+	defaultCompactionWindow     = 24 * time.Hour
+)
+
+// loopDeleteExpiredTopicFiles deletes the messages older than the topic retention,
+// and compacts the messages older than the compaction window by key, only on the balancer
+func (b *MessageQueueBroker) loopDeleteExpiredTopicFiles() {
+	for {
+		time.Sleep(topicRetentionCheckInterval)
+		if b.lockAsBalancer == nil || !b.isLockOwner() || b.isStopping() {
+			continue
+		}
+		b.deleteExpiredTopicFiles()
+	}
+}
+
+func (b *MessageQueueBroker) deleteExpiredTopicFiles() {
+	var namespaces []string
+	if err := filer_pb.ReadDirAllEntries(b, util.FullPath(filer.TopicsDir), "", func(entry *filer_pb.Entry, isLast bool) error {
+		if entry.IsDirectory {
+			namespaces = append(namespaces, entry.Name)
+		}
+		return nil
+	}); err != nil {
+		glog.V(0).Infof("list namespaces: %v", err)
+		return
+	}
+
+	var topics []topic.Topic
+	for _, namespace := range namespaces {
+		if err := filer_pb.ReadDirAllEntries(b, util.FullPath(filer.TopicsDir).Child(namespace), "", func(entry *filer_pb.Entry, isLast bool) error {
+			if entry.IsDirectory {
+				topics = append(topics, topic.NewTopic(namespace, entry.Name))
+			}
+			return nil
+		}); err != nil {
+			glog.V(0).Infof("list topics in namespace %s: %v", namespace, err)
+		}
+	}
+
+	for _, t := range topics {
+		conf, err := b.fca.ReadTopicConfFromFiler(t)
+		if err != nil {
+			continue
+		}
+		if conf.RetentionSeconds > 0 {
+			deletedCount, err := logstore.DeleteExpiredTopicFiles(b, t, time.Duration(conf.RetentionSeconds)*time.Second)
+			if err != nil {
+				glog.Errorf("delete expired files of topic %s: %v", t, err)
+			}
+			if deletedCount > 0 {
+				glog.V(0).Infof("deleted %d files of topic %s older than %ds", deletedCount, t, conf.RetentionSeconds)
+			}
+		}
+		if conf.Compact {
+			b.compactTopicFilesByKey(t, conf)
+		}
+	}
+}
+
+// compactTopicFilesByKey keeps the full history within the compaction window, and only the latest message of each key before it
+func (b *MessageQueueBroker) compactTopicFilesByKey(t topic.Topic, conf *mq_pb.ConfigureTopicResponse) {
+	window := defaultCompactionWindow
+	if conf.CompactionWindowSeconds > 0 {
+		window = time.Duration(conf.CompactionWindowSeconds) * time.Second
+	}
+	removedCount, err := logstore.CompactTopicFilesByKey(b, t, window, func(targetFile string, segment []byte) (*filer_pb.FileChunk, error) {
+		fileId, uploadResult, err := b.assignAndUpload(targetFile, segment, conf.Fsync)
+		if err != nil {
+			return nil, err
+		}
+		return uploadResult.ToPbFileChunk(fileId, 0, time.Now().UnixNano()), nil
+	})
+	if err != nil {
+		glog.Errorf("compact files of topic %s: %v", t, err)
+	}
+	if removedCount > 0 {
+		glog.V(0).Infof("compacted %d messages of topic %s older than %v", removedCount, t, window)
+	}
+}
@@ -0,0 +1,174 @@
+package broker
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	samplingRulesRefreshInterval = 30 * time.Second
+	sampledMessagesQueueSize     = 1024
+)
+
+// topicSampler mirrors the sampled messages published to the topic partitions led by this broker.
+// The sampling is best effort: the messages are dropped if the sample topic can not keep up.
+type topicSampler struct {
+	t         topic.Topic
+	rules     atomic.Pointer[samplingRules]
+	loadedAt  atomic.Int64
+	isLoading atomic.Bool
+}
+
+// samplingRules is replaced as a whole when the rules change, the mirrors are aligned with the rules
+type samplingRules struct {
+	rules   []*mq_pb.SamplingRule
+	mirrors []*sampleMirror
+}
+
+type sampleMirror struct {
+	sampleTopic topic.Topic
+	messages    chan *mq_pb.DataMessage
+	stopCh      chan struct{}
+}
+
+// getTopicSampler returns the sampler of the topic, loading the sampling rules if not yet
+func (b *MessageQueueBroker) getTopicSampler(t topic.Topic) *topicSampler {
+	b.samplersLock.Lock()
+	sampler, found := b.samplers[t]
+	if !found {
+		sampler = &topicSampler{t: t}
+		sampler.rules.Store(&samplingRules{})
+		b.samplers[t] = sampler
+	}
+	b.samplersLock.Unlock()
+
+	if !found && sampler.isLoading.CompareAndSwap(false, true) {
+		b.loadSamplingRules(sampler)
+	}
+	return sampler
+}
+
+// sampleMessage copies the message to the sample topics of the matched rules
+func (b *MessageQueueBroker) sampleMessage(sampler *topicSampler, message *mq_pb.DataMessage) {
+	if time.Since(time.Unix(0, sampler.loadedAt.Load())) > samplingRulesRefreshInterval && sampler.isLoading.CompareAndSwap(false, true) {
+		go b.loadSamplingRules(sampler)
+	}
+	current := sampler.rules.Load()
+	for i, rule := range current.rules {
+		if !topic.IsSampled(rule, message.Key) {
+			continue
+		}
+		mirror := current.mirrors[i]
+		select {
+		case mirror.messages <- proto.Clone(message).(*mq_pb.DataMessage):
+			stats.MqSampledMessagesCounter.WithLabelValues(sampler.t.String(), mirror.sampleTopic.String(), "mirrored").Inc()
+		default:
+			stats.MqSampledMessagesCounter.WithLabelValues(sampler.t.String(), mirror.sampleTopic.String(), "dropped").Inc()
+		}
+	}
+}
+
+// loadSamplingRules reads the sampling rules from the topic configuration,
+// starting the mirrors of the added sample topics and stopping the removed ones
+func (b *MessageQueueBroker) loadSamplingRules(sampler *topicSampler) {
+	defer sampler.isLoading.Store(false)
+	defer sampler.loadedAt.Store(time.Now().UnixNano())
+
+	conf, err := b.fca.ReadTopicConfFromFiler(sampler.t)
+	if err != nil {
+		glog.V(1).Infof("read topic %s sampling rules: %v", sampler.t, err)
+		return
+	}
+
+	b.samplersLock.Lock()
+	defer b.samplersLock.Unlock()
+	if b.samplers[sampler.t] != sampler {
+		// stopped
+		return
+	}
+
+	previous := sampler.rules.Load()
+	existingMirrors := make(map[topic.Topic]*sampleMirror)
+	for _, mirror := range previous.mirrors {
+		existingMirrors[mirror.sampleTopic] = mirror
+	}
+	next := &samplingRules{}
+	for _, rule := range conf.SamplingRules {
+		sampleTopic := topic.SampleTopic(sampler.t, rule)
+		mirror, found := existingMirrors[sampleTopic]
+		if found {
+			delete(existingMirrors, sampleTopic)
+		} else {
+			mirror = &sampleMirror{
+				sampleTopic: sampleTopic,
+				messages:    make(chan *mq_pb.DataMessage, sampledMessagesQueueSize),
+				stopCh:      make(chan struct{}),
+			}
+			go b.runSampleMirror(sampler.t, conf, mirror)
+			glog.V(0).Infof("sample topic %s to %s with ratio %v", sampler.t, sampleTopic, rule.Ratio)
+		}
+		next.rules = append(next.rules, rule)
+		next.mirrors = append(next.mirrors, mirror)
+	}
+	sampler.rules.Store(next)
+
+	for _, mirror := range existingMirrors {
+		glog.V(0).Infof("stop sampling topic %s to %s", sampler.t, mirror.sampleTopic)
+		close(mirror.stopCh)
+	}
+}
+
+// runSampleMirror publishes the sampled messages to the sample topic, keeping their timestamps.
+// The sample topic is created if not exists, with the schema and compression dictionaries of the sampled topic.
+func (b *MessageQueueBroker) runSampleMirror(t topic.Topic, conf *mq_pb.ConfigureTopicResponse, mirror *sampleMirror) {
+	// keep the partitions of an existing sample topic, since configuring a different partition count re-assigns them
+	partitionCount := int32(1)
+	if sampleConf, err := b.fca.ReadTopicConfFromFiler(mirror.sampleTopic); err == nil && len(sampleConf.BrokerPartitionAssignments) > 0 {
+		partitionCount = int32(len(sampleConf.BrokerPartitionAssignments))
+	}
+	publisher := pub_client.NewTopicPublisher(&pub_client.PublisherConfiguration{
+		Topic:                   mirror.sampleTopic,
+		PartitionCount:          partitionCount,
+		Brokers:                 []string{string(b.option.BrokerAddress())},
+		PublisherName:           fmt.Sprintf("%s-sampler-%s", b.option.BrokerAddress(), t),
+		RecordType:              conf.RecordType,
+		CompressionDictionaries: conf.CompressionDictionaries,
+		GrpcDialOption:          b.grpcDialOption,
+	})
+	defer func() {
+		publisher.FinishPublish()
+		publisher.Shutdown()
+	}()
+
+	for {
+		select {
+		case <-mirror.stopCh:
+			return
+		case message := <-mirror.messages:
+			if err := publisher.PublishDataMessage(message); err != nil {
+				glog.V(1).Infof("mirror topic %s to %s: %v", t, mirror.sampleTopic, err)
+			}
+		}
+	}
+}
+
+// stopTopicSamplers stops all mirrors, the sampled messages not yet mirrored are dropped
+func (b *MessageQueueBroker) stopTopicSamplers() {
+	b.samplersLock.Lock()
+	defer b.samplersLock.Unlock()
+	for t, sampler := range b.samplers {
+		for _, mirror := range sampler.rules.Load().mirrors {
+			close(mirror.stopCh)
+		}
+		sampler.rules.Store(&samplingRules{})
+		delete(b.samplers, t)
+	}
+}
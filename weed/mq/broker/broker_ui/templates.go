@@ -0,0 +1,37 @@
+package broker_ui
+
+import (
+	_ "embed"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func join(data []int64) string {
+	var ret []string
+	for _, d := range data {
+		ret = append(ret, strconv.FormatInt(d, 10))
+	}
+	return strings.Join(ret, ",")
+}
+
+func timestamp(tsNs int64) string {
+	if tsNs == 0 {
+		return "-"
+	}
+	return time.Unix(0, tsNs).Format(time.RFC3339)
+}
+
+var funcMap = template.FuncMap{
+	"join":                 join,
+	"bytesToHumanReadable": util.BytesToHumanReadable,
+	"timestamp":            timestamp,
+}
+
+//go:embed broker.html
+var brokerHtml string
+
+var StatusTpl = template.Must(template.New("status").Funcs(funcMap).Parse(brokerHtml))
@@ -3,6 +3,8 @@ package broker
 import (
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/mq/logstore"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -10,9 +12,16 @@ import (
 	"time"
 )
 
-func (b *MessageQueueBroker) appendToFile(targetFile string, data []byte) error {
+// appendToFile appends the log segment to the partition log file, encrypted if configured,
+// and keeps the totals of the partition up to the end of the segment with the log file
+func (b *MessageQueueBroker) appendToFile(targetFile string, data []byte, fsync bool, totalMessages, totalBytes, lastTsNs int64) error {
 
-	fileId, uploadResult, err2 := b.assignAndUpload(targetFile, data)
+	data, err := logstore.EncryptLogSegment(data)
+	if err != nil {
+		return err
+	}
+
+	fileId, uploadResult, err2 := b.assignAndUpload(targetFile, data, fsync)
 	if err2 != nil {
 		return err2
 	}
@@ -42,6 +51,8 @@ func (b *MessageQueueBroker) appendToFile(targetFile string, data []byte) error
 
 	// append to existing chunks
 	entry.Chunks = append(entry.GetChunks(), uploadResult.ToPbFileChunk(fileId, offset, time.Now().UnixNano()))
+	logstore.SetLogFileTotals(entry, totalMessages, totalBytes)
+	logstore.SetLogFileLastTsNs(entry, lastTsNs)
 
 	// update the entry
 	return b.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
@@ -52,7 +63,14 @@ func (b *MessageQueueBroker) appendToFile(targetFile string, data []byte) error
 	})
 }
 
-func (b *MessageQueueBroker) assignAndUpload(targetFile string, data []byte) (fileId string, uploadResult *operation.UploadResult, err error) {
+// loadPartitionTotals loads the totals of the partition saved with its log files, if not loaded yet
+func (b *MessageQueueBroker) loadPartitionTotals(t topic.Topic, p topic.Partition, totals *topic.PartitionTotals) error {
+	return totals.Load(func() (messages, bytes int64, err error) {
+		return logstore.ReadPartitionTotals(b, topic.PartitionDir(t, p))
+	})
+}
+
+func (b *MessageQueueBroker) assignAndUpload(targetFile string, data []byte, fsync bool) (fileId string, uploadResult *operation.UploadResult, err error) {
 
 	reader := util.NewBytesReader(data)
 
@@ -80,6 +98,10 @@ func (b *MessageQueueBroker) assignAndUpload(targetFile string, data []byte) (fi
 			if b.option.VolumeServerAccess == "filerProxy" {
 				fileUrl = fmt.Sprintf("http://%s/?proxyChunkId=%s", b.currentFiler, fileId)
 			}
+			if fsync && b.option.VolumeServerAccess != "filerProxy" {
+				// the filer proxy does not pass on the query
+				fileUrl += "?fsync=true"
+			}
 			return fileUrl
 		},
 		reader,
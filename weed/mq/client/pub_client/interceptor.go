@@ -0,0 +1,31 @@
+package pub_client
+
+import (
+	"context"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+// PublishHandler buffers the message to send to the partition leader
+type PublishHandler func(ctx context.Context, message *mq_pb.DataMessage) error
+
+// PublishInterceptor is called for each published message before it is buffered, like a grpc client interceptor.
+// It can change the key, value, and headers, e.g., to encrypt the value or to add the tracing headers,
+// return an error to reject the message, e.g., failing the schema validation, or observe the result of next,
+// which must be called to publish the message. The values are compressed after the interceptors.
+type PublishInterceptor func(ctx context.Context, message *mq_pb.DataMessage, next PublishHandler) error
+
+// AckInterceptor is called once for each published message when it is acked by the partition leader,
+// or with the error if it can not be published after the retries, before the PublishCallback if any
+type AckInterceptor func(message *mq_pb.DataMessage, err error)
+
+// ChainPublishInterceptors runs the interceptors in order, the first one being the outermost
+func ChainPublishInterceptors(interceptors []PublishInterceptor, handler PublishHandler) PublishHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, message *mq_pb.DataMessage) error {
+			return interceptor(ctx, message, next)
+		}
+	}
+	return handler
+}
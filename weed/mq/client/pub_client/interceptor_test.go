@@ -0,0 +1,64 @@
+package pub_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+func TestChainPublishInterceptors(t *testing.T) {
+	var calls []string
+	trace := func(name string) PublishInterceptor {
+		return func(ctx context.Context, message *mq_pb.DataMessage, next PublishHandler) error {
+			calls = append(calls, name+">")
+			message.Value = append(message.Value, name...)
+			err := next(ctx, message)
+			calls = append(calls, "<"+name)
+			return err
+		}
+	}
+	handler := ChainPublishInterceptors([]PublishInterceptor{trace("a"), trace("b")}, func(ctx context.Context, message *mq_pb.DataMessage) error {
+		calls = append(calls, "publish "+string(message.Value))
+		return nil
+	})
+	if err := handler(context.Background(), &mq_pb.DataMessage{}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if fmt.Sprint(calls) != "[a> b> publish ab <b <a]" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+
+	rejected := errors.New("invalid schema")
+	handler = ChainPublishInterceptors([]PublishInterceptor{func(ctx context.Context, message *mq_pb.DataMessage, next PublishHandler) error {
+		return rejected
+	}}, func(ctx context.Context, message *mq_pb.DataMessage) error {
+		t.Errorf("the rejected message should not be published")
+		return nil
+	})
+	if err := handler(context.Background(), &mq_pb.DataMessage{}); err != rejected {
+		t.Errorf("expected the rejection, got %v", err)
+	}
+}
+
+func TestAckInterceptors(t *testing.T) {
+	var acked, failed int
+	p := &TopicPublisher{
+		config: &PublisherConfiguration{Topic: topic.NewTopic("test", "t"), AckInterceptors: []AckInterceptor{func(message *mq_pb.DataMessage, err error) {
+			if err != nil {
+				failed++
+			} else {
+				acked++
+			}
+		}}},
+	}
+	p.complete(&pendingMessage{DataMessage: &mq_pb.DataMessage{TsNs: 1}}, nil)
+	p.complete(&pendingMessage{DataMessage: &mq_pb.DataMessage{TsNs: 2}}, errors.New("failed"))
+	p.complete(&pendingMessage{DataMessage: &mq_pb.DataMessage{TsNs: 3, Ctrl: &mq_pb.ControlMessage{IsClose: true}}}, nil)
+	if acked != 1 || failed != 1 {
+		t.Errorf("acked %d failed %d, expected 1 and 1", acked, failed)
+	}
+}
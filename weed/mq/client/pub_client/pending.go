@@ -0,0 +1,125 @@
+package pub_client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+const defaultMaxRetries = 3
+
+// ErrMessageTooLarge fails the messages rejected by the broker for the max message size of the topic, without retries
+var ErrMessageTooLarge = errors.New("message too large")
+
+// PublishCallback is called once the message is acked by the partition leader,
+// or with the error if the message can not be published after the retries
+type PublishCallback func(message *mq_pb.DataMessage, err error)
+
+// pendingMessage is a message buffered to send, or sent but not acked yet
+type pendingMessage struct {
+	*mq_pb.DataMessage
+	callback PublishCallback
+	retries  int
+	inFlight bool // holds a slot of the in flight messages
+}
+
+// wakeUpMessage unblocks the partition job waiting for more messages, after its broker connection fails
+var wakeUpMessage = &pendingMessage{}
+
+func (p *TopicPublisher) maxRetries() int {
+	if p.config.MaxRetries > 0 {
+		return p.config.MaxRetries
+	}
+	return defaultMaxRetries
+}
+
+// acquireInFlight blocks while MaxInFlightMessages messages are buffered or not acked yet
+func (p *TopicPublisher) acquireInFlight(message *pendingMessage) {
+	p.acquireInFlightUntil(message, nil)
+}
+
+// acquireInFlightUntil is acquireInFlight giving up once stopped, a nil stop never stops
+func (p *TopicPublisher) acquireInFlightUntil(message *pendingMessage, stop <-chan struct{}) bool {
+	if p.inFlight == nil || message.Ctrl != nil {
+		return true
+	}
+	select {
+	case p.inFlight <- struct{}{}:
+		message.inFlight = true
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// releaseInFlight releases the in flight slot of the message, if any
+func (p *TopicPublisher) releaseInFlight(message *pendingMessage) {
+	if message.inFlight {
+		message.inFlight = false
+		<-p.inFlight
+	}
+}
+
+// complete calls back the message, and releases its in flight slot
+func (p *TopicPublisher) complete(message *pendingMessage, err error) {
+	p.releaseInFlight(message)
+	if message.DataMessage != nil && message.Ctrl == nil {
+		for _, onAck := range p.config.AckInterceptors {
+			onAck(message.DataMessage, err)
+		}
+	}
+	if message.callback != nil {
+		message.callback(message.DataMessage, err)
+	}
+}
+
+// addUnacked tracks the messages sent to the partition leader, in the sending order
+func (job *EachPartitionPublishJob) addUnacked(messages ...*pendingMessage) {
+	job.unackedLock.Lock()
+	defer job.unackedLock.Unlock()
+	job.unacked = append(job.unacked, messages...)
+}
+
+// takeUnacked returns the messages not acked yet, e.g., to send them again to the new partition leader
+func (job *EachPartitionPublishJob) takeUnacked() (messages []*pendingMessage) {
+	job.unackedLock.Lock()
+	defer job.unackedLock.Unlock()
+	messages, job.unacked = job.unacked, nil
+	return
+}
+
+// ack removes the messages acked by the partition leader, which acks the timestamp of the last received message
+func (job *EachPartitionPublishJob) ack(ackTsNs int64) (acked []*pendingMessage) {
+	job.unackedLock.Lock()
+	defer job.unackedLock.Unlock()
+	i := 0
+	for ; i < len(job.unacked) && job.unacked[i].TsNs <= ackTsNs; i++ {
+	}
+	acked = job.unacked[:i:i]
+	job.unacked = job.unacked[i:]
+	return
+}
+
+// reject removes the message rejected by the partition leader, so it is not sent again
+func (job *EachPartitionPublishJob) reject(tsNs int64) *pendingMessage {
+	job.unackedLock.Lock()
+	defer job.unackedLock.Unlock()
+	for i, message := range job.unacked {
+		if message.TsNs == tsNs {
+			job.unacked = append(job.unacked[:i], job.unacked[i+1:]...)
+			return message
+		}
+	}
+	return nil
+}
+
+// retry counts the resending of the message, and fails it if retried too many times
+func (p *TopicPublisher) retry(message *pendingMessage, lastErr error) bool {
+	message.retries++
+	if message.retries > p.maxRetries() {
+		p.complete(message, fmt.Errorf("publish to topic %s after %d retries: %v", p.config.Topic, p.maxRetries(), lastErr))
+		return false
+	}
+	return true
+}
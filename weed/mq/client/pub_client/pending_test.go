@@ -0,0 +1,82 @@
+package pub_client
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+func TestPendingMessagesAck(t *testing.T) {
+	p := &TopicPublisher{
+		config:   &PublisherConfiguration{Topic: topic.NewTopic("test", "t"), MaxInFlightMessages: 3},
+		inFlight: make(chan struct{}, 3),
+	}
+	job := &EachPartitionPublishJob{}
+
+	var acked []int64
+	var failed []error
+	callback := func(message *mq_pb.DataMessage, err error) {
+		if err != nil {
+			failed = append(failed, err)
+			return
+		}
+		acked = append(acked, message.TsNs)
+	}
+	for tsNs := int64(1); tsNs <= 3; tsNs++ {
+		message := &pendingMessage{DataMessage: &mq_pb.DataMessage{TsNs: tsNs}, callback: callback}
+		p.acquireInFlight(message)
+		job.addUnacked(message)
+	}
+	if len(p.inFlight) != 3 {
+		t.Fatalf("in flight %d, expected 3", len(p.inFlight))
+	}
+
+	for _, message := range job.ack(2) {
+		p.complete(message, nil)
+	}
+	if fmt.Sprint(acked) != "[1 2]" || len(p.inFlight) != 1 {
+		t.Fatalf("acked %v with %d in flight", acked, len(p.inFlight))
+	}
+
+	// the message not acked is resent to the new connection, until the retries run out
+	unacked := job.takeUnacked()
+	if len(unacked) != 1 || len(job.takeUnacked()) != 0 {
+		t.Fatalf("unacked %d", len(unacked))
+	}
+	for i := 0; i < defaultMaxRetries; i++ {
+		if !p.retry(unacked[0], fmt.Errorf("broken")) {
+			t.Fatalf("retry %d should be allowed", i+1)
+		}
+	}
+	if p.retry(unacked[0], fmt.Errorf("broken")) {
+		t.Fatalf("retries should run out")
+	}
+	if len(failed) != 1 || len(p.inFlight) != 0 {
+		t.Fatalf("failed %v with %d in flight", failed, len(p.inFlight))
+	}
+}
+
+func TestPendingMessagesReject(t *testing.T) {
+	job := &EachPartitionPublishJob{}
+	for tsNs := int64(1); tsNs <= 3; tsNs++ {
+		job.addUnacked(&pendingMessage{DataMessage: &mq_pb.DataMessage{TsNs: tsNs}})
+	}
+
+	if message := job.reject(2); message == nil || message.TsNs != 2 {
+		t.Fatalf("rejected %v", message)
+	}
+	if message := job.reject(2); message != nil {
+		t.Fatalf("rejected twice")
+	}
+
+	// the other messages are still sent again
+	var resending []int64
+	for _, message := range job.takeUnacked() {
+		resending = append(resending, message.TsNs)
+	}
+	if fmt.Sprint(resending) != "[1 3]" {
+		t.Fatalf("resending %v", resending)
+	}
+}
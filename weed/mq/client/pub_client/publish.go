@@ -1,37 +1,126 @@
 package pub_client
 
 import (
+	"context"
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/seaweedfs/seaweedfs/weed/mq/pub_balancer"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
-	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"time"
 )
 
 func (p *TopicPublisher) Publish(key, value []byte) error {
+	return p.PublishWithContext(context.Background(), key, value)
+}
+
+// PublishWithContext continues the trace in ctx, if any, and carries the trace context to the subscribers in the message headers
+func (p *TopicPublisher) PublishWithContext(ctx context.Context, key, value []byte) error {
+	if p.config.RecordType != nil {
+		return fmt.Errorf("record type is set, use PublishRecord instead")
+	}
+	return p.doPublish(ctx, key, value, "", nil)
+}
+
+// PublishAsync returns once the message is buffered, and the callback is called when the message is acked by the broker,
+// or failed after the retries. It blocks while MaxInFlightMessages messages are buffered or not acked yet.
+func (p *TopicPublisher) PublishAsync(key, value []byte, callback PublishCallback) error {
+	if p.config.RecordType != nil {
+		return fmt.Errorf("record type is set, use PublishRecordAsync instead")
+	}
+	return p.doPublish(context.Background(), key, value, "", callback)
+}
+
+// PublishWithIdempotencyKey lets the broker drop the message if the same idempotency key is published recently,
+// e.g., when retrying without monotonic sequence numbers. The retries must use the same message key,
+// since the keys are only checked within the partition of the message key.
+func (p *TopicPublisher) PublishWithIdempotencyKey(key, value []byte, idempotencyKey string) error {
 	if p.config.RecordType != nil {
 		return fmt.Errorf("record type is set, use PublishRecord instead")
 	}
-	return p.doPublish(key, value)
+	return p.doPublish(context.Background(), key, value, idempotencyKey, nil)
 }
 
-func (p *TopicPublisher) doPublish(key, value []byte) error {
-	hashKey := util.HashToInt32(key) % pub_balancer.MaxPartitionCount
-	if hashKey < 0 {
-		hashKey = -hashKey
+func (p *TopicPublisher) doPublish(ctx context.Context, key, value []byte, idempotencyKey string, callback PublishCallback) error {
+	ctx, span := tracing.Tracer().Start(ctx, "mq.publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(tracing.MessagingAttributes(p.config.Topic.String(), "")...),
+		trace.WithAttributes(attribute.Int("messaging.message.body.size", len(value))))
+	defer span.End()
+
+	message := &mq_pb.DataMessage{
+		Key:     key,
+		Value:   value,
+		TsNs:    time.Now().UnixNano(),
+		Headers: tracing.InjectHeaders(ctx, nil),
+
+		IdempotencyKey: idempotencyKey,
+	}
+
+	err := ChainPublishInterceptors(p.config.PublishInterceptors, func(ctx context.Context, message *mq_pb.DataMessage) error {
+		// the broker reads the record values when compacting into parquet files
+		if p.config.RecordType == nil {
+			p.compressor.Compress(message)
+		}
+		return p.enqueue(message, callback)
+	})(ctx, message)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
-	inputBuffer, found := p.partition2Buffer.Floor(hashKey+1, hashKey+1)
+	return err
+}
+
+// PublishDataMessage publishes a message keeping its timestamp and origin, e.g., when mirroring from another cluster
+func (p *TopicPublisher) PublishDataMessage(message *mq_pb.DataMessage) error {
+	return p.PublishDataMessageAsync(message, nil)
+}
+
+// PublishDataMessageAsync is PublishDataMessage calling back when the message is acked by the broker, like PublishAsync
+func (p *TopicPublisher) PublishDataMessageAsync(message *mq_pb.DataMessage, callback PublishCallback) error {
+	if message.Ctrl != nil {
+		return fmt.Errorf("control message is not allowed")
+	}
+	if message.TsNs == 0 {
+		message.TsNs = time.Now().UnixNano()
+	}
+	return p.enqueue(message, callback)
+}
+
+// enqueue buffers the message for its partition, or spools it while the brokers are unreachable
+func (p *TopicPublisher) enqueue(message *mq_pb.DataMessage, callback PublishCallback) error {
+	if p.spool != nil && (p.spool.isDraining() || !p.isConnected()) {
+		return p.spoolMessage(message, callback)
+	}
+	return p.enqueueToPartition(message, callback, nil)
+}
+
+// enqueueToPartition buffers the message for its partition, and gives up waiting for the in flight slot once stopped
+func (p *TopicPublisher) enqueueToPartition(message *mq_pb.DataMessage, callback PublishCallback, stop <-chan struct{}) error {
+	hashKey := topic.KeyHash(message.Key, p.ringSize)
+	inputBuffers, found := p.partition2Buffer.AllIntersections(hashKey, hashKey)
 	if !found {
 		return fmt.Errorf("no input buffer found for key %d", hashKey)
 	}
+	inputBuffer := inputBuffers[0]
 
-	return inputBuffer.Enqueue(&mq_pb.DataMessage{
-		Key:   key,
-		Value: value,
-		TsNs:  time.Now().UnixNano(),
-	})
+	pending := &pendingMessage{
+		DataMessage: message,
+		callback:    callback,
+	}
+	if !p.acquireInFlightUntil(pending, stop) {
+		return fmt.Errorf("publisher of topic %s is shut down", p.config.Topic)
+	}
+	if err := inputBuffer.Enqueue(pending); err != nil {
+		// not to call back, since the error is returned
+		p.releaseInFlight(pending)
+		return err
+	}
+	return nil
 }
 
 func (p *TopicPublisher) PublishRecord(key []byte, recordValue *schema_pb.RecordValue) error {
@@ -41,17 +130,29 @@ func (p *TopicPublisher) PublishRecord(key []byte, recordValue *schema_pb.Record
 		return fmt.Errorf("failed to marshal record value: %v", err)
 	}
 
-	return p.doPublish(key, value)
+	return p.doPublish(context.Background(), key, value, "", nil)
+}
+
+// PublishRecordAsync is PublishAsync for the topics with a record type
+func (p *TopicPublisher) PublishRecordAsync(key []byte, recordValue *schema_pb.RecordValue, callback PublishCallback) error {
+	value, err := proto.Marshal(recordValue)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record value: %v", err)
+	}
+
+	return p.doPublish(context.Background(), key, value, "", callback)
 }
 
 func (p *TopicPublisher) FinishPublish() error {
 	if inputBuffers, found := p.partition2Buffer.AllIntersections(0, pub_balancer.MaxPartitionCount); found {
 		for _, inputBuffer := range inputBuffers {
-			inputBuffer.Enqueue(&mq_pb.DataMessage{
-				TsNs: time.Now().UnixNano(),
-				Ctrl: &mq_pb.ControlMessage{
-					IsClose:       true,
-					PublisherName: p.config.PublisherName,
+			inputBuffer.Enqueue(&pendingMessage{
+				DataMessage: &mq_pb.DataMessage{
+					TsNs: time.Now().UnixNano(),
+					Ctrl: &mq_pb.ControlMessage{
+						IsClose:       true,
+						PublisherName: p.config.PublisherName,
+					},
 				},
 			})
 		}
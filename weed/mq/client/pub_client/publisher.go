@@ -1,7 +1,9 @@
 package pub_client
 
 import (
+	"fmt"
 	"github.com/rdleal/intervalst/interval"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/mq/pub_balancer"
 	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
@@ -19,6 +21,23 @@ type PublisherConfiguration struct {
 	Brokers        []string
 	PublisherName  string // for debugging
 	RecordType     *schema_pb.RecordType
+	AckLevel       mq_pb.AckLevel // when the broker acks, the default waits for the follower broker if any
+	// compress the message values of at least this size with zstd, 0 to disable
+	CompressionThreshold int
+	// added to the topic, and the one with the largest id is used for compression
+	CompressionDictionaries []*mq_pb.CompressionDictionary
+	// to connect to the brokers, insecure if not set
+	GrpcDialOption grpc.DialOption
+	// block the publishing while this many messages are buffered or not acked yet, 0 for no limit
+	MaxInFlightMessages int
+	// send a message not acked yet again to the reconnected or the new partition leader up to this many times, default 3
+	MaxRetries int
+	// called in order for each message published, except by PublishDataMessage, e.g., for metrics or encryption
+	PublishInterceptors []PublishInterceptor
+	// called in order when each message is acked or failed
+	AckInterceptors []AckInterceptor
+	// spool the messages to the local disk while the brokers are unreachable, nil to buffer them in memory
+	Spool *SpoolConfiguration
 }
 
 type PublishClient struct {
@@ -27,20 +46,45 @@ type PublishClient struct {
 	Err    error
 }
 type TopicPublisher struct {
-	partition2Buffer *interval.SearchTree[*buffered_queue.BufferedQueue[*mq_pb.DataMessage], int32]
+	partition2Buffer *interval.SearchTree[*buffered_queue.BufferedQueue[*pendingMessage], int32]
 	grpcDialOption   grpc.DialOption
 	sync.Mutex       // protects grpc
 	config           *PublisherConfiguration
 	jobs             []*EachPartitionPublishJob
+	jobsLock         sync.RWMutex
+	ringSize         int32 // key hash ring size, from the topic lookup
+	compressor       *topic.MessageCompressor
+	inFlight         chan struct{} // the slots of the in flight messages, nil for no limit
+	spool            *diskSpool    // nil if not spooling
+	stopDrain        chan struct{}
+	drainWg          sync.WaitGroup
 }
 
 func NewTopicPublisher(config *PublisherConfiguration) *TopicPublisher {
 	tp := &TopicPublisher{
-		partition2Buffer: interval.NewSearchTree[*buffered_queue.BufferedQueue[*mq_pb.DataMessage]](func(a, b int32) int {
+		partition2Buffer: interval.NewSearchTree[*buffered_queue.BufferedQueue[*pendingMessage]](func(a, b int32) int {
 			return int(a - b)
 		}),
 		grpcDialOption: grpc.WithTransportCredentials(insecure.NewCredentials()),
 		config:         config,
+		ringSize:       pub_balancer.MaxPartitionCount,
+	}
+	if config.GrpcDialOption != nil {
+		tp.grpcDialOption = config.GrpcDialOption
+	}
+	if config.MaxInFlightMessages > 0 {
+		tp.inFlight = make(chan struct{}, config.MaxInFlightMessages)
+	}
+	if config.Spool != nil {
+		spool, err := openDiskSpool(*config.Spool)
+		if err != nil {
+			glog.Errorf("open publisher spool for topic %s: %v", config.Topic, err)
+		} else {
+			tp.spool = spool
+			tp.stopDrain = make(chan struct{})
+			tp.drainWg.Add(1)
+			go tp.loopDrainSpool()
+		}
 	}
 
 	wg := sync.WaitGroup{}
@@ -52,13 +96,23 @@ func NewTopicPublisher(config *PublisherConfiguration) *TopicPublisher {
 		}
 	}()
 
-	wg.Wait()
+	// the messages are spooled till the brokers are reachable
+	if tp.spool == nil {
+		wg.Wait()
+	}
 
 	return tp
 }
 
 func (p *TopicPublisher) Shutdown() error {
 
+	// the messages not drained yet stay in the spool for the next start
+	if p.spool != nil {
+		close(p.stopDrain)
+		p.drainWg.Wait()
+		defer p.spool.close()
+	}
+
 	if inputBuffers, found := p.partition2Buffer.AllIntersections(0, pub_balancer.MaxPartitionCount); found {
 		for _, inputBuffer := range inputBuffers {
 			inputBuffer.CloseInput()
@@ -69,5 +123,12 @@ func (p *TopicPublisher) Shutdown() error {
 		job.wg.Wait()
 	}
 
+	// the messages not acked by the brokers before the shutdown
+	for _, job := range p.jobs {
+		for _, message := range job.takeUnacked() {
+			p.complete(message, fmt.Errorf("publisher of topic %s is shut down", p.config.Topic))
+		}
+	}
+
 	return nil
 }
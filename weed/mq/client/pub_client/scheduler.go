@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util/buffered_queue"
@@ -22,18 +23,30 @@ type EachPartitionError struct {
 	generation int
 }
 
+const maxLeaderRedirects = 3
+
 type EachPartitionPublishJob struct {
 	*mq_pb.BrokerPartitionAssignment
 	stopChan   chan bool
 	wg         sync.WaitGroup
 	generation int
-	inputQueue *buffered_queue.BufferedQueue[*mq_pb.DataMessage]
+	inputQueue *buffered_queue.BufferedQueue[*pendingMessage]
+	stopped    atomic.Bool // the publishing to the partition leader is stopped, e.g., on errors
+	connected  atomic.Bool // connected to the partition leader
+
+	unacked     []*pendingMessage // sent to the partition leader, but not acked yet
+	unackedLock sync.Mutex
 }
 
 func (p *TopicPublisher) startSchedulerThread(wg *sync.WaitGroup) error {
 
-	if err := p.doConfigureTopic(); err != nil {
-		return fmt.Errorf("configure topic %s: %v", p.config.Topic, err)
+	for {
+		err := p.doConfigureTopic()
+		if err == nil {
+			break
+		}
+		glog.Errorf("configure topic %s: %v", p.config.Topic, err)
+		time.Sleep(5 * time.Second)
 	}
 
 	log.Printf("start scheduler thread for topic %s", p.config.Topic)
@@ -60,6 +73,7 @@ func (p *TopicPublisher) startSchedulerThread(wg *sync.WaitGroup) error {
 		}
 
 		// wait for any error to happen. If so, consume all remaining errors, and retry
+	waitForError:
 		for {
 			select {
 			case eachErr := <-errChan:
@@ -67,7 +81,7 @@ func (p *TopicPublisher) startSchedulerThread(wg *sync.WaitGroup) error {
 				if eachErr.generation < generation {
 					continue
 				}
-				break
+				break waitForError
 			}
 		}
 	}
@@ -84,10 +98,12 @@ func (p *TopicPublisher) onEachAssignments(generation int, assignments []*mq_pb.
 		if assignment.LeaderBroker == "" {
 			continue
 		}
+		var inputQueue *buffered_queue.BufferedQueue[*pendingMessage]
+		var unacked []*pendingMessage
 		if hasExistingJob {
 			var existingJob *EachPartitionPublishJob
 			existingJob = p.jobs[i]
-			if existingJob.BrokerPartitionAssignment.LeaderBroker == assignment.LeaderBroker {
+			if existingJob.BrokerPartitionAssignment.LeaderBroker == assignment.LeaderBroker && !existingJob.stopped.Load() {
 				existingJob.generation = generation
 				jobs = append(jobs, existingJob)
 				continue
@@ -97,6 +113,10 @@ func (p *TopicPublisher) onEachAssignments(generation int, assignments []*mq_pb.
 					existingJob.LeaderBroker = ""
 					existingJob.wg.Wait()
 				}
+				// the leader has moved, e.g., the old leader is shutting down, or the connection is broken.
+				// keep the buffered messages and the ones not acked, and continue with the new connection.
+				inputQueue = existingJob.inputQueue
+				unacked = existingJob.takeUnacked()
 			}
 		}
 
@@ -105,63 +125,40 @@ func (p *TopicPublisher) onEachAssignments(generation int, assignments []*mq_pb.
 			BrokerPartitionAssignment: assignment,
 			stopChan:                  make(chan bool, 1),
 			generation:                generation,
-			inputQueue:                buffered_queue.NewBufferedQueue[*mq_pb.DataMessage](1024),
+			inputQueue:                inputQueue,
+			unacked:                   unacked,
+		}
+		if job.inputQueue == nil {
+			job.inputQueue = buffered_queue.NewBufferedQueue[*pendingMessage](1024)
+			// the interval tree is inclusive, while RangeStop is exclusive
+			p.partition2Buffer.Insert(assignment.Partition.RangeStart, assignment.Partition.RangeStop-1, job.inputQueue)
 		}
 		job.wg.Add(1)
 		go func(job *EachPartitionPublishJob) {
 			defer job.wg.Done()
-			if err := p.doPublishToPartition(job); err != nil {
+			err := p.doPublishToPartition(job)
+			job.stopped.Store(true)
+			if err != nil {
 				errChan <- EachPartitionError{assignment, err, generation}
 			}
 		}(job)
 		jobs = append(jobs, job)
-		// TODO assuming this is not re-configured so the partitions are fixed.
-		// better just re-use the existing job
-		p.partition2Buffer.Insert(assignment.Partition.RangeStart, assignment.Partition.RangeStop, job.inputQueue)
 	}
+	p.jobsLock.Lock()
 	p.jobs = jobs
+	p.jobsLock.Unlock()
 }
 
 func (p *TopicPublisher) doPublishToPartition(job *EachPartitionPublishJob) error {
 
-	log.Printf("connecting to %v for topic partition %+v", job.LeaderBroker, job.Partition)
-
-	grpcConnection, err := pb.GrpcDial(context.Background(), job.LeaderBroker, true, p.grpcDialOption)
-	if err != nil {
-		return fmt.Errorf("dial broker %s: %v", job.LeaderBroker, err)
-	}
-	brokerClient := mq_pb.NewSeaweedMessagingClient(grpcConnection)
-	stream, err := brokerClient.PublishMessage(context.Background())
-	if err != nil {
-		return fmt.Errorf("create publish client: %v", err)
-	}
-	publishClient := &PublishClient{
-		SeaweedMessaging_PublishMessageClient: stream,
-		Broker:                                job.LeaderBroker,
-	}
-	if err = publishClient.Send(&mq_pb.PublishMessageRequest{
-		Message: &mq_pb.PublishMessageRequest_Init{
-			Init: &mq_pb.PublishMessageRequest_InitMessage{
-				Topic:          p.config.Topic.ToPbTopic(),
-				Partition:      job.Partition,
-				AckInterval:    128,
-				FollowerBroker: job.FollowerBroker,
-				PublisherName:  p.config.PublisherName,
-			},
-		},
-	}); err != nil {
-		return fmt.Errorf("send init message: %v", err)
-	}
-	// process the hello message
-	resp, err := stream.Recv()
+	publishClient, err := p.connectToPartitionLeader(job)
 	if err != nil {
-		return fmt.Errorf("recv init response: %v", err)
-	}
-	if resp.Error != "" {
-		return fmt.Errorf("init response error: %v", resp.Error)
+		return err
 	}
+	job.connected.Store(true)
+	defer job.connected.Store(false)
 
-	var publishedTsNs int64
+	var publishedTsNs, throttleUntilNs int64
 	hasMoreData := int32(1)
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -177,13 +174,36 @@ func (p *TopicPublisher) doPublishToPartition(job *EachPartitionPublishJob) erro
 				}
 				publishClient.Err = err
 				log.Printf("publish1 to %s error: %v\n", publishClient.Broker, err)
+				job.inputQueue.Enqueue(wakeUpMessage)
 				return
 			}
 			if ackResp.Error != "" {
+				// fail the rejected message, and send the others again after reconnecting
+				if ackResp.ErrorCode == mq_pb.PublishErrorCode_PUBLISH_ERROR_MESSAGE_TOO_LARGE {
+					if message := job.reject(ackResp.RejectedTsNs); message != nil {
+						p.complete(message, fmt.Errorf("%w: %s", ErrMessageTooLarge, ackResp.Error))
+					}
+				}
 				publishClient.Err = fmt.Errorf("ack error: %v", ackResp.Error)
 				log.Printf("publish2 to %s error: %v\n", publishClient.Broker, ackResp.Error)
+				job.inputQueue.Enqueue(wakeUpMessage)
+				return
+			}
+			if ackResp.AckSequence > 0 {
+				for _, message := range job.ack(ackResp.AckSequence) {
+					p.complete(message, nil)
+				}
+			}
+			if ackResp.ShouldClose {
+				// the broker is shutting down, and the partition leadership is moved to another broker
+				publishClient.Err = fmt.Errorf("broker %s asked to close", publishClient.Broker)
+				log.Printf("publish to %s: should close", publishClient.Broker)
+				job.inputQueue.Enqueue(wakeUpMessage)
 				return
 			}
+			if ackResp.ThrottleMs > 0 {
+				atomic.StoreInt64(&throttleUntilNs, time.Now().Add(time.Duration(ackResp.ThrottleMs)*time.Millisecond).UnixNano())
+			}
 			if ackResp.AckSequence > 0 {
 				log.Printf("ack %d published %d hasMoreData:%d", ackResp.AckSequence, atomic.LoadInt64(&publishedTsNs), atomic.LoadInt32(&hasMoreData))
 			}
@@ -193,15 +213,52 @@ func (p *TopicPublisher) doPublishToPartition(job *EachPartitionPublishJob) erro
 		}
 	}()
 
+	// send the messages not acked by the previous connection first
+	resending := job.takeUnacked()
+	defer func() {
+		// keep the remaining ones for the next connection
+		job.addUnacked(resending...)
+	}()
+
 	publishCounter := 0
-	for data, hasData := job.inputQueue.Dequeue(); hasData; data, hasData = job.inputQueue.Dequeue() {
+	for {
+		// stop before taking more messages, so the remaining ones go to the new leader
+		if publishClient.Err != nil {
+			return publishClient.Err
+		}
+		var data *pendingMessage
+		if len(resending) > 0 {
+			data, resending = resending[0], resending[1:]
+			if !p.retry(data, fmt.Errorf("not acked by %s", job.LeaderBroker)) {
+				continue
+			}
+		} else {
+			var hasData bool
+			if data, hasData = job.inputQueue.Dequeue(); !hasData {
+				break
+			}
+			if data == wakeUpMessage {
+				continue
+			}
+		}
+		if publishClient.Err != nil {
+			resending = append([]*pendingMessage{data}, resending...)
+			return publishClient.Err
+		}
 		if data.Ctrl != nil && data.Ctrl.IsClose {
 			// need to set this before sending to brokers, to avoid timing issue
 			atomic.StoreInt32(&hasMoreData, 0)
 		}
+		// the broker asked to back off
+		if throttleUntil := atomic.LoadInt64(&throttleUntilNs); throttleUntil > 0 {
+			time.Sleep(time.Until(time.Unix(0, throttleUntil)))
+			atomic.CompareAndSwapInt64(&throttleUntilNs, throttleUntil, 0)
+		}
+		// tracked before sending, so the ack always finds it
+		job.addUnacked(data)
 		if err := publishClient.Send(&mq_pb.PublishMessageRequest{
 			Message: &mq_pb.PublishMessageRequest_Data{
-				Data: data,
+				Data: data.DataMessage,
 			},
 		}); err != nil {
 			return fmt.Errorf("send publish data: %v", err)
@@ -223,6 +280,61 @@ func (p *TopicPublisher) doPublishToPartition(job *EachPartitionPublishJob) erro
 	return nil
 }
 
+// connectToPartitionLeader connects to the partition leader, following the redirects if the leader has moved
+func (p *TopicPublisher) connectToPartitionLeader(job *EachPartitionPublishJob) (*PublishClient, error) {
+	for redirectCount := 0; ; redirectCount++ {
+
+		log.Printf("connecting to %v for topic partition %+v", job.LeaderBroker, job.Partition)
+
+		grpcConnection, err := pb.GrpcDial(context.Background(), job.LeaderBroker, true, p.grpcDialOption)
+		if err != nil {
+			return nil, fmt.Errorf("dial broker %s: %v", job.LeaderBroker, err)
+		}
+		brokerClient := mq_pb.NewSeaweedMessagingClient(grpcConnection)
+		stream, err := brokerClient.PublishMessage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("create publish client: %v", err)
+		}
+		publishClient := &PublishClient{
+			SeaweedMessaging_PublishMessageClient: stream,
+			Broker:                                job.LeaderBroker,
+		}
+		if err = publishClient.Send(&mq_pb.PublishMessageRequest{
+			Message: &mq_pb.PublishMessageRequest_Init{
+				Init: &mq_pb.PublishMessageRequest_InitMessage{
+					Topic:          p.config.Topic.ToPbTopic(),
+					Partition:      job.Partition,
+					AckInterval:    128,
+					FollowerBroker: job.FollowerBroker,
+					PublisherName:  p.config.PublisherName,
+					AckLevel:       p.config.AckLevel,
+				},
+			},
+		}); err != nil {
+			return nil, fmt.Errorf("send init message: %v", err)
+		}
+		// process the hello message
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, fmt.Errorf("recv init response: %v", err)
+		}
+		if resp.LeaderBroker != "" && resp.LeaderBroker != job.LeaderBroker && redirectCount < maxLeaderRedirects {
+			log.Printf("redirected from %v to leader %v for topic partition %+v", job.LeaderBroker, resp.LeaderBroker, job.Partition)
+			grpcConnection.Close()
+			job.LeaderBroker = resp.LeaderBroker
+			if job.FollowerBroker == job.LeaderBroker {
+				job.FollowerBroker = ""
+			}
+			continue
+		}
+		if resp.Error != "" {
+			grpcConnection.Close()
+			return nil, fmt.Errorf("init response error: %v", resp.Error)
+		}
+		return publishClient, nil
+	}
+}
+
 func (p *TopicPublisher) doConfigureTopic() (err error) {
 	if len(p.config.Brokers) == 0 {
 		return fmt.Errorf("no bootstrap brokers")
@@ -234,9 +346,10 @@ func (p *TopicPublisher) doConfigureTopic() (err error) {
 			p.grpcDialOption,
 			func(client mq_pb.SeaweedMessagingClient) error {
 				_, err := client.ConfigureTopic(context.Background(), &mq_pb.ConfigureTopicRequest{
-					Topic:          p.config.Topic.ToPbTopic(),
-					PartitionCount: p.config.PartitionCount,
-					RecordType:     p.config.RecordType, // TODO schema upgrade
+					Topic:                   p.config.Topic.ToPbTopic(),
+					PartitionCount:          p.config.PartitionCount,
+					RecordType:              p.config.RecordType, // TODO schema upgrade
+					CompressionDictionaries: p.config.CompressionDictionaries,
 				})
 				return err
 			})
@@ -278,6 +391,22 @@ func (p *TopicPublisher) doLookupTopicPartitions() (assignments []*mq_pb.BrokerP
 					return fmt.Errorf("no broker partition assignments")
 				}
 
+				if lookupResp.KeyHashFunction != "" && lookupResp.KeyHashFunction != topic.KeyHashFunction {
+					return fmt.Errorf("unsupported key hash function %s", lookupResp.KeyHashFunction)
+				}
+				if lookupResp.RingSize > 0 {
+					p.ringSize = lookupResp.RingSize
+				}
+
+				// the dictionaries of the topic are only known after the lookup
+				if p.compressor == nil && p.config.CompressionThreshold > 0 {
+					compressor, err := topic.NewMessageCompressor(p.config.CompressionThreshold, lookupResp.CompressionDictionaries)
+					if err != nil {
+						return err
+					}
+					p.compressor = compressor
+				}
+
 				assignments = lookupResp.BrokerPartitionAssignments
 
 				return nil
@@ -0,0 +1,452 @@
+package pub_client
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+// While the partition leaders are unreachable, the published messages are spooled to the local disk,
+// instead of being buffered in memory, and drained in order once all partition leaders are connected again.
+// The messages are published to the spool as long as it is not drained, so they are not reordered.
+// The spool is kept across restarts, and a segment is only removed once all its messages are acked,
+// so the messages of a partially acked segment can be sent again after a restart.
+
+const (
+	defaultSpoolMaxBytes  = 1 << 30
+	spoolRecordHeaderSize = 8
+	spoolFileExt          = ".spool"
+)
+
+// spoolSegmentSize is the size of each spool file, or a quarter of the spool size if smaller
+var spoolSegmentSize int64 = 64 << 20
+
+var (
+	// ErrSpoolFull fails the messages not spooled, or dropped from the spool, since the spool is full
+	ErrSpoolFull = errors.New("publisher spool is full")
+	// ErrMessageExpired fails the messages spooled longer than the max age
+	ErrMessageExpired = errors.New("spooled message expired")
+)
+
+// SpoolConfiguration spools the messages to the local disk while the brokers are unreachable
+type SpoolConfiguration struct {
+	Dir string
+	// the max size of the spool, default 1GB
+	MaxBytes int64
+	// drop the spooled messages older than this when draining, 0 to keep them
+	MaxAge time.Duration
+	// when the spool is full, drop the oldest spooled messages, instead of rejecting the new messages
+	DropOldest bool
+}
+
+type spoolSegment struct {
+	firstSeq   uint64
+	path       string
+	size       int64 // the bytes written
+	count      int   // the messages written
+	readOffset int64
+	readCount  int
+	pending    int // drained but not acked yet
+	reader     *os.File
+}
+
+type diskSpool struct {
+	mu          sync.Mutex
+	config      SpoolConfiguration
+	segmentSize int64
+	segments    []*spoolSegment // the oldest first
+	writer      *os.File        // appends to the last segment, nil if a new segment is needed
+	nextSeq     uint64
+	size        int64
+	inTransit   *spoolSegment // the segment of the message being drained, if any
+	transitSize int64
+	callbacks   map[uint64]PublishCallback
+	notify      chan struct{}
+}
+
+func openDiskSpool(config SpoolConfiguration) (*diskSpool, error) {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultSpoolMaxBytes
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("create spool dir %s: %v", config.Dir, err)
+	}
+	s := &diskSpool{
+		config:      config,
+		segmentSize: min(spoolSegmentSize, config.MaxBytes/4),
+		callbacks:   make(map[uint64]PublishCallback),
+		notify:      make(chan struct{}, 1),
+	}
+	names, err := filepath.Glob(filepath.Join(config.Dir, "*"+spoolFileExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		firstSeq, parseErr := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), spoolFileExt), 10, 64)
+		if parseErr != nil {
+			continue
+		}
+		segment := &spoolSegment{firstSeq: firstSeq, path: name}
+		if err = segment.load(); err != nil {
+			return nil, err
+		}
+		if segment.count == 0 {
+			os.Remove(name)
+			continue
+		}
+		s.segments = append(s.segments, segment)
+		s.size += segment.size
+		s.nextSeq = firstSeq + uint64(segment.count)
+	}
+	if len(s.segments) > 0 {
+		glog.V(0).Infof("publisher spool %s has %d bytes to drain", config.Dir, s.size)
+	}
+	return s, nil
+}
+
+// load counts the complete messages in the segment, and truncates the partially written one at the end
+func (segment *spoolSegment) load() error {
+	f, err := os.OpenFile(segment.path, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for {
+		_, n, readErr := readSpoolRecord(f, segment.size)
+		if readErr != nil {
+			break
+		}
+		segment.size += n
+		segment.count++
+	}
+	return f.Truncate(segment.size)
+}
+
+func appendSpoolRecord(w io.Writer, data []byte) (int64, error) {
+	record := make([]byte, spoolRecordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(data))
+	copy(record[spoolRecordHeaderSize:], data)
+	n, err := w.Write(record)
+	return int64(n), err
+}
+
+// readSpoolRecord reads the message at the offset, and returns the size of its record
+func readSpoolRecord(r io.ReaderAt, offset int64) (message *mq_pb.DataMessage, n int64, err error) {
+	header := make([]byte, spoolRecordHeaderSize)
+	if _, err = r.ReadAt(header, offset); err != nil {
+		return nil, 0, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+	if _, err = r.ReadAt(data, offset+spoolRecordHeaderSize); err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, 0, fmt.Errorf("spool record at %d: crc mismatch", offset)
+	}
+	message = &mq_pb.DataMessage{}
+	if err = proto.Unmarshal(data, message); err != nil {
+		return nil, 0, err
+	}
+	return message, int64(len(header) + len(data)), nil
+}
+
+// isDraining tells whether there are messages spooled but not drained yet
+func (s *diskSpool) isDraining() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inTransit != nil || s.firstUnread() != nil
+}
+
+func (s *diskSpool) firstUnread() *spoolSegment {
+	for _, segment := range s.segments {
+		if segment.readCount < segment.count {
+			return segment
+		}
+	}
+	return nil
+}
+
+// append spools the message, and returns the messages dropped to make room for it
+func (s *diskSpool) append(message *mq_pb.DataMessage, callback PublishCallback) (dropped []*pendingMessage, err error) {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	recordSize := int64(spoolRecordHeaderSize + len(data))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.size+recordSize > s.config.MaxBytes {
+		if !s.config.DropOldest || len(s.segments) == 0 || s.segments[0] == s.inTransit {
+			return dropped, ErrSpoolFull
+		}
+		dropped = append(dropped, s.dropOldest()...)
+	}
+
+	if s.writer == nil || s.segments[len(s.segments)-1].size >= s.segmentSize {
+		if err = s.nextSegment(); err != nil {
+			return dropped, err
+		}
+	}
+	segment := s.segments[len(s.segments)-1]
+	n, err := appendSpoolRecord(s.writer, data)
+	if err != nil {
+		// the partially written record is truncated when the spool is opened again
+		s.writer.Close()
+		s.writer = nil
+		return dropped, fmt.Errorf("spool message: %v", err)
+	}
+	segment.size += n
+	segment.count++
+	s.size += n
+	if callback != nil {
+		s.callbacks[s.nextSeq] = callback
+	}
+	s.nextSeq++
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return dropped, nil
+}
+
+func (s *diskSpool) nextSegment() error {
+	if s.writer != nil {
+		if err := s.writer.Close(); err != nil {
+			return err
+		}
+		s.writer = nil
+	}
+	path := filepath.Join(s.config.Dir, fmt.Sprintf("%020d%s", s.nextSeq, spoolFileExt))
+	writer, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("create spool file %s: %v", path, err)
+	}
+	s.writer = writer
+	s.segments = append(s.segments, &spoolSegment{firstSeq: s.nextSeq, path: path})
+	return nil
+}
+
+// dropOldest removes the oldest segment, and returns its messages not drained yet
+func (s *diskSpool) dropOldest() (dropped []*pendingMessage) {
+	segment := s.segments[0]
+	if segment == s.segments[len(s.segments)-1] && s.writer != nil {
+		s.writer.Close()
+		s.writer = nil
+	}
+	offset := segment.readOffset
+	for i := segment.readCount; i < segment.count; i++ {
+		message, n, err := readSpoolRecord(segment.openReader(), offset)
+		if err != nil {
+			break
+		}
+		offset += n
+		seq := segment.firstSeq + uint64(i)
+		dropped = append(dropped, &pendingMessage{DataMessage: message, callback: s.callbacks[seq]})
+		delete(s.callbacks, seq)
+	}
+	glog.Warningf("publisher spool %s is full, drop %d messages", s.config.Dir, len(dropped))
+	segment.readCount = segment.count
+	s.remove(segment)
+	return dropped
+}
+
+func (segment *spoolSegment) openReader() io.ReaderAt {
+	if segment.reader == nil {
+		reader, err := os.Open(segment.path)
+		if err != nil {
+			return errReaderAt{err}
+		}
+		segment.reader = reader
+	}
+	return segment.reader
+}
+
+type errReaderAt struct {
+	err error
+}
+
+func (r errReaderAt) ReadAt([]byte, int64) (int, error) {
+	return 0, r.err
+}
+
+func (s *diskSpool) remove(segment *spoolSegment) {
+	for i, each := range s.segments {
+		if each == segment {
+			s.segments = append(s.segments[:i], s.segments[i+1:]...)
+			break
+		}
+	}
+	if segment.reader != nil {
+		segment.reader.Close()
+	}
+	if err := os.Remove(segment.path); err != nil {
+		glog.Errorf("remove spool file %s: %v", segment.path, err)
+	}
+	s.size -= segment.size
+}
+
+// peek returns the oldest message not drained yet with its callback, nil if none,
+// and marks it in transit till commit or abort
+func (s *diskSpool) peek() (message *mq_pb.DataMessage, seq uint64, callback PublishCallback, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segment := s.firstUnread()
+	if segment == nil || s.inTransit != nil {
+		return nil, 0, nil, nil
+	}
+	message, n, err := readSpoolRecord(segment.openReader(), segment.readOffset)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("read spool file %s: %v", segment.path, err)
+	}
+	s.inTransit, s.transitSize = segment, n
+	seq = segment.firstSeq + uint64(segment.readCount)
+	return message, seq, s.callbacks[seq], nil
+}
+
+// commit marks the message in transit as drained. The pending ones are removed from the spool by done.
+func (s *diskSpool) commit(isPending bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segment := s.inTransit
+	s.inTransit = nil
+	delete(s.callbacks, segment.firstSeq+uint64(segment.readCount))
+	segment.readOffset += s.transitSize
+	segment.readCount++
+	if isPending {
+		segment.pending++
+	}
+	// the new messages go to a new segment, once the last one is drained
+	if segment == s.segments[len(s.segments)-1] && segment.readCount == segment.count && s.writer != nil {
+		s.writer.Close()
+		s.writer = nil
+	}
+	s.maybeRemove(segment)
+}
+
+func (s *diskSpool) abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inTransit = nil
+}
+
+// done is called once the drained message is acked or failed
+func (s *diskSpool) done(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, segment := range s.segments {
+		if segment.firstSeq <= seq && seq < segment.firstSeq+uint64(segment.count) {
+			segment.pending--
+			s.maybeRemove(segment)
+			return
+		}
+	}
+}
+
+func (s *diskSpool) maybeRemove(segment *spoolSegment) {
+	isWriting := segment == s.segments[len(s.segments)-1] && s.writer != nil
+	if !isWriting && segment.readCount == segment.count && segment.pending == 0 {
+		s.remove(segment)
+	}
+}
+
+func (s *diskSpool) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writer != nil {
+		s.writer.Close()
+		s.writer = nil
+	}
+	for _, segment := range s.segments {
+		if segment.reader != nil {
+			segment.reader.Close()
+			segment.reader = nil
+		}
+	}
+}
+
+// isConnected tells whether all partition leaders are connected
+func (p *TopicPublisher) isConnected() bool {
+	p.jobsLock.RLock()
+	defer p.jobsLock.RUnlock()
+	if len(p.jobs) == 0 {
+		return false
+	}
+	for _, job := range p.jobs {
+		if !job.connected.Load() {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *TopicPublisher) spoolMessage(message *mq_pb.DataMessage, callback PublishCallback) error {
+	dropped, err := p.spool.append(message, callback)
+	for _, droppedMessage := range dropped {
+		p.complete(droppedMessage, ErrSpoolFull)
+	}
+	return err
+}
+
+func (p *TopicPublisher) loopDrainSpool() {
+	defer p.drainWg.Done()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		p.drainSpool()
+		select {
+		case <-p.stopDrain:
+			return
+		case <-p.spool.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// drainSpool moves the spooled messages in order to the partition buffers, while all partition leaders are connected
+func (p *TopicPublisher) drainSpool() {
+	for p.isConnected() {
+		message, seq, callback, err := p.spool.peek()
+		if err != nil {
+			glog.Errorf("drain publisher spool of topic %s: %v", p.config.Topic, err)
+			return
+		}
+		if message == nil {
+			return
+		}
+		if maxAge := p.spool.config.MaxAge; maxAge > 0 && time.Since(time.Unix(0, message.TsNs)) > maxAge {
+			p.spool.commit(false)
+			p.complete(&pendingMessage{DataMessage: message, callback: callback}, ErrMessageExpired)
+			continue
+		}
+		err = p.enqueueToPartition(message, func(message *mq_pb.DataMessage, err error) {
+			p.spool.done(seq)
+			if callback != nil {
+				callback(message, err)
+			}
+		}, p.stopDrain)
+		if err != nil {
+			p.spool.abort()
+			glog.V(1).Infof("drain publisher spool of topic %s: %v", p.config.Topic, err)
+			return
+		}
+		p.spool.commit(true)
+	}
+}
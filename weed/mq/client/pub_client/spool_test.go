@@ -0,0 +1,189 @@
+package pub_client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rdleal/intervalst/interval"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/pub_balancer"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util/buffered_queue"
+)
+
+func newSpoolingPublisher(t *testing.T, config SpoolConfiguration) (*TopicPublisher, *buffered_queue.BufferedQueue[*pendingMessage]) {
+	spool, err := openDiskSpool(config)
+	if err != nil {
+		t.Fatalf("open spool: %v", err)
+	}
+	inputQueue := buffered_queue.NewBufferedQueue[*pendingMessage](16)
+	p := &TopicPublisher{
+		partition2Buffer: interval.NewSearchTree[*buffered_queue.BufferedQueue[*pendingMessage]](func(a, b int32) int {
+			return int(a - b)
+		}),
+		config:    &PublisherConfiguration{Topic: topic.NewTopic("test", "t"), Spool: &config},
+		ringSize:  pub_balancer.MaxPartitionCount,
+		spool:     spool,
+		stopDrain: make(chan struct{}),
+	}
+	p.partition2Buffer.Insert(0, pub_balancer.MaxPartitionCount-1, inputQueue)
+	return p, inputQueue
+}
+
+func (p *TopicPublisher) setConnected(isConnected bool) {
+	job := &EachPartitionPublishJob{}
+	job.connected.Store(isConnected)
+	p.jobs = []*EachPartitionPublishJob{job}
+}
+
+func spoolFiles(t *testing.T, dir string) []string {
+	names, err := filepath.Glob(filepath.Join(dir, "*"+spoolFileExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestSpoolDrainInOrder(t *testing.T) {
+	dir := t.TempDir()
+	p, inputQueue := newSpoolingPublisher(t, SpoolConfiguration{Dir: dir})
+
+	var acked []string
+	callback := func(message *mq_pb.DataMessage, err error) {
+		acked = append(acked, fmt.Sprintf("%s:%v", message.Value, err))
+	}
+
+	// spooled while disconnected, and after reconnected till the spool is drained
+	for i := 0; i < 3; i++ {
+		if err := p.enqueue(&mq_pb.DataMessage{Value: []byte(fmt.Sprint(i)), TsNs: time.Now().UnixNano()}, callback); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	p.setConnected(true)
+	if err := p.enqueue(&mq_pb.DataMessage{Value: []byte("3"), TsNs: time.Now().UnixNano()}, callback); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if inputQueue.Size() != 0 || len(spoolFiles(t, dir)) != 1 {
+		t.Fatalf("expected all messages spooled, %d buffered", inputQueue.Size())
+	}
+
+	p.drainSpool()
+	if p.spool.isDraining() {
+		t.Fatalf("expected the spool drained")
+	}
+	var drained []*pendingMessage
+	for inputQueue.Size() > 0 {
+		message, _ := inputQueue.Dequeue()
+		drained = append(drained, message)
+	}
+	if len(drained) != 4 {
+		t.Fatalf("drained %d messages, expected 4", len(drained))
+	}
+	for _, message := range drained {
+		p.complete(message, nil)
+	}
+	if fmt.Sprint(acked) != "[0:<nil> 1:<nil> 2:<nil> 3:<nil>]" {
+		t.Errorf("acked %v", acked)
+	}
+	// the spool file is removed once all its messages are acked
+	if files := spoolFiles(t, dir); len(files) != 0 {
+		t.Errorf("spool files %v left", files)
+	}
+
+	// not spooled once drained
+	if err := p.enqueue(&mq_pb.DataMessage{Value: []byte("4")}, nil); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if inputQueue.Size() != 1 {
+		t.Errorf("expected the message buffered directly")
+	}
+}
+
+func TestSpoolReopen(t *testing.T) {
+	dir := t.TempDir()
+	p, _ := newSpoolingPublisher(t, SpoolConfiguration{Dir: dir})
+	for i := 0; i < 3; i++ {
+		if err := p.enqueue(&mq_pb.DataMessage{Value: []byte(fmt.Sprint(i))}, nil); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	p.spool.close()
+
+	// a partially written message at the end is dropped
+	files := spoolFiles(t, dir)
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 9, 1})
+	f.Close()
+
+	p, inputQueue := newSpoolingPublisher(t, SpoolConfiguration{Dir: dir})
+	p.setConnected(true)
+	p.drainSpool()
+	var values []string
+	for inputQueue.Size() > 0 {
+		message, _ := inputQueue.Dequeue()
+		values = append(values, string(message.Value))
+	}
+	if fmt.Sprint(values) != "[0 1 2]" {
+		t.Errorf("drained %v after reopen", values)
+	}
+}
+
+func TestSpoolFull(t *testing.T) {
+	value := make([]byte, 100)
+
+	p, _ := newSpoolingPublisher(t, SpoolConfiguration{Dir: t.TempDir(), MaxBytes: 1000})
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = p.enqueue(&mq_pb.DataMessage{Value: value}, nil)
+	}
+	if err != ErrSpoolFull {
+		t.Errorf("expected spool full, got %v", err)
+	}
+
+	// drop the oldest messages instead
+	p, inputQueue := newSpoolingPublisher(t, SpoolConfiguration{Dir: t.TempDir(), MaxBytes: 1000, DropOldest: true})
+	var dropped []string
+	for i := 0; i < 20; i++ {
+		err = p.enqueue(&mq_pb.DataMessage{Key: []byte(fmt.Sprint(i)), Value: value}, func(message *mq_pb.DataMessage, err error) {
+			if err == ErrSpoolFull {
+				dropped = append(dropped, string(message.Key))
+			}
+		})
+		if err != nil {
+			t.Fatalf("enqueue %d: %v", i, err)
+		}
+	}
+	if len(dropped) == 0 || dropped[0] != "0" {
+		t.Errorf("expected the oldest dropped, got %v", dropped)
+	}
+	p.setConnected(true)
+	p.drainSpool()
+	if inputQueue.Size()+len(dropped) != 20 {
+		t.Errorf("drained %d and dropped %d of 20", inputQueue.Size(), len(dropped))
+	}
+}
+
+func TestSpoolMaxAge(t *testing.T) {
+	p, inputQueue := newSpoolingPublisher(t, SpoolConfiguration{Dir: t.TempDir(), MaxAge: time.Minute})
+	var expired int
+	callback := func(message *mq_pb.DataMessage, err error) {
+		if err == ErrMessageExpired {
+			expired++
+		}
+	}
+	p.enqueue(&mq_pb.DataMessage{TsNs: time.Now().Add(-time.Hour).UnixNano()}, callback)
+	p.enqueue(&mq_pb.DataMessage{TsNs: time.Now().UnixNano()}, callback)
+
+	p.setConnected(true)
+	p.drainSpool()
+	if expired != 1 || inputQueue.Size() != 1 {
+		t.Errorf("expired %d, drained %d", expired, inputQueue.Size())
+	}
+}
@@ -0,0 +1,268 @@
+package sub_client
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	DefaultCommitInterval      = 5 * time.Second
+	DefaultCheckpointDirectory = filer.DirectoryEtcSeaweedFS + "/mq_subscribers"
+)
+
+type CheckpointConfiguration struct {
+	// commit the processed offsets to one file per partition under the directory in the filer.
+	// Without the filer client, the consumer group offsets saved by the brokers are used instead.
+	FilerClient filer_pb.FilerClient
+	Directory   string // default is DefaultCheckpointDirectory
+	// how often to commit the processed offsets. Default is DefaultCommitInterval.
+	CommitInterval time.Duration
+}
+
+// Message is a message received from a partition of the topic
+type Message struct {
+	Partition topic.Partition
+	*mq_pb.DataMessage
+}
+
+// CheckpointSubscriber receives the messages of all assigned partitions from one channel,
+// and commits the offsets of the processed messages, so a restarted subscriber resumes where it left off.
+//
+// A message is taken as processed when the next message is received from Messages(), or when the subscriber is closed.
+// So the messages should be processed by one goroutine, e.g., in the loop ranging over Messages().
+//
+// With the filer checkpoints, the messages are received at least once. With the consumer group offsets,
+// the brokers only know the messages received, so the message being processed when the subscriber dies is skipped.
+type CheckpointSubscriber struct {
+	subscriber     *TopicSubscriber
+	checkpoint     *CheckpointConfiguration
+	nextOffsetFunc func(partition topic.Partition) (*schema_pb.PartitionOffset, error)
+	incoming       chan *Message
+	messages       chan *Message
+	offsetsLock    sync.Mutex
+	processed      map[topic.Partition]int64
+	committed      map[topic.Partition]int64
+	startOnce      sync.Once
+	stopOnce       sync.Once
+	stopCh         chan struct{}
+	doneCh         chan struct{}
+	errLock        sync.Mutex
+	err            error
+}
+
+func NewCheckpointSubscriber(bootstrapBrokers []string, subscriber *SubscriberConfiguration, content *ContentConfiguration, checkpoint *CheckpointConfiguration) *CheckpointSubscriber {
+	s := &CheckpointSubscriber{
+		checkpoint:     checkpoint,
+		nextOffsetFunc: content.PartitionOffsetFunc,
+		incoming:       make(chan *Message),
+		messages:       make(chan *Message),
+		processed:      make(map[topic.Partition]int64),
+		committed:      make(map[topic.Partition]int64),
+		stopCh:         make(chan struct{}),
+		doneCh:         make(chan struct{}),
+	}
+	// keep the order within each partition, so the offset of the last processed message covers the earlier ones
+	subscriberConfig, contentConfig := *subscriber, *content
+	subscriberConfig.SlidingWindowSize = 1
+	if checkpoint.FilerClient != nil {
+		contentConfig.PartitionOffsetFunc = s.startOffset
+	} else {
+		contentConfig.ResumeFromConsumerGroup = true
+	}
+	s.subscriber = NewTopicSubscriber(bootstrapBrokers, &subscriberConfig, &contentConfig, make(chan KeyedOffset, 1024))
+	s.subscriber.SetEachPartitionDataMessageFunc(s.onMessage)
+	return s
+}
+
+// Messages starts the subscriber, and returns the channel of the received messages,
+// which is closed after the subscriber is closed
+func (s *CheckpointSubscriber) Messages() <-chan *Message {
+	s.startOnce.Do(func() {
+		go s.dispatch()
+		if s.checkpoint.FilerClient != nil {
+			go s.loopCommit()
+		}
+		go func() {
+			err := s.subscriber.Subscribe()
+			s.errLock.Lock()
+			s.err = err
+			s.errLock.Unlock()
+			s.Close()
+		}()
+	})
+	return s.messages
+}
+
+// Close stops the subscriber, and commits the messages received so far
+func (s *CheckpointSubscriber) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		s.subscriber.Shutdown()
+	})
+	s.startOnce.Do(func() {
+		close(s.doneCh)
+	})
+	<-s.doneCh
+	if s.checkpoint.FilerClient == nil {
+		return nil
+	}
+	return s.commit()
+}
+
+// Err returns the error stopping the subscriber, if any
+func (s *CheckpointSubscriber) Err() error {
+	s.errLock.Lock()
+	defer s.errLock.Unlock()
+	return s.err
+}
+
+// onMessage hands the message to the dispatcher, and waits so the messages of the partition stay in order
+func (s *CheckpointSubscriber) onMessage(partition topic.Partition, message *mq_pb.DataMessage) error {
+	select {
+	case s.incoming <- &Message{Partition: partition, DataMessage: message}:
+		return nil
+	case <-s.stopCh:
+		return fmt.Errorf("subscriber closed")
+	}
+}
+
+// dispatch sends the messages one by one, and marks the previous message as processed once the next one is received
+func (s *CheckpointSubscriber) dispatch() {
+	defer close(s.doneCh)
+	defer close(s.messages)
+	var last *Message
+	for {
+		var next *Message
+		select {
+		case next = <-s.incoming:
+		case <-s.stopCh:
+			s.markProcessed(last)
+			return
+		}
+		select {
+		case s.messages <- next:
+			s.markProcessed(last)
+			last = next
+		case <-s.stopCh:
+			s.markProcessed(last)
+			return
+		}
+	}
+}
+
+func (s *CheckpointSubscriber) markProcessed(message *Message) {
+	if message == nil {
+		return
+	}
+	s.offsetsLock.Lock()
+	defer s.offsetsLock.Unlock()
+	if message.TsNs > s.processed[message.Partition] {
+		s.processed[message.Partition] = message.TsNs
+	}
+}
+
+func (s *CheckpointSubscriber) loopCommit() {
+	interval := s.checkpoint.CommitInterval
+	if interval <= 0 {
+		interval = DefaultCommitInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.commit(); err != nil {
+				glog.Errorf("subscriber %s/%s commit: %v", s.subscriber.ContentConfig.Topic, s.subscriber.SubscriberConfig.ConsumerGroup, err)
+			}
+		case <-s.doneCh:
+			return
+		}
+	}
+}
+
+// commit saves the offsets of the partitions processed since the last commit
+func (s *CheckpointSubscriber) commit() error {
+	s.offsetsLock.Lock()
+	changed := make(map[topic.Partition]int64)
+	for partition, tsNs := range s.processed {
+		if tsNs > s.committed[partition] {
+			changed[partition] = tsNs
+		}
+	}
+	s.offsetsLock.Unlock()
+
+	for partition, tsNs := range changed {
+		dir, fileName := s.checkpointLocation(partition)
+		data := make([]byte, 8)
+		util.Uint64toBytes(data, uint64(tsNs))
+		if err := s.checkpoint.FilerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			return filer.SaveInsideFiler(client, dir, fileName, data)
+		}); err != nil {
+			return fmt.Errorf("save checkpoint %s/%s: %v", dir, fileName, err)
+		}
+		s.offsetsLock.Lock()
+		s.committed[partition] = max(s.committed[partition], tsNs)
+		s.offsetsLock.Unlock()
+	}
+	return nil
+}
+
+// startOffset resumes the partition from its checkpoint, or as configured if none yet
+func (s *CheckpointSubscriber) startOffset(partition topic.Partition) (*schema_pb.PartitionOffset, error) {
+	dir, fileName := s.checkpointLocation(partition)
+	var tsNs int64
+	err := s.checkpoint.FilerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		data, readErr := filer.ReadInsideFiler(client, dir, fileName)
+		if readErr == filer_pb.ErrNotFound {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+		if len(data) != 8 {
+			return fmt.Errorf("invalid checkpoint %s/%s of %d bytes", dir, fileName, len(data))
+		}
+		tsNs = int64(util.BytesToUint64(data))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %v", err)
+	}
+	if tsNs == 0 {
+		if s.nextOffsetFunc != nil {
+			return s.nextOffsetFunc(partition)
+		}
+		return nil, nil
+	}
+
+	s.offsetsLock.Lock()
+	s.committed[partition] = max(s.committed[partition], tsNs)
+	s.offsetsLock.Unlock()
+
+	glog.V(0).Infof("subscriber %s/%s resumes %v from %v", s.subscriber.ContentConfig.Topic, s.subscriber.SubscriberConfig.ConsumerGroup, partition, time.Unix(0, tsNs))
+	return &schema_pb.PartitionOffset{
+		Partition: partition.ToPbPartition(),
+		StartTsNs: tsNs,
+	}, nil
+}
+
+// checkpointLocation is one file per partition under the directory of the consumer group,
+// named after the partition generation and range, e.g., "v2024-01-02-03-04-05_0000-1024"
+func (s *CheckpointSubscriber) checkpointLocation(partition topic.Partition) (dir, fileName string) {
+	t, directory := s.subscriber.ContentConfig.Topic, s.checkpoint.Directory
+	if directory == "" {
+		directory = DefaultCheckpointDirectory
+	}
+	partitionDir := strings.TrimPrefix(topic.PartitionDir(t, partition), t.Dir()+"/")
+	return util.Join(directory, s.subscriber.SubscriberConfig.ConsumerGroup, t.Namespace, t.Name), strings.ReplaceAll(partitionDir, "/", "_")
+}
@@ -0,0 +1,53 @@
+package sub_client
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+func TestCheckpointSubscriberProcessed(t *testing.T) {
+	s := NewCheckpointSubscriber(nil, &SubscriberConfiguration{ConsumerGroup: "g"},
+		&ContentConfiguration{Topic: topic.NewTopic("ns", "t")}, &CheckpointConfiguration{})
+	// dispatch without connecting to the brokers
+	s.startOnce.Do(func() {
+		go s.dispatch()
+	})
+
+	p1 := topic.Partition{RangeStart: 0, RangeStop: 512, RingSize: 1024}
+	p2 := topic.Partition{RangeStart: 512, RangeStop: 1024, RingSize: 1024}
+	go func() {
+		s.onMessage(p1, &mq_pb.DataMessage{TsNs: 1})
+		s.onMessage(p2, &mq_pb.DataMessage{TsNs: 5})
+		s.onMessage(p1, &mq_pb.DataMessage{TsNs: 2})
+	}()
+	processed := func(partition topic.Partition) int64 {
+		s.offsetsLock.Lock()
+		defer s.offsetsLock.Unlock()
+		return s.processed[partition]
+	}
+
+	// a message is processed once the next one is received
+	for _, tsNs := range []int64{1, 5, 2} {
+		if m := <-s.messages; m.TsNs != tsNs {
+			t.Fatalf("received %d, expected %d", m.TsNs, tsNs)
+		}
+	}
+	if processed(p1) != 1 {
+		t.Fatalf("processed %d before the next message of the partition", processed(p1))
+	}
+
+	// the last received message is processed once closed
+	s.Close()
+	if processed(p1) != 2 || processed(p2) != 5 {
+		t.Fatalf("processed %d and %d after closed", processed(p1), processed(p2))
+	}
+	if _, ok := <-s.messages; ok {
+		t.Fatalf("messages should be closed")
+	}
+
+	if dir, fileName := s.checkpointLocation(p2); dir != DefaultCheckpointDirectory+"/g/ns/t" || fileName != "v1970-01-01-00-00-00_0512-1024" {
+		t.Errorf("checkpoint location %s/%s", dir, fileName)
+	}
+}
@@ -10,8 +10,17 @@ import (
 
 func (sub *TopicSubscriber) doKeepConnectedToSubCoordinator() {
 	waitTime := 1 * time.Second
+	sessionTimeout := sub.SubscriberConfig.SessionTimeout
+	if sessionTimeout < time.Second {
+		sessionTimeout = DefaultSessionTimeout
+	}
 	for {
 		for _, broker := range sub.bootstrapBrokers {
+			select {
+			case <-sub.stopCh:
+				return
+			default:
+			}
 			// lookup topic brokers
 			var brokerLeader string
 			err := pb.WithBrokerGrpcClient(false, broker, sub.SubscriberConfig.GrpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
@@ -32,6 +41,13 @@ func (sub *TopicSubscriber) doKeepConnectedToSubCoordinator() {
 			pb.WithBrokerGrpcClient(true, brokerLeader, sub.SubscriberConfig.GrpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
 				ctx, cancel := context.WithCancel(context.Background())
 				defer cancel()
+				go func() {
+					select {
+					case <-sub.stopCh:
+						cancel()
+					case <-ctx.Done():
+					}
+				}()
 
 				stream, err := client.SubscriberToSubCoordinator(ctx)
 				if err != nil {
@@ -49,6 +65,7 @@ func (sub *TopicSubscriber) doKeepConnectedToSubCoordinator() {
 							ConsumerGroupInstanceId: sub.SubscriberConfig.ConsumerGroupInstanceId,
 							Topic:                   sub.ContentConfig.Topic.ToPbTopic(),
 							MaxPartitionCount:       sub.SubscriberConfig.MaxPartitionCount,
+							SessionTimeoutSeconds:   int32(sessionTimeout / time.Second),
 						},
 					},
 				}); err != nil {
@@ -56,9 +73,24 @@ func (sub *TopicSubscriber) doKeepConnectedToSubCoordinator() {
 					return err
 				}
 
+				// the acks and the heartbeats are sent from one goroutine
 				go func() {
-					for reply := range sub.brokerPartitionAssignmentAckChan {
-						glog.V(0).Infof("subscriber instance %s ack %+v", sub.SubscriberConfig.ConsumerGroupInstanceId, reply)
+					heartbeatTicker := time.NewTicker(sessionTimeout / 3)
+					defer heartbeatTicker.Stop()
+					for {
+						var reply *mq_pb.SubscriberToSubCoordinatorRequest
+						select {
+						case <-ctx.Done():
+							return
+						case reply = <-sub.brokerPartitionAssignmentAckChan:
+							glog.V(0).Infof("subscriber instance %s ack %+v", sub.SubscriberConfig.ConsumerGroupInstanceId, reply)
+						case <-heartbeatTicker.C:
+							reply = &mq_pb.SubscriberToSubCoordinatorRequest{
+								Message: &mq_pb.SubscriberToSubCoordinatorRequest_Heartbeat{
+									Heartbeat: &mq_pb.SubscriberToSubCoordinatorRequest_HeartbeatMessage{},
+								},
+							}
+						}
 						if err := stream.Send(reply); err != nil {
 							glog.V(0).Infof("subscriber %s reply: %v", sub.ContentConfig.Topic, err)
 							return
@@ -84,6 +116,10 @@ func (sub *TopicSubscriber) doKeepConnectedToSubCoordinator() {
 		if waitTime < 10*time.Second {
 			waitTime += 1 * time.Second
 		}
-		time.Sleep(waitTime)
+		select {
+		case <-sub.stopCh:
+			return
+		case <-time.After(waitTime):
+		}
 	}
 }
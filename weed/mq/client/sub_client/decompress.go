@@ -0,0 +1,43 @@
+package sub_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+// decompress restores the message value compressed by the publisher,
+// loading the topic compression dictionaries when a new one is referenced
+func (sub *TopicSubscriber) decompress(message *mq_pb.DataMessage) error {
+	err := sub.decompressor.Decompress(message)
+	if !errors.Is(err, topic.ErrUnknownCompressionDictionary) {
+		return err
+	}
+	if loadErr := sub.loadCompressionDictionaries(); loadErr != nil {
+		return fmt.Errorf("%v: %v", err, loadErr)
+	}
+	return sub.decompressor.Decompress(message)
+}
+
+func (sub *TopicSubscriber) loadCompressionDictionaries() (err error) {
+	for _, broker := range sub.bootstrapBrokers {
+		err = pb.WithBrokerGrpcClient(false, broker, sub.SubscriberConfig.GrpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
+			resp, lookupErr := client.LookupTopicBrokers(context.Background(), &mq_pb.LookupTopicBrokersRequest{
+				Topic: sub.ContentConfig.Topic.ToPbTopic(),
+			})
+			if lookupErr != nil {
+				return lookupErr
+			}
+			sub.decompressor.AddDictionaries(resp.CompressionDictionaries)
+			return nil
+		})
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("load compression dictionaries of topic %s: %v", sub.ContentConfig.Topic, err)
+}
@@ -0,0 +1,32 @@
+package sub_client
+
+import (
+	"context"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+// DeliverHandler processes the message received from the partition, by the message processing function
+type DeliverHandler func(ctx context.Context, partition topic.Partition, message *mq_pb.DataMessage) error
+
+// DeliverInterceptor is called for each received message before it is processed, like a grpc server interceptor.
+// It can change the key, value, and headers, e.g., to decrypt the value, return an error to skip the processing,
+// e.g., failing the schema validation, or observe the result of next, which must be called to process the message.
+// The values are already decompressed, and ctx carries the consumer span of the message.
+// The message is acked if no error is returned, or else delivered again after the ack deadline.
+type DeliverInterceptor func(ctx context.Context, partition topic.Partition, message *mq_pb.DataMessage, next DeliverHandler) error
+
+// AckInterceptor is called after each received message is processed, before it is acked if processErr is nil
+type AckInterceptor func(partition topic.Partition, message *mq_pb.DataMessage, processErr error)
+
+// ChainDeliverInterceptors runs the interceptors in order, the first one being the outermost
+func ChainDeliverInterceptors(interceptors []DeliverInterceptor, handler DeliverHandler) DeliverHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, partition topic.Partition, message *mq_pb.DataMessage) error {
+			return interceptor(ctx, partition, message, next)
+		}
+	}
+	return handler
+}
@@ -0,0 +1,39 @@
+package sub_client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+func TestChainDeliverInterceptors(t *testing.T) {
+	var calls []string
+	trace := func(name string) DeliverInterceptor {
+		return func(ctx context.Context, partition topic.Partition, message *mq_pb.DataMessage, next DeliverHandler) error {
+			calls = append(calls, name+">")
+			err := next(ctx, partition, message)
+			calls = append(calls, "<"+name)
+			return err
+		}
+	}
+	sub := &TopicSubscriber{}
+	sub.SetEachMessageFunc(func(key, value []byte) error {
+		calls = append(calls, "process "+string(value))
+		return nil
+	})
+	deliver := ChainDeliverInterceptors([]DeliverInterceptor{trace("a"), trace("b")}, sub.processMessage)
+	if err := deliver(context.Background(), topic.Partition{}, &mq_pb.DataMessage{Value: []byte("v")}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if fmt.Sprint(calls) != "[a> b> process v <b <a]" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+
+	calls = nil
+	if err := ChainDeliverInterceptors(nil, sub.processMessage)(context.Background(), topic.Partition{}, &mq_pb.DataMessage{Value: []byte("v")}); err != nil || fmt.Sprint(calls) != "[process v]" {
+		t.Errorf("unexpected calls %v without interceptors: %v", calls, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
@@ -32,6 +33,17 @@ func (sub *TopicSubscriber) onEachPartition(assigned *mq_pb.BrokerPartitionAssig
 		}
 
 		po := findPartitionOffset(sub.ContentConfig.PartitionOffsets, assigned.Partition)
+		if po == nil && sub.ContentConfig.PartitionOffsetFunc != nil {
+			if po, err = sub.ContentConfig.PartitionOffsetFunc(topic.FromPbPartition(assigned.Partition)); err != nil {
+				return fmt.Errorf("start offset of partition %+v: %v", assigned.Partition, err)
+			}
+		}
+		if po == nil && sub.ContentConfig.ResumeFromConsumerGroup {
+			po = &schema_pb.PartitionOffset{
+				Partition: assigned.Partition,
+				StartType: schema_pb.PartitionOffsetStartType_LATEST,
+			}
+		}
 		if po == nil {
 			po = &schema_pb.PartitionOffset{
 				Partition: assigned.Partition,
@@ -40,16 +52,20 @@ func (sub *TopicSubscriber) onEachPartition(assigned *mq_pb.BrokerPartitionAssig
 			}
 		}
 
+		// round up, so a short deadline is not taken as no deadline
+		ackDeadlineSeconds := int32((sub.SubscriberConfig.AckDeadline + time.Second - 1) / time.Second)
+
 		if err = subscribeClient.Send(&mq_pb.SubscribeMessageRequest{
 			Message: &mq_pb.SubscribeMessageRequest_Init{
 				Init: &mq_pb.SubscribeMessageRequest_InitMessage{
-					ConsumerGroup:     sub.SubscriberConfig.ConsumerGroup,
-					ConsumerId:        sub.SubscriberConfig.ConsumerGroupInstanceId,
-					Topic:             sub.ContentConfig.Topic.ToPbTopic(),
-					PartitionOffset:   po,
-					Filter:            sub.ContentConfig.Filter,
-					FollowerBroker:    assigned.FollowerBroker,
-					SlidingWindowSize: slidingWindowSize,
+					ConsumerGroup:      sub.SubscriberConfig.ConsumerGroup,
+					ConsumerId:         sub.SubscriberConfig.ConsumerGroupInstanceId,
+					Topic:              sub.ContentConfig.Topic.ToPbTopic(),
+					PartitionOffset:    po,
+					Filter:             sub.ContentConfig.Filter,
+					FollowerBroker:     assigned.FollowerBroker,
+					SlidingWindowSize:  slidingWindowSize,
+					AckDeadlineSeconds: ackDeadlineSeconds,
 				},
 			},
 		}); err != nil {
@@ -62,12 +78,19 @@ func (sub *TopicSubscriber) onEachPartition(assigned *mq_pb.BrokerPartitionAssig
 			defer sub.OnCompletionFunc()
 		}
 
+		resendCh := make(chan *mq_pb.SubscribeMessageRequest_ResendRangeMessage, 16)
 		go func() {
 			for {
 				select {
 				case <-stopCh:
 					subscribeClient.CloseSend()
 					return
+				case resendRange := <-resendCh:
+					subscribeClient.SendMsg(&mq_pb.SubscribeMessageRequest{
+						Message: &mq_pb.SubscribeMessageRequest_ResendRange{
+							ResendRange: resendRange,
+						},
+					})
 				case ack, ok := <-sub.PartitionOffsetChan:
 					if !ok {
 						subscribeClient.CloseSend()
@@ -85,6 +108,7 @@ func (sub *TopicSubscriber) onEachPartition(assigned *mq_pb.BrokerPartitionAssig
 			}
 		}()
 
+		var tracker sequenceTracker
 		for {
 			// glog.V(0).Infof("subscriber %s/%s/%s waiting for message", sub.ContentConfig.Namespace, sub.ContentConfig.Topic, sub.SubscriberConfig.ConsumerGroup)
 			resp, err := subscribeClient.Recv()
@@ -97,6 +121,17 @@ func (sub *TopicSubscriber) onEachPartition(assigned *mq_pb.BrokerPartitionAssig
 			}
 			switch m := resp.Message.(type) {
 			case *mq_pb.SubscribeMessageResponse_Data:
+				if startTsNs, stopTsNs, hasGap := tracker.observe(m.Data.TsNs, m.Data.PrevTsNs); hasGap {
+					glog.Warningf("subscriber %s partition %+v missed messages in (%d, %d), requesting resend", sub.ContentConfig.Topic, assigned.Partition, startTsNs, stopTsNs)
+					select {
+					case resendCh <- &mq_pb.SubscribeMessageRequest_ResendRangeMessage{
+						StartTsNs: startTsNs,
+						StopTsNs:  stopTsNs,
+					}:
+					default:
+						glog.Errorf("subscriber %s partition %+v too many pending resend requests, dropped (%d, %d)", sub.ContentConfig.Topic, assigned.Partition, startTsNs, stopTsNs)
+					}
+				}
 				if m.Data.Ctrl != nil {
 					glog.V(2).Infof("subscriber %s received control from producer:%s isClose:%v", sub.SubscriberConfig.ConsumerGroup, m.Data.Ctrl.PublisherName, m.Data.Ctrl.IsClose)
 					continue
@@ -105,6 +140,9 @@ func (sub *TopicSubscriber) onEachPartition(assigned *mq_pb.BrokerPartitionAssig
 					fmt.Printf("empty key %+v, type %v\n", m, reflect.TypeOf(m))
 					continue
 				}
+				if err := sub.decompress(m.Data); err != nil {
+					return fmt.Errorf("subscriber %s partition %+v: %v", sub.ContentConfig.Topic, assigned.Partition, err)
+				}
 				onDataMessageFn(m)
 			case *mq_pb.SubscribeMessageResponse_Ctrl:
 				// glog.V(0).Infof("subscriber %s/%s/%s received control %+v", sub.ContentConfig.Namespace, sub.ContentConfig.Topic, sub.SubscriberConfig.ConsumerGroup, m.Ctrl)
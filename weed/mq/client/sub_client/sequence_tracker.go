@@ -0,0 +1,24 @@
+package sub_client
+
+// sequenceTracker follows the ts_ns links of the messages delivered from one partition.
+// Each message carries the ts_ns of its previous message in the partition,
+// so a message whose previous ts_ns is newer than the last seen one means some messages are missing.
+// The previous ts_ns is unknown, i.e., -1, for the first message added by the broker before it restored the last
+// persisted ts_ns of the partition, which may also follow missing messages. It is 0 for the first message of the
+// partition, or the messages not linked by the earlier brokers.
+type sequenceTracker struct {
+	lastTsNs int64
+}
+
+// observe returns the range (startTsNs, stopTsNs) of the missing messages, if any.
+// Resent or duplicated messages older than the last seen one are ignored.
+func (t *sequenceTracker) observe(tsNs, prevTsNs int64) (startTsNs, stopTsNs int64, hasGap bool) {
+	if tsNs <= t.lastTsNs {
+		return
+	}
+	if t.lastTsNs != 0 && (prevTsNs < 0 || prevTsNs > t.lastTsNs) {
+		startTsNs, stopTsNs, hasGap = t.lastTsNs, tsNs, true
+	}
+	t.lastTsNs = tsNs
+	return
+}
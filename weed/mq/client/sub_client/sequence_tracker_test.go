@@ -0,0 +1,39 @@
+package sub_client
+
+import (
+	"testing"
+)
+
+func TestSequenceTracker(t *testing.T) {
+	var tracker sequenceTracker
+	for _, tt := range []struct {
+		name                string
+		tsNs, prevTsNs      int64
+		startTsNs, stopTsNs int64
+		hasGap              bool
+	}{
+		{"the first message of the partition", 10, 0, 0, 0, false},
+		{"the next message", 20, 10, 0, 0, false},
+		{"the message after the next", 30, 20, 0, 0, false},
+		{"missed the message at 40", 50, 40, 30, 50, true},
+		{"duplicated", 40, 30, 0, 0, false},
+		{"resent", 30, 20, 0, 0, false},
+		{"the previous message is not linked", 60, 0, 0, 0, false},
+		{"the previous message is unknown", 70, -1, 60, 70, true},
+		{"the message after the unknown one", 80, 70, 0, 0, false},
+	} {
+		startTsNs, stopTsNs, hasGap := tracker.observe(tt.tsNs, tt.prevTsNs)
+		if startTsNs != tt.startTsNs || stopTsNs != tt.stopTsNs || hasGap != tt.hasGap {
+			t.Errorf("%s: got (%d, %d) %v, expected (%d, %d) %v", tt.name, startTsNs, stopTsNs, hasGap, tt.startTsNs, tt.stopTsNs, tt.hasGap)
+		}
+	}
+	// the first received message is not checked, with no previous messages to compare
+	tracker = sequenceTracker{}
+	if _, _, hasGap := tracker.observe(100, 90); hasGap {
+		t.Errorf("the first received message should not be a gap")
+	}
+	tracker = sequenceTracker{}
+	if _, _, hasGap := tracker.observe(100, -1); hasGap {
+		t.Errorf("the first received message should not be a gap")
+	}
+}
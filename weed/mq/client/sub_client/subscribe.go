@@ -1,10 +1,15 @@
 package sub_client
 
 import (
+	"context"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"sync"
 	"time"
 )
@@ -20,10 +25,18 @@ func (sub *TopicSubscriber) Subscribe() error {
 
 	go sub.startProcessors()
 
-	// loop forever
-	// TODO shutdown the subscriber when not needed anymore
+	// loop until the subscriber is shut down
 	sub.doKeepConnectedToSubCoordinator()
 
+	// stop the partition processors, and no more assignments
+	sub.activeProcessorsLock.Lock()
+	for partition, processor := range sub.activeProcessors {
+		close(processor.stopCh)
+		delete(sub.activeProcessors, partition)
+	}
+	sub.activeProcessorsLock.Unlock()
+	close(sub.brokerPartitionAssignmentChan)
+
 	return nil
 }
 
@@ -70,9 +83,24 @@ func (sub *TopicSubscriber) startProcessors() {
 				}
 
 				executors := util.NewLimitedConcurrentExecutor(int(sub.SubscriberConfig.SlidingWindowSize))
+				spanAttributes := append(tracing.MessagingAttributes(sub.ContentConfig.Topic.String(), topicPartition.String()),
+					attribute.String("messaging.consumer.group.name", sub.SubscriberConfig.ConsumerGroup))
+				deliver := ChainDeliverInterceptors(sub.SubscriberConfig.DeliverInterceptors, sub.processMessage)
 				onDataMessageFn := func(m *mq_pb.SubscribeMessageResponse_Data) {
 					executors.Execute(func() {
-						processErr := sub.OnEachMessageFunc(m.Data.Key, m.Data.Value)
+						// the span covers the processing, and records the end to end latency since the message is published
+						ctx, span := tracing.StartSpanFromHeaders(context.Background(), m.Data.Headers, "mq.consume",
+							trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(spanAttributes...),
+							trace.WithAttributes(attribute.Int64("seaweedfs.mq.latency_ms", time.Since(time.Unix(0, m.Data.TsNs)).Milliseconds())))
+						processErr := deliver(ctx, topicPartition, m.Data)
+						if processErr != nil {
+							span.RecordError(processErr)
+							span.SetStatus(codes.Error, processErr.Error())
+						}
+						span.End()
+						for _, onAck := range sub.SubscriberConfig.AckInterceptors {
+							onAck(topicPartition, m.Data, processErr)
+						}
 						if processErr == nil {
 							sub.PartitionOffsetChan <- KeyedOffset{
 								Key:    m.Data.Key,
@@ -112,6 +140,17 @@ func (sub *TopicSubscriber) startProcessors() {
 
 }
 
+// processMessage calls the message processing function set on the subscriber
+func (sub *TopicSubscriber) processMessage(ctx context.Context, partition topic.Partition, message *mq_pb.DataMessage) error {
+	if sub.OnEachPartitionDataMessageFunc != nil {
+		return sub.OnEachPartitionDataMessageFunc(partition, message)
+	}
+	if sub.OnEachDataMessageFunc != nil {
+		return sub.OnEachDataMessageFunc(message)
+	}
+	return sub.OnEachMessageFunc(message.Key, message.Value)
+}
+
 func (sub *TopicSubscriber) waitUntilNoOverlappingPartitionInFlight(topicPartition topic.Partition) {
 	foundOverlapping := true
 	for foundOverlapping {
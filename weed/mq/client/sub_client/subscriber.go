@@ -6,6 +6,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
 	"google.golang.org/grpc"
 	"sync"
+	"time"
 )
 
 type SubscriberConfiguration struct {
@@ -15,16 +16,35 @@ type SubscriberConfiguration struct {
 	GrpcDialOption          grpc.DialOption
 	MaxPartitionCount       int32 // how many partitions to process concurrently
 	SlidingWindowSize       int32 // how many messages to process concurrently per partition
+	// the messages not processed successfully within the deadline are delivered again, 0 to redeliver only after reconnecting
+	AckDeadline time.Duration
+	// without heartbeats within the timeout, the partitions are assigned to other instances in the consumer group.
+	// Default is DefaultSessionTimeout.
+	SessionTimeout time.Duration
+	// called in order for each received message, e.g., for metrics or decryption
+	DeliverInterceptors []DeliverInterceptor
+	// called in order after each received message is processed
+	AckInterceptors []AckInterceptor
 }
 
+const DefaultSessionTimeout = 30 * time.Second
+
 type ContentConfiguration struct {
 	Topic            topic.Topic
 	Filter           string
 	PartitionOffsets []*schema_pb.PartitionOffset
+	// for partitions without PartitionOffsets, resume from the offset saved by the consumer group,
+	// or from the latest if none is saved, instead of from now on
+	ResumeFromConsumerGroup bool
+	// for partitions without PartitionOffsets, returns the offset to start from, e.g., tracked outside of the message queue,
+	// or nil to start as without it
+	PartitionOffsetFunc func(partition topic.Partition) (*schema_pb.PartitionOffset, error)
 }
 
 type OnDataMessageFn func(m *mq_pb.SubscribeMessageResponse_Data)
 type OnEachMessageFunc func(key, value []byte) (err error)
+type OnEachDataMessageFunc func(message *mq_pb.DataMessage) (err error)
+type OnEachPartitionDataMessageFunc func(partition topic.Partition, message *mq_pb.DataMessage) (err error)
 type OnCompletionFunc func()
 
 type TopicSubscriber struct {
@@ -34,12 +54,17 @@ type TopicSubscriber struct {
 	brokerPartitionAssignmentAckChan chan *mq_pb.SubscriberToSubCoordinatorRequest
 	OnDataMessageFnnc                OnDataMessageFn
 	OnEachMessageFunc                OnEachMessageFunc
+	OnEachDataMessageFunc            OnEachDataMessageFunc
+	OnEachPartitionDataMessageFunc   OnEachPartitionDataMessageFunc
 	OnCompletionFunc                 OnCompletionFunc
 	bootstrapBrokers                 []string
 	waitForMoreMessage               bool
 	activeProcessors                 map[topic.Partition]*ProcessorState
 	activeProcessorsLock             sync.Mutex
 	PartitionOffsetChan              chan KeyedOffset
+	stopCh                           chan struct{}
+	stopOnce                         sync.Once
+	decompressor                     *topic.MessageDecompressor
 }
 
 func NewTopicSubscriber(bootstrapBrokers []string, subscriber *SubscriberConfiguration, content *ContentConfiguration, partitionOffsetChan chan KeyedOffset) *TopicSubscriber {
@@ -52,13 +77,32 @@ func NewTopicSubscriber(bootstrapBrokers []string, subscriber *SubscriberConfigu
 		waitForMoreMessage:               true,
 		activeProcessors:                 make(map[topic.Partition]*ProcessorState),
 		PartitionOffsetChan:              partitionOffsetChan,
+		stopCh:                           make(chan struct{}),
+		decompressor:                     topic.NewMessageDecompressor(),
 	}
 }
 
+// Shutdown stops the subscriber, and releases the assigned partitions to other consumer group instances
+func (sub *TopicSubscriber) Shutdown() {
+	sub.stopOnce.Do(func() {
+		close(sub.stopCh)
+	})
+}
+
 func (sub *TopicSubscriber) SetEachMessageFunc(onEachMessageFn OnEachMessageFunc) {
 	sub.OnEachMessageFunc = onEachMessageFn
 }
 
+// SetEachDataMessageFunc receives the whole message, including the timestamp and origin, instead of only key and value
+func (sub *TopicSubscriber) SetEachDataMessageFunc(onEachDataMessageFn OnEachDataMessageFunc) {
+	sub.OnEachDataMessageFunc = onEachDataMessageFn
+}
+
+// SetEachPartitionDataMessageFunc receives the whole message, and the partition it is read from
+func (sub *TopicSubscriber) SetEachPartitionDataMessageFunc(onEachPartitionDataMessageFn OnEachPartitionDataMessageFunc) {
+	sub.OnEachPartitionDataMessageFunc = onEachPartitionDataMessageFn
+}
+
 func (sub *TopicSubscriber) SetOnDataMessageFn(fn OnDataMessageFn) {
 	sub.OnDataMessageFnnc = fn
 }
@@ -0,0 +1,52 @@
+package connect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const checkpointsDir = filer.DirectoryEtcSeaweedFS + "/mq_connect"
+
+// partitionCheckpointLocation is one file per partition under the directory of the connector,
+// named after the partition generation and range, e.g., "v2024-01-02-03-04-05_0000-1024"
+func partitionCheckpointLocation(name string, t topic.Topic, p topic.Partition) (dir, fileName string) {
+	partitionDir := strings.TrimPrefix(topic.PartitionDir(t, p), t.Dir()+"/")
+	return util.Join(checkpointsDir, name, t.Namespace, t.Name), strings.ReplaceAll(partitionDir, "/", "_")
+}
+
+// sourceCheckpointLocation is the file for the progress of a source, e.g., the filer metadata changes
+func sourceCheckpointLocation(name, source string) (dir, fileName string) {
+	return util.Join(checkpointsDir, name), source + "_source"
+}
+
+// readCheckpoint returns the timestamp saved in the checkpoint file, or 0 if none yet
+func readCheckpoint(filerClient filer_pb.FilerClient, dir, fileName string) (tsNs int64, err error) {
+	err = filerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		data, readErr := filer.ReadInsideFiler(client, dir, fileName)
+		if readErr == filer_pb.ErrNotFound {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+		if len(data) != 8 {
+			return fmt.Errorf("invalid checkpoint %s/%s of %d bytes", dir, fileName, len(data))
+		}
+		tsNs = int64(util.BytesToUint64(data))
+		return nil
+	})
+	return
+}
+
+func saveCheckpoint(filerClient filer_pb.FilerClient, dir, fileName string, tsNs int64) error {
+	data := make([]byte, 8)
+	util.Uint64toBytes(data, uint64(tsNs))
+	return filerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer.SaveInsideFiler(client, dir, fileName, data)
+	})
+}
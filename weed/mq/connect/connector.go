@@ -0,0 +1,225 @@
+package connect
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/sub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc"
+)
+
+type ConnectorOption struct {
+	Name              string // names the checkpoints, and the consumer group shared by the instances of the connector
+	Topic             topic.Topic
+	Brokers           []string
+	InstanceId        string
+	MaxPartitionCount int32
+	BatchSize         int           // write out a batch with this many messages
+	BatchBytes        int           // or with this many bytes of keys and values
+	FlushInterval     time.Duration // or when the batch is this old
+	StartFromLatest   bool          // for the partitions without checkpoints, start from now on instead of the earliest message
+	GrpcDialOption    grpc.DialOption
+	FilerClient       filer_pb.FilerClient // to save the checkpoints
+}
+
+// Connector consumes a topic, and writes the messages out to the sink in batches of each partition.
+//
+// After a batch is written, the timestamp of its last message is saved in the filer as the checkpoint of the partition.
+// When the partition is assigned again, e.g., after a restart, it is read from the checkpoint, so the messages are
+// written at least once. The batches written again have the same names, so the sinks usually overwrite them.
+type Connector struct {
+	option       *ConnectorOption
+	sink         Sink
+	subscriber   *sub_client.TopicSubscriber
+	batchers     map[topic.Partition]*partitionBatcher
+	batchersLock sync.Mutex
+	stopped      atomic.Bool
+	writtenCount int64
+	batchCount   int64
+}
+
+// partitionBatcher collects the messages of a partition not written out yet
+type partitionBatcher struct {
+	sync.Mutex
+	partition topic.Partition
+	messages  []*mq_pb.DataMessage
+	size      int
+	startedAt time.Time
+}
+
+func NewConnector(option *ConnectorOption, sink Sink) *Connector {
+	return &Connector{
+		option:   option,
+		sink:     sink,
+		batchers: make(map[topic.Partition]*partitionBatcher),
+	}
+}
+
+func (c *Connector) Run() error {
+	if c.option.Name == "" {
+		return fmt.Errorf("connector name is required")
+	}
+	c.subscriber = sub_client.NewTopicSubscriber(c.option.Brokers, &sub_client.SubscriberConfiguration{
+		ConsumerGroup:           "connect-" + c.option.Name,
+		ConsumerGroupInstanceId: c.option.InstanceId,
+		GrpcDialOption:          c.option.GrpcDialOption,
+		MaxPartitionCount:       c.option.MaxPartitionCount,
+		SlidingWindowSize:       1, // keep the order within each partition
+	}, &sub_client.ContentConfiguration{
+		Topic:               c.option.Topic,
+		PartitionOffsetFunc: c.startOffset,
+	}, make(chan sub_client.KeyedOffset, 1024))
+	c.subscriber.SetEachPartitionDataMessageFunc(c.onMessage)
+
+	go c.loopFlush()
+	go c.reportProgress()
+
+	glog.V(0).Infof("connector %s writes topic %v to %s", c.option.Name, c.option.Topic, c.sink.GetName())
+	return c.subscriber.Subscribe()
+}
+
+// Shutdown stops consuming, and tries once to write out the pending batches
+func (c *Connector) Shutdown() {
+	if c.stopped.Swap(true) {
+		return
+	}
+	if c.subscriber != nil {
+		c.subscriber.Shutdown()
+	}
+	c.batchersLock.Lock()
+	defer c.batchersLock.Unlock()
+	for _, b := range c.batchers {
+		b.Lock()
+		c.flush(b)
+		b.Unlock()
+	}
+}
+
+// startOffset resumes the partition from its checkpoint. The pending messages of the partition,
+// from an earlier assignment, are dropped since they are read again.
+func (c *Connector) startOffset(partition topic.Partition) (*schema_pb.PartitionOffset, error) {
+	b := c.getBatcher(partition)
+	b.Lock()
+	b.messages, b.size = nil, 0
+	b.Unlock()
+
+	dir, fileName := partitionCheckpointLocation(c.option.Name, c.option.Topic, partition)
+	tsNs, err := readCheckpoint(c.option.FilerClient, dir, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %v", err)
+	}
+	if tsNs == 0 {
+		if c.option.StartFromLatest {
+			tsNs = time.Now().UnixNano()
+		} else {
+			tsNs = 1
+		}
+	}
+	glog.V(0).Infof("connector %s resumes %v %v from %v", c.option.Name, c.option.Topic, partition, time.Unix(0, tsNs))
+	return &schema_pb.PartitionOffset{
+		Partition: partition.ToPbPartition(),
+		StartTsNs: tsNs,
+	}, nil
+}
+
+func (c *Connector) getBatcher(partition topic.Partition) *partitionBatcher {
+	c.batchersLock.Lock()
+	defer c.batchersLock.Unlock()
+	b, found := c.batchers[partition]
+	if !found {
+		b = &partitionBatcher{partition: partition}
+		c.batchers[partition] = b
+	}
+	return b
+}
+
+// onMessage adds the message to the batch of the partition, and writes out the batch if full.
+// The messages are acknowledged once added, since the progress is tracked by the checkpoints.
+func (c *Connector) onMessage(partition topic.Partition, message *mq_pb.DataMessage) error {
+	b := c.getBatcher(partition)
+	b.Lock()
+	defer b.Unlock()
+	if len(b.messages) == 0 {
+		b.startedAt = time.Now()
+	}
+	b.messages = append(b.messages, message)
+	b.size += len(message.Key) + len(message.Value)
+	if len(b.messages) >= c.option.BatchSize || b.size >= c.option.BatchBytes {
+		c.flush(b)
+	}
+	return nil
+}
+
+func (c *Connector) loopFlush() {
+	for !c.stopped.Load() {
+		time.Sleep(time.Second)
+		c.batchersLock.Lock()
+		batchers := make([]*partitionBatcher, 0, len(c.batchers))
+		for _, b := range c.batchers {
+			batchers = append(batchers, b)
+		}
+		c.batchersLock.Unlock()
+
+		for _, b := range batchers {
+			b.Lock()
+			if len(b.messages) > 0 && time.Since(b.startedAt) >= c.option.FlushInterval {
+				c.flush(b)
+			}
+			b.Unlock()
+		}
+	}
+}
+
+// flush writes out the batch and saves the checkpoint, retrying until succeeded or the connector is stopped.
+// The batcher should be locked.
+func (c *Connector) flush(b *partitionBatcher) {
+	if len(b.messages) == 0 {
+		return
+	}
+	batch := &Batch{
+		Topic:     c.option.Topic,
+		Partition: b.partition,
+		Messages:  b.messages,
+	}
+	var written bool
+	util.RetryUntil(fmt.Sprintf("connector %s write %s", c.option.Name, batch.Name()), func() error {
+		if err := c.sink.Write(batch); err != nil {
+			return fmt.Errorf("write to %s: %v", c.sink.GetName(), err)
+		}
+		if err := c.saveCheckpoint(b.partition, batch.LastTsNs()); err != nil {
+			return fmt.Errorf("save checkpoint: %v", err)
+		}
+		written = true
+		return nil
+	}, func(err error) (shouldContinue bool) {
+		glog.Errorf("connector %s batch %s: %v", c.option.Name, batch.Name(), err)
+		return !c.stopped.Load()
+	})
+	if !written {
+		return
+	}
+	atomic.AddInt64(&c.writtenCount, int64(len(batch.Messages)))
+	atomic.AddInt64(&c.batchCount, 1)
+	b.messages, b.size = nil, 0
+}
+
+func (c *Connector) saveCheckpoint(partition topic.Partition, tsNs int64) error {
+	dir, fileName := partitionCheckpointLocation(c.option.Name, c.option.Topic, partition)
+	return saveCheckpoint(c.option.FilerClient, dir, fileName, tsNs)
+}
+
+func (c *Connector) reportProgress() {
+	for !c.stopped.Load() {
+		time.Sleep(time.Minute)
+		glog.V(0).Infof("connector %s: wrote %d messages in %d batches to %s", c.option.Name,
+			atomic.LoadInt64(&c.writtenCount), atomic.LoadInt64(&c.batchCount), c.sink.GetName())
+	}
+}
@@ -0,0 +1,88 @@
+package connect
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// Sink writes the batches of messages consumed from a topic to an external destination
+type Sink interface {
+	GetName() string
+	Initialize(configuration util.Configuration, prefix string, filerClient filer_pb.FilerClient) error
+	// Write is retried with the same batch until it succeeds, so writing a batch again should be harmless
+	Write(batch *Batch) error
+}
+
+var (
+	Sinks []Sink
+)
+
+// Batch is a run of consecutive messages of one partition
+type Batch struct {
+	Topic     topic.Topic
+	Partition topic.Partition
+	Messages  []*mq_pb.DataMessage
+}
+
+func (b *Batch) FirstTsNs() int64 {
+	return b.Messages[0].TsNs
+}
+
+func (b *Batch) LastTsNs() int64 {
+	return b.Messages[len(b.Messages)-1].TsNs
+}
+
+// Name is unique for the batches of the topic, and stays the same when the batch is written again
+func (b *Batch) Name() string {
+	return fmt.Sprintf("%04d-%04d-%d.jsonl", b.Partition.RangeStart, b.Partition.RangeStop, b.FirstTsNs())
+}
+
+// TimePartition is the hive style directory of the hour when the batch starts, e.g., "dt=2024-01-02/hour=03"
+func (b *Batch) TimePartition() string {
+	return time.Unix(0, b.FirstTsNs()).UTC().Format("dt=2006-01-02/hour=15")
+}
+
+type record struct {
+	TsNs        int64             `json:"ts_ns"`
+	Key         string            `json:"key,omitempty"`
+	KeyBase64   string            `json:"key_base64,omitempty"`
+	Value       string            `json:"value,omitempty"`
+	ValueBase64 string            `json:"value_base64,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// EncodeJsonLines writes one json object per message. The keys and values are kept as text if they are valid UTF-8,
+// otherwise encoded as base64 in the key_base64 and value_base64 fields.
+func (b *Batch) EncodeJsonLines() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, message := range b.Messages {
+		r := record{
+			TsNs:    message.TsNs,
+			Headers: message.Headers,
+		}
+		if utf8.Valid(message.Key) {
+			r.Key = string(message.Key)
+		} else {
+			r.KeyBase64 = base64.StdEncoding.EncodeToString(message.Key)
+		}
+		if utf8.Valid(message.Value) {
+			r.Value = string(message.Value)
+		} else {
+			r.ValueBase64 = base64.StdEncoding.EncodeToString(message.Value)
+		}
+		if err := encoder.Encode(&r); err != nil {
+			return nil, fmt.Errorf("encode message %d: %v", message.TsNs, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
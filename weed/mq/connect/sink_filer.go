@@ -0,0 +1,98 @@
+package connect
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// FilerSink writes each batch as a json lines file under the directory, partitioned by the hour
+type FilerSink struct {
+	filerClient filer_pb.FilerClient
+	dir         string
+	replication string
+	collection  string
+	ttlSec      int32
+	diskType    string
+}
+
+func init() {
+	Sinks = append(Sinks, &FilerSink{})
+}
+
+func (fs *FilerSink) GetName() string {
+	return "filer"
+}
+
+func (fs *FilerSink) Initialize(configuration util.Configuration, prefix string, filerClient filer_pb.FilerClient) error {
+	fs.filerClient = filerClient
+	fs.dir = configuration.GetString(prefix + "directory")
+	fs.replication = configuration.GetString(prefix + "replication")
+	fs.collection = configuration.GetString(prefix + "collection")
+	fs.ttlSec = int32(configuration.GetInt(prefix + "ttlSec"))
+	fs.diskType = configuration.GetString(prefix + "diskType")
+	if fs.dir == "" {
+		return fmt.Errorf("%sdirectory is required", prefix)
+	}
+	glog.V(0).Infof("sink.filer.directory: %v", fs.dir)
+	return nil
+}
+
+func (fs *FilerSink) Write(batch *Batch) error {
+	data, err := batch.EncodeJsonLines()
+	if err != nil {
+		return err
+	}
+	dir := util.Join(fs.dir, batch.TimePartition())
+
+	uploader, err := operation.NewUploader()
+	if err != nil {
+		return err
+	}
+	fileId, uploadResult, err, _ := uploader.UploadWithRetry(
+		fs.filerClient,
+		&filer_pb.AssignVolumeRequest{
+			Count:       1,
+			Replication: fs.replication,
+			Collection:  fs.collection,
+			TtlSec:      fs.ttlSec,
+			DiskType:    fs.diskType,
+			Path:        dir,
+		},
+		&operation.UploadOption{
+			Filename: batch.Name(),
+			MimeType: "application/x-ndjson",
+		},
+		func(host, fileId string) string {
+			return fmt.Sprintf("http://%s/%s", host, fileId)
+		},
+		util.NewBytesReader(data),
+	)
+	if err != nil {
+		return fmt.Errorf("upload %s/%s: %v", dir, batch.Name(), err)
+	}
+
+	// the entry written again replaces the previous one
+	now := time.Now()
+	return fs.filerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+			Directory: dir,
+			Entry: &filer_pb.Entry{
+				Name: batch.Name(),
+				Attributes: &filer_pb.FuseAttributes{
+					Crtime:   now.Unix(),
+					Mtime:    now.Unix(),
+					FileMode: uint32(0644),
+					FileSize: uint64(len(data)),
+					Mime:     "application/x-ndjson",
+					TtlSec:   fs.ttlSec,
+				},
+				Chunks: []*filer_pb.FileChunk{uploadResult.ToPbFileChunk(fileId, 0, now.UnixNano())},
+			},
+		})
+	})
+}
@@ -0,0 +1,79 @@
+package connect
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// S3Sink writes each batch as a json lines object, with the key prefix partitioned by the hour
+type S3Sink struct {
+	conn   s3iface.S3API
+	bucket string
+	prefix string
+}
+
+func init() {
+	Sinks = append(Sinks, &S3Sink{})
+}
+
+func (s3sink *S3Sink) GetName() string {
+	return "s3"
+}
+
+func (s3sink *S3Sink) Initialize(configuration util.Configuration, prefix string, filerClient filer_pb.FilerClient) error {
+	configuration.SetDefault(prefix+"region", "us-east-2")
+	configuration.SetDefault(prefix+"s3_force_path_style", true)
+	s3sink.bucket = configuration.GetString(prefix + "bucket")
+	s3sink.prefix = strings.Trim(configuration.GetString(prefix+"prefix"), "/")
+	if s3sink.bucket == "" {
+		return fmt.Errorf("%sbucket is required", prefix)
+	}
+	glog.V(0).Infof("sink.s3.bucket: %v", s3sink.bucket)
+	glog.V(0).Infof("sink.s3.prefix: %v", s3sink.prefix)
+
+	config := &aws.Config{
+		Region:           aws.String(configuration.GetString(prefix + "region")),
+		Endpoint:         aws.String(configuration.GetString(prefix + "endpoint")),
+		S3ForcePathStyle: aws.Bool(configuration.GetBool(prefix + "s3_force_path_style")),
+	}
+	awsAccessKeyId, awsSecretAccessKey := configuration.GetString(prefix+"aws_access_key_id"), configuration.GetString(prefix+"aws_secret_access_key")
+	if awsAccessKeyId != "" && awsSecretAccessKey != "" {
+		config.Credentials = credentials.NewStaticCredentials(awsAccessKeyId, awsSecretAccessKey, "")
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return fmt.Errorf("create aws session: %v", err)
+	}
+	s3sink.conn = s3.New(sess)
+	return nil
+}
+
+func (s3sink *S3Sink) Write(batch *Batch) error {
+	data, err := batch.EncodeJsonLines()
+	if err != nil {
+		return err
+	}
+	key := batch.TimePartition() + "/" + batch.Name()
+	if s3sink.prefix != "" {
+		key = s3sink.prefix + "/" + key
+	}
+	if _, err = s3sink.conn.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s3sink.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/x-ndjson"),
+	}); err != nil {
+		return fmt.Errorf("put s3://%s/%s: %v", s3sink.bucket, key, err)
+	}
+	return nil
+}
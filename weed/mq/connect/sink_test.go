@@ -0,0 +1,45 @@
+package connect
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+func TestBatchEncodeJsonLines(t *testing.T) {
+	tsNs := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano()
+	batch := &Batch{
+		Topic:     topic.NewTopic("ns", "t"),
+		Partition: topic.Partition{RangeStart: 0, RangeStop: 1024, RingSize: 4096},
+		Messages: []*mq_pb.DataMessage{
+			{TsNs: tsNs, Key: []byte("k1"), Value: []byte(`{"a":1}`), Headers: map[string]string{"h": "v"}},
+			{TsNs: tsNs + 1, Key: []byte("k2"), Value: []byte{0xff, 0xfe}},
+		},
+	}
+	if name := batch.Name(); name != "0000-1024-1704164645000000000.jsonl" {
+		t.Errorf("unexpected name %s", name)
+	}
+	if timePartition := batch.TimePartition(); timePartition != "dt=2024-01-02/hour=03" {
+		t.Errorf("unexpected time partition %s", timePartition)
+	}
+	data, err := batch.EncodeJsonLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	expected := []string{
+		`{"ts_ns":1704164645000000000,"key":"k1","value":"{\"a\":1}","headers":{"h":"v"}}`,
+		`{"ts_ns":1704164645000000001,"key":"k2","value_base64":"//4="}`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %d: %s", len(expected), len(lines), data)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d: expected %s, got %s", i, expected[i], lines[i])
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package connect
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// WebhookSink posts each batch as json lines to the url. The batch name is sent in the X-Seaweedfs-Batch header,
+// so the receiver can skip the batches written again after a restart.
+type WebhookSink struct {
+	url     string
+	headers http.Header
+	client  *http.Client
+}
+
+func init() {
+	Sinks = append(Sinks, &WebhookSink{})
+}
+
+func (ws *WebhookSink) GetName() string {
+	return "webhook"
+}
+
+func (ws *WebhookSink) Initialize(configuration util.Configuration, prefix string, filerClient filer_pb.FilerClient) error {
+	configuration.SetDefault(prefix+"timeoutSeconds", 30)
+	ws.url = configuration.GetString(prefix + "url")
+	if ws.url == "" {
+		return fmt.Errorf("%surl is required", prefix)
+	}
+	ws.headers = make(http.Header)
+	for _, header := range configuration.GetStringSlice(prefix + "headers") {
+		name, value, found := strings.Cut(header, ":")
+		if !found {
+			return fmt.Errorf("%sheaders %q should be in the form of \"Name: value\"", prefix, header)
+		}
+		ws.headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	ws.client = &http.Client{
+		Timeout: time.Duration(configuration.GetInt(prefix+"timeoutSeconds")) * time.Second,
+	}
+	glog.V(0).Infof("sink.webhook.url: %v", ws.url)
+	return nil
+}
+
+func (ws *WebhookSink) Write(batch *Batch) error {
+	data, err := batch.EncodeJsonLines()
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, ws.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for name, values := range ws.headers {
+		request.Header[name] = values
+	}
+	request.Header.Set("Content-Type", "application/x-ndjson")
+	request.Header.Set("X-Seaweedfs-Topic", batch.Topic.String())
+	request.Header.Set("X-Seaweedfs-Batch", batch.Name())
+
+	response, err := ws.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("post %s: %v", ws.url, err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("post %s: %s %s", ws.url, response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package connect
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc"
+)
+
+const (
+	filerSourceCheckpointInterval = 3 * time.Second
+	// the changes since the checkpoint minus the window are published again after a restart, in case the messages
+	// buffered in the publisher were lost. The brokers drop the duplicated ones by their idempotency keys.
+	filerSourceReplayWindow = time.Minute
+)
+
+type FilerSourceOption struct {
+	Name            string // names the checkpoint
+	Topic           topic.Topic
+	Brokers         []string
+	PartitionCount  int32
+	FilerAddress    pb.ServerAddress
+	PathPrefixes    []string
+	StartFromLatest bool // without the checkpoint, start from now on instead of the earliest change in the filer metadata log
+	GrpcDialOption  grpc.DialOption
+	FilerClient     filer_pb.FilerClient // to save the checkpoint
+	ClientId        int32
+}
+
+// MetadataEvent is the message value published for each filer metadata change, keyed by the path
+type MetadataEvent struct {
+	TsNs        int64  `json:"ts_ns"`
+	Type        string `json:"type"` // create, update, delete, or rename
+	Path        string `json:"path"`
+	NewPath     string `json:"new_path,omitempty"` // the path after renaming
+	IsDirectory bool   `json:"is_directory,omitempty"`
+	FileSize    uint64 `json:"file_size,omitempty"`
+	Mtime       int64  `json:"mtime,omitempty"`
+	Mime        string `json:"mime,omitempty"`
+}
+
+// FilerSource publishes the filer metadata changes under the path prefixes to a topic,
+// so the consumers get the file system change feed with the topic retention and replay.
+//
+// The messages keep the timestamps of the changes, and the changes of the same path are in the same partition.
+type FilerSource struct {
+	option      *FilerSourceOption
+	publisher   *pub_client.TopicPublisher
+	clientEpoch int32
+}
+
+func NewFilerSource(option *FilerSourceOption) *FilerSource {
+	return &FilerSource{
+		option: option,
+	}
+}
+
+func (fs *FilerSource) Run() error {
+	if fs.option.Name == "" {
+		return fmt.Errorf("connector name is required")
+	}
+	if len(fs.option.PathPrefixes) == 0 {
+		return fmt.Errorf("path prefixes are required")
+	}
+	dir, fileName := sourceCheckpointLocation(fs.option.Name, "filer")
+	startTsNs, err := readCheckpoint(fs.option.FilerClient, dir, fileName)
+	if err != nil {
+		return fmt.Errorf("read checkpoint: %v", err)
+	}
+	if startTsNs > 0 {
+		startTsNs -= int64(filerSourceReplayWindow)
+	} else if fs.option.StartFromLatest {
+		startTsNs = time.Now().UnixNano()
+	}
+
+	fs.publisher = pub_client.NewTopicPublisher(&pub_client.PublisherConfiguration{
+		Topic:          fs.option.Topic,
+		PartitionCount: fs.option.PartitionCount,
+		Brokers:        fs.option.Brokers,
+		PublisherName:  "connect-" + fs.option.Name,
+		GrpcDialOption: fs.option.GrpcDialOption,
+	})
+
+	processEventFn := pb.AddOffsetFunc(fs.publishEvent, filerSourceCheckpointInterval, func(counter int64, lastTsNs int64) error {
+		glog.V(1).Infof("connector %s published %d filer changes up to %v", fs.option.Name, counter, time.Unix(0, lastTsNs))
+		return saveCheckpoint(fs.option.FilerClient, dir, fileName, lastTsNs)
+	})
+
+	glog.V(0).Infof("connector %s publishes filer %s changes of %v to topic %v since %v", fs.option.Name, fs.option.FilerAddress,
+		fs.option.PathPrefixes, fs.option.Topic, time.Unix(0, startTsNs))
+	fs.clientEpoch++
+	return pb.FollowMetadata(fs.option.FilerAddress, fs.option.GrpcDialOption, &pb.MetadataFollowOption{
+		ClientName:             "connect-" + fs.option.Name,
+		ClientId:               fs.option.ClientId,
+		ClientEpoch:            fs.clientEpoch,
+		PathPrefix:             fs.option.PathPrefixes[0],
+		AdditionalPathPrefixes: fs.option.PathPrefixes[1:],
+		StartTsNs:              startTsNs,
+		EventErrorType:         pb.RetryForeverOnError,
+	}, processEventFn)
+}
+
+// Shutdown sends out the buffered messages
+func (fs *FilerSource) Shutdown() {
+	if fs.publisher == nil {
+		return
+	}
+	if err := fs.publisher.FinishPublish(); err != nil {
+		glog.Errorf("connector %s finish publishing: %v", fs.option.Name, err)
+	}
+	fs.publisher.Shutdown()
+}
+
+func (fs *FilerSource) publishEvent(resp *filer_pb.SubscribeMetadataResponse) error {
+	event := toMetadataEvent(resp)
+	if event == nil {
+		return nil
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal %s event of %s: %v", event.Type, event.Path, err)
+	}
+	return fs.publisher.PublishDataMessage(&mq_pb.DataMessage{
+		Key:            []byte(event.Path),
+		Value:          value,
+		TsNs:           resp.TsNs,
+		IdempotencyKey: fmt.Sprintf("%s-%d", fs.option.Name, resp.TsNs),
+	})
+}
+
+// toMetadataEvent returns nil for the changes not to publish: the topic data, which changes with each publish,
+// and the connector checkpoints
+func toMetadataEvent(resp *filer_pb.SubscribeMetadataResponse) *MetadataEvent {
+	if filer_pb.IsEmpty(resp) {
+		return nil
+	}
+	if isUnder(resp.Directory, filer.TopicsDir) || isUnder(resp.Directory, checkpointsDir) {
+		return nil
+	}
+	message := resp.EventNotification
+	event := &MetadataEvent{
+		TsNs: resp.TsNs,
+	}
+	entry := message.NewEntry
+	switch {
+	case filer_pb.IsCreate(resp):
+		event.Type = "create"
+		event.Path = string(util.NewFullPath(resp.Directory, message.NewEntry.Name))
+	case filer_pb.IsUpdate(resp):
+		event.Type = "update"
+		event.Path = string(util.NewFullPath(resp.Directory, message.NewEntry.Name))
+	case filer_pb.IsDelete(resp):
+		event.Type = "delete"
+		event.Path = string(util.NewFullPath(resp.Directory, message.OldEntry.Name))
+		entry = message.OldEntry
+	default:
+		event.Type = "rename"
+		event.Path = string(util.NewFullPath(resp.Directory, message.OldEntry.Name))
+		event.NewPath = string(util.NewFullPath(message.NewParentPath, message.NewEntry.Name))
+	}
+	event.IsDirectory = entry.IsDirectory
+	event.FileSize = filer.FileSize(entry)
+	if entry.Attributes != nil {
+		event.Mtime = entry.Attributes.Mtime
+		event.Mime = entry.Attributes.Mime
+	}
+	return event
+}
+
+func isUnder(dir, parent string) bool {
+	return dir == parent || strings.HasPrefix(dir, parent+"/")
+}
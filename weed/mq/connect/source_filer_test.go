@@ -0,0 +1,55 @@
+package connect
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func TestToMetadataEvent(t *testing.T) {
+	file := func(name string) *filer_pb.Entry {
+		return &filer_pb.Entry{Name: name, Attributes: &filer_pb.FuseAttributes{FileSize: 10, Mtime: 100}}
+	}
+	tests := []struct {
+		name string
+		resp *filer_pb.SubscribeMetadataResponse
+		want *MetadataEvent
+	}{
+		{
+			name: "create",
+			resp: &filer_pb.SubscribeMetadataResponse{Directory: "/data", TsNs: 1, EventNotification: &filer_pb.EventNotification{NewEntry: file("a"), NewParentPath: "/data"}},
+			want: &MetadataEvent{TsNs: 1, Type: "create", Path: "/data/a", FileSize: 10, Mtime: 100},
+		},
+		{
+			name: "update",
+			resp: &filer_pb.SubscribeMetadataResponse{Directory: "/data", TsNs: 2, EventNotification: &filer_pb.EventNotification{OldEntry: file("a"), NewEntry: file("a"), NewParentPath: "/data"}},
+			want: &MetadataEvent{TsNs: 2, Type: "update", Path: "/data/a", FileSize: 10, Mtime: 100},
+		},
+		{
+			name: "rename",
+			resp: &filer_pb.SubscribeMetadataResponse{Directory: "/data", TsNs: 3, EventNotification: &filer_pb.EventNotification{OldEntry: file("a"), NewEntry: file("b"), NewParentPath: "/other"}},
+			want: &MetadataEvent{TsNs: 3, Type: "rename", Path: "/data/a", NewPath: "/other/b", FileSize: 10, Mtime: 100},
+		},
+		{
+			name: "delete",
+			resp: &filer_pb.SubscribeMetadataResponse{Directory: "/data", TsNs: 4, EventNotification: &filer_pb.EventNotification{OldEntry: &filer_pb.Entry{Name: "d", IsDirectory: true}}},
+			want: &MetadataEvent{TsNs: 4, Type: "delete", Path: "/data/d", IsDirectory: true},
+		},
+		{
+			name: "topic data",
+			resp: &filer_pb.SubscribeMetadataResponse{Directory: "/topics/ns/t", TsNs: 5, EventNotification: &filer_pb.EventNotification{NewEntry: file("a")}},
+		},
+		{
+			name: "checkpoint",
+			resp: &filer_pb.SubscribeMetadataResponse{Directory: "/etc/seaweedfs/mq_connect/c1", TsNs: 6, EventNotification: &filer_pb.EventNotification{NewEntry: file("filer_source")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toMetadataEvent(tt.resp)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("toMetadataEvent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package logstore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+	"google.golang.org/protobuf/proto"
+)
+
+// ExtendedCompacted marks the log files compacted by key, which are only compacted again
+// after more log files age out of the compaction window
+const ExtendedCompacted = "mq.compacted"
+
+// UploadLogSegmentFunc uploads the log segment of the log file, and returns the chunk of it
+type UploadLogSegmentFunc func(targetFile string, segment []byte) (*filer_pb.FileChunk, error)
+
+// CompactTopicFilesByKey keeps only the latest message of each key in the log files older than the window,
+// and drops the keys whose latest message is a tombstone with an empty value.
+// The messages without keys and the log files within the window are kept as they are.
+// It returns the number of the removed messages.
+func CompactTopicFilesByKey(filerClient filer_pb.FilerClient, t topic.Topic, window time.Duration, uploadFn UploadLogSegmentFunc) (removedCount int, err error) {
+	topicVersions, err := collectTopicVersions(filerClient, t, window)
+	if err != nil {
+		return 0, fmt.Errorf("list topic files: %v", err)
+	}
+
+	cutoff := time.Now().Add(-window).Unix()
+	for _, topicVersion := range topicVersions {
+		partitions, err := collectTopicVersionsPartitions(filerClient, t, topicVersion)
+		if err != nil {
+			return removedCount, fmt.Errorf("list partitions %s/%s/%s: %v", t.Namespace, t.Name, topicVersion, err)
+		}
+		for _, partition := range partitions {
+			partitionDir := topic.PartitionDir(t, partition)
+			removed, err := compactPartitionByKey(filerClient, t.String(), partitionDir, cutoff, uploadFn)
+			removedCount += removed
+			if err != nil {
+				return removedCount, fmt.Errorf("compact %s: %v", partitionDir, err)
+			}
+		}
+	}
+	return removedCount, nil
+}
+
+func compactPartitionByKey(filerClient filer_pb.FilerClient, topicName, partitionDir string, cutoff int64, uploadFn UploadLogSegmentFunc) (removedCount int, err error) {
+	var logFiles []*filer_pb.Entry
+	var hasNewOldFile bool
+	err = filer_pb.ReadDirAllEntries(filerClient, util.FullPath(partitionDir), "", func(entry *filer_pb.Entry, isLast bool) error {
+		if entry.IsDirectory || len(entry.Content) > 0 || entry.Attributes == nil {
+			return nil
+		}
+		if _, parseErr := time.Parse(topic.TIME_FORMAT, entry.Name); parseErr != nil {
+			// skip the .offset and the parquet files
+			return nil
+		}
+		logFiles = append(logFiles, entry)
+		if entry.Attributes.Mtime < cutoff && entry.Extended[ExtendedCompacted] == nil {
+			hasNewOldFile = true
+		}
+		return nil
+	})
+	if err != nil || !hasNewOldFile {
+		return
+	}
+
+	// the latest message of each key, including the ones within the window
+	latestTsNs := make(map[string]int64)
+	for _, logFile := range logFiles {
+		if err = readLogFileEntries(filerClient, topicName, logFile, func(logEntry *filer_pb.LogEntry) {
+			if len(logEntry.Key) > 0 {
+				latestTsNs[string(logEntry.Key)] = max(latestTsNs[string(logEntry.Key)], logEntry.TsNs)
+			}
+		}); err != nil {
+			return
+		}
+	}
+
+	// the kept messages are chained with PrevTsNs, so the subscribers do not see the removed ones as gaps
+	var prevTsNs int64
+	for i, logFile := range logFiles {
+		if logFile.Attributes.Mtime >= cutoff {
+			break
+		}
+		var logEntries []*filer_pb.LogEntry
+		if err = readLogFileEntries(filerClient, topicName, logFile, func(logEntry *filer_pb.LogEntry) {
+			logEntries = append(logEntries, logEntry)
+		}); err != nil {
+			return
+		}
+		var kept []*filer_pb.LogEntry
+		kept, prevTsNs = CompactLogEntries(logEntries, latestTsNs, prevTsNs)
+		isChanged := len(kept) != len(logEntries)
+		for j := range kept {
+			isChanged = isChanged || kept[j] != logEntries[j]
+		}
+		removedCount += len(logEntries) - len(kept)
+
+		// the latest log file is kept even if empty, since it has the running totals of the partition
+		if len(kept) == 0 && i < len(logFiles)-1 {
+			if err = filer_pb.Remove(filerClient, partitionDir, logFile.Name, true, false, false, false, nil); err != nil {
+				return removedCount, fmt.Errorf("delete %s: %v", logFile.Name, err)
+			}
+			continue
+		}
+		if isChanged {
+			var segment []byte
+			if segment, err = EncodeLogEntries(kept); err != nil {
+				return
+			}
+			if segment, err = EncryptLogSegment(segment); err != nil {
+				return
+			}
+			var chunk *filer_pb.FileChunk
+			if chunk, err = uploadFn(partitionDir+"/"+logFile.Name, segment); err != nil {
+				return removedCount, fmt.Errorf("upload %s: %v", logFile.Name, err)
+			}
+			logFile.Chunks = []*filer_pb.FileChunk{chunk}
+		}
+		if logFile.Extended == nil {
+			logFile.Extended = make(map[string][]byte)
+		}
+		logFile.Extended[ExtendedCompacted] = []byte{1}
+		if err = filerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+				Directory: partitionDir,
+				Entry:     logFile,
+			})
+		}); err != nil {
+			return removedCount, fmt.Errorf("update %s: %v", logFile.Name, err)
+		}
+	}
+	return
+}
+
+// CompactLogEntries keeps the messages without keys, and the latest message of each key unless it is a tombstone.
+// The PrevTsNs of the kept messages are chained from prevTsNs, and the changed messages are copies.
+func CompactLogEntries(logEntries []*filer_pb.LogEntry, latestTsNs map[string]int64, prevTsNs int64) (kept []*filer_pb.LogEntry, lastTsNs int64) {
+	lastTsNs = prevTsNs
+	for _, logEntry := range logEntries {
+		if len(logEntry.Key) > 0 && (logEntry.TsNs < latestTsNs[string(logEntry.Key)] || len(logEntry.Data) == 0) {
+			continue
+		}
+		if lastTsNs != 0 && logEntry.PrevTsNs != lastTsNs {
+			logEntry = proto.Clone(logEntry).(*filer_pb.LogEntry)
+			logEntry.PrevTsNs = lastTsNs
+		}
+		kept = append(kept, logEntry)
+		lastTsNs = logEntry.TsNs
+	}
+	return
+}
+
+// EncodeLogEntries encodes the log entries into one log segment
+func EncodeLogEntries(logEntries []*filer_pb.LogEntry) ([]byte, error) {
+	var buf []byte
+	for _, logEntry := range logEntries {
+		data, err := proto.Marshal(logEntry)
+		if err != nil {
+			return nil, fmt.Errorf("marshal log entry: %v", err)
+		}
+		buf = appendUint32(buf, uint32(len(data)))
+		buf = append(buf, data...)
+	}
+	return EncodeLogSegment(buf), nil
+}
+
+// readLogFileEntries reads the log entries of all chunks of the log file, decrypted if encrypted
+func readLogFileEntries(filerClient filer_pb.FilerClient, topicName string, logFile *filer_pb.Entry, eachLogEntryFn func(logEntry *filer_pb.LogEntry)) error {
+	lookupFileIdFn := filer.LookupFn(filerClient)
+	for _, chunk := range logFile.Chunks {
+		if chunk.Size == 0 {
+			continue
+		}
+		if chunk.IsChunkManifest {
+			return fmt.Errorf("unexpected chunk manifest in %s", logFile.Name)
+		}
+		urlStrings, err := lookupFileIdFn(chunk.FileId)
+		if err != nil {
+			return fmt.Errorf("lookup %s: %v", chunk.FileId, err)
+		}
+		if len(urlStrings) == 0 {
+			return fmt.Errorf("no url found for %s", chunk.FileId)
+		}
+		var data []byte
+		for _, urlString := range urlStrings {
+			if data, _, err = util_http.Get(urlString); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %v", chunk.FileId, err)
+		}
+		if data, err = DecryptLogSegment(data); err != nil {
+			return fmt.Errorf("read %s: %v", chunk.FileId, err)
+		}
+		corruption, err := DecodeLogSegment(data, func(logEntry *filer_pb.LogEntry) (isDone bool, err error) {
+			eachLogEntryFn(logEntry)
+			return false, nil
+		})
+		if err != nil {
+			return fmt.Errorf("decode %s: %v", chunk.FileId, err)
+		}
+		reportLogSegmentCorruption(topicName, logFile.Name, chunk.FileId, corruption)
+	}
+	return nil
+}
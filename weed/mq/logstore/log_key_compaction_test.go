@@ -0,0 +1,72 @@
+package logstore
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func TestCompactLogEntries(t *testing.T) {
+	logEntries := []*filer_pb.LogEntry{
+		{TsNs: 1, Key: []byte("a"), Data: []byte("a1")},
+		{TsNs: 2, Key: []byte("b"), Data: []byte("b1"), PrevTsNs: 1},
+		{TsNs: 3, Data: []byte("no key"), PrevTsNs: 2},
+		{TsNs: 4, Key: []byte("a"), Data: []byte("a2"), PrevTsNs: 3},
+		{TsNs: 5, Key: []byte("c"), PrevTsNs: 4},
+	}
+	// the latest messages, including the ones within the window
+	latestTsNs := map[string]int64{"a": 4, "b": 7, "c": 5}
+
+	kept, lastTsNs := CompactLogEntries(logEntries, latestTsNs, 0)
+	if len(kept) != 2 || kept[0].TsNs != 3 || kept[1].TsNs != 4 {
+		t.Fatalf("unexpected kept messages %v", kept)
+	}
+	if lastTsNs != 4 {
+		t.Errorf("expected last ts 4, got %d", lastTsNs)
+	}
+	if kept[1].PrevTsNs != 3 {
+		t.Errorf("expected the kept messages chained, got prev ts %d", kept[1].PrevTsNs)
+	}
+	if kept[1] != logEntries[3] {
+		t.Errorf("the unchanged message should not be copied")
+	}
+
+	// chained from the messages kept in the previous log files
+	kept, _ = CompactLogEntries(logEntries, latestTsNs, 100)
+	if kept[0].PrevTsNs != 100 || logEntries[2].PrevTsNs != 2 {
+		t.Errorf("expected a changed copy chained to 100, got %d, original %d", kept[0].PrevTsNs, logEntries[2].PrevTsNs)
+	}
+}
+
+func TestEncodeLogEntries(t *testing.T) {
+	logEntries := []*filer_pb.LogEntry{
+		{TsNs: 1, Key: []byte("a"), Data: []byte("a1")},
+		{TsNs: 2, Key: []byte("b"), Data: []byte("b1"), PrevTsNs: 1},
+	}
+	segment, err := EncodeLogEntries(logEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*filer_pb.LogEntry
+	corruption, err := DecodeLogSegment(segment, func(logEntry *filer_pb.LogEntry) (bool, error) {
+		decoded = append(decoded, logEntry)
+		return false, nil
+	})
+	if err != nil || corruption.IsCorrupted() {
+		t.Fatalf("decode: %v %+v", err, corruption)
+	}
+	if len(decoded) != 2 || decoded[1].TsNs != 2 || string(decoded[1].Data) != "b1" || decoded[1].PrevTsNs != 1 {
+		t.Errorf("unexpected decoded messages %v", decoded)
+	}
+
+	// an empty segment is still valid
+	if segment, err = EncodeLogEntries(nil); err != nil {
+		t.Fatal(err)
+	}
+	if corruption, err = DecodeLogSegment(segment, func(logEntry *filer_pb.LogEntry) (bool, error) {
+		t.Errorf("unexpected message %v", logEntry)
+		return false, nil
+	}); err != nil || corruption.IsCorrupted() {
+		t.Errorf("decode empty segment: %v %+v", err, corruption)
+	}
+}
@@ -0,0 +1,153 @@
+package logstore
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// LoadLogSegmentEncryption reads the [encryption] section of mq_broker.toml, nil if the encryption is not enabled
+func LoadLogSegmentEncryption() (*LogSegmentEncryption, error) {
+	if !util.LoadConfiguration("mq_broker", false) {
+		return nil, nil
+	}
+	v := util.GetViper()
+	if !v.GetBool("encryption.enabled") {
+		return nil, nil
+	}
+	var keys LogKeyProvider
+	var err error
+	switch keyType := v.GetString("encryption.type"); keyType {
+	case "keyfile":
+		keys, err = NewKeyFileLogKeyProvider(v.GetString("encryption.keyfile"))
+	case "aws_kms":
+		keys, err = NewAwsKmsLogKeyProvider(v.GetString("encryption.aws_kms_key_id"), v.GetString("encryption.aws_region"))
+	default:
+		err = fmt.Errorf("unknown encryption type %q", keyType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewLogSegmentEncryption(keys), nil
+}
+
+// keyFileLogKeyProvider wraps the data keys with the master keys of a key file
+type keyFileLogKeyProvider struct {
+	currentKeyId string
+	masterKeys   map[string]util.CipherKey
+}
+
+// NewKeyFileLogKeyProvider reads the key file of the lines "<key id> <base64 of a 32 byte key>".
+// The last key wraps the new data keys, and the earlier keys are kept to unwrap the data keys of the older segments.
+func NewKeyFileLogKeyProvider(keyFile string) (LogKeyProvider, error) {
+	f, err := os.Open(util.ResolvePath(keyFile))
+	if err != nil {
+		return nil, fmt.Errorf("open key file: %v", err)
+	}
+	defer f.Close()
+
+	p := &keyFileLogKeyProvider{
+		masterKeys: make(map[string]util.CipherKey),
+	}
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || len(fields[0]) > 255 {
+			return nil, fmt.Errorf("key file %s line %d: expecting \"<key id> <base64 key>\"", keyFile, lineNumber)
+		}
+		key, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("key file %s line %d: the key should be 32 bytes in base64", keyFile, lineNumber)
+		}
+		p.currentKeyId = fields[0]
+		p.masterKeys[fields[0]] = key
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read key file %s: %v", keyFile, err)
+	}
+	if p.currentKeyId == "" {
+		return nil, fmt.Errorf("no keys in key file %s", keyFile)
+	}
+	return p, nil
+}
+
+// GenerateDataKey wraps the data key as: key id size byte, key id, and the data key encrypted by the master key
+func (p *keyFileLogKeyProvider) GenerateDataKey() (dataKey, wrappedKey []byte, err error) {
+	dataKey = util.GenCipherKey()
+	encryptedKey, err := util.Encrypt(dataKey, p.masterKeys[p.currentKeyId])
+	if err != nil {
+		return nil, nil, err
+	}
+	wrappedKey = append([]byte{byte(len(p.currentKeyId))}, p.currentKeyId...)
+	return dataKey, append(wrappedKey, encryptedKey...), nil
+}
+
+func (p *keyFileLogKeyProvider) UnwrapDataKey(wrappedKey []byte) ([]byte, error) {
+	if len(wrappedKey) == 0 || 1+int(wrappedKey[0]) > len(wrappedKey) {
+		return nil, fmt.Errorf("invalid wrapped key")
+	}
+	keyId := string(wrappedKey[1 : 1+int(wrappedKey[0])])
+	masterKey, found := p.masterKeys[keyId]
+	if !found {
+		return nil, fmt.Errorf("key %s is not in the key file", keyId)
+	}
+	return util.Decrypt(wrappedKey[1+int(wrappedKey[0]):], masterKey)
+}
+
+// awsKmsLogKeyProvider generates the data keys with the AWS KMS, which keeps the master key.
+// The credentials are from the environment, the shared credentials file, or the instance role.
+type awsKmsLogKeyProvider struct {
+	client *kms.KMS
+	keyId  string
+}
+
+func NewAwsKmsLogKeyProvider(keyId, region string) (LogKeyProvider, error) {
+	if keyId == "" {
+		return nil, fmt.Errorf("missing aws kms key id")
+	}
+	config := &aws.Config{}
+	if region != "" {
+		config.Region = aws.String(region)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, fmt.Errorf("create aws session: %v", err)
+	}
+	return &awsKmsLogKeyProvider{
+		client: kms.New(sess),
+		keyId:  keyId,
+	}, nil
+}
+
+func (p *awsKmsLogKeyProvider) GenerateDataKey() (dataKey, wrappedKey []byte, err error) {
+	resp, err := p.client.GenerateDataKey(&kms.GenerateDataKeyInput{
+		KeyId:   aws.String(p.keyId),
+		KeySpec: aws.String(kms.DataKeySpecAes256),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Plaintext, resp.CiphertextBlob, nil
+}
+
+func (p *awsKmsLogKeyProvider) UnwrapDataKey(wrappedKey []byte) ([]byte, error) {
+	// the wrapped key tells the kms key, which may be an older one than the configured
+	resp, err := p.client.Decrypt(&kms.DecryptInput{
+		CiphertextBlob: wrappedKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,52 @@
+package logstore
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// DeleteExpiredTopicFiles deletes the log files and parquet files of the topic last written before the retention,
+// so all their messages are older than the retention. The consumer group offsets are kept.
+func DeleteExpiredTopicFiles(filerClient filer_pb.FilerClient, t topic.Topic, retention time.Duration) (deletedCount int, err error) {
+	// the partition generations created within the retention have no expired files
+	topicVersions, err := collectTopicVersions(filerClient, t, retention)
+	if err != nil {
+		return 0, fmt.Errorf("list topic files: %v", err)
+	}
+
+	cutoff := time.Now().Add(-retention).Unix()
+	for _, topicVersion := range topicVersions {
+		partitions, err := collectTopicVersionsPartitions(filerClient, t, topicVersion)
+		if err != nil {
+			return deletedCount, fmt.Errorf("list partitions %s/%s/%s: %v", t.Namespace, t.Name, topicVersion, err)
+		}
+		for _, partition := range partitions {
+			partitionDir := topic.PartitionDir(t, partition)
+			var expiredFiles []string
+			err = filer_pb.ReadDirAllEntries(filerClient, util.FullPath(partitionDir), "", func(entry *filer_pb.Entry, isLast bool) error {
+				if entry.IsDirectory || strings.HasSuffix(entry.Name, ".offset") {
+					return nil
+				}
+				if entry.Attributes != nil && entry.Attributes.Mtime < cutoff {
+					expiredFiles = append(expiredFiles, entry.Name)
+				}
+				return nil
+			})
+			if err != nil {
+				return deletedCount, fmt.Errorf("list %s: %v", partitionDir, err)
+			}
+			for _, name := range expiredFiles {
+				if err = filer_pb.Remove(filerClient, partitionDir, name, true, false, false, false, nil); err != nil {
+					return deletedCount, fmt.Errorf("delete %s/%s: %v", partitionDir, name, err)
+				}
+				deletedCount++
+			}
+		}
+	}
+	return deletedCount, nil
+}
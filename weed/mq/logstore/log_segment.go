@@ -0,0 +1,199 @@
+package logstore
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/protobuf/proto"
+)
+
+// A log segment is the data of one flush, uploaded as one chunk of a partition log file:
+//
+//	header:  magic 0xff 'S' 'W' 'L', version uint32
+//	records: size uint32, crc32c of the data uint32, data of the marshalled filer_pb.LogEntry
+//	footer:  magic 0xff 'E' 'O' 'S', record count uint32, records length uint32, crc32c of the footer so far
+//
+// Segments written before the header was introduced are just the records of [size uint32][data].
+// They never start with 0xff, since the size of one log entry can not be that large.
+
+const (
+	LogSegmentVersion = 1
+
+	logSegmentHeaderSize = 8
+	logSegmentFooterSize = 16
+	logRecordHeaderSize  = 8
+)
+
+var (
+	logSegmentHeaderMagic = []byte{0xff, 'S', 'W', 'L'}
+	logSegmentFooterMagic = []byte{0xff, 'E', 'O', 'S'}
+	crc32cTable           = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// LogSegmentCorruption is what DecodeLogSegment skipped over
+type LogSegmentCorruption struct {
+	CorruptedRecords int  // records failing the checksum or the unmarshalling
+	Truncated        bool // the footer is missing or does not match the records, so the tail may be lost
+}
+
+func (c LogSegmentCorruption) IsCorrupted() bool {
+	return c.CorruptedRecords > 0 || c.Truncated
+}
+
+// EncodeLogSegment converts the [size uint32][data] records flushed from the log buffer into a log segment
+func EncodeLogSegment(buf []byte) []byte {
+	var recordCount, recordsLength uint32
+	for pos := 0; pos+4 <= len(buf); {
+		size := int(util.BytesToUint32(buf[pos : pos+4]))
+		if pos+4+size > len(buf) {
+			break
+		}
+		recordCount++
+		recordsLength += uint32(logRecordHeaderSize + size)
+		pos += 4 + size
+	}
+
+	segment := make([]byte, 0, logSegmentHeaderSize+int(recordsLength)+logSegmentFooterSize)
+	segment = append(segment, logSegmentHeaderMagic...)
+	segment = appendUint32(segment, LogSegmentVersion)
+	for pos, i := 0, uint32(0); i < recordCount; i++ {
+		size := int(util.BytesToUint32(buf[pos : pos+4]))
+		data := buf[pos+4 : pos+4+size]
+		segment = appendUint32(segment, uint32(size))
+		segment = appendUint32(segment, crc32.Checksum(data, crc32cTable))
+		segment = append(segment, data...)
+		pos += 4 + size
+	}
+	footerStart := len(segment)
+	segment = append(segment, logSegmentFooterMagic...)
+	segment = appendUint32(segment, recordCount)
+	segment = appendUint32(segment, recordsLength)
+	segment = appendUint32(segment, crc32.Checksum(segment[footerStart:], crc32cTable))
+	return segment
+}
+
+// DecodeLogSegment calls eachLogEntryFn for each intact log entry, in either the segment or the legacy format.
+// Corrupted records are skipped, and the records before a truncation are still delivered.
+// The returned error is only from eachLogEntryFn, or for an unknown segment version.
+func DecodeLogSegment(buf []byte, eachLogEntryFn func(logEntry *filer_pb.LogEntry) (isDone bool, err error)) (corruption LogSegmentCorruption, err error) {
+	if len(buf) == 0 {
+		return
+	}
+	if !bytes.HasPrefix(buf, logSegmentHeaderMagic[:1]) {
+		return decodeLegacyLogSegment(buf, eachLogEntryFn)
+	}
+	if len(buf) < logSegmentHeaderSize || !bytes.HasPrefix(buf, logSegmentHeaderMagic) {
+		corruption.Truncated = true
+		return
+	}
+	if version := util.BytesToUint32(buf[4:8]); version != LogSegmentVersion {
+		err = fmt.Errorf("unsupported log segment version %d", version)
+		return
+	}
+
+	records := buf[logSegmentHeaderSize:]
+	recordCount, hasFooter := parseLogSegmentFooter(buf)
+	if hasFooter {
+		records = records[:len(records)-logSegmentFooterSize]
+	} else {
+		corruption.Truncated = true
+	}
+
+	var seenCount uint32
+	pos := 0
+	for ; pos+logRecordHeaderSize <= len(records); seenCount++ {
+		size := int(util.BytesToUint32(records[pos : pos+4]))
+		checksum := util.BytesToUint32(records[pos+4 : pos+8])
+		if pos+logRecordHeaderSize+size > len(records) {
+			break
+		}
+		data := records[pos+logRecordHeaderSize : pos+logRecordHeaderSize+size]
+		pos += logRecordHeaderSize + size
+
+		if crc32.Checksum(data, crc32cTable) != checksum {
+			corruption.CorruptedRecords++
+			continue
+		}
+		logEntry := &filer_pb.LogEntry{}
+		if unmarshalErr := proto.Unmarshal(data, logEntry); unmarshalErr != nil {
+			corruption.CorruptedRecords++
+			continue
+		}
+		var isDone bool
+		if isDone, err = eachLogEntryFn(logEntry); err != nil || isDone {
+			return
+		}
+	}
+	if pos != len(records) || (hasFooter && seenCount != recordCount) {
+		corruption.Truncated = true
+	}
+	return
+}
+
+func decodeLegacyLogSegment(buf []byte, eachLogEntryFn func(logEntry *filer_pb.LogEntry) (isDone bool, err error)) (corruption LogSegmentCorruption, err error) {
+	pos := 0
+	for pos+4 <= len(buf) {
+		size := int(util.BytesToUint32(buf[pos : pos+4]))
+		if pos+4+size > len(buf) {
+			break
+		}
+		data := buf[pos+4 : pos+4+size]
+		pos += 4 + size
+
+		logEntry := &filer_pb.LogEntry{}
+		if unmarshalErr := proto.Unmarshal(data, logEntry); unmarshalErr != nil {
+			corruption.CorruptedRecords++
+			continue
+		}
+		var isDone bool
+		if isDone, err = eachLogEntryFn(logEntry); err != nil || isDone {
+			return
+		}
+	}
+	if pos != len(buf) {
+		corruption.Truncated = true
+	}
+	return
+}
+
+func parseLogSegmentFooter(buf []byte) (recordCount uint32, ok bool) {
+	if len(buf) < logSegmentHeaderSize+logSegmentFooterSize {
+		return
+	}
+	footer := buf[len(buf)-logSegmentFooterSize:]
+	if !bytes.HasPrefix(footer, logSegmentFooterMagic) {
+		return
+	}
+	if crc32.Checksum(footer[:12], crc32cTable) != util.BytesToUint32(footer[12:16]) {
+		return
+	}
+	recordsLength := util.BytesToUint32(footer[8:12])
+	if logSegmentHeaderSize+int(recordsLength)+logSegmentFooterSize != len(buf) {
+		return
+	}
+	return util.BytesToUint32(footer[4:8]), true
+}
+
+// reportLogSegmentCorruption counts and logs the corruption found in one chunk of a partition log file
+func reportLogSegmentCorruption(topicName, fileName, fileId string, corruption LogSegmentCorruption) {
+	if !corruption.IsCorrupted() {
+		return
+	}
+	if corruption.CorruptedRecords > 0 {
+		stats.MqLogCorruptionCounter.WithLabelValues(topicName, "record").Add(float64(corruption.CorruptedRecords))
+		glog.Warningf("skipped %d corrupted records in %s %s of topic %s", corruption.CorruptedRecords, fileName, fileId, topicName)
+	}
+	if corruption.Truncated {
+		stats.MqLogCorruptionCounter.WithLabelValues(topicName, "truncated").Inc()
+		glog.Warningf("truncated log segment in %s %s of topic %s", fileName, fileId, topicName)
+	}
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
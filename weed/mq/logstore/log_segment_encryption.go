@@ -0,0 +1,155 @@
+package logstore
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// An encrypted log segment wraps the whole log segment with AES-GCM:
+//
+//	header:     magic 0xff 'S' 'W' 'X', version uint32, wrapped data key size uint32, wrapped data key
+//	ciphertext: nonce, the encrypted log segment and the tag, as util.Encrypt()
+//
+// The data key is wrapped by the LogKeyProvider, e.g., with a master key of a key file or by a KMS,
+// and is reused for the segments flushed within logDataKeyRotation.
+
+const (
+	LogSegmentEncryptionVersion = 1
+
+	logDataKeyRotation    = time.Hour
+	maxUnwrappedDataKeys  = 1024
+	encryptedSegmentStart = 12
+)
+
+var encryptedLogSegmentMagic = []byte{0xff, 'S', 'W', 'X'}
+
+// SegmentEncryption encrypts the log segments flushed by the brokers, and decrypts them when read.
+// The log segments are written in plain text if it is nil.
+var SegmentEncryption *LogSegmentEncryption
+
+// LogKeyProvider creates the data keys encrypting the log segments, and wraps them to be stored with the segments
+type LogKeyProvider interface {
+	GenerateDataKey() (dataKey, wrappedKey []byte, err error)
+	UnwrapDataKey(wrappedKey []byte) (dataKey []byte, err error)
+}
+
+type LogSegmentEncryption struct {
+	keys LogKeyProvider
+
+	sync.Mutex
+	dataKey          []byte
+	wrappedKey       []byte
+	dataKeyCreatedAt time.Time
+	unwrappedKeys    map[string][]byte
+}
+
+func NewLogSegmentEncryption(keys LogKeyProvider) *LogSegmentEncryption {
+	return &LogSegmentEncryption{
+		keys:          keys,
+		unwrappedKeys: make(map[string][]byte),
+	}
+}
+
+// EncryptLogSegment encrypts the log segment, or returns it as is without SegmentEncryption
+func EncryptLogSegment(segment []byte) ([]byte, error) {
+	if SegmentEncryption == nil {
+		return segment, nil
+	}
+	return SegmentEncryption.Encrypt(segment)
+}
+
+// DecryptLogSegment decrypts the encrypted log segment, or returns the plain one as is
+func DecryptLogSegment(data []byte) ([]byte, error) {
+	if !IsEncryptedLogSegment(data) {
+		return data, nil
+	}
+	if SegmentEncryption == nil {
+		return nil, fmt.Errorf("log segment is encrypted, but the encryption is not configured")
+	}
+	return SegmentEncryption.Decrypt(data)
+}
+
+func IsEncryptedLogSegment(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedLogSegmentMagic)
+}
+
+func (e *LogSegmentEncryption) Encrypt(segment []byte) ([]byte, error) {
+	dataKey, wrappedKey, err := e.currentDataKey()
+	if err != nil {
+		return nil, fmt.Errorf("generate data key: %v", err)
+	}
+	ciphertext, err := util.Encrypt(segment, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt log segment: %v", err)
+	}
+	encrypted := make([]byte, 0, encryptedSegmentStart+len(wrappedKey)+len(ciphertext))
+	encrypted = append(encrypted, encryptedLogSegmentMagic...)
+	encrypted = appendUint32(encrypted, LogSegmentEncryptionVersion)
+	encrypted = appendUint32(encrypted, uint32(len(wrappedKey)))
+	encrypted = append(encrypted, wrappedKey...)
+	return append(encrypted, ciphertext...), nil
+}
+
+func (e *LogSegmentEncryption) Decrypt(data []byte) ([]byte, error) {
+	if len(data) < encryptedSegmentStart {
+		return nil, fmt.Errorf("encrypted log segment of %d bytes is truncated", len(data))
+	}
+	if version := util.BytesToUint32(data[4:8]); version != LogSegmentEncryptionVersion {
+		return nil, fmt.Errorf("unsupported encrypted log segment version %d", version)
+	}
+	wrappedKeySize := int(util.BytesToUint32(data[8:12]))
+	if encryptedSegmentStart+wrappedKeySize > len(data) {
+		return nil, fmt.Errorf("encrypted log segment of %d bytes is truncated", len(data))
+	}
+	wrappedKey := data[encryptedSegmentStart : encryptedSegmentStart+wrappedKeySize]
+	dataKey, err := e.unwrapDataKey(wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %v", err)
+	}
+	segment, err := util.Decrypt(data[encryptedSegmentStart+wrappedKeySize:], dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt log segment: %v", err)
+	}
+	return segment, nil
+}
+
+// currentDataKey reuses the data key for a while, so the KMS is not called for every flush
+func (e *LogSegmentEncryption) currentDataKey() (dataKey, wrappedKey []byte, err error) {
+	e.Lock()
+	defer e.Unlock()
+	if e.dataKey != nil && time.Since(e.dataKeyCreatedAt) < logDataKeyRotation {
+		return e.dataKey, e.wrappedKey, nil
+	}
+	if dataKey, wrappedKey, err = e.keys.GenerateDataKey(); err != nil {
+		return
+	}
+	e.dataKey, e.wrappedKey, e.dataKeyCreatedAt = dataKey, wrappedKey, time.Now()
+	return
+}
+
+// unwrapDataKey caches the unwrapped data keys, since the segments of a while share one data key
+func (e *LogSegmentEncryption) unwrapDataKey(wrappedKey []byte) ([]byte, error) {
+	e.Lock()
+	dataKey, found := e.unwrappedKeys[string(wrappedKey)]
+	e.Unlock()
+	if found {
+		return dataKey, nil
+	}
+
+	dataKey, err := e.keys.UnwrapDataKey(wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Lock()
+	defer e.Unlock()
+	if len(e.unwrappedKeys) >= maxUnwrappedDataKeys {
+		e.unwrappedKeys = make(map[string][]byte)
+	}
+	e.unwrappedKeys[string(wrappedKey)] = dataKey
+	return dataKey, nil
+}
@@ -0,0 +1,187 @@
+package logstore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/protobuf/proto"
+)
+
+func toLogBufferFormat(t *testing.T, count int) []byte {
+	var buf []byte
+	for i := 0; i < count; i++ {
+		data, err := proto.Marshal(&filer_pb.LogEntry{TsNs: int64(i + 1), Key: []byte("key"), Data: []byte("value")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizeBuf := make([]byte, 4)
+		util.Uint32toBytes(sizeBuf, uint32(len(data)))
+		buf = append(buf, sizeBuf...)
+		buf = append(buf, data...)
+	}
+	return buf
+}
+
+func decodeTimestamps(t *testing.T, buf []byte) (tsNs []int64, corruption LogSegmentCorruption) {
+	corruption, err := DecodeLogSegment(buf, func(logEntry *filer_pb.LogEntry) (isDone bool, err error) {
+		tsNs = append(tsNs, logEntry.TsNs)
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestLogSegmentRoundTrip(t *testing.T) {
+	segment := EncodeLogSegment(toLogBufferFormat(t, 3))
+	tsNs, corruption := decodeTimestamps(t, segment)
+	if len(tsNs) != 3 || tsNs[0] != 1 || tsNs[2] != 3 {
+		t.Errorf("decoded %v", tsNs)
+	}
+	if corruption.IsCorrupted() {
+		t.Errorf("unexpected corruption %+v", corruption)
+	}
+
+	// a flush without any records
+	if tsNs, corruption = decodeTimestamps(t, EncodeLogSegment(nil)); len(tsNs) != 0 || corruption.IsCorrupted() {
+		t.Errorf("empty segment: %v %+v", tsNs, corruption)
+	}
+}
+
+func TestLogSegmentCorruptedRecord(t *testing.T) {
+	segment := EncodeLogSegment(toLogBufferFormat(t, 3))
+	// flip one byte of the data of the second record
+	recordSize := (len(segment) - logSegmentHeaderSize - logSegmentFooterSize) / 3
+	segment[logSegmentHeaderSize+recordSize+logRecordHeaderSize+2] ^= 0xff
+
+	tsNs, corruption := decodeTimestamps(t, segment)
+	if len(tsNs) != 2 || tsNs[0] != 1 || tsNs[1] != 3 {
+		t.Errorf("decoded %v", tsNs)
+	}
+	if corruption.CorruptedRecords != 1 || corruption.Truncated {
+		t.Errorf("unexpected corruption %+v", corruption)
+	}
+}
+
+func TestLogSegmentTruncated(t *testing.T) {
+	segment := EncodeLogSegment(toLogBufferFormat(t, 3))
+	// cut off the footer and the tail of the last record
+	segment = segment[:len(segment)-logSegmentFooterSize-3]
+
+	tsNs, corruption := decodeTimestamps(t, segment)
+	if len(tsNs) != 2 {
+		t.Errorf("decoded %v", tsNs)
+	}
+	if !corruption.Truncated || corruption.CorruptedRecords != 0 {
+		t.Errorf("unexpected corruption %+v", corruption)
+	}
+
+	// only the footer is lost
+	segment = EncodeLogSegment(toLogBufferFormat(t, 3))
+	tsNs, corruption = decodeTimestamps(t, segment[:len(segment)-logSegmentFooterSize])
+	if len(tsNs) != 3 || !corruption.Truncated {
+		t.Errorf("decoded %v with %+v", tsNs, corruption)
+	}
+}
+
+func TestLogSegmentLegacyFormat(t *testing.T) {
+	buf := toLogBufferFormat(t, 3)
+	tsNs, corruption := decodeTimestamps(t, buf)
+	if len(tsNs) != 3 || corruption.IsCorrupted() {
+		t.Errorf("decoded %v with %+v", tsNs, corruption)
+	}
+
+	tsNs, corruption = decodeTimestamps(t, buf[:len(buf)-1])
+	if len(tsNs) != 2 || !corruption.Truncated {
+		t.Errorf("decoded %v with %+v", tsNs, corruption)
+	}
+}
+
+func TestLogSegmentStopEarly(t *testing.T) {
+	var count int
+	_, err := DecodeLogSegment(EncodeLogSegment(toLogBufferFormat(t, 3)), func(logEntry *filer_pb.LogEntry) (isDone bool, err error) {
+		count++
+		return logEntry.TsNs == 2, nil
+	})
+	if err != nil || count != 2 {
+		t.Errorf("processed %d entries: %v", count, err)
+	}
+}
+
+func TestLogSegmentEncryption(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "mq_log.keys")
+	writeKeys := func(keyIds ...string) {
+		var lines []byte
+		for _, keyId := range keyIds {
+			lines = append(lines, keyId+" "+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte(keyId[len(keyId)-1:]), 32))+"\n"...)
+		}
+		if err := os.WriteFile(keyFile, lines, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newEncryption := func() *LogSegmentEncryption {
+		keys, err := NewKeyFileLogKeyProvider(keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewLogSegmentEncryption(keys)
+	}
+	defer func() { SegmentEncryption = nil }()
+
+	writeKeys("key1")
+	SegmentEncryption = newEncryption()
+	segment := EncodeLogSegment(toLogBufferFormat(t, 3))
+	encrypted, err := EncryptLogSegment(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncryptedLogSegment(encrypted) || bytes.Contains(encrypted, []byte("value")) {
+		t.Fatalf("segment is not encrypted")
+	}
+
+	// the older segments are still read after a new key is added
+	writeKeys("key1", "key2")
+	SegmentEncryption = newEncryption()
+	decrypted, err := DecryptLogSegment(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tsNs, corruption := decodeTimestamps(t, decrypted); len(tsNs) != 3 || corruption.IsCorrupted() {
+		t.Fatalf("decrypted %v %+v", tsNs, corruption)
+	}
+
+	// the plain segments are read as is
+	if plain, err := DecryptLogSegment(segment); err != nil || !bytes.Equal(plain, segment) {
+		t.Fatalf("plain segment: %v", err)
+	}
+
+	// without the key, or tampered
+	writeKeys("key2")
+	SegmentEncryption = newEncryption()
+	if _, err = DecryptLogSegment(encrypted); err == nil {
+		t.Fatalf("decrypted without the key")
+	}
+	writeKeys("key1")
+	SegmentEncryption = newEncryption()
+	encrypted[len(encrypted)-1] ^= 1
+	if _, err = DecryptLogSegment(encrypted); err == nil {
+		t.Fatalf("decrypted the tampered segment")
+	}
+}
+
+func TestCountLogRecords(t *testing.T) {
+	buf := toLogBufferFormat(t, 3)
+	if messages, bytes := CountLogRecords(buf); messages != 3 || bytes != int64(len(buf)) {
+		t.Fatalf("counted %d messages of %d bytes, expected 3 of %d", messages, bytes, len(buf))
+	}
+	// a partial record is not counted
+	if messages, _ := CountLogRecords(buf[:len(buf)-1]); messages != 2 {
+		t.Fatalf("counted %d messages in the truncated buffer", messages)
+	}
+}
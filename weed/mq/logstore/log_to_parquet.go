@@ -88,10 +88,10 @@ func compactTopicPartition(filerClient filer_pb.FilerClient, t topic.Topic, time
 	partitionDir := topic.PartitionDir(t, partition)
 
 	// compact the partition directory
-	return compactTopicPartitionDir(filerClient, t.Name, partitionDir, timeAgo, recordType, preference)
+	return compactTopicPartitionDir(filerClient, t, partitionDir, timeAgo, recordType, preference)
 }
 
-func compactTopicPartitionDir(filerClient filer_pb.FilerClient, topicName, partitionDir string, timeAgo time.Duration, recordType *schema_pb.RecordType, preference *operation.StoragePreference) error {
+func compactTopicPartitionDir(filerClient filer_pb.FilerClient, t topic.Topic, partitionDir string, timeAgo time.Duration, recordType *schema_pb.RecordType, preference *operation.StoragePreference) error {
 	// read all existing parquet files
 	minTsNs, maxTsNs, err := readAllParquetFiles(filerClient, partitionDir)
 	if err != nil {
@@ -117,14 +117,14 @@ func compactTopicPartitionDir(filerClient filer_pb.FilerClient, topicName, parti
 	}
 
 	// create a parquet schema
-	parquetSchema, err := schema.ToParquetSchema(topicName, recordType)
+	parquetSchema, err := schema.ToParquetSchema(t.Name, recordType)
 	if err != nil {
 		return fmt.Errorf("ToParquetSchema failed: %v", err)
 	}
 
 	// TODO parallelize the writing
 	for _, logFileGroup := range logFileGroups {
-		if err = writeLogFilesToParquet(filerClient, partitionDir, recordType, logFileGroup, parquetSchema, parquetLevels, preference); err != nil {
+		if err = writeLogFilesToParquet(filerClient, t.String(), partitionDir, recordType, logFileGroup, parquetSchema, parquetLevels, preference); err != nil {
 			return err
 		}
 	}
@@ -205,7 +205,7 @@ func readAllParquetFiles(filerClient filer_pb.FilerClient, partitionDir string)
 	return
 }
 
-func writeLogFilesToParquet(filerClient filer_pb.FilerClient, partitionDir string, recordType *schema_pb.RecordType, logFileGroups []*filer_pb.Entry, parquetSchema *parquet.Schema, parquetLevels *schema.ParquetLevels, preference *operation.StoragePreference) (err error) {
+func writeLogFilesToParquet(filerClient filer_pb.FilerClient, topicName, partitionDir string, recordType *schema_pb.RecordType, logFileGroups []*filer_pb.Entry, parquetSchema *parquet.Schema, parquetLevels *schema.ParquetLevels, preference *operation.StoragePreference) (err error) {
 
 	tempFile, err := os.CreateTemp(".", "t*.parquet")
 	if err != nil {
@@ -224,7 +224,7 @@ func writeLogFilesToParquet(filerClient filer_pb.FilerClient, partitionDir strin
 	for _, logFile := range logFileGroups {
 		fmt.Printf("compact %s/%s ", partitionDir, logFile.Name)
 		var rows []parquet.Row
-		if err := iterateLogEntries(filerClient, logFile, func(entry *filer_pb.LogEntry) error {
+		if err := iterateLogEntries(filerClient, topicName, logFile, func(entry *filer_pb.LogEntry) error {
 
 			if startTsNs == 0 {
 				startTsNs = entry.TsNs
@@ -366,9 +366,9 @@ func saveParquetFileToPartitionDir(filerClient filer_pb.FilerClient, sourceFile
 	return nil
 }
 
-func iterateLogEntries(filerClient filer_pb.FilerClient, logFile *filer_pb.Entry, eachLogEntryFn func(entry *filer_pb.LogEntry) error) error {
+func iterateLogEntries(filerClient filer_pb.FilerClient, topicName string, logFile *filer_pb.Entry, eachLogEntryFn func(entry *filer_pb.LogEntry) error) error {
 	lookupFn := filer.LookupFn(filerClient)
-	_, err := eachFile(logFile, lookupFn, func(logEntry *filer_pb.LogEntry) (isDone bool, err error) {
+	_, err := eachFile(topicName, logFile, lookupFn, func(logEntry *filer_pb.LogEntry) (isDone bool, err error) {
 		if err := eachLogEntryFn(logEntry); err != nil {
 			return true, err
 		}
@@ -377,7 +377,7 @@ func iterateLogEntries(filerClient filer_pb.FilerClient, logFile *filer_pb.Entry
 	return err
 }
 
-func eachFile(entry *filer_pb.Entry, lookupFileIdFn func(fileId string) (targetUrls []string, err error), eachLogEntryFn log_buffer.EachLogEntryFuncType) (processedTsNs int64, err error) {
+func eachFile(topicName string, entry *filer_pb.Entry, lookupFileIdFn func(fileId string) (targetUrls []string, err error), eachLogEntryFn log_buffer.EachLogEntryFuncType) (processedTsNs int64, err error) {
 	if len(entry.Content) > 0 {
 		// skip .offset files
 		return
@@ -407,9 +407,16 @@ func eachFile(entry *filer_pb.Entry, lookupFileIdFn func(fileId string) (targetU
 			var data []byte
 			if data, _, err = util_http.Get(urlString); err == nil {
 				processed = true
-				if processedTsNs, err = eachChunk(data, eachLogEntryFn); err != nil {
+				// the parquet files are in plain text
+				if IsEncryptedLogSegment(data) {
+					err = fmt.Errorf("log file %s is encrypted, and is not compacted into parquet files", entry.Name)
 					return
 				}
+				var corruption LogSegmentCorruption
+				if processedTsNs, corruption, err = eachChunk(data, eachLogEntryFn); err != nil {
+					return
+				}
+				reportLogSegmentCorruption(topicName, entry.Name, chunk.FileId, corruption)
 				break
 			}
 		}
@@ -422,33 +429,13 @@ func eachFile(entry *filer_pb.Entry, lookupFileIdFn func(fileId string) (targetU
 	return
 }
 
-func eachChunk(buf []byte, eachLogEntryFn log_buffer.EachLogEntryFuncType) (processedTsNs int64, err error) {
-	for pos := 0; pos+4 < len(buf); {
-
-		size := util.BytesToUint32(buf[pos : pos+4])
-		if pos+4+int(size) > len(buf) {
-			err = fmt.Errorf("reach each log chunk: read [%d,%d) from [0,%d)", pos, pos+int(size)+4, len(buf))
-			return
-		}
-		entryData := buf[pos+4 : pos+4+int(size)]
-
-		logEntry := &filer_pb.LogEntry{}
-		if err = proto.Unmarshal(entryData, logEntry); err != nil {
-			pos += 4 + int(size)
-			err = fmt.Errorf("unexpected unmarshal mq_pb.Message: %v", err)
-			return
-		}
-
+func eachChunk(buf []byte, eachLogEntryFn log_buffer.EachLogEntryFuncType) (processedTsNs int64, corruption LogSegmentCorruption, err error) {
+	corruption, err = DecodeLogSegment(buf, func(logEntry *filer_pb.LogEntry) (isDone bool, err error) {
 		if _, err = eachLogEntryFn(logEntry); err != nil {
-			err = fmt.Errorf("process log entry %v: %v", logEntry, err)
-			return
+			return true, fmt.Errorf("process log entry %v: %v", logEntry, err)
 		}
-
 		processedTsNs = logEntry.TsNs
-
-		pos += 4 + int(size)
-
-	}
-
+		return false, nil
+	})
 	return
 }
@@ -0,0 +1,80 @@
+package logstore
+
+import (
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The running totals of the partition, and the timestamp of its last message, are kept in the extended attributes
+// of each log file, so they are saved together with the appended log segments.
+const (
+	ExtendedTotalMessages = "mq.total_messages"
+	ExtendedTotalBytes    = "mq.total_bytes"
+	ExtendedLastTsNs      = "mq.last_ts_ns"
+)
+
+// CountLogRecords counts the [size uint32][data] records flushed from the log buffer, and their bytes including the sizes
+func CountLogRecords(buf []byte) (messages, bytes int64) {
+	for pos := 0; pos+4 <= len(buf); {
+		size := int(util.BytesToUint32(buf[pos : pos+4]))
+		if pos+4+size > len(buf) {
+			break
+		}
+		messages++
+		bytes += int64(4 + size)
+		pos += 4 + size
+	}
+	return
+}
+
+// SetLogFileTotals keeps the totals of the partition up to the end of the log file
+func SetLogFileTotals(entry *filer_pb.Entry, messages, bytes int64) {
+	if entry.Extended == nil {
+		entry.Extended = make(map[string][]byte)
+	}
+	totalMessages, totalBytes := make([]byte, 8), make([]byte, 8)
+	util.Uint64toBytes(totalMessages, uint64(messages))
+	util.Uint64toBytes(totalBytes, uint64(bytes))
+	entry.Extended[ExtendedTotalMessages] = totalMessages
+	entry.Extended[ExtendedTotalBytes] = totalBytes
+}
+
+// ReadPartitionTotals reads the totals kept with the latest log file of the partition, 0 if none
+func ReadPartitionTotals(filerClient filer_pb.FilerClient, partitionDir string) (messages, bytes int64, err error) {
+	var latestName string
+	err = filer_pb.ReadDirAllEntries(filerClient, util.FullPath(partitionDir), "", func(entry *filer_pb.Entry, isLast bool) error {
+		totalMessages, totalBytes := entry.Extended[ExtendedTotalMessages], entry.Extended[ExtendedTotalBytes]
+		if entry.IsDirectory || len(totalMessages) != 8 || len(totalBytes) != 8 || entry.Name < latestName {
+			return nil
+		}
+		latestName = entry.Name
+		messages, bytes = int64(util.BytesToUint64(totalMessages)), int64(util.BytesToUint64(totalBytes))
+		return nil
+	})
+	return
+}
+
+// SetLogFileLastTsNs keeps the timestamp of the last message of the partition up to the end of the log file
+func SetLogFileLastTsNs(entry *filer_pb.Entry, tsNs int64) {
+	if entry.Extended == nil {
+		entry.Extended = make(map[string][]byte)
+	}
+	lastTsNs := make([]byte, 8)
+	util.Uint64toBytes(lastTsNs, uint64(tsNs))
+	entry.Extended[ExtendedLastTsNs] = lastTsNs
+}
+
+// ReadPartitionLastTsNs reads the timestamp of the last message kept with the latest log file of the partition, 0 if none
+func ReadPartitionLastTsNs(filerClient filer_pb.FilerClient, partitionDir string) (tsNs int64, err error) {
+	var latestName string
+	err = filer_pb.ReadDirAllEntries(filerClient, util.FullPath(partitionDir), "", func(entry *filer_pb.Entry, isLast bool) error {
+		lastTsNs := entry.Extended[ExtendedLastTsNs]
+		if entry.IsDirectory || len(lastTsNs) != 8 || entry.Name < latestName {
+			return nil
+		}
+		latestName = entry.Name
+		tsNs = int64(util.BytesToUint64(lastTsNs))
+		return nil
+	})
+	return
+}
@@ -6,32 +6,30 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
 )
 
-func GenMergedReadFunc(filerClient filer_pb.FilerClient, t topic.Topic, p topic.Partition) log_buffer.LogReadFromDiskFuncType {
-	fromParquetFn := GenParquetReadFunc(filerClient, t, p)
-	readLogDirectFn := GenLogOnDiskReadFunc(filerClient, t, p)
+// GenMergedReadFunc reads the persisted messages of the partition, first from the parquet files, and then from the log files.
+// The messages are streamed from the volume servers to the subscriber with bounded prefetch, without going through the
+// in-memory log buffer of the partition, so a subscriber starting from the earliest does not add to the broker memory.
+func GenMergedReadFunc(filerClient filer_pb.FilerClient, t topic.Topic, p topic.Partition, prefetchCount int) log_buffer.LogReadFromDiskFuncType {
+	fromParquetFn := GenParquetReadFunc(filerClient, t, p, prefetchCount)
+	readLogDirectFn := GenLogOnDiskReadFunc(filerClient, t, p, prefetchCount)
 	return mergeReadFuncs(fromParquetFn, readLogDirectFn)
 }
 
+// mergeReadFuncs is shared by all subscribers of the partition, so each read checks the parquet files again,
+// which skips the parquet files older than the start position.
 func mergeReadFuncs(fromParquetFn, readLogDirectFn log_buffer.LogReadFromDiskFuncType) log_buffer.LogReadFromDiskFuncType {
-	var exhaustedParquet bool
-	var lastProcessedPosition log_buffer.MessagePosition
 	return func(startPosition log_buffer.MessagePosition, stopTsNs int64, eachLogEntryFn log_buffer.EachLogEntryFuncType) (lastReadPosition log_buffer.MessagePosition, isDone bool, err error) {
-		if !exhaustedParquet {
+		// the topic without a record type has no parquet files
+		if fromParquetFn != nil {
 			// glog.V(4).Infof("reading from parquet startPosition: %v\n", startPosition.UTC())
 			lastReadPosition, isDone, err = fromParquetFn(startPosition, stopTsNs, eachLogEntryFn)
 			// glog.V(4).Infof("read from parquet: %v %v %v %v\n", startPosition, lastReadPosition, isDone, err)
-			if isDone {
-				isDone = false
-			}
 			if err != nil {
 				return
 			}
-			lastProcessedPosition = lastReadPosition
-		}
-		exhaustedParquet = true
-
-		if startPosition.Before(lastProcessedPosition.Time) {
-			startPosition = lastProcessedPosition
+			if startPosition.Before(lastReadPosition.Time) {
+				startPosition = lastReadPosition
+			}
 		}
 
 		// glog.V(4).Infof("reading from direct log startPosition: %v\n", startPosition.UTC())
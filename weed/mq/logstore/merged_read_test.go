@@ -0,0 +1,57 @@
+package logstore
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
+)
+
+// genTestReadFunc reads the messages with the timestamps, like the parquet or the log files do
+func genTestReadFunc(tsNsList ...int64) log_buffer.LogReadFromDiskFuncType {
+	return func(startPosition log_buffer.MessagePosition, stopTsNs int64, eachLogEntryFn log_buffer.EachLogEntryFuncType) (lastReadPosition log_buffer.MessagePosition, isDone bool, err error) {
+		var processedTsNs int64
+		for _, tsNs := range tsNsList {
+			if tsNs < startPosition.UnixNano() {
+				continue
+			}
+			if _, err = eachLogEntryFn(&filer_pb.LogEntry{TsNs: tsNs}); err != nil {
+				return
+			}
+			processedTsNs = tsNs
+		}
+		return log_buffer.NewMessagePosition(processedTsNs, -2), false, nil
+	}
+}
+
+func TestMergeReadFuncs(t *testing.T) {
+	readFn := mergeReadFuncs(genTestReadFunc(1, 2, 3), genTestReadFunc(4, 5, 6))
+
+	// every subscriber starting from the earliest reads the parquet files, and then the log files after them
+	for subscriber := 0; subscriber < 2; subscriber++ {
+		var read []int64
+		lastReadPosition, _, err := readFn(log_buffer.NewMessagePosition(1, -3), 0, func(logEntry *filer_pb.LogEntry) (bool, error) {
+			read = append(read, logEntry.TsNs)
+			return false, nil
+		})
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if len(read) != 6 || read[0] != 1 || read[5] != 6 {
+			t.Errorf("subscriber %d read %v", subscriber, read)
+		}
+		if lastReadPosition.UnixNano() != 6 {
+			t.Errorf("subscriber %d last read %d", subscriber, lastReadPosition.UnixNano())
+		}
+	}
+
+	// the topic without parquet files only reads the log files
+	readFn = mergeReadFuncs(nil, genTestReadFunc(4, 5, 6))
+	var count int
+	if _, _, err := readFn(log_buffer.NewMessagePosition(4, -2), 0, func(logEntry *filer_pb.LogEntry) (bool, error) {
+		count++
+		return false, nil
+	}); err != nil || count != 3 {
+		t.Errorf("read %d messages: %v", count, err)
+	}
+}
@@ -1,144 +1,166 @@
 package logstore
 
 import (
+	"context"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
-	"github.com/seaweedfs/seaweedfs/weed/util"
 	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
 	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
-	"google.golang.org/protobuf/proto"
 	"math"
 	"strings"
 	"time"
 )
 
-func GenLogOnDiskReadFunc(filerClient filer_pb.FilerClient, t topic.Topic, p topic.Partition) log_buffer.LogReadFromDiskFuncType {
+// GenLogOnDiskReadFunc reads the log files of the partition, fetching up to prefetchCount chunks ahead
+// from the volume servers while the current chunk is processed, to catch up faster on large backlogs
+func GenLogOnDiskReadFunc(filerClient filer_pb.FilerClient, t topic.Topic, p topic.Partition, prefetchCount int) log_buffer.LogReadFromDiskFuncType {
 	partitionDir := topic.PartitionDir(t, p)
 
 	lookupFileIdFn := filer.LookupFn(filerClient)
 
-	eachChunkFn := func(buf []byte, eachLogEntryFn log_buffer.EachLogEntryFuncType, starTsNs, stopTsNs int64) (processedTsNs int64, err error) {
-		for pos := 0; pos+4 < len(buf); {
-
-			size := util.BytesToUint32(buf[pos : pos+4])
-			if pos+4+int(size) > len(buf) {
-				err = fmt.Errorf("GenLogOnDiskReadFunc: read [%d,%d) from [0,%d)", pos, pos+int(size)+4, len(buf))
-				return
-			}
-			entryData := buf[pos+4 : pos+4+int(size)]
-
-			logEntry := &filer_pb.LogEntry{}
-			if err = proto.Unmarshal(entryData, logEntry); err != nil {
-				pos += 4 + int(size)
-				err = fmt.Errorf("unexpected unmarshal mq_pb.Message: %v", err)
-				return
-			}
+	eachChunkFn := func(buf []byte, eachLogEntryFn log_buffer.EachLogEntryFuncType, starTsNs, stopTsNs int64) (processedTsNs int64, corruption LogSegmentCorruption, err error) {
+		corruption, err = DecodeLogSegment(buf, func(logEntry *filer_pb.LogEntry) (isDone bool, err error) {
 			if logEntry.TsNs < starTsNs {
-				pos += 4 + int(size)
-				continue
+				return false, nil
 			}
 			if stopTsNs != 0 && logEntry.TsNs > stopTsNs {
-				println("stopTsNs", stopTsNs, "logEntry.TsNs", logEntry.TsNs)
-				return
+				return true, nil
 			}
 
 			// fmt.Printf(" read logEntry: %v, ts %v\n", string(logEntry.Key), time.Unix(0, logEntry.TsNs).UTC())
 			if _, err = eachLogEntryFn(logEntry); err != nil {
-				err = fmt.Errorf("process log entry %v: %v", logEntry, err)
-				return
+				return true, fmt.Errorf("process log entry %v: %v", logEntry, err)
 			}
 
 			processedTsNs = logEntry.TsNs
-
-			pos += 4 + int(size)
-
-		}
-
+			return false, nil
+		})
 		return
 	}
 
-	eachFileFn := func(entry *filer_pb.Entry, eachLogEntryFn log_buffer.EachLogEntryFuncType, starTsNs, stopTsNs int64) (processedTsNs int64, err error) {
-		if len(entry.Content) > 0 {
-			// skip .offset files
-			return
+	// fetchChunkFn tries the volume servers of the chunk until one succeeds
+	fetchChunkFn := func(fileId string) (data []byte, err error) {
+		urlStrings, err := lookupFileIdFn(fileId)
+		if err != nil {
+			return nil, fmt.Errorf("lookup %s: %v", fileId, err)
 		}
-		var urlStrings []string
-		for _, chunk := range entry.Chunks {
-			if chunk.Size == 0 {
-				continue
-			}
-			if chunk.IsChunkManifest {
-				glog.Warningf("this should not happen. unexpected chunk manifest in %s/%s", partitionDir, entry.Name)
-				return
-			}
-			urlStrings, err = lookupFileIdFn(chunk.FileId)
-			if err != nil {
-				err = fmt.Errorf("lookup %s: %v", chunk.FileId, err)
-				return
-			}
-			if len(urlStrings) == 0 {
-				err = fmt.Errorf("no url found for %s", chunk.FileId)
-				return
-			}
-
-			// try one of the urlString until util.Get(urlString) succeeds
-			var processed bool
-			for _, urlString := range urlStrings {
-				// TODO optimization opportunity: reuse the buffer
-				var data []byte
-				// fmt.Printf("reading %s/%s %s\n", partitionDir, entry.Name, urlString)
-				if data, _, err = util_http.Get(urlString); err == nil {
-					processed = true
-					if processedTsNs, err = eachChunkFn(data, eachLogEntryFn, starTsNs, stopTsNs); err != nil {
-						return
-					}
-					break
-				}
-			}
-			if !processed {
-				err = fmt.Errorf("no data processed for %s %s", entry.Name, chunk.FileId)
-				return
+		if len(urlStrings) == 0 {
+			return nil, fmt.Errorf("no url found for %s", fileId)
+		}
+		for _, urlString := range urlStrings {
+			if data, _, err = util_http.Get(urlString); err == nil {
+				break
 			}
-
 		}
-		return
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %v", fileId, err)
+		}
+		if data, err = DecryptLogSegment(data); err != nil {
+			return nil, fmt.Errorf("read %s: %v", fileId, err)
+		}
+		return data, nil
 	}
 
 	return func(startPosition log_buffer.MessagePosition, stopTsNs int64, eachLogEntryFn log_buffer.EachLogEntryFuncType) (lastReadPosition log_buffer.MessagePosition, isDone bool, err error) {
 		startFileName := startPosition.UTC().Format(topic.TIME_FORMAT)
 		startTsNs := startPosition.Time.UnixNano()
 		stopTime := time.Unix(0, stopTsNs)
-		var processedTsNs int64
-		err = filerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
-			return filer_pb.SeaweedList(client, partitionDir, "", func(entry *filer_pb.Entry, isLast bool) error {
-				if entry.IsDirectory {
-					return nil
-				}
-				if strings.HasSuffix(entry.Name, ".parquet") {
-					return nil
-				}
-				// FIXME: this is a hack to skip the .offset files
-				if strings.HasSuffix(entry.Name, ".offset") {
-					return nil
-				}
-				if stopTsNs != 0 && entry.Name > stopTime.UTC().Format(topic.TIME_FORMAT) {
-					isDone = true
-					return nil
-				}
-				if entry.Name < startPosition.UTC().Format(topic.TIME_FORMAT) {
+
+		// list the log files and fetch the chunks ahead, while the fetched chunks are processed in order
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		fetchedChunks := make(chan chan *prefetchedChunk, max(prefetchCount, 0))
+		var listErr error
+		var isListedPastStop bool
+		go func() {
+			defer close(fetchedChunks)
+			listErr = filerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+				return filer_pb.SeaweedList(client, partitionDir, "", func(entry *filer_pb.Entry, isLast bool) error {
+					if entry.IsDirectory {
+						return nil
+					}
+					if strings.HasSuffix(entry.Name, ".parquet") {
+						return nil
+					}
+					// FIXME: this is a hack to skip the .offset files
+					if strings.HasSuffix(entry.Name, ".offset") {
+						return nil
+					}
+					if stopTsNs != 0 && entry.Name > stopTime.UTC().Format(topic.TIME_FORMAT) {
+						isListedPastStop = true
+						return nil
+					}
+					if entry.Name < startPosition.UTC().Format(topic.TIME_FORMAT) {
+						return nil
+					}
+					if len(entry.Content) > 0 {
+						// skip .offset files
+						return nil
+					}
+					for _, chunk := range entry.Chunks {
+						if chunk.IsChunkManifest {
+							glog.Warningf("this should not happen. unexpected chunk manifest in %s/%s", partitionDir, entry.Name)
+							return nil
+						}
+					}
+					for _, chunk := range entry.Chunks {
+						if chunk.Size == 0 {
+							continue
+						}
+						fetched := make(chan *prefetchedChunk, 1)
+						select {
+						case fetchedChunks <- fetched:
+						case <-ctx.Done():
+							return ctx.Err()
+						}
+						go func(entryName, fileId string) {
+							data, fetchErr := fetchChunkFn(fileId)
+							fetched <- &prefetchedChunk{entryName: entryName, fileId: fileId, data: data, err: fetchErr}
+						}(entry.Name, chunk.FileId)
+					}
 					return nil
-				}
-				if processedTsNs, err = eachFileFn(entry, eachLogEntryFn, startTsNs, stopTsNs); err != nil {
-					return err
-				}
-				return nil
 
-			}, startFileName, true, math.MaxInt32)
-		})
+				}, startFileName, true, math.MaxInt32)
+			})
+		}()
+
+		var processedTsNs int64
+		for fetched := range fetchedChunks {
+			chunk := <-fetched
+			if chunk.err != nil {
+				err = fmt.Errorf("no data processed for %s %s: %v", chunk.entryName, chunk.fileId, chunk.err)
+				break
+			}
+			chunkProcessedTsNs, corruption, processErr := eachChunkFn(chunk.data, eachLogEntryFn, startTsNs, stopTsNs)
+			if processErr != nil {
+				err = processErr
+				break
+			}
+			reportLogSegmentCorruption(t.String(), chunk.entryName, chunk.fileId, corruption)
+			if chunkProcessedTsNs > 0 {
+				processedTsNs = chunkProcessedTsNs
+			}
+		}
+		// stop listing and fetching, and wait for the listing to finish
+		cancel()
+		for range fetchedChunks {
+		}
+		if err == nil {
+			err = listErr
+			isDone = isListedPastStop
+		}
+
 		lastReadPosition = log_buffer.NewMessagePosition(processedTsNs, -2)
 		return
 	}
 }
+
+type prefetchedChunk struct {
+	entryName string
+	fileId    string
+	data      []byte
+	err       error
+}
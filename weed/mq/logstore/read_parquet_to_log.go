@@ -9,7 +9,6 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
-	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
 	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
 	"google.golang.org/protobuf/proto"
 	"io"
@@ -17,11 +16,10 @@ import (
 	"strings"
 )
 
-var (
-	chunkCache = chunk_cache.NewChunkCacheInMemory(256) // 256 entries, 8MB max per entry
-)
-
-func GenParquetReadFunc(filerClient filer_pb.FilerClient, t topic.Topic, p topic.Partition) log_buffer.LogReadFromDiskFuncType {
+// GenParquetReadFunc reads the parquet files of the partition. The parquet files are the oldest messages,
+// usually read once by a subscriber starting from the earliest, so they are streamed from the volume servers
+// with up to prefetchCount chunks fetched ahead, and not kept in any broker cache.
+func GenParquetReadFunc(filerClient filer_pb.FilerClient, t topic.Topic, p topic.Partition, prefetchCount int) log_buffer.LogReadFromDiskFuncType {
 	partitionDir := topic.PartitionDir(t, p)
 
 	lookupFileIdFn := filer.LookupFn(filerClient)
@@ -56,8 +54,9 @@ func GenParquetReadFunc(filerClient filer_pb.FilerClient, t topic.Topic, p topic
 		fileSize := filer.FileSize(entry)
 		visibleIntervals, _ := filer.NonOverlappingVisibleIntervals(lookupFileIdFn, entry.Chunks, 0, int64(fileSize))
 		chunkViews := filer.ViewFromVisibleIntervals(visibleIntervals, 0, int64(fileSize))
-		readerCache := filer.NewReaderCache(32, chunkCache, lookupFileIdFn)
+		readerCache := filer.NewReaderCache(max(prefetchCount, 1), noChunkCache{}, lookupFileIdFn)
 		readerAt := filer.NewChunkReaderAtFromClient(readerCache, chunkViews, int64(fileSize))
+		defer readerAt.Close()
 
 		// create parquet reader
 		parquetReader := parquet.NewReader(readerAt, parquetSchema)
@@ -105,7 +104,6 @@ func GenParquetReadFunc(filerClient filer_pb.FilerClient, t topic.Topic, p topic
 				return processedTsNs, readErr
 			}
 		}
-		return
 	}
 
 	return func(startPosition log_buffer.MessagePosition, stopTsNs int64, eachLogEntryFn log_buffer.EachLogEntryFuncType) (lastReadPosition log_buffer.MessagePosition, isDone bool, err error) {
@@ -160,3 +158,21 @@ func GenParquetReadFunc(filerClient filer_pb.FilerClient, t topic.Topic, p topic
 		return
 	}
 }
+
+// noChunkCache caches nothing, so the chunks read once are freed as soon as they are read
+type noChunkCache struct{}
+
+func (noChunkCache) ReadChunkAt(data []byte, fileId string, offset uint64) (n int, err error) {
+	return 0, nil
+}
+
+func (noChunkCache) SetChunk(fileId string, data []byte) {
+}
+
+func (noChunkCache) IsInCache(fileId string, lockNeeded bool) bool {
+	return false
+}
+
+func (noChunkCache) GetMaxFilePartSizeInCache() uint64 {
+	return 0
+}
@@ -0,0 +1,226 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/sub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"google.golang.org/grpc"
+)
+
+type MirrorOption struct {
+	SourceCluster     string // name of the source cluster, recorded as the origin of the mirrored messages
+	TargetCluster     string // name of the target cluster, messages originated from it are not mirrored back
+	SourceBrokers     []string
+	TargetBrokers     []string
+	Namespace         string // mirror all topics in the namespace, if Topics is empty
+	Topics            []topic.Topic
+	ConsumerGroup     string
+	InstanceId        string
+	MaxPartitionCount int32
+	GrpcDialOption    grpc.DialOption
+}
+
+// Mirror subscribes to topics on the source cluster, and republishes the messages to the target cluster.
+//
+// Offsets in the message queue are timestamps. The mirrored messages are published with their source timestamps,
+// but the target broker moves a timestamp to just after the last one of the partition, if not later,
+// e.g., when the target partition also has local publishers, or mirrors several source clusters.
+// So a consumer moving to the target cluster should resume from an earlier offset, and skip the duplicates.
+//
+// The progress is tracked by the consumer group on the source cluster. A message is acked to the source cluster
+// only after the target broker acks it, so the messages are mirrored at least once.
+//
+// To avoid loops in bi-directional mirroring, each mirrored message carries its origin cluster,
+// and messages originated from the target cluster are skipped.
+type Mirror struct {
+	option         *MirrorOption
+	mirroredCount  int64
+	skippedCount   int64
+	publishers     map[topic.Topic]*pub_client.TopicPublisher
+	publishersLock sync.Mutex
+}
+
+// dataMessagePublisher publishes the mirrored messages to the target cluster, and calls back once acked
+type dataMessagePublisher interface {
+	PublishDataMessageAsync(message *mq_pb.DataMessage, callback pub_client.PublishCallback) error
+}
+
+func NewMirror(option *MirrorOption) *Mirror {
+	return &Mirror{
+		option:     option,
+		publishers: make(map[topic.Topic]*pub_client.TopicPublisher),
+	}
+}
+
+func (m *Mirror) Run() error {
+	if m.option.SourceCluster == "" || m.option.TargetCluster == "" {
+		return fmt.Errorf("source and target cluster names are required")
+	}
+	if m.option.SourceCluster == m.option.TargetCluster {
+		return fmt.Errorf("source and target cluster names should be different")
+	}
+
+	topics := m.option.Topics
+	if len(topics) == 0 {
+		sourceTopics, err := m.listSourceTopics()
+		if err != nil {
+			return fmt.Errorf("list topics on %v: %v", m.option.SourceBrokers, err)
+		}
+		topics = sourceTopics
+	}
+	if len(topics) == 0 {
+		return fmt.Errorf("no topics to mirror")
+	}
+
+	go m.reportProgress()
+
+	var wg sync.WaitGroup
+	for _, t := range topics {
+		wg.Add(1)
+		go func(t topic.Topic) {
+			defer wg.Done()
+			if err := m.mirrorTopic(t); err != nil {
+				glog.Errorf("mirror topic %v: %v", t, err)
+			}
+		}(t)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (m *Mirror) mirrorTopic(t topic.Topic) error {
+	partitionCount, err := m.lookupPartitionCount(t)
+	if err != nil {
+		return fmt.Errorf("lookup source topic: %v", err)
+	}
+
+	publisher := pub_client.NewTopicPublisher(&pub_client.PublisherConfiguration{
+		Topic:          t,
+		PartitionCount: partitionCount,
+		Brokers:        m.option.TargetBrokers,
+		PublisherName:  fmt.Sprintf("mirror-%s-%s", m.option.SourceCluster, m.option.InstanceId),
+	})
+	m.publishersLock.Lock()
+	m.publishers[t] = publisher
+	m.publishersLock.Unlock()
+
+	subscriber := sub_client.NewTopicSubscriber(m.option.SourceBrokers, &sub_client.SubscriberConfiguration{
+		ConsumerGroup:           m.option.ConsumerGroup,
+		ConsumerGroupInstanceId: m.option.InstanceId,
+		GrpcDialOption:          m.option.GrpcDialOption,
+		MaxPartitionCount:       m.option.MaxPartitionCount,
+		SlidingWindowSize:       1, // keep the order within each partition
+	}, &sub_client.ContentConfiguration{
+		Topic:                   t,
+		ResumeFromConsumerGroup: true,
+	}, make(chan sub_client.KeyedOffset, 1024))
+
+	subscriber.SetEachDataMessageFunc(func(message *mq_pb.DataMessage) error {
+		return m.mirrorMessage(publisher, message)
+	})
+
+	glog.V(0).Infof("mirror topic %v from %s to %s", t, m.option.SourceCluster, m.option.TargetCluster)
+	return subscriber.Subscribe()
+}
+
+// mirrorMessage returns after the target broker acks the message, so the subscriber only acks the mirrored messages
+func (m *Mirror) mirrorMessage(publisher dataMessagePublisher, message *mq_pb.DataMessage) error {
+	if message.Origin == m.option.TargetCluster {
+		// this message was mirrored from the target cluster
+		atomic.AddInt64(&m.skippedCount, 1)
+		return nil
+	}
+	origin := message.Origin
+	if origin == "" {
+		origin = m.option.SourceCluster
+	}
+	acked := make(chan error, 1)
+	if err := publisher.PublishDataMessageAsync(&mq_pb.DataMessage{
+		Key:     message.Key,
+		Value:   message.Value,
+		TsNs:    message.TsNs,
+		Origin:  origin,
+		Headers: message.Headers,
+	}, func(_ *mq_pb.DataMessage, err error) {
+		acked <- err
+	}); err != nil {
+		return err
+	}
+	if err := <-acked; err != nil {
+		return fmt.Errorf("publish to %s: %v", m.option.TargetCluster, err)
+	}
+	atomic.AddInt64(&m.mirroredCount, 1)
+	return nil
+}
+
+// Shutdown flushes the messages buffered in the publishers
+func (m *Mirror) Shutdown() {
+	m.publishersLock.Lock()
+	defer m.publishersLock.Unlock()
+	for t, publisher := range m.publishers {
+		if err := publisher.FinishPublish(); err != nil {
+			glog.Errorf("finish publishing topic %v: %v", t, err)
+		}
+	}
+}
+
+func (m *Mirror) reportProgress() {
+	for {
+		time.Sleep(time.Minute)
+		glog.V(0).Infof("mirror %s => %s: mirrored %d messages, skipped %d looped messages", m.option.SourceCluster, m.option.TargetCluster,
+			atomic.LoadInt64(&m.mirroredCount), atomic.LoadInt64(&m.skippedCount))
+	}
+}
+
+func (m *Mirror) listSourceTopics() (topics []topic.Topic, err error) {
+	err = m.withSourceBroker(func(client mq_pb.SeaweedMessagingClient) error {
+		resp, listErr := client.ListTopics(context.Background(), &mq_pb.ListTopicsRequest{})
+		if listErr != nil {
+			return listErr
+		}
+		for _, pbTopic := range resp.Topics {
+			if m.option.Namespace != "" && pbTopic.Namespace != m.option.Namespace {
+				continue
+			}
+			topics = append(topics, topic.FromPbTopic(pbTopic))
+		}
+		return nil
+	})
+	return
+}
+
+func (m *Mirror) lookupPartitionCount(t topic.Topic) (partitionCount int32, err error) {
+	err = m.withSourceBroker(func(client mq_pb.SeaweedMessagingClient) error {
+		resp, lookupErr := client.LookupTopicBrokers(context.Background(), &mq_pb.LookupTopicBrokersRequest{
+			Topic: t.ToPbTopic(),
+		})
+		if lookupErr != nil {
+			return lookupErr
+		}
+		partitionCount = int32(len(resp.BrokerPartitionAssignments))
+		return nil
+	})
+	if err == nil && partitionCount == 0 {
+		err = fmt.Errorf("topic %v has no partitions", t)
+	}
+	return
+}
+
+func (m *Mirror) withSourceBroker(fn func(client mq_pb.SeaweedMessagingClient) error) (err error) {
+	for _, broker := range m.option.SourceBrokers {
+		err = pb.WithBrokerGrpcClient(false, broker, m.option.GrpcDialOption, fn)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
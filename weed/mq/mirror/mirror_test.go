@@ -0,0 +1,57 @@
+package mirror
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPublisher acks the published messages after the delay, with the error if any
+type testPublisher struct {
+	published []*mq_pb.DataMessage
+	delay     time.Duration
+	ackErr    error
+}
+
+func (p *testPublisher) PublishDataMessageAsync(message *mq_pb.DataMessage, callback pub_client.PublishCallback) error {
+	p.published = append(p.published, message)
+	go func() {
+		time.Sleep(p.delay)
+		callback(message, p.ackErr)
+	}()
+	return nil
+}
+
+func TestMirrorMessage(t *testing.T) {
+	m := NewMirror(&MirrorOption{SourceCluster: "dc1", TargetCluster: "dc2"})
+
+	// returns only after the target broker acks
+	publisher := &testPublisher{delay: 50 * time.Millisecond}
+	start := time.Now()
+	assert.NoError(t, m.mirrorMessage(publisher, &mq_pb.DataMessage{Key: []byte("k"), Value: []byte("v"), TsNs: 100}))
+	assert.GreaterOrEqual(t, time.Since(start), publisher.delay)
+	assert.Equal(t, int64(1), m.mirroredCount)
+
+	// keeps the timestamp and the origin
+	assert.NoError(t, m.mirrorMessage(publisher, &mq_pb.DataMessage{Key: []byte("k"), TsNs: 200, Origin: "dc3"}))
+	if assert.Len(t, publisher.published, 2) {
+		assert.Equal(t, int64(100), publisher.published[0].TsNs)
+		assert.Equal(t, "dc1", publisher.published[0].Origin)
+		assert.Equal(t, int64(200), publisher.published[1].TsNs)
+		assert.Equal(t, "dc3", publisher.published[1].Origin)
+	}
+
+	// skips the messages mirrored from the target cluster
+	assert.NoError(t, m.mirrorMessage(publisher, &mq_pb.DataMessage{Key: []byte("k"), TsNs: 300, Origin: "dc2"}))
+	assert.Len(t, publisher.published, 2)
+	assert.Equal(t, int64(1), m.skippedCount)
+
+	// fails without the ack, so the source message is not acked
+	failing := &testPublisher{ackErr: fmt.Errorf("broker unavailable")}
+	assert.Error(t, m.mirrorMessage(failing, &mq_pb.DataMessage{Key: []byte("k"), TsNs: 400}))
+	assert.Equal(t, int64(2), m.mirroredCount)
+}
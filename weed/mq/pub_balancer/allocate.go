@@ -9,7 +9,7 @@ import (
 	"time"
 )
 
-func AllocateTopicPartitions(brokers cmap.ConcurrentMap[string, *BrokerStats], partitionCount int32) (assignments []*mq_pb.BrokerPartitionAssignment) {
+func AllocateTopicPartitions(brokers cmap.ConcurrentMap[string, *BrokerStats], partitionCount int32, followerCount int) (assignments []*mq_pb.BrokerPartitionAssignment) {
 	// divide the ring into partitions
 	now := time.Now().UnixNano()
 	rangeSize := MaxPartitionCount / partitionCount
@@ -28,12 +28,20 @@ func AllocateTopicPartitions(brokers cmap.ConcurrentMap[string, *BrokerStats], p
 		assignments = append(assignments, assignment)
 	}
 
-	EnsureAssignmentsToActiveBrokers(brokers, 1, assignments)
+	EnsureAssignmentsToActiveBrokers(brokers, followerCount, assignments)
 
 	glog.V(0).Infof("allocate topic partitions %d: %v", len(assignments), assignments)
 	return
 }
 
+// FollowerCount returns the followers of each partition for the topic replication, which counts the leader too
+func FollowerCount(replication int32) int {
+	if replication == 1 {
+		return 0
+	}
+	return 1
+}
+
 // randomly pick n brokers, which may contain duplicates
 // TODO pick brokers based on the broker stats
 func pickBrokers(brokers cmap.ConcurrentMap[string, *BrokerStats], count int32) []string {
@@ -94,7 +102,12 @@ func EnsureAssignmentsToActiveBrokers(activeBrokers cmap.ConcurrentMap[string, *
 			assignment.LeaderBroker = ""
 			count++
 		}
-		if assignment.FollowerBroker == "" {
+		if followerCount == 0 {
+			if assignment.FollowerBroker != "" {
+				assignment.FollowerBroker = ""
+				hasChanges = true
+			}
+		} else if assignment.FollowerBroker == "" {
 			count++
 		} else if _, found := activeBrokers.Get(assignment.FollowerBroker); !found {
 			assignment.FollowerBroker = ""
@@ -111,7 +124,7 @@ func EnsureAssignmentsToActiveBrokers(activeBrokers cmap.ConcurrentMap[string, *
 					hasChanges = true
 				}
 			}
-			if assignment.FollowerBroker == "" {
+			if assignment.FollowerBroker == "" && followerCount > 0 {
 				if i < len(pickedBrokers) {
 					assignment.FollowerBroker = pickedBrokers[i]
 					i++
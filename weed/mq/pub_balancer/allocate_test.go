@@ -54,7 +54,7 @@ func testThem(t *testing.T, tests []struct {
 }) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotAssignments := AllocateTopicPartitions(tt.args.brokers, tt.args.partitionCount)
+			gotAssignments := AllocateTopicPartitions(tt.args.brokers, tt.args.partitionCount, 1)
 			assert.Equal(t, len(tt.wantAssignments), len(gotAssignments))
 			for i, gotAssignment := range gotAssignments {
 				assert.Equal(t, tt.wantAssignments[i].LeaderBroker, gotAssignment.LeaderBroker)
@@ -119,6 +119,36 @@ func TestEnsureAssignmentsToActiveBrokersX(t *testing.T) {
 			},
 			hasChanges: true,
 		},
+		{
+			name: "test removed follower",
+			args: args{
+				activeBrokers: activeBrokers,
+				followerCount: 0,
+				assignments: []*mq_pb.BrokerPartitionAssignment{
+					{
+						LeaderBroker:   "localhost:1",
+						Partition:      &schema_pb.Partition{},
+						FollowerBroker: "localhost:2",
+					},
+				},
+			},
+			hasChanges: true,
+		},
+		{
+			name: "test no follower",
+			args: args{
+				activeBrokers: activeBrokers,
+				followerCount: 0,
+				assignments: []*mq_pb.BrokerPartitionAssignment{
+					{
+						LeaderBroker:   "localhost:1",
+						Partition:      &schema_pb.Partition{},
+						FollowerBroker: "",
+					},
+				},
+			},
+			hasChanges: false,
+		},
 		{
 			name: "test dead follower",
 			args: args{
@@ -105,6 +105,12 @@ func (cg *ConsumerGroup) AckUnAssignment(cgi *ConsumerGroupInstance, assignment
 }
 
 func (cg *ConsumerGroup) OnPartitionListChange(assignments []*mq_pb.BrokerPartitionAssignment) {
+	var partitions []topic.Partition
+	for _, assignment := range assignments {
+		partitions = append(partitions, topic.FromPbPartition(assignment.Partition))
+	}
+	glog.V(0).Infof("consumer group of topic %s rebalances %d partitions", cg.topic, len(partitions))
+	cg.Market.OnPartitionListChange(partitions)
 }
 
 func (cg *ConsumerGroup) Shutdown() {
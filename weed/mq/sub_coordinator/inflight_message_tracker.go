@@ -3,17 +3,18 @@ package sub_coordinator
 import (
 	"sort"
 	"sync"
+	"time"
 )
 
 type InflightMessageTracker struct {
-	messages   map[string]int64
+	messages   map[string]*inflightMessage
 	mu         sync.Mutex
 	timestamps *RingBuffer
 }
 
 func NewInflightMessageTracker(capacity int) *InflightMessageTracker {
 	return &InflightMessageTracker{
-		messages:   make(map[string]int64),
+		messages:   make(map[string]*inflightMessage),
 		timestamps: NewRingBuffer(capacity),
 	}
 }
@@ -24,7 +25,11 @@ func (imt *InflightMessageTracker) EnflightMessage(key []byte, tsNs int64) {
 	// fmt.Printf("EnflightMessage(%s,%d)\n", string(key), tsNs)
 	imt.mu.Lock()
 	defer imt.mu.Unlock()
-	imt.messages[string(key)] = tsNs
+	imt.messages[string(key)] = &inflightMessage{
+		tsNs:        tsNs,
+		deliveredAt: time.Now(),
+		deliveries:  1,
+	}
 	imt.timestamps.EnflightTimestamp(tsNs)
 }
 
@@ -55,8 +60,8 @@ func (imt *InflightMessageTracker) AcknowledgeMessage(key []byte, tsNs int64) bo
 	// fmt.Printf("AcknowledgeMessage(%s,%d)\n", string(key), tsNs)
 	imt.mu.Lock()
 	defer imt.mu.Unlock()
-	timestamp, exists := imt.messages[string(key)]
-	if !exists || timestamp != tsNs {
+	message, exists := imt.messages[string(key)]
+	if !exists || message.tsNs != tsNs {
 		return false
 	}
 	delete(imt.messages, string(key))
@@ -77,6 +82,49 @@ func (imt *InflightMessageTracker) IsInflight(key []byte) bool {
 	return found
 }
 
+// InflightCount returns the number of the messages sent and not acked yet.
+func (imt *InflightMessageTracker) InflightCount() int {
+	imt.mu.Lock()
+	defer imt.mu.Unlock()
+	return len(imt.messages)
+}
+
+type inflightMessage struct {
+	tsNs        int64
+	deliveredAt time.Time
+	deliveries  int
+}
+
+// InflightMessage is an inflight message to redeliver
+type InflightMessage struct {
+	Key        []byte
+	TsNs       int64
+	Deliveries int // including the coming redelivery
+}
+
+// RenewExpiredMessages returns the messages delivered at least the ack deadline ago and still not acked,
+// sorted by the timestamp. They are counted as delivered again now.
+func (imt *InflightMessageTracker) RenewExpiredMessages(now time.Time, ackDeadline time.Duration) (expired []InflightMessage) {
+	imt.mu.Lock()
+	defer imt.mu.Unlock()
+	for key, message := range imt.messages {
+		if now.Sub(message.deliveredAt) < ackDeadline {
+			continue
+		}
+		message.deliveredAt = now
+		message.deliveries++
+		expired = append(expired, InflightMessage{
+			Key:        []byte(key),
+			TsNs:       message.tsNs,
+			Deliveries: message.deliveries,
+		})
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].TsNs < expired[j].TsNs
+	})
+	return
+}
+
 type TimestampStatus struct {
 	Timestamp int64
 	Acked     bool
@@ -3,6 +3,7 @@ package sub_coordinator
 import (
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestRingBuffer(t *testing.T) {
@@ -131,3 +132,29 @@ func TestInflightMessageTracker4(t *testing.T) {
 	assert.Equal(t, int64(3), tracker.GetOldestAckedTimestamp())
 
 }
+
+func TestInflightMessageTrackerRenewExpired(t *testing.T) {
+	tracker := NewInflightMessageTracker(4)
+
+	tracker.EnflightMessage([]byte("1"), int64(1))
+	tracker.EnflightMessage([]byte("2"), int64(2))
+	tracker.EnflightMessage([]byte("3"), int64(3))
+	assert.True(t, tracker.AcknowledgeMessage([]byte("2"), int64(2)))
+
+	now := time.Now()
+	assert.Empty(t, tracker.RenewExpiredMessages(now, time.Minute))
+
+	expired := tracker.RenewExpiredMessages(now.Add(time.Minute), time.Minute)
+	assert.Equal(t, []InflightMessage{
+		{Key: []byte("1"), TsNs: 1, Deliveries: 2},
+		{Key: []byte("3"), TsNs: 3, Deliveries: 2},
+	}, expired)
+
+	// the redelivered messages wait for another deadline
+	assert.Empty(t, tracker.RenewExpiredMessages(now.Add(time.Minute+time.Second), time.Minute))
+
+	assert.True(t, tracker.AcknowledgeMessage([]byte("1"), int64(1)))
+	expired = tracker.RenewExpiredMessages(now.Add(2*time.Minute), time.Minute)
+	assert.Equal(t, []InflightMessage{{Key: []byte("3"), TsNs: 3, Deliveries: 3}}, expired)
+	assert.Equal(t, int64(2), tracker.GetOldestAckedTimestamp())
+}
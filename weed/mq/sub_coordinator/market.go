@@ -18,7 +18,9 @@ For each loop, the market will:
 
 Trigger rebalance when:
 * A new consumer group instance is added
+* A consumer group instance is removed, when disconnected or missing heartbeats
 * Some partitions are unassigned from a consumer group instance.
+* The partitions of the topic are changed
 
 If multiple reblance requests are received, after a certain period, the market will only process the latest request.
 
@@ -129,6 +131,48 @@ func (m *Market) RemoveConsumerInstance(consumerId ConsumerGroupInstanceId) erro
 	return nil
 }
 
+// OnPartitionListChange tracks the current partitions of the topic, e.g., after the partition count is changed.
+// The consumers of the gone partitions are told to stop, and the new partitions are assigned when rebalancing.
+func (m *Market) OnPartitionListChange(partitions []topic.Partition) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	currentPartitions := make(map[topic.Partition]struct{}, len(partitions))
+	for _, partition := range partitions {
+		currentPartitions[partition] = struct{}{}
+		if _, exists := m.partitions[partition]; !exists {
+			m.partitions[partition] = &PartitionSlot{
+				Partition: partition,
+			}
+		}
+	}
+
+	for partition, partitionSlot := range m.partitions {
+		if _, exists := currentPartitions[partition]; exists {
+			continue
+		}
+		delete(m.partitions, partition)
+		consumer := partitionSlot.AssignedTo
+		if consumer == nil {
+			continue
+		}
+		for i, p := range consumer.AssignedPartitions {
+			if p == partition {
+				consumer.AssignedPartitions = append(consumer.AssignedPartitions[:i], consumer.AssignedPartitions[i+1:]...)
+				break
+			}
+		}
+		m.AdjustmentChan <- &Adjustment{
+			isAssign:  false,
+			partition: partition,
+			consumer:  consumer.InstanceId,
+			ts:        time.Now(),
+		}
+	}
+
+	m.balanceRequestChan <- struct{}{}
+}
+
 func (m *Market) assignPartitionToConsumer(partition *PartitionSlot) {
 	var bestConsumer *ConsumerGroupInstance
 	var minLoad = int(^uint(0) >> 1) // Max int value
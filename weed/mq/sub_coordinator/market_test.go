@@ -101,3 +101,35 @@ func TestConfirmAdjustment(t *testing.T) {
 	}
 
 }
+
+func TestPartitionListChange(t *testing.T) {
+	market := NewMarket(partitions[:2], 100*time.Millisecond)
+	defer market.ShutdownMarket()
+
+	market.AddConsumerInstance(&ConsumerGroupInstance{
+		InstanceId:        "first",
+		MaxPartitionCount: 3,
+	})
+	for i := 0; i < 2; i++ {
+		adjustment := <-market.AdjustmentChan
+		assert.True(t, adjustment.isAssign)
+		market.ConfirmAdjustment(adjustment)
+	}
+	time.Sleep(200 * time.Millisecond) // let the inflight adjustments expire
+
+	// the first partition is gone, and the third partition is added
+	market.OnPartitionListChange(partitions[1:])
+
+	unassignment := <-market.AdjustmentChan
+	assert.False(t, unassignment.isAssign)
+	assert.Equal(t, partitions[0], unassignment.partition)
+	assert.Equal(t, ConsumerGroupInstanceId("first"), unassignment.consumer)
+
+	select {
+	case assignment := <-market.AdjustmentChan:
+		assert.True(t, assignment.isAssign)
+		assert.Equal(t, partitions[2], assignment.partition)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the new partition is not assigned")
+	}
+}
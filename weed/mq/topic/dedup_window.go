@@ -0,0 +1,77 @@
+package topic
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DedupWindow remembers the idempotency keys of the messages recently published to a partition,
+// bounded by both the time window and the number of keys, evicting the oldest keys first.
+// It only lives in the broker memory, so the duplicates are not detected across broker restarts or leader changes.
+type DedupWindow struct {
+	sync.Mutex
+	window  time.Duration
+	maxKeys int
+	keys    map[string]*list.Element
+	order   *list.List // of *dedupEntry, the oldest first
+}
+
+type dedupEntry struct {
+	key    string
+	seenAt time.Time
+}
+
+// NewDedupWindow returns nil, which never finds duplicates, if the window or the max key count is not positive
+func NewDedupWindow(window time.Duration, maxKeys int) *DedupWindow {
+	if window <= 0 || maxKeys <= 0 {
+		return nil
+	}
+	return &DedupWindow{
+		window:  window,
+		maxKeys: maxKeys,
+		keys:    make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// IsDuplicate returns true if the key is seen within the window, otherwise remembers the key
+func (w *DedupWindow) IsDuplicate(key string, now time.Time) bool {
+	if w == nil || key == "" {
+		return false
+	}
+	w.Lock()
+	defer w.Unlock()
+
+	for front := w.order.Front(); front != nil; front = w.order.Front() {
+		entry := front.Value.(*dedupEntry)
+		if now.Sub(entry.seenAt) < w.window {
+			break
+		}
+		delete(w.keys, entry.key)
+		w.order.Remove(front)
+	}
+
+	if _, found := w.keys[key]; found {
+		return true
+	}
+	if w.order.Len() >= w.maxKeys {
+		oldest := w.order.Front()
+		delete(w.keys, oldest.Value.(*dedupEntry).key)
+		w.order.Remove(oldest)
+	}
+	w.keys[key] = w.order.PushBack(&dedupEntry{
+		key:    key,
+		seenAt: now,
+	})
+	return false
+}
+
+func (w *DedupWindow) Len() int {
+	if w == nil {
+		return 0
+	}
+	w.Lock()
+	defer w.Unlock()
+	return w.order.Len()
+}
@@ -0,0 +1,34 @@
+package topic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedupWindow(t *testing.T) {
+	w := NewDedupWindow(time.Minute, 2)
+	now := time.Now()
+
+	if w.IsDuplicate("a", now) {
+		t.Errorf("a is new")
+	}
+	if !w.IsDuplicate("a", now.Add(time.Second)) {
+		t.Errorf("a is a duplicate within the window")
+	}
+	if w.IsDuplicate("b", now.Add(time.Second)) || w.IsDuplicate("c", now.Add(time.Second)) {
+		t.Errorf("b and c are new")
+	}
+	// "a" is evicted by the max key count
+	if w.Len() != 2 || w.IsDuplicate("a", now.Add(2*time.Second)) {
+		t.Errorf("a should be evicted, keys %d", w.Len())
+	}
+	// "b" and "c" are out of the window
+	if w.IsDuplicate("b", now.Add(2*time.Minute)) {
+		t.Errorf("b should be expired")
+	}
+
+	var disabled *DedupWindow = NewDedupWindow(0, 100)
+	if disabled.IsDuplicate("a", now) || disabled.IsDuplicate("a", now) {
+		t.Errorf("disabled window should not find duplicates")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
 	"github.com/shirou/gopsutil/v3/cpu"
+	"sync/atomic"
 	"time"
 )
 
@@ -114,3 +115,105 @@ func (manager *LocalTopicManager) WaitUntilNoPublishers(topic Topic) {
 	}
 	localTopic.WaitUntilNoPublishers()
 }
+
+// ListTopicPartitions lists the topic partitions hosted on the local broker
+func (manager *LocalTopicManager) ListTopicPartitions() (topicPartitions []TopicPartition) {
+	manager.topics.IterCb(func(topic string, localTopic *LocalTopic) {
+		localTopic.partitionLock.RLock()
+		defer localTopic.partitionLock.RUnlock()
+		for _, localPartition := range localTopic.Partitions {
+			topicPartitions = append(topicPartitions, TopicPartition{
+				Topic:     localTopic.Topic,
+				Partition: localPartition.Partition,
+			})
+		}
+	})
+	return
+}
+
+// ListLocalTopics lists the topics with partitions hosted on the local broker
+func (manager *LocalTopicManager) ListLocalTopics() (topics []Topic) {
+	manager.topics.IterCb(func(topic string, localTopic *LocalTopic) {
+		topics = append(topics, localTopic.Topic)
+	})
+	return
+}
+
+// DescribeLocalTopic describes the partitions of the topic hosted on the local broker,
+// returns nil if the topic is not hosted here
+func (manager *LocalTopicManager) DescribeLocalTopic(topic Topic) []*mq_pb.LocalPartitionDescription {
+	localTopic, ok := manager.topics.Get(topic.String())
+	if !ok {
+		return nil
+	}
+	localTopic.partitionLock.RLock()
+	defer localTopic.partitionLock.RUnlock()
+
+	var descriptions []*mq_pb.LocalPartitionDescription
+	for _, localPartition := range localTopic.Partitions {
+		descriptions = append(descriptions, localPartition.Describe())
+	}
+	return descriptions
+}
+
+// ListConnections lists the publishers and subscribers connected to the local broker
+func (manager *LocalTopicManager) ListConnections() (connections []*mq_pb.ClientConnection) {
+	manager.topics.IterCb(func(topic string, localTopic *LocalTopic) {
+		localTopic.partitionLock.RLock()
+		defer localTopic.partitionLock.RUnlock()
+		pbTopic := localTopic.Topic.ToPbTopic()
+		for _, localPartition := range localTopic.Partitions {
+			pbPartition := localPartition.Partition.ToPbPartition()
+			publisherNames, publishers := localPartition.Publishers.ListPublishers()
+			for i, publisher := range publishers {
+				connection := &mq_pb.ClientConnection{
+					Type:          mq_pb.ClientConnection_PUBLISHER,
+					ClientName:    publisherNames[i],
+					Topic:         pbTopic,
+					Partition:     pbPartition,
+					ConnectedAtNs: publisher.ConnectedAtNs,
+				}
+				publisher.Stats.Fill(connection)
+				connections = append(connections, connection)
+			}
+			subscriberNames, subscribers := localPartition.Subscribers.ListSubscribers()
+			for i, subscriber := range subscribers {
+				connection := &mq_pb.ClientConnection{
+					Type:          mq_pb.ClientConnection_SUBSCRIBER,
+					ClientName:    subscriberNames[i],
+					Topic:         pbTopic,
+					Partition:     pbPartition,
+					ConsumerGroup: subscriber.ConsumerGroup,
+					ConnectedAtNs: subscriber.ConnectedAtNs,
+					AckedTsNs:     atomic.LoadInt64(&subscriber.AckedTsNs),
+				}
+				subscriber.Stats.Fill(connection)
+				connections = append(connections, connection)
+			}
+		}
+	})
+	return
+}
+
+// LoopSampleThroughput samples the throughput of the hosted partitions, and the rates of their streams, periodically
+func (manager *LocalTopicManager) LoopSampleThroughput() {
+	for {
+		time.Sleep(ThroughputSampleInterval)
+		now := time.Now()
+		manager.topics.IterCb(func(topic string, localTopic *LocalTopic) {
+			localTopic.partitionLock.RLock()
+			defer localTopic.partitionLock.RUnlock()
+			for _, localPartition := range localTopic.Partitions {
+				localPartition.Throughput.Sample()
+				_, publishers := localPartition.Publishers.ListPublishers()
+				for _, publisher := range publishers {
+					publisher.Stats.Sample(now)
+				}
+				_, subscribers := localPartition.Subscribers.ListSubscribers()
+				for _, subscriber := range subscribers {
+					subscriber.Stats.Sample(now)
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
 	"google.golang.org/grpc"
@@ -17,7 +18,9 @@ import (
 
 type LocalPartition struct {
 	ListenersWaits int64
-	AckTsNs        int64
+	AckTsNs        int64 // received by the follower, or buffered if no follower
+	BufferedTsNs   int64
+	FlushedTsNs    int64
 
 	// notifying clients
 	ListenersLock sync.Mutex
@@ -27,12 +30,28 @@ type LocalPartition struct {
 	LogBuffer   *log_buffer.LogBuffer
 	Publishers  *LocalPartitionPublishers
 	Subscribers *LocalPartitionSubscribers
+	Throughput  PartitionThroughput
+	Dedup       *DedupWindow
+
+	// the dropped duplicates are acked once the messages buffered before them are acked
+	dedupLock        sync.Mutex
+	droppedTsNs      int64
+	droppedAfterTsNs int64
 
 	publishFolloweMeStream mq_pb.SeaweedMessaging_PublishFollowMeClient
 	followerGrpcConnection *grpc.ClientConn
 	Follower               string
+
+	// fsync the log files on the volume servers when flushed
+	Fsync atomic.Bool
+	// the max message size of the topic, 0 for the broker default
+	MaxMessageSize atomic.Int64
+	// the messages flushed to the log files since the partition was created
+	Totals PartitionTotals
 }
 
+const DefaultFlushInterval = 2 * time.Minute
+
 var TIME_FORMAT = "2006-01-02-15-04-05"
 var PartitionGenerationFormat = "v2006-01-02-15-04-05"
 
@@ -44,7 +63,7 @@ func NewLocalPartition(partition Partition, logFlushFn log_buffer.LogFlushFuncTy
 	}
 	lp.ListenersCond = sync.NewCond(&lp.ListenersLock)
 	lp.LogBuffer = log_buffer.NewLogBuffer(fmt.Sprintf("%d/%04d-%04d", partition.UnixTimeNs, partition.RangeStart, partition.RangeStop),
-		2*time.Minute, logFlushFn, readFromDiskFn, func() {
+		DefaultFlushInterval, logFlushFn, readFromDiskFn, func() {
 			if atomic.LoadInt64(&lp.ListenersWaits) > 0 {
 				lp.ListenersCond.Broadcast()
 			}
@@ -52,8 +71,22 @@ func NewLocalPartition(partition Partition, logFlushFn log_buffer.LogFlushFuncTy
 	return lp
 }
 
+// ConfigureFlush changes how the buffered messages are flushed to the log files, 0 for the defaults
+func (p *LocalPartition) ConfigureFlush(flushInterval time.Duration, bufferSize int, fsync bool) {
+	if flushInterval <= 0 {
+		flushInterval = DefaultFlushInterval
+	}
+	if bufferSize <= 0 {
+		bufferSize = log_buffer.BufferSize
+	}
+	p.LogBuffer.Configure(flushInterval, bufferSize)
+	p.Fsync.Store(fsync)
+}
+
 func (p *LocalPartition) Publish(message *mq_pb.DataMessage) error {
 	p.LogBuffer.AddToBuffer(message)
+	atomic.StoreInt64(&p.BufferedTsNs, message.TsNs)
+	p.Throughput.Add(len(message.Key) + len(message.Value))
 
 	// maybe send to the follower
 	if p.publishFolloweMeStream != nil {
@@ -72,6 +105,19 @@ func (p *LocalPartition) Publish(message *mq_pb.DataMessage) error {
 	return nil
 }
 
+// IsDuplicate checks the idempotency key of the message against the recently published ones,
+// and the caller should drop the message if true
+func (p *LocalPartition) IsDuplicate(message *mq_pb.DataMessage) bool {
+	if !p.Dedup.IsDuplicate(message.IdempotencyKey, time.Now()) {
+		return false
+	}
+	p.dedupLock.Lock()
+	p.droppedTsNs = max(p.droppedTsNs, message.TsNs)
+	p.droppedAfterTsNs = atomic.LoadInt64(&p.BufferedTsNs)
+	p.dedupLock.Unlock()
+	return true
+}
+
 func (p *LocalPartition) Subscribe(clientName string, startPosition log_buffer.MessagePosition,
 	onNoMessageFn func() bool, eachMessageFn log_buffer.EachLogEntryFuncType) error {
 	var processedPosition log_buffer.MessagePosition
@@ -106,6 +152,39 @@ func (p *LocalPartition) Subscribe(clientName string, startPosition log_buffer.M
 	}
 }
 
+// ReadRange reads the persisted and in-memory messages with timestamps in (startTsNs, stopTsNs)
+func (p *LocalPartition) ReadRange(clientName string, startTsNs, stopTsNs int64, eachMessageFn log_buffer.EachLogEntryFuncType) error {
+	if stopTsNs <= startTsNs+1 {
+		return nil
+	}
+	eachInRangeFn := func(logEntry *filer_pb.LogEntry) (isDone bool, err error) {
+		if logEntry.TsNs <= startTsNs {
+			return false, nil
+		}
+		if logEntry.TsNs >= stopTsNs {
+			return true, nil
+		}
+		return eachMessageFn(logEntry)
+	}
+	processedPosition, isDone, err := p.LogBuffer.ReadFromDiskFn(log_buffer.NewMessagePosition(startTsNs+1, -2), stopTsNs-1, eachInRangeFn)
+	if err != nil {
+		return fmt.Errorf("read %v persisted log: %v", p.Partition, err)
+	}
+	if isDone {
+		return nil
+	}
+	if processedPosition.UnixNano() < startTsNs {
+		processedPosition = log_buffer.NewMessagePosition(startTsNs, -2)
+	}
+	_, _, err = p.LogBuffer.LoopProcessLogData(clientName, processedPosition, stopTsNs-1, func() bool {
+		return false
+	}, eachInRangeFn)
+	if err != nil && err != log_buffer.ResumeFromDiskError {
+		return fmt.Errorf("read %v in memory log: %v", p.Partition, err)
+	}
+	return nil
+}
+
 func (p *LocalPartition) GetEarliestMessageTimeInMemory() time.Time {
 	return p.LogBuffer.GetEarliestTime()
 }
@@ -136,7 +215,12 @@ func (p *LocalPartition) WaitUntilNoPublishers() {
 
 func (p *LocalPartition) MaybeConnectToFollowers(initMessage *mq_pb.PublishMessageRequest_InitMessage, grpcDialOption grpc.DialOption) (err error) {
 	if p.publishFolloweMeStream != nil {
-		return nil
+		if p.Follower == initMessage.FollowerBroker {
+			return nil
+		}
+		// the follower is changed in the topic configuration
+		glog.V(0).Infof("local partition %v follower %s => %q", p.Partition, p.Follower, initMessage.FollowerBroker)
+		p.disconnectFollower()
 	}
 	if initMessage.FollowerBroker == "" {
 		return nil
@@ -196,18 +280,7 @@ func (p *LocalPartition) MaybeShutdownLocalPartition() (hasShutdown bool) {
 			time.Sleep(113 * time.Millisecond)
 		}
 		if p.publishFolloweMeStream != nil {
-			// send close to the follower
-			if followErr := p.publishFolloweMeStream.Send(&mq_pb.PublishFollowMeRequest{
-				Message: &mq_pb.PublishFollowMeRequest_Close{
-					Close: &mq_pb.PublishFollowMeRequest_CloseMessage{},
-				},
-			}); followErr != nil {
-				glog.Errorf("Error closing follower stream: %v", followErr)
-			}
-			glog.V(4).Infof("closing grpcConnection to follower")
-			p.followerGrpcConnection.Close()
-			p.publishFolloweMeStream = nil
-			p.Follower = ""
+			p.disconnectFollower()
 		}
 
 		hasShutdown = true
@@ -217,6 +290,34 @@ func (p *LocalPartition) MaybeShutdownLocalPartition() (hasShutdown bool) {
 	return
 }
 
+func (p *LocalPartition) disconnectFollower() {
+	// send close to the follower
+	if followErr := p.publishFolloweMeStream.Send(&mq_pb.PublishFollowMeRequest{
+		Message: &mq_pb.PublishFollowMeRequest_Close{
+			Close: &mq_pb.PublishFollowMeRequest_CloseMessage{},
+		},
+	}); followErr != nil {
+		glog.Errorf("Error closing follower stream: %v", followErr)
+	}
+	glog.V(4).Infof("closing grpcConnection to follower")
+	p.followerGrpcConnection.Close()
+	p.publishFolloweMeStream = nil
+	p.Follower = ""
+}
+
+// FlushAndShutdown stops the publishers and subscribers, and waits until all in-memory messages are persisted
+func (p *LocalPartition) FlushAndShutdown(timeout time.Duration) (isAllFlushed bool) {
+	p.Shutdown()
+	deadline := time.Now().Add(timeout)
+	for !p.LogBuffer.IsAllFlushed() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(113 * time.Millisecond)
+	}
+	return true
+}
+
 func (p *LocalPartition) Shutdown() {
 	p.closePublishers()
 	p.closeSubscribers()
@@ -224,7 +325,27 @@ func (p *LocalPartition) Shutdown() {
 	glog.V(0).Infof("local partition %v shutting down", p.Partition)
 }
 
+// AckedTsNs is the timestamp of the last published message meeting the durability of the ack level
+func (p *LocalPartition) AckedTsNs(ackLevel mq_pb.AckLevel) (ackedTsNs int64) {
+	switch ackLevel {
+	case mq_pb.AckLevel_ACK_BUFFERED:
+		ackedTsNs = atomic.LoadInt64(&p.BufferedTsNs)
+	case mq_pb.AckLevel_ACK_FLUSHED:
+		ackedTsNs = atomic.LoadInt64(&p.FlushedTsNs)
+	default:
+		ackedTsNs = atomic.LoadInt64(&p.AckTsNs)
+	}
+	// otherwise the publisher would wait forever for the ack of a dropped duplicate
+	p.dedupLock.Lock()
+	if p.droppedTsNs > ackedTsNs && ackedTsNs >= p.droppedAfterTsNs {
+		ackedTsNs = p.droppedTsNs
+	}
+	p.dedupLock.Unlock()
+	return ackedTsNs
+}
+
 func (p *LocalPartition) NotifyLogFlushed(flushTsNs int64) {
+	atomic.StoreInt64(&p.FlushedTsNs, flushTsNs)
 	if p.publishFolloweMeStream != nil {
 		if followErr := p.publishFolloweMeStream.Send(&mq_pb.PublishFollowMeRequest{
 			Message: &mq_pb.PublishFollowMeRequest_Flush{
@@ -238,3 +359,36 @@ func (p *LocalPartition) NotifyLogFlushed(flushTsNs int64) {
 		// println("notifying", p.Follower, "flushed at", flushTsNs)
 	}
 }
+
+// Describe reports the connected clients and the buffer occupancy of the partition
+func (p *LocalPartition) Describe() *mq_pb.LocalPartitionDescription {
+	publisherNames, _ := p.Publishers.ListPublishers()
+	subscriberNames, subscribers := p.Subscribers.ListSubscribers()
+	bufferStats := p.LogBuffer.GetBufferStats()
+
+	description := &mq_pb.LocalPartitionDescription{
+		Partition:        p.Partition.ToPbPartition(),
+		Publishers:       publisherNames,
+		Subscribers:      subscriberNames,
+		Follower:         p.Follower,
+		AckTsNs:          atomic.LoadInt64(&p.AckTsNs),
+		BufferedBytes:    bufferStats.BufferedBytes,
+		BufferedMessages: bufferStats.BufferedMessages,
+		BufferCapacity:   bufferStats.Capacity,
+		LastTsNs:         bufferStats.LastTsNs,
+		LastFlushTsNs:    bufferStats.LastFlushTsNs,
+	}
+	description.PublishedMessages, description.PublishedBytes = p.Throughput.Totals()
+	description.RecentPublishedMessages = p.Throughput.Samples()
+	description.SampleIntervalSeconds = int32(ThroughputSampleInterval / time.Second)
+	description.TotalMessages, description.TotalBytes, _ = p.Totals.Get()
+	knownGroups := make(map[string]struct{})
+	for _, subscriber := range subscribers {
+		if _, found := knownGroups[subscriber.ConsumerGroup]; found {
+			continue
+		}
+		knownGroups[subscriber.ConsumerGroup] = struct{}{}
+		description.SubscriberGroups = append(description.SubscriberGroups, subscriber.ConsumerGroup)
+	}
+	return description
+}
@@ -1,18 +1,32 @@
 package topic
 
-import "sync"
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type LocalPartitionPublishers struct {
 	publishers     map[string]*LocalPublisher
 	publishersLock sync.RWMutex
 }
 type LocalPublisher struct {
+	isShuttingDown int32
+	ConnectedAtNs  int64
+	Stats          StreamStats
 }
 
 func NewLocalPublisher() *LocalPublisher {
-	return &LocalPublisher{}
+	return &LocalPublisher{
+		ConnectedAtNs: time.Now().UnixNano(),
+	}
 }
 func (p *LocalPublisher) SignalShutdown() {
+	atomic.StoreInt32(&p.isShuttingDown, 1)
+}
+func (p *LocalPublisher) IsShuttingDown() bool {
+	return atomic.LoadInt32(&p.isShuttingDown) == 1
 }
 
 func NewLocalPartitionPublishers() *LocalPartitionPublishers {
@@ -50,3 +64,18 @@ func (p *LocalPartitionPublishers) Size() int {
 
 	return len(p.publishers)
 }
+
+// ListPublishers returns the connected publishers ordered by client name
+func (p *LocalPartitionPublishers) ListPublishers() (clientNames []string, publishers []*LocalPublisher) {
+	p.publishersLock.RLock()
+	defer p.publishersLock.RUnlock()
+
+	for clientName := range p.publishers {
+		clientNames = append(clientNames, clientName)
+	}
+	sort.Strings(clientNames)
+	for _, clientName := range clientNames {
+		publishers = append(publishers, p.publishers[clientName])
+	}
+	return
+}
@@ -1,18 +1,28 @@
 package topic
 
-import "sync"
+import (
+	"sort"
+	"sync"
+	"time"
+)
 
 type LocalPartitionSubscribers struct {
 	Subscribers     map[string]*LocalSubscriber
 	SubscribersLock sync.RWMutex
 }
 type LocalSubscriber struct {
-	stopCh chan struct{}
+	stopCh        chan struct{}
+	ConsumerGroup string
+	ConnectedAtNs int64
+	AckedTsNs     int64
+	Stats         StreamStats
 }
 
-func NewLocalSubscriber() *LocalSubscriber {
+func NewLocalSubscriber(consumerGroup string) *LocalSubscriber {
 	return &LocalSubscriber{
-		stopCh: make(chan struct{}, 1),
+		stopCh:        make(chan struct{}, 1),
+		ConsumerGroup: consumerGroup,
+		ConnectedAtNs: time.Now().UnixNano(),
 	}
 }
 func (p *LocalSubscriber) SignalShutdown() {
@@ -54,3 +64,18 @@ func (p *LocalPartitionSubscribers) Size() int {
 
 	return len(p.Subscribers)
 }
+
+// ListSubscribers returns the connected subscribers ordered by client name
+func (p *LocalPartitionSubscribers) ListSubscribers() (clientNames []string, subscribers []*LocalSubscriber) {
+	p.SubscribersLock.RLock()
+	defer p.SubscribersLock.RUnlock()
+
+	for clientName := range p.Subscribers {
+		clientNames = append(clientNames, clientName)
+	}
+	sort.Strings(clientNames)
+	for _, clientName := range clientNames {
+		subscribers = append(subscribers, p.Subscribers[clientName])
+	}
+	return
+}
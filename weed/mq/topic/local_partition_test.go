@@ -0,0 +1,120 @@
+package topic
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// testLogFiles keeps the flushed messages in memory, as the log files of the partition
+type testLogFiles struct {
+	sync.Mutex
+	logEntries []*filer_pb.LogEntry
+	flushed    chan struct{}
+}
+
+func (f *testLogFiles) flush(logBuffer *log_buffer.LogBuffer, startTime, stopTime time.Time, buf []byte) {
+	f.Lock()
+	defer f.Unlock()
+	for pos := 0; pos+4 <= len(buf); {
+		size := int(util.BytesToUint32(buf[pos : pos+4]))
+		logEntry := &filer_pb.LogEntry{}
+		if err := proto.Unmarshal(buf[pos+4:pos+4+size], logEntry); err == nil {
+			f.logEntries = append(f.logEntries, logEntry)
+		}
+		pos += 4 + size
+	}
+	f.flushed <- struct{}{}
+}
+
+func (f *testLogFiles) read(startPosition log_buffer.MessagePosition, stopTsNs int64, eachLogEntryFn log_buffer.EachLogEntryFuncType) (lastReadPosition log_buffer.MessagePosition, isDone bool, err error) {
+	f.Lock()
+	defer f.Unlock()
+	lastReadPosition = startPosition
+	for _, logEntry := range f.logEntries {
+		if logEntry.TsNs < startPosition.UnixNano() {
+			continue
+		}
+		if stopTsNs != 0 && logEntry.TsNs > stopTsNs {
+			return lastReadPosition, true, nil
+		}
+		if isDone, err = eachLogEntryFn(logEntry); isDone || err != nil {
+			return
+		}
+		lastReadPosition = log_buffer.NewMessagePosition(logEntry.TsNs, -2)
+	}
+	return
+}
+
+func TestLocalPartitionReadRange(t *testing.T) {
+	files := &testLogFiles{flushed: make(chan struct{}, 1)}
+	p := NewLocalPartition(Partition{RingSize: PartitionCount, RangeStop: PartitionCount}, files.flush, files.read)
+	defer p.LogBuffer.ShutdownLogBuffer()
+
+	// the first half is flushed to the log files, the second half stays in memory
+	base := time.Now().UnixNano()
+	var tsNs []int64
+	for i := 0; i < 10; i++ {
+		tsNs = append(tsNs, base+int64(i)*1000)
+		assert.NoError(t, p.Publish(&mq_pb.DataMessage{Key: []byte("k"), Value: []byte{byte(i)}, TsNs: tsNs[i]}))
+		if i == 4 {
+			p.LogBuffer.ForceFlush()
+			<-files.flushed
+		}
+	}
+
+	readRange := func(startTsNs, stopTsNs int64) (read []int64) {
+		assert.NoError(t, p.ReadRange("test", startTsNs, stopTsNs, func(logEntry *filer_pb.LogEntry) (bool, error) {
+			read = append(read, logEntry.TsNs)
+			return false, nil
+		}))
+		return
+	}
+	assert.Equal(t, tsNs[3:8], readRange(tsNs[2], tsNs[8]), "across the log files and the memory")
+	assert.Equal(t, tsNs[1:4], readRange(tsNs[0], tsNs[4]), "in the log files")
+	assert.Equal(t, tsNs[6:9], readRange(tsNs[5], tsNs[9]), "in the memory")
+	assert.Empty(t, readRange(tsNs[3], tsNs[4]), "between the consecutive messages")
+	assert.Empty(t, readRange(tsNs[4], tsNs[3]), "empty range")
+}
+
+func BenchmarkLocalPartitionPublish(b *testing.B) {
+	p := NewLocalPartition(Partition{RingSize: PartitionCount, RangeStop: PartitionCount}, func(logBuffer *log_buffer.LogBuffer, startTime, stopTime time.Time, buf []byte) {
+	}, nil)
+	defer p.LogBuffer.ShutdownLogBuffer()
+
+	message := &mq_pb.DataMessage{Key: []byte("benchmark-key"), Value: make([]byte, 256)}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(message.Key) + len(message.Value)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		message.TsNs = 0
+		if err := p.Publish(message); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLocalPartitionLifecycle creates, fills, and shuts down the partitions, which reuse the pooled buffers
+func BenchmarkLocalPartitionLifecycle(b *testing.B) {
+	message := &mq_pb.DataMessage{Key: []byte("benchmark-key"), Value: make([]byte, 256)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := NewLocalPartition(Partition{RingSize: PartitionCount, RangeStop: PartitionCount}, func(logBuffer *log_buffer.LogBuffer, startTime, stopTime time.Time, buf []byte) {
+		}, nil)
+		for j := 0; j < 100; j++ {
+			message.TsNs = 0
+			p.Publish(message)
+		}
+		p.LogBuffer.ShutdownLogBuffer()
+		for !p.LogBuffer.IsAllFlushed() {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
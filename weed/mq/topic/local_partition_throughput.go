@@ -0,0 +1,51 @@
+package topic
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ThroughputSampleInterval = 10 * time.Second
+	ThroughputSampleCount    = 30
+)
+
+// PartitionThroughput counts the published messages, and keeps the counts of the recent intervals
+type PartitionThroughput struct {
+	messages int64
+	bytes    int64
+
+	samplesLock  sync.Mutex
+	lastMessages int64
+	samples      []int64
+}
+
+func (t *PartitionThroughput) Add(byteCount int) {
+	atomic.AddInt64(&t.messages, 1)
+	atomic.AddInt64(&t.bytes, int64(byteCount))
+}
+
+func (t *PartitionThroughput) Totals() (messages, bytes int64) {
+	return atomic.LoadInt64(&t.messages), atomic.LoadInt64(&t.bytes)
+}
+
+// Sample records the messages published since the last sample
+func (t *PartitionThroughput) Sample() {
+	messages := atomic.LoadInt64(&t.messages)
+
+	t.samplesLock.Lock()
+	defer t.samplesLock.Unlock()
+	t.samples = append(t.samples, messages-t.lastMessages)
+	if len(t.samples) > ThroughputSampleCount {
+		t.samples = t.samples[len(t.samples)-ThroughputSampleCount:]
+	}
+	t.lastMessages = messages
+}
+
+// Samples returns the messages published in the recent intervals, the oldest first
+func (t *PartitionThroughput) Samples() []int64 {
+	t.samplesLock.Lock()
+	defer t.samplesLock.Unlock()
+	return append([]int64(nil), t.samples...)
+}
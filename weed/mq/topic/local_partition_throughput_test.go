@@ -0,0 +1,35 @@
+package topic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPartitionThroughputSamples(t *testing.T) {
+	var throughput PartitionThroughput
+	for i := 0; i < ThroughputSampleCount+2; i++ {
+		for j := 0; j < i; j++ {
+			throughput.Add(10)
+		}
+		throughput.Sample()
+	}
+
+	samples := throughput.Samples()
+	if len(samples) != ThroughputSampleCount {
+		t.Fatalf("kept %d samples", len(samples))
+	}
+	if samples[0] != 2 || samples[len(samples)-1] != ThroughputSampleCount+1 {
+		t.Errorf("samples %v", samples)
+	}
+	messages, bytes := throughput.Totals()
+	expected := int64((ThroughputSampleCount + 1) * (ThroughputSampleCount + 2) / 2)
+	if messages != expected || bytes != expected*10 {
+		t.Errorf("totals %d messages %d bytes", messages, bytes)
+	}
+
+	// the returned samples are a copy
+	samples[0] = -1
+	if reflect.DeepEqual(samples, throughput.Samples()) {
+		t.Errorf("samples are shared")
+	}
+}
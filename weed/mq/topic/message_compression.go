@@ -0,0 +1,179 @@
+package topic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+// the headers of a compressed message, removed after decompression
+const (
+	CompressionHeader           = "sw-compression"      // the codec, only zstd for now
+	CompressionDictionaryHeader = "sw-compression-dict" // the id of the topic compression dictionary, if any
+	CompressionZstd             = "zstd"
+)
+
+var ErrUnknownCompressionDictionary = errors.New("unknown compression dictionary")
+
+// MergeCompressionDictionaries adds the dictionaries to the existing ones of a topic, sorted by id.
+// The content of an existing id can not be changed, since it is referenced by the published messages.
+func MergeCompressionDictionaries(existing, added []*mq_pb.CompressionDictionary) (merged []*mq_pb.CompressionDictionary, changed bool, err error) {
+	byId := make(map[uint32]*mq_pb.CompressionDictionary)
+	for _, dictionary := range existing {
+		byId[dictionary.Id] = dictionary
+	}
+	for _, dictionary := range added {
+		if dictionary.Id == 0 || len(dictionary.Data) == 0 {
+			return nil, false, fmt.Errorf("compression dictionary should have a non-zero id and data")
+		}
+		if found, ok := byId[dictionary.Id]; ok {
+			if !bytes.Equal(found.Data, dictionary.Data) {
+				return nil, false, fmt.Errorf("compression dictionary %d already exists with different data", dictionary.Id)
+			}
+			continue
+		}
+		byId[dictionary.Id] = dictionary
+		changed = true
+	}
+	for _, dictionary := range byId {
+		merged = append(merged, dictionary)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Id < merged[j].Id
+	})
+	return
+}
+
+// MessageCompressor compresses the message values of at least the threshold size,
+// with the latest compression dictionary of the topic if any.
+type MessageCompressor struct {
+	threshold    int
+	dictionaryId uint32
+	encoder      *zstd.Encoder
+}
+
+func NewMessageCompressor(threshold int, dictionaries []*mq_pb.CompressionDictionary) (*MessageCompressor, error) {
+	c := &MessageCompressor{
+		threshold: threshold,
+	}
+	var options []zstd.EOption
+	if len(dictionaries) > 0 {
+		latest := dictionaries[0]
+		for _, dictionary := range dictionaries {
+			if dictionary.Id > latest.Id {
+				latest = dictionary
+			}
+		}
+		c.dictionaryId = latest.Id
+		options = append(options, zstd.WithEncoderDictRaw(latest.Id, latest.Data))
+	}
+	encoder, err := zstd.NewWriter(nil, options...)
+	if err != nil {
+		return nil, fmt.Errorf("create zstd encoder: %v", err)
+	}
+	c.encoder = encoder
+	return c, nil
+}
+
+// Compress replaces the value with the compressed one, if the value is large enough and it gets smaller.
+// A nil compressor does nothing.
+func (c *MessageCompressor) Compress(message *mq_pb.DataMessage) {
+	if c == nil || len(message.Value) < c.threshold || message.Headers[CompressionHeader] != "" {
+		return
+	}
+	compressed := c.encoder.EncodeAll(message.Value, make([]byte, 0, len(message.Value)))
+	if len(compressed) >= len(message.Value) {
+		return
+	}
+	if message.Headers == nil {
+		message.Headers = make(map[string]string)
+	}
+	message.Headers[CompressionHeader] = CompressionZstd
+	if c.dictionaryId != 0 {
+		message.Headers[CompressionDictionaryHeader] = strconv.FormatUint(uint64(c.dictionaryId), 10)
+	}
+	message.Value = compressed
+}
+
+// MessageDecompressor restores the values compressed by MessageCompressor
+type MessageDecompressor struct {
+	sync.Mutex
+	dictionaries map[uint32][]byte
+	decoders     map[uint32]*zstd.Decoder // by dictionary id, 0 for no dictionary
+}
+
+func NewMessageDecompressor() *MessageDecompressor {
+	return &MessageDecompressor{
+		dictionaries: make(map[uint32][]byte),
+		decoders:     make(map[uint32]*zstd.Decoder),
+	}
+}
+
+func (d *MessageDecompressor) AddDictionaries(dictionaries []*mq_pb.CompressionDictionary) {
+	d.Lock()
+	defer d.Unlock()
+	for _, dictionary := range dictionaries {
+		d.dictionaries[dictionary.Id] = dictionary.Data
+	}
+}
+
+// Decompress restores the value of a compressed message, and removes the compression headers.
+// ErrUnknownCompressionDictionary is returned if the dictionary is not added yet.
+func (d *MessageDecompressor) Decompress(message *mq_pb.DataMessage) error {
+	codec := message.Headers[CompressionHeader]
+	if codec == "" {
+		return nil
+	}
+	if codec != CompressionZstd {
+		return fmt.Errorf("unsupported compression %s", codec)
+	}
+	var dictionaryId uint32
+	if idString := message.Headers[CompressionDictionaryHeader]; idString != "" {
+		id, err := strconv.ParseUint(idString, 10, 32)
+		if err != nil {
+			return fmt.Errorf("parse compression dictionary id %s: %v", idString, err)
+		}
+		dictionaryId = uint32(id)
+	}
+
+	decoder, err := d.getDecoder(dictionaryId)
+	if err != nil {
+		return err
+	}
+	value, err := decoder.DecodeAll(message.Value, nil)
+	if err != nil {
+		return fmt.Errorf("decompress with dictionary %d: %v", dictionaryId, err)
+	}
+	message.Value = value
+	delete(message.Headers, CompressionHeader)
+	delete(message.Headers, CompressionDictionaryHeader)
+	return nil
+}
+
+func (d *MessageDecompressor) getDecoder(dictionaryId uint32) (*zstd.Decoder, error) {
+	d.Lock()
+	defer d.Unlock()
+	if decoder, found := d.decoders[dictionaryId]; found {
+		return decoder, nil
+	}
+	var options []zstd.DOption
+	if dictionaryId != 0 {
+		dictionary, found := d.dictionaries[dictionaryId]
+		if !found {
+			return nil, fmt.Errorf("%w %d", ErrUnknownCompressionDictionary, dictionaryId)
+		}
+		options = append(options, zstd.WithDecoderDictRaw(dictionaryId, dictionary))
+	}
+	decoder, err := zstd.NewReader(nil, options...)
+	if err != nil {
+		return nil, fmt.Errorf("create zstd decoder: %v", err)
+	}
+	d.decoders[dictionaryId] = decoder
+	return decoder, nil
+}
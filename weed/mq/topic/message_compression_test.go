@@ -0,0 +1,69 @@
+package topic
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+func TestMessageCompression(t *testing.T) {
+	dictionaries := []*mq_pb.CompressionDictionary{
+		{Id: 1, Data: []byte(`{"user":"","action":"login","device":"mobile"}`)},
+		{Id: 2, Data: []byte(`{"user":"","action":"logout","device":"desktop","region":"us-east","app":"seaweedfs","version":"3.80","timestamp":"2024-10-16T00:00:00Z"}`)},
+	}
+	compressor, err := NewMessageCompressor(32, dictionaries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := []byte(`{"user":"alice","action":"logout","device":"desktop","region":"us-east","app":"seaweedfs","version":"3.80","timestamp":"2024-10-16T08:30:00Z"}`)
+	message := &mq_pb.DataMessage{Value: append([]byte(nil), value...)}
+	compressor.Compress(message)
+	if message.Headers[CompressionHeader] != CompressionZstd || message.Headers[CompressionDictionaryHeader] != "2" {
+		t.Fatalf("unexpected headers %v", message.Headers)
+	}
+	if len(message.Value) >= len(value) {
+		t.Errorf("compressed %d bytes into %d bytes", len(value), len(message.Value))
+	}
+
+	decompressor := NewMessageDecompressor()
+	if err = decompressor.Decompress(message); !errors.Is(err, ErrUnknownCompressionDictionary) {
+		t.Fatalf("expected unknown dictionary, got %v", err)
+	}
+	decompressor.AddDictionaries(dictionaries)
+	if err = decompressor.Decompress(message); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(message.Value, value) || len(message.Headers) != 0 {
+		t.Errorf("decompressed %s with headers %v", message.Value, message.Headers)
+	}
+
+	// small values are not compressed
+	small := &mq_pb.DataMessage{Value: []byte("small")}
+	compressor.Compress(small)
+	if string(small.Value) != "small" || small.Headers != nil {
+		t.Errorf("small value is compressed")
+	}
+	if err = decompressor.Decompress(small); err != nil || string(small.Value) != "small" {
+		t.Errorf("uncompressed value: %s %v", small.Value, err)
+	}
+}
+
+func TestMergeCompressionDictionaries(t *testing.T) {
+	existing := []*mq_pb.CompressionDictionary{{Id: 2, Data: []byte("b")}}
+
+	merged, changed, err := MergeCompressionDictionaries(existing, []*mq_pb.CompressionDictionary{{Id: 1, Data: []byte("a")}, {Id: 2, Data: []byte("b")}})
+	if err != nil || !changed || len(merged) != 2 || merged[0].Id != 1 || merged[1].Id != 2 {
+		t.Errorf("merged %v changed %v: %v", merged, changed, err)
+	}
+
+	if _, changed, err = MergeCompressionDictionaries(existing, existing); err != nil || changed {
+		t.Errorf("merging the same dictionaries changed %v: %v", changed, err)
+	}
+
+	if _, _, err = MergeCompressionDictionaries(existing, []*mq_pb.CompressionDictionary{{Id: 2, Data: []byte("c")}}); err == nil {
+		t.Errorf("changing an existing dictionary should fail")
+	}
+}
@@ -0,0 +1,51 @@
+package topic
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+const SampleTopicSuffix = ".sample"
+
+// SampleTopic is the topic receiving the messages sampled by the rule
+func SampleTopic(t Topic, rule *mq_pb.SamplingRule) Topic {
+	if rule.SampleTopic != "" {
+		return NewTopic(t.Namespace, rule.SampleTopic)
+	}
+	return NewTopic(t.Namespace, t.Name+SampleTopicSuffix)
+}
+
+func ValidateSamplingRules(t Topic, rules []*mq_pb.SamplingRule) error {
+	sampleTopics := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.Ratio <= 0 || rule.Ratio > 1 {
+			return fmt.Errorf("sampling ratio %v should be in (0, 1]", rule.Ratio)
+		}
+		sampleTopic := SampleTopic(t, rule)
+		if sampleTopic == t {
+			return fmt.Errorf("topic %s can not be sampled to itself", t)
+		}
+		if sampleTopics[sampleTopic.Name] {
+			return fmt.Errorf("topic %s is sampled to %s more than once", t, sampleTopic)
+		}
+		sampleTopics[sampleTopic.Name] = true
+	}
+	return nil
+}
+
+// IsSampled picks the message randomly, or by the key hash so the same keys are always or never picked
+func IsSampled(rule *mq_pb.SamplingRule, key []byte) bool {
+	if rule.Ratio >= 1 {
+		return true
+	}
+	if !rule.ByKey {
+		return rand.Float64() < rule.Ratio
+	}
+	// not the partitioning hash, to sample the keys evenly from all partitions
+	h := fnv.New64a()
+	h.Write(key)
+	return float64(h.Sum64()%1000000) < rule.Ratio*1000000
+}
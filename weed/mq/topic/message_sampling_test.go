@@ -0,0 +1,49 @@
+package topic
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+func TestValidateSamplingRules(t *testing.T) {
+	orders := NewTopic("shop", "orders")
+	if got := SampleTopic(orders, &mq_pb.SamplingRule{Ratio: 0.01}); got != NewTopic("shop", "orders.sample") {
+		t.Errorf("default sample topic %v", got)
+	}
+	if err := ValidateSamplingRules(orders, []*mq_pb.SamplingRule{{Ratio: 0.01}, {Ratio: 0.5, SampleTopic: "orders.staging"}}); err != nil {
+		t.Errorf("valid rules: %v", err)
+	}
+	for _, rules := range [][]*mq_pb.SamplingRule{
+		{{Ratio: 0}},
+		{{Ratio: 1.5}},
+		{{Ratio: 0.1, SampleTopic: "orders"}},
+		{{Ratio: 0.1}, {Ratio: 0.2, SampleTopic: "orders.sample"}},
+	} {
+		if err := ValidateSamplingRules(orders, rules); err == nil {
+			t.Errorf("invalid rules %v", rules)
+		}
+	}
+}
+
+func TestIsSampledByKey(t *testing.T) {
+	rule := &mq_pb.SamplingRule{Ratio: 0.1, ByKey: true}
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		isSampled := IsSampled(rule, key)
+		if isSampled != IsSampled(rule, key) {
+			t.Fatalf("key %s is not sampled consistently", key)
+		}
+		if isSampled {
+			sampled++
+		}
+	}
+	if sampled < 800 || sampled > 1200 {
+		t.Errorf("sampled %d of 10000 keys with ratio 0.1", sampled)
+	}
+	if !IsSampled(&mq_pb.SamplingRule{Ratio: 1}, nil) {
+		t.Errorf("ratio 1 samples all messages")
+	}
+}
@@ -3,11 +3,24 @@ package topic
 import (
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 	"time"
 )
 
 const PartitionCount = 4096
 
+// KeyHashFunction describes KeyHash: the first 4 bytes of md5(key) as a big endian int32
+const KeyHashFunction = "md5_int32"
+
+// KeyHash maps a message key to a slot on the ring, which is covered by exactly one partition
+func KeyHash(key []byte, ringSize int32) int32 {
+	slot := util.HashToInt32(key) % ringSize
+	if slot < 0 {
+		slot = -slot
+	}
+	return slot
+}
+
 type Partition struct {
 	RangeStart int32
 	RangeStop  int32 // exclusive
@@ -86,6 +99,15 @@ func (partition Partition) Overlaps(partition2 Partition) bool {
 	return true
 }
 
+// CoversKey checks whether a keyed message is routed to this partition
+func (partition Partition) CoversKey(key []byte) bool {
+	if partition.RingSize <= 0 {
+		return true
+	}
+	slot := KeyHash(key, partition.RingSize)
+	return partition.RangeStart <= slot && slot < partition.RangeStop
+}
+
 func (partition Partition) String() string {
 	return fmt.Sprintf("%04d-%04d", partition.RangeStart, partition.RangeStop)
 }
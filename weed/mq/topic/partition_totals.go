@@ -0,0 +1,45 @@
+package topic
+
+import "sync"
+
+// PartitionTotals counts the messages and bytes flushed to the log files since the partition was created.
+// The totals are saved with each log file, and loaded from the latest log file when the partition is loaded.
+type PartitionTotals struct {
+	loadLock sync.Mutex
+	lock     sync.Mutex
+	loaded   bool
+	messages int64
+	bytes    int64
+}
+
+// Load loads the saved totals once, and is retried on errors
+func (t *PartitionTotals) Load(loadFn func() (messages, bytes int64, err error)) error {
+	t.loadLock.Lock()
+	defer t.loadLock.Unlock()
+	if _, _, loaded := t.Get(); loaded {
+		return nil
+	}
+	messages, bytes, err := loadFn()
+	if err != nil {
+		return err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.messages, t.bytes, t.loaded = messages, bytes, true
+	return nil
+}
+
+// Get returns the totals, which are not complete until loaded
+func (t *PartitionTotals) Get() (messages, bytes int64, loaded bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.messages, t.bytes, t.loaded
+}
+
+// Add counts the flushed messages
+func (t *PartitionTotals) Add(messages, bytes int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.messages += messages
+	t.bytes += bytes
+}
@@ -0,0 +1,27 @@
+package topic
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPartitionTotals(t *testing.T) {
+	var totals PartitionTotals
+	if err := totals.Load(func() (int64, int64, error) { return 0, 0, fmt.Errorf("filer unavailable") }); err == nil {
+		t.Fatalf("load should fail")
+	}
+	if _, _, loaded := totals.Get(); loaded {
+		t.Fatalf("loaded after failure")
+	}
+
+	// retried after the failure, and loaded only once
+	for i := 0; i < 2; i++ {
+		if err := totals.Load(func() (int64, int64, error) { return 10, 1000, nil }); err != nil {
+			t.Fatal(err)
+		}
+		totals.Add(1, 100)
+	}
+	if messages, bytes, loaded := totals.Get(); messages != 12 || bytes != 1200 || !loaded {
+		t.Fatalf("totals %d messages %d bytes loaded %v", messages, bytes, loaded)
+	}
+}
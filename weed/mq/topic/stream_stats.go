@@ -0,0 +1,89 @@
+package topic
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+// StreamStats counts the messages of one publish or subscribe stream, to find the clients causing the backpressure
+type StreamStats struct {
+	messages        atomic.Int64
+	bytes           atomic.Int64
+	lastAckSequence atomic.Int64
+	queueDepth      atomic.Int64
+
+	// the timestamps of the received messages not acked yet, only for the publishers
+	pendingLock sync.Mutex
+	pendingTsNs []int64
+
+	rateLock          sync.Mutex
+	sampledAt         time.Time
+	sampledMessages   int64
+	sampledBytes      int64
+	messagesPerSecond float64
+	bytesPerSecond    float64
+}
+
+func (s *StreamStats) Add(byteCount int) {
+	s.messages.Add(1)
+	s.bytes.Add(int64(byteCount))
+}
+
+func (s *StreamStats) SetLastAckSequence(sequence int64) {
+	s.lastAckSequence.Store(sequence)
+}
+
+func (s *StreamStats) SetQueueDepth(depth int) {
+	s.queueDepth.Store(int64(depth))
+}
+
+// AddPending tracks a received message until AckPending acks its timestamp
+func (s *StreamStats) AddPending(tsNs int64) {
+	s.pendingLock.Lock()
+	defer s.pendingLock.Unlock()
+	s.pendingTsNs = append(s.pendingTsNs, tsNs)
+	s.queueDepth.Store(int64(len(s.pendingTsNs)))
+}
+
+// AckPending removes the messages up to the acked sequence, which are mostly received in the order of the timestamps
+func (s *StreamStats) AckPending(ackSequence int64) {
+	s.pendingLock.Lock()
+	defer s.pendingLock.Unlock()
+	acked := sort.Search(len(s.pendingTsNs), func(i int) bool {
+		return s.pendingTsNs[i] > ackSequence
+	})
+	s.pendingTsNs = s.pendingTsNs[acked:]
+	s.queueDepth.Store(int64(len(s.pendingTsNs)))
+	s.lastAckSequence.Store(ackSequence)
+}
+
+// Sample computes the rates since the last sample
+func (s *StreamStats) Sample(now time.Time) {
+	messages, bytes := s.messages.Load(), s.bytes.Load()
+
+	s.rateLock.Lock()
+	defer s.rateLock.Unlock()
+	if !s.sampledAt.IsZero() {
+		if elapsed := now.Sub(s.sampledAt).Seconds(); elapsed > 0 {
+			s.messagesPerSecond = float64(messages-s.sampledMessages) / elapsed
+			s.bytesPerSecond = float64(bytes-s.sampledBytes) / elapsed
+		}
+	}
+	s.sampledAt, s.sampledMessages, s.sampledBytes = now, messages, bytes
+}
+
+// Fill sets the stream statistics of the connection
+func (s *StreamStats) Fill(connection *mq_pb.ClientConnection) {
+	connection.Messages = s.messages.Load()
+	connection.Bytes = s.bytes.Load()
+	connection.LastAckSequence = s.lastAckSequence.Load()
+	connection.QueueDepth = s.queueDepth.Load()
+	s.rateLock.Lock()
+	defer s.rateLock.Unlock()
+	connection.MessagesPerSecond = s.messagesPerSecond
+	connection.BytesPerSecond = s.bytesPerSecond
+}
@@ -0,0 +1,58 @@
+package topic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+)
+
+func TestStreamStatsRates(t *testing.T) {
+	var s StreamStats
+	now := time.Now()
+	s.Sample(now)
+	for i := 0; i < 10; i++ {
+		s.Add(100)
+	}
+	s.Sample(now.Add(2 * time.Second))
+
+	connection := &mq_pb.ClientConnection{}
+	s.Fill(connection)
+	if connection.Messages != 10 || connection.Bytes != 1000 {
+		t.Errorf("unexpected totals %d messages %d bytes", connection.Messages, connection.Bytes)
+	}
+	if connection.MessagesPerSecond != 5 || connection.BytesPerSecond != 500 {
+		t.Errorf("unexpected rates %v messages/s %v bytes/s", connection.MessagesPerSecond, connection.BytesPerSecond)
+	}
+
+	s.Sample(now.Add(3 * time.Second))
+	s.Fill(connection)
+	if connection.MessagesPerSecond != 0 || connection.BytesPerSecond != 0 {
+		t.Errorf("idle stream should have zero rates, got %v messages/s %v bytes/s", connection.MessagesPerSecond, connection.BytesPerSecond)
+	}
+}
+
+func TestStreamStatsPending(t *testing.T) {
+	var s StreamStats
+	for tsNs := int64(1); tsNs <= 5; tsNs++ {
+		s.AddPending(tsNs)
+	}
+
+	connection := &mq_pb.ClientConnection{}
+	s.Fill(connection)
+	if connection.QueueDepth != 5 {
+		t.Errorf("expected queue depth 5, got %d", connection.QueueDepth)
+	}
+
+	s.AckPending(3)
+	s.Fill(connection)
+	if connection.QueueDepth != 2 || connection.LastAckSequence != 3 {
+		t.Errorf("expected queue depth 2 and last ack 3, got %d and %d", connection.QueueDepth, connection.LastAckSequence)
+	}
+
+	s.AckPending(10)
+	s.Fill(connection)
+	if connection.QueueDepth != 0 {
+		t.Errorf("expected empty queue, got %d", connection.QueueDepth)
+	}
+}
@@ -0,0 +1,452 @@
+package nfs
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// the fattr4 attributes of RFC 7530 section 5
+const (
+	attrSupportedAttrs  = 0
+	attrType            = 1
+	attrFhExpireType    = 2
+	attrChange          = 3
+	attrSize            = 4
+	attrLinkSupport     = 5
+	attrSymlinkSupport  = 6
+	attrNamedAttr       = 7
+	attrFsid            = 8
+	attrUniqueHandles   = 9
+	attrLeaseTime       = 10
+	attrRdattrError     = 11
+	attrCansettime      = 15
+	attrCaseInsensitive = 16
+	attrCasePreserving  = 17
+	attrChownRestricted = 18
+	attrFilehandle      = 19
+	attrFileid          = 20
+	attrFilesAvail      = 21
+	attrFilesFree       = 22
+	attrFilesTotal      = 23
+	attrHomogeneous     = 26
+	attrMaxfilesize     = 27
+	attrMaxlink         = 28
+	attrMaxname         = 29
+	attrMaxread         = 30
+	attrMaxwrite        = 31
+	attrMode            = 33
+	attrNoTrunc         = 35
+	attrNumlinks        = 36
+	attrOwner           = 37
+	attrOwnerGroup      = 38
+	attrRawdev          = 41
+	attrSpaceAvail      = 42
+	attrSpaceFree       = 43
+	attrSpaceTotal      = 44
+	attrSpaceUsed       = 45
+	attrTimeAccess      = 47
+	attrTimeAccessSet   = 48
+	attrTimeCreate      = 50
+	attrTimeDelta       = 51
+	attrTimeMetadata    = 52
+	attrTimeModify      = 53
+	attrTimeModifySet   = 54
+	attrMountedOnFileid = 55
+
+	nf4Reg = 1
+	nf4Dir = 2
+	nf4Blk = 3
+	nf4Chr = 4
+	nf4Lnk = 5
+
+	fh4Persistent = 0
+
+	setToServerTime = 0
+	setToClientTime = 1
+
+	nobodyId = 65534
+
+	maxNameLength      = 255
+	maxReadWriteBytes  = 1024 * 1024
+	maxFileSize        = 1 << 53
+	statisticsCacheTtl = 10 * time.Second
+)
+
+var (
+	supportedAttributes = newBitmap(attrSupportedAttrs, attrType, attrFhExpireType, attrChange, attrSize,
+		attrLinkSupport, attrSymlinkSupport, attrNamedAttr, attrFsid, attrUniqueHandles, attrLeaseTime, attrRdattrError,
+		attrCansettime, attrCaseInsensitive, attrCasePreserving, attrChownRestricted, attrFilehandle, attrFileid,
+		attrFilesAvail, attrFilesFree, attrFilesTotal, attrHomogeneous, attrMaxfilesize, attrMaxlink, attrMaxname,
+		attrMaxread, attrMaxwrite, attrMode, attrNoTrunc, attrNumlinks, attrOwner, attrOwnerGroup, attrRawdev,
+		attrSpaceAvail, attrSpaceFree, attrSpaceTotal, attrSpaceUsed, attrTimeAccess, attrTimeAccessSet,
+		attrTimeCreate, attrTimeDelta, attrTimeMetadata, attrTimeModify, attrTimeModifySet, attrMountedOnFileid)
+	writableAttributes   = newBitmap(attrSize, attrMode, attrOwner, attrOwnerGroup, attrTimeAccessSet, attrTimeModifySet)
+	writeOnlyAttributes  = newBitmap(attrTimeAccessSet, attrTimeModifySet)
+	statisticsAttributes = newBitmap(attrFilesAvail, attrFilesFree, attrFilesTotal, attrSpaceAvail, attrSpaceFree, attrSpaceTotal)
+)
+
+func newBitmap(bits ...int) (bitmap []uint32) {
+	for _, bit := range bits {
+		bitmap = bitmapSet(bitmap, bit)
+	}
+	return
+}
+
+func bitmapSet(bitmap []uint32, bit int) []uint32 {
+	for len(bitmap) <= bit/32 {
+		bitmap = append(bitmap, 0)
+	}
+	bitmap[bit/32] |= 1 << (bit % 32)
+	return bitmap
+}
+
+func bitmapHas(bitmap []uint32, bit int) bool {
+	return bit/32 < len(bitmap) && bitmap[bit/32]&(1<<(bit%32)) != 0
+}
+
+func bitmapIntersects(a, b []uint32) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i]&b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// bitmapIsSubset checks all the bits of a are in b
+func bitmapIsSubset(a, b []uint32) bool {
+	for i, word := range a {
+		var other uint32
+		if i < len(b) {
+			other = b[i]
+		}
+		if word&^other != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func fileType(entry *filer_pb.Entry) uint32 {
+	switch {
+	case entry.IsDirectory:
+		return nf4Dir
+	case entry.Attributes != nil && (entry.Attributes.SymlinkTarget != "" || os.FileMode(entry.Attributes.FileMode)&os.ModeSymlink != 0):
+		return nf4Lnk
+	default:
+		return nf4Reg
+	}
+}
+
+// fileModeToUnix converts the go file mode kept by the filer to the unix permission bits
+func fileModeToUnix(mode os.FileMode) uint32 {
+	unixMode := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		unixMode |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		unixMode |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		unixMode |= 01000
+	}
+	return unixMode
+}
+
+func unixToFileMode(unixMode uint32, oldMode os.FileMode) os.FileMode {
+	mode := oldMode&os.ModeType | os.FileMode(unixMode&0777)
+	if unixMode&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if unixMode&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if unixMode&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// changeAttribute changes whenever the file content or the attributes are changed.
+// The directories also change with the filer metadata events of their children.
+func (s *NfsServer) changeAttribute(obj *nfsObject) uint64 {
+	attr := obj.entry.Attributes
+	change := uint64(attr.Mtime) * uint64(time.Second)
+	for _, chunk := range obj.entry.GetChunks() {
+		if uint64(chunk.ModifiedTsNs) > change {
+			change = uint64(chunk.ModifiedTsNs)
+		}
+	}
+	if obj.entry.IsDirectory {
+		if tsNs := s.dirChanges.get(obj.path); tsNs > change {
+			change = tsNs
+		}
+	}
+	// the size and mode changes within the same second
+	return change + attr.FileSize + uint64(attr.FileMode)<<40 + uint64(attr.Uid)<<20 + uint64(attr.Gid)
+}
+
+func writeTime(w *xdrWriter, unixTime int64) {
+	w.int64(unixTime)
+	w.uint32(0)
+}
+
+// encodeAttributes writes the fattr4 of the requested and supported attributes
+func (s *NfsServer) encodeAttributes(obj *nfsObject, request []uint32, w *xdrWriter) {
+	var returned []uint32
+	values := &xdrWriter{}
+	var stats *filer_pb.StatisticsResponse
+	if bitmapIntersects(request, statisticsAttributes) {
+		stats = s.statistics()
+	}
+	attr := obj.entry.Attributes
+	for bit := 0; bit < len(request)*32; bit++ {
+		if !bitmapHas(request, bit) || !bitmapHas(supportedAttributes, bit) || bitmapHas(writeOnlyAttributes, bit) {
+			continue
+		}
+		returned = bitmapSet(returned, bit)
+		switch bit {
+		case attrSupportedAttrs:
+			values.bitmap(supportedAttributes)
+		case attrType:
+			values.uint32(fileType(obj.entry))
+		case attrFhExpireType:
+			values.uint32(fh4Persistent)
+		case attrChange:
+			values.uint64(s.changeAttribute(obj))
+		case attrSize:
+			if obj.entry.IsDirectory {
+				values.uint64(4096)
+			} else if fileType(obj.entry) == nf4Lnk {
+				values.uint64(uint64(len(attr.SymlinkTarget)))
+			} else {
+				values.uint64(filer.FileSize(obj.entry))
+			}
+		case attrLinkSupport, attrNamedAttr, attrUniqueHandles, attrCaseInsensitive:
+			values.bool(false)
+		case attrSymlinkSupport, attrCansettime, attrCasePreserving, attrChownRestricted, attrHomogeneous, attrNoTrunc:
+			values.bool(true)
+		case attrFsid:
+			values.uint64(s.fsid)
+			values.uint64(0)
+		case attrLeaseTime:
+			values.uint32(leaseSeconds)
+		case attrRdattrError:
+			values.uint32(nfs4Ok)
+		case attrFilehandle:
+			values.opaque(s.encodeFileHandle(obj.fileId, obj.path))
+		case attrFileid, attrMountedOnFileid:
+			values.uint64(obj.fileId)
+		case attrFilesAvail, attrFilesFree:
+			if stats.FileCount < maxFiles {
+				values.uint64(maxFiles - stats.FileCount)
+			} else {
+				values.uint64(0)
+			}
+		case attrFilesTotal:
+			values.uint64(maxFiles)
+		case attrMaxfilesize:
+			values.uint64(maxFileSize)
+		case attrMaxlink:
+			values.uint32(1)
+		case attrMaxname:
+			values.uint32(maxNameLength)
+		case attrMaxread, attrMaxwrite:
+			values.uint64(maxReadWriteBytes)
+		case attrMode:
+			values.uint32(fileModeToUnix(os.FileMode(attr.FileMode)))
+		case attrNumlinks:
+			if obj.entry.IsDirectory {
+				values.uint32(2)
+			} else if obj.entry.HardLinkCounter > 0 {
+				values.uint32(uint32(obj.entry.HardLinkCounter))
+			} else {
+				values.uint32(1)
+			}
+		case attrOwner:
+			values.string(strconv.FormatUint(uint64(attr.Uid), 10))
+		case attrOwnerGroup:
+			values.string(strconv.FormatUint(uint64(attr.Gid), 10))
+		case attrRawdev:
+			values.uint32(attr.Rdev >> 8)
+			values.uint32(attr.Rdev & 0xff)
+		case attrSpaceAvail, attrSpaceFree:
+			if stats.TotalSize > stats.UsedSize {
+				values.uint64(stats.TotalSize - stats.UsedSize)
+			} else {
+				values.uint64(0)
+			}
+		case attrSpaceTotal:
+			values.uint64(stats.TotalSize)
+		case attrSpaceUsed:
+			values.uint64(filer.FileSize(obj.entry))
+		case attrTimeAccess, attrTimeMetadata, attrTimeModify:
+			writeTime(values, attr.Mtime)
+		case attrTimeCreate:
+			writeTime(values, attr.Crtime)
+		case attrTimeDelta:
+			writeTime(values, 1)
+		}
+	}
+	w.bitmap(returned)
+	w.opaque(values.Bytes())
+}
+
+// setAttributes are the attributes decoded from the fattr4 of SETATTR, CREATE, and OPEN
+type setAttributes struct {
+	size     *uint64
+	mode     *uint32
+	uid      *uint32
+	gid      *uint32
+	mtime    *int64
+	attrsSet []uint32
+}
+
+func decodeSetAttributes(r *xdrReader) (*setAttributes, uint32) {
+	mask := r.bitmap()
+	values := newXdrReader(r.opaque(maxRecordBytes))
+	if r.err != nil {
+		return nil, nfs4errBadXdr
+	}
+	if !bitmapIsSubset(mask, supportedAttributes) {
+		return nil, nfs4errAttrNotSupp
+	}
+	if !bitmapIsSubset(mask, writableAttributes) {
+		return nil, nfs4errInval
+	}
+	set := &setAttributes{}
+	for bit := 0; bit < len(mask)*32; bit++ {
+		if !bitmapHas(mask, bit) {
+			continue
+		}
+		set.attrsSet = bitmapSet(set.attrsSet, bit)
+		switch bit {
+		case attrSize:
+			size := values.uint64()
+			set.size = &size
+		case attrMode:
+			mode := values.uint32() & 07777
+			set.mode = &mode
+		case attrOwner, attrOwnerGroup:
+			id, ok := parseOwner(values.string(maxNameLength))
+			if !ok {
+				return nil, nfs4errBadOwner
+			}
+			if bit == attrOwner {
+				set.uid = &id
+			} else {
+				set.gid = &id
+			}
+		case attrTimeAccessSet, attrTimeModifySet:
+			var unixTime int64
+			if values.uint32() == setToClientTime {
+				unixTime = values.int64()
+				values.uint32()
+			} else {
+				unixTime = time.Now().Unix()
+			}
+			if bit == attrTimeModifySet {
+				set.mtime = &unixTime
+			}
+		}
+	}
+	if values.err != nil {
+		return nil, nfs4errBadXdr
+	}
+	return set, nfs4Ok
+}
+
+// parseOwner accepts the numeric ids, as sent by the clients without the id mapping, and a few well known names
+func parseOwner(owner string) (uint32, bool) {
+	if at := strings.IndexByte(owner, '@'); at >= 0 {
+		owner = owner[:at]
+	}
+	switch owner {
+	case "root":
+		return 0, true
+	case "nobody", "nogroup":
+		return nobodyId, true
+	}
+	id, err := strconv.ParseUint(owner, 10, 32)
+	return uint32(id), err == nil
+}
+
+func (set *setAttributes) apply(entry *filer_pb.Entry) {
+	if entry.Attributes == nil {
+		entry.Attributes = &filer_pb.FuseAttributes{}
+	}
+	if set.mode != nil {
+		entry.Attributes.FileMode = uint32(unixToFileMode(*set.mode, os.FileMode(entry.Attributes.FileMode)))
+	}
+	if set.uid != nil {
+		entry.Attributes.Uid = *set.uid
+	}
+	if set.gid != nil {
+		entry.Attributes.Gid = *set.gid
+	}
+	if set.mtime != nil {
+		entry.Attributes.Mtime = *set.mtime
+	}
+	if set.size != nil {
+		truncateEntry(entry, *set.size)
+	}
+}
+
+// truncateEntry changes the file size, and drops the chunks beyond the size
+func truncateEntry(entry *filer_pb.Entry, size uint64) {
+	if size < filer.FileSize(entry) {
+		if uint64(len(entry.Content)) > size {
+			entry.Content = entry.Content[:size]
+		}
+		var chunks []*filer_pb.FileChunk
+		for _, chunk := range entry.GetChunks() {
+			if uint64(chunk.Offset) >= size {
+				continue
+			}
+			if uint64(chunk.Offset)+chunk.Size > size {
+				chunk.Size = size - uint64(chunk.Offset)
+			}
+			chunks = append(chunks, chunk)
+		}
+		entry.Chunks = chunks
+	}
+	entry.Attributes.FileSize = size
+	entry.Attributes.Mtime = time.Now().Unix()
+}
+
+const maxFiles = 1 << 40
+
+// statistics reads the filer statistics for the space and files attributes, cached for a few seconds
+func (s *NfsServer) statistics() *filer_pb.StatisticsResponse {
+	s.statsLock.Lock()
+	defer s.statsLock.Unlock()
+	if s.stats != nil && time.Since(s.statsTime) < statisticsCacheTtl {
+		return s.stats
+	}
+	err := s.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.Statistics(context.Background(), &filer_pb.StatisticsRequest{
+			Collection:  s.option.Collection,
+			Replication: s.option.Replication,
+			DiskType:    s.option.DiskType,
+		})
+		if err != nil {
+			return err
+		}
+		s.stats, s.statsTime = resp, time.Now()
+		return nil
+	})
+	if err != nil {
+		glog.V(0).Infof("nfs read filer statistics: %v", err)
+	}
+	if s.stats == nil {
+		return &filer_pb.StatisticsResponse{}
+	}
+	return s.stats
+}
@@ -0,0 +1,325 @@
+package nfs
+
+import (
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+// the status codes of RFC 7530 and RFC 5661
+const (
+	nfs4Ok                   = 0
+	nfs4errPerm              = 1
+	nfs4errNoent             = 2
+	nfs4errIo                = 5
+	nfs4errAccess            = 13
+	nfs4errExist             = 17
+	nfs4errNotDir            = 20
+	nfs4errIsDir             = 21
+	nfs4errInval             = 22
+	nfs4errNameTooLong       = 63
+	nfs4errNotEmpty          = 66
+	nfs4errStale             = 70
+	nfs4errBadHandle         = 10001
+	nfs4errBadCookie         = 10003
+	nfs4errNotSupp           = 10004
+	nfs4errTooSmall          = 10005
+	nfs4errServerFault       = 10006
+	nfs4errBadType           = 10007
+	nfs4errDelay             = 10008
+	nfs4errSame              = 10009
+	nfs4errExpired           = 10011
+	nfs4errNoFileHandle      = 10020
+	nfs4errMinorVersMismatch = 10021
+	nfs4errStaleClientId     = 10022
+	nfs4errStaleStateId      = 10023
+	nfs4errBadStateId        = 10025
+	nfs4errNotSame           = 10027
+	nfs4errSymlink           = 10029
+	nfs4errRestoreFh         = 10030
+	nfs4errAttrNotSupp       = 10032
+	nfs4errNoGrace           = 10033
+	nfs4errBadXdr            = 10036
+	nfs4errBadOwner          = 10039
+	nfs4errBadName           = 10041
+	nfs4errLockNotSupp       = 10043
+	nfs4errOpIllegal         = 10044
+	nfs4errBadSession        = 10052
+	nfs4errBadSlot           = 10053
+	nfs4errSeqMisordered     = 10063
+	nfs4errSequencePos       = 10064
+	nfs4errRetryUncachedRep  = 10068
+	nfs4errOpNotInSession    = 10071
+	nfs4errClientIdBusy      = 10074
+	nfs4errNotOnlyOp         = 10081
+)
+
+// the operations of RFC 7530 and RFC 5661
+const (
+	opAccess             = 3
+	opClose              = 4
+	opCommit             = 5
+	opCreate             = 6
+	opDelegPurge         = 7
+	opDelegReturn        = 8
+	opGetattr            = 9
+	opGetfh              = 10
+	opLink               = 11
+	opLock               = 12
+	opLockt              = 13
+	opLocku              = 14
+	opLookup             = 15
+	opLookupp            = 16
+	opNverify            = 17
+	opOpen               = 18
+	opOpenattr           = 19
+	opOpenConfirm        = 20
+	opOpenDowngrade      = 21
+	opPutfh              = 22
+	opPutpubfh           = 23
+	opPutrootfh          = 24
+	opRead               = 25
+	opReaddir            = 26
+	opReadlink           = 27
+	opRemove             = 28
+	opRename             = 29
+	opRenew              = 30
+	opRestorefh          = 31
+	opSavefh             = 32
+	opSecinfo            = 33
+	opSetattr            = 34
+	opSetclientid        = 35
+	opSetclientidConfirm = 36
+	opVerify             = 37
+	opWrite              = 38
+	opReleaseLockowner   = 39
+	opBindConnToSession  = 41
+	opExchangeId         = 42
+	opCreateSession      = 43
+	opDestroySession     = 44
+	opFreeStateid        = 45
+	opSecinfoNoName      = 52
+	opSequence           = 53
+	opTestStateid        = 55
+	opDestroyClientid    = 57
+	opReclaimComplete    = 58
+	opIllegal            = 10044
+
+	maxOperationNumber = 58
+	maxMinorVersion    = 1
+)
+
+// compoundState is the current and saved file handles, and the session slot, of one COMPOUND request
+type compoundState struct {
+	s            *NfsServer
+	credential   *rpcCredential
+	minorVersion uint32
+	current      *nfsObject
+	saved        *nfsObject
+	session      *nfsSession
+	slot         *nfsSlot
+	replay       []byte
+}
+
+type nfsOperation struct {
+	fn              func(c *compoundState, r *xdrReader, w *xdrWriter) uint32
+	minMinorVersion uint32
+	maxMinorVersion uint32
+}
+
+var operations map[uint32]nfsOperation
+
+func init() {
+	both := func(fn func(c *compoundState, r *xdrReader, w *xdrWriter) uint32) nfsOperation {
+		return nfsOperation{fn: fn, minMinorVersion: 0, maxMinorVersion: 1}
+	}
+	v40 := func(fn func(c *compoundState, r *xdrReader, w *xdrWriter) uint32) nfsOperation {
+		return nfsOperation{fn: fn, minMinorVersion: 0, maxMinorVersion: 0}
+	}
+	v41 := func(fn func(c *compoundState, r *xdrReader, w *xdrWriter) uint32) nfsOperation {
+		return nfsOperation{fn: fn, minMinorVersion: 1, maxMinorVersion: 1}
+	}
+	notSupported := func(c *compoundState, r *xdrReader, w *xdrWriter) uint32 {
+		return nfs4errNotSupp
+	}
+	lockNotSupported := func(c *compoundState, r *xdrReader, w *xdrWriter) uint32 {
+		return nfs4errLockNotSupp
+	}
+	operations = map[uint32]nfsOperation{
+		opAccess:             both((*compoundState).access),
+		opClose:              both((*compoundState).close),
+		opCommit:             both((*compoundState).commit),
+		opCreate:             both((*compoundState).create),
+		opDelegPurge:         both(notSupported),
+		opDelegReturn:        both((*compoundState).delegReturn),
+		opGetattr:            both((*compoundState).getattr),
+		opGetfh:              both((*compoundState).getfh),
+		opLink:               both(notSupported),
+		opLock:               both(lockNotSupported),
+		opLockt:              both(lockNotSupported),
+		opLocku:              both(lockNotSupported),
+		opLookup:             both((*compoundState).lookup),
+		opLookupp:            both((*compoundState).lookupp),
+		opNverify:            both((*compoundState).nverify),
+		opOpen:               both((*compoundState).open),
+		opOpenattr:           both(notSupported),
+		opOpenConfirm:        v40((*compoundState).openConfirm),
+		opOpenDowngrade:      both((*compoundState).openDowngrade),
+		opPutfh:              both((*compoundState).putfh),
+		opPutpubfh:           both((*compoundState).putrootfh),
+		opPutrootfh:          both((*compoundState).putrootfh),
+		opRead:               both((*compoundState).read),
+		opReaddir:            both((*compoundState).readdir),
+		opReadlink:           both((*compoundState).readlink),
+		opRemove:             both((*compoundState).remove),
+		opRename:             both((*compoundState).rename),
+		opRenew:              v40((*compoundState).renew),
+		opRestorefh:          both((*compoundState).restorefh),
+		opSavefh:             both((*compoundState).savefh),
+		opSecinfo:            both((*compoundState).secinfo),
+		opSetattr:            both((*compoundState).setattr),
+		opSetclientid:        v40((*compoundState).setclientid),
+		opSetclientidConfirm: v40((*compoundState).setclientidConfirm),
+		opVerify:             both((*compoundState).verify),
+		opWrite:              both((*compoundState).write),
+		opReleaseLockowner:   v40((*compoundState).releaseLockowner),
+		opBindConnToSession:  v41((*compoundState).bindConnToSession),
+		opExchangeId:         v41((*compoundState).exchangeId),
+		opCreateSession:      v41((*compoundState).createSession),
+		opDestroySession:     v41((*compoundState).destroySession),
+		opFreeStateid:        v41((*compoundState).freeStateid),
+		opSecinfoNoName:      v41((*compoundState).secinfoNoName),
+		opSequence:           v41((*compoundState).sequence),
+		opTestStateid:        v41((*compoundState).testStateid),
+		opDestroyClientid:    v41((*compoundState).destroyClientid),
+		opReclaimComplete:    v41((*compoundState).reclaimComplete),
+	}
+}
+
+// sessionlessOperations of NFSv4.1 can be the only operation without SEQUENCE
+var sessionlessOperations = map[uint32]bool{
+	opBindConnToSession: true,
+	opExchangeId:        true,
+	opCreateSession:     true,
+	opDestroySession:    true,
+	opDestroyClientid:   true,
+}
+
+// compound runs the operations until one fails, and returns false if the request can not be decoded
+func (s *NfsServer) compound(credential *rpcCredential, r *xdrReader, w *xdrWriter) bool {
+	tag := r.opaque(1024)
+	minorVersion := r.uint32()
+	count := r.uint32()
+	if r.err != nil {
+		return false
+	}
+	if minorVersion > maxMinorVersion {
+		w.uint32(nfs4errMinorVersMismatch)
+		w.opaque(tag)
+		w.uint32(0)
+		return true
+	}
+
+	c := &compoundState{s: s, credential: credential, minorVersion: minorVersion}
+	results := &xdrWriter{}
+	status := uint32(nfs4Ok)
+	var resultCount uint32
+	for i := uint32(0); i < count && status == nfs4Ok; i++ {
+		opcode := r.uint32()
+		if r.err != nil {
+			status = nfs4errBadXdr
+			break
+		}
+		op, found := operations[opcode]
+		resultOpcode := opcode
+		res := &xdrWriter{}
+		switch {
+		case !found && opcode >= opAccess && (opcode <= opReleaseLockowner || minorVersion > 0 && opcode <= maxOperationNumber):
+			status = nfs4errNotSupp
+		case !found || minorVersion < op.minMinorVersion:
+			resultOpcode, status = opIllegal, nfs4errOpIllegal
+		case minorVersion > op.maxMinorVersion:
+			// the NFSv4.0 client id and open confirmation are replaced by the sessions
+			status = nfs4errNotSupp
+		case minorVersion > 0 && i == 0 && opcode != opSequence && !sessionlessOperations[opcode]:
+			status = nfs4errOpNotInSession
+		case minorVersion > 0 && i == 0 && sessionlessOperations[opcode] && count > 1:
+			status = nfs4errNotOnlyOp
+		case minorVersion > 0 && i > 0 && opcode == opSequence:
+			status = nfs4errSequencePos
+		default:
+			status = op.fn(c, r, res)
+			if r.err != nil {
+				status = nfs4errBadXdr
+			}
+		}
+		if c.replay != nil {
+			w.fixedOpaque(c.replay)
+			return true
+		}
+		glog.V(4).Infof("nfs op %d: status %d", opcode, status)
+		results.uint32(resultOpcode)
+		results.uint32(status)
+		results.buf = append(results.buf, res.Bytes()...)
+		resultCount++
+	}
+
+	reply := &xdrWriter{}
+	reply.uint32(status)
+	reply.opaque(tag)
+	reply.uint32(resultCount)
+	reply.buf = append(reply.buf, results.Bytes()...)
+	c.releaseSlot(reply.Bytes())
+	w.buf = append(w.buf, reply.Bytes()...)
+	return true
+}
+
+func (c *compoundState) requireFileHandle() uint32 {
+	if c.current == nil {
+		return nfs4errNoFileHandle
+	}
+	return nfs4Ok
+}
+
+func (c *compoundState) requireDirectory() uint32 {
+	if c.current == nil {
+		return nfs4errNoFileHandle
+	}
+	if !c.current.entry.IsDirectory {
+		return nfs4errNotDir
+	}
+	return nfs4Ok
+}
+
+// requireFile checks the current file handle is a regular file, for READ, WRITE, and COMMIT
+func (c *compoundState) requireFile() uint32 {
+	if c.current == nil {
+		return nfs4errNoFileHandle
+	}
+	switch fileType(c.current.entry) {
+	case nf4Dir:
+		return nfs4errIsDir
+	case nf4Lnk:
+		return nfs4errInval
+	}
+	return nfs4Ok
+}
+
+func checkName(name string) uint32 {
+	switch {
+	case name == "":
+		return nfs4errInval
+	case len(name) > maxNameLength:
+		return nfs4errNameTooLong
+	case name == "." || name == ".." || containsSlashOrNull(name):
+		return nfs4errBadName
+	}
+	return nfs4Ok
+}
+
+func containsSlashOrNull(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if name[i] == '/' || name[i] == 0 {
+			return true
+		}
+	}
+	return false
+}
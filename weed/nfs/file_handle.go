@@ -0,0 +1,111 @@
+package nfs
+
+import (
+	"encoding/binary"
+	"strings"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The NFSv4 file handle is the file id, i.e., the inode of the filer entry, followed by the entry path
+// relative to the export root. The entry found by the path is checked against the file id, so the handle
+// of a removed and recreated file is stale. The entries renamed by this server, and the entries under the
+// renamed directories, are found by their new paths until the server restarts. The paths which do not fit in the handle are kept in memory and referred to by the hash.
+const (
+	fileHandleWithPath = 1
+	fileHandleWithHash = 2
+
+	maxFileHandleBytes = 128
+	fileHandleHeader   = 1 + 8
+	maxRenamedPaths    = 100000
+)
+
+type fileHandles struct {
+	sync.RWMutex
+	longPaths map[uint64]util.FullPath
+	renamed   map[util.FullPath]util.FullPath
+}
+
+func newFileHandles() *fileHandles {
+	return &fileHandles{
+		longPaths: make(map[uint64]util.FullPath),
+		renamed:   make(map[util.FullPath]util.FullPath),
+	}
+}
+
+func (s *NfsServer) relativePath(path util.FullPath) string {
+	if s.root == "/" {
+		return strings.TrimPrefix(string(path), "/")
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(string(path), string(s.root)), "/")
+}
+
+func (s *NfsServer) encodeFileHandle(fileId uint64, path util.FullPath) []byte {
+	relativePath := s.relativePath(path)
+	fh := make([]byte, fileHandleHeader, maxFileHandleBytes)
+	binary.BigEndian.PutUint64(fh[1:], fileId)
+	if fileHandleHeader+len(relativePath) <= maxFileHandleBytes {
+		fh[0] = fileHandleWithPath
+		return append(fh, relativePath...)
+	}
+	hash := uint64(util.HashStringToLong(relativePath))
+	s.handles.Lock()
+	s.handles.longPaths[hash] = path
+	s.handles.Unlock()
+	fh[0] = fileHandleWithHash
+	return binary.BigEndian.AppendUint64(fh, hash)
+}
+
+func (s *NfsServer) decodeFileHandle(fh []byte) (fileId uint64, path util.FullPath, status uint32) {
+	if len(fh) < fileHandleHeader || len(fh) > maxFileHandleBytes {
+		return 0, "", nfs4errBadHandle
+	}
+	fileId = binary.BigEndian.Uint64(fh[1:])
+	switch fh[0] {
+	case fileHandleWithPath:
+		relativePath := string(fh[fileHandleHeader:])
+		if relativePath == "" {
+			return fileId, s.root, nfs4Ok
+		}
+		if strings.Contains("/"+relativePath+"/", "/../") {
+			return 0, "", nfs4errBadHandle
+		}
+		return fileId, s.root.Child(relativePath), nfs4Ok
+	case fileHandleWithHash:
+		if len(fh) != fileHandleHeader+8 {
+			return 0, "", nfs4errBadHandle
+		}
+		s.handles.RLock()
+		path, found := s.handles.longPaths[binary.BigEndian.Uint64(fh[fileHandleHeader:])]
+		s.handles.RUnlock()
+		if !found {
+			return 0, "", nfs4errStale
+		}
+		return fileId, path, nfs4Ok
+	}
+	return 0, "", nfs4errBadHandle
+}
+
+func (h *fileHandles) setRenamed(oldPath, newPath util.FullPath) {
+	h.Lock()
+	defer h.Unlock()
+	if len(h.renamed) >= maxRenamedPaths {
+		h.renamed = make(map[util.FullPath]util.FullPath)
+	}
+	h.renamed[oldPath] = newPath
+}
+
+// renamedPath is the new path of the renamed entry, or of the entry under the renamed directory
+func (h *fileHandles) renamedPath(path util.FullPath) (util.FullPath, bool) {
+	h.RLock()
+	defer h.RUnlock()
+	for dir, suffix := path, ""; dir != "/" && dir != ""; {
+		if newPath, found := h.renamed[dir]; found {
+			return util.FullPath(string(newPath) + suffix), true
+		}
+		parent, name := dir.DirAndName()
+		dir, suffix = util.FullPath(parent), "/"+name+suffix
+	}
+	return "", false
+}
@@ -0,0 +1,282 @@
+package nfs
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	fileSync4 = 2
+
+	// the cookies 1 and 2 are reserved for "." and ".."
+	firstDirCookie = 3
+	maxDirCursors  = 100000
+)
+
+func (c *compoundState) read(r *xdrReader, w *xdrWriter) uint32 {
+	readStateId(r)
+	offset := r.uint64()
+	count := r.uint32()
+	if status := c.requireFile(); status != nfs4Ok {
+		return status
+	}
+	if count > maxReadWriteBytes {
+		count = maxReadWriteBytes
+	}
+	data, eof, err := c.s.readFile(c.current.entry, int64(offset), int(count))
+	if err != nil {
+		glog.Errorf("nfs read %s: %v", c.current.path, err)
+		return nfs4errIo
+	}
+	w.bool(eof)
+	w.opaque(data)
+	return nfs4Ok
+}
+
+func (s *NfsServer) readFile(entry *filer_pb.Entry, offset int64, count int) (data []byte, eof bool, err error) {
+	fileSize := int64(filer.FileSize(entry))
+	if offset >= fileSize {
+		return nil, true, nil
+	}
+	if offset+int64(count) > fileSize {
+		count = int(fileSize - offset)
+	}
+	data = make([]byte, count)
+	if len(entry.Content) > 0 {
+		n := copy(data, entry.Content[min(offset, int64(len(entry.Content))):])
+		return data, offset+int64(n) >= fileSize, nil
+	}
+	visibleIntervals, err := filer.NonOverlappingVisibleIntervals(filer.LookupFn(s), entry.GetChunks(), offset, offset+int64(count))
+	if err != nil {
+		return nil, false, err
+	}
+	chunkViews := filer.ViewFromVisibleIntervals(visibleIntervals, offset, int64(count))
+	reader := filer.NewChunkReaderAtFromClient(s.readerCache, chunkViews, fileSize)
+	n, err := reader.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, false, err
+	}
+	return data[:n], offset+int64(n) >= fileSize, nil
+}
+
+func (c *compoundState) write(r *xdrReader, w *xdrWriter) uint32 {
+	readStateId(r)
+	offset := r.uint64()
+	r.uint32() // stable_how
+	data := r.opaque(maxReadWriteBytes)
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	if status := c.requireFile(); status != nfs4Ok {
+		return status
+	}
+	if offset+uint64(len(data)) > maxFileSize {
+		return nfs4errInval
+	}
+	if err := c.s.writeFile(c.current, int64(offset), data); err != nil {
+		glog.Errorf("nfs write %s: %v", c.current.path, err)
+		return nfs4errIo
+	}
+	w.uint32(uint32(len(data)))
+	w.uint32(fileSync4)
+	w.fixedOpaque(c.s.writeVerifier[:])
+	return nfs4Ok
+}
+
+// writeFile uploads the data as a new chunk, and adds the chunk to the entry before replying,
+// so the writes are always stable and COMMIT has nothing to do.
+func (s *NfsServer) writeFile(obj *nfsObject, offset int64, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	chunk, err := s.saveDataAsChunk(util.NewBytesReader(data), string(obj.path), offset, time.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+
+	lock := s.fileLocks.AcquireLock("write", obj.fileId, util.ExclusiveLock)
+	defer s.fileLocks.ReleaseLock(obj.fileId, lock)
+	latest, status := s.getObject(obj.path)
+	if status != nfs4Ok {
+		return fmt.Errorf("nfs status %d", status)
+	}
+	entry := latest.entry
+	if len(entry.Content) > 0 {
+		contentChunk, err := s.saveDataAsChunk(util.NewBytesReader(entry.Content), string(obj.path), 0, chunk.ModifiedTsNs-1)
+		if err != nil {
+			return err
+		}
+		entry.Chunks = append(entry.GetChunks(), contentChunk)
+		entry.Content = nil
+	}
+	entry.Chunks = append(entry.GetChunks(), chunk)
+	if chunks, err := filer.MaybeManifestize(s.saveDataAsChunk, entry.GetChunks()); err != nil {
+		glog.V(0).Infof("nfs write %s manifestize: %v", obj.path, err)
+	} else {
+		entry.Chunks = chunks
+	}
+	entry.Attributes.FileSize = max(entry.Attributes.FileSize, uint64(offset)+uint64(len(data)))
+	entry.Attributes.Mtime = time.Now().Unix()
+	if err := s.updateEntry(latest); err != nil {
+		return err
+	}
+	obj.entry = entry
+	return nil
+}
+
+func (s *NfsServer) saveDataAsChunk(reader io.Reader, name string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+	uploader, err := operation.NewUploader()
+	if err != nil {
+		return nil, fmt.Errorf("upload data: %v", err)
+	}
+	fileId, uploadResult, err, _ := uploader.UploadWithRetry(
+		s,
+		&filer_pb.AssignVolumeRequest{
+			Count:       1,
+			Replication: s.option.Replication,
+			Collection:  s.option.Collection,
+			DiskType:    s.option.DiskType,
+			Path:        name,
+		},
+		&operation.UploadOption{
+			Filename: name,
+			Cipher:   s.option.Cipher,
+		},
+		func(host, fileId string) string {
+			return fmt.Sprintf("http://%s/%s", host, fileId)
+		},
+		reader,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("upload data: %v", err)
+	}
+	if uploadResult.Error != "" {
+		return nil, fmt.Errorf("upload result: %v", uploadResult.Error)
+	}
+	return uploadResult.ToPbFileChunk(fileId, offset, tsNs), nil
+}
+
+func (c *compoundState) commit(r *xdrReader, w *xdrWriter) uint32 {
+	r.uint64() // offset
+	r.uint32() // count
+	if status := c.requireFile(); status != nfs4Ok {
+		return status
+	}
+	w.fixedOpaque(c.s.writeVerifier[:])
+	return nfs4Ok
+}
+
+type dirCursorKey struct {
+	dir    util.FullPath
+	cookie uint64
+}
+
+// dirCursors remembers the last entry name of each READDIR reply, so the next READDIR continues from the name.
+// The cookie is the position in the directory, and used to skip the entries if the cursor is forgotten.
+type dirCursors struct {
+	sync.Mutex
+	names map[dirCursorKey]string
+}
+
+func (d *dirCursors) get(dir util.FullPath, cookie uint64) (string, bool) {
+	d.Lock()
+	defer d.Unlock()
+	name, found := d.names[dirCursorKey{dir: dir, cookie: cookie}]
+	return name, found
+}
+
+func (d *dirCursors) set(dir util.FullPath, cookie uint64, name string) {
+	d.Lock()
+	defer d.Unlock()
+	if len(d.names) >= maxDirCursors {
+		d.names = make(map[dirCursorKey]string)
+	}
+	d.names[dirCursorKey{dir: dir, cookie: cookie}] = name
+}
+
+func (c *compoundState) readdir(r *xdrReader, w *xdrWriter) uint32 {
+	cookie := r.uint64()
+	r.fixedOpaque(8) // cookieverf
+	r.uint32()       // dircount
+	maxCount := int(r.uint32())
+	request := r.bitmap()
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	if status := c.requireDirectory(); status != nfs4Ok {
+		return status
+	}
+	if cookie == 1 || cookie == 2 {
+		return nfs4errBadCookie
+	}
+	dir := c.current.path
+	startFrom, skip := "", uint64(0)
+	if cookie >= firstDirCookie {
+		if name, found := c.s.dirCursors.get(dir, cookie); found {
+			startFrom = name
+		} else {
+			skip = cookie - firstDirCookie + 1
+		}
+	}
+
+	// the verifier, the end of the entries, and eof
+	entries := &xdrWriter{}
+	budget := maxCount - 8 - 4 - 4 - 16
+	var entryCount int
+	eof, full := true, false
+	err := filer_pb.List(c.s, string(dir), "", func(entry *filer_pb.Entry, isLast bool) error {
+		if full {
+			return nil
+		}
+		if skip > 0 {
+			skip--
+			cookie++
+			eof = isLast
+			return nil
+		}
+		if entry.Attributes == nil {
+			entry.Attributes = &filer_pb.FuseAttributes{}
+		}
+		path := dir.Child(entry.Name)
+		obj := &nfsObject{path: path, entry: entry, fileId: c.s.fileIdOf(path, entry)}
+		nextCookie := cookie + 1
+		if cookie < firstDirCookie {
+			nextCookie = firstDirCookie
+		}
+		encoded := &xdrWriter{}
+		encoded.bool(true)
+		encoded.uint64(nextCookie)
+		encoded.string(entry.Name)
+		c.s.encodeAttributes(obj, request, encoded)
+		if entries.Len()+encoded.Len() > budget {
+			full, eof = true, false
+			return nil
+		}
+		entries.buf = append(entries.buf, encoded.Bytes()...)
+		entryCount++
+		cookie = nextCookie
+		c.s.dirCursors.set(dir, cookie, entry.Name)
+		eof = isLast
+		return nil
+	}, startFrom, false, uint32(min(skip+1024, math.MaxInt32)))
+	if err != nil {
+		return toNfsStatus(err)
+	}
+	if full && entryCount == 0 {
+		return nfs4errTooSmall
+	}
+	w.fixedOpaque(make([]byte, 8))
+	w.buf = append(w.buf, entries.Bytes()...)
+	w.bool(false)
+	w.bool(eof)
+	return nfs4Ok
+}
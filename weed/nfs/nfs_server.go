@@ -0,0 +1,322 @@
+package nfs
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
+)
+
+const rootFileId = 1
+
+type NfsServerOption struct {
+	Filer          pb.ServerAddress
+	FilerRootPath  string
+	GrpcDialOption grpc.DialOption
+	Collection     string
+	Replication    string
+	DiskType       string
+	Cipher         bool
+	CacheDir       string
+	CacheSizeMB    int64
+}
+
+// NfsServer exports the filer directory over NFSv4.0 and NFSv4.1, for the clients which can not use FUSE
+type NfsServer struct {
+	option        *NfsServerOption
+	root          util.FullPath
+	signature     int32
+	fsid          uint64
+	startTime     time.Time
+	writeVerifier [8]byte
+	readerCache   *filer.ReaderCache
+	handles       *fileHandles
+	states        *stateManager
+	dirChanges    *dirChangeTracker
+	dirCursors    *dirCursors
+	fileLocks     *util.LockTable[uint64]
+
+	statsLock sync.Mutex
+	stats     *filer_pb.StatisticsResponse
+	statsTime time.Time
+}
+
+// nfsObject is the filer entry referred to by a file handle
+type nfsObject struct {
+	path   util.FullPath
+	entry  *filer_pb.Entry
+	fileId uint64
+}
+
+func NewNfsServer(option *NfsServerOption) (*NfsServer, error) {
+	root := util.FullPath(path.Clean("/" + option.FilerRootPath))
+	s := &NfsServer{
+		option:     option,
+		root:       root,
+		signature:  util.RandomInt32(),
+		fsid:       uint64(util.HashStringToLong("nfs" + string(option.Filer) + string(root))),
+		startTime:  time.Now(),
+		handles:    newFileHandles(),
+		states:     newStateManager(),
+		dirChanges: newDirChangeTracker(),
+		dirCursors: &dirCursors{names: make(map[dirCursorKey]string)},
+		fileLocks:  util.NewLockTable[uint64](),
+	}
+	if _, err := rand.Read(s.writeVerifier[:]); err != nil {
+		return nil, err
+	}
+
+	cacheUniqueId := util.Md5String([]byte("nfs" + string(option.Filer) + util.Version()))[0:8]
+	cacheDir := path.Join(option.CacheDir, cacheUniqueId)
+	os.MkdirAll(cacheDir, os.FileMode(0755))
+	chunkCache := chunk_cache.NewTieredChunkCache(256, cacheDir, option.CacheSizeMB, 1024*1024)
+	s.readerCache = filer.NewReaderCache(32, chunkCache, filer.LookupFn(s))
+
+	if obj, status := s.getObject(root); status != nfs4Ok {
+		return nil, fmt.Errorf("export %s: nfs status %d", root, status)
+	} else if !obj.entry.IsDirectory {
+		return nil, fmt.Errorf("export %s is not a directory", root)
+	}
+	return s, nil
+}
+
+// Serve accepts the NFS clients, and follows the filer metadata changes until the listener is closed
+func (s *NfsServer) Serve(listener net.Listener) error {
+	go s.followMetadataChanges()
+	go s.states.loopExpireClients()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+var _ = filer_pb.FilerClient(&NfsServer{})
+
+func (s *NfsServer) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithGrpcClient(streamingMode, s.signature, func(grpcConnection *grpc.ClientConn) error {
+		client := filer_pb.NewSeaweedFilerClient(grpcConnection)
+		return fn(client)
+	}, s.option.Filer.ToGrpcAddress(), false, s.option.GrpcDialOption)
+}
+
+func (s *NfsServer) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (s *NfsServer) GetDataCenter() string {
+	return ""
+}
+
+// fileIdOf is the inode of the entry, or derived from the path and the creation time for the entries created without the inode
+func (s *NfsServer) fileIdOf(path util.FullPath, entry *filer_pb.Entry) uint64 {
+	if path == s.root {
+		return rootFileId
+	}
+	if entry.Attributes.Inode != 0 {
+		return entry.Attributes.Inode
+	}
+	return path.AsInode(entry.Attributes.Crtime)
+}
+
+func (s *NfsServer) getObject(path util.FullPath) (*nfsObject, uint32) {
+	var entry *filer_pb.Entry
+	if path == "/" {
+		entry = &filer_pb.Entry{
+			IsDirectory: true,
+			Attributes: &filer_pb.FuseAttributes{
+				FileMode: uint32(os.ModeDir | 0777),
+				Mtime:    s.startTime.Unix(),
+				Crtime:   s.startTime.Unix(),
+			},
+		}
+	} else {
+		var err error
+		if entry, err = filer_pb.GetEntry(s, path); err != nil {
+			return nil, toNfsStatus(err)
+		}
+		if entry == nil {
+			return nil, nfs4errNoent
+		}
+		if entry.Attributes == nil {
+			entry.Attributes = &filer_pb.FuseAttributes{}
+		}
+	}
+	return &nfsObject{path: path, entry: entry, fileId: s.fileIdOf(path, entry)}, nfs4Ok
+}
+
+func (s *NfsServer) resolveFileHandle(fh []byte) (*nfsObject, uint32) {
+	fileId, path, status := s.decodeFileHandle(fh)
+	if status != nfs4Ok {
+		return nil, status
+	}
+	obj, status := s.getObject(path)
+	if status == nfs4Ok && obj.fileId == fileId {
+		return obj, nfs4Ok
+	}
+	if status != nfs4Ok && status != nfs4errNoent {
+		return nil, status
+	}
+	// the file ids derived from the paths change with the renames
+	for i := 0; i < 8; i++ {
+		newPath, found := s.handles.renamedPath(path)
+		if !found {
+			break
+		}
+		obj, status = s.getObject(newPath)
+		if status == nfs4Ok && (obj.fileId == fileId || obj.entry.Attributes.Inode == 0) {
+			obj.fileId = fileId
+			return obj, nfs4Ok
+		}
+		path = newPath
+	}
+	return nil, nfs4errStale
+}
+
+func (s *NfsServer) createEntry(dir util.FullPath, entry *filer_pb.Entry) error {
+	defer s.dirChanges.touch(dir)
+	return s.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+			Directory:  string(dir),
+			Entry:      entry,
+			OExcl:      true,
+			Signatures: []int32{s.signature},
+		})
+	})
+}
+
+func (s *NfsServer) updateEntry(obj *nfsObject) error {
+	dir, _ := obj.path.DirAndName()
+	return s.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory:  dir,
+			Entry:      obj.entry,
+			Signatures: []int32{s.signature},
+		})
+	})
+}
+
+func (s *NfsServer) removeEntry(dir util.FullPath, name string) error {
+	defer s.dirChanges.touch(dir)
+	return s.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.DoRemove(client, string(dir), name, true, false, false, false, []int32{s.signature})
+	})
+}
+
+func (s *NfsServer) renameEntry(oldDir util.FullPath, oldName string, newDir util.FullPath, newName string) error {
+	defer s.dirChanges.touch(oldDir)
+	defer s.dirChanges.touch(newDir)
+	return s.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.AtomicRenameEntry(context.Background(), &filer_pb.AtomicRenameEntryRequest{
+			OldDirectory: string(oldDir),
+			OldName:      oldName,
+			NewDirectory: string(newDir),
+			NewName:      newName,
+			Signatures:   []int32{s.signature},
+		})
+		return err
+	})
+}
+
+// isEmptyDirectory lists one child to check the directory is empty before removing it
+func (s *NfsServer) isEmptyDirectory(dir util.FullPath) (bool, error) {
+	isEmpty := true
+	err := filer_pb.List(s, string(dir), "", func(entry *filer_pb.Entry, isLast bool) error {
+		isEmpty = false
+		return nil
+	}, "", false, 1)
+	return isEmpty, err
+}
+
+func toNfsStatus(err error) uint32 {
+	switch {
+	case err == nil:
+		return nfs4Ok
+	case errors.Is(err, filer_pb.ErrNotFound) || strings.Contains(err.Error(), filer_pb.ErrNotFound.Error()):
+		return nfs4errNoent
+	case strings.Contains(err.Error(), "EEXIST") || strings.Contains(err.Error(), "existing"):
+		return nfs4errExist
+	case strings.Contains(err.Error(), "not empty") || strings.Contains(err.Error(), "non-empty"):
+		return nfs4errNotEmpty
+	}
+	glog.V(1).Infof("nfs filer operation: %v", err)
+	return nfs4errIo
+}
+
+// dirChangeTracker keeps the time of the latest metadata change under the directories,
+// so the NFS clients see the directory changes made by the other filer clients.
+type dirChangeTracker struct {
+	sync.Mutex
+	changes map[util.FullPath]uint64
+}
+
+const maxTrackedDirectories = 100000
+
+func newDirChangeTracker() *dirChangeTracker {
+	return &dirChangeTracker{changes: make(map[util.FullPath]uint64)}
+}
+
+func (t *dirChangeTracker) get(dir util.FullPath) uint64 {
+	t.Lock()
+	defer t.Unlock()
+	return t.changes[dir]
+}
+
+func (t *dirChangeTracker) touch(dir util.FullPath) {
+	t.touchAt(dir, uint64(time.Now().UnixNano()))
+}
+
+func (t *dirChangeTracker) touchAt(dir util.FullPath, tsNs uint64) {
+	t.Lock()
+	defer t.Unlock()
+	if tsNs <= t.changes[dir] {
+		return
+	}
+	if len(t.changes) >= maxTrackedDirectories {
+		// the older changes are covered by the directory mtime or already seen by the clients
+		cutoff := uint64(time.Now().Add(-time.Hour).UnixNano())
+		for d, ts := range t.changes {
+			if ts < cutoff {
+				delete(t.changes, d)
+			}
+		}
+	}
+	t.changes[dir] = tsNs
+}
+
+func (s *NfsServer) followMetadataChanges() {
+	option := &pb.MetadataFollowOption{
+		ClientName: "nfs",
+		ClientId:   s.signature,
+		PathPrefix: string(s.root),
+		StartTsNs:  time.Now().UnixNano(),
+	}
+	for {
+		err := pb.FollowMetadata(s.option.Filer, s.option.GrpcDialOption, option, func(resp *filer_pb.SubscribeMetadataResponse) error {
+			s.dirChanges.touchAt(util.FullPath(resp.Directory), uint64(resp.TsNs))
+			if newParentPath := resp.EventNotification.NewParentPath; newParentPath != "" {
+				s.dirChanges.touchAt(util.FullPath(newParentPath), uint64(resp.TsNs))
+			}
+			return nil
+		})
+		glog.V(0).Infof("nfs follow filer %s metadata: %v", s.option.Filer, err)
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,414 @@
+package nfs
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// fakeFiler keeps the entries in memory, for the namespace operations
+type fakeFiler struct {
+	filer_pb.UnimplementedSeaweedFilerServer
+	sync.Mutex
+	entries map[util.FullPath]*filer_pb.Entry
+}
+
+func (f *fakeFiler) LookupDirectoryEntry(ctx context.Context, req *filer_pb.LookupDirectoryEntryRequest) (*filer_pb.LookupDirectoryEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	entry, found := f.entries[util.NewFullPath(req.Directory, req.Name)]
+	if !found {
+		return nil, filer_pb.ErrNotFound
+	}
+	return &filer_pb.LookupDirectoryEntryResponse{Entry: proto.Clone(entry).(*filer_pb.Entry)}, nil
+}
+
+func (f *fakeFiler) ListEntries(req *filer_pb.ListEntriesRequest, stream grpc.ServerStreamingServer[filer_pb.ListEntriesResponse]) error {
+	f.Lock()
+	var names []string
+	children := make(map[string]*filer_pb.Entry)
+	for path, entry := range f.entries {
+		if dir, name := path.DirAndName(); dir == req.Directory && name > req.StartFromFileName {
+			names = append(names, name)
+			children[name] = proto.Clone(entry).(*filer_pb.Entry)
+		}
+	}
+	f.Unlock()
+	sort.Strings(names)
+	for i, name := range names {
+		if req.Limit > 0 && uint32(i) >= req.Limit {
+			break
+		}
+		if err := stream.Send(&filer_pb.ListEntriesResponse{Entry: children[name]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeFiler) CreateEntry(ctx context.Context, req *filer_pb.CreateEntryRequest) (*filer_pb.CreateEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	path := util.NewFullPath(req.Directory, req.Entry.Name)
+	if _, found := f.entries[path]; found {
+		return &filer_pb.CreateEntryResponse{Error: "EEXIST: entry already exists"}, nil
+	}
+	f.entries[path] = req.Entry
+	return &filer_pb.CreateEntryResponse{}, nil
+}
+
+func (f *fakeFiler) UpdateEntry(ctx context.Context, req *filer_pb.UpdateEntryRequest) (*filer_pb.UpdateEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.entries[util.NewFullPath(req.Directory, req.Entry.Name)] = req.Entry
+	return &filer_pb.UpdateEntryResponse{}, nil
+}
+
+func (f *fakeFiler) DeleteEntry(ctx context.Context, req *filer_pb.DeleteEntryRequest) (*filer_pb.DeleteEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.entries, util.NewFullPath(req.Directory, req.Name))
+	return &filer_pb.DeleteEntryResponse{}, nil
+}
+
+func (f *fakeFiler) AtomicRenameEntry(ctx context.Context, req *filer_pb.AtomicRenameEntryRequest) (*filer_pb.AtomicRenameEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	oldPath, newPath := util.NewFullPath(req.OldDirectory, req.OldName), util.NewFullPath(req.NewDirectory, req.NewName)
+	for path, entry := range f.entries {
+		if path == oldPath || path.IsUnder(oldPath) {
+			delete(f.entries, path)
+			renamed := util.FullPath(string(newPath) + strings.TrimPrefix(string(path), string(oldPath)))
+			entry.Name = renamed.Name()
+			f.entries[renamed] = entry
+		}
+	}
+	return &filer_pb.AtomicRenameEntryResponse{}, nil
+}
+
+func startFakeFiler(t *testing.T) (*fakeFiler, pb.ServerAddress) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filer := &fakeFiler{entries: make(map[util.FullPath]*filer_pb.Entry)}
+	grpcServer := grpc.NewServer()
+	filer_pb.RegisterSeaweedFilerServer(grpcServer, filer)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	port := listener.Addr().(*net.TCPAddr).Port
+	return filer, pb.ServerAddress(fmt.Sprintf("127.0.0.1:%d.%d", port, port))
+}
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	xid  uint32
+}
+
+func (tc *testClient) call(procedure uint32, args []byte) *xdrReader {
+	tc.xid++
+	w := &xdrWriter{}
+	w.uint32(tc.xid)
+	w.uint32(rpcCall)
+	w.uint32(rpcVersion)
+	w.uint32(nfsProgram)
+	w.uint32(nfsVersion)
+	w.uint32(procedure)
+	credential := &xdrWriter{}
+	credential.uint32(0)
+	credential.string("client")
+	credential.uint32(1000)
+	credential.uint32(1000)
+	credential.uint32(0)
+	w.uint32(authSys)
+	w.opaque(credential.Bytes())
+	w.uint32(authNone)
+	w.opaque(nil)
+	w.buf = append(w.buf, args...)
+	c := &rpcConn{conn: tc.conn}
+	if err := c.writeRecord(w.Bytes()); err != nil {
+		tc.t.Fatal(err)
+	}
+	record, err := readRecord(tc.conn)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	r := newXdrReader(record)
+	if xid := r.uint32(); xid != tc.xid {
+		tc.t.Fatalf("reply xid %d, expected %d", xid, tc.xid)
+	}
+	r.uint32() // reply
+	r.uint32() // accepted
+	r.uint32()
+	r.opaque(400)
+	if acceptStat := r.uint32(); acceptStat != rpcSuccess {
+		tc.t.Fatalf("rpc accept status %d", acceptStat)
+	}
+	return r
+}
+
+// compound sends the operations, and returns the reader at the results of the first operation
+func (tc *testClient) compound(minorVersion uint32, ops ...func(w *xdrWriter)) (uint32, *xdrReader) {
+	w := &xdrWriter{}
+	w.string("test")
+	w.uint32(minorVersion)
+	w.uint32(uint32(len(ops)))
+	for _, op := range ops {
+		op(w)
+	}
+	r := tc.call(nfsProcCompound, w.Bytes())
+	status := r.uint32()
+	r.string(1024)
+	r.uint32()
+	return status, r
+}
+
+func expectResult(t *testing.T, r *xdrReader, opcode uint32, status uint32) {
+	t.Helper()
+	if op, s := r.uint32(), r.uint32(); op != opcode || s != status {
+		t.Fatalf("operation %d status %d, expected operation %d status %d", op, s, opcode, status)
+	}
+}
+
+func opWithoutArgs(opcode uint32) func(w *xdrWriter) {
+	return func(w *xdrWriter) { w.uint32(opcode) }
+}
+
+func opWithName(opcode uint32, name string) func(w *xdrWriter) {
+	return func(w *xdrWriter) {
+		w.uint32(opcode)
+		w.string(name)
+	}
+}
+
+func putfhOp(fh []byte) func(w *xdrWriter) {
+	return func(w *xdrWriter) {
+		w.uint32(opPutfh)
+		w.opaque(fh)
+	}
+}
+
+func getattrOp(bits ...int) func(w *xdrWriter) {
+	return func(w *xdrWriter) {
+		w.uint32(opGetattr)
+		w.bitmap(newBitmap(bits...))
+	}
+}
+
+func opCreateDirectory(name string) func(w *xdrWriter) {
+	return func(w *xdrWriter) {
+		w.uint32(opCreate)
+		w.uint32(nf4Dir)
+		w.string(name)
+		w.bitmap(nil)
+		w.opaque(nil)
+	}
+}
+
+func TestNfsServerNamespace(t *testing.T) {
+	filer, filerAddress := startFakeFiler(t)
+	s, err := NewNfsServer(&NfsServerOption{
+		Filer:          filerAddress,
+		FilerRootPath:  "/",
+		GrpcDialOption: grpc.WithTransportCredentials(insecure.NewCredentials()),
+		CacheDir:       t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConn, serverConn := net.Pipe()
+	go s.serveConn(serverConn)
+	defer clientConn.Close()
+	tc := &testClient{t: t, conn: clientConn}
+
+	tc.call(nfsProcNull, nil)
+
+	// create a directory, and keep its file handle
+	status, r := tc.compound(0, opWithoutArgs(opPutrootfh), opCreateDirectory("docs"), opWithoutArgs(opGetfh), getattrOp(attrType, attrFileid, attrOwner))
+	if status != nfs4Ok {
+		t.Fatalf("create directory: status %d", status)
+	}
+	expectResult(t, r, opPutrootfh, nfs4Ok)
+	expectResult(t, r, opCreate, nfs4Ok)
+	r.bool()
+	r.uint64()
+	r.uint64()
+	r.bitmap()
+	expectResult(t, r, opGetfh, nfs4Ok)
+	docsHandle := r.opaque(maxFileHandleBytes)
+	expectResult(t, r, opGetattr, nfs4Ok)
+	r.bitmap()
+	attrs := newXdrReader(r.opaque(1024))
+	fileId := filer.entries["/docs"].Attributes.Inode
+	if attrType, attrFileId, owner := attrs.uint32(), attrs.uint64(), attrs.string(64); attrType != nf4Dir || attrFileId != fileId || owner != "1000" {
+		t.Fatalf("unexpected attributes type %d file id %d owner %s", attrType, attrFileId, owner)
+	}
+
+	// the file handle is still valid after the directory is renamed
+	status, r = tc.compound(0, opWithoutArgs(opPutrootfh), opWithoutArgs(opSavefh), func(w *xdrWriter) {
+		w.uint32(opRename)
+		w.string("docs")
+		w.string("papers")
+	})
+	if status != nfs4Ok {
+		t.Fatalf("rename: status %d", status)
+	}
+	status, r = tc.compound(0, putfhOp(docsHandle), getattrOp(attrFileid))
+	if status != nfs4Ok {
+		t.Fatalf("the renamed directory: status %d", status)
+	}
+
+	// the directory is listed by the new name
+	status, r = tc.compound(0, opWithoutArgs(opPutrootfh), func(w *xdrWriter) {
+		w.uint32(opReaddir)
+		w.uint64(0)
+		w.fixedOpaque(make([]byte, 8))
+		w.uint32(4096)
+		w.uint32(4096)
+		w.bitmap(newBitmap(attrFileid))
+	})
+	if status != nfs4Ok {
+		t.Fatalf("readdir: status %d", status)
+	}
+	expectResult(t, r, opPutrootfh, nfs4Ok)
+	expectResult(t, r, opReaddir, nfs4Ok)
+	r.fixedOpaque(8)
+	if !r.bool() {
+		t.Fatalf("expect one directory entry")
+	}
+	r.uint64()
+	if name := r.string(maxNameLength); name != "papers" {
+		t.Errorf("unexpected directory entry %s", name)
+	}
+	r.bitmap()
+	r.opaque(1024)
+	if r.bool() || !r.bool() {
+		t.Errorf("expect the end of the directory")
+	}
+
+	// the handle of the removed and recreated directory is stale
+	status, _ = tc.compound(0, opWithoutArgs(opPutrootfh), opWithName(opRemove, "papers"), opCreateDirectory("papers"))
+	if status != nfs4Ok {
+		t.Fatalf("recreate directory: status %d", status)
+	}
+	filer.entries["/papers"].Attributes.Inode++
+	if status, _ = tc.compound(0, putfhOp(docsHandle)); status != nfs4errStale {
+		t.Errorf("expect stale file handle, got status %d", status)
+	}
+	if status, _ = tc.compound(0, opWithoutArgs(opPutrootfh), opWithName(opLookup, "missing")); status != nfs4errNoent {
+		t.Errorf("expect no entry, got status %d", status)
+	}
+	if status, _ = tc.compound(2, opWithoutArgs(opPutrootfh)); status != nfs4errMinorVersMismatch {
+		t.Errorf("expect minor version mismatch, got status %d", status)
+	}
+}
+
+func TestNfsServerSessions(t *testing.T) {
+	_, filerAddress := startFakeFiler(t)
+	s, err := NewNfsServer(&NfsServerOption{
+		Filer:          filerAddress,
+		FilerRootPath:  "/",
+		GrpcDialOption: grpc.WithTransportCredentials(insecure.NewCredentials()),
+		CacheDir:       t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConn, serverConn := net.Pipe()
+	go s.serveConn(serverConn)
+	defer clientConn.Close()
+	tc := &testClient{t: t, conn: clientConn}
+
+	status, r := tc.compound(1, func(w *xdrWriter) {
+		w.uint32(opExchangeId)
+		w.fixedOpaque(make([]byte, 8))
+		w.string("linux client")
+		w.uint32(0)
+		w.uint32(stateProtectNone)
+		w.uint32(0)
+	})
+	if status != nfs4Ok {
+		t.Fatalf("exchange id: status %d", status)
+	}
+	expectResult(t, r, opExchangeId, nfs4Ok)
+	clientId, sequenceId := r.uint64(), r.uint32()
+
+	channel := channelAttributes{maxRequestSize: 1 << 20, maxResponseSize: 1 << 20, maxResponseSizeCached: 1 << 20, maxOperations: 16, maxRequests: 4}
+	status, r = tc.compound(1, func(w *xdrWriter) {
+		w.uint32(opCreateSession)
+		w.uint64(clientId)
+		w.uint32(sequenceId)
+		w.uint32(0)
+		writeChannelAttributes(w, channel)
+		writeChannelAttributes(w, channel)
+		w.uint32(0)
+		w.uint32(0)
+	})
+	if status != nfs4Ok {
+		t.Fatalf("create session: status %d", status)
+	}
+	expectResult(t, r, opCreateSession, nfs4Ok)
+	sessionId := r.fixedOpaque(16)
+
+	opSequenceOfSlot := func(sequenceId uint32) func(w *xdrWriter) {
+		return func(w *xdrWriter) {
+			w.uint32(opSequence)
+			w.fixedOpaque(sessionId)
+			w.uint32(sequenceId)
+			w.uint32(0)
+			w.uint32(0)
+			w.bool(true)
+		}
+	}
+	status, r = tc.compound(1, opSequenceOfSlot(1), opWithoutArgs(opPutrootfh), opCreateDirectory("once"))
+	if status != nfs4Ok {
+		t.Fatalf("create directory in session: status %d", status)
+	}
+	// the retried request gets the cached reply, and is not executed again
+	if status, _ = tc.compound(1, opSequenceOfSlot(1), opWithoutArgs(opPutrootfh), opCreateDirectory("once")); status != nfs4Ok {
+		t.Errorf("the retried request should be replayed, got status %d", status)
+	}
+	if status, _ = tc.compound(1, opSequenceOfSlot(3), opWithoutArgs(opPutrootfh)); status != nfs4errSeqMisordered {
+		t.Errorf("expect misordered sequence, got status %d", status)
+	}
+	if status, _ = tc.compound(1, opWithoutArgs(opPutrootfh)); status != nfs4errOpNotInSession {
+		t.Errorf("expect operation not in session, got status %d", status)
+	}
+	if status, _ = tc.compound(1, opSequenceOfSlot(2), func(w *xdrWriter) {
+		w.uint32(opRenew)
+		w.uint64(clientId)
+	}); status != nfs4errNotSupp {
+		t.Errorf("expect the NFSv4.0 operation not supported, got status %d", status)
+	}
+}
+
+func TestFileHandle(t *testing.T) {
+	s := &NfsServer{root: "/exports", handles: newFileHandles()}
+	for _, path := range []util.FullPath{"/exports", "/exports/a/b.txt", util.FullPath("/exports/" + strings.Repeat("x", 200))} {
+		fileId, decodedPath, status := s.decodeFileHandle(s.encodeFileHandle(42, path))
+		if status != nfs4Ok || fileId != 42 || decodedPath != path {
+			t.Errorf("decode %s: status %d file id %d path %s", path, status, fileId, decodedPath)
+		}
+	}
+	if _, _, status := s.decodeFileHandle(append([]byte{fileHandleWithPath, 0, 0, 0, 0, 0, 0, 0, 1}, "../etc"...)); status != nfs4errBadHandle {
+		t.Errorf("expect bad handle for the path out of the export, got %d", status)
+	}
+
+	s.handles.setRenamed("/exports/a", "/exports/c")
+	if newPath, found := s.handles.renamedPath("/exports/a/b.txt"); !found || newPath != "/exports/c/b.txt" {
+		t.Errorf("unexpected renamed path %s", newPath)
+	}
+}
@@ -0,0 +1,637 @@
+package nfs
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	access4Read    = 0x01
+	access4Lookup  = 0x02
+	access4Modify  = 0x04
+	access4Extend  = 0x08
+	access4Delete  = 0x10
+	access4Execute = 0x20
+
+	open4NoCreate = 0
+	open4Create   = 1
+
+	createUnchecked  = 0
+	createGuarded    = 1
+	createExclusive  = 2
+	createExclusive4 = 3 // NFSv4.1
+
+	claimNull     = 0
+	claimPrevious = 1
+	claimFh       = 4 // NFSv4.1
+
+	openDelegateNone = 0
+
+	exclusiveVerifierKey = "nfs.verifier"
+)
+
+func (c *compoundState) putfh(r *xdrReader, w *xdrWriter) uint32 {
+	fh := r.opaque(maxFileHandleBytes)
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	obj, status := c.s.resolveFileHandle(fh)
+	if status != nfs4Ok {
+		return status
+	}
+	c.current = obj
+	return nfs4Ok
+}
+
+func (c *compoundState) putrootfh(r *xdrReader, w *xdrWriter) uint32 {
+	obj, status := c.s.getObject(c.s.root)
+	if status != nfs4Ok {
+		return status
+	}
+	c.current = obj
+	return nfs4Ok
+}
+
+func (c *compoundState) getfh(r *xdrReader, w *xdrWriter) uint32 {
+	if status := c.requireFileHandle(); status != nfs4Ok {
+		return status
+	}
+	w.opaque(c.s.encodeFileHandle(c.current.fileId, c.current.path))
+	return nfs4Ok
+}
+
+func (c *compoundState) savefh(r *xdrReader, w *xdrWriter) uint32 {
+	if status := c.requireFileHandle(); status != nfs4Ok {
+		return status
+	}
+	c.saved = c.current
+	return nfs4Ok
+}
+
+func (c *compoundState) restorefh(r *xdrReader, w *xdrWriter) uint32 {
+	if c.saved == nil {
+		return nfs4errRestoreFh
+	}
+	c.current = c.saved
+	return nfs4Ok
+}
+
+func (c *compoundState) lookup(r *xdrReader, w *xdrWriter) uint32 {
+	name := r.string(maxRecordBytes)
+	if status := c.requireDirectory(); status != nfs4Ok {
+		return status
+	}
+	if status := checkName(name); status != nfs4Ok {
+		return status
+	}
+	obj, status := c.s.getObject(c.current.path.Child(name))
+	if status != nfs4Ok {
+		return status
+	}
+	c.current = obj
+	return nfs4Ok
+}
+
+func (c *compoundState) lookupp(r *xdrReader, w *xdrWriter) uint32 {
+	if status := c.requireDirectory(); status != nfs4Ok {
+		return status
+	}
+	if c.current.path == c.s.root {
+		return nfs4errNoent
+	}
+	parent, _ := c.current.path.DirAndName()
+	obj, status := c.s.getObject(util.FullPath(parent))
+	if status != nfs4Ok {
+		return status
+	}
+	c.current = obj
+	return nfs4Ok
+}
+
+func (c *compoundState) getattr(r *xdrReader, w *xdrWriter) uint32 {
+	request := r.bitmap()
+	if status := c.requireFileHandle(); status != nfs4Ok {
+		return status
+	}
+	c.s.encodeAttributes(c.current, request, w)
+	return nfs4Ok
+}
+
+// compareAttributes checks the attributes sent by VERIFY or NVERIFY are the same as the current ones
+func (c *compoundState) compareAttributes(r *xdrReader) (isSame bool, status uint32) {
+	mask := r.bitmap()
+	values := r.opaque(maxRecordBytes)
+	if status := c.requireFileHandle(); status != nfs4Ok {
+		return false, status
+	}
+	if !bitmapIsSubset(mask, supportedAttributes) {
+		return false, nfs4errAttrNotSupp
+	}
+	if bitmapIntersects(mask, writeOnlyAttributes) || bitmapHas(mask, attrRdattrError) {
+		return false, nfs4errInval
+	}
+	current := &xdrWriter{}
+	c.s.encodeAttributes(c.current, mask, current)
+	cr := newXdrReader(current.Bytes())
+	cr.bitmap()
+	return bytes.Equal(cr.opaque(maxRecordBytes), values), nfs4Ok
+}
+
+func (c *compoundState) verify(r *xdrReader, w *xdrWriter) uint32 {
+	isSame, status := c.compareAttributes(r)
+	if status == nfs4Ok && !isSame {
+		return nfs4errNotSame
+	}
+	return status
+}
+
+func (c *compoundState) nverify(r *xdrReader, w *xdrWriter) uint32 {
+	isSame, status := c.compareAttributes(r)
+	if status == nfs4Ok && isSame {
+		return nfs4errSame
+	}
+	return status
+}
+
+// access evaluates the unix permission bits for the AUTH_SYS user. The permissions are not enforced by the
+// other operations, same as the other gateways, since the AUTH_SYS credentials are not authenticated.
+func (c *compoundState) access(r *xdrReader, w *xdrWriter) uint32 {
+	requested := r.uint32()
+	if status := c.requireFileHandle(); status != nfs4Ok {
+		return status
+	}
+	entry := c.current.entry
+	mode := fileModeToUnix(os.FileMode(entry.Attributes.FileMode))
+	var bits uint32
+	switch {
+	case c.credential.uid == 0:
+		bits = 07
+		if !entry.IsDirectory && mode&0111 == 0 {
+			bits = 06
+		}
+	case c.credential.uid == entry.Attributes.Uid:
+		bits = mode >> 6 & 07
+	case c.credential.gid == entry.Attributes.Gid || containsId(c.credential.gids, entry.Attributes.Gid):
+		bits = mode >> 3 & 07
+	default:
+		bits = mode & 07
+	}
+
+	var supported, allowed uint32
+	if entry.IsDirectory {
+		supported = requested & (access4Read | access4Lookup | access4Modify | access4Extend | access4Delete)
+		if bits&04 != 0 {
+			allowed |= access4Read
+		}
+		if bits&01 != 0 {
+			allowed |= access4Lookup
+		}
+		if bits&02 != 0 {
+			allowed |= access4Modify | access4Extend | access4Delete
+		}
+	} else {
+		supported = requested & (access4Read | access4Modify | access4Extend | access4Execute)
+		if bits&04 != 0 {
+			allowed |= access4Read
+		}
+		if bits&02 != 0 {
+			allowed |= access4Modify | access4Extend
+		}
+		if bits&01 != 0 {
+			allowed |= access4Execute
+		}
+	}
+	w.uint32(supported)
+	w.uint32(supported & allowed)
+	return nfs4Ok
+}
+
+func containsId(ids []uint32, id uint32) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compoundState) readlink(r *xdrReader, w *xdrWriter) uint32 {
+	if status := c.requireFileHandle(); status != nfs4Ok {
+		return status
+	}
+	if fileType(c.current.entry) != nf4Lnk {
+		return nfs4errInval
+	}
+	w.string(c.current.entry.Attributes.SymlinkTarget)
+	return nfs4Ok
+}
+
+func (c *compoundState) secinfo(r *xdrReader, w *xdrWriter) uint32 {
+	name := r.string(maxRecordBytes)
+	if status := c.requireDirectory(); status != nfs4Ok {
+		return status
+	}
+	if status := checkName(name); status != nfs4Ok {
+		return status
+	}
+	if _, status := c.s.getObject(c.current.path.Child(name)); status != nfs4Ok {
+		return status
+	}
+	return c.writeSecinfo(w)
+}
+
+func (c *compoundState) secinfoNoName(r *xdrReader, w *xdrWriter) uint32 {
+	r.uint32() // the current file handle, or its parent
+	if status := c.requireFileHandle(); status != nfs4Ok {
+		return status
+	}
+	return c.writeSecinfo(w)
+}
+
+// writeSecinfo lists AUTH_SYS and AUTH_NONE, and the current file handle is consumed
+func (c *compoundState) writeSecinfo(w *xdrWriter) uint32 {
+	w.uint32(2)
+	w.uint32(authSys)
+	w.uint32(authNone)
+	c.current = nil
+	return nfs4Ok
+}
+
+// writeChangeInfo writes the directory change attribute before and after the change, which are not atomic
+func (c *compoundState) writeChangeInfo(w *xdrWriter, dir *nfsObject, before uint64) {
+	w.bool(false)
+	w.uint64(before)
+	w.uint64(c.s.changeAttribute(dir))
+}
+
+// newEntry has the owner of the AUTH_SYS user, and the inode used as the file id
+func (c *compoundState) newEntry(path util.FullPath, mode os.FileMode) *filer_pb.Entry {
+	now := time.Now().Unix()
+	return &filer_pb.Entry{
+		Name:        path.Name(),
+		IsDirectory: mode.IsDir(),
+		Attributes: &filer_pb.FuseAttributes{
+			Mtime:    now,
+			Crtime:   now,
+			FileMode: uint32(mode),
+			Uid:      c.credential.uid,
+			Gid:      c.credential.gid,
+			Inode:    path.AsInode(now),
+		},
+	}
+}
+
+func (c *compoundState) create(r *xdrReader, w *xdrWriter) uint32 {
+	objType := r.uint32()
+	var linkData string
+	switch objType {
+	case nf4Lnk:
+		linkData = r.string(maxRecordBytes)
+	case nf4Blk, nf4Chr:
+		r.uint32()
+		r.uint32()
+	}
+	name := r.string(maxRecordBytes)
+	set, status := decodeSetAttributes(r)
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	if status != nfs4Ok {
+		return status
+	}
+	if status := c.requireDirectory(); status != nfs4Ok {
+		return status
+	}
+	if status := checkName(name); status != nfs4Ok {
+		return status
+	}
+	var mode os.FileMode
+	switch objType {
+	case nf4Dir:
+		mode = os.ModeDir | 0755
+	case nf4Lnk:
+		mode = os.ModeSymlink | 0777
+	default:
+		return nfs4errBadType
+	}
+
+	dir := c.current
+	path := dir.path.Child(name)
+	if _, status := c.s.getObject(path); status == nfs4Ok {
+		return nfs4errExist
+	}
+	before := c.s.changeAttribute(dir)
+	entry := c.newEntry(path, mode)
+	entry.Attributes.SymlinkTarget = linkData
+	set.apply(entry)
+	if err := c.s.createEntry(dir.path, entry); err != nil {
+		return toNfsStatus(err)
+	}
+	obj, status := c.s.getObject(path)
+	if status != nfs4Ok {
+		return status
+	}
+
+	c.writeChangeInfo(w, dir, before)
+	w.bitmap(set.attrsSet)
+	c.current = obj
+	return nfs4Ok
+}
+
+func (c *compoundState) remove(r *xdrReader, w *xdrWriter) uint32 {
+	name := r.string(maxRecordBytes)
+	if status := c.requireDirectory(); status != nfs4Ok {
+		return status
+	}
+	if status := checkName(name); status != nfs4Ok {
+		return status
+	}
+	dir := c.current
+	obj, status := c.s.getObject(dir.path.Child(name))
+	if status != nfs4Ok {
+		return status
+	}
+	if obj.entry.IsDirectory {
+		if isEmpty, err := c.s.isEmptyDirectory(obj.path); err != nil {
+			return toNfsStatus(err)
+		} else if !isEmpty {
+			return nfs4errNotEmpty
+		}
+	}
+	before := c.s.changeAttribute(dir)
+	if err := c.s.removeEntry(dir.path, name); err != nil {
+		return toNfsStatus(err)
+	}
+	c.writeChangeInfo(w, dir, before)
+	return nfs4Ok
+}
+
+func (c *compoundState) rename(r *xdrReader, w *xdrWriter) uint32 {
+	oldName := r.string(maxRecordBytes)
+	newName := r.string(maxRecordBytes)
+	if c.saved == nil || c.current == nil {
+		return nfs4errNoFileHandle
+	}
+	oldDir, newDir := c.saved, c.current
+	if !oldDir.entry.IsDirectory || !newDir.entry.IsDirectory {
+		return nfs4errNotDir
+	}
+	if status := checkName(oldName); status != nfs4Ok {
+		return status
+	}
+	if status := checkName(newName); status != nfs4Ok {
+		return status
+	}
+	source, status := c.s.getObject(oldDir.path.Child(oldName))
+	if status != nfs4Ok {
+		return status
+	}
+	targetPath := newDir.path.Child(newName)
+	if source.entry.IsDirectory && (targetPath == source.path || targetPath.IsUnder(source.path)) {
+		return nfs4errInval
+	}
+
+	oldBefore, newBefore := c.s.changeAttribute(oldDir), c.s.changeAttribute(newDir)
+	if target, status := c.s.getObject(targetPath); status == nfs4Ok {
+		if target.fileId == source.fileId {
+			c.writeChangeInfo(w, oldDir, oldBefore)
+			c.writeChangeInfo(w, newDir, newBefore)
+			return nfs4Ok
+		}
+		if target.entry.IsDirectory != source.entry.IsDirectory {
+			return nfs4errExist
+		}
+		if target.entry.IsDirectory {
+			if isEmpty, err := c.s.isEmptyDirectory(targetPath); err != nil {
+				return toNfsStatus(err)
+			} else if !isEmpty {
+				return nfs4errExist
+			}
+		}
+		if err := c.s.removeEntry(newDir.path, newName); err != nil {
+			return toNfsStatus(err)
+		}
+	} else if status != nfs4errNoent {
+		return status
+	}
+
+	if err := c.s.renameEntry(oldDir.path, oldName, newDir.path, newName); err != nil {
+		return toNfsStatus(err)
+	}
+	c.s.handles.setRenamed(source.path, targetPath)
+	c.writeChangeInfo(w, oldDir, oldBefore)
+	c.writeChangeInfo(w, newDir, newBefore)
+	return nfs4Ok
+}
+
+func (c *compoundState) setattr(r *xdrReader, w *xdrWriter) uint32 {
+	readStateId(r)
+	set, status := decodeSetAttributes(r)
+	if status == nfs4Ok {
+		status = c.requireFileHandle()
+	}
+	if status == nfs4Ok && set.size != nil {
+		status = c.requireFile()
+	}
+	if status == nfs4Ok {
+		status = c.s.updateAttributes(c.current, func(entry *filer_pb.Entry) {
+			set.apply(entry)
+		})
+	}
+	if status != nfs4Ok {
+		w.bitmap(nil)
+		return status
+	}
+	w.bitmap(set.attrsSet)
+	return nfs4Ok
+}
+
+// updateAttributes changes the entry while the file is not written
+func (s *NfsServer) updateAttributes(obj *nfsObject, fn func(entry *filer_pb.Entry)) uint32 {
+	lock := s.fileLocks.AcquireLock("setattr", obj.fileId, util.ExclusiveLock)
+	defer s.fileLocks.ReleaseLock(obj.fileId, lock)
+	latest, status := s.getObject(obj.path)
+	if status != nfs4Ok {
+		return status
+	}
+	fn(latest.entry)
+	if err := s.updateEntry(latest); err != nil {
+		return toNfsStatus(err)
+	}
+	obj.entry = latest.entry
+	return nfs4Ok
+}
+
+func (c *compoundState) open(r *xdrReader, w *xdrWriter) uint32 {
+	r.uint32() // seqid
+	r.uint32() // share_access
+	r.uint32() // share_deny
+	clientId := r.uint64()
+	owner := r.opaque(1024)
+	openType := r.uint32()
+	var createMode uint32
+	var set *setAttributes
+	var verifier []byte
+	setStatus := uint32(nfs4Ok)
+	if openType == open4Create {
+		switch createMode = r.uint32(); createMode {
+		case createUnchecked, createGuarded:
+			set, setStatus = decodeSetAttributes(r)
+		case createExclusive:
+			verifier = r.fixedOpaque(8)
+		case createExclusive4:
+			verifier = r.fixedOpaque(8)
+			set, setStatus = decodeSetAttributes(r)
+		default:
+			return nfs4errInval
+		}
+	}
+	var name string
+	switch claim := r.uint32(); claim {
+	case claimNull:
+		name = r.string(maxRecordBytes)
+	case claimFh:
+		if c.minorVersion == 0 {
+			return nfs4errInval
+		}
+	case claimPrevious:
+		return nfs4errNoGrace
+	default:
+		// no delegations are granted
+		return nfs4errNotSupp
+	}
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	if setStatus != nfs4Ok {
+		return setStatus
+	}
+	if set == nil {
+		set = &setAttributes{}
+	}
+	if status := c.requireFileHandle(); status != nfs4Ok {
+		return status
+	}
+
+	var dir, obj *nfsObject
+	var before uint64
+	var attrsSet []uint32
+	if name == "" {
+		obj = c.current
+	} else {
+		if status := c.requireDirectory(); status != nfs4Ok {
+			return status
+		}
+		if status := checkName(name); status != nfs4Ok {
+			return status
+		}
+		dir = c.current
+		before = c.s.changeAttribute(dir)
+		path := dir.path.Child(name)
+		var status uint32
+		obj, status = c.s.getObject(path)
+		switch {
+		case status == nfs4errNoent && openType == open4Create:
+			entry := c.newEntry(path, 0644)
+			set.apply(entry)
+			if verifier != nil {
+				entry.Extended = map[string][]byte{exclusiveVerifierKey: verifier}
+			}
+			if err := c.s.createEntry(dir.path, entry); err != nil {
+				return toNfsStatus(err)
+			}
+			if obj, status = c.s.getObject(path); status != nfs4Ok {
+				return status
+			}
+			attrsSet = set.attrsSet
+		case status != nfs4Ok:
+			return status
+		case openType == open4Create && createMode == createGuarded:
+			return nfs4errExist
+		case openType == open4Create && verifier != nil:
+			// the retried exclusive create succeeds
+			if !bytes.Equal(obj.entry.Extended[exclusiveVerifierKey], verifier) {
+				return nfs4errExist
+			}
+		case openType == open4Create && set.size != nil && fileType(obj.entry) == nf4Reg:
+			// only the size is set when the unchecked create opens the existing file
+			size := *set.size
+			if status = c.s.updateAttributes(obj, func(entry *filer_pb.Entry) {
+				truncateEntry(entry, size)
+			}); status != nfs4Ok {
+				return status
+			}
+			attrsSet = newBitmap(attrSize)
+		}
+	}
+	switch fileType(obj.entry) {
+	case nf4Dir:
+		return nfs4errIsDir
+	case nf4Lnk:
+		if c.minorVersion == 0 {
+			return nfs4errSymlink
+		}
+		return nfs4errInval
+	}
+
+	writeStateId(w, c.s.states.open(clientId, string(owner), obj.fileId))
+	if dir != nil {
+		c.writeChangeInfo(w, dir, before)
+	} else {
+		w.bool(false)
+		w.uint64(0)
+		w.uint64(0)
+	}
+	w.uint32(0) // the open is confirmed, and no byte range locks
+	w.bitmap(attrsSet)
+	w.uint32(openDelegateNone)
+	c.current = obj
+	return nfs4Ok
+}
+
+func (c *compoundState) openConfirm(r *xdrReader, w *xdrWriter) uint32 {
+	id := readStateId(r)
+	r.uint32() // seqid
+	return c.updateOpen(w, id, false)
+}
+
+func (c *compoundState) openDowngrade(r *xdrReader, w *xdrWriter) uint32 {
+	id := readStateId(r)
+	r.uint32() // seqid
+	r.uint32() // share_access
+	r.uint32() // share_deny
+	return c.updateOpen(w, id, false)
+}
+
+func (c *compoundState) close(r *xdrReader, w *xdrWriter) uint32 {
+	r.uint32() // seqid
+	id := readStateId(r)
+	return c.updateOpen(w, id, true)
+}
+
+func (c *compoundState) updateOpen(w *xdrWriter, id stateId, isClose bool) uint32 {
+	if r := c.requireFileHandle(); r != nfs4Ok {
+		return r
+	}
+	newId, status := c.s.states.updateOpen(id, isClose)
+	if status != nfs4Ok {
+		return status
+	}
+	writeStateId(w, newId)
+	return nfs4Ok
+}
+
+func (c *compoundState) delegReturn(r *xdrReader, w *xdrWriter) uint32 {
+	readStateId(r)
+	return nfs4errBadStateId
+}
+
+func (c *compoundState) releaseLockowner(r *xdrReader, w *xdrWriter) uint32 {
+	r.uint64()
+	r.opaque(1024)
+	return nfs4Ok
+}
@@ -0,0 +1,205 @@
+package nfs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+// ONC RPC of RFC 5531 over TCP, with the record marking
+
+const (
+	rpcVersion = 2
+
+	rpcCall  = 0
+	rpcReply = 1
+
+	rpcMsgAccepted = 0
+
+	rpcSuccess      = 0
+	rpcProgUnavail  = 1
+	rpcProgMismatch = 2
+	rpcProcUnavail  = 3
+	rpcGarbageArgs  = 4
+
+	authNone = 0
+	authSys  = 1
+
+	nfsProgram = 100003
+	nfsVersion = 4
+
+	nfsProcNull     = 0
+	nfsProcCompound = 1
+
+	lastFragment   = 1 << 31
+	maxRecordBytes = 4*maxReadWriteBytes + 64*1024
+)
+
+// rpcCredential is the AUTH_SYS credential, or the nobody user for AUTH_NONE
+type rpcCredential struct {
+	uid  uint32
+	gid  uint32
+	gids []uint32
+}
+
+type rpcCallHeader struct {
+	xid        uint32
+	program    uint32
+	version    uint32
+	procedure  uint32
+	credential rpcCredential
+}
+
+func parseRpcCall(r *xdrReader) (header rpcCallHeader, err error) {
+	header.xid = r.uint32()
+	if msgType := r.uint32(); r.err == nil && msgType != rpcCall {
+		return header, fmt.Errorf("unexpected rpc message type %d", msgType)
+	}
+	if version := r.uint32(); r.err == nil && version != rpcVersion {
+		return header, fmt.Errorf("unexpected rpc version %d", version)
+	}
+	header.program = r.uint32()
+	header.version = r.uint32()
+	header.procedure = r.uint32()
+
+	header.credential = rpcCredential{uid: nobodyId, gid: nobodyId}
+	flavor := r.uint32()
+	body := r.opaque(400)
+	if flavor == authSys {
+		cr := newXdrReader(body)
+		cr.uint32() // stamp
+		cr.string(255)
+		header.credential.uid = cr.uint32()
+		header.credential.gid = cr.uint32()
+		count := cr.uint32()
+		for i := uint32(0); i < count && i < 16 && cr.err == nil; i++ {
+			header.credential.gids = append(header.credential.gids, cr.uint32())
+		}
+		if cr.err != nil {
+			return header, fmt.Errorf("parse auth_sys credential: %v", cr.err)
+		}
+	}
+	// the verifier
+	r.uint32()
+	r.opaque(400)
+	return header, r.err
+}
+
+func writeAcceptedReply(w *xdrWriter, xid uint32, acceptStat uint32) {
+	w.uint32(xid)
+	w.uint32(rpcReply)
+	w.uint32(rpcMsgAccepted)
+	w.uint32(authNone)
+	w.opaque(nil)
+	w.uint32(acceptStat)
+}
+
+// readRecord reads the fragments of one rpc message
+func readRecord(reader io.Reader) ([]byte, error) {
+	var record []byte
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			return nil, err
+		}
+		fragment := binary.BigEndian.Uint32(header[:])
+		size := int(fragment &^ lastFragment)
+		if len(record)+size > maxRecordBytes {
+			return nil, fmt.Errorf("rpc record larger than %d bytes", maxRecordBytes)
+		}
+		start := len(record)
+		record = append(record, make([]byte, size)...)
+		if _, err := io.ReadFull(reader, record[start:]); err != nil {
+			return nil, err
+		}
+		if fragment&lastFragment != 0 {
+			return record, nil
+		}
+	}
+}
+
+type rpcConn struct {
+	conn      net.Conn
+	writeLock sync.Mutex
+}
+
+func (c *rpcConn) writeRecord(record []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(record))|lastFragment)
+	if _, err := c.conn.Write(append(header[:], record...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// serveConn handles the calls of the connection concurrently, and the replies may be out of order
+func (s *NfsServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	c := &rpcConn{conn: conn}
+	reader := bufio.NewReaderSize(conn, 64*1024)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		record, err := readRecord(reader)
+		if err != nil {
+			if err != io.EOF {
+				glog.V(1).Infof("nfs client %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reply := s.handleCall(record)
+			if reply == nil {
+				return
+			}
+			if err := c.writeRecord(reply); err != nil {
+				glog.V(1).Infof("nfs client %s reply: %v", conn.RemoteAddr(), err)
+				conn.Close()
+			}
+		}()
+	}
+}
+
+func (s *NfsServer) handleCall(record []byte) []byte {
+	r := newXdrReader(record)
+	header, err := parseRpcCall(r)
+	if err != nil {
+		glog.V(1).Infof("nfs rpc call: %v", err)
+		if r.err != nil {
+			return nil
+		}
+		w := &xdrWriter{}
+		writeAcceptedReply(w, header.xid, rpcGarbageArgs)
+		return w.Bytes()
+	}
+
+	w := &xdrWriter{}
+	switch {
+	case header.program != nfsProgram:
+		writeAcceptedReply(w, header.xid, rpcProgUnavail)
+	case header.version != nfsVersion:
+		writeAcceptedReply(w, header.xid, rpcProgMismatch)
+		w.uint32(nfsVersion)
+		w.uint32(nfsVersion)
+	case header.procedure == nfsProcNull:
+		writeAcceptedReply(w, header.xid, rpcSuccess)
+	case header.procedure == nfsProcCompound:
+		writeAcceptedReply(w, header.xid, rpcSuccess)
+		if !s.compound(&header.credential, newXdrReader(r.remaining()), w) {
+			w = &xdrWriter{}
+			writeAcceptedReply(w, header.xid, rpcGarbageArgs)
+		}
+	default:
+		writeAcceptedReply(w, header.xid, rpcProcUnavail)
+	}
+	return w.Bytes()
+}
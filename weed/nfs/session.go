@@ -0,0 +1,308 @@
+package nfs
+
+import (
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	exchangeIdFlagUseNonPnfs = 0x00010000
+	exchangeIdFlagConfirmedR = 0x80000000
+
+	stateProtectNone    = 0
+	stateProtectMachine = 1
+
+	rpcsecGss = 6
+
+	channelDirForeChannel = 1
+
+	maxOperations = 64
+)
+
+// the client id of NFSv4.0
+
+func (c *compoundState) setclientid(r *xdrReader, w *xdrWriter) uint32 {
+	var verifier [8]byte
+	copy(verifier[:], r.fixedOpaque(8))
+	owner := r.opaque(1024)
+	r.uint32()     // cb_program
+	r.string(1024) // r_netid
+	r.string(1024) // r_addr
+	r.uint32()     // callback_ident
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	client := c.s.states.setClientId(string(owner), verifier)
+	w.uint64(client.clientId)
+	w.fixedOpaque(client.confirmVerifier[:])
+	return nfs4Ok
+}
+
+func (c *compoundState) setclientidConfirm(r *xdrReader, w *xdrWriter) uint32 {
+	clientId := r.uint64()
+	var confirmVerifier [8]byte
+	copy(confirmVerifier[:], r.fixedOpaque(8))
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	return c.s.states.setClientIdConfirm(clientId, confirmVerifier)
+}
+
+func (c *compoundState) renew(r *xdrReader, w *xdrWriter) uint32 {
+	return c.s.states.renew(r.uint64())
+}
+
+// the client id and the sessions of NFSv4.1
+
+func (c *compoundState) exchangeId(r *xdrReader, w *xdrWriter) uint32 {
+	var verifier [8]byte
+	copy(verifier[:], r.fixedOpaque(8))
+	owner := r.opaque(1024)
+	r.uint32() // flags
+	switch r.uint32() {
+	case stateProtectNone:
+	case stateProtectMachine:
+		r.bitmap()
+		r.bitmap()
+	default:
+		return nfs4errNotSupp
+	}
+	if implementations := r.uint32(); implementations > 0 {
+		r.string(1024)
+		r.string(1024)
+		r.int64()
+		r.uint32()
+	}
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+
+	client := c.s.states.exchangeId(string(owner), verifier)
+	w.uint64(client.clientId)
+	w.uint32(client.sequenceId)
+	flags := uint32(exchangeIdFlagUseNonPnfs)
+	if client.confirmed {
+		flags |= exchangeIdFlagConfirmedR
+	}
+	w.uint32(flags)
+	w.uint32(stateProtectNone)
+	// the server owner and scope
+	serverOwner := []byte(fmt.Sprintf("seaweedfs-nfs-%x", c.s.fsid))
+	w.uint64(0)
+	w.opaque(serverOwner)
+	w.opaque(serverOwner)
+	w.uint32(1)
+	w.string("seaweedfs.com")
+	w.string("SeaweedFS NFS " + util.Version())
+	writeTime(w, 0)
+	return nfs4Ok
+}
+
+type channelAttributes struct {
+	headerPadSize         uint32
+	maxRequestSize        uint32
+	maxResponseSize       uint32
+	maxResponseSizeCached uint32
+	maxOperations         uint32
+	maxRequests           uint32
+}
+
+func readChannelAttributes(r *xdrReader) (attrs channelAttributes) {
+	attrs.headerPadSize = r.uint32()
+	attrs.maxRequestSize = r.uint32()
+	attrs.maxResponseSize = r.uint32()
+	attrs.maxResponseSizeCached = r.uint32()
+	attrs.maxOperations = r.uint32()
+	attrs.maxRequests = r.uint32()
+	if rdmaIrd := r.uint32(); rdmaIrd > 0 {
+		r.uint32()
+	}
+	return
+}
+
+func writeChannelAttributes(w *xdrWriter, attrs channelAttributes) {
+	w.uint32(attrs.headerPadSize)
+	w.uint32(attrs.maxRequestSize)
+	w.uint32(attrs.maxResponseSize)
+	w.uint32(attrs.maxResponseSizeCached)
+	w.uint32(attrs.maxOperations)
+	w.uint32(attrs.maxRequests)
+	w.uint32(0)
+}
+
+// createSession negotiates the fore channel, and declines the back channel since no delegations are granted
+func (c *compoundState) createSession(r *xdrReader, w *xdrWriter) uint32 {
+	clientId := r.uint64()
+	sequenceId := r.uint32()
+	r.uint32() // flags
+	fore := readChannelAttributes(r)
+	back := readChannelAttributes(r)
+	r.uint32() // cb_program
+	securityParameters := r.uint32()
+	for i := uint32(0); i < securityParameters && r.err == nil; i++ {
+		switch r.uint32() {
+		case authSys:
+			r.uint32()
+			r.string(255)
+			r.uint32()
+			r.uint32()
+			gids := r.uint32()
+			for j := uint32(0); j < gids && j < 16; j++ {
+				r.uint32()
+			}
+		case rpcsecGss:
+			r.uint32()
+			r.opaque(1024)
+			r.opaque(1024)
+		}
+	}
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+
+	fore.headerPadSize = 0
+	fore.maxRequestSize = min(fore.maxRequestSize, maxRecordBytes)
+	fore.maxResponseSize = min(fore.maxResponseSize, maxRecordBytes)
+	fore.maxResponseSizeCached = min(fore.maxResponseSizeCached, maxRecordBytes)
+	fore.maxOperations = min(fore.maxOperations, maxOperations)
+	fore.maxRequests = max(min(fore.maxRequests, maxSlots), 1)
+	back.headerPadSize = 0
+
+	session, client, replay, status := c.s.states.createSession(clientId, sequenceId, int(fore.maxRequests))
+	if status != nfs4Ok {
+		return status
+	}
+	if replay != nil {
+		w.buf = append(w.buf, replay...)
+		return nfs4Ok
+	}
+	reply := &xdrWriter{}
+	reply.fixedOpaque(session.sessionId[:])
+	reply.uint32(sequenceId)
+	reply.uint32(0)
+	writeChannelAttributes(reply, fore)
+	writeChannelAttributes(reply, back)
+	c.s.states.setLastSession(client, reply.Bytes())
+	w.buf = append(w.buf, reply.Bytes()...)
+	return nfs4Ok
+}
+
+func readSessionId(r *xdrReader) (sessionId [16]byte) {
+	copy(sessionId[:], r.fixedOpaque(16))
+	return
+}
+
+func (c *compoundState) destroySession(r *xdrReader, w *xdrWriter) uint32 {
+	return c.s.states.destroySession(readSessionId(r))
+}
+
+func (c *compoundState) bindConnToSession(r *xdrReader, w *xdrWriter) uint32 {
+	sessionId := readSessionId(r)
+	r.uint32() // channel direction
+	r.bool()   // rdma mode
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	if c.s.states.getSession(sessionId) == nil {
+		return nfs4errBadSession
+	}
+	w.fixedOpaque(sessionId[:])
+	w.uint32(channelDirForeChannel)
+	w.bool(false)
+	return nfs4Ok
+}
+
+func (c *compoundState) destroyClientid(r *xdrReader, w *xdrWriter) uint32 {
+	return c.s.states.destroyClientId(r.uint64())
+}
+
+// sequence takes the slot for the rest of the operations, or replays the cached reply of the retried request
+func (c *compoundState) sequence(r *xdrReader, w *xdrWriter) uint32 {
+	sessionId := readSessionId(r)
+	sequenceId := r.uint32()
+	slotId := r.uint32()
+	r.uint32() // highest slot id
+	r.bool()   // cache this
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	session := c.s.states.getSession(sessionId)
+	if session == nil {
+		return nfs4errBadSession
+	}
+	session.Lock()
+	defer session.Unlock()
+	if int(slotId) >= len(session.slots) {
+		return nfs4errBadSlot
+	}
+	slot := &session.slots[slotId]
+	switch {
+	case slot.inUse:
+		return nfs4errDelay
+	case sequenceId == slot.sequenceId && slot.reply != nil:
+		c.replay = slot.reply
+		return nfs4Ok
+	case sequenceId == slot.sequenceId:
+		return nfs4errRetryUncachedRep
+	case sequenceId != slot.sequenceId+1:
+		return nfs4errSeqMisordered
+	}
+	slot.sequenceId = sequenceId
+	slot.inUse = true
+	slot.reply = nil
+	c.session, c.slot = session, slot
+
+	highestSlotId := uint32(len(session.slots) - 1)
+	w.fixedOpaque(sessionId[:])
+	w.uint32(sequenceId)
+	w.uint32(slotId)
+	w.uint32(highestSlotId)
+	w.uint32(highestSlotId)
+	w.uint32(0) // status flags
+	return nfs4Ok
+}
+
+// releaseSlot keeps the reply for the retried request
+func (c *compoundState) releaseSlot(reply []byte) {
+	if c.slot == nil {
+		return
+	}
+	c.session.Lock()
+	defer c.session.Unlock()
+	c.slot.reply = reply
+	c.slot.inUse = false
+}
+
+func (c *compoundState) reclaimComplete(r *xdrReader, w *xdrWriter) uint32 {
+	r.bool() // one fs
+	return nfs4Ok
+}
+
+func (c *compoundState) freeStateid(r *xdrReader, w *xdrWriter) uint32 {
+	id := readStateId(r)
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	c.s.states.updateOpen(id, true)
+	return nfs4Ok
+}
+
+func (c *compoundState) testStateid(r *xdrReader, w *xdrWriter) uint32 {
+	count := r.uint32()
+	if count > maxOperations*16 {
+		return nfs4errBadXdr
+	}
+	var statuses []uint32
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		statuses = append(statuses, c.s.states.testStateId(readStateId(r)))
+	}
+	if r.err != nil {
+		return nfs4errBadXdr
+	}
+	w.uint32(uint32(len(statuses)))
+	for _, status := range statuses {
+		w.uint32(status)
+	}
+	return nfs4Ok
+}
@@ -0,0 +1,332 @@
+package nfs
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// The client, session, and open states are only in memory. After the server restarts, the clients get
+// NFS4ERR_STALE_CLIENTID and establish new client ids, while the file handles are still valid.
+// The file content is not locked by the opens, so READ and WRITE accept any stateid.
+
+const (
+	leaseSeconds  = 90
+	maxSlots      = 64
+	stateIdLength = 12
+)
+
+type stateId struct {
+	seqid uint32
+	other [stateIdLength]byte
+}
+
+func readStateId(r *xdrReader) (id stateId) {
+	id.seqid = r.uint32()
+	copy(id.other[:], r.fixedOpaque(stateIdLength))
+	return
+}
+
+func writeStateId(w *xdrWriter, id stateId) {
+	w.uint32(id.seqid)
+	w.fixedOpaque(id.other[:])
+}
+
+type nfsClient struct {
+	clientId        uint64
+	owner           string
+	verifier        [8]byte
+	confirmVerifier [8]byte
+	confirmed       bool
+	sequenceId      uint32 // the CREATE_SESSION sequence of NFSv4.1
+	lastSession     []byte // the reply to replay the last CREATE_SESSION
+	lastRenew       time.Time
+}
+
+type nfsSession struct {
+	sessionId [16]byte
+	client    *nfsClient
+	sync.Mutex
+	slots []nfsSlot
+}
+
+// nfsSlot keeps the last reply of the slot, to reply the retried request of NFSv4.1 exactly once
+type nfsSlot struct {
+	sequenceId uint32
+	inUse      bool
+	reply      []byte
+}
+
+type openKey struct {
+	clientId uint64
+	owner    string
+	fileId   uint64
+}
+
+type openState struct {
+	openKey
+	id stateId
+}
+
+type stateManager struct {
+	sync.Mutex
+	bootId   uint32
+	sequence uint64
+	clients  map[uint64]*nfsClient
+	sessions map[[16]byte]*nfsSession
+	opens    map[openKey]*openState
+	openIds  map[[stateIdLength]byte]*openState
+}
+
+func newStateManager() *stateManager {
+	return &stateManager{
+		bootId:   uint32(time.Now().Unix()),
+		clients:  make(map[uint64]*nfsClient),
+		sessions: make(map[[16]byte]*nfsSession),
+		opens:    make(map[openKey]*openState),
+		openIds:  make(map[[stateIdLength]byte]*openState),
+	}
+}
+
+func (m *stateManager) nextClientId() uint64 {
+	m.sequence++
+	return uint64(m.bootId)<<32 | m.sequence&0xffffffff
+}
+
+func randomVerifier() (verifier [8]byte) {
+	rand.Read(verifier[:])
+	return
+}
+
+// setClientId registers the NFSv4.0 client, which is confirmed by setClientIdConfirm
+func (m *stateManager) setClientId(owner string, verifier [8]byte) *nfsClient {
+	m.Lock()
+	defer m.Unlock()
+	for _, client := range m.clients {
+		if client.owner == owner && client.verifier == verifier && client.confirmed {
+			client.confirmVerifier = randomVerifier()
+			return client
+		}
+	}
+	client := &nfsClient{
+		clientId:        m.nextClientId(),
+		owner:           owner,
+		verifier:        verifier,
+		confirmVerifier: randomVerifier(),
+		lastRenew:       time.Now(),
+	}
+	m.clients[client.clientId] = client
+	return client
+}
+
+func (m *stateManager) setClientIdConfirm(clientId uint64, confirmVerifier [8]byte) uint32 {
+	m.Lock()
+	defer m.Unlock()
+	client, found := m.clients[clientId]
+	if !found || client.confirmVerifier != confirmVerifier {
+		return nfs4errStaleClientId
+	}
+	m.confirmClient(client)
+	return nfs4Ok
+}
+
+// confirmClient drops the earlier records of the same client, e.g., before the client rebooted
+func (m *stateManager) confirmClient(client *nfsClient) {
+	client.confirmed = true
+	client.lastRenew = time.Now()
+	for _, other := range m.clients {
+		if other != client && other.owner == client.owner {
+			m.removeClient(other)
+		}
+	}
+}
+
+func (m *stateManager) removeClient(client *nfsClient) {
+	delete(m.clients, client.clientId)
+	for id, session := range m.sessions {
+		if session.client == client {
+			delete(m.sessions, id)
+		}
+	}
+	for key, open := range m.opens {
+		if key.clientId == client.clientId {
+			delete(m.opens, key)
+			delete(m.openIds, open.id.other)
+		}
+	}
+}
+
+func (m *stateManager) renew(clientId uint64) uint32 {
+	m.Lock()
+	defer m.Unlock()
+	client, found := m.clients[clientId]
+	if !found {
+		if uint32(clientId>>32) != m.bootId {
+			return nfs4errStaleClientId
+		}
+		return nfs4errExpired
+	}
+	client.lastRenew = time.Now()
+	return nfs4Ok
+}
+
+// exchangeId registers the NFSv4.1 client, which is confirmed by its first session
+func (m *stateManager) exchangeId(owner string, verifier [8]byte) *nfsClient {
+	m.Lock()
+	defer m.Unlock()
+	for _, client := range m.clients {
+		if client.owner == owner && client.verifier == verifier {
+			return client
+		}
+	}
+	client := &nfsClient{
+		clientId:   m.nextClientId(),
+		owner:      owner,
+		verifier:   verifier,
+		sequenceId: 1,
+		lastRenew:  time.Now(),
+	}
+	m.clients[client.clientId] = client
+	return client
+}
+
+// createSession returns the new session, or the reply of the replayed CREATE_SESSION
+func (m *stateManager) createSession(clientId uint64, sequenceId uint32, slots int) (session *nfsSession, client *nfsClient, replay []byte, status uint32) {
+	m.Lock()
+	defer m.Unlock()
+	client, found := m.clients[clientId]
+	if !found {
+		return nil, nil, nil, nfs4errStaleClientId
+	}
+	if sequenceId+1 == client.sequenceId && client.lastSession != nil {
+		return nil, client, client.lastSession, nfs4Ok
+	}
+	if sequenceId != client.sequenceId {
+		return nil, nil, nil, nfs4errSeqMisordered
+	}
+	client.sequenceId++
+	m.confirmClient(client)
+	session = &nfsSession{client: client, slots: make([]nfsSlot, slots)}
+	binary.BigEndian.PutUint64(session.sessionId[:8], clientId)
+	m.sequence++
+	binary.BigEndian.PutUint64(session.sessionId[8:], m.sequence)
+	m.sessions[session.sessionId] = session
+	return session, client, nil, nfs4Ok
+}
+
+func (m *stateManager) getSession(sessionId [16]byte) *nfsSession {
+	m.Lock()
+	defer m.Unlock()
+	session, found := m.sessions[sessionId]
+	if found {
+		session.client.lastRenew = time.Now()
+	}
+	return session
+}
+
+func (m *stateManager) destroySession(sessionId [16]byte) uint32 {
+	m.Lock()
+	defer m.Unlock()
+	if _, found := m.sessions[sessionId]; !found {
+		return nfs4errBadSession
+	}
+	delete(m.sessions, sessionId)
+	return nfs4Ok
+}
+
+func (m *stateManager) destroyClientId(clientId uint64) uint32 {
+	m.Lock()
+	defer m.Unlock()
+	client, found := m.clients[clientId]
+	if !found {
+		return nfs4errStaleClientId
+	}
+	for _, session := range m.sessions {
+		if session.client == client {
+			return nfs4errClientIdBusy
+		}
+	}
+	m.removeClient(client)
+	return nfs4Ok
+}
+
+// open returns the open stateid of the open owner and the file, with the seqid increased for each open
+func (m *stateManager) open(clientId uint64, owner string, fileId uint64) stateId {
+	m.Lock()
+	defer m.Unlock()
+	if client, found := m.clients[clientId]; found {
+		client.lastRenew = time.Now()
+	}
+	key := openKey{clientId: clientId, owner: owner, fileId: fileId}
+	if open, found := m.opens[key]; found {
+		open.id.seqid++
+		return open.id
+	}
+	open := &openState{openKey: key, id: stateId{seqid: 1}}
+	binary.BigEndian.PutUint32(open.id.other[:4], m.bootId)
+	m.sequence++
+	binary.BigEndian.PutUint64(open.id.other[4:], m.sequence)
+	m.opens[key] = open
+	m.openIds[open.id.other] = open
+	return open.id
+}
+
+// updateOpen increases the seqid of the open stateid, and forgets it when closed
+func (m *stateManager) updateOpen(id stateId, isClose bool) (stateId, uint32) {
+	m.Lock()
+	defer m.Unlock()
+	open, found := m.openIds[id.other]
+	if !found {
+		if binary.BigEndian.Uint32(id.other[:4]) != m.bootId {
+			return id, nfs4errStaleStateId
+		}
+		return id, nfs4errBadStateId
+	}
+	open.id.seqid++
+	if isClose {
+		delete(m.opens, open.openKey)
+		delete(m.openIds, id.other)
+	}
+	return open.id, nfs4Ok
+}
+
+func (m *stateManager) testStateId(id stateId) uint32 {
+	if isSpecialStateId(id) {
+		return nfs4errBadStateId
+	}
+	m.Lock()
+	defer m.Unlock()
+	if _, found := m.openIds[id.other]; !found {
+		return nfs4errBadStateId
+	}
+	return nfs4Ok
+}
+
+func isSpecialStateId(id stateId) bool {
+	var zeros, ones [stateIdLength]byte
+	for i := range ones {
+		ones[i] = 0xff
+	}
+	return id.other == zeros || id.other == ones
+}
+
+// loopExpireClients forgets the clients which have not renewed their leases for a long time
+func (m *stateManager) loopExpireClients() {
+	for {
+		time.Sleep(leaseSeconds * time.Second)
+		m.Lock()
+		for _, client := range m.clients {
+			if time.Since(client.lastRenew) > 4*leaseSeconds*time.Second {
+				m.removeClient(client)
+			}
+		}
+		m.Unlock()
+	}
+}
+
+func (m *stateManager) setLastSession(client *nfsClient, reply []byte) {
+	m.Lock()
+	defer m.Unlock()
+	client.lastSession = reply
+}
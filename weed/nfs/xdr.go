@@ -0,0 +1,152 @@
+package nfs
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// the XDR encoding of RFC 4506, only the parts used by ONC RPC and NFSv4
+
+var errXdrShort = errors.New("xdr: short buffer")
+
+type xdrWriter struct {
+	buf []byte
+}
+
+func (w *xdrWriter) uint32(v uint32) {
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+}
+
+func (w *xdrWriter) uint64(v uint64) {
+	w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+}
+
+func (w *xdrWriter) int64(v int64) {
+	w.uint64(uint64(v))
+}
+
+func (w *xdrWriter) bool(v bool) {
+	if v {
+		w.uint32(1)
+	} else {
+		w.uint32(0)
+	}
+}
+
+// fixedOpaque writes the bytes padded to the 4 bytes boundary, without the length
+func (w *xdrWriter) fixedOpaque(b []byte) {
+	w.buf = append(w.buf, b...)
+	if pad := (4 - len(b)%4) % 4; pad > 0 {
+		w.buf = append(w.buf, make([]byte, pad)...)
+	}
+}
+
+func (w *xdrWriter) opaque(b []byte) {
+	w.uint32(uint32(len(b)))
+	w.fixedOpaque(b)
+}
+
+func (w *xdrWriter) string(s string) {
+	w.opaque([]byte(s))
+}
+
+func (w *xdrWriter) bitmap(bitmap []uint32) {
+	w.uint32(uint32(len(bitmap)))
+	for _, word := range bitmap {
+		w.uint32(word)
+	}
+}
+
+func (w *xdrWriter) Len() int {
+	return len(w.buf)
+}
+
+func (w *xdrWriter) Bytes() []byte {
+	return w.buf
+}
+
+// xdrReader keeps the first error, so the decoding can be checked once at the end
+type xdrReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func newXdrReader(data []byte) *xdrReader {
+	return &xdrReader{data: data}
+}
+
+func (r *xdrReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.data)-r.pos < n {
+		r.err = errXdrShort
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *xdrReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *xdrReader) uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *xdrReader) int64() int64 {
+	return int64(r.uint64())
+}
+
+func (r *xdrReader) bool() bool {
+	return r.uint32() != 0
+}
+
+func (r *xdrReader) fixedOpaque(n int) []byte {
+	b := r.next(n)
+	r.next((4 - n%4) % 4)
+	return b
+}
+
+// opaque reads the variable length bytes, up to the limit
+func (r *xdrReader) opaque(limit int) []byte {
+	n := r.uint32()
+	if r.err == nil && int64(n) > int64(limit) {
+		r.err = errXdrShort
+		return nil
+	}
+	return r.fixedOpaque(int(n))
+}
+
+func (r *xdrReader) string(limit int) string {
+	return string(r.opaque(limit))
+}
+
+func (r *xdrReader) bitmap() []uint32 {
+	n := r.uint32()
+	if r.err == nil && n > 8 {
+		r.err = errXdrShort
+		return nil
+	}
+	bitmap := make([]uint32, 0, n)
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		bitmap = append(bitmap, r.uint32())
+	}
+	return bitmap
+}
+
+func (r *xdrReader) remaining() []byte {
+	if r.err != nil {
+		return nil
+	}
+	return r.data[r.pos:]
+}
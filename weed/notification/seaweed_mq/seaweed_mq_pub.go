@@ -0,0 +1,189 @@
+package seaweed_mq
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/notification"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/protobuf/proto"
+)
+
+const maxPublishRetryDelay = 30 * time.Second
+
+func init() {
+	notification.MessageQueues = append(notification.MessageQueues, &SeaweedMqQueue{})
+}
+
+// SeaweedMqQueue publishes the filer metadata events to a SeaweedFS MQ topic, so the events are kept
+// with the topic retention, and can be replayed by the subscribers from any time.
+// Each message is keyed by the full path of the entry, so the events of one path are in one partition and in order,
+// and the value is the filer_pb.EventNotification.
+// The filer writes are never blocked by the brokers: the events beyond the buffer are dropped and counted,
+// and the failed publishes are retried with backoff.
+type SeaweedMqQueue struct {
+	topic        topic.Topic
+	pathPrefixes []string
+	messages     chan *seaweedMqMessage
+	retries      chan *seaweedMqMessage // the failed publishes, retried before the new messages
+	isDropping   atomic.Bool
+}
+
+type seaweedMqMessage struct {
+	key      []byte
+	value    []byte
+	failures int
+}
+
+func (q *SeaweedMqQueue) GetName() string {
+	return "seaweed_mq"
+}
+
+func (q *SeaweedMqQueue) Initialize(configuration util.Configuration, prefix string) (err error) {
+	brokers := configuration.GetStringSlice(prefix + "brokers")
+	namespace, topicName := configuration.GetString(prefix+"namespace"), configuration.GetString(prefix+"topic")
+	glog.V(0).Infof("filer.notification.seaweed_mq.brokers: %v", brokers)
+	glog.V(0).Infof("filer.notification.seaweed_mq.topic: %s.%s", namespace, topicName)
+	if len(brokers) == 0 {
+		return fmt.Errorf("missing brokers")
+	}
+	if namespace == "" || topicName == "" {
+		return fmt.Errorf("missing namespace or topic")
+	}
+	partitionCount := configuration.GetInt(prefix + "partition_count")
+	if partitionCount <= 0 {
+		partitionCount = 4
+	}
+	bufferSize := configuration.GetInt(prefix + "buffer_size")
+	if bufferSize <= 0 {
+		bufferSize = 10000
+	}
+
+	q.topic = topic.NewTopic(namespace, topicName)
+	q.pathPrefixes = configuration.GetStringSlice(prefix + "path_prefixes")
+	q.messages = make(chan *seaweedMqMessage, bufferSize)
+	// at most MaxInFlightMessages publishes can fail at the same time
+	q.retries = make(chan *seaweedMqMessage, bufferSize)
+
+	// the brokers may depend on this filer, so connect to them in the background
+	go q.loopPublish(&pub_client.PublisherConfiguration{
+		Topic:               q.topic,
+		PartitionCount:      int32(partitionCount),
+		Brokers:             brokers,
+		PublisherName:       "filer",
+		GrpcDialOption:      security.LoadClientTLS(util.GetViper(), "grpc.filer"),
+		MaxInFlightMessages: bufferSize,
+	})
+	return nil
+}
+
+// SendMessage buffers the event, and drops it if the buffer is full, e.g., when the brokers are not reachable,
+// since it is called in the filer write path
+func (q *SeaweedMqQueue) SendMessage(key string, message proto.Message) (err error) {
+	if !q.isPublished(key, message) {
+		return nil
+	}
+	value, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	select {
+	case q.messages <- &seaweedMqMessage{key: []byte(key), value: value}:
+		if q.isDropping.CompareAndSwap(true, false) {
+			glog.V(0).Infof("resume sending filer events to %s", q.topic)
+		}
+	default:
+		stats.FilerNotificationDroppedCounter.WithLabelValues(q.GetName()).Inc()
+		if q.isDropping.CompareAndSwap(false, true) {
+			glog.Warningf("drop filer events to %s, %d events are not published yet", q.topic, len(q.messages))
+		}
+	}
+	return nil
+}
+
+// isPublished checks both the old and the new paths of the event, so the entries renamed across the prefixes are published.
+// The events of the topics are never published, since the brokers write the topics into this filer.
+func (q *SeaweedMqQueue) isPublished(key string, message proto.Message) bool {
+	paths := []string{key}
+	if event, ok := message.(*filer_pb.EventNotification); ok && event.NewEntry != nil && event.NewParentPath != "" {
+		paths = append(paths, string(util.NewFullPath(event.NewParentPath, event.NewEntry.Name)))
+	}
+	for _, path := range paths {
+		if path == filer.TopicsDir || strings.HasPrefix(path, filer.TopicsDir+"/") {
+			return false
+		}
+	}
+	if len(q.pathPrefixes) == 0 {
+		return true
+	}
+	for _, path := range paths {
+		for _, pathPrefix := range q.pathPrefixes {
+			if isUnderPathPrefix(path, pathPrefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isUnderPathPrefix matches whole path segments, so "/a" and "/a/" cover "/a" and "/a/b", but not "/ab"
+func isUnderPathPrefix(path, pathPrefix string) bool {
+	if pathPrefix != "/" {
+		pathPrefix = strings.TrimSuffix(pathPrefix, "/")
+	}
+	return path == pathPrefix || util.FullPath(path).IsUnder(util.FullPath(pathPrefix))
+}
+
+func (q *SeaweedMqQueue) loopPublish(config *pub_client.PublisherConfiguration) {
+	publisher := pub_client.NewTopicPublisher(config)
+	glog.V(0).Infof("publishing filer events to %s", q.topic)
+	for {
+		var message *seaweedMqMessage
+		select {
+		case message = <-q.retries:
+		default:
+			select {
+			case message = <-q.retries:
+			case message = <-q.messages:
+			}
+		}
+		if message.failures > 0 {
+			time.Sleep(publishRetryDelay(message.failures))
+		}
+		if err := publisher.PublishAsync(message.key, message.value, func(_ *mq_pb.DataMessage, err error) {
+			if err != nil {
+				q.retry(message, err)
+			}
+		}); err != nil {
+			q.retry(message, err)
+		}
+	}
+}
+
+func (q *SeaweedMqQueue) retry(message *seaweedMqMessage, err error) {
+	message.failures++
+	glog.Warningf("publish filer event %s to %s, failed %d times: %v", message.key, q.topic, message.failures, err)
+	select {
+	case q.retries <- message:
+	default:
+		stats.FilerNotificationDroppedCounter.WithLabelValues(q.GetName()).Inc()
+		glog.Errorf("drop filer event %s to %s: too many failed publishes", message.key, q.topic)
+	}
+}
+
+func publishRetryDelay(failures int) time.Duration {
+	if failures > 5 {
+		return maxPublishRetryDelay
+	}
+	return min(time.Second<<(failures-1), maxPublishRetryDelay)
+}
@@ -0,0 +1,74 @@
+package seaweed_mq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublished(t *testing.T) {
+	q := &SeaweedMqQueue{}
+	assert.True(t, q.isPublished("/a/b", &filer_pb.EventNotification{}))
+	assert.False(t, q.isPublished(filer.TopicsDir, &filer_pb.EventNotification{}))
+	assert.False(t, q.isPublished(filer.TopicsDir+"/ns/t/v", &filer_pb.EventNotification{}))
+
+	q.pathPrefixes = []string{"/a", "/c/"}
+	for path, expected := range map[string]bool{
+		"/a":    true,
+		"/a/b":  true,
+		"/ab":   false,
+		"/ab/c": false,
+		"/c":    true,
+		"/c/d":  true,
+		"/cd":   false,
+		"/b/a":  false,
+		"/":     false,
+	} {
+		assert.Equal(t, expected, q.isPublished(path, &filer_pb.EventNotification{}), path)
+	}
+
+	// renamed into or out of the prefixes
+	renamedIn := &filer_pb.EventNotification{NewEntry: &filer_pb.Entry{Name: "x"}, NewParentPath: "/a/b"}
+	assert.True(t, q.isPublished("/b/x", renamedIn))
+	renamedOut := &filer_pb.EventNotification{NewEntry: &filer_pb.Entry{Name: "x"}, NewParentPath: "/b"}
+	assert.True(t, q.isPublished("/a/x", renamedOut))
+	renamedIntoTopics := &filer_pb.EventNotification{NewEntry: &filer_pb.Entry{Name: "x"}, NewParentPath: filer.TopicsDir}
+	assert.False(t, q.isPublished("/a/x", renamedIntoTopics))
+
+	q.pathPrefixes = []string{"/"}
+	assert.True(t, q.isPublished("/ab", &filer_pb.EventNotification{}))
+}
+
+func TestSendMessageDropsWhenFull(t *testing.T) {
+	q := &SeaweedMqQueue{messages: make(chan *seaweedMqMessage, 1)}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, q.SendMessage("/a/b", &filer_pb.EventNotification{}))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("sending to the full buffer blocks")
+	}
+	assert.Equal(t, 1, len(q.messages))
+	assert.True(t, q.isDropping.Load())
+
+	<-q.messages
+	assert.NoError(t, q.SendMessage("/a/b", &filer_pb.EventNotification{}))
+	assert.False(t, q.isDropping.Load())
+}
+
+func TestPublishRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Second, publishRetryDelay(1))
+	assert.Equal(t, 4*time.Second, publishRetryDelay(3))
+	assert.Equal(t, 16*time.Second, publishRetryDelay(5))
+	assert.Equal(t, maxPublishRetryDelay, publishRetryDelay(6))
+	assert.Equal(t, maxPublishRetryDelay, publishRetryDelay(100))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 	"google.golang.org/grpc"
 	"sync"
 )
@@ -22,6 +23,7 @@ type VolumeAssignRequest struct {
 	Rack                string
 	DataNode            string
 	WritableVolumeCount uint32
+	LatencyClass        string
 }
 
 type AssignResult struct {
@@ -33,6 +35,7 @@ type AssignResult struct {
 	Error     string              `json:"error,omitempty"`
 	Auth      security.EncodedJwt `json:"auth,omitempty"`
 	Replicas  []Location          `json:"replicas,omitempty"`
+	DiskType  string              `json:"diskType,omitempty"` // the disk type of the assigned volume
 }
 
 // This is a proxy to the master server, only for assigning volume ids.
@@ -103,6 +106,7 @@ func (ap *singleThreadAssignProxy) doAssign(grpcConnection *grpc.ClientConn, pri
 			Rack:                request.Rack,
 			DataNode:            request.DataNode,
 			WritableVolumeCount: request.WritableVolumeCount,
+			LatencyClass:        request.LatencyClass,
 		}
 		if err = ap.assignClient.Send(req); err != nil {
 			return nil, fmt.Errorf("StreamAssignSend: %v", err)
@@ -122,6 +126,7 @@ func (ap *singleThreadAssignProxy) doAssign(grpcConnection *grpc.ClientConn, pri
 		ret.GrpcPort = int(resp.Location.GrpcPort)
 		ret.Error = resp.Error
 		ret.Auth = security.EncodedJwt(resp.Auth)
+		ret.DiskType = resp.DiskType
 		for _, r := range resp.Replicas {
 			ret.Replicas = append(ret.Replicas, Location{
 				Url:        r.Url,
@@ -164,6 +169,7 @@ func Assign(masterFn GetMasterFn, grpcDialOption grpc.DialOption, primaryRequest
 				Rack:                request.Rack,
 				DataNode:            request.DataNode,
 				WritableVolumeCount: request.WritableVolumeCount,
+				LatencyClass:        request.LatencyClass,
 			}
 			resp, grpcErr := masterClient.Assign(context.Background(), req)
 			if grpcErr != nil {
@@ -181,6 +187,7 @@ func Assign(masterFn GetMasterFn, grpcDialOption grpc.DialOption, primaryRequest
 			ret.GrpcPort = int(resp.Location.GrpcPort)
 			ret.Error = resp.Error
 			ret.Auth = security.EncodedJwt(resp.Auth)
+			ret.DiskType = resp.DiskType
 			for _, r := range resp.Replicas {
 				ret.Replicas = append(ret.Replicas, Location{
 					Url:        r.Url,
@@ -234,17 +241,33 @@ func LookupJwt(master pb.ServerAddress, grpcDialOption grpc.DialOption, fileId s
 }
 
 type StorageOption struct {
-	Replication       string
-	DiskType          string
-	Collection        string
-	DataCenter        string
-	Rack              string
-	DataNode          string
-	TtlSeconds        int32
-	VolumeGrowthCount uint32
-	MaxFileNameLength uint32
-	Fsync             bool
-	SaveInside        bool
+	Replication           string
+	DiskType              string
+	Collection            string
+	DataCenter            string
+	Rack                  string
+	DataNode              string
+	TtlSeconds            int32
+	VolumeGrowthCount     uint32
+	MaxFileNameLength     uint32
+	Fsync                 bool
+	SaveInside            bool
+	ContentAddressed      bool
+	Compression           string
+	CompressMimeTypes     []string
+	SkipCompressMimeTypes []string
+	LatencyClass          string
+}
+
+// CompressionFor returns the UploadOption.Compression value for the content type
+func (so *StorageOption) CompressionFor(mimeType string) string {
+	if util.MatchMimeType(mimeType, so.SkipCompressMimeTypes) {
+		return "none"
+	}
+	if util.MatchMimeType(mimeType, so.CompressMimeTypes) {
+		return util.Nvl(so.Compression, "gzip")
+	}
+	return ""
 }
 
 func (so *StorageOption) TtlString() string {
@@ -262,6 +285,7 @@ func (so *StorageOption) ToAssignRequests(count int) (ar *VolumeAssignRequest, a
 		Rack:                so.Rack,
 		DataNode:            so.DataNode,
 		WritableVolumeCount: so.VolumeGrowthCount,
+		LatencyClass:        so.LatencyClass,
 	}
 	if so.DataCenter != "" || so.Rack != "" || so.DataNode != "" {
 		altRequest = &VolumeAssignRequest{
@@ -274,6 +298,7 @@ func (so *StorageOption) ToAssignRequests(count int) (ar *VolumeAssignRequest, a
 			Rack:                "",
 			DataNode:            "",
 			WritableVolumeCount: so.VolumeGrowthCount,
+			LatencyClass:        so.LatencyClass,
 		}
 	}
 	return
@@ -28,6 +28,7 @@ type FilePart struct {
 	Server   string //this comes from assign result
 	Fid      string //this comes from assign result, but customizable
 	Fsync    bool
+	Pairs    map[string]string // saved with the needle, e.g., "Seaweed-Mode" returned as the "Mode" header
 }
 
 type SubmitResult struct {
@@ -202,7 +203,7 @@ func (fi *FilePart) Upload(maxMB int, masterFn GetMasterFn, usePublicUrl bool, j
 			)
 			retSize += count
 		}
-		err = uploadChunkedFileManifest(fileUrl, &cm, jwt)
+		err = uploadChunkedFileManifest(fileUrl, &cm, fi.Pairs, jwt)
 		if err != nil {
 			// delete all uploaded chunks
 			cm.DeleteChunks(masterFn, usePublicUrl, grpcDialOption)
@@ -214,7 +215,7 @@ func (fi *FilePart) Upload(maxMB int, masterFn GetMasterFn, usePublicUrl bool, j
 			Cipher:            false,
 			IsInputCompressed: false,
 			MimeType:          fi.MimeType,
-			PairMap:           nil,
+			PairMap:           fi.Pairs,
 			Jwt:               jwt,
 		}
 
@@ -274,7 +275,7 @@ func uploadOneChunk(filename string, reader io.Reader, masterFn GetMasterFn,
 	return uploadResult.Size, nil
 }
 
-func uploadChunkedFileManifest(fileUrl string, manifest *ChunkManifest, jwt security.EncodedJwt) error {
+func uploadChunkedFileManifest(fileUrl string, manifest *ChunkManifest, pairs map[string]string, jwt security.EncodedJwt) error {
 	buf, e := manifest.Marshal()
 	if e != nil {
 		return e
@@ -290,7 +291,7 @@ func uploadChunkedFileManifest(fileUrl string, manifest *ChunkManifest, jwt secu
 		Cipher:            false,
 		IsInputCompressed: false,
 		MimeType:          "application/json",
-		PairMap:           nil,
+		PairMap:           pairs,
 		Jwt:               jwt,
 	}
 
@@ -10,6 +10,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 func TailVolume(masterFn GetMasterFn, grpcDialOption grpc.DialOption, vid needle.VolumeId, sinceNs uint64, timeoutSeconds int, fn func(n *needle.Needle) error) error {
@@ -24,18 +25,22 @@ func TailVolume(masterFn GetMasterFn, grpcDialOption grpc.DialOption, vid needle
 
 	volumeServer := lookup.Locations[0].ServerAddress()
 
-	return TailVolumeFromSource(volumeServer, grpcDialOption, vid, sinceNs, timeoutSeconds, fn)
+	return TailVolumeFromSource(volumeServer, grpcDialOption, vid, sinceNs, timeoutSeconds, util.StreamCompressionZstd, fn)
 }
 
-func TailVolumeFromSource(volumeServer pb.ServerAddress, grpcDialOption grpc.DialOption, vid needle.VolumeId, sinceNs uint64, idleTimeoutSeconds int, fn func(n *needle.Needle) error) error {
+// TailVolumeFromSource follows the needles written to the volume since sinceNs.
+// Only the latest version of each needle is received, and the needle bodies are compressed if acceptCompression is zstd.
+func TailVolumeFromSource(volumeServer pb.ServerAddress, grpcDialOption grpc.DialOption, vid needle.VolumeId, sinceNs uint64, idleTimeoutSeconds int, acceptCompression string, fn func(n *needle.Needle) error) error {
 	return WithVolumeServerClient(true, volumeServer, grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
 		stream, err := client.VolumeTailSender(ctx, &volume_server_pb.VolumeTailSenderRequest{
-			VolumeId:           uint32(vid),
-			SinceNs:            sinceNs,
-			IdleTimeoutSeconds: uint32(idleTimeoutSeconds),
+			VolumeId:               uint32(vid),
+			SinceNs:                sinceNs,
+			IdleTimeoutSeconds:     uint32(idleTimeoutSeconds),
+			AcceptCompression:      acceptCompression,
+			SkipOverwrittenNeedles: true,
 		})
 		if err != nil {
 			return err
@@ -52,7 +57,10 @@ func TailVolumeFromSource(volumeServer pb.ServerAddress, grpcDialOption grpc.Dia
 			}
 
 			needleHeader := resp.NeedleHeader
-			needleBody := resp.NeedleBody
+			needleBody, err := util.DecompressStreamContent(resp.NeedleBody, resp.Compression)
+			if err != nil {
+				return err
+			}
 
 			if len(needleHeader) == 0 {
 				continue
@@ -67,7 +75,11 @@ func TailVolumeFromSource(volumeServer pb.ServerAddress, grpcDialOption grpc.Dia
 						return recvErr
 					}
 				}
-				needleBody = append(needleBody, resp.NeedleBody...)
+				chunk, err := util.DecompressStreamContent(resp.NeedleBody, resp.Compression)
+				if err != nil {
+					return err
+				}
+				needleBody = append(needleBody, chunk...)
 			}
 
 			n := new(needle.Needle)
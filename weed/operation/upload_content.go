@@ -36,6 +36,7 @@ type UploadOption struct {
 	RetryForever      bool
 	Md5               string
 	BytesBuffer       *bytes.Buffer
+	Compression       string // empty to auto detect, "none" to skip, or "gzip", "zstd" to always compress
 }
 
 type UploadResult struct {
@@ -48,11 +49,12 @@ type UploadResult struct {
 	Gzip       uint32 `json:"gzip,omitempty"`
 	ContentMd5 string `json:"contentMd5,omitempty"`
 	RetryCount int    `json:"-"`
+	StoredSize uint32 `json:"-"`
 }
 
 func (uploadResult *UploadResult) ToPbFileChunk(fileId string, offset int64, tsNs int64) *filer_pb.FileChunk {
 	fid, _ := filer_pb.ToFileIdObject(fileId)
-	return &filer_pb.FileChunk{
+	chunk := &filer_pb.FileChunk{
 		FileId:       fileId,
 		Offset:       offset,
 		Size:         uint64(uploadResult.Size),
@@ -62,6 +64,10 @@ func (uploadResult *UploadResult) ToPbFileChunk(fileId string, offset int64, tsN
 		IsCompressed: uploadResult.Gzip > 0,
 		Fid:          fid,
 	}
+	if uploadResult.Gzip > 0 {
+		chunk.CompressedSize = uint64(uploadResult.StoredSize)
+	}
+	return chunk
 }
 
 var (
@@ -195,7 +201,9 @@ func (uploader *Uploader) retriedUploadData(data []byte, option *UploadOption) (
 func (uploader *Uploader) doUploadData(data []byte, option *UploadOption) (uploadResult *UploadResult, err error) {
 	contentIsGzipped := option.IsInputCompressed
 	shouldGzipNow := false
-	if !option.IsInputCompressed {
+	if !option.IsInputCompressed && option.Compression != "" {
+		shouldGzipNow = option.Compression != "none"
+	} else if !option.IsInputCompressed {
 		if option.MimeType == "" {
 			option.MimeType = http.DetectContentType(data)
 			// println("detect1 mimetype to", MimeType)
@@ -222,7 +230,7 @@ func (uploader *Uploader) doUploadData(data []byte, option *UploadOption) (uploa
 	clearDataLen = len(data)
 	clearData := data
 	if shouldGzipNow {
-		compressed, compressErr := util.GzipData(data)
+		compressed, compressErr := util.CompressData(data, option.Compression)
 		// fmt.Printf("data is compressed from %d ==> %d\n", len(data), len(compressed))
 		if compressErr == nil {
 			data = compressed
@@ -267,6 +275,7 @@ func (uploader *Uploader) doUploadData(data []byte, option *UploadOption) (uploa
 		uploadResult.Mime = option.MimeType
 		uploadResult.CipherKey = cipherKey
 		uploadResult.Size = uint32(clearDataLen)
+		uploadResult.StoredSize = uint32(len(data))
 		if contentIsGzipped {
 			uploadResult.Gzip = 1
 		}
@@ -285,11 +294,13 @@ func (uploader *Uploader) doUploadData(data []byte, option *UploadOption) (uploa
 			Jwt:               option.Jwt,
 			Md5:               option.Md5,
 			BytesBuffer:       option.BytesBuffer,
+			Compression:       util.CompressionEncoding(data),
 		})
 		if uploadResult == nil {
 			return
 		}
 		uploadResult.Size = uint32(clearDataLen)
+		uploadResult.StoredSize = uint32(len(data))
 		if contentIsGzipped {
 			uploadResult.Gzip = 1
 		}
@@ -321,7 +332,7 @@ func (uploader *Uploader) upload_content(fillBufferFunction func(w io.Writer) er
 		h.Set("Content-Type", option.MimeType)
 	}
 	if option.IsInputCompressed {
-		h.Set("Content-Encoding", "gzip")
+		h.Set("Content-Encoding", util.Nvl(option.Compression, "gzip"))
 	}
 	if option.Md5 != "" {
 		h.Set("Content-MD5", option.Md5)
@@ -24,9 +24,11 @@ const (
 	SeaweedFiler_CreateEntry_FullMethodName                     = "/filer_pb.SeaweedFiler/CreateEntry"
 	SeaweedFiler_UpdateEntry_FullMethodName                     = "/filer_pb.SeaweedFiler/UpdateEntry"
 	SeaweedFiler_AppendToEntry_FullMethodName                   = "/filer_pb.SeaweedFiler/AppendToEntry"
+	SeaweedFiler_CloneEntry_FullMethodName                      = "/filer_pb.SeaweedFiler/CloneEntry"
 	SeaweedFiler_DeleteEntry_FullMethodName                     = "/filer_pb.SeaweedFiler/DeleteEntry"
 	SeaweedFiler_AtomicRenameEntry_FullMethodName               = "/filer_pb.SeaweedFiler/AtomicRenameEntry"
 	SeaweedFiler_StreamRenameEntry_FullMethodName               = "/filer_pb.SeaweedFiler/StreamRenameEntry"
+	SeaweedFiler_CreateHardLink_FullMethodName                  = "/filer_pb.SeaweedFiler/CreateHardLink"
 	SeaweedFiler_AssignVolume_FullMethodName                    = "/filer_pb.SeaweedFiler/AssignVolume"
 	SeaweedFiler_LookupVolume_FullMethodName                    = "/filer_pb.SeaweedFiler/LookupVolume"
 	SeaweedFiler_CollectionList_FullMethodName                  = "/filer_pb.SeaweedFiler/CollectionList"
@@ -37,6 +39,14 @@ const (
 	SeaweedFiler_TraverseBfsMetadata_FullMethodName             = "/filer_pb.SeaweedFiler/TraverseBfsMetadata"
 	SeaweedFiler_SubscribeMetadata_FullMethodName               = "/filer_pb.SeaweedFiler/SubscribeMetadata"
 	SeaweedFiler_SubscribeLocalMetadata_FullMethodName          = "/filer_pb.SeaweedFiler/SubscribeLocalMetadata"
+	SeaweedFiler_GetMetadataLogStatus_FullMethodName            = "/filer_pb.SeaweedFiler/GetMetadataLogStatus"
+	SeaweedFiler_GetDirectoryStats_FullMethodName               = "/filer_pb.SeaweedFiler/GetDirectoryStats"
+	SeaweedFiler_SearchEntries_FullMethodName                   = "/filer_pb.SeaweedFiler/SearchEntries"
+	SeaweedFiler_ListEntryVersions_FullMethodName               = "/filer_pb.SeaweedFiler/ListEntryVersions"
+	SeaweedFiler_RestoreEntryVersion_FullMethodName             = "/filer_pb.SeaweedFiler/RestoreEntryVersion"
+	SeaweedFiler_DeleteEntryVersion_FullMethodName              = "/filer_pb.SeaweedFiler/DeleteEntryVersion"
+	SeaweedFiler_ListTrashEntries_FullMethodName                = "/filer_pb.SeaweedFiler/ListTrashEntries"
+	SeaweedFiler_RestoreTrashEntry_FullMethodName               = "/filer_pb.SeaweedFiler/RestoreTrashEntry"
 	SeaweedFiler_KvGet_FullMethodName                           = "/filer_pb.SeaweedFiler/KvGet"
 	SeaweedFiler_KvPut_FullMethodName                           = "/filer_pb.SeaweedFiler/KvPut"
 	SeaweedFiler_CacheRemoteObjectToLocalCluster_FullMethodName = "/filer_pb.SeaweedFiler/CacheRemoteObjectToLocalCluster"
@@ -55,9 +65,11 @@ type SeaweedFilerClient interface {
 	CreateEntry(ctx context.Context, in *CreateEntryRequest, opts ...grpc.CallOption) (*CreateEntryResponse, error)
 	UpdateEntry(ctx context.Context, in *UpdateEntryRequest, opts ...grpc.CallOption) (*UpdateEntryResponse, error)
 	AppendToEntry(ctx context.Context, in *AppendToEntryRequest, opts ...grpc.CallOption) (*AppendToEntryResponse, error)
+	CloneEntry(ctx context.Context, in *CloneEntryRequest, opts ...grpc.CallOption) (*CloneEntryResponse, error)
 	DeleteEntry(ctx context.Context, in *DeleteEntryRequest, opts ...grpc.CallOption) (*DeleteEntryResponse, error)
 	AtomicRenameEntry(ctx context.Context, in *AtomicRenameEntryRequest, opts ...grpc.CallOption) (*AtomicRenameEntryResponse, error)
 	StreamRenameEntry(ctx context.Context, in *StreamRenameEntryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamRenameEntryResponse], error)
+	CreateHardLink(ctx context.Context, in *CreateHardLinkRequest, opts ...grpc.CallOption) (*CreateHardLinkResponse, error)
 	AssignVolume(ctx context.Context, in *AssignVolumeRequest, opts ...grpc.CallOption) (*AssignVolumeResponse, error)
 	LookupVolume(ctx context.Context, in *LookupVolumeRequest, opts ...grpc.CallOption) (*LookupVolumeResponse, error)
 	CollectionList(ctx context.Context, in *CollectionListRequest, opts ...grpc.CallOption) (*CollectionListResponse, error)
@@ -68,6 +80,14 @@ type SeaweedFilerClient interface {
 	TraverseBfsMetadata(ctx context.Context, in *TraverseBfsMetadataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TraverseBfsMetadataResponse], error)
 	SubscribeMetadata(ctx context.Context, in *SubscribeMetadataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeMetadataResponse], error)
 	SubscribeLocalMetadata(ctx context.Context, in *SubscribeMetadataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeMetadataResponse], error)
+	GetMetadataLogStatus(ctx context.Context, in *GetMetadataLogStatusRequest, opts ...grpc.CallOption) (*GetMetadataLogStatusResponse, error)
+	GetDirectoryStats(ctx context.Context, in *GetDirectoryStatsRequest, opts ...grpc.CallOption) (*GetDirectoryStatsResponse, error)
+	SearchEntries(ctx context.Context, in *SearchEntriesRequest, opts ...grpc.CallOption) (*SearchEntriesResponse, error)
+	ListEntryVersions(ctx context.Context, in *ListEntryVersionsRequest, opts ...grpc.CallOption) (*ListEntryVersionsResponse, error)
+	RestoreEntryVersion(ctx context.Context, in *RestoreEntryVersionRequest, opts ...grpc.CallOption) (*RestoreEntryVersionResponse, error)
+	DeleteEntryVersion(ctx context.Context, in *DeleteEntryVersionRequest, opts ...grpc.CallOption) (*DeleteEntryVersionResponse, error)
+	ListTrashEntries(ctx context.Context, in *ListTrashEntriesRequest, opts ...grpc.CallOption) (*ListTrashEntriesResponse, error)
+	RestoreTrashEntry(ctx context.Context, in *RestoreTrashEntryRequest, opts ...grpc.CallOption) (*RestoreTrashEntryResponse, error)
 	KvGet(ctx context.Context, in *KvGetRequest, opts ...grpc.CallOption) (*KvGetResponse, error)
 	KvPut(ctx context.Context, in *KvPutRequest, opts ...grpc.CallOption) (*KvPutResponse, error)
 	CacheRemoteObjectToLocalCluster(ctx context.Context, in *CacheRemoteObjectToLocalClusterRequest, opts ...grpc.CallOption) (*CacheRemoteObjectToLocalClusterResponse, error)
@@ -145,6 +165,16 @@ func (c *seaweedFilerClient) AppendToEntry(ctx context.Context, in *AppendToEntr
 	return out, nil
 }
 
+func (c *seaweedFilerClient) CloneEntry(ctx context.Context, in *CloneEntryRequest, opts ...grpc.CallOption) (*CloneEntryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloneEntryResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_CloneEntry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedFilerClient) DeleteEntry(ctx context.Context, in *DeleteEntryRequest, opts ...grpc.CallOption) (*DeleteEntryResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DeleteEntryResponse)
@@ -184,6 +214,16 @@ func (c *seaweedFilerClient) StreamRenameEntry(ctx context.Context, in *StreamRe
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SeaweedFiler_StreamRenameEntryClient = grpc.ServerStreamingClient[StreamRenameEntryResponse]
 
+func (c *seaweedFilerClient) CreateHardLink(ctx context.Context, in *CreateHardLinkRequest, opts ...grpc.CallOption) (*CreateHardLinkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateHardLinkResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_CreateHardLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedFilerClient) AssignVolume(ctx context.Context, in *AssignVolumeRequest, opts ...grpc.CallOption) (*AssignVolumeResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(AssignVolumeResponse)
@@ -311,6 +351,86 @@ func (c *seaweedFilerClient) SubscribeLocalMetadata(ctx context.Context, in *Sub
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SeaweedFiler_SubscribeLocalMetadataClient = grpc.ServerStreamingClient[SubscribeMetadataResponse]
 
+func (c *seaweedFilerClient) GetMetadataLogStatus(ctx context.Context, in *GetMetadataLogStatusRequest, opts ...grpc.CallOption) (*GetMetadataLogStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMetadataLogStatusResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_GetMetadataLogStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) GetDirectoryStats(ctx context.Context, in *GetDirectoryStatsRequest, opts ...grpc.CallOption) (*GetDirectoryStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDirectoryStatsResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_GetDirectoryStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) SearchEntries(ctx context.Context, in *SearchEntriesRequest, opts ...grpc.CallOption) (*SearchEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchEntriesResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_SearchEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) ListEntryVersions(ctx context.Context, in *ListEntryVersionsRequest, opts ...grpc.CallOption) (*ListEntryVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEntryVersionsResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_ListEntryVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) RestoreEntryVersion(ctx context.Context, in *RestoreEntryVersionRequest, opts ...grpc.CallOption) (*RestoreEntryVersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreEntryVersionResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_RestoreEntryVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) DeleteEntryVersion(ctx context.Context, in *DeleteEntryVersionRequest, opts ...grpc.CallOption) (*DeleteEntryVersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteEntryVersionResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_DeleteEntryVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) ListTrashEntries(ctx context.Context, in *ListTrashEntriesRequest, opts ...grpc.CallOption) (*ListTrashEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTrashEntriesResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_ListTrashEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) RestoreTrashEntry(ctx context.Context, in *RestoreTrashEntryRequest, opts ...grpc.CallOption) (*RestoreTrashEntryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreTrashEntryResponse)
+	err := c.cc.Invoke(ctx, SeaweedFiler_RestoreTrashEntry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedFilerClient) KvGet(ctx context.Context, in *KvGetRequest, opts ...grpc.CallOption) (*KvGetResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(KvGetResponse)
@@ -390,9 +510,11 @@ type SeaweedFilerServer interface {
 	CreateEntry(context.Context, *CreateEntryRequest) (*CreateEntryResponse, error)
 	UpdateEntry(context.Context, *UpdateEntryRequest) (*UpdateEntryResponse, error)
 	AppendToEntry(context.Context, *AppendToEntryRequest) (*AppendToEntryResponse, error)
+	CloneEntry(context.Context, *CloneEntryRequest) (*CloneEntryResponse, error)
 	DeleteEntry(context.Context, *DeleteEntryRequest) (*DeleteEntryResponse, error)
 	AtomicRenameEntry(context.Context, *AtomicRenameEntryRequest) (*AtomicRenameEntryResponse, error)
 	StreamRenameEntry(*StreamRenameEntryRequest, grpc.ServerStreamingServer[StreamRenameEntryResponse]) error
+	CreateHardLink(context.Context, *CreateHardLinkRequest) (*CreateHardLinkResponse, error)
 	AssignVolume(context.Context, *AssignVolumeRequest) (*AssignVolumeResponse, error)
 	LookupVolume(context.Context, *LookupVolumeRequest) (*LookupVolumeResponse, error)
 	CollectionList(context.Context, *CollectionListRequest) (*CollectionListResponse, error)
@@ -403,6 +525,14 @@ type SeaweedFilerServer interface {
 	TraverseBfsMetadata(*TraverseBfsMetadataRequest, grpc.ServerStreamingServer[TraverseBfsMetadataResponse]) error
 	SubscribeMetadata(*SubscribeMetadataRequest, grpc.ServerStreamingServer[SubscribeMetadataResponse]) error
 	SubscribeLocalMetadata(*SubscribeMetadataRequest, grpc.ServerStreamingServer[SubscribeMetadataResponse]) error
+	GetMetadataLogStatus(context.Context, *GetMetadataLogStatusRequest) (*GetMetadataLogStatusResponse, error)
+	GetDirectoryStats(context.Context, *GetDirectoryStatsRequest) (*GetDirectoryStatsResponse, error)
+	SearchEntries(context.Context, *SearchEntriesRequest) (*SearchEntriesResponse, error)
+	ListEntryVersions(context.Context, *ListEntryVersionsRequest) (*ListEntryVersionsResponse, error)
+	RestoreEntryVersion(context.Context, *RestoreEntryVersionRequest) (*RestoreEntryVersionResponse, error)
+	DeleteEntryVersion(context.Context, *DeleteEntryVersionRequest) (*DeleteEntryVersionResponse, error)
+	ListTrashEntries(context.Context, *ListTrashEntriesRequest) (*ListTrashEntriesResponse, error)
+	RestoreTrashEntry(context.Context, *RestoreTrashEntryRequest) (*RestoreTrashEntryResponse, error)
 	KvGet(context.Context, *KvGetRequest) (*KvGetResponse, error)
 	KvPut(context.Context, *KvPutRequest) (*KvPutResponse, error)
 	CacheRemoteObjectToLocalCluster(context.Context, *CacheRemoteObjectToLocalClusterRequest) (*CacheRemoteObjectToLocalClusterResponse, error)
@@ -436,6 +566,9 @@ func (UnimplementedSeaweedFilerServer) UpdateEntry(context.Context, *UpdateEntry
 func (UnimplementedSeaweedFilerServer) AppendToEntry(context.Context, *AppendToEntryRequest) (*AppendToEntryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AppendToEntry not implemented")
 }
+func (UnimplementedSeaweedFilerServer) CloneEntry(context.Context, *CloneEntryRequest) (*CloneEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloneEntry not implemented")
+}
 func (UnimplementedSeaweedFilerServer) DeleteEntry(context.Context, *DeleteEntryRequest) (*DeleteEntryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteEntry not implemented")
 }
@@ -445,6 +578,9 @@ func (UnimplementedSeaweedFilerServer) AtomicRenameEntry(context.Context, *Atomi
 func (UnimplementedSeaweedFilerServer) StreamRenameEntry(*StreamRenameEntryRequest, grpc.ServerStreamingServer[StreamRenameEntryResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamRenameEntry not implemented")
 }
+func (UnimplementedSeaweedFilerServer) CreateHardLink(context.Context, *CreateHardLinkRequest) (*CreateHardLinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateHardLink not implemented")
+}
 func (UnimplementedSeaweedFilerServer) AssignVolume(context.Context, *AssignVolumeRequest) (*AssignVolumeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AssignVolume not implemented")
 }
@@ -475,6 +611,30 @@ func (UnimplementedSeaweedFilerServer) SubscribeMetadata(*SubscribeMetadataReque
 func (UnimplementedSeaweedFilerServer) SubscribeLocalMetadata(*SubscribeMetadataRequest, grpc.ServerStreamingServer[SubscribeMetadataResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method SubscribeLocalMetadata not implemented")
 }
+func (UnimplementedSeaweedFilerServer) GetMetadataLogStatus(context.Context, *GetMetadataLogStatusRequest) (*GetMetadataLogStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetadataLogStatus not implemented")
+}
+func (UnimplementedSeaweedFilerServer) GetDirectoryStats(context.Context, *GetDirectoryStatsRequest) (*GetDirectoryStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDirectoryStats not implemented")
+}
+func (UnimplementedSeaweedFilerServer) SearchEntries(context.Context, *SearchEntriesRequest) (*SearchEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchEntries not implemented")
+}
+func (UnimplementedSeaweedFilerServer) ListEntryVersions(context.Context, *ListEntryVersionsRequest) (*ListEntryVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEntryVersions not implemented")
+}
+func (UnimplementedSeaweedFilerServer) RestoreEntryVersion(context.Context, *RestoreEntryVersionRequest) (*RestoreEntryVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreEntryVersion not implemented")
+}
+func (UnimplementedSeaweedFilerServer) DeleteEntryVersion(context.Context, *DeleteEntryVersionRequest) (*DeleteEntryVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteEntryVersion not implemented")
+}
+func (UnimplementedSeaweedFilerServer) ListTrashEntries(context.Context, *ListTrashEntriesRequest) (*ListTrashEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrashEntries not implemented")
+}
+func (UnimplementedSeaweedFilerServer) RestoreTrashEntry(context.Context, *RestoreTrashEntryRequest) (*RestoreTrashEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreTrashEntry not implemented")
+}
 func (UnimplementedSeaweedFilerServer) KvGet(context.Context, *KvGetRequest) (*KvGetResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method KvGet not implemented")
 }
@@ -600,6 +760,24 @@ func _SeaweedFiler_AppendToEntry_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SeaweedFiler_CloneEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloneEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).CloneEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_CloneEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).CloneEntry(ctx, req.(*CloneEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SeaweedFiler_DeleteEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteEntryRequest)
 	if err := dec(in); err != nil {
@@ -647,6 +825,24 @@ func _SeaweedFiler_StreamRenameEntry_Handler(srv interface{}, stream grpc.Server
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SeaweedFiler_StreamRenameEntryServer = grpc.ServerStreamingServer[StreamRenameEntryResponse]
 
+func _SeaweedFiler_CreateHardLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateHardLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).CreateHardLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_CreateHardLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).CreateHardLink(ctx, req.(*CreateHardLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SeaweedFiler_AssignVolume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AssignVolumeRequest)
 	if err := dec(in); err != nil {
@@ -806,6 +1002,150 @@ func _SeaweedFiler_SubscribeLocalMetadata_Handler(srv interface{}, stream grpc.S
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SeaweedFiler_SubscribeLocalMetadataServer = grpc.ServerStreamingServer[SubscribeMetadataResponse]
 
+func _SeaweedFiler_GetMetadataLogStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMetadataLogStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).GetMetadataLogStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_GetMetadataLogStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).GetMetadataLogStatus(ctx, req.(*GetMetadataLogStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_GetDirectoryStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDirectoryStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).GetDirectoryStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_GetDirectoryStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).GetDirectoryStats(ctx, req.(*GetDirectoryStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_SearchEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).SearchEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_SearchEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).SearchEntries(ctx, req.(*SearchEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_ListEntryVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEntryVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).ListEntryVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_ListEntryVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).ListEntryVersions(ctx, req.(*ListEntryVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_RestoreEntryVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreEntryVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).RestoreEntryVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_RestoreEntryVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).RestoreEntryVersion(ctx, req.(*RestoreEntryVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_DeleteEntryVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteEntryVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).DeleteEntryVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_DeleteEntryVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).DeleteEntryVersion(ctx, req.(*DeleteEntryVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_ListTrashEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTrashEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).ListTrashEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_ListTrashEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).ListTrashEntries(ctx, req.(*ListTrashEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_RestoreTrashEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreTrashEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).RestoreTrashEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeaweedFiler_RestoreTrashEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).RestoreTrashEntry(ctx, req.(*RestoreTrashEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SeaweedFiler_KvGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(KvGetRequest)
 	if err := dec(in); err != nil {
@@ -955,6 +1295,10 @@ var SeaweedFiler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AppendToEntry",
 			Handler:    _SeaweedFiler_AppendToEntry_Handler,
 		},
+		{
+			MethodName: "CloneEntry",
+			Handler:    _SeaweedFiler_CloneEntry_Handler,
+		},
 		{
 			MethodName: "DeleteEntry",
 			Handler:    _SeaweedFiler_DeleteEntry_Handler,
@@ -963,6 +1307,10 @@ var SeaweedFiler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AtomicRenameEntry",
 			Handler:    _SeaweedFiler_AtomicRenameEntry_Handler,
 		},
+		{
+			MethodName: "CreateHardLink",
+			Handler:    _SeaweedFiler_CreateHardLink_Handler,
+		},
 		{
 			MethodName: "AssignVolume",
 			Handler:    _SeaweedFiler_AssignVolume_Handler,
@@ -991,6 +1339,38 @@ var SeaweedFiler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetFilerConfiguration",
 			Handler:    _SeaweedFiler_GetFilerConfiguration_Handler,
 		},
+		{
+			MethodName: "GetMetadataLogStatus",
+			Handler:    _SeaweedFiler_GetMetadataLogStatus_Handler,
+		},
+		{
+			MethodName: "GetDirectoryStats",
+			Handler:    _SeaweedFiler_GetDirectoryStats_Handler,
+		},
+		{
+			MethodName: "SearchEntries",
+			Handler:    _SeaweedFiler_SearchEntries_Handler,
+		},
+		{
+			MethodName: "ListEntryVersions",
+			Handler:    _SeaweedFiler_ListEntryVersions_Handler,
+		},
+		{
+			MethodName: "RestoreEntryVersion",
+			Handler:    _SeaweedFiler_RestoreEntryVersion_Handler,
+		},
+		{
+			MethodName: "DeleteEntryVersion",
+			Handler:    _SeaweedFiler_DeleteEntryVersion_Handler,
+		},
+		{
+			MethodName: "ListTrashEntries",
+			Handler:    _SeaweedFiler_ListTrashEntries_Handler,
+		},
+		{
+			MethodName: "RestoreTrashEntry",
+			Handler:    _SeaweedFiler_RestoreTrashEntry_Handler,
+		},
 		{
 			MethodName: "KvGet",
 			Handler:    _SeaweedFiler_KvGet_Handler,
@@ -125,7 +125,7 @@ func UpdateEntry(client SeaweedFilerClient, request *UpdateEntryRequest) error {
 	_, err := client.UpdateEntry(context.Background(), request)
 	if err != nil {
 		glog.V(1).Infof("update entry %s/%s :%v", request.Directory, request.Entry.Name, err)
-		return fmt.Errorf("UpdateEntry: %v", err)
+		return fmt.Errorf("UpdateEntry: %w", err)
 	}
 	return nil
 }
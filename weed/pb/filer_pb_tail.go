@@ -30,6 +30,7 @@ type MetadataFollowOption struct {
 	PathPrefix             string
 	AdditionalPathPrefixes []string
 	DirectoriesToWatch     []string
+	NamesToWatch           []string // only the entries of these names under the watched paths
 	StartTsNs              int64
 	StopTsNs               int64
 	EventErrorType         EventErrorType
@@ -65,6 +66,7 @@ func makeSubscribeMetadataFunc(option *MetadataFollowOption, processEventFn Proc
 			PathPrefix:   option.PathPrefix,
 			PathPrefixes: option.AdditionalPathPrefixes,
 			Directories:  option.DirectoriesToWatch,
+			Names:        option.NamesToWatch,
 			SinceNs:      option.StartTsNs,
 			Signature:    option.SelfSignature,
 			ClientId:     option.ClientId,
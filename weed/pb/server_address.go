@@ -100,10 +100,17 @@ func (r ServerSrvAddress) LookUp() (addresses []ServerAddress, err error) {
 //	dnssrv+_grpc._tcp.master.consul
 //	dnssrv+_grpc._tcp.headless.default.svc.cluster.local
 //	dnssrv+seaweed-master.master.consul
+//
+// OR a service registry the masters are registered in, like:
+//
+//	consul+localhost:8500
+//	etcd+10.0.0.1:2379,10.0.0.2:2379
 func (sa ServerAddresses) ToServiceDiscovery() (sd *ServerDiscovery) {
 	sd = &ServerDiscovery{}
 	prefix := "dnssrv+"
-	if strings.HasPrefix(string(sa), prefix) {
+	if registry := parseServiceRegistry(string(sa)); registry != nil {
+		sd.registry = registry
+	} else if strings.HasPrefix(string(sa), prefix) {
 		trimmed := strings.TrimPrefix(string(sa), prefix)
 		srv := ServerSrvAddress(trimmed)
 		sd.srvRecord = &srv
@@ -34,3 +34,24 @@ func TestServerAddresses_ToAddressMapOrSrv_shouldHandleIPPortList(t *testing.T)
 		t.Fatalf(`Expected %q, got %q`, expected, d.list)
 	}
 }
+
+func TestServerAddresses_ToServiceDiscovery_shouldResolveFromRegistry(t *testing.T) {
+	RegisterServerResolver("test", func(registry string, serverType string) ([]ServerAddress, error) {
+		if registry != "localhost:8500" || serverType != "master" {
+			t.Fatalf("unexpected lookup of %s in %s", serverType, registry)
+		}
+		return []ServerAddress{"10.0.0.1:9333"}, nil
+	})
+
+	d := ServerAddresses("test+localhost:8500").ToServiceDiscovery()
+	d.RefreshBySrvIfAvailable()
+
+	expected := []ServerAddress{ServerAddress("10.0.0.1:9333")}
+	if !reflect.DeepEqual(d.GetInstances(), expected) {
+		t.Fatalf(`Expected %q, got %q`, expected, d.GetInstances())
+	}
+
+	if d = ServerAddresses("unknown+localhost:8500").ToServiceDiscovery(); d.registry != nil {
+		t.Fatalf("unknown scheme should not be a registry")
+	}
+}
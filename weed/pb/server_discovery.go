@@ -3,6 +3,7 @@ package pb
 import (
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"reflect"
+	"strings"
 )
 
 // ServerDiscovery encodes a way to find at least 1 instance of a service,
@@ -10,6 +11,36 @@ import (
 type ServerDiscovery struct {
 	list      []ServerAddress
 	srvRecord *ServerSrvAddress
+	registry  *serviceRegistry
+}
+
+// ServerResolver looks up the servers of the type registered in a service registry,
+// e.g., for "consul+localhost:8500", the resolver of "consul" looks up the registry "localhost:8500"
+type ServerResolver func(registry string, serverType string) ([]ServerAddress, error)
+
+var serverResolvers = make(map[string]ServerResolver)
+
+// RegisterServerResolver lets the server lists in the form of "<scheme>+<registry>" be resolved by the resolver
+func RegisterServerResolver(scheme string, resolver ServerResolver) {
+	serverResolvers[scheme] = resolver
+}
+
+type serviceRegistry struct {
+	resolver ServerResolver
+	registry string
+}
+
+// parseServiceRegistry returns nil if the servers are not in the form of "<scheme>+<registry>" with a registered scheme
+func parseServiceRegistry(servers string) *serviceRegistry {
+	scheme, registry, found := strings.Cut(servers, "+")
+	if !found {
+		return nil
+	}
+	resolver, found := serverResolvers[scheme]
+	if !found {
+		return nil
+	}
+	return &serviceRegistry{resolver: resolver, registry: registry}
 }
 
 func NewServiceDiscoveryFromMap(m map[string]ServerAddress) (sd *ServerDiscovery) {
@@ -20,9 +51,19 @@ func NewServiceDiscoveryFromMap(m map[string]ServerAddress) (sd *ServerDiscovery
 	return sd
 }
 
-// RefreshBySrvIfAvailable performs a DNS SRV lookup and updates list with the results
-// of the lookup
+// RefreshBySrvIfAvailable performs a DNS SRV lookup, or looks up the masters in the service registry,
+// and updates list with the results of the lookup
 func (sd *ServerDiscovery) RefreshBySrvIfAvailable() {
+	if sd.registry != nil {
+		newList, err := sd.registry.resolver(sd.registry.registry, "master")
+		if err != nil {
+			glog.V(0).Infof("failed to lookup masters in %s: %v", sd.registry.registry, err)
+		}
+		if len(newList) > 0 && !reflect.DeepEqual(sd.list, newList) {
+			sd.list = newList
+		}
+		return
+	}
 	if sd.srvRecord == nil {
 		return
 	}
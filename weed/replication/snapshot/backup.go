@@ -0,0 +1,260 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+	"github.com/seaweedfs/seaweedfs/weed/wdclient"
+)
+
+// Backup uploads the metadata snapshots, the metadata events, and the chunks they refer to.
+// Each chunk is uploaded once, since the chunks are never changed.
+type Backup struct {
+	store    Store
+	lookupFn wdclient.LookupFileIdFunctionType
+
+	chunksLock sync.Mutex
+	chunks     map[string]struct{} // the uploaded chunks
+
+	logLock      sync.Mutex
+	logBuffer    bytes.Buffer
+	logStartTsNs int64
+	logStopTsNs  int64
+	MaxLogSize   int
+}
+
+func NewBackup(store Store, lookupFn wdclient.LookupFileIdFunctionType) *Backup {
+	return &Backup{
+		store:      store,
+		lookupFn:   lookupFn,
+		chunks:     make(map[string]struct{}),
+		MaxLogSize: 16 * 1024 * 1024,
+	}
+}
+
+// Resume loads the uploaded chunks, and returns the time the backup has progressed to,
+// and the stop time of the latest snapshot. Both are zero for a new backup.
+func (b *Backup) Resume() (lastTsNs int64, lastSnapshotTsNs int64, err error) {
+	files, err := b.store.List(ChunksDir)
+	if err != nil {
+		return 0, 0, err
+	}
+	b.chunksLock.Lock()
+	for _, file := range files {
+		b.chunks[ChunksDir+"/"+file.Name] = struct{}{}
+	}
+	b.chunksLock.Unlock()
+
+	snapshots, err := ListSnapshots(b.store)
+	if err != nil {
+		return 0, 0, err
+	}
+	logs, err := ListLogs(b.store)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, segment := range snapshots {
+		lastSnapshotTsNs = max(lastSnapshotTsNs, segment.StopTsNs)
+	}
+	lastTsNs = lastSnapshotTsNs
+	for _, segment := range logs {
+		lastTsNs = max(lastTsNs, segment.StopTsNs)
+	}
+	return lastTsNs, lastSnapshotTsNs, nil
+}
+
+// WriteSnapshot saves the entries visited by the traverse function as a snapshot, which is fuzzy
+// if the entries are changed during the traversal, and consistent with the events replayed from its start time.
+func (b *Backup) WriteSnapshot(startTsNs int64, traverse func(eachEntryFn func(dir string, entry *filer_pb.Entry) error) error) (stopTsNs int64, err error) {
+	tmpFile, err := os.CreateTemp("", "snapshot")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}()
+
+	var count int64
+	err = traverse(func(dir string, entry *filer_pb.Entry) error {
+		entry, err := b.backupEntry(util.NewFullPath(dir, entry.Name), entry)
+		if err != nil {
+			return err
+		}
+		count++
+		return writeMessage(tmpFile, &filer_pb.FullEntry{Dir: dir, Entry: entry})
+	})
+	if err != nil {
+		return 0, err
+	}
+	stopTsNs = time.Now().UnixNano()
+
+	size, err := tmpFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tmpFile.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	name := segmentFileName(SnapshotsDir, startTsNs, stopTsNs, snapshotExt)
+	if err = b.store.Write(name, size, tmpFile); err != nil {
+		return 0, fmt.Errorf("upload %s: %v", name, err)
+	}
+	glog.V(0).Infof("snapshot %s of %d entries, %d bytes", name, count, size)
+	return stopTsNs, nil
+}
+
+// AddEvent uploads the new chunks of the event, and buffers the event to the next log file
+func (b *Backup) AddEvent(event *filer_pb.SubscribeMetadataResponse) error {
+	message := event.EventNotification
+	if message.NewEntry != nil {
+		entry, err := b.backupEntry(util.NewFullPath(message.NewParentPath, message.NewEntry.Name), message.NewEntry)
+		if err != nil {
+			return err
+		}
+		event = proto.Clone(event).(*filer_pb.SubscribeMetadataResponse)
+		event.EventNotification.NewEntry = entry
+	}
+
+	b.logLock.Lock()
+	defer b.logLock.Unlock()
+	if b.logBuffer.Len() == 0 {
+		b.logStartTsNs = event.TsNs
+	}
+	b.logStopTsNs = event.TsNs
+	if err := writeMessage(&b.logBuffer, event); err != nil {
+		return err
+	}
+	if b.logBuffer.Len() >= b.MaxLogSize {
+		return b.flushLogLocked()
+	}
+	return nil
+}
+
+// FlushLog uploads the buffered events, and returns the time the backup has progressed to
+func (b *Backup) FlushLog() (lastTsNs int64, err error) {
+	b.logLock.Lock()
+	defer b.logLock.Unlock()
+	lastTsNs = b.logStopTsNs
+	return lastTsNs, b.flushLogLocked()
+}
+
+func (b *Backup) flushLogLocked() error {
+	if b.logBuffer.Len() == 0 {
+		return nil
+	}
+	name := segmentFileName(LogsDir, b.logStartTsNs, b.logStopTsNs, logExt)
+	if err := b.store.Write(name, int64(b.logBuffer.Len()), bytes.NewReader(b.logBuffer.Bytes())); err != nil {
+		return fmt.Errorf("upload %s: %v", name, err)
+	}
+	glog.V(1).Infof("uploaded %s, %d bytes", name, b.logBuffer.Len())
+	b.logBuffer.Reset()
+	return nil
+}
+
+// backupEntry uploads the chunks not uploaded yet, and returns the entry with the manifest chunks resolved,
+// since the backup keeps the plain data of each data chunk
+func (b *Backup) backupEntry(fullPath util.FullPath, entry *filer_pb.Entry) (*filer_pb.Entry, error) {
+	if entry.IsDirectory || len(entry.GetChunks()) == 0 {
+		return entry, nil
+	}
+	dataChunks, _, err := filer.ResolveChunkManifest(b.lookupFn, entry.GetChunks(), 0, math.MaxInt64)
+	if err != nil {
+		return nil, fmt.Errorf("resolve chunks of %s: %v", fullPath, err)
+	}
+
+	entry = proto.Clone(entry).(*filer_pb.Entry)
+	entry.Chunks = nil
+	for _, chunk := range dataChunks {
+		if err := b.backupChunk(chunk); err != nil {
+			if errors.Is(err, util_http.ErrNotFound) {
+				// the chunk is already deleted, e.g., the file is overwritten before the backup catches up
+				glog.Warningf("skip chunk %s of %s: %v", chunk.GetFileIdString(), fullPath, err)
+				continue
+			}
+			return nil, fmt.Errorf("backup chunk %s of %s: %v", chunk.GetFileIdString(), fullPath, err)
+		}
+		chunk = proto.Clone(chunk).(*filer_pb.FileChunk)
+		chunk.CipherKey, chunk.IsCompressed = nil, false
+		entry.Chunks = append(entry.Chunks, chunk)
+	}
+	return entry, nil
+}
+
+func (b *Backup) backupChunk(chunk *filer_pb.FileChunk) error {
+	fileId := chunk.GetFileIdString()
+	name := chunkFileName(fileId)
+	b.chunksLock.Lock()
+	_, found := b.chunks[name]
+	b.chunksLock.Unlock()
+	if found {
+		return nil
+	}
+
+	urlStrings, err := b.lookupFn(fileId)
+	if err != nil {
+		return fmt.Errorf("lookup: %v", err)
+	}
+	data := make([]byte, chunk.Size)
+	n, err := util_http.RetriedFetchChunkData(data, urlStrings, chunk.CipherKey, chunk.IsCompressed, true, 0)
+	if err != nil {
+		return err
+	}
+	if err = b.store.Write(name, int64(n), bytes.NewReader(data[:n])); err != nil {
+		return fmt.Errorf("upload: %v", err)
+	}
+
+	b.chunksLock.Lock()
+	b.chunks[name] = struct{}{}
+	b.chunksLock.Unlock()
+	return nil
+}
+
+// the snapshots and logs are sequences of the size prefixed messages, the same as "fs.meta.save"
+func writeMessage(w io.Writer, message proto.Message) error {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	sizeBuf := make([]byte, 4)
+	util.Uint32toBytes(sizeBuf, uint32(len(data)))
+	if _, err = w.Write(sizeBuf); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func readMessages(data []byte, newMessage func() proto.Message, fn func(message proto.Message) error) error {
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return fmt.Errorf("truncated message size")
+		}
+		size := int(util.BytesToUint32(data[:4]))
+		if len(data) < 4+size {
+			return fmt.Errorf("truncated message of %d bytes", size)
+		}
+		message := newMessage()
+		if err := proto.Unmarshal(data[4:4+size], message); err != nil {
+			return err
+		}
+		if err := fn(message); err != nil {
+			return err
+		}
+		data = data[4+size:]
+	}
+	return nil
+}
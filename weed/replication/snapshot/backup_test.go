@@ -0,0 +1,164 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+// newTestBackup serves each chunk "<n>,<key>" with its file id as the content
+func newTestBackup(t *testing.T) (*Backup, *memoryStore, map[string]int) {
+	util_http.InitGlobalHttpClient()
+
+	var lock sync.Mutex
+	downloads := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fileId := strings.TrimPrefix(r.URL.Path, "/")
+		lock.Lock()
+		downloads[fileId]++
+		lock.Unlock()
+		w.Write([]byte(fileId))
+	}))
+	t.Cleanup(server.Close)
+
+	store := newMemoryStore()
+	b := NewBackup(store, func(fileId string) ([]string, error) {
+		return []string{fmt.Sprintf("%s/%s", server.URL, fileId)}, nil
+	})
+	return b, store, downloads
+}
+
+func testFile(name string, fileIds ...string) *filer_pb.Entry {
+	entry := &filer_pb.Entry{
+		Name:       name,
+		Attributes: &filer_pb.FuseAttributes{FileMode: 0644},
+	}
+	var offset int64
+	for _, fileId := range fileIds {
+		entry.Chunks = append(entry.Chunks, &filer_pb.FileChunk{
+			FileId: fileId,
+			Offset: offset,
+			Size:   uint64(len(fileId)),
+		})
+		offset += int64(len(fileId))
+	}
+	entry.Attributes.FileSize = uint64(offset)
+	return entry
+}
+
+func testEvent(tsNs int64, dir string, oldEntry *filer_pb.Entry, newParentPath string, newEntry *filer_pb.Entry) *filer_pb.SubscribeMetadataResponse {
+	return &filer_pb.SubscribeMetadataResponse{
+		Directory: dir,
+		TsNs:      tsNs,
+		EventNotification: &filer_pb.EventNotification{
+			OldEntry:      oldEntry,
+			NewEntry:      newEntry,
+			NewParentPath: newParentPath,
+		},
+	}
+}
+
+func TestBackupAndLoadNamespace(t *testing.T) {
+	b, store, downloads := newTestBackup(t)
+
+	startTsNs := time.Now().UnixNano()
+	snapshotTsNs, err := b.WriteSnapshot(startTsNs, func(eachEntryFn func(dir string, entry *filer_pb.Entry) error) error {
+		for _, fullEntry := range []*filer_pb.FullEntry{
+			{Dir: "/", Entry: &filer_pb.Entry{Name: "docs", IsDirectory: true}},
+			{Dir: "/docs", Entry: testFile("a.txt", "1,01", "2,02")},
+			{Dir: "/docs", Entry: testFile("b.txt", "1,01")},
+		} {
+			if err := eachEntryFn(fullEntry.Dir, fullEntry.Entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []*filer_pb.SubscribeMetadataResponse{
+		testEvent(snapshotTsNs+1, "/docs", nil, "/docs", testFile("c.txt", "3,03")),
+		testEvent(snapshotTsNs+2, "/docs", testFile("a.txt", "1,01", "2,02"), "/docs", testFile("a.txt", "4,04")),
+		testEvent(snapshotTsNs+3, "/", &filer_pb.Entry{Name: "docs", IsDirectory: true}, "/", &filer_pb.Entry{Name: "papers", IsDirectory: true}),
+		testEvent(snapshotTsNs+4, "/papers", testFile("b.txt", "1,01"), "", nil),
+	}
+	for i, event := range events {
+		if err := b.AddEvent(event); err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			if _, err := b.FlushLog(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if lastTsNs, err := b.FlushLog(); err != nil || lastTsNs != snapshotTsNs+4 {
+		t.Fatalf("flush log: %d %v", lastTsNs, err)
+	}
+	for fileId, count := range downloads {
+		if count != 1 {
+			t.Errorf("chunk %s downloaded %d times", fileId, count)
+		}
+	}
+
+	tests := []struct {
+		tsNs     int64
+		expected map[util.FullPath]string
+	}{
+		{snapshotTsNs, map[util.FullPath]string{"/docs": "", "/docs/a.txt": "1,012,02", "/docs/b.txt": "1,01"}},
+		{snapshotTsNs + 2, map[util.FullPath]string{"/docs": "", "/docs/a.txt": "4,04", "/docs/b.txt": "1,01", "/docs/c.txt": "3,03"}},
+		{snapshotTsNs + 4, map[util.FullPath]string{"/papers": "", "/papers/a.txt": "4,04", "/papers/c.txt": "3,03"}},
+	}
+	for _, tt := range tests {
+		ns, err := LoadNamespace(store, "/", tt.tsNs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ns.Entries) != len(tt.expected) {
+			t.Errorf("as of %d: expected %v, got %v", tt.tsNs, tt.expected, ns.Paths())
+		}
+		for fullPath, content := range tt.expected {
+			entry, found := ns.Entries[fullPath]
+			if !found {
+				t.Errorf("as of %d: %s not found", tt.tsNs, fullPath)
+				continue
+			}
+			restored, err := RestoreEntry(store, entry, func(reader io.Reader, name string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+				data, _ := io.ReadAll(reader)
+				return &filer_pb.FileChunk{FileId: string(data), Offset: offset, Size: uint64(len(data))}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			for _, chunk := range restored.Chunks {
+				buf.WriteString(chunk.FileId)
+			}
+			if buf.String() != content {
+				t.Errorf("as of %d: %s should be %q, got %q", tt.tsNs, fullPath, content, buf.String())
+			}
+		}
+	}
+
+	if _, err := LoadNamespace(store, "/", startTsNs); err == nil {
+		t.Errorf("no snapshot should be found before it finished")
+	}
+
+	resumed := NewBackup(store, nil)
+	lastTsNs, lastSnapshotTsNs, err := resumed.Resume()
+	if err != nil || lastTsNs != snapshotTsNs+4 || lastSnapshotTsNs != snapshotTsNs || len(resumed.chunks) != 4 {
+		t.Errorf("resume: %d %d %d %v", lastTsNs, lastSnapshotTsNs, len(resumed.chunks), err)
+	}
+}
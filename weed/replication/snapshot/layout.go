@@ -0,0 +1,76 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the backup files under the location:
+//
+//	chunks/<file id>                       the chunk data, uploaded once
+//	snapshots/<start>-<stop>.snapshot      the entries traversed from the start to the stop time
+//	logs/<start>-<stop>.log                the metadata events from the start to the stop time
+//
+// the times are in unix nano seconds, zero padded to sort by name
+const (
+	ChunksDir    = "chunks"
+	SnapshotsDir = "snapshots"
+	LogsDir      = "logs"
+
+	snapshotExt = ".snapshot"
+	logExt      = ".log"
+)
+
+// Segment is a snapshot or a log file covering the time range
+type Segment struct {
+	Name      string
+	Size      int64
+	StartTsNs int64
+	StopTsNs  int64
+}
+
+func chunkFileName(fileId string) string {
+	return ChunksDir + "/" + strings.ReplaceAll(fileId, ",", "_")
+}
+
+func segmentFileName(dir string, startTsNs, stopTsNs int64, ext string) string {
+	return fmt.Sprintf("%s/%019d-%019d%s", dir, startTsNs, stopTsNs, ext)
+}
+
+func parseSegment(file StoreFile, ext string) (segment Segment, ok bool) {
+	if !strings.HasSuffix(file.Name, ext) {
+		return
+	}
+	if _, err := fmt.Sscanf(strings.TrimSuffix(file.Name, ext), "%d-%d", &segment.StartTsNs, &segment.StopTsNs); err != nil {
+		return
+	}
+	segment.Name, segment.Size = file.Name, file.Size
+	return segment, true
+}
+
+// listSegments lists the snapshots or logs, ordered by the start time
+func listSegments(store Store, dir string, ext string) (segments []Segment, err error) {
+	files, err := store.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if segment, ok := parseSegment(file, ext); ok {
+			segment.Name = dir + "/" + segment.Name
+			segments = append(segments, segment)
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].StartTsNs < segments[j].StartTsNs
+	})
+	return segments, nil
+}
+
+func ListSnapshots(store Store) ([]Segment, error) {
+	return listSegments(store, SnapshotsDir, snapshotExt)
+}
+
+func ListLogs(store Store) ([]Segment, error) {
+	return listSegments(store, LogsDir, logExt)
+}
@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// Namespace is the entries under a directory as of a time
+type Namespace struct {
+	Dir     util.FullPath
+	Entries map[util.FullPath]*filer_pb.Entry
+}
+
+// LoadNamespace materializes the entries under the directory as of the time,
+// from the latest snapshot finished before the time, and the events after the snapshot started
+func LoadNamespace(store Store, dir util.FullPath, tsNs int64) (*Namespace, error) {
+	snapshots, err := ListSnapshots(store)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot *Segment
+	for i := range snapshots {
+		if snapshots[i].StopTsNs <= tsNs && (snapshot == nil || snapshots[i].StopTsNs > snapshot.StopTsNs) {
+			snapshot = &snapshots[i]
+		}
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("no snapshot finished before %v", time.Unix(0, tsNs))
+	}
+
+	ns := &Namespace{
+		Dir:     dir,
+		Entries: make(map[util.FullPath]*filer_pb.Entry),
+	}
+	data, err := store.Read(snapshot.Name, snapshot.Size)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %v", snapshot.Name, err)
+	}
+	err = readMessages(data, func() proto.Message { return &filer_pb.FullEntry{} }, func(message proto.Message) error {
+		fullEntry := message.(*filer_pb.FullEntry)
+		ns.set(util.NewFullPath(fullEntry.Dir, fullEntry.Entry.Name), fullEntry.Entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %v", snapshot.Name, err)
+	}
+
+	logs, err := ListLogs(store)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		if log.StopTsNs < snapshot.StartTsNs || log.StartTsNs > tsNs {
+			continue
+		}
+		if data, err = store.Read(log.Name, log.Size); err != nil {
+			return nil, fmt.Errorf("read %s: %v", log.Name, err)
+		}
+		err = readMessages(data, func() proto.Message { return &filer_pb.SubscribeMetadataResponse{} }, func(message proto.Message) error {
+			event := message.(*filer_pb.SubscribeMetadataResponse)
+			if event.TsNs >= snapshot.StartTsNs && event.TsNs <= tsNs {
+				ns.apply(event)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %v", log.Name, err)
+		}
+	}
+	return ns, nil
+}
+
+func (ns *Namespace) contains(fullPath util.FullPath) bool {
+	return fullPath.IsUnder(ns.Dir)
+}
+
+func (ns *Namespace) set(fullPath util.FullPath, entry *filer_pb.Entry) {
+	if ns.contains(fullPath) {
+		ns.Entries[fullPath] = entry
+	}
+}
+
+// apply replays the event, which may be already in the fuzzy snapshot
+func (ns *Namespace) apply(event *filer_pb.SubscribeMetadataResponse) {
+	message := event.EventNotification
+	var oldPath, newPath util.FullPath
+	if message.OldEntry != nil {
+		oldPath = util.NewFullPath(event.Directory, message.OldEntry.Name)
+	}
+	if message.NewEntry != nil {
+		newPath = util.NewFullPath(message.NewParentPath, message.NewEntry.Name)
+	}
+
+	if oldPath != "" && oldPath != newPath {
+		delete(ns.Entries, oldPath)
+		for fullPath, entry := range ns.Entries {
+			if !fullPath.IsUnder(oldPath) {
+				continue
+			}
+			// the children are moved with the renamed directory, or deleted with the deleted one
+			delete(ns.Entries, fullPath)
+			if newPath != "" {
+				ns.set(util.FullPath(string(newPath)+string(fullPath)[len(oldPath):]), entry)
+			}
+		}
+	}
+	if newPath != "" {
+		ns.set(newPath, message.NewEntry)
+	}
+}
+
+// Paths returns the paths of the entries, the parent directories before the children
+func (ns *Namespace) Paths() []util.FullPath {
+	var paths []util.FullPath
+	for fullPath := range ns.Entries {
+		paths = append(paths, fullPath)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+// RestoreEntry uploads the chunks of the entry from the backup, and returns the entry with the new chunks
+func RestoreEntry(store Store, entry *filer_pb.Entry, saveFn filer.SaveDataAsChunkFunctionType) (*filer_pb.Entry, error) {
+	entry = proto.Clone(entry).(*filer_pb.Entry)
+	// the hard links are restored as separate files
+	entry.HardLinkId, entry.HardLinkCounter = nil, 0
+	if entry.IsDirectory || len(entry.GetChunks()) == 0 {
+		return entry, nil
+	}
+
+	var chunks []*filer_pb.FileChunk
+	for _, chunk := range entry.GetChunks() {
+		fileId := chunk.GetFileIdString()
+		data, err := store.Read(chunkFileName(fileId), int64(chunk.Size))
+		if err != nil {
+			return nil, fmt.Errorf("read chunk %s: %v", fileId, err)
+		}
+		newChunk, err := saveFn(bytes.NewReader(data), entry.Name, chunk.Offset, chunk.ModifiedTsNs)
+		if err != nil {
+			return nil, fmt.Errorf("upload chunk %s: %v", fileId, err)
+		}
+		chunks = append(chunks, newChunk)
+	}
+	entry.Chunks = chunks
+	return entry, nil
+}
@@ -0,0 +1,79 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/remote_pb"
+	"github.com/seaweedfs/seaweedfs/weed/remote_storage"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// Store keeps the backup files under one location
+type Store interface {
+	Write(name string, size int64, reader io.Reader) error
+	Read(name string, size int64) ([]byte, error)
+	// List returns the files directly under the directory
+	List(dir string) ([]StoreFile, error)
+}
+
+type StoreFile struct {
+	Name string
+	Size int64
+}
+
+// remoteStore keeps the backup files in a remote storage configured by "remote.configure"
+type remoteStore struct {
+	client remote_storage.RemoteStorageClient
+	loc    *remote_pb.RemoteStorageLocation
+}
+
+func NewRemoteStore(client remote_storage.RemoteStorageClient, loc *remote_pb.RemoteStorageLocation) Store {
+	return &remoteStore{
+		client: client,
+		loc:    loc,
+	}
+}
+
+func (s *remoteStore) location(name string) *remote_pb.RemoteStorageLocation {
+	return &remote_pb.RemoteStorageLocation{
+		Name:   s.loc.Name,
+		Bucket: s.loc.Bucket,
+		Path:   string(util.FullPath(s.loc.Path).Child(name)),
+	}
+}
+
+func (s *remoteStore) Write(name string, size int64, reader io.Reader) error {
+	_, err := s.client.WriteFile(s.location(name), &filer_pb.Entry{
+		Name: name,
+		Attributes: &filer_pb.FuseAttributes{
+			FileSize: uint64(size),
+			Mtime:    time.Now().Unix(),
+		},
+	}, reader)
+	return err
+}
+
+func (s *remoteStore) Read(name string, size int64) ([]byte, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	return s.client.ReadFile(s.location(name), 0, size)
+}
+
+func (s *remoteStore) List(dir string) (files []StoreFile, err error) {
+	loc := s.location(dir)
+	err = s.client.Traverse(loc, func(parent string, name string, isDirectory bool, remoteEntry *filer_pb.RemoteEntry) error {
+		if !isDirectory && strings.TrimSuffix(parent, "/") == loc.Path {
+			files = append(files, StoreFile{Name: name, Size: remoteEntry.RemoteSize})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %v", remote_storage.FormatLocation(loc), err)
+	}
+	return files, nil
+}
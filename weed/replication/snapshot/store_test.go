@@ -0,0 +1,44 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// memoryStore keeps the files in memory, for tests
+type memoryStore struct {
+	files map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{files: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Write(name string, size int64, reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	s.files[name] = data
+	return nil
+}
+
+func (s *memoryStore) Read(name string, size int64) ([]byte, error) {
+	data, found := s.files[name]
+	if !found {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	return bytes.Clone(data), nil
+}
+
+func (s *memoryStore) List(dir string) (files []StoreFile, err error) {
+	for name, data := range s.files {
+		if parent, fileName := util.FullPath("/" + name).DirAndName(); parent == "/"+dir {
+			files = append(files, StoreFile{Name: fileName, Size: int64(len(data))})
+		}
+	}
+	return files, nil
+}
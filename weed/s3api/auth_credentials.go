@@ -14,6 +14,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/iam_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 )
 
 type Action string
@@ -35,6 +36,12 @@ type IdentityAccessManagement struct {
 	hashMu            sync.RWMutex
 	domain            string
 	isAuthEnabled     bool
+	// the bucket policies evaluated for the requests, or nil if not looked up
+	bucketPolicyOf func(bucket string) *BucketPolicy
+	// the OIDC bearer tokens and the temporary credentials, nil if not configured
+	oidc        *security.OidcProvider
+	oidcMapping *oidcIdentityMapping
+	sts         *stsService
 }
 
 type Identity struct {
@@ -126,7 +133,7 @@ func NewIdentityAccessManagement(option *S3ApiServerOption) *IdentityAccessManag
 			glog.Fatalf("fail to load config file %s: %v", option.Config, err)
 		}
 	} else {
-		glog.V(3).Infof("no static config file specified... loading config from filer %s", option.Filer)
+		glog.V(3).Infof("no static config file specified... loading config from filer %v", option.Filers)
 		if err := iam.loadS3ApiConfigurationFromFiler(option); err != nil {
 			glog.Warningf("fail to load config: %v", err)
 		}
@@ -136,8 +143,8 @@ func NewIdentityAccessManagement(option *S3ApiServerOption) *IdentityAccessManag
 
 func (iam *IdentityAccessManagement) loadS3ApiConfigurationFromFiler(option *S3ApiServerOption) (err error) {
 	var content []byte
-	err = pb.WithFilerClient(false, 0, option.Filer, option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
-		glog.V(3).Infof("loading config %s from filer %s", filer.IamConfigDirectory+"/"+filer.IamIdentityFile, option.Filer)
+	err = pb.WithOneOfGrpcFilerClients(false, option.Filers, option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		glog.V(3).Infof("loading config %s from filer %s", filer.IamConfigDirectory+"/"+filer.IamIdentityFile, option.Filers)
 		content, err = filer.ReadInsideFiler(client, filer.IamConfigDirectory, filer.IamIdentityFile)
 		return err
 	})
@@ -334,6 +341,9 @@ func (iam *IdentityAccessManagement) Auth(f http.HandlerFunc, action Action) htt
 					r.Header.Del(s3_constants.AmzIsAdmin)
 				}
 			}
+			if isBypassGovernanceRetention(r) && !iam.canBypassGovernanceRetention(r, identity) {
+				r.Header.Del(s3_constants.AmzBypassGovernanceRetention)
+			}
 			f(w, r)
 			return
 		}
@@ -370,12 +380,16 @@ func (iam *IdentityAccessManagement) authRequest(r *http.Request, action Action)
 	case authTypeJWT:
 		glog.V(3).Infof("jwt auth type")
 		r.Header.Set(s3_constants.AmzAuthType, "Jwt")
-		return identity, s3err.ErrNotImplemented
+		if iam.oidc == nil {
+			return identity, s3err.ErrNotImplemented
+		}
+		authType = "Jwt"
+		identity, s3Err = iam.authOidcToken(r)
 	case authTypeAnonymous:
 		authType = "Anonymous"
 		if identity, found = iam.lookupAnonymous(); !found {
-			r.Header.Set(s3_constants.AmzAuthType, authType)
-			return identity, s3err.ErrAccessDenied
+			// the anonymous requests can still be allowed by the bucket policies
+			identity = &Identity{Name: AccountAnonymous.Id, Account: &AccountAnonymous}
 		}
 	default:
 		return identity, s3err.ErrNotImplemented
@@ -398,8 +412,14 @@ func (iam *IdentityAccessManagement) authRequest(r *http.Request, action Action)
 		object = prefix
 	}
 
-	if !identity.canDo(action, bucket, object) {
+	switch iam.evaluateBucketPolicy(r, identity, bucket) {
+	case policyDeny:
 		return identity, s3err.ErrAccessDenied
+	case policyAllow:
+	default:
+		if !identity.canDo(action, bucket, object) {
+			return identity, s3err.ErrAccessDenied
+		}
 	}
 
 	r.Header.Set(s3_constants.AmzAccountId, identity.Account.Id)
@@ -439,7 +459,11 @@ func (iam *IdentityAccessManagement) authUser(r *http.Request) (*Identity, s3err
 	case authTypeJWT:
 		glog.V(3).Infof("jwt auth type")
 		r.Header.Set(s3_constants.AmzAuthType, "Jwt")
-		return identity, s3err.ErrNotImplemented
+		if iam.oidc == nil {
+			return identity, s3err.ErrNotImplemented
+		}
+		authType = "Jwt"
+		identity, s3Err = iam.authOidcToken(r)
 	case authTypeAnonymous:
 		authType = "Anonymous"
 		identity, found = iam.lookupAnonymous()
@@ -80,6 +80,10 @@ func (s3a *S3ApiServer) onCircuitBreakerConfigUpdate(dir, filename string, conte
 func (s3a *S3ApiServer) onBucketMetadataChange(dir string, oldEntry *filer_pb.Entry, newEntry *filer_pb.Entry) error {
 	if dir == s3a.option.BucketsPath {
 		if newEntry != nil {
+			// a renamed bucket
+			if oldEntry != nil && oldEntry.Name != newEntry.Name {
+				s3a.bucketRegistry.RemoveBucketMetadata(oldEntry)
+			}
 			s3a.bucketRegistry.LoadBucketMetadata(newEntry)
 			glog.V(0).Infof("updated bucketMetadata %s/%s", dir, newEntry)
 		} else {
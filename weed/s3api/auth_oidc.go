@@ -0,0 +1,221 @@
+package s3api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	stsIssuer                    = "seaweedfs-sts"
+	stsDefaultDurationSeconds    = 3600
+	stsMinDurationSeconds        = 900
+	stsDefaultMaxDurationSeconds = 43200
+	amzSecurityToken             = "X-Amz-Security-Token"
+)
+
+// stsService issues the temporary credentials. The credentials are not stored: the session token is signed,
+// and the secret key is derived from the access key, so any S3 gateway with the same signing key verifies them.
+type stsService struct {
+	signingKey  []byte
+	maxDuration time.Duration
+}
+
+// oidcIdentityMapping maps the users and the roles of the tokens to the identities, explicitly,
+// so the users or the groups named like the identities, e.g., "admin", are not granted their actions.
+type oidcIdentityMapping struct {
+	users map[string][]string
+	roles map[string][]string
+}
+
+type stsSessionClaims struct {
+	AccessKey string `json:"ak"`
+	Role      string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// loadOidcConfiguration enables the OIDC bearer tokens and the AssumeRoleWithWebIdentity, if the issuer is configured
+func (iam *IdentityAccessManagement) loadOidcConfiguration(config *util.ViperProxy) {
+	iam.oidc = security.LoadOidcProvider(config, "oidc.")
+	if iam.oidc == nil {
+		return
+	}
+	iam.oidcMapping = &oidcIdentityMapping{
+		users: parseOidcIdentityMapping(config.GetStringSlice("oidc.s3.user_identities")),
+		roles: parseOidcIdentityMapping(config.GetStringSlice("oidc.s3.role_identities")),
+	}
+	if len(iam.oidcMapping.users) == 0 && len(iam.oidcMapping.roles) == 0 {
+		glog.Warningf("oidc.s3.user_identities and oidc.s3.role_identities are empty, all the OIDC tokens are denied")
+	}
+	signingKey := []byte(config.GetString("oidc.sts.signing_key"))
+	if len(signingKey) == 0 {
+		glog.Warningf("oidc.sts.signing_key is not set, the temporary credentials only work on this S3 gateway until restarted")
+		signingKey = make([]byte, 32)
+		rand.Read(signingKey)
+	}
+	config.SetDefault("oidc.sts.max_duration_seconds", stsDefaultMaxDurationSeconds)
+	iam.sts = &stsService{
+		signingKey:  signingKey,
+		maxDuration: time.Duration(config.GetInt("oidc.sts.max_duration_seconds")) * time.Second,
+	}
+}
+
+// parseOidcIdentityMapping parses the "<user or role>=<identity name>" items
+func parseOidcIdentityMapping(items []string) map[string][]string {
+	mapping := make(map[string][]string)
+	for _, item := range items {
+		separator := strings.LastIndex(item, "=")
+		if separator <= 0 || separator == len(item)-1 {
+			glog.Warningf("invalid oidc identity mapping %q, expecting <user or role>=<identity name>", item)
+			continue
+		}
+		name := strings.TrimSpace(item[:separator])
+		mapping[name] = append(mapping[name], strings.TrimSpace(item[separator+1:]))
+	}
+	return mapping
+}
+
+// identityNamesOf lists the identities mapped from the user or any of the roles
+func (mapping *oidcIdentityMapping) identityNamesOf(user *security.OidcIdentity) (names []string) {
+	if mapping == nil {
+		return nil
+	}
+	names = append(names, mapping.users[user.Username]...)
+	for _, role := range user.Roles {
+		names = append(names, mapping.roles[role]...)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// authOidcToken authenticates the bearer token, as the identity allowing the actions of
+// the identities mapped from the user or any of the roles
+func (iam *IdentityAccessManagement) authOidcToken(r *http.Request) (*Identity, s3err.ErrorCode) {
+	user, err := iam.oidc.Verify(string(security.GetJwt(r)))
+	if err != nil {
+		glog.V(1).Infof("oidc token from %s: %v", r.RemoteAddr, err)
+		return nil, s3err.ErrAccessDenied
+	}
+	identity := iam.identityOfOidcUser(user)
+	if identity == nil {
+		glog.V(1).Infof("oidc user %s with roles %v is not mapped to any identity", user.Username, user.Roles)
+		return nil, s3err.ErrAccessDenied
+	}
+	return identity, s3err.ErrNone
+}
+
+func (iam *IdentityAccessManagement) identityOfOidcUser(user *security.OidcIdentity) *Identity {
+	var identity *Identity
+	for _, name := range iam.oidcMapping.identityNamesOf(user) {
+		matched := iam.lookupByName(name)
+		if matched == nil {
+			continue
+		}
+		if identity == nil {
+			identity = &Identity{Name: user.Username, Account: matched.Account}
+		}
+		identity.Actions = append(identity.Actions, matched.Actions...)
+	}
+	return identity
+}
+
+// lookupByName finds the configured identity, except the anonymous one
+func (iam *IdentityAccessManagement) lookupByName(name string) *Identity {
+	if name == "" || name == AccountAnonymous.Id {
+		return nil
+	}
+	iam.m.RLock()
+	defer iam.m.RUnlock()
+	for _, identity := range iam.identities {
+		if identity.Name == name {
+			return identity
+		}
+	}
+	return nil
+}
+
+// lookupCredential looks up the access key, or the temporary credential with the session token of the request
+func (iam *IdentityAccessManagement) lookupCredential(accessKey string, r *http.Request) (identity *Identity, cred *Credential, found bool) {
+	if identity, cred, found = iam.lookupByAccessKey(accessKey); found || iam.sts == nil {
+		return
+	}
+	sessionToken := r.Header.Get(amzSecurityToken)
+	if sessionToken == "" {
+		sessionToken = r.URL.Query().Get(amzSecurityToken)
+	}
+	if sessionToken == "" {
+		return nil, nil, false
+	}
+	claims, err := iam.sts.verifySessionToken(sessionToken)
+	if err != nil || claims.AccessKey != accessKey {
+		glog.V(1).Infof("invalid session token of %s: %v", accessKey, err)
+		return nil, nil, false
+	}
+	// the role is looked up on each request, so removing the identity revokes the temporary credentials
+	role := iam.lookupByName(claims.Role)
+	if role == nil {
+		glog.V(1).Infof("the role %s of %s is not found", claims.Role, accessKey)
+		return nil, nil, false
+	}
+	cred = &Credential{AccessKey: accessKey, SecretKey: iam.sts.secretKeyOf(accessKey)}
+	return &Identity{
+		Name:        role.Name,
+		Account:     role.Account,
+		Actions:     role.Actions,
+		Credentials: []*Credential{cred},
+	}, cred, true
+}
+
+// issue creates the temporary credential of the role for the user, with the session token
+func (sts *stsService) issue(role, username string, duration time.Duration) (cred *Credential, sessionToken string, expiration time.Time, err error) {
+	randomBytes := make([]byte, 10)
+	if _, err = rand.Read(randomBytes); err != nil {
+		return
+	}
+	accessKey := "ASIA" + base32.StdEncoding.EncodeToString(randomBytes)
+	expiration = time.Now().Add(duration).UTC().Truncate(time.Second)
+	claims := &stsSessionClaims{
+		AccessKey: accessKey,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    stsIssuer,
+			Subject:   username,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiration),
+		},
+	}
+	if sessionToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(sts.signingKey); err != nil {
+		return
+	}
+	return &Credential{AccessKey: accessKey, SecretKey: sts.secretKeyOf(accessKey)}, sessionToken, expiration, nil
+}
+
+func (sts *stsService) verifySessionToken(sessionToken string) (*stsSessionClaims, error) {
+	claims := &stsSessionClaims{}
+	_, err := jwt.ParseWithClaims(sessionToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return sts.signingKey, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithIssuer(stsIssuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (sts *stsService) secretKeyOf(accessKey string) string {
+	h := hmac.New(sha256.New, sts.signingKey)
+	fmt.Fprintf(h, "secret:%s", accessKey)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,198 @@
+package s3api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/seaweedfs/seaweedfs/weed/pb/iam_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+)
+
+// testIdentityProvider serves the discovery and the keys, and issues the tokens
+type testIdentityProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+}
+
+func newTestIdentityProvider(t *testing.T) *testIdentityProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testIdentityProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": p.server.URL, "jwks_uri": p.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *testIdentityProvider) token(t *testing.T, issuer, subject string, groups []string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":    issuer,
+		"aud":    "seaweedfs",
+		"sub":    subject,
+		"groups": groups,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func newTestOidcIam(t *testing.T, p *testIdentityProvider) *IdentityAccessManagement {
+	iam := &IdentityAccessManagement{
+		hashes:       make(map[string]*sync.Pool),
+		hashCounters: make(map[string]*int32),
+		oidc:         security.NewOidcProvider(p.server.URL, "seaweedfs", ""),
+		oidcMapping: &oidcIdentityMapping{
+			users: parseOidcIdentityMapping([]string{"dave=writers"}),
+			roles: parseOidcIdentityMapping([]string{"storage-readers=readers", "storage-writers=writers", "storage-writers=readers"}),
+		},
+		sts: &stsService{signingKey: []byte("sts signing key"), maxDuration: 12 * time.Hour},
+	}
+	if err := iam.loadS3ApiConfiguration(&iam_pb.S3ApiConfiguration{
+		Identities: []*iam_pb.Identity{
+			{Name: "readers", Actions: []string{"Read", "List"}},
+			{Name: "writers", Actions: []string{"Write:bucket1"}},
+			{Name: "admin", Actions: []string{"Admin"}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return iam
+}
+
+func TestOidcBearerToken(t *testing.T) {
+	p := newTestIdentityProvider(t)
+	iam := newTestOidcIam(t, p)
+
+	newRequest := func(token string) *http.Request {
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodPut, "http://localhost/bucket1/object", nil), map[string]string{"bucket": "bucket1", "object": "object"})
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+
+	token := p.token(t, p.server.URL, "alice", []string{"storage-readers", "storage-writers"})
+	if identity, errCode := iam.authRequest(newRequest(token), s3_constants.ACTION_WRITE); errCode != s3err.ErrNone || identity.Name != "alice" {
+		t.Fatalf("write with the roles: %v", errCode)
+	}
+	if _, errCode := iam.authRequest(newRequest(p.token(t, p.server.URL, "dave", nil)), s3_constants.ACTION_WRITE); errCode != s3err.ErrNone {
+		t.Errorf("write with the mapped user: %v", errCode)
+	}
+	if _, errCode := iam.authRequest(newRequest(p.token(t, p.server.URL, "bob", []string{"storage-readers"})), s3_constants.ACTION_WRITE); errCode != s3err.ErrAccessDenied {
+		t.Errorf("the readers should not write, got %v", errCode)
+	}
+	if _, errCode := iam.authRequest(newRequest(p.token(t, p.server.URL, "carol", []string{"others"})), s3_constants.ACTION_READ); errCode != s3err.ErrAccessDenied {
+		t.Errorf("the user without mapped identities should be denied, got %v", errCode)
+	}
+	// the users and the roles named like the identities are not mapped to them
+	if _, errCode := iam.authRequest(newRequest(p.token(t, p.server.URL, "admin", []string{"admin", "writers"})), s3_constants.ACTION_READ); errCode != s3err.ErrAccessDenied {
+		t.Errorf("the user and the roles named like the identities should be denied, got %v", errCode)
+	}
+	if _, errCode := iam.authRequest(newRequest(p.token(t, "https://other.example.com", "alice", []string{"storage-writers"})), s3_constants.ACTION_WRITE); errCode != s3err.ErrAccessDenied {
+		t.Errorf("the token of other issuers should be denied, got %v", errCode)
+	}
+	if _, errCode := iam.authRequest(newRequest(token[:len(token)-4]+"AAAA"), s3_constants.ACTION_WRITE); errCode != s3err.ErrAccessDenied {
+		t.Errorf("the tampered token should be denied, got %v", errCode)
+	}
+}
+
+func TestAssumeRoleWithWebIdentity(t *testing.T) {
+	p := newTestIdentityProvider(t)
+	s3a := &S3ApiServer{iam: newTestOidcIam(t, p)}
+
+	assumeRole := func(role, token string) *httptest.ResponseRecorder {
+		form := url.Values{
+			"Action":           {"AssumeRoleWithWebIdentity"},
+			"RoleArn":          {"arn:aws:iam::000000000000:role/" + role},
+			"RoleSessionName":  {"test"},
+			"WebIdentityToken": {token},
+			"DurationSeconds":  {"900"},
+		}
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s3a.AssumeRoleWithWebIdentityHandler(w, r)
+		return w
+	}
+
+	if w := assumeRole("writers", p.token(t, p.server.URL, "writers", []string{"writers"})); w.Code != http.StatusForbidden {
+		t.Errorf("assume the role named like the user and the roles, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := assumeRole("admin", p.token(t, p.server.URL, "alice", []string{"storage-writers"})); w.Code != http.StatusForbidden {
+		t.Errorf("assume the role not mapped, got %d: %s", w.Code, w.Body.String())
+	}
+	token := p.token(t, p.server.URL, "alice", []string{"storage-writers"})
+	w := assumeRole("writers", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("assume role: %d %s", w.Code, w.Body.String())
+	}
+	response := &AssumeRoleWithWebIdentityResponse{}
+	if err := xml.Unmarshal(w.Body.Bytes(), response); err != nil {
+		t.Fatal(err)
+	}
+	cred := response.Result.Credentials
+	if !strings.HasPrefix(cred.AccessKeyId, "ASIA") || time.Until(cred.Expiration) > 15*time.Minute {
+		t.Fatalf("unexpected credentials %+v", cred)
+	}
+
+	newSignedRequest := func(sessionToken string) *http.Request {
+		r := mustNewRequest(http.MethodGet, "http://127.0.0.1:9000/bucket1/object", 0, nil, t)
+		r.Header.Set(amzSecurityToken, sessionToken)
+		if err := signRequestV4(r, cred.AccessKeyId, cred.SecretAccessKey); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	identity, errCode := s3a.iam.reqSignatureV4Verify(newSignedRequest(cred.SessionToken))
+	if errCode != s3err.ErrNone || identity.Name != "writers" {
+		t.Fatalf("sign with the temporary credentials: %v", errCode)
+	}
+	if _, errCode := s3a.iam.reqSignatureV4Verify(newSignedRequest("")); errCode != s3err.ErrInvalidAccessKeyID {
+		t.Errorf("the temporary credentials need the session token, got %v", errCode)
+	}
+	otherSts := &stsService{signingKey: []byte("other key"), maxDuration: time.Hour}
+	_, forgedToken, _, _ := otherSts.issue("writers", "alice", time.Hour)
+	if _, errCode := s3a.iam.reqSignatureV4Verify(newSignedRequest(forgedToken)); errCode != s3err.ErrInvalidAccessKeyID {
+		t.Errorf("the session token of other keys should be rejected, got %v", errCode)
+	}
+}
+
+func TestParseOidcIdentityMapping(t *testing.T) {
+	mapping := parseOidcIdentityMapping([]string{"a=x", "b = y", "a=z", "urn:group=a=b=w", "=x", "c=", "d"})
+	expected := map[string][]string{"a": {"x", "z"}, "b": {"y"}, "urn:group=a=b": {"w"}}
+	if !reflect.DeepEqual(mapping, expected) {
+		t.Errorf("got %v, expected %v", mapping, expected)
+	}
+}
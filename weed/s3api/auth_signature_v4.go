@@ -117,7 +117,7 @@ func (iam *IdentityAccessManagement) doesSignatureMatch(hashedPayload string, r
 	}
 
 	// Verify if the access key id matches.
-	identity, cred, found := iam.lookupByAccessKey(signV4Values.Credential.accessKey)
+	identity, cred, found := iam.lookupCredential(signV4Values.Credential.accessKey, r)
 	if !found {
 		return nil, s3err.ErrInvalidAccessKeyID
 	}
@@ -382,7 +382,7 @@ func (iam *IdentityAccessManagement) doesPresignedSignatureMatch(hashedPayload s
 	}
 
 	// Verify if the access key id matches.
-	identity, cred, found := iam.lookupByAccessKey(pSignValues.Credential.accessKey)
+	identity, cred, found := iam.lookupCredential(pSignValues.Credential.accessKey, r)
 	if !found {
 		return nil, s3err.ErrInvalidAccessKeyID
 	}
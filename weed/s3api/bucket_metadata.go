@@ -38,6 +38,24 @@ type BucketMetaData struct {
 
 	// A list of grants for access controls.
 	Acl []*s3.Grant `locationName:"AccessControlList" locationNameList:"Grant" type:"list"`
+
+	// The versioning status, Enabled or Suspended, or empty if the versioning has never been configured.
+	Versioning string
+
+	// The replication configuration, or nil if the bucket is not replicated.
+	Replication *s3.ReplicationConfiguration
+
+	// The notification configuration, or nil if the bucket events are not published.
+	Notification *s3.NotificationConfiguration
+
+	// The object lock configuration, or nil if the object lock is not enabled.
+	ObjectLock *s3.ObjectLockConfiguration
+
+	// The bucket policy, or nil if the bucket has no policy.
+	Policy *BucketPolicy
+
+	// The access logging target, or nil if the requests are not logged.
+	Logging *s3.LoggingEnabled
 }
 
 type BucketRegistry struct {
@@ -132,6 +150,61 @@ func buildBucketMetadata(accountManager AccountManager, entry *filer_pb.Entry) *
 				glog.Warningf("Unmarshal ACP grants: %s(%v), bucket: %s", string(acpGrantsBytes), err, bucketMetadata.Name)
 			}
 		}
+
+		//versioning
+		if versioning, ok := entry.Extended[s3_constants.ExtVersioningKey]; ok {
+			bucketMetadata.Versioning = string(versioning)
+		}
+
+		//replication
+		if replicationBytes, ok := entry.Extended[s3_constants.ExtReplicationKey]; ok {
+			replication, err := ParseReplicationConfiguration(replicationBytes)
+			if err == nil {
+				bucketMetadata.Replication = replication
+			} else {
+				glog.Warningf("Unmarshal replication: %s(%v), bucket: %s", string(replicationBytes), err, bucketMetadata.Name)
+			}
+		}
+
+		//notification
+		if notificationBytes, ok := entry.Extended[s3_constants.ExtNotificationKey]; ok {
+			notification, err := ParseNotificationConfiguration(notificationBytes)
+			if err == nil {
+				bucketMetadata.Notification = notification
+			} else {
+				glog.Warningf("Unmarshal notification: %s(%v), bucket: %s", string(notificationBytes), err, bucketMetadata.Name)
+			}
+		}
+
+		//object lock
+		if objectLockBytes, ok := entry.Extended[s3_constants.ExtObjectLockKey]; ok {
+			objectLock, err := ParseObjectLockConfiguration(objectLockBytes)
+			if err == nil {
+				bucketMetadata.ObjectLock = objectLock
+			} else {
+				glog.Warningf("Unmarshal object lock: %s(%v), bucket: %s", string(objectLockBytes), err, bucketMetadata.Name)
+			}
+		}
+
+		//policy
+		if policyBytes, ok := entry.Extended[s3_constants.ExtBucketPolicyKey]; ok {
+			policy, err := ParseBucketPolicy(policyBytes)
+			if err == nil {
+				bucketMetadata.Policy = policy
+			} else {
+				glog.Warningf("Unmarshal policy: %s(%v), bucket: %s", string(policyBytes), err, bucketMetadata.Name)
+			}
+		}
+
+		//logging
+		if loggingBytes, ok := entry.Extended[s3_constants.ExtLoggingKey]; ok {
+			logging, err := ParseLoggingConfiguration(loggingBytes)
+			if err == nil {
+				bucketMetadata.Logging = logging
+			} else {
+				glog.Warningf("Unmarshal logging: %s(%v), bucket: %s", string(loggingBytes), err, bucketMetadata.Name)
+			}
+		}
 	}
 	return bucketMetadata
 }
@@ -2,6 +2,7 @@ package s3api
 
 import (
 	"cmp"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
@@ -23,6 +24,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 const (
@@ -93,7 +95,7 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 	sort.Ints(completedPartNumbers)
 
 	uploadDirectory := s3a.genUploadsFolder(*input.Bucket) + "/" + *input.UploadId
-	entries, _, err := s3a.list(uploadDirectory, "", "", false, maxPartsList)
+	entries, _, err := s3a.list(uploadDirectory, "", "", false, math.MaxInt32)
 	if err != nil {
 		glog.Errorf("completeMultipartUpload %s %s error: %v, entries:%d", *input.Bucket, *input.UploadId, err, len(entries))
 		stats.S3HandlerCounter.WithLabelValues(stats.ErrorCompletedNoSuchUpload).Inc()
@@ -106,7 +108,7 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 			if uploadId, ok := entry.Extended[s3_constants.SeaweedFSUploadId]; ok && *input.UploadId == string(uploadId) {
 				return &CompleteMultipartUploadResult{
 					CompleteMultipartUploadOutput: s3.CompleteMultipartUploadOutput{
-						Location: aws.String(fmt.Sprintf("http://%s%s/%s", s3a.option.Filer.ToHttpAddress(), urlEscapeObject(dirName), urlPathEscape(entryName))),
+						Location: aws.String(fmt.Sprintf("http://%s%s/%s", s3a.filers.Current().ToHttpAddress(), urlEscapeObject(dirName), urlPathEscape(entryName))),
 						Bucket:   input.Bucket,
 						ETag:     aws.String("\"" + filer.ETagChunks(entry.GetChunks()) + "\""),
 						Key:      objectKey(input.Key),
@@ -182,6 +184,9 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 	mime := pentry.Attributes.Mime
 	var finalParts []*filer_pb.FileChunk
 	var offset int64
+	// the parts are encrypted separately, so the layout of the parts is needed to decrypt the object
+	var ssePartsLayout []ssePart
+	_, isEncrypted := pentry.Extended[s3_constants.ExtSseNonceKey]
 	for _, partNumber := range completedPartNumbers {
 		partEntriesByNumber, ok := partEntries[partNumber]
 		if !ok {
@@ -201,6 +206,7 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 				stats.S3HandlerCounter.WithLabelValues(stats.ErrorCompletedPartEntryMismatch).Inc()
 				continue
 			}
+			partStart := offset
 			for _, chunk := range entry.GetChunks() {
 				p := &filer_pb.FileChunk{
 					FileId:       chunk.GetFileIdString(),
@@ -214,11 +220,21 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 				finalParts = append(finalParts, p)
 				offset += int64(chunk.Size)
 			}
+			if isEncrypted {
+				// the nonce of the part upload, saved as the nonce of the part
+				partNonce, decodeErr := base64.StdEncoding.DecodeString(string(entry.Extended[s3_constants.ExtSseNonceKey]))
+				if decodeErr != nil || len(partNonce) != sseNonceSize {
+					glog.Errorf("completeMultipartUpload %s invalid nonce %s", entry.Name, entry.Extended[s3_constants.ExtSseNonceKey])
+					return nil, s3err.ErrInternalError
+				}
+				ssePartsLayout = append(ssePartsLayout, ssePart{Number: partNumber, Size: offset - partStart, Nonce: partNonce})
+			}
 			found = true
 		}
 	}
 
 	entryName, dirName := s3a.getEntryNameAndDir(input)
+	versionId := s3a.versionIdForWrite(*input.Bucket)
 	err = s3a.mkFile(dirName, entryName, finalParts, func(entry *filer_pb.Entry) {
 		if entry.Extended == nil {
 			entry.Extended = make(map[string][]byte)
@@ -229,6 +245,11 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 				entry.Extended[k] = v
 			}
 		}
+		if isEncrypted {
+			entry.Extended[s3_constants.ExtSsePartsKey] = []byte(formatSseParts(ssePartsLayout))
+		}
+		setObjectVersionId(entry.Extended, versionId)
+		s3a.markEntryReplicationPending(entry.Extended, *input.Bucket, *input.Key)
 		if pentry.Attributes.Mime != "" {
 			entry.Attributes.Mime = pentry.Attributes.Mime
 		} else if mime != "" {
@@ -244,12 +265,16 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 
 	output = &CompleteMultipartUploadResult{
 		CompleteMultipartUploadOutput: s3.CompleteMultipartUploadOutput{
-			Location: aws.String(fmt.Sprintf("http://%s%s/%s", s3a.option.Filer.ToHttpAddress(), urlEscapeObject(dirName), urlPathEscape(entryName))),
+			Location: aws.String(fmt.Sprintf("http://%s%s/%s", s3a.filers.Current().ToHttpAddress(), urlEscapeObject(dirName), urlPathEscape(entryName))),
 			Bucket:   input.Bucket,
 			ETag:     aws.String("\"" + filer.ETagChunks(finalParts) + "\""),
 			Key:      objectKey(input.Key),
 		},
 	}
+	if versionId != "" {
+		output.VersionId = aws.String(versionId)
+	}
+	s3a.maybeRemoveNullVersions(*input.Bucket, util.NewFullPath(dirName, entryName))
 
 	for _, deleteEntry := range deleteEntries {
 		//delete unused part data
@@ -329,6 +354,7 @@ type ListMultipartUploadsResult struct {
 	Prefix             *string               `type:"string"`
 	UploadIdMarker     *string               `type:"string"`
 	Upload             []*s3.MultipartUpload `locationName:"Upload" type:"list" flattened:"true"`
+	CommonPrefixes     []*s3.CommonPrefix    `locationName:"CommonPrefixes" type:"list" flattened:"true"`
 }
 
 func (s3a *S3ApiServer) listMultipartUploads(input *s3.ListMultipartUploadsInput) (output *ListMultipartUploadsResult, code s3err.ErrorCode) {
@@ -336,46 +362,94 @@ func (s3a *S3ApiServer) listMultipartUploads(input *s3.ListMultipartUploadsInput
 
 	glog.V(2).Infof("listMultipartUploads input %v", input)
 
-	output = &ListMultipartUploadsResult{
-		Bucket:       input.Bucket,
-		Delimiter:    input.Delimiter,
-		EncodingType: input.EncodingType,
-		KeyMarker:    input.KeyMarker,
-		MaxUploads:   input.MaxUploads,
-		Prefix:       input.Prefix,
-		IsTruncated:  aws.Bool(false),
-	}
-
-	entries, _, err := s3a.list(s3a.genUploadsFolder(*input.Bucket), "", *input.UploadIdMarker, false, math.MaxInt32)
+	entries, _, err := s3a.list(s3a.genUploadsFolder(*input.Bucket), "", "", false, math.MaxInt32)
 	if err != nil {
 		glog.Errorf("listMultipartUploads %s error: %v", *input.Bucket, err)
-		return
+		return nil, s3err.ErrInternalError
 	}
 
-	uploadsCount := int64(0)
+	var uploads []*s3.MultipartUpload
 	for _, entry := range entries {
-		if entry.Extended != nil {
-			key := string(entry.Extended["key"])
-			if *input.KeyMarker != "" && *input.KeyMarker != key {
-				continue
-			}
-			if *input.Prefix != "" && !strings.HasPrefix(key, *input.Prefix) {
-				continue
+		if !entry.IsDirectory || entry.Extended == nil {
+			continue
+		}
+		key := string(entry.Extended["key"])
+		uploads = append(uploads, &s3.MultipartUpload{
+			Key:          objectKey(aws.String(key)),
+			UploadId:     aws.String(entry.Name),
+			Initiated:    aws.Time(time.Unix(entry.Attributes.Crtime, 0).UTC()),
+			StorageClass: aws.String("STANDARD"),
+		})
+	}
+
+	return paginateMultipartUploads(input, uploads), s3err.ErrNone
+}
+
+// paginateMultipartUploads lists the uploads ordered by the keys, and by the upload ids for the same key,
+// after the key marker, or after the upload id marker of the same key, with the keys of the common prefixes
+// under the delimiter rolled up
+func paginateMultipartUploads(input *s3.ListMultipartUploadsInput, uploads []*s3.MultipartUpload) *ListMultipartUploadsResult {
+	output := &ListMultipartUploadsResult{
+		Bucket:         input.Bucket,
+		Delimiter:      input.Delimiter,
+		EncodingType:   input.EncodingType,
+		KeyMarker:      input.KeyMarker,
+		MaxUploads:     input.MaxUploads,
+		Prefix:         input.Prefix,
+		UploadIdMarker: input.UploadIdMarker,
+		IsTruncated:    aws.Bool(false),
+	}
+
+	slices.SortFunc(uploads, func(a, b *s3.MultipartUpload) int {
+		return cmp.Or(strings.Compare(*a.Key, *b.Key), strings.Compare(*a.UploadId, *b.UploadId))
+	})
+
+	prefix, delimiter := aws.StringValue(input.Prefix), aws.StringValue(input.Delimiter)
+	keyMarker, uploadIdMarker := aws.StringValue(input.KeyMarker), aws.StringValue(input.UploadIdMarker)
+	maxUploads := aws.Int64Value(input.MaxUploads)
+	count := int64(0)
+	lastCommonPrefix := ""
+	for _, upload := range uploads {
+		key := *upload.Key
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		// the upload id marker is ignored without the key marker
+		if keyMarker != "" && (key < keyMarker || key == keyMarker && (uploadIdMarker == "" || *upload.UploadId <= uploadIdMarker)) {
+			continue
+		}
+		commonPrefix := ""
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				commonPrefix = key[:len(prefix)+i+len(delimiter)]
 			}
-			output.Upload = append(output.Upload, &s3.MultipartUpload{
-				Key:      objectKey(aws.String(key)),
-				UploadId: aws.String(entry.Name),
-			})
-			uploadsCount += 1
 		}
-		if uploadsCount >= *input.MaxUploads {
+		if commonPrefix != "" && commonPrefix == lastCommonPrefix {
+			continue
+		}
+		// the common prefix listed before the key marker is skipped
+		if commonPrefix != "" && keyMarker != "" && strings.HasPrefix(keyMarker, commonPrefix) {
+			continue
+		}
+		if count >= maxUploads {
 			output.IsTruncated = aws.Bool(true)
-			output.NextUploadIdMarker = aws.String(entry.Name)
 			break
 		}
+		count++
+		if commonPrefix != "" {
+			lastCommonPrefix = commonPrefix
+			output.CommonPrefixes = append(output.CommonPrefixes, &s3.CommonPrefix{Prefix: aws.String(commonPrefix)})
+			output.NextKeyMarker, output.NextUploadIdMarker = aws.String(commonPrefix), nil
+			continue
+		}
+		output.Upload = append(output.Upload, upload)
+		output.NextKeyMarker, output.NextUploadIdMarker = upload.Key, upload.UploadId
+	}
+	if !*output.IsTruncated {
+		output.NextKeyMarker, output.NextUploadIdMarker = nil, nil
 	}
 
-	return
+	return output
 }
 
 type ListPartsResult struct {
@@ -405,37 +479,76 @@ func (s3a *S3ApiServer) listObjectParts(input *s3.ListPartsInput) (output *ListP
 		MaxParts:         input.MaxParts,         // the maximum number of parts to return.
 		PartNumberMarker: input.PartNumberMarker, // the part number starts after this, exclusive
 		StorageClass:     aws.String("STANDARD"),
+		IsTruncated:      aws.Bool(false),
 	}
 
-	entries, isLast, err := s3a.list(s3a.genUploadsFolder(*input.Bucket)+"/"+*input.UploadId, "", fmt.Sprintf("%04d%s", *input.PartNumberMarker, multipartExt), false, uint32(*input.MaxParts))
+	// Note: The upload directory is sort of a marker of the existence of an multipart upload request.
+	// So can not just delete empty upload folders.
+	if _, err := s3a.getEntry(s3a.genUploadsFolder(*input.Bucket), *input.UploadId); err != nil {
+		glog.V(1).Infof("listObjectParts %s %s: %v", *input.Bucket, *input.UploadId, err)
+		return nil, s3err.ErrNoSuchUpload
+	}
+	entries, _, err := s3a.list(s3a.genUploadsFolder(*input.Bucket)+"/"+*input.UploadId, "", "", false, math.MaxInt32)
 	if err != nil {
 		glog.Errorf("listObjectParts %s %s error: %v", *input.Bucket, *input.UploadId, err)
 		return nil, s3err.ErrNoSuchUpload
 	}
 
-	// Note: The upload directory is sort of a marker of the existence of an multipart upload request.
-	// So can not just delete empty upload folders.
+	for _, entry := range latestParts(entries) {
+		partNumber, _ := parsePartNumber(entry.Name)
+		if int64(partNumber) <= *input.PartNumberMarker {
+			continue
+		}
+		if int64(len(output.Part)) >= *input.MaxParts {
+			output.IsTruncated = aws.Bool(true)
+			break
+		}
+		output.Part = append(output.Part, &s3.Part{
+			PartNumber:   aws.Int64(int64(partNumber)),
+			LastModified: aws.Time(time.Unix(entry.Attributes.Mtime, 0).UTC()),
+			Size:         aws.Int64(int64(filer.FileSize(entry))),
+			ETag:         aws.String("\"" + filer.ETag(entry) + "\""),
+		})
+		output.NextPartNumberMarker = aws.Int64(int64(partNumber))
+	}
+	if !*output.IsTruncated {
+		output.NextPartNumberMarker = nil
+	}
 
-	output.IsTruncated = aws.Bool(!isLast)
+	return
+}
 
+// latestParts is the last uploaded entry of each part, in the order of the part numbers.
+// The same part can be uploaded more than once, e.g., retried by the clients, and the last one is completed.
+func latestParts(entries []*filer_pb.Entry) (parts []*filer_pb.Entry) {
+	latest := make(map[int]*filer_pb.Entry)
 	for _, entry := range entries {
-		if strings.HasSuffix(entry.Name, multipartExt) && !entry.IsDirectory {
-			partNumber, err := parsePartNumber(entry.Name)
-			if err != nil {
-				glog.Errorf("listObjectParts %s %s parse %s: %v", *input.Bucket, *input.UploadId, entry.Name, err)
-				continue
-			}
-			output.Part = append(output.Part, &s3.Part{
-				PartNumber:   aws.Int64(int64(partNumber)),
-				LastModified: aws.Time(time.Unix(entry.Attributes.Mtime, 0).UTC()),
-				Size:         aws.Int64(int64(filer.FileSize(entry))),
-				ETag:         aws.String("\"" + filer.ETag(entry) + "\""),
-			})
-			if !isLast {
-				output.NextPartNumberMarker = aws.Int64(int64(partNumber))
-			}
+		if entry.IsDirectory || !strings.HasSuffix(entry.Name, multipartExt) {
+			continue
+		}
+		partNumber, err := parsePartNumber(entry.Name)
+		if err != nil {
+			glog.Errorf("parse part number %s: %v", entry.Name, err)
+			continue
+		}
+		if found, ok := latest[partNumber]; !ok || partModifiedTsNs(entry) > partModifiedTsNs(found) {
+			latest[partNumber] = entry
 		}
 	}
-
+	partNumbers := make([]int, 0, len(latest))
+	for partNumber := range latest {
+		partNumbers = append(partNumbers, partNumber)
+	}
+	sort.Ints(partNumbers)
+	for _, partNumber := range partNumbers {
+		parts = append(parts, latest[partNumber])
+	}
 	return
 }
+
+func partModifiedTsNs(entry *filer_pb.Entry) int64 {
+	if len(entry.Chunks) > 0 {
+		return entry.Chunks[0].ModifiedTsNs
+	}
+	return entry.Attributes.Mtime * int64(time.Second)
+}
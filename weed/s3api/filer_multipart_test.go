@@ -3,6 +3,7 @@ package s3api
 import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -74,3 +75,70 @@ func Test_parsePartNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestPaginateMultipartUploads(t *testing.T) {
+	newUploads := func() []*s3.MultipartUpload {
+		var uploads []*s3.MultipartUpload
+		for _, ku := range [][2]string{{"b", "2"}, {"a", "1"}, {"b", "1"}, {"dir/x", "1"}, {"dir/y", "1"}, {"c", "1"}} {
+			uploads = append(uploads, &s3.MultipartUpload{Key: aws.String(ku[0]), UploadId: aws.String(ku[1])})
+		}
+		return uploads
+	}
+	keysOf := func(output *ListMultipartUploadsResult) (keys []string) {
+		for _, upload := range output.Upload {
+			keys = append(keys, *upload.Key+"/"+*upload.UploadId)
+		}
+		for _, prefix := range output.CommonPrefixes {
+			keys = append(keys, *prefix.Prefix)
+		}
+		return
+	}
+
+	output := paginateMultipartUploads(&s3.ListMultipartUploadsInput{MaxUploads: aws.Int64(3)}, newUploads())
+	assert.Equal(t, []string{"a/1", "b/1", "b/2"}, keysOf(output))
+	assert.True(t, *output.IsTruncated)
+	assert.Equal(t, "b", *output.NextKeyMarker)
+	assert.Equal(t, "2", *output.NextUploadIdMarker)
+
+	output = paginateMultipartUploads(&s3.ListMultipartUploadsInput{MaxUploads: aws.Int64(3),
+		KeyMarker: aws.String("b"), UploadIdMarker: aws.String("1")}, newUploads())
+	assert.Equal(t, []string{"b/2", "c/1", "dir/x/1"}, keysOf(output))
+
+	output = paginateMultipartUploads(&s3.ListMultipartUploadsInput{MaxUploads: aws.Int64(10),
+		KeyMarker: aws.String("b")}, newUploads())
+	assert.Equal(t, []string{"c/1", "dir/x/1", "dir/y/1"}, keysOf(output))
+	assert.False(t, *output.IsTruncated)
+	assert.Nil(t, output.NextKeyMarker)
+
+	output = paginateMultipartUploads(&s3.ListMultipartUploadsInput{MaxUploads: aws.Int64(10),
+		Delimiter: aws.String("/")}, newUploads())
+	assert.Equal(t, []string{"a/1", "b/1", "b/2", "c/1", "dir/"}, keysOf(output))
+
+	output = paginateMultipartUploads(&s3.ListMultipartUploadsInput{MaxUploads: aws.Int64(10),
+		Delimiter: aws.String("/"), KeyMarker: aws.String("dir/x")}, newUploads())
+	assert.Empty(t, keysOf(output))
+
+	output = paginateMultipartUploads(&s3.ListMultipartUploadsInput{MaxUploads: aws.Int64(10),
+		Prefix: aws.String("dir/"), Delimiter: aws.String("/")}, newUploads())
+	assert.Equal(t, []string{"dir/x/1", "dir/y/1"}, keysOf(output))
+}
+
+func TestLatestParts(t *testing.T) {
+	part := func(name string, modifiedTsNs int64) *filer_pb.Entry {
+		return &filer_pb.Entry{Name: name, Attributes: &filer_pb.FuseAttributes{},
+			Chunks: []*filer_pb.FileChunk{{ModifiedTsNs: modifiedTsNs}}}
+	}
+	parts := latestParts([]*filer_pb.Entry{
+		part("0010_a.part", 1),
+		part("0002_a.part", 2),
+		part("0002_b.part", 3),
+		part("0002_c.part", 1),
+		{Name: "dir", IsDirectory: true},
+		part("0001_a.part", 5),
+	})
+	var names []string
+	for _, p := range parts {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"0001_a.part", "0002_b.part", "0010_a.part"}, names)
+}
@@ -1,7 +1,59 @@
 package s3_constants
 
+import "github.com/seaweedfs/seaweedfs/weed/filer"
+
 const (
 	ExtAmzOwnerKey  = "Seaweed-X-Amz-Owner"
 	ExtAmzAclKey    = "Seaweed-X-Amz-Acl"
 	ExtOwnershipKey = "Seaweed-X-Amz-Ownership"
+
+	// ExtVersioningKey is the versioning status of a bucket, Enabled or Suspended
+	ExtVersioningKey = "Seaweed-X-Amz-Versioning"
+	// ExtVersionIdKey is the version id of an object in a versioned bucket, absent for the "null" version.
+	// It is named as the header, so the filer returns it with the object.
+	ExtVersionIdKey = filer.ExtVersionIdKey
+	// ExtDeleteMarkerKey marks a delete marker, kept as a version of the deleted object
+	ExtDeleteMarkerKey = AmzDeleteMarker
+
+	// ExtSsePrefix prefixes the server side encryption metadata of an object, saved by the filer from the headers
+	// of the s3 gateway and returned as the headers with the object
+	ExtSsePrefix = "Seaweed-X-Amz-Sse-"
+	// ExtSseAlgorithmKey is AES256 or aws:kms
+	ExtSseAlgorithmKey = ExtSsePrefix + "Algorithm"
+	// ExtSseKmsKeyIdKey is the kms key encrypting the data key
+	ExtSseKmsKeyIdKey = ExtSsePrefix + "Kms-Key-Id"
+	// ExtSseDataKeyKey is the data key encrypted by the kms, in base64
+	ExtSseDataKeyKey = ExtSsePrefix + "Data-Key"
+	// ExtSseNonceKey is the nonce of the object or the part upload in base64, present on all the encrypted objects
+	ExtSseNonceKey = ExtSsePrefix + "Nonce"
+	// ExtSseCustomerKeyMD5Key is the md5 of the customer provided key, in base64
+	ExtSseCustomerKeyMD5Key = ExtSsePrefix + "Customer-Key-Md5"
+	// ExtSsePartsKey is the part numbers, sizes and nonces in base64 of a completed multipart upload,
+	// e.g., "1:5242880:AAECAwQFBgc=,2:1024:CAkKCwwNDg8="
+	ExtSsePartsKey = ExtSsePrefix + "Parts"
+
+	// ExtReplicationKey is the replication configuration of a bucket, in json
+	ExtReplicationKey = "Seaweed-X-Amz-Replication"
+	// ExtReplicationStatusKey is the replication status of an object, PENDING, COMPLETED, FAILED or REPLICA.
+	// It is named as the header, so the filer returns it with the object.
+	ExtReplicationStatusKey = AmzReplicationStatus
+
+	// ExtNotificationKey is the notification configuration of a bucket, in json
+	ExtNotificationKey = "Seaweed-X-Amz-Notification"
+
+	// ExtLoggingKey is the logging configuration of a bucket, in json
+	ExtLoggingKey = "Seaweed-X-Amz-Logging"
+
+	// ExtBucketPolicyKey is the policy of a bucket, in json as it is put
+	ExtBucketPolicyKey = "Seaweed-X-Amz-Bucket-Policy"
+
+	// ExtObjectLockKey is the object lock configuration of a bucket, in json
+	ExtObjectLockKey = filer.ExtObjectLockKey
+	// ExtObjectLockModeKey is the retention mode of an object, GOVERNANCE or COMPLIANCE.
+	// The object lock of an object is named as the headers, so the filer returns it with the object.
+	ExtObjectLockModeKey = filer.ExtObjectLockModeKey
+	// ExtObjectLockRetainUntilDateKey is when the retention of an object expires, in RFC3339
+	ExtObjectLockRetainUntilDateKey = filer.ExtObjectLockRetainUntilDateKey
+	// ExtObjectLockLegalHoldKey is the legal hold of an object, ON or OFF
+	ExtObjectLockLegalHoldKey = filer.ExtObjectLockLegalHoldKey
 )
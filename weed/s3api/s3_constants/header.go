@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 )
 
 // Standard S3 HTTP request constants
@@ -35,7 +36,7 @@ const (
 
 	// S3 object tagging
 	AmzObjectTagging          = "X-Amz-Tagging"
-	AmzObjectTaggingPrefix    = "X-Amz-Tagging-"
+	AmzObjectTaggingPrefix    = filer.ExtTaggingPrefix
 	AmzObjectTaggingDirective = "X-Amz-Tagging-Directive"
 	AmzTagCount               = "x-amz-tagging-count"
 
@@ -52,6 +53,42 @@ const (
 	AmzAclWriteAcp    = "X-Amz-Grant-Write-Acp"
 
 	AmzMpPartsCount = "X-Amz-Mp-Parts-Count"
+
+	// S3 copy source, of the objects and the parts copied
+	AmzCopySourceRange             = "X-Amz-Copy-Source-Range"
+	AmzCopySourceVersionId         = "X-Amz-Copy-Source-Version-Id"
+	AmzCopySourceIfMatch           = "X-Amz-Copy-Source-If-Match"
+	AmzCopySourceIfNoneMatch       = "X-Amz-Copy-Source-If-None-Match"
+	AmzCopySourceIfModifiedSince   = "X-Amz-Copy-Source-If-Modified-Since"
+	AmzCopySourceIfUnmodifiedSince = "X-Amz-Copy-Source-If-Unmodified-Since"
+
+	// S3 object lock, for the locked objects and the files in the filer worm locations
+	AmzObjectLockMode            = "X-Amz-Object-Lock-Mode"
+	AmzObjectLockRetainUntilDate = "X-Amz-Object-Lock-Retain-Until-Date"
+	AmzObjectLockLegalHold       = "X-Amz-Object-Lock-Legal-Hold"
+	AmzBucketObjectLockEnabled   = "X-Amz-Bucket-Object-Lock-Enabled"
+	AmzBypassGovernanceRetention = "X-Amz-Bypass-Governance-Retention"
+	// the values of the object lock headers
+	ObjectLockModeGovernance = filer.ObjectLockModeGovernance
+	ObjectLockModeCompliance = filer.ObjectLockModeCompliance
+	ObjectLockLegalHoldOn    = filer.ObjectLockLegalHoldOn
+
+	// S3 object versioning
+	AmzVersionId    = "X-Amz-Version-Id"
+	AmzDeleteMarker = "X-Amz-Delete-Marker"
+
+	// S3 server side encryption
+	AmzServerSideEncryption                            = "X-Amz-Server-Side-Encryption"
+	AmzServerSideEncryptionAwsKmsKeyId                 = "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"
+	AmzServerSideEncryptionCustomerAlgorithm           = "X-Amz-Server-Side-Encryption-Customer-Algorithm"
+	AmzServerSideEncryptionCustomerKey                 = "X-Amz-Server-Side-Encryption-Customer-Key"
+	AmzServerSideEncryptionCustomerKeyMD5              = "X-Amz-Server-Side-Encryption-Customer-Key-Md5"
+	AmzCopySourceServerSideEncryptionCustomerAlgorithm = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Algorithm"
+	AmzCopySourceServerSideEncryptionCustomerKey       = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key"
+	AmzCopySourceServerSideEncryptionCustomerKeyMD5    = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key-Md5"
+
+	// S3 bucket replication
+	AmzReplicationStatus = "X-Amz-Replication-Status"
 )
 
 // Non-Standard S3 HTTP request constants
@@ -10,7 +10,11 @@ const (
 	ACTION_LIST          = "List"
 	ACTION_DELETE_BUCKET = "DeleteBucket"
 
+	ACTION_BYPASS_GOVERNANCE_RETENTION = "BypassGovernanceRetention"
+
 	SeaweedStorageDestinationHeader = "x-seaweedfs-destination"
-	MultipartUploadsFolder          = ".uploads"
-	FolderMimeType                  = "httpd/unix-directory"
+	// SeaweedPlaintextMd5Header is the trailer with the base64 md5 of the object before the s3 gateway encrypts it
+	SeaweedPlaintextMd5Header = "x-seaweedfs-plaintext-md5"
+	MultipartUploadsFolder    = ".uploads"
+	FolderMimeType            = "httpd/unix-directory"
 )
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
 )
 
@@ -120,6 +122,7 @@ func (s3a *S3ApiServer) PutBucketHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	isObjectLockEnabled := strings.EqualFold(r.Header.Get(s3_constants.AmzBucketObjectLockEnabled), "true")
 	fn := func(entry *filer_pb.Entry) {
 		if identityId := r.Header.Get(s3_constants.AmzIdentityId); identityId != "" {
 			if entry.Extended == nil {
@@ -127,6 +130,21 @@ func (s3a *S3ApiServer) PutBucketHandler(w http.ResponseWriter, r *http.Request)
 			}
 			entry.Extended[s3_constants.AmzIdentityId] = []byte(identityId)
 		}
+		if isObjectLockEnabled {
+			if entry.Extended == nil {
+				entry.Extended = make(map[string][]byte)
+			}
+			enableObjectLock(entry.Extended)
+		}
+	}
+
+	// the bucket with the object lock keeps the previous versions of its objects from the start
+	if isObjectLockEnabled {
+		if err := s3a.keepBucketVersions(bucket); err != nil {
+			glog.Errorf("PutBucketHandler %s: %v", bucket, err)
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
 	}
 
 	// create the folder for bucket, but lazily create actual collection
@@ -149,8 +167,11 @@ func (s3a *S3ApiServer) DeleteBucketHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// the bucket with the object lock is only deleted without any objects or previous versions
+	isObjectLockEnabled := s3a.getObjectLockConfiguration(bucket) != nil
+	versionsDir := string(filer.VersionsDirOf(util.FullPath(s3a.option.BucketsPath)))
 	err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
-		if !s3a.option.AllowDeleteBucketNotEmpty {
+		if !s3a.option.AllowDeleteBucketNotEmpty || isObjectLockEnabled {
 			entries, _, err := s3a.list(s3a.option.BucketsPath+"/"+bucket, "", "", false, 2)
 			if err != nil {
 				return fmt.Errorf("failed to list bucket %s: %v", bucket, err)
@@ -161,15 +182,16 @@ func (s3a *S3ApiServer) DeleteBucketHandler(w http.ResponseWriter, r *http.Reque
 				}
 			}
 		}
-
-		// delete collection
-		deleteCollectionRequest := &filer_pb.DeleteCollectionRequest{
-			Collection: s3a.getCollectionName(bucket),
-		}
-
-		glog.V(1).Infof("delete collection: %v", deleteCollectionRequest)
-		if _, err := client.DeleteCollection(context.Background(), deleteCollectionRequest); err != nil {
-			return fmt.Errorf("delete collection %s: %v", bucket, err)
+		if isObjectLockEnabled {
+			hasVersions := false
+			if err := filer_pb.TraverseBfs(s3a, util.NewFullPath(versionsDir, bucket), func(parentPath util.FullPath, entry *filer_pb.Entry) {
+				hasVersions = hasVersions || !entry.IsDirectory
+			}); err != nil && !strings.Contains(err.Error(), filer_pb.ErrNotFound.Error()) {
+				return fmt.Errorf("failed to list versions of bucket %s: %v", bucket, err)
+			}
+			if hasVersions {
+				return errors.New(s3err.GetAPIError(s3err.ErrBucketNotEmpty).Code)
+			}
 		}
 
 		return nil
@@ -184,16 +206,91 @@ func (s3a *S3ApiServer) DeleteBucketHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = s3a.rm(s3a.option.BucketsPath, bucket, false, true)
+	// the filer drops the bucket collections not shared with the other buckets, and deletes the shared chunks
+	err = s3a.rm(s3a.option.BucketsPath, bucket, true, true)
 
 	if err != nil {
 		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
 		return
 	}
 
+	// the previous versions of the objects go with the bucket
+	if err = s3a.rm(versionsDir, bucket, true, true); err != nil && !strings.Contains(err.Error(), filer_pb.ErrNotFound.Error()) {
+		glog.Errorf("DeleteBucketHandler delete versions of %s: %v", bucket, err)
+	}
+	if err = s3a.forgetBucketVersions(bucket); err != nil {
+		glog.Errorf("DeleteBucketHandler %s: %v", bucket, err)
+	}
+
 	s3err.WriteEmptyResponse(w, r, http.StatusNoContent)
 }
 
+// RenameBucketHandler renames the bucket without copying the objects, for "PUT /bucket?rename=newBucket".
+// This is a SeaweedFS extension. The objects keep their chunks in the collection of the old bucket name.
+func (s3a *S3ApiServer) RenameBucketHandler(w http.ResponseWriter, r *http.Request) {
+
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	newBucket := r.URL.Query().Get("rename")
+	glog.V(3).Infof("RenameBucketHandler %s => %s", bucket, newBucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+	if err := s3bucket.VerifyS3BucketName(newBucket); err != nil {
+		glog.Errorf("rename to invalid bucket name: %v %v", newBucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidBucketName)
+		return
+	}
+	if exist, err := s3a.exists(s3a.option.BucketsPath, newBucket, true); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	} else if exist {
+		s3err.WriteErrorResponse(w, r, s3err.ErrBucketAlreadyExists)
+		return
+	}
+
+	if err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.AtomicRenameEntry(context.Background(), &filer_pb.AtomicRenameEntryRequest{
+			OldDirectory: s3a.option.BucketsPath,
+			OldName:      bucket,
+			NewDirectory: s3a.option.BucketsPath,
+			NewName:      newBucket,
+		})
+		return err
+	}); err != nil {
+		glog.Errorf("RenameBucketHandler %s => %s: %v", bucket, newBucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	// the bucket rules in filer.conf follow the bucket
+	fc, err := filer.ReadFilerConf(s3a.filers.Current(), s3a.option.GrpcDialOption, nil)
+	if err != nil {
+		glog.Errorf("RenameBucketHandler read filer config: %s", err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	if fc.MoveLocationConfs(s3a.option.BucketsPath+"/"+bucket+"/", s3a.option.BucketsPath+"/"+newBucket+"/", s3a.getCollectionName(bucket), s3a.getCollectionName(newBucket)) {
+		var buf bytes.Buffer
+		if err := fc.ToText(&buf); err != nil {
+			glog.Errorf("RenameBucketHandler save config to text: %s", err)
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
+		if err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			return filer.SaveInsideFiler(client, filer.DirectoryEtcSeaweedFS, filer.FilerConfName, buf.Bytes())
+		}); err != nil {
+			glog.Errorf("RenameBucketHandler save config inside filer: %s", err)
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
+	}
+
+	w.Header().Set("Location", "/"+newBucket)
+	writeSuccessResponseEmpty(w, r)
+}
+
 func (s3a *S3ApiServer) HeadBucketHandler(w http.ResponseWriter, r *http.Request) {
 
 	bucket, _ := s3_constants.GetBucketAndObject(r)
@@ -316,37 +413,61 @@ func (s3a *S3ApiServer) GetBucketLifecycleConfigurationHandler(w http.ResponseWr
 		s3err.WriteErrorResponse(w, r, err)
 		return
 	}
-	fc, err := filer.ReadFilerConf(s3a.option.Filer, s3a.option.GrpcDialOption, nil)
+	fc, err := filer.ReadFilerConf(s3a.filers.Current(), s3a.option.GrpcDialOption, nil)
 	if err != nil {
 		glog.Errorf("GetBucketLifecycleConfigurationHandler: %s", err)
 		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
 		return
 	}
-	ttls := fc.GetCollectionTtls(s3a.getCollectionName(bucket))
-	if len(ttls) == 0 {
-		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchLifecycleConfiguration)
-		return
+	bucketPrefix := fmt.Sprintf("%s/%s/", s3a.option.BucketsPath, bucket)
+	rules := make(map[string]*Rule)
+	ruleOf := func(prefix string) *Rule {
+		rule, found := rules[prefix]
+		if !found {
+			rule = &Rule{
+				ID:     prefix,
+				Status: Enabled,
+				Prefix: Prefix{val: prefix, set: true},
+			}
+			rules[prefix] = rule
+		}
+		return rule
 	}
-
-	response := Lifecycle{}
+	ttls := fc.GetCollectionTtls(s3a.getCollectionName(bucket))
 	for locationPrefix, internalTtl := range ttls {
 		ttl, _ := needle.ReadTTL(internalTtl)
 		days := int(ttl.Minutes() / 60 / 24)
 		if days == 0 {
 			continue
 		}
-		prefix, found := strings.CutPrefix(locationPrefix, fmt.Sprintf("%s/%s/", s3a.option.BucketsPath, bucket))
+		prefix, found := strings.CutPrefix(locationPrefix, bucketPrefix)
 		if !found {
 			continue
 		}
-		response.Rules = append(response.Rules, Rule{
-			ID:         prefix,
-			Status:     Enabled,
-			Prefix:     Prefix{val: prefix, set: true},
-			Expiration: Expiration{Days: days, set: true},
-		})
+		ruleOf(prefix).Expiration = Expiration{Days: days, set: true}
+	}
+	// the previous versions are kept for version_retention_seconds after they become noncurrent
+	for _, locConf := range fc.ToProto().Locations {
+		prefix, found := strings.CutPrefix(locConf.LocationPrefix, bucketPrefix)
+		if !found || locConf.VersionRetentionSeconds < 24*3600 {
+			continue
+		}
+		days := int(locConf.VersionRetentionSeconds / (24 * 3600))
+		ruleOf(prefix).NoncurrentVersionExpiration = NoncurrentVersionExpiration{NoncurrentDays: days, set: true}
+	}
+	if len(rules) == 0 {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchLifecycleConfiguration)
+		return
 	}
 
+	response := Lifecycle{}
+	for _, rule := range rules {
+		response.Rules = append(response.Rules, *rule)
+	}
+	sort.Slice(response.Rules, func(i, j int) bool {
+		return response.Rules[i].ID < response.Rules[j].ID
+	})
+
 	writeSuccessResponseXML(w, r, response)
 }
 
@@ -369,7 +490,7 @@ func (s3a *S3ApiServer) PutBucketLifecycleConfigurationHandler(w http.ResponseWr
 		return
 	}
 
-	fc, err := filer.ReadFilerConf(s3a.option.Filer, s3a.option.GrpcDialOption, nil)
+	fc, err := filer.ReadFilerConf(s3a.filers.Current(), s3a.option.GrpcDialOption, nil)
 	if err != nil {
 		glog.Errorf("PutBucketLifecycleConfigurationHandler read filer config: %s", err)
 		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
@@ -394,6 +515,23 @@ func (s3a *S3ApiServer) PutBucketLifecycleConfigurationHandler(w http.ResponseWr
 			return
 		}
 
+		if days := rule.NoncurrentVersionExpiration.NoncurrentDays; days > 0 {
+			locationPrefix := fmt.Sprintf("%s/%s/%s", s3a.option.BucketsPath, bucket, rulePrefix)
+			retentionSeconds := uint64(days) * 24 * 3600
+			if locConf, found := fc.GetLocationConf(locationPrefix); !found || locConf.VersionRetentionSeconds != retentionSeconds {
+				if err := fc.AddLocationConf(&filer_pb.FilerConf_PathConf{
+					LocationPrefix:          locationPrefix,
+					Collection:              collectionName,
+					VersionRetentionSeconds: retentionSeconds,
+				}); err != nil {
+					glog.Errorf("PutBucketLifecycleConfigurationHandler add location config: %s", err)
+					s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+					return
+				}
+				changed = true
+			}
+		}
+
 		if rule.Expiration.Days == 0 {
 			continue
 		}
@@ -444,16 +582,16 @@ func (s3a *S3ApiServer) DeleteBucketLifecycleHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	fc, err := filer.ReadFilerConf(s3a.option.Filer, s3a.option.GrpcDialOption, nil)
+	fc, err := filer.ReadFilerConf(s3a.filers.Current(), s3a.option.GrpcDialOption, nil)
 	if err != nil {
 		glog.Errorf("DeleteBucketLifecycleHandler read filer config: %s", err)
 		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
 		return
 	}
 	collectionTtls := fc.GetCollectionTtls(s3a.getCollectionName(bucket))
+	bucketPrefix := fmt.Sprintf("%s/%s/", s3a.option.BucketsPath, bucket)
 	changed := false
 	for prefix, ttl := range collectionTtls {
-		bucketPrefix := fmt.Sprintf("%s/%s/", s3a.option.BucketsPath, bucket)
 		if strings.HasPrefix(prefix, bucketPrefix) && strings.HasSuffix(ttl, "d") {
 			pathConf, found := fc.GetLocationConf(prefix)
 			if found {
@@ -463,6 +601,13 @@ func (s3a *S3ApiServer) DeleteBucketLifecycleHandler(w http.ResponseWriter, r *h
 			changed = true
 		}
 	}
+	for _, pathConf := range fc.ToProto().Locations {
+		if strings.HasPrefix(pathConf.LocationPrefix, bucketPrefix) && pathConf.VersionRetentionSeconds > 0 {
+			pathConf.VersionRetentionSeconds = 0
+			fc.SetLocationConf(pathConf)
+			changed = true
+		}
+	}
 
 	if changed {
 		var buf bytes.Buffer
@@ -668,9 +813,80 @@ func (s3a *S3ApiServer) GetBucketVersioningHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	versioningConfiguration := &s3.VersioningConfiguration{}
+	if status := s3a.getVersioningStatus(bucket); status != "" {
+		versioningConfiguration.Status = aws.String(status)
+	}
 	s3err.WriteAwsXMLResponse(w, r, http.StatusOK, &s3.PutBucketVersioningInput{
-		VersioningConfiguration: &s3.VersioningConfiguration{
-			Status: aws.String(s3.BucketVersioningStatusSuspended),
-		},
+		VersioningConfiguration: versioningConfiguration,
 	})
 }
+
+// PutBucketVersioningHandler Put bucket Versioning
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
+func (s3a *S3ApiServer) PutBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutBucketVersioning %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	var v s3.VersioningConfiguration
+	defer util_http.CloseRequest(r)
+
+	if err := xmlutil.UnmarshalXML(&v, xml.NewDecoder(r.Body), ""); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	status := aws.StringValue(v.Status)
+	if status == s3.BucketVersioningStatusSuspended && s3a.getObjectLockConfiguration(bucket) != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidBucketState)
+		return
+	}
+	switch status {
+	case s3.BucketVersioningStatusEnabled:
+		// the filer keeps all the previous versions of the objects in the bucket
+		if err := s3a.keepBucketVersions(bucket); err != nil {
+			glog.Errorf("PutBucketVersioning %s: %v", bucket, err)
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
+	case s3.BucketVersioningStatusSuspended:
+	default:
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		if err == filer_pb.ErrNotFound {
+			s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
+			return
+		}
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	if oldStatus, ok := bucketEntry.Extended[s3_constants.ExtVersioningKey]; !ok || string(oldStatus) != status {
+		if bucketEntry.Extended == nil {
+			bucketEntry.Extended = make(map[string][]byte)
+		}
+		bucketEntry.Extended[s3_constants.ExtVersioningKey] = []byte(status)
+		if err = s3a.updateEntry(s3a.option.BucketsPath, bucketEntry); err != nil {
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
+		// the following writes to this s3 server see the new status right away
+		s3a.bucketRegistry.LoadBucketMetadata(bucketEntry)
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
@@ -0,0 +1,386 @@
+package s3api
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/xml/xmlutil"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+// The requests to the buckets with the logging enabled are logged in the S3 server access log format.
+// The records are written to the target bucket as the log objects named by the target prefix and the time,
+// e.g., "logs/2024-01-02-03-04-05-0123456789ABCDEF", rolled every few minutes or when large enough.
+// The target can also be a SeaweedFS MQ topic, named by its arn as the bucket notifications,
+// where each record is published as a message keyed by the source bucket:
+//
+//	<LoggingEnabled><TargetBucket>arn:seaweed:mq:::namespace/topic</TargetBucket><TargetPrefix/></LoggingEnabled>
+//
+// The records are dropped if the writing falls behind.
+
+const (
+	accessLogRollInterval = 5 * time.Minute
+	accessLogMaxSize      = 1024 * 1024 // the log objects are stored inline in the filer
+	accessLogQueueSize    = 4096
+)
+
+// GetBucketLoggingHandler Get bucket Logging
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLogging.html
+func (s3a *S3ApiServer) GetBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetBucketLogging %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	// an empty status if not logged
+	s3err.WriteAwsXMLResponse(w, r, http.StatusOK, &s3.PutBucketLoggingInput{
+		BucketLoggingStatus: &s3.BucketLoggingStatus{LoggingEnabled: metadata.Logging},
+	})
+}
+
+// PutBucketLoggingHandler Put bucket Logging
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLogging.html
+func (s3a *S3ApiServer) PutBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutBucketLogging %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	var status s3.BucketLoggingStatus
+	defer util_http.CloseRequest(r)
+
+	if err := xmlutil.UnmarshalXML(&status, xml.NewDecoder(r.Body), ""); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	// an empty status turns off the logging
+	var configBytes []byte
+	if status.LoggingEnabled != nil {
+		if errCode := s3a.validateLogging(status.LoggingEnabled); errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+		var err error
+		if configBytes, err = json.Marshal(status.LoggingEnabled); err != nil {
+			glog.Errorf("PutBucketLogging %s: %v", bucket, err)
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
+	}
+	if errCode := s3a.updateBucketExtended(bucket, func(extended map[string][]byte) {
+		if configBytes == nil {
+			delete(extended, s3_constants.ExtLoggingKey)
+		} else {
+			extended[s3_constants.ExtLoggingKey] = configBytes
+		}
+	}); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+func (s3a *S3ApiServer) validateLogging(logging *s3.LoggingEnabled) s3err.ErrorCode {
+	targetBucket := aws.StringValue(logging.TargetBucket)
+	if strings.HasPrefix(targetBucket, notificationTopicArnPrefix) {
+		if _, ok := notificationTopicOf(targetBucket); !ok {
+			return s3err.ErrInvalidTargetBucketForLogging
+		}
+		return s3err.ErrNone
+	}
+	if targetBucket == "" {
+		return s3err.ErrInvalidTargetBucketForLogging
+	}
+	if entry, err := s3a.getEntry(s3a.option.BucketsPath, targetBucket); err != nil || !entry.IsDirectory {
+		return s3err.ErrInvalidTargetBucketForLogging
+	}
+	return s3err.ErrNone
+}
+
+// ParseLoggingConfiguration parses the logging configuration saved in the bucket
+func ParseLoggingConfiguration(data []byte) (*s3.LoggingEnabled, error) {
+	logging := &s3.LoggingEnabled{}
+	if err := json.Unmarshal(data, logging); err != nil {
+		return nil, err
+	}
+	return logging, nil
+}
+
+// accessLogRecorder records the response of the request for the access log
+type accessLogRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytesSent   int64
+	firstByteAt time.Time
+}
+
+func (r *accessLogRecorder) WriteHeader(status int) {
+	if r.firstByteAt.IsZero() {
+		r.firstByteAt = time.Now()
+	}
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *accessLogRecorder) Write(p []byte) (int, error) {
+	if r.firstByteAt.IsZero() {
+		r.firstByteAt = time.Now()
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytesSent += int64(n)
+	return n, err
+}
+
+func (r *accessLogRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// accessLogging logs the requests to the buckets with the logging enabled
+func (s3a *S3ApiServer) accessLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &accessLogRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		bucket := mux.Vars(r)["bucket"]
+		if s3a.accessLogger == nil || s3a.bucketRegistry == nil || bucket == "" {
+			return
+		}
+		metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+		if errCode != s3err.ErrNone || metadata.Logging == nil {
+			return
+		}
+		bucketOwner := ""
+		if metadata.Owner != nil {
+			bucketOwner = aws.StringValue(metadata.Owner.ID)
+		}
+		line := formatAccessLogRecord(r, recorder, bucketOwner, bucket, start)
+		s3a.accessLogger.log(metadata.Logging, bucket, line)
+	})
+}
+
+// formatAccessLogRecord formats the request in the S3 server access log format
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/LogFormat.html
+func formatAccessLogRecord(r *http.Request, recorder *accessLogRecorder, bucketOwner, bucket string, start time.Time) string {
+	accessLog := s3err.GetAccessLog(r, recorder.status, s3err.ErrNone)
+	key := ""
+	if _, object := s3_constants.GetBucketAndObject(r); object != "/" {
+		key = strings.ReplaceAll(url.PathEscape(strings.TrimPrefix(object, "/")), "%2F", "/")
+	}
+	remoteIP := accessLog.RemoteIP
+	if host, _, err := net.SplitHostPort(remoteIP); err == nil {
+		remoteIP = host
+	}
+	turnAroundTime := ""
+	if !recorder.firstByteAt.IsZero() {
+		turnAroundTime = strconv.FormatInt(recorder.firstByteAt.Sub(start).Milliseconds(), 10)
+	}
+	objectSize := ""
+	if r.ContentLength > 0 && (r.Method == http.MethodPut || r.Method == http.MethodPost) {
+		objectSize = strconv.FormatInt(r.ContentLength, 10)
+	}
+	cipherSuite, tlsVersion := "", ""
+	if r.TLS != nil {
+		cipherSuite = tls.CipherSuiteName(r.TLS.CipherSuite)
+		tlsVersion = strings.Replace(tls.VersionName(r.TLS.Version), "TLS 1", "TLSv1", 1)
+	}
+	authType := ""
+	if r.Header.Get("Authorization") != "" {
+		authType = "AuthHeader"
+	} else if r.URL.Query().Get("X-Amz-Signature") != "" || r.URL.Query().Get("Signature") != "" {
+		authType = "QueryString"
+	}
+
+	fields := []string{
+		orDash(bucketOwner),
+		orDash(bucket),
+		start.UTC().Format("[02/Jan/2006:15:04:05 +0000]"),
+		orDash(remoteIP),
+		orDash(accessLog.Requester),
+		orDash(recorder.Header().Get("x-amz-request-id")),
+		orDash(accessLog.Operation),
+		orDash(key),
+		strconv.Quote(fmt.Sprintf("%s %s %s", r.Method, r.RequestURI, r.Proto)),
+		strconv.Itoa(recorder.status),
+		"-", // the error code is only in the response body
+		strconv.FormatInt(recorder.bytesSent, 10),
+		orDash(objectSize),
+		strconv.FormatInt(time.Since(start).Milliseconds(), 10),
+		orDash(turnAroundTime),
+		strconv.Quote(orDash(r.Referer())),
+		strconv.Quote(orDash(r.UserAgent())),
+		orDash(r.URL.Query().Get("versionId")),
+		orDash(accessLog.HostId),
+		orDash(accessLog.SignatureVersion),
+		orDash(cipherSuite),
+		orDash(authType),
+		orDash(accessLog.HostHeader),
+		orDash(tlsVersion),
+	}
+	return strings.Join(fields, " ") + "\n"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+type accessLogTarget struct {
+	bucket string
+	prefix string
+}
+
+type accessLogRecord struct {
+	target accessLogTarget
+	source string
+	line   string
+}
+
+// bucketAccessLogger writes the access log records in the background
+type bucketAccessLogger struct {
+	records   chan *accessLogRecord
+	startOnce sync.Once
+	buffers   map[accessLogTarget]*bytes.Buffer // only accessed by the writing loop
+
+	// writes the log object to the target bucket
+	writeObject func(target accessLogTarget, name string, data []byte) error
+	// publishes the record to the mq topic
+	publish func(t topic.Topic, key, value []byte)
+}
+
+func newBucketAccessLogger(writeObject func(target accessLogTarget, name string, data []byte) error, publish func(t topic.Topic, key, value []byte)) *bucketAccessLogger {
+	return &bucketAccessLogger{
+		records:     make(chan *accessLogRecord, accessLogQueueSize),
+		buffers:     make(map[accessLogTarget]*bytes.Buffer),
+		writeObject: writeObject,
+		publish:     publish,
+	}
+}
+
+func (l *bucketAccessLogger) log(logging *s3.LoggingEnabled, source, line string) {
+	l.startOnce.Do(func() {
+		go l.loopWrite()
+	})
+	target := accessLogTarget{bucket: aws.StringValue(logging.TargetBucket), prefix: aws.StringValue(logging.TargetPrefix)}
+	select {
+	case l.records <- &accessLogRecord{target: target, source: source, line: line}:
+	default:
+		glog.Warningf("drop access log of bucket %s to %s: too many pending records", source, target.bucket)
+	}
+}
+
+func (l *bucketAccessLogger) loopWrite() {
+	ticker := time.NewTicker(accessLogRollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case record := <-l.records:
+			l.add(record)
+		case <-ticker.C:
+			l.flushAll()
+		}
+	}
+}
+
+func (l *bucketAccessLogger) add(record *accessLogRecord) {
+	if t, ok := notificationTopicOf(record.target.bucket); ok {
+		l.publish(t, []byte(record.source), []byte(record.line))
+		return
+	}
+	buffer, found := l.buffers[record.target]
+	if !found {
+		buffer = &bytes.Buffer{}
+		l.buffers[record.target] = buffer
+	}
+	buffer.WriteString(record.line)
+	if buffer.Len() >= accessLogMaxSize {
+		l.flush(record.target, buffer)
+	}
+}
+
+func (l *bucketAccessLogger) flushAll() {
+	for target, buffer := range l.buffers {
+		l.flush(target, buffer)
+	}
+}
+
+func (l *bucketAccessLogger) flush(target accessLogTarget, buffer *bytes.Buffer) {
+	if buffer.Len() == 0 {
+		delete(l.buffers, target)
+		return
+	}
+	if err := l.writeObject(target, accessLogObjectName(target.prefix, time.Now()), buffer.Bytes()); err != nil {
+		// kept to be written with the next roll
+		glog.Errorf("write access log to bucket %s: %v", target.bucket, err)
+		if buffer.Len() < 2*accessLogMaxSize {
+			return
+		}
+		glog.Warningf("drop %d bytes of access log to bucket %s", buffer.Len(), target.bucket)
+	}
+	buffer.Reset()
+}
+
+// accessLogObjectName is the key of the log object, e.g., "logs/2024-01-02-03-04-05-0123456789ABCDEF"
+func accessLogObjectName(prefix string, now time.Time) string {
+	unique := make([]byte, 8)
+	_, _ = rand.Read(unique)
+	return prefix + now.UTC().Format("2006-01-02-15-04-05") + "-" + strings.ToUpper(hex.EncodeToString(unique))
+}
+
+// writeAccessLogObject saves the log object into the target bucket
+func (s3a *S3ApiServer) writeAccessLogObject(target accessLogTarget, name string, data []byte) error {
+	dir, fileName := s3a.objectPath(target.bucket, "/"+name).DirAndName()
+	md5Sum := md5.Sum(data)
+	content := append([]byte(nil), data...)
+	return s3a.mkFile(dir, fileName, nil, func(entry *filer_pb.Entry) {
+		entry.Content = content
+		entry.Attributes.FileSize = uint64(len(content))
+		entry.Attributes.FileMode = uint32(0660)
+		entry.Attributes.Mime = "text/plain"
+		entry.Attributes.Md5 = md5Sum[:]
+	})
+}
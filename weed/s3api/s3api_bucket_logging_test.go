@@ -0,0 +1,108 @@
+package s3api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+)
+
+func TestFormatAccessLogRecord(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/b/dir/a%20b.txt?versionId=v1", nil)
+	r = mux.SetURLVars(r, map[string]string{"bucket": "b", "object": "dir/a b.txt"})
+	r.RemoteAddr = "192.0.2.3:4567"
+	r.Header.Set(s3_constants.AmzIdentityId, "u1")
+	r.Header.Set(s3_constants.AmzAuthType, "SigV4")
+	r.Header.Set("Authorization", "AWS4-HMAC-SHA256 ...")
+	r.Header.Set("User-Agent", "aws-cli/2")
+
+	recorder := &accessLogRecorder{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	recorder.Header().Set("x-amz-request-id", "123")
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	recorder.WriteHeader(http.StatusPartialContent)
+	_, _ = recorder.Write([]byte("0123456789"))
+
+	line := formatAccessLogRecord(r, recorder, "owner", "b", start)
+	assert.True(t, strings.HasSuffix(line, "\n"))
+	assert.True(t, strings.HasPrefix(line, `owner b [02/Jan/2024:03:04:05 +0000] 192.0.2.3 u1 123 REST.GET.OBJECT dir/a%20b.txt "GET /b/dir/a%20b.txt?versionId=v1 HTTP/1.1" 206 - 10 - `), line)
+	assert.Contains(t, line, ` "-" "aws-cli/2" v1 `)
+	assert.True(t, strings.HasSuffix(line, " SigV4 - AuthHeader example.com -\n"), line)
+}
+
+func TestBucketAccessLogger(t *testing.T) {
+	written := make(map[string]string)
+	var published []string
+	logger := newBucketAccessLogger(func(target accessLogTarget, name string, data []byte) error {
+		written[target.bucket+"/"+name] = string(data)
+		return nil
+	}, func(t topic.Topic, key, value []byte) {
+		published = append(published, fmt.Sprintf("%s %s %s", t, key, value))
+	})
+	record := func(targetBucket, source, line string) *accessLogRecord {
+		return &accessLogRecord{target: accessLogTarget{bucket: targetBucket, prefix: "logs/"}, source: source, line: line}
+	}
+
+	logger.add(record("logs", "b1", "line1\n"))
+	logger.add(record("logs", "b2", "line2\n"))
+	logger.add(record(notificationTopicArnPrefix+"ns/access", "b1", "line3\n"))
+	assert.Empty(t, written)
+	assert.Equal(t, []string{"ns.access b1 line3\n"}, published)
+
+	logger.flushAll()
+	assert.Len(t, written, 1)
+	for name, data := range written {
+		assert.Regexp(t, regexp.MustCompile(`^logs/logs/\d{4}-\d{2}-\d{2}-\d{2}-\d{2}-\d{2}-[0-9A-F]{16}$`), name)
+		assert.Equal(t, "line1\nline2\n", data)
+	}
+
+	// the empty buffers are not written
+	logger.flushAll()
+	assert.Len(t, written, 1)
+	assert.Empty(t, logger.buffers)
+
+	// rolled when large enough
+	logger.add(record("logs", "b1", strings.Repeat("x", accessLogMaxSize)))
+	assert.Len(t, written, 2)
+}
+
+func TestAccessLogging(t *testing.T) {
+	s3a := &S3ApiServer{bucketRegistry: &BucketRegistry{
+		metadataCache: map[string]*BucketMetaData{
+			"logged": {Name: "logged", Owner: &s3.Owner{ID: aws.String("admin")},
+				Logging: &s3.LoggingEnabled{TargetBucket: aws.String("logs"), TargetPrefix: aws.String("logged/")}},
+			"plain": {Name: "plain"},
+		},
+		notFound: make(map[string]struct{}),
+	}}
+	s3a.accessLogger = newBucketAccessLogger(nil, nil)
+	// the records are kept in the queue without writing
+	s3a.accessLogger.startOnce.Do(func() {})
+
+	handler := s3a.accessLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	for _, bucket := range []string{"plain", "logged"} {
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/"+bucket+"/a", nil), map[string]string{"bucket": bucket, "object": "a"})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, "ok", w.Body.String())
+	}
+
+	if assert.Len(t, s3a.accessLogger.records, 1) {
+		record := <-s3a.accessLogger.records
+		assert.Equal(t, accessLogTarget{bucket: "logs", prefix: "logged/"}, record.target)
+		assert.Equal(t, "logged", record.source)
+		assert.True(t, strings.HasPrefix(record.line, "admin logged "), record.line)
+	}
+}
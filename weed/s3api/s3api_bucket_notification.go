@@ -0,0 +1,445 @@
+package s3api
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/xml/xmlutil"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mq/client/pub_client"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+// The object events are published as the json records of the S3 event notifications to the SeaweedFS MQ topics,
+// keyed by the bucket and the object key. The topic of a configuration is named by its arn:
+//
+//	<TopicConfiguration><Topic>arn:seaweed:mq:::namespace/topic</Topic><Event>s3:ObjectCreated:*</Event></TopicConfiguration>
+//
+// The brokers are discovered from the masters. The events are published in the background,
+// and dropped if the brokers fall behind.
+
+const (
+	notificationTopicArnPrefix = "arn:seaweed:mq:::"
+
+	s3EventObjectCreatedPut                     = "ObjectCreated:Put"
+	s3EventObjectCreatedPost                    = "ObjectCreated:Post"
+	s3EventObjectCreatedCopy                    = "ObjectCreated:Copy"
+	s3EventObjectCreatedCompleteMultipartUpload = "ObjectCreated:CompleteMultipartUpload"
+	s3EventObjectRemovedDelete                  = "ObjectRemoved:Delete"
+	s3EventObjectRemovedDeleteMarkerCreated     = "ObjectRemoved:DeleteMarkerCreated"
+
+	// the partitions of the topics created for the notifications
+	notificationTopicPartitionCount = 4
+	notificationQueueSize           = 1024
+	notificationMaxInFlight         = 1024
+)
+
+var s3EventNames = []string{
+	s3EventObjectCreatedPut,
+	s3EventObjectCreatedPost,
+	s3EventObjectCreatedCopy,
+	s3EventObjectCreatedCompleteMultipartUpload,
+	s3EventObjectRemovedDelete,
+	s3EventObjectRemovedDeleteMarkerCreated,
+}
+
+// GetBucketNotificationConfigurationHandler Get Bucket Notification Configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketNotificationConfiguration.html
+func (s3a *S3ApiServer) GetBucketNotificationConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetBucketNotificationConfiguration %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	// an empty configuration if not configured
+	config := metadata.Notification
+	if config == nil {
+		config = &s3.NotificationConfiguration{}
+	}
+	s3err.WriteAwsXMLResponse(w, r, http.StatusOK, &s3.PutBucketNotificationConfigurationInput{
+		NotificationConfiguration: config,
+	})
+}
+
+// PutBucketNotificationConfigurationHandler Put Bucket Notification Configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketNotificationConfiguration.html
+func (s3a *S3ApiServer) PutBucketNotificationConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutBucketNotificationConfiguration %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	var config s3.NotificationConfiguration
+	defer util_http.CloseRequest(r)
+
+	if err := xmlutil.UnmarshalXML(&config, xml.NewDecoder(r.Body), ""); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	if errCode := validateNotification(&config); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	// an empty configuration turns off the notifications
+	var configBytes []byte
+	if len(config.TopicConfigurations) > 0 {
+		var err error
+		if configBytes, err = json.Marshal(&config); err != nil {
+			glog.Errorf("PutBucketNotificationConfiguration %s: %v", bucket, err)
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
+	}
+	if errCode := s3a.updateBucketExtended(bucket, func(extended map[string][]byte) {
+		if configBytes == nil {
+			delete(extended, s3_constants.ExtNotificationKey)
+		} else {
+			extended[s3_constants.ExtNotificationKey] = configBytes
+		}
+	}); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+func validateNotification(config *s3.NotificationConfiguration) s3err.ErrorCode {
+	// only the mq topics are supported as the destinations
+	if len(config.QueueConfigurations) > 0 || len(config.LambdaFunctionConfigurations) > 0 {
+		return s3err.ErrInvalidNotificationTopic
+	}
+	for _, topicConfig := range config.TopicConfigurations {
+		if _, ok := notificationTopicOf(aws.StringValue(topicConfig.TopicArn)); !ok {
+			return s3err.ErrInvalidNotificationTopic
+		}
+		if len(topicConfig.Events) == 0 {
+			return s3err.ErrInvalidNotificationEvent
+		}
+		for _, event := range topicConfig.Events {
+			if !isValidEventPattern(aws.StringValue(event)) {
+				return s3err.ErrInvalidNotificationEvent
+			}
+		}
+		if filter := topicConfig.Filter; filter != nil && filter.Key != nil {
+			for _, rule := range filter.Key.FilterRules {
+				if name := strings.ToLower(aws.StringValue(rule.Name)); name != "prefix" && name != "suffix" {
+					return s3err.ErrInvalidNotificationEvent
+				}
+			}
+		}
+	}
+	return s3err.ErrNone
+}
+
+// ParseNotificationConfiguration parses the notification configuration saved in the bucket
+func ParseNotificationConfiguration(data []byte) (*s3.NotificationConfiguration, error) {
+	config := &s3.NotificationConfiguration{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// notificationTopicOf parses the topic arn, e.g., arn:seaweed:mq:::namespace/topic
+func notificationTopicOf(topicArn string) (t topic.Topic, ok bool) {
+	if !strings.HasPrefix(topicArn, notificationTopicArnPrefix) {
+		return t, false
+	}
+	namespace, name, found := strings.Cut(topicArn[len(notificationTopicArnPrefix):], "/")
+	if !found || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return t, false
+	}
+	return topic.NewTopic(namespace, name), true
+}
+
+func isValidEventPattern(pattern string) bool {
+	pattern = strings.TrimPrefix(pattern, "s3:")
+	for _, eventName := range s3EventNames {
+		if eventMatches(pattern, eventName) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventMatches tells whether the event name, e.g., ObjectCreated:Put, matches the pattern, e.g., s3:ObjectCreated:*
+func eventMatches(pattern, eventName string) bool {
+	pattern = strings.TrimPrefix(pattern, "s3:")
+	if strings.HasSuffix(pattern, ":*") {
+		return strings.HasPrefix(eventName, pattern[:len(pattern)-1])
+	}
+	return pattern == eventName
+}
+
+// notificationsOf finds the topic configurations notified of the event of the object
+func notificationsOf(config *s3.NotificationConfiguration, eventName, key string) (matched []*s3.TopicConfiguration) {
+	if config == nil {
+		return nil
+	}
+	key = strings.TrimPrefix(key, "/")
+	for _, topicConfig := range config.TopicConfigurations {
+		if topicConfig.Filter != nil && topicConfig.Filter.Key != nil && !keyFilterMatches(topicConfig.Filter.Key, key) {
+			continue
+		}
+		for _, event := range topicConfig.Events {
+			if eventMatches(aws.StringValue(event), eventName) {
+				matched = append(matched, topicConfig)
+				break
+			}
+		}
+	}
+	return
+}
+
+func keyFilterMatches(keyFilter *s3.KeyFilter, key string) bool {
+	for _, rule := range keyFilter.FilterRules {
+		switch strings.ToLower(aws.StringValue(rule.Name)) {
+		case "prefix":
+			if !strings.HasPrefix(key, aws.StringValue(rule.Value)) {
+				return false
+			}
+		case "suffix":
+			if !strings.HasSuffix(key, aws.StringValue(rule.Value)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type s3EventRecords struct {
+	Records []*s3EventRecord `json:"Records"`
+}
+
+// s3EventRecord is the event message structure of the S3 event notifications
+type s3EventRecord struct {
+	EventVersion string `json:"eventVersion"`
+	EventSource  string `json:"eventSource"`
+	AwsRegion    string `json:"awsRegion"`
+	EventTime    string `json:"eventTime"`
+	EventName    string `json:"eventName"`
+	UserIdentity struct {
+		PrincipalId string `json:"principalId"`
+	} `json:"userIdentity"`
+	RequestParameters struct {
+		SourceIPAddress string `json:"sourceIPAddress"`
+	} `json:"requestParameters"`
+	S3 struct {
+		SchemaVersion   string `json:"s3SchemaVersion"`
+		ConfigurationId string `json:"configurationId"`
+		Bucket          struct {
+			Name string `json:"name"`
+			Arn  string `json:"arn"`
+		} `json:"bucket"`
+		Object struct {
+			Key       string `json:"key"`
+			Size      int64  `json:"size,omitempty"`
+			ETag      string `json:"eTag,omitempty"`
+			VersionId string `json:"versionId,omitempty"`
+			Sequencer string `json:"sequencer"`
+		} `json:"object"`
+	} `json:"s3"`
+}
+
+// notifyObjectEvent publishes the event of the object to the topics configured for the bucket
+func (s3a *S3ApiServer) notifyObjectEvent(r *http.Request, eventName, bucket, object, versionId string) {
+	if s3a.notifier == nil || s3a.bucketRegistry == nil {
+		return
+	}
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone {
+		return
+	}
+	topicConfigs := notificationsOf(metadata.Notification, eventName, object)
+	if len(topicConfigs) == 0 {
+		return
+	}
+
+	now := time.Now()
+	key := strings.TrimPrefix(object, "/")
+	record := &s3EventRecord{
+		EventVersion: "2.1",
+		EventSource:  "seaweedfs:s3",
+		EventTime:    now.UTC().Format(time.RFC3339Nano),
+		EventName:    eventName,
+	}
+	record.UserIdentity.PrincipalId = r.Header.Get(s3_constants.AmzIdentityId)
+	record.RequestParameters.SourceIPAddress = r.RemoteAddr
+	record.S3.SchemaVersion = "1.0"
+	record.S3.Bucket.Name = bucket
+	record.S3.Bucket.Arn = "arn:aws:s3:::" + bucket
+	record.S3.Object.Key = url.QueryEscape(key)
+	record.S3.Object.VersionId = versionId
+	record.S3.Object.Sequencer = fmt.Sprintf("%016X", now.UnixNano())
+	if strings.HasPrefix(eventName, "ObjectCreated:") {
+		dir, name := s3a.objectPath(bucket, object).DirAndName()
+		if entry, err := s3a.getEntry(dir, name); err == nil {
+			record.S3.Object.Size = int64(filer.FileSize(entry))
+			record.S3.Object.ETag = filer.ETag(entry)
+		}
+	}
+
+	for _, topicConfig := range topicConfigs {
+		t, _ := notificationTopicOf(aws.StringValue(topicConfig.TopicArn))
+		record.S3.ConfigurationId = aws.StringValue(topicConfig.Id)
+		value, err := json.Marshal(&s3EventRecords{Records: []*s3EventRecord{record}})
+		if err != nil {
+			glog.Errorf("marshal %s event of %s/%s: %v", eventName, bucket, key, err)
+			return
+		}
+		s3a.notifier.notify(t, []byte(bucket+"/"+key), value)
+	}
+}
+
+func (s3a *S3ApiServer) notifyObjectDeleted(r *http.Request, bucket, object string, deleted ObjectIdentifier) {
+	if deleted.DeleteMarker {
+		s3a.notifyObjectEvent(r, s3EventObjectRemovedDeleteMarkerCreated, bucket, object, deleted.DeleteMarkerVersionId)
+	} else {
+		s3a.notifyObjectEvent(r, s3EventObjectRemovedDelete, bucket, object, deleted.VersionId)
+	}
+}
+
+type notificationMessage struct {
+	topic topic.Topic
+	key   []byte
+	value []byte
+}
+
+// bucketNotifier publishes the notification messages to the mq topics in the background
+type bucketNotifier struct {
+	masters        []pb.ServerAddress
+	filerGroup     string
+	grpcDialOption grpc.DialOption
+
+	messages   chan *notificationMessage
+	startOnce  sync.Once
+	publishers map[topic.Topic]*pub_client.TopicPublisher // only accessed by the publishing loop
+}
+
+func newBucketNotifier(masters []pb.ServerAddress, filerGroup string, grpcDialOption grpc.DialOption) *bucketNotifier {
+	return &bucketNotifier{
+		masters:        masters,
+		filerGroup:     filerGroup,
+		grpcDialOption: grpcDialOption,
+		messages:       make(chan *notificationMessage, notificationQueueSize),
+		publishers:     make(map[topic.Topic]*pub_client.TopicPublisher),
+	}
+}
+
+func (n *bucketNotifier) notify(t topic.Topic, key, value []byte) {
+	n.startOnce.Do(func() {
+		go n.loopPublish()
+	})
+	select {
+	case n.messages <- &notificationMessage{topic: t, key: key, value: value}:
+	default:
+		glog.Warningf("drop notification %s to topic %s: too many pending notifications", key, t)
+	}
+}
+
+func (n *bucketNotifier) loopPublish() {
+	for message := range n.messages {
+		publisher, err := n.publisherOf(message.topic)
+		if err != nil {
+			glog.Errorf("drop notification %s to topic %s: %v", message.key, message.topic, err)
+			continue
+		}
+		key := message.key
+		if err = publisher.PublishAsync(key, message.value, func(_ *mq_pb.DataMessage, err error) {
+			if err != nil {
+				glog.Errorf("publish notification %s to topic %s: %v", key, message.topic, err)
+			}
+		}); err != nil {
+			glog.Errorf("publish notification %s to topic %s: %v", key, message.topic, err)
+		}
+	}
+}
+
+func (n *bucketNotifier) publisherOf(t topic.Topic) (*pub_client.TopicPublisher, error) {
+	if publisher, found := n.publishers[t]; found {
+		return publisher, nil
+	}
+	brokers := n.discoverBrokers()
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no mq brokers found from masters %v", n.masters)
+	}
+	publisher := pub_client.NewTopicPublisher(&pub_client.PublisherConfiguration{
+		Topic:               t,
+		PartitionCount:      n.lookupPartitionCount(brokers, t),
+		Brokers:             brokers,
+		PublisherName:       "s3-notification",
+		GrpcDialOption:      n.grpcDialOption,
+		MaxInFlightMessages: notificationMaxInFlight,
+	})
+	n.publishers[t] = publisher
+	return publisher, nil
+}
+
+func (n *bucketNotifier) discoverBrokers() (brokers []string) {
+	for _, master := range n.masters {
+		for _, node := range cluster.ListExistingPeerUpdates(master, n.grpcDialOption, n.filerGroup, cluster.BrokerType) {
+			brokers = append(brokers, node.Address)
+		}
+		if len(brokers) > 0 {
+			return
+		}
+	}
+	return
+}
+
+// lookupPartitionCount keeps the partitions of an existing topic, which would be re-balanced if configured differently
+func (n *bucketNotifier) lookupPartitionCount(brokers []string, t topic.Topic) int32 {
+	for _, broker := range brokers {
+		var partitionCount int32
+		err := pb.WithBrokerGrpcClient(false, broker, n.grpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
+			resp, err := client.LookupTopicBrokers(context.Background(), &mq_pb.LookupTopicBrokersRequest{
+				Topic: t.ToPbTopic(),
+			})
+			if err != nil {
+				return err
+			}
+			partitionCount = int32(len(resp.BrokerPartitionAssignments))
+			return nil
+		})
+		if err == nil && partitionCount > 0 {
+			return partitionCount
+		}
+	}
+	return notificationTopicPartitionCount
+}
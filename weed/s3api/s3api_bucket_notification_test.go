@@ -0,0 +1,73 @@
+package s3api
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+func TestNotificationTopicOf(t *testing.T) {
+	if topic, ok := notificationTopicOf("arn:seaweed:mq:::events/uploads"); !ok || topic.Namespace != "events" || topic.Name != "uploads" {
+		t.Errorf("unexpected topic %v", topic)
+	}
+	for _, arn := range []string{"arn:aws:sns:us-east-1:123:uploads", "arn:seaweed:mq:::uploads", "arn:seaweed:mq:::/uploads", "arn:seaweed:mq:::a/b/c"} {
+		if _, ok := notificationTopicOf(arn); ok {
+			t.Errorf("unexpected valid topic arn %s", arn)
+		}
+	}
+}
+
+func TestNotificationsOf(t *testing.T) {
+	config := &s3.NotificationConfiguration{
+		TopicConfigurations: []*s3.TopicConfiguration{
+			{
+				Id:       aws.String("created"),
+				TopicArn: aws.String("arn:seaweed:mq:::events/created"),
+				Events:   []*string{aws.String("s3:ObjectCreated:*")},
+			},
+			{
+				Id:       aws.String("images"),
+				TopicArn: aws.String("arn:seaweed:mq:::events/images"),
+				Events:   []*string{aws.String("s3:ObjectCreated:Put"), aws.String("s3:ObjectRemoved:Delete")},
+				Filter: &s3.NotificationConfigurationFilter{Key: &s3.KeyFilter{FilterRules: []*s3.FilterRule{
+					{Name: aws.String("prefix"), Value: aws.String("images/")},
+					{Name: aws.String("Suffix"), Value: aws.String(".jpg")},
+				}}},
+			},
+		},
+	}
+	if errCode := validateNotification(config); errCode != s3err.ErrNone {
+		t.Fatalf("unexpected error %v", errCode)
+	}
+
+	tests := []struct {
+		eventName string
+		key       string
+		ids       []string
+	}{
+		{eventName: s3EventObjectCreatedCopy, key: "/images/a.jpg", ids: []string{"created"}},
+		{eventName: s3EventObjectCreatedPut, key: "/images/a.jpg", ids: []string{"created", "images"}},
+		{eventName: s3EventObjectCreatedPut, key: "/images/a.png", ids: []string{"created"}},
+		{eventName: s3EventObjectRemovedDelete, key: "/images/a.jpg", ids: []string{"images"}},
+		{eventName: s3EventObjectRemovedDeleteMarkerCreated, key: "/images/a.jpg"},
+	}
+	for _, tt := range tests {
+		matched := notificationsOf(config, tt.eventName, tt.key)
+		if len(matched) != len(tt.ids) {
+			t.Errorf("%s %s: expected %v, got %d configurations", tt.eventName, tt.key, tt.ids, len(matched))
+			continue
+		}
+		for i, topicConfig := range matched {
+			if aws.StringValue(topicConfig.Id) != tt.ids[i] {
+				t.Errorf("%s %s: expected %v, got %s", tt.eventName, tt.key, tt.ids, aws.StringValue(topicConfig.Id))
+			}
+		}
+	}
+
+	config.TopicConfigurations[0].Events = []*string{aws.String("s3:ObjectRestore:*")}
+	if errCode := validateNotification(config); errCode != s3err.ErrInvalidNotificationEvent {
+		t.Errorf("unexpected error %v for the unsupported event", errCode)
+	}
+}
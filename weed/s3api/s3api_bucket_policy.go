@@ -0,0 +1,555 @@
+package s3api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+// The bucket policies are evaluated for each request after its identity is authenticated.
+// A matched Deny statement denies the request, and a matched Allow statement allows the request
+// even if the identity has no such action. The identities with the Admin action are not subject to the policies,
+// so that a policy never locks the operators out of the bucket.
+//
+// The statements are matched by the principals, the actions, e.g., "s3:GetObject", the resources,
+// e.g., "arn:aws:s3:::bucket/prefix/*", and the conditions on these keys:
+//
+//	aws:SourceIp          the address the request comes from, without trusting the X-Forwarded-For
+//	aws:SecureTransport   whether the request is sent over https
+//	s3:prefix             the prefix of the listing requests
+//	s3:x-amz-acl          the canned acl of the request
+//
+// with the operators StringEquals, StringNotEquals, StringLike, StringNotLike, IpAddress, NotIpAddress, Bool and Null.
+
+const (
+	bucketPolicyMaxSize   = 20 * 1024
+	bucketPolicyArnPrefix = "arn:aws:s3:::"
+	bucketPolicyAllow     = "Allow"
+	bucketPolicyDeny      = "Deny"
+)
+
+var (
+	bucketPolicyVersions      = []string{"2012-10-17", "2008-10-17"}
+	bucketPolicyOperators     = []string{"StringEquals", "StringNotEquals", "StringLike", "StringNotLike", "IpAddress", "NotIpAddress", "Bool", "Null"}
+	bucketPolicyConditionKeys = []string{"aws:sourceip", "aws:securetransport", "s3:prefix", "s3:x-amz-acl"}
+)
+
+type BucketPolicy struct {
+	Version   string
+	Id        string `json:",omitempty"`
+	Statement policyStatements
+}
+
+type BucketPolicyStatement struct {
+	Sid       string `json:",omitempty"`
+	Effect    string
+	Principal *policyPrincipal
+	Action    policyValues
+	Resource  policyValues
+	// the values of the condition keys, by the operators
+	Condition map[string]map[string]policyValues `json:",omitempty"`
+}
+
+// policyStatements is a list of statements, or a single statement
+type policyStatements []*BucketPolicyStatement
+
+func (s *policyStatements) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		statement := &BucketPolicyStatement{}
+		if err := unmarshalPolicy(data, statement); err != nil {
+			return err
+		}
+		*s = policyStatements{statement}
+		return nil
+	}
+	return unmarshalPolicy(data, (*[]*BucketPolicyStatement)(s))
+}
+
+// policyPrincipal is "*" for everyone, or {"AWS": [...]} of the identity names, the account ids or the user arns
+type policyPrincipal struct {
+	AWS policyValues
+}
+
+func (p *policyPrincipal) UnmarshalJSON(data []byte) error {
+	var everyone string
+	if err := json.Unmarshal(data, &everyone); err == nil {
+		if everyone != "*" {
+			return fmt.Errorf("invalid principal %s", everyone)
+		}
+		p.AWS = policyValues{everyone}
+		return nil
+	}
+	type principal policyPrincipal
+	return unmarshalPolicy(data, (*principal)(p))
+}
+
+// policyValues is a list of values, or a single value, of strings, booleans or numbers
+type policyValues []string
+
+func (v *policyValues) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		raws = []json.RawMessage{data}
+	}
+	*v = nil
+	for _, raw := range raws {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			var scalar interface{}
+			if err = json.Unmarshal(raw, &scalar); err != nil {
+				return err
+			}
+			switch scalar.(type) {
+			case bool, float64:
+				value = string(raw)
+			default:
+				return fmt.Errorf("invalid value %s", raw)
+			}
+		}
+		*v = append(*v, value)
+	}
+	return nil
+}
+
+// unmarshalPolicy rejects the unknown elements, e.g., NotPrincipal or NotAction, which are not supported
+func unmarshalPolicy(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func ParseBucketPolicy(policyBytes []byte) (*BucketPolicy, error) {
+	policy := &BucketPolicy{}
+	if err := unmarshalPolicy(policyBytes, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// GetBucketPolicyHandler Get bucket Policy
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketPolicy.html
+func (s3a *S3ApiServer) GetBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetBucketPolicy %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		glog.Errorf("GetBucketPolicy %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	policyBytes, found := bucketEntry.Extended[s3_constants.ExtBucketPolicyKey]
+	if !found {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucketPolicy)
+		return
+	}
+	s3err.WriteResponse(w, r, http.StatusOK, policyBytes, s3err.MimeJSON)
+}
+
+// PutBucketPolicyHandler Put bucket Policy
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketPolicy.html
+func (s3a *S3ApiServer) PutBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutBucketPolicy %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedPolicy)
+		return
+	}
+	defer util_http.CloseRequest(r)
+
+	policyBytes, err := io.ReadAll(io.LimitReader(r.Body, bucketPolicyMaxSize+1))
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidRequest)
+		return
+	}
+	if len(policyBytes) > bucketPolicyMaxSize {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedPolicy)
+		return
+	}
+	policy, err := ParseBucketPolicy(policyBytes)
+	if err != nil {
+		glog.V(1).Infof("PutBucketPolicy %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedPolicy)
+		return
+	}
+	if err = validateBucketPolicy(bucket, policy); err != nil {
+		glog.V(1).Infof("PutBucketPolicy %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedPolicy)
+		return
+	}
+
+	if errCode := s3a.updateBucketExtended(bucket, func(extended map[string][]byte) {
+		extended[s3_constants.ExtBucketPolicyKey] = policyBytes
+	}); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	s3err.WriteEmptyResponse(w, r, http.StatusNoContent)
+}
+
+// DeleteBucketPolicyHandler Delete bucket Policy
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketPolicy.html
+func (s3a *S3ApiServer) DeleteBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("DeleteBucketPolicy %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	if errCode := s3a.updateBucketExtended(bucket, func(extended map[string][]byte) {
+		delete(extended, s3_constants.ExtBucketPolicyKey)
+	}); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	s3err.WriteEmptyResponse(w, r, http.StatusNoContent)
+}
+
+func validateBucketPolicy(bucket string, policy *BucketPolicy) error {
+	if !slices.Contains(bucketPolicyVersions, policy.Version) {
+		return fmt.Errorf("invalid version %q", policy.Version)
+	}
+	if len(policy.Statement) == 0 {
+		return fmt.Errorf("no statement")
+	}
+	for _, statement := range policy.Statement {
+		if statement.Effect != bucketPolicyAllow && statement.Effect != bucketPolicyDeny {
+			return fmt.Errorf("invalid effect %q", statement.Effect)
+		}
+		if statement.Principal == nil || len(statement.Principal.AWS) == 0 {
+			return fmt.Errorf("missing principal")
+		}
+		if len(statement.Action) == 0 {
+			return fmt.Errorf("missing action")
+		}
+		for _, action := range statement.Action {
+			if action != "*" && !strings.HasPrefix(strings.ToLower(action), "s3:") {
+				return fmt.Errorf("invalid action %q", action)
+			}
+		}
+		if len(statement.Resource) == 0 {
+			return fmt.Errorf("missing resource")
+		}
+		for _, resource := range statement.Resource {
+			// the resources are the bucket itself or its objects
+			if name, _, _ := strings.Cut(strings.TrimPrefix(resource, bucketPolicyArnPrefix), "/"); !strings.HasPrefix(resource, bucketPolicyArnPrefix) || name != bucket {
+				return fmt.Errorf("invalid resource %q", resource)
+			}
+		}
+		for operator, conditions := range statement.Condition {
+			if !slices.Contains(bucketPolicyOperators, operator) {
+				return fmt.Errorf("unsupported condition operator %q", operator)
+			}
+			for key, values := range conditions {
+				if !slices.Contains(bucketPolicyConditionKeys, strings.ToLower(key)) {
+					return fmt.Errorf("unsupported condition key %q", key)
+				}
+				if len(values) == 0 {
+					return fmt.Errorf("missing values of condition key %q", key)
+				}
+				for _, value := range values {
+					if (operator == "IpAddress" || operator == "NotIpAddress") && parsePolicyIpNet(value) == nil {
+						return fmt.Errorf("invalid ip address %q", value)
+					}
+					if (operator == "Bool" || operator == "Null") && value != "true" && value != "false" {
+						return fmt.Errorf("invalid boolean %q", value)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type policyEffect int
+
+const (
+	policyNone policyEffect = iota
+	policyAllow
+	policyDeny
+)
+
+// bucketPolicyOf is nil if the bucket has no policy or does not exist
+func (s3a *S3ApiServer) bucketPolicyOf(bucket string) *BucketPolicy {
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone {
+		return nil
+	}
+	return metadata.Policy
+}
+
+func (iam *IdentityAccessManagement) evaluateBucketPolicy(r *http.Request, identity *Identity, bucket string) policyEffect {
+	return iam.evaluateBucketPolicyAction(r, identity, bucket, bucketPolicyActionOf(r))
+}
+
+// evaluateBucketPolicyAction evaluates the bucket policy for the action on the resource of the request
+func (iam *IdentityAccessManagement) evaluateBucketPolicyAction(r *http.Request, identity *Identity, bucket, action string) policyEffect {
+	if bucket == "" || iam.bucketPolicyOf == nil || identity.isAdmin() {
+		return policyNone
+	}
+	policy := iam.bucketPolicyOf(bucket)
+	if policy == nil {
+		return policyNone
+	}
+	_, object := s3_constants.GetBucketAndObject(r)
+	resource := bucketPolicyArnPrefix + bucket
+	if object != "/" {
+		resource += object
+	}
+	effect := policy.evaluate(identity, action, resource, bucketPolicyConditionValues(r))
+	glog.V(3).Infof("bucket policy of %s for %s to %s on %s: %v", bucket, identity.Name, action, resource, effect)
+	return effect
+}
+
+// evaluate denies the request by any matched Deny statement, or allows the request by any matched Allow statement
+func (policy *BucketPolicy) evaluate(identity *Identity, action, resource string, values map[string]string) policyEffect {
+	effect := policyNone
+	for _, statement := range policy.Statement {
+		if !statement.Principal.matches(identity) ||
+			!slices.ContainsFunc(statement.Action, func(pattern string) bool {
+				return matchPolicyPattern(strings.ToLower(pattern), strings.ToLower(action))
+			}) ||
+			!slices.ContainsFunc(statement.Resource, func(pattern string) bool {
+				return matchPolicyPattern(pattern, resource)
+			}) ||
+			!statement.matchesConditions(values) {
+			continue
+		}
+		if statement.Effect == bucketPolicyDeny {
+			return policyDeny
+		}
+		effect = policyAllow
+	}
+	return effect
+}
+
+func (p *policyPrincipal) matches(identity *Identity) bool {
+	if p == nil {
+		return false
+	}
+	for _, principal := range p.AWS {
+		if principal == "*" {
+			return true
+		}
+		if identity.Account != nil && identity.isAnonymous() {
+			continue
+		}
+		if principal == identity.Name || strings.HasSuffix(principal, ":user/"+identity.Name) ||
+			identity.Account != nil && principal == identity.Account.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesConditions requires all the conditions matched, where a condition matches any of its values,
+// and the negated operators match the absent keys
+func (statement *BucketPolicyStatement) matchesConditions(values map[string]string) bool {
+	for operator, conditions := range statement.Condition {
+		for key, expected := range conditions {
+			value, found := values[strings.ToLower(key)]
+			matchesAny := func(match func(expected string) bool) bool {
+				return found && slices.ContainsFunc(expected, match)
+			}
+			var matched bool
+			switch operator {
+			case "StringEquals", "StringNotEquals":
+				matched = matchesAny(func(e string) bool { return e == value }) == (operator == "StringEquals")
+			case "StringLike", "StringNotLike":
+				matched = matchesAny(func(e string) bool { return matchPolicyPattern(e, value) }) == (operator == "StringLike")
+			case "IpAddress", "NotIpAddress":
+				ip := net.ParseIP(value)
+				matched = matchesAny(func(e string) bool {
+					ipNet := parsePolicyIpNet(e)
+					return ipNet != nil && ipNet.Contains(ip)
+				}) == (operator == "IpAddress")
+			case "Bool":
+				matched = matchesAny(func(e string) bool { return strings.EqualFold(e, value) })
+			case "Null":
+				matched = slices.Contains(expected, strconv.FormatBool(!found))
+			}
+			if !matched {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// bucketPolicyConditionValues are the values of the condition keys of the request, absent if not applicable
+func bucketPolicyConditionValues(r *http.Request) map[string]string {
+	values := map[string]string{
+		"aws:securetransport": strconv.FormatBool(r.TLS != nil),
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		values["aws:sourceip"] = host
+	}
+	if query := r.URL.Query(); query.Has("prefix") {
+		values["s3:prefix"] = query.Get("prefix")
+	}
+	if acl := r.Header.Get(s3_constants.AmzCannedAcl); acl != "" {
+		values["s3:x-amz-acl"] = acl
+	}
+	return values
+}
+
+// bucketPolicySubresources are the names of the bucket actions by the sub resources,
+// e.g., "s3:GetBucketVersioning" and "s3:PutBucketVersioning" for "?versioning"
+var bucketPolicySubresources = []struct {
+	query string
+	name  string
+}{
+	{"policy", "BucketPolicy"},
+	{"acl", "BucketAcl"},
+	{"versioning", "BucketVersioning"},
+	{"replication", "ReplicationConfiguration"},
+	{"notification", "BucketNotification"},
+	{"object-lock", "BucketObjectLockConfiguration"},
+	{"lifecycle", "LifecycleConfiguration"},
+	{"tagging", "BucketTagging"},
+	{"cors", "BucketCORS"},
+	{"location", "BucketLocation"},
+	{"requestPayment", "BucketRequestPayment"},
+	{"ownershipControls", "BucketOwnershipControls"},
+	{"encryption", "EncryptionConfiguration"},
+	{"logging", "BucketLogging"},
+}
+
+// bucketPolicyActionOf is the policy action of the request, e.g., "s3:GetObject"
+func bucketPolicyActionOf(r *http.Request) string {
+	_, object := s3_constants.GetBucketAndObject(r)
+	query := r.URL.Query()
+	method := r.Method
+	if object == "/" {
+		for _, subresource := range bucketPolicySubresources {
+			if !query.Has(subresource.query) {
+				continue
+			}
+			switch {
+			case method == http.MethodGet:
+				return "s3:Get" + subresource.name
+			case method == http.MethodDelete && subresource.query == "policy":
+				return "s3:DeleteBucketPolicy"
+			default:
+				// the bucket configurations other than the policy are deleted by the put permissions
+				return "s3:Put" + subresource.name
+			}
+		}
+		switch {
+		case method == http.MethodPut:
+			return "s3:CreateBucket"
+		case method == http.MethodDelete:
+			return "s3:DeleteBucket"
+		case method == http.MethodPost && query.Has("delete"):
+			return "s3:DeleteObject"
+		case query.Has("uploads"):
+			return "s3:ListBucketMultipartUploads"
+		case query.Has("versions"):
+			return "s3:ListBucketVersions"
+		}
+		return "s3:ListBucket"
+	}
+
+	versionSuffix := ""
+	if query.Get("versionId") != "" {
+		versionSuffix = "Version"
+	}
+	for _, subresource := range []struct {
+		query string
+		name  string
+	}{{"acl", "Acl"}, {"tagging", "Tagging"}, {"retention", "Retention"}, {"legal-hold", "LegalHold"}} {
+		if !query.Has(subresource.query) {
+			continue
+		}
+		switch method {
+		case http.MethodGet:
+			return "s3:GetObject" + versionSuffix + subresource.name
+		case http.MethodDelete:
+			return "s3:DeleteObject" + versionSuffix + subresource.name
+		}
+		return "s3:PutObject" + versionSuffix + subresource.name
+	}
+	switch {
+	case query.Has("uploadId") && method == http.MethodGet:
+		return "s3:ListMultipartUploadParts"
+	case query.Has("uploadId") && method == http.MethodDelete:
+		return "s3:AbortMultipartUpload"
+	case method == http.MethodGet || method == http.MethodHead || query.Has("select"):
+		return "s3:GetObject" + versionSuffix
+	case method == http.MethodDelete:
+		return "s3:DeleteObject" + versionSuffix
+	}
+	return "s3:PutObject"
+}
+
+// parsePolicyIpNet parses an address, or a range in CIDR, or returns nil if invalid
+func parsePolicyIpNet(value string) *net.IPNet {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil
+		}
+		if ip.To4() != nil {
+			value += "/32"
+		} else {
+			value += "/128"
+		}
+	}
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil
+	}
+	return ipNet
+}
+
+// matchPolicyPattern matches the value by the pattern, where "*" matches any characters, and "?" matches any one character
+func matchPolicyPattern(pattern, value string) bool {
+	// the positions to restart matching from, after the last "*"
+	starAt, matchAt := -1, 0
+	p, v := 0, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			starAt, matchAt = p, v
+			p++
+		case starAt >= 0:
+			matchAt++
+			p, v = starAt+1, matchAt
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,174 @@
+package s3api
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+)
+
+func TestValidateBucketPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy string
+		valid  bool
+	}{
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}]}`, true},
+		{`{"Version":"2012-10-17","Statement":{"Effect":"Deny","Principal":{"AWS":["u1"]},"Action":["s3:*"],"Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"],
+			"Condition":{"NotIpAddress":{"aws:SourceIp":["10.0.0.0/8","::1"]},"Bool":{"aws:SecureTransport":false}}}}`, true},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:ListBucket","Resource":"arn:aws:s3:::b",
+			"Condition":{"StringLike":{"s3:prefix":"home/*"},"Null":{"s3:x-amz-acl":"true"}}}]}`, true},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::other/*"}]}`, false},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"iam:GetUser","Resource":"arn:aws:s3:::b/*"}]}`, false},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"u1","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}]}`, false},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","NotPrincipal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}]}`, false},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*",
+			"Condition":{"DateGreaterThan":{"aws:CurrentTime":"2024-01-01T00:00:00Z"}}}]}`, false},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*",
+			"Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/33"}}}]}`, false},
+		{`{"Version":"2012-10-17","Statement":[]}`, false},
+		{`{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}]}`, false},
+	} {
+		policy, err := ParseBucketPolicy([]byte(tt.policy))
+		if err == nil {
+			err = validateBucketPolicy("b", policy)
+		}
+		if (err == nil) != tt.valid {
+			t.Errorf("validate %s: %v", tt.policy, err)
+		}
+	}
+}
+
+func TestEvaluateBucketPolicy(t *testing.T) {
+	policy, err := ParseBucketPolicy([]byte(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/public/*",
+			"Condition":{"IpAddress":{"aws:SourceIp":["192.168.1.0/24"]}}},
+		{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam:::user/u1"},"Action":"s3:ListBucket","Resource":"arn:aws:s3:::b",
+			"Condition":{"StringLike":{"s3:prefix":["home/u1/*"]}}},
+		{"Effect":"Allow","Principal":{"AWS":["u1"]},"Action":"s3:PutObject","Resource":"arn:aws:s3:::b/*",
+			"Condition":{"StringNotEquals":{"s3:x-amz-acl":"public-read"}}},
+		{"Effect":"Deny","Principal":"*","Action":"s3:*","Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"],
+			"Condition":{"Bool":{"aws:SecureTransport":false},"NotIpAddress":{"aws:SourceIp":"192.168.0.0/16"}}}
+	]}`))
+	if !assert.NoError(t, err) || !assert.NoError(t, validateBucketPolicy("b", policy)) {
+		return
+	}
+	iam := &IdentityAccessManagement{bucketPolicyOf: func(bucket string) *BucketPolicy {
+		if bucket == "b" {
+			return policy
+		}
+		return nil
+	}}
+	anonymous := &Identity{Name: AccountAnonymous.Id, Account: &AccountAnonymous}
+	u1 := &Identity{Name: "u1", Account: &AccountAdmin}
+	admin := &Identity{Name: "admin", Account: &AccountAdmin, Actions: []Action{s3_constants.ACTION_ADMIN}}
+
+	request := func(method, target, remoteAddr string, secure bool, header map[string]string) *http.Request {
+		r := httptest.NewRequest(method, target, nil)
+		r.RemoteAddr = remoteAddr
+		if secure {
+			r.TLS = &tls.ConnectionState{}
+		}
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		vars := map[string]string{"bucket": "b"}
+		if object := r.URL.Path[len("/b"):]; len(object) > 1 {
+			vars["object"] = object[1:]
+		}
+		return mux.SetURLVars(r, vars)
+	}
+	for _, tt := range []struct {
+		name     string
+		identity *Identity
+		r        *http.Request
+		effect   policyEffect
+	}{
+		{"public read from the allowed addresses", anonymous, request("GET", "/b/public/a", "192.168.1.5:1234", false, nil), policyAllow},
+		{"public read from the other addresses", anonymous, request("GET", "/b/public/a", "192.168.2.5:1234", true, nil), policyNone},
+		{"public read of the private objects", anonymous, request("GET", "/b/private/a", "192.168.1.5:1234", false, nil), policyNone},
+		{"insecure requests from the outside", anonymous, request("GET", "/b/public/a", "10.0.0.1:1234", false, nil), policyDeny},
+		{"secure requests from the outside", u1, request("PUT", "/b/a", "10.0.0.1:1234", true, nil), policyAllow},
+		{"listing the home of the user", u1, request("GET", "/b?prefix=home/u1/docs", "10.0.0.1:1234", true, nil), policyAllow},
+		{"listing the home of others", u1, request("GET", "/b?prefix=home/u2/", "10.0.0.1:1234", true, nil), policyNone},
+		{"listing without the prefix", u1, request("GET", "/b", "10.0.0.1:1234", true, nil), policyNone},
+		{"putting the public objects", u1, request("PUT", "/b/a", "10.0.0.1:1234", true,
+			map[string]string{s3_constants.AmzCannedAcl: "public-read"}), policyNone},
+		{"putting the private objects", u1, request("PUT", "/b/a", "10.0.0.1:1234", true,
+			map[string]string{s3_constants.AmzCannedAcl: "private"}), policyAllow},
+		{"the admin is not subject to the policies", admin, request("GET", "/b/public/a", "10.0.0.1:1234", false, nil), policyNone},
+		{"the bucket without policies", u1, mux.SetURLVars(httptest.NewRequest("GET", "/c/a", nil), map[string]string{"bucket": "c", "object": "a"}), policyNone},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, _ := s3_constants.GetBucketAndObject(tt.r)
+			assert.Equal(t, tt.effect, iam.evaluateBucketPolicy(tt.r, tt.identity, bucket))
+		})
+	}
+}
+
+func TestBucketPolicyActionOf(t *testing.T) {
+	for _, tt := range []struct {
+		method string
+		target string
+		action string
+	}{
+		{"GET", "/b", "s3:ListBucket"},
+		{"GET", "/b?list-type=2&prefix=a", "s3:ListBucket"},
+		{"GET", "/b?versions", "s3:ListBucketVersions"},
+		{"GET", "/b?uploads", "s3:ListBucketMultipartUploads"},
+		{"PUT", "/b", "s3:CreateBucket"},
+		{"DELETE", "/b", "s3:DeleteBucket"},
+		{"GET", "/b?policy", "s3:GetBucketPolicy"},
+		{"DELETE", "/b?policy", "s3:DeleteBucketPolicy"},
+		{"DELETE", "/b?replication", "s3:PutReplicationConfiguration"},
+		{"PUT", "/b?versioning", "s3:PutBucketVersioning"},
+		{"POST", "/b?delete", "s3:DeleteObject"},
+		{"GET", "/b/a", "s3:GetObject"},
+		{"HEAD", "/b/a?versionId=1", "s3:GetObjectVersion"},
+		{"PUT", "/b/a", "s3:PutObject"},
+		{"POST", "/b/a?uploads", "s3:PutObject"},
+		{"GET", "/b/a?uploadId=1", "s3:ListMultipartUploadParts"},
+		{"DELETE", "/b/a?uploadId=1", "s3:AbortMultipartUpload"},
+		{"DELETE", "/b/a?versionId=1", "s3:DeleteObjectVersion"},
+		{"PUT", "/b/a?tagging", "s3:PutObjectTagging"},
+		{"DELETE", "/b/a?tagging&versionId=1", "s3:DeleteObjectVersionTagging"},
+		{"GET", "/b/a?acl", "s3:GetObjectAcl"},
+		{"PUT", "/b/a?legal-hold", "s3:PutObjectLegalHold"},
+		{"POST", "/b/a?select&select-type=2", "s3:GetObject"},
+	} {
+		r := httptest.NewRequest(tt.method, tt.target, nil)
+		vars := map[string]string{"bucket": "b"}
+		if r.URL.Path != "/b" {
+			vars["object"] = r.URL.Path[len("/b/"):]
+		}
+		if action := bucketPolicyActionOf(mux.SetURLVars(r, vars)); action != tt.action {
+			t.Errorf("%s %s: got %s, expected %s", tt.method, tt.target, action, tt.action)
+		}
+	}
+}
+
+func TestMatchPolicyPattern(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		value   string
+		matched bool
+	}{
+		{"*", "", true},
+		{"arn:aws:s3:::b/*", "arn:aws:s3:::b/a/b", true},
+		{"arn:aws:s3:::b/*", "arn:aws:s3:::b", false},
+		{"arn:aws:s3:::b/*/x.txt", "arn:aws:s3:::b/a/b/x.txt", true},
+		{"home/u?/*", "home/u1/docs", true},
+		{"home/u?/*", "home/u12/docs", false},
+		{"s3:get*", "s3:getobject", true},
+		{"*a*b", "xaxxbxb", true},
+		{"*a*b", "xaxxbx", false},
+	} {
+		if matched := matchPolicyPattern(tt.pattern, tt.value); matched != tt.matched {
+			t.Errorf("matchPolicyPattern(%s, %s) = %v", tt.pattern, tt.value, matched)
+		}
+	}
+}
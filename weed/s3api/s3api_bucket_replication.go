@@ -0,0 +1,271 @@
+package s3api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/xml/xmlutil"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+// The objects are replicated by "weed s3.replicate", which follows the filer events of the buckets.
+// The destination of a rule is a bucket in a remote storage configured by "remote.configure",
+// e.g., another SeaweedFS cluster or AWS S3, named by the account of the destination:
+//
+//	<Destination><Bucket>arn:aws:s3:::backup</Bucket><Account>cloud1</Account></Destination>
+
+const replicationBucketArnPrefix = "arn:aws:s3:::"
+
+// GetBucketReplicationHandler Get Bucket Replication
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketReplication.html
+func (s3a *S3ApiServer) GetBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetBucketReplication %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	if metadata.Replication == nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchReplicationConfiguration)
+		return
+	}
+	s3err.WriteAwsXMLResponse(w, r, http.StatusOK, &s3.PutBucketReplicationInput{
+		ReplicationConfiguration: metadata.Replication,
+	})
+}
+
+// PutBucketReplicationHandler Put Bucket Replication
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketReplication.html
+func (s3a *S3ApiServer) PutBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutBucketReplication %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	var config s3.ReplicationConfiguration
+	defer util_http.CloseRequest(r)
+
+	if err := xmlutil.UnmarshalXML(&config, xml.NewDecoder(r.Body), ""); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	if errCode := s3a.validateReplication(&config); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	configBytes, err := json.Marshal(&config)
+	if err != nil {
+		glog.Errorf("PutBucketReplication %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	if errCode := s3a.updateBucketExtended(bucket, func(extended map[string][]byte) {
+		extended[s3_constants.ExtReplicationKey] = configBytes
+	}); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+// DeleteBucketReplicationHandler Delete Bucket Replication
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketReplication.html
+func (s3a *S3ApiServer) DeleteBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("DeleteBucketReplication %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	if errCode := s3a.updateBucketExtended(bucket, func(extended map[string][]byte) {
+		delete(extended, s3_constants.ExtReplicationKey)
+	}); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	s3err.WriteEmptyResponse(w, r, http.StatusNoContent)
+}
+
+// updateBucketExtended updates the extended attributes of the bucket, seen by the following requests right away
+func (s3a *S3ApiServer) updateBucketExtended(bucket string, fn func(extended map[string][]byte)) s3err.ErrorCode {
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		if err == filer_pb.ErrNotFound {
+			return s3err.ErrNoSuchBucket
+		}
+		return s3err.ErrInternalError
+	}
+	if bucketEntry.Extended == nil {
+		bucketEntry.Extended = make(map[string][]byte)
+	}
+	fn(bucketEntry.Extended)
+	if err = s3a.updateEntry(s3a.option.BucketsPath, bucketEntry); err != nil {
+		glog.Errorf("update bucket %s: %v", bucket, err)
+		return s3err.ErrInternalError
+	}
+	s3a.bucketRegistry.LoadBucketMetadata(bucketEntry)
+	return s3err.ErrNone
+}
+
+func (s3a *S3ApiServer) validateReplication(config *s3.ReplicationConfiguration) s3err.ErrorCode {
+	if len(config.Rules) == 0 {
+		return s3err.ErrMalformedXML
+	}
+	for _, rule := range config.Rules {
+		status := aws.StringValue(rule.Status)
+		if status != s3.ReplicationRuleStatusEnabled && status != s3.ReplicationRuleStatusDisabled {
+			return s3err.ErrMalformedXML
+		}
+		remoteName, destBucket := ReplicationDestination(rule)
+		if remoteName == "" || destBucket == "" {
+			return s3err.ErrInvalidReplicationDestination
+		}
+		if _, err := s3a.getEntry(filer.DirectoryEtcRemote, remoteName+filer.REMOTE_STORAGE_CONF_SUFFIX); err != nil {
+			glog.V(1).Infof("replication destination %s: %v", remoteName, err)
+			return s3err.ErrInvalidReplicationDestination
+		}
+	}
+	return s3err.ErrNone
+}
+
+// ParseReplicationConfiguration parses the replication configuration saved in the bucket
+func ParseReplicationConfiguration(data []byte) (*s3.ReplicationConfiguration, error) {
+	config := &s3.ReplicationConfiguration{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ReplicationDestination returns the remote storage name and the bucket of the rule destination
+func ReplicationDestination(rule *s3.ReplicationRule) (remoteName, bucket string) {
+	if rule.Destination == nil {
+		return "", ""
+	}
+	bucketArn := aws.StringValue(rule.Destination.Bucket)
+	if !strings.HasPrefix(bucketArn, replicationBucketArnPrefix) {
+		return "", ""
+	}
+	return aws.StringValue(rule.Destination.Account), strings.TrimPrefix(bucketArn, replicationBucketArnPrefix)
+}
+
+// ReplicationRuleOf finds the enabled rule with the highest priority replicating the object, or nil if none
+func ReplicationRuleOf(config *s3.ReplicationConfiguration, key string, tags map[string]string) (matched *s3.ReplicationRule) {
+	if config == nil {
+		return nil
+	}
+	key = strings.TrimPrefix(key, "/")
+	for _, rule := range config.Rules {
+		if aws.StringValue(rule.Status) != s3.ReplicationRuleStatusEnabled || !replicationRuleMatches(rule, key, tags) {
+			continue
+		}
+		if matched == nil || aws.Int64Value(rule.Priority) > aws.Int64Value(matched.Priority) {
+			matched = rule
+		}
+	}
+	return
+}
+
+func replicationRuleMatches(rule *s3.ReplicationRule, key string, tags map[string]string) bool {
+	prefix := aws.StringValue(rule.Prefix)
+	var ruleTags []*s3.Tag
+	if filter := rule.Filter; filter != nil {
+		if filter.Prefix != nil {
+			prefix = aws.StringValue(filter.Prefix)
+		}
+		if filter.Tag != nil {
+			ruleTags = append(ruleTags, filter.Tag)
+		}
+		if filter.And != nil {
+			if filter.And.Prefix != nil {
+				prefix = aws.StringValue(filter.And.Prefix)
+			}
+			ruleTags = append(ruleTags, filter.And.Tags...)
+		}
+	}
+	if !strings.HasPrefix(key, prefix) {
+		return false
+	}
+	for _, tag := range ruleTags {
+		if value, found := tags[aws.StringValue(tag.Key)]; !found || value != aws.StringValue(tag.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsDeleteReplicated tells whether the deletes of the objects are replicated by the rule
+func IsDeleteReplicated(rule *s3.ReplicationRule) bool {
+	return rule.DeleteMarkerReplication != nil && aws.StringValue(rule.DeleteMarkerReplication.Status) == s3.DeleteMarkerReplicationStatusEnabled
+}
+
+// ObjectTagsOf returns the tags of the object saved in its extended attributes
+func ObjectTagsOf(extended map[string][]byte) map[string]string {
+	tags := make(map[string]string)
+	for k, v := range extended {
+		if strings.HasPrefix(k, S3TAG_PREFIX) {
+			tags[k[len(S3TAG_PREFIX):]] = string(v)
+		}
+	}
+	return tags
+}
+
+// replicationStatusForWrite is PENDING if the bucket replicates the object being written, or empty
+func (s3a *S3ApiServer) replicationStatusForWrite(bucket, object string, tags map[string]string) string {
+	if s3a.bucketRegistry == nil {
+		return ""
+	}
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone || ReplicationRuleOf(metadata.Replication, object, tags) == nil {
+		return ""
+	}
+	return s3.ReplicationStatusPending
+}
+
+// markReplicationPending sets the replication status saved by the filer with the object written by the request
+func (s3a *S3ApiServer) markReplicationPending(header http.Header, bucket, object string) {
+	// the replication status is never set by the clients
+	header.Del(s3_constants.AmzReplicationStatus)
+	tags, _ := parseTagsHeader(header.Get(s3_constants.AmzObjectTagging))
+	if status := s3a.replicationStatusForWrite(bucket, object, tags); status != "" {
+		header.Set(s3_constants.AmzReplicationStatus, status)
+	}
+}
+
+// markEntryReplicationPending sets the replication status of the object written with its extended attributes
+func (s3a *S3ApiServer) markEntryReplicationPending(extended map[string][]byte, bucket, object string) {
+	delete(extended, s3_constants.ExtReplicationStatusKey)
+	if status := s3a.replicationStatusForWrite(bucket, object, ObjectTagsOf(extended)); status != "" {
+		extended[s3_constants.ExtReplicationStatusKey] = []byte(status)
+	}
+}
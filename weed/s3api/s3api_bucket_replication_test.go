@@ -0,0 +1,70 @@
+package s3api
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestReplicationRuleOf(t *testing.T) {
+	config := &s3.ReplicationConfiguration{
+		Rules: []*s3.ReplicationRule{
+			{
+				ID:          aws.String("all"),
+				Status:      aws.String(s3.ReplicationRuleStatusEnabled),
+				Priority:    aws.Int64(1),
+				Filter:      &s3.ReplicationRuleFilter{Prefix: aws.String("")},
+				Destination: &s3.Destination{Bucket: aws.String("arn:aws:s3:::backup"), Account: aws.String("cloud1")},
+			},
+			{
+				ID:       aws.String("logs"),
+				Status:   aws.String(s3.ReplicationRuleStatusEnabled),
+				Priority: aws.Int64(2),
+				Filter: &s3.ReplicationRuleFilter{And: &s3.ReplicationRuleAndOperator{
+					Prefix: aws.String("logs/"),
+					Tags:   []*s3.Tag{{Key: aws.String("keep"), Value: aws.String("yes")}},
+				}},
+				DeleteMarkerReplication: &s3.DeleteMarkerReplication{Status: aws.String(s3.DeleteMarkerReplicationStatusEnabled)},
+				Destination:             &s3.Destination{Bucket: aws.String("arn:aws:s3:::logs"), Account: aws.String("cloud2")},
+			},
+			{
+				ID:          aws.String("disabled"),
+				Status:      aws.String(s3.ReplicationRuleStatusDisabled),
+				Priority:    aws.Int64(3),
+				Prefix:      aws.String("logs/"),
+				Destination: &s3.Destination{Bucket: aws.String("arn:aws:s3:::other"), Account: aws.String("cloud3")},
+			},
+		},
+	}
+
+	tests := []struct {
+		key    string
+		tags   map[string]string
+		ruleId string
+	}{
+		{key: "/a.txt", ruleId: "all"},
+		{key: "/logs/1.log", ruleId: "all"},
+		{key: "/logs/1.log", tags: map[string]string{"keep": "no"}, ruleId: "all"},
+		{key: "/logs/1.log", tags: map[string]string{"keep": "yes"}, ruleId: "logs"},
+	}
+	for _, tt := range tests {
+		rule := ReplicationRuleOf(config, tt.key, tt.tags)
+		if rule == nil || aws.StringValue(rule.ID) != tt.ruleId {
+			t.Errorf("%s %v: expected rule %s, got %+v", tt.key, tt.tags, tt.ruleId, rule)
+		}
+	}
+
+	rule := ReplicationRuleOf(config, "/logs/1.log", map[string]string{"keep": "yes"})
+	if remoteName, bucket := ReplicationDestination(rule); remoteName != "cloud2" || bucket != "logs" {
+		t.Errorf("unexpected destination %s/%s", remoteName, bucket)
+	}
+	if !IsDeleteReplicated(rule) || IsDeleteReplicated(config.Rules[0]) {
+		t.Errorf("unexpected delete replication")
+	}
+
+	config.Rules[0].Filter.Prefix = aws.String("docs/")
+	if rule := ReplicationRuleOf(config, "/a.txt", nil); rule != nil {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+}
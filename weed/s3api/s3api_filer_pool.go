@@ -0,0 +1,174 @@
+package s3api
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	filerHealthCheckInterval = 5 * time.Second
+	filerHealthCheckTimeout  = 3 * time.Second
+)
+
+// FilerPool picks the filer for each request among the configured filers.
+// The writes and metadata requests stick to the current filer until it is unreachable,
+// and the object reads can be spread over all healthy filers if they share the same filer store.
+type FilerPool struct {
+	filers       []*pooledFiler
+	balanceReads bool
+	current      atomic.Int32
+	nextRead     atomic.Uint32
+}
+
+type pooledFiler struct {
+	address     pb.ServerAddress
+	unreachable atomic.Bool
+}
+
+func NewFilerPool(addresses []pb.ServerAddress, balanceReads bool) *FilerPool {
+	fp := &FilerPool{balanceReads: balanceReads}
+	for _, address := range addresses {
+		fp.filers = append(fp.filers, &pooledFiler{address: address})
+	}
+	// spread the gateways over the filers
+	fp.current.Store(int32(rand.Intn(len(fp.filers))))
+	return fp
+}
+
+// Current returns the filer for the writes and metadata requests
+func (fp *FilerPool) Current() pb.ServerAddress {
+	return fp.filers[fp.current.Load()].address
+}
+
+// ForRead returns the next healthy filer in turn if the reads are balanced, otherwise the current filer
+func (fp *FilerPool) ForRead() pb.ServerAddress {
+	if !fp.balanceReads {
+		return fp.Current()
+	}
+	var healthy []pb.ServerAddress
+	for _, f := range fp.filers {
+		if !f.unreachable.Load() {
+			healthy = append(healthy, f.address)
+		}
+	}
+	if len(healthy) == 0 {
+		return fp.Current()
+	}
+	return healthy[fp.nextRead.Add(1)%uint32(len(healthy))]
+}
+
+// Others returns the filers other than the given one, the healthy ones first, to retry a failed request
+func (fp *FilerPool) Others(address pb.ServerAddress) (others []pb.ServerAddress) {
+	var unreachable []pb.ServerAddress
+	for _, f := range fp.filers {
+		if f.address == address {
+			continue
+		}
+		if f.unreachable.Load() {
+			unreachable = append(unreachable, f.address)
+		} else {
+			others = append(others, f.address)
+		}
+	}
+	return append(others, unreachable...)
+}
+
+// MarkUnreachable moves the current filer to the next healthy one if the failed filer is the current one
+func (fp *FilerPool) MarkUnreachable(address pb.ServerAddress) {
+	n := int32(len(fp.filers))
+	for i, f := range fp.filers {
+		if f.address != address {
+			continue
+		}
+		if !f.unreachable.Swap(true) {
+			glog.Warningf("filer %s is unreachable", address)
+		}
+		for x := int32(1); x < n; x++ {
+			next := (int32(i) + x) % n
+			if !fp.filers[next].unreachable.Load() {
+				if fp.current.CompareAndSwap(int32(i), next) {
+					glog.V(0).Infof("switch from filer %s to %s", address, fp.filers[next].address)
+				}
+				return
+			}
+		}
+		return
+	}
+}
+
+func (fp *FilerPool) markReachable(i int32) {
+	if fp.filers[i].unreachable.Swap(false) {
+		glog.V(0).Infof("filer %s is reachable again", fp.filers[i].address)
+	}
+}
+
+// WithFilerClient runs fn on the current filer, and fails over to the other filers only if the filer is unreachable,
+// so the errors returned by the filer are not retried
+func (fp *FilerPool) WithFilerClient(streamingMode bool, signature int32, grpcDialOption grpc.DialOption, fn func(filer_pb.SeaweedFilerClient) error) (err error) {
+	i := fp.current.Load()
+	n := int32(len(fp.filers))
+	for x := int32(0); x < n; x++ {
+		address := fp.filers[i].address
+		err = pb.WithGrpcClient(streamingMode, signature, func(grpcConnection *grpc.ClientConn) error {
+			return fn(filer_pb.NewSeaweedFilerClient(grpcConnection))
+		}, address.ToGrpcAddress(), false, grpcDialOption)
+		if !isFilerUnreachable(err) {
+			return err
+		}
+		glog.V(0).Infof("filer %s: %v", address, err)
+		fp.MarkUnreachable(address)
+		i = (i + 1) % n
+	}
+	return err
+}
+
+// StartHealthCheck pings the filers periodically, to fail over before the requests fail and to use the recovered filers again
+func (fp *FilerPool) StartHealthCheck(grpcDialOption grpc.DialOption) {
+	if len(fp.filers) <= 1 {
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(filerHealthCheckInterval)
+			for i, f := range fp.filers {
+				err := pb.WithGrpcClient(false, 0, func(grpcConnection *grpc.ClientConn) error {
+					ctx, cancel := context.WithTimeout(context.Background(), filerHealthCheckTimeout)
+					defer cancel()
+					_, pingErr := filer_pb.NewSeaweedFilerClient(grpcConnection).Ping(ctx, &filer_pb.PingRequest{})
+					return pingErr
+				}, f.address.ToGrpcAddress(), false, grpcDialOption)
+				if err != nil {
+					fp.MarkUnreachable(f.address)
+				} else {
+					fp.markReachable(int32(i))
+				}
+			}
+		}
+	}()
+}
+
+func isFilerUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if status.Code(err) == codes.Unavailable {
+		return true
+	}
+	// the grpc errors are often wrapped as text
+	message := err.Error()
+	return strings.Contains(message, "getOrCreateConnection") ||
+		strings.Contains(message, "fail to dial") ||
+		strings.Contains(message, "code = Unavailable") ||
+		strings.Contains(message, "transport") ||
+		strings.Contains(message, "connection closed")
+}
@@ -0,0 +1,54 @@
+package s3api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFilerPoolFailover(t *testing.T) {
+	fp := NewFilerPool([]pb.ServerAddress{"f1:8888", "f2:8888", "f3:8888"}, false)
+	fp.current.Store(0)
+
+	assert.Equal(t, pb.ServerAddress("f1:8888"), fp.Current())
+	assert.Equal(t, pb.ServerAddress("f1:8888"), fp.ForRead(), "reads stay on the current filer")
+
+	fp.MarkUnreachable("f1:8888")
+	assert.Equal(t, pb.ServerAddress("f2:8888"), fp.Current())
+	assert.Equal(t, []pb.ServerAddress{"f3:8888", "f1:8888"}, fp.Others("f2:8888"), "unreachable filers are retried last")
+
+	// marking a filer other than the current one keeps the current filer
+	fp.MarkUnreachable("f3:8888")
+	assert.Equal(t, pb.ServerAddress("f2:8888"), fp.Current())
+
+	// no healthy filer to switch to
+	fp.MarkUnreachable("f2:8888")
+	assert.Equal(t, pb.ServerAddress("f2:8888"), fp.Current())
+
+	fp.markReachable(0)
+	fp.MarkUnreachable("f2:8888")
+	assert.Equal(t, pb.ServerAddress("f1:8888"), fp.Current())
+}
+
+func TestFilerPoolBalanceReads(t *testing.T) {
+	fp := NewFilerPool([]pb.ServerAddress{"f1:8888", "f2:8888", "f3:8888"}, true)
+	fp.MarkUnreachable("f2:8888")
+
+	seen := make(map[pb.ServerAddress]int)
+	for i := 0; i < 6; i++ {
+		seen[fp.ForRead()]++
+	}
+	assert.Equal(t, map[pb.ServerAddress]int{"f1:8888": 3, "f3:8888": 3}, seen)
+}
+
+func TestIsFilerUnreachable(t *testing.T) {
+	assert.False(t, isFilerUnreachable(nil))
+	assert.False(t, isFilerUnreachable(errors.New("entry not found")))
+	assert.False(t, isFilerUnreachable(status.Error(codes.NotFound, "not found")))
+	assert.True(t, isFilerUnreachable(status.Error(codes.Unavailable, "connection refused")))
+	assert.True(t, isFilerUnreachable(errors.New("getOrCreateConnection f1:18888: context deadline exceeded")))
+}
@@ -0,0 +1,479 @@
+package s3api
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/kms"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+// The objects are encrypted by the s3 gateway with AES-256 in the counter mode, so the encrypted object has the same
+// size as the object, and any byte range of it can be read from the filer and decrypted.
+// The counter block is the nonce followed by the part number and the block index within the part.
+// The parts of a multipart upload are encrypted independently by the same data key, each upload of a part
+// with its own random nonce, so uploading a part again never reuses the key stream.
+
+const (
+	sseAlgorithmAES256 = "AES256"
+	sseAlgorithmKMS    = "aws:kms"
+	sseNonceSize       = 8
+)
+
+var errContentMd5Mismatch = errors.New("the Content-Md5 you specified did not match what we received")
+
+// sseCustomerKeyHeaders are the headers of a customer provided key
+type sseCustomerKeyHeaders struct {
+	algorithm string
+	key       string
+	keyMD5    string
+}
+
+var (
+	sseCustomerHeaders = sseCustomerKeyHeaders{
+		algorithm: s3_constants.AmzServerSideEncryptionCustomerAlgorithm,
+		key:       s3_constants.AmzServerSideEncryptionCustomerKey,
+		keyMD5:    s3_constants.AmzServerSideEncryptionCustomerKeyMD5,
+	}
+	copySourceSseCustomerHeaders = sseCustomerKeyHeaders{
+		algorithm: s3_constants.AmzCopySourceServerSideEncryptionCustomerAlgorithm,
+		key:       s3_constants.AmzCopySourceServerSideEncryptionCustomerKey,
+		keyMD5:    s3_constants.AmzCopySourceServerSideEncryptionCustomerKeyMD5,
+	}
+)
+
+type ssePart struct {
+	Number int
+	Size   int64
+	Nonce  []byte // the nonce of the part upload
+}
+
+// objectEncryption is the server side encryption of an object
+type objectEncryption struct {
+	Algorithm        string // AES256 or aws:kms, also AES256 with the customer provided keys
+	KmsKeyId         string
+	CustomerKeyMD5   string // only with the customer provided keys
+	EncryptedDataKey []byte // only with the kms managed keys
+	Nonce            []byte
+	Parts            []ssePart // only for the completed multipart uploads
+	dataKey          []byte
+}
+
+func (e *objectEncryption) isCustomerKey() bool {
+	return e.CustomerKeyMD5 != ""
+}
+
+// isS3ManagedKey is SSE-S3, whose etag is the md5 of the object like not encrypted
+func (e *objectEncryption) isS3ManagedKey() bool {
+	return e.Algorithm == sseAlgorithmAES256 && !e.isCustomerKey()
+}
+
+func newSseNonce() ([]byte, error) {
+	nonce := make([]byte, sseNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %v", err)
+	}
+	return nonce, nil
+}
+
+// forPartUpload copies the encryption of the multipart upload with a new nonce, to encrypt one upload of a part.
+// The nonce is saved with the part, and kept in the parts of the object when the upload is completed.
+func (e *objectEncryption) forPartUpload() (*objectEncryption, error) {
+	nonce, err := newSseNonce()
+	if err != nil {
+		return nil, err
+	}
+	partEncryption := *e
+	partEncryption.Nonce = nonce
+	return &partEncryption, nil
+}
+
+// newObjectEncryption creates the encryption requested by the headers of a write, or nil if not requested
+func (s3a *S3ApiServer) newObjectEncryption(ctx context.Context, header http.Header) (*objectEncryption, s3err.ErrorCode) {
+	algorithm := header.Get(s3_constants.AmzServerSideEncryption)
+	customerKey, customerKeyMD5, errCode := parseCustomerKey(header, sseCustomerHeaders)
+	if errCode != s3err.ErrNone {
+		return nil, errCode
+	}
+	if customerKey == nil && algorithm == "" {
+		return nil, s3err.ErrNone
+	}
+
+	nonce, err := newSseNonce()
+	if err != nil {
+		glog.Error(err)
+		return nil, s3err.ErrInternalError
+	}
+	e := &objectEncryption{
+		Algorithm: sseAlgorithmAES256,
+		Nonce:     nonce,
+	}
+
+	if customerKey != nil {
+		if algorithm != "" {
+			return nil, s3err.ErrInvalidEncryptionAlgorithm
+		}
+		e.CustomerKeyMD5, e.dataKey = customerKeyMD5, customerKey
+		return e, s3err.ErrNone
+	}
+
+	switch algorithm {
+	case sseAlgorithmAES256:
+	case sseAlgorithmKMS:
+		e.Algorithm = sseAlgorithmKMS
+		e.KmsKeyId = header.Get(s3_constants.AmzServerSideEncryptionAwsKmsKeyId)
+	default:
+		return nil, s3err.ErrInvalidEncryptionAlgorithm
+	}
+	if s3a.kms == nil {
+		return nil, s3err.ErrKMSNotConfigured
+	}
+	if e.KmsKeyId == "" {
+		e.KmsKeyId = s3a.kms.DefaultKeyId()
+	}
+	if e.dataKey, e.EncryptedDataKey, err = s3a.kms.GenerateDataKey(ctx, e.KmsKeyId); err != nil {
+		return nil, kmsErrorToS3Error(e.KmsKeyId, err)
+	}
+	return e, s3err.ErrNone
+}
+
+// parseCustomerKey parses and verifies the customer provided key, or returns nil if not provided
+func parseCustomerKey(header http.Header, headers sseCustomerKeyHeaders) (key []byte, keyMD5 string, errCode s3err.ErrorCode) {
+	algorithm, encodedKey, keyMD5 := header.Get(headers.algorithm), header.Get(headers.key), header.Get(headers.keyMD5)
+	if algorithm == "" && encodedKey == "" && keyMD5 == "" {
+		return nil, "", s3err.ErrNone
+	}
+	if algorithm != sseAlgorithmAES256 {
+		return nil, "", s3err.ErrInvalidEncryptionAlgorithm
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != kms.DataKeySize {
+		return nil, "", s3err.ErrSSECustomerKeyInvalid
+	}
+	sum := md5.Sum(key)
+	if keyMD5 != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, "", s3err.ErrSSECustomerKeyMD5Mismatch
+	}
+	return key, keyMD5, s3err.ErrNone
+}
+
+// objectEncryptionOf reads the encryption of an object from its metadata, or returns nil if it is not encrypted
+func objectEncryptionOf(header http.Header) (e *objectEncryption, err error) {
+	encodedNonce := header.Get(s3_constants.ExtSseNonceKey)
+	if encodedNonce == "" {
+		return nil, nil
+	}
+	e = &objectEncryption{
+		Algorithm:      header.Get(s3_constants.ExtSseAlgorithmKey),
+		KmsKeyId:       header.Get(s3_constants.ExtSseKmsKeyIdKey),
+		CustomerKeyMD5: header.Get(s3_constants.ExtSseCustomerKeyMD5Key),
+	}
+	if e.Nonce, err = base64.StdEncoding.DecodeString(encodedNonce); err != nil || len(e.Nonce) != sseNonceSize {
+		return nil, fmt.Errorf("invalid nonce %s", encodedNonce)
+	}
+	if e.EncryptedDataKey, err = base64.StdEncoding.DecodeString(header.Get(s3_constants.ExtSseDataKeyKey)); err != nil {
+		return nil, fmt.Errorf("invalid data key: %v", err)
+	}
+	if e.Parts, err = parseSseParts(header.Get(s3_constants.ExtSsePartsKey)); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// objectEncryptionOfExtended reads the encryption of an object from its extended attributes
+func objectEncryptionOfExtended(extended map[string][]byte) (*objectEncryption, error) {
+	header := make(http.Header)
+	for k, v := range extended {
+		if strings.HasPrefix(k, s3_constants.ExtSsePrefix) {
+			header.Set(k, string(v))
+		}
+	}
+	return objectEncryptionOf(header)
+}
+
+func parseSseParts(value string) (parts []ssePart, err error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Split(part, ":")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid part %s", part)
+		}
+		var p ssePart
+		if p.Number, err = strconv.Atoi(fields[0]); err != nil {
+			return nil, fmt.Errorf("invalid part %s: %v", part, err)
+		}
+		if p.Size, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid part %s: %v", part, err)
+		}
+		if p.Nonce, err = base64.StdEncoding.DecodeString(fields[2]); err != nil || len(p.Nonce) != sseNonceSize {
+			return nil, fmt.Errorf("invalid part %s nonce", part)
+		}
+		parts = append(parts, p)
+	}
+	return parts, nil
+}
+
+func formatSseParts(parts []ssePart) string {
+	var values []string
+	for _, part := range parts {
+		values = append(values, fmt.Sprintf("%d:%d:%s", part.Number, part.Size, base64.StdEncoding.EncodeToString(part.Nonce)))
+	}
+	return strings.Join(values, ",")
+}
+
+// unlock gets the data key of the object, decrypted by the kms or provided by the customer in the headers
+func (s3a *S3ApiServer) unlock(ctx context.Context, e *objectEncryption, header http.Header, headers sseCustomerKeyHeaders) s3err.ErrorCode {
+	if e.isCustomerKey() {
+		key, keyMD5, errCode := parseCustomerKey(header, headers)
+		if errCode != s3err.ErrNone {
+			return errCode
+		}
+		if key == nil {
+			return s3err.ErrSSECustomerKeyRequired
+		}
+		if keyMD5 != e.CustomerKeyMD5 {
+			return s3err.ErrAccessDenied
+		}
+		e.dataKey = key
+		return s3err.ErrNone
+	}
+	if s3a.kms == nil {
+		return s3err.ErrKMSNotConfigured
+	}
+	dataKey, err := s3a.kms.DecryptDataKey(ctx, e.KmsKeyId, e.EncryptedDataKey)
+	if err != nil {
+		return kmsErrorToS3Error(e.KmsKeyId, err)
+	}
+	e.dataKey = dataKey
+	return s3err.ErrNone
+}
+
+func kmsErrorToS3Error(keyId string, err error) s3err.ErrorCode {
+	if errors.Is(err, kms.ErrKeyNotFound) {
+		return s3err.ErrKMSKeyNotFound
+	}
+	glog.Errorf("kms key %s: %v", keyId, err)
+	return s3err.ErrInternalError
+}
+
+// setMetadataHeaders sets the headers saved by the filer as the metadata of the object
+func (e *objectEncryption) setMetadataHeaders(header http.Header) {
+	header.Set(s3_constants.ExtSseAlgorithmKey, e.Algorithm)
+	header.Set(s3_constants.ExtSseNonceKey, base64.StdEncoding.EncodeToString(e.Nonce))
+	if e.isCustomerKey() {
+		header.Set(s3_constants.ExtSseCustomerKeyMD5Key, e.CustomerKeyMD5)
+	} else {
+		header.Set(s3_constants.ExtSseKmsKeyIdKey, e.KmsKeyId)
+		header.Set(s3_constants.ExtSseDataKeyKey, base64.StdEncoding.EncodeToString(e.EncryptedDataKey))
+	}
+}
+
+// setResponseHeaders sets the s3 headers describing the encryption of the object
+func (e *objectEncryption) setResponseHeaders(header http.Header) {
+	if e.isCustomerKey() {
+		header.Set(s3_constants.AmzServerSideEncryptionCustomerAlgorithm, e.Algorithm)
+		header.Set(s3_constants.AmzServerSideEncryptionCustomerKeyMD5, e.CustomerKeyMD5)
+		return
+	}
+	header.Set(s3_constants.AmzServerSideEncryption, e.Algorithm)
+	if e.Algorithm == sseAlgorithmKMS {
+		header.Set(s3_constants.AmzServerSideEncryptionAwsKmsKeyId, e.KmsKeyId)
+	}
+}
+
+// removeEncryptionHeaders removes the encryption metadata and the customer provided keys
+func removeEncryptionHeaders(header http.Header) {
+	for k := range header {
+		if strings.HasPrefix(k, s3_constants.ExtSsePrefix) ||
+			k == s3_constants.AmzServerSideEncryptionCustomerKey || k == s3_constants.AmzCopySourceServerSideEncryptionCustomerKey {
+			delete(header, k)
+		}
+	}
+}
+
+// newStream starts the key stream at the offset within the part
+func (e *objectEncryption) newStream(part ssePart, offset int64) (cipher.Stream, error) {
+	block, err := aes.NewCipher(e.dataKey)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	// the object which is not uploaded in parts uses the object nonce
+	if part.Nonce != nil {
+		copy(iv, part.Nonce)
+	} else {
+		copy(iv, e.Nonce)
+	}
+	binary.BigEndian.PutUint64(iv[sseNonceSize:], uint64(part.Number)<<32+uint64(offset/aes.BlockSize))
+	stream := cipher.NewCTR(block, iv)
+	if skip := offset % aes.BlockSize; skip > 0 {
+		discard := make([]byte, skip)
+		stream.XORKeyStream(discard, discard)
+	}
+	return stream, nil
+}
+
+// encrypt encrypts the object, or the part of a multipart upload with the encryption for the part upload
+func (e *objectEncryption) encrypt(reader io.Reader, partNumber int) (io.Reader, error) {
+	stream, err := e.newStream(ssePart{Number: partNumber}, 0)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.StreamReader{S: stream, R: reader}, nil
+}
+
+// decrypt decrypts the object read from the offset
+func (e *objectEncryption) decrypt(reader io.ReadCloser, offset int64) io.ReadCloser {
+	return &decryptingReader{
+		encryption: e,
+		reader:     reader,
+		offset:     offset,
+	}
+}
+
+// locatePart finds the part containing the offset of the object, and the offset where the part ends, or -1 if unbounded
+func (e *objectEncryption) locatePart(offset int64) (part ssePart, partOffset, partEnd int64) {
+	var start int64
+	for i, part := range e.Parts {
+		if i == len(e.Parts)-1 {
+			return part, offset - start, -1
+		}
+		if offset < start+part.Size {
+			return part, offset - start, start + part.Size
+		}
+		start += part.Size
+	}
+	return ssePart{}, offset, -1
+}
+
+type decryptingReader struct {
+	encryption *objectEncryption
+	reader     io.ReadCloser
+	offset     int64
+	partEnd    int64
+	stream     cipher.Stream
+}
+
+func (r *decryptingReader) Read(p []byte) (n int, err error) {
+	if r.stream == nil || r.offset == r.partEnd {
+		part, partOffset, partEnd := r.encryption.locatePart(r.offset)
+		if r.stream, err = r.encryption.newStream(part, partOffset); err != nil {
+			return 0, err
+		}
+		r.partEnd = partEnd
+	}
+	if r.partEnd >= 0 && int64(len(p)) > r.partEnd-r.offset {
+		p = p[:r.partEnd-r.offset]
+	}
+	n, err = r.reader.Read(p)
+	r.stream.XORKeyStream(p[:n], p[:n])
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *decryptingReader) Close() error {
+	return r.reader.Close()
+}
+
+// md5VerifyingReader verifies the Content-Md5 of the object, which the filer can not verify on the encrypted object
+type md5VerifyingReader struct {
+	reader     io.Reader
+	hash       hash.Hash
+	contentMd5 string
+	mismatched bool
+}
+
+func (r *md5VerifyingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && base64.StdEncoding.EncodeToString(r.hash.Sum(nil)) != r.contentMd5 {
+		r.mismatched = true
+		return n, errContentMd5Mismatch
+	}
+	return n, err
+}
+
+// md5TrailerReader reads the encrypted object, and sets the md5 of the object before encryption in the trailer at the end
+type md5TrailerReader struct {
+	reader  io.Reader
+	hash    hash.Hash // fed with the object before encryption
+	trailer http.Header
+}
+
+func (r *md5TrailerReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	if err == io.EOF {
+		// the trailer must be set before the EOF is returned to the http client
+		r.trailer.Set(s3_constants.SeaweedPlaintextMd5Header, base64.StdEncoding.EncodeToString(r.hash.Sum(nil)))
+	}
+	return n, err
+}
+
+// decryptingResponse decrypts the encrypted objects read from the filer, unlocked by the headers of the request
+func (s3a *S3ApiServer) decryptingResponse(r *http.Request) func(proxyResponse *http.Response, w http.ResponseWriter) (statusCode int, bytesTransferred int64) {
+	return func(proxyResponse *http.Response, w http.ResponseWriter) (statusCode int, bytesTransferred int64) {
+		e, err := objectEncryptionOf(proxyResponse.Header)
+		if err != nil {
+			glog.Errorf("read encryption of %s: %v", r.URL.Path, err)
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return http.StatusInternalServerError, 0
+		}
+		if e == nil {
+			return passThroughResponse(proxyResponse, w)
+		}
+
+		isReading := r.Method != http.MethodHead && (proxyResponse.StatusCode == http.StatusOK || proxyResponse.StatusCode == http.StatusPartialContent)
+		if isReading && strings.Contains(r.Header.Get("Range"), ",") {
+			s3err.WriteErrorResponse(w, r, s3err.ErrNotImplemented)
+			return http.StatusNotImplemented, 0
+		}
+		// only the customer provided keys are verified without reading the object
+		if isReading || e.isCustomerKey() {
+			if errCode := s3a.unlock(r.Context(), e, r.Header, sseCustomerHeaders); errCode != s3err.ErrNone {
+				s3err.WriteErrorResponse(w, r, errCode)
+				return s3err.GetAPIError(errCode).HTTPStatusCode, 0
+			}
+		}
+
+		removeEncryptionHeaders(proxyResponse.Header)
+		e.setResponseHeaders(proxyResponse.Header)
+		if isReading {
+			offset, err := contentRangeStart(proxyResponse.Header.Get("Content-Range"))
+			if err != nil {
+				glog.Errorf("read %s: %v", r.URL.Path, err)
+				s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+				return http.StatusInternalServerError, 0
+			}
+			proxyResponse.Body = e.decrypt(proxyResponse.Body, offset)
+		}
+		return passThroughResponse(proxyResponse, w)
+	}
+}
+
+// contentRangeStart returns the start of the Content-Range, e.g., 100 of "bytes 100-199/1000", or 0 without a range
+func contentRangeStart(contentRange string) (int64, error) {
+	if contentRange == "" {
+		return 0, nil
+	}
+	start, _, found := strings.Cut(strings.TrimPrefix(contentRange, "bytes "), "-")
+	if !found {
+		return 0, fmt.Errorf("invalid Content-Range %s", contentRange)
+	}
+	return strconv.ParseInt(start, 10, 64)
+}
@@ -0,0 +1,241 @@
+package s3api
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
+)
+
+func newTestEncryption(t *testing.T) *objectEncryption {
+	e := &objectEncryption{
+		Algorithm: sseAlgorithmAES256,
+		Nonce:     make([]byte, sseNonceSize),
+		dataKey:   make([]byte, 32),
+	}
+	if _, err := rand.Read(e.Nonce); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rand.Read(e.dataKey); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func encryptForTest(t *testing.T, e *objectEncryption, data []byte, partNumber int) []byte {
+	reader, err := e.encrypt(bytes.NewReader(data), partNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encrypted
+}
+
+func TestObjectEncryptionRanges(t *testing.T) {
+	e := newTestEncryption(t)
+	data := make([]byte, 1000)
+	rand.Read(data)
+
+	encrypted := encryptForTest(t, e, data, 0)
+	if len(encrypted) != len(data) || bytes.Equal(encrypted, data) {
+		t.Fatalf("unexpected encrypted data")
+	}
+	for _, offset := range []int64{0, 1, 15, 16, 17, 999} {
+		decrypted, err := io.ReadAll(e.decrypt(io.NopCloser(bytes.NewReader(encrypted[offset:])), offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, data[offset:]) {
+			t.Errorf("unexpected data decrypted from offset %d", offset)
+		}
+	}
+}
+
+func TestObjectEncryptionParts(t *testing.T) {
+	e := newTestEncryption(t)
+	part1, part2 := make([]byte, 100), make([]byte, 57)
+	rand.Read(part1)
+	rand.Read(part2)
+
+	// the parts are encrypted separately, and completed as one object
+	e1, err := e.forPartUpload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, err := e.forPartUpload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := append(encryptForTest(t, e1, part1, 1), encryptForTest(t, e2, part2, 2)...)
+	data := append(append([]byte{}, part1...), part2...)
+	e.Parts = []ssePart{{Number: 1, Size: int64(len(part1)), Nonce: e1.Nonce}, {Number: 2, Size: int64(len(part2)), Nonce: e2.Nonce}}
+
+	for _, offset := range []int64{0, 50, 99, 100, 101, 156} {
+		decrypted, err := io.ReadAll(e.decrypt(io.NopCloser(bytes.NewReader(encrypted[offset:])), offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, data[offset:]) {
+			t.Errorf("unexpected data decrypted from offset %d", offset)
+		}
+	}
+
+	parts, err := parseSseParts(formatSseParts(e.Parts))
+	if err != nil || !reflect.DeepEqual(parts, e.Parts) {
+		t.Errorf("unexpected parts %+v: %v", parts, err)
+	}
+}
+
+func TestObjectEncryptionPartUploadedAgain(t *testing.T) {
+	e := newTestEncryption(t)
+	data := make([]byte, 100)
+
+	// the same part uploaded twice never shares the key stream
+	first, err := e.forPartUpload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := e.forPartUpload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first.Nonce, second.Nonce) || bytes.Equal(first.Nonce, e.Nonce) {
+		t.Fatalf("the part uploads share the nonce")
+	}
+	if bytes.Equal(encryptForTest(t, first, data, 1), encryptForTest(t, second, data, 1)) {
+		t.Errorf("the part uploads share the key stream")
+	}
+}
+
+func TestParseSsePartsInvalid(t *testing.T) {
+	// every part has the nonce of its upload
+	for _, invalid := range []string{"1", "1:30", "1:30:AA==", "1:30:AAECAwQFBgc=:x", "x:30:AAECAwQFBgc="} {
+		if _, err := parseSseParts(invalid); err == nil {
+			t.Errorf("parsed the invalid parts %s", invalid)
+		}
+	}
+}
+
+func TestObjectEncryptionMetadata(t *testing.T) {
+	e := newTestEncryption(t)
+	e.Algorithm, e.KmsKeyId, e.EncryptedDataKey = sseAlgorithmKMS, "key1", []byte("encrypted")
+
+	header := make(http.Header)
+	e.setMetadataHeaders(header)
+	parsed, err := objectEncryptionOf(header)
+	if err != nil || parsed == nil {
+		t.Fatalf("parse %v: %v", header, err)
+	}
+	if parsed.Algorithm != e.Algorithm || parsed.KmsKeyId != e.KmsKeyId || !bytes.Equal(parsed.Nonce, e.Nonce) ||
+		!bytes.Equal(parsed.EncryptedDataKey, e.EncryptedDataKey) || parsed.isCustomerKey() {
+		t.Errorf("unexpected encryption %+v", parsed)
+	}
+
+	removeEncryptionHeaders(header)
+	if parsed, err = objectEncryptionOf(header); parsed != nil || err != nil {
+		t.Errorf("unexpected encryption %+v: %v", parsed, err)
+	}
+}
+
+func TestCustomerKey(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	sum := md5.Sum(key)
+	keyMD5 := base64.StdEncoding.EncodeToString(sum[:])
+
+	header := make(http.Header)
+	header.Set(s3_constants.AmzServerSideEncryptionCustomerAlgorithm, sseAlgorithmAES256)
+	header.Set(s3_constants.AmzServerSideEncryptionCustomerKey, base64.StdEncoding.EncodeToString(key))
+	header.Set(s3_constants.AmzServerSideEncryptionCustomerKeyMD5, keyMD5)
+
+	s3a := &S3ApiServer{}
+	e, errCode := s3a.newObjectEncryption(context.Background(), header)
+	if errCode != s3err.ErrNone || e == nil || !e.isCustomerKey() || e.CustomerKeyMD5 != keyMD5 {
+		t.Fatalf("unexpected encryption %+v: %v", e, errCode)
+	}
+
+	// reading needs the same key
+	stored, _ := objectEncryptionOfExtended(map[string][]byte{
+		s3_constants.ExtSseAlgorithmKey:      []byte(e.Algorithm),
+		s3_constants.ExtSseNonceKey:          []byte(base64.StdEncoding.EncodeToString(e.Nonce)),
+		s3_constants.ExtSseCustomerKeyMD5Key: []byte(e.CustomerKeyMD5),
+	})
+	if errCode = s3a.unlock(context.Background(), stored, header, sseCustomerHeaders); errCode != s3err.ErrNone || !bytes.Equal(stored.dataKey, key) {
+		t.Errorf("unlock: %v", errCode)
+	}
+	if errCode = s3a.unlock(context.Background(), stored, make(http.Header), sseCustomerHeaders); errCode != s3err.ErrSSECustomerKeyRequired {
+		t.Errorf("unexpected error %v without the key", errCode)
+	}
+	otherKey := make([]byte, 32)
+	otherSum := md5.Sum(otherKey)
+	header.Set(s3_constants.AmzServerSideEncryptionCustomerKey, base64.StdEncoding.EncodeToString(otherKey))
+	header.Set(s3_constants.AmzServerSideEncryptionCustomerKeyMD5, base64.StdEncoding.EncodeToString(otherSum[:]))
+	if errCode = s3a.unlock(context.Background(), stored, header, sseCustomerHeaders); errCode != s3err.ErrAccessDenied {
+		t.Errorf("unexpected error %v with another key", errCode)
+	}
+
+	header.Set(s3_constants.AmzServerSideEncryptionCustomerKeyMD5, keyMD5)
+	if _, errCode = s3a.newObjectEncryption(context.Background(), header); errCode != s3err.ErrSSECustomerKeyMD5Mismatch {
+		t.Errorf("unexpected error %v with the mismatched md5", errCode)
+	}
+
+	// the managed keys need a kms
+	header = make(http.Header)
+	header.Set(s3_constants.AmzServerSideEncryption, sseAlgorithmKMS)
+	if _, errCode = s3a.newObjectEncryption(context.Background(), header); errCode != s3err.ErrKMSNotConfigured {
+		t.Errorf("unexpected error %v without a kms", errCode)
+	}
+}
+
+func TestPutEncryptedObjectEtag(t *testing.T) {
+	data := []byte("the object to encrypt")
+	plaintextMd5 := md5.Sum(data)
+
+	var received []byte
+	var trailerMd5 string
+	filer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		trailerMd5 = r.Trailer.Get(s3_constants.SeaweedPlaintextMd5Header)
+		json.NewEncoder(w).Encode(weed_server.FilerPostResult{Size: int64(len(received))})
+	}))
+	defer filer.Close()
+	s3a := &S3ApiServer{option: &S3ApiServerOption{}, filerGuard: &security.Guard{}, client: &http.Client{}}
+
+	for _, algorithm := range []string{sseAlgorithmAES256, sseAlgorithmKMS} {
+		e := newTestEncryption(t)
+		e.Algorithm = algorithm
+		r := httptest.NewRequest(http.MethodPut, "/bucket/object", nil)
+		etag, errCode := s3a.putToFiler(r, filer.URL+"/buckets/bucket/object", bytes.NewReader(data), "", "bucket", "", e, 0)
+		if errCode != s3err.ErrNone {
+			t.Fatalf("%s put: %v", algorithm, errCode)
+		}
+		if bytes.Equal(received, data) {
+			t.Fatalf("%s object is not encrypted", algorithm)
+		}
+		encryptedMd5 := md5.Sum(received)
+		if algorithm == sseAlgorithmAES256 {
+			// SSE-S3 objects keep the md5 of the object as the etag
+			if etag != hex.EncodeToString(plaintextMd5[:]) || trailerMd5 != base64.StdEncoding.EncodeToString(plaintextMd5[:]) {
+				t.Errorf("unexpected SSE-S3 etag %s with the trailer %q", etag, trailerMd5)
+			}
+		} else if etag != hex.EncodeToString(encryptedMd5[:]) || trailerMd5 != "" {
+			t.Errorf("unexpected SSE-KMS etag %s with the trailer %q", etag, trailerMd5)
+		}
+	}
+}
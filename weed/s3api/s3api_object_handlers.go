@@ -106,7 +106,7 @@ func newListEntry(entry *filer_pb.Entry, key string, dir string, name string, bu
 func (s3a *S3ApiServer) toFilerUrl(bucket, object string) string {
 	object = urlPathEscape(removeDuplicateSlashes(object))
 	destUrl := fmt.Sprintf("http://%s%s/%s%s",
-		s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, bucket, object)
+		s3a.filers.Current().ToHttpAddress(), s3a.option.BucketsPath, bucket, object)
 	return destUrl
 }
 
@@ -121,8 +121,15 @@ func (s3a *S3ApiServer) GetObjectHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	destUrl := s3a.toFilerUrl(bucket, object)
+	if versionId := r.URL.Query().Get("versionId"); versionId != "" {
+		var errCode s3err.ErrorCode
+		if destUrl, errCode = s3a.toFilerVersionUrl(w, bucket, object, versionId); errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+	}
 
-	s3a.proxyToFiler(w, r, destUrl, false, passThroughResponse)
+	s3a.proxyToFiler(w, r, destUrl, false, s3a.decryptingResponse(r))
 }
 
 func (s3a *S3ApiServer) HeadObjectHandler(w http.ResponseWriter, r *http.Request) {
@@ -131,8 +138,34 @@ func (s3a *S3ApiServer) HeadObjectHandler(w http.ResponseWriter, r *http.Request
 	glog.V(3).Infof("HeadObjectHandler %s %s", bucket, object)
 
 	destUrl := s3a.toFilerUrl(bucket, object)
+	if versionId := r.URL.Query().Get("versionId"); versionId != "" {
+		var errCode s3err.ErrorCode
+		if destUrl, errCode = s3a.toFilerVersionUrl(w, bucket, object, versionId); errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+	}
 
-	s3a.proxyToFiler(w, r, destUrl, false, passThroughResponse)
+	s3a.proxyToFiler(w, r, destUrl, false, s3a.decryptingResponse(r))
+}
+
+// readFromFilers sends the read request to the next filer, and retries the other filers if the filer is unreachable
+func (s3a *S3ApiServer) readFromFilers(proxyReq *http.Request) (resp *http.Response, err error) {
+	filerAddress := s3a.filers.ForRead()
+	proxyReq.URL.Host, proxyReq.Host = filerAddress.ToHttpAddress(), ""
+	if resp, err = s3a.client.Do(proxyReq); err == nil {
+		return
+	}
+	for _, other := range s3a.filers.Others(filerAddress) {
+		glog.V(0).Infof("read from filer %s: %v", filerAddress, err)
+		s3a.filers.MarkUnreachable(filerAddress)
+		filerAddress = other
+		proxyReq.URL.Host = filerAddress.ToHttpAddress()
+		if resp, err = s3a.client.Do(proxyReq); err == nil {
+			return
+		}
+	}
+	return
 }
 
 func (s3a *S3ApiServer) proxyToFiler(w http.ResponseWriter, r *http.Request, destUrl string, isWrite bool, responseFn func(proxyResponse *http.Response, w http.ResponseWriter) (statusCode int, bytesTransferred int64)) {
@@ -161,6 +194,8 @@ func (s3a *S3ApiServer) proxyToFiler(w http.ResponseWriter, r *http.Request, des
 	for header, values := range r.Header {
 		proxyReq.Header[header] = values
 	}
+	// the objects are decrypted by the s3 gateway, the filer needs no keys
+	removeEncryptionHeaders(proxyReq.Header)
 	if proxyReq.ContentLength == 0 && r.ContentLength != 0 {
 		proxyReq.ContentLength = r.ContentLength
 	}
@@ -168,7 +203,13 @@ func (s3a *S3ApiServer) proxyToFiler(w http.ResponseWriter, r *http.Request, des
 	// ensure that the Authorization header is overriding any previous
 	// Authorization header which might be already present in proxyReq
 	s3a.maybeAddFilerJwtAuthorization(proxyReq, isWrite)
-	resp, postErr := s3a.client.Do(proxyReq)
+	var resp *http.Response
+	var postErr error
+	if isWrite || s3a.option.LocalFilerSocket != "" {
+		resp, postErr = s3a.client.Do(proxyReq)
+	} else {
+		resp, postErr = s3a.readFromFilers(proxyReq)
+	}
 
 	if postErr != nil {
 		glog.Errorf("post to filer: %v", postErr)
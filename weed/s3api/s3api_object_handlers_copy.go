@@ -1,16 +1,21 @@
 package s3api
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
 	"modernc.org/strutil"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -32,14 +37,22 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 		// Save unescaped string as is.
 		cpSrcPath = r.Header.Get("X-Amz-Copy-Source")
 	}
+	cpSrcPath, srcVersionId := splitCopySourceVersionId(cpSrcPath)
 
 	srcBucket, srcObject := pathToBucketAndObject(cpSrcPath)
 
 	glog.V(3).Infof("CopyObjectHandler %s %s => %s %s", srcBucket, srcObject, dstBucket, dstObject)
 
 	replaceMeta, replaceTagging := replaceDirective(r.Header)
+	// the copy has its own retention and legal hold, never the ones of the source object
+	if errCode := s3a.objectLockForWrite(r.Header, dstBucket); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	// copying a previous version over the object restores it
+	isInPlace := srcBucket == dstBucket && srcObject == dstObject && srcVersionId == ""
 
-	if (srcBucket == dstBucket && srcObject == dstObject || cpSrcPath == "") && (replaceMeta || replaceTagging) {
+	if (isInPlace || cpSrcPath == "") && (replaceMeta || replaceTagging) {
 		fullPath := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, dstBucket, dstObject))
 		dir, name := fullPath.DirAndName()
 		entry, err := s3a.getEntry(dir, name)
@@ -47,6 +60,7 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 			s3err.WriteErrorResponse(w, r, s3err.ErrInvalidCopySource)
 			return
 		}
+		existing := entry.Extended
 		entry.Extended, err = processMetadataBytes(r.Header, entry.Extended, replaceMeta, replaceTagging)
 		entry.Attributes.Mtime = time.Now().Unix()
 		if err != nil {
@@ -54,11 +68,26 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 			s3err.WriteErrorResponse(w, r, s3err.ErrInvalidTag)
 			return
 		}
+		// the data is not changed, nor its encryption
+		for k, v := range existing {
+			if strings.HasPrefix(k, s3_constants.ExtSsePrefix) {
+				entry.Extended[k] = v
+			}
+		}
+		// the object with the replaced metadata is a new version
+		versionId := s3a.versionIdForWrite(dstBucket)
+		if s3a.getVersioningStatus(dstBucket) != "" {
+			setObjectVersionId(entry.Extended, versionId)
+		}
+		setEntryObjectLock(entry.Extended, r.Header)
+		s3a.markEntryReplicationPending(entry.Extended, dstBucket, dstObject)
 		err = s3a.touch(dir, name, entry)
 		if err != nil {
 			s3err.WriteErrorResponse(w, r, s3err.ErrInvalidCopySource)
 			return
 		}
+		setVersionIdHeader(w, versionId)
+		s3a.notifyObjectEvent(r, s3EventObjectCreatedCopy, dstBucket, dstObject, versionId)
 		writeSuccessResponseXML(w, r, CopyObjectResult{
 			ETag:         fmt.Sprintf("%x", entry.Attributes.Md5),
 			LastModified: time.Now().UTC(),
@@ -71,22 +100,53 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidCopySource)
 		return
 	}
+	encryption, errCode := s3a.newObjectEncryption(r.Context(), r.Header)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
 	srcPath := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, srcBucket, srcObject))
 	dir, name := srcPath.DirAndName()
-	if entry, err := s3a.getEntry(dir, name); err != nil || entry.IsDirectory {
+	var srcEntry *filer_pb.Entry
+	srcUrl := fmt.Sprintf("http://%s%s/%s%s",
+		s3a.filers.Current().ToHttpAddress(), s3a.option.BucketsPath, srcBucket, urlEscapeObject(srcObject))
+	if srcVersionId != "" {
+		var errCode s3err.ErrorCode
+		if srcUrl, errCode = s3a.toFilerVersionUrl(w, srcBucket, srcObject, srcVersionId); errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+		w.Header().Set("X-Amz-Copy-Source-Version-Id", srcVersionId)
+	} else if srcEntry, err = s3a.getEntry(dir, name); err != nil || srcEntry.IsDirectory {
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidCopySource)
 		return
 	}
 
-	if srcBucket == dstBucket && srcObject == dstObject {
+	if isInPlace {
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidCopyDest)
 		return
 	}
 
+	// within the bucket, share the reference counted chunks instead of copying the data,
+	// except in the versioned buckets, where each version owns its chunks,
+	// and unless the object is encrypted differently, or by a customer provided key
+	_, isSrcCustomerKey := srcEntry.GetExtended()[s3_constants.ExtSseCustomerKeyMD5Key]
+	if srcBucket == dstBucket && srcVersionId == "" && s3a.getVersioningStatus(dstBucket) == "" && encryption == nil && !isSrcCustomerKey {
+		etag, err := s3a.cloneObject(r, dstBucket, srcObject, dstObject, replaceMeta, replaceTagging)
+		if err == nil {
+			setEtag(w, etag)
+			s3a.notifyObjectEvent(r, s3EventObjectCreatedCopy, dstBucket, dstObject, "")
+			writeSuccessResponseXML(w, r, CopyObjectResult{
+				ETag:         etag,
+				LastModified: time.Now().UTC(),
+			})
+			return
+		}
+		glog.V(1).Infof("clone %s%s to %s: %v, copying the data instead", srcBucket, srcObject, dstObject, err)
+	}
+
 	dstUrl := fmt.Sprintf("http://%s%s/%s%s",
-		s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, dstBucket, urlEscapeObject(dstObject))
-	srcUrl := fmt.Sprintf("http://%s%s/%s%s",
-		s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, srcBucket, urlEscapeObject(srcObject))
+		s3a.filers.Current().ToHttpAddress(), s3a.option.BucketsPath, dstBucket, urlEscapeObject(dstObject))
 
 	_, _, resp, err := util_http.DownloadFile(srcUrl, s3a.maybeGetFilerJwtAuthorizationToken(false))
 	if err != nil {
@@ -95,14 +155,22 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 	}
 	defer util_http.CloseResponse(resp)
 
+	srcReader, errCode := s3a.decryptCopySource(r, resp.Header, resp.Body)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
 	tagErr := processMetadata(r.Header, resp.Header, replaceMeta, replaceTagging, s3a.getTags, dir, name)
 	if tagErr != nil {
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidCopySource)
 		return
 	}
+	s3a.markReplicationPending(r.Header, dstBucket, dstObject)
 	glog.V(2).Infof("copy from %s to %s", srcUrl, dstUrl)
 	destination := fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, dstBucket, dstObject)
-	etag, errCode := s3a.putToFiler(r, dstUrl, resp.Body, destination, dstBucket)
+	versionId := s3a.versionIdForWrite(dstBucket)
+	etag, errCode := s3a.putToFiler(r, dstUrl, srcReader, destination, dstBucket, versionId, encryption, 0)
 
 	if errCode != s3err.ErrNone {
 		s3err.WriteErrorResponse(w, r, errCode)
@@ -110,6 +178,12 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	setEtag(w, etag)
+	setVersionIdHeader(w, versionId)
+	if encryption != nil {
+		encryption.setResponseHeaders(w.Header())
+	}
+	s3a.maybeRemoveNullVersions(dstBucket, s3a.objectPath(dstBucket, dstObject))
+	s3a.notifyObjectEvent(r, s3EventObjectCreatedCopy, dstBucket, dstObject, versionId)
 
 	response := CopyObjectResult{
 		ETag:         etag,
@@ -120,6 +194,79 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 
 }
 
+// cloneObject copies the object within the bucket by sharing its chunks, with the metadata processed as a copy
+func (s3a *S3ApiServer) cloneObject(r *http.Request, bucket, srcObject, dstObject string, replaceMeta, replaceTagging bool) (etag string, err error) {
+	srcDir, srcName := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, srcObject)).DirAndName()
+	dstDir, dstName := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, dstObject)).DirAndName()
+
+	var resp *filer_pb.CloneEntryResponse
+	err = s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) (err error) {
+		resp, err = client.CloneEntry(context.Background(), &filer_pb.CloneEntryRequest{
+			SourceDirectory: srcDir,
+			SourceName:      srcName,
+			TargetDirectory: dstDir,
+			TargetName:      dstName,
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// an existing target keeps its own extended attributes, which are replaced by the ones of the source object
+	entry := resp.Entry
+	metadata, err := processMetadataBytes(r.Header, resp.SourceEntry.Extended, replaceMeta, replaceTagging)
+	if err != nil {
+		return "", err
+	}
+	extended := make(map[string][]byte)
+	for k, v := range resp.SourceEntry.Extended {
+		if k != s3_constants.AmzStorageClass && !strings.HasPrefix(k, s3_constants.AmzUserMetaPrefix) && !strings.HasPrefix(k, s3_constants.AmzObjectTagging) {
+			extended[k] = v
+		}
+	}
+	for k, v := range metadata {
+		extended[k] = v
+	}
+	setEntryObjectLock(extended, r.Header)
+	s3a.markEntryReplicationPending(extended, bucket, dstObject)
+	if !reflect.DeepEqual(entry.Extended, extended) {
+		entry.Extended = extended
+		if err = s3a.touch(dstDir, dstName, entry); err != nil {
+			return "", err
+		}
+	}
+
+	return filer.ETag(entry), nil
+}
+
+// decryptCopySource decrypts the copy source read from the offset in the Content-Range, if the source is encrypted
+func (s3a *S3ApiServer) decryptCopySource(r *http.Request, srcHeader http.Header, srcReader io.ReadCloser) (io.ReadCloser, s3err.ErrorCode) {
+	srcEncryption, err := objectEncryptionOf(srcHeader)
+	if err != nil {
+		glog.Errorf("read encryption of copy source %s: %v", r.Header.Get("X-Amz-Copy-Source"), err)
+		return nil, s3err.ErrInternalError
+	}
+	if srcEncryption == nil {
+		return srcReader, s3err.ErrNone
+	}
+	if errCode := s3a.unlock(r.Context(), srcEncryption, r.Header, copySourceSseCustomerHeaders); errCode != s3err.ErrNone {
+		return nil, errCode
+	}
+	offset, err := contentRangeStart(srcHeader.Get("Content-Range"))
+	if err != nil {
+		glog.Errorf("read copy source %s: %v", r.Header.Get("X-Amz-Copy-Source"), err)
+		return nil, s3err.ErrInternalError
+	}
+	return srcEncryption.decrypt(srcReader, offset), s3err.ErrNone
+}
+
+// splitCopySourceVersionId splits the version id from the copy source, e.g., "/bucket/key?versionId=id"
+func splitCopySourceVersionId(cpSrcPath string) (path, versionId string) {
+	path, versionId, _ = strings.Cut(cpSrcPath, "?versionId=")
+	return
+}
+
 func pathToBucketAndObject(path string) (bucket, object string) {
 	path = strings.TrimPrefix(path, "/")
 	parts := strings.SplitN(path, "/", 2)
@@ -145,6 +292,7 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 		// Save unescaped string as is.
 		cpSrcPath = r.Header.Get("X-Amz-Copy-Source")
 	}
+	cpSrcPath, srcVersionId := splitCopySourceVersionId(cpSrcPath)
 
 	srcBucket, srcObject := pathToBucketAndObject(cpSrcPath)
 	// If source object is empty or bucket is empty, reply back invalid copy source.
@@ -157,7 +305,7 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 	partIDString := r.URL.Query().Get("partNumber")
 
 	partID, err := strconv.Atoi(partIDString)
-	if err != nil {
+	if err != nil || partID < 1 {
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidPart)
 		return
 	}
@@ -169,12 +317,47 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidMaxParts)
 		return
 	}
+	if err = s3a.checkUploadId(dstObject, uploadID); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchUpload)
+		return
+	}
 
-	rangeHeader := r.Header.Get("x-amz-copy-source-range")
+	encryption, errCode := s3a.uploadEncryption(r, dstBucket, uploadID)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	// the source is checked before its data is read
+	srcEntry, errCode := s3a.selectObjectEntry(srcBucket, srcObject, srcVersionId)
+	if errCode != s3err.ErrNone {
+		if errCode == s3err.ErrNoSuchKey {
+			errCode = s3err.ErrInvalidCopySource
+		}
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	if errCode = checkCopySourceConditions(r.Header, srcEntry); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	rangeHeader, errCode := copySourceRange(r.Header.Get(s3_constants.AmzCopySourceRange), int64(filer.FileSize(srcEntry)))
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
 
 	dstUrl := s3a.genPartUploadUrl(dstBucket, uploadID, partID)
 	srcUrl := fmt.Sprintf("http://%s%s/%s%s",
-		s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, srcBucket, urlEscapeObject(srcObject))
+		s3a.filers.Current().ToHttpAddress(), s3a.option.BucketsPath, srcBucket, urlEscapeObject(srcObject))
+	if srcVersionId != "" {
+		var errCode s3err.ErrorCode
+		if srcUrl, errCode = s3a.toFilerVersionUrl(w, srcBucket, srcObject, srcVersionId); errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+		w.Header().Set(s3_constants.AmzCopySourceVersionId, srcVersionId)
+	}
 
 	resp, dataReader, err := util_http.ReadUrlAsReaderCloser(srcUrl, s3a.maybeGetFilerJwtAuthorizationToken(false), rangeHeader)
 	if err != nil {
@@ -184,9 +367,15 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 	defer util_http.CloseResponse(resp)
 	defer dataReader.Close()
 
+	srcReader, errCode := s3a.decryptCopySource(r, resp.Header, dataReader)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
 	glog.V(2).Infof("copy from %s to %s", srcUrl, dstUrl)
 	destination := fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, dstBucket, dstObject)
-	etag, errCode := s3a.putToFiler(r, dstUrl, dataReader, destination, dstBucket)
+	etag, errCode := s3a.putToFiler(r, dstUrl, srcReader, destination, dstBucket, "", encryption, partID)
 
 	if errCode != s3err.ErrNone {
 		s3err.WriteErrorResponse(w, r, errCode)
@@ -194,6 +383,9 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	setEtag(w, etag)
+	if encryption != nil {
+		encryption.setResponseHeaders(w.Header())
+	}
 
 	response := CopyPartResult{
 		ETag:         etag,
@@ -204,6 +396,53 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 
 }
 
+// checkCopySourceConditions checks the conditions on the etag and the modified time of the copy source,
+// where a matched etag takes precedence over the modified time, as in the conditional GET requests
+func checkCopySourceConditions(header http.Header, srcEntry *filer_pb.Entry) s3err.ErrorCode {
+	etag := filer.ETag(srcEntry)
+	mtime := time.Unix(srcEntry.Attributes.Mtime, 0)
+	isEtagListed := func(etags string) bool {
+		for _, e := range strings.Split(etags, ",") {
+			if e = strings.Trim(strings.TrimSpace(e), `"`); e == etag || e == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifMatch := header.Get(s3_constants.AmzCopySourceIfMatch); ifMatch != "" {
+		if !isEtagListed(ifMatch) {
+			return s3err.ErrPreconditionFailed
+		}
+	} else if t, err := http.ParseTime(header.Get(s3_constants.AmzCopySourceIfUnmodifiedSince)); err == nil && mtime.After(t) {
+		return s3err.ErrPreconditionFailed
+	}
+	if ifNoneMatch := header.Get(s3_constants.AmzCopySourceIfNoneMatch); ifNoneMatch != "" {
+		if isEtagListed(ifNoneMatch) {
+			return s3err.ErrPreconditionFailed
+		}
+	} else if t, err := http.ParseTime(header.Get(s3_constants.AmzCopySourceIfModifiedSince)); err == nil && !mtime.After(t) {
+		return s3err.ErrPreconditionFailed
+	}
+	return s3err.ErrNone
+}
+
+// copySourceRange validates the "bytes=first-last" range of the copy source, and returns the range to read,
+// or an empty range to read the whole source
+func copySourceRange(rangeValue string, srcSize int64) (string, s3err.ErrorCode) {
+	if rangeValue == "" {
+		return "", s3err.ErrNone
+	}
+	first, last, found := strings.Cut(strings.TrimPrefix(rangeValue, "bytes="), "-")
+	start, startErr := strconv.ParseInt(first, 10, 64)
+	end, endErr := strconv.ParseInt(last, 10, 64)
+	if !strings.HasPrefix(rangeValue, "bytes=") || !found || startErr != nil || endErr != nil ||
+		start < 0 || end < start || end >= srcSize {
+		return "", s3err.ErrInvalidRange
+	}
+	return fmt.Sprintf("bytes=%d-%d", start, end), s3err.ErrNone
+}
+
 func replaceDirective(reqHeader http.Header) (replaceMeta, replaceTagging bool) {
 	return reqHeader.Get(s3_constants.AmzUserMetaDirective) == DirectiveReplace, reqHeader.Get(s3_constants.AmzObjectTaggingDirective) == DirectiveReplace
 }
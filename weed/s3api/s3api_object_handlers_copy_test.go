@@ -2,12 +2,15 @@ package s3api
 
 import (
 	"fmt"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	"net/http"
 	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 type H map[string]string
@@ -436,3 +439,57 @@ func transferHeaderToH(data map[string][]string) H {
 	}
 	return m
 }
+
+func TestCopySourceRange(t *testing.T) {
+	for _, tt := range []struct {
+		rangeValue string
+		expected   string
+		errCode    s3err.ErrorCode
+	}{
+		{"", "", s3err.ErrNone},
+		{"bytes=0-9", "bytes=0-9", s3err.ErrNone},
+		{"bytes=5-5", "bytes=5-5", s3err.ErrNone},
+		{"bytes=0-10", "", s3err.ErrInvalidRange},
+		{"bytes=5-4", "", s3err.ErrInvalidRange},
+		{"bytes=-5", "", s3err.ErrInvalidRange},
+		{"bytes=5-", "", s3err.ErrInvalidRange},
+		{"0-5", "", s3err.ErrInvalidRange},
+	} {
+		rangeValue, errCode := copySourceRange(tt.rangeValue, 10)
+		if rangeValue != tt.expected || errCode != tt.errCode {
+			t.Errorf("copySourceRange(%s) = %s %v, expected %s %v", tt.rangeValue, rangeValue, errCode, tt.expected, tt.errCode)
+		}
+	}
+}
+
+func TestCheckCopySourceConditions(t *testing.T) {
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry := &filer_pb.Entry{
+		Attributes: &filer_pb.FuseAttributes{Mtime: mtime.Unix(), Md5: []byte{0xab, 0xc0}},
+	}
+	before, after := mtime.Add(-time.Hour).Format(http.TimeFormat), mtime.Add(time.Hour).Format(http.TimeFormat)
+	for _, tt := range []struct {
+		header  map[string]string
+		errCode s3err.ErrorCode
+	}{
+		{map[string]string{}, s3err.ErrNone},
+		{map[string]string{s3_constants.AmzCopySourceIfMatch: `"abc0"`}, s3err.ErrNone},
+		{map[string]string{s3_constants.AmzCopySourceIfMatch: `"xyz"`}, s3err.ErrPreconditionFailed},
+		{map[string]string{s3_constants.AmzCopySourceIfNoneMatch: `"abc0"`}, s3err.ErrPreconditionFailed},
+		{map[string]string{s3_constants.AmzCopySourceIfUnmodifiedSince: before}, s3err.ErrPreconditionFailed},
+		{map[string]string{s3_constants.AmzCopySourceIfUnmodifiedSince: after}, s3err.ErrNone},
+		{map[string]string{s3_constants.AmzCopySourceIfModifiedSince: after}, s3err.ErrPreconditionFailed},
+		{map[string]string{s3_constants.AmzCopySourceIfModifiedSince: before}, s3err.ErrNone},
+		// the matched etag takes precedence over the modified time
+		{map[string]string{s3_constants.AmzCopySourceIfMatch: `"abc0"`, s3_constants.AmzCopySourceIfUnmodifiedSince: before}, s3err.ErrNone},
+		{map[string]string{s3_constants.AmzCopySourceIfNoneMatch: `"xyz"`, s3_constants.AmzCopySourceIfModifiedSince: after}, s3err.ErrNone},
+	} {
+		header := http.Header{}
+		for k, v := range tt.header {
+			header.Set(k, v)
+		}
+		if errCode := checkCopySourceConditions(header, entry); errCode != tt.errCode {
+			t.Errorf("checkCopySourceConditions(%v) = %v, expected %v", tt.header, errCode, tt.errCode)
+		}
+	}
+}
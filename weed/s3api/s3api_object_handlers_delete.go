@@ -31,10 +31,23 @@ func (s3a *S3ApiServer) DeleteObjectHandler(w http.ResponseWriter, r *http.Reque
 
 	target := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object))
 	dir, name := target.DirAndName()
+	versionId := r.URL.Query().Get("versionId")
+	versioning := s3a.getVersioningStatus(bucket)
 
+	var deleted ObjectIdentifier
 	err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
 
-		if err := doDeleteEntry(client, dir, name, true, false); err != nil {
+		if versionId != "" && s3a.getObjectLockConfiguration(bucket) != nil {
+			if err := checkObjectLockDeletable(client, target, versionId, isBypassGovernanceRetention(r)); err != nil {
+				return err
+			}
+		}
+		if versioning != "" || versionId != "" {
+			var deleteErr error
+			if deleted, deleteErr = s3a.deleteVersionedObject(client, target, versionId, versioning); deleteErr != nil {
+				return deleteErr
+			}
+		} else if err := doDeleteEntry(client, dir, name, true, false); err != nil {
 			return err
 		}
 
@@ -54,18 +67,27 @@ func (s3a *S3ApiServer) DeleteObjectHandler(w http.ResponseWriter, r *http.Reque
 		return nil
 	})
 	if err != nil {
-		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		s3err.WriteErrorResponse(w, r, filerErrorToS3Error(err))
 		return
 	}
 
-	stats_collect.RecordBucketActiveTime(bucket)
-	stats_collect.S3DeletedObjectsCounter.WithLabelValues(bucket).Inc()
+	if deleted.DeleteMarker {
+		w.Header().Set(s3_constants.AmzDeleteMarker, "true")
+		setVersionIdHeader(w, deleted.DeleteMarkerVersionId)
+	} else {
+		setVersionIdHeader(w, deleted.VersionId)
+	}
+	s3a.notifyObjectDeleted(r, bucket, object, deleted)
+	stats_collect.S3DeletedObjectsCounter.WithLabelValues(stats_collect.S3BucketLabel(bucket)).Inc()
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// / ObjectIdentifier carries key name for the object to delete.
+// / ObjectIdentifier carries key name and the version id for the object to delete.
 type ObjectIdentifier struct {
-	ObjectName string `xml:"Key"`
+	ObjectName            string `xml:"Key"`
+	VersionId             string `xml:"VersionId,omitempty"`
+	DeleteMarker          bool   `xml:"DeleteMarker,omitempty"`
+	DeleteMarkerVersionId string `xml:"DeleteMarkerVersionId,omitempty"`
 }
 
 // DeleteObjectsRequest - xml carrying the object key names which needs to be deleted.
@@ -122,6 +144,9 @@ func (s3a *S3ApiServer) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *h
 	var auditLog *s3err.AccessLog
 
 	directoriesWithDeletion := make(map[string]int)
+	versioning := s3a.getVersioningStatus(bucket)
+	isObjectLockEnabled := s3a.getObjectLockConfiguration(bucket) != nil
+	bypassGovernance := isBypassGovernanceRetention(r)
 
 	if s3err.Logger != nil {
 		auditLog = s3err.GetAccessLog(r, http.StatusNoContent, s3err.ErrNone)
@@ -141,12 +166,30 @@ func (s3a *S3ApiServer) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *h
 			}
 			parentDirectoryPath = fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, parentDirectoryPath)
 
-			err := doDeleteEntry(client, parentDirectoryPath, entryName, isDeleteData, isRecursive)
+			var err error
+			deleted := ObjectIdentifier{ObjectName: object.ObjectName}
+			if object.VersionId != "" && isObjectLockEnabled {
+				err = checkObjectLockDeletable(client, util.NewFullPath(parentDirectoryPath, entryName), object.VersionId, bypassGovernance)
+			}
+			if err == nil && (versioning != "" || object.VersionId != "") {
+				deleted, err = s3a.deleteVersionedObject(client, util.NewFullPath(parentDirectoryPath, entryName), object.VersionId, versioning)
+				deleted.ObjectName = object.ObjectName
+			} else if err == nil {
+				err = doDeleteEntry(client, parentDirectoryPath, entryName, isDeleteData, isRecursive)
+			}
 			if err == nil {
 				directoriesWithDeletion[parentDirectoryPath]++
-				deletedObjects = append(deletedObjects, object)
+				deletedObjects = append(deletedObjects, deleted)
+				s3a.notifyObjectDeleted(r, bucket, object.ObjectName, deleted)
 			} else if strings.Contains(err.Error(), filer.MsgFailDelNonEmptyFolder) {
 				deletedObjects = append(deletedObjects, object)
+			} else if isWormEnforced(err) {
+				delete(directoriesWithDeletion, parentDirectoryPath)
+				deleteErrors = append(deleteErrors, DeleteError{
+					Code:    "AccessDenied",
+					Message: err.Error(),
+					Key:     object.ObjectName,
+				})
 			} else {
 				delete(directoriesWithDeletion, parentDirectoryPath)
 				deleteErrors = append(deleteErrors, DeleteError{
@@ -178,8 +221,7 @@ func (s3a *S3ApiServer) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *h
 		deleteResp.DeletedObjects = deletedObjects
 	}
 	deleteResp.Errors = deleteErrors
-	stats_collect.RecordBucketActiveTime(bucket)
-	stats_collect.S3DeletedObjectsCounter.WithLabelValues(bucket).Add(float64(len(deletedObjects)))
+	stats_collect.S3DeletedObjectsCounter.WithLabelValues(stats_collect.S3BucketLabel(bucket)).Add(float64(len(deletedObjects)))
 
 	writeSuccessResponseXML(w, r, deleteResp)
 
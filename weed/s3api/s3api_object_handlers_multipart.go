@@ -37,6 +37,23 @@ func (s3a *S3ApiServer) NewMultipartUploadHandler(w http.ResponseWriter, r *http
 		Metadata: make(map[string]*string),
 	}
 
+	// the parts are encrypted by the data key of the upload
+	encryption, errCode := s3a.newObjectEncryption(r.Context(), r.Header)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	removeEncryptionHeaders(r.Header)
+	if encryption != nil {
+		encryption.setMetadataHeaders(r.Header)
+		encryption.setResponseHeaders(w.Header())
+	}
+
+	// the retention of the object starts when the upload is created
+	if errCode = s3a.objectLockForWrite(r.Header, bucket); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
 	metadata := weed_server.SaveAmzMetaData(r, nil, false)
 	for k, v := range metadata {
 		createMultipartUploadInput.Metadata[k] = aws.String(string(v))
@@ -91,9 +108,10 @@ func (s3a *S3ApiServer) CompleteMultipartUploadHandler(w http.ResponseWriter, r
 		s3err.WriteErrorResponse(w, r, errCode)
 		return
 	}
-	stats_collect.RecordBucketActiveTime(bucket)
-	stats_collect.S3UploadedObjectsCounter.WithLabelValues(bucket).Inc()
+	stats_collect.S3UploadedObjectsCounter.WithLabelValues(stats_collect.S3BucketLabel(bucket)).Inc()
+	s3a.notifyObjectEvent(r, s3EventObjectCreatedCompleteMultipartUpload, bucket, object, aws.StringValue(response.VersionId))
 
+	setVersionIdHeader(w, aws.StringValue(response.VersionId))
 	writeSuccessResponseXML(w, r, response)
 
 }
@@ -138,13 +156,6 @@ func (s3a *S3ApiServer) ListMultipartUploadsHandler(w http.ResponseWriter, r *ht
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidMaxUploads)
 		return
 	}
-	if keyMarker != "" {
-		// Marker not common with prefix is not implemented.
-		if !strings.HasPrefix(keyMarker, prefix) {
-			s3err.WriteErrorResponse(w, r, s3err.ErrNotImplemented)
-			return
-		}
-	}
 
 	response, errCode := s3a.listMultipartUploads(&s3.ListMultipartUploadsInput{
 		Bucket:         aws.String(bucket),
@@ -253,6 +264,12 @@ func (s3a *S3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 	}
 	defer dataReader.Close()
 
+	encryption, errCode := s3a.uploadEncryption(r, bucket, uploadID)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
 	glog.V(2).Infof("PutObjectPartHandler %s %s %04d", bucket, uploadID, partID)
 
 	uploadUrl := s3a.genPartUploadUrl(bucket, uploadID, partID)
@@ -262,25 +279,48 @@ func (s3a *S3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 	}
 	destination := fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object)
 
-	etag, errCode := s3a.putToFiler(r, uploadUrl, dataReader, destination, bucket)
+	etag, errCode := s3a.putToFiler(r, uploadUrl, dataReader, destination, bucket, "", encryption, partID)
 	if errCode != s3err.ErrNone {
 		s3err.WriteErrorResponse(w, r, errCode)
 		return
 	}
 
 	setEtag(w, etag)
+	if encryption != nil {
+		encryption.setResponseHeaders(w.Header())
+	}
 
 	writeSuccessResponseEmpty(w, r)
 
 }
 
+// uploadEncryption unlocks the encryption of the multipart upload, or returns nil if the upload is not encrypted
+func (s3a *S3ApiServer) uploadEncryption(r *http.Request, bucket, uploadID string) (*objectEncryption, s3err.ErrorCode) {
+	uploadEntry, err := s3a.getEntry(s3a.genUploadsFolder(bucket), uploadID)
+	if err != nil {
+		return nil, s3err.ErrNoSuchUpload
+	}
+	encryption, err := objectEncryptionOfExtended(uploadEntry.Extended)
+	if err != nil {
+		glog.Errorf("read encryption of upload %s: %v", uploadID, err)
+		return nil, s3err.ErrInternalError
+	}
+	if encryption == nil {
+		return nil, s3err.ErrNone
+	}
+	if errCode := s3a.unlock(r.Context(), encryption, r.Header, sseCustomerHeaders); errCode != s3err.ErrNone {
+		return nil, errCode
+	}
+	return encryption, s3err.ErrNone
+}
+
 func (s3a *S3ApiServer) genUploadsFolder(bucket string) string {
 	return fmt.Sprintf("%s/%s/%s", s3a.option.BucketsPath, bucket, s3_constants.MultipartUploadsFolder)
 }
 
 func (s3a *S3ApiServer) genPartUploadUrl(bucket, uploadID string, partID int) string {
 	return fmt.Sprintf("http://%s%s/%s/%04d_%s.part",
-		s3a.option.Filer.ToHttpAddress(), s3a.genUploadsFolder(bucket), uploadID, partID, uuid.NewString())
+		s3a.filers.Current().ToHttpAddress(), s3a.genUploadsFolder(bucket), uploadID, partID, uuid.NewString())
 }
 
 // Generate uploadID hash string from object
@@ -313,6 +353,7 @@ func getBucketMultipartResources(values url.Values) (prefix, keyMarker, uploadID
 	delimiter = values.Get("delimiter")
 	if values.Get("max-uploads") != "" {
 		maxUploads, _ = strconv.Atoi(values.Get("max-uploads"))
+		maxUploads = min(maxUploads, maxUploadsList)
 	} else {
 		maxUploads = maxUploadsList
 	}
@@ -326,6 +367,7 @@ func getObjectResources(values url.Values) (uploadID string, partNumberMarker, m
 	partNumberMarker, _ = strconv.Atoi(values.Get("part-number-marker"))
 	if values.Get("max-parts") != "" {
 		maxParts, _ = strconv.Atoi(values.Get("max-parts"))
+		maxParts = min(maxParts, maxPartsList)
 	} else {
 		maxParts = maxPartsList
 	}
@@ -114,7 +114,7 @@ func (s3a *S3ApiServer) PostPolicyBucketHandler(w http.ResponseWriter, r *http.R
 		}
 	}
 
-	uploadUrl := fmt.Sprintf("http://%s%s/%s%s", s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, bucket, urlEscapeObject(object))
+	uploadUrl := fmt.Sprintf("http://%s%s/%s%s", s3a.filers.Current().ToHttpAddress(), s3a.option.BucketsPath, bucket, urlEscapeObject(object))
 
 	// Get ContentType from post formData
 	// Otherwise from formFile ContentType
@@ -126,7 +126,8 @@ func (s3a *S3ApiServer) PostPolicyBucketHandler(w http.ResponseWriter, r *http.R
 
 	// Add s3 postpolicy support header
 	for k, _ := range formValues {
-		if k == "Cache-Control" || k == "Expires" || k == "Content-Disposition" {
+		if k == "Cache-Control" || k == "Expires" || k == "Content-Disposition" ||
+			k == s3_constants.AmzServerSideEncryption || k == s3_constants.AmzServerSideEncryptionAwsKmsKeyId {
 			r.Header.Set(k, formValues.Get(k))
 			continue
 		}
@@ -136,12 +137,27 @@ func (s3a *S3ApiServer) PostPolicyBucketHandler(w http.ResponseWriter, r *http.R
 		}
 	}
 
-	etag, errCode := s3a.putToFiler(r, uploadUrl, fileBody, "", bucket)
+	encryption, errCode := s3a.newObjectEncryption(r.Context(), r.Header)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	if errCode = s3a.objectLockForWrite(r.Header, bucket); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	versionId := s3a.versionIdForWrite(bucket)
+	s3a.markReplicationPending(r.Header, bucket, object)
+	etag, errCode := s3a.putToFiler(r, uploadUrl, fileBody, "", bucket, versionId, encryption, 0)
 
 	if errCode != s3err.ErrNone {
 		s3err.WriteErrorResponse(w, r, errCode)
 		return
 	}
+	setVersionIdHeader(w, versionId)
+	s3a.maybeRemoveNullVersions(bucket, s3a.objectPath(bucket, object))
+	s3a.notifyObjectEvent(r, s3EventObjectCreatedPost, bucket, object, versionId)
 
 	if successRedirect != "" {
 		// Replace raw query params..
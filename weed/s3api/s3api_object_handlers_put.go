@@ -3,7 +3,9 @@ package s3api
 import (
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"strings"
@@ -93,7 +95,19 @@ func (s3a *S3ApiServer) PutObjectHandler(w http.ResponseWriter, r *http.Request)
 			dataReader = mimeDetect(r, dataReader)
 		}
 
-		etag, errCode := s3a.putToFiler(r, uploadUrl, dataReader, "", bucket)
+		encryption, errCode := s3a.newObjectEncryption(r.Context(), r.Header)
+		if errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+
+		if errCode = s3a.objectLockForWrite(r.Header, bucket); errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+		versionId := s3a.versionIdForWrite(bucket)
+		s3a.markReplicationPending(r.Header, bucket, object)
+		etag, errCode := s3a.putToFiler(r, uploadUrl, dataReader, "", bucket, versionId, encryption, 0)
 
 		if errCode != s3err.ErrNone {
 			s3err.WriteErrorResponse(w, r, errCode)
@@ -101,17 +115,54 @@ func (s3a *S3ApiServer) PutObjectHandler(w http.ResponseWriter, r *http.Request)
 		}
 
 		setEtag(w, etag)
+		setVersionIdHeader(w, versionId)
+		if encryption != nil {
+			encryption.setResponseHeaders(w.Header())
+		}
+		s3a.maybeRemoveNullVersions(bucket, s3a.objectPath(bucket, object))
+		s3a.notifyObjectEvent(r, s3EventObjectCreatedPut, bucket, object, versionId)
 	}
-	stats_collect.RecordBucketActiveTime(bucket)
-	stats_collect.S3UploadedObjectsCounter.WithLabelValues(bucket).Inc()
+	stats_collect.S3UploadedObjectsCounter.WithLabelValues(stats_collect.S3BucketLabel(bucket)).Inc()
 
 	writeSuccessResponseEmpty(w, r)
 }
 
-func (s3a *S3ApiServer) putToFiler(r *http.Request, uploadUrl string, dataReader io.Reader, destination string, bucket string) (etag string, code s3err.ErrorCode) {
+// putToFiler uploads the object, with the version id if the bucket is versioned,
+// and encrypted if the encryption is not nil, as the object or the part of the multipart upload
+func (s3a *S3ApiServer) putToFiler(r *http.Request, uploadUrl string, dataReader io.Reader, destination string, bucket string, versionId string, encryption *objectEncryption, partNumber int) (etag string, code s3err.ErrorCode) {
+
+	var md5Verifier *md5VerifyingReader
+	var plaintextHash hash.Hash
+	if encryption != nil {
+		if contentMd5 := r.Header.Get("Content-Md5"); contentMd5 != "" {
+			md5Verifier = &md5VerifyingReader{reader: dataReader, hash: md5.New(), contentMd5: contentMd5}
+			dataReader = md5Verifier
+		}
+		if encryption.isS3ManagedKey() {
+			plaintextHash = md5.New()
+			dataReader = io.TeeReader(dataReader, plaintextHash)
+		}
+		var err error
+		if partNumber > 0 {
+			if encryption, err = encryption.forPartUpload(); err != nil {
+				glog.Errorf("encrypt %s: %v", uploadUrl, err)
+				return "", s3err.ErrInternalError
+			}
+		}
+		if dataReader, err = encryption.encrypt(dataReader, partNumber); err != nil {
+			glog.Errorf("encrypt %s: %v", uploadUrl, err)
+			return "", s3err.ErrInternalError
+		}
+	}
 
 	hash := md5.New()
 	var body = io.TeeReader(dataReader, hash)
+	var trailer http.Header
+	if plaintextHash != nil {
+		// the filer saves the md5 of the object as the etag, sent after the object is read
+		trailer = http.Header{http.CanonicalHeaderKey(s3_constants.SeaweedPlaintextMd5Header): nil}
+		body = &md5TrailerReader{reader: body, hash: plaintextHash, trailer: trailer}
+	}
 
 	proxyReq, err := http.NewRequest(http.MethodPut, uploadUrl, body)
 
@@ -119,6 +170,7 @@ func (s3a *S3ApiServer) putToFiler(r *http.Request, uploadUrl string, dataReader
 		glog.Errorf("NewRequest %s: %v", uploadUrl, err)
 		return "", s3err.ErrInternalError
 	}
+	proxyReq.Trailer = trailer
 
 	proxyReq.Header.Set("X-Forwarded-For", r.RemoteAddr)
 	if destination != "" {
@@ -132,22 +184,44 @@ func (s3a *S3ApiServer) putToFiler(r *http.Request, uploadUrl string, dataReader
 	}
 
 	for header, values := range r.Header {
+		// the version id is never set by the clients
+		if header == s3_constants.AmzVersionId {
+			continue
+		}
 		for _, value := range values {
 			proxyReq.Header.Add(header, value)
 		}
 	}
+	if versionId != "" {
+		proxyReq.Header.Set(s3_constants.AmzVersionId, versionId)
+	}
+	removeEncryptionHeaders(proxyReq.Header)
+	if encryption != nil {
+		proxyReq.Header.Del("Content-Md5")
+		encryption.setMetadataHeaders(proxyReq.Header)
+	}
 	// ensure that the Authorization header is overriding any previous
 	// Authorization header which might be already present in proxyReq
 	s3a.maybeAddFilerJwtAuthorization(proxyReq, true)
 	resp, postErr := s3a.client.Do(proxyReq)
 
+	if md5Verifier != nil && md5Verifier.mismatched {
+		if postErr == nil {
+			resp.Body.Close()
+		}
+		return "", s3err.ErrInvalidDigest
+	}
 	if postErr != nil {
 		glog.Errorf("post to filer: %v", postErr)
 		return "", s3err.ErrInternalError
 	}
 	defer resp.Body.Close()
 
+	// the etag of SSE-KMS and SSE-C objects is the md5 of the encrypted data
 	etag = fmt.Sprintf("%x", hash.Sum(nil))
+	if plaintextHash != nil {
+		etag = fmt.Sprintf("%x", plaintextHash.Sum(nil))
+	}
 
 	resp_body, ra_err := io.ReadAll(resp.Body)
 	if ra_err != nil {
@@ -162,10 +236,13 @@ func (s3a *S3ApiServer) putToFiler(r *http.Request, uploadUrl string, dataReader
 	}
 	if ret.Error != "" {
 		glog.Errorf("upload to filer error: %v", ret.Error)
-		return "", filerErrorToS3Error(ret.Error)
+		// the filer forbids changing the write-once files and the locked objects
+		if resp.StatusCode == http.StatusForbidden {
+			return "", s3err.ErrAccessDenied
+		}
+		return "", filerErrorToS3Error(errors.New(ret.Error))
 	}
-	stats_collect.RecordBucketActiveTime(bucket)
-	stats_collect.S3BucketTrafficReceivedBytesCounter.WithLabelValues(bucket).Add(float64(ret.Size))
+	BucketTrafficReceived(ret.Size, bucket, r)
 	return etag, s3err.ErrNone
 }
 
@@ -179,12 +256,15 @@ func setEtag(w http.ResponseWriter, etag string) {
 	}
 }
 
-func filerErrorToS3Error(errString string) s3err.ErrorCode {
+func filerErrorToS3Error(err error) s3err.ErrorCode {
+	errString := err.Error()
 	switch {
 	case strings.HasPrefix(errString, "existing ") && strings.HasSuffix(errString, "is a directory"):
 		return s3err.ErrExistingObjectIsDirectory
 	case strings.HasSuffix(errString, "is a file"):
 		return s3err.ErrExistingObjectIsFile
+	case isWormEnforced(err):
+		return s3err.ErrAccessDenied
 	default:
 		return s3err.ErrInternalError
 	}
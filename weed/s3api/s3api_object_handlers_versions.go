@@ -0,0 +1,373 @@
+package s3api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	versionsListPageSize = 1024
+)
+
+type ListObjectVersionsResult struct {
+	XMLName             xml.Name            `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListVersionsResult"`
+	Name                string              `xml:"Name"`
+	Prefix              string              `xml:"Prefix"`
+	KeyMarker           string              `xml:"KeyMarker"`
+	VersionIdMarker     string              `xml:"VersionIdMarker"`
+	NextKeyMarker       string              `xml:"NextKeyMarker,omitempty"`
+	NextVersionIdMarker string              `xml:"NextVersionIdMarker,omitempty"`
+	MaxKeys             int                 `xml:"MaxKeys"`
+	Delimiter           string              `xml:"Delimiter,omitempty"`
+	IsTruncated         bool                `xml:"IsTruncated"`
+	Versions            []VersionEntry      `xml:"Version,omitempty"`
+	DeleteMarkers       []DeleteMarkerEntry `xml:"DeleteMarker,omitempty"`
+	CommonPrefixes      []PrefixEntry       `xml:"CommonPrefixes,omitempty"`
+	EncodingType        string              `xml:"EncodingType,omitempty"`
+}
+
+func (s3a *S3ApiServer) ListObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
+
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("ListObjectVersionsHandler %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	values := r.URL.Query()
+	maxKeys := maxObjectListSizeLimit
+	if values.Get("max-keys") != "" {
+		parsed, err := strconv.Atoi(values.Get("max-keys"))
+		if err != nil || parsed < 0 {
+			s3err.WriteErrorResponse(w, r, s3err.ErrInvalidMaxKeys)
+			return
+		}
+		if parsed < maxKeys {
+			maxKeys = parsed
+		}
+	}
+
+	lister := &objectVersionsLister{
+		bucketDir:       fmt.Sprintf("%s/%s", s3a.option.BucketsPath, bucket),
+		keyMarker:       values.Get("key-marker"),
+		versionIdMarker: values.Get("version-id-marker"),
+		delimiter:       values.Get("delimiter"),
+		maxKeys:         maxKeys,
+		encodingTypeUrl: values.Get("encoding-type") == s3.EncodingTypeUrl,
+		result: &ListObjectVersionsResult{
+			Name:            bucket,
+			Prefix:          values.Get("prefix"),
+			KeyMarker:       values.Get("key-marker"),
+			VersionIdMarker: values.Get("version-id-marker"),
+			MaxKeys:         maxKeys,
+			Delimiter:       values.Get("delimiter"),
+		},
+	}
+	if lister.keyMarker == "" {
+		lister.versionIdMarker = ""
+	}
+
+	err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		lister.client = client
+		return lister.list(strings.TrimPrefix(values.Get("prefix"), "/"))
+	})
+	if err != nil {
+		glog.Errorf("list object versions of %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	if lister.encodingTypeUrl {
+		lister.result.EncodingType = s3.EncodingTypeUrl
+	}
+	writeSuccessResponseXML(w, r, lister.result)
+}
+
+// objectVersionsLister walks the bucket and the versions of its objects side by side, both ordered by names,
+// so the objects deleted with delete markers are listed as well
+type objectVersionsLister struct {
+	client          filer_pb.SeaweedFilerClient
+	bucketDir       string
+	keyMarker       string
+	versionIdMarker string
+	delimiter       string
+	maxKeys         int
+	encodingTypeUrl bool
+	result          *ListObjectVersionsResult
+	count           int
+}
+
+func (l *objectVersionsLister) list(prefix string) error {
+	relDir, namePrefix := toDirAndName(prefix)
+	startFrom := ""
+	if l.keyMarker != "" {
+		if relDir == "" {
+			startFrom, _ = toParentAndDescendants(l.keyMarker)
+		} else if strings.HasPrefix(l.keyMarker, relDir+"/") {
+			startFrom, _ = toParentAndDescendants(l.keyMarker[len(relDir)+1:])
+		}
+	}
+	_, err := l.walk(relDir, namePrefix, startFrom)
+	return err
+}
+
+// walk lists the versions of the objects under the directory relative to the bucket, returns true once truncated
+func (l *objectVersionsLister) walk(relDir, namePrefix, startFrom string) (isTruncated bool, err error) {
+	dir := util.FullPath(l.bucketDir)
+	if relDir != "" {
+		dir = dir.Child(relDir)
+	}
+	current := newDirLister(l.client, string(dir), namePrefix, startFrom)
+	versions := newDirLister(l.client, string(filer.VersionsDirOf(dir)), namePrefix, startFrom)
+
+	for {
+		currentEntry, currentErr := current.peek()
+		if currentErr != nil {
+			return false, currentErr
+		}
+		versionsEntry, versionsErr := versions.peek()
+		if versionsErr != nil {
+			return false, versionsErr
+		}
+		// the files in the versions directory are the versions of the directory itself
+		if versionsEntry != nil && !versionsEntry.IsDirectory {
+			versions.next()
+			continue
+		}
+		if currentEntry == nil && versionsEntry == nil {
+			return false, nil
+		}
+
+		var name string
+		switch {
+		case versionsEntry == nil || currentEntry != nil && currentEntry.Name < versionsEntry.Name:
+			name, versionsEntry = currentEntry.Name, nil
+			current.next()
+		case currentEntry == nil || versionsEntry.Name < currentEntry.Name:
+			name, currentEntry = versionsEntry.Name, nil
+			versions.next()
+		default:
+			name = currentEntry.Name
+			current.next()
+			versions.next()
+		}
+		if relDir == "" && name == s3_constants.MultipartUploadsFolder {
+			continue
+		}
+
+		key := name
+		if relDir != "" {
+			key = relDir + "/" + name
+		}
+		p := dir.Child(name)
+		isObject := currentEntry != nil && !currentEntry.IsDirectory
+		isDirectory := currentEntry != nil && currentEntry.IsDirectory
+
+		if isObject || versionsEntry != nil {
+			var object *filer_pb.Entry
+			if isObject {
+				object = currentEntry
+			}
+			if isTruncated, err = l.listKey(key, p, object, versionsEntry != nil); err != nil || isTruncated {
+				return
+			}
+		}
+
+		if !isDirectory && versionsEntry == nil {
+			continue
+		}
+		prefix := key + "/"
+		if l.keyMarker != "" && prefix <= l.keyMarker && !strings.HasPrefix(l.keyMarker, prefix) {
+			continue
+		}
+		if l.delimiter == "/" {
+			if strings.HasPrefix(l.keyMarker, prefix) {
+				continue
+			}
+			if !isDirectory {
+				hasSubDirs, subErr := l.hasSubDirectories(filer.VersionsDirOf(p))
+				if subErr != nil {
+					return false, subErr
+				}
+				if !hasSubDirs {
+					continue
+				}
+			}
+			if l.isFull() {
+				return true, nil
+			}
+			if l.encodingTypeUrl {
+				prefix = urlPathEscape(prefix)
+			}
+			l.result.CommonPrefixes = append(l.result.CommonPrefixes, PrefixEntry{Prefix: prefix})
+			l.count++
+			l.result.NextKeyMarker, l.result.NextVersionIdMarker = key+"/", ""
+			continue
+		}
+		subStartFrom := ""
+		if strings.HasPrefix(l.keyMarker, prefix) {
+			subStartFrom, _ = toParentAndDescendants(l.keyMarker[len(prefix):])
+		}
+		if isTruncated, err = l.walk(key, "", subStartFrom); err != nil || isTruncated {
+			return
+		}
+	}
+}
+
+// listKey lists the current object first, and then its previous versions and delete markers, the newest first
+func (l *objectVersionsLister) listKey(key string, p util.FullPath, object *filer_pb.Entry, hasVersions bool) (isTruncated bool, err error) {
+	if l.keyMarker != "" && (key < l.keyMarker || key == l.keyMarker && l.versionIdMarker == "") {
+		return false, nil
+	}
+	var entries []*filer_pb.Entry
+	if object != nil {
+		entries = append(entries, object)
+	}
+	if hasVersions {
+		versions, listErr := listObjectVersions(l.client, p)
+		if listErr != nil {
+			return false, listErr
+		}
+		for _, version := range versions {
+			entries = append(entries, version.Entry)
+		}
+	}
+
+	isAfterMarker := key != l.keyMarker
+	for i, entry := range entries {
+		versionId := objectVersionIdOf(entry)
+		if !isAfterMarker {
+			isAfterMarker = versionId == l.versionIdMarker
+			continue
+		}
+		if l.isFull() {
+			return true, nil
+		}
+		l.addVersion(key, versionId, i == 0, entry)
+	}
+	return false, nil
+}
+
+func (l *objectVersionsLister) addVersion(key, versionId string, isLatest bool, entry *filer_pb.Entry) {
+	l.count++
+	l.result.NextKeyMarker, l.result.NextVersionIdMarker = key, versionId
+	if l.encodingTypeUrl {
+		key = urlPathEscape(key)
+	}
+	lastModified := time.Unix(entry.Attributes.GetMtime(), 0).UTC()
+	owner := CanonicalUser{
+		ID:          fmt.Sprintf("%x", entry.Attributes.GetUid()),
+		DisplayName: entry.Attributes.GetUserName(),
+	}
+	if isDeleteMarker(entry) {
+		l.result.DeleteMarkers = append(l.result.DeleteMarkers, DeleteMarkerEntry{
+			Key:          key,
+			VersionId:    versionId,
+			IsLatest:     isLatest,
+			LastModified: lastModified,
+			Owner:        owner,
+		})
+		return
+	}
+	storageClass := "STANDARD"
+	if v, ok := entry.Extended[s3_constants.AmzStorageClass]; ok {
+		storageClass = string(v)
+	}
+	l.result.Versions = append(l.result.Versions, VersionEntry{
+		Key:          key,
+		VersionId:    versionId,
+		IsLatest:     isLatest,
+		LastModified: lastModified,
+		ETag:         "\"" + filer.ETag(entry) + "\"",
+		Size:         int64(filer.FileSize(entry)),
+		Owner:        owner,
+		StorageClass: StorageClass(storageClass),
+	})
+}
+
+func (l *objectVersionsLister) isFull() bool {
+	if l.count < l.maxKeys {
+		return false
+	}
+	l.result.IsTruncated = true
+	return true
+}
+
+// hasSubDirectories tells whether the versions directory of an object also has the versions of the objects under it
+func (l *objectVersionsLister) hasSubDirectories(dir util.FullPath) (hasSubDirs bool, err error) {
+	lister := newDirLister(l.client, string(dir), "", "")
+	for {
+		entry, peekErr := lister.peek()
+		if peekErr != nil || entry == nil {
+			return false, peekErr
+		}
+		if entry.IsDirectory {
+			return true, nil
+		}
+		lister.next()
+	}
+}
+
+// dirLister lists the entries of a directory page by page
+type dirLister struct {
+	client      filer_pb.SeaweedFilerClient
+	dir         string
+	prefix      string
+	startFrom   string
+	inclusive   bool
+	entries     []*filer_pb.Entry
+	isExhausted bool
+}
+
+func newDirLister(client filer_pb.SeaweedFilerClient, dir, prefix, startFrom string) *dirLister {
+	return &dirLister{
+		client:    client,
+		dir:       dir,
+		prefix:    prefix,
+		startFrom: startFrom,
+		inclusive: startFrom != "",
+	}
+}
+
+// peek returns the next entry, or nil once all are listed
+func (l *dirLister) peek() (*filer_pb.Entry, error) {
+	if len(l.entries) == 0 && !l.isExhausted {
+		count := 0
+		err := filer_pb.SeaweedList(l.client, l.dir, l.prefix, func(entry *filer_pb.Entry, isLast bool) error {
+			l.entries = append(l.entries, entry)
+			l.startFrom = entry.Name
+			count++
+			return nil
+		}, l.startFrom, l.inclusive, versionsListPageSize)
+		if err != nil && !strings.Contains(err.Error(), filer_pb.ErrNotFound.Error()) {
+			return nil, err
+		}
+		l.inclusive = false
+		l.isExhausted = count < versionsListPageSize
+	}
+	if len(l.entries) == 0 {
+		return nil, nil
+	}
+	return l.entries[0], nil
+}
+
+func (l *dirLister) next() {
+	l.entries = l.entries[1:]
+}
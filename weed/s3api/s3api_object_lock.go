@@ -0,0 +1,446 @@
+package s3api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/xml/xmlutil"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+// The retention and the legal hold of the objects are saved in their extended attributes, and enforced by the filer,
+// which keeps the locked objects as the previous versions when they are overwritten or deleted, and never deletes
+// the locked versions. The retention in the GOVERNANCE mode is only enforced here, and can be bypassed by the requests
+// with the "X-Amz-Bypass-Governance-Retention: true" header, from the identities allowed the BypassGovernanceRetention
+// action, or by the bucket policy. The header of the other requests is dropped by the authentication.
+
+// GetObjectLockConfigurationHandler Get Object Lock configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLockConfiguration.html
+func (s3a *S3ApiServer) GetObjectLockConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetObjectLockConfiguration %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	if metadata.ObjectLock == nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrObjectLockConfigurationNotFound)
+		return
+	}
+	s3err.WriteAwsXMLResponse(w, r, http.StatusOK, &s3.PutObjectLockConfigurationInput{
+		ObjectLockConfiguration: metadata.ObjectLock,
+	})
+}
+
+// PutObjectLockConfigurationHandler Put Object Lock configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLockConfiguration.html
+func (s3a *S3ApiServer) PutObjectLockConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutObjectLockConfiguration %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	var config s3.ObjectLockConfiguration
+	defer util_http.CloseRequest(r)
+
+	if err := xmlutil.UnmarshalXML(&config, xml.NewDecoder(r.Body), ""); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	if errCode := validateObjectLockConfiguration(&config); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	// the locked objects are kept as the previous versions when overwritten or deleted
+	if s3a.getVersioningStatus(bucket) != s3.BucketVersioningStatusEnabled {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidBucketState)
+		return
+	}
+
+	configBytes, err := json.Marshal(&config)
+	if err != nil {
+		glog.Errorf("PutObjectLockConfiguration %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	if errCode := s3a.updateBucketExtended(bucket, func(extended map[string][]byte) {
+		extended[s3_constants.ExtObjectLockKey] = configBytes
+	}); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+func validateObjectLockConfiguration(config *s3.ObjectLockConfiguration) s3err.ErrorCode {
+	// the object lock can not be disabled once enabled
+	if aws.StringValue(config.ObjectLockEnabled) != s3.ObjectLockEnabledEnabled {
+		return s3err.ErrInvalidObjectLockConfiguration
+	}
+	if config.Rule == nil {
+		return s3err.ErrNone
+	}
+	retention := config.Rule.DefaultRetention
+	if retention == nil || !isObjectLockMode(aws.StringValue(retention.Mode)) {
+		return s3err.ErrInvalidObjectLockConfiguration
+	}
+	days, years := aws.Int64Value(retention.Days), aws.Int64Value(retention.Years)
+	if (days > 0) == (years > 0) || days < 0 || years < 0 {
+		return s3err.ErrInvalidObjectLockConfiguration
+	}
+	return s3err.ErrNone
+}
+
+// ParseObjectLockConfiguration parses the object lock configuration saved in the bucket
+func ParseObjectLockConfiguration(data []byte) (*s3.ObjectLockConfiguration, error) {
+	config := &s3.ObjectLockConfiguration{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// enableObjectLock enables the versioning and the object lock of the bucket being created
+func enableObjectLock(extended map[string][]byte) {
+	configBytes, _ := json.Marshal(&s3.ObjectLockConfiguration{
+		ObjectLockEnabled: aws.String(s3.ObjectLockEnabledEnabled),
+	})
+	extended[s3_constants.ExtVersioningKey] = []byte(s3.BucketVersioningStatusEnabled)
+	extended[s3_constants.ExtObjectLockKey] = configBytes
+}
+
+func (s3a *S3ApiServer) getObjectLockConfiguration(bucket string) *s3.ObjectLockConfiguration {
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone {
+		return nil
+	}
+	return metadata.ObjectLock
+}
+
+func isObjectLockMode(mode string) bool {
+	return mode == s3_constants.ObjectLockModeGovernance || mode == s3_constants.ObjectLockModeCompliance
+}
+
+// objectLockForWrite validates the retention and the legal hold headers of the object written by the request,
+// and sets the default retention of the bucket if the object has no retention, for the filer to save them
+func (s3a *S3ApiServer) objectLockForWrite(header http.Header, bucket string) s3err.ErrorCode {
+	mode := header.Get(s3_constants.AmzObjectLockMode)
+	retainUntilDate := header.Get(s3_constants.AmzObjectLockRetainUntilDate)
+	legalHold := header.Get(s3_constants.AmzObjectLockLegalHold)
+
+	config := s3a.getObjectLockConfiguration(bucket)
+	if config == nil {
+		if mode != "" || retainUntilDate != "" || legalHold != "" {
+			return s3err.ErrMissingObjectLockConfiguration
+		}
+		return s3err.ErrNone
+	}
+
+	if legalHold != "" && legalHold != s3.ObjectLockLegalHoldStatusOn && legalHold != s3.ObjectLockLegalHoldStatusOff {
+		return s3err.ErrInvalidObjectLock
+	}
+	if (mode == "") != (retainUntilDate == "") {
+		return s3err.ErrInvalidObjectLock
+	}
+	now := time.Now()
+	if mode != "" {
+		retainUntil, err := time.Parse(time.RFC3339, retainUntilDate)
+		if err != nil || !isObjectLockMode(mode) || !retainUntil.After(now) {
+			return s3err.ErrInvalidObjectLock
+		}
+		header.Set(s3_constants.AmzObjectLockRetainUntilDate, retainUntil.UTC().Format(time.RFC3339))
+		return s3err.ErrNone
+	}
+	if config.Rule != nil && config.Rule.DefaultRetention != nil {
+		retention := config.Rule.DefaultRetention
+		retainUntil := now.AddDate(int(aws.Int64Value(retention.Years)), 0, int(aws.Int64Value(retention.Days)))
+		header.Set(s3_constants.AmzObjectLockMode, aws.StringValue(retention.Mode))
+		header.Set(s3_constants.AmzObjectLockRetainUntilDate, retainUntil.UTC().Format(time.RFC3339))
+	}
+	return s3err.ErrNone
+}
+
+// setEntryObjectLock replaces the retention and the legal hold of the object written with its extended attributes,
+// by the ones of the request checked by objectLockForWrite
+func setEntryObjectLock(extended map[string][]byte, header http.Header) {
+	for _, key := range []string{s3_constants.ExtObjectLockModeKey, s3_constants.ExtObjectLockRetainUntilDateKey, s3_constants.ExtObjectLockLegalHoldKey} {
+		delete(extended, key)
+		if value := header.Get(key); value != "" {
+			extended[key] = []byte(value)
+		}
+	}
+}
+
+func isBypassGovernanceRetention(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(s3_constants.AmzBypassGovernanceRetention), "true")
+}
+
+// canBypassGovernanceRetention checks the permission of the identity to bypass the governance retention of the object,
+// or of all the objects in the bucket for deleting multiple objects
+func (iam *IdentityAccessManagement) canBypassGovernanceRetention(r *http.Request, identity *Identity) bool {
+	if identity == nil {
+		return false
+	}
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	switch iam.evaluateBucketPolicyAction(r, identity, bucket, "s3:BypassGovernanceRetention") {
+	case policyDeny:
+		return false
+	case policyAllow:
+		return true
+	}
+	return identity.canDo(s3_constants.ACTION_BYPASS_GOVERNANCE_RETENTION, bucket, object)
+}
+
+// isWormEnforced tells the errors of the write-once files and the locked objects,
+// also when returned by the filer over grpc as PermissionDenied
+func isWormEnforced(err error) bool {
+	return errors.Is(err, filer.ErrWormEnforced) || status.Code(err) == codes.PermissionDenied
+}
+
+// checkObjectLockDeletable checks that the version of the object is not locked, before deleting it for good
+func checkObjectLockDeletable(client filer_pb.SeaweedFilerClient, p util.FullPath, versionId string, bypassGovernance bool) error {
+	var extended map[string][]byte
+	current, err := lookupObject(client, p)
+	if err != nil {
+		return err
+	}
+	if current != nil && objectVersionIdOf(current) == versionId {
+		extended = current.Extended
+	} else {
+		versions, err := listObjectVersions(client, p)
+		if err != nil {
+			return err
+		}
+		version := findObjectVersion(versions, versionId)
+		if version == nil {
+			return nil
+		}
+		extended = version.Entry.Extended
+	}
+	now := time.Now()
+	if filer.IsObjectLocked(extended, now) {
+		return filer.ErrWormEnforced
+	}
+	if mode, retainUntil, _ := filer.ObjectLockOf(extended); mode == s3_constants.ObjectLockModeGovernance && now.Before(retainUntil) && !bypassGovernance {
+		return filer.ErrWormEnforced
+	}
+	return nil
+}
+
+// GetObjectRetentionHandler Get Object Retention
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectRetention.html
+func (s3a *S3ApiServer) GetObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetObjectRetention %s %s", bucket, object)
+
+	_, entry, errCode := s3a.lockedObjectEntry(bucket, object, r.URL.Query().Get("versionId"))
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	mode, retainUntil, _ := filer.ObjectLockOf(entry.Extended)
+	if mode == "" {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchObjectLockConfiguration)
+		return
+	}
+	s3err.WriteAwsXMLResponse(w, r, http.StatusOK, &s3.PutObjectRetentionInput{
+		Retention: &s3.ObjectLockRetention{
+			Mode:            aws.String(mode),
+			RetainUntilDate: aws.Time(retainUntil.UTC()),
+		},
+	})
+}
+
+// PutObjectRetentionHandler Put Object Retention
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectRetention.html
+func (s3a *S3ApiServer) PutObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutObjectRetention %s %s", bucket, object)
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	var retention s3.ObjectLockRetention
+	defer util_http.CloseRequest(r)
+	if err := xmlutil.UnmarshalXML(&retention, xml.NewDecoder(r.Body), ""); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	mode, retainUntil := aws.StringValue(retention.Mode), aws.TimeValue(retention.RetainUntilDate)
+	now := time.Now()
+	// the retention is removed without the mode and the date
+	if mode != "" && (!isObjectLockMode(mode) || !retainUntil.After(now)) || mode == "" && retention.RetainUntilDate != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidObjectLock)
+		return
+	}
+
+	dir, entry, errCode := s3a.lockedObjectEntry(bucket, object, r.URL.Query().Get("versionId"))
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	oldMode, oldRetainUntil, _ := filer.ObjectLockOf(entry.Extended)
+	isShortened := mode == "" || retainUntil.Before(oldRetainUntil) ||
+		oldMode == s3_constants.ObjectLockModeCompliance && mode != s3_constants.ObjectLockModeCompliance
+	// the retention is only shortened in the GOVERNANCE mode, by the requests bypassing it
+	if now.Before(oldRetainUntil) && isShortened && (oldMode == s3_constants.ObjectLockModeCompliance || !isBypassGovernanceRetention(r)) {
+		s3err.WriteErrorResponse(w, r, s3err.ErrObjectLocked)
+		return
+	}
+
+	if entry.Extended == nil {
+		entry.Extended = make(map[string][]byte)
+	}
+	if mode == "" {
+		delete(entry.Extended, s3_constants.ExtObjectLockModeKey)
+		delete(entry.Extended, s3_constants.ExtObjectLockRetainUntilDateKey)
+	} else {
+		entry.Extended[s3_constants.ExtObjectLockModeKey] = []byte(mode)
+		entry.Extended[s3_constants.ExtObjectLockRetainUntilDateKey] = []byte(retainUntil.UTC().Format(time.RFC3339))
+	}
+	if err := s3a.updateEntry(dir, entry); err != nil {
+		glog.Errorf("PutObjectRetention %s/%s: %v", bucket, object, err)
+		s3err.WriteErrorResponse(w, r, filerErrorToS3Error(err))
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+// GetObjectLegalHoldHandler Get Object Legal Hold
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
+func (s3a *S3ApiServer) GetObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetObjectLegalHold %s %s", bucket, object)
+
+	_, entry, errCode := s3a.lockedObjectEntry(bucket, object, r.URL.Query().Get("versionId"))
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	status, found := entry.Extended[s3_constants.ExtObjectLockLegalHoldKey]
+	if !found {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchObjectLockConfiguration)
+		return
+	}
+	s3err.WriteAwsXMLResponse(w, r, http.StatusOK, &s3.PutObjectLegalHoldInput{
+		LegalHold: &s3.ObjectLockLegalHold{
+			Status: aws.String(string(status)),
+		},
+	})
+}
+
+// PutObjectLegalHoldHandler Put Object Legal Hold
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
+func (s3a *S3ApiServer) PutObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutObjectLegalHold %s %s", bucket, object)
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	var legalHold s3.ObjectLockLegalHold
+	defer util_http.CloseRequest(r)
+	if err := xmlutil.UnmarshalXML(&legalHold, xml.NewDecoder(r.Body), ""); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	status := aws.StringValue(legalHold.Status)
+	if status != s3.ObjectLockLegalHoldStatusOn && status != s3.ObjectLockLegalHoldStatusOff {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidObjectLock)
+		return
+	}
+
+	dir, entry, errCode := s3a.lockedObjectEntry(bucket, object, r.URL.Query().Get("versionId"))
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	if entry.Extended == nil {
+		entry.Extended = make(map[string][]byte)
+	}
+	entry.Extended[s3_constants.ExtObjectLockLegalHoldKey] = []byte(status)
+	if err := s3a.updateEntry(dir, entry); err != nil {
+		glog.Errorf("PutObjectLegalHold %s/%s: %v", bucket, object, err)
+		s3err.WriteErrorResponse(w, r, filerErrorToS3Error(err))
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+// lockedObjectEntry finds the object, or its version, in the bucket with the object lock enabled,
+// with the directory to update it in
+func (s3a *S3ApiServer) lockedObjectEntry(bucket, object, versionId string) (dir string, entry *filer_pb.Entry, errCode s3err.ErrorCode) {
+	if s3a.getObjectLockConfiguration(bucket) == nil {
+		if _, errCode = s3a.bucketRegistry.GetBucketMetadata(bucket); errCode != s3err.ErrNone {
+			return "", nil, errCode
+		}
+		return "", nil, s3err.ErrMissingObjectLockConfiguration
+	}
+	p := s3a.objectPath(bucket, object)
+	if versionId == "" {
+		dir, name := p.DirAndName()
+		entry, err := s3a.getEntry(dir, name)
+		if err == filer_pb.ErrNotFound || err == nil && (entry.IsDirectory || isDeleteMarker(entry)) {
+			return "", nil, s3err.ErrNoSuchKey
+		}
+		if err != nil {
+			glog.Errorf("lookup %s/%s: %v", bucket, object, err)
+			return "", nil, s3err.ErrInternalError
+		}
+		return dir, entry, s3err.ErrNone
+	}
+
+	entry, filerVersionId, err := s3a.lookupObjectVersion(p, versionId)
+	if err == filer_pb.ErrNotFound {
+		return "", nil, s3err.ErrNoSuchVersion
+	}
+	if err != nil {
+		glog.Errorf("lookup version %s of %s/%s: %v", versionId, bucket, object, err)
+		return "", nil, s3err.ErrInternalError
+	}
+	if isDeleteMarker(entry) {
+		return "", nil, s3err.ErrMethodNotAllowed
+	}
+	if filerVersionId == "" {
+		dir, _ = p.DirAndName()
+		return dir, entry, s3err.ErrNone
+	}
+	// the previous versions are kept by the filer in the versions directory
+	entry.Name = filerVersionId
+	return string(filer.VersionsDirOf(p)), entry, s3err.ErrNone
+}
@@ -0,0 +1,172 @@
+package s3api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+func TestValidateObjectLockConfiguration(t *testing.T) {
+	defaultRetention := func(mode string, days, years int64) *s3.ObjectLockConfiguration {
+		return &s3.ObjectLockConfiguration{
+			ObjectLockEnabled: aws.String(s3.ObjectLockEnabledEnabled),
+			Rule: &s3.ObjectLockRule{DefaultRetention: &s3.DefaultRetention{
+				Mode: aws.String(mode), Days: aws.Int64(days), Years: aws.Int64(years),
+			}},
+		}
+	}
+	for _, tt := range []struct {
+		config  *s3.ObjectLockConfiguration
+		errCode s3err.ErrorCode
+	}{
+		{&s3.ObjectLockConfiguration{ObjectLockEnabled: aws.String(s3.ObjectLockEnabledEnabled)}, s3err.ErrNone},
+		{&s3.ObjectLockConfiguration{}, s3err.ErrInvalidObjectLockConfiguration},
+		{defaultRetention(s3_constants.ObjectLockModeGovernance, 30, 0), s3err.ErrNone},
+		{defaultRetention(s3_constants.ObjectLockModeCompliance, 0, 1), s3err.ErrNone},
+		{defaultRetention(s3_constants.ObjectLockModeCompliance, 30, 1), s3err.ErrInvalidObjectLockConfiguration},
+		{defaultRetention(s3_constants.ObjectLockModeCompliance, 0, 0), s3err.ErrInvalidObjectLockConfiguration},
+		{defaultRetention("LEGAL", 30, 0), s3err.ErrInvalidObjectLockConfiguration},
+	} {
+		if errCode := validateObjectLockConfiguration(tt.config); errCode != tt.errCode {
+			t.Errorf("validate %v: got %v, expected %v", tt.config, errCode, tt.errCode)
+		}
+	}
+}
+
+func TestObjectLockForWrite(t *testing.T) {
+	s3a := &S3ApiServer{bucketRegistry: &BucketRegistry{
+		metadataCache: map[string]*BucketMetaData{
+			"plain": {Name: "plain"},
+			"locked": {Name: "locked", ObjectLock: &s3.ObjectLockConfiguration{
+				ObjectLockEnabled: aws.String(s3.ObjectLockEnabledEnabled),
+				Rule: &s3.ObjectLockRule{DefaultRetention: &s3.DefaultRetention{
+					Mode: aws.String(s3_constants.ObjectLockModeGovernance), Days: aws.Int64(1),
+				}},
+			}},
+		},
+		notFound: make(map[string]struct{}),
+	}}
+
+	header := http.Header{}
+	if errCode := s3a.objectLockForWrite(header, "plain"); errCode != s3err.ErrNone || len(header) != 0 {
+		t.Errorf("unexpected %v with %v", errCode, header)
+	}
+	header.Set(s3_constants.AmzObjectLockLegalHold, s3_constants.ObjectLockLegalHoldOn)
+	if errCode := s3a.objectLockForWrite(header, "plain"); errCode != s3err.ErrMissingObjectLockConfiguration {
+		t.Errorf("unexpected %v", errCode)
+	}
+
+	// the default retention of the bucket
+	if errCode := s3a.objectLockForWrite(header, "locked"); errCode != s3err.ErrNone {
+		t.Fatalf("unexpected %v", errCode)
+	}
+	retainUntil, err := time.Parse(time.RFC3339, header.Get(s3_constants.AmzObjectLockRetainUntilDate))
+	if err != nil || header.Get(s3_constants.AmzObjectLockMode) != s3_constants.ObjectLockModeGovernance ||
+		retainUntil.Before(time.Now().Add(23*time.Hour)) || retainUntil.After(time.Now().Add(25*time.Hour)) {
+		t.Errorf("unexpected default retention %v: %v", header, err)
+	}
+
+	// the retention of the request
+	retainUntil = time.Now().Add(time.Hour).Truncate(time.Second)
+	header.Set(s3_constants.AmzObjectLockMode, s3_constants.ObjectLockModeCompliance)
+	header.Set(s3_constants.AmzObjectLockRetainUntilDate, retainUntil.In(time.FixedZone("", 3600)).Format(time.RFC3339))
+	if errCode := s3a.objectLockForWrite(header, "locked"); errCode != s3err.ErrNone ||
+		header.Get(s3_constants.AmzObjectLockRetainUntilDate) != retainUntil.UTC().Format(time.RFC3339) {
+		t.Errorf("unexpected %v with %v", errCode, header)
+	}
+
+	header.Set(s3_constants.AmzObjectLockRetainUntilDate, time.Now().Add(-time.Hour).Format(time.RFC3339))
+	if errCode := s3a.objectLockForWrite(header, "locked"); errCode != s3err.ErrInvalidObjectLock {
+		t.Errorf("the retention in the past should be invalid: %v", errCode)
+	}
+	header.Del(s3_constants.AmzObjectLockRetainUntilDate)
+	if errCode := s3a.objectLockForWrite(header, "locked"); errCode != s3err.ErrInvalidObjectLock {
+		t.Errorf("the mode without the date should be invalid: %v", errCode)
+	}
+
+	extended := map[string][]byte{s3_constants.ExtObjectLockLegalHoldKey: []byte("ON"), "other": []byte("x")}
+	setEntryObjectLock(extended, http.Header{s3_constants.AmzObjectLockMode: []string{s3_constants.ObjectLockModeGovernance}})
+	if len(extended) != 2 || string(extended[s3_constants.ExtObjectLockModeKey]) != s3_constants.ObjectLockModeGovernance {
+		t.Errorf("unexpected %v", extended)
+	}
+}
+
+func TestCanBypassGovernanceRetention(t *testing.T) {
+	policy, err := ParseBucketPolicy([]byte(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":{"AWS":"u2"},"Action":"s3:BypassGovernanceRetention","Resource":"arn:aws:s3:::b/tmp/*"},
+		{"Effect":"Deny","Principal":{"AWS":"u3"},"Action":"s3:BypassGovernanceRetention","Resource":"arn:aws:s3:::b/*"}
+	]}`))
+	if err != nil {
+		t.Fatalf("parse bucket policy: %v", err)
+	}
+	iam := &IdentityAccessManagement{bucketPolicyOf: func(bucket string) *BucketPolicy {
+		if bucket == "b" {
+			return policy
+		}
+		return nil
+	}}
+	writer := &Identity{Name: "u1", Account: &AccountAdmin, Actions: []Action{s3_constants.ACTION_WRITE}}
+	allowedByPolicy := &Identity{Name: "u2", Account: &AccountAdmin, Actions: []Action{s3_constants.ACTION_WRITE}}
+	deniedByPolicy := &Identity{Name: "u3", Account: &AccountAdmin,
+		Actions: []Action{s3_constants.ACTION_WRITE, s3_constants.ACTION_BYPASS_GOVERNANCE_RETENTION}}
+	bypassing := &Identity{Name: "u4", Account: &AccountAdmin,
+		Actions: []Action{s3_constants.ACTION_WRITE, s3_constants.ACTION_BYPASS_GOVERNANCE_RETENTION + ":c/logs/*"}}
+	admin := &Identity{Name: "admin", Account: &AccountAdmin, Actions: []Action{s3_constants.ACTION_ADMIN}}
+
+	request := func(bucket, object string) *http.Request {
+		r := httptest.NewRequest(http.MethodDelete, "/"+bucket+"/"+object+"?versionId=v1", nil)
+		r.Header.Set(s3_constants.AmzBypassGovernanceRetention, "true")
+		return mux.SetURLVars(r, map[string]string{"bucket": bucket, "object": object})
+	}
+	for _, tt := range []struct {
+		name     string
+		identity *Identity
+		r        *http.Request
+		expected bool
+	}{
+		{"unauthenticated", nil, request("b", "a"), false},
+		{"any writer", writer, request("b", "a"), false},
+		{"allowed by the bucket policy", allowedByPolicy, request("b", "tmp/a"), true},
+		{"outside of the bucket policy", allowedByPolicy, request("b", "a"), false},
+		{"denied by the bucket policy", deniedByPolicy, request("b", "a"), false},
+		{"allowed by the action", deniedByPolicy, request("c", "a"), true},
+		{"allowed by the action on the prefix", bypassing, request("c", "logs/a"), true},
+		{"outside of the prefix", bypassing, request("c", "a"), false},
+		{"admin", admin, request("b", "a"), true},
+	} {
+		if allowed := iam.canBypassGovernanceRetention(tt.r, tt.identity); allowed != tt.expected {
+			t.Errorf("%s: got %v, expected %v", tt.name, allowed, tt.expected)
+		}
+	}
+}
+
+func TestFilerErrorToS3Error(t *testing.T) {
+	overGrpc := fmt.Errorf("UpdateEntry: %w", status.Error(codes.PermissionDenied, filer.ErrWormEnforced.Error()))
+	for i, c := range []struct {
+		err      error
+		expected s3err.ErrorCode
+	}{
+		{filer.ErrWormEnforced, s3err.ErrAccessDenied},
+		{fmt.Errorf("delete entry /buckets/b/o: %w", filer.ErrWormEnforced), s3err.ErrAccessDenied},
+		{overGrpc, s3err.ErrAccessDenied},
+		{fmt.Errorf("UpdateEntry: %v", status.Error(codes.Unavailable, "connection refused")), s3err.ErrInternalError},
+		{errors.New("existing /buckets/b/o is a directory"), s3err.ErrExistingObjectIsDirectory},
+	} {
+		if code := filerErrorToS3Error(c.err); code != c.expected {
+			t.Errorf("%d: %v is %v, expected %v", i, c.err, code, c.expected)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package s3api
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3select"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+// The objects are selected by the s3 gateway, which reads the chunks from the volume servers,
+// and streams back only the selected rows, so the rows filtered out never leave the cluster.
+
+const (
+	// the selected rows are sent in the Records events of about this size
+	selectRecordsPayloadSize = 128 * 1024
+	// the Cont events keep the connection alive while the rows are filtered out
+	selectContinuationInterval = 5 * time.Second
+	// the parquet chunks read ahead
+	selectParquetPrefetchCount = 4
+)
+
+// selectObjectContentRequest is the request body of SelectObjectContent
+type selectObjectContentRequest struct {
+	XMLName         xml.Name `xml:"SelectObjectContentRequest"`
+	Expression      string
+	ExpressionType  string
+	RequestProgress struct {
+		Enabled bool
+	}
+	InputSerialization struct {
+		CompressionType string
+		CSV             *s3select.CSVInput
+		JSON            *s3select.JSONInput
+		Parquet         *s3select.ParquetInput
+	}
+	OutputSerialization struct {
+		CSV  *s3select.CSVOutput
+		JSON *s3select.JSONOutput
+	}
+	ScanRange *struct {
+		Start *int64
+		End   *int64
+	}
+}
+
+// SelectObjectContentHandler Select Object Content
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+func (s3a *S3ApiServer) SelectObjectContentHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("SelectObjectContentHandler %s %s", bucket, object)
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	var request selectObjectContentRequest
+	defer util_http.CloseRequest(r)
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	query, output, errCode := parseSelectRequest(&request)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	entry, errCode := s3a.selectObjectEntry(bucket, object, r.URL.Query().Get("versionId"))
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	e, err := objectEncryptionOfExtended(entry.Extended)
+	if err != nil {
+		glog.Errorf("read encryption of %s/%s: %v", bucket, object, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	if e != nil {
+		if errCode = s3a.unlock(r.Context(), e, r.Header, sseCustomerHeaders); errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+	}
+
+	stats := &s3select.Stats{}
+	records, closeFn, errCode := s3a.openSelectRecords(&request, query, entry, e, stats)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	defer closeFn()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	events := s3select.NewEventWriter(w)
+	if err = selectRecords(events, query, records, output, stats, request.RequestProgress.Enabled); err != nil {
+		glog.V(1).Infof("select %s/%s: %v", bucket, object, err)
+		// the response has started, so the errors are sent as the error events
+		if writeErr := events.WriteError("InvalidQuery", err.Error()); writeErr != nil {
+			glog.V(1).Infof("select %s/%s: %v", bucket, object, writeErr)
+		}
+	}
+}
+
+func parseSelectRequest(request *selectObjectContentRequest) (*s3select.Query, s3select.Output, s3err.ErrorCode) {
+	if !strings.EqualFold(request.ExpressionType, "SQL") {
+		return nil, nil, s3err.ErrInvalidExpressionType
+	}
+	query, err := s3select.Parse(request.Expression)
+	if err != nil {
+		glog.V(1).Infof("select %q: %v", request.Expression, err)
+		return nil, nil, s3err.ErrInvalidSelectExpression
+	}
+	// the records of the scan ranges would be split by the other requests
+	if request.ScanRange != nil {
+		return nil, nil, s3err.ErrNotImplemented
+	}
+
+	input := request.InputSerialization
+	inputs := 0
+	for _, isSet := range []bool{input.CSV != nil, input.JSON != nil, input.Parquet != nil} {
+		if isSet {
+			inputs++
+		}
+	}
+	if inputs != 1 {
+		return nil, nil, s3err.ErrInvalidSelectRequest
+	}
+	switch strings.ToUpper(input.CompressionType) {
+	case "", "NONE":
+	case "GZIP", "BZIP2":
+		if input.Parquet != nil {
+			return nil, nil, s3err.ErrInvalidCompressionFormat
+		}
+	default:
+		return nil, nil, s3err.ErrInvalidCompressionFormat
+	}
+
+	var output s3select.Output
+	switch {
+	case request.OutputSerialization.CSV != nil && request.OutputSerialization.JSON == nil:
+		if err = request.OutputSerialization.CSV.Validate(); err != nil {
+			glog.V(1).Infof("select output: %v", err)
+			return nil, nil, s3err.ErrInvalidSelectRequest
+		}
+		output = request.OutputSerialization.CSV
+	case request.OutputSerialization.JSON != nil && request.OutputSerialization.CSV == nil:
+		if err = request.OutputSerialization.JSON.Validate(); err != nil {
+			glog.V(1).Infof("select output: %v", err)
+			return nil, nil, s3err.ErrInvalidSelectRequest
+		}
+		output = request.OutputSerialization.JSON
+	default:
+		return nil, nil, s3err.ErrInvalidSelectRequest
+	}
+	return query, output, s3err.ErrNone
+}
+
+// selectObjectEntry finds the object, or its version
+func (s3a *S3ApiServer) selectObjectEntry(bucket, object, versionId string) (*filer_pb.Entry, s3err.ErrorCode) {
+	if versionId != "" {
+		entry, _, err := s3a.lookupObjectVersion(s3a.objectPath(bucket, object), versionId)
+		if err == filer_pb.ErrNotFound {
+			return nil, s3err.ErrNoSuchVersion
+		}
+		if err != nil {
+			glog.Errorf("lookup version %s of %s/%s: %v", versionId, bucket, object, err)
+			return nil, s3err.ErrInternalError
+		}
+		if isDeleteMarker(entry) {
+			return nil, s3err.ErrMethodNotAllowed
+		}
+		return entry, s3err.ErrNone
+	}
+	dir, name := s3a.objectPath(bucket, object).DirAndName()
+	entry, err := s3a.getEntry(dir, name)
+	if err == filer_pb.ErrNotFound {
+		return nil, s3err.ErrNoSuchKey
+	}
+	if err != nil {
+		glog.Errorf("lookup %s/%s: %v", bucket, object, err)
+		return nil, s3err.ErrInternalError
+	}
+	if entry.IsDirectory || isDeleteMarker(entry) {
+		return nil, s3err.ErrNoSuchKey
+	}
+	return entry, s3err.ErrNone
+}
+
+// openSelectRecords reads the records of the object, decrypted and decompressed
+func (s3a *S3ApiServer) openSelectRecords(request *selectObjectContentRequest, query *s3select.Query, entry *filer_pb.Entry,
+	e *objectEncryption, stats *s3select.Stats) (records s3select.RecordReader, closeFn func(), errCode s3err.ErrorCode) {
+
+	input := request.InputSerialization
+	size := int64(filer.FileSize(entry))
+	closeFn = func() {}
+
+	if input.Parquet != nil {
+		var readerAt io.ReaderAt = bytes.NewReader(entry.Content)
+		if len(entry.Content) == 0 {
+			lookupFileIdFn := filer.LookupFn(s3a)
+			visibleIntervals, err := filer.NonOverlappingVisibleIntervals(lookupFileIdFn, entry.GetChunks(), 0, size)
+			if err != nil {
+				glog.Errorf("read chunks of %s: %v", entry.Name, err)
+				return nil, nil, s3err.ErrInternalError
+			}
+			chunkViews := filer.ViewFromVisibleIntervals(visibleIntervals, 0, size)
+			readerCache := filer.NewReaderCache(selectParquetPrefetchCount, noChunkCache{}, lookupFileIdFn)
+			chunkReaderAt := filer.NewChunkReaderAtFromClient(readerCache, chunkViews, size)
+			readerAt, closeFn = chunkReaderAt, func() { chunkReaderAt.Close() }
+		}
+		if e != nil {
+			readerAt = &decryptingReaderAt{encryption: e, readerAt: readerAt}
+		}
+		// the parquet objects are read in the column chunks, roughly scanned through
+		stats.BytesScanned, stats.BytesProcessed = size, size
+		records, err := s3select.NewParquetReader(readerAt, size, query)
+		if err != nil {
+			closeFn()
+			glog.V(1).Infof("read parquet %s: %v", entry.Name, err)
+			return nil, nil, s3err.ErrInvalidSelectRequest
+		}
+		return records, closeFn, s3err.ErrNone
+	}
+
+	var reader io.Reader = filer.NewFileReader(s3a, entry)
+	if closer, ok := reader.(io.Closer); ok {
+		closeFn = func() { closer.Close() }
+	}
+	if e != nil {
+		reader = e.decrypt(io.NopCloser(reader), 0)
+	}
+	reader = &countingReader{reader: reader, count: &stats.BytesScanned}
+	switch strings.ToUpper(input.CompressionType) {
+	case "GZIP":
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			closeFn()
+			glog.V(1).Infof("read gzip %s: %v", entry.Name, err)
+			return nil, nil, s3err.ErrInvalidCompressionFormat
+		}
+		reader = gzipReader
+	case "BZIP2":
+		reader = bzip2.NewReader(reader)
+	}
+	reader = &countingReader{reader: reader, count: &stats.BytesProcessed}
+
+	var err error
+	if input.CSV != nil {
+		records, err = s3select.NewCSVReader(reader, input.CSV)
+	} else {
+		records, err = s3select.NewJSONReader(reader, input.JSON, query)
+	}
+	if err != nil {
+		closeFn()
+		glog.V(1).Infof("read %s: %v", entry.Name, err)
+		return nil, nil, s3err.ErrInvalidSelectRequest
+	}
+	return records, closeFn, s3err.ErrNone
+}
+
+// selectRecords streams the rows selected from the records as the events
+func selectRecords(events *s3select.EventWriter, query *s3select.Query, records s3select.RecordReader, output s3select.Output,
+	stats *s3select.Stats, progressEnabled bool) error {
+
+	selection := query.NewSelection()
+	var payload []byte
+	lastSent := time.Now()
+	flush := func() error {
+		if len(payload) == 0 {
+			return nil
+		}
+		if err := events.WriteRecords(payload); err != nil {
+			return err
+		}
+		stats.BytesReturned += int64(len(payload))
+		payload, lastSent = payload[:0], time.Now()
+		if progressEnabled {
+			return events.WriteProgress(*stats)
+		}
+		return nil
+	}
+
+	for !selection.Done() {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		row, err := selection.Select(record)
+		if err != nil {
+			return err
+		}
+		if row != nil {
+			if payload, err = output.AppendRow(payload, row); err != nil {
+				return err
+			}
+		}
+		if len(payload) >= selectRecordsPayloadSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		} else if time.Since(lastSent) > selectContinuationInterval {
+			if err = events.WriteContinuation(); err != nil {
+				return err
+			}
+			lastSent = time.Now()
+		}
+	}
+
+	row, err := selection.Result()
+	if err != nil {
+		return err
+	}
+	if row != nil {
+		if payload, err = output.AppendRow(payload, row); err != nil {
+			return err
+		}
+	}
+	if err = flush(); err != nil {
+		return err
+	}
+	if err = events.WriteStats(*stats); err != nil {
+		return err
+	}
+	return events.WriteEnd()
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  *int64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	*r.count += int64(n)
+	return n, err
+}
+
+// decryptingReaderAt decrypts the encrypted object read at any offset
+type decryptingReaderAt struct {
+	encryption *objectEncryption
+	readerAt   io.ReaderAt
+}
+
+func (r *decryptingReaderAt) ReadAt(p []byte, offset int64) (n int, err error) {
+	section := io.NopCloser(io.NewSectionReader(r.readerAt, offset, int64(len(p))))
+	n, err = io.ReadFull(r.encryption.decrypt(section, offset), p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// noChunkCache caches nothing, the chunks of the selected objects are read once
+type noChunkCache struct{}
+
+func (noChunkCache) ReadChunkAt(data []byte, fileId string, offset uint64) (n int, err error) {
+	return 0, nil
+}
+
+func (noChunkCache) SetChunk(fileId string, data []byte) {
+}
+
+func (noChunkCache) IsInCache(fileId string, lockNeeded bool) bool {
+	return false
+}
+
+func (noChunkCache) GetMaxFilePartSizeInCache() uint64 {
+	return 0
+}
@@ -0,0 +1,88 @@
+package s3api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3select"
+)
+
+func TestSelectObjectContent(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<SelectObjectContentRequest xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Expression>SELECT s.name FROM S3Object s WHERE CAST(s.age AS INT) &gt; 30</Expression>
+  <ExpressionType>SQL</ExpressionType>
+  <InputSerialization>
+    <CompressionType>NONE</CompressionType>
+    <CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV>
+  </InputSerialization>
+  <OutputSerialization><JSON/></OutputSerialization>
+</SelectObjectContentRequest>`
+	var request selectObjectContentRequest
+	if err := xml.NewDecoder(strings.NewReader(body)).Decode(&request); err != nil {
+		t.Fatal(err)
+	}
+	query, output, errCode := parseSelectRequest(&request)
+	if errCode != s3err.ErrNone {
+		t.Fatalf("parse request: %v", errCode)
+	}
+	records, err := s3select.NewCSVReader(strings.NewReader("name,age\na,31\nb,20\nc,40\n"), request.InputSerialization.CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	stats := &s3select.Stats{}
+	if err = selectRecords(s3select.NewEventWriter(&buf), query, records, output, stats, false); err != nil {
+		t.Fatal(err)
+	}
+	decoder := eventstream.NewDecoder(&buf)
+	var events []string
+	for {
+		message, err := decoder.Decode(nil)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, message.Headers.Get(":event-type").String())
+		if events[len(events)-1] == "Records" && string(message.Payload) != "{\"name\":\"a\"}\n{\"name\":\"c\"}\n" {
+			t.Errorf("unexpected records %q", message.Payload)
+		}
+	}
+	if strings.Join(events, ",") != "Records,Stats,End" || stats.BytesReturned != 26 {
+		t.Errorf("unexpected events %v with stats %+v", events, stats)
+	}
+
+	request.ExpressionType = "XPATH"
+	if _, _, errCode = parseSelectRequest(&request); errCode != s3err.ErrInvalidExpressionType {
+		t.Errorf("unexpected error %v", errCode)
+	}
+	request.ExpressionType, request.InputSerialization.CompressionType = "SQL", "ZSTD"
+	if _, _, errCode = parseSelectRequest(&request); errCode != s3err.ErrInvalidCompressionFormat {
+		t.Errorf("unexpected error %v", errCode)
+	}
+}
+
+func TestDecryptingReaderAt(t *testing.T) {
+	e := newTestEncryption(t)
+	data := make([]byte, 300)
+	rand.Read(data)
+	readerAt := &decryptingReaderAt{encryption: e, readerAt: bytes.NewReader(encryptForTest(t, e, data, 0))}
+
+	for _, offset := range []int64{0, 7, 16, 250} {
+		p := make([]byte, 64)
+		n, err := readerAt.ReadAt(p, offset)
+		if want := min(64, int(300-offset)); n != want || !bytes.Equal(p[:n], data[offset:offset+int64(n)]) {
+			t.Errorf("read %d bytes at %d: %v", n, offset, err)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package s3api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The objects of a versioned bucket keep their previous versions as the filer keeps the previous versions of the
+// files, under "/.versions/buckets/<bucket>/<key>/", one filer entry per version. Enabling the versioning sets
+// unlimited max_versions for the bucket location in filer.conf, and the objects written afterwards carry their
+// version ids in the extended attributes. The objects written before, or while the versioning is suspended,
+// have the "null" version id.
+//
+// Deleting an object without a version id keeps it as a previous version, and adds a delete marker, an empty
+// version entry marked as such, so the deleted object is still listed with its versions. Deleting a version removes
+// it with the chunks not shared with the other versions, and if it was the latest, the previous version becomes
+// the current object again. The NoncurrentVersionExpiration of the lifecycle rules is the version_retention_seconds
+// of the locations, so the filer prunes the versions noncurrent for longer.
+
+const (
+	versionIdNull = "null"
+)
+
+// newObjectVersionId generates a version id, ordered by the time it is generated
+func newObjectVersionId() string {
+	randomBytes := make([]byte, 8)
+	_, _ = rand.Read(randomBytes)
+	return fmt.Sprintf("%016x%s", time.Now().UnixNano(), hex.EncodeToString(randomBytes))
+}
+
+// objectVersionIdOf is the version id of the object or the delete marker
+func objectVersionIdOf(entry *filer_pb.Entry) string {
+	if versionId, found := entry.Extended[s3_constants.ExtVersionIdKey]; found && len(versionId) > 0 {
+		return string(versionId)
+	}
+	return versionIdNull
+}
+
+// setObjectVersionId sets the version id of the object, or removes it for the "null" version
+func setObjectVersionId(extended map[string][]byte, versionId string) {
+	if versionId == "" || versionId == versionIdNull {
+		delete(extended, s3_constants.ExtVersionIdKey)
+		return
+	}
+	extended[s3_constants.ExtVersionIdKey] = []byte(versionId)
+}
+
+func isDeleteMarker(entry *filer_pb.Entry) bool {
+	_, found := entry.Extended[s3_constants.ExtDeleteMarkerKey]
+	return found
+}
+
+// isSameObject tells whether the saved version is the object
+func isSameObject(a, b *filer_pb.Entry) bool {
+	return objectVersionIdOf(a) == objectVersionIdOf(b) &&
+		a.Attributes.GetMtime() == b.Attributes.GetMtime() &&
+		filer.FileSize(a) == filer.FileSize(b) &&
+		filer.ETag(a) == filer.ETag(b)
+}
+
+// getVersioningStatus is Enabled, Suspended, or empty if the versioning of the bucket has never been configured
+func (s3a *S3ApiServer) getVersioningStatus(bucket string) string {
+	if s3a.bucketRegistry == nil {
+		return ""
+	}
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone {
+		return ""
+	}
+	return metadata.Versioning
+}
+
+// versionIdForWrite is the version id of an object written to the bucket, empty for the "null" version
+func (s3a *S3ApiServer) versionIdForWrite(bucket string) string {
+	if s3a.getVersioningStatus(bucket) == s3.BucketVersioningStatusEnabled {
+		return newObjectVersionId()
+	}
+	return ""
+}
+
+func (s3a *S3ApiServer) objectPath(bucket, object string) util.FullPath {
+	if !strings.HasPrefix(object, "/") {
+		object = "/" + object
+	}
+	return util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, removeDuplicateSlashes(object)))
+}
+
+func setVersionIdHeader(w http.ResponseWriter, versionId string) {
+	if versionId != "" {
+		w.Header().Set(s3_constants.AmzVersionId, versionId)
+	}
+}
+
+// keepBucketVersions lets the filer keep all the previous versions of the objects in the bucket
+func (s3a *S3ApiServer) keepBucketVersions(bucket string) error {
+	fc, err := filer.ReadFilerConf(s3a.filers.Current(), s3a.option.GrpcDialOption, nil)
+	if err != nil {
+		return fmt.Errorf("read filer conf: %v", err)
+	}
+	locationPrefix := fmt.Sprintf("%s/%s/", s3a.option.BucketsPath, bucket)
+	if locConf, found := fc.GetLocationConf(locationPrefix); found && locConf.MaxVersions == math.MaxUint32 {
+		return nil
+	}
+	if err = fc.AddLocationConf(&filer_pb.FilerConf_PathConf{
+		LocationPrefix: locationPrefix,
+		MaxVersions:    math.MaxUint32,
+	}); err != nil {
+		return err
+	}
+	return s3a.saveFilerConf(fc)
+}
+
+// forgetBucketVersions stops keeping the previous versions of the objects in the deleted bucket
+func (s3a *S3ApiServer) forgetBucketVersions(bucket string) error {
+	fc, err := filer.ReadFilerConf(s3a.filers.Current(), s3a.option.GrpcDialOption, nil)
+	if err != nil {
+		return fmt.Errorf("read filer conf: %v", err)
+	}
+	changed := false
+	bucketPrefix := fmt.Sprintf("%s/%s/", s3a.option.BucketsPath, bucket)
+	for _, locConf := range fc.ToProto().Locations {
+		if strings.HasPrefix(locConf.LocationPrefix, bucketPrefix) && (locConf.MaxVersions > 0 || locConf.VersionRetentionSeconds > 0) {
+			locConf.MaxVersions, locConf.VersionRetentionSeconds = 0, 0
+			if err = fc.SetLocationConf(locConf); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s3a.saveFilerConf(fc)
+}
+
+func (s3a *S3ApiServer) saveFilerConf(fc *filer.FilerConf) error {
+	var buf strings.Builder
+	if err := fc.ToText(&buf); err != nil {
+		return fmt.Errorf("filer conf to text: %v", err)
+	}
+	return s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer.SaveInsideFiler(client, filer.DirectoryEtcSeaweedFS, filer.FilerConfName, []byte(buf.String()))
+	})
+}
+
+// listObjectVersions lists the previous versions and the delete markers of the object, the newest first
+func listObjectVersions(client filer_pb.SeaweedFilerClient, p util.FullPath) ([]*filer_pb.EntryVersion, error) {
+	dir, name := p.DirAndName()
+	resp, err := client.ListEntryVersions(context.Background(), &filer_pb.ListEntryVersionsRequest{
+		Directory: dir,
+		Name:      name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list versions of %s: %v", p, err)
+	}
+	versions := resp.Versions
+	slices.Reverse(versions)
+	return versions, nil
+}
+
+// findObjectVersion finds the newest version with the version id
+func findObjectVersion(versions []*filer_pb.EntryVersion, versionId string) *filer_pb.EntryVersion {
+	for _, version := range versions {
+		if objectVersionIdOf(version.Entry) == versionId {
+			return version
+		}
+	}
+	return nil
+}
+
+func lookupObject(client filer_pb.SeaweedFilerClient, p util.FullPath) (*filer_pb.Entry, error) {
+	dir, name := p.DirAndName()
+	resp, err := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+		Directory: dir,
+		Name:      name,
+	})
+	if err == filer_pb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Entry.IsDirectory {
+		return nil, nil
+	}
+	return resp.Entry, nil
+}
+
+func deleteEntryVersion(client filer_pb.SeaweedFilerClient, p util.FullPath, filerVersionId string) error {
+	dir, name := p.DirAndName()
+	_, err := client.DeleteEntryVersion(context.Background(), &filer_pb.DeleteEntryVersionRequest{
+		Directory: dir,
+		Name:      name,
+		VersionId: filerVersionId,
+	})
+	if err != nil && !strings.Contains(err.Error(), filer_pb.ErrNotFound.Error()) {
+		return fmt.Errorf("delete version %s of %s: %w", filerVersionId, p, err)
+	}
+	return nil
+}
+
+// deleteNullVersions deletes the previous "null" versions, replaced by the writes while the versioning is suspended
+func deleteNullVersions(client filer_pb.SeaweedFilerClient, p util.FullPath, versions []*filer_pb.EntryVersion) error {
+	for _, version := range versions {
+		if objectVersionIdOf(version.Entry) != versionIdNull {
+			continue
+		}
+		if err := deleteEntryVersion(client, p, version.VersionId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maybeRemoveNullVersions removes the "null" version replaced by a write while the versioning is suspended
+func (s3a *S3ApiServer) maybeRemoveNullVersions(bucket string, p util.FullPath) {
+	if s3a.getVersioningStatus(bucket) != s3.BucketVersioningStatusSuspended {
+		return
+	}
+	err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		versions, err := listObjectVersions(client, p)
+		if err != nil {
+			return err
+		}
+		return deleteNullVersions(client, p, versions)
+	})
+	if err != nil {
+		glog.Warningf("remove null versions of %s: %v", p, err)
+	}
+}
+
+// createDeleteMarker adds a delete marker as the latest version of the object, after the existing versions
+func createDeleteMarker(client filer_pb.SeaweedFilerClient, p util.FullPath, versionId string, versions []*filer_pb.EntryVersion) error {
+	now := time.Now()
+	// the versions are ordered by the time they are replaced, by the clock of the filer
+	if len(versions) > 0 && versions[0].ReplacedAtNs >= now.UnixNano() {
+		now = time.Unix(0, versions[0].ReplacedAtNs+1)
+	}
+	marker := &filer_pb.Entry{
+		Name: filer.VersionIdOf(now),
+		Attributes: &filer_pb.FuseAttributes{
+			Mtime:    now.Unix(),
+			Crtime:   now.Unix(),
+			FileMode: uint32(0644),
+		},
+		Extended: map[string][]byte{
+			s3_constants.ExtDeleteMarkerKey: []byte("true"),
+		},
+	}
+	setObjectVersionId(marker.Extended, versionId)
+	return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+		Directory: string(filer.VersionsDirOf(p)),
+		Entry:     marker,
+	})
+}
+
+// promoteLatestVersion restores the latest previous version as the current object, unless it is a delete marker
+func promoteLatestVersion(client filer_pb.SeaweedFilerClient, p util.FullPath) error {
+	versions, err := listObjectVersions(client, p)
+	if err != nil || len(versions) == 0 || isDeleteMarker(versions[0].Entry) {
+		return err
+	}
+	dir, name := p.DirAndName()
+	if _, err = client.RestoreEntryVersion(context.Background(), &filer_pb.RestoreEntryVersionRequest{
+		Directory: dir,
+		Name:      name,
+		VersionId: versions[0].VersionId,
+	}); err != nil {
+		return fmt.Errorf("restore version %s of %s: %v", versions[0].VersionId, p, err)
+	}
+	return nil
+}
+
+// deleteVersionedObject deletes the object in a versioned bucket. Without a version id, the object is kept as a
+// previous version, and a delete marker becomes the latest version. With a version id, the version is deleted.
+func (s3a *S3ApiServer) deleteVersionedObject(client filer_pb.SeaweedFilerClient, p util.FullPath, versionId, versioning string) (deleted ObjectIdentifier, err error) {
+	dir, name := p.DirAndName()
+
+	if versionId == "" {
+		if err = doDeleteEntry(client, dir, name, true, false); err != nil {
+			return
+		}
+		versions, listErr := listObjectVersions(client, p)
+		if listErr != nil {
+			return deleted, listErr
+		}
+		markerVersionId := newObjectVersionId()
+		if versioning == s3.BucketVersioningStatusSuspended {
+			// the delete marker replaces the "null" version
+			markerVersionId = versionIdNull
+			if err = deleteNullVersions(client, p, versions); err != nil {
+				return
+			}
+		}
+		if err = createDeleteMarker(client, p, markerVersionId, versions); err != nil {
+			return
+		}
+		deleted.DeleteMarker, deleted.DeleteMarkerVersionId = true, markerVersionId
+		return
+	}
+
+	deleted.VersionId = versionId
+	current, err := lookupObject(client, p)
+	if err != nil {
+		return
+	}
+	if current != nil && objectVersionIdOf(current) == versionId {
+		if err = doDeleteEntry(client, dir, name, true, false); err != nil {
+			return
+		}
+		// the filer keeps the deleted object as a previous version, which is deleted as well
+		versions, listErr := listObjectVersions(client, p)
+		if listErr != nil {
+			return deleted, listErr
+		}
+		for _, version := range versions {
+			if isSameObject(version.Entry, current) {
+				if err = deleteEntryVersion(client, p, version.VersionId); err != nil {
+					return
+				}
+				break
+			}
+		}
+		err = promoteLatestVersion(client, p)
+		return
+	}
+
+	versions, err := listObjectVersions(client, p)
+	if err != nil {
+		return
+	}
+	version := findObjectVersion(versions, versionId)
+	if version == nil {
+		// already deleted
+		return deleted, nil
+	}
+	if isDeleteMarker(version.Entry) {
+		deleted.DeleteMarker, deleted.DeleteMarkerVersionId = true, versionId
+	}
+	if err = deleteEntryVersion(client, p, version.VersionId); err != nil {
+		return
+	}
+	if current == nil && version == versions[0] {
+		err = promoteLatestVersion(client, p)
+	}
+	return
+}
+
+// lookupObjectVersion finds the version of the object, the current object, or a previous version with its filer version id
+func (s3a *S3ApiServer) lookupObjectVersion(p util.FullPath, versionId string) (entry *filer_pb.Entry, filerVersionId string, err error) {
+	err = s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		current, lookupErr := lookupObject(client, p)
+		if lookupErr != nil {
+			return lookupErr
+		}
+		if current != nil && objectVersionIdOf(current) == versionId {
+			entry = current
+			return nil
+		}
+		versions, listErr := listObjectVersions(client, p)
+		if listErr != nil {
+			return listErr
+		}
+		if version := findObjectVersion(versions, versionId); version != nil {
+			entry, filerVersionId = version.Entry, version.VersionId
+			return nil
+		}
+		return filer_pb.ErrNotFound
+	})
+	return
+}
+
+// toFilerVersionUrl is the filer url to read the version of the object
+func (s3a *S3ApiServer) toFilerVersionUrl(w http.ResponseWriter, bucket, object, versionId string) (string, s3err.ErrorCode) {
+	entry, filerVersionId, err := s3a.lookupObjectVersion(s3a.objectPath(bucket, object), versionId)
+	if err == filer_pb.ErrNotFound {
+		return "", s3err.ErrNoSuchVersion
+	}
+	if err != nil {
+		glog.Errorf("lookup version %s of %s/%s: %v", versionId, bucket, object, err)
+		return "", s3err.ErrInternalError
+	}
+	if isDeleteMarker(entry) {
+		w.Header().Set(s3_constants.AmzDeleteMarker, "true")
+		w.Header().Set(s3_constants.AmzVersionId, versionId)
+		return "", s3err.ErrMethodNotAllowed
+	}
+	destUrl := s3a.toFilerUrl(bucket, object)
+	if filerVersionId != "" {
+		destUrl += "?version=" + filerVersionId
+	}
+	return destUrl, s3err.ErrNone
+}
@@ -0,0 +1,63 @@
+package s3api
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+)
+
+func TestObjectVersionId(t *testing.T) {
+	entry := &filer_pb.Entry{Extended: make(map[string][]byte)}
+	if versionId := objectVersionIdOf(entry); versionId != versionIdNull {
+		t.Errorf("expected the null version, got %s", versionId)
+	}
+
+	versionId := newObjectVersionId()
+	setObjectVersionId(entry.Extended, versionId)
+	if got := objectVersionIdOf(entry); got != versionId {
+		t.Errorf("expected version %s, got %s", versionId, got)
+	}
+	if later := newObjectVersionId(); later <= versionId {
+		t.Errorf("expected version %s ordered after %s", later, versionId)
+	}
+
+	setObjectVersionId(entry.Extended, versionIdNull)
+	if _, found := entry.Extended[s3_constants.ExtVersionIdKey]; found {
+		t.Errorf("the null version should not be stored")
+	}
+}
+
+func TestSplitCopySourceVersionId(t *testing.T) {
+	path, versionId := splitCopySourceVersionId("/bucket/dir/key?versionId=abc")
+	if path != "/bucket/dir/key" || versionId != "abc" {
+		t.Errorf("unexpected path %s version %s", path, versionId)
+	}
+	path, versionId = splitCopySourceVersionId("/bucket/key")
+	if path != "/bucket/key" || versionId != "" {
+		t.Errorf("unexpected path %s version %s", path, versionId)
+	}
+}
+
+func TestFindObjectVersion(t *testing.T) {
+	newVersion := func(filerVersionId, versionId string) *filer_pb.EntryVersion {
+		entry := &filer_pb.Entry{Extended: make(map[string][]byte)}
+		setObjectVersionId(entry.Extended, versionId)
+		return &filer_pb.EntryVersion{Entry: entry, VersionId: filerVersionId}
+	}
+	// the newest first
+	versions := []*filer_pb.EntryVersion{
+		newVersion("3", "b"),
+		newVersion("2", versionIdNull),
+		newVersion("1", versionIdNull),
+	}
+	if version := findObjectVersion(versions, versionIdNull); version == nil || version.VersionId != "2" {
+		t.Errorf("expected the newest null version, got %+v", version)
+	}
+	if version := findObjectVersion(versions, "b"); version == nil || version.VersionId != "3" {
+		t.Errorf("expected version b, got %+v", version)
+	}
+	if version := findObjectVersion(versions, "c"); version != nil {
+		t.Errorf("unexpected version %+v", version)
+	}
+}
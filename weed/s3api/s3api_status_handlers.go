@@ -1,11 +1,28 @@
 package s3api
 
 import (
-	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"context"
 	"net/http"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"google.golang.org/grpc"
 )
 
 func (s3a *S3ApiServer) StatusHandler(w http.ResponseWriter, r *http.Request) {
 	// write out the response code and content type header
 	s3err.WriteResponse(w, r, http.StatusOK, []byte{}, "")
 }
+
+// checkFilerReady checks that a filer is reachable, to read and write the objects
+func (s3a *S3ApiServer) checkFilerReady(ctx context.Context) error {
+	return s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.Ping(ctx, &filer_pb.PingRequest{})
+		return err
+	})
+}
+
+// RegisterGrpcHealth serves the gRPC health checking, following the readiness checks
+func (s3a *S3ApiServer) RegisterGrpcHealth(grpcS *grpc.Server) {
+	s3a.healthChecks.RegisterGrpc(grpcS)
+}
@@ -0,0 +1,148 @@
+package s3api
+
+import (
+	"encoding/xml"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+type AssumeRoleWithWebIdentityResponse struct {
+	XMLName          xml.Name                        `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleWithWebIdentityResponse"`
+	Result           AssumeRoleWithWebIdentityResult `xml:"AssumeRoleWithWebIdentityResult"`
+	ResponseMetadata struct {
+		RequestId string `xml:"RequestId"`
+	} `xml:"ResponseMetadata"`
+}
+
+type AssumeRoleWithWebIdentityResult struct {
+	Credentials                 StsCredentials `xml:"Credentials"`
+	SubjectFromWebIdentityToken string         `xml:"SubjectFromWebIdentityToken"`
+	AssumedRoleUser             struct {
+		Arn           string `xml:"Arn"`
+		AssumedRoleId string `xml:"AssumedRoleId"`
+	} `xml:"AssumedRoleUser"`
+	Provider string `xml:"Provider"`
+	Audience string `xml:"Audience,omitempty"`
+}
+
+type StsCredentials struct {
+	AccessKeyId     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+type StsErrorResponse struct {
+	XMLName xml.Name `xml:"https://sts.amazonaws.com/doc/2011-06-15/ ErrorResponse"`
+	Error   struct {
+		Type    string `xml:"Type"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+	RequestId string `xml:"RequestId"`
+}
+
+// AssumeRoleWithWebIdentityHandler exchanges the OIDC token for the temporary credentials of the role,
+// which is the identity named in the role ARN, e.g., arn:aws:iam::000000000000:role/readers,
+// and must be mapped from the user or one of the roles of the token.
+// https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+func (s3a *S3ApiServer) AssumeRoleWithWebIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	iam := s3a.iam
+	if err := r.ParseForm(); err != nil {
+		writeStsErrorResponse(w, r, http.StatusBadRequest, "InvalidParameterValue", err.Error())
+		return
+	}
+	if action := r.Form.Get("Action"); action != "AssumeRoleWithWebIdentity" {
+		writeStsErrorResponse(w, r, http.StatusBadRequest, "InvalidAction", "unsupported action "+action)
+		return
+	}
+
+	roleArn := r.Form.Get("RoleArn")
+	roleName := roleArn[strings.LastIndex(roleArn, "/")+1:]
+	if !strings.Contains(roleArn, ":role/") || roleName == "" {
+		writeStsErrorResponse(w, r, http.StatusBadRequest, "InvalidParameterValue", "invalid RoleArn "+roleArn)
+		return
+	}
+	duration := time.Duration(stsDefaultDurationSeconds) * time.Second
+	if durationSeconds := r.Form.Get("DurationSeconds"); durationSeconds != "" {
+		seconds, err := strconv.Atoi(durationSeconds)
+		duration = time.Duration(seconds) * time.Second
+		if err != nil || seconds < stsMinDurationSeconds || duration > iam.sts.maxDuration {
+			writeStsErrorResponse(w, r, http.StatusBadRequest, "InvalidParameterValue",
+				"DurationSeconds should be from "+strconv.Itoa(stsMinDurationSeconds)+" to "+strconv.Itoa(int(iam.sts.maxDuration.Seconds())))
+			return
+		}
+	}
+	if duration > iam.sts.maxDuration {
+		duration = iam.sts.maxDuration
+	}
+
+	user, err := iam.oidc.Verify(r.Form.Get("WebIdentityToken"))
+	if err != nil {
+		glog.V(1).Infof("assume role %s from %s: %v", roleArn, r.RemoteAddr, err)
+		writeStsErrorResponse(w, r, http.StatusBadRequest, "InvalidIdentityToken", err.Error())
+		return
+	}
+	if !slices.Contains(iam.oidcMapping.identityNamesOf(user), roleName) || iam.lookupByName(roleName) == nil {
+		glog.V(1).Infof("user %s with roles %v can not assume role %s", user.Username, user.Roles, roleArn)
+		writeStsErrorResponse(w, r, http.StatusForbidden, "AccessDenied", "not authorized to assume the role "+roleArn)
+		return
+	}
+	// the credentials never outlive the token
+	if expiresIn := time.Until(user.ExpiresAt); !user.ExpiresAt.IsZero() && expiresIn < duration {
+		duration = expiresIn
+	}
+
+	cred, sessionToken, expiration, err := iam.sts.issue(roleName, user.Username, duration)
+	if err != nil {
+		glog.Errorf("issue the credentials of role %s: %v", roleArn, err)
+		writeStsErrorResponse(w, r, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	glog.V(1).Infof("user %s assumed role %s as %s until %v", user.Username, roleArn, cred.AccessKey, expiration)
+
+	response := &AssumeRoleWithWebIdentityResponse{}
+	response.Result.Credentials = StsCredentials{
+		AccessKeyId:     cred.AccessKey,
+		SecretAccessKey: cred.SecretKey,
+		SessionToken:    sessionToken,
+		Expiration:      expiration,
+	}
+	response.Result.SubjectFromWebIdentityToken = user.Username
+	response.Result.AssumedRoleUser.Arn = roleArn + "/" + sessionNameOf(r, user.Username)
+	response.Result.AssumedRoleUser.AssumedRoleId = cred.AccessKey + ":" + sessionNameOf(r, user.Username)
+	response.Result.Provider = iam.oidc.Issuer
+	response.Result.Audience = iam.oidc.Audience
+	response.ResponseMetadata.RequestId = stsRequestId()
+	writeSuccessResponseXML(w, r, response)
+}
+
+func sessionNameOf(r *http.Request, username string) string {
+	if sessionName := r.Form.Get("RoleSessionName"); sessionName != "" {
+		return sessionName
+	}
+	return username
+}
+
+func writeStsErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, code, message string) {
+	response := &StsErrorResponse{}
+	response.Error.Type = "Sender"
+	if statusCode >= http.StatusInternalServerError {
+		response.Error.Type = "Receiver"
+	}
+	response.Error.Code = code
+	response.Error.Message = message
+	response.RequestId = stsRequestId()
+	s3err.WriteXMLResponse(w, r, statusCode, response)
+	s3err.PostLog(r, statusCode, s3err.ErrNone)
+}
+
+func stsRequestId() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
@@ -97,6 +97,8 @@ func getResourceType(object string, query_key string, metod string) (string, boo
 			return getREST(metod, "ACCESSCONTROLPOLICY"), true
 		case "policy":
 			return getREST(metod, "BUCKETPOLICY"), true
+		case "logging":
+			return getREST(metod, "LOGGINGSTATUS"), true
 		default:
 			return getREST(metod, "BUCKET"), false
 		}
@@ -55,6 +55,7 @@ const (
 	ErrNoSuchCORSConfiguration
 	ErrNoSuchLifecycleConfiguration
 	ErrNoSuchKey
+	ErrNoSuchVersion
 	ErrNoSuchUpload
 	ErrInvalidBucketName
 	ErrInvalidDigest
@@ -108,6 +109,30 @@ const (
 	ErrTooManyRequest
 	ErrRequestBytesExceed
 
+	ErrInvalidEncryptionAlgorithm
+	ErrSSECustomerKeyInvalid
+	ErrSSECustomerKeyMD5Mismatch
+	ErrSSECustomerKeyRequired
+	ErrKMSNotConfigured
+	ErrKMSKeyNotFound
+	ErrNoSuchReplicationConfiguration
+	ErrInvalidReplicationDestination
+	ErrInvalidNotificationTopic
+	ErrInvalidNotificationEvent
+	ErrInvalidExpressionType
+	ErrInvalidSelectExpression
+	ErrInvalidSelectRequest
+	ErrInvalidCompressionFormat
+	ErrObjectLockConfigurationNotFound
+	ErrNoSuchObjectLockConfiguration
+	ErrMissingObjectLockConfiguration
+	ErrInvalidObjectLockConfiguration
+	ErrInvalidObjectLock
+	ErrInvalidBucketState
+	ErrObjectLocked
+	ErrMalformedPolicy
+	ErrInvalidTargetBucketForLogging
+
 	OwnershipControlsNotFoundError
 	ErrNoSuchTagSet
 )
@@ -205,6 +230,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The specified key does not exist.",
 		HTTPStatusCode: http.StatusNotFound,
 	},
+	ErrNoSuchVersion: {
+		Code:           "NoSuchVersion",
+		Description:    "The specified version does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
 	ErrNoSuchUpload: {
 		Code:           "NoSuchUpload",
 		Description:    "The specified multipart upload does not exist. The upload ID may be invalid, or the upload may have been aborted or completed.",
@@ -423,6 +453,122 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		HTTPStatusCode: http.StatusTooManyRequests,
 	},
 
+	ErrInvalidEncryptionAlgorithm: {
+		Code:           "InvalidEncryptionAlgorithmError",
+		Description:    "The encryption request you specified is not valid. The valid value is AES256 or aws:kms.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSECustomerKeyInvalid: {
+		Code:           "InvalidArgument",
+		Description:    "The secret key was invalid for the specified algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSECustomerKeyMD5Mismatch: {
+		Code:           "InvalidArgument",
+		Description:    "The calculated MD5 hash of the key did not match the hash that was provided.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSECustomerKeyRequired: {
+		Code:           "InvalidRequest",
+		Description:    "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrKMSNotConfigured: {
+		Code:           "InvalidArgument",
+		Description:    "Server side encryption with the managed keys requires a KMS configured in security.toml.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrKMSKeyNotFound: {
+		Code:           "KMS.NotFoundException",
+		Description:    "The KMS key was not found.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchReplicationConfiguration: {
+		Code:           "ReplicationConfigurationNotFoundError",
+		Description:    "The replication configuration was not found",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidReplicationDestination: {
+		Code:           "InvalidArgument",
+		Description:    "The replication destination should be a bucket arn with the account naming a configured remote storage.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidNotificationTopic: {
+		Code:           "InvalidArgument",
+		Description:    "The notification destination should be a topic arn, e.g., arn:seaweed:mq:::namespace/topic.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidNotificationEvent: {
+		Code:           "InvalidArgument",
+		Description:    "The notification event or filter rule is not supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidExpressionType: {
+		Code:           "InvalidExpressionType",
+		Description:    "The ExpressionType is invalid. Only SQL expressions are supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSelectExpression: {
+		Code:           "ParseUnsupportedSyntax",
+		Description:    "The SQL expression contains unsupported syntax.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSelectRequest: {
+		Code:           "InvalidRequestParameter",
+		Description:    "The value of a parameter in SelectRequest element is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidCompressionFormat: {
+		Code:           "InvalidCompressionFormat",
+		Description:    "The file is not in a supported compression format. Only GZIP and BZIP2 are supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectLockConfigurationNotFound: {
+		Code:           "ObjectLockConfigurationNotFoundError",
+		Description:    "Object Lock configuration does not exist for this bucket",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrNoSuchObjectLockConfiguration: {
+		Code:           "NoSuchObjectLockConfiguration",
+		Description:    "The specified object does not have a ObjectLock configuration",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrMissingObjectLockConfiguration: {
+		Code:           "InvalidRequest",
+		Description:    "Bucket is missing Object Lock Configuration",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidObjectLockConfiguration: {
+		Code:           "InvalidArgument",
+		Description:    "The object lock configuration should be enabled, with a default retention in the GOVERNANCE or COMPLIANCE mode of either days or years.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidObjectLock: {
+		Code:           "InvalidArgument",
+		Description:    "The retention should be in the GOVERNANCE or COMPLIANCE mode with a retain until date in the future, and the legal hold either ON or OFF.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidBucketState: {
+		Code:           "InvalidBucketState",
+		Description:    "The object lock requires the versioning of the bucket enabled, which can not be suspended afterwards.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrObjectLocked: {
+		Code:           "AccessDenied",
+		Description:    "The object is locked by its retention or legal hold.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrMalformedPolicy: {
+		Code:           "MalformedPolicy",
+		Description:    "The policy is not valid, or uses an unsupported element or condition.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidTargetBucketForLogging: {
+		Code:           "InvalidTargetBucketForLogging",
+		Description:    "The target bucket for logging does not exist, or is not a valid mq topic arn.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+
 	OwnershipControlsNotFoundError: {
 		Code:           "OwnershipControlsNotFoundError",
 		Description:    "The bucket ownership controls were not found",
@@ -0,0 +1,765 @@
+package s3select
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The values are nil for NULL and MISSING, string, int64, float64, bool, time.Time,
+// and map[string]interface{} or []interface{} for the nested json values.
+
+type expr interface {
+	eval(c *evalContext) (interface{}, error)
+}
+
+type evalContext struct {
+	record     Record
+	alias      string
+	aggregates []*aggregateState
+}
+
+type pathElement struct {
+	name          string
+	caseSensitive bool
+	index         int
+	isIndex       bool
+	wildcard      bool
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e *literalExpr) eval(c *evalContext) (interface{}, error) {
+	return e.value, nil
+}
+
+type columnExpr struct {
+	path []pathElement
+}
+
+func (e *columnExpr) eval(c *evalContext) (interface{}, error) {
+	path := e.path
+	// the alias of S3Object is optional in the column references
+	if c.alias != "" && !path[0].caseSensitive && strings.EqualFold(path[0].name, c.alias) {
+		if len(path) == 1 {
+			return c.record.Value(), nil
+		}
+		path = path[1:]
+	}
+	if path[0].isIndex {
+		return nil, nil
+	}
+	value, found := c.record.Column(path[0].name, path[0].caseSensitive)
+	if !found {
+		return nil, nil
+	}
+	for _, element := range path[1:] {
+		if value = lookupPath(value, element); value == nil {
+			return nil, nil
+		}
+	}
+	return normalize(value), nil
+}
+
+// name is the column name of the projection without an alias
+func (e *columnExpr) name() string {
+	last := e.path[len(e.path)-1]
+	if last.isIndex {
+		return ""
+	}
+	return last.name
+}
+
+// lookupPath finds the value of the field or the element of a json value
+func lookupPath(value interface{}, element pathElement) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if element.isIndex {
+			return nil
+		}
+		if found, ok := v[element.name]; ok || element.caseSensitive {
+			return found
+		}
+		for k, found := range v {
+			if strings.EqualFold(k, element.name) {
+				return found
+			}
+		}
+	case []interface{}:
+		if element.isIndex && element.index >= 0 && element.index < len(v) {
+			return v[element.index]
+		}
+	}
+	return nil
+}
+
+type logicalExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *logicalExpr) eval(c *evalContext) (interface{}, error) {
+	left, err := evalBool(e.left, c)
+	if err != nil {
+		return nil, err
+	}
+	// short circuit
+	if left != nil && *left == (e.op == "OR") {
+		return *left, nil
+	}
+	right, err := evalBool(e.right, c)
+	if err != nil {
+		return nil, err
+	}
+	if right != nil && *right == (e.op == "OR") {
+		return *right, nil
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	return *right, nil
+}
+
+// evalBool evaluates the condition, nil if unknown
+func evalBool(e expr, c *evalContext) (*bool, error) {
+	value, err := e.eval(c)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case bool:
+		return &v, nil
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return &b, nil
+		}
+	}
+	return nil, fmt.Errorf("%v is not a boolean", value)
+}
+
+type notExpr struct {
+	expr expr
+}
+
+func (e *notExpr) eval(c *evalContext) (interface{}, error) {
+	value, err := evalBool(e.expr, c)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return !*value, nil
+}
+
+type comparisonExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *comparisonExpr) eval(c *evalContext) (interface{}, error) {
+	left, err := e.left.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	cmp, comparable := compareValues(left, right)
+	if !comparable {
+		// the values of different types are never equal
+		switch e.op {
+		case "=":
+			return false, nil
+		case "!=", "<>":
+			return true, nil
+		}
+		return nil, nil
+	}
+	switch e.op {
+	case "=":
+		return cmp == 0, nil
+	case "!=", "<>":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+type isNullExpr struct {
+	expr    expr
+	negated bool
+}
+
+func (e *isNullExpr) eval(c *evalContext) (interface{}, error) {
+	value, err := e.expr.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	return (value == nil) != e.negated, nil
+}
+
+type likeExpr struct {
+	expr, pattern, escape expr
+	negated               bool
+
+	// the last compiled pattern, mostly a literal
+	lastPattern string
+	lastEscape  string
+	regexp      *regexp.Regexp
+}
+
+func (e *likeExpr) eval(c *evalContext) (interface{}, error) {
+	value, err := e.expr.eval(c)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	pattern, err := e.pattern.eval(c)
+	if err != nil || pattern == nil {
+		return nil, err
+	}
+	var escape interface{} = ""
+	if e.escape != nil {
+		if escape, err = e.escape.eval(c); err != nil || escape == nil {
+			return nil, err
+		}
+	}
+	patternString, escapeString := formatValue(pattern), formatValue(escape)
+	if e.regexp == nil || patternString != e.lastPattern || escapeString != e.lastEscape {
+		if e.regexp, err = likeToRegexp(patternString, escapeString); err != nil {
+			return nil, err
+		}
+		e.lastPattern, e.lastEscape = patternString, escapeString
+	}
+	return e.regexp.MatchString(formatValue(value)) != e.negated, nil
+}
+
+// likeToRegexp converts the LIKE pattern, where % matches any characters and _ matches one character
+func likeToRegexp(pattern, escape string) (*regexp.Regexp, error) {
+	if utf8.RuneCountInString(escape) > 1 {
+		return nil, fmt.Errorf("invalid escape %q", escape)
+	}
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case escape != "" && string(r) == escape:
+			escaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("pattern %q ends with the escape", pattern)
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+type betweenExpr struct {
+	expr, low, high expr
+	negated         bool
+}
+
+func (e *betweenExpr) eval(c *evalContext) (interface{}, error) {
+	low, err := (&comparisonExpr{op: ">=", left: e.expr, right: e.low}).eval(c)
+	if err != nil || low == nil {
+		return nil, err
+	}
+	high, err := (&comparisonExpr{op: "<=", left: e.expr, right: e.high}).eval(c)
+	if err != nil || high == nil {
+		return nil, err
+	}
+	return (low.(bool) && high.(bool)) != e.negated, nil
+}
+
+type inExpr struct {
+	expr    expr
+	list    []expr
+	negated bool
+}
+
+func (e *inExpr) eval(c *evalContext) (interface{}, error) {
+	value, err := e.expr.eval(c)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	for _, item := range e.list {
+		candidate, err := item.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		if cmp, comparable := compareValues(value, candidate); comparable && cmp == 0 {
+			return !e.negated, nil
+		}
+	}
+	return e.negated, nil
+}
+
+type arithmeticExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *arithmeticExpr) eval(c *evalContext) (interface{}, error) {
+	left, err := e.left.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	if e.op == "||" {
+		return formatValue(left) + formatValue(right), nil
+	}
+	a, ok := toNumber(left)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", left)
+	}
+	b, ok := toNumber(right)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", right)
+	}
+	ai, aIsInt := a.(int64)
+	bi, bIsInt := b.(int64)
+	if aIsInt && bIsInt {
+		switch e.op {
+		case "+":
+			return ai + bi, nil
+		case "-":
+			return ai - bi, nil
+		case "*":
+			return ai * bi, nil
+		case "/", "%":
+			if bi == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			if e.op == "%" {
+				return ai % bi, nil
+			}
+			if ai%bi == 0 {
+				return ai / bi, nil
+			}
+		}
+	}
+	af, bf := toFloat(a), toFloat(b)
+	switch e.op {
+	case "+":
+		return af + bf, nil
+	case "-":
+		return af - bf, nil
+	case "*":
+		return af * bf, nil
+	case "/":
+		if bf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return af / bf, nil
+	default:
+		if bf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(af, bf), nil
+	}
+}
+
+type castExpr struct {
+	expr     expr
+	castType string
+}
+
+func (e *castExpr) eval(c *evalContext) (interface{}, error) {
+	value, err := e.expr.eval(c)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	switch e.castType {
+	case "INT", "INTEGER":
+		if n, ok := toNumber(value); ok {
+			if f, isFloat := n.(float64); isFloat {
+				return int64(f), nil
+			}
+			return n, nil
+		}
+		if b, ok := value.(bool); ok {
+			if b {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+	case "FLOAT", "DECIMAL", "NUMERIC":
+		if n, ok := toNumber(value); ok {
+			return toFloat(n), nil
+		}
+	case "STRING", "VARCHAR":
+		return formatValue(value), nil
+	case "BOOL", "BOOLEAN":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		default:
+			if n, ok := toNumber(v); ok {
+				return toFloat(n) != 0, nil
+			}
+		}
+	case "TIMESTAMP":
+		if t, ok := toTime(value); ok {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("can not cast %v to %s", value, e.castType)
+}
+
+type functionArity struct {
+	min, max int // max is -1 if unlimited
+}
+
+var scalarFunctions = map[string]functionArity{
+	"LOWER":            {1, 1},
+	"UPPER":            {1, 1},
+	"TRIM":             {1, 1},
+	"CHAR_LENGTH":      {1, 1},
+	"CHARACTER_LENGTH": {1, 1},
+	"SUBSTRING":        {2, 3},
+	"COALESCE":         {1, -1},
+	"NULLIF":           {2, 2},
+}
+
+type functionExpr struct {
+	name string
+	args []expr
+}
+
+func (e *functionExpr) eval(c *evalContext) (interface{}, error) {
+	args := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		value, err := arg.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		// the other functions return NULL with a NULL argument
+		if value != nil && e.name == "COALESCE" {
+			return value, nil
+		}
+		args[i] = value
+	}
+	switch e.name {
+	case "COALESCE":
+		return nil, nil
+	case "NULLIF":
+		if cmp, comparable := compareValues(args[0], args[1]); comparable && cmp == 0 {
+			return nil, nil
+		}
+		return args[0], nil
+	}
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+	switch e.name {
+	case "LOWER":
+		return strings.ToLower(formatValue(args[0])), nil
+	case "UPPER":
+		return strings.ToUpper(formatValue(args[0])), nil
+	case "TRIM":
+		return strings.TrimSpace(formatValue(args[0])), nil
+	case "CHAR_LENGTH", "CHARACTER_LENGTH":
+		return int64(utf8.RuneCountInString(formatValue(args[0]))), nil
+	default:
+		return substring(args)
+	}
+}
+
+// substring is SUBSTRING(s, start[, length]), where the start is 1 based
+func substring(args []interface{}) (interface{}, error) {
+	runes := []rune(formatValue(args[0]))
+	start, ok := toNumber(args[1])
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", args[1])
+	}
+	begin, end := int64(toFloat(start)), int64(len(runes))+1
+	if len(args) > 2 {
+		length, ok := toNumber(args[2])
+		if !ok || toFloat(length) < 0 {
+			return nil, fmt.Errorf("invalid length %v", args[2])
+		}
+		end = min(end, begin+int64(toFloat(length)))
+	}
+	begin = max(begin, 1)
+	if begin >= end {
+		return "", nil
+	}
+	return string(runes[begin-1 : end-1]), nil
+}
+
+func isAggregate(name string) bool {
+	switch name {
+	case "COUNT", "SUM", "AVG", "MIN", "MAX":
+		return true
+	}
+	return false
+}
+
+type aggregateExpr struct {
+	name  string
+	arg   expr // nil for COUNT(*)
+	index int
+}
+
+func (e *aggregateExpr) eval(c *evalContext) (interface{}, error) {
+	return c.aggregates[e.index].result(e.name), nil
+}
+
+// aggregateState accumulates the values of an aggregate over the selected records
+type aggregateState struct {
+	count    int64
+	intSum   int64
+	floatSum float64
+	allInts  bool
+	extreme  interface{}
+}
+
+func (s *aggregateState) add(name string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	switch name {
+	case "COUNT":
+	case "SUM", "AVG":
+		n, ok := toNumber(value)
+		if !ok {
+			return fmt.Errorf("%v is not a number", value)
+		}
+		if s.count == 0 {
+			s.allInts = true
+		}
+		if i, isInt := n.(int64); isInt && s.allInts {
+			s.intSum += i
+		} else {
+			s.allInts = false
+		}
+		s.floatSum += toFloat(n)
+	default:
+		if n, ok := toNumber(value); ok {
+			value = n
+		}
+		if s.extreme == nil {
+			s.extreme = value
+			break
+		}
+		cmp, comparable := compareValues(value, s.extreme)
+		if !comparable {
+			return fmt.Errorf("can not compare %v with %v", value, s.extreme)
+		}
+		if name == "MIN" && cmp < 0 || name == "MAX" && cmp > 0 {
+			s.extreme = value
+		}
+	}
+	s.count++
+	return nil
+}
+
+func (s *aggregateState) result(name string) interface{} {
+	switch name {
+	case "COUNT":
+		return s.count
+	case "SUM":
+		if s.count == 0 {
+			return nil
+		}
+		if s.allInts {
+			return s.intSum
+		}
+		return s.floatSum
+	case "AVG":
+		if s.count == 0 {
+			return nil
+		}
+		return s.floatSum / float64(s.count)
+	default:
+		return s.extreme
+	}
+}
+
+// normalize converts the values read from the records to the types of the SQL values
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n := parseNumber(v.String()); n != nil {
+			return n
+		}
+		return v.String()
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return float64(v)
+		}
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return string(v)
+	}
+	return value
+}
+
+// toNumber converts the value to int64 or float64, parsing the strings, e.g., the csv fields
+func toNumber(value interface{}) (interface{}, bool) {
+	switch v := normalize(value).(type) {
+	case int64:
+		return v, true
+	case float64:
+		return v, true
+	case string:
+		if n := parseNumber(strings.TrimSpace(v)); n != nil {
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+func toFloat(n interface{}) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
+
+var timestampLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02", "2006-01", "2006"}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareValues compares the values of the comparable types, with the strings compared to the numbers as numbers
+func compareValues(a, b interface{}) (cmp int, comparable bool) {
+	a, b = normalize(a), normalize(b)
+	if a == nil || b == nil {
+		return 0, false
+	}
+	_, aIsString := a.(string)
+	_, bIsString := b.(string)
+	if aIsString && bIsString {
+		return strings.Compare(a.(string), b.(string)), true
+	}
+	if an, ok := toNumber(a); ok {
+		if bn, ok := toNumber(b); ok {
+			ai, aIsInt := an.(int64)
+			bi, bIsInt := bn.(int64)
+			if aIsInt && bIsInt {
+				return compareOrdered(ai, bi), true
+			}
+			return compareOrdered(toFloat(an), toFloat(bn)), true
+		}
+	}
+	_, aIsTime := a.(time.Time)
+	_, bIsTime := b.(time.Time)
+	if aIsTime || bIsTime {
+		at, aOk := toTime(a)
+		bt, bOk := toTime(b)
+		if aOk && bOk {
+			return at.Compare(bt), true
+		}
+		return 0, false
+	}
+	if ab, ok := a.(bool); ok {
+		if bb, ok := b.(bool); ok {
+			if ab == bb {
+				return 0, true
+			}
+			if !ab {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+func compareOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// formatValue formats the value as a string, e.g., as a csv field
+func formatValue(value interface{}) string {
+	switch v := normalize(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,97 @@
+package s3select
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+)
+
+// Stats are the bytes of a select request, reported by the Stats and the Progress events
+type Stats struct {
+	BytesScanned   int64
+	BytesProcessed int64
+	BytesReturned  int64
+}
+
+// EventWriter writes the events of a SelectObjectContent response in the event stream encoding,
+// flushed to the client one by one
+type EventWriter struct {
+	writer  io.Writer
+	encoder *eventstream.Encoder
+}
+
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{
+		writer:  w,
+		encoder: eventstream.NewEncoder(w),
+	}
+}
+
+func (e *EventWriter) writeEvent(eventType, contentType string, payload []byte) error {
+	var headers eventstream.Headers
+	headers.Set(":message-type", eventstream.StringValue("event"))
+	headers.Set(":event-type", eventstream.StringValue(eventType))
+	if contentType != "" {
+		headers.Set(":content-type", eventstream.StringValue(contentType))
+	}
+	return e.write(eventstream.Message{Headers: headers, Payload: payload})
+}
+
+func (e *EventWriter) write(message eventstream.Message) error {
+	if err := e.encoder.Encode(message); err != nil {
+		return err
+	}
+	if flusher, ok := e.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// WriteRecords writes the formatted rows
+func (e *EventWriter) WriteRecords(payload []byte) error {
+	return e.writeEvent("Records", "application/octet-stream", payload)
+}
+
+// WriteContinuation keeps the connection alive while no rows are selected
+func (e *EventWriter) WriteContinuation() error {
+	return e.writeEvent("Cont", "", nil)
+}
+
+type statsPayload struct {
+	Stats
+	XMLName xml.Name
+}
+
+// WriteProgress reports the bytes processed so far
+func (e *EventWriter) WriteProgress(stats Stats) error {
+	payload, err := xml.Marshal(&statsPayload{Stats: stats, XMLName: xml.Name{Local: "Progress"}})
+	if err != nil {
+		return err
+	}
+	return e.writeEvent("Progress", "text/xml", payload)
+}
+
+// WriteStats reports the bytes of the request after all the records are selected
+func (e *EventWriter) WriteStats(stats Stats) error {
+	payload, err := xml.Marshal(&statsPayload{Stats: stats, XMLName: xml.Name{Local: "Stats"}})
+	if err != nil {
+		return err
+	}
+	return e.writeEvent("Stats", "text/xml", payload)
+}
+
+// WriteEnd ends the response successfully
+func (e *EventWriter) WriteEnd() error {
+	return e.writeEvent("End", "", nil)
+}
+
+// WriteError ends the response with an error, after the response has started
+func (e *EventWriter) WriteError(code, message string) error {
+	var headers eventstream.Headers
+	headers.Set(":message-type", eventstream.StringValue("error"))
+	headers.Set(":error-code", eventstream.StringValue(code))
+	headers.Set(":error-message", eventstream.StringValue(message))
+	return e.write(eventstream.Message{Headers: headers})
+}
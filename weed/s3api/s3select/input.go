@@ -0,0 +1,305 @@
+package s3select
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// CSVInput describes the csv objects, as in the InputSerialization of the select requests
+type CSVInput struct {
+	FileHeaderInfo             string // USE, IGNORE, or NONE
+	Comments                   string
+	QuoteEscapeCharacter       string
+	RecordDelimiter            string
+	FieldDelimiter             string
+	QuoteCharacter             string
+	AllowQuotedRecordDelimiter bool
+}
+
+// JSONInput describes the json objects, as in the InputSerialization of the select requests
+type JSONInput struct {
+	Type string // DOCUMENT or LINES
+}
+
+// ParquetInput describes the parquet objects, which have no options
+type ParquetInput struct {
+}
+
+const parquetRowBatchSize = 64
+
+// RecordReader reads the records of an object, until io.EOF
+type RecordReader interface {
+	Read() (Record, error)
+}
+
+type csvRecordReader struct {
+	reader  *csv.Reader
+	headers []string
+}
+
+// NewCSVReader reads the records of a csv object
+func NewCSVReader(r io.Reader, input *CSVInput) (RecordReader, error) {
+	fieldDelimiter, err := singleRune(input.FieldDelimiter, ',')
+	if err != nil {
+		return nil, fmt.Errorf("field delimiter: %w", err)
+	}
+	comment, err := singleRune(input.Comments, 0)
+	if err != nil {
+		return nil, fmt.Errorf("comments: %w", err)
+	}
+	// encoding/csv only supports the double quotes, escaped by doubling them
+	if input.QuoteCharacter != "" && input.QuoteCharacter != `"` ||
+		input.QuoteEscapeCharacter != "" && input.QuoteEscapeCharacter != `"` {
+		return nil, fmt.Errorf("only \" is supported as the quote character")
+	}
+	switch input.RecordDelimiter {
+	case "", "\n", "\r\n":
+	default:
+		delimiter, err := singleRune(input.RecordDelimiter, '\n')
+		if err != nil || delimiter > 0x7f {
+			return nil, fmt.Errorf("unsupported record delimiter %q", input.RecordDelimiter)
+		}
+		r = &delimiterReplacingReader{reader: r, delimiter: byte(delimiter)}
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = fieldDelimiter
+	reader.Comment = comment
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	c := &csvRecordReader{reader: reader}
+
+	switch strings.ToUpper(input.FileHeaderInfo) {
+	case "", "NONE":
+	case "USE", "IGNORE":
+		headers, err := reader.Read()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if strings.EqualFold(input.FileHeaderInfo, "USE") {
+			c.headers = headers
+		}
+	default:
+		return nil, fmt.Errorf("invalid FileHeaderInfo %s", input.FileHeaderInfo)
+	}
+	return c, nil
+}
+
+func singleRune(s string, defaultValue rune) (rune, error) {
+	runes := []rune(s)
+	switch len(runes) {
+	case 0:
+		return defaultValue, nil
+	case 1:
+		return runes[0], nil
+	}
+	return 0, fmt.Errorf("%q is not a single character", s)
+}
+
+func (c *csvRecordReader) Read() (Record, error) {
+	fields, err := c.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	return &csvRecord{headers: c.headers, fields: fields}, nil
+}
+
+// delimiterReplacingReader replaces the record delimiter with \n for encoding/csv
+type delimiterReplacingReader struct {
+	reader    io.Reader
+	delimiter byte
+}
+
+func (r *delimiterReplacingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	for i := 0; i < n; i++ {
+		if p[i] == r.delimiter {
+			p[i] = '\n'
+		}
+	}
+	return n, err
+}
+
+type csvRecord struct {
+	headers []string
+	fields  []string
+}
+
+func (r *csvRecord) Column(name string, caseSensitive bool) (interface{}, bool) {
+	for i, header := range r.headers {
+		if i < len(r.fields) && (header == name || !caseSensitive && strings.EqualFold(header, name)) {
+			return r.fields[i], true
+		}
+	}
+	return positionalColumn(name, r.fields)
+}
+
+// positionalColumn finds the column _N, 1 based
+func positionalColumn(name string, fields []string) (interface{}, bool) {
+	if !strings.HasPrefix(name, "_") {
+		return nil, false
+	}
+	position, err := strconv.Atoi(name[1:])
+	if err != nil || position < 1 || position > len(fields) {
+		return nil, false
+	}
+	return fields[position-1], true
+}
+
+func (r *csvRecord) Columns() (names []string, values []interface{}) {
+	for i, field := range r.fields {
+		if i < len(r.headers) {
+			names = append(names, r.headers[i])
+		} else {
+			names = append(names, fmt.Sprintf("_%d", i+1))
+		}
+		values = append(values, field)
+	}
+	return
+}
+
+func (r *csvRecord) Value() interface{} {
+	names, values := r.Columns()
+	value := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		value[name] = values[i]
+	}
+	return value
+}
+
+// documentRecordReader reads the records selected from the json documents
+type documentRecordReader struct {
+	next    func() (interface{}, error)
+	query   *Query
+	pending []interface{}
+	// the names of the fields in order, for the parquet rows
+	names []string
+}
+
+func (d *documentRecordReader) Read() (Record, error) {
+	for len(d.pending) == 0 {
+		document, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		d.pending = d.query.Documents(document)
+	}
+	document := d.pending[0]
+	d.pending = d.pending[1:]
+	return newDocumentRecord(document, d.names), nil
+}
+
+// NewJSONReader reads the records of the json documents, which are one or more documents, or one per line
+func NewJSONReader(r io.Reader, input *JSONInput, query *Query) (RecordReader, error) {
+	switch strings.ToUpper(input.Type) {
+	case "DOCUMENT", "LINES":
+	default:
+		return nil, fmt.Errorf("invalid JSON Type %s", input.Type)
+	}
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	decoder.UseNumber()
+	return &documentRecordReader{
+		next: func() (document interface{}, err error) {
+			err = decoder.Decode(&document)
+			return
+		},
+		query: query,
+	}, nil
+}
+
+// NewParquetReader reads the rows of a parquet object
+func NewParquetReader(r io.ReaderAt, size int64, query *Query) (RecordReader, error) {
+	file, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return nil, err
+	}
+	schema := file.Schema()
+	var names []string
+	if len(query.from) == 0 {
+		for _, field := range schema.Fields() {
+			names = append(names, field.Name())
+		}
+	}
+	reader := parquet.NewReader(file)
+	rows := make([]parquet.Row, parquetRowBatchSize)
+	var n, i int
+	return &documentRecordReader{
+		next: func() (interface{}, error) {
+			if i == n {
+				var err error
+				n, err = reader.ReadRows(rows)
+				if n == 0 {
+					if err == nil {
+						err = io.EOF
+					}
+					return nil, err
+				}
+				i = 0
+			}
+			document := make(map[string]interface{})
+			if err := schema.Reconstruct(&document, rows[i]); err != nil {
+				return nil, err
+			}
+			i++
+			return document, nil
+		},
+		query: query,
+		names: names,
+	}, nil
+}
+
+// documentRecord is a json document, or a parquet row
+type documentRecord struct {
+	names    []string
+	document interface{}
+}
+
+func newDocumentRecord(document interface{}, names []string) *documentRecord {
+	record := &documentRecord{document: document}
+	if fields, isObject := document.(map[string]interface{}); isObject {
+		if len(names) == len(fields) {
+			record.names = names
+		} else {
+			for name := range fields {
+				record.names = append(record.names, name)
+			}
+			sort.Strings(record.names)
+		}
+	}
+	return record
+}
+
+func (r *documentRecord) Column(name string, caseSensitive bool) (interface{}, bool) {
+	if fields, isObject := r.document.(map[string]interface{}); isObject {
+		value := lookupPath(fields, pathElement{name: name, caseSensitive: caseSensitive})
+		return value, value != nil
+	}
+	if name == "_1" {
+		return r.document, true
+	}
+	return nil, false
+}
+
+func (r *documentRecord) Columns() (names []string, values []interface{}) {
+	fields, isObject := r.document.(map[string]interface{})
+	if !isObject {
+		return []string{"_1"}, []interface{}{r.document}
+	}
+	for _, name := range r.names {
+		names = append(names, name)
+		values = append(values, fields[name])
+	}
+	return
+}
+
+func (r *documentRecord) Value() interface{} {
+	return r.document
+}
@@ -0,0 +1,98 @@
+package s3select
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Output formats the selected rows
+type Output interface {
+	AppendRow(buf []byte, row *Row) ([]byte, error)
+}
+
+// CSVOutput describes the csv rows, as in the OutputSerialization of the select requests
+type CSVOutput struct {
+	QuoteFields          string // ALWAYS or ASNEEDED
+	QuoteEscapeCharacter string
+	RecordDelimiter      string
+	FieldDelimiter       string
+	QuoteCharacter       string
+}
+
+// JSONOutput describes the json rows, as in the OutputSerialization of the select requests
+type JSONOutput struct {
+	RecordDelimiter string
+}
+
+// Validate checks the options, and fills the defaults
+func (o *CSVOutput) Validate() error {
+	switch strings.ToUpper(o.QuoteFields) {
+	case "", "ASNEEDED", "ALWAYS":
+	default:
+		return fmt.Errorf("invalid QuoteFields %s", o.QuoteFields)
+	}
+	if o.RecordDelimiter == "" {
+		o.RecordDelimiter = "\n"
+	}
+	if o.FieldDelimiter == "" {
+		o.FieldDelimiter = ","
+	}
+	if o.QuoteCharacter == "" {
+		o.QuoteCharacter = `"`
+	}
+	if o.QuoteEscapeCharacter == "" {
+		o.QuoteEscapeCharacter = o.QuoteCharacter
+	}
+	return nil
+}
+
+func (o *CSVOutput) AppendRow(buf []byte, row *Row) ([]byte, error) {
+	always := strings.EqualFold(o.QuoteFields, "ALWAYS")
+	for i, value := range row.Values {
+		if i > 0 {
+			buf = append(buf, o.FieldDelimiter...)
+		}
+		field := formatValue(value)
+		if !always && !strings.Contains(field, o.FieldDelimiter) && !strings.Contains(field, o.QuoteCharacter) &&
+			!strings.Contains(field, o.RecordDelimiter) && !strings.ContainsAny(field, "\r\n") {
+			buf = append(buf, field...)
+			continue
+		}
+		buf = append(buf, o.QuoteCharacter...)
+		buf = append(buf, strings.ReplaceAll(field, o.QuoteCharacter, o.QuoteEscapeCharacter+o.QuoteCharacter)...)
+		buf = append(buf, o.QuoteCharacter...)
+	}
+	return append(buf, o.RecordDelimiter...), nil
+}
+
+// Validate fills the defaults
+func (o *JSONOutput) Validate() error {
+	if o.RecordDelimiter == "" {
+		o.RecordDelimiter = "\n"
+	}
+	return nil
+}
+
+// AppendRow formats the row as a json object, with the fields in the order of the columns
+func (o *JSONOutput) AppendRow(buf []byte, row *Row) ([]byte, error) {
+	buf = append(buf, '{')
+	for i, name := range row.Names {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		nameBytes, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		valueBytes, err := json.Marshal(normalize(row.Values[i]))
+		if err != nil {
+			return nil, fmt.Errorf("format %s: %w", name, err)
+		}
+		buf = append(buf, nameBytes...)
+		buf = append(buf, ':')
+		buf = append(buf, valueBytes...)
+	}
+	buf = append(buf, '}')
+	return append(buf, o.RecordDelimiter...), nil
+}
@@ -0,0 +1,185 @@
+package s3select
+
+import (
+	"fmt"
+)
+
+// Query is a parsed SELECT statement
+type Query struct {
+	selectAll      bool
+	projections    []*projection
+	from           []pathElement
+	alias          string
+	where          expr
+	limit          int64 // -1 if unlimited
+	aggregateCount int
+}
+
+type projection struct {
+	expr  expr
+	name  string
+	index int
+}
+
+// columnName is the alias of the projection, the name of the column, or _N by its position
+func (p *projection) columnName() string {
+	if p.name != "" {
+		return p.name
+	}
+	if column, ok := p.expr.(*columnExpr); ok {
+		if name := column.name(); name != "" {
+			return name
+		}
+	}
+	return fmt.Sprintf("_%d", p.index+1)
+}
+
+// Record is a record of the object, e.g., a csv line, a json document, or a parquet row
+type Record interface {
+	// Column finds the value of the column by its name, or by its position like _1
+	Column(name string, caseSensitive bool) (value interface{}, found bool)
+	// Columns lists the names and the values of all the columns in order
+	Columns() (names []string, values []interface{})
+	// Value is the whole record, selected by the alias of S3Object
+	Value() interface{}
+}
+
+// Row is a selected row
+type Row struct {
+	Names  []string
+	Values []interface{}
+}
+
+// IsAggregate tells whether the query returns one row aggregating the selected records
+func (q *Query) IsAggregate() bool {
+	return q.aggregateCount > 0
+}
+
+// Documents selects the records of a json document by the path in FROM, e.g., the elements of S3Object[*].items[*]
+func (q *Query) Documents(document interface{}) []interface{} {
+	documents := []interface{}{document}
+	for _, element := range q.from {
+		var selected []interface{}
+		for _, d := range documents {
+			switch {
+			case element.wildcard:
+				if elements, isArray := d.([]interface{}); isArray {
+					selected = append(selected, elements...)
+				} else {
+					selected = append(selected, d)
+				}
+			default:
+				if value := lookupPath(d, element); value != nil {
+					selected = append(selected, value)
+				}
+			}
+		}
+		documents = selected
+	}
+	return documents
+}
+
+// Selection selects the records by the query, keeping the state of the aggregates and the limit
+type Selection struct {
+	query      *Query
+	aggregates []*aggregateState
+	selected   int64
+}
+
+// NewSelection starts selecting the records
+func (q *Query) NewSelection() *Selection {
+	s := &Selection{query: q}
+	for i := 0; i < q.aggregateCount; i++ {
+		s.aggregates = append(s.aggregates, &aggregateState{})
+	}
+	return s
+}
+
+// Done tells whether the limit of the records is reached
+func (s *Selection) Done() bool {
+	return s.query.limit >= 0 && s.selected >= s.query.limit
+}
+
+// Select filters the record, and returns the projected row, or nil if the record is not selected.
+// The records selected by an aggregate query are aggregated into the Result.
+func (s *Selection) Select(record Record) (*Row, error) {
+	if s.Done() {
+		return nil, nil
+	}
+	c := &evalContext{record: record, alias: s.query.alias, aggregates: s.aggregates}
+	if s.query.where != nil {
+		matched, err := evalBool(s.query.where, c)
+		if err != nil {
+			return nil, err
+		}
+		if matched == nil || !*matched {
+			return nil, nil
+		}
+	}
+	s.selected++
+
+	if s.query.IsAggregate() {
+		for _, p := range s.query.projections {
+			if err := s.aggregate(p.expr, c); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	if s.query.selectAll {
+		names, values := record.Columns()
+		return &Row{Names: names, Values: values}, nil
+	}
+	return s.project(c)
+}
+
+// aggregate adds the record to the aggregates in the expression
+func (s *Selection) aggregate(e expr, c *evalContext) error {
+	switch v := e.(type) {
+	case *aggregateExpr:
+		var value interface{} = true // COUNT(*)
+		if v.arg != nil {
+			var err error
+			if value, err = v.arg.eval(c); err != nil {
+				return err
+			}
+		}
+		return s.aggregates[v.index].add(v.name, value)
+	case *arithmeticExpr:
+		if err := s.aggregate(v.left, c); err != nil {
+			return err
+		}
+		return s.aggregate(v.right, c)
+	case *functionExpr:
+		for _, arg := range v.args {
+			if err := s.aggregate(arg, c); err != nil {
+				return err
+			}
+		}
+	case *castExpr:
+		return s.aggregate(v.expr, c)
+	}
+	return nil
+}
+
+func (s *Selection) project(c *evalContext) (*Row, error) {
+	row := &Row{}
+	for _, p := range s.query.projections {
+		value, err := p.expr.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		row.Names = append(row.Names, p.columnName())
+		row.Values = append(row.Values, value)
+	}
+	return row, nil
+}
+
+// Result is the row of an aggregate query after all the records are selected, or nil for the other queries
+func (s *Selection) Result() (*Row, error) {
+	if !s.query.IsAggregate() {
+		return nil, nil
+	}
+	return s.project(&evalContext{alias: s.query.alias, aggregates: s.aggregates})
+}
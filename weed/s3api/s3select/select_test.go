@@ -0,0 +1,197 @@
+package s3select
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+	"github.com/parquet-go/parquet-go"
+)
+
+const testCSV = `name,city,age
+Alice,Berlin,34
+Bob,"New York, NY",27
+Carol,Paris,
+Dave,berlin,45
+`
+
+func selectAll(t *testing.T, sql string, reader func(q *Query) (RecordReader, error), output Output) string {
+	q, err := Parse(sql)
+	if err != nil {
+		t.Fatalf("parse %s: %v", sql, err)
+	}
+	records, err := reader(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selection := q.NewSelection()
+	var buf []byte
+	for !selection.Done() {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		row, err := selection.Select(record)
+		if err != nil {
+			t.Fatalf("select %s: %v", sql, err)
+		}
+		if row != nil {
+			if buf, err = output.AppendRow(buf, row); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	row, err := selection.Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row != nil {
+		if buf, err = output.AppendRow(buf, row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return string(buf)
+}
+
+func TestSelectCSV(t *testing.T) {
+	csvOutput := &CSVOutput{}
+	csvOutput.Validate()
+	jsonOutput := &JSONOutput{}
+	jsonOutput.Validate()
+	tests := []struct {
+		sql    string
+		output Output
+		want   string
+	}{
+		{"SELECT * FROM S3Object", csvOutput, "Alice,Berlin,34\nBob,\"New York, NY\",27\nCarol,Paris,\nDave,berlin,45\n"},
+		{"select s.name from s3object s where s.age > 30", csvOutput, "Alice\nDave\n"},
+		{"SELECT name, age FROM S3Object WHERE LOWER(city) = 'berlin' LIMIT 1", jsonOutput, "{\"name\":\"Alice\",\"age\":\"34\"}\n"},
+		{"SELECT s._1 FROM S3Object s WHERE s.city LIKE 'New%' OR s.age IS NULL OR s.age = ''", csvOutput, "Bob\nCarol\n"},
+		{"SELECT COUNT(*), SUM(CAST(age AS INT)), MAX(age) FROM S3Object WHERE age <> ''", csvOutput, "3,106,45\n"},
+		{"SELECT UPPER(name) AS n, CAST(age AS INT) + 1 FROM S3Object s WHERE s.age BETWEEN 30 AND 40 AND name NOT IN ('Bob')", jsonOutput, "{\"n\":\"ALICE\",\"_2\":35}\n"},
+	}
+	for _, tt := range tests {
+		got := selectAll(t, tt.sql, func(q *Query) (RecordReader, error) {
+			return NewCSVReader(strings.NewReader(testCSV), &CSVInput{FileHeaderInfo: "USE"})
+		}, tt.output)
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestSelectJSON(t *testing.T) {
+	jsonOutput := &JSONOutput{}
+	jsonOutput.Validate()
+	lines := `{"id":1,"user":{"name":"a","tags":["x","y"]},"score":1.5}
+{"id":2,"user":{"name":"b","tags":[]},"score":3}
+{"id":3,"user":{"name":"c"}}
+`
+	document := `{"items":[{"id":1,"ok":true},{"id":2,"ok":false}]}`
+	tests := []struct {
+		sql      string
+		input    string
+		jsonType string
+		want     string
+	}{
+		{"SELECT s.id, s.user.name FROM S3Object s WHERE s.score >= 1.5", lines, "LINES", "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n"},
+		{"SELECT s.user.tags[1] AS tag FROM S3Object s WHERE s.user.tags[1] IS NOT NULL", lines, "LINES", "{\"tag\":\"y\"}\n"},
+		{"SELECT AVG(s.score), COUNT(s.score) FROM S3Object s", lines, "LINES", "{\"_1\":2.25,\"_2\":2}\n"},
+		{"SELECT * FROM S3Object[*].items[*] s WHERE s.ok = true", document, "DOCUMENT", "{\"id\":1,\"ok\":true}\n"},
+	}
+	for _, tt := range tests {
+		got := selectAll(t, tt.sql, func(q *Query) (RecordReader, error) {
+			return NewJSONReader(strings.NewReader(tt.input), &JSONInput{Type: tt.jsonType}, q)
+		}, jsonOutput)
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestSelectParquet(t *testing.T) {
+	type row struct {
+		Name  string  `parquet:"name"`
+		Count int64   `parquet:"count"`
+		Price float64 `parquet:"price"`
+	}
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, []row{{"a", 1, 0.5}, {"b", 20, 2}, {"c", 3, 1.25}}); err != nil {
+		t.Fatal(err)
+	}
+	csvOutput := &CSVOutput{}
+	csvOutput.Validate()
+	reader := func(q *Query) (RecordReader, error) {
+		return NewParquetReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), q)
+	}
+	if got, want := selectAll(t, "SELECT * FROM S3Object WHERE count < 10", reader, csvOutput), "a,1,0.5\nc,3,1.25\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := selectAll(t, "SELECT SUM(price * count) FROM S3Object", reader, csvOutput), "44.25\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, sql := range []string{
+		"",
+		"SELECT FROM S3Object",
+		"SELECT * FROM table",
+		"SELECT name, COUNT(*) FROM S3Object",
+		"SELECT * FROM S3Object WHERE COUNT(*) > 1",
+		"SELECT * FROM S3Object LIMIT -1",
+		"SELECT * FROM S3Object WHERE name = 'x",
+		"SELECT UNKNOWN(name) FROM S3Object",
+		"SELECT * FROM S3Object s extra",
+	} {
+		if _, err := Parse(sql); err == nil {
+			t.Errorf("expect an error parsing %q", sql)
+		}
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewEventWriter(&buf)
+	if err := w.WriteRecords([]byte("a,b\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteStats(Stats{BytesScanned: 10, BytesProcessed: 10, BytesReturned: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEnd(); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := eventstream.NewDecoder(&buf)
+	var events []string
+	for {
+		message, err := decoder.Decode(nil)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		eventType := message.Headers.Get(":event-type").String()
+		events = append(events, eventType)
+		switch eventType {
+		case "Records":
+			if string(message.Payload) != "a,b\n" {
+				t.Errorf("unexpected records %q", message.Payload)
+			}
+		case "Stats":
+			if !strings.Contains(string(message.Payload), "<BytesReturned>4</BytesReturned>") {
+				t.Errorf("unexpected stats %s", message.Payload)
+			}
+		}
+	}
+	if strings.Join(events, ",") != "Records,Stats,End" {
+		t.Errorf("unexpected events %v", events)
+	}
+}
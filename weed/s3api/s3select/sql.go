@@ -0,0 +1,658 @@
+package s3select
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The SQL of S3 Select, a single SELECT statement over the records of an object:
+//
+//	SELECT * | expression [[AS] alias], ... FROM S3Object[[*]...] [[AS] alias] [WHERE condition] [LIMIT number]
+//
+// The expressions are the column references like s._1, s.name, s."First Name", s.address.city or s.tags[0],
+// the literals, the arithmetic, the comparisons, [NOT] LIKE, [NOT] BETWEEN, [NOT] IN, IS [NOT] NULL, AND, OR, NOT,
+// the string functions, CAST, COALESCE, NULLIF, and the aggregates COUNT, SUM, AVG, MIN and MAX.
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of the expression"
+	}
+	return fmt.Sprintf("%q at %d", t.text, t.pos)
+}
+
+func tokenize(sql string) (tokens []token, err error) {
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			start := i
+			var text strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated %c at %d", c, start)
+				}
+				if runes[i] == c {
+					// the quote is escaped by doubling it
+					if i+1 < len(runes) && runes[i+1] == c {
+						text.WriteRune(c)
+						i++
+						continue
+					}
+					i++
+					break
+				}
+				text.WriteRune(runes[i])
+			}
+			kind := tokenString
+			if c == '"' {
+				kind = tokenQuotedIdent
+			}
+			tokens = append(tokens, token{kind: kind, text: text.String(), pos: start})
+		case unicode.IsDigit(c) || c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		default:
+			start := i
+			symbol := string(c)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "<=", ">=", "<>", "!=", "||":
+					symbol = two
+				}
+			}
+			if !strings.Contains("*,().[]=<>!+-/%;", symbol[:1]) || symbol == "!" {
+				return nil, fmt.Errorf("unexpected %q at %d", symbol, start)
+			}
+			i += len([]rune(symbol))
+			tokens = append(tokens, token{kind: tokenSymbol, text: symbol, pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens     []token
+	pos        int
+	query      *Query
+	aggregates int
+	// the columns are not allowed outside the aggregates in the projections of an aggregate query
+	inAggregate bool
+	columns     int
+}
+
+// Parse parses the SQL expression of a select request
+func Parse(sql string) (query *Query, err error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, query: &Query{limit: -1}}
+	if err = p.parseSelect(); err != nil {
+		return nil, err
+	}
+	return p.query, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword tells whether the next token is the keyword, which is not case-sensitive
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return fmt.Errorf("expect %s but found %s", keyword, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) isSymbol(symbol string) bool {
+	t := p.peek()
+	return t.kind == tokenSymbol && t.text == symbol
+}
+
+func (p *parser) acceptSymbol(symbol string) bool {
+	if p.isSymbol(symbol) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return fmt.Errorf("expect %s but found %s", symbol, p.peek())
+	}
+	return nil
+}
+
+var reservedWords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LIMIT": true, "AS": true, "AND": true, "OR": true, "NOT": true,
+	"LIKE": true, "ESCAPE": true, "BETWEEN": true, "IN": true, "IS": true, "NULL": true, "MISSING": true,
+	"TRUE": true, "FALSE": true, "CAST": true,
+}
+
+// acceptAlias parses an optional alias, with or without AS
+func (p *parser) acceptAlias() (alias string, err error) {
+	hasAs := p.acceptKeyword("AS")
+	t := p.peek()
+	if t.kind == tokenQuotedIdent || t.kind == tokenIdent && !reservedWords[strings.ToUpper(t.text)] {
+		p.pos++
+		return t.text, nil
+	}
+	if hasAs {
+		return "", fmt.Errorf("expect an alias but found %s", t)
+	}
+	return "", nil
+}
+
+func (p *parser) parseSelect() (err error) {
+	q := p.query
+	if err = p.expectKeyword("SELECT"); err != nil {
+		return err
+	}
+	if !p.acceptSymbol("*") {
+		for {
+			projection, err := p.parseProjection(len(q.projections))
+			if err != nil {
+				return err
+			}
+			if projection == nil {
+				q.selectAll = true
+			} else {
+				q.projections = append(q.projections, projection)
+			}
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if q.selectAll && len(q.projections) > 0 {
+			return fmt.Errorf("can not select * with the other columns")
+		}
+	} else {
+		q.selectAll = true
+	}
+	q.aggregateCount = p.aggregates
+	if p.aggregates > 0 && (q.selectAll || p.columns > 0) {
+		return fmt.Errorf("can not select the columns with the aggregates")
+	}
+
+	if err = p.expectKeyword("FROM"); err != nil {
+		return err
+	}
+	if err = p.parseFrom(); err != nil {
+		return err
+	}
+	if q.alias, err = p.acceptAlias(); err != nil {
+		return err
+	}
+
+	if p.acceptKeyword("WHERE") {
+		aggregates := p.aggregates
+		if q.where, err = p.parseExpr(); err != nil {
+			return err
+		}
+		if p.aggregates != aggregates {
+			return fmt.Errorf("can not use the aggregates in WHERE")
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		t := p.next()
+		limit, parseErr := strconv.ParseInt(t.text, 10, 64)
+		if t.kind != tokenNumber || parseErr != nil || limit < 0 {
+			return fmt.Errorf("expect a limit but found %s", t)
+		}
+		q.limit = limit
+	}
+	p.acceptSymbol(";")
+	if t := p.peek(); t.kind != tokenEOF {
+		return fmt.Errorf("unexpected %s", t)
+	}
+	return nil
+}
+
+// parseProjection parses an expression with its alias, or nil for alias.*
+func (p *parser) parseProjection(index int) (*projection, error) {
+	// alias.*
+	if t := p.peek(); t.kind == tokenIdent && p.pos+2 < len(p.tokens) && p.tokens[p.pos+1].text == "." && p.tokens[p.pos+2].text == "*" {
+		p.pos += 3
+		return nil, nil
+	}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	alias, err := p.acceptAlias()
+	if err != nil {
+		return nil, err
+	}
+	return &projection{expr: e, name: alias, index: index}, nil
+}
+
+// parseFrom parses S3Object with the optional path into the json documents, e.g., S3Object[*].records[*]
+func (p *parser) parseFrom() error {
+	t := p.next()
+	if t.kind != tokenIdent || !strings.EqualFold(t.text, "S3Object") {
+		return fmt.Errorf("expect S3Object but found %s", t)
+	}
+	for {
+		switch {
+		case p.acceptSymbol("["):
+			if p.acceptSymbol("*") {
+				p.query.from = append(p.query.from, pathElement{wildcard: true})
+			} else {
+				t := p.next()
+				index, err := strconv.Atoi(t.text)
+				if t.kind != tokenNumber || err != nil {
+					return fmt.Errorf("expect an index but found %s", t)
+				}
+				p.query.from = append(p.query.from, pathElement{index: index, isIndex: true})
+			}
+			if err := p.expectSymbol("]"); err != nil {
+				return err
+			}
+		case p.acceptSymbol("."):
+			t := p.next()
+			if t.kind != tokenIdent && t.kind != tokenQuotedIdent {
+				return fmt.Errorf("expect a name but found %s", t)
+			}
+			p.query.from = append(p.query.from, pathElement{name: t.text, caseSensitive: t.kind == tokenQuotedIdent})
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.acceptKeyword("NOT") {
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: e}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokenSymbol {
+		switch t.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.pos++
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &comparisonExpr{op: t.text, left: left, right: right}, nil
+		}
+	}
+
+	if p.acceptKeyword("IS") {
+		negated := p.acceptKeyword("NOT")
+		if !p.acceptKeyword("NULL") && !p.acceptKeyword("MISSING") {
+			return nil, fmt.Errorf("expect NULL or MISSING but found %s", p.peek())
+		}
+		return &isNullExpr{expr: left, negated: negated}, nil
+	}
+
+	negated := false
+	if p.isKeyword("NOT") {
+		if next := p.tokens[p.pos+1]; next.kind == tokenIdent {
+			switch strings.ToUpper(next.text) {
+			case "LIKE", "BETWEEN", "IN":
+				p.pos++
+				negated = true
+			}
+		}
+	}
+	switch {
+	case p.acceptKeyword("LIKE"):
+		pattern, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		like := &likeExpr{expr: left, pattern: pattern, negated: negated}
+		if p.acceptKeyword("ESCAPE") {
+			if like.escape, err = p.parseAdditive(); err != nil {
+				return nil, err
+			}
+		}
+		return like, nil
+	case p.acceptKeyword("BETWEEN"):
+		low, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &betweenExpr{expr: left, low: low, high: high, negated: negated}, nil
+	case p.acceptKeyword("IN"):
+		if err = p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		in := &inExpr{expr: left, negated: negated}
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, e)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if err = p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return in, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isSymbol("+") || p.isSymbol("-") || p.isSymbol("||") {
+		op := p.next().text
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticExpr{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isSymbol("*") || p.isSymbol("/") || p.isSymbol("%") {
+		op := p.next().text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticExpr{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.acceptSymbol("-") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &arithmeticExpr{op: "-", left: &literalExpr{value: int64(0)}, right: e}, nil
+	}
+	p.acceptSymbol("+")
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &literalExpr{value: t.text}, nil
+	case tokenNumber:
+		value := parseNumber(t.text)
+		if value == nil {
+			return nil, fmt.Errorf("invalid number %s", t)
+		}
+		return &literalExpr{value: value}, nil
+	case tokenSymbol:
+		if t.text == "(" {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expectSymbol(")")
+		}
+	case tokenQuotedIdent:
+		return p.parseColumn(t)
+	case tokenIdent:
+		switch strings.ToUpper(t.text) {
+		case "NULL", "MISSING":
+			return &literalExpr{value: nil}, nil
+		case "TRUE":
+			return &literalExpr{value: true}, nil
+		case "FALSE":
+			return &literalExpr{value: false}, nil
+		case "CAST":
+			return p.parseCast()
+		}
+		if p.isSymbol("(") {
+			return p.parseFunction(t)
+		}
+		if reservedWords[strings.ToUpper(t.text)] {
+			break
+		}
+		return p.parseColumn(t)
+	}
+	return nil, fmt.Errorf("unexpected %s", t)
+}
+
+func (p *parser) parseColumn(first token) (expr, error) {
+	if !p.inAggregate {
+		p.columns++
+	}
+	column := &columnExpr{path: []pathElement{{name: first.text, caseSensitive: first.kind == tokenQuotedIdent}}}
+	for {
+		switch {
+		case p.acceptSymbol("."):
+			t := p.next()
+			if t.kind != tokenIdent && t.kind != tokenQuotedIdent {
+				return nil, fmt.Errorf("expect a name but found %s", t)
+			}
+			column.path = append(column.path, pathElement{name: t.text, caseSensitive: t.kind == tokenQuotedIdent})
+		case p.acceptSymbol("["):
+			t := p.next()
+			switch t.kind {
+			case tokenNumber:
+				index, err := strconv.Atoi(t.text)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %s", t)
+				}
+				column.path = append(column.path, pathElement{index: index, isIndex: true})
+			case tokenString:
+				column.path = append(column.path, pathElement{name: t.text, caseSensitive: true})
+			default:
+				return nil, fmt.Errorf("expect an index but found %s", t)
+			}
+			if err := p.expectSymbol("]"); err != nil {
+				return nil, err
+			}
+		default:
+			return column, nil
+		}
+	}
+}
+
+func (p *parser) parseCast() (expr, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expectKeyword("AS"); err != nil {
+		return nil, err
+	}
+	t := p.next()
+	castType := strings.ToUpper(t.text)
+	switch castType {
+	case "INT", "INTEGER", "FLOAT", "DECIMAL", "NUMERIC", "STRING", "VARCHAR", "BOOL", "BOOLEAN", "TIMESTAMP":
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+	return &castExpr{expr: e, castType: castType}, p.expectSymbol(")")
+}
+
+func (p *parser) parseFunction(name token) (expr, error) {
+	p.next() // (
+	funcName := strings.ToUpper(name.text)
+	if isAggregate(funcName) {
+		if p.inAggregate {
+			return nil, fmt.Errorf("nested aggregate %s", name)
+		}
+		aggregate := &aggregateExpr{name: funcName, index: p.aggregates}
+		p.aggregates++
+		if funcName == "COUNT" && p.acceptSymbol("*") {
+			return aggregate, p.expectSymbol(")")
+		}
+		p.inAggregate = true
+		arg, err := p.parseExpr()
+		p.inAggregate = false
+		if err != nil {
+			return nil, err
+		}
+		aggregate.arg = arg
+		return aggregate, p.expectSymbol(")")
+	}
+
+	call := &functionExpr{name: funcName}
+	if _, found := scalarFunctions[funcName]; !found {
+		return nil, fmt.Errorf("unsupported function %s", name)
+	}
+	if !p.acceptSymbol(")") {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			// SUBSTRING(s FROM start FOR length)
+			if funcName == "SUBSTRING" && (p.acceptKeyword("FROM") || p.acceptKeyword("FOR")) {
+				continue
+			}
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+	}
+	if arity := scalarFunctions[funcName]; len(call.args) < arity.min || arity.max >= 0 && len(call.args) > arity.max {
+		return nil, fmt.Errorf("wrong number of arguments to %s", name)
+	}
+	return call, nil
+}
+
+func parseNumber(text string) interface{} {
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f
+	}
+	return nil
+}
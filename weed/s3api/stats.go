@@ -3,9 +3,12 @@ package s3api
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/seaweedfs/seaweedfs/weed/audit"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
@@ -24,20 +27,41 @@ func track(f http.HandlerFunc, action string) http.HandlerFunc {
 		if recorder.Status == http.StatusForbidden {
 			bucket = ""
 		}
-		stats_collect.S3RequestHistogram.WithLabelValues(action, bucket).Observe(time.Since(start).Seconds())
-		stats_collect.S3RequestCounter.WithLabelValues(action, strconv.Itoa(recorder.Status), bucket).Inc()
-		stats_collect.RecordBucketActiveTime(bucket)
+		elapsed := time.Since(start).Seconds()
+		code := strconv.Itoa(recorder.Status)
+		bucket = stats_collect.S3BucketLabel(bucket)
+		stats_collect.S3RequestHistogram.WithLabelValues(action, bucket).Observe(elapsed)
+		stats_collect.S3RequestCounter.WithLabelValues(action, code, bucket).Inc()
+
+		// the identity is set by the authentication
+		identity := identityLabel(r)
+		stats_collect.S3IdentityRequestHistogram.WithLabelValues(identity, action).Observe(elapsed)
+		stats_collect.S3IdentityRequestCounter.WithLabelValues(identity, action, code).Inc()
+
+		if audit.IsEnabled() {
+			accessLog := s3err.GetAccessLog(r, recorder.Status, s3err.ErrNone)
+			audit.LogHttpRequest(audit.ServiceS3, r, r.Header.Get(s3_constants.AmzIdentityId), accessLog.Operation,
+				strings.TrimSuffix("/"+accessLog.Bucket+accessLog.Key, "/"), recorder.Status, start)
+		}
 	}
 }
 
 func TimeToFirstByte(action string, start time.Time, r *http.Request) {
 	bucket, _ := s3_constants.GetBucketAndObject(r)
-	stats_collect.S3TimeToFirstByteHistogram.WithLabelValues(action, bucket).Observe(float64(time.Since(start).Milliseconds()))
-	stats_collect.RecordBucketActiveTime(bucket)
+	stats_collect.S3TimeToFirstByteHistogram.WithLabelValues(action, stats_collect.S3BucketLabel(bucket)).Observe(float64(time.Since(start).Milliseconds()))
 }
 
 func BucketTrafficSent(bytesTransferred int64, r *http.Request) {
 	bucket, _ := s3_constants.GetBucketAndObject(r)
-	stats_collect.RecordBucketActiveTime(bucket)
-	stats_collect.S3BucketTrafficSentBytesCounter.WithLabelValues(bucket).Add(float64(bytesTransferred))
+	stats_collect.S3BucketTrafficSentBytesCounter.WithLabelValues(stats_collect.S3BucketLabel(bucket)).Add(float64(bytesTransferred))
+	stats_collect.S3IdentityTrafficSentBytesCounter.WithLabelValues(identityLabel(r)).Add(float64(bytesTransferred))
+}
+
+func BucketTrafficReceived(bytesTransferred int64, bucket string, r *http.Request) {
+	stats_collect.S3BucketTrafficReceivedBytesCounter.WithLabelValues(stats_collect.S3BucketLabel(bucket)).Add(float64(bytesTransferred))
+	stats_collect.S3IdentityTrafficReceivedBytesCounter.WithLabelValues(identityLabel(r)).Add(float64(bytesTransferred))
+}
+
+func identityLabel(r *http.Request) string {
+	return stats_collect.S3IdentityLabel(r.Header.Get(s3_constants.AmzIdentityId))
 }
@@ -0,0 +1,265 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// the keys of an unknown key id are fetched again at most once in this interval, e.g., after the keys are rotated
+const oidcKeysRefreshInterval = time.Minute
+
+// OidcProvider verifies the bearer tokens issued by an OpenID Connect identity provider,
+// with the public keys of the provider, found by the discovery if the jwks_uri is not configured.
+type OidcProvider struct {
+	Issuer        string
+	Audience      string // the client id, not checked if empty
+	UsernameClaim string // the claim of the user name, "sub" by default
+	RolesClaim    string // the claim of the roles or groups, "groups" by default
+
+	jwksUri    string
+	httpClient *http.Client
+	parser     *jwt.Parser
+
+	keysLock      sync.Mutex
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// OidcIdentity is the verified user of a token
+type OidcIdentity struct {
+	Username  string
+	Roles     []string
+	ExpiresAt time.Time
+}
+
+// LoadOidcProvider loads the [oidc] section in security.toml, nil if no issuer is configured
+func LoadOidcProvider(config util.Configuration, prefix string) *OidcProvider {
+	issuer := config.GetString(prefix + "issuer")
+	if issuer == "" {
+		return nil
+	}
+	config.SetDefault(prefix+"username_claim", "sub")
+	config.SetDefault(prefix+"roles_claim", "groups")
+	p := NewOidcProvider(issuer, config.GetString(prefix+"audience"), config.GetString(prefix+"jwks_uri"))
+	p.UsernameClaim = config.GetString(prefix + "username_claim")
+	p.RolesClaim = config.GetString(prefix + "roles_claim")
+	glog.V(0).Infof("verify the OIDC tokens issued by %s", issuer)
+	return p
+}
+
+func NewOidcProvider(issuer, audience, jwksUri string) *OidcProvider {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	return &OidcProvider{
+		Issuer:        issuer,
+		Audience:      audience,
+		UsernameClaim: "sub",
+		RolesClaim:    "groups",
+		jwksUri:       jwksUri,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		parser:        jwt.NewParser(options...),
+		keys:          make(map[string]crypto.PublicKey),
+	}
+}
+
+// Verify checks the signature, the issuer, the audience, and the expiration of the token
+func (p *OidcProvider) Verify(tokenString string) (*OidcIdentity, error) {
+	claims := jwt.MapClaims{}
+	if _, err := p.parser.ParseWithClaims(tokenString, claims, p.keyOf); err != nil {
+		return nil, err
+	}
+	username, _ := claims[p.UsernameClaim].(string)
+	identity := &OidcIdentity{
+		Username: username,
+		Roles:    toClaimStrings(claims[p.RolesClaim]),
+	}
+	if identity.Username == "" {
+		return nil, fmt.Errorf("missing the claim %s", p.UsernameClaim)
+	}
+	if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		identity.ExpiresAt = expiresAt.Time
+	}
+	return identity, nil
+}
+
+// the claim of a string, a list of strings, or a space separated string like "scope"
+func toClaimStrings(value interface{}) (values []string) {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return
+}
+
+func (p *OidcProvider) keyOf(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	p.keysLock.Lock()
+	defer p.keysLock.Unlock()
+	if key, found := p.lookupKey(kid); found {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	p.keysFetchedAt = time.Now()
+	keys, err := p.fetchKeys()
+	if err != nil {
+		return nil, fmt.Errorf("fetch the keys of %s: %v", p.Issuer, err)
+	}
+	p.keys = keys
+	if key, found := p.lookupKey(kid); found {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// lookupKey finds the key by the id, or the only key if the token has no key id
+func (p *OidcProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, found := p.keys[kid]
+	return key, found
+}
+
+func (p *OidcProvider) fetchKeys() (map[string]crypto.PublicKey, error) {
+	if p.jwksUri == "" {
+		discovery := struct {
+			Issuer  string `json:"issuer"`
+			JwksUri string `json:"jwks_uri"`
+		}{}
+		if err := p.getJson(strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.Issuer != p.Issuer {
+			return nil, fmt.Errorf("the discovered issuer %s is not %s", discovery.Issuer, p.Issuer)
+		}
+		if discovery.JwksUri == "" {
+			return nil, fmt.Errorf("missing jwks_uri in the discovery")
+		}
+		p.jwksUri = discovery.JwksUri
+	}
+	jwks := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := p.getJson(p.jwksUri, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			glog.Warningf("skip the key %s of %s: %v", jwk.Kid, p.Issuer, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (p *OidcProvider) getJson(url string, v interface{}) error {
+	resp, err := p.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// jsonWebKey is a public key in the JWK format, RFC 7517
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (jwk *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("the point is not on the curve %s", jwk.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -23,6 +23,12 @@ func (m *MemorySequencer) NextFileId(count uint64) uint64 {
 	return ret
 }
 
+func (m *MemorySequencer) Peek() uint64 {
+	m.sequenceLock.Lock()
+	defer m.sequenceLock.Unlock()
+	return m.counter
+}
+
 func (m *MemorySequencer) SetMax(seenValue uint64) {
 	m.sequenceLock.Lock()
 	defer m.sequenceLock.Unlock()
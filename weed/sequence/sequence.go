@@ -3,4 +3,6 @@ package sequence
 type Sequencer interface {
 	NextFileId(count uint64) uint64
 	SetMax(uint64)
+	// Peek returns the next file id without allocating it
+	Peek() uint64
 }
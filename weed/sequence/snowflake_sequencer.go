@@ -39,6 +39,11 @@ func (m *SnowflakeSequencer) NextFileId(count uint64) uint64 {
 	return uint64(m.node.Generate().Int64())
 }
 
+// the snowflake ids grow with the time, so a newly generated one is as good as the next one
+func (m *SnowflakeSequencer) Peek() uint64 {
+	return uint64(m.node.Generate().Int64())
+}
+
 // ignore setmax as we are snowflake
 func (m *SnowflakeSequencer) SetMax(seenValue uint64) {
 }
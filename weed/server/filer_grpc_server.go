@@ -2,12 +2,16 @@ package weed_server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/seaweedfs/seaweedfs/weed/cluster"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
@@ -51,6 +55,21 @@ func (fs *FilerServer) ListEntries(req *filer_pb.ListEntriesRequest, stream file
 		paginationLimit = limit
 	}
 
+	if req.SortOrder != filer_pb.ListEntriesSortOrder_NAME {
+		listErr := fs.filer.StreamListSortedDirectoryEntries(stream.Context(), util.FullPath(req.Directory), req.StartFromFileName, req.InclusiveStartFrom, int64(limit), req.Prefix, req.SortOrder, func(entry *filer.Entry) bool {
+			if err = stream.Send(&filer_pb.ListEntriesResponse{
+				Entry: projectEntry(entry.ToProtoEntry(), req.Projection),
+			}); err != nil {
+				return false
+			}
+			return true
+		})
+		if listErr != nil {
+			return listErr
+		}
+		return err
+	}
+
 	lastFileName := req.StartFromFileName
 	includeLastFile := req.InclusiveStartFrom
 	var listErr error
@@ -59,7 +78,7 @@ func (fs *FilerServer) ListEntries(req *filer_pb.ListEntriesRequest, stream file
 		lastFileName, listErr = fs.filer.StreamListDirectoryEntries(stream.Context(), util.FullPath(req.Directory), lastFileName, includeLastFile, int64(paginationLimit), req.Prefix, "", "", func(entry *filer.Entry) bool {
 			hasEntries = true
 			if err = stream.Send(&filer_pb.ListEntriesResponse{
-				Entry: entry.ToProtoEntry(),
+				Entry: projectEntry(entry.ToProtoEntry(), req.Projection),
 			}); err != nil {
 				return false
 			}
@@ -88,6 +107,22 @@ func (fs *FilerServer) ListEntries(req *filer_pb.ListEntriesRequest, stream file
 	return nil
 }
 
+// projectEntry keeps only the requested parts of the listed entry
+func projectEntry(entry *filer_pb.Entry, projection filer_pb.ListEntriesProjection) *filer_pb.Entry {
+	switch projection {
+	case filer_pb.ListEntriesProjection_NAME_ONLY:
+		return &filer_pb.Entry{
+			Name:        entry.Name,
+			IsDirectory: entry.IsDirectory,
+		}
+	case filer_pb.ListEntriesProjection_ATTRIBUTES:
+		entry.Chunks = nil
+		entry.Content = nil
+		entry.Extended = nil
+	}
+	return entry
+}
+
 func (fs *FilerServer) LookupVolume(ctx context.Context, req *filer_pb.LookupVolumeRequest) (*filer_pb.LookupVolumeResponse, error) {
 
 	resp := &filer_pb.LookupVolumeResponse{
@@ -190,6 +225,10 @@ func (fs *FilerServer) UpdateEntry(ctx context.Context, req *filer_pb.UpdateEntr
 	}
 
 	if err = fs.filer.UpdateEntry(ctx, entry, newEntry); err == nil {
+		if fs.filer.MaybeSaveVersion(ctx, entry, newEntry, req.IsFromOtherCluster) {
+			// the old chunks are kept by the version
+			garbage, _ = filer.MinusChunks(fs.lookupFileId, garbage, entry.GetChunks())
+		}
 		fs.filer.DeleteChunksNotRecursive(garbage)
 
 		fs.filer.NotifyUpdateEvent(ctx, entry, newEntry, true, req.IsFromOtherCluster, req.Signatures)
@@ -198,7 +237,16 @@ func (fs *FilerServer) UpdateEntry(ctx context.Context, req *filer_pb.UpdateEntr
 		glog.V(3).Infof("UpdateEntry %s: %v", filepath.Join(req.Directory, req.Entry.Name), err)
 	}
 
-	return &filer_pb.UpdateEntryResponse{}, err
+	return &filer_pb.UpdateEntryResponse{}, wormGrpcError(err)
+}
+
+// wormGrpcError returns the errors of the write-once files and the locked objects as PermissionDenied,
+// for the clients to tell them from the other errors
+func wormGrpcError(err error) error {
+	if errors.Is(err, filer.ErrWormEnforced) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return err
 }
 
 func (fs *FilerServer) cleanupChunks(fullpath string, existingEntry *filer.Entry, newEntry *filer_pb.Entry) (chunks, garbage []*filer_pb.FileChunk, err error) {
@@ -291,7 +339,20 @@ func (fs *FilerServer) DeleteEntry(ctx context.Context, req *filer_pb.DeleteEntr
 
 	glog.V(4).Infof("DeleteEntry %v", req)
 
-	err = fs.filer.DeleteEntryMetaAndData(ctx, util.JoinPath(req.Directory, req.Name), req.IsRecursive, req.IgnoreRecursiveError, req.IsDeleteData, req.IsFromOtherCluster, req.Signatures, req.IfNotModifiedAfter)
+	if forbidden, checkErr := fs.contentAddressedForbidden(ctx, string(util.JoinPath(req.Directory, req.Name))); checkErr != nil {
+		return &filer_pb.DeleteEntryResponse{Error: checkErr.Error()}, nil
+	} else if forbidden {
+		return &filer_pb.DeleteEntryResponse{Error: ErrContentAddressedFile.Error()}, nil
+	}
+
+	p := util.JoinPath(req.Directory, req.Name)
+	isTrashed, err := fs.moveToTrash(ctx, p, req.IsRecursive, req.IsDeleteData, req.IsFromOtherCluster, req.Signatures, req.IfNotModifiedAfter)
+	if !isTrashed && err == nil {
+		err = fs.filer.DeleteEntryMetaAndData(ctx, p, req.IsRecursive, req.IgnoreRecursiveError, req.IsDeleteData, req.IsFromOtherCluster, req.Signatures, req.IfNotModifiedAfter)
+	}
+	if errors.Is(err, filer.ErrWormEnforced) {
+		return nil, wormGrpcError(err)
+	}
 	resp = &filer_pb.DeleteEntryResponse{}
 	if err != nil && err != filer_pb.ErrNotFound {
 		resp.Error = err.Error()
@@ -310,6 +371,9 @@ func (fs *FilerServer) AssignVolume(ctx context.Context, req *filer_pb.AssignVol
 		glog.V(3).Infof("AssignVolume: %v", err)
 		return &filer_pb.AssignVolumeResponse{Error: fmt.Sprintf("assign volume: %v", err)}, nil
 	}
+	if req.Path != "" {
+		fs.maybeInheritDirTtl(so, req.Path)
+	}
 
 	assignRequest, altRequest := so.ToAssignRequests(int(req.Count))
 
@@ -0,0 +1,136 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// CloneEntry copies a file by sharing its data chunks, similar to a reflink.
+// The shared chunks are reference counted, and deleted only after all entries referencing them are gone.
+// Chunk manifests are not shared, each entry gets its own manifest chunks.
+func (fs *FilerServer) CloneEntry(ctx context.Context, req *filer_pb.CloneEntryRequest) (*filer_pb.CloneEntryResponse, error) {
+
+	glog.V(4).Infof("CloneEntry %v", req)
+
+	sourcePath := util.NewFullPath(req.SourceDirectory, req.SourceName)
+	targetPath := util.NewFullPath(req.TargetDirectory, req.TargetName)
+	if sourcePath == targetPath {
+		return nil, fmt.Errorf("clone %s to itself", sourcePath)
+	}
+
+	// lock both entries, in the path order to avoid deadlocks with the clone in the other direction,
+	// so the target is not overwritten concurrently, which would release its old chunks twice
+	lockClient := cluster.NewLockClient(fs.grpcDialOption, fs.option.Host)
+	lockPaths := []util.FullPath{sourcePath, targetPath}
+	if targetPath < sourcePath {
+		lockPaths[0], lockPaths[1] = targetPath, sourcePath
+	}
+	for _, lockPath := range lockPaths {
+		lock := lockClient.NewShortLivedLock(string(lockPath), string(fs.option.Host))
+		defer lock.StopShortLivedLock()
+	}
+
+	source, err := fs.filer.FindEntry(ctx, sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("find %s: %v", sourcePath, err)
+	}
+	if source.IsDirectory() {
+		return nil, fmt.Errorf("%s is a directory", sourcePath)
+	}
+	if source.Remote != nil {
+		return nil, fmt.Errorf("%s is mounted from remote storage", sourcePath)
+	}
+	if source.TtlSec > 0 {
+		return nil, fmt.Errorf("%s has ttl %d seconds", sourcePath, source.TtlSec)
+	}
+
+	target, err := fs.filer.FindEntry(ctx, targetPath)
+	if err != nil && err != filer_pb.ErrNotFound {
+		return nil, fmt.Errorf("find %s: %v", targetPath, err)
+	}
+	if target != nil && target.IsDirectory() {
+		return nil, fmt.Errorf("%s is a directory", targetPath)
+	}
+
+	// deleting a bucket drops its collection, so the chunks can only be shared within the same collection
+	sourceSo, err := fs.detectStorageOption(string(sourcePath), "", "", 0, "", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	targetSo, err := fs.detectStorageOption(string(targetPath), "", "", 0, "", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	if sourceSo.Collection != targetSo.Collection {
+		return nil, fmt.Errorf("can not share chunks between collection %s and %s", sourceSo.Collection, targetSo.Collection)
+	}
+
+	newTarget := &filer.Entry{
+		FullPath: targetPath,
+		Attr:     source.Attr,
+		Extended: source.Extended,
+	}
+	newTarget.Crtime = time.Now()
+	newTarget.Inode = 0
+	if target != nil {
+		newTarget = target.ShallowClone()
+		newTarget.Mime = source.Mime
+		newTarget.Md5 = source.Md5
+	}
+	newTarget.Mtime = time.Now()
+	newTarget.FileSize = source.Size()
+	newTarget.Content = source.Content
+	newTarget.Remote = nil
+
+	newSource := source.ShallowClone()
+	if len(source.GetChunks()) > 0 {
+		dataChunks, _, resolveErr := filer.ResolveChunkManifest(fs.lookupFileId, source.GetChunks(), 0, math.MaxInt64)
+		if resolveErr != nil {
+			return nil, fmt.Errorf("resolve chunks of %s: %v", sourcePath, resolveErr)
+		}
+
+		// the references are added first, so a failure below only leaks the chunks, instead of losing them
+		if err = fs.filer.ReferenceChunks(ctx, dataChunks); err != nil {
+			return nil, fmt.Errorf("reference chunks of %s: %v", sourcePath, err)
+		}
+
+		targetChunks := make([]*filer_pb.FileChunk, 0, len(dataChunks))
+		for _, chunk := range dataChunks {
+			targetChunks = append(targetChunks, proto.Clone(chunk).(*filer_pb.FileChunk))
+		}
+		if newTarget.Chunks, err = filer.MaybeManifestize(fs.saveAsChunk(targetSo), targetChunks); err != nil {
+			return nil, fmt.Errorf("manifestize %s: %v", targetPath, err)
+		}
+		if newSource.Chunks, err = filer.MaybeManifestize(fs.saveAsChunk(sourceSo), dataChunks); err != nil {
+			return nil, fmt.Errorf("manifestize %s: %v", sourcePath, err)
+		}
+
+		// persist the shared marks of the source chunks
+		if err = fs.filer.UpdateEntry(ctx, source, newSource); err != nil {
+			return nil, fmt.Errorf("update %s: %v", sourcePath, err)
+		}
+		if garbage, minusErr := filer.MinusChunks(fs.lookupFileId, source.GetChunks(), newSource.GetChunks()); minusErr == nil {
+			fs.filer.DeleteChunksNotRecursive(garbage)
+		}
+		fs.filer.NotifyUpdateEvent(ctx, source, newSource, true, false, req.Signatures)
+	}
+
+	if err = fs.filer.CreateEntry(ctx, newTarget, false, false, req.Signatures, false, fs.filer.MaxFilenameLength); err != nil {
+		return nil, fmt.Errorf("create %s: %v", targetPath, err)
+	}
+
+	return &filer_pb.CloneEntryResponse{
+		Entry:       newTarget.ToProtoEntry(),
+		SourceEntry: newSource.ToProtoEntry(),
+	}, nil
+}
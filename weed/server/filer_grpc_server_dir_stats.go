@@ -0,0 +1,42 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// GetDirectoryStats returns the total size and the numbers of the files and directories under a directory
+func (fs *FilerServer) GetDirectoryStats(ctx context.Context, req *filer_pb.GetDirectoryStatsRequest) (*filer_pb.GetDirectoryStatsResponse, error) {
+
+	glog.V(4).Infof("GetDirectoryStats %v", req)
+
+	dir := util.FullPath(strings.TrimSuffix(req.Directory, "/"))
+	if dir == "" {
+		dir = "/"
+	}
+	if dir != "/" {
+		entry, err := fs.filer.FindEntry(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		if !entry.IsDirectory() {
+			return nil, fmt.Errorf("%s is not a directory", dir)
+		}
+	}
+
+	stats, isCached, err := fs.filer.GetDirStats(ctx, dir, req.Recount)
+	if err != nil {
+		return nil, fmt.Errorf("count %s: %v", dir, err)
+	}
+	return &filer_pb.GetDirectoryStatsResponse{
+		TotalBytes:     stats.Bytes,
+		FileCount:      stats.Files,
+		DirectoryCount: stats.Directories,
+		IsCached:       isCached,
+	}, nil
+}
@@ -0,0 +1,39 @@
+package weed_server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func (fs *FilerServer) CreateHardLink(ctx context.Context, req *filer_pb.CreateHardLinkRequest) (*filer_pb.CreateHardLinkResponse, error) {
+
+	glog.V(4).Infof("CreateHardLink %v", req)
+
+	oldPath := util.NewFullPath(req.OldDirectory, req.OldName)
+	newPath := util.NewFullPath(req.NewDirectory, req.NewName)
+	linked, link, err := fs.filer.CreateHardLink(ctx, oldPath, newPath, req.Signatures)
+	switch {
+	case err == nil:
+	case errors.Is(err, filer_pb.ErrNotFound):
+		return nil, status.Errorf(codes.NotFound, "%s not found", oldPath)
+	case errors.Is(err, filer.ErrEntryExists):
+		return nil, status.Errorf(codes.AlreadyExists, "%s already exists", newPath)
+	case errors.Is(err, filer.ErrWormEnforced):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	default:
+		glog.V(0).Infof("CreateHardLink %s => %s: %v", newPath, oldPath, err)
+		return nil, err
+	}
+	return &filer_pb.CreateHardLinkResponse{
+		OldEntry: linked.ToProtoEntry(),
+		Entry:    link.ToProtoEntry(),
+	}, nil
+}
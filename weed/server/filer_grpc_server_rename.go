@@ -2,6 +2,7 @@ package weed_server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"time"
@@ -23,10 +24,12 @@ func (fs *FilerServer) AtomicRenameEntry(ctx context.Context, req *filer_pb.Atom
 		return nil, err
 	}
 
-	ctx, err := fs.filer.BeginTransaction(ctx)
+	txCtx, err := fs.filer.BeginTransaction(ctx)
 	if err != nil {
 		return nil, err
 	}
+	isTransactional := txCtx != ctx
+	ctx = txCtx
 
 	oldEntry, err := fs.filer.FindEntry(ctx, oldParent.Child(req.OldName))
 	if err != nil {
@@ -34,7 +37,21 @@ func (fs *FilerServer) AtomicRenameEntry(ctx context.Context, req *filer_pb.Atom
 		return nil, fmt.Errorf("%s/%s not found: %v", req.OldDirectory, req.OldName, err)
 	}
 
-	moveErr := fs.moveEntry(ctx, nil, oldParent, oldEntry, newParent, req.NewName, req.Signatures)
+	if err = fs.filer.CheckWormDeletable(ctx, oldEntry); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return nil, err
+	}
+	if err = fs.filer.CheckDirQuotaMove(ctx, oldEntry, newParent.Child(req.NewName)); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return nil, err
+	}
+	if err = fs.filer.RecordBucketOrigins(ctx, oldEntry, newParent.Child(req.NewName)); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return nil, err
+	}
+	ctx = filer.WithoutDirQuotaCheck(ctx)
+
+	moveErr := fs.renameEntry(ctx, isTransactional, nil, oldParent, oldEntry, newParent, req.NewName, req.Signatures)
 	if moveErr != nil {
 		fs.filer.RollbackTransaction(ctx)
 		return nil, fmt.Errorf("%s/%s move error: %v", req.OldDirectory, req.OldName, moveErr)
@@ -61,10 +78,12 @@ func (fs *FilerServer) StreamRenameEntry(req *filer_pb.StreamRenameEntryRequest,
 
 	ctx := context.Background()
 
-	ctx, err = fs.filer.BeginTransaction(ctx)
+	txCtx, err := fs.filer.BeginTransaction(ctx)
 	if err != nil {
 		return err
 	}
+	isTransactional := txCtx != ctx
+	ctx = txCtx
 
 	oldEntry, err := fs.filer.FindEntry(ctx, oldParent.Child(req.OldName))
 	if err != nil {
@@ -87,7 +106,21 @@ func (fs *FilerServer) StreamRenameEntry(req *filer_pb.StreamRenameEntryRequest,
 		}
 	}
 
-	moveErr := fs.moveEntry(ctx, stream, oldParent, oldEntry, newParent, req.NewName, req.Signatures)
+	if err = fs.filer.CheckWormDeletable(ctx, oldEntry); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return err
+	}
+	if err = fs.filer.CheckDirQuotaMove(ctx, oldEntry, newParent.Child(req.NewName)); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return err
+	}
+	if err = fs.filer.RecordBucketOrigins(ctx, oldEntry, newParent.Child(req.NewName)); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return err
+	}
+	ctx = filer.WithoutDirQuotaCheck(ctx)
+
+	moveErr := fs.renameEntry(ctx, isTransactional, stream, oldParent, oldEntry, newParent, req.NewName, req.Signatures)
 	if moveErr != nil {
 		fs.filer.RollbackTransaction(ctx)
 		return fmt.Errorf("%s/%s move error: %v", req.OldDirectory, req.OldName, moveErr)
@@ -101,6 +134,61 @@ func (fs *FilerServer) StreamRenameEntry(req *filer_pb.StreamRenameEntryRequest,
 	return nil
 }
 
+// renameEntry moves the entry within the store transaction. Without transactions, a directory tree is moved
+// with a rename journal, so the rename is resumed if interrupted, and looks atomic to the clients of this filer.
+func (fs *FilerServer) renameEntry(ctx context.Context, isTransactional bool, stream filer_pb.SeaweedFiler_StreamRenameEntryServer, oldParent util.FullPath, entry *filer.Entry, newParent util.FullPath, newName string, signatures []int32) error {
+	if isTransactional || !entry.IsDirectory() {
+		return fs.moveEntry(ctx, stream, oldParent, entry, newParent, newName, signatures)
+	}
+
+	journal := &filer.RenameJournal{
+		OldPath:     oldParent.Child(entry.Name()),
+		NewPath:     newParent.Child(newName),
+		Signatures:  signatures,
+		StartedAtNs: time.Now().UnixNano(),
+	}
+	if err := fs.filer.StartRename(ctx, journal); err != nil {
+		return err
+	}
+	renameCtx := filer.WithRename(ctx)
+	err := fs.moveEntry(renameCtx, stream, oldParent, entry, newParent, newName, signatures)
+	if err != nil {
+		if _, findErr := fs.filer.FindEntry(renameCtx, journal.NewPath); errors.Is(findErr, filer_pb.ErrNotFound) {
+			// nothing is moved yet, since the new directory is created first
+			fs.filer.FinishRename(ctx, journal, nil)
+			return err
+		}
+	}
+	fs.filer.FinishRename(ctx, journal, err)
+	return err
+}
+
+// loopResumeRenames resumes the renames interrupted by a restart or an error, when their backoff is due
+func (fs *FilerServer) loopResumeRenames() {
+	for {
+		for _, journal := range fs.filer.ResumeRenames() {
+			fs.filer.FinishRename(context.Background(), journal, fs.resumeRename(journal))
+		}
+		time.Sleep(10 * time.Second)
+	}
+}
+
+func (fs *FilerServer) resumeRename(journal *filer.RenameJournal) error {
+	ctx := filer.WithoutDirQuotaCheck(filer.WithRename(context.Background()))
+	oldEntry, err := fs.filer.FindEntry(ctx, journal.OldPath)
+	if errors.Is(err, filer_pb.ErrNotFound) {
+		// the old directory is deleted after all the entries under it are moved
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	glog.V(0).Infof("resume renaming %s => %s", journal.OldPath, journal.NewPath)
+	oldParent, _ := journal.OldPath.DirAndName()
+	newParent, newName := journal.NewPath.DirAndName()
+	return fs.moveEntry(ctx, nil, util.FullPath(oldParent), oldEntry, util.FullPath(newParent), newName, journal.Signatures)
+}
+
 func (fs *FilerServer) moveEntry(ctx context.Context, stream filer_pb.SeaweedFiler_StreamRenameEntryServer, oldParent util.FullPath, entry *filer.Entry, newParent util.FullPath, newName string, signatures []int32) error {
 
 	if err := fs.moveSelfEntry(ctx, stream, oldParent, entry, newParent, newName, func() error {
@@ -172,6 +260,8 @@ func (fs *FilerServer) moveSelfEntry(ctx context.Context, stream filer_pb.Seawee
 		HardLinkId:      entry.HardLinkId,
 		Remote:          entry.Remote,
 		Quota:           entry.Quota,
+
+		WORMEnforcedAtTsNs: entry.WORMEnforcedAtTsNs,
 	}
 	if createErr := fs.filer.CreateEntry(ctx, newEntry, false, false, signatures, false, fs.filer.MaxFilenameLength); createErr != nil {
 		return createErr
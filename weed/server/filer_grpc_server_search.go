@@ -0,0 +1,46 @@
+package weed_server
+
+import (
+	"context"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/metadata_index"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// SearchEntries finds the entries by their metadata in the metadata index, one page at a time
+func (fs *FilerServer) SearchEntries(ctx context.Context, req *filer_pb.SearchEntriesRequest) (*filer_pb.SearchEntriesResponse, error) {
+
+	glog.V(4).Infof("SearchEntries %v", req)
+
+	entries, nextCursor, err := fs.filer.SearchMetadata(ctx, &metadata_index.Query{
+		Directory:   req.Directory,
+		NamePattern: req.NamePattern,
+		Ext:         req.Extension,
+		MimePrefix:  req.MimePrefix,
+		MinSize:     req.MinSize,
+		MaxSize:     req.MaxSize,
+		MtimeAfter:  req.MtimeAfter,
+		MtimeBefore: req.MtimeBefore,
+		Extended:    req.Extended,
+		Tags:        req.Tags,
+		FilesOnly:   req.FilesOnly,
+		Limit:       int(req.Limit),
+		Cursor:      req.Cursor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &filer_pb.SearchEntriesResponse{
+		NextCursor: nextCursor,
+	}
+	for _, entry := range entries {
+		dir, _ := entry.FullPath.DirAndName()
+		resp.Entries = append(resp.Entries, &filer_pb.FullEntry{
+			Dir:   dir,
+			Entry: entry.ToProtoEntry(),
+		})
+	}
+	return resp, nil
+}
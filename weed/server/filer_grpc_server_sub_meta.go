@@ -1,7 +1,9 @@
 package weed_server
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -110,6 +112,20 @@ func (fs *FilerServer) SubscribeMetadata(req *filer_pb.SubscribeMetadataRequest,
 
 }
 
+// GetMetadataLogStatus tells how far back the subscribers can resume
+func (fs *FilerServer) GetMetadataLogStatus(ctx context.Context, req *filer_pb.GetMetadataLogStatusRequest) (*filer_pb.GetMetadataLogStatusResponse, error) {
+	earliestTsNs, err := fs.filer.EarliestMetaLogTsNs()
+	if err != nil {
+		return nil, fmt.Errorf("find earliest metadata change: %v", err)
+	}
+	return &filer_pb.GetMetadataLogStatusResponse{
+		EarliestTsNs:        earliestTsNs,
+		RetentionSeconds:    int64(fs.filer.MetaLog.Retention.Seconds()),
+		SegmentSeconds:      int64(fs.filer.MetaLog.SegmentDuration.Seconds()),
+		CompactAfterSeconds: int64(fs.filer.MetaLog.CompactAfter.Seconds()),
+	}, nil
+}
+
 func (fs *FilerServer) SubscribeLocalMetadata(req *filer_pb.SubscribeMetadataRequest, stream filer_pb.SeaweedFiler_SubscribeLocalMetadataServer) error {
 
 	peerAddress := findClientAddress(stream.Context(), 0)
@@ -273,6 +289,11 @@ func (fs *FilerServer) eachEventNotificationFn(req *filer_pb.SubscribeMetadataRe
 			}
 		}
 
+		if len(req.Names) > 0 && !slices.Contains(req.Names, entryName) &&
+			(eventNotification.NewEntry == nil || !slices.Contains(req.Names, eventNotification.NewEntry.Name)) {
+			return nil
+		}
+
 		// collect timestamps for path
 		stats.FilerServerLastSendTsOfSubscribeGauge.WithLabelValues(fs.option.Host.String(), req.ClientName, req.PathPrefix).Set(float64(tsNs))
 
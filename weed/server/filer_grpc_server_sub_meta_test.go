@@ -0,0 +1,52 @@
+package weed_server
+
+import (
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+type subscribeMetadataStream struct {
+	grpc.ServerStream
+	sent []*filer_pb.SubscribeMetadataResponse
+}
+
+func (s *subscribeMetadataStream) Send(resp *filer_pb.SubscribeMetadataResponse) error {
+	s.sent = append(s.sent, resp)
+	return nil
+}
+
+func TestSubscribeMetadataByNames(t *testing.T) {
+	fs := &FilerServer{filer: &filer.Filer{}, option: &FilerOption{}}
+	stream := &subscribeMetadataStream{}
+	req := &filer_pb.SubscribeMetadataRequest{PathPrefix: filer.TopicsDir + "/", Names: []string{filer.TopicConfFile}}
+	eachEventNotificationFn := fs.eachEventNotificationFn(req, stream, "test")
+
+	for _, event := range []struct {
+		dir          string
+		notification *filer_pb.EventNotification
+	}{
+		{"/topics/ns/t1", &filer_pb.EventNotification{NewEntry: &filer_pb.Entry{Name: filer.TopicConfFile}}},
+		{"/topics/ns/t1/v2024/0000-0630", &filer_pb.EventNotification{NewEntry: &filer_pb.Entry{Name: "2024-01-01-00-00-00"}}},
+		{"/topics/ns/t2", &filer_pb.EventNotification{OldEntry: &filer_pb.Entry{Name: filer.TopicConfFile}}},
+		// renamed to the watched name
+		{"/topics/ns/t3", &filer_pb.EventNotification{OldEntry: &filer_pb.Entry{Name: "topic.conf.tmp"},
+			NewEntry: &filer_pb.Entry{Name: filer.TopicConfFile}, NewParentPath: "/topics/ns/t3"}},
+		{"/buckets/b", &filer_pb.EventNotification{NewEntry: &filer_pb.Entry{Name: filer.TopicConfFile}}},
+	} {
+		if err := eachEventNotificationFn(event.dir, event.notification, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var dirs []string
+	for _, resp := range stream.sent {
+		dirs = append(dirs, resp.Directory)
+	}
+	if len(dirs) != 3 || dirs[0] != "/topics/ns/t1" || dirs[1] != "/topics/ns/t2" || dirs[2] != "/topics/ns/t3" {
+		t.Errorf("unexpected events sent in %v", dirs)
+	}
+}
@@ -0,0 +1,136 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func (fs *FilerServer) ListTrashEntries(ctx context.Context, req *filer_pb.ListTrashEntriesRequest) (*filer_pb.ListTrashEntriesResponse, error) {
+
+	glog.V(4).Infof("ListTrashEntries %v", req)
+
+	trashEntries, err := fs.filer.ListTrash(ctx, req.PathPrefix, req.StartFromTrashName, int(req.Limit))
+	if err != nil {
+		return nil, err
+	}
+	resp := &filer_pb.ListTrashEntriesResponse{}
+	for _, trashEntry := range trashEntries {
+		resp.Entries = append(resp.Entries, &filer_pb.TrashEntry{
+			TrashName:    trashEntry.TrashName,
+			OriginalPath: string(trashEntry.OriginalPath),
+			DeletedAtNs:  trashEntry.DeletedAtNs,
+			PurgeAtNs:    trashEntry.PurgeAtNs,
+			Entry:        trashEntry.ToProtoEntry(),
+		})
+	}
+	return resp, nil
+}
+
+func (fs *FilerServer) RestoreTrashEntry(ctx context.Context, req *filer_pb.RestoreTrashEntryRequest) (*filer_pb.RestoreTrashEntryResponse, error) {
+
+	glog.V(4).Infof("RestoreTrashEntry %v", req)
+
+	trashEntry, err := fs.filer.FindTrash(ctx, req.TrashName)
+	if err != nil {
+		return nil, err
+	}
+	newPath := trashEntry.OriginalPath
+	if req.NewPath != "" {
+		newPath = util.FullPath(req.NewPath)
+	}
+	if _, findErr := fs.filer.FindEntry(ctx, newPath); findErr == nil {
+		return nil, fmt.Errorf("restore %s: %s already exists", req.TrashName, newPath)
+	} else if findErr != filer_pb.ErrNotFound {
+		return nil, findErr
+	}
+
+	entry := trashEntry.Entry
+	filer.PrepareRestoredEntry(entry)
+	if err = fs.filer.CheckDirQuotaMove(ctx, entry, newPath); err != nil {
+		return nil, err
+	}
+	// restored into another bucket, the chunks stay in the collections of the original bucket
+	deleted := entry.ShallowClone()
+	deleted.FullPath = trashEntry.OriginalPath
+	if err = fs.filer.RecordBucketOrigins(ctx, deleted, newPath); err != nil {
+		return nil, err
+	}
+	newParent, newName := newPath.DirAndName()
+	if err = fs.moveInTransaction(ctx, filer.TrashDir, entry, util.FullPath(newParent), newName, nil); err != nil {
+		return nil, fmt.Errorf("restore %s to %s: %v", req.TrashName, newPath, err)
+	}
+
+	restored, err := fs.filer.FindEntry(ctx, newPath)
+	if err != nil {
+		return nil, err
+	}
+	return &filer_pb.RestoreTrashEntryResponse{
+		Entry: restored.ToProtoEntry(),
+		Path:  string(newPath),
+	}, nil
+}
+
+// moveToTrash moves the deleted entry with everything under it to the trash, if its location keeps the deleted entries.
+// When not trashed, the entry is deleted as usual, which also reports the missing entry.
+func (fs *FilerServer) moveToTrash(ctx context.Context, p util.FullPath, isRecursive, isDeleteData, isFromOtherCluster bool, signatures []int32, ifNotModifiedAfter int64) (isTrashed bool, err error) {
+	if p == "/" {
+		return false, nil
+	}
+	entry, findErr := fs.filer.FindEntry(ctx, p)
+	if findErr != nil {
+		return false, nil
+	}
+	retention := fs.filer.TrashRetentionOf(entry, isDeleteData, isFromOtherCluster)
+	if retention == 0 {
+		return false, nil
+	}
+	if ifNotModifiedAfter > 0 && entry.Attr.Mtime.Unix() > ifNotModifiedAfter {
+		return true, nil
+	}
+	if err = fs.filer.CheckWormDeletable(ctx, entry); err != nil {
+		return false, err
+	}
+	if entry.IsDirectory() && !isRecursive {
+		entries, _, listErr := fs.filer.ListDirectoryEntries(ctx, p, "", false, 1, "", "", "")
+		if listErr != nil {
+			return false, fmt.Errorf("list folder %s: %v", p, listErr)
+		}
+		if len(entries) > 0 {
+			return false, fmt.Errorf("%s: %s", filer.MsgFailDelNonEmptyFolder, p)
+		}
+	}
+
+	oldParent, _ := p.DirAndName()
+	trashName := filer.PrepareTrashEntry(entry, retention, time.Now())
+	glog.V(1).Infof("move %s to trash as %s", p, trashName)
+	if err = fs.moveInTransaction(ctx, util.FullPath(oldParent), entry, filer.TrashDir, trashName, signatures); err != nil {
+		return false, fmt.Errorf("move %s to trash: %v", p, err)
+	}
+	return true, nil
+}
+
+// moveInTransaction moves the entry as one rename, not limited by the directory quota
+func (fs *FilerServer) moveInTransaction(ctx context.Context, oldParent util.FullPath, entry *filer.Entry, newParent util.FullPath, newName string, signatures []int32) error {
+	txCtx, err := fs.filer.BeginTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	isTransactional := txCtx != ctx
+	ctx = filer.WithoutDirQuotaCheck(txCtx)
+
+	if err = fs.renameEntry(ctx, isTransactional, nil, oldParent, entry, newParent, newName, signatures); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return err
+	}
+	if err = fs.filer.CommitTransaction(ctx); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package weed_server
+
+import (
+	"context"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func (fs *FilerServer) ListEntryVersions(ctx context.Context, req *filer_pb.ListEntryVersionsRequest) (*filer_pb.ListEntryVersionsResponse, error) {
+
+	glog.V(4).Infof("ListEntryVersions %v", req)
+
+	versions, err := fs.filer.ListVersions(ctx, util.NewFullPath(req.Directory, req.Name))
+	if err != nil {
+		return nil, err
+	}
+	resp := &filer_pb.ListEntryVersionsResponse{}
+	for _, version := range versions {
+		resp.Versions = append(resp.Versions, &filer_pb.EntryVersion{
+			VersionId:    version.VersionId,
+			ReplacedAtNs: version.ReplacedAtNs,
+			Entry:        version.ToProtoEntry(),
+		})
+	}
+	return resp, nil
+}
+
+func (fs *FilerServer) RestoreEntryVersion(ctx context.Context, req *filer_pb.RestoreEntryVersionRequest) (*filer_pb.RestoreEntryVersionResponse, error) {
+
+	glog.V(4).Infof("RestoreEntryVersion %v", req)
+
+	entry, err := fs.filer.RestoreVersion(ctx, util.NewFullPath(req.Directory, req.Name), req.VersionId)
+	if err != nil {
+		return nil, err
+	}
+	return &filer_pb.RestoreEntryVersionResponse{
+		Entry: entry.ToProtoEntry(),
+	}, nil
+}
+
+func (fs *FilerServer) DeleteEntryVersion(ctx context.Context, req *filer_pb.DeleteEntryVersionRequest) (*filer_pb.DeleteEntryVersionResponse, error) {
+
+	glog.V(4).Infof("DeleteEntryVersion %v", req)
+
+	if err := fs.filer.DeleteVersion(ctx, util.NewFullPath(req.Directory, req.Name), req.VersionId); err != nil {
+		return nil, wormGrpcError(err)
+	}
+	return &filer_pb.DeleteEntryVersionResponse{}, nil
+}
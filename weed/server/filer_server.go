@@ -21,6 +21,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/health"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/arangodb"
@@ -42,12 +43,16 @@ import (
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/sqlite"
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/ydb"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/metadata_index"
+	_ "github.com/seaweedfs/seaweedfs/weed/metadata_index/elastic"
+	_ "github.com/seaweedfs/seaweedfs/weed/metadata_index/leveldb"
 	"github.com/seaweedfs/seaweedfs/weed/notification"
 	_ "github.com/seaweedfs/seaweedfs/weed/notification/aws_sqs"
 	_ "github.com/seaweedfs/seaweedfs/weed/notification/gocdk_pub_sub"
 	_ "github.com/seaweedfs/seaweedfs/weed/notification/google_pub_sub"
 	_ "github.com/seaweedfs/seaweedfs/weed/notification/kafka"
 	_ "github.com/seaweedfs/seaweedfs/weed/notification/log"
+	_ "github.com/seaweedfs/seaweedfs/weed/notification/seaweed_mq"
 	"github.com/seaweedfs/seaweedfs/weed/security"
 )
 
@@ -71,9 +76,14 @@ type FilerOption struct {
 	ConcurrentUploadLimit int64
 	ShowUIDirectoryDelete bool
 	DownloadMaxBytesPs    int64
+	ReadTransformWorkers  int
 	DiskType              string
 	AllowedOrigins        []string
 	ExposeDirectoryData   bool
+	MetaLog               filer.MetaLogOption
+	DirStats              filer.DirStatsOption
+	Admission             FilerAdmissionOption
+	ClientLimits          FilerClientLimitOption
 }
 
 type FilerServer struct {
@@ -101,6 +111,12 @@ type FilerServer struct {
 	// track known metadata listeners
 	knownListenersLock sync.Mutex
 	knownListeners     map[int32]int32
+
+	healthChecks *health.Checks
+
+	admission    *filerAdmission
+	clientLimits *filerClientLimits
+	oidc         *filerOidc
 }
 
 func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption) (fs *FilerServer, err error) {
@@ -137,8 +153,11 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 		grpcDialOption:        security.LoadClientTLS(util.GetViper(), "grpc.filer"),
 		knownListeners:        make(map[int32]int32),
 		inFlightDataLimitCond: sync.NewCond(new(sync.Mutex)),
+		admission:             newFilerAdmission(option.Admission),
+		clientLimits:          newFilerClientLimits(option.ClientLimits),
 	}
 	fs.listenersCond = sync.NewCond(&fs.listenersLock)
+	util.ReadTransformWorkers = util.NewTransformWorkers(option.ReadTransformWorkers)
 
 	option.Masters.RefreshBySrvIfAvailable()
 	if len(option.Masters.GetInstances()) == 0 {
@@ -146,20 +165,27 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 	}
 	v.SetDefault("filer.options.max_file_name_length", 255)
 	maxFilenameLength := v.GetUint32("filer.options.max_file_name_length")
+	if err := option.MetaLog.Validate(); err != nil {
+		return nil, err
+	}
 	fs.filer = filer.NewFiler(*option.Masters, fs.grpcDialOption, option.Host, option.FilerGroup, option.Collection, option.DefaultReplication, option.DataCenter, maxFilenameLength, func() {
 		if atomic.LoadInt64(&fs.listenersWaits) > 0 {
 			fs.listenersCond.Broadcast()
 		}
 	})
 	fs.filer.Cipher = option.Cipher
+	fs.filer.MetaLog = option.MetaLog
+	fs.filer.DirStats = option.DirStats
 	whiteList := util.StringSplit(v.GetString("guard.white_list"), ",")
 	fs.filerGuard = security.NewGuard(whiteList, signingKey, expiresAfterSec, readSigningKey, readExpiresAfterSec)
 	fs.volumeGuard = security.NewGuard([]string{}, volumeSigningKey, volumeExpiresAfterSec, volumeReadSigningKey, volumeReadExpiresAfterSec)
+	fs.oidc = loadFilerOidc(v)
 
 	fs.checkWithMaster()
 
 	go stats.LoopPushingMetric("filer", string(fs.option.Host), fs.metricsAddress, fs.metricsIntervalSec)
 	go fs.filer.KeepMasterClientConnected(context.Background())
+	go fs.filer.LoopMaintainMetaLog()
 
 	if !util.LoadConfiguration("filer", false) {
 		v.SetDefault("leveldb2.enabled", true)
@@ -183,18 +209,36 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 	isFresh := fs.filer.LoadConfiguration(v)
 
 	notification.LoadConfiguration(v, "notification.")
+	if index := metadata_index.LoadConfiguration(v, "metadata_index."); index != nil {
+		fs.filer.StartMetadataIndex(index)
+	}
+
+	fs.healthChecks = health.NewChecks()
+	fs.healthChecks.Add("store", fs.checkStoreReady)
+	fs.healthChecks.Add("master", fs.checkMasterReady)
 
 	handleStaticResources(defaultMux)
 	if !option.DisableHttp {
-		defaultMux.HandleFunc("/healthz", fs.filerHealthzHandler)
+		defaultMux.HandleFunc("/healthz", fs.healthChecks.HealthzHandler)
+		defaultMux.HandleFunc("/readyz", fs.healthChecks.ReadyzHandler)
 		defaultMux.HandleFunc("/", fs.filerGuard.WhiteList(fs.filerHandler))
 	}
 	if defaultMux != readonlyMux {
 		handleStaticResources(readonlyMux)
-		readonlyMux.HandleFunc("/healthz", fs.filerHealthzHandler)
+		readonlyMux.HandleFunc("/healthz", fs.healthChecks.HealthzHandler)
+		readonlyMux.HandleFunc("/readyz", fs.healthChecks.ReadyzHandler)
 		readonlyMux.HandleFunc("/", fs.filerGuard.WhiteList(fs.readonlyFilerHandler))
 	}
 
+	fs.filer.LoadDirQuotas()
+	fs.filer.LoadDirTtls()
+	go fs.filer.LoopExpireDirTtls()
+	go fs.filer.LoopReconcileDirStats()
+	go fs.filer.LoopPruneVersions()
+	go fs.filer.LoopPurgeTrash()
+	fs.filer.LoadRenameJournals()
+	go fs.loopResumeRenames()
+
 	existingNodes := fs.filer.ListExistingPeerUpdates(context.Background())
 	startFromTime := time.Now().Add(-filer.LogFlushInterval)
 	if isFresh {
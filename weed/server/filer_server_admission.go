@@ -0,0 +1,231 @@
+package weed_server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+// The filer limits the concurrent list, read, write and delete requests, over both http and gRPC, so a stampede of
+// metadata requests queues up on the filer instead of overloading the filer store. A request over the limit waits
+// in the queue of its type, and is rejected with 429 or RESOURCE_EXHAUSTED when the queue is full,
+// or when it waits longer than the queue timeout. The other requests, e.g., the metadata subscriptions, are not limited.
+
+const (
+	admissionList   = "list"
+	admissionRead   = "read"
+	admissionWrite  = "write"
+	admissionDelete = "delete"
+)
+
+var ErrFilerOverloaded = errors.New("filer is overloaded, retry later")
+
+// FilerAdmissionOption limits the concurrent requests of each type, 0 for unlimited
+type FilerAdmissionOption struct {
+	ListConcurrency   int
+	ReadConcurrency   int
+	WriteConcurrency  int
+	DeleteConcurrency int
+	MaxQueued         int
+	QueueTimeout      time.Duration
+}
+
+// admissionLimit admits a limited number of concurrent requests, and queues a limited number of the others
+type admissionLimit struct {
+	opType       string
+	slots        chan struct{}
+	queued       atomic.Int32
+	maxQueued    int32
+	queueTimeout time.Duration
+}
+
+func newAdmissionLimit(opType string, concurrency, maxQueued int, queueTimeout time.Duration) *admissionLimit {
+	if concurrency <= 0 {
+		return nil
+	}
+	return &admissionLimit{
+		opType:       opType,
+		slots:        make(chan struct{}, concurrency),
+		maxQueued:    int32(maxQueued),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// acquire waits for a slot, and returns ErrFilerOverloaded when the queue is full or the wait times out
+func (l *admissionLimit) acquire(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	release = func() {
+		<-l.slots
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if l.queued.Add(1) > l.maxQueued {
+		l.queued.Add(-1)
+		stats.FilerHandlerCounter.WithLabelValues(l.opType + "." + stats.ErrorOverloaded).Inc()
+		return nil, ErrFilerOverloaded
+	}
+	defer l.queued.Add(-1)
+	queuedGauge := stats.FilerInFlightRequestsGauge.WithLabelValues(l.opType + ".queued")
+	queuedGauge.Inc()
+	defer queuedGauge.Dec()
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		stats.FilerHandlerCounter.WithLabelValues(l.opType + "." + stats.ErrorOverloaded).Inc()
+		return nil, ErrFilerOverloaded
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type filerAdmission struct {
+	limits map[string]*admissionLimit
+}
+
+func newFilerAdmission(option FilerAdmissionOption) *filerAdmission {
+	newLimit := func(opType string, concurrency int) *admissionLimit {
+		return newAdmissionLimit(opType, concurrency, option.MaxQueued, option.QueueTimeout)
+	}
+	return &filerAdmission{
+		limits: map[string]*admissionLimit{
+			admissionList:   newLimit(admissionList, option.ListConcurrency),
+			admissionRead:   newLimit(admissionRead, option.ReadConcurrency),
+			admissionWrite:  newLimit(admissionWrite, option.WriteConcurrency),
+			admissionDelete: newLimit(admissionDelete, option.DeleteConcurrency),
+		},
+	}
+}
+
+func (a *filerAdmission) isLimited() bool {
+	for _, limit := range a.limits {
+		if limit != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *filerAdmission) acquire(ctx context.Context, opType string) (release func(), err error) {
+	return a.limits[opType].acquire(ctx)
+}
+
+// httpAdmissionType classifies the http request, "" if not limited
+func httpAdmissionType(r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		// the directories are usually listed with a trailing slash
+		if strings.HasSuffix(r.URL.Path, "/") {
+			return admissionList
+		}
+		return admissionRead
+	case http.MethodPost, http.MethodPut:
+		return admissionWrite
+	case http.MethodDelete:
+		return admissionDelete
+	}
+	return ""
+}
+
+// admitHttpRequest waits for the request to be admitted, or responds with 429 when the filer is overloaded
+func (fs *FilerServer) admitHttpRequest(w http.ResponseWriter, r *http.Request) (release func(), isAdmitted bool) {
+	release, err := fs.admission.acquire(r.Context(), httpAdmissionType(r))
+	if err != nil {
+		if errors.Is(err, ErrFilerOverloaded) {
+			w.Header().Set("Retry-After", "1")
+			writeJsonError(w, r, http.StatusTooManyRequests, err)
+		}
+		return nil, false
+	}
+	return release, true
+}
+
+// grpcAdmissionTypes classifies the filer gRPC methods touching the filer store, the others are not limited
+var grpcAdmissionTypes = map[string]string{
+	"ListEntries":          admissionList,
+	"ListEntryVersions":    admissionList,
+	"ListTrashEntries":     admissionList,
+	"LookupDirectoryEntry": admissionRead,
+	"GetDirectoryStats":    admissionRead,
+	"KvGet":                admissionRead,
+	"CreateEntry":          admissionWrite,
+	"UpdateEntry":          admissionWrite,
+	"AppendToEntry":        admissionWrite,
+	"AtomicRenameEntry":    admissionWrite,
+	"StreamRenameEntry":    admissionWrite,
+	"RestoreEntryVersion":  admissionWrite,
+	"DeleteEntryVersion":   admissionWrite,
+	"RestoreTrashEntry":    admissionWrite,
+	"KvPut":                admissionWrite,
+	"DeleteEntry":          admissionDelete,
+}
+
+func grpcAdmissionType(fullMethod string) string {
+	return grpcAdmissionTypes[fullMethod[strings.LastIndex(fullMethod, "/")+1:]]
+}
+
+func (a *filerAdmission) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	opType := grpcAdmissionType(info.FullMethod)
+	if opType == "" {
+		return handler(ctx, req)
+	}
+	release, err := a.acquire(ctx, opType)
+	if err != nil {
+		return nil, toAdmissionStatus(err)
+	}
+	defer release()
+	return handler(ctx, req)
+}
+
+func (a *filerAdmission) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	opType := grpcAdmissionType(info.FullMethod)
+	if opType == "" {
+		return handler(srv, ss)
+	}
+	release, err := a.acquire(ss.Context(), opType)
+	if err != nil {
+		return toAdmissionStatus(err)
+	}
+	defer release()
+	return handler(srv, ss)
+}
+
+func toAdmissionStatus(err error) error {
+	if errors.Is(err, ErrFilerOverloaded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.FromContextError(err).Err()
+}
+
+// AdmissionServerOptions limits the concurrent gRPC requests, if any limit is set
+func (fs *FilerServer) AdmissionServerOptions() []grpc.ServerOption {
+	if !fs.admission.isLimited() {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(fs.admission.unaryInterceptor),
+		grpc.ChainStreamInterceptor(fs.admission.streamInterceptor),
+	}
+}
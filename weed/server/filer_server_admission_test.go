@@ -0,0 +1,90 @@
+package weed_server
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdmissionLimit(t *testing.T) {
+	l := newAdmissionLimit(admissionList, 1, 1, 50*time.Millisecond)
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	// the second request is queued, and admitted once the first is released
+	admitted := make(chan error, 1)
+	go func() {
+		queuedRelease, queuedErr := l.acquire(context.Background())
+		if queuedErr == nil {
+			queuedRelease()
+		}
+		admitted <- queuedErr
+	}()
+	for l.queued.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the third request is rejected, since the queue is full
+	if _, err = l.acquire(context.Background()); err != ErrFilerOverloaded {
+		t.Errorf("expected overloaded with a full queue, got %v", err)
+	}
+
+	release()
+	if err = <-admitted; err != nil {
+		t.Errorf("expected the queued request admitted, got %v", err)
+	}
+
+	// the queued request times out
+	release, _ = l.acquire(context.Background())
+	if _, err = l.acquire(context.Background()); err != ErrFilerOverloaded {
+		t.Errorf("expected overloaded after the queue timeout, got %v", err)
+	}
+	release()
+}
+
+func TestAdmissionUnlimited(t *testing.T) {
+	a := newFilerAdmission(FilerAdmissionOption{WriteConcurrency: 1})
+	if !a.isLimited() {
+		t.Errorf("expected limited")
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := a.acquire(context.Background(), admissionList); err != nil {
+			t.Errorf("expected unlimited listing, got %v", err)
+		}
+	}
+	if newFilerAdmission(FilerAdmissionOption{}).isLimited() {
+		t.Errorf("expected unlimited")
+	}
+}
+
+func TestAdmissionTypes(t *testing.T) {
+	for _, tc := range []struct {
+		method, target, opType string
+	}{
+		{"GET", "/dir/", admissionList},
+		{"GET", "/dir/file", admissionRead},
+		{"PUT", "/dir/file", admissionWrite},
+		{"DELETE", "/dir/file", admissionDelete},
+		{"OPTIONS", "/dir/file", ""},
+	} {
+		if opType := httpAdmissionType(httptest.NewRequest(tc.method, tc.target, nil)); opType != tc.opType {
+			t.Errorf("%s %s: expected %q, got %q", tc.method, tc.target, tc.opType, opType)
+		}
+	}
+	if opType := grpcAdmissionType("/filer_pb.SeaweedFiler/ListEntries"); opType != admissionList {
+		t.Errorf("unexpected %q", opType)
+	}
+	if opType := grpcAdmissionType("/filer_pb.SeaweedFiler/SubscribeMetadata"); opType != "" {
+		t.Errorf("unexpected %q", opType)
+	}
+	if code := status.Code(toAdmissionStatus(ErrFilerOverloaded)); code != codes.ResourceExhausted {
+		t.Errorf("unexpected code %v", code)
+	}
+}
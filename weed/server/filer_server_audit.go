@@ -0,0 +1,46 @@
+package weed_server
+
+import (
+	"github.com/seaweedfs/seaweedfs/weed/audit"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc"
+)
+
+// AuditServerOptions audits the filer gRPC methods touching the filer store, if the audit log is enabled
+func (fs *FilerServer) AuditServerOptions() []grpc.ServerOption {
+	operations := make(map[string]bool)
+	for method, opType := range grpcAdmissionTypes {
+		operations[method] = opType == admissionRead || opType == admissionList
+	}
+	return audit.GrpcServerOptions(audit.ServiceFiler, operations, filerAuditPath)
+}
+
+func filerAuditPath(req interface{}) string {
+	switch req := req.(type) {
+	case *filer_pb.CreateEntryRequest:
+		return string(util.NewFullPath(req.Directory, req.GetEntry().GetName()))
+	case *filer_pb.UpdateEntryRequest:
+		return string(util.NewFullPath(req.Directory, req.GetEntry().GetName()))
+	case *filer_pb.AppendToEntryRequest:
+		return string(util.NewFullPath(req.Directory, req.EntryName))
+	case *filer_pb.AtomicRenameEntryRequest:
+		return string(util.NewFullPath(req.OldDirectory, req.OldName)) + " -> " + string(util.NewFullPath(req.NewDirectory, req.NewName))
+	case *filer_pb.StreamRenameEntryRequest:
+		return string(util.NewFullPath(req.OldDirectory, req.OldName)) + " -> " + string(util.NewFullPath(req.NewDirectory, req.NewName))
+	case *filer_pb.ListTrashEntriesRequest:
+		return req.PathPrefix
+	case *filer_pb.RestoreTrashEntryRequest:
+		return req.TrashName
+	case *filer_pb.KvGetRequest, *filer_pb.KvPutRequest:
+		return ""
+	case interface {
+		GetDirectory() string
+		GetName() string
+	}:
+		return string(util.NewFullPath(req.GetDirectory(), req.GetName()))
+	case interface{ GetDirectory() string }:
+		return req.GetDirectory()
+	}
+	return ""
+}
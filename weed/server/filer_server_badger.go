@@ -0,0 +1,8 @@
+//go:build badger
+// +build badger
+
+package weed_server
+
+import (
+	_ "github.com/seaweedfs/seaweedfs/weed/filer/badger"
+)
@@ -0,0 +1,212 @@
+package weed_server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+// The filer limits the http requests of each client, so one misbehaving client can not starve the others. A client is
+// the remote ip, as the white list tells them apart, or the subject of its verified jwt when keyed by identity.
+// Each client has its own request rate, concurrent uploads and upload bandwidth. A request over the rate, or an upload
+// over the concurrent uploads, is rejected with 429 right away, while the upload bandwidth is only slowed down.
+// The clients idle for a while are forgotten.
+
+const (
+	clientLimitKeyByIdentity = "identity"
+
+	clientLimitIdleTimeout   = 5 * time.Minute
+	clientLimitSweepPeriod   = time.Minute
+	clientUploadBurstMinSize = 64 * 1024
+)
+
+var (
+	ErrClientRateLimited   = errors.New("too many requests from this client, retry later")
+	ErrClientUploadLimited = errors.New("too many concurrent uploads from this client, retry later")
+)
+
+// FilerClientLimitOption limits the http requests of each client, 0 for unlimited
+type FilerClientLimitOption struct {
+	KeyBy                string // "ip" or "identity"
+	RequestsPerSecond    float64
+	Burst                int
+	ConcurrentUploads    int
+	UploadBytesPerSecond int64
+}
+
+func (option FilerClientLimitOption) isLimited() bool {
+	return option.RequestsPerSecond > 0 || option.ConcurrentUploads > 0 || option.UploadBytesPerSecond > 0
+}
+
+type clientLimit struct {
+	requests *rate.Limiter
+	upload   *rate.Limiter
+	uploads  int
+	lastSeen time.Time
+}
+
+type filerClientLimits struct {
+	option  FilerClientLimitOption
+	lock    sync.Mutex
+	clients map[string]*clientLimit
+}
+
+func newFilerClientLimits(option FilerClientLimitOption) *filerClientLimits {
+	if !option.isLimited() {
+		return nil
+	}
+	if option.Burst <= 0 {
+		option.Burst = int(option.RequestsPerSecond)
+		if option.Burst < 1 {
+			option.Burst = 1
+		}
+	}
+	l := &filerClientLimits{
+		option:  option,
+		clients: make(map[string]*clientLimit),
+	}
+	go l.loopForgetIdleClients()
+	return l
+}
+
+func (l *filerClientLimits) getOrCreate(key string, now time.Time) *clientLimit {
+	c, found := l.clients[key]
+	if !found {
+		c = &clientLimit{}
+		if l.option.RequestsPerSecond > 0 {
+			c.requests = rate.NewLimiter(rate.Limit(l.option.RequestsPerSecond), l.option.Burst)
+		}
+		if l.option.UploadBytesPerSecond > 0 {
+			burst := int(l.option.UploadBytesPerSecond)
+			if burst < clientUploadBurstMinSize {
+				burst = clientUploadBurstMinSize
+			}
+			c.upload = rate.NewLimiter(rate.Limit(l.option.UploadBytesPerSecond), burst)
+		}
+		l.clients[key] = c
+	}
+	c.lastSeen = now
+	return c
+}
+
+// admit takes one request of the client, and one of its concurrent uploads if it is an upload
+func (l *filerClientLimits) admit(key string, isUpload bool, now time.Time) (c *clientLimit, release func(), retryAfter time.Duration, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	c = l.getOrCreate(key, now)
+	if c.requests != nil {
+		reservation := c.requests.ReserveN(now, 1)
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			return nil, nil, delay, ErrClientRateLimited
+		}
+	}
+	if !isUpload || l.option.ConcurrentUploads <= 0 {
+		return c, func() {}, 0, nil
+	}
+	if c.uploads >= l.option.ConcurrentUploads {
+		return nil, nil, time.Second, ErrClientUploadLimited
+	}
+	c.uploads++
+	return c, func() {
+		l.lock.Lock()
+		c.uploads--
+		c.lastSeen = time.Now()
+		l.lock.Unlock()
+	}, 0, nil
+}
+
+func (l *filerClientLimits) loopForgetIdleClients() {
+	for range time.Tick(clientLimitSweepPeriod) {
+		l.forgetIdleClients(time.Now())
+	}
+}
+
+func (l *filerClientLimits) forgetIdleClients(now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for key, c := range l.clients {
+		if c.uploads == 0 && now.Sub(c.lastSeen) > clientLimitIdleTimeout {
+			delete(l.clients, key)
+		}
+	}
+}
+
+// clientKey is the subject of the verified jwt if keyed by identity, or else the remote ip
+func (fs *FilerServer) clientKey(r *http.Request) string {
+	if fs.clientLimits.option.KeyBy == clientLimitKeyByIdentity {
+		if tokenStr := security.GetJwt(r); tokenStr != "" {
+			for _, signingKey := range []security.SigningKey{fs.filerGuard.SigningKey, fs.filerGuard.ReadSigningKey} {
+				if len(signingKey) == 0 {
+					continue
+				}
+				claims := &security.SeaweedFilerClaims{}
+				if token, err := security.DecodeJwt(signingKey, tokenStr, claims); err == nil && token.Valid && claims.Subject != "" {
+					return "jwt:" + claims.Subject
+				}
+			}
+		}
+	}
+	host, err := security.GetActualRemoteHost(r)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limitHttpClient admits the request of the client, or responds with 429 when the client is over its limits.
+// The body of an admitted upload is read at the upload bandwidth of the client.
+func (fs *FilerServer) limitHttpClient(w http.ResponseWriter, r *http.Request) (release func(), isAdmitted bool) {
+	if fs.clientLimits == nil {
+		return func() {}, true
+	}
+	key := fs.clientKey(r)
+	isUpload := r.Method == http.MethodPost || r.Method == http.MethodPut
+	c, release, retryAfter, err := fs.clientLimits.admit(key, isUpload, time.Now())
+	if err != nil {
+		if errors.Is(err, ErrClientRateLimited) {
+			stats.FilerHandlerCounter.WithLabelValues(stats.ErrorClientRateLimited).Inc()
+		} else {
+			stats.FilerHandlerCounter.WithLabelValues(stats.ErrorClientUploadLimited).Inc()
+		}
+		glog.V(2).Infof("client %s %s %s: %v", key, r.Method, r.URL.Path, err)
+		w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+		writeJsonError(w, r, http.StatusTooManyRequests, err)
+		return nil, false
+	}
+	if isUpload && c.upload != nil && r.Body != nil {
+		r.Body = &clientLimitedReader{ReadCloser: r.Body, ctx: r.Context(), limiter: c.upload}
+	}
+	return release, true
+}
+
+// clientLimitedReader reads the upload no faster than the client is allowed, shared by the uploads of the client
+type clientLimitedReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func (r *clientLimitedReader) Read(p []byte) (n int, err error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err = r.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return
+}
@@ -0,0 +1,108 @@
+package weed_server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientLimitsRequestRate(t *testing.T) {
+	l := newFilerClientLimits(FilerClientLimitOption{RequestsPerSecond: 2})
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if _, _, _, err := l.admit("10.0.0.1", false, now); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	_, _, retryAfter, err := l.admit("10.0.0.1", false, now)
+	if err != ErrClientRateLimited {
+		t.Fatalf("expected rate limited over the burst, got %v", err)
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("unexpected retry after %v", retryAfter)
+	}
+
+	// the other clients are not affected
+	if _, _, _, err = l.admit("10.0.0.2", false, now); err != nil {
+		t.Errorf("expected another client admitted, got %v", err)
+	}
+
+	// the rejected requests do not take the tokens
+	if _, _, _, err = l.admit("10.0.0.1", false, now.Add(500*time.Millisecond)); err != nil {
+		t.Errorf("expected admitted after the refill, got %v", err)
+	}
+}
+
+func TestClientLimitsConcurrentUploads(t *testing.T) {
+	l := newFilerClientLimits(FilerClientLimitOption{ConcurrentUploads: 1})
+	now := time.Now()
+
+	_, release, _, err := l.admit("10.0.0.1", true, now)
+	if err != nil {
+		t.Fatalf("first upload: %v", err)
+	}
+	if _, _, _, err = l.admit("10.0.0.1", true, now); err != ErrClientUploadLimited {
+		t.Errorf("expected upload limited, got %v", err)
+	}
+	if _, _, _, err = l.admit("10.0.0.1", false, now); err != nil {
+		t.Errorf("expected the read admitted, got %v", err)
+	}
+
+	// the client with an upload in flight is not forgotten
+	l.forgetIdleClients(now.Add(2 * clientLimitIdleTimeout))
+	if len(l.clients) != 1 {
+		t.Errorf("expected the uploading client kept, got %d clients", len(l.clients))
+	}
+
+	release()
+	if _, release, _, err = l.admit("10.0.0.1", true, now); err != nil {
+		t.Errorf("expected the upload admitted after release, got %v", err)
+	}
+	release()
+	l.forgetIdleClients(time.Now().Add(2 * clientLimitIdleTimeout))
+	if len(l.clients) != 0 {
+		t.Errorf("expected the idle client forgotten, got %d clients", len(l.clients))
+	}
+}
+
+func TestLimitHttpClient(t *testing.T) {
+	fs := &FilerServer{
+		clientLimits: newFilerClientLimits(FilerClientLimitOption{KeyBy: "ip", RequestsPerSecond: 1, UploadBytesPerSecond: 1024 * 1024}),
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/dir/file", strings.NewReader("hello"))
+	r.RemoteAddr = "10.0.0.1:1234"
+	w := httptest.NewRecorder()
+	release, isAdmitted := fs.limitHttpClient(w, r)
+	if !isAdmitted {
+		t.Fatalf("expected admitted, got %d", w.Code)
+	}
+	if _, ok := r.Body.(*clientLimitedReader); !ok {
+		t.Errorf("expected the upload body limited, got %T", r.Body)
+	}
+	if data, err := io.ReadAll(r.Body); err != nil || string(data) != "hello" {
+		t.Errorf("read limited body: %q %v", data, err)
+	}
+	release()
+
+	r = httptest.NewRequest(http.MethodGet, "/dir/file", nil)
+	r.RemoteAddr = "10.0.0.1:1235"
+	w = httptest.NewRecorder()
+	if _, isAdmitted = fs.limitHttpClient(w, r); isAdmitted {
+		t.Fatalf("expected rate limited")
+	}
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected 429 with Retry-After 1, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// the client is told apart by the forwarded ip
+	r.Header.Set("X-Forwarded-For", "10.0.0.3")
+	w = httptest.NewRecorder()
+	if _, isAdmitted = fs.limitHttpClient(w, r); !isAdmitted {
+		t.Errorf("expected another client admitted, got %d", w.Code)
+	}
+}
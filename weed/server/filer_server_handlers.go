@@ -3,6 +3,7 @@ package weed_server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/seaweedfs/seaweedfs/weed/audit"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -17,6 +19,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/util"
 
 	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"google.golang.org/grpc"
 )
 
 func (fs *FilerServer) filerHandler(w http.ResponseWriter, r *http.Request) {
@@ -72,6 +75,7 @@ func (fs *FilerServer) filerHandler(w http.ResponseWriter, r *http.Request) {
 	defer func(method *string) {
 		stats.FilerRequestCounter.WithLabelValues(*method, strconv.Itoa(statusRecorder.Status)).Inc()
 		stats.FilerRequestHistogram.WithLabelValues(*method).Observe(time.Since(start).Seconds())
+		audit.LogHttpRequest(audit.ServiceFiler, r, "", *method, r.URL.Path, statusRecorder.Status, start)
 	}(&requestMethod)
 
 	isReadHttpCall := r.Method == http.MethodGet || r.Method == http.MethodHead
@@ -82,6 +86,18 @@ func (fs *FilerServer) filerHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Server", "SeaweedFS "+util.VERSION)
 
+	releaseClient, isAdmitted := fs.limitHttpClient(w, r)
+	if !isAdmitted {
+		return
+	}
+	defer releaseClient()
+
+	release, isAdmitted := fs.admitHttpRequest(w, r)
+	if !isAdmitted {
+		return
+	}
+	defer release()
+
 	switch r.Method {
 	case http.MethodGet, http.MethodHead:
 		fs.GetOrHeadHandler(w, r)
@@ -156,6 +172,7 @@ func (fs *FilerServer) readonlyFilerHandler(w http.ResponseWriter, r *http.Reque
 	defer func(method *string) {
 		stats.FilerRequestCounter.WithLabelValues(*method, strconv.Itoa(statusRecorder.Status)).Inc()
 		stats.FilerRequestHistogram.WithLabelValues(*method).Observe(time.Since(start).Seconds())
+		audit.LogHttpRequest(audit.ServiceFiler, r, "", *method, r.URL.Path, statusRecorder.Status, start)
 	}(&requestMethod)
 	// We handle OPTIONS first because it never should be authenticated
 	if r.Method == http.MethodOptions {
@@ -170,6 +187,18 @@ func (fs *FilerServer) readonlyFilerHandler(w http.ResponseWriter, r *http.Reque
 
 	w.Header().Set("Server", "SeaweedFS "+util.VERSION)
 
+	releaseClient, isAdmitted := fs.limitHttpClient(w, r)
+	if !isAdmitted {
+		return
+	}
+	defer releaseClient()
+
+	release, isAdmitted := fs.admitHttpRequest(w, r)
+	if !isAdmitted {
+		return
+	}
+	defer release()
+
 	switch r.Method {
 	case http.MethodGet, http.MethodHead:
 		fs.GetOrHeadHandler(w, r)
@@ -216,6 +245,9 @@ func (fs *FilerServer) maybeCheckJwtAuthorization(r *http.Request, isWrite bool)
 	}
 
 	token, err := security.DecodeJwt(signingKey, tokenStr, &security.SeaweedFilerClaims{})
+	if err != nil && fs.oidc != nil {
+		return fs.oidc.authorize(r, tokenStr, isWrite)
+	}
 	if err != nil {
 		glog.V(1).Infof("jwt verification error from %s: %v", r.RemoteAddr, err)
 		return false
@@ -228,12 +260,24 @@ func (fs *FilerServer) maybeCheckJwtAuthorization(r *http.Request, isWrite bool)
 	}
 }
 
-func (fs *FilerServer) filerHealthzHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Server", "SeaweedFS "+util.VERSION)
-	if _, err := fs.filer.Store.FindEntry(context.Background(), filer.TopicsDir); err != nil && err != filer_pb.ErrNotFound {
-		glog.Warningf("filerHealthzHandler FindEntry: %+v", err)
-		w.WriteHeader(http.StatusServiceUnavailable)
-	} else {
-		w.WriteHeader(http.StatusOK)
+// checkStoreReady checks that the filer store is reachable
+func (fs *FilerServer) checkStoreReady(ctx context.Context) error {
+	if _, err := fs.filer.Store.FindEntry(ctx, filer.TopicsDir); err != nil && err != filer_pb.ErrNotFound {
+		glog.Warningf("checkStoreReady FindEntry: %+v", err)
+		return err
 	}
+	return nil
+}
+
+// checkMasterReady checks that the filer is connected to the master, to assign and look up the volumes
+func (fs *FilerServer) checkMasterReady(ctx context.Context) error {
+	if !fs.filer.MasterClient.IsConnected() {
+		return fmt.Errorf("not connected to any master")
+	}
+	return nil
+}
+
+// RegisterGrpcHealth serves the gRPC health checking, following the readiness checks
+func (fs *FilerServer) RegisterGrpcHealth(grpcS *grpc.Server) {
+	fs.healthChecks.RegisterGrpc(grpcS)
 }
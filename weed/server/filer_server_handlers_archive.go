@@ -0,0 +1,236 @@
+package weed_server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// archiveWriter writes the entries of a directory into one archive format
+type archiveWriter interface {
+	addDirectory(name string, entry *filer.Entry) error
+	addSymlink(name string, entry *filer.Entry) error
+	addFile(name string, entry *filer.Entry, streamFn filer.DoStreamContent) error
+	Close() error
+}
+
+func newArchiveWriter(format string, w io.Writer) (archiveWriter, error) {
+	switch format {
+	case "zip":
+		return &zipArchiveWriter{w: zip.NewWriter(w)}, nil
+	case "tar.gz", "tgz":
+		gw := gzip.NewWriter(w)
+		return &tarArchiveWriter{gw: gw, w: tar.NewWriter(gw)}, nil
+	}
+	return nil, fmt.Errorf("unknown archive format %q, expecting zip or tar.gz", format)
+}
+
+// archiveRootName is the top directory in the archive, also the archive file name without the extension
+func archiveRootName(dir util.FullPath) string {
+	if name := dir.Name(); name != "" {
+		return name
+	}
+	return "root"
+}
+
+func archiveFileName(dir util.FullPath, format string) string {
+	if format == "tgz" {
+		format = "tar.gz"
+	}
+	return archiveRootName(dir) + "." + format
+}
+
+type zipArchiveWriter struct {
+	w *zip.Writer
+}
+
+func (z *zipArchiveWriter) header(name string, entry *filer.Entry) *zip.FileHeader {
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: entry.Mtime,
+	}
+	header.SetMode(entry.Mode)
+	return header
+}
+
+func (z *zipArchiveWriter) addDirectory(name string, entry *filer.Entry) error {
+	header := z.header(name+"/", entry)
+	header.Method = zip.Store
+	_, err := z.w.CreateHeader(header)
+	return err
+}
+
+func (z *zipArchiveWriter) addSymlink(name string, entry *filer.Entry) error {
+	// the symlinks are stored with their targets as the content, as done by the zip tools
+	header := z.header(name, entry)
+	header.SetMode(os.ModeSymlink | entry.Mode.Perm())
+	fw, err := z.w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(fw, entry.SymlinkTarget)
+	return err
+}
+
+func (z *zipArchiveWriter) addFile(name string, entry *filer.Entry, streamFn filer.DoStreamContent) error {
+	fw, err := z.w.CreateHeader(z.header(name, entry))
+	if err != nil {
+		return err
+	}
+	return streamFn(fw)
+}
+
+func (z *zipArchiveWriter) Close() error {
+	return z.w.Close()
+}
+
+type tarArchiveWriter struct {
+	gw *gzip.Writer
+	w  *tar.Writer
+}
+
+func (t *tarArchiveWriter) header(name string, entry *filer.Entry) *tar.Header {
+	return &tar.Header{
+		Name:    name,
+		Mode:    int64(entry.Mode.Perm()),
+		ModTime: entry.Mtime,
+		Uid:     int(entry.Uid),
+		Gid:     int(entry.Gid),
+		Uname:   entry.UserName,
+		Format:  tar.FormatPAX,
+	}
+}
+
+func (t *tarArchiveWriter) addDirectory(name string, entry *filer.Entry) error {
+	header := t.header(name+"/", entry)
+	header.Typeflag = tar.TypeDir
+	return t.w.WriteHeader(header)
+}
+
+func (t *tarArchiveWriter) addSymlink(name string, entry *filer.Entry) error {
+	header := t.header(name, entry)
+	header.Typeflag = tar.TypeSymlink
+	header.Linkname = entry.SymlinkTarget
+	return t.w.WriteHeader(header)
+}
+
+func (t *tarArchiveWriter) addFile(name string, entry *filer.Entry, streamFn filer.DoStreamContent) error {
+	header := t.header(name, entry)
+	header.Typeflag = tar.TypeReg
+	header.Size = int64(entry.Size())
+	if err := t.w.WriteHeader(header); err != nil {
+		return err
+	}
+	return streamFn(t.w)
+}
+
+func (t *tarArchiveWriter) Close() error {
+	if err := t.w.Close(); err != nil {
+		return err
+	}
+	return t.gw.Close()
+}
+
+// archiveHandler streams the directory as a zip or tar.gz archive built on the fly,
+// for "GET /path/to/dir/?archive=zip" or "GET /path/to/dir/?archive=tar.gz".
+// The archive is not buffered, so a failure after the first bytes can only be seen as a truncated archive.
+func (fs *FilerServer) archiveHandler(w http.ResponseWriter, r *http.Request, dir util.FullPath, format string) {
+	aw, err := newArchiveWriter(format, w)
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if format == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+	} else {
+		w.Header().Set("Content-Type", "application/gzip")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, archiveFileName(dir, format)))
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if err = fs.archiveDirectory(r.Context(), aw, dir, archiveRootName(dir)); err != nil {
+		stats.FilerHandlerCounter.WithLabelValues(stats.ErrorReadStream).Inc()
+		glog.Errorf("archive %s: %v", dir, err)
+		return
+	}
+	if err = aw.Close(); err != nil {
+		glog.Errorf("archive %s: %v", dir, err)
+	}
+}
+
+// archiveDirectory adds the directory and everything under it to the archive, with the names under the prefix
+func (fs *FilerServer) archiveDirectory(ctx context.Context, aw archiveWriter, dir util.FullPath, prefix string) error {
+	lastFileName := ""
+	for {
+		entries, hasMore, err := fs.filer.ListDirectoryEntries(ctx, dir, lastFileName, false, filer.PaginationSize, "", "", "")
+		if err != nil {
+			return fmt.Errorf("list %s: %v", dir, err)
+		}
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			name := prefix + "/" + entry.Name()
+			switch {
+			case entry.IsDirectory():
+				if err = aw.addDirectory(name, entry); err != nil {
+					return err
+				}
+				if err = fs.archiveDirectory(ctx, aw, entry.FullPath, name); err != nil {
+					return err
+				}
+			case entry.SymlinkTarget != "":
+				if err = aw.addSymlink(name, entry); err != nil {
+					return err
+				}
+			default:
+				if err = fs.archiveFile(ctx, aw, name, entry); err != nil {
+					return err
+				}
+			}
+		}
+		if !hasMore {
+			return nil
+		}
+	}
+}
+
+func (fs *FilerServer) archiveFile(ctx context.Context, aw archiveWriter, name string, entry *filer.Entry) error {
+	size := int64(entry.Size())
+	if size <= int64(len(entry.Content)) {
+		return aw.addFile(name, entry, func(writer io.Writer) error {
+			_, err := writer.Write(entry.Content[:size])
+			return err
+		})
+	}
+	chunks := entry.GetChunks()
+	if entry.IsInRemoteOnly() {
+		dir, entryName := entry.FullPath.DirAndName()
+		resp, err := fs.CacheRemoteObjectToLocalCluster(ctx, &filer_pb.CacheRemoteObjectToLocalClusterRequest{
+			Directory: dir,
+			Name:      entryName,
+		})
+		if err != nil {
+			return fmt.Errorf("cache %s: %v", entry.FullPath, err)
+		}
+		chunks = resp.Entry.GetChunks()
+	}
+	streamFn, err := filer.PrepareStreamContentWithThrottler(fs.filer.MasterClient, fs.maybeGetVolumeReadJwtAuthorizationToken, chunks, 0, size, fs.option.DownloadMaxBytesPs)
+	if err != nil {
+		return fmt.Errorf("prepare stream content %s: %v", entry.FullPath, err)
+	}
+	return aw.addFile(name, entry, streamFn)
+}
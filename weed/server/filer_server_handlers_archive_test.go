@@ -0,0 +1,122 @@
+package weed_server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+)
+
+func writeTestArchive(t *testing.T, format string) []byte {
+	var buf bytes.Buffer
+	aw, err := newArchiveWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("new %s writer: %v", format, err)
+	}
+	mtime := time.Unix(1700000000, 0)
+	dir := &filer.Entry{FullPath: "/a/b", Attr: filer.Attr{Mtime: mtime, Mode: os.ModeDir | 0755}}
+	file := &filer.Entry{FullPath: "/a/b/f.txt", Attr: filer.Attr{Mtime: mtime, Mode: 0644, FileSize: 5}}
+	link := &filer.Entry{FullPath: "/a/b/l", Attr: filer.Attr{Mtime: mtime, Mode: 0777, SymlinkTarget: "f.txt"}}
+	if err = aw.addDirectory("a/b", dir); err != nil {
+		t.Fatalf("add directory: %v", err)
+	}
+	if err = aw.addFile("a/b/f.txt", file, func(w io.Writer) error {
+		_, err := w.Write([]byte("hello"))
+		return err
+	}); err != nil {
+		t.Fatalf("add file: %v", err)
+	}
+	if err = aw.addSymlink("a/b/l", link); err != nil {
+		t.Fatalf("add symlink: %v", err)
+	}
+	if err = aw.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestZipArchiveWriter(t *testing.T) {
+	data := writeTestArchive(t, "zip")
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("read zip: %v", err)
+	}
+	if len(zr.File) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(zr.File))
+	}
+	if zr.File[0].Name != "a/b/" || !zr.File[0].Mode().IsDir() {
+		t.Errorf("unexpected directory %s %v", zr.File[0].Name, zr.File[0].Mode())
+	}
+	for _, f := range zr.File[1:] {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		switch f.Name {
+		case "a/b/f.txt":
+			if string(content) != "hello" {
+				t.Errorf("unexpected content %q", content)
+			}
+		case "a/b/l":
+			if f.Mode()&os.ModeSymlink == 0 || string(content) != "f.txt" {
+				t.Errorf("unexpected symlink %v %q", f.Mode(), content)
+			}
+		default:
+			t.Errorf("unexpected entry %s", f.Name)
+		}
+	}
+}
+
+func TestTarArchiveWriter(t *testing.T) {
+	data := writeTestArchive(t, "tar.gz")
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read gzip: %v", err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		names = append(names, header.Name)
+		switch header.Typeflag {
+		case tar.TypeReg:
+			content, _ := io.ReadAll(tr)
+			if string(content) != "hello" {
+				t.Errorf("unexpected content %q", content)
+			}
+		case tar.TypeSymlink:
+			if header.Linkname != "f.txt" {
+				t.Errorf("unexpected link target %q", header.Linkname)
+			}
+		}
+	}
+	if len(names) != 3 || names[0] != "a/b/" || names[1] != "a/b/f.txt" || names[2] != "a/b/l" {
+		t.Errorf("unexpected entries %v", names)
+	}
+}
+
+func TestArchiveFileName(t *testing.T) {
+	if name := archiveFileName("/a/b", "tgz"); name != "b.tar.gz" {
+		t.Errorf("unexpected name %s", name)
+	}
+	if name := archiveFileName("/", "zip"); name != "root.zip" {
+		t.Errorf("unexpected name %s", name)
+	}
+	if _, err := newArchiveWriter("rar", io.Discard); err == nil {
+		t.Errorf("expected an error for the unknown format")
+	}
+}
@@ -0,0 +1,32 @@
+package weed_server
+
+import (
+	"net/http"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+type DirStatsResult struct {
+	Directory      string
+	TotalBytes     int64
+	FileCount      int64
+	DirectoryCount int64
+	IsCached       bool
+}
+
+// dirStatsHandler returns the total size and the numbers of the files and directories under the directory,
+// for "GET /path/to/dir/?stats=true", or "GET /path/to/dir/?stats=true&recount=true" to traverse it
+func (fs *FilerServer) dirStatsHandler(w http.ResponseWriter, r *http.Request, dir util.FullPath) {
+	stats, isCached, err := fs.filer.GetDirStats(r.Context(), dir, r.URL.Query().Get("recount") == "true")
+	if err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJsonQuiet(w, r, http.StatusOK, DirStatsResult{
+		Directory:      string(dir),
+		TotalBytes:     stats.Bytes,
+		FileCount:      stats.Files,
+		DirectoryCount: stats.Directories,
+		IsCached:       isCached,
+	})
+}
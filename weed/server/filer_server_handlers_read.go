@@ -96,9 +96,27 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 		path = path[:len(path)-1]
 	}
 
-	entry, err := fs.filer.FindEntry(context.Background(), util.FullPath(path))
+	// the versions are also listed after the file is deleted
+	if r.URL.Query().Get("versions") == "true" {
+		fs.versionsHandler(w, r, util.FullPath(path))
+		return
+	}
+	versionId := r.URL.Query().Get("version")
+	entry, err := fs.findEntryOrVersion(context.Background(), util.FullPath(path), versionId)
 	if err != nil {
 		if path == "/" {
+			if r.URL.Query().Get("stats") == "true" && !fs.option.DisableDirListing {
+				fs.dirStatsHandler(w, r, "/")
+				return
+			}
+			if r.URL.Query().Get("search") == "true" && !fs.option.DisableDirListing {
+				fs.searchHandler(w, r, "/")
+				return
+			}
+			if format := r.URL.Query().Get("archive"); format != "" && !fs.option.DisableDirListing {
+				fs.archiveHandler(w, r, "/", format)
+				return
+			}
 			fs.listDirectoryHandler(w, r)
 			return
 		}
@@ -125,6 +143,18 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 			writeJsonQuiet(w, r, http.StatusOK, entry)
 			return
 		}
+		if query.Get("stats") == "true" {
+			fs.dirStatsHandler(w, r, entry.FullPath)
+			return
+		}
+		if query.Get("search") == "true" {
+			fs.searchHandler(w, r, entry.FullPath)
+			return
+		}
+		if format := query.Get("archive"); format != "" {
+			fs.archiveHandler(w, r, entry.FullPath, format)
+			return
+		}
 		if entry.Attr.Mime == "" || (entry.Attr.Mime == s3_constants.FolderMimeType && r.Header.Get(s3_constants.AmzIdentityId) == "") {
 			// Don't return directory meta if config value is set to true
 			if fs.option.ExposeDirectoryData == false {
@@ -144,6 +174,10 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if versionId != "" {
+		w.Header().Set("Seaweed-Version-Id", versionId)
+	}
+
 	if query.Get("metadata") == "true" {
 		if query.Get("resolveManifest") == "true" {
 			if entry.Chunks, _, err = filer.ResolveChunkManifest(
@@ -222,6 +256,18 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 		w.Header().Set(s3_constants.AmzTagCount, strconv.Itoa(tagCount))
 	}
 
+	// the committed write-once files are shown as locked in the compliance mode, or under a legal hold if retained forever
+	if entry.WORMEnforcedAtTsNs != 0 {
+		if rule := fs.filer.FilerConf.MatchStorageRule(string(entry.FullPath)); rule.Worm {
+			w.Header().Set(s3_constants.AmzObjectLockMode, "COMPLIANCE")
+			if retainUntil, isForever := filer.WormRetainUntil(rule, entry.WORMEnforcedAtTsNs); isForever {
+				w.Header().Set(s3_constants.AmzObjectLockLegalHold, "ON")
+			} else {
+				w.Header().Set(s3_constants.AmzObjectLockRetainUntilDate, retainUntil.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+
 	SetEtag(w, etag)
 
 	filename := entry.Name()
@@ -248,7 +294,10 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
-			rs, _, _ := images.Resized(ext, bytes.NewReader(data), width, height, mode)
+			var rs io.ReadSeeker
+			util.ReadTransformWorkers.Do("resize", func() {
+				rs, _, _ = images.Resized(ext, bytes.NewReader(data), width, height, mode)
+			})
 			io.Copy(w, rs)
 			return
 		}
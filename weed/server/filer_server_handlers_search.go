@@ -0,0 +1,90 @@
+package weed_server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/metadata_index"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+type SearchResult struct {
+	Directory  string
+	Entries    []*filer.Entry
+	NextCursor string `json:"NextCursor,omitempty"`
+}
+
+// searchHandler finds the entries under the directory in the metadata index, for
+// "GET /path/to/dir/?search=true&name=*.jpg&ext=&mime=&minSize=&maxSize=&mtimeAfter=&mtimeBefore=&tag.<key>=<value>&extended.<name>=<value>&filesOnly=true&limit=100&cursor="
+// with the times in unix seconds, and the cursor from the NextCursor of the previous page.
+func (fs *FilerServer) searchHandler(w http.ResponseWriter, r *http.Request, dir util.FullPath) {
+	values := r.URL.Query()
+	query := &metadata_index.Query{
+		Directory:   string(dir),
+		NamePattern: values.Get("name"),
+		Ext:         values.Get("ext"),
+		MimePrefix:  values.Get("mime"),
+		FilesOnly:   values.Get("filesOnly") == "true",
+		Cursor:      values.Get("cursor"),
+	}
+	for _, param := range []struct {
+		name  string
+		value *int64
+	}{
+		{"minSize", &query.MinSize},
+		{"maxSize", &query.MaxSize},
+		{"mtimeAfter", &query.MtimeAfter},
+		{"mtimeBefore", &query.MtimeBefore},
+	} {
+		if s := values.Get(param.name); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				writeJsonError(w, r, http.StatusBadRequest, err)
+				return
+			}
+			*param.value = n
+		}
+	}
+	if s := values.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil {
+			writeJsonError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		query.Limit = limit
+	}
+	for k, v := range values {
+		if tag, found := strings.CutPrefix(k, "tag."); found && len(v) > 0 {
+			if query.Tags == nil {
+				query.Tags = make(map[string]string)
+			}
+			query.Tags[tag] = v[0]
+		}
+		if name, found := strings.CutPrefix(k, "extended."); found && len(v) > 0 {
+			if query.Extended == nil {
+				query.Extended = make(map[string]string)
+			}
+			query.Extended[name] = v[0]
+		}
+	}
+
+	entries, nextCursor, err := fs.filer.SearchMetadata(r.Context(), query)
+	if err == filer.ErrMetadataIndexDisabled {
+		writeJsonError(w, r, http.StatusNotImplemented, err)
+		return
+	}
+	if err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if entries == nil {
+		entries = []*filer.Entry{}
+	}
+	writeJsonQuiet(w, r, http.StatusOK, SearchResult{
+		Directory:  query.Directory,
+		Entries:    entries,
+		NextCursor: nextCursor,
+	})
+}
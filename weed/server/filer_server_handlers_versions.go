@@ -0,0 +1,78 @@
+package weed_server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+type EntryVersionResult struct {
+	VersionId  string
+	ReplacedAt time.Time
+	Mtime      time.Time
+	FileSize   uint64
+	ETag       string
+}
+
+type EntryVersionsResult struct {
+	Path     string
+	Versions []EntryVersionResult // the oldest first
+}
+
+// versionsHandler lists the previous versions of the file, for "GET /path/to/file?versions=true".
+// A version is read by "GET /path/to/file?version=<version id>", also after the file is deleted.
+func (fs *FilerServer) versionsHandler(w http.ResponseWriter, r *http.Request, p util.FullPath) {
+	versions, err := fs.filer.ListVersions(r.Context(), p)
+	if err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	result := EntryVersionsResult{
+		Path:     string(p),
+		Versions: []EntryVersionResult{},
+	}
+	for _, version := range versions {
+		result.Versions = append(result.Versions, EntryVersionResult{
+			VersionId:  version.VersionId,
+			ReplacedAt: time.Unix(0, version.ReplacedAtNs),
+			Mtime:      version.Mtime,
+			FileSize:   version.Size(),
+			ETag:       filer.ETagEntry(version.Entry),
+		})
+	}
+	writeJsonQuiet(w, r, http.StatusOK, result)
+}
+
+// findEntryOrVersion finds the file, or its previous version served as the file itself
+func (fs *FilerServer) findEntryOrVersion(ctx context.Context, p util.FullPath, versionId string) (*filer.Entry, error) {
+	if versionId == "" {
+		return fs.filer.FindEntry(ctx, p)
+	}
+	version, err := fs.filer.FindVersion(ctx, p, versionId)
+	if err != nil {
+		return nil, err
+	}
+	version.FullPath = p
+	return version.Entry, nil
+}
+
+// restoreVersion moves the previous version back as the current file,
+// for "POST /path/to/file?restoreVersion=<version id>"
+func (fs *FilerServer) restoreVersion(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	p := util.FullPath(strings.TrimSuffix(r.URL.Path, "/"))
+	entry, err := fs.filer.RestoreVersion(ctx, p, r.URL.Query().Get("restoreVersion"))
+	if err == filer_pb.ErrNotFound {
+		writeJsonError(w, r, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJsonQuiet(w, r, http.StatusOK, entry)
+}
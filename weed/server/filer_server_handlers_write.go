@@ -11,6 +11,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -116,6 +117,8 @@ func (fs *FilerServer) PostHandler(w http.ResponseWriter, r *http.Request, conte
 
 	if query.Has("mv.from") {
 		fs.move(ctx, w, r, so)
+	} else if query.Has("restoreVersion") {
+		fs.restoreVersion(ctx, w, r)
 	} else {
 		fs.autoChunk(ctx, w, r, contentLength, so)
 	}
@@ -226,11 +229,27 @@ func (fs *FilerServer) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 		writeJsonError(w, r, http.StatusInternalServerError, err)
 		return
 	} else if wormEnforced {
-		writeJsonError(w, r, http.StatusForbidden, errors.New("operation not permitted"))
+		writeJsonError(w, r, http.StatusForbidden, filer.ErrWormEnforced)
 		return
 	}
 
-	err = fs.filer.DeleteEntryMetaAndData(context.Background(), util.FullPath(objectPath), isRecursive, ignoreRecursiveError, !skipChunkDeletion, false, nil, 0)
+	contentAddressedForbidden, err := fs.contentAddressedForbidden(context.TODO(), objectPath)
+	if err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	} else if contentAddressedForbidden {
+		writeJsonError(w, r, http.StatusForbidden, ErrContentAddressedFile)
+		return
+	}
+
+	isTrashed, err := fs.moveToTrash(context.Background(), util.FullPath(objectPath), isRecursive, !skipChunkDeletion, false, nil, 0)
+	if !isTrashed && err == nil {
+		err = fs.filer.DeleteEntryMetaAndData(context.Background(), util.FullPath(objectPath), isRecursive, ignoreRecursiveError, !skipChunkDeletion, false, nil, 0)
+	}
+	if errors.Is(err, filer.ErrWormEnforced) {
+		writeJsonError(w, r, http.StatusForbidden, err)
+		return
+	}
 	if err != nil && err != filer_pb.ErrNotFound {
 		glog.V(1).Infoln("deleting", objectPath, ":", err.Error())
 		writeJsonError(w, r, http.StatusInternalServerError, err)
@@ -267,19 +286,32 @@ func (fs *FilerServer) detectStorageOption(requestURI, qCollection, qReplication
 	}
 
 	return &operation.StorageOption{
-		Replication:       util.Nvl(qReplication, rule.Replication, fs.option.DefaultReplication),
-		Collection:        util.Nvl(qCollection, rule.Collection, bucketDefaultCollection, fs.option.Collection),
-		DataCenter:        util.Nvl(dataCenter, rule.DataCenter, fs.option.DataCenter),
-		Rack:              util.Nvl(rack, rule.Rack, fs.option.Rack),
-		DataNode:          util.Nvl(dataNode, rule.DataNode, fs.option.DataNode),
-		TtlSeconds:        ttlSeconds,
-		DiskType:          util.Nvl(diskType, rule.DiskType),
-		Fsync:             rule.Fsync,
-		VolumeGrowthCount: rule.VolumeGrowthCount,
-		MaxFileNameLength: rule.MaxFileNameLength,
+		Replication:           util.Nvl(qReplication, rule.Replication, fs.option.DefaultReplication),
+		Collection:            util.Nvl(qCollection, rule.Collection, bucketDefaultCollection, fs.option.Collection),
+		DataCenter:            util.Nvl(dataCenter, rule.DataCenter, fs.option.DataCenter),
+		Rack:                  util.Nvl(rack, rule.Rack, fs.option.Rack),
+		DataNode:              util.Nvl(dataNode, rule.DataNode, fs.option.DataNode),
+		TtlSeconds:            ttlSeconds,
+		DiskType:              util.Nvl(diskType, rule.DiskType),
+		Fsync:                 rule.Fsync,
+		VolumeGrowthCount:     rule.VolumeGrowthCount,
+		MaxFileNameLength:     rule.MaxFileNameLength,
+		Compression:           rule.Compression,
+		CompressMimeTypes:     rule.CompressMimeTypes,
+		SkipCompressMimeTypes: rule.SkipCompressMimeTypes,
+		ContentAddressed:      rule.ContentAddressed,
+		LatencyClass:          rule.LatencyClass,
 	}, nil
 }
 
+// maybeInheritDirTtl writes the file without a ttl, neither by the request nor by filer.conf, with the ttl
+// inherited from the directories above, so its chunks are on the volumes of the same ttl
+func (fs *FilerServer) maybeInheritDirTtl(so *operation.StorageOption, path string) {
+	if so.TtlSeconds == 0 {
+		so.TtlSeconds = fs.filer.InheritedTtlSec(util.FullPath(path))
+	}
+}
+
 func (fs *FilerServer) detectStorageOption0(requestURI, qCollection, qReplication string, qTtl string, diskType string, fsync string, dataCenter, rack, dataNode, saveInside string) (*operation.StorageOption, error) {
 
 	ttl, err := needle.ReadTTL(qTtl)
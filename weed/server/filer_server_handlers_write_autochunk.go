@@ -3,6 +3,8 @@ package weed_server
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -42,18 +44,24 @@ func (fs *FilerServer) autoChunk(ctx context.Context, w http.ResponseWriter, r *
 		if r.Header.Get("Content-Type") == "" && strings.HasSuffix(r.URL.Path, "/") {
 			reply, err = fs.mkdir(ctx, w, r, so)
 		} else {
+			fs.maybeInheritDirTtl(so, r.URL.Path)
 			reply, md5bytes, err = fs.doPostAutoChunk(ctx, w, r, chunkSize, contentLength, so)
 		}
 	} else {
+		fs.maybeInheritDirTtl(so, r.URL.Path)
 		reply, md5bytes, err = fs.doPutAutoChunk(ctx, w, r, chunkSize, contentLength, so)
 	}
 	if err != nil {
-		if err.Error() == "operation not permitted" {
+		if errors.Is(err, filer.ErrWormEnforced) || errors.Is(err, ErrContentAddressedFile) {
 			writeJsonError(w, r, http.StatusForbidden, err)
+		} else if errors.Is(err, ErrContentHashMismatch) {
+			writeJsonError(w, r, http.StatusBadRequest, err)
 		} else if strings.HasPrefix(err.Error(), "read input:") || err.Error() == io.ErrUnexpectedEOF.Error() {
 			writeJsonError(w, r, util.HttpStatusCancelled, err)
 		} else if strings.HasSuffix(err.Error(), "is a file") || strings.HasSuffix(err.Error(), "already exists") {
 			writeJsonError(w, r, http.StatusConflict, err)
+		} else if errors.Is(err, filer.ErrDirQuotaExceeded) {
+			writeJsonError(w, r, http.StatusInsufficientStorage, err)
 		} else {
 			writeJsonError(w, r, http.StatusInternalServerError, err)
 		}
@@ -90,6 +98,10 @@ func (fs *FilerServer) doPostAutoChunk(ctx context.Context, w http.ResponseWrite
 		return nil, nil, err
 	}
 
+	if so.ContentAddressed {
+		return fs.doContentAddressedAutoChunk(ctx, w, r, part1, fileName, contentType, chunkSize, contentLength, so)
+	}
+
 	if so.SaveInside {
 		buf := bufPool.Get().(*bytes.Buffer)
 		buf.Reset()
@@ -130,6 +142,11 @@ func (fs *FilerServer) doPutAutoChunk(ctx context.Context, w http.ResponseWriter
 		return nil, nil, err
 	}
 
+	if so.ContentAddressed {
+		// the file name is in the request path, if any
+		return fs.doContentAddressedAutoChunk(ctx, w, r, r.Body, "", contentType, chunkSize, contentLength, so)
+	}
+
 	fileChunks, md5Hash, chunkOffset, err, smallContent := fs.uploadRequestToChunks(w, r, r.Body, chunkSize, fileName, contentType, contentLength, so)
 
 	if err != nil {
@@ -169,7 +186,7 @@ func (fs *FilerServer) checkPermissions(ctx context.Context, r *http.Request, fi
 		return err
 	} else if enforced {
 		// you cannot change a worm file
-		return errors.New("operation not permitted")
+		return filer.ErrWormEnforced
 	}
 
 	return nil
@@ -190,24 +207,7 @@ func (fs *FilerServer) wormEnforcedForEntry(ctx context.Context, fullPath string
 		return false, err
 	}
 
-	// worm is not enforced
-	if entry.WORMEnforcedAtTsNs == 0 {
-		return false, nil
-	}
-
-	// worm will never expire
-	if rule.WormRetentionTimeSeconds == 0 {
-		return true, nil
-	}
-
-	enforcedAt := time.Unix(0, entry.WORMEnforcedAtTsNs)
-
-	// worm is expired
-	if time.Now().Sub(enforcedAt).Seconds() >= float64(rule.WormRetentionTimeSeconds) {
-		return false, nil
-	}
-
-	return true, nil
+	return filer.IsWormEnforced(rule, entry.WORMEnforcedAtTsNs, time.Now()), nil
 }
 
 func (fs *FilerServer) fixFilePath(ctx context.Context, r *http.Request, fileName string) string {
@@ -232,6 +232,11 @@ func (fs *FilerServer) fixFilePath(ctx context.Context, r *http.Request, fileNam
 
 func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileName string, contentType string, so *operation.StorageOption, md5bytes []byte, fileChunks []*filer_pb.FileChunk, chunkOffset int64, content []byte) (filerResult *FilerPostResult, replyerr error) {
 
+	// the object encrypted by the s3 gateway keeps the md5 before encryption as its etag
+	if plaintextMd5, err := base64.StdEncoding.DecodeString(r.Trailer.Get(s3_constants.SeaweedPlaintextMd5Header)); err == nil && len(plaintextMd5) == md5.Size {
+		md5bytes = plaintextMd5
+	}
+
 	// detect file mode
 	modeStr := r.URL.Query().Get("mode")
 	if modeStr == "" {
@@ -461,6 +466,12 @@ func SaveAmzMetaData(r *http.Request, existing map[string][]byte, isReplace bool
 		metadata["Content-Encoding"] = []byte(ce)
 	}
 
+	for _, header := range []string{s3_constants.AmzObjectLockMode, s3_constants.AmzObjectLockRetainUntilDate, s3_constants.AmzObjectLockLegalHold} {
+		if value := r.Header.Get(header); value != "" {
+			metadata[header] = []byte(value)
+		}
+	}
+
 	if tags := r.Header.Get(s3_constants.AmzObjectTagging); tags != "" {
 		for _, v := range strings.Split(tags, "&") {
 			tag := strings.Split(v, "=")
@@ -480,6 +491,23 @@ func SaveAmzMetaData(r *http.Request, existing map[string][]byte, isReplace bool
 		}
 	}
 
+	// the version id of the s3 object in a versioned bucket, generated by the s3 gateway
+	if versionId := r.Header.Get(s3_constants.ExtVersionIdKey); versionId != "" {
+		metadata[s3_constants.ExtVersionIdKey] = []byte(versionId)
+	}
+
+	// the replication status of the s3 object, pending when written to a replicated bucket
+	if status := r.Header.Get(s3_constants.ExtReplicationStatusKey); status != "" {
+		metadata[s3_constants.ExtReplicationStatusKey] = []byte(status)
+	}
+
+	// the server side encryption of the s3 object, encrypted by the s3 gateway
+	for header, values := range r.Header {
+		if strings.HasPrefix(header, s3_constants.ExtSsePrefix) && len(values) > 0 {
+			metadata[header] = []byte(values[0])
+		}
+	}
+
 	//acp-owner
 	acpOwner := r.Header.Get(s3_constants.ExtAmzOwnerKey)
 	if len(acpOwner) > 0 {
@@ -0,0 +1,113 @@
+package weed_server
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var (
+	ErrContentHashMismatch  = errors.New("file name does not match the sha256 of the content")
+	ErrContentAddressedFile = errors.New("content addressed file is immutable")
+)
+
+// doContentAddressedAutoChunk saves the content into a content addressed location, named by the sha256 of the content.
+//
+//	curl -F file=@artifact.tgz "http://localhost:8888/artifacts/"
+//	curl -X PUT -T artifact.tgz "http://localhost:8888/artifacts/<sha256 of artifact.tgz>"
+//
+// Writing the content already saved is a no-op, and the uploaded chunks are deleted.
+func (fs *FilerServer) doContentAddressedAutoChunk(ctx context.Context, w http.ResponseWriter, r *http.Request, reader io.Reader, fileName, contentType string, chunkSize int32, contentLength int64, so *operation.StorageOption) (filerResult *FilerPostResult, md5bytes []byte, replyerr error) {
+
+	if isAppend(r) || r.URL.Query().Has("offset") {
+		return nil, nil, ErrContentAddressedFile
+	}
+
+	dir, name := fs.contentAddressedTarget(ctx, r, fileName)
+
+	// no need to upload the content already saved under this name
+	if name != "" {
+		if existingEntry, findErr := fs.filer.FindEntry(ctx, util.NewFullPath(dir, name)); findErr == nil {
+			return &FilerPostResult{
+				Name: name,
+				Size: int64(existingEntry.FileSize),
+			}, existingEntry.Md5, nil
+		}
+	}
+
+	sha256Hash := sha256.New()
+	fileChunks, md5Hash, chunkOffset, err, smallContent := fs.uploadRequestToChunks(w, r, io.TeeReader(reader, sha256Hash), chunkSize, name, contentType, contentLength, so)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	md5bytes = md5Hash.Sum(nil)
+	contentName := filer.ContentAddressedName(sha256Hash.Sum(nil))
+	if name != "" && name != contentName {
+		fs.filer.DeleteUncommittedChunks(fileChunks)
+		return nil, nil, fmt.Errorf("%w: %s is %s", ErrContentHashMismatch, name, contentName)
+	}
+
+	// the same content is already saved
+	existingEntry, findErr := fs.filer.FindEntry(ctx, util.NewFullPath(dir, contentName))
+	if findErr == nil {
+		fs.filer.DeleteUncommittedChunks(fileChunks)
+		return &FilerPostResult{
+			Name: contentName,
+			Size: int64(existingEntry.FileSize),
+		}, existingEntry.Md5, nil
+	}
+	if findErr != filer_pb.ErrNotFound {
+		fs.filer.DeleteUncommittedChunks(fileChunks)
+		return nil, nil, findErr
+	}
+
+	// saveMetaData puts the named file under the directory of the request path
+	r.URL.Path = strings.TrimSuffix(dir, "/") + "/"
+	filerResult, replyerr = fs.saveMetaData(ctx, r, contentName, contentType, so, md5bytes, fileChunks, chunkOffset, smallContent)
+	if replyerr != nil {
+		fs.filer.DeleteUncommittedChunks(fileChunks)
+	}
+
+	return
+}
+
+// contentAddressedTarget finds the directory to save the content, and the file name if the request names it
+func (fs *FilerServer) contentAddressedTarget(ctx context.Context, r *http.Request, fileName string) (dir, name string) {
+	fullPath := r.URL.Path
+	if strings.HasSuffix(fullPath, "/") {
+		return fullPath, fileName
+	}
+	if possibleDirEntry, findDirErr := fs.filer.FindEntry(ctx, util.FullPath(fullPath)); findDirErr == nil && possibleDirEntry.IsDirectory() {
+		return fullPath, fileName
+	}
+	return util.FullPath(fullPath).DirAndName()
+}
+
+// contentAddressedForbidden checks whether the entry is immutable in a content addressed location
+func (fs *FilerServer) contentAddressedForbidden(ctx context.Context, fullPath string) (bool, error) {
+	rule := fs.filer.FilerConf.MatchStorageRule(fullPath)
+	if !rule.ContentAddressed {
+		return false, nil
+	}
+
+	entry, err := fs.filer.FindEntry(ctx, util.FullPath(fullPath))
+	if err != nil {
+		if errors.Is(err, filer_pb.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return !filer.IsContentAddressedDeletable(rule, entry, time.Now()), nil
+}
@@ -0,0 +1,53 @@
+package weed_server
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// filerOidc accepts the OIDC tokens besides the tokens signed with the filer signing keys,
+// so the users of the identity provider need no filer signing keys
+type filerOidc struct {
+	provider   *security.OidcProvider
+	readRoles  []string // any verified user can read if empty
+	writeRoles []string // any verified user can write if empty
+}
+
+func loadFilerOidc(v *util.ViperProxy) *filerOidc {
+	if !v.GetBool("oidc.filer.enabled") {
+		return nil
+	}
+	provider := security.LoadOidcProvider(v, "oidc.")
+	if provider == nil {
+		glog.Warningf("oidc.filer is enabled, but oidc.issuer is not set")
+		return nil
+	}
+	return &filerOidc{
+		provider:   provider,
+		readRoles:  v.GetStringSlice("oidc.filer.read_roles"),
+		writeRoles: v.GetStringSlice("oidc.filer.write_roles"),
+	}
+}
+
+func (o *filerOidc) authorize(r *http.Request, tokenStr security.EncodedJwt, isWrite bool) bool {
+	user, err := o.provider.Verify(string(tokenStr))
+	if err != nil {
+		glog.V(1).Infof("oidc token from %s: %v", r.RemoteAddr, err)
+		return false
+	}
+	roles := o.readRoles
+	if isWrite {
+		roles = o.writeRoles
+	}
+	if len(roles) == 0 || slices.ContainsFunc(user.Roles, func(role string) bool {
+		return slices.Contains(roles, role)
+	}) {
+		return true
+	}
+	glog.V(1).Infof("oidc user %s with roles %v from %s is not allowed, write: %v", user.Username, user.Roles, r.RemoteAddr, isWrite)
+	return false
+}
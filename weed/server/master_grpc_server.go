@@ -326,6 +326,7 @@ func (ms *MasterServer) KeepConnected(stream master_pb.Seaweed_KeepConnectedServ
 				stats.MasterRaftIsleader.Set(0)
 				stats.MasterAdminLock.Reset()
 				stats.MasterReplicaPlacementMismatch.Reset()
+				stats.MasterVolumeSlotsGauge.Reset()
 				return ms.informNewLeader(stream)
 			} else {
 				stats.MasterRaftIsleader.Set(1)
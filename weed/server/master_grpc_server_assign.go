@@ -42,6 +42,11 @@ func (ms *MasterServer) Assign(ctx context.Context, req *master_pb.AssignRequest
 		return nil, raft.NotLeaderError
 	}
 
+	assignResult := "failure"
+	defer func(start time.Time) {
+		stats.MasterAssignRequestHistogram.WithLabelValues("grpc", assignResult).Observe(time.Since(start).Seconds())
+	}(time.Now())
+
 	if req.Count == 0 {
 		req.Count = 1
 	}
@@ -70,6 +75,12 @@ func (ms *MasterServer) Assign(ctx context.Context, req *master_pb.AssignRequest
 		DataNode:           req.DataNode,
 		MemoryMapMaxSizeMb: req.MemoryMapMaxSizeMb,
 	}
+	if err = topology.ApplyCollectionDiskType(option, req.DiskType); err != nil {
+		return nil, err
+	}
+	if err = ms.Topo.ApplyLatencyClass(option, req.LatencyClass); err != nil {
+		return nil, err
+	}
 
 	if !ms.Topo.DataCenterExists(option.DataCenter) {
 		return nil, fmt.Errorf("data center %v not found in topology", option.DataCenter)
@@ -120,6 +131,7 @@ func (ms *MasterServer) Assign(ctx context.Context, req *master_pb.AssignRequest
 				DataCenter: r.GetDataCenterId(),
 			})
 		}
+		assignResult = "success"
 		return &master_pb.AssignResponse{
 			Fid: fid,
 			Location: &master_pb.Location{
@@ -131,6 +143,7 @@ func (ms *MasterServer) Assign(ctx context.Context, req *master_pb.AssignRequest
 			Count:    count,
 			Auth:     string(security.GenJwtForVolumeServer(ms.guard.SigningKey, ms.guard.ExpiresAfterSec, fid)),
 			Replicas: replicas,
+			DiskType: option.DiskType.ReadableString(),
 		}, nil
 	}
 	if lastErr != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
 )
 
 func (ms *MasterServer) CollectionList(ctx context.Context, req *master_pb.CollectionListRequest) (*master_pb.CollectionListResponse, error) {
@@ -19,8 +20,11 @@ func (ms *MasterServer) CollectionList(ctx context.Context, req *master_pb.Colle
 	resp := &master_pb.CollectionListResponse{}
 	collections := ms.Topo.ListCollections(req.IncludeNormalVolumes, req.IncludeEcVolumes)
 	for _, c := range collections {
+		scheme := topology.EcSchemeOf(c)
 		resp.Collections = append(resp.Collections, &master_pb.Collection{
-			Name: c,
+			Name:           c,
+			EcDataShards:   uint32(scheme.DataShards),
+			EcParityShards: uint32(scheme.ParityShards),
 		})
 	}
 
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/raft"
+	hashicorpRaft "github.com/hashicorp/raft"
+	"github.com/seaweedfs/raft"
 
 	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 )
 
@@ -14,6 +17,25 @@ func (ms *MasterServer) RaftListClusterServers(ctx context.Context, req *master_
 	resp := &master_pb.RaftListClusterServersResponse{}
 
 	ms.Topo.RaftServerAccessLock.RLock()
+	if ms.Topo.RaftServer != nil {
+		defer ms.Topo.RaftServerAccessLock.RUnlock()
+		name, leader := ms.Topo.RaftServer.Name(), ms.Topo.RaftServer.Leader()
+		resp.ClusterServers = append(resp.ClusterServers, &master_pb.RaftListClusterServersResponse_ClusterServers{
+			Id:       name,
+			Address:  pb.ServerAddress(name).ToGrpcAddress(),
+			Suffrage: "Voter",
+			IsLeader: name == leader,
+		})
+		for _, peer := range ms.Topo.RaftServer.Peers() {
+			resp.ClusterServers = append(resp.ClusterServers, &master_pb.RaftListClusterServersResponse_ClusterServers{
+				Id:       peer.Name,
+				Address:  peer.ConnectionString,
+				Suffrage: "Voter",
+				IsLeader: peer.Name == leader,
+			})
+		}
+		return resp, nil
+	}
 	if ms.Topo.HashicorpRaft == nil {
 		ms.Topo.RaftServerAccessLock.RUnlock()
 		return resp, nil
@@ -40,19 +62,36 @@ func (ms *MasterServer) RaftAddServer(ctx context.Context, req *master_pb.RaftAd
 	ms.Topo.RaftServerAccessLock.RLock()
 	defer ms.Topo.RaftServerAccessLock.RUnlock()
 
+	if ms.Topo.RaftServer != nil {
+		if ms.Topo.RaftServer.State() != raft.Leader {
+			return nil, fmt.Errorf("raft add server %s failed: %s is no current leader", req.Id, ms.Topo.RaftServer.Name())
+		}
+		if !req.Voter {
+			return nil, fmt.Errorf("raft add server %s failed: non-voters are only supported by hashicorp raft", req.Id)
+		}
+		if _, err := ms.Topo.RaftServer.Do(&raft.DefaultJoinCommand{
+			Name:             req.Id,
+			ConnectionString: req.Address,
+		}); err != nil {
+			return nil, err
+		}
+		glog.V(0).Infof("raft added server %s %s", req.Id, req.Address)
+		return resp, nil
+	}
+
 	if ms.Topo.HashicorpRaft == nil {
 		return resp, nil
 	}
 
-	if ms.Topo.HashicorpRaft.State() != raft.Leader {
+	if ms.Topo.HashicorpRaft.State() != hashicorpRaft.Leader {
 		return nil, fmt.Errorf("raft add server %s failed: %s is no current leader", req.Id, ms.Topo.HashicorpRaft.String())
 	}
 
-	var idxFuture raft.IndexFuture
+	var idxFuture hashicorpRaft.IndexFuture
 	if req.Voter {
-		idxFuture = ms.Topo.HashicorpRaft.AddVoter(raft.ServerID(req.Id), raft.ServerAddress(req.Address), 0, 0)
+		idxFuture = ms.Topo.HashicorpRaft.AddVoter(hashicorpRaft.ServerID(req.Id), hashicorpRaft.ServerAddress(req.Address), 0, 0)
 	} else {
-		idxFuture = ms.Topo.HashicorpRaft.AddNonvoter(raft.ServerID(req.Id), raft.ServerAddress(req.Address), 0, 0)
+		idxFuture = ms.Topo.HashicorpRaft.AddNonvoter(hashicorpRaft.ServerID(req.Id), hashicorpRaft.ServerAddress(req.Address), 0, 0)
 	}
 
 	if err := idxFuture.Error(); err != nil {
@@ -67,11 +106,13 @@ func (ms *MasterServer) RaftRemoveServer(ctx context.Context, req *master_pb.Raf
 	ms.Topo.RaftServerAccessLock.RLock()
 	defer ms.Topo.RaftServerAccessLock.RUnlock()
 
-	if ms.Topo.HashicorpRaft == nil {
+	if ms.Topo.RaftServer != nil {
+		if ms.Topo.RaftServer.State() != raft.Leader {
+			return nil, fmt.Errorf("raft remove server %s failed: %s is no current leader", req.Id, ms.Topo.RaftServer.Name())
+		}
+	} else if ms.Topo.HashicorpRaft == nil {
 		return resp, nil
-	}
-
-	if ms.Topo.HashicorpRaft.State() != raft.Leader {
+	} else if ms.Topo.HashicorpRaft.State() != hashicorpRaft.Leader {
 		return nil, fmt.Errorf("raft remove server %s failed: %s is no current leader", req.Id, ms.Topo.HashicorpRaft.String())
 	}
 
@@ -84,9 +125,44 @@ func (ms *MasterServer) RaftRemoveServer(ctx context.Context, req *master_pb.Raf
 		}
 	}
 
-	idxFuture := ms.Topo.HashicorpRaft.RemoveServer(raft.ServerID(req.Id), 0, 0)
+	if ms.Topo.RaftServer != nil {
+		if _, err := ms.Topo.RaftServer.Do(&raft.DefaultLeaveCommand{
+			Name: req.Id,
+		}); err != nil {
+			return nil, err
+		}
+		glog.V(0).Infof("raft removed server %s", req.Id)
+		return resp, nil
+	}
+
+	idxFuture := ms.Topo.HashicorpRaft.RemoveServer(hashicorpRaft.ServerID(req.Id), 0, 0)
 	if err := idxFuture.Error(); err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
+
+// RaftSnapshot snapshots the state of this master and compacts its raft log
+func (ms *MasterServer) RaftSnapshot(ctx context.Context, req *master_pb.RaftSnapshotRequest) (*master_pb.RaftSnapshotResponse, error) {
+	resp := &master_pb.RaftSnapshotResponse{}
+
+	ms.Topo.RaftServerAccessLock.RLock()
+	defer ms.Topo.RaftServerAccessLock.RUnlock()
+
+	if ms.Topo.RaftServer != nil {
+		resp.LastIndex = ms.Topo.RaftServer.CommitIndex()
+		if err := ms.Topo.RaftServer.TakeSnapshot(); err != nil {
+			return nil, fmt.Errorf("raft snapshot: %v", err)
+		}
+		return resp, nil
+	}
+	if ms.Topo.HashicorpRaft == nil {
+		return nil, fmt.Errorf("raft snapshot: raft server is not started")
+	}
+
+	if err := ms.Topo.HashicorpRaft.Snapshot().Error(); err != nil && err != hashicorpRaft.ErrNothingNewToSnapshot {
+		return nil, fmt.Errorf("raft snapshot: %v", err)
+	}
+	resp.LastIndex = ms.Topo.HashicorpRaft.AppliedIndex()
+	return resp, nil
+}
@@ -34,13 +34,49 @@ func (ms *MasterServer) DoAutomaticVolumeGrow(req *topology.VolumeGrowRequest) {
 	glog.V(1).Infoln("finished automatic volume grow, cost ", time.Now().Sub(start))
 	if err != nil {
 		glog.V(1).Infof("automatic volume grow failed: %+v", err)
+		stats.MasterVolumeGrowRequestCounter.WithLabelValues(req.Option.Collection, req.Reason, "failure").Inc()
 		return
 	}
+	stats.MasterVolumeGrowRequestCounter.WithLabelValues(req.Option.Collection, req.Reason, "success").Inc()
+	stats.MasterGrownVolumesCounter.WithLabelValues(req.Option.Collection, string(req.Option.DiskType)).Add(float64(len(newVidLocations)))
 	for _, newVidLocation := range newVidLocations {
 		ms.broadcastToClients(&master_pb.KeepConnectedResponse{VolumeLocation: newVidLocation})
 	}
 }
 
+// growVolumesByPolicies grows the collections having fewer writable volumes than their growth policies require
+func (ms *MasterServer) growVolumesByPolicies(ctx context.Context, dcs map[topology.NodeId][]topology.NodeId) {
+	for _, policy := range topology.VolumeGrowPolicies {
+		replication := policy.Replication
+		if replication == "" {
+			replication = ms.option.DefaultReplicaPlacement
+		}
+		replicaPlacement, err := super_block.NewReplicaPlacementFromString(replication)
+		if err != nil {
+			glog.Errorf("volume growth policy of collection %s: %v", policy.Collection, err)
+			continue
+		}
+		vl := ms.Topo.GetVolumeLayout(policy.Collection, replicaPlacement, needle.EMPTY_TTL, types.ToDiskType(policy.DiskType))
+		if vl.HasGrowRequest() {
+			continue
+		}
+		for _, target := range policy.FindGrowTargets(vl, dcs) {
+			stats.MasterVolumeGrowDecisionCounter.WithLabelValues(policy.Collection, policy.DiskType, "policy").Inc()
+			glog.V(0).Infof("grow %d volumes of collection %s dc:%s rack:%s by policy", target.Count, policy.Collection, target.DataCenter, target.Rack)
+			if _, err = ms.VolumeGrow(ctx, &master_pb.VolumeGrowRequest{
+				Collection:          policy.Collection,
+				Replication:         replication,
+				DiskType:            policy.DiskType,
+				DataCenter:          target.DataCenter,
+				Rack:                target.Rack,
+				WritableVolumeCount: target.Count,
+			}); err != nil {
+				glog.V(0).Infof("volume grow request of collection %s dc:%s rack:%s by policy failed: %+v", policy.Collection, target.DataCenter, target.Rack, err)
+			}
+		}
+	}
+}
+
 func (ms *MasterServer) ProcessGrowRequest() {
 	go func() {
 		ctx := context.Background()
@@ -70,11 +106,15 @@ func (ms *MasterServer) ProcessGrowRequest() {
 
 				switch {
 				case mustGrow > 0:
+					stats.MasterVolumeGrowDecisionCounter.WithLabelValues(vlc.Collection, vgr.DiskType, "below_writable").Inc()
 					vgr.WritableVolumeCount = uint32(mustGrow)
 					_, err = ms.VolumeGrow(ctx, vgr)
 				case lastGrowCount > 0 && writable < int(lastGrowCount*2) && float64(crowded+volumeGrowStepCount) > float64(writable)*topology.VolumeGrowStrategy.Threshold:
+					stats.MasterVolumeGrowDecisionCounter.WithLabelValues(vlc.Collection, vgr.DiskType, "crowded").Inc()
 					vgr.WritableVolumeCount = volumeGrowStepCount
 					_, err = ms.VolumeGrow(ctx, vgr)
+				default:
+					stats.MasterVolumeGrowDecisionCounter.WithLabelValues(vlc.Collection, vgr.DiskType, "skip").Inc()
 				}
 				if err != nil {
 					glog.V(0).Infof("volume grow request failed: %+v", err)
@@ -83,6 +123,7 @@ func (ms *MasterServer) ProcessGrowRequest() {
 				for dcId, racks := range dcs {
 					for _, rackId := range racks {
 						if vl.ShouldGrowVolumesByDcAndRack(&writableVolumes, dcId, rackId) {
+							stats.MasterVolumeGrowDecisionCounter.WithLabelValues(vlc.Collection, vgr.DiskType, "dc_rack").Inc()
 							vgr.DataCenter = string(dcId)
 							vgr.Rack = string(rackId)
 							if lastGrowCount > 0 {
@@ -98,6 +139,7 @@ func (ms *MasterServer) ProcessGrowRequest() {
 					}
 				}
 			}
+			ms.growVolumesByPolicies(ctx, dcs)
 		}
 	}()
 	go func() {
@@ -113,6 +155,7 @@ func (ms *MasterServer) ProcessGrowRequest() {
 
 			if !ms.Topo.IsLeader() {
 				//discard buffered requests
+				stats.MasterVolumeGrowRequestCounter.WithLabelValues(option.Collection, req.Reason, "not_leader").Inc()
 				time.Sleep(time.Second * 1)
 				vl.DoneGrowRequest()
 				continue
@@ -131,6 +174,7 @@ func (ms *MasterServer) ProcessGrowRequest() {
 			// not atomic but it's okay
 			if found || (!req.Force && !vl.ShouldGrowVolumes()) {
 				glog.V(4).Infoln("discard volume grow request")
+				stats.MasterVolumeGrowRequestCounter.WithLabelValues(option.Collection, req.Reason, "discarded").Inc()
 				time.Sleep(time.Millisecond * 211)
 				vl.DoneGrowRequest()
 				continue
@@ -224,6 +268,8 @@ func (ms *MasterServer) VolumeList(ctx context.Context, req *master_pb.VolumeLis
 	resp := &master_pb.VolumeListResponse{
 		TopologyInfo:      ms.Topo.ToTopologyInfo(),
 		VolumeSizeLimitMb: uint64(ms.option.VolumeSizeLimitMB),
+		MaxVolumeId:       uint32(ms.Topo.GetMaxVolumeId()),
+		MaxFileKey:        ms.Topo.Sequence.Peek(),
 	}
 
 	return resp, nil
@@ -245,7 +291,7 @@ func (ms *MasterServer) LookupEcVolume(ctx context.Context, req *master_pb.Looku
 
 	resp.VolumeId = req.VolumeId
 
-	for shardId, shardLocations := range ecLocations.Locations {
+	for shardId, shardLocations := range ecLocations.Locations[:ecLocations.Scheme.TotalShards()] {
 		var locations []*master_pb.Location
 		for _, dn := range shardLocations {
 			locations = append(locations, &master_pb.Location{
@@ -344,6 +390,9 @@ func (ms *MasterServer) VolumeGrow(ctx context.Context, req *master_pb.VolumeGro
 		DataNode:           req.DataNode,
 		MemoryMapMaxSizeMb: req.MemoryMapMaxSizeMb,
 	}
+	if err = topology.ApplyCollectionDiskType(&volumeGrowOption, req.DiskType); err != nil {
+		return nil, err
+	}
 	volumeGrowRequest := topology.VolumeGrowRequest{
 		Option: &volumeGrowOption,
 		Count:  req.WritableVolumeCount,
@@ -27,8 +27,10 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/sequence"
 	"github.com/seaweedfs/seaweedfs/weed/shell"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
 	"github.com/seaweedfs/seaweedfs/weed/topology"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/health"
 	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
 	"github.com/seaweedfs/seaweedfs/weed/wdclient"
 )
@@ -76,6 +78,10 @@ type MasterServer struct {
 	adminLocks *AdminLocks
 
 	Cluster *cluster.Cluster
+
+	healthChecks *health.Checks
+
+	volumeHeats *topology.VolumeHeatTracker
 }
 
 func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]pb.ServerAddress) *MasterServer {
@@ -102,6 +108,12 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]pb.Se
 	topology.VolumeGrowStrategy.Copy3Count = v.GetUint32("master.volume_growth.copy_3")
 	topology.VolumeGrowStrategy.CopyOtherCount = v.GetUint32("master.volume_growth.copy_other")
 	topology.VolumeGrowStrategy.Threshold = v.GetFloat64("master.volume_growth.threshold")
+	loadVolumeGrowPolicies(v)
+	loadEcSchemes(v)
+	loadLatencyClasses(v)
+	loadPlacementPolicy(v)
+	loadVolumeTieringPolicies(v)
+	loadCollectionDiskTypes(v)
 	whiteList := util.StringSplit(v.GetString("guard.white_list"), ",")
 
 	var preallocateSize int64
@@ -119,6 +131,7 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]pb.Se
 		MasterClient:            wdclient.NewMasterClient(grpcDialOption, "", cluster.MasterType, option.Master, "", "", *pb.NewServiceDiscoveryFromMap(peers)),
 		adminLocks:              NewAdminLocks(),
 		Cluster:                 cluster.NewCluster(),
+		volumeHeats:             topology.NewVolumeHeatTracker(),
 	}
 
 	ms.MasterClient.SetOnPeerUpdateFn(ms.OnPeerUpdate)
@@ -133,7 +146,13 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]pb.Se
 
 	ms.guard = security.NewGuard(append(ms.option.WhiteList, whiteList...), signingKey, expiresAfterSec, readSigningKey, readExpiresAfterSec)
 
+	ms.healthChecks = health.NewChecks()
+	ms.healthChecks.Add("raft", ms.checkRaftReady)
+
 	handleStaticResources2(r)
+	r.HandleFunc("/healthz", ms.healthChecks.HealthzHandler)
+	r.HandleFunc("/readyz", ms.healthChecks.ReadyzHandler)
+	r.HandleFunc("/cluster/health", ms.proxyToLeader(ms.guard.WhiteList(ms.clusterHealthHandler)))
 	r.HandleFunc("/", ms.proxyToLeader(ms.uiStatusHandler))
 	r.HandleFunc("/ui/index.html", ms.uiStatusHandler)
 	if !ms.option.DisableHttp {
@@ -163,6 +182,7 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]pb.Se
 	)
 
 	ms.ProcessGrowRequest()
+	ms.startVolumeTiering()
 
 	if !option.IsFollower {
 		ms.startAdminScripts()
@@ -183,6 +203,7 @@ func (ms *MasterServer) SetRaftServer(raftServer *RaftServer) {
 			if ms.Topo.RaftServer.Leader() != "" {
 				glog.V(0).Infof("[%s] %s becomes leader.", ms.Topo.RaftServer.Name(), ms.Topo.RaftServer.Leader())
 				ms.Topo.LastLeaderChangeTime = time.Now()
+				stats.MasterLeaderChangeTimestampGauge.Set(float64(ms.Topo.LastLeaderChangeTime.Unix()))
 			}
 		})
 		raftServerName = fmt.Sprintf("[%s]", ms.Topo.RaftServer.Name())
@@ -190,6 +211,7 @@ func (ms *MasterServer) SetRaftServer(raftServer *RaftServer) {
 		ms.Topo.HashicorpRaft = raftServer.RaftHashicorp
 		raftServerName = ms.Topo.HashicorpRaft.String()
 		ms.Topo.LastLeaderChangeTime = time.Now()
+		stats.MasterLeaderChangeTimestampGauge.Set(float64(ms.Topo.LastLeaderChangeTime.Unix()))
 	}
 	ms.Topo.RaftServerAccessLock.Unlock()
 
@@ -416,3 +438,126 @@ func (ms *MasterServer) Reload() {
 		util.StringSplit(v.GetString("guard.white_list"), ",")...),
 	)
 }
+
+func loadLatencyClasses(v *util.ViperProxy) {
+	for name := range v.GetStringMap("master.latency_class") {
+		prefix := "master.latency_class." + name + "."
+		class := &topology.LatencyClass{
+			Name:                name,
+			DiskTypes:           v.GetStringSlice(prefix + "disk_types"),
+			PreferLightlyLoaded: v.GetBool(prefix + "prefer_lightly_loaded"),
+		}
+		topology.LatencyClasses[name] = class
+		glog.V(0).Infof("latency class %+v", class)
+	}
+}
+
+func loadPlacementPolicy(v *util.ViperProxy) {
+	placement, err := topology.ParsePlacementPolicy(
+		v.GetStringSlice("master.placement.weights"),
+		v.GetStringSlice("master.placement.zones"),
+		v.GetBool("master.placement.spread_zones"),
+	)
+	if err != nil {
+		glog.Fatalf("placement policy: %v", err)
+	}
+	topology.Placement = placement
+	if len(placement.Weights) > 0 || len(placement.Zones) > 0 {
+		glog.V(0).Infof("placement policy %+v", placement)
+	}
+}
+
+func loadVolumeGrowPolicies(v *util.ViperProxy) {
+	// viper lower cases the keys, so are the collection names
+	for collection := range v.GetStringMap("master.volume_growth.collection") {
+		prefix := "master.volume_growth.collection." + collection + "."
+		if !v.GetBool(prefix + "enabled") {
+			continue
+		}
+		policy := &topology.VolumeGrowthPolicy{
+			Collection:  collection,
+			MinWritable: v.GetInt(prefix + "min_writable"),
+			GrowCount:   v.GetUint32(prefix + "grow_count"),
+			Replication: v.GetString(prefix + "replication"),
+			DiskType:    v.GetString(prefix + "disk"),
+			Spread:      v.GetString(prefix + "spread"),
+			DataCenters: v.GetStringSlice(prefix + "data_centers"),
+		}
+		if err := policy.Validate(); err != nil {
+			glog.Fatalf("volume growth policy: %v", err)
+		}
+		topology.VolumeGrowPolicies[collection] = policy
+		glog.V(0).Infof("volume growth policy %+v", policy)
+	}
+}
+
+func loadCollectionDiskTypes(v *util.ViperProxy) {
+	diskTypes, err := topology.ParseCollectionDiskTypes(v.GetStringSlice("master.storage_class.collections"))
+	if err != nil {
+		glog.Fatalf("storage class: %v", err)
+	}
+	topology.CollectionDiskTypes = diskTypes
+	for collection, diskType := range diskTypes {
+		glog.V(0).Infof("collection %s requires disk type %s", collection, diskType.ReadableString())
+	}
+}
+
+func loadVolumeTieringPolicies(v *util.ViperProxy) {
+	// viper lower cases the keys, so are the collection names
+	for collection := range v.GetStringMap("master.tiering.collection") {
+		prefix := "master.tiering.collection." + collection + "."
+		if !v.GetBool(prefix + "enabled") {
+			continue
+		}
+		v.SetDefault(prefix+"full_percent", 95)
+		policy := &topology.VolumeTieringPolicy{
+			Collection:       collection,
+			Backend:          v.GetString(prefix + "backend"),
+			ColdAfter:        v.GetDuration(prefix + "cold_after"),
+			FullPercent:      v.GetFloat64(prefix + "full_percent"),
+			HotReadsPerHour:  v.GetFloat64(prefix + "hot_reads_per_hour"),
+			KeepLocalDatFile: v.GetBool(prefix + "keep_local_dat_file"),
+		}
+		if err := policy.Validate(); err != nil {
+			glog.Fatalf("volume tiering policy: %v", err)
+		}
+		topology.VolumeTieringPolicies[collection] = policy
+		glog.V(0).Infof("volume tiering policy %+v", policy)
+	}
+}
+
+func loadEcSchemes(v *util.ViperProxy) {
+	// viper lower cases the keys, so are the collection names
+	for collection := range v.GetStringMap("master.erasure_coding.collection") {
+		prefix := "master.erasure_coding.collection." + collection + "."
+		if !v.GetBool(prefix + "enabled") {
+			continue
+		}
+		scheme := erasure_coding.Scheme{
+			DataShards:   v.GetInt(prefix + "data_shards"),
+			ParityShards: v.GetInt(prefix + "parity_shards"),
+		}
+		if err := scheme.Validate(); err != nil {
+			glog.Fatalf("collection %s: %v", collection, err)
+		}
+		topology.EcSchemes[collection] = scheme
+		glog.V(0).Infof("collection %s erasure coding scheme %s", collection, scheme)
+	}
+}
+
+// checkRaftReady checks that the master has joined the raft cluster, and knows the leader
+func (ms *MasterServer) checkRaftReady(ctx context.Context) error {
+	leader, err := ms.Topo.MaybeLeader()
+	if err != nil {
+		return err
+	}
+	if leader == "" {
+		return fmt.Errorf("raft leader not selected yet")
+	}
+	return nil
+}
+
+// RegisterGrpcHealth serves the gRPC health checking, following the readiness checks
+func (ms *MasterServer) RegisterGrpcHealth(grpcS *grpc.Server) {
+	ms.healthChecks.RegisterGrpc(grpcS)
+}
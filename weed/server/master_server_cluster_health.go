@@ -0,0 +1,360 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/mq/topic"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/util/health"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+const (
+	// the disks with less free space are reported
+	defaultDiskWarningPercentFree  = 10
+	defaultDiskCriticalPercentFree = 5
+	// the nodes checked at the same time
+	clusterHealthConcurrency = 16
+	// the volume ids listed in one issue
+	maxListedVolumeIds = 20
+)
+
+type clusterHealthOptions struct {
+	filerGroup              string
+	diskWarningPercentFree  float64
+	diskCriticalPercentFree float64
+}
+
+// clusterHealthHandler reports the health of the volume servers, the filers, and the MQ brokers as JSON,
+// with the status 503 if any issue is critical
+func (ms *MasterServer) clusterHealthHandler(w http.ResponseWriter, r *http.Request) {
+	option := clusterHealthOptions{
+		filerGroup:              r.FormValue("filerGroup"),
+		diskWarningPercentFree:  defaultDiskWarningPercentFree,
+		diskCriticalPercentFree: defaultDiskCriticalPercentFree,
+	}
+	for name, value := range map[string]*float64{
+		"diskWarningPercentFree":  &option.diskWarningPercentFree,
+		"diskCriticalPercentFree": &option.diskCriticalPercentFree,
+	} {
+		if s := r.FormValue(name); s != "" {
+			parsed, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("parse %s=%s: %v", name, s, err))
+				return
+			}
+			*value = parsed
+		}
+	}
+
+	report := ms.collectClusterHealth(r.Context(), option)
+	status := http.StatusOK
+	if report.Status == cluster.HealthCritical {
+		status = http.StatusServiceUnavailable
+	}
+	writeJsonQuiet(w, r, status, report)
+}
+
+func (ms *MasterServer) collectClusterHealth(ctx context.Context, option clusterHealthOptions) *cluster.HealthReport {
+	report := cluster.NewHealthReport()
+	var reportLock sync.Mutex
+	var wg sync.WaitGroup
+	limiter := make(chan struct{}, clusterHealthConcurrency)
+	checkNode := func(fn func(report *cluster.HealthReport)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter <- struct{}{}
+			defer func() { <-limiter }()
+			// each check adds to its own report, merged into the final report
+			nodeReport := cluster.NewHealthReport()
+			fn(nodeReport)
+			reportLock.Lock()
+			mergeHealthReport(report, nodeReport)
+			reportLock.Unlock()
+		}()
+	}
+
+	topologyInfo := ms.Topo.ToTopologyInfo()
+	eachDataNode(topologyInfo, func(dn *master_pb.DataNodeInfo) {
+		checkNode(func(report *cluster.HealthReport) {
+			ms.checkVolumeServerHealth(ctx, report, dn, option)
+		})
+	})
+	for _, node := range ms.Cluster.ListClusterNode(cluster.FilerGroupName(option.filerGroup), cluster.FilerType) {
+		address := string(node.Address)
+		checkNode(func(report *cluster.HealthReport) {
+			report.AddNode(cluster.FilerType, address, checkReadyz(ctx, address))
+		})
+	}
+	var brokers []string
+	for _, node := range ms.Cluster.ListClusterNode(cluster.FilerGroupName(option.filerGroup), cluster.BrokerType) {
+		brokers = append(brokers, string(node.Address))
+	}
+	readyBrokers := make(map[string]bool)
+	for _, broker := range brokers {
+		broker := broker
+		checkNode(func(report *cluster.HealthReport) {
+			err := ms.checkBrokerReady(ctx, broker)
+			report.AddNode(cluster.BrokerType, broker, err)
+			if err == nil {
+				reportLock.Lock()
+				readyBrokers[broker] = true
+				reportLock.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	checkVolumeFreeSlots(report, topologyInfo)
+	checkVolumeReplicas(report, topologyInfo)
+	checkEcShards(report, topologyInfo)
+	ms.checkTopicPartitions(ctx, report, brokers, readyBrokers)
+
+	report.Sort()
+	return report
+}
+
+func mergeHealthReport(report, other *cluster.HealthReport) {
+	report.Nodes = append(report.Nodes, other.Nodes...)
+	for _, issue := range other.Issues {
+		report.AddIssue(issue.Status, issue.Kind, issue.Node, "%s", issue.Message)
+	}
+}
+
+func eachDataNode(topologyInfo *master_pb.TopologyInfo, fn func(dn *master_pb.DataNodeInfo)) {
+	for _, dc := range topologyInfo.DataCenterInfos {
+		for _, rack := range dc.RackInfos {
+			for _, dn := range rack.DataNodeInfos {
+				fn(dn)
+			}
+		}
+	}
+}
+
+func (ms *MasterServer) checkVolumeServerHealth(ctx context.Context, report *cluster.HealthReport, dn *master_pb.DataNodeInfo, option clusterHealthOptions) {
+	address := pb.NewServerAddressFromDataNode(dn)
+	report.AddNode(cluster.VolumeServerType, dn.Id, checkReadyz(ctx, address.ToHttpAddress()))
+
+	statusCtx, cancel := context.WithTimeout(ctx, health.CheckTimeout)
+	defer cancel()
+	err := operation.WithVolumeServerClient(false, address, ms.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+		resp, err := client.VolumeServerStatus(statusCtx, &volume_server_pb.VolumeServerStatusRequest{})
+		if err != nil {
+			return err
+		}
+		for _, disk := range resp.DiskStatuses {
+			percentFree := float64(disk.PercentFree)
+			if percentFree < option.diskCriticalPercentFree {
+				report.AddIssue(cluster.HealthCritical, cluster.IssueDiskPressure, dn.Id, "disk %s on %s has %.1f%% free space", disk.Dir, dn.Id, percentFree)
+			} else if percentFree < option.diskWarningPercentFree {
+				report.AddIssue(cluster.HealthWarning, cluster.IssueDiskPressure, dn.Id, "disk %s on %s has %.1f%% free space", disk.Dir, dn.Id, percentFree)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		report.AddIssue(cluster.HealthWarning, cluster.IssueDiskPressure, dn.Id, "get the disk status of %s: %v", dn.Id, err)
+	}
+}
+
+// checkReadyz checks the readiness probe of the server at the http address
+func checkReadyz(ctx context.Context, address string) error {
+	ctx, cancel := context.WithTimeout(ctx, health.CheckTimeout)
+	defer cancel()
+	url, err := util_http.NormalizeUrl(fmt.Sprintf("http://%s/readyz", address))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := util_http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer util_http.CloseResponse(resp)
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var failures []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.HasPrefix(line, "[-]") {
+			failures = append(failures, strings.TrimPrefix(line, "[-]"))
+		}
+	}
+	if len(failures) == 0 {
+		return fmt.Errorf("readyz status %d", resp.StatusCode)
+	}
+	return fmt.Errorf("%s", strings.Join(failures, "; "))
+}
+
+func (ms *MasterServer) checkBrokerReady(ctx context.Context, broker string) error {
+	ctx, cancel := context.WithTimeout(ctx, health.CheckTimeout)
+	defer cancel()
+	return pb.WithBrokerGrpcClient(false, broker, ms.grpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
+		_, err := client.FindBrokerLeader(ctx, &mq_pb.FindBrokerLeaderRequest{})
+		return err
+	})
+}
+
+// checkVolumeFreeSlots reports the disk types without any free volume slot, where no volume can be created
+func checkVolumeFreeSlots(report *cluster.HealthReport, topologyInfo *master_pb.TopologyInfo) {
+	var diskTypes []string
+	for diskType := range topologyInfo.DiskInfos {
+		diskTypes = append(diskTypes, diskType)
+	}
+	sort.Strings(diskTypes)
+	for _, diskType := range diskTypes {
+		diskInfo := topologyInfo.DiskInfos[diskType]
+		if diskInfo.MaxVolumeCount > 0 && diskInfo.FreeVolumeCount <= 0 {
+			report.AddIssue(cluster.HealthWarning, cluster.IssueNoFreeVolumeSlot, "", "no free volume slot for the disk type %q, %d volumes of %d", diskType, diskInfo.VolumeCount, diskInfo.MaxVolumeCount)
+		}
+	}
+}
+
+// checkVolumeReplicas reports the volumes with fewer replicas than their replica placements
+func checkVolumeReplicas(report *cluster.HealthReport, topologyInfo *master_pb.TopologyInfo) {
+	replicas := make(map[uint32]int)
+	copies := make(map[uint32]int)
+	eachDataNode(topologyInfo, func(dn *master_pb.DataNodeInfo) {
+		for _, diskInfo := range dn.DiskInfos {
+			for _, v := range diskInfo.VolumeInfos {
+				replicas[v.Id]++
+				if rp, err := super_block.NewReplicaPlacementFromByte(byte(v.ReplicaPlacement)); err == nil {
+					copies[v.Id] = rp.GetCopyCount()
+				}
+			}
+		}
+	})
+	var underReplicated []uint32
+	for vid, count := range replicas {
+		if count < copies[vid] {
+			underReplicated = append(underReplicated, vid)
+		}
+	}
+	if len(underReplicated) > 0 {
+		report.AddIssue(cluster.HealthWarning, cluster.IssueUnderReplicated, "", "%d volumes have fewer replicas than the replica placements: %s", len(underReplicated), listVolumeIds(underReplicated))
+	}
+}
+
+// checkEcShards reports the erasure coded volumes with missing shards, which are critical if not recoverable
+func checkEcShards(report *cluster.HealthReport, topologyInfo *master_pb.TopologyInfo) {
+	shards := make(map[uint32]erasure_coding.ShardBits)
+	schemes := make(map[uint32]erasure_coding.Scheme)
+	eachDataNode(topologyInfo, func(dn *master_pb.DataNodeInfo) {
+		for _, diskInfo := range dn.DiskInfos {
+			for _, ecShardInfo := range diskInfo.EcShardInfos {
+				shards[ecShardInfo.Id] = shards[ecShardInfo.Id].Plus(erasure_coding.ShardBits(ecShardInfo.EcIndexBits))
+				schemes[ecShardInfo.Id] = erasure_coding.NewScheme(ecShardInfo.DataShards, ecShardInfo.ParityShards)
+			}
+		}
+	})
+	var missing, unrecoverable []uint32
+	for vid, shardBits := range shards {
+		scheme := schemes[vid]
+		if count := shardBits.ShardIdCount(); count < scheme.DataShards {
+			unrecoverable = append(unrecoverable, vid)
+		} else if count < scheme.TotalShards() {
+			missing = append(missing, vid)
+		}
+	}
+	if len(unrecoverable) > 0 {
+		report.AddIssue(cluster.HealthCritical, cluster.IssueMissingEcShards, "", "%d erasure coded volumes have fewer shards than the data shards: %s", len(unrecoverable), listVolumeIds(unrecoverable))
+	}
+	if len(missing) > 0 {
+		report.AddIssue(cluster.HealthWarning, cluster.IssueMissingEcShards, "", "%d erasure coded volumes have missing shards: %s", len(missing), listVolumeIds(missing))
+	}
+}
+
+func listVolumeIds(vids []uint32) string {
+	sort.Slice(vids, func(i, j int) bool { return vids[i] < vids[j] })
+	var sb strings.Builder
+	for i, vid := range vids {
+		if i == maxListedVolumeIds {
+			fmt.Fprintf(&sb, " ...")
+			break
+		}
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(strconv.FormatUint(uint64(vid), 10))
+	}
+	return sb.String()
+}
+
+// checkTopicPartitions looks up the partitions of all topics from a ready broker
+func (ms *MasterServer) checkTopicPartitions(ctx context.Context, report *cluster.HealthReport, brokers []string, readyBrokers map[string]bool) {
+	for _, broker := range brokers {
+		if !readyBrokers[broker] {
+			continue
+		}
+		lookupCtx, cancel := context.WithTimeout(ctx, health.CheckTimeout)
+		err := pb.WithBrokerGrpcClient(false, broker, ms.grpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
+			topics, err := client.ListTopics(lookupCtx, &mq_pb.ListTopicsRequest{})
+			if err != nil {
+				return err
+			}
+			for _, t := range topics.Topics {
+				resp, err := client.LookupTopicBrokers(lookupCtx, &mq_pb.LookupTopicBrokersRequest{Topic: t})
+				if err != nil {
+					report.AddIssue(cluster.HealthCritical, cluster.IssueUnassignedPartition, "", "look up the partitions of topic %s: %v", topic.FromPbTopic(t), err)
+					continue
+				}
+				checkPartitionAssignments(report, topic.FromPbTopic(t).String(), resp, readyBrokers)
+			}
+			return nil
+		})
+		cancel()
+		if err == nil {
+			return
+		}
+	}
+}
+
+// checkPartitionAssignments reports the partitions without ready leader brokers, and the key ranges not covered by any partition
+func checkPartitionAssignments(report *cluster.HealthReport, topicName string, resp *mq_pb.LookupTopicBrokersResponse, readyBrokers map[string]bool) {
+	assignments := append([]*mq_pb.BrokerPartitionAssignment(nil), resp.BrokerPartitionAssignments...)
+	sort.Slice(assignments, func(i, j int) bool {
+		return assignments[i].Partition.GetRangeStart() < assignments[j].Partition.GetRangeStart()
+	})
+	ringSize := resp.RingSize
+	var covered int32
+	for _, assignment := range assignments {
+		partition := assignment.Partition
+		if ringSize == 0 {
+			ringSize = partition.GetRingSize()
+		}
+		if partition.GetRangeStart() > covered {
+			report.AddIssue(cluster.HealthCritical, cluster.IssuePartitionGap, "", "topic %s has no partition for the key range [%d,%d)", topicName, covered, partition.GetRangeStart())
+		}
+		if partition.GetRangeStop() > covered {
+			covered = partition.GetRangeStop()
+		}
+		switch {
+		case assignment.LeaderBroker == "":
+			report.AddIssue(cluster.HealthCritical, cluster.IssueUnassignedPartition, "", "topic %s partition [%d,%d) has no leader broker", topicName, partition.GetRangeStart(), partition.GetRangeStop())
+		case !readyBrokers[assignment.LeaderBroker]:
+			report.AddIssue(cluster.HealthCritical, cluster.IssueUnassignedPartition, assignment.LeaderBroker, "topic %s partition [%d,%d) is assigned to the broker %s not ready", topicName, partition.GetRangeStart(), partition.GetRangeStop(), assignment.LeaderBroker)
+		}
+	}
+	if len(assignments) > 0 && covered < ringSize {
+		report.AddIssue(cluster.HealthCritical, cluster.IssuePartitionGap, "", "topic %s has no partition for the key range [%d,%d)", topicName, covered, ringSize)
+	}
+}
@@ -0,0 +1,105 @@
+package weed_server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+)
+
+func testTopologyInfo(nodes ...*master_pb.DataNodeInfo) *master_pb.TopologyInfo {
+	return &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{
+			RackInfos: []*master_pb.RackInfo{{DataNodeInfos: nodes}},
+		}},
+	}
+}
+
+func testDataNode(id string, volumes []*master_pb.VolumeInformationMessage, ecShards []*master_pb.VolumeEcShardInformationMessage) *master_pb.DataNodeInfo {
+	return &master_pb.DataNodeInfo{
+		Id: id,
+		DiskInfos: map[string]*master_pb.DiskInfo{
+			"": {VolumeInfos: volumes, EcShardInfos: ecShards},
+		},
+	}
+}
+
+func TestCheckVolumeReplicasAndEcShards(t *testing.T) {
+	topologyInfo := testTopologyInfo(
+		testDataNode("a:8080", []*master_pb.VolumeInformationMessage{
+			{Id: 1, ReplicaPlacement: 1}, // 001, two copies
+			{Id: 2, ReplicaPlacement: 1},
+			{Id: 3},
+		}, []*master_pb.VolumeEcShardInformationMessage{
+			{Id: 10, EcIndexBits: 0x3fff},                               // all 10+4 shards
+			{Id: 11, EcIndexBits: 0x0fff},                               // 12 shards
+			{Id: 12, DataShards: 4, ParityShards: 2, EcIndexBits: 0x07}, // 3 of 4+2
+		}),
+		testDataNode("b:8080", []*master_pb.VolumeInformationMessage{
+			{Id: 2, ReplicaPlacement: 1},
+		}, nil),
+	)
+
+	report := cluster.NewHealthReport()
+	checkVolumeReplicas(report, topologyInfo)
+	if report.Status != cluster.HealthWarning || len(report.Issues) != 1 || !strings.HasSuffix(report.Issues[0].Message, ": 1") {
+		t.Fatalf("only volume 1 is under replicated, got %+v", report.Issues)
+	}
+
+	report = cluster.NewHealthReport()
+	checkEcShards(report, topologyInfo)
+	report.Sort()
+	if report.Status != cluster.HealthCritical || len(report.Issues) != 2 {
+		t.Fatalf("unexpected issues %+v", report.Issues)
+	}
+	if issue := report.Issues[0]; issue.Status != cluster.HealthCritical || !strings.HasSuffix(issue.Message, ": 12") {
+		t.Errorf("volume 12 is not recoverable, got %+v", issue)
+	}
+	if issue := report.Issues[1]; issue.Status != cluster.HealthWarning || !strings.HasSuffix(issue.Message, ": 11") {
+		t.Errorf("volume 11 misses shards, got %+v", issue)
+	}
+	if report.ExitCode() != 2 {
+		t.Errorf("expect exit code 2, got %d", report.ExitCode())
+	}
+}
+
+func TestCheckPartitionAssignments(t *testing.T) {
+	partition := func(start, stop int32) *schema_pb.Partition {
+		return &schema_pb.Partition{RingSize: 1024, RangeStart: start, RangeStop: stop}
+	}
+	readyBrokers := map[string]bool{"broker1:17777": true}
+
+	report := cluster.NewHealthReport()
+	checkPartitionAssignments(report, "ns.ok", &mq_pb.LookupTopicBrokersResponse{
+		RingSize: 1024,
+		BrokerPartitionAssignments: []*mq_pb.BrokerPartitionAssignment{
+			{Partition: partition(512, 1024), LeaderBroker: "broker1:17777"},
+			{Partition: partition(0, 512), LeaderBroker: "broker1:17777"},
+		},
+	}, readyBrokers)
+	if report.Status != cluster.HealthOk {
+		t.Fatalf("unexpected issues %+v", report.Issues)
+	}
+
+	checkPartitionAssignments(report, "ns.broken", &mq_pb.LookupTopicBrokersResponse{
+		RingSize: 1024,
+		BrokerPartitionAssignments: []*mq_pb.BrokerPartitionAssignment{
+			{Partition: partition(0, 256), LeaderBroker: ""},
+			{Partition: partition(512, 768), LeaderBroker: "broker2:17777"},
+		},
+	}, readyBrokers)
+	var kinds []string
+	for _, issue := range report.Issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	expected := []string{cluster.IssueUnassignedPartition, cluster.IssuePartitionGap, cluster.IssueUnassignedPartition, cluster.IssuePartitionGap}
+	if strings.Join(kinds, ",") != strings.Join(expected, ",") || report.Status != cluster.HealthCritical {
+		t.Fatalf("expect issues %v, got %+v", expected, report.Issues)
+	}
+	if report.Issues[3].Message != "topic ns.broken has no partition for the key range [768,1024)" {
+		t.Errorf("unexpected gap %s", report.Issues[3].Message)
+	}
+}
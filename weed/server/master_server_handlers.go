@@ -109,6 +109,11 @@ func (ms *MasterServer) findVolumeLocation(collection, vid string) operation.Loo
 
 func (ms *MasterServer) dirAssignHandler(w http.ResponseWriter, r *http.Request) {
 	stats.AssignRequest()
+	assignResult := "failure"
+	defer func(start time.Time) {
+		stats.MasterAssignRequestHistogram.WithLabelValues("http", assignResult).Observe(time.Since(start).Seconds())
+	}(time.Now())
+
 	requestedCount, e := strconv.ParseUint(r.FormValue("count"), 10, 64)
 	if e != nil || requestedCount == 0 {
 		requestedCount = 1
@@ -120,6 +125,9 @@ func (ms *MasterServer) dirAssignHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	option, err := ms.getVolumeGrowOption(r)
+	if err == nil {
+		err = ms.Topo.ApplyLatencyClass(option, r.FormValue("latencyClass"))
+	}
 	if err != nil {
 		writeJsonQuiet(w, r, http.StatusNotAcceptable, operation.AssignResult{Error: err.Error()})
 		return
@@ -165,7 +173,8 @@ func (ms *MasterServer) dirAssignHandler(w http.ResponseWriter, r *http.Request)
 			if dn == nil {
 				continue
 			}
-			writeJsonQuiet(w, r, http.StatusOK, operation.AssignResult{Fid: fid, Url: dn.Url(), PublicUrl: dn.PublicUrl, Count: count})
+			assignResult = "success"
+			writeJsonQuiet(w, r, http.StatusOK, operation.AssignResult{Fid: fid, Url: dn.Url(), PublicUrl: dn.PublicUrl, Count: count, DiskType: option.DiskType.ReadableString()})
 			return
 		}
 	}
@@ -177,6 +177,9 @@ func (ms *MasterServer) getVolumeGrowOption(r *http.Request) (*topology.VolumeGr
 		DataNode:           r.FormValue("dataNode"),
 		MemoryMapMaxSizeMb: memoryMapMaxSizeMb,
 	}
+	if err = topology.ApplyCollectionDiskType(volumeGrowOption, r.FormValue("disk")); err != nil {
+		return nil, err
+	}
 	return volumeGrowOption, nil
 }
 
@@ -0,0 +1,157 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+const volumeTieringCheckInterval = 10 * time.Minute
+
+// startVolumeTiering moves the volumes between the local disks and the remote tier by the tiering policies
+func (ms *MasterServer) startVolumeTiering() {
+	if len(topology.VolumeTieringPolicies) == 0 {
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(volumeTieringCheckInterval + time.Duration(30*rand.Float32())*time.Second)
+			if !ms.Topo.IsLeader() {
+				continue
+			}
+			// leave the volumes alone while the admin shell is moving them
+			if _, _, isLocked := ms.adminLocks.isLocked("shell"); isLocked {
+				glog.V(1).Infof("skip volume tiering while the admin shell is locked")
+				continue
+			}
+			ms.tierVolumesByPolicies(time.Now())
+		}
+	}()
+}
+
+func (ms *MasterServer) tierVolumesByPolicies(now time.Time) {
+	heats := ms.Topo.CollectVolumeHeats()
+	defer ms.volumeHeats.Prune(heats)
+	volumeSizeLimit := uint64(ms.option.VolumeSizeLimitMB) * 1024 * 1024
+	for _, heat := range heats {
+		readsPerHour, hasRate := ms.volumeHeats.ReadsPerHour(heat, now)
+		policy, found := topology.VolumeTieringPolicies[heat.Collection]
+		if !found {
+			continue
+		}
+		switch {
+		case policy.IsCold(heat, volumeSizeLimit, now):
+			glog.V(0).Infof("move cold volume %d of collection %s to %s, last read at %v", heat.Id, heat.Collection, policy.Backend, time.Unix(heat.LastReadAtSecond, 0))
+			if err := ms.moveVolumeToRemote(policy, heat); err != nil {
+				glog.Errorf("move volume %d to %s: %v", heat.Id, policy.Backend, err)
+			}
+		case hasRate && policy.IsHot(heat, readsPerHour):
+			glog.V(0).Infof("move hot volume %d of collection %s back from %s, %.1f reads per hour", heat.Id, heat.Collection, policy.Backend, readsPerHour)
+			if err := ms.moveVolumeFromRemote(heat); err != nil {
+				glog.Errorf("move volume %d back from %s: %v", heat.Id, policy.Backend, err)
+			}
+		}
+	}
+}
+
+// moveVolumeToRemote uploads the .dat file of the first replica, and deletes the other replicas, same as volume.tier.upload
+func (ms *MasterServer) moveVolumeToRemote(policy *topology.VolumeTieringPolicy, heat *topology.VolumeHeat) error {
+	for _, dn := range heat.Locations {
+		if err := operation.WithVolumeServerClient(false, dn.ServerAddress(), ms.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			_, err := client.VolumeMarkReadonly(context.Background(), &volume_server_pb.VolumeMarkReadonlyRequest{
+				VolumeId: uint32(heat.Id),
+			})
+			return err
+		}); err != nil {
+			return fmt.Errorf("mark volume %d as readonly on %s: %v", heat.Id, dn.Url(), err)
+		}
+	}
+
+	source := heat.Locations[0]
+	err := operation.WithVolumeServerClient(true, source.ServerAddress(), ms.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+		stream, err := client.VolumeTierMoveDatToRemote(context.Background(), &volume_server_pb.VolumeTierMoveDatToRemoteRequest{
+			VolumeId:               uint32(heat.Id),
+			Collection:             heat.Collection,
+			DestinationBackendName: policy.Backend,
+			KeepLocalDatFile:       policy.KeepLocalDatFile,
+		})
+		if stream == nil || err != nil {
+			// the stream is nil if already uploaded
+			return err
+		}
+		for {
+			if _, err = stream.Recv(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("copy dat file on %s: %v", source.Url(), err)
+	}
+	if policy.KeepLocalDatFile {
+		return nil
+	}
+
+	// the first replica has the .idx and .vif files, and reads from the remote tier
+	for _, dn := range heat.Locations[1:] {
+		if err := operation.WithVolumeServerClient(false, dn.ServerAddress(), ms.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			_, err := client.VolumeDelete(context.Background(), &volume_server_pb.VolumeDeleteRequest{
+				VolumeId: uint32(heat.Id),
+			})
+			return err
+		}); err != nil {
+			return fmt.Errorf("delete volume %d on %s: %v", heat.Id, dn.Url(), err)
+		}
+	}
+	return nil
+}
+
+// moveVolumeFromRemote downloads the .dat file and remounts the volume, same as volume.tier.download.
+// The missing replicas can be fixed by volume.fix.replication.
+func (ms *MasterServer) moveVolumeFromRemote(heat *topology.VolumeHeat) error {
+	for _, dn := range heat.Locations {
+		if v, err := dn.GetVolumesById(heat.Id); err != nil || !v.IsRemote() {
+			continue
+		}
+		err := operation.WithVolumeServerClient(true, dn.ServerAddress(), ms.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			stream, err := client.VolumeTierMoveDatFromRemote(context.Background(), &volume_server_pb.VolumeTierMoveDatFromRemoteRequest{
+				VolumeId:   uint32(heat.Id),
+				Collection: heat.Collection,
+			})
+			if err != nil {
+				return err
+			}
+			for {
+				if _, err = stream.Recv(); err != nil {
+					if err == io.EOF {
+						break
+					}
+					return err
+				}
+			}
+			if _, err = client.VolumeUnmount(context.Background(), &volume_server_pb.VolumeUnmountRequest{
+				VolumeId: uint32(heat.Id),
+			}); err != nil {
+				return err
+			}
+			_, err = client.VolumeMount(context.Background(), &volume_server_pb.VolumeMountRequest{
+				VolumeId: uint32(heat.Id),
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("download dat file on %s: %v", dn.Url(), err)
+		}
+	}
+	return nil
+}
@@ -79,6 +79,7 @@ func (s *RaftServer) monitorLeaderLoop(updatePeers bool) {
 			glog.V(0).Infof("is leader %+v change event: %+v => %+v", isLeader, prevLeader, leader)
 			prevLeader = leader
 			s.topo.LastLeaderChangeTime = time.Now()
+			stats.MasterLeaderChangeTimestampGauge.Set(float64(s.topo.LastLeaderChangeTime.Unix()))
 		}
 	}
 }
@@ -128,6 +129,12 @@ func NewHashicorpRaftServer(option *RaftServerOption) (*RaftServer, error) {
 	if c.LeaderLeaseTimeout > c.HeartbeatTimeout {
 		c.LeaderLeaseTimeout = c.HeartbeatTimeout
 	}
+	if option.SnapshotInterval > 0 {
+		c.SnapshotInterval = option.SnapshotInterval
+	}
+	if option.SnapshotThreshold > 0 {
+		c.SnapshotThreshold = option.SnapshotThreshold
+	}
 	if glog.V(4) {
 		c.LogLevel = "Debug"
 	} else if glog.V(2) {
@@ -31,6 +31,8 @@ type RaftServerOption struct {
 	HeartbeatInterval time.Duration
 	ElectionTimeout   time.Duration
 	RaftBootstrap     bool
+	SnapshotInterval  time.Duration // check whether to snapshot the raft log this often, 0 to disable
+	SnapshotThreshold uint64        // snapshot when there are this many new log entries since the last snapshot
 }
 
 type RaftServer struct {
@@ -167,9 +169,32 @@ func NewRaftServer(option *RaftServerOption) (*RaftServer, error) {
 
 	glog.V(0).Infof("current cluster leader: %v", s.raftServer.Leader())
 
+	go s.snapshotLoop(option.SnapshotInterval, option.SnapshotThreshold)
+
 	return s, nil
 }
 
+// snapshotLoop snapshots the state and compacts the raft log,
+// so long-running masters don't accumulate unbounded logs
+func (s *RaftServer) snapshotLoop(interval time.Duration, threshold uint64) {
+	if interval <= 0 {
+		return
+	}
+	lastIndex := s.raftServer.CommitIndex()
+	for range time.Tick(interval) {
+		commitIndex := s.raftServer.CommitIndex()
+		if commitIndex < lastIndex+threshold {
+			continue
+		}
+		if err := s.raftServer.TakeSnapshot(); err != nil {
+			glog.Warningf("raft snapshot at index %d: %v", commitIndex, err)
+			continue
+		}
+		glog.V(1).Infof("raft snapshot at index %d", commitIndex)
+		lastIndex = commitIndex
+	}
+}
+
 func (s *RaftServer) Peers() (members []string) {
 	if s.raftServer != nil {
 		peers := s.raftServer.Peers()
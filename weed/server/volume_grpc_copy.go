@@ -212,6 +212,7 @@ func (vs *VolumeServer) doCopyFileWithThrottler(client volume_server_pb.VolumeSe
 		Collection:               collection,
 		IsEcVolume:               isEcVolume,
 		IgnoreSourceFileNotFound: ignoreSourceFileNotFound,
+		AcceptCompression:        vs.copyCompression,
 	})
 	if err != nil {
 		return modifiedTsNs, fmt.Errorf("failed to start copying volume %d %s file: %v", vid, ext, err)
@@ -280,14 +281,18 @@ func writeToFile(client volume_server_pb.VolumeServer_CopyFileClient, fileName s
 		if receiveErr != nil {
 			return modifiedTsNs, fmt.Errorf("receiving %s: %v", fileName, receiveErr)
 		}
-		dst.Write(resp.FileContent)
-		progressedBytes += int64(len(resp.FileContent))
+		fileContent, decompressErr := util.DecompressStreamContent(resp.FileContent, resp.Compression)
+		if decompressErr != nil {
+			return modifiedTsNs, fmt.Errorf("receiving %s: %v", fileName, decompressErr)
+		}
+		dst.Write(fileContent)
+		progressedBytes += int64(len(fileContent))
 		if progressFn != nil {
 			if !progressFn(progressedBytes) {
 				return modifiedTsNs, fmt.Errorf("interrupted copy operation")
 			}
 		}
-		wt.MaybeSlowdown(int64(len(resp.FileContent)))
+		wt.MaybeSlowdown(int64(len(fileContent)))
 	}
 	return modifiedTsNs, nil
 }
@@ -385,9 +390,11 @@ func (vs *VolumeServer) CopyFile(req *volume_server_pb.CopyFileRequest, stream v
 		if int64(bytesread) > bytesToRead {
 			bytesread = int(bytesToRead)
 		}
+		fileContent, compression := util.MaybeCompressStreamContent(buffer[:bytesread], req.AcceptCompression)
 		err = stream.Send(&volume_server_pb.CopyFileResponse{
-			FileContent:  buffer[:bytesread],
+			FileContent:  fileContent,
 			ModifiedTsNs: fileModTsNs,
+			Compression:  compression,
 		})
 		if err != nil {
 			// println("sending", bytesread, "bytes err", err.Error())
@@ -15,6 +15,14 @@ func (vs *VolumeServer) ReadNeedleBlob(ctx context.Context, req *volume_server_p
 		return nil, fmt.Errorf("not found volume id %d", req.VolumeId)
 	}
 
+	if req.NeedleId != 0 {
+		resp.NeedleBlob, err = v.ReadVerifiedNeedleBlob(types.NeedleId(req.NeedleId), types.Size(req.Size))
+		if err != nil {
+			return nil, fmt.Errorf("read needle %d blob size %d: %v", req.NeedleId, req.Size, err)
+		}
+		return resp, nil
+	}
+
 	resp.NeedleBlob, err = v.ReadNeedleBlob(req.Offset, types.Size(req.Size))
 	if err != nil {
 		return nil, fmt.Errorf("read needle blob offset %d size %d: %v", req.Offset, req.Size, err)
@@ -12,6 +12,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/storage"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 func (vs *VolumeServer) VolumeTailSender(req *volume_server_pb.VolumeTailSenderRequest, stream volume_server_pb.VolumeServer_VolumeTailSenderServer) error {
@@ -27,7 +28,7 @@ func (vs *VolumeServer) VolumeTailSender(req *volume_server_pb.VolumeTailSenderR
 	drainingSeconds := req.IdleTimeoutSeconds
 
 	for {
-		lastProcessedTimestampNs, err := sendNeedlesSince(stream, v, lastTimestampNs)
+		lastProcessedTimestampNs, err := sendNeedlesSince(stream, v, lastTimestampNs, req)
 		if err != nil {
 			glog.Infof("sendNeedlesSince: %v", err)
 			return fmt.Errorf("streamFollow: %v", err)
@@ -54,7 +55,7 @@ func (vs *VolumeServer) VolumeTailSender(req *volume_server_pb.VolumeTailSenderR
 
 }
 
-func sendNeedlesSince(stream volume_server_pb.VolumeServer_VolumeTailSenderServer, v *storage.Volume, lastTimestampNs uint64) (lastProcessedTimestampNs uint64, err error) {
+func sendNeedlesSince(stream volume_server_pb.VolumeServer_VolumeTailSenderServer, v *storage.Volume, lastTimestampNs uint64, req *volume_server_pb.VolumeTailSenderRequest) (lastProcessedTimestampNs uint64, err error) {
 
 	foundOffset, isLastOne, err := v.BinarySearchByAppendAtNs(lastTimestampNs)
 	if err != nil {
@@ -70,7 +71,11 @@ func sendNeedlesSince(stream volume_server_pb.VolumeServer_VolumeTailSenderServe
 	}
 
 	scanner := &VolumeFileScanner4Tailing{
-		stream: stream,
+		stream:            stream,
+		acceptCompression: req.AcceptCompression,
+	}
+	if req.SkipOverwrittenNeedles {
+		scanner.v = v
 	}
 
 	err = storage.ScanVolumeFileFrom(v.Version(), v.DataBackend, foundOffset.ToActualOffset(), scanner)
@@ -90,7 +95,7 @@ func (vs *VolumeServer) VolumeTailReceiver(ctx context.Context, req *volume_serv
 
 	defer glog.V(1).Infof("receive tailing volume %d finished", v.Id)
 
-	return resp, operation.TailVolumeFromSource(pb.ServerAddress(req.SourceVolumeServer), vs.grpcDialOption, v.Id, req.SinceNs, int(req.IdleTimeoutSeconds), func(n *needle.Needle) error {
+	return resp, operation.TailVolumeFromSource(pb.ServerAddress(req.SourceVolumeServer), vs.grpcDialOption, v.Id, req.SinceNs, int(req.IdleTimeoutSeconds), vs.copyCompression, func(n *needle.Needle) error {
 		_, err := vs.store.WriteVolumeNeedle(v.Id, n, false, false)
 		return err
 	})
@@ -100,6 +105,8 @@ func (vs *VolumeServer) VolumeTailReceiver(ctx context.Context, req *volume_serv
 // generate the volume idx
 type VolumeFileScanner4Tailing struct {
 	stream                   volume_server_pb.VolumeServer_VolumeTailSenderServer
+	acceptCompression        string
+	v                        *storage.Volume // if set, skip the needles overwritten or deleted later
 	lastProcessedTimestampNs uint64
 }
 
@@ -112,6 +119,12 @@ func (scanner *VolumeFileScanner4Tailing) ReadNeedleBody() bool {
 }
 
 func (scanner *VolumeFileScanner4Tailing) VisitNeedle(n *needle.Needle, offset int64, needleHeader, needleBody []byte) error {
+	if scanner.v != nil && !scanner.v.IsLatestNeedle(n.Id, offset, n.Size) {
+		// the later version or the deletion is sent instead
+		scanner.lastProcessedTimestampNs = n.AppendAtNs
+		return nil
+	}
+
 	isLastChunk := false
 
 	// need to send body by chunks
@@ -122,10 +135,12 @@ func (scanner *VolumeFileScanner4Tailing) VisitNeedle(n *needle.Needle, offset i
 			stopOffset = len(needleBody)
 		}
 
+		chunk, compression := util.MaybeCompressStreamContent(needleBody[i:stopOffset], scanner.acceptCompression)
 		sendErr := scanner.stream.Send(&volume_server_pb.VolumeTailSenderResponse{
 			NeedleHeader: needleHeader,
-			NeedleBody:   needleBody[i:stopOffset],
+			NeedleBody:   chunk,
 			IsLastChunk:  isLastChunk,
+			Compression:  compression,
 		})
 		if sendErr != nil {
 			return sendErr
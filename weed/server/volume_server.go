@@ -13,6 +13,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/health"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/security"
@@ -30,6 +31,9 @@ type VolumeServer struct {
 	inflightUploadDataTimeout     time.Duration
 	hasSlowRead                   bool
 	readBufferSizeMB              int
+	zeroCopyRead                  bool
+	compactInPlace                bool
+	copyCompression               string
 
 	SeedMasterNodes []pb.ServerAddress
 	whiteList       []string
@@ -46,11 +50,14 @@ type VolumeServer struct {
 	FixJpgOrientation       bool
 	ReadMode                string
 	compactionBytePerSecond int64
+	scrubBytePerSecond      int64
+	scrubInterval           time.Duration
 	metricsAddress          string
 	metricsIntervalSec      int
 	fileSizeLimitBytes      int64
 	isHeartbeating          bool
 	stopChan                chan bool
+	healthChecks            *health.Checks
 }
 
 func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
@@ -64,12 +71,18 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 	fixJpgOrientation bool,
 	readMode string,
 	compactionMBPerSecond int,
+	scrubMBPerSecond int,
+	scrubInterval time.Duration,
 	fileSizeLimitMB int,
 	concurrentUploadLimit int64,
 	concurrentDownloadLimit int64,
 	inflightUploadDataTimeout time.Duration,
 	hasSlowRead bool,
 	readBufferSizeMB int,
+	zeroCopyRead bool,
+	compactInPlace bool,
+	readCache *storage.NeedleReadCache,
+	copyCompression string,
 	ldbTimeout int64,
 ) *VolumeServer {
 
@@ -92,6 +105,8 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 		ReadMode:                      readMode,
 		grpcDialOption:                security.LoadClientTLS(util.GetViper(), "grpc.volume"),
 		compactionBytePerSecond:       int64(compactionMBPerSecond) * 1024 * 1024,
+		scrubBytePerSecond:            int64(scrubMBPerSecond) * 1024 * 1024,
+		scrubInterval:                 scrubInterval,
 		fileSizeLimitBytes:            int64(fileSizeLimitMB) * 1024 * 1024,
 		isHeartbeating:                true,
 		stopChan:                      make(chan bool),
@@ -102,6 +117,9 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 		inflightUploadDataTimeout:     inflightUploadDataTimeout,
 		hasSlowRead:                   hasSlowRead,
 		readBufferSizeMB:              readBufferSizeMB,
+		zeroCopyRead:                  zeroCopyRead,
+		compactInPlace:                compactInPlace,
+		copyCompression:               copyCompression,
 		ldbTimout:                     ldbTimeout,
 		whiteList:                     whiteList,
 	}
@@ -112,11 +130,18 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 	vs.checkWithMaster()
 
 	vs.store = storage.NewStore(vs.grpcDialOption, ip, port, grpcPort, publicUrl, folders, maxCounts, minFreeSpaces, idxFolder, vs.needleMapKind, diskTypes, ldbTimeout)
+	if readCache != nil {
+		vs.store.SetNeedleReadCache(readCache)
+	}
 	vs.guard = security.NewGuard(whiteList, signingKey, expiresAfterSec, readSigningKey, readExpiresAfterSec)
 
 	handleStaticResources(adminMux)
 	adminMux.HandleFunc("/status", vs.statusHandler)
-	adminMux.HandleFunc("/healthz", vs.healthzHandler)
+	vs.healthChecks = health.NewChecks()
+	vs.healthChecks.Add("master", vs.checkMasterReady)
+	vs.healthChecks.Add("replication", vs.checkReplicationReady)
+	adminMux.HandleFunc("/healthz", vs.healthChecks.HealthzHandler)
+	adminMux.HandleFunc("/readyz", vs.healthChecks.ReadyzHandler)
 	if signingKey == "" || enableUiAccess {
 		// only expose the volume server details for safe environments
 		adminMux.HandleFunc("/ui/index.html", vs.uiStatusHandler)
@@ -134,6 +159,7 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 	}
 
 	go vs.heartbeat()
+	go vs.scrubLoop()
 	go stats.LoopPushingMetric("volumeServer", util.JoinHostPort(ip, port), vs.metricsAddress, vs.metricsIntervalSec)
 
 	return vs
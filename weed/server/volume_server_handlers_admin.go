@@ -1,6 +1,8 @@
 package weed_server
 
 import (
+	"context"
+	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/topology"
 	"net/http"
 	"path/filepath"
@@ -8,10 +10,27 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc"
 )
 
-func (vs *VolumeServer) healthzHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Server", "SeaweedFS Volume "+util.VERSION)
+// checkMasterReady checks that the volume server is sending heartbeats to the master, and not stopping
+func (vs *VolumeServer) checkMasterReady(ctx context.Context) error {
+	if !vs.isHeartbeating {
+		return fmt.Errorf("stopping")
+	}
+	if vs.store.MasterAddress == "" {
+		return fmt.Errorf("not connected to any master")
+	}
+	return nil
+}
+
+// RegisterGrpcHealth serves the gRPC health checking, following the readiness checks
+func (vs *VolumeServer) RegisterGrpcHealth(grpcS *grpc.Server) {
+	vs.healthChecks.RegisterGrpc(grpcS)
+}
+
+// checkReplicationReady checks that the replicas of the replicated volumes are reachable for writes
+func (vs *VolumeServer) checkReplicationReady(ctx context.Context) error {
 	volumeInfos := vs.store.VolumeInfos()
 	for _, vinfo := range volumeInfos {
 		if len(vinfo.Collection) == 0 {
@@ -20,12 +39,11 @@ func (vs *VolumeServer) healthzHandler(w http.ResponseWriter, r *http.Request) {
 		if vinfo.ReplicaPlacement.GetCopyCount() > 1 {
 			_, err := topology.GetWritableRemoteReplications(vs.store, vs.grpcDialOption, vinfo.Id, vs.GetMaster)
 			if err != nil {
-				w.WriteHeader(http.StatusServiceUnavailable)
-				return
+				return fmt.Errorf("volume %d: %v", vinfo.Id, err)
 			}
 		}
 	}
-	w.WriteHeader(http.StatusOK)
+	return nil
 }
 
 func (vs *VolumeServer) statusHandler(w http.ResponseWriter, r *http.Request) {
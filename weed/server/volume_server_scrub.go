@@ -0,0 +1,120 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// scrubLoop re-reads all needles every scrubInterval, limited to scrubBytePerSecond,
+// and repairs the corrupted needles from the healthy replicas or the other ec shards.
+func (vs *VolumeServer) scrubLoop() {
+	if vs.scrubBytePerSecond <= 0 {
+		return
+	}
+	glog.V(0).Infof("scrub volumes at %d MB/s every %v", vs.scrubBytePerSecond/1024/1024, vs.scrubInterval)
+
+	// the first pass starts shortly after the volumes are loaded
+	nextScrub := time.Minute
+	for {
+		select {
+		case <-vs.stopChan:
+			return
+		case <-time.After(nextScrub):
+		}
+		start := time.Now()
+		vs.scrubVolumes()
+		glog.V(0).Infof("scrubbed volumes in %v", time.Since(start))
+		nextScrub = vs.scrubInterval
+	}
+}
+
+func (vs *VolumeServer) scrubVolumes() {
+	throttler := util.NewWriteThrottler(vs.scrubBytePerSecond)
+	throttle := func(bytes int64) {
+		throttler.MaybeSlowdown(bytes)
+	}
+
+	for _, v := range vs.store.Volumes() {
+		if v.HasRemoteFile() {
+			continue
+		}
+		scrubbed, err := v.ScrubNeedles(vs.stopChan, throttle, func(needleId types.NeedleId, offset types.Offset, size types.Size, scrubErr error) {
+			stats.VolumeServerScrubbedNeedlesCounter.WithLabelValues("volume", "corrupted").Inc()
+			glog.Errorf("scrub volume %d needle %s: %v", v.Id, needleId, scrubErr)
+			if repairErr := vs.repairNeedleFromReplicas(v, needleId, offset, size); repairErr != nil {
+				glog.Errorf("repair volume %d needle %s: %v", v.Id, needleId, repairErr)
+				return
+			}
+			stats.VolumeServerScrubbedNeedlesCounter.WithLabelValues("volume", "repaired").Inc()
+			glog.V(0).Infof("repaired volume %d needle %s from replicas", v.Id, needleId)
+		})
+		stats.VolumeServerScrubbedNeedlesCounter.WithLabelValues("volume", "scrubbed").Add(float64(scrubbed))
+		if err != nil {
+			glog.V(0).Infof("scrub volume %d: %v", v.Id, err)
+		}
+	}
+
+	for _, ecVolume := range vs.store.EcVolumes() {
+		scrubbed, err := vs.store.ScrubEcVolume(ecVolume, vs.stopChan, throttle, func(needleId types.NeedleId, scrubErr error, repairErr error) {
+			stats.VolumeServerScrubbedNeedlesCounter.WithLabelValues("ec_shards", "corrupted").Inc()
+			glog.Errorf("scrub ec volume %d needle %s: %v", ecVolume.VolumeId, needleId, scrubErr)
+			if repairErr != nil {
+				glog.Errorf("repair ec volume %d needle %s: %v", ecVolume.VolumeId, needleId, repairErr)
+				return
+			}
+			stats.VolumeServerScrubbedNeedlesCounter.WithLabelValues("ec_shards", "repaired").Inc()
+		})
+		stats.VolumeServerScrubbedNeedlesCounter.WithLabelValues("ec_shards", "scrubbed").Add(float64(scrubbed))
+		if err != nil {
+			glog.V(0).Infof("scrub ec volume %d: %v", ecVolume.VolumeId, err)
+		}
+	}
+}
+
+func (vs *VolumeServer) repairNeedleFromReplicas(v *storage.Volume, needleId types.NeedleId, offset types.Offset, size types.Size) error {
+	if v.ReplicaPlacement.GetCopyCount() == 1 {
+		return fmt.Errorf("no replicas to repair from")
+	}
+	lookupResult, err := operation.LookupVolumeId(vs.GetMaster, vs.grpcDialOption, v.Id.String())
+	if err != nil {
+		return fmt.Errorf("lookup volume %d: %v", v.Id, err)
+	}
+
+	err = fmt.Errorf("no healthy replicas found in %v", lookupResult.Locations)
+	selfUrl := util.JoinHostPort(vs.store.Ip, vs.store.Port)
+	for _, location := range lookupResult.Locations {
+		if location.Url == selfUrl {
+			continue
+		}
+		var needleBlob []byte
+		readErr := operation.WithVolumeServerClient(false, location.ServerAddress(), vs.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			resp, err := client.ReadNeedleBlob(context.Background(), &volume_server_pb.ReadNeedleBlobRequest{
+				VolumeId: uint32(v.Id),
+				NeedleId: uint64(needleId),
+				Size:     int32(size),
+			})
+			if err != nil {
+				return err
+			}
+			needleBlob = resp.NeedleBlob
+			return nil
+		})
+		if readErr != nil {
+			glog.V(0).Infof("read volume %d needle %s from %s: %v", v.Id, needleId, location.Url, readErr)
+			continue
+		}
+		if err = v.RepairNeedleBlob(needleId, offset, size, needleBlob); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,307 @@
+package weed_server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/webdav"
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// The webdav locks are kept in the filer, so they survive restarts and are shared by all webdav servers
+// of the same filer cluster. The locks are saved as one filer kv entry, with their roots as the full filer paths,
+// and the changes are serialized by the filer distributed lock.
+
+const webdavLocksKey = "webdav.locks"
+
+type webdavLock struct {
+	Token      string `json:"token"`
+	Root       string `json:"root"`
+	OwnerXML   string `json:"ownerXml,omitempty"`
+	ZeroDepth  bool   `json:"zeroDepth,omitempty"`
+	Duration   int64  `json:"duration"`
+	ExpireAtNs int64  `json:"expireAtNs,omitempty"`
+}
+
+func (l *webdavLock) isExpired(now time.Time) bool {
+	return l.Duration >= 0 && l.ExpireAtNs <= now.UnixNano()
+}
+
+func (l *webdavLock) setDuration(now time.Time, duration time.Duration) {
+	l.Duration = int64(duration)
+	l.ExpireAtNs = 0
+	if duration >= 0 {
+		l.ExpireAtNs = now.Add(duration).UnixNano()
+	}
+}
+
+// covers tells whether the lock applies to the path
+func (l *webdavLock) covers(p string) bool {
+	if l.Root == p {
+		return true
+	}
+	return !l.ZeroDepth && isUnderPath(p, l.Root)
+}
+
+func isUnderPath(p, dir string) bool {
+	return (dir == "/" && p != "/") || strings.HasPrefix(p, dir+"/")
+}
+
+// webdavLockStore loads and saves all the locks
+type webdavLockStore interface {
+	load() ([]*webdavLock, error)
+	// update changes the locks exclusively among all the webdav servers
+	update(fn func(locks []*webdavLock) ([]*webdavLock, error)) error
+}
+
+type filerLockSystem struct {
+	mu       sync.Mutex
+	store    webdavLockStore
+	rootPath string
+	held     map[string]bool
+}
+
+var _ = webdav.LockSystem(&filerLockSystem{})
+
+func newFilerLockSystem(store webdavLockStore, rootPath string) *filerLockSystem {
+	if rootPath != "" {
+		rootPath = strings.TrimSuffix(path.Clean(rootPath), "/")
+	}
+	return &filerLockSystem{
+		store:    store,
+		rootPath: rootPath,
+		held:     make(map[string]bool),
+	}
+}
+
+// toFullPath converts the webdav name to the filer path
+func (ls *filerLockSystem) toFullPath(name string) string {
+	name = slashClean(name)
+	if ls.rootPath == "" {
+		return name
+	}
+	if name == "/" {
+		return ls.rootPath
+	}
+	return ls.rootPath + name
+}
+
+func (ls *filerLockSystem) toName(fullPath string) string {
+	if fullPath == ls.rootPath {
+		return "/"
+	}
+	return strings.TrimPrefix(fullPath, ls.rootPath)
+}
+
+func (ls *filerLockSystem) toDetails(l *webdavLock) webdav.LockDetails {
+	return webdav.LockDetails{
+		Root:      ls.toName(l.Root),
+		Duration:  time.Duration(l.Duration),
+		OwnerXML:  l.OwnerXML,
+		ZeroDepth: l.ZeroDepth,
+	}
+}
+
+func (ls *filerLockSystem) Confirm(now time.Time, name0, name1 string, conditions ...webdav.Condition) (func(), error) {
+	locks, err := ls.store.load()
+	if err != nil {
+		glog.Errorf("load webdav locks: %v", err)
+		return nil, err
+	}
+	locks = withoutExpired(locks, now)
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	var token0, token1 string
+	if name0 != "" {
+		if token0 = ls.lookup(locks, ls.toFullPath(name0), conditions...); token0 == "" {
+			return nil, webdav.ErrConfirmationFailed
+		}
+	}
+	if name1 != "" {
+		if token1 = ls.lookup(locks, ls.toFullPath(name1), conditions...); token1 == "" {
+			return nil, webdav.ErrConfirmationFailed
+		}
+	}
+	for _, token := range []string{token0, token1} {
+		if token != "" {
+			ls.held[token] = true
+		}
+	}
+	return func() {
+		ls.mu.Lock()
+		defer ls.mu.Unlock()
+		delete(ls.held, token0)
+		delete(ls.held, token1)
+	}, nil
+}
+
+// lookup returns the token of the lock in the conditions applying to the path, and not held by another request
+func (ls *filerLockSystem) lookup(locks []*webdavLock, p string, conditions ...webdav.Condition) string {
+	for _, c := range conditions {
+		if c.Token == "" || ls.held[c.Token] {
+			continue
+		}
+		for _, l := range locks {
+			if l.Token == c.Token && l.covers(p) {
+				return l.Token
+			}
+		}
+	}
+	return ""
+}
+
+func (ls *filerLockSystem) Create(now time.Time, details webdav.LockDetails) (token string, err error) {
+	root := ls.toFullPath(details.Root)
+	err = ls.store.update(func(locks []*webdavLock) ([]*webdavLock, error) {
+		locks = withoutExpired(locks, now)
+		for _, l := range locks {
+			if l.covers(root) || !details.ZeroDepth && isUnderPath(l.Root, root) {
+				return nil, webdav.ErrLocked
+			}
+		}
+		lock := &webdavLock{
+			Token:     "urn:uuid:" + uuid.NewString(),
+			Root:      root,
+			OwnerXML:  details.OwnerXML,
+			ZeroDepth: details.ZeroDepth,
+		}
+		lock.setDuration(now, details.Duration)
+		token = lock.Token
+		return append(locks, lock), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (ls *filerLockSystem) Refresh(now time.Time, token string, duration time.Duration) (details webdav.LockDetails, err error) {
+	if ls.isHeld(token) {
+		return webdav.LockDetails{}, webdav.ErrLocked
+	}
+	err = ls.store.update(func(locks []*webdavLock) ([]*webdavLock, error) {
+		locks = withoutExpired(locks, now)
+		for _, l := range locks {
+			if l.Token == token {
+				l.setDuration(now, duration)
+				details = ls.toDetails(l)
+				return locks, nil
+			}
+		}
+		return nil, webdav.ErrNoSuchLock
+	})
+	return details, err
+}
+
+func (ls *filerLockSystem) Unlock(now time.Time, token string) error {
+	if ls.isHeld(token) {
+		return webdav.ErrLocked
+	}
+	return ls.store.update(func(locks []*webdavLock) ([]*webdavLock, error) {
+		locks = withoutExpired(locks, now)
+		for i, l := range locks {
+			if l.Token == token {
+				return append(locks[:i], locks[i+1:]...), nil
+			}
+		}
+		return nil, webdav.ErrNoSuchLock
+	})
+}
+
+func (ls *filerLockSystem) isHeld(token string) bool {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.held[token]
+}
+
+func withoutExpired(locks []*webdavLock, now time.Time) []*webdavLock {
+	var alive []*webdavLock
+	for _, l := range locks {
+		if !l.isExpired(now) {
+			alive = append(alive, l)
+		}
+	}
+	return alive
+}
+
+func slashClean(name string) string {
+	if name == "" || name[0] != '/' {
+		name = "/" + name
+	}
+	return path.Clean(name)
+}
+
+// filerWebdavLockStore keeps the locks in the filer kv
+type filerWebdavLockStore struct {
+	filer          pb.ServerAddress
+	grpcDialOption grpc.DialOption
+	lockClient     *cluster.LockClient
+	owner          string
+}
+
+func newFilerWebdavLockStore(filer pb.ServerAddress, grpcDialOption grpc.DialOption) *filerWebdavLockStore {
+	return &filerWebdavLockStore{
+		filer:          filer,
+		grpcDialOption: grpcDialOption,
+		lockClient:     cluster.NewLockClient(grpcDialOption, filer),
+		owner:          "webdav-" + uuid.NewString(),
+	}
+}
+
+func (s *filerWebdavLockStore) load() (locks []*webdavLock, err error) {
+	err = pb.WithFilerClient(false, 0, s.filer, s.grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.KvGet(context.Background(), &filer_pb.KvGetRequest{Key: []byte(webdavLocksKey)})
+		if err != nil {
+			return err
+		}
+		if len(resp.Error) != 0 {
+			return errors.New(resp.Error)
+		}
+		if len(resp.Value) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Value, &locks)
+	})
+	return
+}
+
+func (s *filerWebdavLockStore) update(fn func(locks []*webdavLock) ([]*webdavLock, error)) error {
+	lock := s.lockClient.NewShortLivedLock(webdavLocksKey, s.owner)
+	defer lock.StopShortLivedLock()
+
+	locks, err := s.load()
+	if err != nil {
+		return err
+	}
+	if locks, err = fn(locks); err != nil {
+		return err
+	}
+	var value []byte
+	if len(locks) > 0 {
+		if value, err = json.Marshal(locks); err != nil {
+			return err
+		}
+	}
+	return pb.WithFilerClient(false, 0, s.filer, s.grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.KvPut(context.Background(), &filer_pb.KvPutRequest{Key: []byte(webdavLocksKey), Value: value})
+		if err != nil {
+			return err
+		}
+		if len(resp.Error) != 0 {
+			return errors.New(resp.Error)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,96 @@
+package weed_server
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// memWebdavLockStore keeps the locks encoded, as in the filer kv
+type memWebdavLockStore struct {
+	sync.Mutex
+	value []byte
+}
+
+func (s *memWebdavLockStore) load() (locks []*webdavLock, err error) {
+	s.Lock()
+	defer s.Unlock()
+	if len(s.value) == 0 {
+		return nil, nil
+	}
+	err = json.Unmarshal(s.value, &locks)
+	return
+}
+
+func (s *memWebdavLockStore) update(fn func(locks []*webdavLock) ([]*webdavLock, error)) error {
+	locks, err := s.load()
+	if err != nil {
+		return err
+	}
+	if locks, err = fn(locks); err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.value, err = json.Marshal(locks)
+	return err
+}
+
+func TestFilerLockSystem(t *testing.T) {
+	store := &memWebdavLockStore{}
+	ls := newFilerLockSystem(store, "/buckets/")
+	now := time.Now()
+
+	token, err := ls.Create(now, webdav.LockDetails{Root: "/dir", Duration: time.Minute})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// the locks are kept with the filer paths, and shared with the other webdav servers
+	other := newFilerLockSystem(store, "/")
+	if _, err = other.Create(now, webdav.LockDetails{Root: "/buckets/dir/file", Duration: time.Minute, ZeroDepth: true}); err != webdav.ErrLocked {
+		t.Errorf("expected locked by the infinite depth lock of the parent, got %v", err)
+	}
+	if _, err = ls.Create(now, webdav.LockDetails{Root: "/", Duration: time.Minute}); err != webdav.ErrLocked {
+		t.Errorf("expected locked by the lock of a descendant, got %v", err)
+	}
+	if _, err = ls.Create(now, webdav.LockDetails{Root: "/other", Duration: time.Minute}); err != nil {
+		t.Errorf("create lock on a sibling: %v", err)
+	}
+
+	if _, err = ls.Confirm(now, "/dir/file", "", webdav.Condition{Token: "urn:uuid:unknown"}); err != webdav.ErrConfirmationFailed {
+		t.Errorf("expected confirmation failed without the token, got %v", err)
+	}
+	release, err := other.Confirm(now, "/buckets/dir/file", "", webdav.Condition{Token: token})
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if err = other.Unlock(now, token); err != webdav.ErrLocked {
+		t.Errorf("expected locked while held, got %v", err)
+	}
+	release()
+
+	details, err := ls.Refresh(now, token, 2*time.Minute)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if details.Root != "/dir" || details.Duration != 2*time.Minute {
+		t.Errorf("unexpected refreshed lock %+v", details)
+	}
+
+	// a restarted server sees the lock until it expires
+	restarted := newFilerLockSystem(store, "/buckets")
+	if _, err = restarted.Create(now.Add(time.Minute), webdav.LockDetails{Root: "/dir", Duration: time.Minute}); err != webdav.ErrLocked {
+		t.Errorf("expected locked after restart, got %v", err)
+	}
+	if _, err = restarted.Create(now.Add(3*time.Minute), webdav.LockDetails{Root: "/dir", Duration: time.Minute}); err != nil {
+		t.Errorf("create lock after the old lock expired: %v", err)
+	}
+
+	if err = restarted.Unlock(now.Add(3*time.Minute), token); err != webdav.ErrNoSuchLock {
+		t.Errorf("expected no such lock for the expired lock, got %v", err)
+	}
+}
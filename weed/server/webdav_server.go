@@ -74,7 +74,7 @@ func NewWebDavServer(option *WebDavOption) (ws *WebDavServer, err error) {
 		grpcDialOption: security.LoadClientTLS(util.GetViper(), "grpc.filer"),
 		Handler: &webdav.Handler{
 			FileSystem: fs,
-			LockSystem: webdav.NewMemLS(),
+			LockSystem: newFilerLockSystem(newFilerWebdavLockStore(option.Filer, option.GrpcDialOption), option.FilerRootPath),
 		},
 	}
 
@@ -0,0 +1,227 @@
+package shell
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandClusterConfigExport{})
+}
+
+const clusterConfigBundleVersion = 1
+
+// the progress of the mq connectors is kept under /etc, but is not the configuration
+var clusterConfigExcludedDirs = []string{"/etc/seaweedfs/mq_connect"}
+
+// clusterConfigBundle holds the configuration files stored in the filer, signed with HMAC-SHA256
+type clusterConfigBundle struct {
+	Version   int                 `json:"version"`
+	CreatedAt time.Time           `json:"createdAt"`
+	Filer     string              `json:"filer"`
+	Files     []clusterConfigFile `json:"files"`
+	Signature string              `json:"signature"`
+}
+
+type clusterConfigFile struct {
+	Path    string `json:"path"`
+	Content []byte `json:"content"`
+}
+
+type commandClusterConfigExport struct {
+}
+
+func (c *commandClusterConfigExport) Name() string {
+	return "cluster.config.export"
+}
+
+func (c *commandClusterConfigExport) Help() string {
+	return `export the configuration stored in the filer to a signed json bundle
+
+	cluster.config.export -o cluster.json
+	cluster.config.export -o cluster.json -signingKey=<secret>
+
+	The bundle contains all the files under /etc, i.e., the filer.conf path rules, the s3 identities and
+	circuit breaker, the remote storages and mounts, and the topic.conf files of the message queue topics.
+	The bundle is signed with the -signingKey, or "jwt.filer_signing.key" in security.toml by default,
+	and can be loaded into the same or another cluster by "cluster.config.import".
+
+`
+}
+
+func (c *commandClusterConfigExport) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandClusterConfigExport) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	exportCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	outputFileName := exportCommand.String("o", "", "output the bundle to this file")
+	signingKey := exportCommand.String("signingKey", "", "the key to sign the bundle, default to jwt.filer_signing.key in security.toml")
+	if err = exportCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *outputFileName == "" {
+		return fmt.Errorf("specify the output file by -o")
+	}
+	key, err := clusterConfigSigningKey(*signingKey)
+	if err != nil {
+		return err
+	}
+
+	bundle := &clusterConfigBundle{
+		Version:   clusterConfigBundleVersion,
+		CreatedAt: time.Now().UTC(),
+		Filer:     string(commandEnv.option.FilerAddress),
+	}
+	var paths []util.FullPath
+	if paths, err = listClusterConfigFiles(commandEnv); err != nil {
+		return err
+	}
+	err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		for _, path := range paths {
+			dir, name := path.DirAndName()
+			var buf bytes.Buffer
+			if err := filer.ReadEntry(commandEnv.MasterClient, client, dir, name, &buf); err != nil {
+				return fmt.Errorf("read %s: %v", path, err)
+			}
+			bundle.Files = append(bundle.Files, clusterConfigFile{
+				Path:    string(path),
+				Content: buf.Bytes(),
+			})
+			fmt.Fprintf(writer, "exported %s, %d bytes\n", path, buf.Len())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = bundle.sign(key); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(*outputFileName, data, 0600); err != nil {
+		return fmt.Errorf("write %s: %v", *outputFileName, err)
+	}
+	fmt.Fprintf(writer, "exported %d configuration files to %s\n", len(bundle.Files), *outputFileName)
+	return nil
+}
+
+func clusterConfigSigningKey(signingKey string) ([]byte, error) {
+	if signingKey == "" {
+		util.LoadSecurityConfiguration()
+		signingKey = util.GetViper().GetString("jwt.filer_signing.key")
+	}
+	if signingKey == "" {
+		return nil, fmt.Errorf("specify -signingKey, or jwt.filer_signing.key in security.toml")
+	}
+	return []byte(signingKey), nil
+}
+
+// listClusterConfigFiles lists the files under /etc, and the topic.conf of each topic
+func listClusterConfigFiles(commandEnv *CommandEnv) (paths []util.FullPath, err error) {
+	if err = listClusterConfigDir(commandEnv, util.FullPath("/etc"), &paths); err != nil {
+		return nil, err
+	}
+
+	var topicDirs []util.FullPath
+	err = filer_pb.ReadDirAllEntries(commandEnv, util.FullPath(filer.TopicsDir), "", func(namespace *filer_pb.Entry, isLast bool) error {
+		if !namespace.IsDirectory || strings.HasPrefix(namespace.Name, ".") {
+			return nil
+		}
+		return filer_pb.ReadDirAllEntries(commandEnv, util.NewFullPath(filer.TopicsDir, namespace.Name), "", func(topic *filer_pb.Entry, isLast bool) error {
+			if topic.IsDirectory {
+				topicDirs = append(topicDirs, util.FullPath(filer.TopicsDir).Child(namespace.Name).Child(topic.Name))
+			}
+			return nil
+		})
+	})
+	if err != nil && err != filer_pb.ErrNotFound {
+		return nil, err
+	}
+	for _, topicDir := range topicDirs {
+		if entry, lookupErr := filer_pb.GetEntry(commandEnv, topicDir.Child(filer.TopicConfFile)); lookupErr == nil && entry != nil {
+			paths = append(paths, topicDir.Child(filer.TopicConfFile))
+		}
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i] < paths[j]
+	})
+	return paths, nil
+}
+
+func listClusterConfigDir(commandEnv *CommandEnv, dir util.FullPath, paths *[]util.FullPath) error {
+	for _, excluded := range clusterConfigExcludedDirs {
+		if dir == util.FullPath(excluded) {
+			return nil
+		}
+	}
+	var subDirs []util.FullPath
+	err := filer_pb.ReadDirAllEntries(commandEnv, dir, "", func(entry *filer_pb.Entry, isLast bool) error {
+		if entry.IsDirectory {
+			subDirs = append(subDirs, dir.Child(entry.Name))
+		} else {
+			*paths = append(*paths, dir.Child(entry.Name))
+		}
+		return nil
+	})
+	if err != nil {
+		if err == filer_pb.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	for _, subDir := range subDirs {
+		if err = listClusterConfigDir(commandEnv, subDir, paths); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bundle *clusterConfigBundle) computeSignature(key []byte) (string, error) {
+	unsigned := *bundle
+	unsigned.Signature = ""
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (bundle *clusterConfigBundle) sign(key []byte) (err error) {
+	bundle.Signature, err = bundle.computeSignature(key)
+	return
+}
+
+func (bundle *clusterConfigBundle) verify(key []byte) error {
+	signature, err := bundle.computeSignature(key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(signature), []byte(bundle.Signature)) {
+		return fmt.Errorf("the bundle signature does not match, it is changed or signed by another key")
+	}
+	return nil
+}
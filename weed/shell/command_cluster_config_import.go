@@ -0,0 +1,126 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	gopath "path"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandClusterConfigImport{})
+}
+
+type commandClusterConfigImport struct {
+}
+
+func (c *commandClusterConfigImport) Name() string {
+	return "cluster.config.import"
+}
+
+func (c *commandClusterConfigImport) Help() string {
+	return `import the configuration bundle exported by "cluster.config.export"
+
+	# see the changes to the configuration files
+	cluster.config.import -i cluster.json
+
+	# apply the changes
+	cluster.config.import -i cluster.json -apply
+	cluster.config.import -i cluster.json -signingKey=<secret> -apply
+
+	The bundle signature is verified with the -signingKey, or "jwt.filer_signing.key" in security.toml by default.
+	The files in the bundle are created or overwritten. The other existing configuration files are kept.
+
+`
+}
+
+func (c *commandClusterConfigImport) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandClusterConfigImport) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	importCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	inputFileName := importCommand.String("i", "", "the bundle file to import")
+	signingKey := importCommand.String("signingKey", "", "the key to verify the bundle, default to jwt.filer_signing.key in security.toml")
+	apply := importCommand.Bool("apply", false, "write the configuration files")
+	if err = importCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *inputFileName == "" {
+		return fmt.Errorf("specify the bundle file by -i")
+	}
+	key, err := clusterConfigSigningKey(*signingKey)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*inputFileName)
+	if err != nil {
+		return fmt.Errorf("read %s: %v", *inputFileName, err)
+	}
+	bundle := &clusterConfigBundle{}
+	if err = json.Unmarshal(data, bundle); err != nil {
+		return fmt.Errorf("parse %s: %v", *inputFileName, err)
+	}
+	if bundle.Version != clusterConfigBundleVersion {
+		return fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	if err = bundle.verify(key); err != nil {
+		return err
+	}
+	for _, file := range bundle.Files {
+		if !isClusterConfigPath(util.FullPath(file.Path)) {
+			return fmt.Errorf("unexpected file %s in the bundle", file.Path)
+		}
+	}
+	fmt.Fprintf(writer, "bundle of %d files exported from %s at %v\n", len(bundle.Files), bundle.Filer, bundle.CreatedAt)
+
+	infoAboutSimulationMode(writer, *apply, "-apply")
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		var changed int
+		for _, file := range bundle.Files {
+			dir, name := util.FullPath(file.Path).DirAndName()
+			var buf bytes.Buffer
+			action := "update"
+			if readErr := filer.ReadEntry(commandEnv.MasterClient, client, dir, name, &buf); readErr == filer_pb.ErrNotFound {
+				action = "create"
+			} else if readErr != nil {
+				return fmt.Errorf("read %s: %v", file.Path, readErr)
+			} else if bytes.Equal(buf.Bytes(), file.Content) {
+				continue
+			}
+			changed++
+			fmt.Fprintf(writer, "%s %s, %d bytes\n", action, file.Path, len(file.Content))
+			if !*apply {
+				continue
+			}
+			if err := filer.SaveInsideFiler(client, dir, name, file.Content); err != nil {
+				return fmt.Errorf("save %s: %v", file.Path, err)
+			}
+		}
+		fmt.Fprintf(writer, "%d of %d configuration files changed\n", changed, len(bundle.Files))
+		return nil
+	})
+}
+
+// isClusterConfigPath limits the import to the paths exported by cluster.config.export
+func isClusterConfigPath(path util.FullPath) bool {
+	if string(path) != gopath.Clean(string(path)) {
+		return false
+	}
+	if path.IsUnder("/etc") {
+		return true
+	}
+	parts := path.Split()
+	return len(parts) == 4 && "/"+parts[0] == filer.TopicsDir && !strings.HasPrefix(parts[1], ".") && parts[3] == filer.TopicConfFile
+}
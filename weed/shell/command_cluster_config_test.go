@@ -0,0 +1,51 @@
+package shell
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestClusterConfigBundleSignature(t *testing.T) {
+	bundle := &clusterConfigBundle{
+		Version:   clusterConfigBundleVersion,
+		CreatedAt: time.Now(),
+		Filer:     "localhost:8888",
+		Files: []clusterConfigFile{
+			{Path: "/etc/seaweedfs/filer.conf", Content: []byte(`{"locations":[]}`)},
+			{Path: "/topics/test/orders/topic.conf", Content: []byte(`{"partitionCount":6}`)},
+		},
+	}
+	if err := bundle.sign([]byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	if err := bundle.verify([]byte("secret")); err != nil {
+		t.Errorf("verify the signed bundle: %v", err)
+	}
+	if err := bundle.verify([]byte("another secret")); err == nil {
+		t.Errorf("the bundle signed by another key should be rejected")
+	}
+	bundle.Files[0].Content = []byte(`{"locations":[{"locationPrefix":"/"}]}`)
+	if err := bundle.verify([]byte("secret")); err == nil {
+		t.Errorf("the changed bundle should be rejected")
+	}
+}
+
+func TestIsClusterConfigPath(t *testing.T) {
+	tests := map[util.FullPath]bool{
+		"/etc/seaweedfs/filer.conf":      true,
+		"/etc/iam/identity.json":         true,
+		"/etc/remote/mount.mapping":      true,
+		"/topics/test/orders/topic.conf": true,
+		"/topics/.system/log/topic.conf": false,
+		"/topics/test/orders/data":       false,
+		"/etc/../buckets/b1/x":           false,
+		"/buckets/b1/x":                  false,
+	}
+	for path, expected := range tests {
+		if isClusterConfigPath(path) != expected {
+			t.Errorf("isClusterConfigPath(%s) should be %v", path, expected)
+		}
+	}
+}
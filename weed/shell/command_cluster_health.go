@@ -0,0 +1,130 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	util_http "github.com/seaweedfs/seaweedfs/weed/util/http"
+)
+
+func init() {
+	Commands = append(Commands, &commandClusterHealth{})
+}
+
+// the exit code if the health is not known, following the monitoring plugins
+const clusterHealthUnknownExitCode = 3
+
+type commandClusterHealth struct {
+}
+
+func (c *commandClusterHealth) Name() string {
+	return "cluster.health"
+}
+
+func (c *commandClusterHealth) Help() string {
+	return `report the aggregated health of the volume servers, the filers, and the MQ brokers
+
+	cluster.health [-json] [-diskWarningPercentFree=10] [-diskCriticalPercentFree=5]
+
+	The master checks the readiness of each server, the free disk space of the volume servers,
+	the volumes with missing replicas or erasure coding shards, and the topic partitions without ready brokers.
+
+	The exit code of "weed shell" is for the monitoring scripts, e.g.,
+		echo "cluster.health" | weed shell -master=localhost:9333
+	0: ok
+	1: warning, e.g., under replicated volumes, or low disk space
+	2: critical, e.g., servers not ready, unrecoverable erasure coded volumes, or unassigned partitions
+	3: unknown, the master can not be reached
+
+`
+}
+
+func (c *commandClusterHealth) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandClusterHealth) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	healthCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	isJson := healthCommand.Bool("json", false, "output the report as JSON")
+	diskWarningPercentFree := healthCommand.Float64("diskWarningPercentFree", 10, "warn about the disks with less free space in percentage")
+	diskCriticalPercentFree := healthCommand.Float64("diskCriticalPercentFree", 5, "the disks with less free space in percentage are critical")
+	if err = healthCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	report, err := fetchClusterHealth(commandEnv, url.Values{
+		"filerGroup":              []string{*commandEnv.option.FilerGroup},
+		"diskWarningPercentFree":  []string{fmt.Sprint(*diskWarningPercentFree)},
+		"diskCriticalPercentFree": []string{fmt.Sprint(*diskCriticalPercentFree)},
+	})
+	if err != nil {
+		return &ExitCodeError{Code: clusterHealthUnknownExitCode, Err: err}
+	}
+
+	if *isJson {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printClusterHealth(writer, report)
+	}
+
+	if report.Status != cluster.HealthOk {
+		return &ExitCodeError{Code: report.ExitCode(), Err: fmt.Errorf("the cluster health is %s with %d issues", report.Status, len(report.Issues))}
+	}
+	return nil
+}
+
+func fetchClusterHealth(commandEnv *CommandEnv, values url.Values) (*cluster.HealthReport, error) {
+	master := commandEnv.MasterClient.GetMaster(context.Background())
+	healthUrl, err := util_http.NormalizeUrl(fmt.Sprintf("http://%s/cluster/health?%s", master.ToHttpAddress(), values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, healthUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := util_http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get the cluster health from master %s: %v", master, err)
+	}
+	defer util_http.CloseResponse(resp)
+	// the critical health is responded with 503
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("get the cluster health from master %s: status %d %s", master, resp.StatusCode, body)
+	}
+	report := &cluster.HealthReport{}
+	if err = json.NewDecoder(resp.Body).Decode(report); err != nil {
+		return nil, fmt.Errorf("decode the cluster health from master %s: %v", master, err)
+	}
+	return report, nil
+}
+
+func printClusterHealth(writer io.Writer, report *cluster.HealthReport) {
+	fmt.Fprintf(writer, "cluster health: %s\n", report.Status)
+	for _, node := range report.Nodes {
+		if node.Ready {
+			fmt.Fprintf(writer, "  %-12s %-24s ready\n", node.Type, node.Address)
+		} else {
+			fmt.Fprintf(writer, "  %-12s %-24s not ready: %s\n", node.Type, node.Address, node.Error)
+		}
+	}
+	if len(report.Issues) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "%d issues:\n", len(report.Issues))
+	for _, issue := range report.Issues {
+		fmt.Fprintf(writer, "  [%s] %s: %s\n", issue.Status, issue.Kind, issue.Message)
+	}
+}
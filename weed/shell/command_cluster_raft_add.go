@@ -22,6 +22,10 @@ func (c *commandRaftServerAdd) Name() string {
 func (c *commandRaftServerAdd) Help() string {
 	return `add a server to the raft cluster
 
+	To replace a master without restarting the cluster, start the new master with the other masters as -peers,
+	add it with cluster.raft.add, and then remove the old master with cluster.raft.remove.
+	Non-voters are only supported with -raftHashicorp.
+
 	Example:
 		cluster.raft.add -id <server_name> -address <server_host:port> -voter
 `
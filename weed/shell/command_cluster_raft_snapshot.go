@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandRaftSnapshot{})
+}
+
+type commandRaftSnapshot struct {
+}
+
+func (c *commandRaftSnapshot) Name() string {
+	return "cluster.raft.snapshot"
+}
+
+func (c *commandRaftSnapshot) Help() string {
+	return `snapshot the master state and compact the raft log
+
+	The masters also snapshot periodically, see the -raftSnapshotInterval and -raftSnapshotThreshold options of "weed master".
+
+	Example:
+		cluster.raft.snapshot        # snapshot the current leader
+		cluster.raft.snapshot -all   # snapshot all masters in the raft cluster
+`
+}
+
+func (c *commandRaftSnapshot) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandRaftSnapshot) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	raftSnapshotCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	all := raftSnapshotCommand.Bool("all", false, "snapshot all masters instead of only the leader")
+	if err = raftSnapshotCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	var servers []string
+	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		if *all {
+			resp, err := client.RaftListClusterServers(context.Background(), &master_pb.RaftListClusterServersRequest{})
+			if err != nil {
+				return fmt.Errorf("raft list cluster: %v", err)
+			}
+			for _, server := range resp.ClusterServers {
+				servers = append(servers, server.Id)
+			}
+			return nil
+		}
+		resp, err := client.RaftSnapshot(context.Background(), &master_pb.RaftSnapshotRequest{})
+		if err != nil {
+			return fmt.Errorf("raft snapshot: %v", err)
+		}
+		fmt.Fprintf(writer, "snapshot the leader at index %d\n", resp.LastIndex)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		err = pb.WithMasterClient(false, pb.ServerAddress(server), commandEnv.option.GrpcDialOption, false, func(client master_pb.SeaweedClient) error {
+			resp, err := client.RaftSnapshot(context.Background(), &master_pb.RaftSnapshotRequest{})
+			if err != nil {
+				return fmt.Errorf("raft snapshot %s: %v", server, err)
+			}
+			fmt.Fprintf(writer, "snapshot %s at index %d\n", server, resp.LastIndex)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
@@ -20,7 +20,10 @@ func (c *commandEcBalance) Name() string {
 func (c *commandEcBalance) Help() string {
 	return `balance all ec shards among all racks and volume servers
 
-	ec.balance [-c EACH_COLLECTION|<collection_name>] [-force] [-dataCenter <data_center>] [-shardReplicaPlacement <replica_placement>]
+	ec.balance [-c EACH_COLLECTION|<collection_name>] [-force] [-dataCenter <data_center>] [-shardReplicaPlacement <replica_placement>] [-maxMBps=50]
+
+	The option "-maxMBps" caps the bandwidth of copying each shard, enforced by the target volume servers.
+	It defaults to the -compactionMBps of the target volume servers. The copies up to -maxParallelization are capped separately.
 
 	Algorithm:
 	` + ecBalanceAlgorithmDescription
@@ -37,6 +40,7 @@ func (c *commandEcBalance) Do(args []string, commandEnv *CommandEnv, writer io.W
 	shardReplicaPlacement := balanceCommand.String("shardReplicaPlacement", "", "replica placement for EC shards, or master default if empty")
 	maxParallelization := balanceCommand.Int("maxParallelization", 10, "run up to X tasks in parallel, whenever possible")
 	applyBalancing := balanceCommand.Bool("force", false, "apply the balancing plan")
+	maxMBps := balanceCommand.Int64("maxMBps", 0, "limit the bandwidth of copying each shard in MB/s")
 	if err = balanceCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -62,5 +66,5 @@ func (c *commandEcBalance) Do(args []string, commandEnv *CommandEnv, writer io.W
 		return err
 	}
 
-	return EcBalance(commandEnv, collections, *dc, rp, *maxParallelization, *applyBalancing)
+	return EcBalance(commandEnv, collections, *dc, rp, *maxParallelization, *applyBalancing, *maxMBps*1024*1024)
 }
@@ -34,22 +34,28 @@ func (c *commandEcEncode) Help() string {
 	return `apply erasure coding to a volume
 
 	ec.encode [-collection=""] [-fullPercent=95 -quietFor=1h]
-	ec.encode [-collection=""] [-volumeId=<volume_id>]
+	ec.encode [-collection=""] [-volumeId=<volume_id>] [-scheme=6+3] [-maxMBps=50]
 
 	This command will:
 	1. freeze one volume
 	2. apply erasure coding to the volume
 	3. (optionally) re-balance encoded shards across multiple volume servers
 
-	The erasure coding is 10.4. So ideally you have more than 14 volume servers, and you can afford
+	The erasure coding is 10.4 by default. So ideally you have more than 14 volume servers, and you can afford
 	to lose 4 volume servers.
 
+	Small clusters can use smaller schemes, e.g. 4+2 or 6+3, configured for the collection on the master
+	in master.toml, or given by -scheme. The scheme is kept with the ec volume, for "ec.rebuild" and "ec.balance".
+
 	If the number of volumes are not high, the worst case is that you only have 4 volume servers,
 	and the shards are spread as 4,4,3,3, respectively. You can afford to lose one volume server.
 
 	If you only have less than 4 volume servers, with erasure coding, at least you can afford to
 	have 4 corrupted shard files.
 
+	The option "-maxMBps" caps the bandwidth of copying each shard when spreading the shards, enforced by the
+	target volume servers. It defaults to the -compactionMBps of the target volume servers.
+
 	Re-balancing algorithm:
 	` + ecBalanceAlgorithmDescription
 }
@@ -69,6 +75,8 @@ func (c *commandEcEncode) Do(args []string, commandEnv *CommandEnv, writer io.Wr
 	forceChanges := encodeCommand.Bool("force", false, "force the encoding even if the cluster has less than recommended 4 nodes")
 	shardReplicaPlacement := encodeCommand.String("shardReplicaPlacement", "", "replica placement for EC shards, or master default if empty")
 	applyBalancing := encodeCommand.Bool("rebalance", false, "re-balance EC shards after creation")
+	maxMBps := encodeCommand.Int64("maxMBps", 0, "limit the bandwidth of copying each shard in MB/s")
+	schemeOption := encodeCommand.String("scheme", "", "the erasure coding scheme, e.g. 6+3, or the scheme of the collection configured on the master if empty")
 
 	if err = encodeCommand.Parse(args); err != nil {
 		return nil
@@ -80,6 +88,10 @@ func (c *commandEcEncode) Do(args []string, commandEnv *CommandEnv, writer io.Wr
 	if err != nil {
 		return err
 	}
+	scheme, err := parseEcSchemeArg(commandEnv, *collection, *schemeOption)
+	if err != nil {
+		return err
+	}
 
 	// collect topology information
 	topologyInfo, _, err := collectTopologyInfo(commandEnv, 0)
@@ -92,8 +104,8 @@ func (c *commandEcEncode) Do(args []string, commandEnv *CommandEnv, writer io.Wr
 		eachDataNode(topologyInfo, func(dc DataCenterId, rack RackId, dn *master_pb.DataNodeInfo) {
 			nodeCount++
 		})
-		if nodeCount < erasure_coding.ParityShardsCount {
-			glog.V(0).Infof("skip erasure coding with %d nodes, less than recommended %d nodes", nodeCount, erasure_coding.ParityShardsCount)
+		if nodeCount < scheme.ParityShards {
+			glog.V(0).Infof("skip erasure coding %s with %d nodes, less than recommended %d nodes", scheme, nodeCount, scheme.ParityShards)
 			return nil
 		}
 	}
@@ -119,19 +131,19 @@ func (c *commandEcEncode) Do(args []string, commandEnv *CommandEnv, writer io.Wr
 
 	// encode all requested volumes...
 	for _, vid := range volumeIds {
-		if err = doEcEncode(commandEnv, *collection, vid, *maxParallelization); err != nil {
+		if err = doEcEncode(commandEnv, *collection, vid, scheme, *maxParallelization); err != nil {
 			return fmt.Errorf("ec encode for volume %d: %v", vid, err)
 		}
 	}
 	// ...then re-balance ec shards.
-	if err := EcBalance(commandEnv, collections, "", rp, *maxParallelization, *applyBalancing); err != nil {
+	if err := EcBalance(commandEnv, collections, "", rp, *maxParallelization, *applyBalancing, *maxMBps*1024*1024); err != nil {
 		return fmt.Errorf("re-balance ec shards for collection(s) %v: %v", collections, err)
 	}
 
 	return nil
 }
 
-func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId, maxParallelization int) error {
+func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId, scheme erasure_coding.Scheme, maxParallelization int) error {
 	var ewg *ErrorWaitGroup
 
 	if !commandEnv.isLocked() {
@@ -160,7 +172,7 @@ func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId,
 	}
 
 	// generate ec shards
-	if err := generateEcShards(commandEnv.option.GrpcDialOption, vid, collection, target.ServerAddress()); err != nil {
+	if err := generateEcShards(commandEnv.option.GrpcDialOption, vid, collection, scheme, target.ServerAddress()); err != nil {
 		return fmt.Errorf("generate ec shards for volume %d on %s: %v", vid, target.Url, err)
 	}
 
@@ -180,7 +192,7 @@ func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId,
 	}
 
 	// mount all ec shards for the converted volume
-	shardIds := make([]uint32, erasure_coding.TotalShardsCount)
+	shardIds := make([]uint32, scheme.TotalShards())
 	for i := range shardIds {
 		shardIds[i] = uint32(i)
 	}
@@ -191,14 +203,16 @@ func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId,
 	return nil
 }
 
-func generateEcShards(grpcDialOption grpc.DialOption, volumeId needle.VolumeId, collection string, sourceVolumeServer pb.ServerAddress) error {
+func generateEcShards(grpcDialOption grpc.DialOption, volumeId needle.VolumeId, collection string, scheme erasure_coding.Scheme, sourceVolumeServer pb.ServerAddress) error {
 
-	fmt.Printf("generateEcShards %s %d on %s ...\n", collection, volumeId, sourceVolumeServer)
+	fmt.Printf("generateEcShards %s %d with %s on %s ...\n", collection, volumeId, scheme, sourceVolumeServer)
 
 	err := operation.WithVolumeServerClient(false, sourceVolumeServer, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
 		_, genErr := volumeServerClient.VolumeEcShardsGenerate(context.Background(), &volume_server_pb.VolumeEcShardsGenerateRequest{
-			VolumeId:   uint32(volumeId),
-			Collection: collection,
+			VolumeId:     uint32(volumeId),
+			Collection:   collection,
+			DataShards:   uint32(scheme.DataShards),
+			ParityShards: uint32(scheme.ParityShards),
 		})
 		return genErr
 	})
@@ -207,6 +221,29 @@ func generateEcShards(grpcDialOption grpc.DialOption, volumeId needle.VolumeId,
 
 }
 
+// parseEcSchemeArg parses the -scheme option, or looks up the scheme of the collection configured on the master
+func parseEcSchemeArg(commandEnv *CommandEnv, collection string, schemeOption string) (erasure_coding.Scheme, error) {
+	if schemeOption != "" {
+		return erasure_coding.ParseScheme(schemeOption)
+	}
+	var resp *master_pb.CollectionListResponse
+	err := commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) (err error) {
+		resp, err = client.CollectionList(context.Background(), &master_pb.CollectionListRequest{
+			IncludeNormalVolumes: true,
+		})
+		return err
+	})
+	if err != nil {
+		return erasure_coding.Scheme{}, err
+	}
+	for _, c := range resp.Collections {
+		if c.Name == collection {
+			return erasure_coding.NewScheme(c.EcDataShards, c.EcParityShards), nil
+		}
+	}
+	return erasure_coding.DefaultScheme, nil
+}
+
 func collectVolumeIdsForEcEncode(commandEnv *CommandEnv, selectedCollection string, fullPercentage float64, quietPeriod time.Duration) (vids []needle.VolumeId, err error) {
 	// collect topology information
 	topologyInfo, volumeSizeLimitMb, err := collectTopologyInfo(commandEnv, 0)
@@ -5,6 +5,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 )
 
 func TestCommandEcBalanceSmall(t *testing.T) {
@@ -115,6 +116,34 @@ func TestCommandEcBalanceVolumeEvenButRackUneven(t *testing.T) {
 	ecb.balanceEcRacks()
 }
 
+func TestCommandEcBalanceWithScheme(t *testing.T) {
+	dn1 := newEcNode("dc1", "rack1", "dn1", 100).addEcVolumeAndShardsForTest(1, "c1", []uint32{0, 1, 2, 3, 4, 5})
+	for _, shardInfo := range dn1.info.DiskInfos[string(types.HardDriveType)].EcShardInfos {
+		shardInfo.DataShards, shardInfo.ParityShards = 4, 2
+	}
+	ecb := &ecBalancer{
+		ecNodes: []*EcNode{
+			dn1,
+			newEcNode("dc1", "rack2", "dn2", 100),
+			newEcNode("dc1", "rack3", "dn3", 100),
+		},
+		applyBalancing: false,
+	}
+
+	if scheme := findEcVolumeScheme(ecb.ecNodes, 1); scheme.String() != "4+2" {
+		t.Fatalf("unexpected scheme %s", scheme)
+	}
+	if err := ecb.balanceEcVolumes("c1"); err != nil {
+		t.Fatal(err)
+	}
+	// the 6 shards of 4+2 are spread as 2 in each rack, so losing a rack loses no more than the parity shards
+	for _, ecNode := range ecb.ecNodes {
+		if count := findEcVolumeShards(ecNode, 1).ShardIdCount(); count != 2 {
+			t.Errorf("%s has %d shards", ecNode.info.Id, count)
+		}
+	}
+}
+
 func newEcNode(dc string, rack string, dataNodeId string, freeEcSlot int) *EcNode {
 	return &EcNode{
 		info: &master_pb.DataNodeInfo{
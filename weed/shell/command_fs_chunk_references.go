@@ -0,0 +1,150 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsChunkReferences{})
+}
+
+type commandFsChunkReferences struct {
+}
+
+func (c *commandFsChunkReferences) Name() string {
+	return "fs.chunk.references"
+}
+
+func (c *commandFsChunkReferences) Help() string {
+	return `recount the references of the chunks shared by the cloned files
+
+	fs.chunk.references          # list the shared chunks with wrong reference counts
+	fs.chunk.references -apply   # fix the reference counts
+
+	The chunks shared by cloned files, e.g., by copy_file_range on the mount, or the s3 copy within a bucket,
+	are reference counted, and only deleted when the last file referencing them is deleted.
+	If the filer fails in the middle of a clone, the count may keep a reference of no file, and the chunk
+	would never be deleted. This command scans all files, and compares the number of files referencing each
+	shared chunk with its count. The hard links of one file count as one file.
+
+	Since files cloned during the scan may be miscounted, only apply when no files are being cloned.
+	The shared chunks are in use by the files, so "volume.fsck" never purges them.
+
+`
+}
+
+func (c *commandFsChunkReferences) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandFsChunkReferences) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	fsChunkReferencesCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	apply := fsChunkReferencesCommand.Bool("apply", false, "fix the reference counts")
+	if err = fsChunkReferencesCommand.Parse(args); err != nil {
+		return nil
+	}
+	infoAboutSimulationMode(writer, *apply, "-apply")
+
+	// the number of files referencing each shared chunk, counted from the root, so no references are missed
+	var lock sync.Mutex
+	references := make(map[string]uint32)
+	shared := make(map[string]bool)
+	seenHardLinks := make(map[string]bool)
+	var resolveErr error
+	err = filer_pb.TraverseBfs(commandEnv, util.FullPath("/"), func(parentPath util.FullPath, entry *filer_pb.Entry) {
+		if entry.IsDirectory || len(entry.GetChunks()) == 0 {
+			return
+		}
+		dataChunks, _, err := filer.ResolveChunkManifest(filer.LookupFn(commandEnv), entry.GetChunks(), 0, math.MaxInt64)
+
+		lock.Lock()
+		defer lock.Unlock()
+		if err != nil {
+			resolveErr = fmt.Errorf("resolve chunks of %s: %v", parentPath.Child(entry.Name), err)
+			return
+		}
+		if len(entry.HardLinkId) > 0 {
+			if seenHardLinks[string(entry.HardLinkId)] {
+				return
+			}
+			seenHardLinks[string(entry.HardLinkId)] = true
+		}
+		for _, chunk := range dataChunks {
+			fileId := chunk.GetFileIdString()
+			references[fileId]++
+			if chunk.IsShared {
+				shared[fileId] = true
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	// the counts can not be trusted if any file is not counted
+	if resolveErr != nil {
+		return resolveErr
+	}
+
+	var fileIds []string
+	for fileId := range shared {
+		fileIds = append(fileIds, fileId)
+	}
+	sort.Strings(fileIds)
+
+	var wrongCount, fixedCount int
+	err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		for _, fileId := range fileIds {
+			key := filer.ChunkReferenceKey(fileId)
+			resp, err := client.KvGet(context.Background(), &filer_pb.KvGetRequest{Key: key})
+			if err != nil {
+				return fmt.Errorf("read reference count of chunk %s: %v", fileId, err)
+			}
+			if resp.Error != "" {
+				return fmt.Errorf("read reference count of chunk %s: %s", fileId, resp.Error)
+			}
+			// the count is not kept for a chunk referenced by at most one file
+			counted, expected := filer.DecodeChunkReferenceCount(resp.Value), references[fileId]
+			if expected <= 1 {
+				expected = 0
+			}
+			if counted == expected {
+				continue
+			}
+			wrongCount++
+			fmt.Fprintf(writer, "chunk %s is referenced by %d files, counted as %d\n", fileId, references[fileId], counted)
+			if !*apply {
+				continue
+			}
+			var value []byte
+			if expected > 0 {
+				value = filer.EncodeChunkReferenceCount(expected)
+			}
+			putResp, err := client.KvPut(context.Background(), &filer_pb.KvPutRequest{Key: key, Value: value})
+			if err != nil {
+				return fmt.Errorf("fix reference count of chunk %s: %v", fileId, err)
+			}
+			if putResp.Error != "" {
+				return fmt.Errorf("fix reference count of chunk %s: %s", fileId, putResp.Error)
+			}
+			fixedCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "%d shared chunks, %d with wrong reference counts, %d fixed\n", len(fileIds), wrongCount, fixedCount)
+	return nil
+}
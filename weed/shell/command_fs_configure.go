@@ -34,9 +34,27 @@ func (c *commandFsConfigure) Help() string {
 	fs.configure -locationPrefix=/my/folder -collection=abc
 	fs.configure -locationPrefix=/my/folder -collection=abc -ttl=7d
 
+	# example: compress text and json with zstd, never compress videos
+	fs.configure -locationPrefix=/my/folder -compression=zstd -compressMimeTypes=text/*,application/json -skipCompressMimeTypes=video/*
+
 	# example: configure adding only 1 physical volume for each bucket collection
 	fs.configure -locationPrefix=/buckets/ -volumeGrowthCount=1
 
+	# example: an immutable content addressed location, files are named by the sha256 of the content
+	fs.configure -locationPrefix=/artifacts/ -contentAddressed -contentAddressedRetention=31536000
+
+	# example: a write-once location for audit logs, each written file can be changed within 10 minutes,
+	# and then can not be changed, moved, or deleted for 7 years, through the filer, S3, and the mounts
+	fs.configure -locationPrefix=/buckets/audit/ -worm -wormGracePeriod=600 -wormRetentionTime=220752000
+
+	# example: keep 10 previous versions of the overwritten or deleted files, each for at most 30 days,
+	# listed by "GET /path/to/file?versions=true", and restored by "POST /path/to/file?restoreVersion=<version id>"
+	fs.configure -locationPrefix=/home/ -maxVersions=10 -versionRetention=2592000
+
+	# example: move the deleted files and directories to /.trash, and purge them after 7 days,
+	# listed by "fs.trash.list", and restored by "fs.trash.restore"
+	fs.configure -locationPrefix=/home/ -trashRetention=604800
+
 	# apply the changes
 	fs.configure -locationPrefix=/my/folder -collection=abc -apply
 
@@ -58,16 +76,25 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 	replication := fsConfigureCommand.String("replication", "", "assign writes with this replication")
 	ttl := fsConfigureCommand.String("ttl", "", "assign writes with this ttl (e.g., 1m, 1h, 1d, 1w, 1y)")
 	diskType := fsConfigureCommand.String("disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
+	latencyClass := fsConfigureCommand.String("latencyClass", "", "[high|bulk|<class in master.toml>] high prefers ssd and lightly loaded volume servers, bulk goes to hdd")
 	fsync := fsConfigureCommand.Bool("fsync", false, "fsync for the writes")
 	isReadOnly := fsConfigureCommand.Bool("readOnly", false, "disable writes")
-	worm := fsConfigureCommand.Bool("worm", false, "write-once-read-many, written files are readonly")
-	wormGracePeriod := fsConfigureCommand.Uint64("wormGracePeriod", 0, "grace period before worm is enforced, in seconds")
+	worm := fsConfigureCommand.Bool("worm", false, "write-once-read-many, files written with data or set to readonly can not be changed, moved, or deleted")
+	wormGracePeriod := fsConfigureCommand.Uint64("wormGracePeriod", 0, "grace period before worm is enforced on a written file, in seconds, e.g., for the mounts writing files in several flushes")
 	wormRetentionTime := fsConfigureCommand.Uint64("wormRetentionTime", 0, "retention time for a worm enforced file, in seconds")
+	contentAddressed := fsConfigureCommand.Bool("contentAddressed", false, "name the files by the sha256 of the content, rewriting the same content is a no-op, and the files can not be changed or deleted")
+	contentAddressedRetention := fsConfigureCommand.Uint64("contentAddressedRetention", 0, "the content addressed files can be deleted after this retention time, in seconds. 0 means never")
+	maxVersions := fsConfigureCommand.Uint("maxVersions", 0, "keep this many previous versions of the overwritten or deleted files under /.versions, 0 to keep none")
+	versionRetention := fsConfigureCommand.Uint64("versionRetention", 0, "delete the previous versions replaced longer ago than this, in seconds. 0 means never")
+	trashRetention := fsConfigureCommand.Uint64("trashRetention", 0, "move the deleted files and directories to /.trash, and purge them after this, in seconds. 0 means deleting directly")
 	maxFileNameLength := fsConfigureCommand.Uint("maxFileNameLength", 0, "file name length limits in bytes for compatibility with Unix-based systems")
 	dataCenter := fsConfigureCommand.String("dataCenter", "", "assign writes to this dataCenter")
 	rack := fsConfigureCommand.String("rack", "", "assign writes to this rack")
 	dataNode := fsConfigureCommand.String("dataNode", "", "assign writes to this dataNode")
 	volumeGrowthCount := fsConfigureCommand.Int("volumeGrowthCount", 0, "the number of physical volumes to add if no writable volumes")
+	compression := fsConfigureCommand.String("compression", "", "[gzip|zstd] compression for the matched mime types")
+	compressMimeTypes := fsConfigureCommand.String("compressMimeTypes", "", "comma separated mime types to always compress, e.g. text/*,application/json")
+	skipCompressMimeTypes := fsConfigureCommand.String("skipCompressMimeTypes", "", "comma separated mime types to never compress, e.g. video/*")
 	isDelete := fsConfigureCommand.Bool("delete", false, "delete the configuration by locationPrefix")
 	apply := fsConfigureCommand.Bool("apply", false, "update and apply filer configuration")
 	if err = fsConfigureCommand.Parse(args); err != nil {
@@ -82,21 +109,30 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 	if *locationPrefix != "" {
 		infoAboutSimulationMode(writer, *apply, "-apply")
 		locConf := &filer_pb.FilerConf_PathConf{
-			LocationPrefix:           *locationPrefix,
-			Collection:               *collection,
-			Replication:              *replication,
-			Ttl:                      *ttl,
-			Fsync:                    *fsync,
-			MaxFileNameLength:        uint32(*maxFileNameLength),
-			DiskType:                 *diskType,
-			VolumeGrowthCount:        uint32(*volumeGrowthCount),
-			ReadOnly:                 *isReadOnly,
-			DataCenter:               *dataCenter,
-			Rack:                     *rack,
-			DataNode:                 *dataNode,
-			Worm:                     *worm,
-			WormGracePeriodSeconds:   *wormGracePeriod,
-			WormRetentionTimeSeconds: *wormRetentionTime,
+			LocationPrefix:                   *locationPrefix,
+			Collection:                       *collection,
+			Replication:                      *replication,
+			Ttl:                              *ttl,
+			Fsync:                            *fsync,
+			MaxFileNameLength:                uint32(*maxFileNameLength),
+			DiskType:                         *diskType,
+			LatencyClass:                     *latencyClass,
+			VolumeGrowthCount:                uint32(*volumeGrowthCount),
+			ReadOnly:                         *isReadOnly,
+			DataCenter:                       *dataCenter,
+			Rack:                             *rack,
+			DataNode:                         *dataNode,
+			Worm:                             *worm,
+			WormGracePeriodSeconds:           *wormGracePeriod,
+			WormRetentionTimeSeconds:         *wormRetentionTime,
+			ContentAddressed:                 *contentAddressed,
+			ContentAddressedRetentionSeconds: *contentAddressedRetention,
+			MaxVersions:                      uint32(*maxVersions),
+			VersionRetentionSeconds:          *versionRetention,
+			TrashRetentionSeconds:            *trashRetention,
+			Compression:                      *compression,
+			CompressMimeTypes:                splitMimeTypes(*compressMimeTypes),
+			SkipCompressMimeTypes:            splitMimeTypes(*skipCompressMimeTypes),
 		}
 
 		// check collection
@@ -115,6 +151,11 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 			}
 		}
 
+		// check compression
+		if *compression != "" && *compression != "gzip" && *compression != "zstd" {
+			return fmt.Errorf("compression should be gzip or zstd")
+		}
+
 		// check ttl
 		if *ttl != "" {
 			regex := "^(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)[mhdwMy]$"
@@ -153,6 +194,15 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 
 }
 
+func splitMimeTypes(mimeTypes string) (list []string) {
+	for _, t := range strings.Split(mimeTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			list = append(list, t)
+		}
+	}
+	return
+}
+
 func infoAboutSimulationMode(writer io.Writer, forceMode bool, forceModeOption string) {
 	if forceMode {
 		return
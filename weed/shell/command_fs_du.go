@@ -1,6 +1,8 @@
 package shell
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"io"
 
@@ -21,11 +23,15 @@ func (c *commandFsDu) Name() string {
 }
 
 func (c *commandFsDu) Help() string {
-	return `show disk usage
+	return `show disk usage, and the savings of the compressed chunks
 
 	fs.du /dir
 	fs.du /dir/file_name
 	fs.du /dir/file_prefix
+	fs.du -cached /dir           # the recursive size and counts of a directory, cached by the filer
+	fs.du -cached -recount /dir  # traverse the directory on the filer, and recount its cached statistics
+
+	The filer caches the statistics of the directories up to its -dirStats.depth, and traverses the deeper ones.
 `
 }
 
@@ -34,55 +40,108 @@ func (c *commandFsDu) HasTag(CommandTag) bool {
 }
 
 func (c *commandFsDu) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+	duCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	cached := duCommand.Bool("cached", false, "read the recursive statistics of the directory from the filer")
+	recount := duCommand.Bool("recount", false, "with -cached, traverse the directory on the filer, and recount its cached statistics")
+	if err = duCommand.Parse(args); err != nil {
+		return nil
+	}
 
-	path, err := commandEnv.parseUrl(findInputDirectory(args))
+	path, err := commandEnv.parseUrl(findInputDirectory(duCommand.Args()))
 	if err != nil {
 		return err
 	}
 
+	if *cached {
+		return duCachedDirectory(writer, commandEnv, path, *recount)
+	}
+
 	if commandEnv.isDirectory(path) {
 		path = path + "/"
 	}
 
-	var blockCount, byteCount uint64
+	var blockCount, byteCount, storedCount uint64
 	dir, name := util.FullPath(path).DirAndName()
-	blockCount, byteCount, err = duTraverseDirectory(writer, commandEnv, dir, name)
+	blockCount, byteCount, storedCount, err = duTraverseDirectory(writer, commandEnv, dir, name)
 
 	if name == "" && err == nil {
-		fmt.Fprintf(writer, "block:%4d\tlogical size:%10d\t%s\n", blockCount, byteCount, dir)
+		fmt.Fprintf(writer, "block:%4d\tlogical size:%10d\tstored size:%10d%s\t%s\n", blockCount, byteCount, storedCount, compressionSavings(byteCount, storedCount), dir)
 	}
 
 	return
 
 }
 
-func duTraverseDirectory(writer io.Writer, filerClient filer_pb.FilerClient, dir, name string) (blockCount, byteCount uint64, err error) {
+func duTraverseDirectory(writer io.Writer, filerClient filer_pb.FilerClient, dir, name string) (blockCount, byteCount, storedCount uint64, err error) {
 
 	err = filer_pb.ReadDirAllEntries(filerClient, util.FullPath(dir), name, func(entry *filer_pb.Entry, isLast bool) error {
 
-		var fileBlockCount, fileByteCount uint64
+		var fileBlockCount, fileByteCount, fileStoredCount uint64
 
 		if entry.IsDirectory {
 			subDir := fmt.Sprintf("%s/%s", dir, entry.Name)
 			if dir == "/" {
 				subDir = "/" + entry.Name
 			}
-			numBlock, numByte, err := duTraverseDirectory(writer, filerClient, subDir, "")
+			numBlock, numByte, numStored, err := duTraverseDirectory(writer, filerClient, subDir, "")
 			if err == nil {
 				blockCount += numBlock
 				byteCount += numByte
+				storedCount += numStored
 			}
 		} else {
 			fileBlockCount = uint64(len(entry.GetChunks()))
 			fileByteCount = filer.FileSize(entry)
+			fileStoredCount = storedSize(entry)
 			blockCount += fileBlockCount
 			byteCount += fileByteCount
+			storedCount += fileStoredCount
 		}
 
 		if name != "" && !entry.IsDirectory {
-			fmt.Fprintf(writer, "block:%4d\tlogical size:%10d\t%s/%s\n", fileBlockCount, fileByteCount, dir, entry.Name)
+			fmt.Fprintf(writer, "block:%4d\tlogical size:%10d\tstored size:%10d%s\t%s/%s\n", fileBlockCount, fileByteCount, fileStoredCount, compressionSavings(fileByteCount, fileStoredCount), dir, entry.Name)
 		}
 		return nil
 	})
 	return
 }
+
+func duCachedDirectory(writer io.Writer, commandEnv *CommandEnv, dir string, recount bool) error {
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.GetDirectoryStats(context.Background(), &filer_pb.GetDirectoryStatsRequest{
+			Directory: dir,
+			Recount:   recount,
+		})
+		if err != nil {
+			return err
+		}
+		source := "traversed"
+		if resp.IsCached {
+			source = "cached"
+		}
+		fmt.Fprintf(writer, "files:%10d\tdirectories:%10d\tlogical size:%10d\t%s\t(%s)\n", resp.FileCount, resp.DirectoryCount, resp.TotalBytes, dir, source)
+		return nil
+	})
+}
+
+// storedSize counts compressed chunks by their size on the volume servers
+func storedSize(entry *filer_pb.Entry) (size uint64) {
+	if len(entry.GetChunks()) == 0 {
+		return filer.FileSize(entry)
+	}
+	for _, chunk := range entry.GetChunks() {
+		if chunk.IsCompressed && chunk.CompressedSize > 0 {
+			size += chunk.CompressedSize
+		} else {
+			size += chunk.Size
+		}
+	}
+	return
+}
+
+func compressionSavings(logical, stored uint64) string {
+	if stored >= logical {
+		return ""
+	}
+	return fmt.Sprintf("\tcompression saved:%10d (%.1f%%)", logical-stored, float64(logical-stored)*100/float64(logical))
+}
@@ -0,0 +1,60 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsLogStatus{})
+}
+
+type commandFsLogStatus struct {
+}
+
+func (c *commandFsLogStatus) Name() string {
+	return "fs.log.status"
+}
+
+func (c *commandFsLogStatus) Help() string {
+	return `show the earliest change in the filer metadata log, and how the log is kept
+
+	fs.log.status
+
+	The metadata subscribers, e.g., "weed filer.sync" or "weed filer.backup", can resume from any time after the earliest change.
+`
+}
+
+func (c *commandFsLogStatus) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandFsLogStatus) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+	fsLogStatusCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	if err = fsLogStatusCommand.Parse(args); err != nil {
+		return err
+	}
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.GetMetadataLogStatus(context.Background(), &filer_pb.GetMetadataLogStatusRequest{})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "earliest change: %v\n", time.Unix(0, resp.EarliestTsNs).UTC())
+		if resp.RetentionSeconds > 0 {
+			fmt.Fprintf(writer, "retention: %v\n", time.Duration(resp.RetentionSeconds)*time.Second)
+		} else {
+			fmt.Fprintf(writer, "retention: forever\n")
+		}
+		fmt.Fprintf(writer, "segment: %v\n", time.Duration(resp.SegmentSeconds)*time.Second)
+		if resp.CompactAfterSeconds > 0 {
+			fmt.Fprintf(writer, "compact after: %v\n", time.Duration(resp.CompactAfterSeconds)*time.Second)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,211 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsQuota{})
+}
+
+type commandFsQuota struct {
+}
+
+func (c *commandFsQuota) Name() string {
+	return "fs.quota"
+}
+
+func (c *commandFsQuota) Help() string {
+	return `set, remove, or show the quotas of directories
+
+	fs.quota                                            # list the quota directories and their usage
+	fs.quota /dir                                       # show the quota and the usage of the directory
+	fs.quota -maxBytes=10GiB -maxEntries=100000 /dir    # set the quota of the directory
+	fs.quota -clear /dir                                # remove the quota of the directory
+	fs.quota -recount /dir                              # recount the usage of the directory
+
+	A quota limits the total size of the files, and the number of the files and directories, under the directory,
+	including those in the sub directories. Writes and renames into the directory going over the quota are rejected.
+	The limits are kept as the extended attributes of the directory, and the filer counts the usage of the existing
+	entries when the quota is set.
+
+	The usage is counted by the filer as the entries change. If it drifts, e.g., after the filers sharing one filer
+	store update it concurrently, "-recount" counts it again from the entries. Only recount when the directory
+	is not being written.
+
+`
+}
+
+func (c *commandFsQuota) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandFsQuota) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	fsQuotaCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	maxBytes := fsQuotaCommand.String("maxBytes", "", "the total size limit of the files, e.g., 100MiB or 10GiB")
+	maxEntries := fsQuotaCommand.Int64("maxEntries", 0, "the limit of the number of files and directories")
+	clearQuota := fsQuotaCommand.Bool("clear", false, "remove the quota")
+	recount := fsQuotaCommand.Bool("recount", false, "recount the usage")
+	if err = fsQuotaCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if fsQuotaCommand.NArg() == 0 {
+		return c.listQuotas(commandEnv, writer)
+	}
+	path, err := commandEnv.parseUrl(fsQuotaCommand.Arg(0))
+	if err != nil {
+		return err
+	}
+	dir := util.FullPath(path)
+
+	if *clearQuota || *maxBytes != "" || *maxEntries > 0 {
+		var quota filer.DirQuota
+		if !*clearQuota {
+			if *maxBytes != "" {
+				parsed, parseErr := util.ParseBytes(*maxBytes)
+				if parseErr != nil {
+					return fmt.Errorf("parse -maxBytes %s: %v", *maxBytes, parseErr)
+				}
+				quota.MaxBytes = int64(parsed)
+			}
+			quota.MaxEntries = *maxEntries
+		}
+		if err = c.setQuota(commandEnv, dir, quota); err != nil {
+			return err
+		}
+	}
+
+	if *recount {
+		usage, countErr := c.countUsage(commandEnv, dir)
+		if countErr != nil {
+			return countErr
+		}
+		if err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			resp, err := client.KvPut(context.Background(), &filer_pb.KvPutRequest{
+				Key:   filer.DirQuotaUsageKey(dir),
+				Value: filer.EncodeDirQuotaUsage(usage),
+			})
+			if err != nil {
+				return err
+			}
+			if resp.Error != "" {
+				return fmt.Errorf("%s", resp.Error)
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("save usage of %s: %v", dir, err)
+		}
+	}
+
+	if *clearQuota {
+		fmt.Fprintf(writer, "removed the quota of %s\n", dir)
+		return nil
+	}
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return printDirQuota(client, writer, dir)
+	})
+}
+
+func (c *commandFsQuota) setQuota(commandEnv *CommandEnv, dir util.FullPath, quota filer.DirQuota) error {
+	parent, name := dir.DirAndName()
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+			Directory: parent,
+			Name:      name,
+		})
+		if err != nil {
+			return fmt.Errorf("lookup %s: %v", dir, err)
+		}
+		if !resp.Entry.IsDirectory {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+		if resp.Entry.Extended == nil {
+			resp.Entry.Extended = make(map[string][]byte)
+		}
+		filer.SetDirQuota(resp.Entry.Extended, quota)
+		return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory: parent,
+			Entry:     resp.Entry,
+		})
+	})
+}
+
+func (c *commandFsQuota) countUsage(commandEnv *CommandEnv, dir util.FullPath) (usage filer.DirQuotaUsage, err error) {
+	var lock sync.Mutex
+	err = filer_pb.TraverseBfs(commandEnv, dir, func(parentPath util.FullPath, entry *filer_pb.Entry) {
+		lock.Lock()
+		defer lock.Unlock()
+		usage.Entries++
+		if !entry.IsDirectory {
+			usage.Bytes += int64(filer.FileSize(entry))
+		}
+	})
+	if err != nil {
+		return usage, fmt.Errorf("count usage of %s: %v", dir, err)
+	}
+	return usage, nil
+}
+
+func (c *commandFsQuota) listQuotas(commandEnv *CommandEnv, writer io.Writer) error {
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.KvGet(context.Background(), &filer_pb.KvGetRequest{Key: []byte(filer.DirQuotaListKey)})
+		if err != nil {
+			return fmt.Errorf("read quota directories: %v", err)
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("read quota directories: %s", resp.Error)
+		}
+		dirs := filer.DecodeDirQuotaList(resp.Value)
+		for _, dir := range dirs {
+			if err = printDirQuota(client, writer, dir); err != nil {
+				fmt.Fprintf(writer, "%s: %v\n", dir, err)
+			}
+		}
+		fmt.Fprintf(writer, "%d quota directories\n", len(dirs))
+		return nil
+	})
+}
+
+func printDirQuota(client filer_pb.SeaweedFilerClient, writer io.Writer, dir util.FullPath) error {
+	parent, name := dir.DirAndName()
+	lookupResp, err := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+		Directory: parent,
+		Name:      name,
+	})
+	if err != nil {
+		return fmt.Errorf("lookup %s: %v", dir, err)
+	}
+	quota, found := filer.GetDirQuota(lookupResp.Entry.Extended)
+	if !found {
+		fmt.Fprintf(writer, "%s has no quota\n", dir)
+		return nil
+	}
+	kvResp, err := client.KvGet(context.Background(), &filer_pb.KvGetRequest{Key: filer.DirQuotaUsageKey(dir)})
+	if err != nil {
+		return fmt.Errorf("read usage of %s: %v", dir, err)
+	}
+	if kvResp.Error != "" {
+		return fmt.Errorf("read usage of %s: %s", dir, kvResp.Error)
+	}
+	usage := filer.DecodeDirQuotaUsage(kvResp.Value)
+
+	bytesLimit, entriesLimit := "unlimited", "unlimited"
+	if quota.MaxBytes > 0 {
+		bytesLimit = util.BytesToHumanReadable(uint64(quota.MaxBytes))
+	}
+	if quota.MaxEntries > 0 {
+		entriesLimit = fmt.Sprintf("%d", quota.MaxEntries)
+	}
+	fmt.Fprintf(writer, "%s\tbytes: %s of %s\tentries: %d of %s\n", dir, util.BytesToHumanReadable(uint64(usage.Bytes)), bytesLimit, usage.Entries, entriesLimit)
+	return nil
+}
@@ -0,0 +1,77 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsTrashList{})
+}
+
+type commandFsTrashList struct {
+}
+
+func (c *commandFsTrashList) Name() string {
+	return "fs.trash.list"
+}
+
+func (c *commandFsTrashList) Help() string {
+	return `list the deleted files and directories in the trash
+
+	fs.trash.list                   # list all the entries in the trash, the oldest deleted first
+	fs.trash.list /home/chris       # list the entries deleted under /home/chris
+	fs.trash.list -limit=100 /home
+
+	The locations configured with "fs.configure -trashRetention" move the deleted entries to /.trash,
+	where they are purged after the retention. Restore them with "fs.trash.restore".
+
+`
+}
+
+func (c *commandFsTrashList) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandFsTrashList) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	trashListCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	limit := trashListCommand.Uint("limit", 0, "list at most this many entries, 0 for all")
+	if err = trashListCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	pathPrefix := ""
+	if trashListCommand.NArg() > 0 {
+		if pathPrefix, err = commandEnv.parseUrl(trashListCommand.Arg(0)); err != nil {
+			return err
+		}
+	}
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.ListTrashEntries(context.Background(), &filer_pb.ListTrashEntriesRequest{
+			PathPrefix: pathPrefix,
+			Limit:      uint32(*limit),
+		})
+		if err != nil {
+			return err
+		}
+		for _, trashEntry := range resp.Entries {
+			kind := "file"
+			size := filer.FileSize(trashEntry.Entry)
+			if trashEntry.Entry.IsDirectory {
+				kind = "dir"
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%d\t%s\tdeleted %s\tpurge %s\n", trashEntry.TrashName, kind, size, trashEntry.OriginalPath,
+				time.Unix(0, trashEntry.DeletedAtNs).Format(time.RFC3339), time.Unix(0, trashEntry.PurgeAtNs).Format(time.RFC3339))
+		}
+		fmt.Fprintf(writer, "total %d entries\n", len(resp.Entries))
+		return nil
+	})
+}
@@ -0,0 +1,69 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsTrashRestore{})
+}
+
+type commandFsTrashRestore struct {
+}
+
+func (c *commandFsTrashRestore) Name() string {
+	return "fs.trash.restore"
+}
+
+func (c *commandFsTrashRestore) Help() string {
+	return `restore a deleted file or directory from the trash
+
+	fs.trash.restore -name=<trash name>                    # restore to the original path
+	fs.trash.restore -name=<trash name> -to=/dir/new_name  # restore to another path
+
+	The trash names are listed by "fs.trash.list". A directory is restored with everything under it.
+	The restore fails if the path already exists.
+
+`
+}
+
+func (c *commandFsTrashRestore) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandFsTrashRestore) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	trashRestoreCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	trashName := trashRestoreCommand.String("name", "", "the name in the trash, as listed by fs.trash.list")
+	to := trashRestoreCommand.String("to", "", "restore to this path instead of the original path")
+	if err = trashRestoreCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if *trashName == "" {
+		return fmt.Errorf("empty trash name")
+	}
+	newPath := ""
+	if *to != "" {
+		if newPath, err = commandEnv.parseUrl(*to); err != nil {
+			return err
+		}
+	}
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.RestoreTrashEntry(context.Background(), &filer_pb.RestoreTrashEntryRequest{
+			TrashName: *trashName,
+			NewPath:   newPath,
+		})
+		if err != nil {
+			return fmt.Errorf("restore %s: %v", *trashName, err)
+		}
+		fmt.Fprintf(writer, "restored %s to %s\n", *trashName, resp.Path)
+		return nil
+	})
+}
@@ -0,0 +1,153 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsTtl{})
+}
+
+type commandFsTtl struct {
+}
+
+func (c *commandFsTtl) Name() string {
+	return "fs.ttl"
+}
+
+func (c *commandFsTtl) Help() string {
+	return `set, remove, or show the ttl inherited under directories
+
+	fs.ttl                                # list the ttl directories
+	fs.ttl /dir                           # show the ttl of the directory
+	fs.ttl -ttl=7d /dir                   # the files created under the directory expire after 7 days
+	fs.ttl -ttl=7d -retroactive /dir      # also expire the existing files older than 7 days
+	fs.ttl -clear /dir                    # remove the ttl of the directory
+
+	The ttl is inherited by the files created under the directory, including those in the sub directories,
+	unless the writes set their own ttl, e.g., by "?ttl=", or filer.conf sets one for the location.
+	The nearest directory ttl wins. The ttl is in the format of the volume ttl, e.g., 30m, 12h, 7d, 4w, 3M or 1y.
+
+	The files written before the ttl is set keep living, unless the ttl is retroactive. The filer walks the ttl
+	directories every hour to remove the expired files, and with -retroactive, the files without a ttl whose
+	creation time is older than the ttl.
+
+`
+}
+
+func (c *commandFsTtl) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandFsTtl) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	fsTtlCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	ttl := fsTtlCommand.String("ttl", "", "the ttl of the files created under the directory, e.g., 7d")
+	retroactive := fsTtlCommand.Bool("retroactive", false, "also expire the existing files without a ttl")
+	clearTtl := fsTtlCommand.Bool("clear", false, "remove the ttl")
+	if err = fsTtlCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if fsTtlCommand.NArg() == 0 {
+		return c.listTtls(commandEnv, writer)
+	}
+	path, err := commandEnv.parseUrl(fsTtlCommand.Arg(0))
+	if err != nil {
+		return err
+	}
+	dir := util.FullPath(path)
+
+	if *clearTtl || *ttl != "" {
+		if *clearTtl {
+			*ttl = ""
+		} else if parsed, parseErr := needle.ReadTTL(*ttl); parseErr != nil || parsed.Minutes() == 0 {
+			return fmt.Errorf("invalid -ttl %s", *ttl)
+		}
+		if err = c.setTtl(commandEnv, dir, *ttl, *retroactive); err != nil {
+			return err
+		}
+	}
+
+	if *clearTtl {
+		fmt.Fprintf(writer, "removed the ttl of %s\n", dir)
+		return nil
+	}
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return printDirTtl(client, writer, dir)
+	})
+}
+
+func (c *commandFsTtl) setTtl(commandEnv *CommandEnv, dir util.FullPath, ttl string, retroactive bool) error {
+	parent, name := dir.DirAndName()
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+			Directory: parent,
+			Name:      name,
+		})
+		if err != nil {
+			return fmt.Errorf("lookup %s: %v", dir, err)
+		}
+		if !resp.Entry.IsDirectory {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+		if resp.Entry.Extended == nil {
+			resp.Entry.Extended = make(map[string][]byte)
+		}
+		filer.SetDirTtl(resp.Entry.Extended, ttl, retroactive)
+		return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory: parent,
+			Entry:     resp.Entry,
+		})
+	})
+}
+
+func (c *commandFsTtl) listTtls(commandEnv *CommandEnv, writer io.Writer) error {
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.KvGet(context.Background(), &filer_pb.KvGetRequest{Key: []byte(filer.DirTtlListKey)})
+		if err != nil {
+			return fmt.Errorf("read ttl directories: %v", err)
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("read ttl directories: %s", resp.Error)
+		}
+		dirs := filer.DecodeDirTtlList(resp.Value)
+		for _, dir := range dirs {
+			if err = printDirTtl(client, writer, dir); err != nil {
+				fmt.Fprintf(writer, "%s: %v\n", dir, err)
+			}
+		}
+		fmt.Fprintf(writer, "%d ttl directories\n", len(dirs))
+		return nil
+	})
+}
+
+func printDirTtl(client filer_pb.SeaweedFilerClient, writer io.Writer, dir util.FullPath) error {
+	parent, name := dir.DirAndName()
+	resp, err := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+		Directory: parent,
+		Name:      name,
+	})
+	if err != nil {
+		return fmt.Errorf("lookup %s: %v", dir, err)
+	}
+	dirTtl, found := filer.GetDirTtl(resp.Entry.Extended)
+	if !found {
+		fmt.Fprintf(writer, "%s has no ttl\n", dir)
+		return nil
+	}
+	if dirTtl.Retroactive {
+		fmt.Fprintf(writer, "%s\tttl: %s\tretroactive\n", dir, dirTtl.Ttl)
+	} else {
+		fmt.Fprintf(writer, "%s\tttl: %s\n", dir, dirTtl.Ttl)
+	}
+	return nil
+}
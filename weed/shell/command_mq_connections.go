@@ -0,0 +1,132 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandMqConnections{})
+}
+
+type commandMqConnections struct {
+}
+
+func (c *commandMqConnections) Name() string {
+	return "mq.connections"
+}
+
+func (c *commandMqConnections) Help() string {
+	return `list the publish and subscribe streams of the message queue brokers, with their statistics
+
+	mq.connections                            # list the streams of all brokers
+	mq.connections -topic=ns.topic            # only the streams of one topic
+	mq.connections -sortBy=queue -limit=10    # the 10 streams with the most unacked messages
+
+	For each stream, the messages and bytes per second are sampled by the broker every few seconds.
+	The queue depth is the number of the messages received from the publisher but not acked to it yet,
+	or sent to the subscriber but not acked by it yet. A publisher with a growing queue depth is waiting
+	on the broker, while a subscriber with a growing queue depth is slow to process the messages.
+
+`
+}
+
+func (c *commandMqConnections) HasTag(CommandTag) bool {
+	return false
+}
+
+type mqConnection struct {
+	broker string
+	*mq_pb.ClientConnection
+}
+
+func (c *commandMqConnections) Do(args []string, commandEnv *CommandEnv, writer io.Writer) error {
+	mqCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	topicName := mqCommand.String("topic", "", "only the streams of the topic, as <namespace>.<topic>")
+	sortBy := mqCommand.String("sortBy", "", "sort the streams by [queue|messages|bytes], descending")
+	limit := mqCommand.Int("limit", 0, "the max number of streams to list, 0 for all")
+	if err := mqCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	var brokers []*master_pb.ListClusterNodesResponse_ClusterNode
+	err := commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		resp, err := client.ListClusterNodes(context.Background(), &master_pb.ListClusterNodesRequest{
+			ClientType: cluster.BrokerType,
+			FilerGroup: *commandEnv.option.FilerGroup,
+		})
+		if err != nil {
+			return err
+		}
+		brokers = resp.ClusterNodes
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list brokers: %v", err)
+	}
+
+	var connections []mqConnection
+	for _, broker := range brokers {
+		err = pb.WithBrokerGrpcClient(false, broker.Address, commandEnv.option.GrpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
+			resp, err := client.ListConnections(context.Background(), &mq_pb.ListConnectionsRequest{})
+			if err != nil {
+				return err
+			}
+			for _, connection := range resp.Connections {
+				if *topicName != "" && fmt.Sprintf("%s.%s", connection.Topic.GetNamespace(), connection.Topic.GetName()) != *topicName {
+					continue
+				}
+				connections = append(connections, mqConnection{broker: broker.Address, ClientConnection: connection})
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(writer, "broker %s: %v\n", broker.Address, err)
+		}
+	}
+
+	switch *sortBy {
+	case "":
+	case "queue":
+		sort.SliceStable(connections, func(i, j int) bool {
+			return connections[i].QueueDepth > connections[j].QueueDepth
+		})
+	case "messages":
+		sort.SliceStable(connections, func(i, j int) bool {
+			return connections[i].MessagesPerSecond > connections[j].MessagesPerSecond
+		})
+	case "bytes":
+		sort.SliceStable(connections, func(i, j int) bool {
+			return connections[i].BytesPerSecond > connections[j].BytesPerSecond
+		})
+	default:
+		return fmt.Errorf("unknown -sortBy %s", *sortBy)
+	}
+	if *limit > 0 && len(connections) > *limit {
+		connections = connections[:*limit]
+	}
+
+	for _, connection := range connections {
+		fmt.Fprintf(writer, "%s %s %s on %s\n", connection.Type, connection.ClientName, connection.ConsumerGroup, connection.broker)
+		fmt.Fprintf(writer, "  topic %s.%s partition [%d,%d) connected %v\n",
+			connection.Topic.GetNamespace(), connection.Topic.GetName(),
+			connection.Partition.GetRangeStart(), connection.Partition.GetRangeStop(),
+			time.Unix(0, connection.ConnectedAtNs).Format(time.RFC3339))
+		fmt.Fprintf(writer, "  %.1f messages/s %s/s, total %d messages %s, last ack %d, queue depth %d\n",
+			connection.MessagesPerSecond, util.BytesToHumanReadable(uint64(connection.BytesPerSecond)),
+			connection.Messages, util.BytesToHumanReadable(uint64(connection.Bytes)),
+			connection.LastAckSequence, connection.QueueDepth)
+	}
+	fmt.Fprintf(writer, "%d streams on %d brokers\n", len(connections), len(brokers))
+	return nil
+}
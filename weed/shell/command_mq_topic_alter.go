@@ -0,0 +1,147 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandMqTopicAlter{})
+}
+
+type commandMqTopicAlter struct {
+}
+
+func (c *commandMqTopicAlter) Name() string {
+	return "mq.topic.alter"
+}
+
+func (c *commandMqTopicAlter) Help() string {
+	return `change the settings of a live topic, the publishers and subscribers do not need to recreate the topic
+
+	Example:
+		# increase the partitions, which can not be decreased
+		mq.topic.alter -namespace <namespace> -topic <topic_name> -partitionCount 12
+
+		# delete the messages older than 7 days, or keep them forever
+		mq.topic.alter -namespace <namespace> -topic <topic_name> -retention 168h
+		mq.topic.alter -namespace <namespace> -topic <topic_name> -retention -1s
+
+		# keep the full history of the last 3 days, and only the latest message of each key before that,
+		# deleting all messages older than 30 days, or stop compacting
+		mq.topic.alter -namespace <namespace> -topic <topic_name> -compact true -compactionWindow 72h -retention 720h
+		mq.topic.alter -namespace <namespace> -topic <topic_name> -compact false
+
+		# keep only one copy of each partition, or 2 copies with a follower
+		mq.topic.alter -namespace <namespace> -topic <topic_name> -replication 1
+
+		# flush the buffered messages every second in a 1MB buffer, and fsync the log files, for latency or durability
+		mq.topic.alter -namespace <namespace> -topic <topic_name> -flushInterval 1s -bufferSizeKB 1024 -fsync true
+		# back to the defaults, flushing every 2 minutes or when 8MB is buffered, without fsync
+		mq.topic.alter -namespace <namespace> -topic <topic_name> -flushInterval -1s -bufferSizeKB -1 -fsync false
+
+		# reject the published messages larger than 1MB, or -1 for the broker default
+		mq.topic.alter -namespace <namespace> -topic <topic_name> -maxMessageSizeKB 1024
+`
+}
+
+func (c *commandMqTopicAlter) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandMqTopicAlter) Do(args []string, commandEnv *CommandEnv, writer io.Writer) error {
+
+	// parse parameters
+	mqCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	namespace := mqCommand.String("namespace", "", "namespace name")
+	topicName := mqCommand.String("topic", "", "topic name")
+	partitionCount := mqCommand.Int("partitionCount", 0, "increase the partitions to this count")
+	retention := mqCommand.Duration("retention", 0, "delete the older messages, a negative value to keep the messages forever")
+	replication := mqCommand.Int("replication", 0, "copies of each partition including the leader, 1 or 2")
+	flushInterval := mqCommand.Duration("flushInterval", 0, "flush the buffered messages to the log files at this interval, a negative value for the default")
+	bufferSizeKB := mqCommand.Int("bufferSizeKB", 0, "flush the buffered messages when the buffer of this size is full, a negative value for the default")
+	fsync := mqCommand.String("fsync", "", "true to fsync the log files on the volume servers when flushed, false not to")
+	maxMessageSizeKB := mqCommand.Int("maxMessageSizeKB", 0, "reject the larger published messages, a negative value for the broker default")
+	compact := mqCommand.String("compact", "", "true to keep only the latest message of each key older than the compaction window, false not to")
+	compactionWindow := mqCommand.Duration("compactionWindow", 0, "keep the full history within this window when compacting, a negative value for the default 24h")
+	if err := mqCommand.Parse(args); err != nil {
+		return err
+	}
+
+	retentionSeconds := int64(retention.Seconds())
+	if *retention < 0 {
+		retentionSeconds = -1
+	} else if *retention > 0 && retentionSeconds == 0 {
+		return fmt.Errorf("retention %v should be at least 1s", *retention)
+	}
+
+	flushIntervalMs := int32(flushInterval.Milliseconds())
+	if *flushInterval < 0 {
+		flushIntervalMs = -1
+	}
+	var fsyncChange int32
+	switch *fsync {
+	case "true":
+		fsyncChange = 1
+	case "false":
+		fsyncChange = -1
+	case "":
+	default:
+		return fmt.Errorf("fsync %q should be true or false", *fsync)
+	}
+	var compactChange int32
+	switch *compact {
+	case "true":
+		compactChange = 1
+	case "false":
+		compactChange = -1
+	case "":
+	default:
+		return fmt.Errorf("compact %q should be true or false", *compact)
+	}
+	compactionWindowSeconds := int64(compactionWindow.Seconds())
+	if *compactionWindow < 0 {
+		compactionWindowSeconds = -1
+	} else if *compactionWindow > 0 && compactionWindowSeconds == 0 {
+		return fmt.Errorf("compaction window %v should be at least 1s", *compactionWindow)
+	}
+
+	// find the broker balancer
+	brokerBalancer, err := findBrokerBalancer(commandEnv)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "current balancer: %s\n", brokerBalancer)
+
+	return pb.WithBrokerGrpcClient(false, brokerBalancer, commandEnv.option.GrpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
+		resp, err := client.AlterTopic(context.Background(), &mq_pb.AlterTopicRequest{
+			Topic: &schema_pb.Topic{
+				Namespace: *namespace,
+				Name:      *topicName,
+			},
+			PartitionCount:          int32(*partitionCount),
+			RetentionSeconds:        retentionSeconds,
+			Replication:             int32(*replication),
+			FlushIntervalMs:         flushIntervalMs,
+			BufferSizeKb:            int32(*bufferSizeKB),
+			Fsync:                   fsyncChange,
+			MaxMessageSizeKb:        int32(*maxMessageSizeKB),
+			Compact:                 compactChange,
+			CompactionWindowSeconds: compactionWindowSeconds,
+		})
+		if err != nil {
+			return err
+		}
+		output, _ := json.MarshalIndent(resp, "", "  ")
+		fmt.Fprintf(writer, "response:\n%+v\n", string(output))
+		return nil
+	})
+
+}
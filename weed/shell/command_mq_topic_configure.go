@@ -9,6 +9,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/mq_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/schema_pb"
 	"io"
+	"os"
 )
 
 func init() {
@@ -27,6 +28,14 @@ func (c *commandMqTopicConfigure) Help() string {
 
 	Example:
 		mq.topic.configure -namespace <namespace> -topic <topic_name> -partition_count <partition_count>
+
+		# add a zstd compression dictionary, used by the publishers with a compression threshold
+		mq.topic.configure -namespace <namespace> -topic <topic_name> -compressionDictionary <file> -compressionDictionaryId 2
+
+		# mirror 1% of the published messages to the topic <topic_name>.sample, or stop sampling
+		mq.topic.configure -namespace <namespace> -topic <topic_name> -sample 0.01
+		mq.topic.configure -namespace <namespace> -topic <topic_name> -sample 0.05 -sampleTopic <sample_topic_name> -sampleByKey
+		mq.topic.configure -namespace <namespace> -topic <topic_name> -noSampling
 `
 }
 
@@ -41,9 +50,38 @@ func (c *commandMqTopicConfigure) Do(args []string, commandEnv *CommandEnv, writ
 	namespace := mqCommand.String("namespace", "", "namespace name")
 	topicName := mqCommand.String("topic", "", "topic name")
 	partitionCount := mqCommand.Int("partitionCount", 6, "partition count")
+	dictionaryFile := mqCommand.String("compressionDictionary", "", "zstd compression dictionary file to add to the topic")
+	dictionaryId := mqCommand.Uint("compressionDictionaryId", 1, "id of the added compression dictionary, the largest id is used by the publishers")
+	sampleRatio := mqCommand.Float64("sample", 0, "mirror this ratio of the published messages to the sample topic, replacing the existing sampling rule")
+	sampleTopic := mqCommand.String("sampleTopic", "", "the sample topic name in the same namespace, <topic>.sample if empty")
+	sampleByKey := mqCommand.Bool("sampleByKey", false, "sample by the message key, so all messages of a sampled key are mirrored")
+	noSampling := mqCommand.Bool("noSampling", false, "stop sampling the topic")
 	if err := mqCommand.Parse(args); err != nil {
 		return err
 	}
+	if *sampleRatio != 0 && *noSampling {
+		return fmt.Errorf("-sample and -noSampling can not be used together")
+	}
+	var samplingRules []*mq_pb.SamplingRule
+	if *sampleRatio != 0 {
+		samplingRules = append(samplingRules, &mq_pb.SamplingRule{
+			Ratio:       *sampleRatio,
+			SampleTopic: *sampleTopic,
+			ByKey:       *sampleByKey,
+		})
+	}
+
+	var dictionaries []*mq_pb.CompressionDictionary
+	if *dictionaryFile != "" {
+		data, err := os.ReadFile(*dictionaryFile)
+		if err != nil {
+			return fmt.Errorf("read compression dictionary %s: %v", *dictionaryFile, err)
+		}
+		dictionaries = append(dictionaries, &mq_pb.CompressionDictionary{
+			Id:   uint32(*dictionaryId),
+			Data: data,
+		})
+	}
 
 	// find the broker balancer
 	brokerBalancer, err := findBrokerBalancer(commandEnv)
@@ -59,7 +97,10 @@ func (c *commandMqTopicConfigure) Do(args []string, commandEnv *CommandEnv, writ
 				Namespace: *namespace,
 				Name:      *topicName,
 			},
-			PartitionCount: int32(*partitionCount),
+			PartitionCount:          int32(*partitionCount),
+			CompressionDictionaries: dictionaries,
+			SamplingRules:           samplingRules,
+			UpdateSamplingRules:     *sampleRatio != 0 || *noSampling,
 		})
 		if err != nil {
 			return err
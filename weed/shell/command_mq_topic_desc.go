@@ -45,19 +45,57 @@ func (c *commandMqTopicDescribe) Do(args []string, commandEnv *CommandEnv, write
 	}
 	fmt.Fprintf(writer, "current balancer: %s\n", brokerBalancer)
 
-	return pb.WithBrokerGrpcClient(false, brokerBalancer, commandEnv.option.GrpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
+	t := &schema_pb.Topic{
+		Namespace: *namespace,
+		Name:      *topicName,
+	}
+	var leaderBrokers []string
+	err = pb.WithBrokerGrpcClient(false, brokerBalancer, commandEnv.option.GrpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
 		resp, err := client.LookupTopicBrokers(context.Background(), &mq_pb.LookupTopicBrokersRequest{
-			Topic: &schema_pb.Topic{
-				Namespace: *namespace,
-				Name:      *topicName,
-			},
+			Topic: t,
 		})
 		if err != nil {
 			return err
 		}
+		knownBrokers := make(map[string]struct{})
 		for _, assignment := range resp.BrokerPartitionAssignments {
 			fmt.Fprintf(writer, "  %+v\n", assignment)
+			if _, found := knownBrokers[assignment.LeaderBroker]; !found && assignment.LeaderBroker != "" {
+				knownBrokers[assignment.LeaderBroker] = struct{}{}
+				leaderBrokers = append(leaderBrokers, assignment.LeaderBroker)
+			}
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// what each broker is hosting
+	for _, broker := range leaderBrokers {
+		err = pb.WithBrokerGrpcClient(false, broker, commandEnv.option.GrpcDialOption, func(client mq_pb.SeaweedMessagingClient) error {
+			resp, err := client.DescribeTopic(context.Background(), &mq_pb.DescribeTopicRequest{
+				Topic: t,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(writer, "broker %s:\n", broker)
+			for _, p := range resp.Partitions {
+				fmt.Fprintf(writer, "  partition [%d,%d) total %d messages %d bytes, buffered %d messages %d/%d bytes, follower %q\n",
+					p.Partition.RangeStart, p.Partition.RangeStop, p.TotalMessages, p.TotalBytes, p.BufferedMessages, p.BufferedBytes, p.BufferCapacity, p.Follower)
+				for _, publisher := range p.Publishers {
+					fmt.Fprintf(writer, "    publisher %s\n", publisher)
+				}
+				for _, subscriber := range p.Subscribers {
+					fmt.Fprintf(writer, "    subscriber %s\n", subscriber)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(writer, "broker %s: %v\n", broker, err)
+		}
+	}
+	return nil
 }
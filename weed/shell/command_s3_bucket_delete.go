@@ -1,10 +1,8 @@
 package shell
 
 import (
-	"context"
 	"flag"
 	"fmt"
-	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"io"
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -55,17 +53,7 @@ func (c *commandS3BucketDelete) Do(args []string, commandEnv *CommandEnv, writer
 		return fmt.Errorf("read buckets: %v", err)
 	}
 
-	// delete the collection directly first
-	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
-		_, err = client.CollectionDelete(context.Background(), &master_pb.CollectionDeleteRequest{
-			Name: getCollectionName(commandEnv, *bucketName),
-		})
-		return err
-	})
-	if err != nil {
-		return
-	}
-
-	return filer_pb.Remove(commandEnv, filerBucketsPath, *bucketName, false, true, true, false, nil)
+	// the filer drops the bucket collections not shared with the other buckets, and deletes the shared chunks
+	return filer_pb.Remove(commandEnv, filerBucketsPath, *bucketName, true, true, true, false, nil)
 
 }
@@ -0,0 +1,105 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3bucket"
+)
+
+func init() {
+	Commands = append(Commands, &commandS3BucketRename{})
+}
+
+type commandS3BucketRename struct {
+}
+
+func (c *commandS3BucketRename) Name() string {
+	return "s3.bucket.rename"
+}
+
+func (c *commandS3BucketRename) Help() string {
+	return `rename a bucket without copying the objects
+
+	s3.bucket.rename -name <bucket_name> -newName <new_bucket_name>
+
+	Only the metadata is changed. The objects keep their chunks in the collection of the old bucket name,
+	which is dropped when no bucket uses it anymore. The bucket rules in filer.conf are moved to the new name.
+
+	The objects can also be moved between buckets without copying, with "fs.mv /buckets/a/key /buckets/b/".
+`
+}
+
+func (c *commandS3BucketRename) HasTag(CommandTag) bool {
+	return false
+}
+
+func (c *commandS3BucketRename) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	bucketCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	bucketName := bucketCommand.String("name", "", "bucket name")
+	newBucketName := bucketCommand.String("newName", "", "new bucket name")
+	if err = bucketCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if *bucketName == "" || *newBucketName == "" {
+		return fmt.Errorf("empty bucket name")
+	}
+	if err = s3bucket.VerifyS3BucketName(*newBucketName); err != nil {
+		return fmt.Errorf("invalid bucket name %s: %v", *newBucketName, err)
+	}
+
+	var filerBucketsPath string
+	filerBucketsPath, err = readFilerBucketsPath(commandEnv)
+	if err != nil {
+		return fmt.Errorf("read buckets: %v", err)
+	}
+
+	err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		if _, lookupErr := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+			Directory: filerBucketsPath,
+			Name:      *newBucketName,
+		}); lookupErr == nil {
+			return fmt.Errorf("bucket %s already exists", *newBucketName)
+		} else if lookupErr != filer_pb.ErrNotFound {
+			return lookupErr
+		}
+		_, renameErr := client.AtomicRenameEntry(context.Background(), &filer_pb.AtomicRenameEntryRequest{
+			OldDirectory: filerBucketsPath,
+			OldName:      *bucketName,
+			NewDirectory: filerBucketsPath,
+			NewName:      *newBucketName,
+		})
+		return renameErr
+	})
+	if err != nil {
+		return fmt.Errorf("rename bucket %s to %s: %v", *bucketName, *newBucketName, err)
+	}
+
+	fc, err := filer.ReadFilerConf(commandEnv.option.FilerAddress, commandEnv.option.GrpcDialOption, commandEnv.MasterClient)
+	if err != nil {
+		return fmt.Errorf("read filer.conf: %v", err)
+	}
+	oldPrefix, newPrefix := filerBucketsPath+"/"+*bucketName+"/", filerBucketsPath+"/"+*newBucketName+"/"
+	if fc.MoveLocationConfs(oldPrefix, newPrefix, getCollectionName(commandEnv, *bucketName), getCollectionName(commandEnv, *newBucketName)) {
+		var buf bytes.Buffer
+		if err = fc.ToText(&buf); err != nil {
+			return err
+		}
+		if err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			return filer.SaveInsideFiler(client, filer.DirectoryEtcSeaweedFS, filer.FilerConfName, buf.Bytes())
+		}); err != nil {
+			return fmt.Errorf("save filer.conf: %v", err)
+		}
+		fmt.Fprintf(writer, "moved filer.conf rules of %s to %s\n", oldPrefix, newPrefix)
+	}
+
+	fmt.Fprintf(writer, "renamed bucket %s to %s\n", *bucketName, *newBucketName)
+	return nil
+}
@@ -0,0 +1,201 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandVolumeCapacityPlan{})
+}
+
+type commandVolumeCapacityPlan struct {
+}
+
+func (c *commandVolumeCapacityPlan) Name() string {
+	return "volume.capacity.plan"
+}
+
+func (c *commandVolumeCapacityPlan) Help() string {
+	return `report when the volume id space, the file key space, or the volume slots will run out
+
+	volume.capacity.plan                           # sample the growth over 1 minute
+	volume.capacity.plan -interval=10m -warnDays=180
+	volume.capacity.plan -deleteEmpty -quietFor=24h  # also free the slots of the empty volumes if running out
+
+	The cluster is sampled twice, the interval apart, and the growth per day of each limit is projected from
+	the two samples, so a longer interval under the usual load gives a better projection.
+
+	The limits:
+	  volume ids:   the volume ids are 32 bits, and never reused, even after the volumes are deleted.
+	  file keys:    the file keys are 64 bits, allocated by the master sequencer, and never reused.
+	                The snowflake sequencer uses up the file keys with the time, not with the writes.
+	  volume slots: the max volume counts of the volume servers, also used by the erasure coded shards.
+	                Add volume servers or disks, raise -max of the volume servers, delete the empty volumes,
+	                or erasure code the full volumes with ec.encode to free the slots.
+
+	With -deleteEmpty, the empty volumes with no writes during -quietFor are deleted, by volume.deleteEmpty,
+	if the volume slots run out within the warning days. Neither the volume ids nor the file keys can be
+	renumbered in place, since they are in the file ids kept by the clients.
+
+`
+}
+
+func (c *commandVolumeCapacityPlan) HasTag(CommandTag) bool {
+	return false
+}
+
+// capacitySample is the usage of the limits of the cluster at one time
+type capacitySample struct {
+	time        time.Time
+	maxVolumeId uint64
+	maxFileKey  uint64
+	usedSlots   uint64
+	maxSlots    uint64
+	fileCount   uint64
+}
+
+// capacityProjection is how soon one limit is hit with the current growth
+type capacityProjection struct {
+	name         string
+	used         uint64
+	limit        uint64
+	growthPerDay float64
+}
+
+// daysLeft is the days until the limit is hit, or +Inf if not growing
+func (p capacityProjection) daysLeft() float64 {
+	if p.used >= p.limit {
+		return 0
+	}
+	if p.growthPerDay <= 0 {
+		return math.Inf(1)
+	}
+	return float64(p.limit-p.used) / p.growthPerDay
+}
+
+func (p capacityProjection) String() string {
+	s := fmt.Sprintf("%-13s %d of %d used (%.2f%%), %+.1f per day", p.name+":", p.used, p.limit, 100*float64(p.used)/float64(max(p.limit, 1)), p.growthPerDay)
+	switch daysLeft := p.daysLeft(); {
+	case daysLeft == 0:
+		return s + ", exhausted"
+	case math.IsInf(daysLeft, 1):
+		return s + ", not growing"
+	case daysLeft > 365*1000:
+		return s + ", lasts over 1000 years"
+	case daysLeft > 365:
+		return s + fmt.Sprintf(", lasts %.1f years", daysLeft/365)
+	default:
+		return s + fmt.Sprintf(", lasts %.1f days", daysLeft)
+	}
+}
+
+func growthPerDay(before, after uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return (float64(after) - float64(before)) * float64(24*time.Hour) / float64(elapsed)
+}
+
+func projectCapacity(before, after capacitySample) []capacityProjection {
+	elapsed := after.time.Sub(before.time)
+	return []capacityProjection{
+		{name: "volume ids", used: after.maxVolumeId, limit: math.MaxUint32, growthPerDay: growthPerDay(before.maxVolumeId, after.maxVolumeId, elapsed)},
+		{name: "file keys", used: after.maxFileKey, limit: math.MaxUint64, growthPerDay: growthPerDay(before.maxFileKey, after.maxFileKey, elapsed)},
+		{name: "volume slots", used: after.usedSlots, limit: after.maxSlots, growthPerDay: growthPerDay(before.usedSlots, after.usedSlots, elapsed)},
+	}
+}
+
+func (c *commandVolumeCapacityPlan) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	planCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	interval := planCommand.Duration("interval", time.Minute, "the time between the two samples of the cluster")
+	warnDays := planCommand.Int("warnDays", 90, "warn about the limits hit within these days")
+	deleteEmpty := planCommand.Bool("deleteEmpty", false, "delete the empty volumes if the volume slots run out within the warning days")
+	quietPeriod := planCommand.Duration("quietFor", 24*time.Hour, "with -deleteEmpty, only delete the empty volumes with no writes during this period")
+	if err = planCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *deleteEmpty {
+		if err = commandEnv.confirmIsLocked(args); err != nil {
+			return
+		}
+	}
+
+	before, err := sampleCapacity(commandEnv)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "sampling the growth for %v ...\n", *interval)
+	time.Sleep(*interval)
+	after, err := sampleCapacity(commandEnv)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "%d files, %+.1f per day\n", after.fileCount, growthPerDay(before.fileCount, after.fileCount, after.time.Sub(before.time)))
+	var isSlotsRunningOut bool
+	for _, projection := range projectCapacity(before, after) {
+		fmt.Fprintf(writer, "%s\n", projection)
+		if projection.daysLeft() > float64(*warnDays) {
+			continue
+		}
+		fmt.Fprintf(writer, "  WARNING: %s run out within %d days\n", projection.name, *warnDays)
+		switch projection.name {
+		case "volume ids":
+			fmt.Fprintf(writer, "  grow the volumes in larger batches less often, and avoid deleting and recreating volumes\n")
+		case "file keys":
+			fmt.Fprintf(writer, "  check the max file keys reported by the volume servers for unusually large file keys\n")
+		case "volume slots":
+			isSlotsRunningOut = true
+			fmt.Fprintf(writer, "  add volume servers, raise -max of the volume servers, delete the empty volumes, or ec.encode the full volumes\n")
+		}
+	}
+
+	if *deleteEmpty && isSlotsRunningOut {
+		fmt.Fprintf(writer, "deleting the empty volumes quiet for %v\n", *quietPeriod)
+		return (&commandVolumeDeleteEmpty{}).Do([]string{"-quietFor=" + quietPeriod.String(), "-force"}, commandEnv, writer)
+	}
+	return nil
+}
+
+func sampleCapacity(commandEnv *CommandEnv) (sample capacitySample, err error) {
+	var resp *master_pb.VolumeListResponse
+	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		resp, err = client.VolumeList(context.Background(), &master_pb.VolumeListRequest{})
+		return err
+	})
+	if err != nil {
+		return sample, fmt.Errorf("list volumes: %v", err)
+	}
+
+	sample.time = time.Now()
+	sample.maxVolumeId = uint64(resp.MaxVolumeId)
+	sample.maxFileKey = resp.MaxFileKey
+	fileCounts := make(map[uint32]uint64)
+	eachDataNode(resp.TopologyInfo, func(dc DataCenterId, rack RackId, dn *master_pb.DataNodeInfo) {
+		for _, diskInfo := range dn.DiskInfos {
+			sample.maxSlots += uint64(diskInfo.MaxVolumeCount)
+			sample.usedSlots += uint64(max(diskInfo.MaxVolumeCount-diskInfo.FreeVolumeCount, 0))
+			for _, v := range diskInfo.VolumeInfos {
+				// the masters before reporting the max volume id
+				sample.maxVolumeId = max(sample.maxVolumeId, uint64(v.Id))
+				// the replicas are counted once
+				fileCounts[v.Id] = max(fileCounts[v.Id], v.FileCount-min(v.DeleteCount, v.FileCount))
+			}
+			for _, shardInfo := range diskInfo.EcShardInfos {
+				sample.maxVolumeId = max(sample.maxVolumeId, uint64(shardInfo.Id))
+			}
+		}
+	})
+	for _, fileCount := range fileCounts {
+		sample.fileCount += fileCount
+	}
+	return sample, nil
+}
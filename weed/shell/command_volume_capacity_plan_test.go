@@ -0,0 +1,32 @@
+package shell
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProjectCapacity(t *testing.T) {
+	now := time.Now()
+	before := capacitySample{time: now, maxVolumeId: 100, maxFileKey: 1000, usedSlots: 80, maxSlots: 100}
+	after := capacitySample{time: now.Add(12 * time.Hour), maxVolumeId: 105, maxFileKey: 1000, usedSlots: 85, maxSlots: 100}
+
+	projections := projectCapacity(before, after)
+	if len(projections) != 3 {
+		t.Fatalf("expected 3 projections, got %d", len(projections))
+	}
+	if volumeIds := projections[0]; volumeIds.growthPerDay != 10 || volumeIds.daysLeft() < 365*1000 {
+		t.Errorf("unexpected volume ids projection: %s", volumeIds)
+	}
+	if fileKeys := projections[1]; !math.IsInf(fileKeys.daysLeft(), 1) {
+		t.Errorf("file keys not growing should last forever: %s", fileKeys)
+	}
+	if slots := projections[2]; slots.growthPerDay != 10 || slots.daysLeft() != 1.5 {
+		t.Errorf("unexpected volume slots projection: %s", slots)
+	}
+
+	full := capacityProjection{name: "volume slots", used: 100, limit: 100}
+	if full.daysLeft() != 0 {
+		t.Errorf("full slots should be exhausted: %s", full)
+	}
+}
@@ -219,7 +219,7 @@ func (c *commandVolumeList) writeDiskInfo(writer io.Writer, t *master_pb.DiskInf
 		if destroyTime > 0 {
 			expireAtString = fmt.Sprintf("expireAt:%s", time.Unix(int64(destroyTime), 0).Format("2006-01-02 15:04:05"))
 		}
-		output(verbosityLevel >= 5, writer, "          ec volume id:%v collection:%v shards:%v %s\n", ecShardInfo.Id, ecShardInfo.Collection, erasure_coding.ShardBits(ecShardInfo.EcIndexBits).ShardIds(), expireAtString)
+		output(verbosityLevel >= 5, writer, "          ec volume id:%v collection:%v scheme:%s shards:%v %s\n", ecShardInfo.Id, ecShardInfo.Collection, erasure_coding.NewScheme(ecShardInfo.DataShards, ecShardInfo.ParityShards), erasure_coding.ShardBits(ecShardInfo.EcIndexBits).ShardIds(), expireAtString)
 	}
 	output((volumeInfosFound || ecShardInfoFound) && verbosityLevel >= 4, writer, "        Disk %s %+v \n", diskType, s)
 	return s
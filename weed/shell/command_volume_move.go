@@ -47,6 +47,8 @@ func (c *commandVolumeMove) Help() string {
 	5. This command asks the source volume server to delete the source volume
 
 	The option "-disk [hdd|ssd|<tag>]" can be used to change the volume disk type.
+	The option "-maxMBps" caps the bandwidth of copying the volume, enforced by the target volume server.
+	It defaults to the -compactionMBps of the target volume server.
 
 `
 }
@@ -62,7 +64,8 @@ func (c *commandVolumeMove) Do(args []string, commandEnv *CommandEnv, writer io.
 	sourceNodeStr := volMoveCommand.String("source", "", "the source volume server <host>:<port>")
 	targetNodeStr := volMoveCommand.String("target", "", "the target volume server <host>:<port>")
 	diskTypeStr := volMoveCommand.String("disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
-	ioBytePerSecond := volMoveCommand.Int64("ioBytePerSecond", 0, "limit the speed of move")
+	ioBytePerSecond := volMoveCommand.Int64("ioBytePerSecond", 0, "limit the speed of move, deprecated by -maxMBps")
+	maxMBps := volMoveCommand.Int64("maxMBps", 0, "limit the bandwidth of copying the volume in MB/s")
 	noLock := volMoveCommand.Bool("noLock", false, "do not lock the admin shell at one's own risk")
 
 	if err = volMoveCommand.Parse(args); err != nil {
@@ -80,6 +83,9 @@ func (c *commandVolumeMove) Do(args []string, commandEnv *CommandEnv, writer io.
 	sourceVolumeServer, targetVolumeServer := pb.ServerAddress(*sourceNodeStr), pb.ServerAddress(*targetNodeStr)
 
 	volumeId := needle.VolumeId(*volumeIdInt)
+	if *maxMBps > 0 {
+		*ioBytePerSecond = *maxMBps * 1024 * 1024
+	}
 
 	if sourceVolumeServer == targetVolumeServer {
 		return fmt.Errorf("source and target volume servers are the same!")
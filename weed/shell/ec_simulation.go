@@ -0,0 +1,69 @@
+package shell
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+)
+
+// SimulateEcPlacement places the ec shards of count volumes, from the volume id on, in the topology, without touching
+// any volume server. All the shards of a volume start on one server, as "ec.encode" leaves them on the server of the
+// volume, taking turns among the servers with the most free slots. They are then spread as "ec.balance" does.
+// The plan of the moves is printed to stdout, as "ec.balance" without -force.
+//
+// It returns the shards of each volume by the data node id.
+func SimulateEcPlacement(topo *master_pb.TopologyInfo, collection string, vid needle.VolumeId, count int, rp *super_block.ReplicaPlacement) (placement map[needle.VolumeId]map[string]erasure_coding.ShardBits, err error) {
+	ecNodes, totalFreeEcSlots := collectEcVolumeServersByDc(topo, "")
+	if totalFreeEcSlots < count*erasure_coding.TotalShardsCount {
+		return nil, fmt.Errorf("%d free ec shard slots, not enough for %d volumes", totalFreeEcSlots, count)
+	}
+
+	vids := make([]needle.VolumeId, 0, count)
+	allShardIds := make([]uint32, erasure_coding.TotalShardsCount)
+	for i := range allShardIds {
+		allShardIds[i] = uint32(i)
+	}
+	for i := 0; i < count; i++ {
+		sortEcNodesByFreeslotsDescending(ecNodes)
+		if ecNodes[0].freeEcSlot < erasure_coding.TotalShardsCount {
+			return nil, fmt.Errorf("no server has %d free ec shard slots for volume %d", erasure_coding.TotalShardsCount, vid)
+		}
+		ecNodes[0].addEcVolumeShards(vid, collection, allShardIds)
+		vids = append(vids, vid)
+		vid = vid.Next()
+	}
+	// keep the simulation reproducible except the random choices among the equally good servers
+	sort.Slice(ecNodes, func(i, j int) bool {
+		return ecNodes[i].info.Id < ecNodes[j].info.Id
+	})
+
+	ecb := &ecBalancer{
+		ecNodes:            ecNodes,
+		replicaPlacement:   rp,
+		applyBalancing:     false,
+		maxParallelization: 1,
+	}
+	if err = ecb.balanceEcVolumes(collection); err != nil {
+		return nil, err
+	}
+	if err = ecb.balanceEcRacks(); err != nil {
+		return nil, fmt.Errorf("balance ec racks: %v", err)
+	}
+
+	placement = make(map[needle.VolumeId]map[string]erasure_coding.ShardBits)
+	for _, v := range vids {
+		placement[v] = make(map[string]erasure_coding.ShardBits)
+	}
+	for _, ecNode := range ecNodes {
+		for _, v := range vids {
+			if shardBits := findEcVolumeShards(ecNode, v); shardBits.ShardIdCount() > 0 {
+				placement[v][ecNode.info.Id] = shardBits
+			}
+		}
+	}
+	return placement, nil
+}
@@ -0,0 +1,55 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+func TestSimulateEcPlacement(t *testing.T) {
+	topo, err := topology.NewSimulatedTopology([]byte(`{
+		"dc1": {
+			"rack1": {"a": {"limit": 10, "count": 3}},
+			"rack2": {"b": {"limit": 10, "count": 3}},
+			"rack3": {"c": {"limit": 10, "count": 3}}
+		}
+	}`), 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topoInfo := topo.ToTopologyInfo()
+	racks := make(map[string]string)
+	eachDataNode(topoInfo, func(dc DataCenterId, rack RackId, dn *master_pb.DataNodeInfo) {
+		racks[dn.Id] = string(rack)
+	})
+
+	placement, err := SimulateEcPlacement(topoInfo, "", 1, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placement) != 5 {
+		t.Fatalf("placed %d volumes", len(placement))
+	}
+	for vid, shards := range placement {
+		var all erasure_coding.ShardBits
+		rackShards := make(map[string]int)
+		for server, shardBits := range shards {
+			all = all.Plus(shardBits)
+			rackShards[racks[server]] += shardBits.ShardIdCount()
+		}
+		if all.ShardIdCount() != erasure_coding.TotalShardsCount {
+			t.Errorf("volume %d has %d shards", vid, all.ShardIdCount())
+		}
+		for rack, count := range rackShards {
+			if count > 5 {
+				t.Errorf("volume %d has %d shards in %s", vid, count, rack)
+			}
+		}
+	}
+
+	if _, err = SimulateEcPlacement(topo.ToTopologyInfo(), "", 1, 100, nil); err == nil {
+		t.Errorf("expected not enough slots")
+	}
+}
@@ -0,0 +1,222 @@
+package shell
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	maxCompletionEntries   = 100
+	collectionCacheTimeout = 30 * time.Second
+)
+
+var helpFlagRegexp = regexp.MustCompile(`(?:^|[\s\[|])(-[a-zA-Z][a-zA-Z0-9_.]*)`)
+
+// shellCompleter completes the command names, the flags mentioned in the command help,
+// the collections after collection flags, and the filer paths, which are queried live from the cluster.
+type shellCompleter struct {
+	listCollections func() ([]string, error)
+	listDirectory   func(dir, prefix string) (names []string, err error) // directory names end with "/"
+	currentDir      func() string
+
+	collectionsLock     sync.Mutex
+	collections         []string
+	collectionsLoadedAt time.Time
+}
+
+func newShellCompleter(commandEnv *CommandEnv) *shellCompleter {
+	return &shellCompleter{
+		listCollections: func() ([]string, error) {
+			return ListCollectionNames(commandEnv, true, true)
+		},
+		listDirectory: func(dir, prefix string) (names []string, err error) {
+			err = filer_pb.List(commandEnv, dir, prefix, func(entry *filer_pb.Entry, isLast bool) error {
+				if entry.IsDirectory {
+					names = append(names, entry.Name+"/")
+				} else {
+					names = append(names, entry.Name)
+				}
+				return nil
+			}, "", false, maxCompletionEntries)
+			return
+		},
+		currentDir: func() string {
+			return commandEnv.option.Directory
+		},
+	}
+}
+
+// Complete is a liner.WordCompleter, replacing the word under the cursor with the completions
+func (sc *shellCompleter) Complete(line string, pos int) (head string, completions []string, tail string) {
+	if pos > len(line) {
+		pos = len(line)
+	}
+	wordStart := strings.LastIndexAny(line[:pos], " \t;") + 1
+	head, word, tail := line[:wordStart], line[wordStart:pos], line[pos:]
+
+	// only the command after the last ";" matters
+	words := strings.Fields(head[strings.LastIndex(head, ";")+1:])
+	if len(words) == 0 {
+		return head, sc.completeCommand(word, true), tail
+	}
+	if words[0] == "help" || words[0] == "?" {
+		return head, sc.completeCommand(word, false), tail
+	}
+
+	c := findCommand(words[0])
+	if c == nil {
+		return head, nil, tail
+	}
+
+	if flagName, value, found := strings.Cut(word, "="); found && strings.HasPrefix(flagName, "-") {
+		for _, completion := range sc.completeFlagValue(c, flagName, value) {
+			completions = append(completions, flagName+"="+completion)
+		}
+		return head, completions, tail
+	}
+	if strings.HasPrefix(word, "-") {
+		return head, completeFlag(c, word), tail
+	}
+	if previous := words[len(words)-1]; strings.HasPrefix(previous, "-") && !strings.Contains(previous, "=") {
+		if completions = sc.completeFlagValue(c, previous, word); completions != nil {
+			return head, completions, tail
+		}
+	}
+	if strings.HasPrefix(c.Name(), "fs.") {
+		return head, sc.completePath(word), tail
+	}
+	return head, nil, tail
+}
+
+func (sc *shellCompleter) completeCommand(prefix string, includeBuiltins bool) (completions []string) {
+	prefix = strings.ToLower(prefix)
+	for _, c := range Commands {
+		if strings.HasPrefix(c.Name(), prefix) {
+			completions = append(completions, c.Name())
+		}
+	}
+	if includeBuiltins {
+		for _, builtin := range []string{"help", "exit", "quit"} {
+			if strings.HasPrefix(builtin, prefix) {
+				completions = append(completions, builtin)
+			}
+		}
+	}
+	sort.Strings(completions)
+	return
+}
+
+// completeFlag completes the flags mentioned in the help of the command
+func completeFlag(c command, prefix string) (completions []string) {
+	seen := make(map[string]bool)
+	for _, match := range helpFlagRegexp.FindAllStringSubmatch(c.Help(), -1) {
+		flagName := match[1]
+		if !seen[flagName] && strings.HasPrefix(flagName, prefix) {
+			seen[flagName] = true
+			completions = append(completions, flagName)
+		}
+	}
+	sort.Strings(completions)
+	return
+}
+
+// completeFlagValue completes the values of the collection and path flags, or returns nil for other flags
+func (sc *shellCompleter) completeFlagValue(c command, flagName, prefix string) []string {
+	name := strings.ToLower(strings.TrimLeft(flagName, "-"))
+	switch {
+	case strings.Contains(name, "collection"):
+		return sc.completeCollection(prefix)
+	case name == "dir" || name == "path" || strings.HasSuffix(name, "dir") || strings.HasSuffix(name, "path"):
+		return sc.completePath(prefix)
+	}
+	return nil
+}
+
+func (sc *shellCompleter) completeCollection(prefix string) (completions []string) {
+	sc.collectionsLock.Lock()
+	defer sc.collectionsLock.Unlock()
+	if time.Since(sc.collectionsLoadedAt) > collectionCacheTimeout {
+		collections, err := sc.listCollections()
+		if err != nil {
+			return nil
+		}
+		sc.collections, sc.collectionsLoadedAt = collections, time.Now()
+	}
+	for _, collection := range sc.collections {
+		if strings.HasPrefix(collection, prefix) {
+			completions = append(completions, collection)
+		}
+	}
+	sort.Strings(completions)
+	return
+}
+
+// completePath lists the directory of the typed path, relative to the current directory if not absolute
+func (sc *shellCompleter) completePath(word string) (completions []string) {
+	typedDir, namePrefix := "", word
+	if i := strings.LastIndex(word, "/"); i >= 0 {
+		typedDir, namePrefix = word[:i+1], word[i+1:]
+	}
+	dir := typedDir
+	if !strings.HasPrefix(dir, "/") {
+		dir = util.Join(sc.currentDir(), dir)
+	}
+	if len(dir) > 1 {
+		dir = strings.TrimSuffix(dir, "/")
+	}
+	names, err := sc.listDirectory(dir, namePrefix)
+	if err != nil {
+		return nil
+	}
+	for _, name := range names {
+		completions = append(completions, typedDir+name)
+	}
+	return
+}
+
+func findCommand(name string) command {
+	for _, c := range Commands {
+		if c.Name() == name || c.Name() == "fs."+name {
+			return c
+		}
+	}
+	return nil
+}
+
+// suggestCommands returns the commands similar to the unknown command, e.g., with a typo or a missing prefix
+func suggestCommands(name string) (suggestions []string) {
+	name = strings.ToLower(name)
+	for _, c := range Commands {
+		if strings.HasSuffix(c.Name(), "."+name) || strings.HasPrefix(c.Name(), name) || editDistance(c.Name(), name) <= 2 {
+			suggestions = append(suggestions, c.Name())
+		}
+	}
+	sort.Strings(suggestions)
+	return
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShellCompletion(t *testing.T) {
+	sc := &shellCompleter{
+		listCollections: func() ([]string, error) {
+			return []string{"images", "logs", "important"}, nil
+		},
+		listDirectory: func(dir, prefix string) (names []string, err error) {
+			if dir == "/buckets" && prefix == "b" {
+				return []string{"b1/", "b2.txt"}, nil
+			}
+			return nil, nil
+		},
+		currentDir: func() string {
+			return "/"
+		},
+	}
+
+	for _, tc := range []struct {
+		line        string
+		head        string
+		completions []string
+	}{
+		{"volume.bal", "", []string{"volume.balance"}},
+		{"help collection.l", "help ", []string{"collection.list"}},
+		{"volume.balance -coll", "volume.balance ", []string{"-collection"}},
+		{"volume.balance -collection im", "volume.balance -collection ", []string{"images", "important"}},
+		{"collection.delete -collection=l", "collection.delete ", []string{"-collection=logs"}},
+		{"fs.ls /buckets/b", "fs.ls ", []string{"/buckets/b1/", "/buckets/b2.txt"}},
+		{"ls buckets/b", "ls ", []string{"buckets/b1/", "buckets/b2.txt"}},
+		{"fs.pwd; fs.ls /buckets/b", "fs.pwd; fs.ls ", []string{"/buckets/b1/", "/buckets/b2.txt"}},
+		{"unknown.command x", "unknown.command ", nil},
+	} {
+		head, completions, tail := sc.Complete(tc.line, len(tc.line))
+		if head != tc.head || tail != "" || !reflect.DeepEqual(completions, tc.completions) {
+			t.Errorf("complete %q: head %q completions %v tail %q", tc.line, head, completions, tail)
+		}
+	}
+}
+
+func TestSuggestCommands(t *testing.T) {
+	if suggestions := suggestCommands("volume.lst"); !reflect.DeepEqual(suggestions, []string{"volume.list"}) {
+		t.Errorf("suggestions %v", suggestions)
+	}
+	if suggestions := suggestCommands("balance"); len(suggestions) == 0 {
+		t.Errorf("no suggestions for balance")
+	}
+}
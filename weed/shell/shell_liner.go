@@ -2,6 +2,7 @@ package shell
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/cluster"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
@@ -12,6 +13,7 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
@@ -20,10 +22,33 @@ import (
 )
 
 var (
-	line        *liner.State
-	historyPath = path.Join(os.TempDir(), "weed-shell")
+	line *liner.State
+	// the history used to be kept in the temp directory, which is still read if there is no other history
+	legacyHistoryPath = path.Join(os.TempDir(), "weed-shell")
+	historyPath       string
 )
 
+// ExitCodeError makes "weed shell" exit with the code after all the commands, e.g., for the monitoring scripts
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+// exitCode is from the last command failed with an ExitCodeError
+var exitCode int
+
+func ExitCode() int {
+	return exitCode
+}
+
 func RunShell(options ShellOptions) {
 	slices.SortFunc(Commands, func(a, b command) int {
 		return strings.Compare(a.Name(), b.Name())
@@ -37,7 +62,7 @@ func RunShell(options ShellOptions) {
 	line.SetCtrlCAborts(true)
 	line.SetTabCompletionStyle(liner.TabPrints)
 
-	setCompletionHandler()
+	historyPath = shellHistoryPath()
 	loadHistory()
 
 	defer saveHistory()
@@ -45,6 +70,7 @@ func RunShell(options ShellOptions) {
 	reg, _ := regexp.Compile(`'.*?'|".*?"|\S+`)
 
 	commandEnv := NewCommandEnv(&options)
+	setCompletionHandler(commandEnv)
 
 	ctx := context.Background()
 	go commandEnv.MasterClient.KeepConnectedToMaster(ctx)
@@ -88,6 +114,8 @@ func RunShell(options ShellOptions) {
 				return
 			}
 		}
+		// keep the history even if the shell is killed
+		saveHistory()
 	}
 }
 
@@ -117,12 +145,19 @@ func processEachCmd(reg *regexp.Regexp, cmd string, commandEnv *CommandEnv) bool
 				if c.Name() == cmd || c.Name() == "fs."+cmd {
 					if err := c.Do(args, commandEnv, os.Stdout); err != nil {
 						fmt.Fprintf(os.Stderr, "error: %v\n", err)
+						var exitCodeError *ExitCodeError
+						if errors.As(err, &exitCodeError) {
+							exitCode = exitCodeError.Code
+						}
 					}
 					foundCommand = true
 				}
 			}
 			if !foundCommand {
 				fmt.Fprintf(os.Stderr, "unknown command: %v\n", cmd)
+				if suggestions := suggestCommands(cmd); len(suggestions) > 0 {
+					fmt.Fprintf(os.Stderr, "did you mean: %s\n", strings.Join(suggestions, ", "))
+				}
 			}
 		}
 
@@ -151,34 +186,49 @@ func printHelp(cmds []string) {
 	} else {
 		cmd := strings.ToLower(args[0])
 
+		if c := findCommand(cmd); c != nil {
+			fmt.Printf("  %s\t# %s\n", c.Name(), c.Help())
+			return
+		}
+		// list the commands with the prefix, e.g., "help volume."
 		for _, c := range Commands {
-			if strings.ToLower(c.Name()) == cmd {
-				fmt.Printf("  %s\t# %s\n", c.Name(), c.Help())
+			if strings.HasPrefix(c.Name(), cmd) {
+				helpTexts := strings.SplitN(c.Help(), "\n", 2)
+				fmt.Printf("  %-30s\t# %s \n", c.Name(), helpTexts[0])
 			}
 		}
 	}
 }
 
-func setCompletionHandler() {
-	line.SetCompleter(func(line string) (c []string) {
-		for _, i := range Commands {
-			if strings.HasPrefix(i.Name(), strings.ToLower(line)) {
-				c = append(c, i.Name())
-			}
-		}
-		return
-	})
+func setCompletionHandler(commandEnv *CommandEnv) {
+	line.SetWordCompleter(newShellCompleter(commandEnv).Complete)
+}
+
+func shellHistoryPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return legacyHistoryPath
+	}
+	dir := filepath.Join(homeDir, ".seaweedfs")
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return legacyHistoryPath
+	}
+	return filepath.Join(dir, "shell_history")
 }
 
 func loadHistory() {
-	if f, err := os.Open(historyPath); err == nil {
+	f, err := os.Open(historyPath)
+	if os.IsNotExist(err) {
+		f, err = os.Open(legacyHistoryPath)
+	}
+	if err == nil {
 		line.ReadHistory(f)
 		f.Close()
 	}
 }
 
 func saveHistory() {
-	if f, err := os.Create(historyPath); err != nil {
+	if f, err := os.OpenFile(historyPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600); err != nil {
 		fmt.Printf("Error creating history file: %v\n", err)
 	} else {
 		if _, err = line.WriteHistory(f); err != nil {
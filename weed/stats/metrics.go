@@ -29,8 +29,16 @@ const (
 
 var readOnlyVolumeTypes = [4]string{IsReadOnly, NoWriteOrDelete, NoWriteCanDelete, IsDiskSpaceLow}
 
-var bucketLastActiveTsNs map[string]int64 = map[string]int64{}
-var bucketLastActiveLock sync.Mutex
+// S3OtherLabelValue is shared by the buckets and identities over the cardinality cap.
+// Bucket names can not contain "_".
+const S3OtherLabelValue = "_other"
+
+// S3MaxLabelValues caps the number of active buckets and identities with their own metric labels,
+// 0 means no limit
+var S3MaxLabelValues = 1000
+
+var bucketLastActive = newActiveLabelValues()
+var identityLastActive = newActiveLabelValues()
 
 var (
 	Gather = prometheus.NewRegistry()
@@ -115,6 +123,55 @@ var (
 			Help:      "Counter of master leader changes.",
 		}, []string{"type"})
 
+	MasterLeaderChangeTimestampGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "master",
+			Name:      "leader_change_timestamp_seconds",
+			Help:      "Unix timestamp of the last leader change seen by this master.",
+		})
+
+	MasterAssignRequestHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "master",
+			Name:      "assign_request_seconds",
+			Help:      "Bucketed histogram of master assign request processing time.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
+		}, []string{"type", "result"})
+
+	MasterVolumeSlotsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "master",
+			Name:      "volume_slots",
+			Help:      "Number of max, used and free volume slots per data center, rack and disk type.",
+		}, []string{"dc", "rack", "disk", "type"})
+
+	MasterVolumeGrowDecisionCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "master",
+			Name:      "volume_grow_decisions",
+			Help:      "Counter of the periodic volume growth decisions of volume layouts.",
+		}, []string{"collection", "disk", "decision"})
+
+	MasterVolumeGrowRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "master",
+			Name:      "volume_grow_requests",
+			Help:      "Counter of volume growth requests by reason and result.",
+		}, []string{"collection", "reason", "result"})
+
+	MasterGrownVolumesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "master",
+			Name:      "grown_volumes",
+			Help:      "Counter of volumes created by volume growth.",
+		}, []string{"collection", "disk"})
+
 	FilerRequestCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: Namespace,
@@ -148,6 +205,39 @@ var (
 			Help:      "Current number of in-flight requests being handled by filer.",
 		}, []string{"type"})
 
+	FilerReadTransformQueueGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "filer",
+			Name:      "read_transform_queued",
+			Help:      "Current number of reads waiting for a worker to decrypt, decompress or resize the data.",
+		}, []string{"type"})
+
+	FilerReadTransformHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "filer",
+			Name:      "read_transform_seconds",
+			Help:      "Bucketed histogram of the time each read waits for a worker, and the time of decrypting, decompressing or resizing the data.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
+		}, []string{"type", "stage"})
+
+	FilerNotificationDroppedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "filer",
+			Name:      "notification_dropped_total",
+			Help:      "Counter of the metadata events not sent to the notification queue.",
+		}, []string{"queue"})
+
+	FilerStalledRenamesGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "filer",
+			Name:      "stalled_renames",
+			Help:      "Current number of the interrupted directory renames given up resuming, until the filer restarts.",
+		})
+
 	FilerServerLastSendTsOfSubscribeGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: Namespace,
@@ -213,6 +303,14 @@ var (
 			Help:      "Counter of volume vacuuming commit counter",
 		}, []string{"success"})
 
+	VolumeServerScrubbedNeedlesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "volumeServer",
+			Name:      "scrubbed_needles_total",
+			Help:      "Counter of needles scrubbed, corrupted and repaired.",
+		}, []string{"type", "status"})
+
 	VolumeServerVacuumingHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: Namespace,
@@ -255,6 +353,22 @@ var (
 			Help:      "Number of read only volumes.",
 		}, []string{"collection", "type"})
 
+	VolumeServerReadCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "volumeServer",
+			Name:      "read_cache_total",
+			Help:      "Counter of needle read cache hits, misses, writes and evictions.",
+		}, []string{"type"})
+
+	VolumeServerReadCacheGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "volumeServer",
+			Name:      "read_cache",
+			Help:      "Number of needles and bytes in the needle read cache.",
+		}, []string{"type"})
+
 	VolumeServerMaxVolumeCounter = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: Namespace,
@@ -351,6 +465,79 @@ var (
 			Name:      "uploaded_objects",
 			Help:      "Number of objects uploaded in each bucket.",
 		}, []string{"bucket"})
+
+	S3IdentityRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "s3",
+			Name:      "identity_request_total",
+			Help:      "Counter of s3 requests by each identity.",
+		}, []string{"identity", "type", "code"})
+
+	S3IdentityRequestHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "s3",
+			Name:      "identity_request_seconds",
+			Help:      "Bucketed histogram of s3 request processing time by each identity.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
+		}, []string{"identity", "type"})
+
+	S3IdentityTrafficReceivedBytesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "s3",
+			Name:      "identity_traffic_received_bytes_total",
+			Help:      "Total number of bytes received from each identity.",
+		}, []string{"identity"})
+
+	S3IdentityTrafficSentBytesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "s3",
+			Name:      "identity_traffic_sent_bytes_total",
+			Help:      "Total number of bytes sent to each identity.",
+		}, []string{"identity"})
+
+	MqLogCorruptionCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "mq",
+			Name:      "log_corruption_total",
+			Help:      "Counter of corrupted records and truncated segments found when reading the partition logs.",
+		}, []string{"topic", "type"})
+
+	MqRedeliveredMessagesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "mq",
+			Name:      "redelivered_messages_total",
+			Help:      "Counter of messages redelivered to the subscribers after the ack deadline.",
+		}, []string{"topic", "consumer_group"})
+
+	MqDeduplicatedMessagesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "mq",
+			Name:      "deduplicated_messages_total",
+			Help:      "Counter of published messages dropped for the idempotency keys seen within the dedup window.",
+		}, []string{"topic"})
+
+	MqOversizedMessagesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "mq",
+			Name:      "oversized_messages_total",
+			Help:      "Counter of published messages rejected for being larger than the max message size.",
+		}, []string{"topic"})
+
+	MqSampledMessagesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "mq",
+			Name:      "sampled_messages_total",
+			Help:      "Counter of published messages sampled to the sample topics, mirrored or dropped if the sample topic can not keep up.",
+		}, []string{"topic", "sample_topic", "type"})
 )
 
 func init() {
@@ -359,6 +546,12 @@ func init() {
 	Gather.MustRegister(MasterAdminLock)
 	Gather.MustRegister(MasterReceivedHeartbeatCounter)
 	Gather.MustRegister(MasterLeaderChangeCounter)
+	Gather.MustRegister(MasterLeaderChangeTimestampGauge)
+	Gather.MustRegister(MasterAssignRequestHistogram)
+	Gather.MustRegister(MasterVolumeSlotsGauge)
+	Gather.MustRegister(MasterVolumeGrowDecisionCounter)
+	Gather.MustRegister(MasterVolumeGrowRequestCounter)
+	Gather.MustRegister(MasterGrownVolumesCounter)
 	Gather.MustRegister(MasterReplicaPlacementMismatch)
 	Gather.MustRegister(MasterVolumeLayoutWritable)
 	Gather.MustRegister(MasterVolumeLayoutCrowded)
@@ -373,6 +566,10 @@ func init() {
 	Gather.MustRegister(FilerStoreHistogram)
 	Gather.MustRegister(FilerSyncOffsetGauge)
 	Gather.MustRegister(FilerServerLastSendTsOfSubscribeGauge)
+	Gather.MustRegister(FilerNotificationDroppedCounter)
+	Gather.MustRegister(FilerStalledRenamesGauge)
+	Gather.MustRegister(FilerReadTransformQueueGauge)
+	Gather.MustRegister(FilerReadTransformHistogram)
 	Gather.MustRegister(collectors.NewGoCollector())
 	Gather.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 
@@ -383,9 +580,12 @@ func init() {
 	Gather.MustRegister(VolumeServerVacuumingCompactCounter)
 	Gather.MustRegister(VolumeServerVacuumingCommitCounter)
 	Gather.MustRegister(VolumeServerVacuumingHistogram)
+	Gather.MustRegister(VolumeServerScrubbedNeedlesCounter)
 	Gather.MustRegister(VolumeServerVolumeGauge)
 	Gather.MustRegister(VolumeServerMaxVolumeCounter)
 	Gather.MustRegister(VolumeServerReadOnlyVolumeGauge)
+	Gather.MustRegister(VolumeServerReadCacheCounter)
+	Gather.MustRegister(VolumeServerReadCacheGauge)
 	Gather.MustRegister(VolumeServerDiskSizeGauge)
 	Gather.MustRegister(VolumeServerResourceGauge)
 
@@ -398,6 +598,16 @@ func init() {
 	Gather.MustRegister(S3BucketTrafficSentBytesCounter)
 	Gather.MustRegister(S3DeletedObjectsCounter)
 	Gather.MustRegister(S3UploadedObjectsCounter)
+	Gather.MustRegister(S3IdentityRequestCounter)
+	Gather.MustRegister(S3IdentityRequestHistogram)
+	Gather.MustRegister(S3IdentityTrafficReceivedBytesCounter)
+	Gather.MustRegister(S3IdentityTrafficSentBytesCounter)
+
+	Gather.MustRegister(MqLogCorruptionCounter)
+	Gather.MustRegister(MqRedeliveredMessagesCounter)
+	Gather.MustRegister(MqDeduplicatedMessagesCounter)
+	Gather.MustRegister(MqOversizedMessagesCounter)
+	Gather.MustRegister(MqSampledMessagesCounter)
 
 	go bucketMetricTTLControl()
 }
@@ -447,10 +657,48 @@ func SourceName(port uint32) string {
 	return net.JoinHostPort(hostname, strconv.Itoa(int(port)))
 }
 
-func RecordBucketActiveTime(bucket string) {
-	bucketLastActiveLock.Lock()
-	bucketLastActiveTsNs[bucket] = time.Now().UnixNano()
-	bucketLastActiveLock.Unlock()
+// S3BucketLabel records the bucket as active, and returns its label value in the bucket metrics
+func S3BucketLabel(bucket string) string {
+	return bucketLastActive.record(bucket)
+}
+
+// S3IdentityLabel records the identity as active, and returns its label value in the identity metrics
+func S3IdentityLabel(identity string) string {
+	return identityLastActive.record(identity)
+}
+
+type activeLabelValues struct {
+	lastActiveTsNs map[string]int64
+	sync.Mutex
+}
+
+func newActiveLabelValues() *activeLabelValues {
+	return &activeLabelValues{
+		lastActiveTsNs: make(map[string]int64),
+	}
+}
+
+func (a *activeLabelValues) record(value string) string {
+	a.Lock()
+	defer a.Unlock()
+	if _, found := a.lastActiveTsNs[value]; !found && S3MaxLabelValues > 0 && len(a.lastActiveTsNs) >= S3MaxLabelValues {
+		value = S3OtherLabelValue
+	}
+	a.lastActiveTsNs[value] = time.Now().UnixNano()
+	return value
+}
+
+// removeInactive forgets the values not active since the ttl, so new values can take their places
+func (a *activeLabelValues) removeInactive(ttl time.Duration, fn func(value string)) {
+	now := time.Now().UnixNano()
+	a.Lock()
+	defer a.Unlock()
+	for value, ts := range a.lastActiveTsNs {
+		if (now - ts) > ttl.Nanoseconds() {
+			delete(a.lastActiveTsNs, value)
+			fn(value)
+		}
+	}
 }
 
 func DeleteCollectionMetrics(collection string) {
@@ -466,28 +714,28 @@ func DeleteCollectionMetrics(collection string) {
 }
 
 func bucketMetricTTLControl() {
-	ttlNs := bucketAtiveTTL.Nanoseconds()
 	for {
-		now := time.Now().UnixNano()
-
-		bucketLastActiveLock.Lock()
-		for bucket, ts := range bucketLastActiveTsNs {
-			if (now - ts) > ttlNs {
-				delete(bucketLastActiveTsNs, bucket)
-
-				labels := prometheus.Labels{"bucket": bucket}
-				c := S3RequestCounter.DeletePartialMatch(labels)
-				c += S3RequestHistogram.DeletePartialMatch(labels)
-				c += S3TimeToFirstByteHistogram.DeletePartialMatch(labels)
-				c += S3BucketTrafficReceivedBytesCounter.DeletePartialMatch(labels)
-				c += S3BucketTrafficSentBytesCounter.DeletePartialMatch(labels)
-				c += S3DeletedObjectsCounter.DeletePartialMatch(labels)
-				c += S3UploadedObjectsCounter.DeletePartialMatch(labels)
-				glog.V(0).Infof("delete inactive bucket metrics, %s: %d", bucket, c)
-			}
-		}
+		bucketLastActive.removeInactive(bucketAtiveTTL, func(bucket string) {
+			labels := prometheus.Labels{"bucket": bucket}
+			c := S3RequestCounter.DeletePartialMatch(labels)
+			c += S3RequestHistogram.DeletePartialMatch(labels)
+			c += S3TimeToFirstByteHistogram.DeletePartialMatch(labels)
+			c += S3BucketTrafficReceivedBytesCounter.DeletePartialMatch(labels)
+			c += S3BucketTrafficSentBytesCounter.DeletePartialMatch(labels)
+			c += S3DeletedObjectsCounter.DeletePartialMatch(labels)
+			c += S3UploadedObjectsCounter.DeletePartialMatch(labels)
+			glog.V(0).Infof("delete inactive bucket metrics, %s: %d", bucket, c)
+		})
+
+		identityLastActive.removeInactive(bucketAtiveTTL, func(identity string) {
+			labels := prometheus.Labels{"identity": identity}
+			c := S3IdentityRequestCounter.DeletePartialMatch(labels)
+			c += S3IdentityRequestHistogram.DeletePartialMatch(labels)
+			c += S3IdentityTrafficReceivedBytesCounter.DeletePartialMatch(labels)
+			c += S3IdentityTrafficSentBytesCounter.DeletePartialMatch(labels)
+			glog.V(0).Infof("delete inactive identity metrics, %s: %d", identity, c)
+		})
 
-		bucketLastActiveLock.Unlock()
 		time.Sleep(bucketAtiveTTL)
 	}
 
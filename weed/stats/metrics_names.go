@@ -46,6 +46,9 @@ const (
 	ErrorReadChunk           = "read.chunk.failed"
 	ErrorReadCache           = "read.cache.failed"
 	ErrorReadStream          = "read.stream.failed"
+	ErrorOverloaded          = "overloaded"
+	ErrorClientRateLimited   = "client.rateLimited"
+	ErrorClientUploadLimited = "client.uploadLimited"
 
 	// s3 handler
 	ErrorCompletedNoSuchUpload      = "errorCompletedNoSuchUpload"
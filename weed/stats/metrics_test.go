@@ -0,0 +1,33 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveLabelValuesCap(t *testing.T) {
+	defer func(limit int) { S3MaxLabelValues = limit }(S3MaxLabelValues)
+	S3MaxLabelValues = 2
+
+	a := newActiveLabelValues()
+	for _, value := range []string{"b1", "b2", "b1"} {
+		if got := a.record(value); got != value {
+			t.Errorf("record %s: got %s", value, got)
+		}
+	}
+	if got := a.record("b3"); got != S3OtherLabelValue {
+		t.Errorf("over the cap: got %s", got)
+	}
+
+	// inactive values release their places
+	var removed []string
+	a.removeInactive(-time.Second, func(value string) {
+		removed = append(removed, value)
+	})
+	if len(removed) != 3 {
+		t.Errorf("removed %v", removed)
+	}
+	if got := a.record("b3"); got != "b3" {
+		t.Errorf("after removal: got %s", got)
+	}
+}
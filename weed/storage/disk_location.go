@@ -36,6 +36,11 @@ type DiskLocation struct {
 
 	isDiskSpaceLow bool
 	closeCh        chan struct{}
+
+	readCache *NeedleReadCache // caches the needles of the hard drive or remote tier volumes, if set
+
+	// the volumes closed cleanly at the last shutdown, only used when loading the existing volumes
+	cleanShutdownVolumes map[needle.VolumeId]*cleanShutdownVolume
 }
 
 func GenerateDirUuid(dir string) (dirUuidString string, err error) {
@@ -170,12 +175,16 @@ func (l *DiskLocation) loadExistingVolume(dirEntry os.DirEntry, needleMapKind Ne
 		return true
 	}
 
-	// load the volume
-	v, e := NewVolume(l.Directory, l.IdxDirectory, collection, vid, needleMapKind, nil, nil, 0, 0, ldbTimeout)
+	// load the volume, skipping the data integrity check if closed cleanly
+	v := newVolume(l.Directory, l.IdxDirectory, collection, vid, needleMapKind, nil, nil, 0, ldbTimeout)
+	v.cleanShutdown = l.cleanShutdownVolumes[vid]
+	e := v.load(true, true, needleMapKind, 0)
+	v.startWorker()
 	if e != nil {
 		glog.V(0).Infof("new volume %s error %s", volumeName, e)
 		return false
 	}
+	v.maybeResumeInPlaceCompaction()
 
 	l.SetVolume(vid, v)
 
@@ -235,7 +244,12 @@ func (l *DiskLocation) loadExistingVolumes(needleMapKind NeedleMapKind, ldbTimeo
 			workerNum = 10
 		}
 	}
+	l.cleanShutdownVolumes = l.takeCleanShutdownMarker()
+	if l.cleanShutdownVolumes != nil {
+		glog.V(0).Infof("dir %s was shut down cleanly with %d volumes", l.Directory, len(l.cleanShutdownVolumes))
+	}
 	l.concurrentLoadingVolumes(needleMapKind, workerNum, ldbTimeout)
+	l.cleanShutdownVolumes = nil
 	glog.V(0).Infof("Store started on dir: %s with %d volumes max %d", l.Directory, len(l.volumes), l.MaxVolumeCount)
 
 	l.loadAllEcShards()
@@ -389,6 +403,9 @@ func (l *DiskLocation) Close() {
 	for _, v := range l.volumes {
 		v.Close()
 	}
+	if err := l.writeCleanShutdownMarker(l.volumes); err != nil {
+		glog.Warningf("write clean shutdown marker in %s: %v", l.Directory, err)
+	}
 	l.volumesLock.Unlock()
 
 	l.ecVolumesLock.Lock()
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+// the marker is written after all volumes are closed cleanly, and removed before loading the volumes,
+// so any crash after the volume server starts again would leave no marker
+const cleanShutdownMarkerFile = "vol_dir.clean"
+
+// cleanShutdownVolume is the state of the volume files when the volume is closed cleanly
+type cleanShutdownVolume struct {
+	Collection     string `json:"collection"`
+	DatSize        int64  `json:"datSize"`
+	DatModTimeNs   int64  `json:"datModTimeNs"`
+	IdxSize        int64  `json:"idxSize"`
+	LastAppendAtNs uint64 `json:"lastAppendAtNs"`
+}
+
+type cleanShutdownMarker struct {
+	Volumes map[needle.VolumeId]*cleanShutdownVolume `json:"volumes"`
+}
+
+// matches checks the volume files are not changed since the clean shutdown
+func (c *cleanShutdownVolume) matches(v *Volume, datSize int64, idxSize int64) bool {
+	if c == nil || c.Collection != v.Collection || c.DatSize != datSize || c.IdxSize != idxSize {
+		return false
+	}
+	datStat, err := os.Stat(v.FileName(".dat"))
+	return err == nil && datStat.ModTime().UnixNano() == c.DatModTimeNs
+}
+
+// writeCleanShutdownMarker records the closed volumes, writing to a temp file and renaming it,
+// so a partially written marker is never read
+func (l *DiskLocation) writeCleanShutdownMarker(volumes map[needle.VolumeId]*Volume) error {
+	marker := &cleanShutdownMarker{Volumes: make(map[needle.VolumeId]*cleanShutdownVolume)}
+	for vid, v := range volumes {
+		if v.HasRemoteFile() {
+			continue
+		}
+		datStat, datErr := os.Stat(v.FileName(".dat"))
+		idxStat, idxErr := os.Stat(v.FileName(".idx"))
+		if datErr != nil || idxErr != nil {
+			continue
+		}
+		marker.Volumes[vid] = &cleanShutdownVolume{
+			Collection:     v.Collection,
+			DatSize:        datStat.Size(),
+			DatModTimeNs:   datStat.ModTime().UnixNano(),
+			IdxSize:        idxStat.Size(),
+			LastAppendAtNs: v.lastAppendAtNs,
+		}
+	}
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+
+	markerPath := filepath.Join(l.Directory, cleanShutdownMarkerFile)
+	tmpPath := markerPath + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = os.Rename(tmpPath, markerPath); err != nil {
+		return err
+	}
+	return syncDir(l.Directory)
+}
+
+// takeCleanShutdownMarker reads and removes the marker, returning nil if the last shutdown was not clean
+func (l *DiskLocation) takeCleanShutdownMarker() map[needle.VolumeId]*cleanShutdownVolume {
+	markerPath := filepath.Join(l.Directory, cleanShutdownMarkerFile)
+	data, err := os.ReadFile(markerPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		glog.Warningf("read clean shutdown marker %s: %v", markerPath, err)
+		return nil
+	}
+	// the volumes could be changed after being loaded, so the marker must be gone before that
+	if err = os.Remove(markerPath); err == nil {
+		err = syncDir(l.Directory)
+	}
+	if err != nil {
+		glog.Warningf("remove clean shutdown marker %s: %v", markerPath, err)
+		return nil
+	}
+	marker := &cleanShutdownMarker{}
+	if err = json.Unmarshal(data, marker); err != nil {
+		glog.Warningf("parse clean shutdown marker %s: %v", markerPath, err)
+		return nil
+	}
+	return marker.Volumes
+}
+
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		// directories can not be synced on windows
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err = d.Sync(); err != nil {
+		return fmt.Errorf("sync dir %s: %v", dir, err)
+	}
+	return nil
+}
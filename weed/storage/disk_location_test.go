@@ -7,6 +7,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -77,3 +78,46 @@ func TestUnUsedSpace(t *testing.T) {
 	}
 
 }
+
+func TestCleanShutdownMarker(t *testing.T) {
+	dir := t.TempDir()
+	minFreeSpace := util.MinFreeSpace{Type: util.AsPercent, Percent: 0, Raw: "0"}
+
+	location := NewDiskLocation(dir, 10, minFreeSpace, "", types.HddType)
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+	location.SetVolume(1, v)
+	if _, _, _, err = v.writeNeedle2(newRandomNeedle(1), true, false); err != nil {
+		t.Fatalf("write needle: %v", err)
+	}
+	lastAppendAtNs := v.lastAppendAtNs
+	location.Close()
+
+	// the marker is consumed once
+	location = NewDiskLocation(dir, 10, minFreeSpace, "", types.HddType)
+	location.loadExistingVolumes(NeedleMapInMemory, 0)
+	if util.FileExists(dir + "/" + cleanShutdownMarkerFile) {
+		t.Fatalf("marker should be removed after loading")
+	}
+	v, found := location.FindVolume(1)
+	if !found || v.lastAppendAtNs != lastAppendAtNs {
+		t.Fatalf("volume 1 found %v, lastAppendAtNs %d, expected %d", found, v.lastAppendAtNs, lastAppendAtNs)
+	}
+	location.Close()
+
+	// changed volume files do not match
+	marker := location.takeCleanShutdownMarker()[1]
+	if marker == nil || location.takeCleanShutdownMarker() != nil {
+		t.Fatalf("marker should be taken once")
+	}
+	v = newVolume(dir, dir, "", 1, NeedleMapInMemory, nil, nil, 0, 0)
+	datSize := marker.DatSize
+	if !marker.matches(v, datSize, marker.IdxSize) {
+		t.Errorf("unchanged volume should match")
+	}
+	if marker.matches(v, datSize+8, marker.IdxSize) || marker.matches(v, datSize, marker.IdxSize+16) {
+		t.Errorf("changed volume should not match")
+	}
+}
@@ -12,8 +12,8 @@ type Interval struct {
 	LargeBlockRowsCount int
 }
 
-func LocateData(largeBlockLength, smallBlockLength int64, shardDatSize int64, offset int64, size types.Size) (intervals []Interval) {
-	blockIndex, isLargeBlock, nLargeBlockRows, innerBlockOffset := locateOffset(largeBlockLength, smallBlockLength, shardDatSize, offset)
+func (s Scheme) LocateData(largeBlockLength, smallBlockLength int64, shardDatSize int64, offset int64, size types.Size) (intervals []Interval) {
+	blockIndex, isLargeBlock, nLargeBlockRows, innerBlockOffset := s.locateOffset(largeBlockLength, smallBlockLength, shardDatSize, offset)
 
 	for size > 0 {
 		interval := Interval{
@@ -38,7 +38,7 @@ func LocateData(largeBlockLength, smallBlockLength int64, shardDatSize int64, of
 
 		size -= interval.Size
 		blockIndex += 1
-		if isLargeBlock && blockIndex == interval.LargeBlockRowsCount*DataShardsCount {
+		if isLargeBlock && blockIndex == interval.LargeBlockRowsCount*s.DataShards {
 			isLargeBlock = false
 			blockIndex = 0
 		}
@@ -48,8 +48,8 @@ func LocateData(largeBlockLength, smallBlockLength int64, shardDatSize int64, of
 	return
 }
 
-func locateOffset(largeBlockLength, smallBlockLength int64, shardDatSize int64, offset int64) (blockIndex int, isLargeBlock bool, nLargeBlockRows int64, innerBlockOffset int64) {
-	largeRowSize := largeBlockLength * DataShardsCount
+func (s Scheme) locateOffset(largeBlockLength, smallBlockLength int64, shardDatSize int64, offset int64) (blockIndex int, isLargeBlock bool, nLargeBlockRows int64, innerBlockOffset int64) {
+	largeRowSize := largeBlockLength * int64(s.DataShards)
 	nLargeBlockRows = (shardDatSize - 1) / largeBlockLength
 
 	// if offset is within the large block area
@@ -71,14 +71,14 @@ func locateOffsetWithinBlocks(blockLength int64, offset int64) (blockIndex int,
 	return
 }
 
-func (interval Interval) ToShardIdAndOffset(largeBlockSize, smallBlockSize int64) (ShardId, int64) {
+func (s Scheme) ToShardIdAndOffset(interval Interval, largeBlockSize, smallBlockSize int64) (ShardId, int64) {
 	ecFileOffset := interval.InnerBlockOffset
-	rowIndex := interval.BlockIndex / DataShardsCount
+	rowIndex := interval.BlockIndex / s.DataShards
 	if interval.IsLargeBlock {
 		ecFileOffset += int64(rowIndex) * largeBlockSize
 	} else {
 		ecFileOffset += int64(interval.LargeBlockRowsCount)*largeBlockSize + int64(rowIndex)*smallBlockSize
 	}
-	ecFileIndex := interval.BlockIndex % DataShardsCount
+	ecFileIndex := interval.BlockIndex % s.DataShards
 	return ShardId(ecFileIndex), ecFileOffset
 }
@@ -0,0 +1,64 @@
+package erasure_coding
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxShardCount is limited by the bits of ShardBits
+const MaxShardCount = 32
+
+// Scheme is the erasure coding layout of a volume, the data shards come first, followed by the parity shards.
+// Smaller schemes, e.g. 4+2, allow small clusters to use erasure coding.
+type Scheme struct {
+	DataShards   int
+	ParityShards int
+}
+
+var DefaultScheme = Scheme{DataShards: DataShardsCount, ParityShards: ParityShardsCount}
+
+// NewScheme converts the shard counts of the protobuf messages, where zeros mean the default scheme
+func NewScheme(dataShards, parityShards uint32) Scheme {
+	if dataShards == 0 && parityShards == 0 {
+		return DefaultScheme
+	}
+	return Scheme{DataShards: int(dataShards), ParityShards: int(parityShards)}
+}
+
+// ParseScheme parses the scheme in the form of "data+parity", e.g. "6+3"
+func ParseScheme(s string) (scheme Scheme, err error) {
+	dataShards, parityShards, found := strings.Cut(s, "+")
+	if !found {
+		return scheme, fmt.Errorf("erasure coding scheme %q should be data+parity, e.g. 6+3", s)
+	}
+	if scheme.DataShards, err = strconv.Atoi(strings.TrimSpace(dataShards)); err != nil {
+		return scheme, fmt.Errorf("erasure coding scheme %q: %v", s, err)
+	}
+	if scheme.ParityShards, err = strconv.Atoi(strings.TrimSpace(parityShards)); err != nil {
+		return scheme, fmt.Errorf("erasure coding scheme %q: %v", s, err)
+	}
+	return scheme, scheme.Validate()
+}
+
+func (s Scheme) Validate() error {
+	if s.DataShards < 1 || s.ParityShards < 1 {
+		return fmt.Errorf("erasure coding scheme %s: needs at least 1 data shard and 1 parity shard", s)
+	}
+	if s.TotalShards() > MaxShardCount {
+		return fmt.Errorf("erasure coding scheme %s: more than %d shards", s, MaxShardCount)
+	}
+	return nil
+}
+
+func (s Scheme) TotalShards() int {
+	return s.DataShards + s.ParityShards
+}
+
+func (s Scheme) IsDefault() bool {
+	return s == DefaultScheme
+}
+
+func (s Scheme) String() string {
+	return fmt.Sprintf("%d+%d", s.DataShards, s.ParityShards)
+}
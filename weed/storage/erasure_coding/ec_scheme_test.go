@@ -0,0 +1,110 @@
+package erasure_coding
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle_map"
+)
+
+func TestParseScheme(t *testing.T) {
+	for _, tt := range []struct {
+		s       string
+		scheme  Scheme
+		isValid bool
+	}{
+		{"10+4", DefaultScheme, true},
+		{"4+2", Scheme{DataShards: 4, ParityShards: 2}, true},
+		{" 6 + 3 ", Scheme{DataShards: 6, ParityShards: 3}, true},
+		{"28+4", Scheme{DataShards: 28, ParityShards: 4}, true},
+		{"28+5", Scheme{}, false},
+		{"4+0", Scheme{}, false},
+		{"4", Scheme{}, false},
+		{"a+2", Scheme{}, false},
+	} {
+		scheme, err := ParseScheme(tt.s)
+		if (err == nil) != tt.isValid || tt.isValid && scheme != tt.scheme {
+			t.Errorf("ParseScheme(%q) = %v, %v", tt.s, scheme, err)
+		}
+	}
+	if NewScheme(0, 0) != DefaultScheme || NewScheme(4, 2).String() != "4+2" {
+		t.Errorf("unexpected schemes %v %v", NewScheme(0, 0), NewScheme(4, 2))
+	}
+}
+
+func TestEncodeRebuildDecodeWithScheme(t *testing.T) {
+	datData, err := os.ReadFile("1.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idxData, err := os.ReadFile("1.idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scheme := range []Scheme{{DataShards: 4, ParityShards: 2}, {DataShards: 6, ParityShards: 3}} {
+		baseFileName := filepath.Join(t.TempDir(), "1")
+		os.WriteFile(baseFileName+".dat", datData, 0644)
+		os.WriteFile(baseFileName+".idx", idxData, 0644)
+
+		if err := WriteEcFiles(baseFileName, scheme); err != nil {
+			t.Fatalf("%s: WriteEcFiles: %v", scheme, err)
+		}
+		if _, err := os.Stat(baseFileName + ToExt(scheme.TotalShards())); !os.IsNotExist(err) {
+			t.Errorf("%s: unexpected shard %d", scheme, scheme.TotalShards())
+		}
+
+		// every needle is read from the data shards
+		shards := make([][]byte, scheme.TotalShards())
+		for i := range shards {
+			if shards[i], err = os.ReadFile(baseFileName + ToExt(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		nm, err := readNeedleMap(baseFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nm.AscendingVisit(func(value needle_map.NeedleValue) error {
+			offset := value.Offset.ToActualOffset()
+			var data []byte
+			for _, interval := range scheme.LocateData(ErasureCodingLargeBlockSize, ErasureCodingSmallBlockSize, int64(len(datData))/int64(scheme.DataShards), offset, value.Size) {
+				shardId, shardOffset := scheme.ToShardIdAndOffset(interval, ErasureCodingLargeBlockSize, ErasureCodingSmallBlockSize)
+				data = append(data, shards[shardId][shardOffset:shardOffset+int64(interval.Size)]...)
+			}
+			if !bytes.Equal(data, datData[offset:offset+int64(value.Size)]) {
+				t.Errorf("%s: needle %d differs", scheme, value.Key)
+			}
+			return nil
+		})
+		nm.Close()
+
+		// lose as many shards as the parity shards
+		for i := 0; i < scheme.ParityShards; i++ {
+			os.Remove(baseFileName + ToExt(i*2))
+		}
+		rebuilt, err := RebuildEcFiles(baseFileName, scheme)
+		if err != nil || len(rebuilt) != scheme.ParityShards {
+			t.Fatalf("%s: RebuildEcFiles %v: %v", scheme, rebuilt, err)
+		}
+		for _, shardId := range rebuilt {
+			if data, _ := os.ReadFile(baseFileName + ToExt(int(shardId))); !bytes.Equal(data, shards[shardId]) {
+				t.Errorf("%s: rebuilt shard %d differs", scheme, shardId)
+			}
+		}
+
+		var shardFileNames []string
+		for i := 0; i < scheme.DataShards; i++ {
+			shardFileNames = append(shardFileNames, baseFileName+ToExt(i))
+		}
+		os.Remove(baseFileName + ".dat")
+		if err := WriteDatFile(baseFileName, int64(len(datData)), shardFileNames); err != nil {
+			t.Fatalf("%s: WriteDatFile: %v", scheme, err)
+		}
+		if data, _ := os.ReadFile(baseFileName + ".dat"); !bytes.Equal(data, datData) {
+			t.Errorf("%s: decoded dat file differs", scheme)
+		}
+	}
+}
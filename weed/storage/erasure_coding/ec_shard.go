@@ -101,3 +101,19 @@ func (shard *EcVolumeShard) ReadAt(buf []byte, offset int64) (int, error) {
 	return n, err
 
 }
+
+// WriteAt overwrites the shard content, only to repair the corrupted intervals found by scrubbing
+func (shard *EcVolumeShard) WriteAt(buf []byte, offset int64) (int, error) {
+	fileName := shard.FileName() + ToExt(int(shard.ShardId))
+	f, err := os.OpenFile(fileName, os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("cannot write ec volume shard %s: %v", fileName, err)
+	}
+	defer f.Close()
+
+	n, err := f.WriteAt(buf, offset)
+	if err == nil {
+		err = f.Sync()
+	}
+	return n, err
+}
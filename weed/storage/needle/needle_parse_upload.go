@@ -176,8 +176,7 @@ func parseUpload(r *http.Request, sizeLimit int64, pu *ParsedUpload) (e error) {
 			}
 		}
 
-		pu.IsGzipped = part.Header.Get("Content-Encoding") == "gzip"
-		// pu.IsZstd = part.Header.Get("Content-Encoding") == "zstd"
+		pu.IsGzipped = isCompressedEncoding(part.Header.Get("Content-Encoding"))
 
 	} else {
 		disposition := r.Header.Get("Content-Disposition")
@@ -225,8 +224,7 @@ func parseUpload(r *http.Request, sizeLimit int64, pu *ParsedUpload) (e error) {
 
 		pu.Data = pu.bytesBuffer.Bytes()
 		pu.MimeType = contentType
-		pu.IsGzipped = r.Header.Get("Content-Encoding") == "gzip"
-		// pu.IsZstd = r.Header.Get("Content-Encoding") == "zstd"
+		pu.IsGzipped = isCompressedEncoding(r.Header.Get("Content-Encoding"))
 	}
 
 	pu.IsChunkedFile, _ = strconv.ParseBool(r.FormValue("cm"))
@@ -251,3 +249,8 @@ func parseUpload(r *http.Request, sizeLimit int64, pu *ParsedUpload) (e error) {
 
 	return
 }
+
+// the needle only records whether the data is compressed, the algorithm is detected when reading
+func isCompressedEncoding(contentEncoding string) bool {
+	return contentEncoding == "gzip" || contentEncoding == "zstd"
+}
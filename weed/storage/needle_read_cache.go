@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"container/heap"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+const (
+	NeedleReadCacheLRU = "lru"
+	NeedleReadCacheLFU = "lfu"
+
+	needleReadCacheFileExt = ".ncache"
+)
+
+// NeedleReadCache keeps the needles recently or frequently read from the hard drive or remote tier volumes
+// in files on a fast local device, e.g., NVMe or SSD.
+// The cached needles are checked against the current needle offset and size, and the volume compaction revision,
+// so the overwritten, deleted, or compacted needles are never served from the cache.
+type NeedleReadCache struct {
+	dir           string
+	capacity      int64
+	maxNeedleSize int64
+	isLfu         bool
+	sync.Mutex
+	entries        map[needleCacheKey]*needleCacheEntry
+	evictionHeap   needleCacheHeap
+	usedBytes      int64
+	accessSequence uint64
+	fileSequence   uint64
+	pendingWrites  chan *needleCacheEntry
+}
+
+type needleCacheKey struct {
+	volumeId needle.VolumeId
+	needleId NeedleId
+}
+
+type needleCacheEntry struct {
+	needleCacheKey
+	offset             Offset
+	size               Size
+	compactionRevision uint16
+	fileName           string
+	blob               []byte // only until written to the file
+	diskSize           int64
+	frequency          uint64
+	lastAccess         uint64
+	heapIndex          int
+}
+
+// NewNeedleReadCache creates the cache in the directory, and removes the needles cached by the previous run
+func NewNeedleReadCache(dir string, capacity int64, eviction string) (*NeedleReadCache, error) {
+	eviction = strings.ToLower(eviction)
+	if eviction != NeedleReadCacheLRU && eviction != NeedleReadCacheLFU {
+		return nil, fmt.Errorf("unknown read cache eviction %q, expecting %s or %s", eviction, NeedleReadCacheLRU, NeedleReadCacheLFU)
+	}
+	if capacity <= 0 {
+		return nil, fmt.Errorf("read cache size %d should be positive", capacity)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create read cache dir %s: %v", dir, err)
+	}
+	if oldFiles, err := filepath.Glob(filepath.Join(dir, "*"+needleReadCacheFileExt)); err == nil {
+		for _, oldFile := range oldFiles {
+			os.Remove(oldFile)
+		}
+	}
+	c := &NeedleReadCache{
+		dir:           dir,
+		capacity:      capacity,
+		maxNeedleSize: capacity / 16,
+		isLfu:         eviction == NeedleReadCacheLFU,
+		entries:       make(map[needleCacheKey]*needleCacheEntry),
+		pendingWrites: make(chan *needleCacheEntry, 64),
+	}
+	c.evictionHeap.isLfu = c.isLfu
+	go c.loopWriteNeedles()
+	glog.V(0).Infof("needle read cache in %s, %d bytes, %s eviction", dir, capacity, eviction)
+	return c, nil
+}
+
+// Get returns the cached needle blob, if it is still at the offset with the size in the volume of the compaction revision
+func (c *NeedleReadCache) Get(volumeId needle.VolumeId, needleId NeedleId, offset Offset, size Size, compactionRevision uint16) []byte {
+	key := needleCacheKey{volumeId: volumeId, needleId: needleId}
+	c.Lock()
+	entry, found := c.entries[key]
+	if found && (entry.offset != offset || entry.size != size || entry.compactionRevision != compactionRevision) {
+		c.removeEntry(entry)
+		found = false
+	}
+	if !found {
+		c.Unlock()
+		stats.VolumeServerReadCacheCounter.WithLabelValues("miss").Inc()
+		return nil
+	}
+	c.accessSequence++
+	entry.lastAccess = c.accessSequence
+	entry.frequency++
+	heap.Fix(&c.evictionHeap, entry.heapIndex)
+	fileName := entry.fileName
+	c.Unlock()
+
+	// the file name is unique, so it is never overwritten, and it is gone if evicted in between
+	blob, err := os.ReadFile(fileName)
+	if err != nil || int64(len(blob)) != entry.diskSize {
+		stats.VolumeServerReadCacheCounter.WithLabelValues("miss").Inc()
+		return nil
+	}
+	stats.VolumeServerReadCacheCounter.WithLabelValues("hit").Inc()
+	return blob
+}
+
+// Set caches the needle blob read at the offset, asynchronously and only if the write queue is not full
+func (c *NeedleReadCache) Set(volumeId needle.VolumeId, needleId NeedleId, offset Offset, size Size, compactionRevision uint16, blob []byte) {
+	if int64(len(blob)) > c.maxNeedleSize {
+		return
+	}
+	entry := &needleCacheEntry{
+		needleCacheKey:     needleCacheKey{volumeId: volumeId, needleId: needleId},
+		offset:             offset,
+		size:               size,
+		compactionRevision: compactionRevision,
+		blob:               blob,
+		diskSize:           int64(len(blob)),
+		heapIndex:          -1,
+	}
+	select {
+	case c.pendingWrites <- entry:
+	default:
+		stats.VolumeServerReadCacheCounter.WithLabelValues("skip").Inc()
+	}
+}
+
+// DeleteVolume forgets the cached needles of the volume, e.g., when the volume is deleted or unmounted
+func (c *NeedleReadCache) DeleteVolume(volumeId needle.VolumeId) {
+	c.Lock()
+	defer c.Unlock()
+	for key, entry := range c.entries {
+		if key.volumeId == volumeId {
+			c.removeEntry(entry)
+		}
+	}
+}
+
+func (c *NeedleReadCache) loopWriteNeedles() {
+	for entry := range c.pendingWrites {
+		c.Lock()
+		c.fileSequence++
+		entry.fileName = filepath.Join(c.dir, fmt.Sprintf("%d_%s_%d%s", entry.volumeId, entry.needleId, c.fileSequence, needleReadCacheFileExt))
+		c.Unlock()
+
+		if err := os.WriteFile(entry.fileName, entry.blob, 0644); err != nil {
+			glog.V(1).Infof("write read cache %s: %v", entry.fileName, err)
+			os.Remove(entry.fileName)
+			stats.VolumeServerReadCacheCounter.WithLabelValues("error").Inc()
+			continue
+		}
+		entry.blob = nil
+		stats.VolumeServerReadCacheCounter.WithLabelValues("write").Inc()
+
+		c.Lock()
+		if oldEntry, found := c.entries[entry.needleCacheKey]; found {
+			c.removeEntry(oldEntry)
+		}
+		for c.usedBytes+entry.diskSize > c.capacity && c.evictionHeap.Len() > 0 {
+			c.removeEntry(c.evictionHeap.entries[0])
+			stats.VolumeServerReadCacheCounter.WithLabelValues("evict").Inc()
+		}
+		c.accessSequence++
+		entry.lastAccess = c.accessSequence
+		entry.frequency = 1
+		c.entries[entry.needleCacheKey] = entry
+		heap.Push(&c.evictionHeap, entry)
+		c.usedBytes += entry.diskSize
+		c.updateGauges()
+		c.Unlock()
+	}
+}
+
+func (c *NeedleReadCache) removeEntry(entry *needleCacheEntry) {
+	delete(c.entries, entry.needleCacheKey)
+	heap.Remove(&c.evictionHeap, entry.heapIndex)
+	c.usedBytes -= entry.diskSize
+	os.Remove(entry.fileName)
+	c.updateGauges()
+}
+
+func (c *NeedleReadCache) updateGauges() {
+	stats.VolumeServerReadCacheGauge.WithLabelValues("needles").Set(float64(len(c.entries)))
+	stats.VolumeServerReadCacheGauge.WithLabelValues("bytes").Set(float64(c.usedBytes))
+}
+
+// needleCacheHeap has the entry to evict first on the top
+type needleCacheHeap struct {
+	entries []*needleCacheEntry
+	isLfu   bool
+}
+
+func (h *needleCacheHeap) Len() int { return len(h.entries) }
+func (h *needleCacheHeap) Less(i, j int) bool {
+	if h.isLfu && h.entries[i].frequency != h.entries[j].frequency {
+		return h.entries[i].frequency < h.entries[j].frequency
+	}
+	return h.entries[i].lastAccess < h.entries[j].lastAccess
+}
+func (h *needleCacheHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.entries[i].heapIndex = i
+	h.entries[j].heapIndex = j
+}
+func (h *needleCacheHeap) Push(x any) {
+	entry := x.(*needleCacheEntry)
+	entry.heapIndex = len(h.entries)
+	h.entries = append(h.entries, entry)
+}
+func (h *needleCacheHeap) Pop() any {
+	last := len(h.entries) - 1
+	entry := h.entries[last]
+	h.entries[last] = nil
+	h.entries = h.entries[:last]
+	entry.heapIndex = -1
+	return entry
+}
+
+// needleReadCache is only for the client reads from the hard drive or remote tier volumes
+func (v *Volume) needleReadCache(readOption *ReadOption) *NeedleReadCache {
+	if readOption == nil || readOption.ReadDeleted || v.location == nil || v.location.readCache == nil {
+		return nil
+	}
+	if v.location.DiskType != HardDriveType && !v.HasRemoteFile() {
+		return nil
+	}
+	return v.location.readCache
+}
+
+// readNeedleThroughCache reads the needle from the read cache if cached, otherwise reads from the volume and caches it
+func (v *Volume) readNeedleThroughCache(readCache *NeedleReadCache, n *needle.Needle, offset Offset, size Size) error {
+	actualOffset := offset.ToActualOffset()
+	revision := v.SuperBlock.CompactionRevision
+	if blob := readCache.Get(v.Id, n.Id, offset, size, revision); blob != nil {
+		// the checksum is also verified
+		if err := n.ReadBytes(blob, actualOffset, size, v.Version()); err == nil {
+			return nil
+		}
+	}
+
+	blob, err := needle.ReadNeedleBlob(v.DataBackend, actualOffset, size, v.Version())
+	if err != nil {
+		return err
+	}
+	err = n.ReadBytes(blob, actualOffset, size, v.Version())
+	if err == needle.ErrorSizeMismatch && OffsetSize == 4 {
+		actualOffset += int64(MaxPossibleVolumeSize)
+		if blob, err = needle.ReadNeedleBlob(v.DataBackend, actualOffset, size, v.Version()); err != nil {
+			return err
+		}
+		err = n.ReadBytes(blob, actualOffset, size, v.Version())
+	}
+	if err == nil {
+		readCache.Set(v.Id, n.Id, offset, size, revision, blob)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func isNeedleCached(c *NeedleReadCache, needleId types.NeedleId) bool {
+	c.Lock()
+	defer c.Unlock()
+	_, found := c.entries[needleCacheKey{volumeId: 1, needleId: needleId}]
+	return found
+}
+
+func waitForCachedNeedle(t *testing.T, c *NeedleReadCache, needleId types.NeedleId) {
+	for i := 0; i < 100; i++ {
+		if isNeedleCached(c, needleId) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("needle %d is not cached", needleId)
+}
+
+func TestNeedleReadCacheEviction(t *testing.T) {
+	for _, eviction := range []string{NeedleReadCacheLRU, NeedleReadCacheLFU} {
+		c, err := NewNeedleReadCache(t.TempDir(), 4*512*16, eviction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blob := make([]byte, 512)
+		for i := 1; i <= 4*16; i++ {
+			c.Set(1, types.NeedleId(i), types.Uint32ToOffset(uint32(i)), 512, 0, blob)
+			waitForCachedNeedle(t, c, types.NeedleId(i))
+		}
+		// the needle 1 is read the most but the earliest, then the needle 2, and then the others once
+		for i := 0; i < 3; i++ {
+			if c.Get(1, 1, types.Uint32ToOffset(1), 512, 0) == nil {
+				t.Fatalf("%s: needle 1 should be cached", eviction)
+			}
+		}
+		if c.Get(1, 2, types.Uint32ToOffset(2), 512, 0) == nil {
+			t.Fatalf("%s: needle 2 should be cached", eviction)
+		}
+		for i := 3; i <= 4*16; i++ {
+			c.Get(1, types.NeedleId(i), types.Uint32ToOffset(uint32(i)), 512, 0)
+		}
+
+		c.Set(1, 100, types.Uint32ToOffset(100), 512, 0, blob)
+		waitForCachedNeedle(t, c, 100)
+		hasNeedle1, hasNeedle2 := isNeedleCached(c, 1), isNeedleCached(c, 2)
+		if eviction == NeedleReadCacheLRU && (hasNeedle1 || !hasNeedle2) {
+			t.Errorf("lru should evict the least recently read needle 1")
+		}
+		if eviction == NeedleReadCacheLFU && (!hasNeedle1 || hasNeedle2) {
+			t.Errorf("lfu should keep the most frequently read needle 1, and evict the needle 2")
+		}
+	}
+
+	if _, err := NewNeedleReadCache(t.TempDir(), 1024, "fifo"); err == nil {
+		t.Errorf("expect error for unknown eviction")
+	}
+}
+
+func TestNeedleReadCacheVolumeReads(t *testing.T) {
+	dir := t.TempDir()
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+	defer v.Close()
+	c, err := NewNeedleReadCache(t.TempDir(), 1024*1024, NeedleReadCacheLRU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.location = &DiskLocation{DiskType: types.HardDriveType, readCache: c}
+
+	write := func(fill byte) []byte {
+		n := newEmptyNeedle(1)
+		n.Data = bytes.Repeat([]byte{fill}, 1024)
+		n.Checksum = needle.NewCRC(n.Data)
+		if _, _, _, err := v.writeNeedle2(n, true, false); err != nil {
+			t.Fatalf("write needle: %v", err)
+		}
+		return n.Data
+	}
+	read := func() []byte {
+		n := newEmptyNeedle(1)
+		if _, err := v.readNeedle(n, &ReadOption{}, nil); err != nil {
+			t.Fatalf("read needle: %v", err)
+		}
+		return n.Data
+	}
+
+	data := write(1)
+	if !bytes.Equal(read(), data) {
+		t.Fatalf("unexpected data")
+	}
+	waitForCachedNeedle(t, c, 1)
+
+	// the cached needle is read, even if the volume data is changed underneath
+	nv, _ := v.nm.Get(1)
+	if _, err := v.DataBackend.WriteAt([]byte{0xff}, nv.Offset.ToActualOffset()+types.NeedleHeaderSize+types.DataSizeSize); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read(), data) {
+		t.Errorf("the needle should be read from the cache")
+	}
+
+	// the overwritten needle is not read from the cache
+	data = write(2)
+	if !bytes.Equal(read(), data) {
+		t.Errorf("the overwritten needle should not be read from the cache")
+	}
+
+	// the needles of the ssd volumes are not cached
+	v.location.DiskType = types.SsdType
+	if v.needleReadCache(&ReadOption{}) != nil {
+		t.Errorf("the ssd volume should not use the read cache")
+	}
+}
@@ -49,6 +49,11 @@ type ReadOption struct {
 	// increasing ReadBufferSize can reduce the number of get locks times and shorten read P99 latency.
 	// but will increase memory usage a bit. Use with hasSlowRead normally.
 	ReadBufferSize int
+
+	// ZeroCopy sends the data of the needles already verified with sendfile, skipping the checksum recompute
+	ZeroCopy bool
+
+	relocationRevision uint32 // to re-read the needle offset if the needle is relocated by the in place compaction
 }
 
 /*
@@ -73,6 +78,7 @@ type Store struct {
 	NewEcShardsChan     chan master_pb.VolumeEcShardInformationMessage
 	DeletedEcShardsChan chan master_pb.VolumeEcShardInformationMessage
 	isStopping          bool
+	readCache           *NeedleReadCache
 }
 
 func (s *Store) String() (str string) {
@@ -455,6 +461,14 @@ func (s *Store) DeleteVolumeNeedle(i needle.VolumeId, n *needle.Needle) (Size, e
 	return 0, fmt.Errorf("volume %d not found on %s:%d", i, s.Ip, s.Port)
 }
 
+// SetNeedleReadCache caches the needles read from the hard drive or remote tier volumes
+func (s *Store) SetNeedleReadCache(readCache *NeedleReadCache) {
+	s.readCache = readCache
+	for _, location := range s.Locations {
+		location.readCache = readCache
+	}
+}
+
 func (s *Store) ReadVolumeNeedle(i needle.VolumeId, n *needle.Needle, readOption *ReadOption, onReadSizeFn func(size Size)) (int, error) {
 	if v := s.findVolume(i); v != nil {
 		return v.readNeedle(n, readOption, onReadSizeFn)
@@ -544,6 +558,9 @@ func (s *Store) UnmountVolume(i needle.VolumeId) error {
 		DiskType:         string(v.location.DiskType),
 	}
 
+	if s.readCache != nil {
+		s.readCache.DeleteVolume(i)
+	}
 	for _, location := range s.Locations {
 		err := location.UnloadVolume(i)
 		if err == nil {
@@ -571,6 +588,9 @@ func (s *Store) DeleteVolume(i needle.VolumeId, onlyEmpty bool) error {
 		Ttl:              v.Ttl.ToUint32(),
 		DiskType:         string(v.location.DiskType),
 	}
+	if s.readCache != nil {
+		s.readCache.DeleteVolume(i)
+	}
 	for _, location := range s.Locations {
 		err := location.DeleteVolume(i, onlyEmpty)
 		if err == nil {
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+var errScrubStopped = errors.New("scrubbing stopped")
+
+func (s *Store) Volumes() (volumes []*Volume) {
+	for _, location := range s.Locations {
+		location.volumesLock.RLock()
+		for _, v := range location.volumes {
+			volumes = append(volumes, v)
+		}
+		location.volumesLock.RUnlock()
+	}
+	return
+}
+
+// ScrubEcVolume re-reads the live needles having intervals on the local shards, and verifies their checksums.
+// The corrupted intervals on the local shards are rebuilt from the other shards.
+// The needles failing the verification are passed to onCorrupted, with the error of the repair if not repaired.
+func (s *Store) ScrubEcVolume(ecVolume *erasure_coding.EcVolume, stopChan <-chan bool, throttle func(bytes int64), onCorrupted func(needleId types.NeedleId, err error, repairErr error)) (scrubbed int64, err error) {
+	err = ecVolume.WalkIndex(func(key types.NeedleId, offset types.Offset, size types.Size) error {
+		select {
+		case <-stopChan:
+			return errScrubStopped
+		default:
+		}
+		if offset.IsZero() || size.IsDeleted() || size == 0 {
+			return nil
+		}
+		found, scrubErr, readErr := s.scrubEcNeedle(ecVolume, key, offset, size)
+		if readErr != nil {
+			// the shards may be moved or temporarily unreachable
+			glog.V(1).Infof("scrub ec volume %d needle %s: %v", ecVolume.VolumeId, key, readErr)
+			return nil
+		}
+		if !found {
+			return nil
+		}
+		scrubbed++
+		throttle(needle.GetActualSize(size, ecVolume.Version))
+		if scrubErr != nil {
+			onCorrupted(key, scrubErr, s.repairEcNeedle(ecVolume, key, offset, size))
+		}
+		return nil
+	})
+	if err == errScrubStopped {
+		err = nil
+	}
+	return
+}
+
+func (s *Store) scrubEcNeedle(ecVolume *erasure_coding.EcVolume, needleId types.NeedleId, offset types.Offset, size types.Size) (found bool, scrubErr, err error) {
+	if len(ecVolume.Shards) == 0 {
+		return false, nil, fmt.Errorf("ec volume %d has no local shards", ecVolume.VolumeId)
+	}
+	intervals := ecVolume.LocateEcShardNeedleInterval(ecVolume.Version, offset.ToActualOffset(), size)
+
+	// every server holding a part of the needle scrubs the needle, to repair its own shards
+	for _, interval := range intervals {
+		shardId, _ := ecVolume.Scheme.ToShardIdAndOffset(interval, erasure_coding.ErasureCodingLargeBlockSize, erasure_coding.ErasureCodingSmallBlockSize)
+		if _, found = ecVolume.FindEcVolumeShard(shardId); found {
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	data, isDeleted, err := s.readEcShardIntervals(ecVolume.VolumeId, needleId, ecVolume, intervals)
+	if err != nil || isDeleted {
+		return false, nil, err
+	}
+	return true, verifyNeedleBlob(needleId, data, size, ecVolume.Version), nil
+}
+
+// ecNeedleRepair is a local shard interval of the needle, to be overwritten by the recovered bytes
+type ecNeedleRepair struct {
+	shard       *erasure_coding.EcVolumeShard
+	shardOffset int64
+	recovered   []byte
+}
+
+// repairEcNeedle rebuilds the needle in memory, with the local intervals recovered from the other shards,
+// and only overwrites the differing local intervals after the rebuilt needle passes the verification.
+func (s *Store) repairEcNeedle(ecVolume *erasure_coding.EcVolume, needleId types.NeedleId, offset types.Offset, size types.Size) error {
+	intervals := ecVolume.LocateEcShardNeedleInterval(ecVolume.Version, offset.ToActualOffset(), size)
+	if err := s.cachedLookupEcShardLocations(ecVolume); err != nil {
+		return fmt.Errorf("failed to locate shard via master grpc %s: %v", s.MasterAddress, err)
+	}
+
+	var data []byte
+	var repairs []*ecNeedleRepair
+	for _, interval := range intervals {
+		shardId, shardOffset := ecVolume.Scheme.ToShardIdAndOffset(interval, erasure_coding.ErasureCodingLargeBlockSize, erasure_coding.ErasureCodingSmallBlockSize)
+		shard, found := ecVolume.FindEcVolumeShard(shardId)
+		if !found {
+			remote, _, err := s.readOneEcShardInterval(needleId, ecVolume, interval)
+			if err != nil {
+				return fmt.Errorf("read ec shard %d.%d offset %d: %v", ecVolume.VolumeId, shardId, shardOffset, err)
+			}
+			data = append(data, remote...)
+			continue
+		}
+		local := make([]byte, interval.Size)
+		if _, err := shard.ReadAt(local, shardOffset); err != nil {
+			return fmt.Errorf("read ec shard %d.%d offset %d: %v", ecVolume.VolumeId, shardId, shardOffset, err)
+		}
+		recovered := make([]byte, interval.Size)
+		if _, _, err := s.recoverOneRemoteEcShardInterval(needleId, ecVolume, shardId, recovered, shardOffset); err != nil {
+			return fmt.Errorf("recover ec shard %d.%d offset %d: %v", ecVolume.VolumeId, shardId, shardOffset, err)
+		}
+		data = append(data, recovered...)
+		if !bytes.Equal(local, recovered) {
+			repairs = append(repairs, &ecNeedleRepair{shard: shard, shardOffset: shardOffset, recovered: recovered})
+		}
+	}
+	if len(repairs) == 0 {
+		return fmt.Errorf("the corrupted parts are not on the local shards")
+	}
+	if err := verifyNeedleBlob(needleId, data, size, ecVolume.Version); err != nil {
+		return fmt.Errorf("verify rebuilt needle: %v", err)
+	}
+
+	for _, repair := range repairs {
+		if _, err := repair.shard.WriteAt(repair.recovered, repair.shardOffset); err != nil {
+			return fmt.Errorf("repair ec shard %d.%d offset %d: %v", ecVolume.VolumeId, repair.shard.ShardId, repair.shardOffset, err)
+		}
+		glog.V(0).Infof("repaired ec shard %d.%d offset %d size %d of needle %s", ecVolume.VolumeId, repair.shard.ShardId, repair.shardOffset, len(repair.recovered), needleId)
+	}
+	return nil
+}
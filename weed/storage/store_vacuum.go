@@ -16,11 +16,20 @@ func (s *Store) CheckCompactVolume(volumeId needle.VolumeId) (float64, error) {
 	}
 	return 0, fmt.Errorf("volume id %d is not found during check compact", volumeId)
 }
-func (s *Store) CompactVolume(vid needle.VolumeId, preallocate int64, compactionBytePerSecond int64, progressFn ProgressFunc) error {
+func (s *Store) CompactVolume(vid needle.VolumeId, preallocate int64, compactionBytePerSecond int64, inPlace bool, progressFn ProgressFunc) error {
 	if v := s.findVolume(vid); v != nil {
 		s := stats.NewDiskStatus(v.dir)
-		if int64(s.Free) < preallocate {
-			return fmt.Errorf("free space: %d bytes, not enough for %d bytes", s.Free, preallocate)
+		// the compacted copy takes about the size of the live needles
+		copySize := int64(v.ContentSize()) - int64(v.DeletedSize())
+		if copySize < preallocate {
+			copySize = preallocate
+		}
+		if inPlace || v.hasInPlaceCompactionJournal() {
+			glog.V(0).Infof("compact volume %d in place, free space: %d bytes, compacted copy: %d bytes", vid, s.Free, copySize)
+			return v.CompactInPlace(compactionBytePerSecond, progressFn)
+		}
+		if int64(s.Free) < copySize {
+			return fmt.Errorf("volume %d needs %d bytes for the compacted copy, but only %d bytes are free, consider -compactInPlace", vid, copySize, s.Free)
 		}
 		return v.Compact2(preallocate, compactionBytePerSecond, progressFn)
 	}
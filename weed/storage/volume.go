@@ -5,6 +5,7 @@ import (
 	"path"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
@@ -45,28 +46,54 @@ type Volume struct {
 	lastCompactRevision    uint16
 	ldbTimeout             int64
 
-	isCompacting       bool
-	isCommitCompacting bool
+	isCompacting        bool
+	isCommitCompacting  bool
+	isCompactingInPlace bool   // the needles are being relocated inside the .dat file
+	lastCompactInPlace  bool   // the last compaction was in place, so there is nothing to commit
+	relocationRevision  uint32 // increased whenever the in place compaction relocates needles
 
 	volumeInfoRWLock sync.RWMutex
 	volumeInfo       *volume_server_pb.VolumeInfo
 	location         *DiskLocation
 
 	lastIoError error
+
+	cleanShutdown *cleanShutdownVolume // the volume files state at the last clean shutdown, if any
+
+	readCount        uint64 // the client reads since loaded, for the master to find the cold volumes
+	lastReadAtSecond int64  // unix time in seconds, the loading time if not read yet
+
+	verifiedNeedlesLock sync.Mutex
+	verifiedNeedles     map[types.NeedleId]types.Offset // the needles passed the checksum verification, for zero copy reads
+	zeroCopySendLock    sync.RWMutex                    // held by the zero copy sends outside of the dataFileAccessLock
 }
 
 func NewVolume(dirname string, dirIdx string, collection string, id needle.VolumeId, needleMapKind NeedleMapKind, replicaPlacement *super_block.ReplicaPlacement, ttl *needle.TTL, preallocate int64, memoryMapMaxSizeMb uint32, ldbTimeout int64) (v *Volume, e error) {
+	v = newVolume(dirname, dirIdx, collection, id, needleMapKind, replicaPlacement, ttl, memoryMapMaxSizeMb, ldbTimeout)
+	e = v.load(true, true, needleMapKind, preallocate)
+	v.startWorker()
+	if e == nil {
+		v.maybeResumeInPlaceCompaction()
+	}
+	return
+}
+
+func newVolume(dirname string, dirIdx string, collection string, id needle.VolumeId, needleMapKind NeedleMapKind, replicaPlacement *super_block.ReplicaPlacement, ttl *needle.TTL, memoryMapMaxSizeMb uint32, ldbTimeout int64) (v *Volume) {
 	// if replicaPlacement is nil, the superblock will be loaded from disk
 	v = &Volume{dir: dirname, dirIdx: dirIdx, Collection: collection, Id: id, MemoryMapMaxSizeMb: memoryMapMaxSizeMb,
 		asyncRequestsChan: make(chan *needle.AsyncRequest, 128)}
 	v.SuperBlock = super_block.SuperBlock{ReplicaPlacement: replicaPlacement, Ttl: ttl}
 	v.needleMapKind = needleMapKind
 	v.ldbTimeout = ldbTimeout
-	e = v.load(true, true, needleMapKind, preallocate)
-	v.startWorker()
+	v.lastReadAtSecond = time.Now().Unix()
 	return
 }
 
+func (v *Volume) recordRead() {
+	atomic.AddUint64(&v.readCount, 1)
+	atomic.StoreInt64(&v.lastReadAtSecond, time.Now().Unix())
+}
+
 func (v *Volume) String() string {
 	v.noWriteLock.RLock()
 	defer v.noWriteLock.RUnlock()
@@ -337,6 +364,8 @@ func (v *Volume) ToVolumeInformationMessage() (types.NeedleId, *master_pb.Volume
 		CompactRevision:  uint32(v.SuperBlock.CompactionRevision),
 		ModifiedAtSecond: modTime.Unix(),
 		DiskType:         string(v.location.DiskType),
+		ReadCount:        atomic.LoadUint64(&v.readCount),
+		LastReadAtSecond: atomic.LoadInt64(&v.lastReadAtSecond),
 	}
 
 	volumeInfo.RemoteStorageName, volumeInfo.RemoteStorageKey = v.RemoteStorageNameKey()
@@ -16,6 +16,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
 	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 func (v *Volume) GetVolumeSyncStatus() *volume_server_pb.VolumeSyncStatusResponse {
@@ -77,8 +78,9 @@ func (v *Volume) IncrementalBackup(volumeServer pb.ServerAddress, grpcDialOption
 	err = operation.WithVolumeServerClient(false, volumeServer, grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
 
 		stream, err := client.VolumeIncrementalCopy(context.Background(), &volume_server_pb.VolumeIncrementalCopyRequest{
-			VolumeId: uint32(v.Id),
-			SinceNs:  appendAtNs,
+			VolumeId:          uint32(v.Id),
+			SinceNs:           appendAtNs,
+			AcceptCompression: util.StreamCompressionZstd,
 		})
 		if err != nil {
 			return err
@@ -94,7 +96,11 @@ func (v *Volume) IncrementalBackup(volumeServer pb.ServerAddress, grpcDialOption
 				}
 			}
 
-			n, writeErr := v.DataBackend.WriteAt(resp.FileContent, writeOffset)
+			fileContent, decompressErr := util.DecompressStreamContent(resp.FileContent, resp.Compression)
+			if decompressErr != nil {
+				return decompressErr
+			}
+			n, writeErr := v.DataBackend.WriteAt(fileContent, writeOffset)
 			if writeErr != nil {
 				return writeErr
 			}
@@ -168,9 +174,24 @@ func (v *Volume) readAppendAtNs(offset Offset) (uint64, error) {
 
 }
 
+// IsLatestNeedle checks whether the needle written at the actual offset is still the current version.
+// The deletion records and empty needles are always the latest, since they are cheap to send.
+func (v *Volume) IsLatestNeedle(needleId NeedleId, offset int64, size Size) bool {
+	if size == 0 {
+		return true
+	}
+	nv, ok := v.nm.Get(needleId)
+	return ok && nv.Size.IsValid() && nv.Offset.ToActualOffset() == offset
+}
+
 // on server side
 func (v *Volume) BinarySearchByAppendAtNs(sinceNs uint64) (offset Offset, isLast bool, err error) {
 
+	if v.isRelocatingInPlace() {
+		err = fmt.Errorf("volume %d is being compacted in place", v.Id)
+		return
+	}
+
 	fileSize := int64(v.IndexFileSize())
 	if fileSize%NeedleMapEntrySize != 0 {
 		err = fmt.Errorf("unexpected file %s.idx size: %d", v.IndexFileName(), fileSize)
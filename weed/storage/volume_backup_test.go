@@ -1,6 +1,12 @@
 package storage
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
 
 func TestBinarySearch(t *testing.T) {
 	var testInput []int
@@ -37,3 +43,62 @@ func binarySearchForLargerThanTarget(nums []int, target int) int {
 	}
 	return l
 }
+
+type latestNeedleScanner struct {
+	v      *Volume
+	latest []bool
+}
+
+func (scanner *latestNeedleScanner) VisitSuperBlock(superBlock super_block.SuperBlock) error {
+	return nil
+}
+func (scanner *latestNeedleScanner) ReadNeedleBody() bool {
+	return false
+}
+func (scanner *latestNeedleScanner) VisitNeedle(n *needle.Needle, offset int64, needleHeader, needleBody []byte) error {
+	scanner.latest = append(scanner.latest, scanner.v.IsLatestNeedle(n.Id, offset, n.Size))
+	return nil
+}
+
+func TestIsLatestNeedle(t *testing.T) {
+	for _, needleMapKind := range []NeedleMapKind{NeedleMapInMemory, NeedleMapLevelDb} {
+		dir := t.TempDir()
+		v, err := NewVolume(dir, dir, "", 1, needleMapKind, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+		if err != nil {
+			t.Fatalf("volume creation: %v", err)
+		}
+		write := func(id uint64, data string) {
+			n := newEmptyNeedle(id)
+			n.Data = []byte(data)
+			n.Checksum = needle.NewCRC(n.Data)
+			if _, _, _, err := v.writeNeedle2(n, true, false); err != nil {
+				t.Fatalf("write needle %d: %v", id, err)
+			}
+		}
+		write(1, "first")
+		write(2, "deleted")
+		write(1, "overwritten")
+		if _, err := v.deleteNeedle2(newEmptyNeedle(2)); err != nil {
+			t.Fatalf("delete needle 2: %v", err)
+		}
+		write(3, "unchanged")
+
+		scanner := &latestNeedleScanner{v: v}
+		if err := ScanVolumeFileFrom(v.Version(), v.DataBackend, int64(v.SuperBlock.BlockSize()), scanner); err != nil {
+			t.Fatalf("scan volume: %v", err)
+		}
+		expected := []bool{false, false, true, true, true}
+		if len(scanner.latest) != len(expected) {
+			t.Fatalf("scanned %d needles, expected %d", len(scanner.latest), len(expected))
+		}
+		for i := range expected {
+			if scanner.latest[i] != expected[i] {
+				t.Errorf("needle record %d: latest %v, expected %v", i, scanner.latest[i], expected[i])
+			}
+		}
+		if v.IsLatestNeedle(types.NeedleId(4), int64(v.SuperBlock.BlockSize()), 10) {
+			t.Errorf("the missing needle should not be the latest")
+		}
+		v.Close()
+	}
+}
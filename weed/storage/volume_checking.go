@@ -167,3 +167,19 @@ func (v *Volume) checkIdxFile() error {
 	}
 	return fmt.Errorf("idx file %s does not exists", indexFileName)
 }
+
+// isUnchangedSinceCleanShutdown checks the volume files are the same as recorded at the last clean shutdown
+func (v *Volume) isUnchangedSinceCleanShutdown(indexFile *os.File) bool {
+	if v.cleanShutdown == nil {
+		return false
+	}
+	datSize, _, err := v.DataBackend.GetStat()
+	if err != nil {
+		return false
+	}
+	idxSize, err := util.GetFileSize(indexFile)
+	if err != nil {
+		return false
+	}
+	return v.cleanShutdown.matches(v, datSize, idxSize)
+}
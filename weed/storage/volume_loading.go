@@ -136,12 +136,18 @@ func (v *Volume) load(alsoLoadIndex bool, createDatIfMissing bool, needleMapKind
 		// storage tier, and download to local storage, which may cause the
 		// capactiy overloading.
 		if !v.HasRemoteFile() {
-			glog.V(0).Infof("checking volume data integrity for volume %d", v.Id)
-			if v.lastAppendAtNs, err = CheckVolumeDataIntegrity(v, indexFile); err != nil {
-				v.noWriteOrDelete = true
-				glog.V(0).Infof("volumeDataIntegrityChecking failed %v", err)
+			if v.isUnchangedSinceCleanShutdown(indexFile) {
+				glog.V(0).Infof("skip checking volume data integrity for volume %d after clean shutdown", v.Id)
+				v.lastAppendAtNs = v.cleanShutdown.LastAppendAtNs
+			} else {
+				glog.V(0).Infof("checking volume data integrity for volume %d", v.Id)
+				if v.lastAppendAtNs, err = CheckVolumeDataIntegrity(v, indexFile); err != nil {
+					v.noWriteOrDelete = true
+					glog.V(0).Infof("volumeDataIntegrityChecking failed %v", err)
+				}
 			}
 		}
+		v.cleanShutdown = nil
 
 		if v.noWriteOrDelete || v.noWriteCanDelete {
 			if v.nm, err = NewSortedFileNeedleMap(v.IndexFileName(), indexFile); err != nil {
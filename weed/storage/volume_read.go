@@ -25,6 +25,10 @@ func (v *Volume) readNeedle(n *needle.Needle, readOption *ReadOption, onReadSize
 	if !ok || nv.Offset.IsZero() {
 		return -1, ErrorNotFound
 	}
+	if readOption != nil {
+		// only the client reads come with the read option
+		v.recordRead()
+	}
 	readSize := nv.Size
 	if readSize.IsDeleted() {
 		if readOption != nil && readOption.ReadDeleted && readSize != TombstoneFileSize {
@@ -55,7 +59,11 @@ func (v *Volume) readNeedle(n *needle.Needle, readOption *ReadOption, onReadSize
 		}
 	}
 	if readOption == nil || !readOption.IsMetaOnly {
-		err = n.ReadData(v.DataBackend, nv.Offset.ToActualOffset(), readSize, v.Version())
+		if readCache := v.needleReadCache(readOption); readCache != nil {
+			err = v.readNeedleThroughCache(readCache, n, nv.Offset, readSize)
+		} else {
+			err = n.ReadData(v.DataBackend, nv.Offset.ToActualOffset(), readSize, v.Version())
+		}
 		v.checkReadWriteError(err)
 		if err != nil {
 			return 0, err
@@ -108,6 +116,7 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 		v.dataFileAccessLock.RLock()
 	}
 	nv, ok := v.nm.Get(n.Id)
+	readOption.relocationRevision = v.relocationRevision
 	if readOption.HasSlowRead {
 		v.dataFileAccessLock.RUnlock()
 	}
@@ -133,6 +142,15 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 		actualOffset += int64(MaxPossibleVolumeSize)
 	}
 
+	if readerFrom, ok := writer.(io.ReaderFrom); ok && readOption.ZeroCopy {
+		if handled, err := v.sendVerifiedNeedleData(n, nv.Offset, readOption, readerFrom, offset, size); handled {
+			if err != nil {
+				return fmt.Errorf("ReadNeedleData send: %v", err)
+			}
+			return nil
+		}
+	}
+
 	buf := mem.Allocate(min(readOption.ReadBufferSize, int(size)))
 	defer mem.Free(buf)
 
@@ -144,8 +162,8 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 			v.dataFileAccessLock.RLock()
 		}
 		// possibly re-read needle offset if volume is compacted
-		if readOption.VolumeRevision != v.SuperBlock.CompactionRevision {
-			// the volume is compacted
+		if readOption.VolumeRevision != v.SuperBlock.CompactionRevision || readOption.relocationRevision != v.relocationRevision {
+			// the volume is compacted, or the needle is relocated by the in place compaction
 			nv, ok = v.nm.Get(n.Id)
 			if !ok || nv.Offset.IsZero() {
 				if readOption.HasSlowRead {
@@ -155,6 +173,7 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 			}
 			actualOffset = nv.Offset.ToActualOffset()
 			readOption.VolumeRevision = v.SuperBlock.CompactionRevision
+			readOption.relocationRevision = v.relocationRevision
 		}
 		count, err := n.ReadNeedleData(v.DataBackend, actualOffset, buf, x)
 		if readOption.HasSlowRead {
@@ -189,12 +208,17 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 			break
 		}
 	}
-	if offset == 0 && size == int64(n.DataSize) && (n.Checksum != crc && uint32(n.Checksum) != crc.Value()) {
-		// the crc.Value() function is to be deprecated. this double checking is for backward compatibility
-		// with seaweed version using crc.Value() instead of uint32(crc), which appears in commit 056c480eb
-		// and switch appeared in version 3.09.
-		stats.VolumeServerHandlerCounter.WithLabelValues(stats.ErrorCRC).Inc()
-		return fmt.Errorf("ReadNeedleData checksum %v expected %v for Needle: %v,%v", crc, n.Checksum, v.Id, n)
+	if offset == 0 && size == int64(n.DataSize) {
+		if n.Checksum != crc && uint32(n.Checksum) != crc.Value() {
+			// the crc.Value() function is to be deprecated. this double checking is for backward compatibility
+			// with seaweed version using crc.Value() instead of uint32(crc), which appears in commit 056c480eb
+			// and switch appeared in version 3.09.
+			stats.VolumeServerHandlerCounter.WithLabelValues(stats.ErrorCRC).Inc()
+			return fmt.Errorf("ReadNeedleData checksum %v expected %v for Needle: %v,%v", crc, n.Checksum, v.Id, n)
+		}
+		if readOption.ZeroCopy {
+			v.markNeedleVerified(n.Id, nv.Offset)
+		}
 	}
 	return nil
 
@@ -228,10 +252,13 @@ func ScanVolumeFile(dirname string, collection string, id needle.VolumeId,
 	if v, err = loadVolumeWithoutIndex(dirname, collection, id, needleMapKind); err != nil {
 		return fmt.Errorf("failed to load volume %d: %v", id, err)
 	}
+	defer v.Close()
+	if v.hasInPlaceCompactionJournal() {
+		return fmt.Errorf("volume %d has an unfinished in place compaction, which is resumed by loading the volume", id)
+	}
 	if err = volumeFileScanner.VisitSuperBlock(v.SuperBlock); err != nil {
 		return fmt.Errorf("failed to process volume %d super block: %v", id, err)
 	}
-	defer v.Close()
 
 	version := v.Version()
 
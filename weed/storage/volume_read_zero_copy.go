@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"io"
+	"os"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+// the verified needles are forgotten all together beyond this, to bound the memory
+const maxVerifiedNeedlesPerVolume = 1 << 16
+
+// markNeedleVerified remembers the needle at the offset passed the checksum verification,
+// so reading it again can skip the checksum recompute
+func (v *Volume) markNeedleVerified(needleId NeedleId, offset Offset) {
+	v.verifiedNeedlesLock.Lock()
+	defer v.verifiedNeedlesLock.Unlock()
+	if v.verifiedNeedles == nil || len(v.verifiedNeedles) >= maxVerifiedNeedlesPerVolume {
+		v.verifiedNeedles = make(map[NeedleId]Offset)
+	}
+	v.verifiedNeedles[needleId] = offset
+}
+
+// isNeedleVerified is false if the needle is rewritten or moved by compaction after the verification
+func (v *Volume) isNeedleVerified(needleId NeedleId, offset Offset) bool {
+	v.verifiedNeedlesLock.Lock()
+	defer v.verifiedNeedlesLock.Unlock()
+	verifiedOffset, found := v.verifiedNeedles[needleId]
+	return found && verifiedOffset == offset
+}
+
+// sendVerifiedNeedleData writes the data of a verified needle with sendfile or splice, e.g., to a http response,
+// without copying through the user space buffers or recomputing the checksum.
+// It is not handled if the needle is not verified, or the data is not in a local disk file.
+func (v *Volume) sendVerifiedNeedleData(n *needle.Needle, needleOffset Offset, readOption *ReadOption, readerFrom io.ReaderFrom, offset int64, size int64) (handled bool, err error) {
+	diskFile, ok := v.DataBackend.(*backend.DiskFile)
+	if !ok {
+		return false, nil
+	}
+	size = min64(size, int64(n.DataSize)-offset)
+	if size <= 0 {
+		return false, nil
+	}
+
+	if readOption.HasSlowRead {
+		v.dataFileAccessLock.RLock()
+	}
+	// the offset is stale if the volume is compacted, and the needles may be overwritten by the in place compaction
+	if readOption.VolumeRevision != v.SuperBlock.CompactionRevision || readOption.relocationRevision != v.relocationRevision ||
+		v.isCompactingInPlace || !v.isNeedleVerified(n.Id, needleOffset) {
+		if readOption.HasSlowRead {
+			v.dataFileAccessLock.RUnlock()
+		}
+		return false, nil
+	}
+	// a separate file, since sendfile reads from the current position of the file
+	f, err := os.Open(diskFile.Name())
+	if readOption.HasSlowRead {
+		// the in place compaction waits for the sends outside of the lock
+		v.zeroCopySendLock.RLock()
+		defer v.zeroCopySendLock.RUnlock()
+		v.dataFileAccessLock.RUnlock()
+	}
+	if err != nil {
+		return false, nil
+	}
+	defer f.Close()
+
+	actualOffset := needleOffset.ToActualOffset()
+	if readOption.IsOutOfRange {
+		actualOffset += int64(MaxPossibleVolumeSize)
+	}
+	if _, err = f.Seek(actualOffset+NeedleHeaderSize+DataSizeSize+offset, io.SeekStart); err != nil {
+		return true, err
+	}
+	_, err = readerFrom.ReadFrom(&io.LimitedReader{R: f, N: size})
+	return true, err
+}
+
+func min64(x, y int64) int64 {
+	if x < y {
+		return x
+	}
+	return y
+}
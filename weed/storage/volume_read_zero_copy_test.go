@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestZeroCopyReadVerifiedNeedles(t *testing.T) {
+	dir := t.TempDir()
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+	defer v.Close()
+
+	write := func(fill byte) []byte {
+		n := newEmptyNeedle(1)
+		n.Data = bytes.Repeat([]byte{fill}, 3*PagedReadLimit)
+		n.Checksum = needle.NewCRC(n.Data)
+		if _, _, _, err := v.writeNeedle2(n, true, false); err != nil {
+			t.Fatalf("write needle: %v", err)
+		}
+		return n.Data
+	}
+	read := func(offset, size int64) []byte {
+		// only the large needles are streamed
+		n := newEmptyNeedle(1)
+		readOption := &ReadOption{AttemptMetaOnly: true, ReadBufferSize: PagedReadLimit, ZeroCopy: true}
+		if _, err := v.readNeedle(n, readOption, nil); err != nil || !readOption.IsMetaOnly {
+			t.Fatalf("read needle meta: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := v.readNeedleDataInto(n, readOption, &buf, offset, size); err != nil {
+			t.Fatalf("read needle data: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	data := write(1)
+	nv, _ := v.nm.Get(types.NeedleId(1))
+	if v.isNeedleVerified(1, nv.Offset) {
+		t.Fatalf("needle 1 is not read yet")
+	}
+	if !bytes.Equal(read(0, int64(len(data))), data) {
+		t.Fatalf("unexpected data")
+	}
+	if !v.isNeedleVerified(1, nv.Offset) {
+		t.Fatalf("needle 1 should be verified by the whole read")
+	}
+
+	// the verified needle is sent as is, also for the ranges
+	if !bytes.Equal(read(0, int64(len(data))), data) || !bytes.Equal(read(100, 200), data[100:300]) {
+		t.Errorf("unexpected zero copy data")
+	}
+
+	// the checksum is not recomputed for the verified needle
+	if _, err := v.DataBackend.WriteAt([]byte{0xff}, nv.Offset.ToActualOffset()+types.NeedleHeaderSize+types.DataSizeSize); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(0, int64(len(data))); got[0] != 0xff {
+		t.Errorf("the verified needle should be sent without the checksum recompute")
+	}
+
+	// the rewritten needle is verified again
+	data = write(2)
+	nv, _ = v.nm.Get(types.NeedleId(1))
+	if v.isNeedleVerified(1, nv.Offset) {
+		t.Errorf("the rewritten needle 1 is not verified yet")
+	}
+	if !bytes.Equal(read(0, int64(len(data))), data) || !v.isNeedleVerified(1, nv.Offset) {
+		t.Errorf("unexpected data of the rewritten needle")
+	}
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+// ScrubNeedles re-reads the live needles of the volume, and verifies their ids, sizes and checksums.
+// The bytes read are passed to throttle, and the needles failing the verification are passed to onCorrupted.
+func (v *Volume) ScrubNeedles(stopChan <-chan bool, throttle func(bytes int64), onCorrupted func(needleId NeedleId, offset Offset, size Size, err error)) (scrubbed int64, err error) {
+	entryCount := int64(v.IndexFileSize()) / NeedleMapEntrySize
+	for i := int64(0); i < entryCount; i++ {
+		select {
+		case <-stopChan:
+			return
+		default:
+		}
+		key, offset, size, found, scrubErr, readErr := v.scrubIndexEntry(i)
+		if readErr == io.EOF {
+			// the index is shorter after the volume is compacted
+			return scrubbed, nil
+		}
+		if readErr != nil {
+			return scrubbed, readErr
+		}
+		if !found {
+			continue
+		}
+		scrubbed++
+		throttle(needle.GetActualSize(size, v.Version()))
+		if scrubErr != nil {
+			onCorrupted(key, offset, size, scrubErr)
+		}
+	}
+	return
+}
+
+func (v *Volume) scrubIndexEntry(i int64) (key NeedleId, offset Offset, size Size, found bool, scrubErr, err error) {
+	v.dataFileAccessLock.RLock()
+	defer v.dataFileAccessLock.RUnlock()
+
+	if v.nm == nil || v.DataBackend == nil {
+		return key, offset, size, false, nil, fmt.Errorf("volume %d is closed", v.Id)
+	}
+	if key, offset, size, err = v.nm.ReadIndexEntry(i); err != nil {
+		return
+	}
+	// skip the deleted needles, and the needles updated later in the index
+	if offset.IsZero() || size.IsDeleted() || size == 0 {
+		return
+	}
+	if nv, ok := v.nm.Get(key); !ok || nv.Offset != offset || nv.Size != size {
+		return
+	}
+
+	n := new(needle.Needle)
+	found, scrubErr = true, n.ReadData(v.DataBackend, offset.ToActualOffset(), size, v.Version())
+	if scrubErr == nil && n.Id != key {
+		scrubErr = fmt.Errorf("needle id %s found, expected %s", n.Id, key)
+	}
+	return
+}
+
+// ReadVerifiedNeedleBlob reads the blob of the current version of the needle, only if its size and checksum are verified.
+func (v *Volume) ReadVerifiedNeedleBlob(needleId NeedleId, size Size) ([]byte, error) {
+	v.dataFileAccessLock.RLock()
+	defer v.dataFileAccessLock.RUnlock()
+
+	nv, ok := v.nm.Get(needleId)
+	if !ok || nv.Offset.IsZero() || nv.Size.IsDeleted() {
+		return nil, ErrorNotFound
+	}
+	if nv.Size != size {
+		return nil, fmt.Errorf("needle %s size %d, expected size %d", needleId, nv.Size, size)
+	}
+	needleBlob, err := needle.ReadNeedleBlob(v.DataBackend, nv.Offset.ToActualOffset(), nv.Size, v.Version())
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyNeedleBlob(needleId, needleBlob, size, v.Version()); err != nil {
+		return nil, err
+	}
+	return needleBlob, nil
+}
+
+// RepairNeedleBlob appends the healthy copy of the corrupted needle at the offset,
+// unless the needle has been updated or deleted since it was scrubbed.
+func (v *Volume) RepairNeedleBlob(needleId NeedleId, offset Offset, size Size, needleBlob []byte) error {
+	if err := verifyNeedleBlob(needleId, needleBlob, size, v.Version()); err != nil {
+		return err
+	}
+
+	v.dataFileAccessLock.Lock()
+	defer v.dataFileAccessLock.Unlock()
+
+	if nv, ok := v.nm.Get(needleId); !ok || nv.Offset != offset || nv.Size != size {
+		return fmt.Errorf("needle %s has changed since scrubbed", needleId)
+	}
+	return v.doWriteNeedleBlob(needleId, needleBlob, size)
+}
+
+func verifyNeedleBlob(needleId NeedleId, needleBlob []byte, size Size, version needle.Version) error {
+	if int64(len(needleBlob)) < needle.GetActualSize(size, version) {
+		return fmt.Errorf("needle %s blob has %d bytes, expected %d bytes", needleId, len(needleBlob), needle.GetActualSize(size, version))
+	}
+	n := new(needle.Needle)
+	if err := n.ReadBytes(needleBlob, 0, size, version); err != nil {
+		return fmt.Errorf("verify needle %s: %v", needleId, err)
+	}
+	if n.Id != needleId {
+		return fmt.Errorf("needle id %s found, expected %s", n.Id, needleId)
+	}
+	return nil
+}
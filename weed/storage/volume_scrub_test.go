@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestScrubAndRepairNeedles(t *testing.T) {
+	var volumes []*Volume
+	for i := 0; i < 2; i++ {
+		dir := t.TempDir()
+		v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+		if err != nil {
+			t.Fatalf("volume creation: %v", err)
+		}
+		defer v.Close()
+		for id := uint64(1); id <= 10; id++ {
+			n := newEmptyNeedle(id)
+			n.Data = bytes.Repeat([]byte{byte(id)}, 100*int(id))
+			n.Checksum = needle.NewCRC(n.Data)
+			if _, _, _, err := v.writeNeedle2(n, true, false); err != nil {
+				t.Fatalf("write needle %d: %v", id, err)
+			}
+		}
+		volumes = append(volumes, v)
+	}
+	corrupted, replica := volumes[0], volumes[1]
+
+	// flip a byte in the data of needle 3
+	nv, _ := corrupted.nm.Get(types.NeedleId(3))
+	if _, err := corrupted.DataBackend.WriteAt([]byte{0xff}, nv.Offset.ToActualOffset()+types.NeedleHeaderSize+10); err != nil {
+		t.Fatal(err)
+	}
+
+	var corruptedIds []types.NeedleId
+	var readBytes int64
+	scrub := func() {
+		corruptedIds = nil
+		scrubbed, err := corrupted.ScrubNeedles(nil, func(bytes int64) { readBytes += bytes }, func(needleId types.NeedleId, offset types.Offset, size types.Size, err error) {
+			corruptedIds = append(corruptedIds, needleId)
+			needleBlob, readErr := replica.ReadVerifiedNeedleBlob(needleId, size)
+			if readErr != nil {
+				t.Fatalf("read needle %s from replica: %v", needleId, readErr)
+			}
+			if repairErr := corrupted.RepairNeedleBlob(needleId, offset, size, needleBlob); repairErr != nil {
+				t.Fatalf("repair needle %s: %v", needleId, repairErr)
+			}
+		})
+		if err != nil || scrubbed != 10 {
+			t.Fatalf("scrubbed %d needles: %v", scrubbed, err)
+		}
+	}
+
+	scrub()
+	if len(corruptedIds) != 1 || corruptedIds[0] != 3 || readBytes == 0 {
+		t.Fatalf("unexpected corrupted needles %v", corruptedIds)
+	}
+	if _, err := corrupted.ReadVerifiedNeedleBlob(3, nv.Size); err != nil {
+		t.Errorf("needle 3 is not repaired: %v", err)
+	}
+
+	// the repaired needle is appended, and the corrupted copy is skipped as stale
+	scrub()
+	if len(corruptedIds) != 0 {
+		t.Errorf("unexpected corrupted needles %v after repair", corruptedIds)
+	}
+
+	// the needle changed after scrubbing is not overwritten
+	nv5, _ := replica.nm.Get(types.NeedleId(5))
+	needleBlob, err := replica.ReadVerifiedNeedleBlob(5, nv5.Size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := corrupted.RepairNeedleBlob(5, nv.Offset, nv5.Size, needleBlob); err == nil {
+		t.Errorf("repair needle 5 at a stale offset should fail")
+	}
+	if err := corrupted.RepairNeedleBlob(5, nv5.Offset, nv5.Size, needleBlob[:100]); err == nil {
+		t.Errorf("repair needle 5 with a short blob should fail")
+	}
+}
@@ -83,6 +83,7 @@ func (v *Volume) Compact2(preallocate int64, compactionBytePerSecond int64, prog
 	defer func() {
 		v.isCompacting = false
 	}()
+	v.lastCompactInPlace = false
 
 	v.lastCompactIndexOffset = v.IndexFileSize()
 	v.lastCompactRevision = v.SuperBlock.CompactionRevision
@@ -111,6 +112,11 @@ func (v *Volume) CommitCompact() error {
 	if v.MemoryMapMaxSizeMb != 0 { //it makes no sense to compact in memory
 		return nil
 	}
+	if v.lastCompactInPlace {
+		// nothing to commit, the in place compaction is already effective
+		v.lastCompactInPlace = false
+		return nil
+	}
 	glog.V(0).Infof("Committing volume %d vacuuming...", v.Id)
 
 	if v.isCommitCompacting {
@@ -0,0 +1,562 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
+	idx2 "github.com/seaweedfs/seaweedfs/weed/storage/idx"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle_map"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// the needles relocated while holding the volume lock once, except a needle larger than this
+const inPlaceCompactionBatchSize = 8 * 1024 * 1024
+
+// CompactInPlace compacts a volume without a compacted copy, so it works on nearly full disks.
+// The live needles are moved toward the head of the .dat file in batches, in the order of their offsets,
+// and the reclaimed tail is truncated at the end, with a fresh .idx file.
+// The needle map always points to an intact copy of a needle:
+// a batch overlapping its own needles is staged at the end of the .dat file before being written in place.
+// There is nothing to commit afterwards.
+// Meanwhile the .dat file has holes with partial needles, and can not be read sequentially.
+// The progress is kept in a .cpj journal until the compaction finishes, and an interrupted compaction is resumed
+// by the next compaction of the volume, or when loading the volume.
+func (v *Volume) CompactInPlace(compactionBytePerSecond int64, progressFn ProgressFunc) error {
+
+	if v.MemoryMapMaxSizeMb != 0 { //it makes no sense to compact in memory
+		return nil
+	}
+	glog.V(3).Infof("Compact volume %d in place ...", v.Id)
+
+	if v.isCompacting || v.isCommitCompacting {
+		glog.V(0).Infof("Volume %d is already compacting ...", v.Id)
+		return nil
+	}
+	v.isCompacting = true
+	defer func() {
+		v.isCompacting = false
+	}()
+	v.lastCompactInPlace = false
+
+	v.dataFileAccessLock.Lock()
+	if _, ok := v.DataBackend.(*backend.DiskFile); !ok {
+		v.dataFileAccessLock.Unlock()
+		return fmt.Errorf("volume %d is not in a local disk file, remote:%v", v.Id, v.HasRemoteFile())
+	}
+	// stays set after a failure, until the compaction is resumed
+	v.isCompactingInPlace = true
+	v.dataFileAccessLock.Unlock()
+	// wait for the zero copy sends already started outside of the lock
+	v.zeroCopySendLock.Lock()
+	v.zeroCopySendLock.Unlock()
+
+	c, err := v.startInPlaceCompaction()
+	if err != nil {
+		return err
+	}
+	locations, err := c.newNeedleLocations()
+	if err != nil {
+		return err
+	}
+
+	writeThrottler := util.NewWriteThrottler(compactionBytePerSecond)
+	for {
+		for len(locations) > 0 {
+			var written int64
+			if locations, written, err = c.relocateBatch(locations); err != nil {
+				return err
+			}
+			if progressFn != nil {
+				if !progressFn(c.readOffset) {
+					return fmt.Errorf("interrupted")
+				}
+			}
+			writeThrottler.MaybeSlowdown(written)
+		}
+		// the needles written during the compaction are relocated in the next round
+		if locations, err = c.finish(); err != nil {
+			return err
+		}
+		if len(locations) == 0 {
+			break
+		}
+	}
+
+	v.lastCompactInPlace = true
+	glog.V(0).Infof("Compacted volume %d in place, moved %d bytes", v.Id, c.movedBytes)
+	return nil
+}
+
+type inPlaceCompaction struct {
+	v           *Volume
+	version     needle.Version
+	journal     inPlaceCompactionJournal
+	readOffset  int64  // the live needles before it are relocated
+	writeOffset int64  // the relocated needles are written before it
+	idxEntries  uint64 // the .idx file entries already visited
+	movedBytes  int64
+	// the relocated needles before it are verified to be live
+	verifiedOffset int64
+}
+
+// inPlaceCompactionJournal is saved before staging a batch and after writing each batch in place,
+// so an interrupted compaction can be resumed
+type inPlaceCompactionJournal struct {
+	compactionRevision uint16 // before the compaction
+	writeOffset        int64  // the needles before it are relocated
+	stagingOffset      int64  // the batch being relocated is staged here, if not 0
+	stagingSize        int64
+}
+
+const inPlaceCompactionJournalSize = 4 * 8
+
+func (j inPlaceCompactionJournal) toBytes() []byte {
+	b := make([]byte, inPlaceCompactionJournalSize)
+	binary.BigEndian.PutUint64(b[0:8], uint64(j.compactionRevision))
+	binary.BigEndian.PutUint64(b[8:16], uint64(j.writeOffset))
+	binary.BigEndian.PutUint64(b[16:24], uint64(j.stagingOffset))
+	binary.BigEndian.PutUint64(b[24:32], uint64(j.stagingSize))
+	return b
+}
+
+func parseInPlaceCompactionJournal(b []byte) (j inPlaceCompactionJournal, err error) {
+	if len(b) != inPlaceCompactionJournalSize {
+		return j, fmt.Errorf("unexpected journal size %d", len(b))
+	}
+	j.compactionRevision = uint16(binary.BigEndian.Uint64(b[0:8]))
+	j.writeOffset = int64(binary.BigEndian.Uint64(b[8:16]))
+	j.stagingOffset = int64(binary.BigEndian.Uint64(b[16:24]))
+	j.stagingSize = int64(binary.BigEndian.Uint64(b[24:32]))
+	return j, nil
+}
+
+// hasInPlaceCompactionJournal is true if an in place compaction is not finished
+func (v *Volume) hasInPlaceCompactionJournal() bool {
+	return util.FileExists(v.FileName(".cpj"))
+}
+
+// maybeResumeInPlaceCompaction finishes an interrupted in place compaction after loading the volume
+func (v *Volume) maybeResumeInPlaceCompaction() {
+	if !v.hasInPlaceCompactionJournal() {
+		return
+	}
+	v.isCompactingInPlace = true
+	if err := v.CompactInPlace(0, nil); err != nil {
+		glog.Errorf("resume compacting volume %d in place: %v", v.Id, err)
+	}
+}
+
+// isRelocatingInPlace is true during an in place compaction, or after it failed until resumed,
+// when the .dat file has holes and can not be read sequentially
+func (v *Volume) isRelocatingInPlace() bool {
+	v.dataFileAccessLock.RLock()
+	defer v.dataFileAccessLock.RUnlock()
+	return v.isCompactingInPlace
+}
+
+// startInPlaceCompaction resumes the compaction from the journal if any, otherwise starts the journal
+func (v *Volume) startInPlaceCompaction() (*inPlaceCompaction, error) {
+	v.dataFileAccessLock.Lock()
+	defer v.dataFileAccessLock.Unlock()
+
+	c := &inPlaceCompaction{
+		v:           v,
+		version:     v.Version(),
+		readOffset:  int64(v.SuperBlock.BlockSize()),
+		writeOffset: int64(v.SuperBlock.BlockSize()),
+	}
+	data, err := os.ReadFile(v.FileName(".cpj"))
+	if os.IsNotExist(err) {
+		c.journal = inPlaceCompactionJournal{compactionRevision: v.SuperBlock.CompactionRevision, writeOffset: c.writeOffset}
+		return c, c.saveJournal()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %v", v.FileName(".cpj"), err)
+	}
+	if c.journal, err = parseInPlaceCompactionJournal(data); err != nil {
+		return nil, fmt.Errorf("parse %s: %v", v.FileName(".cpj"), err)
+	}
+	glog.V(0).Infof("resume compacting volume %d in place from offset %d", v.Id, c.journal.writeOffset)
+	c.readOffset, c.writeOffset = c.journal.writeOffset, c.journal.writeOffset
+	if c.journal.stagingOffset != 0 {
+		if err = c.restoreStagedBatch(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// saveJournal replaces the journal atomically
+func (c *inPlaceCompaction) saveJournal() error {
+	name := c.v.FileName(".cpj")
+	f, err := os.OpenFile(name+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("save %s: %v", name, err)
+	}
+	if _, err = f.Write(c.journal.toBytes()); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(name+".tmp", name)
+	}
+	if err != nil {
+		return fmt.Errorf("save %s: %v", name, err)
+	}
+	return nil
+}
+
+// restoreStagedBatch writes the staged needles of an interrupted batch in place.
+// The staged needles are the first ones of the batch, since they are pointed to in order, and writing them
+// in place only overwrites their own original copies, like writing the whole batch.
+// The staged copies are left as garbage, since new needles may have been written after them.
+func (c *inPlaceCompaction) restoreStagedBatch() error {
+	v := c.v
+	stagingEnd := c.journal.stagingOffset + c.journal.stagingSize
+	staged := make(map[NeedleId]int64)
+	idxFile, err := os.Open(v.FileName(".idx"))
+	if err != nil {
+		return fmt.Errorf("open %s: %v", v.FileName(".idx"), err)
+	}
+	err = idx2.WalkIndexFile(idxFile, 0, func(key NeedleId, offset Offset, size Size) error {
+		if nv, ok := v.nm.Get(key); ok && !nv.Size.IsDeleted() {
+			if actualOffset := nv.Offset.ToActualOffset(); actualOffset >= c.journal.stagingOffset && actualOffset < stagingEnd {
+				staged[key] = actualOffset
+			}
+		}
+		return nil
+	})
+	idxFile.Close()
+	if err != nil {
+		return fmt.Errorf("walk %s: %v", v.FileName(".idx"), err)
+	}
+	var locations []needleLocation
+	for key, offset := range staged {
+		locations = append(locations, needleLocation{key: key, offset: offset})
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].offset < locations[j].offset
+	})
+
+	var relocations []needleRelocation
+	var batchSize int64
+	for _, location := range locations {
+		nv, _ := v.nm.Get(location.key)
+		blob, err := needle.ReadNeedleBlob(v.DataBackend, location.offset, nv.Size, c.version)
+		if err != nil {
+			return fmt.Errorf("read staged needle %d at %d from volume %d: %v", location.key, location.offset, v.Id, err)
+		}
+		relocations = append(relocations, needleRelocation{key: location.key, size: nv.Size, blob: blob})
+		batchSize += needle.GetActualSize(nv.Size, c.version)
+	}
+	if len(relocations) > 0 {
+		if err = c.writeRelocations(relocations, c.writeOffset); err != nil {
+			return err
+		}
+	}
+	c.writeOffset += batchSize
+	c.readOffset = c.writeOffset
+	c.journal.writeOffset = c.writeOffset
+	c.journal.stagingOffset, c.journal.stagingSize = 0, 0
+	return c.saveJournal()
+}
+
+type needleLocation struct {
+	key    NeedleId
+	offset int64
+}
+
+type needleRelocation struct {
+	key  NeedleId
+	size Size
+	blob []byte
+}
+
+// newNeedleLocations lists the needles added to the .idx file since the last call, beyond the relocated ones
+func (c *inPlaceCompaction) newNeedleLocations() (locations []needleLocation, err error) {
+	idxFile, err := os.Open(c.v.FileName(".idx"))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %v", c.v.FileName(".idx"), err)
+	}
+	defer idxFile.Close()
+
+	var count uint64
+	err = idx2.WalkIndexFile(idxFile, c.idxEntries, func(key NeedleId, offset Offset, size Size) error {
+		count++
+		if !offset.IsZero() && !size.IsDeleted() && offset.ToActualOffset() >= c.readOffset {
+			locations = append(locations, needleLocation{key: key, offset: offset.ToActualOffset()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %v", idxFile.Name(), err)
+	}
+	c.idxEntries += count
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].offset < locations[j].offset
+	})
+	return locations, nil
+}
+
+// relocateBatch moves the next batch of live needles to the write offset, and returns the remaining locations
+func (c *inPlaceCompaction) relocateBatch(locations []needleLocation) (rest []needleLocation, written int64, err error) {
+	v := c.v
+	v.dataFileAccessLock.Lock()
+	defer v.dataFileAccessLock.Unlock()
+
+	if v.nm == nil || v.DataBackend == nil {
+		return nil, 0, fmt.Errorf("volume %d is closed", v.Id)
+	}
+
+	var relocations []needleRelocation
+	var batchStart, batchSize int64
+	for ; len(locations) > 0; locations = locations[1:] {
+		location := locations[0]
+		if location.offset < c.readOffset {
+			continue
+		}
+		// skip the needles deleted or overwritten since listed, and the stale entries in the .idx file
+		nv, ok := v.nm.Get(location.key)
+		if !ok || nv.Offset.ToActualOffset() != location.offset || nv.Size.IsDeleted() {
+			continue
+		}
+		size := nv.Size
+		actualSize := needle.GetActualSize(size, c.version)
+		if len(relocations) == 0 && location.offset == c.writeOffset {
+			// already in place
+			c.writeOffset += actualSize
+			c.readOffset = location.offset + actualSize
+			continue
+		}
+		if len(relocations) > 0 && batchSize+actualSize > inPlaceCompactionBatchSize {
+			break
+		}
+		blob, err := needle.ReadNeedleBlob(v.DataBackend, location.offset, size, c.version)
+		if err != nil {
+			return nil, 0, fmt.Errorf("read needle %d at %d from volume %d: %v", location.key, location.offset, v.Id, err)
+		}
+		if len(relocations) == 0 {
+			batchStart = location.offset
+		}
+		relocations = append(relocations, needleRelocation{key: location.key, size: size, blob: blob})
+		batchSize += actualSize
+		c.readOffset = location.offset + actualSize
+	}
+	if len(relocations) == 0 {
+		return locations, 0, nil
+	}
+
+	var datSize int64
+	isStaged := c.writeOffset+batchSize > batchStart
+	if isStaged {
+		// keep a copy at the end of the .dat file, since writing in place overwrites the batch itself
+		if datSize, _, err = v.DataBackend.GetStat(); err != nil {
+			return nil, 0, fmt.Errorf("stat volume %d: %v", v.Id, err)
+		}
+		stagingOffset := datSize
+		if stagingOffset%NeedlePaddingSize != 0 {
+			stagingOffset += NeedlePaddingSize - stagingOffset%NeedlePaddingSize
+		}
+		// reserve the staging space first, so the needles written after a failure are not taken as staged
+		if err = v.DataBackend.Truncate(stagingOffset + batchSize); err == nil {
+			err = v.DataBackend.Sync()
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("reserve volume %d staging: %v", v.Id, err)
+		}
+		c.journal.writeOffset = c.writeOffset
+		c.journal.stagingOffset, c.journal.stagingSize = stagingOffset, batchSize
+		if err = c.saveJournal(); err != nil {
+			return nil, 0, err
+		}
+		if err = c.writeRelocations(relocations, stagingOffset); err != nil {
+			return nil, 0, err
+		}
+		written += batchSize
+	}
+	if err = c.writeRelocations(relocations, c.writeOffset); err != nil {
+		return nil, 0, err
+	}
+	written += batchSize
+	c.writeOffset += batchSize
+	c.movedBytes += batchSize
+	v.relocationRevision++
+
+	if isStaged {
+		if err = v.DataBackend.Truncate(datSize); err != nil {
+			return nil, 0, fmt.Errorf("truncate volume %d staging: %v", v.Id, err)
+		}
+	}
+	c.journal.writeOffset = c.writeOffset
+	c.journal.stagingOffset, c.journal.stagingSize = 0, 0
+	if err = c.saveJournal(); err != nil {
+		return nil, 0, err
+	}
+
+	return locations, written, nil
+}
+
+// writeRelocations writes the needles from the offset, and points the needle map to them after they are synced
+func (c *inPlaceCompaction) writeRelocations(relocations []needleRelocation, offset int64) error {
+	v := c.v
+	writeOffset := offset
+	for _, r := range relocations {
+		if _, err := v.DataBackend.WriteAt(r.blob, writeOffset); err != nil {
+			return fmt.Errorf("write needle %d at %d to volume %d: %v", r.key, writeOffset, v.Id, err)
+		}
+		writeOffset += needle.GetActualSize(r.size, c.version)
+	}
+	if err := v.DataBackend.Sync(); err != nil {
+		return fmt.Errorf("sync volume %d: %v", v.Id, err)
+	}
+	for _, r := range relocations {
+		if err := v.nm.Put(r.key, ToOffset(offset), r.size); err != nil {
+			return fmt.Errorf("relocate needle %d to %d in volume %d: %v", r.key, offset, v.Id, err)
+		}
+		offset += needle.GetActualSize(r.size, c.version)
+	}
+	if err := v.nm.Sync(); err != nil {
+		return fmt.Errorf("sync volume %d idx: %v", v.Id, err)
+	}
+	return nil
+}
+
+// relocateAgainFromDeadNeedle finds the first relocated needle deleted or overwritten since relocated,
+// which would come back if the .idx file is rebuilt from the .dat file, and lists the needles to relocate over it.
+func (c *inPlaceCompaction) relocateAgainFromDeadNeedle() (locations []needleLocation, err error) {
+	v := c.v
+	if c.verifiedOffset < int64(v.SuperBlock.BlockSize()) {
+		c.verifiedOffset = int64(v.SuperBlock.BlockSize())
+	}
+	for c.verifiedOffset < c.writeOffset {
+		n, _, _, err := needle.ReadNeedleHeader(v.DataBackend, c.version, c.verifiedOffset)
+		if err != nil {
+			return nil, fmt.Errorf("read needle header at %d from volume %d: %v", c.verifiedOffset, v.Id, err)
+		}
+		if nv, ok := v.nm.Get(n.Id); !ok || nv.Size.IsDeleted() || nv.Offset.ToActualOffset() != c.verifiedOffset {
+			break
+		}
+		c.verifiedOffset += needle.GetActualSize(n.Size, c.version)
+	}
+	if c.verifiedOffset >= c.writeOffset {
+		return nil, nil
+	}
+
+	glog.V(1).Infof("relocate volume %d again from the dead needle at %d", v.Id, c.verifiedOffset)
+	c.readOffset, c.writeOffset = c.verifiedOffset, c.verifiedOffset
+	c.idxEntries = 0
+	return c.newNeedleLocations()
+}
+
+// finish returns the needles written during the compaction if any.
+// Otherwise it truncates the .dat file after the relocated needles, and reloads the volume with a fresh .idx file.
+func (c *inPlaceCompaction) finish() (locations []needleLocation, err error) {
+	v := c.v
+	v.dataFileAccessLock.Lock()
+	defer v.dataFileAccessLock.Unlock()
+
+	if v.nm == nil || v.DataBackend == nil {
+		return nil, fmt.Errorf("volume %d is closed", v.Id)
+	}
+	if err = v.nm.Sync(); err != nil {
+		return nil, fmt.Errorf("sync volume %d idx: %v", v.Id, err)
+	}
+	if locations, err = c.newNeedleLocations(); err != nil || len(locations) > 0 {
+		return locations, err
+	}
+	if locations, err = c.relocateAgainFromDeadNeedle(); err != nil || len(locations) > 0 {
+		return locations, err
+	}
+
+	nm := needle_map.NewMemDb()
+	defer nm.Close()
+	if err = nm.LoadFromIdx(v.FileName(".idx")); err != nil {
+		return nil, fmt.Errorf("load %s: %v", v.FileName(".idx"), err)
+	}
+	var values []needle_map.NeedleValue
+	err = nm.AscendingVisit(func(value needle_map.NeedleValue) error {
+		if value.Offset.IsZero() || value.Size.IsDeleted() {
+			return nil
+		}
+		if value.Offset.ToActualOffset()+needle.GetActualSize(value.Size, c.version) > c.writeOffset {
+			return fmt.Errorf("needle %d at %d is not relocated", value.Key, value.Offset.ToActualOffset())
+		}
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("volume %d: %v", v.Id, err)
+	}
+	// in the order of the offsets, which is also the order of the append times kept by the relocation,
+	// since loading the volume checks the last entry against the end of the .dat file,
+	// and the tailing binary searches the .idx file by the append times
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Offset.ToActualOffset() < values[j].Offset.ToActualOffset()
+	})
+	if err = writeIdxFile(v.FileName(".cpx"), values); err != nil {
+		return nil, fmt.Errorf("save %s: %v", v.FileName(".cpx"), err)
+	}
+
+	if err = v.DataBackend.Truncate(c.writeOffset); err != nil {
+		return nil, fmt.Errorf("truncate volume %d: %v", v.Id, err)
+	}
+	// the needle offsets are changed like by the compaction with a copy
+	v.SuperBlock.CompactionRevision = c.journal.compactionRevision + 1
+	if _, err = v.DataBackend.WriteAt(v.SuperBlock.Bytes(), 0); err != nil {
+		return nil, fmt.Errorf("write volume %d super block: %v", v.Id, err)
+	}
+
+	v.nm.Close()
+	v.nm = nil
+	if err = v.DataBackend.Close(); err != nil {
+		glog.V(0).Infof("failed to close volume %d", v.Id)
+	}
+	v.DataBackend = nil
+	stats.VolumeServerVolumeGauge.WithLabelValues(v.Collection, "volume").Dec()
+
+	if err = os.Rename(v.FileName(".cpx"), v.FileName(".idx")); err != nil {
+		return nil, fmt.Errorf("rename %s: %v", v.FileName(".cpx"), err)
+	}
+	os.RemoveAll(v.FileName(".ldb"))
+	if err = os.Remove(v.FileName(".cpj")); err != nil {
+		return nil, fmt.Errorf("remove %s: %v", v.FileName(".cpj"), err)
+	}
+	v.isCompactingInPlace = false
+
+	v.verifiedNeedlesLock.Lock()
+	v.verifiedNeedles = nil
+	v.verifiedNeedlesLock.Unlock()
+
+	if err = v.load(true, false, v.needleMapKind, 0); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func writeIdxFile(idxName string, values []needle_map.NeedleValue) error {
+	idxFile, err := os.OpenFile(idxName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer idxFile.Close()
+	writer := bufio.NewWriter(idxFile)
+	for _, value := range values {
+		if _, err = writer.Write(value.ToBytes()); err != nil {
+			return err
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	return idxFile.Sync()
+}
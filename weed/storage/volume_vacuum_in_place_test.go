@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
+	"github.com/seaweedfs/seaweedfs/weed/storage/idx"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestMemIndexCompactionInPlace(t *testing.T) {
+	testCompactionInPlace(t, NeedleMapInMemory)
+}
+
+func TestLDBIndexCompactionInPlace(t *testing.T) {
+	testCompactionInPlace(t, NeedleMapLevelDb)
+}
+
+type inPlaceCompactionScanner struct {
+	count int
+}
+
+func (scanner *inPlaceCompactionScanner) VisitSuperBlock(superBlock super_block.SuperBlock) error {
+	return nil
+}
+func (scanner *inPlaceCompactionScanner) ReadNeedleBody() bool {
+	return false
+}
+func (scanner *inPlaceCompactionScanner) VisitNeedle(n *needle.Needle, offset int64, needleHeader, needleBody []byte) error {
+	scanner.count++
+	return nil
+}
+
+type inPlaceCompactionTestVolume struct {
+	t         *testing.T
+	v         *Volume
+	infos     map[uint64]*needleInfo
+	diskSizes map[uint64]int64
+}
+
+func (tv *inPlaceCompactionTestVolume) write(id uint64, size int) {
+	n := newEmptyNeedle(id)
+	n.Data = make([]byte, size)
+	rand.Read(n.Data)
+	n.Checksum = needle.NewCRC(n.Data)
+	if _, _, _, err := tv.v.writeNeedle2(n, true, false); err != nil {
+		tv.t.Fatalf("write file %d: %v", id, err)
+	}
+	tv.infos[id] = &needleInfo{size: types.Size(n.DataSize), crc: n.Checksum}
+	tv.diskSizes[id] = n.DiskSize(tv.v.Version())
+}
+
+func (tv *inPlaceCompactionTestVolume) remove(id uint64) {
+	if _, err := tv.v.deleteNeedle2(newEmptyNeedle(id)); err != nil {
+		tv.t.Fatalf("delete file %d: %v", id, err)
+	}
+	delete(tv.infos, id)
+	delete(tv.diskSizes, id)
+}
+
+func newInPlaceCompactionTestVolume(t *testing.T, dir string, needleMapKind NeedleMapKind) *inPlaceCompactionTestVolume {
+	v, err := NewVolume(dir, dir, "", 1, needleMapKind, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+	tv := &inPlaceCompactionTestVolume{t: t, v: v, infos: make(map[uint64]*needleInfo), diskSizes: make(map[uint64]int64)}
+
+	// the first batch overlaps its own needles after the small deletions, and is staged
+	for i := uint64(1); i <= 200; i++ {
+		tv.write(i, 1+rand.Intn(64*1024))
+		if i%10 == 0 {
+			tv.remove(i - 5)
+		}
+	}
+	// the later batches are written directly after the large deletion
+	tv.write(1000, inPlaceCompactionBatchSize+1024)
+	tv.remove(1000)
+	for i := uint64(201); i <= 400; i++ {
+		tv.write(i, 1+rand.Intn(64*1024))
+		if i%7 == 0 {
+			tv.write(i-3, 1+rand.Intn(1024))
+		}
+	}
+	return tv
+}
+
+// verifyCompacted checks the compacted .dat size, the .idx order, and reads every file after reloading the volume
+func (tv *inPlaceCompactionTestVolume) verifyCompacted(dir string, needleMapKind NeedleMapKind) {
+	t, v := tv.t, tv.v
+	expectedSize := int64(v.SuperBlock.BlockSize())
+	for _, diskSize := range tv.diskSizes {
+		expectedSize += diskSize
+	}
+	if datSize, _, _ := v.DataBackend.GetStat(); datSize != expectedSize {
+		t.Errorf("compacted .dat size %d, expected %d", datSize, expectedSize)
+	}
+	if v.FileCount() != uint64(len(tv.infos)) || v.DeletedCount() != 0 {
+		t.Errorf("file count %d deleted count %d, expected %d files", v.FileCount(), v.DeletedCount(), len(tv.infos))
+	}
+	if v.hasInPlaceCompactionJournal() || v.isRelocatingInPlace() {
+		t.Errorf("the compaction is not finished")
+	}
+
+	// the tailing binary searches the .idx file, which needs the offset order
+	idxFile, err := os.Open(v.FileName(".idx"))
+	if err != nil {
+		t.Fatalf("open idx: %v", err)
+	}
+	lastOffset := int64(0)
+	err = idx.WalkIndexFile(idxFile, 0, func(key types.NeedleId, offset types.Offset, size types.Size) error {
+		if offset.ToActualOffset() <= lastOffset {
+			return fmt.Errorf("needle %d at %d after %d", key, offset.ToActualOffset(), lastOffset)
+		}
+		lastOffset = offset.ToActualOffset()
+		return nil
+	})
+	idxFile.Close()
+	if err != nil {
+		t.Errorf("compacted idx is not in the offset order: %v", err)
+	}
+	if offset, isLast, err := v.BinarySearchByAppendAtNs(0); err != nil || isLast || offset.ToActualOffset() != int64(v.SuperBlock.BlockSize()) {
+		t.Errorf("binary search from the start: %v %v %v", offset.ToActualOffset(), isLast, err)
+	}
+
+	// the writes continue after the compaction
+	tv.write(2000, 1024)
+
+	v.Close()
+	scanner := &inPlaceCompactionScanner{}
+	if err = ScanVolumeFile(dir, "", 1, needleMapKind, scanner); err != nil {
+		t.Fatalf("scan compacted volume: %v", err)
+	}
+	if scanner.count != len(tv.infos) {
+		t.Errorf("scanned %d needles, expected %d", scanner.count, len(tv.infos))
+	}
+
+	v, err = NewVolume(dir, dir, "", 1, needleMapKind, nil, nil, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume reloading: %v", err)
+	}
+	defer v.Close()
+	for id, info := range tv.infos {
+		n := newEmptyNeedle(id)
+		size, err := v.readNeedle(n, nil, nil)
+		if err != nil {
+			t.Fatalf("read file %d: %v", id, err)
+		}
+		if info.size != types.Size(size) || info.crc != n.Checksum {
+			t.Fatalf("read file %d size %d checksum %d, expected size %d checksum %d", id, size, n.Checksum, info.size, info.crc)
+		}
+	}
+	if _, err := v.readNeedle(newEmptyNeedle(1000), nil, nil); err == nil {
+		t.Errorf("the deleted file 1000 should not be found")
+	}
+}
+
+func testCompactionInPlace(t *testing.T, needleMapKind NeedleMapKind) {
+	dir := t.TempDir()
+	tv := newInPlaceCompactionTestVolume(t, dir, needleMapKind)
+	v := tv.v
+
+	revision := v.SuperBlock.CompactionRevision
+	if err := v.CompactInPlace(0, nil); err != nil {
+		t.Fatalf("compact in place: %v", err)
+	}
+	if err := v.CommitCompact(); err != nil {
+		t.Fatalf("nothing to commit: %v", err)
+	}
+	if v.SuperBlock.CompactionRevision != revision+1 {
+		t.Errorf("compaction revision %d, expected %d", v.SuperBlock.CompactionRevision, revision+1)
+	}
+
+	tv.verifyCompacted(dir, needleMapKind)
+}
+
+func TestCompactionInPlaceResumedByCompaction(t *testing.T) {
+	dir := t.TempDir()
+	tv := newInPlaceCompactionTestVolume(t, dir, NeedleMapInMemory)
+	v := tv.v
+	revision := v.SuperBlock.CompactionRevision
+
+	batches := 0
+	err := v.CompactInPlace(0, func(processed int64) bool {
+		batches++
+		return batches < 2
+	})
+	if err == nil {
+		t.Fatalf("the compaction should be interrupted")
+	}
+	if !v.hasInPlaceCompactionJournal() {
+		t.Fatalf("the interrupted compaction should keep the journal")
+	}
+	if _, _, err = v.BinarySearchByAppendAtNs(0); err == nil {
+		t.Errorf("the binary search should fail during the compaction")
+	}
+
+	// the writes continue before the compaction is resumed
+	tv.write(3000, 4096)
+	tv.remove(3)
+
+	if err = v.CompactInPlace(0, nil); err != nil {
+		t.Fatalf("resume compacting in place: %v", err)
+	}
+	if v.SuperBlock.CompactionRevision != revision+1 {
+		t.Errorf("compaction revision %d, expected %d", v.SuperBlock.CompactionRevision, revision+1)
+	}
+	tv.verifyCompacted(dir, NeedleMapInMemory)
+}
+
+// inPlaceWriteFailure fails writing before the offset, i.e. writing the staged batch in place
+type inPlaceWriteFailure struct {
+	backend.BackendStorageFile
+	before int64
+}
+
+func (f *inPlaceWriteFailure) WriteAt(p []byte, off int64) (n int, err error) {
+	if off < f.before {
+		return 0, fmt.Errorf("injected failure at %d", off)
+	}
+	return f.BackendStorageFile.WriteAt(p, off)
+}
+
+func TestCompactionInPlaceResumedByLoading(t *testing.T) {
+	dir := t.TempDir()
+	tv := newInPlaceCompactionTestVolume(t, dir, NeedleMapInMemory)
+	v := tv.v
+	revision := v.SuperBlock.CompactionRevision
+
+	// stop after staging the first batch, like crashing before writing it in place
+	v.isCompactingInPlace = true
+	c, err := v.startInPlaceCompaction()
+	if err != nil {
+		t.Fatalf("start compacting in place: %v", err)
+	}
+	locations, err := c.newNeedleLocations()
+	if err != nil {
+		t.Fatalf("list needles: %v", err)
+	}
+	datSize, _, _ := v.DataBackend.GetStat()
+	diskFile := v.DataBackend
+	v.DataBackend = &inPlaceWriteFailure{BackendStorageFile: diskFile, before: datSize}
+	if _, _, err = c.relocateBatch(locations); err == nil {
+		t.Fatalf("writing the batch in place should fail")
+	}
+	v.DataBackend = diskFile
+	if c.journal.stagingOffset == 0 {
+		t.Fatalf("the first batch should be staged")
+	}
+	if _, _, err = v.BinarySearchByAppendAtNs(0); err == nil {
+		t.Errorf("the binary search should fail during the compaction")
+	}
+
+	// the needles written after the failure are not taken as staged
+	tv.write(3000, 4096)
+	v.Close()
+	if err = ScanVolumeFile(dir, "", 1, NeedleMapInMemory, &inPlaceCompactionScanner{}); err == nil {
+		t.Errorf("scanning should fail before the compaction is resumed")
+	}
+
+	if tv.v, err = NewVolume(dir, dir, "", 1, NeedleMapInMemory, nil, nil, 0, 0, 0); err != nil {
+		t.Fatalf("volume reloading: %v", err)
+	}
+	if tv.v.SuperBlock.CompactionRevision != revision+1 {
+		t.Errorf("compaction revision %d, expected %d", tv.v.SuperBlock.CompactionRevision, revision+1)
+	}
+	tv.verifyCompacted(dir, NeedleMapInMemory)
+}
@@ -0,0 +1,48 @@
+package topology
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+// CollectionDiskTypes are the disk types required by the collections, configurable in master.toml.
+// The volumes of these collections are only assigned and grown on the matching disks.
+var CollectionDiskTypes = make(map[string]types.DiskType)
+
+// ParseCollectionDiskTypes parses the disk types in the form of "collection=diskType"
+func ParseCollectionDiskTypes(collectionDiskTypes []string) (map[string]types.DiskType, error) {
+	diskTypes := make(map[string]types.DiskType)
+	for _, c := range collectionDiskTypes {
+		collection, diskType, found := strings.Cut(c, "=")
+		collection, diskType = strings.TrimSpace(collection), strings.TrimSpace(diskType)
+		if !found || collection == "" || diskType == "" {
+			return nil, fmt.Errorf("collection disk type %q should be collection=diskType", c)
+		}
+		if _, found := diskTypes[collection]; found {
+			return nil, fmt.Errorf("collection %s has more than one disk type", collection)
+		}
+		diskTypes[collection] = types.ToDiskType(diskType)
+	}
+	return diskTypes, nil
+}
+
+// ApplyCollectionDiskType sets the disk type required by the collection,
+// and fails if the client requests a different disk type
+func ApplyCollectionDiskType(option *VolumeGrowOption, requestedDiskType string) error {
+	diskType, found := CollectionDiskTypes[option.Collection]
+	if !found {
+		return nil
+	}
+	if requestedDiskType != "" && types.ToDiskType(requestedDiskType) != diskType {
+		return fmt.Errorf("collection %s requires disk type %s, not %s", option.Collection, diskType.ReadableString(), requestedDiskType)
+	}
+	option.DiskType = diskType
+	return nil
+}
+
+func hasCollectionDiskType(collection string) bool {
+	_, found := CollectionDiskTypes[collection]
+	return found
+}
@@ -0,0 +1,62 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestCollectionDiskTypes(t *testing.T) {
+	defer func(diskTypes map[string]types.DiskType) { CollectionDiskTypes = diskTypes }(CollectionDiskTypes)
+
+	for _, bad := range [][]string{{"hot"}, {"=ssd"}, {"hot="}, {"hot=ssd", "hot=hdd"}} {
+		if _, err := ParseCollectionDiskTypes(bad); err == nil {
+			t.Errorf("expected error for %v", bad)
+		}
+	}
+	diskTypes, err := ParseCollectionDiskTypes([]string{"Hot_Data = SSD", "archive=hdd", "fast=nvme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diskTypes["Hot_Data"] != types.SsdType || diskTypes["archive"] != types.HardDriveType || diskTypes["fast"] != "nvme" {
+		t.Fatalf("unexpected disk types %v", diskTypes)
+	}
+	CollectionDiskTypes = diskTypes
+
+	option := &VolumeGrowOption{Collection: "Hot_Data"}
+	if err := ApplyCollectionDiskType(option, ""); err != nil || option.DiskType != types.SsdType {
+		t.Errorf("the assign without a disk type gets ssd, not %q: %v", option.DiskType, err)
+	}
+	if err := ApplyCollectionDiskType(&VolumeGrowOption{Collection: "Hot_Data"}, "ssd"); err != nil {
+		t.Errorf("the matching disk type: %v", err)
+	}
+	if err := ApplyCollectionDiskType(&VolumeGrowOption{Collection: "Hot_Data"}, "hdd"); err == nil {
+		t.Errorf("a different disk type should fail")
+	}
+	option = &VolumeGrowOption{Collection: "other", DiskType: types.SsdType}
+	if err := ApplyCollectionDiskType(option, "ssd"); err != nil || option.DiskType != types.SsdType {
+		t.Errorf("the collection without a disk type is unchanged: %v", err)
+	}
+
+	// the latency class does not move the collection off its required disk type
+	topo := setup(topologyLayout)
+	for _, dc := range topo.Children() {
+		for _, rack := range dc.Children() {
+			for _, dn := range rack.Children() {
+				dn.(*DataNode).getOrCreateDisk(types.SsdType).UpAdjustDiskUsageDelta(types.SsdType, &DiskUsageCounts{maxVolumeCount: 10})
+			}
+		}
+	}
+	option = &VolumeGrowOption{Collection: "other", ReplicaPlacement: &super_block.ReplicaPlacement{}}
+	if err := topo.ApplyLatencyClass(option, LatencyClassHigh); err != nil || option.DiskType != types.SsdType {
+		t.Fatalf("the high latency class prefers ssd, not %q: %v", option.DiskType, err)
+	}
+	option = &VolumeGrowOption{Collection: "archive", ReplicaPlacement: &super_block.ReplicaPlacement{}}
+	if err := ApplyCollectionDiskType(option, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := topo.ApplyLatencyClass(option, LatencyClassHigh); err != nil || option.DiskType != types.HardDriveType {
+		t.Errorf("the archive collection should stay on hdd, not %q: %v", option.DiskType, err)
+	}
+}
@@ -22,6 +22,7 @@ type DataNode struct {
 	LastSeen      int64 // unix time in seconds
 	Counter       int   // in race condition, the previous dataNode was not dead
 	IsTerminating bool
+	assignLoad    assignLoad
 }
 
 func NewDataNode(id string) *DataNode {
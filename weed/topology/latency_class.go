@@ -0,0 +1,116 @@
+package topology
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+const (
+	LatencyClassHigh = "high"
+	LatencyClassBulk = "bulk"
+
+	assignLoadHalfLife = time.Minute
+)
+
+// LatencyClass is how to assign the writes of the clients tagged with the latency class
+type LatencyClass struct {
+	Name string
+	// for the assign requests without a disk type, the first disk type with writable volumes or free space is used
+	DiskTypes []string
+	// pick the writable volumes on the volume servers with the fewest recent assigns
+	PreferLightlyLoaded bool
+}
+
+// LatencyClasses are the latency classes by name, configurable in master.toml
+var LatencyClasses = map[string]*LatencyClass{
+	LatencyClassHigh: {Name: LatencyClassHigh, DiskTypes: []string{"ssd", "hdd"}, PreferLightlyLoaded: true},
+	LatencyClassBulk: {Name: LatencyClassBulk, DiskTypes: []string{"hdd"}},
+}
+
+// ApplyLatencyClass adjusts the assign option for the latency class, empty for the default
+func (t *Topology) ApplyLatencyClass(option *VolumeGrowOption, latencyClass string) error {
+	if latencyClass == "" {
+		return nil
+	}
+	class, found := LatencyClasses[latencyClass]
+	if !found {
+		return fmt.Errorf("unknown latency class %s", latencyClass)
+	}
+	option.PreferLightlyLoaded = class.PreferLightlyLoaded
+
+	// the disk type requested by the client or required by the collection wins
+	if option.DiskType != types.HardDriveType || len(class.DiskTypes) == 0 || hasCollectionDiskType(option.Collection) {
+		return nil
+	}
+	for _, diskType := range class.DiskTypes {
+		option.DiskType = types.ToDiskType(diskType)
+		if t.hasWritableVolumes(option) || t.AvailableSpaceFor(option) > 0 {
+			return nil
+		}
+	}
+	// spill to the last disk type, which grows or fails as usual
+	return nil
+}
+
+func (t *Topology) hasWritableVolumes(option *VolumeGrowOption) bool {
+	collection, found := t.FindCollection(option.Collection)
+	if !found {
+		return false
+	}
+	vl, found := collection.GetVolumeLayout(option.ReplicaPlacement, option.Ttl, option.DiskType)
+	if !found {
+		return false
+	}
+	writable, crowded := vl.GetWritableVolumeCount()
+	return writable > crowded
+}
+
+// assignLoad counts the recent assigns to a volume server, decaying by half every assignLoadHalfLife
+type assignLoad struct {
+	sync.Mutex
+	count     float64
+	updatedAt time.Time
+}
+
+func (l *assignLoad) add(count uint64, now time.Time) {
+	l.Lock()
+	defer l.Unlock()
+	l.decay(now)
+	l.count += float64(count)
+}
+
+func (l *assignLoad) get(now time.Time) float64 {
+	l.Lock()
+	defer l.Unlock()
+	l.decay(now)
+	return l.count
+}
+
+func (l *assignLoad) decay(now time.Time) {
+	if elapsed := now.Sub(l.updatedAt); elapsed > 0 {
+		l.count *= math.Pow(0.5, float64(elapsed)/float64(assignLoadHalfLife))
+		l.updatedAt = now
+	}
+}
+
+// RecordAssign counts the assign of the file ids to the volume servers
+func (dn *DataNode) RecordAssign(count uint64) {
+	dn.assignLoad.add(count, time.Now())
+}
+
+// AssignLoad is the recent assigns to the volume server
+func (dn *DataNode) AssignLoad() float64 {
+	return dn.assignLoad.get(time.Now())
+}
+
+// assignLoadOf is the load of the most loaded volume server of the locations
+func (vl *VolumeLocationList) assignLoadOf(now time.Time) (load float64) {
+	for _, dn := range vl.list {
+		load = max(load, dn.assignLoad.get(now))
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package topology
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/sequence"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestApplyLatencyClass(t *testing.T) {
+	topo := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+	rack := topo.GetOrCreateDataCenter("dc1").GetOrCreateRack("rack1")
+	rack.GetOrCreateDataNode("127.0.0.1", 34534, 0, "127.0.0.1", map[string]uint32{"": 25})
+
+	rp, _ := super_block.NewReplicaPlacementFromString("000")
+	newOption := func(diskType types.DiskType) *VolumeGrowOption {
+		return &VolumeGrowOption{ReplicaPlacement: rp, Ttl: needle.EMPTY_TTL, DiskType: diskType}
+	}
+
+	// no ssd yet, spill to hdd
+	option := newOption(types.HardDriveType)
+	if err := topo.ApplyLatencyClass(option, LatencyClassHigh); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if option.DiskType != types.HardDriveType || !option.PreferLightlyLoaded {
+		t.Errorf("high without ssd: %v", option)
+	}
+
+	rack.GetOrCreateDataNode("127.0.0.1", 34535, 0, "127.0.0.1", map[string]uint32{"ssd": 12})
+	option = newOption(types.HardDriveType)
+	topo.ApplyLatencyClass(option, LatencyClassHigh)
+	if option.DiskType != types.SsdType {
+		t.Errorf("high with ssd: %v", option)
+	}
+
+	option = newOption(types.HardDriveType)
+	topo.ApplyLatencyClass(option, LatencyClassBulk)
+	if option.DiskType != types.HardDriveType || option.PreferLightlyLoaded {
+		t.Errorf("bulk: %v", option)
+	}
+
+	// the requested disk type is kept
+	option = newOption(types.DiskType("nvme"))
+	topo.ApplyLatencyClass(option, LatencyClassBulk)
+	if option.DiskType != types.DiskType("nvme") {
+		t.Errorf("bulk with disk type: %v", option)
+	}
+
+	if err := topo.ApplyLatencyClass(newOption(types.HardDriveType), "unknown"); err == nil {
+		t.Errorf("unknown latency class should fail")
+	}
+}
+
+func TestPickForWritePreferLightlyLoaded(t *testing.T) {
+	rp, _ := super_block.NewReplicaPlacementFromString("000")
+	vl := NewVolumeLayout(rp, needle.EMPTY_TTL, types.HardDriveType, 32*1024, false)
+
+	busy, idle := NewDataNode("busy"), NewDataNode("idle")
+	for vid, dn := range map[needle.VolumeId]*DataNode{1: busy, 2: idle} {
+		vl.vid2location[vid] = NewVolumeLocationList()
+		vl.vid2location[vid].Set(dn)
+		vl.writables = append(vl.writables, vid)
+	}
+	busy.RecordAssign(1000)
+
+	idleCount := 0
+	for i := 0; i < 1000; i++ {
+		_, _, locationList, _, err := vl.PickForWrite(1, &VolumeGrowOption{PreferLightlyLoaded: true})
+		if err != nil {
+			t.Fatalf("pick: %v", err)
+		}
+		if locationList.Head() == idle {
+			idleCount++
+		}
+	}
+	// the busy one is only picked if both random choices are the busy one
+	if idleCount < 600 {
+		t.Errorf("picked the idle volume server %d times out of 1000", idleCount)
+	}
+}
+
+func TestAssignLoadDecay(t *testing.T) {
+	now := time.Now()
+	l := &assignLoad{updatedAt: now}
+	l.add(100, now)
+	if load := l.get(now.Add(assignLoadHalfLife)); load < 49.9 || load > 50.1 {
+		t.Errorf("load after a half life: %f", load)
+	}
+}
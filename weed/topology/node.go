@@ -65,14 +65,15 @@ func (n *NodeImpl) PickNodesByWeight(numberOfNodes int, option *VolumeGrowOption
 	n.RLock()
 	candidates := make([]Node, 0, len(n.children))
 	candidatesWeights := make([]int64, 0, len(n.children))
-	//pick nodes which has enough free volumes as candidates, and use free volumes number as node weight.
+	//pick nodes which has enough free volumes as candidates, and use free volumes number multiplied by the placement weight as node weight.
 	for _, node := range n.children {
-		if node.AvailableSpaceFor(option) <= 0 {
+		weight := weightedAvailableSpaceFor(node, option)
+		if weight <= 0 {
 			continue
 		}
-		totalWeights += node.AvailableSpaceFor(option)
+		totalWeights += weight
 		candidates = append(candidates, node)
-		candidatesWeights = append(candidatesWeights, node.AvailableSpaceFor(option))
+		candidatesWeights = append(candidatesWeights, weight)
 	}
 	n.RUnlock()
 	if len(candidates) < numberOfNodes {
@@ -96,23 +97,20 @@ func (n *NodeImpl) PickNodesByWeight(numberOfNodes int, option *VolumeGrowOption
 		}
 	}
 
-	restNodes = make([]Node, 0, numberOfNodes-1)
 	ret := false
 	n.RLock()
 	for k, node := range sortedCandidates {
-		if err := filterFirstNodeFn(node); err == nil {
-			firstNode = node
-			if k >= numberOfNodes-1 {
-				restNodes = sortedCandidates[:numberOfNodes-1]
-			} else {
-				restNodes = append(restNodes, sortedCandidates[:k]...)
-				restNodes = append(restNodes, sortedCandidates[k+1:numberOfNodes]...)
-			}
-			ret = true
-			break
-		} else {
+		if err := filterFirstNodeFn(node); err != nil {
 			errs = append(errs, string(node.Id())+":"+err.Error())
+			continue
 		}
+		if restNodes, err = pickRestNodes(sortedCandidates, k, numberOfNodes-1); err != nil {
+			errs = append(errs, string(node.Id())+":"+err.Error())
+			continue
+		}
+		firstNode = node
+		ret = true
+		break
 	}
 	n.RUnlock()
 	if !ret {
@@ -189,7 +187,7 @@ func (n *NodeImpl) ReserveOneVolume(r int64, option *VolumeGrowOption) (assigned
 	n.RLock()
 	defer n.RUnlock()
 	for _, node := range n.children {
-		freeSpace := node.AvailableSpaceFor(option)
+		freeSpace := weightedAvailableSpaceFor(node, option)
 		// fmt.Println("r =", r, ", node =", node, ", freeSpace =", freeSpace)
 		if freeSpace <= 0 {
 			continue
@@ -205,7 +203,12 @@ func (n *NodeImpl) ReserveOneVolume(r int64, option *VolumeGrowOption) (assigned
 				}
 				return dn, nil
 			}
-			assignedNode, err = node.ReserveOneVolume(r, option)
+			// the weights of the children differ from the weight of the node
+			childrenSpace := weightedChildrenSpaceFor(node, option)
+			if childrenSpace <= 0 {
+				continue
+			}
+			assignedNode, err = node.ReserveOneVolume(rand.Int64N(childrenSpace), option)
 			if err == nil {
 				return
 			}
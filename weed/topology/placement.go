@@ -0,0 +1,129 @@
+package topology
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PlacementPolicy weights the nodes and groups the racks into availability zones when placing the volume replicas,
+// so heterogeneous clusters don't overload the small nodes
+type PlacementPolicy struct {
+	// multiplies the free slots of the data centers, racks or volume servers, default to 1, and 0 to place no new volumes
+	Weights map[NodeId]float64
+	// the availability zone of each rack
+	Zones map[NodeId]string
+	// place the replicas on different racks in different zones, a rack without a zone is a zone of its own
+	SpreadZones bool
+}
+
+// Placement is the placement policy of the master, configurable in master.toml
+var Placement = &PlacementPolicy{}
+
+// ParsePlacementPolicy parses the weights in the form of "node=weight", and the zones in the form of "zone=rack1,rack2"
+func ParsePlacementPolicy(weights, zones []string, spreadZones bool) (*PlacementPolicy, error) {
+	p := &PlacementPolicy{
+		Weights:     make(map[NodeId]float64),
+		Zones:       make(map[NodeId]string),
+		SpreadZones: spreadZones,
+	}
+	for _, w := range weights {
+		node, weight, found := strings.Cut(w, "=")
+		node = strings.TrimSpace(node)
+		if !found || node == "" {
+			return nil, fmt.Errorf("placement weight %q should be node=weight", w)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("placement weight %q should be a non-negative number", w)
+		}
+		p.Weights[NodeId(node)] = value
+	}
+	for _, z := range zones {
+		zone, racks, found := strings.Cut(z, "=")
+		zone = strings.TrimSpace(zone)
+		if !found || zone == "" {
+			return nil, fmt.Errorf("placement zone %q should be zone=rack1,rack2", z)
+		}
+		for _, rack := range strings.Split(racks, ",") {
+			if rack = strings.TrimSpace(rack); rack == "" {
+				continue
+			}
+			if existing, found := p.Zones[NodeId(rack)]; found && existing != zone {
+				return nil, fmt.Errorf("rack %s is in both zone %s and zone %s", rack, existing, zone)
+			}
+			p.Zones[NodeId(rack)] = zone
+		}
+	}
+	return p, nil
+}
+
+func (p *PlacementPolicy) weightOf(node Node) float64 {
+	if weight, found := p.Weights[node.Id()]; found {
+		return weight
+	}
+	return 1
+}
+
+// zoneOf is empty if not spreading across zones or not a rack
+func (p *PlacementPolicy) zoneOf(node Node) string {
+	if !p.SpreadZones || !node.IsRack() {
+		return ""
+	}
+	if zone, found := p.Zones[node.Id()]; found {
+		return zone
+	}
+	return "rack:" + string(node.Id())
+}
+
+// weightedAvailableSpaceFor is the free slots multiplied by the weight of the node,
+// a node with free slots and a positive weight has at least 1
+func weightedAvailableSpaceFor(node Node, option *VolumeGrowOption) int64 {
+	freeSpace := node.AvailableSpaceFor(option)
+	weight := Placement.weightOf(node)
+	if freeSpace <= 0 || weight == 1 {
+		return freeSpace
+	}
+	weighted := int64(float64(freeSpace) * weight)
+	if weighted == 0 && weight > 0 {
+		return 1
+	}
+	return weighted
+}
+
+func weightedChildrenSpaceFor(node Node, option *VolumeGrowOption) (total int64) {
+	for _, child := range node.Children() {
+		if space := weightedAvailableSpaceFor(child, option); space > 0 {
+			total += space
+		}
+	}
+	return
+}
+
+// pickRestNodes picks the earliest candidates other than the first node, in different zones if spreading across zones
+func pickRestNodes(candidates []Node, first int, count int) ([]Node, error) {
+	restNodes := make([]Node, 0, count)
+	zones := make(map[string]bool)
+	if zone := Placement.zoneOf(candidates[first]); zone != "" {
+		zones[zone] = true
+	}
+	for k, node := range candidates {
+		if len(restNodes) == count {
+			break
+		}
+		if k == first {
+			continue
+		}
+		if zone := Placement.zoneOf(node); zone != "" {
+			if zones[zone] {
+				continue
+			}
+			zones[zone] = true
+		}
+		restNodes = append(restNodes, node)
+	}
+	if len(restNodes) < count {
+		return nil, fmt.Errorf("Only has %d nodes in other zones, not enough for %d.", len(restNodes), count)
+	}
+	return restNodes, nil
+}
@@ -0,0 +1,89 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+)
+
+var placementLayout = `
+{
+  "dc1":{
+    "rack1":{
+      "server11":{"volumes":[], "limit":100},
+      "server12":{"volumes":[], "limit":100}
+    },
+    "rack2":{
+      "server21":{"volumes":[], "limit":100}
+    },
+    "rack3":{
+      "server31":{"volumes":[], "limit":10}
+    }
+  }
+}
+`
+
+func TestParsePlacementPolicy(t *testing.T) {
+	p, err := ParsePlacementPolicy([]string{"rack1=2", " 192.168.1.5:8080 = 0.5 "}, []string{"zone_a=rack1, rack2", "zone_b=rack3"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Weights["rack1"] != 2 || p.Weights["192.168.1.5:8080"] != 0.5 || p.Zones["rack2"] != "zone_a" || p.Zones["rack3"] != "zone_b" {
+		t.Errorf("unexpected %+v", p)
+	}
+	for _, tt := range []struct {
+		weights, zones []string
+	}{
+		{[]string{"rack1"}, nil},
+		{[]string{"rack1=-1"}, nil},
+		{[]string{"=1"}, nil},
+		{nil, []string{"rack1"}},
+		{nil, []string{"zone_a=rack1", "zone_b=rack1"}},
+	} {
+		if _, err := ParsePlacementPolicy(tt.weights, tt.zones, false); err == nil {
+			t.Errorf("expected error for %v %v", tt.weights, tt.zones)
+		}
+	}
+}
+
+func TestFindEmptySlotsWithPlacementPolicy(t *testing.T) {
+	defer func(placement *PlacementPolicy) { Placement = placement }(Placement)
+	topo := setup(placementLayout)
+	vg := NewDefaultVolumeGrowth()
+
+	// the replicas on different racks are in different zones
+	Placement, _ = ParsePlacementPolicy(nil, []string{"zone_a=rack1,rack2", "zone_b=rack3"}, true)
+	rp, _ := super_block.NewReplicaPlacementFromString("010")
+	for i := 0; i < 100; i++ {
+		servers, err := vg.findEmptySlotsForOneVolume(topo, &VolumeGrowOption{ReplicaPlacement: rp})
+		if err != nil {
+			t.Fatal(err)
+		}
+		zones := map[string]bool{}
+		for _, server := range servers {
+			zones[Placement.zoneOf(server.Parent())] = true
+		}
+		if len(servers) != 2 || len(zones) != 2 {
+			t.Fatalf("servers %v are not in different zones", servers)
+		}
+	}
+	rp, _ = super_block.NewReplicaPlacementFromString("020")
+	if _, err := vg.findEmptySlotsForOneVolume(topo, &VolumeGrowOption{ReplicaPlacement: rp}); err == nil {
+		t.Errorf("3 racks in 2 zones should fail")
+	}
+
+	// the servers with weight 0 get no new volumes, and the weighted rack gets more
+	Placement, _ = ParsePlacementPolicy([]string{"server11=0", "rack3=20"}, nil, false)
+	rp, _ = super_block.NewReplicaPlacementFromString("000")
+	distribution := map[NodeId]int{}
+	for i := 0; i < 1000; i++ {
+		servers, err := vg.findEmptySlotsForOneVolume(topo, &VolumeGrowOption{ReplicaPlacement: rp})
+		if err != nil {
+			t.Fatal(err)
+		}
+		distribution[servers[0].Id()]++
+	}
+	if distribution["server11"] != 0 || distribution["server31"] < distribution["server21"] {
+		t.Errorf("unexpected distribution %v", distribution)
+	}
+}
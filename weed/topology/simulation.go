@@ -0,0 +1,139 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/sequence"
+	"github.com/seaweedfs/seaweedfs/weed/storage"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+// The simulated topology is built from a layout description, without any volume server, to check how the master
+// places the volumes on it, e.g., with "weed sim". The layout is the data centers, racks and servers, as
+//
+//	{
+//	  "dc1": {
+//	    "rack1": {
+//	      "server1":   {"limit": 100, "used": 20},
+//	      "rack1-hdd": {"limit": 100, "count": 8},
+//	      "rack1-ssd": {"limit": 20, "disk": "ssd", "count": 2}
+//	    }
+//	  }
+//	}
+//
+// where "limit" is the max volume count, "used" is the count of the volume slots already taken, and "count" makes
+// that many servers named as "<name>-1", "<name>-2", and so on. The server names should be unique.
+
+// SimulatedServer is one server, or a group of the same servers, in the layout
+type SimulatedServer struct {
+	Limit    uint32 `json:"limit"`
+	Used     uint32 `json:"used,omitempty"`
+	DiskType string `json:"disk,omitempty"`
+	Count    int    `json:"count,omitempty"`
+}
+
+// SimulatedLayout is the servers by the data center and the rack
+type SimulatedLayout map[string]map[string]map[string]SimulatedServer
+
+// SimulatedVolume is where the replicas of one volume are placed
+type SimulatedVolume struct {
+	Id      needle.VolumeId
+	Servers []*DataNode
+}
+
+// NewSimulatedTopology builds the topology of the layout
+func NewSimulatedTopology(layoutJson []byte, volumeSizeLimit uint64) (*Topology, error) {
+	var layout SimulatedLayout
+	if err := json.Unmarshal(layoutJson, &layout); err != nil {
+		return nil, fmt.Errorf("parse layout: %v", err)
+	}
+
+	topo := NewTopology("simulated", sequence.NewMemorySequencer(), volumeSizeLimit, 5, false)
+	serverNames := make(map[string]bool)
+	for _, dcName := range sortedKeys(layout) {
+		dc := NewDataCenter(dcName)
+		topo.LinkChildNode(dc)
+		for _, rackName := range sortedKeys(layout[dcName]) {
+			rack := NewRack(rackName)
+			dc.LinkChildNode(rack)
+			servers := layout[dcName][rackName]
+			for _, serverName := range sortedKeys(servers) {
+				server := servers[serverName]
+				if server.Limit == 0 {
+					return nil, fmt.Errorf("%s/%s/%s: limit should be positive", dcName, rackName, serverName)
+				}
+				if server.Used > server.Limit {
+					return nil, fmt.Errorf("%s/%s/%s: used %d > limit %d", dcName, rackName, serverName, server.Used, server.Limit)
+				}
+				names := []string{serverName}
+				if server.Count > 0 {
+					names = names[:0]
+					for i := 1; i <= server.Count; i++ {
+						names = append(names, fmt.Sprintf("%s-%d", serverName, i))
+					}
+				}
+				for _, name := range names {
+					if serverNames[name] {
+						return nil, fmt.Errorf("%s/%s: duplicated server %s", dcName, rackName, name)
+					}
+					serverNames[name] = true
+					dn := NewDataNode(name)
+					dn.Ip, dn.Port = name, 8080
+					dn.LastSeen = time.Now().Unix()
+					rack.LinkChildNode(dn)
+					diskType := types.ToDiskType(server.DiskType)
+					dn.AdjustMaxVolumeCounts(map[string]uint32{string(diskType): server.Limit})
+					if server.Used > 0 {
+						disk := dn.getOrCreateDisk(diskType.String())
+						disk.UpAdjustDiskUsageDelta(diskType, &DiskUsageCounts{
+							volumeCount: int64(server.Used),
+						})
+					}
+				}
+			}
+		}
+	}
+	return topo, nil
+}
+
+func sortedKeys[T any](m map[string]T) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
+
+// SimulateGrowth places count volumes from the volume id on, one by one as the master grows the volumes, and keeps
+// them in the topology, without allocating them on the volume servers. It stops at the first volume that can not
+// be placed, with the error.
+func (vg *VolumeGrowth) SimulateGrowth(topo *Topology, option *VolumeGrowOption, vid needle.VolumeId, count int) (volumes []SimulatedVolume, err error) {
+	vg.accessLock.Lock()
+	defer vg.accessLock.Unlock()
+
+	for i := 0; i < count; i++ {
+		servers, findErr := vg.findEmptySlotsForOneVolume(topo, option)
+		if findErr != nil {
+			return volumes, fmt.Errorf("place volume %d: %v", vid, findErr)
+		}
+		vi := storage.VolumeInfo{
+			Id:               vid,
+			Collection:       option.Collection,
+			ReplicaPlacement: option.ReplicaPlacement,
+			Ttl:              option.Ttl,
+			Version:          needle.CurrentVersion,
+			DiskType:         option.DiskType.String(),
+		}
+		for _, server := range servers {
+			server.AddOrUpdateVolume(vi)
+			topo.RegisterVolumeLayout(vi, server)
+		}
+		volumes = append(volumes, SimulatedVolume{Id: vid, Servers: servers})
+		vid = vid.Next()
+	}
+	return volumes, nil
+}
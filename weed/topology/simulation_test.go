@@ -0,0 +1,62 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+var simulatedLayout = `
+{
+  "dc1": {
+    "rack1": {"a": {"limit": 10, "count": 2}},
+    "rack2": {"b": {"limit": 10, "count": 2}, "full": {"limit": 5, "used": 5}}
+  },
+  "dc2": {
+    "rack1": {"ssd": {"limit": 4, "disk": "ssd"}}
+  }
+}
+`
+
+func TestSimulateGrowth(t *testing.T) {
+	topo, err := NewSimulatedTopology([]byte(simulatedLayout), 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if free := topo.AvailableSpaceFor(&VolumeGrowOption{DiskType: types.HardDriveType}); free != 40 {
+		t.Errorf("free hdd slots %d, expected 40", free)
+	}
+
+	rp, _ := super_block.NewReplicaPlacementFromString("010")
+	option := &VolumeGrowOption{ReplicaPlacement: rp, Ttl: needle.EMPTY_TTL, DiskType: types.HardDriveType}
+	volumes, err := NewDefaultVolumeGrowth().SimulateGrowth(topo, option, 1, 25)
+	if err == nil {
+		t.Errorf("expected to run out of the slots")
+	}
+	if len(volumes) != 20 {
+		t.Fatalf("grew %d volumes, expected 20", len(volumes))
+	}
+	for _, v := range volumes {
+		if len(v.Servers) != 2 || v.Servers[0].GetRack() == v.Servers[1].GetRack() {
+			t.Errorf("volume %d is not on two racks: %v", v.Id, v.Servers)
+		}
+		if dc := v.Servers[0].GetDataCenterId(); dc != "dc1" {
+			t.Errorf("volume %d in %s", v.Id, dc)
+		}
+		if v.Servers[0].Id() == "full" || v.Servers[1].Id() == "full" {
+			t.Errorf("volume %d on the full server", v.Id)
+		}
+	}
+	if volumes[19].Id != 20 {
+		t.Errorf("last volume id %d", volumes[19].Id)
+	}
+
+	if _, err = NewSimulatedTopology([]byte(`{"dc1": {"rack1": {"s": {"limit": 1, "used": 2}}}}`), 1024*1024); err == nil {
+		t.Errorf("expected used > limit rejected")
+	}
+	if _, err = NewSimulatedTopology([]byte(`{"dc1": {"rack1": {"s": {"limit": 1}}, "rack2": {"s": {"limit": 1}}}}`), 1024*1024); err == nil {
+		t.Errorf("expected duplicated server rejected")
+	}
+}
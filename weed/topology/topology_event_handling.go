@@ -19,6 +19,7 @@ func (t *Topology) StartRefreshWritableVolumes(grpcDialOption grpc.DialOption, g
 			if t.IsLeader() {
 				freshThreshHold := time.Now().Unix() - 3*t.pulse //3 times of sleep interval
 				t.CollectDeadNodeAndFullVolumes(freshThreshHold, t.volumeSizeLimit, growThreshold)
+				t.UpdateVolumeSlotMetrics()
 			}
 			time.Sleep(time.Duration(float32(t.pulse*1e3)*(1+rand.Float32())) * time.Millisecond)
 		}
@@ -46,6 +47,40 @@ func (t *Topology) StartRefreshWritableVolumes(grpcDialOption grpc.DialOption, g
 		}
 	}()
 }
+
+// UpdateVolumeSlotMetrics exports the max, used and free volume slots of each rack by disk type
+func (t *Topology) UpdateVolumeSlotMetrics() {
+	type rackSlots struct {
+		dc, rack, disk      string
+		maxSlots, freeSlots int64
+	}
+	var slots []rackSlots
+	for _, dc := range t.Children() {
+		for _, rack := range dc.Children() {
+			diskUsages := rack.GetDiskUsages()
+			diskUsages.RLock()
+			for diskType, diskUsage := range diskUsages.usages {
+				slots = append(slots, rackSlots{
+					dc:        string(dc.Id()),
+					rack:      string(rack.Id()),
+					disk:      diskType.ReadableString(),
+					maxSlots:  diskUsage.maxVolumeCount,
+					freeSlots: diskUsage.FreeSpace(),
+				})
+			}
+			diskUsages.RUnlock()
+		}
+	}
+
+	// the removed racks are dropped
+	stats.MasterVolumeSlotsGauge.Reset()
+	for _, s := range slots {
+		stats.MasterVolumeSlotsGauge.WithLabelValues(s.dc, s.rack, s.disk, "max").Set(float64(s.maxSlots))
+		stats.MasterVolumeSlotsGauge.WithLabelValues(s.dc, s.rack, s.disk, "used").Set(float64(s.maxSlots - s.freeSlots))
+		stats.MasterVolumeSlotsGauge.WithLabelValues(s.dc, s.rack, s.disk, "free").Set(float64(s.freeSlots))
+	}
+}
+
 func (t *Topology) SetVolumeCapacityFull(volumeInfo storage.VolumeInfo) bool {
 	diskType := types.ToDiskType(volumeInfo.DiskType)
 	vl := t.GetVolumeLayout(volumeInfo.Collection, volumeInfo.ReplicaPlacement, volumeInfo.Ttl, diskType)
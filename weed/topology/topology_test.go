@@ -3,12 +3,15 @@ package topology
 import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/sequence"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/storage"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestRemoveDataCenter(t *testing.T) {
@@ -23,6 +26,31 @@ func TestRemoveDataCenter(t *testing.T) {
 	}
 }
 
+func TestUpdateVolumeSlotMetrics(t *testing.T) {
+	topo := setup(topologyLayout)
+	topo.UpdateVolumeSlotMetrics()
+	for _, expected := range []struct {
+		dc, rack        string
+		max, used, free float64
+	}{
+		{"dc1", "rack1", 13, 6, 7},
+		{"dc1", "rack2", 13, 6, 7},
+		{"dc3", "rack2", 4, 3, 1},
+	} {
+		for slotType, value := range map[string]float64{"max": expected.max, "used": expected.used, "free": expected.free} {
+			if actual := testutil.ToFloat64(stats.MasterVolumeSlotsGauge.WithLabelValues(expected.dc, expected.rack, "hdd", slotType)); actual != value {
+				t.Errorf("%s %s %s volume slots %v, expected %v", expected.dc, expected.rack, slotType, actual, value)
+			}
+		}
+	}
+
+	topo.UnlinkChildNode(NodeId("dc3"))
+	topo.UpdateVolumeSlotMetrics()
+	if count := testutil.CollectAndCount(stats.MasterVolumeSlotsGauge); count != 6 {
+		t.Errorf("%d volume slot series after removing dc3, expected 6", count)
+	}
+}
+
 func TestHandlingVolumeServerHeartbeat(t *testing.T) {
 	topo := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
 
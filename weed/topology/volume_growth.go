@@ -66,6 +66,8 @@ type VolumeGrowOption struct {
 	Rack               string                        `json:"rack,omitempty"`
 	DataNode           string                        `json:"dataNode,omitempty"`
 	MemoryMapMaxSizeMb uint32                        `json:"memoryMapMaxSizeMb,omitempty"`
+	// only to pick the writable volumes, for the latency sensitive assigns
+	PreferLightlyLoaded bool `json:"preferLightlyLoaded,omitempty"`
 }
 
 type VolumeGrowth struct {
@@ -168,6 +170,7 @@ func (vg *VolumeGrowth) findEmptySlotsForOneVolume(topo *Topology, option *Volum
 			return fmt.Errorf("Free:%d < Expected:%d", node.AvailableSpaceFor(option), rp.DiffRackCount+rp.SameRackCount+1)
 		}
 		possibleRacksCount := 0
+		possibleZones := make(map[string]bool)
 		for _, rack := range node.Children() {
 			possibleDataNodesCount := 0
 			for _, n := range rack.Children() {
@@ -177,11 +180,15 @@ func (vg *VolumeGrowth) findEmptySlotsForOneVolume(topo *Topology, option *Volum
 			}
 			if possibleDataNodesCount >= rp.SameRackCount+1 {
 				possibleRacksCount++
+				possibleZones[Placement.zoneOf(rack)] = true
 			}
 		}
 		if possibleRacksCount < rp.DiffRackCount+1 {
 			return fmt.Errorf("Only has %d racks with more than %d free data nodes, not enough for %d.", possibleRacksCount, rp.SameRackCount+1, rp.DiffRackCount+1)
 		}
+		if Placement.SpreadZones && len(possibleZones) < rp.DiffRackCount+1 {
+			return fmt.Errorf("Only has %d zones with more than %d free data nodes, not enough for %d.", len(possibleZones), rp.SameRackCount+1, rp.DiffRackCount+1)
+		}
 		return nil
 	})
 	if dc_err != nil {
@@ -234,7 +241,7 @@ func (vg *VolumeGrowth) findEmptySlotsForOneVolume(topo *Topology, option *Volum
 		servers = append(servers, server.(*DataNode))
 	}
 	for _, rack := range otherRacks {
-		r := rand.Int64N(rack.AvailableSpaceFor(option))
+		r := rand.Int64N(max(weightedChildrenSpaceFor(rack, option), 1))
 		if server, e := rack.ReserveOneVolume(r, option); e == nil {
 			servers = append(servers, server)
 		} else {
@@ -242,7 +249,7 @@ func (vg *VolumeGrowth) findEmptySlotsForOneVolume(topo *Topology, option *Volum
 		}
 	}
 	for _, datacenter := range otherDataCenters {
-		r := rand.Int64N(datacenter.AvailableSpaceFor(option))
+		r := rand.Int64N(max(weightedChildrenSpaceFor(datacenter, option), 1))
 		if server, e := datacenter.ReserveOneVolume(r, option); e == nil {
 			servers = append(servers, server)
 		} else {
@@ -0,0 +1,128 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	VolumeGrowthSpreadDataCenter = "dataCenter"
+	VolumeGrowthSpreadRack       = "rack"
+)
+
+// VolumeGrowthPolicy keeps enough writable volumes for a collection,
+// configured on the master instead of running volume.grow manually
+type VolumeGrowthPolicy struct {
+	Collection  string
+	MinWritable int      // grow when there are fewer writable volumes
+	GrowCount   uint32   // the number of volumes to grow each time
+	Replication string   // empty for the master's default replication
+	DiskType    string   // empty for hdd
+	Spread      string   // empty, VolumeGrowthSpreadDataCenter, or VolumeGrowthSpreadRack
+	DataCenters []string // only for spread, limit the data centers, empty for all
+}
+
+// VolumeGrowPolicies are the growth policies of each collection
+var VolumeGrowPolicies = make(map[string]*VolumeGrowthPolicy)
+
+// VolumeGrowthTarget is where to grow, the data center and the rack are empty if not spread
+type VolumeGrowthTarget struct {
+	DataCenter string
+	Rack       string
+	Count      uint32
+}
+
+func (p *VolumeGrowthPolicy) Validate() error {
+	if p.MinWritable <= 0 {
+		return fmt.Errorf("collection %s: min_writable should be positive", p.Collection)
+	}
+	if p.GrowCount == 0 {
+		return fmt.Errorf("collection %s: grow_count should be positive", p.Collection)
+	}
+	switch p.Spread {
+	case "", VolumeGrowthSpreadDataCenter, VolumeGrowthSpreadRack:
+	default:
+		return fmt.Errorf("collection %s: unknown spread %q", p.Collection, p.Spread)
+	}
+	return nil
+}
+
+// FindGrowTargets checks the writable volumes of the layout against the policy.
+// When spread, each data center or rack should have the minimum writable volumes by itself,
+// and a volume counts for every data center or rack having one of its replicas.
+func (p *VolumeGrowthPolicy) FindGrowTargets(vl *VolumeLayout, dcs map[NodeId][]NodeId) (targets []VolumeGrowthTarget) {
+	if p.Spread == "" {
+		if writable, crowded := vl.GetWritableVolumeCount(); writable-crowded < p.MinWritable {
+			targets = append(targets, VolumeGrowthTarget{Count: p.GrowCount})
+		}
+		return
+	}
+
+	dcWritables, rackWritables := vl.countWritableVolumesByDcAndRack()
+	for _, dcId := range p.sortedDataCenters(dcs) {
+		if p.Spread == VolumeGrowthSpreadDataCenter {
+			if dcWritables[dcId] < p.MinWritable {
+				targets = append(targets, VolumeGrowthTarget{DataCenter: string(dcId), Count: p.GrowCount})
+			}
+			continue
+		}
+		for _, rackId := range dcs[dcId] {
+			if rackWritables[dcId][rackId] < p.MinWritable {
+				targets = append(targets, VolumeGrowthTarget{DataCenter: string(dcId), Rack: string(rackId), Count: p.GrowCount})
+			}
+		}
+	}
+	return
+}
+
+func (p *VolumeGrowthPolicy) sortedDataCenters(dcs map[NodeId][]NodeId) (dcIds []NodeId) {
+	for dcId, racks := range dcs {
+		if len(racks) == 0 {
+			continue
+		}
+		if len(p.DataCenters) > 0 && !p.hasDataCenter(string(dcId)) {
+			continue
+		}
+		dcIds = append(dcIds, dcId)
+	}
+	sort.Slice(dcIds, func(i, j int) bool {
+		return dcIds[i] < dcIds[j]
+	})
+	return
+}
+
+func (p *VolumeGrowthPolicy) hasDataCenter(dc string) bool {
+	for _, d := range p.DataCenters {
+		if d == dc {
+			return true
+		}
+	}
+	return false
+}
+
+func (vl *VolumeLayout) countWritableVolumesByDcAndRack() (dcWritables map[NodeId]int, rackWritables map[NodeId]map[NodeId]int) {
+	dcWritables = make(map[NodeId]int)
+	rackWritables = make(map[NodeId]map[NodeId]int)
+	for _, v := range vl.CloneWritableVolumes() {
+		dcSeen := make(map[NodeId]bool)
+		rackSeen := make(map[NodeId]bool)
+		for _, dn := range vl.Lookup(v) {
+			if info, err := dn.GetVolumesById(v); err != nil || vl.isCrowdedVolume(&info) {
+				continue
+			}
+			dcId, rackId := dn.GetDataCenter().Id(), dn.GetRack().Id()
+			if !dcSeen[dcId] {
+				dcSeen[dcId] = true
+				dcWritables[dcId]++
+			}
+			if !rackSeen[dcId+":"+rackId] {
+				rackSeen[dcId+":"+rackId] = true
+				if rackWritables[dcId] == nil {
+					rackWritables[dcId] = make(map[NodeId]int)
+				}
+				rackWritables[dcId][rackId]++
+			}
+		}
+	}
+	return
+}
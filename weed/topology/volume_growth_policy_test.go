@@ -0,0 +1,95 @@
+package topology
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+var topologyLayoutPolicy = `
+{
+  "dc1":{
+    "rack1":{
+      "server111":{
+        "volumes":[
+          {"id":1, "size":12312, "collection":"logs", "replication":"000"},
+          {"id":2, "size":12312, "collection":"logs", "replication":"000"}
+        ],
+        "limit":10
+      }
+    },
+    "rack2":{
+      "server121":{
+        "volumes":[
+          {"id":3, "size":12312, "collection":"logs", "replication":"000"}
+        ],
+        "limit":10
+      }
+    }
+  },
+  "dc2":{
+    "rack1":{
+      "server211":{
+        "volumes":[],
+        "limit":10
+      }
+    }
+  }
+}
+`
+
+func TestVolumeGrowthPolicyFindGrowTargets(t *testing.T) {
+	topo := setup(topologyLayoutPolicy)
+	rp, _ := super_block.NewReplicaPlacementFromString("000")
+	vl := topo.GetVolumeLayout("logs", rp, needle.EMPTY_TTL, types.HardDriveType)
+	dcs := topo.ListDCAndRacks()
+
+	tests := []struct {
+		policy   VolumeGrowthPolicy
+		expected []VolumeGrowthTarget
+	}{
+		{
+			policy:   VolumeGrowthPolicy{MinWritable: 3, GrowCount: 2},
+			expected: nil,
+		},
+		{
+			policy:   VolumeGrowthPolicy{MinWritable: 4, GrowCount: 2},
+			expected: []VolumeGrowthTarget{{Count: 2}},
+		},
+		{
+			policy: VolumeGrowthPolicy{MinWritable: 2, GrowCount: 1, Spread: VolumeGrowthSpreadDataCenter},
+			expected: []VolumeGrowthTarget{
+				{DataCenter: "dc2", Count: 1},
+			},
+		},
+		{
+			policy: VolumeGrowthPolicy{MinWritable: 2, GrowCount: 1, Spread: VolumeGrowthSpreadRack},
+			expected: []VolumeGrowthTarget{
+				{DataCenter: "dc1", Rack: "rack2", Count: 1},
+				{DataCenter: "dc2", Rack: "rack1", Count: 1},
+			},
+		},
+		{
+			policy: VolumeGrowthPolicy{MinWritable: 2, GrowCount: 1, Spread: VolumeGrowthSpreadRack, DataCenters: []string{"dc1"}},
+			expected: []VolumeGrowthTarget{
+				{DataCenter: "dc1", Rack: "rack2", Count: 1},
+			},
+		},
+	}
+	for i, tt := range tests {
+		if err := tt.policy.Validate(); err != nil {
+			t.Errorf("case %d: %v", i, err)
+		}
+		targets := tt.policy.FindGrowTargets(vl, dcs)
+		if !reflect.DeepEqual(targets, tt.expected) {
+			t.Errorf("case %d: expected %+v, got %+v", i, tt.expected, targets)
+		}
+	}
+
+	if err := (&VolumeGrowthPolicy{MinWritable: 1, GrowCount: 1, Spread: "node"}).Validate(); err == nil {
+		t.Errorf("unknown spread should be rejected")
+	}
+}
@@ -306,6 +306,14 @@ func (vl *VolumeLayout) PickForWrite(count uint64, option *VolumeGrowOption) (vi
 	}
 	if option.DataCenter == "" && option.Rack == "" && option.DataNode == "" {
 		vid := vl.writables[rand.IntN(lenWriters)]
+		if option.PreferLightlyLoaded && lenWriters > 1 {
+			// the less loaded of two random choices, which balances well without sorting all volumes
+			now := time.Now()
+			if other := vl.writables[rand.IntN(lenWriters)]; vl.vid2location[other] != nil && vl.vid2location[vid] != nil &&
+				vl.vid2location[other].assignLoadOf(now) < vl.vid2location[vid].assignLoadOf(now) {
+				vid = other
+			}
+		}
 		locationList = vl.vid2location[vid]
 		if locationList == nil || len(locationList.list) == 0 {
 			return 0, 0, nil, false, fmt.Errorf("Strangely vid %s is on no machine!", vid.String())
@@ -333,10 +341,21 @@ func (vl *VolumeLayout) PickForWrite(count uint64, option *VolumeGrowOption) (vi
 			if option.DataNode != "" && dn.Id() != NodeId(option.DataNode) {
 				continue
 			}
+			if option.PreferLightlyLoaded && locationList == nil {
+				// take the less loaded of the first two matches
+				vid, locationList, counter = writableVolumeId, volumeLocationList.Copy(), count
+				break
+			}
+			if locationList != nil && locationList.assignLoadOf(time.Now()) <= volumeLocationList.assignLoadOf(time.Now()) {
+				return
+			}
 			vid, locationList, counter = writableVolumeId, volumeLocationList.Copy(), count
 			return
 		}
 	}
+	if locationList != nil {
+		return
+	}
 	return vid, count, locationList, true, fmt.Errorf("%s in DataCenter:%v Rack:%v DataNode:%v", NoWritableVolumes, option.DataCenter, option.Rack, option.DataNode)
 }
 
@@ -0,0 +1,147 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+// VolumeTieringPolicy moves the cold volumes of a collection to the remote tier,
+// and optionally back when they get hot, configured on the master instead of running volume.tier.move manually
+type VolumeTieringPolicy struct {
+	Collection       string
+	Backend          string        // the remote tier, e.g. s3.default
+	ColdAfter        time.Duration // move to the remote tier when not read for this long
+	FullPercent      float64       // only move the volumes at least this full
+	HotReadsPerHour  float64       // move back to the local disk when read more often, 0 to keep on the remote tier
+	KeepLocalDatFile bool
+}
+
+// VolumeTieringPolicies are the tiering policies of each collection
+var VolumeTieringPolicies = make(map[string]*VolumeTieringPolicy)
+
+func (p *VolumeTieringPolicy) Validate() error {
+	if p.Backend == "" {
+		return fmt.Errorf("collection %s: backend should be set", p.Collection)
+	}
+	if p.ColdAfter <= 0 {
+		return fmt.Errorf("collection %s: cold_after should be positive", p.Collection)
+	}
+	if p.FullPercent <= 0 || p.FullPercent > 100 {
+		return fmt.Errorf("collection %s: full_percent should be in (0, 100]", p.Collection)
+	}
+	if p.HotReadsPerHour < 0 {
+		return fmt.Errorf("collection %s: hot_reads_per_hour should not be negative", p.Collection)
+	}
+	return nil
+}
+
+// VolumeHeat is the reads of a volume summed over its replicas
+type VolumeHeat struct {
+	Id               needle.VolumeId
+	Collection       string
+	Size             uint64
+	IsRemote         bool
+	ReadCount        uint64
+	LastReadAtSecond int64 // the latest of the replicas, 0 if reported by older volume servers
+	Locations        []*DataNode
+}
+
+// IsCold is true for a full enough local volume not read for ColdAfter
+func (p *VolumeTieringPolicy) IsCold(heat *VolumeHeat, volumeSizeLimit uint64, now time.Time) bool {
+	if heat.IsRemote || heat.LastReadAtSecond == 0 {
+		return false
+	}
+	if float64(heat.Size) < float64(volumeSizeLimit)*p.FullPercent/100 {
+		return false
+	}
+	return now.Sub(time.Unix(heat.LastReadAtSecond, 0)) >= p.ColdAfter
+}
+
+// IsHot is true for a remote volume read more often than HotReadsPerHour
+func (p *VolumeTieringPolicy) IsHot(heat *VolumeHeat, readsPerHour float64) bool {
+	return heat.IsRemote && p.HotReadsPerHour > 0 && readsPerHour > p.HotReadsPerHour
+}
+
+// CollectVolumeHeats collects the reads of the volumes from all volume servers, sorted by volume id
+func (t *Topology) CollectVolumeHeats() (heats []*VolumeHeat) {
+	heatById := make(map[needle.VolumeId]*VolumeHeat)
+	t.RLock()
+	for _, dc := range t.children {
+		for _, rack := range dc.Children() {
+			for _, n := range rack.Children() {
+				dn := n.(*DataNode)
+				for _, v := range dn.GetVolumes() {
+					heat, found := heatById[v.Id]
+					if !found {
+						heat = &VolumeHeat{Id: v.Id, Collection: v.Collection}
+						heatById[v.Id] = heat
+						heats = append(heats, heat)
+					}
+					if v.Size > heat.Size {
+						heat.Size = v.Size
+					}
+					heat.IsRemote = heat.IsRemote || v.IsRemote()
+					heat.ReadCount += v.ReadCount
+					if v.LastReadAtSecond > heat.LastReadAtSecond {
+						heat.LastReadAtSecond = v.LastReadAtSecond
+					}
+					heat.Locations = append(heat.Locations, dn)
+				}
+			}
+		}
+	}
+	t.RUnlock()
+	sort.Slice(heats, func(i, j int) bool {
+		return heats[i].Id < heats[j].Id
+	})
+	return
+}
+
+// VolumeHeatTracker turns the read counts of consecutive checks into read rates
+type VolumeHeatTracker struct {
+	sync.Mutex
+	samples map[needle.VolumeId]volumeHeatSample
+}
+
+type volumeHeatSample struct {
+	readCount uint64
+	at        time.Time
+}
+
+func NewVolumeHeatTracker() *VolumeHeatTracker {
+	return &VolumeHeatTracker{
+		samples: make(map[needle.VolumeId]volumeHeatSample),
+	}
+}
+
+// ReadsPerHour records the read count of the volume, and returns the read rate since the last check.
+// It is not ok for the first check, or when the count is reset by restarting or moving the replicas.
+func (tracker *VolumeHeatTracker) ReadsPerHour(heat *VolumeHeat, now time.Time) (readsPerHour float64, ok bool) {
+	tracker.Lock()
+	defer tracker.Unlock()
+	last, found := tracker.samples[heat.Id]
+	tracker.samples[heat.Id] = volumeHeatSample{readCount: heat.ReadCount, at: now}
+	if !found || heat.ReadCount < last.readCount || !now.After(last.at) {
+		return 0, false
+	}
+	return float64(heat.ReadCount-last.readCount) / now.Sub(last.at).Hours(), true
+}
+
+// Prune forgets the volumes no longer existing
+func (tracker *VolumeHeatTracker) Prune(heats []*VolumeHeat) {
+	existing := make(map[needle.VolumeId]bool, len(heats))
+	for _, heat := range heats {
+		existing[heat.Id] = true
+	}
+	tracker.Lock()
+	defer tracker.Unlock()
+	for vid := range tracker.samples {
+		if !existing[vid] {
+			delete(tracker.samples, vid)
+		}
+	}
+}
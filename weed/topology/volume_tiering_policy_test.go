@@ -0,0 +1,79 @@
+package topology
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+var tieringLayout = `
+{
+  "dc1":{
+    "rack1":{
+      "server11":{"volumes":[{"id":1, "size":32000, "collection":"logs"}, {"id":2, "size":1000, "collection":"logs"}], "limit":10},
+      "server12":{"volumes":[{"id":1, "size":32000, "collection":"logs"}], "limit":10}
+    }
+  }
+}
+`
+
+func TestVolumeTieringPolicy(t *testing.T) {
+	topo := setup(tieringLayout)
+	now := time.Now()
+	for _, dn := range topo.GetOrCreateDataCenter("dc1").GetOrCreateRack("rack1").Children() {
+		for _, v := range dn.(*DataNode).GetVolumes() {
+			v.ReadCount = 10
+			v.LastReadAtSecond = now.Add(-48 * time.Hour).Unix()
+			if dn.Id() == "server12" {
+				v.LastReadAtSecond = now.Add(-2 * time.Hour).Unix()
+			}
+			dn.(*DataNode).AddOrUpdateVolume(v)
+		}
+	}
+
+	heats := topo.CollectVolumeHeats()
+	if len(heats) != 2 || heats[0].Id != 1 || heats[0].ReadCount != 20 || len(heats[0].Locations) != 2 {
+		t.Fatalf("unexpected heats %+v", heats)
+	}
+	if heats[0].LastReadAtSecond != now.Add(-2*time.Hour).Unix() {
+		t.Errorf("the last read should be the latest of the replicas")
+	}
+
+	policy := &VolumeTieringPolicy{Collection: "logs", Backend: "s3.default", ColdAfter: time.Hour, FullPercent: 95, HotReadsPerHour: 100}
+	if err := policy.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !policy.IsCold(heats[0], 32*1024, now) {
+		t.Errorf("volume 1 is full and not read for 2 hours")
+	}
+	if policy.IsCold(heats[1], 32*1024, now) {
+		t.Errorf("volume 2 is not full")
+	}
+	policy.ColdAfter = 3 * time.Hour
+	if policy.IsCold(heats[0], 32*1024, now) {
+		t.Errorf("volume 1 is read within 3 hours")
+	}
+
+	// the read rate between the checks
+	tracker := NewVolumeHeatTracker()
+	remote := &VolumeHeat{Id: 1, IsRemote: true, ReadCount: 1000}
+	if _, ok := tracker.ReadsPerHour(remote, now); ok {
+		t.Errorf("the first check has no rate")
+	}
+	remote.ReadCount = 1100
+	if rate, ok := tracker.ReadsPerHour(remote, now.Add(30*time.Minute)); !ok || rate != 200 || !policy.IsHot(remote, rate) {
+		t.Errorf("unexpected rate %v", rate)
+	}
+	remote.ReadCount = 5
+	if _, ok := tracker.ReadsPerHour(remote, now.Add(time.Hour)); ok {
+		t.Errorf("the reset count has no rate")
+	}
+	tracker.Prune(nil)
+	if len(tracker.samples) != 0 {
+		t.Errorf("the deleted volumes should be pruned")
+	}
+	if policy.IsHot(&VolumeHeat{Id: needle.VolumeId(2), ReadCount: 1000}, 1000) {
+		t.Errorf("a local volume is never hot")
+	}
+}
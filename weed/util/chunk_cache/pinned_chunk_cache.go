@@ -0,0 +1,156 @@
+package chunk_cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const pinnedChunkFileExt = ".chunk"
+
+var _ ChunkCache = &PinnedChunkCache{}
+
+// PinnedChunkCache keeps the pinned chunks in their own files, which are never evicted and survive restarts,
+// in front of the chunk cache for the other chunks.
+type PinnedChunkCache struct {
+	ChunkCache
+	dir string
+	sync.RWMutex
+	pinned map[string]int64 // file id => chunk size
+}
+
+// NewPinnedChunkCache loads the chunks pinned by the previous run from the directory
+func NewPinnedChunkCache(chunkCache ChunkCache, dir string) (*PinnedChunkCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create pinned chunk dir %s: %v", dir, err)
+	}
+	c := &PinnedChunkCache{
+		ChunkCache: chunkCache,
+		dir:        dir,
+		pinned:     make(map[string]int64),
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("list pinned chunk dir %s: %v", dir, err)
+	}
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasSuffix(name, pinnedChunkFileExt) {
+			// the partially written chunks
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		c.pinned[strings.Replace(strings.TrimSuffix(name, pinnedChunkFileExt), "_", ",", 1)] = info.Size()
+	}
+	glog.V(0).Infof("%d pinned chunks in %s", len(c.pinned), dir)
+	return c, nil
+}
+
+func (c *PinnedChunkCache) chunkFileName(fileId string) string {
+	return filepath.Join(c.dir, strings.Replace(fileId, ",", "_", 1)+pinnedChunkFileExt)
+}
+
+// IsPinned checks whether the chunk is kept in the pinned chunk files
+func (c *PinnedChunkCache) IsPinned(fileId string) bool {
+	c.RLock()
+	defer c.RUnlock()
+	_, found := c.pinned[fileId]
+	return found
+}
+
+// Pin saves the whole chunk, which is then read from the local file until unpinned
+func (c *PinnedChunkCache) Pin(fileId string, data []byte) error {
+	if strings.ContainsAny(fileId, "/\\") {
+		return fmt.Errorf("invalid file id %q", fileId)
+	}
+	fileName := c.chunkFileName(fileId)
+	tmpFileName := fileName + ".tmp"
+	if err := os.WriteFile(tmpFileName, data, 0644); err != nil {
+		os.Remove(tmpFileName)
+		return fmt.Errorf("write pinned chunk %s: %v", fileId, err)
+	}
+	if err := os.Rename(tmpFileName, fileName); err != nil {
+		os.Remove(tmpFileName)
+		return fmt.Errorf("save pinned chunk %s: %v", fileId, err)
+	}
+	c.Lock()
+	c.pinned[fileId] = int64(len(data))
+	c.Unlock()
+	return nil
+}
+
+// Unpin removes the pinned chunk file
+func (c *PinnedChunkCache) Unpin(fileId string) {
+	c.Lock()
+	defer c.Unlock()
+	if _, found := c.pinned[fileId]; !found {
+		return
+	}
+	delete(c.pinned, fileId)
+	if err := os.Remove(c.chunkFileName(fileId)); err != nil && !os.IsNotExist(err) {
+		glog.Warningf("remove pinned chunk %s: %v", fileId, err)
+	}
+}
+
+// PinnedFileIds lists the pinned chunks
+func (c *PinnedChunkCache) PinnedFileIds() (fileIds []string) {
+	c.RLock()
+	defer c.RUnlock()
+	for fileId := range c.pinned {
+		fileIds = append(fileIds, fileId)
+	}
+	return
+}
+
+// PinnedBytes is the total size of the pinned chunks
+func (c *PinnedChunkCache) PinnedBytes() (size int64) {
+	c.RLock()
+	defer c.RUnlock()
+	for _, chunkSize := range c.pinned {
+		size += chunkSize
+	}
+	return
+}
+
+func (c *PinnedChunkCache) ReadChunkAt(data []byte, fileId string, offset uint64) (n int, err error) {
+	c.RLock()
+	chunkSize, found := c.pinned[fileId]
+	c.RUnlock()
+	if !found {
+		return c.ChunkCache.ReadChunkAt(data, fileId, offset)
+	}
+	if offset+uint64(len(data)) > uint64(chunkSize) {
+		return 0, ErrorOutOfBounds
+	}
+	f, err := os.Open(c.chunkFileName(fileId))
+	if err != nil {
+		// unpinned in between
+		return c.ChunkCache.ReadChunkAt(data, fileId, offset)
+	}
+	defer f.Close()
+	n, err = f.ReadAt(data, int64(offset))
+	if err == io.EOF && n == len(data) {
+		err = nil
+	}
+	return
+}
+
+func (c *PinnedChunkCache) SetChunk(fileId string, data []byte) {
+	if c.IsPinned(fileId) {
+		return
+	}
+	c.ChunkCache.SetChunk(fileId, data)
+}
+
+func (c *PinnedChunkCache) IsInCache(fileId string, lockNeeded bool) (answer bool) {
+	return c.IsPinned(fileId) || c.ChunkCache.IsInCache(fileId, lockNeeded)
+}
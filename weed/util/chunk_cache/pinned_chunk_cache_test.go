@@ -0,0 +1,47 @@
+package chunk_cache
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPinnedChunkCache(t *testing.T) {
+	dir := t.TempDir()
+	var tiered *TieredChunkCache // the read cache may be disabled
+	cache, err := NewPinnedChunkCache(tiered, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("0123456789")
+	if err := cache.Pin("3,01637037d6", data); err != nil {
+		t.Fatal(err)
+	}
+	if !cache.IsInCache("3,01637037d6", true) {
+		t.Fatalf("the pinned chunk should be in cache")
+	}
+	buf := make([]byte, 4)
+	if n, err := cache.ReadChunkAt(buf, "3,01637037d6", 3); err != nil || n != 4 || !bytes.Equal(buf, data[3:7]) {
+		t.Fatalf("read pinned chunk: %d %v %q", n, err, buf)
+	}
+	if _, err := cache.ReadChunkAt(buf, "3,01637037d6", 8); err != ErrorOutOfBounds {
+		t.Errorf("expect out of bounds, got %v", err)
+	}
+
+	// the pinned chunks are loaded after restart
+	cache, err = NewPinnedChunkCache(tiered, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileIds := cache.PinnedFileIds(); len(fileIds) != 1 || fileIds[0] != "3,01637037d6" || cache.PinnedBytes() != 10 {
+		t.Fatalf("unexpected pinned chunks %v", fileIds)
+	}
+
+	cache.Unpin("3,01637037d6")
+	if cache.IsInCache("3,01637037d6", true) {
+		t.Errorf("the unpinned chunk should not be in cache")
+	}
+	if n, _ := cache.ReadChunkAt(buf, "3,01637037d6", 0); n != 0 {
+		t.Errorf("the unpinned chunk should not be read")
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
-	// "github.com/klauspost/compress/zstd"
 )
 
 var (
@@ -60,11 +60,9 @@ func DecompressData(input []byte) ([]byte, error) {
 	if IsGzippedContent(input) {
 		return ungzipData(input)
 	}
-	/*
-		if IsZstdContent(input) {
-			return unzstdData(input)
-		}
-	*/
+	if IsZstdContent(input) {
+		return unzstdData(input)
+	}
 	return input, UnsupportedCompression
 }
 
@@ -75,7 +73,6 @@ func IsGzippedContent(data []byte) bool {
 	return data[0] == 31 && data[1] == 139
 }
 
-/*
 var zstdEncoder, _ = zstd.NewWriter(nil)
 
 func ZstdData(input []byte) ([]byte, error) {
@@ -94,7 +91,71 @@ func IsZstdContent(data []byte) bool {
 	}
 	return data[3] == 0xFD && data[2] == 0x2F && data[1] == 0xB5 && data[0] == 0x28
 }
-*/
+
+// CompressData compresses the input with the named algorithm, gzip by default
+func CompressData(input []byte, algorithm string) ([]byte, error) {
+	switch algorithm {
+	case "", "gzip":
+		return GzipData(input)
+	case "zstd":
+		return ZstdData(input)
+	}
+	return nil, fmt.Errorf("compression %s: %w", algorithm, UnsupportedCompression)
+}
+
+const StreamCompressionZstd = "zstd"
+
+// MaybeCompressStreamContent compresses a chunk of the volume copy or tail streams,
+// if the client accepts zstd and the compressed chunk is smaller
+func MaybeCompressStreamContent(content []byte, acceptCompression string) ([]byte, string) {
+	if acceptCompression != StreamCompressionZstd || len(content) == 0 {
+		return content, ""
+	}
+	compressed, _ := ZstdData(content)
+	if len(compressed) >= len(content) {
+		return content, ""
+	}
+	return compressed, StreamCompressionZstd
+}
+
+// DecompressStreamContent restores a chunk of the volume copy or tail streams
+func DecompressStreamContent(content []byte, compression string) ([]byte, error) {
+	switch compression {
+	case "":
+		return content, nil
+	case StreamCompressionZstd:
+		return unzstdData(content)
+	}
+	return nil, fmt.Errorf("stream compression %s: %w", compression, UnsupportedCompression)
+}
+
+// CompressionEncoding returns the http Content-Encoding of the compressed data
+func CompressionEncoding(data []byte) string {
+	if IsZstdContent(data) {
+		return "zstd"
+	}
+	return "gzip"
+}
+
+// MatchMimeType checks the mime type against patterns like "text/*" or "application/json"
+func MatchMimeType(mtype string, patterns []string) bool {
+	if mtype == "" {
+		return false
+	}
+	if i := strings.Index(mtype, ";"); i >= 0 {
+		mtype = strings.TrimSpace(mtype[:i])
+	}
+	for _, pattern := range patterns {
+		if prefix, found := strings.CutSuffix(pattern, "/*"); found {
+			if strings.HasPrefix(mtype, prefix+"/") {
+				return true
+			}
+		} else if pattern == "*" || strings.EqualFold(pattern, mtype) {
+			return true
+		}
+	}
+	return false
+}
 
 /*
 * Default not to compressed since compression can be done on client side.
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressDataRoundTrip(t *testing.T) {
+	input := bytes.Repeat([]byte("seaweedfs compression "), 100)
+	for _, algorithm := range []string{"gzip", "zstd"} {
+		compressed, err := CompressData(input, algorithm)
+		if err != nil {
+			t.Fatalf("compress %s: %v", algorithm, err)
+		}
+		if CompressionEncoding(compressed) != algorithm {
+			t.Errorf("expected encoding %s, got %s", algorithm, CompressionEncoding(compressed))
+		}
+		decompressed, err := DecompressData(compressed)
+		if err != nil {
+			t.Fatalf("decompress %s: %v", algorithm, err)
+		}
+		if !bytes.Equal(input, decompressed) {
+			t.Errorf("%s round trip mismatch", algorithm)
+		}
+	}
+	if _, err := CompressData(input, "lz4"); err == nil {
+		t.Errorf("expected unsupported compression error")
+	}
+}
+
+func TestStreamContentCompression(t *testing.T) {
+	input := bytes.Repeat([]byte("volume copy stream "), 1000)
+	compressed, compression := MaybeCompressStreamContent(input, StreamCompressionZstd)
+	if compression != StreamCompressionZstd || len(compressed) >= len(input) {
+		t.Fatalf("expected smaller zstd content, got %q with %d bytes", compression, len(compressed))
+	}
+	decompressed, err := DecompressStreamContent(compressed, compression)
+	if err != nil || !bytes.Equal(input, decompressed) {
+		t.Fatalf("stream content round trip mismatch: %v", err)
+	}
+
+	if content, compression := MaybeCompressStreamContent(input, ""); compression != "" || !bytes.Equal(content, input) {
+		t.Errorf("the content should not be compressed without the client accepting zstd")
+	}
+	incompressible, _ := ZstdData(input)
+	if content, compression := MaybeCompressStreamContent(incompressible, StreamCompressionZstd); compression != "" || !bytes.Equal(content, incompressible) {
+		t.Errorf("the incompressible content should be sent as is")
+	}
+	if _, err := DecompressStreamContent(input, "lz4"); err == nil {
+		t.Errorf("expected unsupported compression error")
+	}
+}
+
+func TestMatchMimeType(t *testing.T) {
+	patterns := []string{"text/*", "application/json"}
+	tests := []struct {
+		mtype string
+		want  bool
+	}{
+		{"text/plain", true},
+		{"text/html; charset=utf-8", true},
+		{"application/json", true},
+		{"application/javascript", false},
+		{"video/mp4", false},
+		{"textual/x", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := MatchMimeType(tt.mtype, patterns); got != tt.want {
+			t.Errorf("MatchMimeType(%q) = %v, want %v", tt.mtype, got, tt.want)
+		}
+	}
+}
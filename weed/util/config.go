@@ -107,6 +107,12 @@ func (vp *ViperProxy) GetStringSlice(key string) []string {
 	return vp.Viper.GetStringSlice(key)
 }
 
+func (vp *ViperProxy) GetFloat64(key string) float64 {
+	vp.Lock()
+	defer vp.Unlock()
+	return vp.Viper.GetFloat64(key)
+}
+
 func GetViper() *ViperProxy {
 	vp.Lock()
 	defer vp.Unlock()
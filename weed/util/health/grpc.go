@@ -0,0 +1,43 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	grpc_health "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// GrpcStatusInterval is how often the readiness checks refresh the gRPC serving status
+var GrpcStatusInterval = 5 * time.Second
+
+// RegisterGrpc registers the standard grpc.health.v1.Health service, for the Kubernetes gRPC probes and grpcurl.
+// The serving status of the server, i.e., the empty service name, and of each service already registered
+// follows the readiness checks: SERVING if all passed, otherwise NOT_SERVING.
+func (c *Checks) RegisterGrpc(s *grpc.Server) *grpc_health.Server {
+	var services []string
+	for name := range s.GetServiceInfo() {
+		services = append(services, name)
+	}
+	healthServer := grpc_health.NewServer()
+	grpc_health_v1.RegisterHealthServer(s, healthServer)
+	c.updateGrpcStatus(healthServer, services)
+	go func() {
+		for range time.Tick(GrpcStatusInterval) {
+			c.updateGrpcStatus(healthServer, services)
+		}
+	}()
+	return healthServer
+}
+
+func (c *Checks) updateGrpcStatus(healthServer *grpc_health.Server, services []string) {
+	servingStatus := grpc_health_v1.HealthCheckResponse_SERVING
+	if _, isReady := c.Run(context.Background(), nil); !isReady {
+		servingStatus = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	healthServer.SetServingStatus("", servingStatus)
+	for _, service := range services {
+		healthServer.SetServingStatus(service, servingStatus)
+	}
+}
@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A server answers two probes for the orchestration, like Kubernetes:
+//
+//	/healthz: the liveness, the process is up and serving http. Failing it restarts the server.
+//	/readyz:  the readiness, the server is initialized and its dependencies are reachable. Failing it only stops
+//	          routing the traffic to the server, so it must not fail just because the whole cluster is down.
+//
+// The readiness checks can be skipped per probe with ?exclude=<name>, and listed with ?verbose.
+
+// CheckTimeout limits each readiness check
+var CheckTimeout = 5 * time.Second
+
+// Checks are the named readiness checks of a server
+type Checks struct {
+	sync.RWMutex
+	names  []string
+	checks map[string]func(ctx context.Context) error
+}
+
+func NewChecks() *Checks {
+	return &Checks{
+		checks: make(map[string]func(ctx context.Context) error),
+	}
+}
+
+// Add adds or replaces the readiness check of the name
+func (c *Checks) Add(name string, check func(ctx context.Context) error) {
+	c.Lock()
+	defer c.Unlock()
+	if _, found := c.checks[name]; !found {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// CheckResult is the result of one readiness check, with a nil Err if passed
+type CheckResult struct {
+	Name     string
+	Err      error
+	Excluded bool
+}
+
+// Run runs the readiness checks except the excluded ones, and returns whether all passed
+func (c *Checks) Run(ctx context.Context, exclude map[string]bool) (results []CheckResult, isReady bool) {
+	c.RLock()
+	names := append([]string(nil), c.names...)
+	checks := make([]func(ctx context.Context) error, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.RUnlock()
+
+	isReady = true
+	for i, name := range names {
+		if exclude[name] {
+			results = append(results, CheckResult{Name: name, Excluded: true})
+			continue
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, CheckTimeout)
+		err := checks[i](checkCtx)
+		cancel()
+		if err != nil {
+			isReady = false
+		}
+		results = append(results, CheckResult{Name: name, Err: err})
+	}
+	return
+}
+
+// HealthzHandler tells the process is up
+func (c *Checks) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		fmt.Fprint(w, "ok")
+	}
+}
+
+// ReadyzHandler runs the readiness checks, with the status 503 if any failed
+func (c *Checks) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	exclude := make(map[string]bool)
+	for _, names := range r.URL.Query()["exclude"] {
+		for _, name := range strings.Split(names, ",") {
+			exclude[strings.TrimSpace(name)] = true
+		}
+	}
+	_, isVerbose := r.URL.Query()["verbose"]
+
+	results, isReady := c.Run(r.Context(), exclude)
+
+	var sb strings.Builder
+	for _, result := range results {
+		switch {
+		case result.Excluded:
+			if isVerbose {
+				fmt.Fprintf(&sb, "[+]%s excluded: ok\n", result.Name)
+			}
+		case result.Err != nil:
+			fmt.Fprintf(&sb, "[-]%s failed: %v\n", result.Name, result.Err)
+		case isVerbose:
+			fmt.Fprintf(&sb, "[+]%s ok\n", result.Name)
+		}
+	}
+	status := http.StatusOK
+	if isReady {
+		if isVerbose {
+			sb.WriteString("readyz check passed\n")
+		} else {
+			sb.WriteString("ok")
+		}
+	} else {
+		status = http.StatusServiceUnavailable
+		sb.WriteString("readyz check failed\n")
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		fmt.Fprint(w, sb.String())
+	}
+}
+
+// Register adds the /healthz and the /readyz handlers to the mux
+func (c *Checks) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", c.HealthzHandler)
+	mux.HandleFunc("/readyz", c.ReadyzHandler)
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func TestReadyzHandler(t *testing.T) {
+	checks := NewChecks()
+	checks.Add("store", func(ctx context.Context) error {
+		return nil
+	})
+	checks.Add("master", func(ctx context.Context) error {
+		return errors.New("not connected")
+	})
+
+	get := func(url string) (int, string) {
+		w := httptest.NewRecorder()
+		checks.ReadyzHandler(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w.Code, w.Body.String()
+	}
+
+	if code, body := get("/readyz"); code != http.StatusServiceUnavailable || !strings.Contains(body, "[-]master failed: not connected") {
+		t.Errorf("expected master failed, got %d %q", code, body)
+	}
+	if code, body := get("/readyz?exclude=master"); code != http.StatusOK || body != "ok" {
+		t.Errorf("expected ready without master, got %d %q", code, body)
+	}
+	if code, body := get("/readyz?exclude=master&verbose"); code != http.StatusOK ||
+		!strings.Contains(body, "[+]store ok") || !strings.Contains(body, "[+]master excluded: ok") {
+		t.Errorf("expected verbose results, got %d %q", code, body)
+	}
+
+	// the check of the same name is replaced
+	checks.Add("master", func(ctx context.Context) error {
+		return nil
+	})
+	if code, _ := get("/readyz"); code != http.StatusOK {
+		t.Errorf("expected ready, got %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	checks.HealthzHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("healthz should always pass, got %d", w.Code)
+	}
+}
+
+func TestRegisterGrpc(t *testing.T) {
+	var storeErr error
+	checks := NewChecks()
+	checks.Add("store", func(ctx context.Context) error {
+		return storeErr
+	})
+
+	s := grpc.NewServer()
+	reflection.Register(s)
+	healthServer := checks.RegisterGrpc(s)
+	check := func(service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, err := healthServer.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("check %q: %v", service, err)
+		}
+		return resp.Status
+	}
+
+	if status := check(""); status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("expected serving, got %v", status)
+	}
+	if status := check("grpc.reflection.v1alpha.ServerReflection"); status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("expected the registered service serving, got %v", status)
+	}
+
+	storeErr = errors.New("not reachable")
+	checks.updateGrpcStatus(healthServer, []string{"filer_pb.SeaweedFiler"})
+	if status := check(""); status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected not serving, got %v", status)
+	}
+	if status := check("filer_pb.SeaweedFiler"); status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected the service not serving, got %v", status)
+	}
+}
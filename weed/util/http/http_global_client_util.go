@@ -322,8 +322,23 @@ func ReadUrlAsStreamAuthenticated(fileUrl, jwt string, cipherKey []byte, isConte
 
 	var reader io.ReadCloser
 	contentEncoding := r.Header.Get("Content-Encoding")
-	switch contentEncoding {
-	case "gzip":
+	switch {
+	case contentEncoding == "gzip" && util.ReadTransformWorkers != nil:
+		// decompress the whole chunk with the read transform workers, instead of streaming in this goroutine
+		compressedData, readErr := io.ReadAll(r.Body)
+		if readErr != nil {
+			return true, readErr
+		}
+		var data []byte
+		util.ReadTransformWorkers.Do("decompress", func() {
+			data, err = util.DecompressData(compressedData)
+		})
+		if err != nil {
+			return false, fmt.Errorf("decompress %s: %v", fileUrl, err)
+		}
+		fn(data)
+		return false, nil
+	case contentEncoding == "gzip":
 		reader, err = gzip.NewReader(r.Body)
 		defer reader.Close()
 	default:
@@ -356,16 +371,21 @@ func readEncryptedUrl(fileUrl, jwt string, cipherKey []byte, isContentCompressed
 	if err != nil {
 		return retryable, fmt.Errorf("fetch %s: %v", fileUrl, err)
 	}
-	decryptedData, err := util.Decrypt(encryptedData, util.CipherKey(cipherKey))
+	var decryptedData []byte
+	util.ReadTransformWorkers.Do("decrypt", func() {
+		decryptedData, err = util.Decrypt(encryptedData, util.CipherKey(cipherKey))
+		if err != nil || !isContentCompressed {
+			return
+		}
+		if uncompressedData, unzipErr := util.DecompressData(decryptedData); unzipErr != nil {
+			glog.V(0).Infof("unzip decrypt %s: %v", fileUrl, unzipErr)
+		} else {
+			decryptedData = uncompressedData
+		}
+	})
 	if err != nil {
 		return false, fmt.Errorf("decrypt %s: %v", fileUrl, err)
 	}
-	if isContentCompressed {
-		decryptedData, err = util.DecompressData(decryptedData)
-		if err != nil {
-			glog.V(0).Infof("unzip decrypt %s: %v", fileUrl, err)
-		}
-	}
 	if len(decryptedData) < int(offset)+size {
 		return false, fmt.Errorf("read decrypted %s size %d [%d, %d)", fileUrl, len(decryptedData), offset, int(offset)+size)
 	}
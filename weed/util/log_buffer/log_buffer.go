@@ -40,6 +40,7 @@ type LogBuffer struct {
 	lastFlushDataTime time.Time
 	sizeBuf           []byte
 	flushInterval     time.Duration
+	bufferSize        int
 	flushFn           LogFlushFuncType
 	ReadFromDiskFn    LogReadFromDiskFuncType
 	notifyFn          func()
@@ -47,6 +48,8 @@ type LogBuffer struct {
 	isAllFlushed      bool
 	flushChan         chan *dataToFlush
 	LastTsNs          int64
+	isLastTsNsUnknown bool               // the next message is not linked to the previous one, until LastTsNs is restored
+	logEntry          *filer_pb.LogEntry // reused under the lock, to avoid allocations per message
 	sync.RWMutex
 }
 
@@ -55,25 +58,67 @@ func NewLogBuffer(name string, flushInterval time.Duration, flushFn LogFlushFunc
 	lb := &LogBuffer{
 		name:           name,
 		prevBuffers:    newSealedBuffers(PreviousBufferCount),
-		buf:            make([]byte, BufferSize),
+		buf:            allocateBuffer(BufferSize),
 		sizeBuf:        make([]byte, 4),
 		flushInterval:  flushInterval,
+		bufferSize:     BufferSize,
 		flushFn:        flushFn,
 		ReadFromDiskFn: readFromDiskFn,
 		notifyFn:       notifyFn,
 		flushChan:      make(chan *dataToFlush, 256),
 		isStopping:     new(atomic.Bool),
+		logEntry:       &filer_pb.LogEntry{},
 	}
 	go lb.loopFlush()
 	go lb.loopInterval()
 	return lb
 }
 
+// Configure changes how often the buffered data is flushed, and the size of the buffer to flush when full.
+// A smaller buffer is used after the current one is flushed, if not empty.
+func (logBuffer *LogBuffer) Configure(flushInterval time.Duration, bufferSize int) {
+	logBuffer.Lock()
+	defer logBuffer.Unlock()
+	logBuffer.flushInterval, logBuffer.bufferSize = flushInterval, bufferSize
+	if logBuffer.pos == 0 && len(logBuffer.buf) != bufferSize {
+		freeBuffer(logBuffer.buf)
+		logBuffer.buf = allocateBuffer(bufferSize)
+	}
+}
+
+// SetLastTsNsUnknown marks the previous message of the next one as unknown, i.e., -1, until RestoreLastTsNs
+func (logBuffer *LogBuffer) SetLastTsNsUnknown() {
+	logBuffer.Lock()
+	defer logBuffer.Unlock()
+	logBuffer.isLastTsNsUnknown = true
+}
+
+// RestoreLastTsNs continues the timestamps, and the links to the previous messages, after the persisted messages,
+// unless any message is added already, which is linked to the unknown previous message
+func (logBuffer *LogBuffer) RestoreLastTsNs(lastTsNs int64) {
+	logBuffer.Lock()
+	defer logBuffer.Unlock()
+	if logBuffer.isLastTsNsUnknown {
+		logBuffer.LastTsNs = max(logBuffer.LastTsNs, lastTsNs)
+		logBuffer.isLastTsNsUnknown = false
+	}
+}
+
+func (logBuffer *LogBuffer) getFlushInterval() time.Duration {
+	logBuffer.RLock()
+	defer logBuffer.RUnlock()
+	return logBuffer.flushInterval
+}
+
 func (logBuffer *LogBuffer) AddToBuffer(message *mq_pb.DataMessage) {
-	logBuffer.AddDataToBuffer(message.Key, message.Value, message.TsNs)
+	logBuffer.addToBuffer(message.Key, message.Value, message.TsNs, message.Origin, message.Headers)
 }
 
 func (logBuffer *LogBuffer) AddDataToBuffer(partitionKey, data []byte, processingTsNs int64) {
+	logBuffer.addToBuffer(partitionKey, data, processingTsNs, "", nil)
+}
+
+func (logBuffer *LogBuffer) addToBuffer(partitionKey, data []byte, processingTsNs int64, origin string, headers map[string]string) {
 
 	var toFlush *dataToFlush
 	logBuffer.Lock()
@@ -100,17 +145,21 @@ func (logBuffer *LogBuffer) AddDataToBuffer(partitionKey, data []byte, processin
 		processingTsNs = logBuffer.LastTsNs + 1
 		ts = time.Unix(0, processingTsNs)
 	}
-	logBuffer.LastTsNs = processingTsNs
-	logEntry := &filer_pb.LogEntry{
-		TsNs:             processingTsNs,
-		PartitionKeyHash: util.HashToInt32(partitionKey),
-		Data:             data,
-		Key:              partitionKey,
+	prevTsNs := logBuffer.LastTsNs
+	if logBuffer.isLastTsNsUnknown {
+		prevTsNs, logBuffer.isLastTsNsUnknown = -1, false
 	}
+	logBuffer.LastTsNs = processingTsNs
+	logEntry := logBuffer.logEntry
+	logEntry.TsNs = processingTsNs
+	logEntry.PrevTsNs = prevTsNs
+	logEntry.PartitionKeyHash = util.HashToInt32(partitionKey)
+	logEntry.Data = data
+	logEntry.Key = partitionKey
+	logEntry.Origin = origin
+	logEntry.Headers = headers
 
-	logEntryData, _ := proto.Marshal(logEntry)
-
-	size := len(logEntryData)
+	size := proto.Size(logEntry)
 
 	if logBuffer.pos == 0 {
 		logBuffer.startTime = ts
@@ -121,6 +170,7 @@ func (logBuffer *LogBuffer) AddDataToBuffer(partitionKey, data []byte, processin
 		toFlush = logBuffer.copyToFlush()
 		logBuffer.startTime = ts
 		if len(logBuffer.buf) < size+4 {
+			freeBuffer(logBuffer.buf)
 			logBuffer.buf = make([]byte, 2*size+4)
 		}
 	}
@@ -129,9 +179,13 @@ func (logBuffer *LogBuffer) AddDataToBuffer(partitionKey, data []byte, processin
 	logBuffer.idx = append(logBuffer.idx, logBuffer.pos)
 	util.Uint32toBytes(logBuffer.sizeBuf, uint32(size))
 	copy(logBuffer.buf[logBuffer.pos:logBuffer.pos+4], logBuffer.sizeBuf)
-	copy(logBuffer.buf[logBuffer.pos+4:logBuffer.pos+4+size], logEntryData)
+	// marshal in place, the buffer has enough capacity so nothing is allocated
+	proto.MarshalOptions{UseCachedSize: true}.MarshalAppend(logBuffer.buf[logBuffer.pos+4:logBuffer.pos+4], logEntry)
 	logBuffer.pos += size + 4
 
+	// do not hold on to the caller's data
+	logEntry.Data, logEntry.Key, logEntry.Headers = nil, nil, nil
+
 	// fmt.Printf("partitionKey %v entry size %d total %d count %d\n", string(partitionKey), size, m.pos, len(m.idx))
 
 }
@@ -166,12 +220,22 @@ func (logBuffer *LogBuffer) loopFlush() {
 			logBuffer.lastFlushDataTime = d.stopTime
 		}
 	}
+	logBuffer.releaseBuffers()
 	logBuffer.isAllFlushed = true
 }
 
+// releaseBuffers puts the memory buffers back to the pool after shutting down, since all data is flushed
+func (logBuffer *LogBuffer) releaseBuffers() {
+	logBuffer.Lock()
+	defer logBuffer.Unlock()
+	freeBuffer(logBuffer.buf)
+	logBuffer.buf = nil
+	logBuffer.prevBuffers.release()
+}
+
 func (logBuffer *LogBuffer) loopInterval() {
 	for !logBuffer.IsStopping() {
-		time.Sleep(logBuffer.flushInterval)
+		time.Sleep(logBuffer.getFlushInterval())
 		if logBuffer.IsStopping() {
 			return
 		}
@@ -187,6 +251,19 @@ func (logBuffer *LogBuffer) loopInterval() {
 	}
 }
 
+// ForceFlush flushes the buffered data now, without waiting for the flush interval or a full buffer
+func (logBuffer *LogBuffer) ForceFlush() {
+	if logBuffer.IsStopping() {
+		return
+	}
+	logBuffer.Lock()
+	toFlush := logBuffer.copyToFlush()
+	logBuffer.Unlock()
+	if toFlush != nil {
+		logBuffer.flushChan <- toFlush
+	}
+}
+
 func (logBuffer *LogBuffer) copyToFlush() *dataToFlush {
 
 	if logBuffer.pos > 0 {
@@ -204,6 +281,11 @@ func (logBuffer *LogBuffer) copyToFlush() *dataToFlush {
 			logBuffer.lastFlushDataTime = logBuffer.stopTime
 		}
 		logBuffer.buf = logBuffer.prevBuffers.SealBuffer(logBuffer.startTime, logBuffer.stopTime, logBuffer.buf, logBuffer.pos, logBuffer.batchIndex)
+		if len(logBuffer.buf) != logBuffer.bufferSize {
+			// the sealed buffer is not allocated yet, was grown for a large entry, or the size is configured
+			freeBuffer(logBuffer.buf)
+			logBuffer.buf = allocateBuffer(logBuffer.bufferSize)
+		}
 		logBuffer.startTime = time.Unix(0, 0)
 		logBuffer.stopTime = time.Unix(0, 0)
 		logBuffer.pos = 0
@@ -214,6 +296,27 @@ func (logBuffer *LogBuffer) copyToFlush() *dataToFlush {
 	return nil
 }
 
+// BufferStats describes the current in-memory buffer, which is not yet flushed
+type BufferStats struct {
+	BufferedBytes    int64
+	BufferedMessages int64
+	Capacity         int64
+	LastTsNs         int64
+	LastFlushTsNs    int64
+}
+
+func (logBuffer *LogBuffer) GetBufferStats() BufferStats {
+	logBuffer.RLock()
+	defer logBuffer.RUnlock()
+	return BufferStats{
+		BufferedBytes:    int64(logBuffer.pos),
+		BufferedMessages: int64(len(logBuffer.idx)),
+		Capacity:         int64(len(logBuffer.buf)),
+		LastTsNs:         logBuffer.LastTsNs,
+		LastFlushTsNs:    atomic.LoadInt64(&logBuffer.LastFlushTsNs),
+	}
+}
+
 func (logBuffer *LogBuffer) GetEarliestTime() time.Time {
 	return logBuffer.startTime
 }
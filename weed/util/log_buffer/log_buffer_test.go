@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestNewLogBufferFirstBuffer(t *testing.T) {
@@ -63,3 +65,162 @@ func TestNewLogBufferFirstBuffer(t *testing.T) {
 		t.Errorf("expect %d messages, but got %d", messageCount, receivedMessageCount)
 	}
 }
+
+func TestLogBufferConfigure(t *testing.T) {
+	var flushLock sync.Mutex
+	var flushedSizes []int
+	lb := NewLogBuffer("test", time.Minute, func(logBuffer *LogBuffer, startTime time.Time, stopTime time.Time, buf []byte) {
+		flushLock.Lock()
+		flushedSizes = append(flushedSizes, len(buf))
+		flushLock.Unlock()
+	}, nil, func() {
+	})
+	defer lb.ShutdownLogBuffer()
+
+	// flushed when the smaller buffer is full, not after the minute
+	lb.Configure(time.Minute, 64*1024)
+	for i := 0; i < 100; i++ {
+		lb.AddToBuffer(&mq_pb.DataMessage{Key: []byte("key"), Value: make([]byte, 1024)})
+	}
+	time.Sleep(100 * time.Millisecond)
+	flushLock.Lock()
+	defer flushLock.Unlock()
+	if len(flushedSizes) == 0 {
+		t.Fatalf("nothing flushed")
+	}
+	for _, size := range flushedSizes {
+		if size > 64*1024 {
+			t.Errorf("flushed %d bytes from a 64KB buffer", size)
+		}
+	}
+}
+
+func BenchmarkAddToBuffer(b *testing.B) {
+	lb := NewLogBuffer("bench", time.Minute, func(logBuffer *LogBuffer, startTime time.Time, stopTime time.Time, buf []byte) {
+	}, nil, func() {
+	})
+	defer lb.ShutdownLogBuffer()
+
+	message := &mq_pb.DataMessage{
+		Key:   []byte("benchmark-key"),
+		Value: make([]byte, 256),
+	}
+	rand.Read(message.Value)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(message.Key) + len(message.Value)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lb.AddToBuffer(message)
+	}
+}
+
+func TestLogBufferRestoreLastTsNs(t *testing.T) {
+	var prevTsNs []int64
+	readAll := func(lb *LogBuffer) {
+		prevTsNs = nil
+		lb.LoopProcessLogData("test", NewMessagePosition(1, -2), 0, func() bool {
+			return false
+		}, func(logEntry *filer_pb.LogEntry) (isDone bool, err error) {
+			prevTsNs = append(prevTsNs, logEntry.PrevTsNs)
+			return false, nil
+		})
+	}
+	newLogBuffer := func() *LogBuffer {
+		return NewLogBuffer("test", time.Minute, nil, nil, nil)
+	}
+
+	// restored before adding the messages, which are linked to the persisted ones
+	lb := newLogBuffer()
+	lb.SetLastTsNsUnknown()
+	lb.RestoreLastTsNs(100)
+	lb.AddDataToBuffer(nil, []byte("a"), 200)
+	lb.AddDataToBuffer(nil, []byte("b"), 300)
+	readAll(lb)
+	if fmt.Sprint(prevTsNs) != "[100 200]" {
+		t.Errorf("restored before adding: got %v", prevTsNs)
+	}
+
+	// restored after adding the messages, the first one is linked to the unknown one
+	lb = newLogBuffer()
+	lb.SetLastTsNsUnknown()
+	lb.AddDataToBuffer(nil, []byte("a"), 200)
+	lb.RestoreLastTsNs(100)
+	lb.AddDataToBuffer(nil, []byte("b"), 300)
+	readAll(lb)
+	if fmt.Sprint(prevTsNs) != "[-1 200]" {
+		t.Errorf("restored after adding: got %v", prevTsNs)
+	}
+
+	// the new log buffer links the first message to none
+	lb = newLogBuffer()
+	lb.AddDataToBuffer(nil, []byte("a"), 200)
+	readAll(lb)
+	if fmt.Sprint(prevTsNs) != "[0]" {
+		t.Errorf("new log buffer: got %v", prevTsNs)
+	}
+}
+
+func TestLogBufferReleasedAfterShutdown(t *testing.T) {
+	lb := NewLogBuffer("test", time.Minute, func(logBuffer *LogBuffer, startTime time.Time, stopTime time.Time, buf []byte) {
+	}, nil, func() {
+	})
+	for i := 0; i < 3; i++ {
+		lb.AddDataToBuffer(nil, []byte("a"), 0)
+		lb.ForceFlush()
+	}
+	sealed := 0
+	for _, mb := range lb.prevBuffers.buffers {
+		if mb.buf != nil {
+			sealed++
+		}
+	}
+	if sealed != 3 {
+		t.Errorf("sealed %d buffers, expected 3", sealed)
+	}
+
+	lb.ShutdownLogBuffer()
+	// released by the flushing goroutine after the last flush
+	deadline := time.Now().Add(10 * time.Second)
+	lb.RLock()
+	for lb.buf != nil {
+		lb.RUnlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("the current buffer is not released")
+		}
+		time.Sleep(10 * time.Millisecond)
+		lb.RLock()
+	}
+	defer lb.RUnlock()
+	for i, mb := range lb.prevBuffers.buffers {
+		if mb.buf != nil || mb.size != 0 {
+			t.Errorf("the sealed buffer %d is not released", i)
+		}
+	}
+}
+
+func appendTestLogEntry(buf []byte, tsNs int64) []byte {
+	data, _ := proto.Marshal(&filer_pb.LogEntry{TsNs: tsNs, Data: []byte("data")})
+	sizeBuf := make([]byte, 4)
+	util.Uint32toBytes(sizeBuf, uint32(len(data)))
+	buf = append(buf, sizeBuf...)
+	return append(buf, data...)
+}
+
+func TestLocateByTsIgnoresStaleData(t *testing.T) {
+	var buf []byte
+	buf = appendTestLogEntry(buf, 100)
+	second := len(buf)
+	buf = appendTestLogEntry(buf, 200)
+	size := len(buf)
+	// the pooled buffer keeps the entries of its previous use
+	buf = appendTestLogEntry(buf, 1000)
+	mb := &MemBuffer{buf: buf, size: size}
+
+	if pos := mb.locateByTs(time.Unix(0, 150)); pos != second {
+		t.Errorf("located %d, expected %d", pos, second)
+	}
+	if pos := mb.locateByTs(time.Unix(0, 500)); pos != size {
+		t.Errorf("located %d in the stale data, expected %d", pos, size)
+	}
+}
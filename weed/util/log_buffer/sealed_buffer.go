@@ -2,6 +2,7 @@ package log_buffer
 
 import (
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -17,14 +18,35 @@ type SealedBuffers struct {
 	buffers []*MemBuffer
 }
 
+// memBufferPool keeps the buffers of BufferSize, shared by the log buffers of all partitions
+var memBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, BufferSize)
+		return &buf
+	},
+}
+
+// allocateBuffer takes the buffer from the pool if of BufferSize. The pooled buffers are not zeroed.
+func allocateBuffer(size int) []byte {
+	if size != BufferSize {
+		return make([]byte, size)
+	}
+	return *memBufferPool.Get().(*[]byte)
+}
+
+func freeBuffer(buf []byte) {
+	if len(buf) == BufferSize {
+		memBufferPool.Put(&buf)
+	}
+}
+
+// newSealedBuffers does not allocate the buffers, which are taken from the pool once sealed
 func newSealedBuffers(size int) *SealedBuffers {
 	sbs := &SealedBuffers{}
 
 	sbs.buffers = make([]*MemBuffer, size)
 	for i := 0; i < size; i++ {
-		sbs.buffers[i] = &MemBuffer{
-			buf: make([]byte, BufferSize),
-		}
+		sbs.buffers[i] = &MemBuffer{}
 	}
 
 	return sbs
@@ -48,16 +70,25 @@ func (sbs *SealedBuffers) SealBuffer(startTime, stopTime time.Time, buf []byte,
 	return oldMemBuffer.buf
 }
 
+// release puts the buffers back to the pool, and forgets the sealed data
+func (sbs *SealedBuffers) release() {
+	for _, mb := range sbs.buffers {
+		freeBuffer(mb.buf)
+		*mb = MemBuffer{}
+	}
+}
+
 func (mb *MemBuffer) locateByTs(lastReadTime time.Time) (pos int) {
 	lastReadTs := lastReadTime.UnixNano()
-	for pos < len(mb.buf) {
+	// the buffer after the size has the stale data of its previous use
+	for pos < mb.size {
 		size, t := readTs(mb.buf, pos)
 		if t > lastReadTs {
 			return
 		}
 		pos += size + 4
 	}
-	return len(mb.buf)
+	return mb.size
 }
 
 func (mb *MemBuffer) String() string {
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const instrumentationName = "github.com/seaweedfs/seaweedfs"
+
+// the trace context is carried in the message headers with the W3C traceparent and tracestate keys
+var propagator = propagation.TraceContext{}
+
+// noopSpan is returned for untraced messages, so the callers can always end the span
+var noopSpan = trace.SpanFromContext(context.Background())
+
+// Init exports the spans to an OpenTelemetry collector, Jaeger, or Tempo, with OTLP over HTTP,
+// e.g., endpoint "http://localhost:4318". Spans are not recorded if the endpoint is empty.
+// The returned function flushes the remaining spans.
+func Init(serviceName, endpoint string, sampleRatio float64) (shutdown func()) {
+	if endpoint == "" {
+		return func() {}
+	}
+	exporter, err := newOtlpHttpExporter(endpoint)
+	if err != nil {
+		glog.Errorf("export traces to %s: %v", endpoint, err)
+		return func() {}
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	glog.V(0).Infof("export traces of %s to %s, sample ratio %v", serviceName, endpoint, sampleRatio)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			glog.Warningf("shutdown tracer provider: %v", err)
+		}
+	}
+}
+
+// newOtlpHttpExporter exports the spans to the endpoint, with the /v1/traces path added if missing,
+// and http:// if without a scheme
+func newOtlpHttpExporter(endpoint string) (*otlptrace.Exporter, error) {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+	// the exporter connects lazily, so creating it does not wait for the collector
+	return otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(url))
+}
+
+// Tracer uses the tracer provider set by Init, or a no-op one
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// InjectHeaders writes the trace context of ctx into the headers.
+// The headers are allocated if nil and there is a trace context to write.
+func InjectHeaders(ctx context.Context, headers map[string]string) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return headers
+	}
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	propagator.Inject(ctx, propagation.MapCarrier(headers))
+	return headers
+}
+
+// StartSpanFromHeaders starts a span continuing the trace context carried in the headers.
+// Messages without a trace context are not traced, so it is cheap for untraced messages.
+func StartSpanFromHeaders(ctx context.Context, headers map[string]string, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if len(headers) == 0 {
+		return ctx, noopSpan
+	}
+	ctx = propagator.Extract(ctx, propagation.MapCarrier(headers))
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, noopSpan
+	}
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// MessagingAttributes are the common attributes of the message queue spans, the partition is optional
+func MessagingAttributes(topic string, partition string) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		attribute.String("messaging.system", "seaweedfs"),
+		attribute.String("messaging.destination.name", topic),
+	}
+	if partition != "" {
+		attributes = append(attributes, attribute.String("messaging.destination.partition.id", partition))
+	}
+	return attributes
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPropagateThroughHeadersAndExport(t *testing.T) {
+	var lock sync.Mutex
+	var received []*tracepb.Span
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read: %v", err)
+		}
+		traces := &collectortrace.ExportTraceServiceRequest{}
+		if err = proto.Unmarshal(body, traces); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		lock.Lock()
+		for _, rs := range traces.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				received = append(received, ss.Spans...)
+			}
+		}
+		lock.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		response, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	exporter, err := newOtlpHttpExporter(server.URL)
+	if err != nil {
+		t.Fatalf("new exporter: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+	tracer := provider.Tracer(instrumentationName)
+
+	// producer
+	ctx, producerSpan := tracer.Start(context.Background(), "publish", trace.WithSpanKind(trace.SpanKindProducer))
+	headers := InjectHeaders(ctx, nil)
+	producerSpan.End()
+	if headers["traceparent"] == "" {
+		t.Fatalf("trace context is not injected: %v", headers)
+	}
+
+	// consumer
+	ctx = propagator.Extract(context.Background(), propagation.MapCarrier(headers))
+	_, consumerSpan := tracer.Start(ctx, "consume", trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.Int64("messaging.batch.message_count", 3)))
+	consumerSpan.End()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("received %d spans", len(received))
+	}
+	producer, consumer := received[0], received[1]
+	if !bytes.Equal(consumer.TraceId, producer.TraceId) || !bytes.Equal(consumer.ParentSpanId, producer.SpanId) {
+		t.Errorf("consumer %+v is not a child of producer %+v", consumer, producer)
+	}
+	if consumer.Kind != tracepb.Span_SPAN_KIND_CONSUMER {
+		t.Errorf("consumer kind %v", consumer.Kind)
+	}
+	if len(consumer.Attributes) != 1 || consumer.Attributes[0].Value.GetIntValue() != 3 {
+		t.Errorf("consumer attributes %+v", consumer.Attributes)
+	}
+}
+
+func TestUntracedHeaders(t *testing.T) {
+	if headers := InjectHeaders(context.Background(), nil); headers != nil {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	_, span := StartSpanFromHeaders(context.Background(), map[string]string{"other": "value"}, "consume")
+	if span.SpanContext().IsValid() {
+		t.Errorf("untraced message should not start a span")
+	}
+	span.End()
+}
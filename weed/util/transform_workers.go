@@ -0,0 +1,48 @@
+package util
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+// TransformWorkers bounds the CPU heavy transformations of the data read, e.g., decrypting, decompressing,
+// and resizing images, so a burst of such reads can not starve the goroutines handling the network.
+type TransformWorkers struct {
+	slots chan struct{}
+}
+
+// ReadTransformWorkers is set by the filer. When nil, the transformations run right away.
+var ReadTransformWorkers *TransformWorkers
+
+// NewTransformWorkers runs up to count transformations at the same time, or the number of CPUs if count <= 0
+func NewTransformWorkers(count int) *TransformWorkers {
+	if count <= 0 {
+		count = runtime.NumCPU()
+	}
+	return &TransformWorkers{
+		slots: make(chan struct{}, count),
+	}
+}
+
+// Do runs the transformation of the kind once a worker is free, and records the time queued and running
+func (w *TransformWorkers) Do(kind string, fn func()) {
+	if w == nil {
+		fn()
+		return
+	}
+
+	queuedAt := time.Now()
+	stats.FilerReadTransformQueueGauge.WithLabelValues(kind).Inc()
+	w.slots <- struct{}{}
+	stats.FilerReadTransformQueueGauge.WithLabelValues(kind).Dec()
+	startedAt := time.Now()
+	stats.FilerReadTransformHistogram.WithLabelValues(kind, "queued").Observe(startedAt.Sub(queuedAt).Seconds())
+
+	defer func() {
+		<-w.slots
+		stats.FilerReadTransformHistogram.WithLabelValues(kind, "run").Observe(time.Since(startedAt).Seconds())
+	}()
+	fn()
+}
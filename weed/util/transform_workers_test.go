@@ -0,0 +1,44 @@
+package util
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransformWorkers(t *testing.T) {
+	w := NewTransformWorkers(2)
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Do("test", func() {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&maxRunning)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			})
+		}()
+	}
+	wg.Wait()
+	if maxRunning > 2 {
+		t.Errorf("%d transformations ran at the same time, expected at most 2", maxRunning)
+	}
+
+	// without workers, the transformation runs right away
+	var nilWorkers *TransformWorkers
+	ran := false
+	nilWorkers.Do("test", func() { ran = true })
+	if !ran {
+		t.Errorf("transformation did not run without workers")
+	}
+}
@@ -103,6 +103,11 @@ func (mc *MasterClient) setCurrentMaster(master pb.ServerAddress) {
 	mc.currentMasterLock.Unlock()
 }
 
+// IsConnected checks whether the client is connected to the master leader now
+func (mc *MasterClient) IsConnected() bool {
+	return mc.getCurrentMaster() != ""
+}
+
 func (mc *MasterClient) GetMaster(ctx context.Context) pb.ServerAddress {
 	mc.WaitUntilConnected(ctx)
 	return mc.getCurrentMaster()